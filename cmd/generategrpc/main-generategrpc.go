@@ -0,0 +1,36 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/wavetermdev/waveterm/pkg/grpcapi"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+)
+
+const ProtoDir = "proto"
+
+func main() {
+	protoFile, err := grpcapi.GenerateProtoFile()
+	if err != nil {
+		log.Fatalf("error generating proto file: %v", err)
+	}
+	fileName := filepath.Join(ProtoDir, grpcapi.ProtoFileName)
+	fmt.Fprintf(os.Stderr, "generating proto file to %s\n", fileName)
+	err = os.MkdirAll(filepath.Dir(fileName), 0755)
+	if err != nil {
+		log.Fatalf("error creating %s: %v", filepath.Dir(fileName), err)
+	}
+	written, err := utilfn.WriteFileIfDifferent(fileName, []byte(protoFile))
+	if err != nil {
+		log.Fatalf("error writing %s: %v", fileName, err)
+	}
+	if !written {
+		fmt.Fprintf(os.Stderr, "no changes to %s\n", fileName)
+	}
+}
@@ -12,12 +12,14 @@ import (
 	"github.com/invopop/jsonschema"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/web"
 )
 
 const WaveSchemaSettingsFileName = "schema/settings.json"
 const WaveSchemaConnectionsFileName = "schema/connections.json"
 const WaveSchemaAiPresetsFileName = "schema/aipresets.json"
 const WaveSchemaWidgetsFileName = "schema/widgets.json"
+const WaveSchemaApiFileName = "schema/api.json"
 
 func generateSchema(template any, dir string) error {
 	settingsSchema := jsonschema.Reflect(template)
@@ -36,6 +38,26 @@ func generateSchema(template any, dir string) error {
 	return nil
 }
 
+// the automation api's OpenAPI document (see pkg/apischema)
+func generateApiSchema(fileName string) error {
+	doc, err := web.GetApiSchema()
+	if err != nil {
+		return err
+	}
+	barr, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal api schema: %w", err)
+	}
+	written, err := utilfn.WriteFileIfDifferent(fileName, barr)
+	if !written {
+		fmt.Fprintf(os.Stderr, "no changes to %s\n", fileName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write api schema: %w", err)
+	}
+	return nil
+}
+
 func main() {
 	err := generateSchema(&wconfig.SettingsType{}, WaveSchemaSettingsFileName)
 	if err != nil {
@@ -59,4 +81,9 @@ func main() {
 	if err != nil {
 		log.Fatalf("widgets schema error: %v", err)
 	}
+
+	err = generateApiSchema(WaveSchemaApiFileName)
+	if err != nil {
+		log.Fatalf("api schema error: %v", err)
+	}
 }
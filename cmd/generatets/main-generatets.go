@@ -14,6 +14,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/service"
 	"github.com/wavetermdev/waveterm/pkg/tsgen"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/web"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 )
 
@@ -111,6 +112,25 @@ func generateWshClientApiFile(tsTypeMap map[reflect.Type]string) error {
 	return err
 }
 
+func generateApiClientFile() error {
+	fileName := "frontend/app/store/waveapiclient.ts"
+	fmt.Fprintf(os.Stderr, "generating api client file to %s\n", fileName)
+	doc, err := web.GetApiSchema()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Copyright 2025, Command Line Inc.\n")
+	fmt.Fprintf(&buf, "// SPDX-License-Identifier: Apache-2.0\n\n")
+	fmt.Fprintf(&buf, "// generated by cmd/generate/main-generatets.go from the automation api schema (schema/api.json)\n\n")
+	fmt.Fprint(&buf, tsgen.GenerateApiClient(doc))
+	written, err := utilfn.WriteFileIfDifferent(fileName, buf.Bytes())
+	if !written {
+		fmt.Fprintf(os.Stderr, "no changes to %s\n", fileName)
+	}
+	return err
+}
+
 func main() {
 	err := service.ValidateServiceMap()
 	if err != nil {
@@ -133,4 +153,9 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error generating wshserver file: %v\n", err)
 		os.Exit(1)
 	}
+	err = generateApiClientFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating api client file: %v\n", err)
+		os.Exit(1)
+	}
 }
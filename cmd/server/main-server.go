@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
 
 	"runtime"
@@ -16,8 +17,15 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/authkey"
 	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
+	"github.com/wavetermdev/waveterm/pkg/filequota"
+	"github.com/wavetermdev/waveterm/pkg/filereplica"
+	"github.com/wavetermdev/waveterm/pkg/fileretention"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/keychain"
+	"github.com/wavetermdev/waveterm/pkg/palette"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/portforward"
+	"github.com/wavetermdev/waveterm/pkg/ptyhost"
 	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
 	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/wshfs"
 	"github.com/wavetermdev/waveterm/pkg/service"
@@ -32,13 +40,18 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/wconfig"
 	"github.com/wavetermdev/waveterm/pkg/wcore"
 	"github.com/wavetermdev/waveterm/pkg/web"
+	"github.com/wavetermdev/waveterm/pkg/webhook"
+	"github.com/wavetermdev/waveterm/pkg/wnotify"
+	"github.com/wavetermdev/waveterm/pkg/wplugin"
 	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wsettings"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshremote"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshserver"
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
 	"github.com/wavetermdev/waveterm/pkg/wslconn"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
+	"github.com/wavetermdev/waveterm/pkg/wtheme"
 )
 
 // these are set at build time
@@ -51,29 +64,81 @@ const TelemetryInterval = 4 * time.Hour
 const TelemetryInitialCountsWait = 5 * time.Second
 const TelemetryCountsInterval = 1 * time.Hour
 
+const ShutdownHardDeadline = 10 * time.Second
+const ShutdownClientGracePeriod = 300 * time.Millisecond
+const ShutdownServersTimeout = 2 * time.Second
+const ShutdownFlushTimeout = 2 * time.Second
+const ShutdownShellsTimeout = 2 * time.Second
+
 var shutdownOnce sync.Once
+var wshListener net.Listener // the domain socket for wsh, closed on shutdown
 
+// the order matters: the clients are told first (so they can save their state while the servers are up), then
+// the servers stop accepting requests and the rpcs in flight are drained, the lazily written controller state is
+// checkpointed and flushed, and the ptys are closed last (their final output is flushed too).  each phase has a
+// timeout, and if the shutdown still hangs the hard deadline exits anyway.
 func doShutdown(reason string) {
 	shutdownOnce.Do(func() {
 		log.Printf("shutting down: %s\n", reason)
-		ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancelFn()
-		go blockcontroller.StopAllBlockControllers()
+		time.AfterFunc(ShutdownHardDeadline, func() {
+			log.Printf("shutdown did not complete in %v, exiting\n", ShutdownHardDeadline)
+			os.Exit(1)
+		})
+		wps.Broker.Publish(wps.WaveEvent{
+			Event: wps.Event_ServerShutdown,
+			Data:  wps.ServerShutdownEventData{Reason: reason},
+		})
+		time.Sleep(ShutdownClientGracePeriod)
+		runShutdownPhase("servers", ShutdownServersTimeout, func(ctx context.Context) error {
+			web.ShutdownServers(ctx)
+			if wshListener != nil {
+				wshListener.Close()
+			}
+			return wshutil.DrainRpcs(ctx)
+		})
+		// persistent sessions keep running in their ptyhost helpers, detach before the other controllers are stopped
+		blockcontroller.DetachAllPersistentSessions()
+		blockcontroller.CheckpointAllBlockControllers()
+		wplugin.StopAllPlugins()
+		runShutdownPhase("filestore flush", ShutdownFlushTimeout, func(ctx context.Context) error {
+			_, err := filestore.WFS.FlushAndStop(ctx)
+			return err
+		})
+		runShutdownPhase("stop shells", ShutdownShellsTimeout, blockcontroller.StopAllBlockControllersAndWait)
+		runShutdownPhase("final filestore flush", ShutdownFlushTimeout, func(ctx context.Context) error {
+			_, err := filestore.WFS.FlushAndStop(ctx)
+			return err
+		})
+		// after the state is saved (sending the telemetry can be slow)
 		shutdownActivityUpdate()
 		sendTelemetryWrapper()
-		// TODO deal with flush in progress
-		clearTempFiles()
-		filestore.WFS.FlushCache(ctx)
+		if err := filestore.CloseFilestore(); err != nil {
+			log.Printf("error closing filestore: %v\n", err)
+		}
+		if err := wstore.CloseWStore(); err != nil {
+			log.Printf("error closing wstore: %v\n", err)
+		}
 		watcher := wconfig.GetWatcher()
 		if watcher != nil {
 			watcher.Close()
 		}
-		time.Sleep(500 * time.Millisecond)
+		clearTempFiles()
 		log.Printf("shutdown complete\n")
 		os.Exit(0)
 	})
 }
 
+func runShutdownPhase(name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), timeout)
+	defer cancelFn()
+	startTime := time.Now()
+	err := fn(ctx)
+	if err != nil {
+		log.Printf("shutdown (%s): %v\n", name, err)
+	}
+	log.Printf("shutdown (%s) done in %v\n", name, time.Since(startTime).Round(time.Millisecond))
+}
+
 // watch stdin, kill server if stdin is closed
 func stdinReadWatch() {
 	buf := make([]byte, 1024)
@@ -86,6 +151,43 @@ func stdinReadWatch() {
 	}
 }
 
+// the key is needed to write (storage:encrypt) or to read the blobs that are already encrypted, the setting is
+// read when wave starts
+func initFilestoreEncryption() {
+	encrypt := wconfig.ReadFullConfig().Settings.StorageEncrypt
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	hasEncrypted, err := filestore.WFS.HasEncryptedBlobs(ctx)
+	if err != nil {
+		log.Printf("error checking for encrypted blobs: %v\n", err)
+	}
+	if !encrypt && !hasEncrypted {
+		return
+	}
+	key, err := keychain.GetKey(filestore.EncryptionKeyName, encrypt)
+	if err != nil {
+		log.Printf("error getting the filestore key (block files are not encrypted): %v\n", err)
+		return
+	}
+	err = filestore.SetEncryption(key, encrypt)
+	if err != nil {
+		log.Printf("error setting the filestore key: %v\n", err)
+	}
+}
+
+// the read cache of the block files (storage:cachebytes, storage:cachepolicy), the settings are read when wave starts
+func initFilestoreCache() {
+	settings := wconfig.ReadFullConfig().Settings
+	cacheBytes := int64(filestore.DefaultReadCacheBytes)
+	if settings.StorageCacheBytes != nil {
+		cacheBytes = *settings.StorageCacheBytes
+	}
+	err := filestore.SetReadCache(cacheBytes, settings.StorageCachePolicy)
+	if err != nil {
+		log.Printf("error setting the filestore cache: %v\n", err)
+	}
+}
+
 func startConfigWatcher() {
 	watcher := wconfig.GetWatcher()
 	if watcher != nil {
@@ -275,6 +377,15 @@ func clearTempFiles() error {
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
+	if len(os.Args) == 3 && os.Args[1] == ptyhost.HostArg {
+		// helper process that owns the pty for a persistent local shell session
+		err := ptyhost.RunHost(os.Args[2])
+		if err != nil {
+			log.Printf("ptyhost error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	log.SetPrefix("[wavesrv] ")
 	wavebase.WaveVersion = WaveVersion
 	wavebase.BuildTime = BuildTime
@@ -330,6 +441,8 @@ func main() {
 		log.Printf("error initializing filestore: %v\n", err)
 		return
 	}
+	initFilestoreEncryption()
+	initFilestoreCache()
 	err = wstore.InitWStore()
 	if err != nil {
 		log.Printf("error initializing wstore: %v\n", err)
@@ -350,6 +463,19 @@ func main() {
 		log.Printf("error ensuring initial data: %v\n", err)
 		return
 	}
+	blockcontroller.ReapLeftoverShellProcs()
+	// synchronous, nothing can create objects (or start controllers) until it is done
+	func() {
+		defer func() {
+			panichandler.PanicHandler("ReapOrphanedObjects", recover())
+		}()
+		ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelFn()
+		err := wcore.ReapOrphanedObjects(ctx)
+		if err != nil {
+			log.Printf("error reaping orphaned objects: %v\n", err)
+		}
+	}()
 	err = clearTempFiles()
 	if err != nil {
 		log.Printf("error clearing temp files: %v\n", err)
@@ -365,6 +491,17 @@ func main() {
 	go updateTelemetryCountsLoop()
 	startupActivityUpdate() // must be after startConfigWatcher()
 	blocklogger.InitBlockLogger()
+	webhook.Start()
+	blockcontroller.StartConnStateHandler()
+	blockcontroller.StartSettingsHandler()
+	portforward.Start()
+	palette.Start()
+	wnotify.Start()
+	wsettings.Start()
+	wtheme.Start()
+	filequota.Start()
+	fileretention.Start()
+	filereplica.Start()
 
 	webListener, err := web.MakeTCPListener("web")
 	if err != nil {
@@ -377,6 +514,30 @@ func main() {
 		return
 	}
 	go web.RunWebSocketServer(wsListener)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RunApiServer", recover())
+		}()
+		web.RunApiServer()
+	}()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RunGrpcApiServer", recover())
+		}()
+		web.RunGrpcApiServer()
+	}()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RunRemoteAccessServer", recover())
+		}()
+		web.RunRemoteAccessServer()
+	}()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RunApiSocketServer", recover())
+		}()
+		web.RunApiSocketServer()
+	}()
 	unixListener, err := web.MakeUnixListener()
 	if err != nil {
 		log.Printf("error creating unix listener: %v\n", err)
@@ -389,7 +550,18 @@ func main() {
 		// use fmt instead of log here to make sure it goes directly to stderr
 		fmt.Fprintf(os.Stderr, "WAVESRV-ESTART ws:%s web:%s version:%s buildtime:%s\n", wsListener.Addr(), webListener.Addr(), WaveVersion, BuildTime)
 	}()
+	wshListener = unixListener
 	go wshutil.RunWshRpcOverListener(unixListener)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("StartPlugins", recover())
+		}()
+		wplugin.StartPlugins()
+	}()
 	web.RunWebServer(webListener) // blocking
 	runtime.KeepAlive(waveLock)
+	if web.IsShuttingDown() {
+		// doShutdown exits the process
+		select {}
+	}
 }
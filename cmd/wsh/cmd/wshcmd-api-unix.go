@@ -0,0 +1,18 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+)
+
+func dialApiSocket(dataDir string) (net.Conn, error) {
+	return net.DialTimeout("unix", filepath.Join(dataDir, wavebase.ApiSocketBaseName), 2*time.Second)
+}
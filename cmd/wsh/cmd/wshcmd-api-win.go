@@ -0,0 +1,18 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"net"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/util/pipeutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+)
+
+func dialApiSocket(dataDir string) (net.Conn, error) {
+	return pipeutil.Dial(pipeutil.PipeName(wavebase.ApiPipeService, dataDir), 2*time.Second)
+}
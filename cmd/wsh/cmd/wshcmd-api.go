@@ -0,0 +1,77 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var apiCmd = &cobra.Command{
+	Use:     "api METHOD [JSON-PARAMS]",
+	Short:   "call a method of the automation api (use \"methods\" to list them)",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    apiRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+}
+
+type apiRpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func apiRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("api", rtnErr == nil)
+	}()
+	var params json.RawMessage
+	if len(args) > 1 {
+		params = json.RawMessage(args[1])
+		if !json.Valid(params) {
+			return fmt.Errorf("params are not valid json")
+		}
+	}
+	dataDir, err := wshclient.PathCommand(RpcClient, wshrpc.PathCommandData{PathType: "data"}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("getting data dir: %w", err)
+	}
+	// the socket is only on the machine running wave
+	conn, err := dialApiSocket(dataDir)
+	if err != nil {
+		return fmt.Errorf("connecting to the api socket (wsh api only works locally): %w", err)
+	}
+	defer conn.Close()
+	reqBytes, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": args[0], "params": params})
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	_, err = conn.Write(append(reqBytes, '\n'))
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	var resp apiRpcResponse
+	err = json.NewDecoder(bufio.NewReader(conn)).Decode(&resp)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s", resp.Error.Message)
+	}
+	WriteStdout("%s\n", string(resp.Result))
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var connClusterTags []string
+var connClusterConcurrency int
+var connClusterCwd string
+var connClusterEnv []string
+var connClusterTimeout int
+var connClusterView bool
+var connClusterJson bool
+var connClusterSudo bool
+
+var connClusterCmd = &cobra.Command{
+	Use:     "cluster [-t TAG]... [CONNECTION...] -- COMMAND...",
+	Short:   "run a command on many connections in parallel",
+	Long:    "Run a non-interactive command on the saved connections with any of the tags and on the named connections, at most --parallel of them at a time.  Each host has its own --timeout (which includes connecting).  wsh prints each host as it finishes, then the output grouped by the hosts that had the same exit code and output, and exits with 1 if the command failed on any host.  With --sudo the command runs as root, on the hosts with passwordless sudo or a conn:sudosecret (the password is not asked for).  With --view the results are shown in a block instead (wsh returns right away), with --json the whole run is written as json when it is done.",
+	Example: "  wsh conn cluster -t web -- uptime\n  wsh conn cluster -t db -t cache -p 20 --timeout 30 -- systemctl is-active redis\n  wsh conn cluster --view db1 db2 -- df -h /var",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    activityWrap("conn", connClusterRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	connClusterCmd.Flags().StringArrayVarP(&connClusterTags, "tag", "t", nil, "run on the saved connections with the tag, can be repeated")
+	connClusterCmd.Flags().IntVarP(&connClusterConcurrency, "parallel", "p", 0, "the hosts to run on at once (default 10)")
+	connClusterCmd.Flags().StringVar(&connClusterCwd, "cwd", "", "the directory to run the command in")
+	connClusterCmd.Flags().StringArrayVarP(&connClusterEnv, "env", "e", nil, "an environment variable for the command, NAME=VALUE")
+	connClusterCmd.Flags().IntVar(&connClusterTimeout, "timeout", 0, "give up on a host after this many seconds")
+	connClusterCmd.Flags().BoolVar(&connClusterView, "view", false, "show the results in a block")
+	connClusterCmd.Flags().BoolVar(&connClusterJson, "json", false, "write the run as json")
+	connClusterCmd.Flags().BoolVar(&connClusterSudo, "sudo", false, "run the command with sudo")
+	connCmd.AddCommand(connClusterCmd)
+}
+
+func connClusterRun(cmd *cobra.Command, args []string) error {
+	dashIdx := cmd.ArgsLenAtDash()
+	if dashIdx < 0 || dashIdx == len(args) {
+		return fmt.Errorf("no command to run (put it after --)")
+	}
+	data := wshrpc.CommandClusterExecData{
+		Cmd:         strings.Join(args[dashIdx:], " "),
+		Tags:        connClusterTags,
+		Connections: args[:dashIdx],
+		Concurrency: connClusterConcurrency,
+		TimeoutMs:   connClusterTimeout * 1000,
+		Cwd:         connClusterCwd,
+		Sudo:        connClusterSudo,
+	}
+	for _, envStr := range connClusterEnv {
+		name, value, ok := strings.Cut(envStr, "=")
+		if !ok {
+			return fmt.Errorf("invalid --env %q (NAME=VALUE)", envStr)
+		}
+		if data.Env == nil {
+			data.Env = make(map[string]string)
+		}
+		data.Env[name] = value
+	}
+	run, err := wshclient.ClusterExecStartCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("starting the run: %w", err)
+	}
+	if connClusterView {
+		blockData := wshrpc.CommandCreateBlockData{
+			BlockDef: &waveobj.BlockDef{
+				Meta: waveobj.MetaMapType{
+					waveobj.MetaKey_View:             "clusterexec",
+					waveobj.MetaKey_ClusterExecRunId: run.RunId,
+				},
+			},
+		}
+		oref, err := wshclient.CreateBlockCommand(RpcClient, blockData, nil)
+		if err != nil {
+			return fmt.Errorf("opening the results view: %w", err)
+		}
+		WriteStdout("running on %d hosts, results in block %s\n", len(run.Hosts), oref)
+		return nil
+	}
+	printed := make(map[string]bool)
+	for run.EndTs == 0 {
+		time.Sleep(500 * time.Millisecond)
+		run, err = wshclient.ClusterExecGetCommand(RpcClient, wshrpc.CommandClusterExecRunData{RunId: run.RunId}, &wshrpc.RpcOpts{Timeout: 5000})
+		if err != nil {
+			return fmt.Errorf("getting the run: %w", err)
+		}
+		if !connClusterJson {
+			printClusterHosts(run, printed)
+		}
+	}
+	if clusterRunFailed(run) {
+		WshExitCode = 1
+	}
+	if connClusterJson {
+		barr, err := json.MarshalIndent(run, "", "  ")
+		if err != nil {
+			return err
+		}
+		WriteStdout("%s\n", string(barr))
+		return nil
+	}
+	for _, group := range run.Groups {
+		status := group.Error
+		if status == "" {
+			status = fmt.Sprintf("exit %d", group.ExitCode)
+		}
+		WriteStdout("---- %s (%s) ----\n", strings.Join(group.Hosts, ", "), status)
+		if group.Stdout != "" {
+			WriteStdout("%s", withNewline(group.Stdout))
+		}
+		if group.Stderr != "" {
+			WriteStdout("%s", withNewline(group.Stderr))
+		}
+	}
+	return nil
+}
+
+// prints the hosts that finished since the last call (to stderr, the grouped output goes to stdout)
+func printClusterHosts(run *wshrpc.ClusterExecRun, printed map[string]bool) {
+	for _, host := range run.Hosts {
+		if printed[host.Connection] || (host.Status != "done" && host.Status != "error") {
+			continue
+		}
+		printed[host.Connection] = true
+		switch {
+		case host.Result == nil:
+			WriteStderr("%s: %s\n", host.Connection, host.Error)
+		case host.Result.TimedOut:
+			WriteStderr("%s: timed out\n", host.Connection)
+		case host.Result.Signal != "":
+			WriteStderr("%s: killed by SIG%s\n", host.Connection, host.Result.Signal)
+		default:
+			WriteStderr("%s: exit %d (%dms)\n", host.Connection, host.Result.ExitCode, host.Result.DurationMs)
+		}
+	}
+}
+
+func clusterRunFailed(run *wshrpc.ClusterExecRun) bool {
+	for _, host := range run.Hosts {
+		if host.Result == nil || host.Result.ExitCode != 0 || host.Result.TimedOut {
+			return true
+		}
+	}
+	return false
+}
+
+func withNewline(str string) string {
+	if strings.HasSuffix(str, "\n") {
+		return str
+	}
+	return str + "\n"
+}
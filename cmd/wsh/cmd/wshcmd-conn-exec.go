@@ -0,0 +1,116 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var connExecCwd string
+var connExecEnv []string
+var connExecTimeout int
+var connExecStdin bool
+var connExecJson bool
+var connExecSudo bool
+var connExecSudoUser string
+
+var connExecCmd = &cobra.Command{
+	Use:     "exec CONNECTION -- COMMAND...",
+	Short:   "run a non-interactive command on a connection",
+	Long:    "Run a command on an ssh connection (a saved connection or a connection name), connecting it first if needed, like \"ssh host cmd\".  The output is written as it comes, and wsh exits with the exit code of the command.  With --json the result (exit code, stdout, stderr and the duration) is written as json when the command is done.  With --sudo the command runs as root (or the --sudo-user), the sudo password is the secret in conn:sudosecret or is asked for in Wave.",
+	Example: "  wsh conn exec prod-db-3 -- df -h /var\n  wsh conn exec --cwd ~/app -e ENV=staging dev -- make test\n  wsh conn exec --json --timeout 10 bastion -- uptime\n  wsh conn exec --sudo web-1 -- systemctl restart nginx",
+	Args:    cobra.MinimumNArgs(2),
+	RunE:    activityWrap("conn", connExecRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	connExecCmd.Flags().StringVar(&connExecCwd, "cwd", "", "the directory to run the command in")
+	connExecCmd.Flags().StringArrayVarP(&connExecEnv, "env", "e", nil, "an environment variable for the command, NAME=VALUE")
+	connExecCmd.Flags().IntVar(&connExecTimeout, "timeout", 0, "kill the command after this many seconds")
+	connExecCmd.Flags().BoolVar(&connExecStdin, "stdin", false, "send the stdin of wsh to the command")
+	connExecCmd.Flags().BoolVar(&connExecJson, "json", false, "write the result as json")
+	connExecCmd.Flags().BoolVar(&connExecSudo, "sudo", false, "run the command with sudo")
+	connExecCmd.Flags().StringVar(&connExecSudoUser, "sudo-user", "", "the user to run the command as with sudo (implies --sudo)")
+	connCmd.AddCommand(connExecCmd)
+}
+
+func connExecRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandConnectionRunData{
+		Connection: args[0],
+		Cmd:        strings.Join(args[1:], " "),
+		Cwd:        connExecCwd,
+		TimeoutMs:  connExecTimeout * 1000,
+		Sudo:       connExecSudo || connExecSudoUser != "",
+		SudoUser:   connExecSudoUser,
+	}
+	for _, envStr := range connExecEnv {
+		name, value, ok := strings.Cut(envStr, "=")
+		if !ok {
+			return fmt.Errorf("invalid --env %q (NAME=VALUE)", envStr)
+		}
+		if data.Env == nil {
+			data.Env = make(map[string]string)
+		}
+		data.Env[name] = value
+	}
+	if connExecStdin {
+		stdin, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading stdin: %w", err)
+		}
+		data.Stdin = string(stdin)
+	}
+	rpcOpts := &wshrpc.RpcOpts{Timeout: TimeoutYear}
+	if connExecJson {
+		result, err := wshclient.ConnectionRunCommand(RpcClient, data, rpcOpts)
+		if err != nil {
+			return fmt.Errorf("running the command on %s: %w", args[0], err)
+		}
+		barr, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		WriteStdout("%s\n", string(barr))
+		return nil
+	}
+	var result *wshrpc.ConnectionRunResult
+	for respUnion := range wshclient.ConnectionRunStreamCommand(RpcClient, data, rpcOpts) {
+		if respUnion.Error != nil {
+			return fmt.Errorf("running the command on %s: %w", args[0], respUnion.Error)
+		}
+		output := respUnion.Response
+		if output.Stdout != "" {
+			WriteStdout("%s", output.Stdout)
+		}
+		if output.Stderr != "" {
+			WriteStderr("%s", output.Stderr)
+		}
+		if output.Result != nil {
+			result = output.Result
+		}
+	}
+	if result == nil {
+		return fmt.Errorf("running the command on %s: no result", args[0])
+	}
+	switch {
+	case result.TimedOut:
+		WriteStderr("[killed after %ds]\n", connExecTimeout)
+		WshExitCode = 124
+	case result.Signal != "":
+		WriteStderr("[killed by SIG%s]\n", result.Signal)
+		WshExitCode = 1
+	default:
+		WshExitCode = result.ExitCode
+	}
+	return nil
+}
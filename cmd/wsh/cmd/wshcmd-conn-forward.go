@@ -0,0 +1,220 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var connForwardLocal string
+var connForwardRemote string
+var connForwardDynamic string
+var connForwardAuto bool
+var connForwardWatch bool
+var connForwardForget bool
+
+var connForwardCmd = &cobra.Command{
+	Use:   "forward",
+	Short: "manage the port forwards of saved connections",
+	Long:  "Commands to manage port forwards on saved connections: local (-L, a port here forwarded to a host reachable from the connection), remote (-R, a port on the connection forwarded to a host reachable from here) and dynamic (-D, a socks5 proxy here that connects from the connection).  An --auto forward is saved on the connection and started every time it connects.",
+}
+
+var connForwardAddCmd = &cobra.Command{
+	Use:     "add CONNECTION",
+	Short:   "start a port forward on a saved connection",
+	Example: "  wsh conn forward add prod-db-3 -L 5432:localhost:5432 --auto\n  wsh conn forward add dev -R 8080:localhost:3000\n  wsh conn forward add bastion -D 1080",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("conn", connForwardAddRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connForwardListCmd = &cobra.Command{
+	Use:     "ls [CONNECTION]",
+	Short:   "list the port forwards, their status and the bytes they carried",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("conn", connForwardListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connForwardStopCmd = &cobra.Command{
+	Use:     "stop ID",
+	Short:   "stop a port forward (an --auto forward starts again on the next connect, unless --forget is set)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("conn", connForwardStopRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	connForwardAddCmd.Flags().StringVarP(&connForwardLocal, "local", "L", "", "a local forward, [BIND_ADDR:]PORT:HOST:HOSTPORT")
+	connForwardAddCmd.Flags().StringVarP(&connForwardRemote, "remote", "R", "", "a remote forward, [BIND_ADDR:]PORT:HOST:HOSTPORT")
+	connForwardAddCmd.Flags().StringVarP(&connForwardDynamic, "dynamic", "D", "", "a dynamic (socks5) forward, [BIND_ADDR:]PORT")
+	connForwardAddCmd.Flags().BoolVar(&connForwardAuto, "auto", false, "save the forward on the connection, it is started every time the connection connects")
+	connForwardListCmd.Flags().BoolVarP(&connForwardWatch, "watch", "w", false, "refresh the list every second")
+	connForwardStopCmd.Flags().BoolVar(&connForwardForget, "forget", false, "remove it from the connection's auto-start forwards")
+	connCmd.AddCommand(connForwardCmd)
+	connForwardCmd.AddCommand(connForwardAddCmd)
+	connForwardCmd.AddCommand(connForwardListCmd)
+	connForwardCmd.AddCommand(connForwardStopCmd)
+}
+
+// splits on ':', an ipv6 address can be in brackets ("[::1]:8080:localhost:80")
+func splitForwardSpec(spec string) []string {
+	var parts []string
+	for len(spec) > 0 {
+		if strings.HasPrefix(spec, "[") {
+			if end := strings.Index(spec, "]"); end > 0 {
+				parts = append(parts, spec[1:end])
+				spec = strings.TrimPrefix(spec[end+1:], ":")
+				continue
+			}
+		}
+		part, rest, found := strings.Cut(spec, ":")
+		parts = append(parts, part)
+		spec = rest
+		if !found {
+			break
+		}
+	}
+	return parts
+}
+
+func parsePort(portStr string) (int, error) {
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", portStr)
+	}
+	return port, nil
+}
+
+func parseForwardSpec(fwdType string, spec string) (waveobj.PortForward, error) {
+	rtn := waveobj.PortForward{Type: fwdType}
+	parts := splitForwardSpec(spec)
+	if fwdType == "dynamic" {
+		if len(parts) == 2 {
+			rtn.BindAddr = parts[0]
+			parts = parts[1:]
+		}
+		if len(parts) != 1 {
+			return rtn, fmt.Errorf("invalid dynamic forward %q, it is [BIND_ADDR:]PORT", spec)
+		}
+		port, err := parsePort(parts[0])
+		rtn.BindPort = port
+		return rtn, err
+	}
+	if len(parts) == 4 {
+		rtn.BindAddr = parts[0]
+		parts = parts[1:]
+	}
+	if len(parts) != 3 {
+		return rtn, fmt.Errorf("invalid %s forward %q, it is [BIND_ADDR:]PORT:HOST:HOSTPORT", fwdType, spec)
+	}
+	var err error
+	rtn.BindPort, err = parsePort(parts[0])
+	if err != nil {
+		return rtn, err
+	}
+	rtn.DestHost = parts[1]
+	rtn.DestPort, err = parsePort(parts[2])
+	return rtn, err
+}
+
+func formatForwardSpec(fwd waveobj.PortForward) string {
+	bind := fmt.Sprintf("%s:%d", fwd.BindAddr, fwd.BindPort)
+	switch fwd.Type {
+	case "local":
+		return fmt.Sprintf("-L %s -> %s:%d", bind, fwd.DestHost, fwd.DestPort)
+	case "remote":
+		return fmt.Sprintf("-R %s -> %s:%d", bind, fwd.DestHost, fwd.DestPort)
+	}
+	return fmt.Sprintf("-D %s (socks)", bind)
+}
+
+func connForwardAddRun(cmd *cobra.Command, args []string) error {
+	var fwdType, spec string
+	var numSpecs int
+	for _, opt := range []struct{ fwdType, spec string }{{"local", connForwardLocal}, {"remote", connForwardRemote}, {"dynamic", connForwardDynamic}} {
+		if opt.spec != "" {
+			fwdType, spec = opt.fwdType, opt.spec
+			numSpecs++
+		}
+	}
+	if numSpecs != 1 {
+		return fmt.Errorf("one of -L, -R or -D is required")
+	}
+	fwd, err := parseForwardSpec(fwdType, spec)
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandPortForwardCreateData{Connection: args[0], Forward: fwd, AutoStart: connForwardAuto}
+	// the connection may have to connect first
+	info, err := wshclient.PortForwardCreateCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 60000})
+	if err != nil {
+		return fmt.Errorf("adding forward: %w", err)
+	}
+	WriteStdout("forward %s started on %s: %s\n", info.Id, info.Connection, formatForwardSpec(info.Forward))
+	return nil
+}
+
+func printForwards(forwards []wshrpc.PortForwardInfo) {
+	if len(forwards) == 0 {
+		WriteStdout("no port forwards\n")
+		return
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID\tCONNECTION\tFORWARD\tSTATUS\tCONNS\tSENT\tRECEIVED\n")
+	for _, fwd := range forwards {
+		status := fwd.Status
+		if fwd.AutoStart {
+			status += " (auto)"
+		}
+		if fwd.Error != "" {
+			status += ": " + fwd.Error
+		}
+		conns := fmt.Sprintf("%d/%d", fwd.ActiveConns, fwd.TotalConns)
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", fwd.Id, fwd.Connection, formatForwardSpec(fwd.Forward), status, conns, formatStorageBytes(fwd.BytesSent), formatStorageBytes(fwd.BytesRecv))
+	}
+	writer.Flush()
+}
+
+func connForwardListRun(cmd *cobra.Command, args []string) error {
+	var data wshrpc.CommandPortForwardListData
+	if len(args) > 0 {
+		data.Connection = args[0]
+	}
+	for {
+		forwards, err := wshclient.PortForwardListCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+		if err != nil {
+			return fmt.Errorf("listing forwards: %w", err)
+		}
+		if connForwardWatch {
+			// clear the screen
+			WriteStdout("\x1b[H\x1b[2J")
+		}
+		printForwards(forwards)
+		if !connForwardWatch {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func connForwardStopRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandPortForwardStopData{Id: args[0], Forget: connForwardForget}
+	err := wshclient.PortForwardStopCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("stopping forward: %w", err)
+	}
+	WriteStdout("forward %s stopped\n", args[0])
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var connHostKeyCmd = &cobra.Command{
+	Use:   "hostkey",
+	Short: "manage the host keys accepted or rejected in wave",
+	Long:  "Commands to manage the host keys you accepted or rejected when Wave asked (they are kept in a known_hosts file Wave manages, along with the known_hosts files of your ssh config).",
+}
+
+var connHostKeyListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the host keys accepted or rejected in wave",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("conn", connHostKeyListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connHostKeyRemoveCmd = &cobra.Command{
+	Use:     "rm HOST",
+	Short:   "forget the host keys of a host, so wave asks again on the next connect",
+	Example: "  wsh conn hostkey rm example.com\n  wsh conn hostkey rm \"[example.com]:2222\"",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("conn", connHostKeyRemoveRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	connCmd.AddCommand(connHostKeyCmd)
+	connHostKeyCmd.AddCommand(connHostKeyListCmd)
+	connHostKeyCmd.AddCommand(connHostKeyRemoveCmd)
+}
+
+func connHostKeyListRun(cmd *cobra.Command, args []string) error {
+	keys, err := wshclient.HostKeyListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("listing host keys: %w", err)
+	}
+	if len(keys) == 0 {
+		WriteStdout("no host keys\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "HOST\tTYPE\tFINGERPRINT\tDECISION\n")
+	for _, key := range keys {
+		decision := "accepted"
+		if key.Revoked {
+			decision = "rejected"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", strings.Join(key.Hosts, ","), key.KeyType, key.Fingerprint, decision)
+	}
+	writer.Flush()
+	return nil
+}
+
+func connHostKeyRemoveRun(cmd *cobra.Command, args []string) error {
+	numRemoved, err := wshclient.HostKeyForgetCommand(RpcClient, wshrpc.CommandHostKeyForgetData{Host: args[0]}, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("removing host keys: %w", err)
+	}
+	if numRemoved == 0 {
+		return fmt.Errorf("no host keys for %s", args[0])
+	}
+	WriteStdout("removed %d host key(s) of %s\n", numRemoved, args[0])
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var connKeyType string
+var connKeyBits int
+var connKeyComment string
+var connKeyPassphrase bool
+var connKeyAgent bool
+var connKeyConn string
+var connKeyLifetime int
+
+var connKeyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "manage ssh keys",
+	Long:  "Commands to manage the ssh keys in ~/.ssh on the machine running Wave: list them, generate new ones, add them to the ssh agent, and set the key of a saved connection.",
+}
+
+var connKeyListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the private keys in ~/.ssh",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("conn", connKeyListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connKeyGenCmd = &cobra.Command{
+	Use:     "gen NAME",
+	Short:   "generate a keypair (NAME is a file name in ~/.ssh or a path)",
+	Example: "  wsh conn key gen id_prod -p --agent --conn prod-db-3\n  wsh conn key gen id_legacy -t rsa -b 4096",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("conn", connKeyGenRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connKeyAddCmd = &cobra.Command{
+	Use:     "add KEY",
+	Short:   "add a private key to the ssh agent",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("conn", connKeyAddRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connKeyUseCmd = &cobra.Command{
+	Use:     "use CONNECTION KEY",
+	Short:   "make a saved connection authenticate with a private key",
+	Args:    cobra.ExactArgs(2),
+	RunE:    activityWrap("conn", connKeyUseRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	connKeyGenCmd.Flags().StringVarP(&connKeyType, "type", "t", "ed25519", "the key type: ed25519, ecdsa or rsa")
+	connKeyGenCmd.Flags().IntVarP(&connKeyBits, "bits", "b", 0, "the key size (rsa 2048-8192, ecdsa 256, 384 or 521)")
+	connKeyGenCmd.Flags().StringVarP(&connKeyComment, "comment", "C", "", "the comment of the key (user@host if not set)")
+	connKeyGenCmd.Flags().BoolVarP(&connKeyPassphrase, "passphrase", "p", false, "ask for a passphrase to encrypt the key with")
+	connKeyGenCmd.Flags().BoolVar(&connKeyAgent, "agent", false, "add the new key to the ssh agent")
+	connKeyGenCmd.Flags().StringVar(&connKeyConn, "conn", "", "a saved connection to use the new key")
+	connKeyAddCmd.Flags().BoolVarP(&connKeyPassphrase, "passphrase", "p", false, "ask for the passphrase of the key")
+	connKeyAddCmd.Flags().IntVar(&connKeyLifetime, "lifetime", 0, "remove the key from the agent after this many seconds")
+	connCmd.AddCommand(connKeyCmd)
+	connKeyCmd.AddCommand(connKeyListCmd)
+	connKeyCmd.AddCommand(connKeyGenCmd)
+	connKeyCmd.AddCommand(connKeyAddCmd)
+	connKeyCmd.AddCommand(connKeyUseCmd)
+}
+
+func connKeyListRun(cmd *cobra.Command, args []string) error {
+	keys, err := wshclient.SshKeyListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("listing keys: %w", err)
+	}
+	if len(keys) == 0 {
+		WriteStdout("no keys in ~/.ssh\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "PATH\tTYPE\tFINGERPRINT\tAGENT\tCONNECTIONS\n")
+	for _, key := range keys {
+		keyType := key.KeyType
+		if keyType == "" {
+			keyType = "?"
+		}
+		if key.Bits > 0 {
+			keyType += fmt.Sprintf(" (%d)", key.Bits)
+		}
+		if key.Encrypted {
+			keyType += " encrypted"
+		}
+		inAgent := "-"
+		if key.InAgent {
+			inAgent = "yes"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", key.Path, keyType, key.Fingerprint, inAgent, strings.Join(key.Connections, ","))
+	}
+	writer.Flush()
+	return nil
+}
+
+func connKeyGenRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandSshKeyGenerateData{
+		Name:       args[0],
+		KeyType:    connKeyType,
+		Bits:       connKeyBits,
+		Comment:    connKeyComment,
+		AddToAgent: connKeyAgent,
+		Connection: connKeyConn,
+	}
+	if connKeyPassphrase {
+		passphrase, err := readPassword("Passphrase: ")
+		if err != nil {
+			return fmt.Errorf("reading passphrase: %w", err)
+		}
+		confirm, err := readPassword("Confirm passphrase: ")
+		if err != nil {
+			return fmt.Errorf("reading passphrase: %w", err)
+		}
+		if passphrase != confirm {
+			return fmt.Errorf("the passphrases do not match")
+		}
+		data.Passphrase = passphrase
+	}
+	// rsa keys can take a while to generate
+	key, err := wshclient.SshKeyGenerateCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 60000})
+	if err != nil {
+		return fmt.Errorf("generating key: %w", err)
+	}
+	WriteStdout("generated %s (%s %s)\n", key.Path, key.KeyType, key.Fingerprint)
+	WriteStdout("public key: %s\n", key.PubPath)
+	if len(key.Connections) > 0 {
+		WriteStdout("connection %q uses the key\n", key.Connections[0])
+	}
+	return nil
+}
+
+func connKeyAddRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandSshKeyAddToAgentData{Path: args[0], LifetimeSecs: connKeyLifetime}
+	if connKeyPassphrase {
+		passphrase, err := readPassword("Passphrase: ")
+		if err != nil {
+			return fmt.Errorf("reading passphrase: %w", err)
+		}
+		data.Passphrase = passphrase
+	}
+	err := wshclient.SshKeyAddToAgentCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("adding key: %w", err)
+	}
+	WriteStdout("added %s to the ssh agent\n", args[0])
+	return nil
+}
+
+func connKeyUseRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandConnectionSetKeyData{Connection: args[0], Path: args[1]}
+	conn, err := wshclient.ConnectionSetKeyCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("setting key: %w", err)
+	}
+	WriteStdout("connection %q uses %s\n", conn.Name, conn.IdentityFile)
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var connMetricsHistory bool
+var connMetricsJson bool
+
+var connMetricsCmd = &cobra.Command{
+	Use:     "metrics [CONNECTION]",
+	Short:   "show the latency and traffic of the connections",
+	Long:    "Show the latency (the round trips of the keepalive probes) and the traffic (the bytes read and written on the ssh transport) of the ssh connections used since Wave started, or of one connection.  The min and max latencies are over the last 10 minutes, the rates are over the last 5 seconds.  With --history the samples of the last 10 minutes are listed (one every 5 seconds while connected).",
+	Example: "  wsh conn metrics\n  wsh conn metrics prod-db-3 --history",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("conn", connMetricsRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	connMetricsCmd.Flags().BoolVar(&connMetricsHistory, "history", false, "list the samples (needs a connection)")
+	connMetricsCmd.Flags().BoolVar(&connMetricsJson, "json", false, "write the metrics (with the samples) as json")
+	connCmd.AddCommand(connMetricsCmd)
+}
+
+func formatLatency(latencyMs int64) string {
+	if latencyMs == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", latencyMs)
+}
+
+func connMetricsRun(cmd *cobra.Command, args []string) error {
+	var data wshrpc.CommandConnMetricsData
+	if len(args) > 0 {
+		data.Connection = args[0]
+	} else if connMetricsHistory {
+		return fmt.Errorf("--history needs a connection")
+	}
+	data.NoHistory = !connMetricsHistory && !connMetricsJson
+	allMetrics, err := wshclient.ConnMetricsCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("getting connection metrics: %w", err)
+	}
+	if connMetricsJson {
+		barr, err := json.MarshalIndent(allMetrics, "", "  ")
+		if err != nil {
+			return err
+		}
+		WriteStdout("%s\n", string(barr))
+		return nil
+	}
+	if len(allMetrics) == 0 {
+		WriteStdout("no connections\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "CONNECTION\tSTATUS\tLATENCY\tSMOOTHED\tMIN\tMAX\tIN\tOUT\tIN/S\tOUT/S\n")
+	for _, m := range allMetrics {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.Connection, m.Status,
+			formatLatency(m.LatencyMs), formatLatency(m.SmoothedLatencyMs), formatLatency(m.MinLatencyMs), formatLatency(m.MaxLatencyMs),
+			formatStorageBytes(m.BytesIn), formatStorageBytes(m.BytesOut), formatStorageBytes(m.BytesInRate), formatStorageBytes(m.BytesOutRate))
+	}
+	writer.Flush()
+	if !connMetricsHistory {
+		return nil
+	}
+	WriteStdout("\n")
+	writer = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "TIME\tLATENCY\tIN\tOUT\n")
+	for _, sample := range allMetrics[0].Samples {
+		ts := time.UnixMilli(sample.Ts).Format("15:04:05")
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", ts, formatLatency(sample.LatencyMs), formatStorageBytes(sample.BytesIn), formatStorageBytes(sample.BytesOut))
+	}
+	writer.Flush()
+	return nil
+}
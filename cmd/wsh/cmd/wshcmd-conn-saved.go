@@ -0,0 +1,272 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var connSavedAuth string
+var connSavedIdentity string
+var connSavedCert string
+var connSavedSecret string
+var connSavedTags []string
+var connSavedJumps []string
+var connSavedForwardAgent bool
+var connSavedName string
+var connSavedHost string
+var connListTag string
+var connRemoveForce bool
+
+var connAddCmd = &cobra.Command{
+	Use:     "add NAME [USER@]HOST[:PORT]",
+	Short:   "save a connection under a name",
+	Long:    "Save an ssh connection under a name, so a terminal can be opened on it with \"wsh conn term NAME\".  The auth method (key, agent, password or gssapi), the identity file and certificate, the jump hosts and agent forwarding are saved in connections.json for the connection, so every block that connects to it uses them.  A jump host is a saved connection (connected with its own auth method) or a [USER@]HOST[:PORT].",
+	Example: "  wsh conn add prod-db-3 admin@10.0.3.12 --auth key --identity ~/.ssh/prod_ed25519 --tag prod\n  wsh conn add bastion ops@bastion.example.com --auth agent --cert ~/.ssh/ops-cert.pub\n  wsh conn add build-7 build-7.corp.example.com --auth gssapi\n  wsh conn add legacy-1 root@10.0.9.4 --auth password --secret legacy-root\n  wsh conn add prod-db-4 admin@10.0.3.13 --jump bastion",
+	Args:    cobra.ExactArgs(2),
+	RunE:    activityWrap("conn", connAddRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the saved connections, their status and the number of blocks that use them",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("conn", connListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connEditCmd = &cobra.Command{
+	Use:     "edit NAME",
+	Short:   "change a saved connection (the blocks that use it follow a new host, user or port)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("conn", connEditRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connRemoveCmd = &cobra.Command{
+	Use:     "rm NAME",
+	Short:   "remove a saved connection",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("conn", connRemoveRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connTermCmd = &cobra.Command{
+	Use:     "term NAME",
+	Short:   "open a terminal on a saved connection in the current tab",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("conn", connTermRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connDashboardCmd = &cobra.Command{
+	Use:     "dashboard",
+	Short:   "open the connections view (the status, latency, blocks and forwards of the saved connections)",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("conn", connDashboardRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	connAddCmd.Flags().StringVar(&connSavedAuth, "auth", "", "the auth method: key, agent, password or gssapi (the ssh config if not set)")
+	connAddCmd.Flags().StringVarP(&connSavedIdentity, "identity", "i", "", "the identity file (for --auth key)")
+	connAddCmd.Flags().StringVar(&connSavedCert, "cert", "", "an openssh certificate for the key (for --auth key or agent)")
+	connAddCmd.Flags().StringVar(&connSavedSecret, "secret", "", "a stored secret (wsh secret set) with the password (--auth password) or the key's passphrase (--auth key)")
+	connAddCmd.Flags().StringArrayVarP(&connSavedTags, "tag", "t", nil, "a tag for the connection, can be repeated")
+	connAddCmd.Flags().StringArrayVarP(&connSavedJumps, "jump", "J", nil, "a jump host (a saved connection or [USER@]HOST[:PORT]), can be repeated for a chain")
+	connAddCmd.Flags().BoolVarP(&connSavedForwardAgent, "forward-agent", "A", false, "forward the local ssh agent to the connection")
+	connEditCmd.Flags().StringVar(&connSavedName, "name", "", "rename the connection")
+	connEditCmd.Flags().StringVar(&connSavedHost, "host", "", "the new [USER@]HOST[:PORT]")
+	connEditCmd.Flags().StringVar(&connSavedAuth, "auth", "", "the auth method: key, agent, password or gssapi (\"default\" for the ssh config)")
+	connEditCmd.Flags().StringVarP(&connSavedIdentity, "identity", "i", "", "the identity file (for --auth key)")
+	connEditCmd.Flags().StringVar(&connSavedCert, "cert", "", "an openssh certificate for the key (for --auth key or agent, \"\" to remove it)")
+	connEditCmd.Flags().StringVar(&connSavedSecret, "secret", "", "a stored secret with the password or the key's passphrase (\"\" to remove it)")
+	connEditCmd.Flags().StringArrayVarP(&connSavedTags, "tag", "t", nil, "replace the tags, can be repeated (\"\" to remove them)")
+	connEditCmd.Flags().StringArrayVarP(&connSavedJumps, "jump", "J", nil, "replace the jump hosts, can be repeated (\"\" to remove them)")
+	connEditCmd.Flags().BoolVarP(&connSavedForwardAgent, "forward-agent", "A", false, "forward the local ssh agent to the connection (--forward-agent=false to stop)")
+	connListCmd.Flags().StringVarP(&connListTag, "tag", "t", "", "only the connections with the tag")
+	connRemoveCmd.Flags().BoolVarP(&connRemoveForce, "force", "f", false, "remove it even if blocks (or other connections, as a jump host) use it")
+	connCmd.AddCommand(connAddCmd)
+	connCmd.AddCommand(connListCmd)
+	connCmd.AddCommand(connEditCmd)
+	connCmd.AddCommand(connRemoveCmd)
+	connCmd.AddCommand(connTermCmd)
+	connCmd.AddCommand(connDashboardCmd)
+}
+
+func setConnHost(conn *waveobj.Connection, hostArg string) error {
+	opts, err := remote.ParseOpts(hostArg)
+	if err != nil {
+		return fmt.Errorf("invalid host %q: %w", hostArg, err)
+	}
+	conn.Host = opts.SSHHost
+	conn.User = opts.SSHUser
+	conn.Port = opts.SSHPort
+	return nil
+}
+
+func connAddRun(cmd *cobra.Command, args []string) error {
+	conn := waveobj.Connection{Name: args[0], AuthMethod: connSavedAuth, IdentityFile: connSavedIdentity, CertificateFile: connSavedCert, Secret: connSavedSecret, Tags: connSavedTags, ProxyJump: connSavedJumps, ForwardAgent: connSavedForwardAgent}
+	err := setConnHost(&conn, args[1])
+	if err != nil {
+		return err
+	}
+	rtn, err := wshclient.ConnectionCreateCommand(RpcClient, conn, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("adding connection: %w", err)
+	}
+	WriteStdout("connection %q saved (%s)\n", rtn.Name, formatConnHost(rtn))
+	return nil
+}
+
+func formatConnHost(conn *waveobj.Connection) string {
+	return remote.SSHOpts{SSHHost: conn.Host, SSHUser: conn.User, SSHPort: conn.Port}.String()
+}
+
+func connListRun(cmd *cobra.Command, args []string) error {
+	infos, err := wshclient.ConnectionListCommand(RpcClient, wshrpc.CommandConnectionListData{Tag: connListTag}, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("listing connections: %w", err)
+	}
+	if len(infos) == 0 {
+		WriteStdout("no saved connections\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "NAME\tCONNECTION\tAUTH\tTAGS\tSTATUS\tBLOCKS\n")
+	for _, info := range infos {
+		auth := info.Connection.AuthMethod
+		if auth == "" {
+			auth = "-"
+		}
+		if info.Connection.CertificateFile != "" {
+			auth += " (cert)"
+		}
+		if info.Connection.Secret != "" {
+			auth += " (secret " + info.Connection.Secret + ")"
+		}
+		if info.Connection.ForwardAgent {
+			auth += " (forward agent)"
+		}
+		status := info.Status.Status
+		if info.Status.Error != "" {
+			status += " (" + info.Status.Error + ")"
+		}
+		connName := info.ConnName
+		if len(info.Connection.ProxyJump) > 0 {
+			connName += " via " + strings.Join(info.Connection.ProxyJump, " -> ")
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\n", info.Connection.Name, connName, auth, strings.Join(info.Connection.Tags, ","), status, len(info.BlockIds))
+	}
+	writer.Flush()
+	return nil
+}
+
+func connEditRun(cmd *cobra.Command, args []string) error {
+	infos, err := wshclient.ConnectionListCommand(RpcClient, wshrpc.CommandConnectionListData{}, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("listing connections: %w", err)
+	}
+	var conn *waveobj.Connection
+	for _, info := range infos {
+		if info.Connection.Name == args[0] || info.Connection.OID == args[0] {
+			conn = info.Connection
+		}
+	}
+	if conn == nil {
+		return fmt.Errorf("connection %q not found", args[0])
+	}
+	if cmd.Flags().Changed("name") {
+		conn.Name = connSavedName
+	}
+	if cmd.Flags().Changed("host") {
+		err = setConnHost(conn, connSavedHost)
+		if err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("auth") {
+		conn.AuthMethod = connSavedAuth
+		if connSavedAuth == "default" {
+			conn.AuthMethod = ""
+		}
+		if conn.AuthMethod != "key" {
+			conn.IdentityFile = ""
+		}
+		if conn.AuthMethod != "key" && conn.AuthMethod != "agent" {
+			conn.CertificateFile = ""
+		}
+		if conn.AuthMethod != "key" && conn.AuthMethod != "password" {
+			conn.Secret = ""
+		}
+	}
+	if cmd.Flags().Changed("identity") {
+		conn.IdentityFile = connSavedIdentity
+	}
+	if cmd.Flags().Changed("cert") {
+		conn.CertificateFile = connSavedCert
+	}
+	if cmd.Flags().Changed("secret") {
+		conn.Secret = connSavedSecret
+	}
+	if cmd.Flags().Changed("tag") {
+		conn.Tags = connSavedTags
+	}
+	if cmd.Flags().Changed("jump") {
+		conn.ProxyJump = connSavedJumps
+	}
+	if cmd.Flags().Changed("forward-agent") {
+		conn.ForwardAgent = connSavedForwardAgent
+	}
+	rtn, err := wshclient.ConnectionUpdateCommand(RpcClient, *conn, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("updating connection: %w", err)
+	}
+	WriteStdout("connection %q updated (%s)\n", rtn.Name, formatConnHost(rtn))
+	return nil
+}
+
+func connRemoveRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandConnectionData{Connection: args[0], Force: connRemoveForce}
+	err := wshclient.ConnectionDeleteCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("removing connection: %w", err)
+	}
+	WriteStdout("connection %q removed\n", args[0])
+	return nil
+}
+
+func connTermRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandConnectionData{Connection: args[0]}
+	oref, err := wshclient.ConnectionOpenTermCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("opening terminal: %w", err)
+	}
+	WriteStdout("terminal block created: %s\n", oref)
+	return nil
+}
+
+func connDashboardRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandCreateBlockData{
+		BlockDef: &waveobj.BlockDef{
+			Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "connections"},
+		},
+	}
+	oref, err := wshclient.CreateBlockCommand(RpcClient, data, nil)
+	if err != nil {
+		return fmt.Errorf("opening the connections view: %w", err)
+	}
+	WriteStdout("connections block created: %s\n", oref)
+	return nil
+}
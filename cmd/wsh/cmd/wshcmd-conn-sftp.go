@@ -0,0 +1,177 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var connSftpRecursive bool
+var connSftpResume bool
+var connSftpPreserve bool
+var connSftpVerify bool
+var connSftpSudo bool
+
+var connPutCmd = &cobra.Command{
+	Use:     "put CONNECTION LOCAL REMOTE",
+	Short:   "upload a file (or a directory with -r) to a connection over sftp",
+	Long:    "Upload a file from the machine running Wave to an ssh connection (a saved connection or a connection name) over sftp.  Like scp, a remote path that is an existing directory gets the file inside of it.  --resume continues the files that were partially uploaded, --preserve keeps the permissions and modification times.",
+	Example: "  wsh conn put prod-db-3 ./dump.sql /tmp\n  wsh conn put -r --resume user@host ~/photos ~/backup\n  wsh conn put --sudo web-1 ./nginx.conf /etc/nginx/nginx.conf",
+	Args:    cobra.ExactArgs(3),
+	RunE:    activityWrap("conn", connPutRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connGetCmd = &cobra.Command{
+	Use:     "get CONNECTION REMOTE [LOCAL]",
+	Short:   "download a file (or a directory with -r) from a connection over sftp",
+	Long:    "Download a file from an ssh connection (a saved connection or a connection name) over sftp to the machine running Wave, into the current directory if LOCAL is not given.  --resume continues the files that were partially downloaded, --preserve keeps the permissions and modification times.",
+	Example: "  wsh conn get prod-db-3 /var/log/syslog\n  wsh conn get -rp user@host ~/project ./project-copy",
+	Args:    cobra.RangeArgs(2, 3),
+	RunE:    activityWrap("conn", connGetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connCpCmd = &cobra.Command{
+	Use:     "cp [CONNECTION:]SRC [CONNECTION:]DEST",
+	Short:   "copy a file (or a directory with -r) between two connections, or a connection and this machine",
+	Long:    "Copy a file between two ssh connections (saved connections or connection names), or between a connection and the machine running Wave.  A remote path is CONNECTION:PATH, where the path starts with \"/\" or \"~\", anything else is a local path.  Between two hosts the data goes through Wave over sftp.  A copy within one host runs cp on it, unless --resume or --verify is given.  --verify compares the sha256 of each file after it is copied (a resumed file that does not match is copied again).",
+	Example: "  wsh conn cp prod-db-3:/var/backups/db.dump staging-db:~/restore/\n  wsh conn cp -r --resume --verify user@host:2222:~/data backup:/srv/data",
+	Args:    cobra.ExactArgs(2),
+	RunE:    activityWrap("conn", connCpRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{connPutCmd, connGetCmd, connCpCmd} {
+		cmd.Flags().BoolVarP(&connSftpRecursive, "recursive", "r", false, "copy directories with their contents")
+		cmd.Flags().BoolVar(&connSftpResume, "resume", false, "continue partially copied files")
+		cmd.Flags().BoolVarP(&connSftpPreserve, "preserve", "p", false, "keep the permissions and modification times")
+		cmd.Flags().BoolVar(&connSftpVerify, "verify", false, "compare the sha256 of each file after it is copied")
+		cmd.Flags().BoolVar(&connSftpSudo, "sudo", false, "read and write the remote files as root (with sudo)")
+		connCmd.AddCommand(cmd)
+	}
+}
+
+func connPutRun(cmd *cobra.Command, args []string) error {
+	localPath, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	data := makeSftpTransferData(args[0], localPath, args[2])
+	ch := wshclient.SftpUploadCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: TimeoutYear})
+	return showSftpTransfer(ch, "uploading "+args[1])
+}
+
+func connGetRun(cmd *cobra.Command, args []string) error {
+	localPath := "."
+	if len(args) > 2 {
+		localPath = args[2]
+	}
+	localPath, err := filepath.Abs(localPath)
+	if err != nil {
+		return err
+	}
+	data := makeSftpTransferData(args[0], localPath, args[1])
+	ch := wshclient.SftpDownloadCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: TimeoutYear})
+	return showSftpTransfer(ch, "downloading "+args[1])
+}
+
+func makeSftpTransferData(connection string, localPath string, remotePath string) wshrpc.CommandSftpTransferData {
+	return wshrpc.CommandSftpTransferData{
+		Connection: connection,
+		LocalPath:  localPath,
+		RemotePath: remotePath,
+		Recursive:  connSftpRecursive,
+		Resume:     connSftpResume,
+		Preserve:   connSftpPreserve,
+		Verify:     connSftpVerify,
+		Sudo:       connSftpSudo,
+	}
+}
+
+// splits "CONNECTION:PATH" (the path starts with "/" or "~", a connection name can have a port), a local path is
+// made absolute
+func parseCopyArg(arg string) (string, string, error) {
+	for i := 1; i < len(arg)-1; i++ {
+		if arg[i] == ':' && (arg[i+1] == '/' || arg[i+1] == '~') {
+			return arg[:i], arg[i+1:], nil
+		}
+	}
+	localPath, err := filepath.Abs(arg)
+	if err != nil {
+		return "", "", err
+	}
+	return "", localPath, nil
+}
+
+func connCpRun(cmd *cobra.Command, args []string) error {
+	srcConn, srcPath, err := parseCopyArg(args[0])
+	if err != nil {
+		return err
+	}
+	destConn, destPath, err := parseCopyArg(args[1])
+	if err != nil {
+		return err
+	}
+	if srcConn == "" && destConn == "" {
+		return fmt.Errorf("at least one side of the copy must be CONNECTION:PATH")
+	}
+	data := wshrpc.CommandSftpCopyData{
+		SrcConnection:  srcConn,
+		SrcPath:        srcPath,
+		DestConnection: destConn,
+		DestPath:       destPath,
+		Recursive:      connSftpRecursive,
+		Resume:         connSftpResume,
+		Preserve:       connSftpPreserve,
+		Verify:         connSftpVerify,
+		Sudo:           connSftpSudo,
+	}
+	ch := wshclient.SftpCopyCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: TimeoutYear})
+	return showSftpTransfer(ch, "copying "+args[0])
+}
+
+func showSftpTransfer(ch <-chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress], desc string) error {
+	var progress *transferProgress
+	var last wshrpc.SftpTransferProgress
+	for respUnion := range ch {
+		if respUnion.Error != nil {
+			return fmt.Errorf("%s: %w", desc, respUnion.Error)
+		}
+		status := respUnion.Response
+		last = status
+		if progress == nil || progress.name != status.Path {
+			progress = makeTransferProgress(status.Path, status.Size)
+		}
+		if !status.FileDone {
+			progress.update(status.Offset, false)
+			continue
+		}
+		switch {
+		case status.Direct:
+			WriteStdout("%s -> %s (copied on the host)\n", status.Path, status.DestPath)
+		case status.Skipped:
+			WriteStderr("%s: skipped, already complete\n", status.Path)
+		case status.ResumedFrom > 0:
+			progress.update(status.Offset, true)
+			WriteStdout("%s -> %s (resumed at %s)\n", status.Path, status.DestPath, formatStorageBytes(status.ResumedFrom))
+		default:
+			progress.update(status.Offset, true)
+			WriteStdout("%s -> %s\n", status.Path, status.DestPath)
+		}
+	}
+	if last.Checksum != "" && last.FilesTotal == 1 {
+		WriteStdout("sha256 %s\n", last.Checksum)
+	}
+	if last.FilesTotal > 1 {
+		WriteStdout("%d files, %s\n", last.FilesDone, formatStorageBytes(last.BytesDone))
+	}
+	return nil
+}
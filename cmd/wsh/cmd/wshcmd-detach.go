@@ -0,0 +1,110 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var detachCmd = &cobra.Command{
+	Use:     "detach",
+	Short:   "detach a block from its tab (its controller keeps running in the background)",
+	Args:    cobra.NoArgs,
+	RunE:    detachRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var attachCmd = &cobra.Command{
+	Use:     "attach blockid",
+	Short:   "attach a detached block to the current tab",
+	Args:    cobra.ExactArgs(1),
+	RunE:    attachRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var sessionsCmd = &cobra.Command{
+	Use:     "sessions",
+	Short:   "list detached blocks",
+	Args:    cobra.NoArgs,
+	RunE:    sessionsRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	rootCmd.AddCommand(detachCmd)
+	rootCmd.AddCommand(attachCmd)
+	rootCmd.AddCommand(sessionsCmd)
+}
+
+func detachRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("detach", rtnErr == nil)
+	}()
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	if fullORef.OType != "block" {
+		return fmt.Errorf("object reference is not a block")
+	}
+	err = wshclient.DetachBlockCommand(RpcClient, wshrpc.CommandDetachBlockData{BlockId: fullORef.OID}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("detach block failed: %v", err)
+	}
+	WriteStdout("block detached, use \"wsh attach %s\" to attach it again\n", fullORef.OID)
+	return nil
+}
+
+func attachRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("attach", rtnErr == nil)
+	}()
+	if RpcContext.TabId == "" {
+		return fmt.Errorf("attach must be run from a terminal block (no tab)")
+	}
+	data := wshrpc.CommandAttachBlockData{
+		BlockId: args[0],
+		TabId:   RpcContext.TabId,
+	}
+	err := wshclient.AttachBlockCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("attach block failed: %v", err)
+	}
+	return nil
+}
+
+func sessionsRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("sessions", rtnErr == nil)
+	}()
+	sessions, err := wshclient.ListDetachedBlocksCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing detached blocks: %v", err)
+	}
+	if len(sessions) == 0 {
+		WriteStdout("no detached blocks\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "BLOCKID\tCONTROLLER\tCONNECTION\tSTATUS\tCWD\n")
+	for _, session := range sessions {
+		connName := session.ConnName
+		if connName == "" {
+			connName = "local"
+		}
+		status := session.ShellProcStatus
+		if status == "" {
+			status = "stopped"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", session.BlockId, session.Controller, connName, status, session.Cwd)
+	}
+	writer.Flush()
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var envSnapshotCmd = &cobra.Command{
+	Use:     "envsnapshot",
+	Short:   "save the current shell's environment so new blocks can start with it (see wsh term --envfrom)",
+	Args:    cobra.NoArgs,
+	RunE:    envSnapshotRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	rootCmd.AddCommand(envSnapshotCmd)
+}
+
+// wsh inherits the exported environment of the shell it is run from
+func envSnapshotRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("envsnapshot", rtnErr == nil)
+	}()
+	if RpcContext.BlockId == "" {
+		return fmt.Errorf("envsnapshot must be run from a terminal block")
+	}
+	envMap := make(map[string]string)
+	for _, envVar := range os.Environ() {
+		name, value, ok := strings.Cut(envVar, "=")
+		if ok && name != "" {
+			envMap[name] = value
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting current directory: %w", err)
+	}
+	data := wshrpc.CommandSaveEnvSnapshotData{
+		BlockId: RpcContext.BlockId,
+		Cwd:     cwd,
+		Env:     envMap,
+	}
+	err = wshclient.SaveEnvSnapshotCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("saving env snapshot: %w", err)
+	}
+	WriteStdout("env snapshot saved (%d variables), use \"wsh term --envfrom %s\" to open a terminal with it\n", len(envMap), RpcContext.BlockId)
+	return nil
+}
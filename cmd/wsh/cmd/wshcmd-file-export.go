@@ -0,0 +1,77 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var fileExportCmd = &cobra.Command{
+	Use:   "export [wavefile-uri] [local-dir]",
+	Short: "export a wave file (or all the files of a block) into a local directory",
+	Long:  "Export a wave file into a local directory (on the machine running Wave), or all the files of a block if the uri has no file name.  The local files have the names of the wave files (with the characters that can not be in a file name replaced).  If a local file with the name exists, the export gets a new name (\"name (1).ext\") unless --collision is overwrite or skip.",
+	Example: "  wsh file export wavefile://block/cast:1 ~/Downloads\n" +
+		"  wsh file export --collision overwrite wavefile://5d2b1c9e-.../ .",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    activityWrap("file", fileExportRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	fileExportCmd.Flags().String("collision", "rename", "what to do when a local file exists: rename, overwrite or skip")
+	fileCmd.AddCommand(fileExportCmd)
+}
+
+func fileExportRun(cmd *cobra.Command, args []string) error {
+	path, err := fixRelativePaths(args[0])
+	if err != nil {
+		return err
+	}
+	localDir := "."
+	if len(args) > 1 {
+		localDir = args[1]
+	}
+	localDir, err = filepath.Abs(localDir)
+	if err != nil {
+		return err
+	}
+	collision, _ := cmd.Flags().GetString("collision")
+	data := wshrpc.CommandFileExportData{Path: path, Dir: localDir, Collision: collision}
+	ch := wshclient.FileExportCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: TimeoutYear})
+	var numErrors int
+	var progress *transferProgress
+	for respUnion := range ch {
+		err = convertNotFoundErr(respUnion.Error)
+		if err != nil {
+			return fmt.Errorf("exporting %s: %w", args[0], err)
+		}
+		status := respUnion.Response
+		if progress == nil || progress.name != status.Name {
+			progress = makeTransferProgress(status.Name, status.Size)
+		}
+		if !status.Done && status.Error == "" {
+			progress.update(status.Offset, false)
+			continue
+		}
+		switch {
+		case status.Error != "":
+			numErrors++
+			WriteStderr("%s: not exported: %s\n", status.Name, status.Error)
+		case status.Skipped:
+			WriteStderr("%s: skipped, the local file exists\n", status.Name)
+		default:
+			progress.update(status.Offset, true)
+			WriteStdout("%s -> %s\n", status.Name, status.LocalPath)
+		}
+	}
+	if numErrors > 0 {
+		return fmt.Errorf("%d files were not exported", numErrors)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var fileImportCmd = &cobra.Command{
+	Use:     "import [local-file]",
+	Short:   "import a local file into a block file (of this block, or the block given with -b)",
+	Long:    "Import a local file (on the machine running Wave) into a block file.  The file is copied, or with --ref it is referenced: it is read from the local file, as long as the local file does not change.  The sha256, size and mime type of the local file are kept in the meta of the block file, and importing the same content into a block again does not copy it again.",
+	Example: "  wsh file import ./report.pdf\n  wsh file import --ref --name video.mp4 ~/Videos/demo.mp4",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("file", fileImportRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	fileImportCmd.Flags().String("name", "", "the name of the block file (the name of the local file if not given)")
+	fileImportCmd.Flags().Bool("ref", false, "reference the local file instead of copying it")
+	fileImportCmd.Flags().Bool("overwrite", false, "replace a block file with the name")
+	fileCmd.AddCommand(fileImportCmd)
+}
+
+func fileImportRun(cmd *cobra.Command, args []string) error {
+	localPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	oref, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	name, _ := cmd.Flags().GetString("name")
+	reference, _ := cmd.Flags().GetBool("ref")
+	overwrite, _ := cmd.Flags().GetBool("overwrite")
+	data := wshrpc.CommandFileImportData{
+		BlockId:   oref.OID,
+		Path:      localPath,
+		Name:      name,
+		Reference: reference,
+		Overwrite: overwrite,
+	}
+	info, err := wshclient.FileImportCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: TimeoutYear})
+	if err != nil {
+		return fmt.Errorf("importing %s: %w", args[0], err)
+	}
+	WriteStdout("%s (%s)\n", info.Path, formatStorageBytes(info.Size))
+	return nil
+}
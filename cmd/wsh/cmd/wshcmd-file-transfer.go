@@ -0,0 +1,196 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"golang.org/x/term"
+)
+
+const fileTransferChunkSize = 256 * 1024
+const fileTransferProgressInterval = 250 * time.Millisecond
+
+var fileUploadCmd = &cobra.Command{
+	Use:     "upload [local-file] [wavefile-uri]",
+	Short:   "upload a large local file into a wave file, in chunks",
+	Long:    "Upload a local file into a wave file in chunks (there is no size limit).  The wave file is replaced when the whole file is uploaded.  Running the same upload again resumes it where it stopped (if the local file has not changed).",
+	Example: "  wsh file upload ./dump.sql wavefile://block/dump.sql",
+	Args:    cobra.ExactArgs(2),
+	RunE:    activityWrap("file", fileUploadRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var fileDownloadCmd = &cobra.Command{
+	Use:     "download [wavefile-uri] [local-file]",
+	Short:   "download a large wave file into a local file, in chunks",
+	Long:    "Download a wave file into a local file in chunks (there is no size limit).  With --resume, a partial local file is continued from its size.",
+	Example: "  wsh file download wavefile://block/dump.sql ./dump.sql\n  wsh file download --resume wavefile://block/dump.sql ./dump.sql",
+	Args:    cobra.ExactArgs(2),
+	RunE:    activityWrap("file", fileDownloadRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	fileDownloadCmd.Flags().Bool("resume", false, "continue a partial download (appends to the local file)")
+	fileCmd.AddCommand(fileUploadCmd)
+	fileCmd.AddCommand(fileDownloadCmd)
+}
+
+// prints the progress of a transfer to stderr (when it is a terminal)
+type transferProgress struct {
+	name   string
+	size   int64
+	show   bool
+	lastTs time.Time
+}
+
+func makeTransferProgress(name string, size int64) *transferProgress {
+	return &transferProgress{name: name, size: size, show: term.IsTerminal(int(os.Stderr.Fd()))}
+}
+
+func (p *transferProgress) update(offset int64, done bool) {
+	if !p.show || (!done && time.Since(p.lastTs) < fileTransferProgressInterval) {
+		return
+	}
+	p.lastTs = time.Now()
+	percent := 100
+	if p.size > 0 {
+		percent = int(offset * 100 / p.size)
+	}
+	fmt.Fprintf(os.Stderr, "\r%s: %3d%% (%s / %s)", p.name, percent, formatStorageBytes(offset), formatStorageBytes(p.size))
+	if done {
+		fmt.Fprintf(os.Stderr, "\n")
+	}
+}
+
+// the same local file (path, size and mtime) uploaded to the same uri gets the same id, so the upload is resumed
+func makeUploadTransferId(localPath string, info os.FileInfo, uri string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%d", localPath, uri, info.Size(), info.ModTime().UnixNano())))
+	return hex.EncodeToString(sum[:16])
+}
+
+func fileUploadRun(cmd *cobra.Command, args []string) error {
+	localPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	path, err := fixRelativePaths(args[1])
+	if err != nil {
+		return err
+	}
+	fd, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	info, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", args[0])
+	}
+	size := info.Size()
+	transferId := makeUploadTransferId(localPath, info, path)
+	rpcOpts := &wshrpc.RpcOpts{Timeout: fileTimeout}
+	status, err := wshclient.FileTransferStatusCommand(RpcClient, wshrpc.CommandFileTransferData{Path: path, TransferId: transferId}, rpcOpts)
+	if err != nil {
+		return fmt.Errorf("getting upload status: %w", err)
+	}
+	offset := status.Offset
+	if offset > 0 {
+		fmt.Fprintf(os.Stderr, "resuming upload of %s at %s\n", args[0], formatStorageBytes(offset))
+	}
+	progress := makeTransferProgress(filepath.Base(localPath), size)
+	buf := make([]byte, fileTransferChunkSize)
+	for {
+		_, err = fd.Seek(offset, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		n, err := io.ReadFull(fd, buf[:min(int64(len(buf)), size-offset)])
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("reading %s: %w", args[0], err)
+		}
+		chunk := wshrpc.CommandFileUploadChunkData{
+			Path:       path,
+			TransferId: transferId,
+			Size:       size,
+			Offset:     offset,
+			Data64:     base64.StdEncoding.EncodeToString(buf[:n]),
+		}
+		status, err = wshclient.FileUploadChunkCommand(RpcClient, chunk, rpcOpts)
+		if err != nil {
+			return fmt.Errorf("uploading chunk at %d: %w", offset, err)
+		}
+		// the server has the offset of the next chunk (it is not offset+n if the chunk was not written)
+		offset = status.Offset
+		progress.update(offset, status.Done)
+		if status.Done {
+			return nil
+		}
+	}
+}
+
+func fileDownloadRun(cmd *cobra.Command, args []string) error {
+	path, err := fixRelativePaths(args[0])
+	if err != nil {
+		return err
+	}
+	resume, _ := cmd.Flags().GetBool("resume")
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resume {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	fd, err := os.OpenFile(args[1], flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	var offset int64
+	if resume {
+		info, err := fd.Stat()
+		if err != nil {
+			return err
+		}
+		offset = info.Size()
+	}
+	data := wshrpc.CommandFileTransferData{Path: path, Offset: offset}
+	ch := wshclient.FileDownloadCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: TimeoutYear})
+	var progress *transferProgress
+	for respUnion := range ch {
+		err = convertNotFoundErr(respUnion.Error)
+		if err != nil {
+			return fmt.Errorf("downloading %s: %w", args[0], err)
+		}
+		chunk := respUnion.Response
+		if progress == nil {
+			if resume && chunk.Offset != offset {
+				return fmt.Errorf("cannot resume at %d, the file now starts at %d", offset, chunk.Offset)
+			}
+			progress = makeTransferProgress(filepath.Base(args[1]), chunk.Size)
+		}
+		dataBuf, err := base64.StdEncoding.DecodeString(chunk.Data64)
+		if err != nil {
+			return fmt.Errorf("decoding data: %w", err)
+		}
+		_, err = fd.Write(dataBuf)
+		if err != nil {
+			return err
+		}
+		progress.update(chunk.Offset+int64(len(dataBuf)), chunk.Offset+int64(len(dataBuf)) >= chunk.Size)
+	}
+	return nil
+}
@@ -9,8 +9,11 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/remote/connparse"
 	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/fsutil"
 	"github.com/wavetermdev/waveterm/pkg/util/fileutil"
@@ -96,6 +99,52 @@ func streamReadFromFile(ctx context.Context, fileData wshrpc.FileData, writer io
 	return fsutil.ReadFileStreamToWriter(ctx, ch, writer)
 }
 
+// writes the file and then the data appended to it until wsh is interrupted.  follows the file when it is
+// truncated or deleted and made again (like tail -F).
+func followFile(path string, writer io.Writer) error {
+	var offset int64
+	deleted := false
+	for {
+		watchData := wshrpc.CommandFileWatchData{Path: path, Offset: offset}
+		ch := wshclient.FileWatchCommand(RpcClient, watchData, &wshrpc.RpcOpts{Timeout: TimeoutYear})
+		for respUnion := range ch {
+			err := convertNotFoundErr(respUnion.Error)
+			if err == fs.ErrNotExist && deleted {
+				// wait for the file to be made again
+				time.Sleep(time.Second)
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("following file: %w", err)
+			}
+			event := respUnion.Response
+			switch event.Type {
+			case filestore.WatchEvent_Data:
+				data, err := base64.StdEncoding.DecodeString(event.Data64)
+				if err != nil {
+					return fmt.Errorf("decoding data: %w", err)
+				}
+				_, err = writer.Write(data)
+				if err != nil {
+					return err
+				}
+				offset = event.Offset + int64(len(data))
+			case filestore.WatchEvent_Truncate:
+				fmt.Fprintf(os.Stderr, "wsh: %s: file truncated\n", path)
+				offset = event.Offset
+			case filestore.WatchEvent_Rotate:
+				fmt.Fprintf(os.Stderr, "wsh: %s: file replaced, following the new file\n", path)
+				offset = event.Offset
+				deleted = false
+			case filestore.WatchEvent_Deleted:
+				fmt.Fprintf(os.Stderr, "wsh: %s: file deleted\n", path)
+				offset = 0
+				deleted = true
+			}
+		}
+	}
+}
+
 type fileListResult struct {
 	info *wshrpc.FileInfo
 	err  error
@@ -94,6 +94,7 @@ func init() {
 	fileListCmd.Flags().BoolP("files", "f", false, "list files only")
 
 	fileCmd.AddCommand(fileListCmd)
+	fileCatCmd.Flags().BoolP("follow", "f", false, "output data as it is appended to the file (wavefile:// only)")
 	fileCmd.AddCommand(fileCatCmd)
 	fileCmd.AddCommand(fileWriteCmd)
 	fileRmCmd.Flags().BoolP("recursive", "r", false, "remove directories recursively")
@@ -122,7 +123,7 @@ var fileCatCmd = &cobra.Command{
 	Use:     "cat [uri]",
 	Short:   "display contents of a file",
 	Long:    "Display the contents of a file." + UriHelpText,
-	Example: "  wsh file cat wsh://user@ec2/home/user/config.txt\n  wsh file cat wavefile://client/settings.json",
+	Example: "  wsh file cat wsh://user@ec2/home/user/config.txt\n  wsh file cat wavefile://client/settings.json\n  wsh file cat -f wavefile://[blockid]/app.log",
 	Args:    cobra.ExactArgs(1),
 	RunE:    activityWrap("file", fileCatRun),
 	PreRunE: preRunSetupRpcClient,
@@ -195,6 +196,10 @@ func fileCatRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	follow, _ := cmd.Flags().GetBool("follow")
+	if follow {
+		return followFile(path, os.Stdout)
+	}
 	fileData := wshrpc.FileData{
 		Info: &wshrpc.FileInfo{
 			Path: path}}
@@ -0,0 +1,182 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var fontListAll bool
+var fontSetFamily string
+var fontSetSize float64
+var fontSetLineHeight float64
+var fontSetLigatures bool
+
+var fontCmd = &cobra.Command{
+	Use:   "font",
+	Short: "manage the font of the terminal blocks",
+	Long:  "Commands to list the fonts and to set the font of a terminal block.  The font of a terminal is its term:fontfamily, term:fontsize, term:lineheight and term:ligatures settings, with the font set for the block (wsh font set) over them.  The values are checked when they are set: the font family must be installed (or bundled with wave), and the size and line height must be in range.",
+}
+
+var fontListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the font families (bundled with wave and installed, --all also lists the generic css families)",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("font", fontListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var fontGetCmd = &cobra.Command{
+	Use:     "get",
+	Short:   "print the font of a block (the current one, or -b), and the font set for it",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("font", fontGetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var fontSetCmd = &cobra.Command{
+	Use:     "set",
+	Short:   "set the font of a block (the current one, or -b), over its settings",
+	Example: "  wsh font set --family \"Fira Code\" --ligatures\n  wsh font set --size 15 --lineheight 1.2 -b 2",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("font", fontSetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var fontClearCmd = &cobra.Command{
+	Use:     "clear",
+	Short:   "remove the font set for a block (its settings are used again)",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("font", fontClearRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	fontListCmd.Flags().BoolVar(&fontListAll, "all", false, "also list the generic css families")
+	fontSetCmd.Flags().StringVar(&fontSetFamily, "family", "", "the font family (a css font-family list)")
+	fontSetCmd.Flags().Float64Var(&fontSetSize, "size", 0, "the font size (4 to 64)")
+	fontSetCmd.Flags().Float64Var(&fontSetLineHeight, "lineheight", 0, "the line height, relative to the font size (0.5 to 3)")
+	fontSetCmd.Flags().BoolVar(&fontSetLigatures, "ligatures", false, "render ligatures (--ligatures=false turns them off)")
+	rootCmd.AddCommand(fontCmd)
+	fontCmd.AddCommand(fontListCmd)
+	fontCmd.AddCommand(fontGetCmd)
+	fontCmd.AddCommand(fontSetCmd)
+	fontCmd.AddCommand(fontClearCmd)
+}
+
+func fontListRun(cmd *cobra.Command, args []string) error {
+	fonts, err := wshclient.FontListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("listing fonts: %w", err)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "FAMILY\tSOURCE\n")
+	for _, font := range fonts {
+		if font.Source == "generic" && !fontListAll {
+			continue
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", font.Name, font.Source)
+	}
+	writer.Flush()
+	return nil
+}
+
+func formatTermFont(font *waveobj.TermFontOpts) string {
+	var parts []string
+	if font.FontFamily != "" {
+		parts = append(parts, fmt.Sprintf("family=%q", font.FontFamily))
+	}
+	if font.FontSize != 0 {
+		parts = append(parts, fmt.Sprintf("size=%v", font.FontSize))
+	}
+	if font.LineHeight != 0 {
+		parts = append(parts, fmt.Sprintf("lineheight=%v", font.LineHeight))
+	}
+	if font.Ligatures != nil {
+		parts = append(parts, fmt.Sprintf("ligatures=%v", *font.Ligatures))
+	}
+	return strings.Join(parts, " ")
+}
+
+func getTermFont() (string, *wshrpc.TermFontInfo, error) {
+	oref, err := resolveBlockArg()
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := wshclient.TermFontGetCommand(RpcClient, wshrpc.CommandTermFontData{BlockId: oref.OID}, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return "", nil, fmt.Errorf("getting font: %w", err)
+	}
+	return oref.OID, info, nil
+}
+
+func fontGetRun(cmd *cobra.Command, args []string) error {
+	_, info, err := getTermFont()
+	if err != nil {
+		return err
+	}
+	WriteStdout("%s\n", formatTermFont(&info.Font))
+	if info.BlockSet != nil {
+		WriteStdout("set for the block: %s\n", formatTermFont(info.BlockSet))
+	}
+	return nil
+}
+
+func fontSetRun(cmd *cobra.Command, args []string) error {
+	blockId, info, err := getTermFont()
+	if err != nil {
+		return err
+	}
+	var font waveobj.TermFontOpts
+	if info.BlockSet != nil {
+		font = *info.BlockSet
+	}
+	changed := false
+	if cmd.Flags().Changed("family") {
+		font.FontFamily = fontSetFamily
+		changed = true
+	}
+	if cmd.Flags().Changed("size") {
+		font.FontSize = fontSetSize
+		changed = true
+	}
+	if cmd.Flags().Changed("lineheight") {
+		font.LineHeight = fontSetLineHeight
+		changed = true
+	}
+	if cmd.Flags().Changed("ligatures") {
+		font.Ligatures = &fontSetLigatures
+		changed = true
+	}
+	if !changed {
+		return fmt.Errorf("set --family, --size, --lineheight or --ligatures")
+	}
+	err = wshclient.TermFontSetCommand(RpcClient, wshrpc.CommandTermFontSetData{BlockId: blockId, Font: &font}, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return err
+	}
+	WriteStdout("font of the block set (%s)\n", formatTermFont(&font))
+	return nil
+}
+
+func fontClearRun(cmd *cobra.Command, args []string) error {
+	oref, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	err = wshclient.TermFontSetCommand(RpcClient, wshrpc.CommandTermFontSetData{BlockId: oref.OID}, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("removing font: %w", err)
+	}
+	WriteStdout("font of the block removed\n")
+	return nil
+}
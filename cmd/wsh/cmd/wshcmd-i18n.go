@@ -0,0 +1,91 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var i18nShowLocale string
+
+var i18nCmd = &cobra.Command{
+	Use:   "i18n",
+	Short: "show the translations of the text wave generates",
+	Long:  "Commands to show the catalogs of the text wave generates (errors, notifications, and action titles), in the locale of app:locale (or of the os).  A string is looked up in the locale, then in its parent locales (pt-BR, then pt), then in en.  Add a catalog (or change a translation) with a <locale>.json file of keys and texts in the i18n directory of the config directory, plugins add catalogs from their own i18n directory.",
+}
+
+var i18nListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the catalogs (by locale and source)",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("i18n", i18nListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var i18nShowCmd = &cobra.Command{
+	Use:     "show [KEY...]",
+	Short:   "print the strings of a locale (the current one, or --locale), or the given keys",
+	Example: "  wsh i18n show\n  wsh i18n show --locale de notify.recording.stopped.title",
+	RunE:    activityWrap("i18n", i18nShowRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	i18nShowCmd.Flags().StringVar(&i18nShowLocale, "locale", "", "the locale (e.g. de or pt-BR)")
+	rootCmd.AddCommand(i18nCmd)
+	i18nCmd.AddCommand(i18nListCmd)
+	i18nCmd.AddCommand(i18nShowCmd)
+}
+
+func i18nListRun(cmd *cobra.Command, args []string) error {
+	catalogs, err := wshclient.I18nListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing catalogs: %w", err)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "LOCALE\tSOURCE\tSTRINGS\n")
+	for _, catalog := range catalogs {
+		source := catalog.Source
+		if catalog.PluginId != "" {
+			source += ":" + catalog.PluginId
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\n", catalog.Locale, source, catalog.NumStrings)
+	}
+	writer.Flush()
+	return nil
+}
+
+func i18nShowRun(cmd *cobra.Command, args []string) error {
+	data, err := wshclient.I18nCatalogCommand(RpcClient, wshrpc.CommandI18nCatalogData{Locale: i18nShowLocale}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("getting catalog: %w", err)
+	}
+	keys := args
+	if len(keys) == 0 {
+		for key := range data.Strings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+	WriteStdout("locale %s (looked up in %s)\n", data.Locale, strings.Join(data.Chain, ", "))
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "KEY\tTEXT\n")
+	for _, key := range keys {
+		text, ok := data.Strings[key]
+		if !ok {
+			text = "(not translated)"
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", key, text)
+	}
+	writer.Flush()
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var keybindingsWorkspace bool
+var keybindingsListAll bool
+
+var keybindingsCmd = &cobra.Command{
+	Use:   "keybindings",
+	Short: "list and check the key bindings",
+	Long:  "Commands to list the key bindings and to find the action bound to keys.  The bindings are the default keymap with the keys set in the app:keybindings setting (action => keys, e.g. wsh settings set 'app:keybindings={\"block:magnify\":[\"Cmd:Shift:m\"]}'), with -w for the workspace of the block.",
+}
+
+var keybindingsListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the key bindings, and the conflicts and invalid keys in app:keybindings",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("keybindings", keybindingsListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var keybindingsResolveCmd = &cobra.Command{
+	Use:     "resolve KEYS",
+	Short:   "print the action bound to a key or a chord",
+	Example: "  wsh keybindings resolve Cmd:t\n  wsh keybindings resolve \"Ctrl:Shift:s ArrowUp\"",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("keybindings", keybindingsResolveRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	keybindingsCmd.PersistentFlags().BoolVarP(&keybindingsWorkspace, "workspace", "w", false, "the key bindings of the workspace of the block (-b)")
+	keybindingsListCmd.Flags().BoolVarP(&keybindingsListAll, "all", "a", false, "also list the actions without keys")
+	rootCmd.AddCommand(keybindingsCmd)
+	keybindingsCmd.AddCommand(keybindingsListCmd)
+	keybindingsCmd.AddCommand(keybindingsResolveCmd)
+}
+
+func keybindingsListRun(cmd *cobra.Command, args []string) error {
+	workspaceId, err := getBlockWorkspaceId(keybindingsWorkspace)
+	if err != nil {
+		return err
+	}
+	data, err := wshclient.KeyBindingsListCommand(RpcClient, wshrpc.CommandSettingsData{WorkspaceId: workspaceId}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing key bindings: %w", err)
+	}
+	keysByAction := make(map[string][]string)
+	for _, binding := range data.Bindings {
+		keysByAction[binding.Action] = append(keysByAction[binding.Action], binding.Keys)
+	}
+	for _, action := range data.Actions {
+		keys := keysByAction[action.Action]
+		if len(keys) == 0 && !keybindingsListAll {
+			continue
+		}
+		WriteStdout("%-24s %-32s %s\n", action.Action, strings.Join(keys, ", "), action.Title)
+	}
+	for _, conflict := range data.Conflicts {
+		WriteStdout("conflict: %q %s, bound to %s (not %s)\n", conflict.Keys, conflict.Reason, conflict.Action, strings.Join(conflict.Shadowed, ", "))
+	}
+	for _, errStr := range data.Errors {
+		WriteStdout("error: app:keybindings: %s\n", errStr)
+	}
+	return nil
+}
+
+func keybindingsResolveRun(cmd *cobra.Command, args []string) error {
+	workspaceId, err := getBlockWorkspaceId(keybindingsWorkspace)
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandKeyBindingResolveData{WorkspaceId: workspaceId, Keys: args[0]}
+	rtn, err := wshclient.KeyBindingResolveCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("resolving keys: %w", err)
+	}
+	switch {
+	case rtn.Action != "":
+		WriteStdout("%s\n", rtn.Action)
+	case rtn.Partial:
+		WriteStdout("%s starts a chord\n", rtn.Keys)
+	default:
+		return fmt.Errorf("%s is not bound", rtn.Keys)
+	}
+	return nil
+}
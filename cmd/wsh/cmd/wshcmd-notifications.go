@@ -0,0 +1,117 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var notificationsDismissAll bool
+
+var notificationsCmd = &cobra.Command{
+	Use:   "notifications",
+	Short: "list and dismiss notifications",
+	Long:  "Commands to manage the notifications sent with wsh notify, the automation api, and by wave itself.  Notifications are kept until they are dismissed.",
+}
+
+var notificationsListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the notifications that have not been dismissed",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("notifications", notificationsListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var notificationsDismissCmd = &cobra.Command{
+	Use:     "dismiss [ID]",
+	Short:   "dismiss a notification (or all of them with --all)",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("notifications", notificationsDismissRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	notificationsDismissCmd.Flags().BoolVarP(&notificationsDismissAll, "all", "a", false, "dismiss all notifications")
+	rootCmd.AddCommand(notificationsCmd)
+	notificationsCmd.AddCommand(notificationsListCmd)
+	notificationsCmd.AddCommand(notificationsDismissCmd)
+}
+
+func notificationsListRun(cmd *cobra.Command, args []string) error {
+	notifs, err := wshclient.NotificationListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing notifications: %w", err)
+	}
+	if len(notifs) == 0 {
+		WriteStdout("no notifications\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID\tTIME\tTYPE\tSOURCE\tCOUNT\tTITLE\tMESSAGE\n")
+	for _, notif := range notifs {
+		notifType := notif.Type
+		if !notif.Delivered {
+			notifType += " (held)"
+		}
+		ts := time.UnixMilli(notif.UpdatedTs).Format("2006-01-02 15:04:05")
+		message := strings.ReplaceAll(notif.Message, "\n", " ")
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", notif.OID[:8], ts, notifType, notif.Source, notif.Count, notif.Title, message)
+	}
+	writer.Flush()
+	return nil
+}
+
+// accepts a full id or a unique prefix of one
+func resolveNotificationId(idArg string) (string, error) {
+	notifs, err := wshclient.NotificationListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return "", fmt.Errorf("listing notifications: %w", err)
+	}
+	var found string
+	for _, notif := range notifs {
+		if notif.OID == idArg {
+			return notif.OID, nil
+		}
+		if strings.HasPrefix(notif.OID, idArg) {
+			if found != "" {
+				return "", fmt.Errorf("notification id %q is ambiguous", idArg)
+			}
+			found = notif.OID
+		}
+	}
+	if found == "" {
+		return "", fmt.Errorf("notification %q not found", idArg)
+	}
+	return found, nil
+}
+
+func notificationsDismissRun(cmd *cobra.Command, args []string) error {
+	if notificationsDismissAll == (len(args) == 1) {
+		return fmt.Errorf("pass a notification id or --all")
+	}
+	data := wshrpc.CommandNotificationDismissData{All: notificationsDismissAll}
+	if !notificationsDismissAll {
+		notifId, err := resolveNotificationId(args[0])
+		if err != nil {
+			return err
+		}
+		data.Id = notifId
+	}
+	count, err := wshclient.NotificationDismissCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("dismissing notifications: %w", err)
+	}
+	if notificationsDismissAll {
+		WriteStdout("dismissed %d notification(s)\n", count)
+	}
+	return nil
+}
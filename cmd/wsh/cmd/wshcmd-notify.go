@@ -8,11 +8,13 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
-	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 )
 
 var notifyTitle string
 var notifySilent bool
+var notifyType string
+var notifyKey string
 
 var setNotifyCmd = &cobra.Command{
 	Use:     "notify <message> [-t <title>] [-s]",
@@ -25,6 +27,8 @@ var setNotifyCmd = &cobra.Command{
 func init() {
 	setNotifyCmd.Flags().StringVarP(&notifyTitle, "title", "t", "Wsh Notify", "the notification title")
 	setNotifyCmd.Flags().BoolVarP(&notifySilent, "silent", "s", false, "whether or not the notification sound is silenced")
+	setNotifyCmd.Flags().StringVar(&notifyType, "type", "info", "the notification type (info, warning, or error)")
+	setNotifyCmd.Flags().StringVar(&notifyKey, "key", "", "notifications with the same key are only shown once until they are dismissed")
 	rootCmd.AddCommand(setNotifyCmd)
 }
 
@@ -32,13 +36,17 @@ func notifyRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	defer func() {
 		sendActivity("notify", rtnErr == nil)
 	}()
-	message := args[0]
-	notificationOptions := &wshrpc.WaveNotificationOptions{
-		Title:  notifyTitle,
-		Body:   message,
-		Silent: notifySilent,
+	data := wshrpc.CommandNotificationSendData{
+		Title:    notifyTitle,
+		Message:  args[0],
+		Type:     notifyType,
+		Source:   "wsh",
+		BlockId:  RpcContext.BlockId,
+		DedupKey: notifyKey,
+		Desktop:  true,
+		Silent:   notifySilent,
 	}
-	_, err := RpcClient.SendRpcRequest(wshrpc.Command_Notify, notificationOptions, &wshrpc.RpcOpts{Timeout: 2000, Route: wshutil.ElectronRoute})
+	_, err := wshclient.NotificationSendCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
 	if err != nil {
 		return fmt.Errorf("sending notification: %w", err)
 	}
@@ -0,0 +1,70 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var paletteTypes []string
+var paletteLimit int
+
+var paletteCmd = &cobra.Command{
+	Use:   "palette [QUERY]",
+	Short: "search the command palette",
+	Long: "Fuzzy searches the workspaces, tabs, blocks, bookmarks, connections, settings, and the actions of this block " +
+		"(or the block given with -b), like the command palette does.  With no query all of the items are listed.",
+	Args:    cobra.ArbitraryArgs,
+	RunE:    activityWrap("palette", paletteRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	paletteCmd.Flags().StringArrayVarP(&paletteTypes, "type", "t", nil, "only search this type of item (action, tab, block, workspace, bookmark, connection, setting), can be repeated")
+	paletteCmd.Flags().IntVarP(&paletteLimit, "limit", "n", 20, "the maximum number of items to show")
+	rootCmd.AddCommand(paletteCmd)
+}
+
+func paletteRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandPaletteSearchData{
+		Query: strings.Join(args, " "),
+		Types: paletteTypes,
+		Limit: paletteLimit,
+	}
+	if blockArg != "" || RpcContext.BlockId != "" {
+		oref, err := resolveBlockArg()
+		if err != nil {
+			return err
+		}
+		data.ORef = *oref
+	}
+	items, err := wshclient.PaletteSearchCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("searching: %w", err)
+	}
+	if len(items) == 0 {
+		WriteStdout("no matches\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "TYPE\tTITLE\tSUBTEXT\tID\n")
+	for _, item := range items {
+		itemId := item.ItemId
+		if item.ActionId != "" {
+			itemId = item.ActionId
+		} else if item.Value != "" {
+			itemId = item.Value
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", item.ItemType, item.Title, item.SubText, itemId)
+	}
+	writer.Flush()
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var paneNoSwitch bool
+
+var paneCmd = &cobra.Command{
+	Use:   "pane",
+	Short: "run commands in panes inside a terminal block",
+	Long:  "Commands to run extra commands (or shells) in a terminal block next to its shell, on the block's connection.  The block shows one pane at a time.",
+}
+
+var paneNewCmd = &cobra.Command{
+	Use:     "new [CMD]",
+	Short:   "start a pane running CMD (a shell if CMD is not given) and switch to it",
+	RunE:    activityWrap("pane", paneNewRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var paneListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the panes of a terminal block",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("pane", paneListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var paneSwitchCmd = &cobra.Command{
+	Use:     "switch [PANEID]",
+	Short:   "show a pane in the block (the block's shell if PANEID is not given)",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("pane", paneSwitchRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var paneKillCmd = &cobra.Command{
+	Use:     "kill PANEID",
+	Short:   "kill the process running in a pane",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("pane", paneKillRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	paneNewCmd.Flags().BoolVar(&paneNoSwitch, "noswitch", false, "start the pane without switching to it")
+	rootCmd.AddCommand(paneCmd)
+	paneCmd.AddCommand(paneNewCmd)
+	paneCmd.AddCommand(paneListCmd)
+	paneCmd.AddCommand(paneSwitchCmd)
+	paneCmd.AddCommand(paneKillCmd)
+}
+
+func paneNewRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandTermPaneCreateData{
+		BlockId: fullORef.OID,
+		Cmd:     strings.Join(args, " "),
+		Switch:  !paneNoSwitch,
+	}
+	info, err := wshclient.TermPaneCreateCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("creating pane: %w", err)
+	}
+	WriteStdout("pane %s started\n", info.PaneId)
+	return nil
+}
+
+func paneListRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	panes, err := wshclient.TermPaneListCommand(RpcClient, wshrpc.CommandTermPaneData{BlockId: fullORef.OID}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing panes: %w", err)
+	}
+	if len(panes) == 0 {
+		WriteStdout("no panes\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "PANE\tSTARTED\tCMD\n")
+	for _, pane := range panes {
+		paneId := pane.PaneId
+		if pane.Active {
+			paneId += " (active)"
+		}
+		cmdStr := pane.Cmd
+		if cmdStr == "" {
+			cmdStr = "(shell)"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", paneId, time.UnixMilli(pane.StartTs).Format(time.DateTime), cmdStr)
+	}
+	writer.Flush()
+	return nil
+}
+
+func paneSwitchRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandTermPaneData{BlockId: fullORef.OID}
+	if len(args) > 0 {
+		data.PaneId = args[0]
+	}
+	err = wshclient.TermPaneSwitchCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("switching pane: %w", err)
+	}
+	return nil
+}
+
+func paneKillRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	err = wshclient.TermPaneKillCommand(RpcClient, wshrpc.CommandTermPaneData{BlockId: fullORef.OID, PaneId: args[0]}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("killing pane: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/util/wavefileutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var playMagnified bool
+
+var playCmd = &cobra.Command{
+	Use:     "play FILE|RECORDING",
+	Short:   "play an asciicast file, or a recording of a block (see wsh record), in a player block",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("play", playRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	playCmd.Flags().BoolVarP(&playMagnified, "magnified", "m", false, "open view in magnified mode")
+	rootCmd.AddCommand(playCmd)
+}
+
+// recordings (cast:[timestamp]) belong to the block given with -b (the current block by default), anything
+// else is a file on this machine
+func getPlaySrc(arg string) (string, error) {
+	if strings.HasPrefix(arg, wavebase.BlockFile_CastPrefix) {
+		fullORef, err := resolveBlockArg()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(wavefileutil.WaveFilePathPattern, fullORef.OID, arg), nil
+	}
+	if RpcContext.Conn != "" {
+		return "", fmt.Errorf("files on remote connections cannot be played, copy the file to the local machine first")
+	}
+	filePath, err := wavebase.ExpandHomeDir(arg)
+	if err != nil {
+		return "", err
+	}
+	filePath, err = filepath.Abs(filePath)
+	if err != nil {
+		return "", fmt.Errorf("getting absolute path: %w", err)
+	}
+	return filePath, nil
+}
+
+func playRun(cmd *cobra.Command, args []string) error {
+	src, err := getPlaySrc(args[0])
+	if err != nil {
+		return err
+	}
+	createBlockData := wshrpc.CommandCreateBlockData{
+		BlockDef: &waveobj.BlockDef{
+			Meta: map[string]any{
+				waveobj.MetaKey_View:      "player",
+				waveobj.MetaKey_PlayerSrc: src,
+			},
+		},
+		Magnified: playMagnified,
+	}
+	oref, err := wshclient.CreateBlockCommand(RpcClient, createBlockData, nil)
+	if err != nil {
+		return fmt.Errorf("creating player block: %w", err)
+	}
+	WriteStdout("player block created: %s\n", oref)
+	return nil
+}
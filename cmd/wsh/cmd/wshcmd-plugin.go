@@ -0,0 +1,154 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var pluginTokenName string
+var pluginTokenCaps []string
+var pluginTokenScope []string
+var pluginTokenHours float64
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "manage plugins",
+	Long:  "Commands to manage plugins.  Plugins in the plugins dir of the config dir are launched by wave, external plugins (processes wave does not launch) connect with a token from \"wsh plugin token\".",
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the plugins",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("plugin", pluginListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var pluginTokenCmd = &cobra.Command{
+	Use:   "token PLUGINID",
+	Short: "issue a token for an external plugin (revokes its previous token)",
+	Long: "Issues a token for an external plugin and prints it.  The plugin connects to wave's socket with the token in WAVETERM_JWT.  " +
+		"It can only use the blocks of its views and controllers and the blocks, tabs, and workspaces given with --scope, " +
+		"and only call the rpcs its capabilities (--cap) allow: " + strings.Join(wshrpc.AllPluginCaps, ", ") + ".",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("plugin", pluginTokenRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var pluginRevokeCmd = &cobra.Command{
+	Use:     "revoke PLUGINID",
+	Short:   "revoke the token of an external plugin (and disconnect it)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("plugin", pluginRevokeRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var pluginCallCmd = &cobra.Command{
+	Use:     "call PLUGINID HANDLER [JSON]",
+	Short:   "call a handler of a plugin and print its result",
+	Args:    cobra.RangeArgs(2, 3),
+	RunE:    activityWrap("plugin", pluginCallRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	pluginTokenCmd.Flags().StringVarP(&pluginTokenName, "name", "n", "", "a name for the plugin")
+	pluginTokenCmd.Flags().StringArrayVarP(&pluginTokenCaps, "cap", "c", nil, "a capability to give the plugin, can be repeated")
+	pluginTokenCmd.Flags().StringArrayVarP(&pluginTokenScope, "scope", "s", nil, "a block, tab, or workspace the plugin can use (an id or oref, \"this\" for this block), can be repeated")
+	pluginTokenCmd.Flags().Float64Var(&pluginTokenHours, "hours", 24, "how long the token is valid for")
+	rootCmd.AddCommand(pluginCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+	pluginCmd.AddCommand(pluginTokenCmd)
+	pluginCmd.AddCommand(pluginRevokeCmd)
+	pluginCmd.AddCommand(pluginCallCmd)
+}
+
+func pluginListRun(cmd *cobra.Command, args []string) error {
+	plugins, err := wshclient.PluginListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing plugins: %w", err)
+	}
+	if len(plugins) == 0 {
+		WriteStdout("no plugins\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID\tNAME\tTYPE\tCONNECTED\tCAPS\tHANDLERS\tEXPIRES\n")
+	for _, plugin := range plugins {
+		pluginType := "launched"
+		expiresTs := "-"
+		if plugin.External {
+			pluginType = "external"
+			expiresTs = time.UnixMilli(plugin.ExpiresTs).Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%v\t%s\t%s\t%s\n", plugin.PluginId, plugin.Name, pluginType, plugin.Connected, strings.Join(plugin.Caps, ","), strings.Join(plugin.Handlers, ","), expiresTs)
+	}
+	writer.Flush()
+	return nil
+}
+
+func pluginTokenRun(cmd *cobra.Command, args []string) error {
+	if pluginTokenHours <= 0 {
+		return fmt.Errorf("--hours must be positive")
+	}
+	data := wshrpc.CommandPluginTokenIssueData{
+		PluginId: args[0],
+		Name:     pluginTokenName,
+		Caps:     pluginTokenCaps,
+		Hours:    pluginTokenHours,
+	}
+	for _, scopeArg := range pluginTokenScope {
+		oref, err := resolveSimpleId(scopeArg)
+		if err != nil {
+			return fmt.Errorf("resolving scope %q: %w", scopeArg, err)
+		}
+		data.Scope = append(data.Scope, oref.String())
+	}
+	rtn, err := wshclient.PluginTokenIssueCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("issuing token: %w", err)
+	}
+	WriteStdout("%s\n", rtn.Token)
+	WriteStderr("token for plugin %q expires %s (socket %s)\n", data.PluginId, time.UnixMilli(rtn.ExpiresTs).Format("2006-01-02 15:04:05"), rtn.SockName)
+	return nil
+}
+
+func pluginRevokeRun(cmd *cobra.Command, args []string) error {
+	err := wshclient.PluginRevokeCommand(RpcClient, wshrpc.CommandPluginRevokeData{PluginId: args[0]}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("revoking token: %w", err)
+	}
+	WriteStdout("plugin %q revoked\n", args[0])
+	return nil
+}
+
+func pluginCallRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandPluginCallData{PluginId: args[0], Handler: args[1]}
+	if len(args) > 2 {
+		err := json.Unmarshal([]byte(args[2]), &data.Data)
+		if err != nil {
+			return fmt.Errorf("invalid json: %w", err)
+		}
+	}
+	rtn, err := wshclient.PluginCallCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("calling plugin: %w", err)
+	}
+	barr, err := json.MarshalIndent(rtn, "", "  ")
+	if err != nil {
+		return fmt.Errorf("formatting result: %w", err)
+	}
+	WriteStdout("%s\n", string(barr))
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var profileSetTheme string
+var profileSetNewBlock string
+var profileSetTabPreset string
+var profileSetAiPreset string
+var profileSwitchOff bool
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "manage the settings profiles",
+	Long:  "Commands to manage the settings profiles.  A profile is a named set of settings (e.g. \"work\", \"personal\", \"presentation\") with a terminal theme, key bindings (app:keybindings), and the defaults of new blocks and tabs.  Switching to a profile applies all of its settings at once, over the global settings and under the settings of the workspace.",
+}
+
+var profileListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the profiles (the active one is marked with *)",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("profile", profileListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:     "show NAME",
+	Short:   "print a profile (as json)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("profile", profileShowRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var profileSetCmd = &cobra.Command{
+	Use:     "set NAME [KEY=VALUE...]",
+	Short:   "create a profile or change its settings (a value of null removes a setting)",
+	Example: "  wsh profile set presentation term:fontsize=20 --theme campbell\n  wsh profile set work --newblock term --tabpreset bg@blue\n  wsh profile set work term:fontsize=null",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    activityWrap("profile", profileSetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:     "rm NAME",
+	Short:   "remove a profile",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("profile", profileRemoveRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var profileSwitchCmd = &cobra.Command{
+	Use:     "switch [NAME]",
+	Short:   "switch to a profile (or to no profile with --off)",
+	Example: "  wsh profile switch presentation\n  wsh profile switch --off",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("profile", profileSwitchRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	profileSetCmd.Flags().StringVar(&profileSetTheme, "theme", "", "the terminal theme of the profile (term:theme)")
+	profileSetCmd.Flags().StringVar(&profileSetNewBlock, "newblock", "", "the view of a new block (app:defaultnewblock)")
+	profileSetCmd.Flags().StringVar(&profileSetTabPreset, "tabpreset", "", "the background of a new tab (tab:preset)")
+	profileSetCmd.Flags().StringVar(&profileSetAiPreset, "aipreset", "", "the preset of a new ai block (ai:preset)")
+	profileSwitchCmd.Flags().BoolVar(&profileSwitchOff, "off", false, "switch to no profile")
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileShowCmd)
+	profileCmd.AddCommand(profileSetCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+	profileCmd.AddCommand(profileSwitchCmd)
+}
+
+func profileListRun(cmd *cobra.Command, args []string) error {
+	data, err := wshclient.ProfileListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing profiles: %w", err)
+	}
+	if len(data.Profiles) == 0 {
+		WriteStdout("no profiles (create one with: wsh profile set NAME KEY=VALUE...)\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "NAME\tSETTINGS\tTHEME\tKEY BINDINGS\n")
+	for _, profile := range data.Profiles {
+		name := profile.Name
+		if name == data.Active {
+			name = "* " + name
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%d\n", name, len(profile.Settings), profile.Theme, len(profile.Keybindings))
+	}
+	writer.Flush()
+	return nil
+}
+
+func findProfileByName(name string) (*waveobj.Profile, error) {
+	data, err := wshclient.ProfileListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return nil, fmt.Errorf("listing profiles: %w", err)
+	}
+	for _, profile := range data.Profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return nil, nil
+}
+
+func profileShowRun(cmd *cobra.Command, args []string) error {
+	profile, err := findProfileByName(args[0])
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("profile not found: %s", args[0])
+	}
+	barr, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	WriteStdout("%s\n", barr)
+	return nil
+}
+
+func profileSetRun(cmd *cobra.Command, args []string) error {
+	sets, err := parseMetaSets(args[1:])
+	if err != nil {
+		return err
+	}
+	flagSets := []struct {
+		flag string
+		key  string
+		val  string
+	}{
+		{"theme", wconfig.ConfigKey_TermTheme, profileSetTheme},
+		{"newblock", wconfig.ConfigKey_AppDefaultNewBlock, profileSetNewBlock},
+		{"tabpreset", wconfig.ConfigKey_TabPreset, profileSetTabPreset},
+		{"aipreset", wconfig.ConfigKey_AiPreset, profileSetAiPreset},
+	}
+	for _, flagSet := range flagSets {
+		if cmd.Flags().Changed(flagSet.flag) {
+			sets[flagSet.key] = flagSet.val
+		}
+	}
+	profile, err := findProfileByName(args[0])
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		profile = &waveobj.Profile{Name: args[0]}
+	}
+	if profile.Settings == nil {
+		profile.Settings = make(waveobj.MetaMapType)
+	}
+	// a null value is sent as it is, so it also clears the theme, key bindings, and templates
+	for key, val := range sets {
+		profile.Settings[key] = val
+	}
+	profile, err = wshclient.ProfileSaveCommand(RpcClient, *profile, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return err
+	}
+	WriteStdout("profile %q saved (switch to it with: wsh profile switch %s)\n", profile.Name, profile.Name)
+	return nil
+}
+
+func profileRemoveRun(cmd *cobra.Command, args []string) error {
+	err := wshclient.ProfileDeleteCommand(RpcClient, wshrpc.CommandProfileData{Name: args[0]}, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("removing profile: %w", err)
+	}
+	WriteStdout("profile %q removed\n", args[0])
+	return nil
+}
+
+func profileSwitchRun(cmd *cobra.Command, args []string) error {
+	if (len(args) == 0) != profileSwitchOff {
+		return fmt.Errorf("set a profile name, or --off")
+	}
+	var data wshrpc.CommandProfileData
+	if len(args) > 0 {
+		data.Name = args[0]
+	}
+	_, err := wshclient.ProfileSwitchCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return err
+	}
+	if data.Name == "" {
+		WriteStdout("switched to no profile\n")
+	} else {
+		WriteStdout("switched to profile %q\n", data.Name)
+	}
+	return nil
+}
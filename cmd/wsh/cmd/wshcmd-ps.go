@@ -0,0 +1,65 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var psCmd = &cobra.Command{
+	Use:     "ps",
+	Short:   "list the processes running in a block (local shells only)",
+	Args:    cobra.NoArgs,
+	RunE:    psRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	rootCmd.AddCommand(psCmd)
+}
+
+func formatRss(rss int64) string {
+	const mb = 1024 * 1024
+	if rss >= 1024*mb {
+		return fmt.Sprintf("%.1fG", float64(rss)/(1024*mb))
+	}
+	return fmt.Sprintf("%.1fM", float64(rss)/mb)
+}
+
+func psRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("ps", rtnErr == nil)
+	}()
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	if fullORef.OType != "block" {
+		return fmt.Errorf("object reference is not a block")
+	}
+	data := wshrpc.CommandControllerProcessTreeData{BlockId: fullORef.OID}
+	procs, err := wshclient.ControllerProcessTreeCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("getting process tree: %v", err)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "PID\tCPU%%\tMEM\tCOMMAND\n")
+	for _, proc := range procs {
+		command := proc.CmdLine
+		if command == "" {
+			command = proc.Name
+		}
+		indent := strings.Repeat("  ", proc.Depth)
+		fmt.Fprintf(writer, "%d\t%.1f\t%s\t%s%s\n", proc.Pid, proc.CpuPercent, formatRss(proc.MemRss), indent, command)
+	}
+	writer.Flush()
+	return nil
+}
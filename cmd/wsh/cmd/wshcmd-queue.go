@@ -0,0 +1,143 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var queueWait bool
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "queue commands to run one after another in a terminal block",
+	Long:  "Commands to queue commands in a terminal block.  Queued commands are typed into the block's shell one at a time, each once the previous one has finished (this needs shell integration).",
+}
+
+var queueAddCmd = &cobra.Command{
+	Use:     "add CMD",
+	Short:   "add a command to the block's queue",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    activityWrap("queue", queueAddRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var queueListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the queued (and recently finished) commands of a terminal block",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("queue", queueListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var queueCancelCmd = &cobra.Command{
+	Use:     "cancel [ID]",
+	Short:   "cancel a queued command, or interrupt it if it is running (cancels all queued commands if ID is not given)",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("queue", queueCancelRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	queueAddCmd.Flags().BoolVarP(&queueWait, "wait", "w", false, "wait for the command to finish, and exit with its exit code")
+	rootCmd.AddCommand(queueCmd)
+	queueCmd.AddCommand(queueAddCmd)
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueCancelCmd)
+}
+
+func queueAddRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandTermQueueData{BlockId: fullORef.OID, Cmd: strings.Join(args, " ")}
+	item, err := wshclient.TermQueueAddCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("queueing command: %w", err)
+	}
+	if !queueWait {
+		WriteStdout("command %d queued\n", item.Id)
+		return nil
+	}
+	for {
+		time.Sleep(500 * time.Millisecond)
+		items, err := wshclient.TermQueueListCommand(RpcClient, wshrpc.CommandTermQueueData{BlockId: fullORef.OID}, &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("getting queue: %w", err)
+		}
+		var found *wshrpc.QueuedCommand
+		for idx := range items {
+			if items[idx].Id == item.Id {
+				found = &items[idx]
+				break
+			}
+		}
+		if found == nil || found.Status == "canceled" {
+			WriteStderr("command %d was canceled\n", item.Id)
+			WshExitCode = 1
+			return nil
+		}
+		if found.Status == "done" {
+			if found.ExitCode != nil {
+				WshExitCode = *found.ExitCode
+			}
+			return nil
+		}
+	}
+}
+
+func queueListRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	items, err := wshclient.TermQueueListCommand(RpcClient, wshrpc.CommandTermQueueData{BlockId: fullORef.OID}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing queue: %w", err)
+	}
+	if len(items) == 0 {
+		WriteStdout("no queued commands\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID\tSTATUS\tEXIT\tCMD\n")
+	for _, item := range items {
+		exitCode := "-"
+		if item.ExitCode != nil {
+			exitCode = strconv.Itoa(*item.ExitCode)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", item.Id, item.Status, exitCode, item.Cmd)
+	}
+	writer.Flush()
+	return nil
+}
+
+func queueCancelRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandTermQueueData{BlockId: fullORef.OID}
+	if len(args) > 0 {
+		data.Id, err = strconv.Atoi(args[0])
+		if err != nil || data.Id <= 0 {
+			return fmt.Errorf("invalid command id %q", args[0])
+		}
+	}
+	err = wshclient.TermQueueCancelCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("canceling command: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/util/wavefileutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "record terminal output as an asciicast",
+	Long:  "Commands to record a terminal block's output (asciicast v2) and export the recordings",
+}
+
+var recordStartCmd = &cobra.Command{
+	Use:     "start [TITLE]",
+	Short:   "start recording a terminal block",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("record", recordStartRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var recordStopCmd = &cobra.Command{
+	Use:     "stop",
+	Short:   "stop recording a terminal block",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("record", recordStopRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var recordListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the recordings of a terminal block",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("record", recordListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var recordExportCmd = &cobra.Command{
+	Use:     "export RECORDING [FILE]",
+	Short:   "write a recording to a .cast file (or stdout)",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    activityWrap("record", recordExportRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+	recordCmd.AddCommand(recordStartCmd)
+	recordCmd.AddCommand(recordStopCmd)
+	recordCmd.AddCommand(recordListCmd)
+	recordCmd.AddCommand(recordExportCmd)
+}
+
+func printRecording(prefix string, rec *wshrpc.TermRecording) {
+	WriteStdout("%s %s (%dx%d, %.1fs, %d bytes)\n", prefix, rec.FileName, rec.Width, rec.Height, rec.Duration, rec.Size)
+}
+
+func recordStartRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandTermRecordData{BlockId: fullORef.OID}
+	if len(args) > 0 {
+		data.Title = args[0]
+	}
+	rec, err := wshclient.TermRecordStartCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("starting recording: %w", err)
+	}
+	WriteStdout("recording to %s, use \"wsh record stop\" to stop\n", rec.FileName)
+	return nil
+}
+
+func recordStopRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	rec, err := wshclient.TermRecordStopCommand(RpcClient, wshrpc.CommandTermRecordData{BlockId: fullORef.OID}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("stopping recording: %w", err)
+	}
+	printRecording("stopped", rec)
+	return nil
+}
+
+func recordListRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	recs, err := wshclient.TermListRecordingsCommand(RpcClient, wshrpc.CommandTermRecordData{BlockId: fullORef.OID}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing recordings: %w", err)
+	}
+	if len(recs) == 0 {
+		WriteStdout("no recordings\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "RECORDING\tSTARTED\tDURATION\tSIZE\tTITLE\n")
+	for _, rec := range recs {
+		name := rec.FileName
+		if rec.Active {
+			name += " (recording)"
+		}
+		startStr := time.UnixMilli(rec.StartTs).Format(time.DateTime)
+		fmt.Fprintf(writer, "%s\t%s\t%.1fs\t%d\t%s\n", name, startStr, rec.Duration, rec.Size, rec.Title)
+	}
+	writer.Flush()
+	return nil
+}
+
+// the recording can be given with or without the cast: prefix
+func recordExportRun(cmd *cobra.Command, args []string) error {
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	fileName := args[0]
+	if !strings.HasPrefix(fileName, wavebase.BlockFile_CastPrefix) {
+		fileName = wavebase.BlockFile_CastPrefix + fileName
+	}
+	fileData := wshrpc.FileData{
+		Info: &wshrpc.FileInfo{
+			Path: fmt.Sprintf(wavefileutil.WaveFilePathPattern, fullORef.OID, fileName)}}
+	var writer io.Writer = os.Stdout
+	if len(args) > 1 && args[1] != "-" {
+		outFile, err := os.Create(args[1])
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer outFile.Close()
+		writer = outFile
+	}
+	err = streamReadFromFile(cmd.Context(), fileData, writer)
+	if err != nil {
+		return fmt.Errorf("reading recording: %w", err)
+	}
+	return nil
+}
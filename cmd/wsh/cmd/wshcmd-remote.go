@@ -0,0 +1,197 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/fesession"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"golang.org/x/term"
+)
+
+var remoteRevokeAll bool
+var remoteShareInput bool
+var remoteShareEdit bool
+var remoteShareHours float64
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "manage browser remote access",
+	Long:  "Commands to manage browser remote access (set \"remote:enabled\" to serve wave to browsers over https).  Browsers log in with the password set by \"wsh remote passwd\", and can only open the workspaces listed in \"remote:workspaces\".",
+}
+
+var remotePasswdCmd = &cobra.Command{
+	Use:     "passwd",
+	Short:   "set the remote-access password (logs out all browsers)",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("remote", remotePasswdRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var remoteSessionsCmd = &cobra.Command{
+	Use:     "sessions",
+	Short:   "list the browsers that are logged in",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("remote", remoteSessionsRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var remoteShareCmd = &cobra.Command{
+	Use:     "share WORKSPACE",
+	Short:   "create a share link for an exposed workspace (view only unless --input or --edit is given)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("remote", remoteShareRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var remoteSharesCmd = &cobra.Command{
+	Use:     "shares",
+	Short:   "list the share links",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("remote", remoteSharesRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var remoteRevokeCmd = &cobra.Command{
+	Use:     "revoke [ID]",
+	Short:   "log out a browser session or revoke a share link and its sessions (or all of them with --all)",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("remote", remoteRevokeRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	remoteRevokeCmd.Flags().BoolVar(&remoteRevokeAll, "all", false, "log out all of the browser sessions and revoke all of the share links")
+	remoteShareCmd.Flags().BoolVar(&remoteShareInput, "input", false, "allow typing into blocks")
+	remoteShareCmd.Flags().BoolVar(&remoteShareEdit, "edit", false, "allow typing into, creating, changing, and deleting blocks and tabs")
+	remoteShareCmd.Flags().Float64Var(&remoteShareHours, "hours", 24, "how long the link can be opened for")
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remotePasswdCmd)
+	remoteCmd.AddCommand(remoteSessionsCmd)
+	remoteCmd.AddCommand(remoteShareCmd)
+	remoteCmd.AddCommand(remoteSharesCmd)
+	remoteCmd.AddCommand(remoteRevokeCmd)
+}
+
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("the password must be typed in a terminal")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	barr, err := term.ReadPassword(fd)
+	fmt.Fprint(os.Stderr, "\n")
+	if err != nil {
+		return "", err
+	}
+	return string(barr), nil
+}
+
+func remotePasswdRun(cmd *cobra.Command, args []string) error {
+	password, err := readPassword("New password: ")
+	if err != nil {
+		return fmt.Errorf("reading password: %w", err)
+	}
+	confirm, err := readPassword("Confirm password: ")
+	if err != nil {
+		return fmt.Errorf("reading password: %w", err)
+	}
+	if password != confirm {
+		return fmt.Errorf("the passwords do not match")
+	}
+	err = wshclient.RemoteSetPasswordCommand(RpcClient, wshrpc.CommandRemoteSetPasswordData{Password: password}, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("setting password: %w", err)
+	}
+	WriteStdout("remote-access password set\n")
+	return nil
+}
+
+func remoteSessionsRun(cmd *cobra.Command, args []string) error {
+	sessions, err := wshclient.RemoteSessionsCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		WriteStdout("no sessions\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID\tADDRESS\tLOGIN\tLAST SEEN\tSHARE\tPERMS\tUSER AGENT\n")
+	for _, sess := range sessions {
+		createdTs := time.UnixMilli(sess.CreatedTs).Format("2006-01-02 15:04:05")
+		lastSeenTs := time.UnixMilli(sess.LastSeenTs).Format("2006-01-02 15:04:05")
+		shareId := "-"
+		if sess.ShareId != "" {
+			shareId = sess.ShareId[:8]
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", sess.SessionId[:8], sess.RemoteAddr, createdTs, lastSeenTs, shareId, strings.Join(sess.Perms, ","), sess.UserAgent)
+	}
+	writer.Flush()
+	return nil
+}
+
+func remoteRevokeRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandRemoteRevokeData{All: remoteRevokeAll}
+	if len(args) > 0 {
+		data.SessionId = args[0]
+	}
+	if !data.All && data.SessionId == "" {
+		return fmt.Errorf("a session id, a share id, or --all is required")
+	}
+	numRevoked, err := wshclient.RemoteRevokeCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+	WriteStdout("%d session(s)/share link(s) revoked\n", numRevoked)
+	return nil
+}
+
+func remoteShareRun(cmd *cobra.Command, args []string) error {
+	if remoteShareHours <= 0 {
+		return fmt.Errorf("--hours must be positive")
+	}
+	perms := []string{fesession.Perm_View}
+	if remoteShareInput || remoteShareEdit {
+		perms = append(perms, fesession.Perm_Input)
+	}
+	if remoteShareEdit {
+		perms = append(perms, fesession.Perm_Edit)
+	}
+	data := wshrpc.CommandRemoteShareCreateData{Workspace: args[0], Perms: perms, Hours: remoteShareHours}
+	share, err := wshclient.RemoteShareCreateCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("creating share link: %w", err)
+	}
+	WriteStdout("%s\n", share.Url)
+	WriteStdout("share %s (%s), expires %s\n", share.ShareId[:8], strings.Join(share.Perms, ","), time.UnixMilli(share.ExpiresTs).Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+func remoteSharesRun(cmd *cobra.Command, args []string) error {
+	shares, err := wshclient.RemoteShareListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing share links: %w", err)
+	}
+	if len(shares) == 0 {
+		WriteStdout("no share links\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID\tWORKSPACE\tPERMS\tCREATED\tEXPIRES\n")
+	for _, share := range shares {
+		createdTs := time.UnixMilli(share.CreatedTs).Format("2006-01-02 15:04:05")
+		expiresTs := time.UnixMilli(share.ExpiresTs).Format("2006-01-02 15:04:05")
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", share.ShareId[:8], share.WorkspaceName, strings.Join(share.Perms, ","), createdTs, expiresTs)
+	}
+	writer.Flush()
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/roam"
+)
+
+var roamHostCmd = &cobra.Command{
+	Use:    "roamhost",
+	Hidden: true,
+	Short:  "remote server for shells on the roam (udp) transport",
+	Args:   cobra.NoArgs,
+	RunE:   roamHostRun,
+}
+
+var roamHostServe bool
+
+func init() {
+	roamHostCmd.Flags().BoolVar(&roamHostServe, "serve", false, "run the server (started by roamhost in its own session)")
+	rootCmd.AddCommand(roamHostCmd)
+}
+
+// reads a roam.ServerSpec from stdin and writes the roam.ServerInfo of the started server to stdout
+func roamHostRun(cmd *cobra.Command, args []string) error {
+	if roamHostServe {
+		return roam.RunServer()
+	}
+	var spec roam.ServerSpec
+	var info *roam.ServerInfo
+	err := json.NewDecoder(os.Stdin).Decode(&spec)
+	if err == nil {
+		info, err = roam.StartServer([]string{"roamhost", "--serve"}, spec)
+	}
+	if err != nil {
+		info = &roam.ServerInfo{Error: err.Error()}
+	}
+	barr, _ := json.Marshal(info)
+	WriteStdout("%s\n", barr)
+	return nil
+}
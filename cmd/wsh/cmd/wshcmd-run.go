@@ -4,22 +4,39 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/util/wavefileutil"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 )
 
+// after the command exits, its output is followed until there is none for RunOutputQuiet (the last output can be
+// written after the exit), for at most RunOutputDrain
+const RunOutputQuiet = 250 * time.Millisecond
+const RunOutputDrain = 2 * time.Second
+
+// the exit event can come after the close event when the block closes on exit
+const RunCloseGrace = 500 * time.Millisecond
+
 var runCmd = &cobra.Command{
 	Use:              "run [flags] -- command [args...]",
 	Short:            "run a command in a new block",
+	Example:          "  wsh run -- make build\n  wsh run --wait --output --tab tab:2 -- go test ./...",
 	RunE:             runRun,
 	PreRunE:          preRunSetupRpcClient,
 	TraverseChildren: true,
@@ -35,6 +52,10 @@ func init() {
 	flags.BoolP("paused", "p", false, "create block in paused state")
 	flags.String("cwd", "", "set working directory for command")
 	flags.BoolP("append", "a", false, "append output on restart instead of clearing")
+	flags.String("tab", "", "create the block in this tab (tab:<id>, or tab:N for the Nth tab, the current tab by default)")
+	flags.String("view", "term", "the view of the block")
+	flags.BoolP("wait", "w", false, "wait for the command to exit, and exit with its exit code")
+	flags.BoolP("output", "o", false, "write the output of the command to stdout (implies --wait)")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -52,6 +73,11 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	cwd, _ := flags.GetString("cwd")
 	delayMs, _ := flags.GetInt("delay")
 	appendOutput, _ := flags.GetBool("append")
+	tabArg, _ := flags.GetString("tab")
+	view, _ := flags.GetString("view")
+	wait, _ := flags.GetBool("wait")
+	output, _ := flags.GetBool("output")
+	wait = wait || output
 	var cmdArgs []string
 	var useShell bool
 	var shellCmd string
@@ -79,6 +105,21 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		shellCmd = commandArg
 		useShell = true
 	}
+	if wait && paused {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("cannot use --wait or --output with --paused")
+	}
+	var tabId string
+	if tabArg != "" {
+		tabORef, err := resolveSimpleId(tabArg)
+		if err != nil {
+			return fmt.Errorf("resolving tab: %w", err)
+		}
+		if tabORef.OType != waveobj.OType_Tab {
+			return fmt.Errorf("%q is not a tab", tabArg)
+		}
+		tabId = tabORef.OID
+	}
 
 	// Get current working directory
 	if cwd == "" {
@@ -105,7 +146,7 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	// Convert to null-terminated format
 	envContent := envutil.MapToEnv(envMap)
 	createMeta := map[string]any{
-		waveobj.MetaKey_View:            "term",
+		waveobj.MetaKey_View:            view,
 		waveobj.MetaKey_CmdCwd:          cwd,
 		waveobj.MetaKey_Controller:      "cmd",
 		waveobj.MetaKey_CmdClearOnStart: true,
@@ -113,7 +154,8 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	createMeta[waveobj.MetaKey_Cmd] = shellCmd
 	createMeta[waveobj.MetaKey_CmdArgs] = cmdArgs
 	createMeta[waveobj.MetaKey_CmdShell] = useShell
-	if paused {
+	if paused || wait {
+		// a waited for block is started below, once its events are subscribed to
 		createMeta[waveobj.MetaKey_CmdRunOnStart] = false
 	} else {
 		createMeta[waveobj.MetaKey_CmdRunOnce] = true
@@ -134,6 +176,7 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	}
 
 	createBlockData := wshrpc.CommandCreateBlockData{
+		TabId: tabId,
 		BlockDef: &waveobj.BlockDef{
 			Meta: createMeta,
 			Files: map[string]*waveobj.FileDef{
@@ -149,7 +192,154 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	if err != nil {
 		return fmt.Errorf("creating new run block: %w", err)
 	}
-
-	WriteStdout("run block created: %s\n", oref)
+	if !wait {
+		WriteStdout("run block created: %s\n", oref)
+		return nil
+	}
+	exitCode, err := waitForRunBlock(oref, tabId, output, appendOutput)
+	if err != nil {
+		return err
+	}
+	WshExitCode = exitCode
 	return nil
 }
+
+// starts the block (it is created paused, so its exit and output can not be missed) and waits for its command to
+// exit, writing the output of the command to stdout if output is set.  returns the exit code of the command.
+func waitForRunBlock(blockRef waveobj.ORef, tabId string, output bool, appendOutput bool) (int, error) {
+	exitCh := make(chan int, 1)
+	closeCh := make(chan struct{})
+	var closeOnce sync.Once
+	RpcClient.EventListener.On(wps.Event_BlockExit, func(event *wps.WaveEvent) {
+		if !event.HasScope(blockRef.String()) {
+			return
+		}
+		var exitData wps.BlockExitEventData
+		if err := utilfn.ReUnmarshal(&exitData, event.Data); err != nil {
+			return
+		}
+		select {
+		case exitCh <- exitData.ExitCode:
+		default:
+		}
+	})
+	RpcClient.EventListener.On(wps.Event_BlockClose, func(event *wps.WaveEvent) {
+		if event.HasScope(blockRef.String()) {
+			closeOnce.Do(func() { close(closeCh) })
+		}
+	})
+	for _, eventName := range []string{wps.Event_BlockExit, wps.Event_BlockClose} {
+		subReq := wps.SubscriptionRequest{Event: eventName, Scopes: []string{blockRef.String()}}
+		err := wshclient.EventSubCommand(RpcClient, subReq, &wshrpc.RpcOpts{Timeout: 5000})
+		if err != nil {
+			return 0, fmt.Errorf("subscribing to %s: %w", eventName, err)
+		}
+	}
+	termPath := fmt.Sprintf(wavefileutil.WaveFilePathPattern, blockRef.OID, wavebase.BlockFile_Term)
+	var startOffset int64
+	if output && appendOutput {
+		info, err := wshclient.FileInfoCommand(RpcClient, wshrpc.FileData{Info: &wshrpc.FileInfo{Path: termPath}}, &wshrpc.RpcOpts{Timeout: 5000})
+		if err == nil {
+			startOffset = info.Size
+		}
+	}
+	resyncData := wshrpc.CommandControllerResyncData{TabId: tabId, BlockId: blockRef.OID, ForceRestart: true}
+	err := wshclient.ControllerResyncCommand(RpcClient, resyncData, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return 0, fmt.Errorf("starting command: %w", err)
+	}
+	var follower *runOutputFollower
+	if output {
+		follower = &runOutputFollower{path: termPath, offset: startOffset, activityCh: make(chan struct{}, 1)}
+		go follower.run()
+	}
+	var exitCode int
+	select {
+	case exitCode = <-exitCh:
+	case <-closeCh:
+		select {
+		case exitCode = <-exitCh:
+		case <-time.After(RunCloseGrace):
+			return 0, fmt.Errorf("block was closed before the command exited")
+		}
+	}
+	if follower != nil {
+		follower.drain()
+	}
+	return exitCode, nil
+}
+
+// writes the term file of a run block to stdout (from offset, following truncates)
+type runOutputFollower struct {
+	lock       sync.Mutex
+	path       string
+	offset     int64
+	done       bool
+	activityCh chan struct{}
+}
+
+func (f *runOutputFollower) run() {
+	for {
+		f.lock.Lock()
+		watchData := wshrpc.CommandFileWatchData{Path: f.path, Offset: f.offset}
+		f.lock.Unlock()
+		ch := wshclient.FileWatchCommand(RpcClient, watchData, &wshrpc.RpcOpts{Timeout: TimeoutYear})
+		for respUnion := range ch {
+			if err := convertNotFoundErr(respUnion.Error); err != nil {
+				if err != fs.ErrNotExist {
+					WriteStderr("wsh: following output: %v\n", err)
+					return
+				}
+				// the file is made when the command starts
+				time.Sleep(100 * time.Millisecond)
+				break
+			}
+			if !f.handleEvent(respUnion.Response) {
+				return
+			}
+		}
+	}
+}
+
+// returns false once the output is drained
+func (f *runOutputFollower) handleEvent(event wshrpc.FileWatchEvent) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.done {
+		return false
+	}
+	switch event.Type {
+	case filestore.WatchEvent_Data:
+		data, err := base64.StdEncoding.DecodeString(event.Data64)
+		if err != nil {
+			return true
+		}
+		os.Stdout.Write(data)
+		f.offset = event.Offset + int64(len(data))
+	case filestore.WatchEvent_Truncate, filestore.WatchEvent_Rotate:
+		f.offset = event.Offset
+	}
+	select {
+	case f.activityCh <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// waits for the output written after the exit, see RunOutputQuiet
+func (f *runOutputFollower) drain() {
+	deadline := time.After(RunOutputDrain)
+	quiet := false
+	for !quiet {
+		select {
+		case <-f.activityCh:
+		case <-time.After(RunOutputQuiet):
+			quiet = true
+		case <-deadline:
+			quiet = true
+		}
+	}
+	f.lock.Lock()
+	f.done = true
+	f.lock.Unlock()
+}
@@ -0,0 +1,63 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var searchName string
+var searchView string
+var searchLimit int
+
+var searchCmd = &cobra.Command{
+	Use:     "search [words...]",
+	Short:   "search the output and files of all the blocks",
+	Long:    "Search the output of all the blocks for the words (every word has to match, a word like CVE-2024-1234 is matched as a phrase), and/or find the block files by name (--name) or the blocks by view (--view).  The output is only indexed when storage:searchindex is set.",
+	Example: "  wsh search CVE-2024-1234\n  wsh search --view preview --name cache\n  wsh search error --view term",
+	RunE:    activityWrap("search", searchRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	searchCmd.Flags().StringVarP(&searchName, "name", "n", "", "only the files whose name contains this")
+	searchCmd.Flags().StringVar(&searchView, "view", "", "only the blocks with this view (term, preview, ...)")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 100, "the max number of hits")
+	rootCmd.AddCommand(searchCmd)
+}
+
+func searchRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandFileSearchData{
+		Text:  strings.Join(args, " "),
+		Name:  searchName,
+		View:  searchView,
+		Limit: searchLimit,
+	}
+	if data.Text == "" && data.Name == "" && data.View == "" {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("nothing to search for")
+	}
+	hits, err := wshclient.FileSearchCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("searching: %w", err)
+	}
+	if len(hits) == 0 {
+		WriteStderr("no matches\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "BLOCK\tVIEW\tFILE\tOFFSET\tMATCH\n")
+	for _, hit := range hits {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%s\n", hit.BlockId, hit.View, hit.Name, hit.Offset, hit.Snippet)
+	}
+	writer.Flush()
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var secretConn string
+var secretStdin bool
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "manage the stored secrets of the connections",
+	Long:  "Commands to manage the secrets (passwords, key passphrases and api tokens) that connections use by name: ssh:passwordsecret, ssh:passphrasesecret and conn:secretenv in connections.json, or \"wsh conn add --secret\".  The values are kept in the OS keychain (or an encrypted file if there is none), and can't be read back.  A secret belongs to a connection (--conn), or is global and usable by every connection.",
+}
+
+var secretSetCmd = &cobra.Command{
+	Use:     "set NAME",
+	Short:   "store a secret (the value is typed, or read from stdin with --stdin)",
+	Example: "  wsh secret set legacy-root --conn legacy-1\n  wsh secret set openai-token --stdin < ~/token.txt",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("secret", secretSetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var secretListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the secrets (not their values)",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("secret", secretListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var secretRemoveCmd = &cobra.Command{
+	Use:     "rm NAME",
+	Short:   "remove a secret",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("secret", secretRemoveRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	secretSetCmd.Flags().StringVarP(&secretConn, "conn", "c", "", "the connection the secret belongs to (global if not set)")
+	secretSetCmd.Flags().BoolVar(&secretStdin, "stdin", false, "read the value from stdin")
+	secretListCmd.Flags().StringVarP(&secretConn, "conn", "c", "", "only the secrets of the connection")
+	secretRemoveCmd.Flags().StringVarP(&secretConn, "conn", "c", "", "the connection the secret belongs to (global if not set)")
+	rootCmd.AddCommand(secretCmd)
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretRemoveCmd)
+}
+
+func secretSetRun(cmd *cobra.Command, args []string) error {
+	var value string
+	if secretStdin {
+		barr, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading stdin: %w", err)
+		}
+		value = strings.TrimRight(string(barr), "\r\n")
+	} else {
+		var err error
+		value, err = readPassword(fmt.Sprintf("Value of %s: ", args[0]))
+		if err != nil {
+			return fmt.Errorf("reading the value: %w", err)
+		}
+	}
+	data := wshrpc.CommandSecretSetData{Name: args[0], Connection: secretConn, Value: value}
+	err := wshclient.SecretSetCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("storing secret: %w", err)
+	}
+	WriteStdout("secret %q stored\n", args[0])
+	return nil
+}
+
+func secretListRun(cmd *cobra.Command, args []string) error {
+	secrets, err := wshclient.SecretListCommand(RpcClient, wshrpc.CommandSecretListData{Connection: secretConn}, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("listing secrets: %w", err)
+	}
+	if len(secrets) == 0 {
+		WriteStdout("no secrets\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "NAME\tCONNECTION\tSTORED IN\tUPDATED\n")
+	for _, secret := range secrets {
+		connName := secret.ConnName
+		if connName == "" {
+			connName = "(global)"
+		}
+		updated := time.UnixMilli(secret.UpdatedTs).Format("2006-01-02 15:04")
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", secret.Name, connName, secret.Backend, updated)
+	}
+	writer.Flush()
+	return nil
+}
+
+func secretRemoveRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandSecretData{Name: args[0], Connection: secretConn}
+	err := wshclient.SecretDeleteCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("removing secret: %w", err)
+	}
+	WriteStdout("secret %q removed\n", args[0])
+	return nil
+}
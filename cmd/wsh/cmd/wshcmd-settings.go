@@ -0,0 +1,326 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var settingsWorkspace bool
+var settingsExplainConn string
+var settingsMigrateDryRun bool
+var settingsImportFormat string
+var settingsImportOnly []string
+var settingsImportDryRun bool
+
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "manage the settings of wave and of the workspaces",
+	Long:  "Commands to manage the settings set in wave, for all of wave or (with -w) for the workspace of the block.  They use the keys of settings.json and override it: the settings of a workspace override the global settings, which override settings.json, and in a block they are overridden by its connection (connections.json) and its meta, except for the settings locked with settings:locked.  A change applies right away, e.g. to the scrollback of the running terminals.",
+}
+
+var settingsListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the settings set in wave (globally, or for the workspace with -w)",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("settings", settingsListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var settingsGetCmd = &cobra.Command{
+	Use:     "get KEY...",
+	Short:   "print the effective value of settings (with -w, for the workspace of the block)",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    activityWrap("settings", settingsGetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var settingsSetCmd = &cobra.Command{
+	Use:     "set KEY=VALUE...",
+	Short:   "set settings (a null value removes the setting)",
+	Example: "  wsh settings set term:scrollback=10000\n  wsh settings set -w term:localshellpath=/bin/zsh\n  wsh settings set -w term:localshellpath=null",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    activityWrap("settings", settingsSetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var settingsCheckCmd = &cobra.Command{
+	Use:     "check",
+	Short:   "check settings.json (and settings/*.json), print the errors with their line and column",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("settings", settingsCheckRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var settingsExplainCmd = &cobra.Command{
+	Use:     "explain [KEY...]",
+	Short:   "print the effective value of settings in the block (-b) and the layer it comes from",
+	Example: "  wsh settings explain term:theme term:safepaste\n  wsh settings explain --conn user@prod term:theme",
+	RunE:    activityWrap("settings", settingsExplainRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var settingsMigrateCmd = &cobra.Command{
+	Use:     "migrate",
+	Short:   "rewrite settings.json (and settings/*.json) with the renamed and changed settings of this version of wave",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("settings", settingsMigrateRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var settingsImportCmd = &cobra.Command{
+	Use:     "import FILE",
+	Short:   "import the themes, font, key bindings and ssh profiles of another terminal (iterm2, windowsterminal, alacritty or kitty)",
+	Example: "  plutil -convert xml1 -o /tmp/iterm2.plist ~/Library/Preferences/com.googlecode.iterm2.plist\n  wsh settings import /tmp/iterm2.plist\n  wsh settings import ~/.config/kitty/kitty.conf --only theme,font\n  wsh settings import settings.json --format windowsterminal -n",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("settings", settingsImportRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	settingsImportCmd.Flags().StringVar(&settingsImportFormat, "format", "", "the format of the file: iterm2, windowsterminal, alacritty or kitty (detected if not set)")
+	settingsImportCmd.Flags().StringSliceVar(&settingsImportOnly, "only", nil, "only import these kinds: theme, font, keybinding, connection")
+	settingsImportCmd.Flags().BoolVarP(&settingsImportDryRun, "dry-run", "n", false, "only print what would be imported")
+	settingsMigrateCmd.Flags().BoolVarP(&settingsMigrateDryRun, "dry-run", "n", false, "only print the changes")
+	settingsExplainCmd.Flags().StringVar(&settingsExplainConn, "conn", "", "the connection (instead of the one of the block)")
+	settingsCmd.PersistentFlags().BoolVarP(&settingsWorkspace, "workspace", "w", false, "the settings of the workspace of the block (-b)")
+	rootCmd.AddCommand(settingsCmd)
+	settingsCmd.AddCommand(settingsListCmd)
+	settingsCmd.AddCommand(settingsGetCmd)
+	settingsCmd.AddCommand(settingsSetCmd)
+	settingsCmd.AddCommand(settingsCheckCmd)
+	settingsCmd.AddCommand(settingsExplainCmd)
+	settingsCmd.AddCommand(settingsMigrateCmd)
+	settingsCmd.AddCommand(settingsImportCmd)
+}
+
+func getSettingsWorkspaceId() (string, error) {
+	return getBlockWorkspaceId(settingsWorkspace)
+}
+
+// the workspace of the block (-b), "" if useWorkspace is not set
+func getBlockWorkspaceId(useWorkspace bool) (string, error) {
+	if !useWorkspace {
+		return "", nil
+	}
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return "", err
+	}
+	blockInfo, err := wshclient.BlockInfoCommand(RpcClient, fullORef.OID, nil)
+	if err != nil {
+		return "", fmt.Errorf("getting block info: %w", err)
+	}
+	if blockInfo.WorkspaceId == "" {
+		return "", fmt.Errorf("block %s is not in a workspace", fullORef.OID)
+	}
+	return blockInfo.WorkspaceId, nil
+}
+
+func formatSettingValue(val any) string {
+	barr, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(barr)
+}
+
+func settingsListRun(cmd *cobra.Command, args []string) error {
+	workspaceId, err := getSettingsWorkspaceId()
+	if err != nil {
+		return err
+	}
+	settings, err := wshclient.SettingsGetCommand(RpcClient, wshrpc.CommandSettingsData{WorkspaceId: workspaceId}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("getting settings: %w", err)
+	}
+	if len(settings) == 0 {
+		WriteStdout("no settings\n")
+		return nil
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		WriteStdout("%s=%s\n", key, formatSettingValue(settings[key]))
+	}
+	return nil
+}
+
+func settingsGetRun(cmd *cobra.Command, args []string) error {
+	workspaceId, err := getSettingsWorkspaceId()
+	if err != nil {
+		return err
+	}
+	settings, err := wshclient.SettingsResolveCommand(RpcClient, wshrpc.CommandSettingsData{WorkspaceId: workspaceId}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("resolving settings: %w", err)
+	}
+	for _, key := range args {
+		if len(args) == 1 {
+			WriteStdout("%s\n", formatSettingValue(settings[key]))
+		} else {
+			WriteStdout("%s=%s\n", key, formatSettingValue(settings[key]))
+		}
+	}
+	return nil
+}
+
+func settingsSetRun(cmd *cobra.Command, args []string) error {
+	workspaceId, err := getSettingsWorkspaceId()
+	if err != nil {
+		return err
+	}
+	meta, err := parseMetaSets(args)
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandSettingsSetData{WorkspaceId: workspaceId, Settings: waveobj.MetaMapType(meta)}
+	err = wshclient.SettingsSetCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting settings: %w", err)
+	}
+	if workspaceId != "" {
+		WriteStdout("workspace settings set\n")
+	} else {
+		WriteStdout("settings set\n")
+	}
+	return nil
+}
+
+func settingsCheckRun(cmd *cobra.Command, args []string) error {
+	fullConfig, err := wshclient.GetFullConfigCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("getting config: %w", err)
+	}
+	var numErrs int
+	for _, cerr := range fullConfig.ConfigErrors {
+		fileName := filepath.ToSlash(cerr.File)
+		if fileName != wconfig.SettingsFile && !strings.HasPrefix(fileName, "settings/") {
+			continue
+		}
+		WriteStdout("%s\n", cerr.String())
+		numErrs++
+	}
+	if numErrs > 0 {
+		return fmt.Errorf("%d error(s) in the settings, the invalid settings are ignored", numErrs)
+	}
+	WriteStdout("settings ok\n")
+	return nil
+}
+
+func settingsExplainRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandSettingsExplainData{Connection: settingsExplainConn, Keys: args}
+	if blockArg != "" || RpcContext.BlockId != "" {
+		fullORef, err := resolveBlockArg()
+		if err != nil {
+			return err
+		}
+		data.BlockId = fullORef.OID
+	}
+	infos, err := wshclient.SettingsExplainCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("explaining settings: %w", err)
+	}
+	for _, info := range infos {
+		source := info.Source
+		if source == "" {
+			source = "not set"
+		}
+		if info.Locked {
+			source += ", locked"
+		}
+		WriteStdout("%s=%s (%s)\n", info.Key, formatSettingValue(info.Value), source)
+		for _, layer := range info.Layers {
+			layerName := layer.Source
+			if layer.Name != "" {
+				layerName = fmt.Sprintf("%s %s", layer.Source, layer.Name)
+			}
+			var note string
+			if layer.Ignored {
+				note = " (ignored, locked)"
+			}
+			WriteStdout("  %-24s %s%s\n", layerName, formatSettingValue(layer.Value), note)
+		}
+	}
+	return nil
+}
+
+func settingsMigrateRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandSettingsMigrateData{DryRun: settingsMigrateDryRun}
+	changes, err := wshclient.SettingsMigrateCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("migrating settings: %w", err)
+	}
+	if len(changes) == 0 {
+		WriteStdout("settings are up to date\n")
+		return nil
+	}
+	files := make(map[string]bool)
+	for _, change := range changes {
+		WriteStdout("%s: %s %s\n", change.File, change.Key, change.Desc)
+		files[change.File] = true
+	}
+	if !settingsMigrateDryRun {
+		WriteStdout("%d file(s) rewritten (the original files are kept as .bak)\n", len(files))
+	}
+	return nil
+}
+
+func settingsImportRun(cmd *cobra.Command, args []string) error {
+	barr, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	data := wshrpc.CommandSettingsImportData{
+		Format:   settingsImportFormat,
+		FileName: filepath.Base(args[0]),
+		Data:     string(barr),
+		Only:     settingsImportOnly,
+		DryRun:   settingsImportDryRun,
+	}
+	result, err := wshclient.SettingsImportCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("importing settings: %w", err)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "KIND\tNAME\tVALUE\tFROM\tSTATUS\n")
+	var numImported int
+	for _, item := range result.Items {
+		status := "imported"
+		if settingsImportDryRun {
+			status = "to import"
+		}
+		if item.Skipped {
+			status = "skipped: " + item.Reason
+		} else {
+			numImported++
+			if item.Reason != "" {
+				status += " (" + item.Reason + ")"
+			}
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", item.Kind, item.Name, item.Value, item.From, status)
+	}
+	writer.Flush()
+	if settingsImportDryRun {
+		WriteStdout("%d item(s) to import from %s (dry run)\n", numImported, result.Format)
+	} else {
+		WriteStdout("%d item(s) imported from %s\n", numImported, result.Format)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var signalCmd = &cobra.Command{
+	Use:     "signal [SIGINT|SIGTERM|SIGHUP|SIGKILL|SIGBREAK]",
+	Short:   "send a signal to the process running in a block (defaults to SIGTERM)",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    signalRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var signalPid int
+
+func init() {
+	signalCmd.Flags().IntVar(&signalPid, "pid", 0, "signal a single process from the block's process tree (see wsh ps)")
+	rootCmd.AddCommand(signalCmd)
+}
+
+func signalRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("signal", rtnErr == nil)
+	}()
+	sigName := "SIGTERM"
+	if len(args) > 0 {
+		sigName = args[0]
+	}
+	fullORef, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	if fullORef.OType != "block" {
+		return fmt.Errorf("object reference is not a block")
+	}
+	data := wshrpc.CommandControllerSignalData{
+		BlockId: fullORef.OID,
+		Signal:  sigName,
+		Pid:     signalPid,
+	}
+	err = wshclient.ControllerSignalCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("sending signal: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+const storageReplicaTimeout = 30 * 60 * 1000
+
+var storageReplicaOverwrite bool
+var storageReplicaSetKey string
+
+var storageReplicaCmd = &cobra.Command{
+	Use:   "replica",
+	Short: "replicate the block files to an s3 bucket, and restore them",
+	Long:  "The block files are replicated to the s3 bucket in storage:replicaurl every 5 minutes (encrypted with a key kept in the OS keychain).  The files of a block can be restored from the bucket, on this machine or on another one with the same key.",
+}
+
+var storageReplicaSyncCmd = &cobra.Command{
+	Use:     "sync",
+	Short:   "replicate the changed block files now",
+	Example: "  wsh storage replica sync",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("storage", storageReplicaSyncRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var storageReplicaListCmd = &cobra.Command{
+	Use:     "list [blockid]",
+	Short:   "list the block files in the replica (of all the blocks, or of one block)",
+	Example: "  wsh storage replica list\n  wsh storage replica list 5d2b1c9e-...",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("storage", storageReplicaListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var storageReplicaRestoreCmd = &cobra.Command{
+	Use:     "restore [blockid] [file...]",
+	Short:   "restore the files of a block from the replica into this block (or the block given with -b)",
+	Long:    "Restore the files of a block (all of them, or the files given) from the replica into the current block, or the block given with -b.  The files that exist are not replaced unless --overwrite is given.",
+	Example: "  wsh storage replica restore 5d2b1c9e-...\n  wsh storage replica restore --overwrite 5d2b1c9e-... term",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    activityWrap("storage", storageReplicaRestoreRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var storageReplicaKeyCmd = &cobra.Command{
+	Use:     "key",
+	Short:   "show the replica key, or set it to the key of another machine",
+	Long:    "Show the key the replica is encrypted with.  To restore files replicated on another machine, set its key here with --set (and use the same storage:replicaurl).",
+	Example: "  wsh storage replica key\n  wsh storage replica key --set <key>",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("storage", storageReplicaKeyRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	storageReplicaRestoreCmd.Flags().BoolVar(&storageReplicaOverwrite, "overwrite", false, "replace the files that exist")
+	storageReplicaKeyCmd.Flags().StringVar(&storageReplicaSetKey, "set", "", "the key to set (base64)")
+	storageReplicaCmd.AddCommand(storageReplicaSyncCmd)
+	storageReplicaCmd.AddCommand(storageReplicaListCmd)
+	storageReplicaCmd.AddCommand(storageReplicaRestoreCmd)
+	storageReplicaCmd.AddCommand(storageReplicaKeyCmd)
+	storageCmd.AddCommand(storageReplicaCmd)
+}
+
+func storageReplicaSyncRun(cmd *cobra.Command, args []string) error {
+	result, err := wshclient.StorageReplicaSyncCommand(RpcClient, &wshrpc.RpcOpts{Timeout: storageReplicaTimeout})
+	if err != nil {
+		return fmt.Errorf("syncing replica: %w", err)
+	}
+	WriteStdout("replicated %d blobs (%s) and %d files, deleted %d files\n", result.Blobs, formatStorageBytes(result.Bytes), result.Files, result.Deleted)
+	if result.Offloaded > 0 {
+		WriteStdout("offloaded %d blobs (%s freed)\n", result.Offloaded, formatStorageBytes(result.OffloadedBytes))
+	}
+	if result.Rehydrated > 0 {
+		WriteStdout("brought back %d offloaded blobs\n", result.Rehydrated)
+	}
+	return nil
+}
+
+func storageReplicaListRun(cmd *cobra.Command, args []string) error {
+	var data wshrpc.CommandStorageReplicaListData
+	if len(args) > 0 {
+		data.ZoneId = args[0]
+	}
+	files, err := wshclient.StorageReplicaListCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: storageReplicaTimeout})
+	if err != nil {
+		return fmt.Errorf("listing replica: %w", err)
+	}
+	if len(files) == 0 {
+		WriteStdout("no files in the replica\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "BLOCK\tFILE\tSIZE\tMODIFIED\n")
+	for _, file := range files {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", file.ZoneId, file.Name, formatStorageBytes(file.Size), time.UnixMilli(file.ModTs).Format("2006-01-02 15:04"))
+	}
+	writer.Flush()
+	return nil
+}
+
+func storageReplicaRestoreRun(cmd *cobra.Command, args []string) error {
+	oref, err := resolveBlockArg()
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandStorageReplicaRestoreData{
+		ZoneId:       args[0],
+		TargetZoneId: oref.OID,
+		Names:        args[1:],
+		Overwrite:    storageReplicaOverwrite,
+	}
+	files, err := wshclient.StorageReplicaRestoreCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: storageReplicaTimeout})
+	if err != nil {
+		return fmt.Errorf("restoring from replica: %w", err)
+	}
+	var numErrors int
+	for _, file := range files {
+		if file.Error != "" {
+			numErrors++
+			WriteStderr("%s: not restored: %s\n", file.Name, file.Error)
+			continue
+		}
+		WriteStdout("%s: restored (%s)\n", file.Name, formatStorageBytes(file.Size))
+	}
+	if numErrors > 0 {
+		return fmt.Errorf("%d files were not restored", numErrors)
+	}
+	return nil
+}
+
+func storageReplicaKeyRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandStorageReplicaKeyData{Key: storageReplicaSetKey}
+	key, err := wshclient.StorageReplicaKeyCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 30000})
+	if err != nil {
+		return fmt.Errorf("replica key: %w", err)
+	}
+	if storageReplicaSetKey != "" {
+		WriteStdout("replica key set\n")
+		return nil
+	}
+	WriteStdout("%s\n", key)
+	return nil
+}
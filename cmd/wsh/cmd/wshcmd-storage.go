@@ -0,0 +1,190 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var storageAll bool
+var storageCheckRepair bool
+var storagePruneDryRun bool
+
+var storageCmd = &cobra.Command{
+	Use:     "storage",
+	Short:   "show the storage used by the files of a block or workspace",
+	Long:    "Show the storage used by the files of a block (the current block by default), or of a workspace and its blocks (-b workspace), or of all the workspaces (--all).  The quotas are set with storage:maxblockbytes and storage:maxworkspacebytes, the oldest data is evicted over them.",
+	Example: "  wsh storage\n  wsh storage -b workspace\n  wsh storage --all",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("storage", storageRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var storageCheckCmd = &cobra.Command{
+	Use:     "check",
+	Short:   "check the stored files for corruption and inconsistencies",
+	Long:    "Check every stored part against its checksum, and the references between the parts, blobs and indexes of the stored files.  With --repair, the corrupt parts are quarantined (they read as zeros) and the inconsistencies are fixed.",
+	Example: "  wsh storage check\n  wsh storage check --repair",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("storage", storageCheckRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var storagePruneCmd = &cobra.Command{
+	Use:     "prune",
+	Short:   "run the storage:retention policies now",
+	Long:    "Delete the block files that are past the storage:retention policies now (they are also run every hour).  With --dry-run, the files are listed and not deleted.",
+	Example: "  wsh storage prune --dry-run\n  wsh storage prune",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("storage", storagePruneRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var storageCacheCmd = &cobra.Command{
+	Use:     "cache",
+	Short:   "show the stats of the block file cache",
+	Long:    "Show the hits, misses and evictions of the cache of the block file data read from disk, and its size and policy (set with storage:cachebytes and storage:cachepolicy).  The stats are also served by the metrics endpoint of the api.",
+	Example: "  wsh storage cache",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("storage", storageCacheRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	storageCmd.Flags().BoolVarP(&storageAll, "all", "a", false, "show all the workspaces")
+	storageCheckCmd.Flags().BoolVar(&storageCheckRepair, "repair", false, "repair the problems that are found")
+	storageCmd.AddCommand(storageCheckCmd)
+	storagePruneCmd.Flags().BoolVarP(&storagePruneDryRun, "dry-run", "n", false, "list the files that would be deleted")
+	storageCmd.AddCommand(storagePruneCmd)
+	storageCmd.AddCommand(storageCacheCmd)
+	rootCmd.AddCommand(storageCmd)
+}
+
+func formatStorageBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func storageRun(cmd *cobra.Command, args []string) error {
+	var data wshrpc.CommandStorageUsageData
+	if !storageAll {
+		oref, err := resolveBlockArg()
+		if err != nil {
+			return err
+		}
+		data.ORef = oref.String()
+	}
+	usage, err := wshclient.StorageUsageCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("getting storage usage: %w", err)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "OREF\tFILES\tSIZE\tSTORED\tEVICTED\tQUOTA\n")
+	for _, item := range usage {
+		quota := "-"
+		if item.MaxSize > 0 {
+			quota = formatStorageBytes(item.MaxSize)
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%s\t%s\n", item.ORef, item.NumFiles, formatStorageBytes(item.Size), formatStorageBytes(item.StoredSize), formatStorageBytes(item.Evicted), quota)
+	}
+	writer.Flush()
+	return nil
+}
+
+func storageCheckRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandStorageCheckData{Repair: storageCheckRepair}
+	result, err := wshclient.StorageCheckCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10 * 60 * 1000})
+	if err != nil {
+		return fmt.Errorf("checking storage: %w", err)
+	}
+	WriteStdout("checked %d blobs, %d parts (%d parts in quarantine)\n", result.NumBlobs, result.NumParts, result.NumQuarantined)
+	if len(result.Problems) == 0 {
+		WriteStdout("no problems found\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "PROBLEM\tFILE\tPART\tREPAIRED\tDETAIL\n")
+	numUnrepaired := 0
+	for _, problem := range result.Problems {
+		file := "-"
+		part := "-"
+		if problem.ZoneId != "" {
+			file = problem.ZoneId + ":" + problem.Name
+		}
+		if problem.ZoneId != "" && problem.Type != filestore.CheckProblem_Orphan {
+			part = fmt.Sprintf("%d", problem.PartIdx)
+		}
+		if !problem.Repaired {
+			numUnrepaired++
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%v\t%s\n", problem.Type, file, part, problem.Repaired, problem.Detail)
+	}
+	writer.Flush()
+	if numUnrepaired > 0 {
+		return fmt.Errorf("%d problems found", numUnrepaired)
+	}
+	return nil
+}
+
+func storagePruneRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandStoragePruneData{DryRun: storagePruneDryRun}
+	actions, err := wshclient.StoragePruneCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5 * 60 * 1000})
+	if err != nil {
+		return fmt.Errorf("running retention policies: %w", err)
+	}
+	if len(actions) == 0 {
+		WriteStdout("no files are past the retention policies\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "BLOCK\tFILE\tACTION\tSIZE\tPOLICY\n")
+	var total int64
+	for _, action := range actions {
+		total += action.Size
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", action.BlockId, action.Name, action.Action, formatStorageBytes(action.Size), action.Reason)
+	}
+	writer.Flush()
+	if storagePruneDryRun {
+		WriteStdout("%d files (%s) would be deleted or cleared\n", len(actions), formatStorageBytes(total))
+	} else {
+		WriteStdout("%d files (%s) deleted or cleared\n", len(actions), formatStorageBytes(total))
+	}
+	return nil
+}
+
+func storageCacheRun(cmd *cobra.Command, args []string) error {
+	stats, err := wshclient.StorageCacheCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("getting cache stats: %w", err)
+	}
+	hitRatio := 0.0
+	if stats.Hits+stats.Misses > 0 {
+		hitRatio = float64(stats.Hits) / float64(stats.Hits+stats.Misses) * 100
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "hits\t%d (%.1f%%)\n", stats.Hits, hitRatio)
+	fmt.Fprintf(writer, "misses\t%d\n", stats.Misses)
+	fmt.Fprintf(writer, "evictions\t%d\n", stats.Evictions)
+	fmt.Fprintf(writer, "read ahead\t%d parts\n", stats.ReadAheadParts)
+	fmt.Fprintf(writer, "cached\t%s / %s\n", formatStorageBytes(stats.CachedBytes), formatStorageBytes(stats.MaxBytes))
+	fmt.Fprintf(writer, "policy\t%s\n", stats.Policy)
+	fmt.Fprintf(writer, "open files\t%d (%d not flushed)\n", stats.OpenFiles, stats.DirtyFiles)
+	writer.Flush()
+	return nil
+}
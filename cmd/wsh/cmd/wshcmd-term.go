@@ -16,6 +16,7 @@ import (
 )
 
 var termMagnified bool
+var termEnvFrom string
 
 var termCmd = &cobra.Command{
 	Use:     "term",
@@ -27,6 +28,7 @@ var termCmd = &cobra.Command{
 
 func init() {
 	termCmd.Flags().BoolVarP(&termMagnified, "magnified", "m", false, "open view in magnified mode")
+	termCmd.Flags().StringVar(&termEnvFrom, "envfrom", "", "start with the env snapshot of this block (see wsh envsnapshot)")
 	rootCmd.AddCommand(termCmd)
 }
 
@@ -60,7 +62,17 @@ func termRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		waveobj.MetaKey_CmdCwd:     cwd,
 		waveobj.MetaKey_Controller: "shell",
 	}
-	if RpcContext.Conn != "" {
+	if termEnvFrom != "" {
+		envFromORef, err := resolveSimpleId(termEnvFrom)
+		if err != nil {
+			return fmt.Errorf("resolving envfrom block: %w", err)
+		}
+		createMeta[waveobj.MetaKey_CmdEnvFrom] = envFromORef.OID
+		// the connection comes from the snapshot, and so does the cwd unless one was given
+		if len(args) == 0 {
+			delete(createMeta, waveobj.MetaKey_CmdCwd)
+		}
+	} else if RpcContext.Conn != "" {
 		createMeta[waveobj.MetaKey_Connection] = RpcContext.Conn
 	}
 	createBlockData := wshrpc.CommandCreateBlockData{
@@ -0,0 +1,170 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var themeImportName string
+var themeImportFormat string
+var themeSetConn string
+var themeSetClear bool
+
+var themeCmd = &cobra.Command{
+	Use:   "theme",
+	Short: "manage the terminal themes",
+	Long:  "Commands to list, import and assign the terminal themes.  The themes are the built-in ones, the ones in termthemes.json, and the ones imported into wave (iTerm2 .itermcolors, Alacritty .toml, VS Code color themes, kitty themes, and Windows Terminal color schemes).  A theme is assigned to a block (term:theme in its meta) or to a connection (term:theme in connections.json).",
+}
+
+var themeListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the terminal themes",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("theme", themeListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var themeShowCmd = &cobra.Command{
+	Use:     "show NAME",
+	Short:   "print the colors of a theme (as json)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("theme", themeShowRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var themeImportCmd = &cobra.Command{
+	Use:     "import FILE",
+	Short:   "import a theme file (iterm2, alacritty, vscode, kitty, or windowsterminal)",
+	Example: "  wsh theme import ~/Downloads/Dracula.itermcolors\n  wsh theme import one-dark.json --name one-dark --format vscode",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("theme", themeImportRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var themeRemoveCmd = &cobra.Command{
+	Use:     "rm NAME",
+	Short:   "remove a theme imported into wave",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("theme", themeRemoveRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var themeSetCmd = &cobra.Command{
+	Use:     "set [NAME]",
+	Short:   "set the theme of a block (the current one, or -b) or of a connection (--conn)",
+	Example: "  wsh theme set dracula\n  wsh theme set solarized-dark --conn user@prod\n  wsh theme set --clear -b 2",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("theme", themeSetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	themeImportCmd.Flags().StringVarP(&themeImportName, "name", "n", "", "the name of the theme (the name in the file, or the file name, if not set)")
+	themeImportCmd.Flags().StringVar(&themeImportFormat, "format", "", "the format of the file: iterm2, alacritty, vscode, kitty or windowsterminal (detected if not set)")
+	themeSetCmd.Flags().StringVar(&themeSetConn, "conn", "", "set the theme of the connection")
+	themeSetCmd.Flags().BoolVar(&themeSetClear, "clear", false, "remove the theme (use the default one)")
+	rootCmd.AddCommand(themeCmd)
+	themeCmd.AddCommand(themeListCmd)
+	themeCmd.AddCommand(themeShowCmd)
+	themeCmd.AddCommand(themeImportCmd)
+	themeCmd.AddCommand(themeRemoveCmd)
+	themeCmd.AddCommand(themeSetCmd)
+}
+
+func themeListRun(cmd *cobra.Command, args []string) error {
+	themes, err := wshclient.ThemeListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing themes: %w", err)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "NAME\tDISPLAY NAME\tSOURCE\n")
+	for _, theme := range themes {
+		source := theme.Source
+		if theme.ImportedFrom != "" {
+			source = fmt.Sprintf("%s (%s)", source, theme.ImportedFrom)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", theme.Name, theme.DisplayName, source)
+	}
+	writer.Flush()
+	return nil
+}
+
+func themeShowRun(cmd *cobra.Command, args []string) error {
+	theme, err := wshclient.ThemeGetCommand(RpcClient, wshrpc.CommandThemeData{Name: args[0]}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("getting theme: %w", err)
+	}
+	barr, err := json.MarshalIndent(theme, "", "  ")
+	if err != nil {
+		return err
+	}
+	WriteStdout("%s\n", barr)
+	return nil
+}
+
+func themeImportRun(cmd *cobra.Command, args []string) error {
+	barr, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading theme file: %w", err)
+	}
+	data := wshrpc.CommandThemeImportData{
+		Name:     themeImportName,
+		Format:   themeImportFormat,
+		FileName: filepath.Base(args[0]),
+		Data:     string(barr),
+	}
+	theme, err := wshclient.ThemeImportCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return err
+	}
+	WriteStdout("theme %q imported (set it with: wsh theme set %s)\n", theme.Name, theme.Name)
+	return nil
+}
+
+func themeRemoveRun(cmd *cobra.Command, args []string) error {
+	err := wshclient.ThemeDeleteCommand(RpcClient, wshrpc.CommandThemeData{Name: args[0]}, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("removing theme: %w", err)
+	}
+	WriteStdout("theme %q removed\n", args[0])
+	return nil
+}
+
+func themeSetRun(cmd *cobra.Command, args []string) error {
+	if (len(args) == 0) != themeSetClear {
+		return fmt.Errorf("set a theme name, or --clear")
+	}
+	data := wshrpc.CommandThemeAssignData{Connection: themeSetConn}
+	if len(args) > 0 {
+		data.Theme = args[0]
+	}
+	target := fmt.Sprintf("connection %q", themeSetConn)
+	if themeSetConn == "" {
+		oref, err := resolveBlockArg()
+		if err != nil {
+			return err
+		}
+		data.BlockId = oref.OID
+		target = "block"
+	}
+	err := wshclient.ThemeAssignCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("setting theme: %w", err)
+	}
+	if data.Theme == "" {
+		WriteStdout("theme of the %s removed\n", target)
+	} else {
+		WriteStdout("theme of the %s set to %q\n", target, data.Theme)
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
@@ -21,6 +23,18 @@ func init() {
 	rootCmd.AddCommand(tokenCmd)
 }
 
+// wsh is installed in [wave home]/bin (locally and on remote hosts)
+func getWaveHomeFromExe() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if realPath, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = realPath
+	}
+	return filepath.Dir(filepath.Dir(exePath))
+}
+
 func tokenCmdRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	if len(args) != 2 {
 		OutputHelpMessage(cmd)
@@ -35,11 +49,16 @@ func tokenCmdRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	if err != nil {
 		return fmt.Errorf("error setting up rpc client: %w", err)
 	}
+	shellutil.FixTermEnv(rtnData.Env, getWaveHomeFromExe())
 	envScriptText, err := shellutil.EncodeEnvVarsForShell(shellType, rtnData.Env)
 	if err != nil {
 		return fmt.Errorf("error encoding env vars: %w", err)
 	}
 	WriteStdout("%s\n", envScriptText)
+	if shellType == shellutil.ShellType_nu {
+		// output must be a single json record, nushell cannot run the init script
+		return nil
+	}
 	WriteStdout("%s\n", rtnData.InitScriptText)
 	return nil
 }
@@ -0,0 +1,249 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var webhookAddEvents []string
+var webhookAddNonZero bool
+var webhookAddName string
+var webhookAddSecret string
+var webhookLogLimit int
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "manage the webhooks wave sends events to",
+	Long:  "Commands to manage webhooks.  Wave POSTs the events a webhook subscribes to (block:exit, workspace:create, workspace:delete, conn:agentforward, conn:state) to its url as json, signed with the webhook's secret.",
+}
+
+var webhookAddCmd = &cobra.Command{
+	Use:     "add URL",
+	Short:   "add a webhook (prints its id and secret, the secret is only shown here)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("webhook", webhookAddRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var webhookListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the webhooks",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("webhook", webhookListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var webhookRemoveCmd = &cobra.Command{
+	Use:     "rm ID",
+	Short:   "remove a webhook and its delivery log",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("webhook", webhookRemoveRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var webhookEnableCmd = &cobra.Command{
+	Use:     "enable ID",
+	Short:   "enable a webhook",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("webhook", webhookSetDisabledRun(false)),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var webhookDisableCmd = &cobra.Command{
+	Use:     "disable ID",
+	Short:   "disable a webhook (no events are sent to it until it is enabled)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("webhook", webhookSetDisabledRun(true)),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var webhookTestCmd = &cobra.Command{
+	Use:     "test ID",
+	Short:   "send a webhook:test event to a webhook",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("webhook", webhookTestRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var webhookLogCmd = &cobra.Command{
+	Use:     "log [ID]",
+	Short:   "show the recent deliveries of a webhook (or of all webhooks)",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    activityWrap("webhook", webhookLogRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	webhookAddCmd.Flags().StringArrayVarP(&webhookAddEvents, "event", "e", nil, "an event to send (block:exit, workspace:create, workspace:delete, or * for all), can be repeated")
+	webhookAddCmd.Flags().BoolVar(&webhookAddNonZero, "nonzero", false, "only send block:exit events when the exit code is not 0")
+	webhookAddCmd.Flags().StringVarP(&webhookAddName, "name", "n", "", "a name for the webhook")
+	webhookAddCmd.Flags().StringVar(&webhookAddSecret, "secret", "", "the secret to sign the payloads with (generated if not set)")
+	webhookLogCmd.Flags().IntVarP(&webhookLogLimit, "limit", "l", 20, "the number of deliveries to show")
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.AddCommand(webhookAddCmd)
+	webhookCmd.AddCommand(webhookListCmd)
+	webhookCmd.AddCommand(webhookRemoveCmd)
+	webhookCmd.AddCommand(webhookEnableCmd)
+	webhookCmd.AddCommand(webhookDisableCmd)
+	webhookCmd.AddCommand(webhookTestCmd)
+	webhookCmd.AddCommand(webhookLogCmd)
+}
+
+// accepts a full id or a unique prefix of one
+func resolveWebhookId(idArg string) (string, error) {
+	hooks, err := wshclient.WebhookListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return "", fmt.Errorf("listing webhooks: %w", err)
+	}
+	var found string
+	for _, hook := range hooks {
+		if hook.OID == idArg {
+			return hook.OID, nil
+		}
+		if strings.HasPrefix(hook.OID, idArg) {
+			if found != "" {
+				return "", fmt.Errorf("webhook id %q is ambiguous", idArg)
+			}
+			found = hook.OID
+		}
+	}
+	if found == "" {
+		return "", fmt.Errorf("webhook %q not found", idArg)
+	}
+	return found, nil
+}
+
+func webhookAddRun(cmd *cobra.Command, args []string) error {
+	if len(webhookAddEvents) == 0 {
+		return fmt.Errorf("at least one event is required (-e)")
+	}
+	data := wshrpc.CommandWebhookCreateData{Name: webhookAddName, Url: args[0], Secret: webhookAddSecret}
+	for _, event := range webhookAddEvents {
+		data.Filters = append(data.Filters, waveobj.WebhookFilter{Event: event, NonZeroExit: webhookAddNonZero && event == "block:exit"})
+	}
+	rtn, err := wshclient.WebhookCreateCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("adding webhook: %w", err)
+	}
+	WriteStdout("webhook %s added\n", rtn.Webhook.OID)
+	WriteStdout("secret: %s (it is not shown again)\n", rtn.Secret)
+	return nil
+}
+
+func formatWebhookFilters(filters []waveobj.WebhookFilter) string {
+	var parts []string
+	for _, filter := range filters {
+		if filter.NonZeroExit {
+			parts = append(parts, filter.Event+" (nonzero)")
+		} else {
+			parts = append(parts, filter.Event)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func webhookListRun(cmd *cobra.Command, args []string) error {
+	hooks, err := wshclient.WebhookListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing webhooks: %w", err)
+	}
+	if len(hooks) == 0 {
+		WriteStdout("no webhooks\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID\tNAME\tURL\tEVENTS\tSTATUS\n")
+	for _, hook := range hooks {
+		status := "enabled"
+		if hook.Disabled {
+			status = "disabled"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", hook.OID[:8], hook.Name, hook.Url, formatWebhookFilters(hook.Filters), status)
+	}
+	writer.Flush()
+	return nil
+}
+
+func webhookRemoveRun(cmd *cobra.Command, args []string) error {
+	webhookId, err := resolveWebhookId(args[0])
+	if err != nil {
+		return err
+	}
+	err = wshclient.WebhookDeleteCommand(RpcClient, wshrpc.CommandWebhookData{WebhookId: webhookId}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("removing webhook: %w", err)
+	}
+	return nil
+}
+
+func webhookSetDisabledRun(disabled bool) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		webhookId, err := resolveWebhookId(args[0])
+		if err != nil {
+			return err
+		}
+		data := wshrpc.CommandWebhookData{WebhookId: webhookId, Disabled: disabled}
+		_, err = wshclient.WebhookSetDisabledCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("updating webhook: %w", err)
+		}
+		return nil
+	}
+}
+
+func webhookTestRun(cmd *cobra.Command, args []string) error {
+	webhookId, err := resolveWebhookId(args[0])
+	if err != nil {
+		return err
+	}
+	err = wshclient.WebhookTestCommand(RpcClient, wshrpc.CommandWebhookData{WebhookId: webhookId}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("sending test event: %w", err)
+	}
+	WriteStdout("test event sent, see wsh webhook log %s\n", webhookId[:8])
+	return nil
+}
+
+func webhookLogRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandWebhookData{Limit: webhookLogLimit}
+	if len(args) > 0 {
+		webhookId, err := resolveWebhookId(args[0])
+		if err != nil {
+			return err
+		}
+		data.WebhookId = webhookId
+	}
+	deliveries, err := wshclient.WebhookDeliveriesCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("getting deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		WriteStdout("no deliveries\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "TIME\tWEBHOOK\tEVENT\tSTATUS\tATTEMPTS\tRESULT\n")
+	for _, delivery := range deliveries {
+		result := "-"
+		if delivery.Error != "" {
+			result = delivery.Error
+		} else if delivery.StatusCode != 0 {
+			result = fmt.Sprintf("%d", delivery.StatusCode)
+		}
+		ts := time.UnixMilli(delivery.Ts).Format("2006-01-02 15:04:05")
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%s\n", ts, delivery.WebhookId[:8], delivery.Event, delivery.Status, delivery.Attempts, result)
+	}
+	writer.Flush()
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var wslPathWindows bool
+
+var wslListCmd = &cobra.Command{
+	Use:     "ls",
+	Short:   "list the installed wsl distributions and their connections",
+	Args:    cobra.NoArgs,
+	RunE:    activityWrap("wsl", wslListRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var wslSetShellCmd = &cobra.Command{
+	Use:     "setshell DISTRIBUTION [SHELL]",
+	Short:   "set the shell of the new terminals on a distribution (the login shell if SHELL is not given)",
+	Example: "  wsh wsl setshell Ubuntu /usr/bin/zsh\n  wsh wsl setshell Ubuntu",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    activityWrap("wsl", wslSetShellRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var wslPathCmd = &cobra.Command{
+	Use:     "path [-d <distribution-name>] [--windows] PATH",
+	Short:   "translate a path between windows and wsl",
+	Long:    "Translate a windows path (C:\\... or \\\\wsl.localhost\\<distribution>\\...) to its path in wsl, or with --windows a path in a distribution (-d, the default distribution if it is not set) to its windows path.",
+	Example: "  wsh wsl path 'C:\\Users\\me\\notes.txt'\n  wsh wsl path --windows -d Ubuntu ~/project",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("wsl", wslPathRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	wslPathCmd.Flags().BoolVar(&wslPathWindows, "windows", false, "translate a wsl path to a windows path")
+	wslPathCmd.Flags().StringVarP(&distroName, "distribution", "d", "", "the distribution of the wsl path")
+	wslCmd.AddCommand(wslListCmd)
+	wslCmd.AddCommand(wslSetShellCmd)
+	wslCmd.AddCommand(wslPathCmd)
+}
+
+func wslListRun(cmd *cobra.Command, args []string) error {
+	distros, err := wshclient.WslDistroListCommand(RpcClient, nil)
+	if err != nil {
+		return fmt.Errorf("listing wsl distributions: %w", err)
+	}
+	if len(distros) == 0 {
+		WriteStdout("no wsl distributions\n")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(writer, "NAME\tCONNECTION\tSTATE\tVERSION\tSTATUS\tSHELL\n")
+	for _, distro := range distros {
+		name := distro.Name
+		if distro.Default {
+			name += " (default)"
+		}
+		shell := distro.ShellPath
+		if shell == "" {
+			shell = "(login shell)"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%s\t%s\n", name, distro.ConnName, distro.State, distro.Version, distro.Status.Status, shell)
+	}
+	writer.Flush()
+	return nil
+}
+
+func wslSetShellRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandWslSetShellData{Distro: args[0]}
+	if len(args) > 1 {
+		data.ShellPath = args[1]
+	}
+	err := wshclient.WslSetShellCommand(RpcClient, data, nil)
+	if err != nil {
+		return fmt.Errorf("setting the shell of %s: %w", args[0], err)
+	}
+	if data.ShellPath == "" {
+		WriteStdout("new terminals on %s use the login shell\n", args[0])
+	} else {
+		WriteStdout("new terminals on %s use %s\n", args[0], data.ShellPath)
+	}
+	return nil
+}
+
+func wslPathRun(cmd *cobra.Command, args []string) error {
+	data := wshrpc.CommandWslPathData{Path: args[0], ToWindows: wslPathWindows}
+	if wslPathWindows {
+		distro := distroName
+		if distro == "" {
+			if strings.HasPrefix(RpcContext.Conn, "wsl://") {
+				distro = RpcContext.Conn
+			} else {
+				defaultDistro, err := wshclient.WslDefaultDistroCommand(RpcClient, nil)
+				if err != nil {
+					return err
+				}
+				distro = defaultDistro
+			}
+		}
+		data.Distro = distro
+	}
+	rtn, err := wshclient.WslPathCommand(RpcClient, data, nil)
+	if err != nil {
+		return err
+	}
+	WriteStdout("%s\n", rtn.Path)
+	return nil
+}
@@ -4,7 +4,11 @@
 package main
 
 import (
+	"log"
+	"os"
+
 	"github.com/wavetermdev/waveterm/cmd/wsh/cmd"
+	"github.com/wavetermdev/waveterm/pkg/ptyhost"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 )
 
@@ -13,6 +17,15 @@ var WaveVersion = "0.0.0"
 var BuildTime = "0"
 
 func main() {
+	if len(os.Args) == 3 && os.Args[1] == ptyhost.HostArg {
+		// helper process that owns the pty of a persistent remote shell session (started by connserver)
+		err := ptyhost.RunHost(os.Args[2])
+		if err != nil {
+			log.Printf("ptyhost error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	wavebase.WaveVersion = WaveVersion
 	wavebase.BuildTime = BuildTime
 	cmd.Execute()
@@ -0,0 +1,3 @@
+DROP TABLE db_file_lineidx;
+
+ALTER TABLE db_wave_file DROP COLUMN numlines;
@@ -0,0 +1,10 @@
+ALTER TABLE db_wave_file ADD COLUMN numlines bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE db_file_lineidx (
+    zoneid varchar(36) NOT NULL,
+    name varchar(200) NOT NULL,
+    partidx int NOT NULL,
+    fileoffset bigint NOT NULL,
+    numlines bigint NOT NULL,
+    PRIMARY KEY(zoneid, name, partidx)
+);
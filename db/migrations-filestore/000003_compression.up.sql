@@ -0,0 +1 @@
+ALTER TABLE db_file_data ADD COLUMN compression varchar(20) NOT NULL DEFAULT '';
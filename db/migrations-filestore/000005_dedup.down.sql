@@ -0,0 +1,5 @@
+DROP INDEX idx_file_data_hash;
+
+ALTER TABLE db_file_data DROP COLUMN hash;
+
+DROP TABLE db_file_blob;
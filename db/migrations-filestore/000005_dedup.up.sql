@@ -0,0 +1,10 @@
+CREATE TABLE db_file_blob (
+    hash varchar(64) PRIMARY KEY,
+    data blob NOT NULL,
+    compression varchar(20) NOT NULL,
+    refcount int NOT NULL
+);
+
+ALTER TABLE db_file_data ADD COLUMN hash varchar(64) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_file_data_hash ON db_file_data (hash);
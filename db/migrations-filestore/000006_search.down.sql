@@ -0,0 +1,3 @@
+DROP TABLE db_file_text;
+
+DROP TABLE db_file_textidx;
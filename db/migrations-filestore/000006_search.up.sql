@@ -0,0 +1,10 @@
+CREATE TABLE db_file_textidx (
+    docid INTEGER PRIMARY KEY,
+    zoneid varchar(36) NOT NULL,
+    name varchar(200) NOT NULL,
+    partidx int NOT NULL,
+    fileoffset bigint NOT NULL,
+    UNIQUE (zoneid, name, partidx)
+);
+
+CREATE VIRTUAL TABLE db_file_text USING fts4(content, tokenize=unicode61);
@@ -0,0 +1 @@
+ALTER TABLE db_file_blob DROP COLUMN encryption;
@@ -0,0 +1 @@
+ALTER TABLE db_file_blob ADD COLUMN encryption varchar(20) NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+CREATE TABLE db_file_quarantine (
+    id INTEGER PRIMARY KEY,
+    zoneid varchar(36) NOT NULL,
+    name varchar(200) NOT NULL,
+    partidx int NOT NULL,
+    hash varchar(64) NOT NULL,
+    data blob NOT NULL,
+    compression varchar(20) NOT NULL,
+    encryption varchar(20) NOT NULL,
+    reason varchar(200) NOT NULL,
+    ts bigint NOT NULL
+);
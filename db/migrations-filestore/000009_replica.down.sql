@@ -0,0 +1,4 @@
+DROP TABLE db_file_replica_target;
+DROP TABLE db_file_replica;
+ALTER TABLE db_file_blob DROP COLUMN offloaded;
+ALTER TABLE db_file_blob DROP COLUMN replica;
@@ -0,0 +1,15 @@
+ALTER TABLE db_file_blob ADD COLUMN replica varchar(64) NOT NULL DEFAULT '';
+ALTER TABLE db_file_blob ADD COLUMN offloaded boolean NOT NULL DEFAULT 0;
+
+CREATE TABLE db_file_replica (
+    zoneid varchar(36) NOT NULL,
+    name varchar(200) NOT NULL,
+    modts bigint NOT NULL,
+    size bigint NOT NULL,
+    PRIMARY KEY (zoneid, name)
+);
+
+CREATE TABLE db_file_replica_target (
+    id int PRIMARY KEY,
+    target varchar(500) NOT NULL
+);
@@ -0,0 +1,13 @@
+CREATE TABLE db_cmdhistory (
+    historyid varchar(36) PRIMARY KEY,
+    ts bigint NOT NULL,
+    blockid varchar(36) NOT NULL,
+    workspaceid varchar(36) NOT NULL,
+    connname varchar(200) NOT NULL,
+    cwd text NOT NULL,
+    cmdstr text NOT NULL,
+    exitcode int NULL DEFAULT NULL,
+    durationms bigint NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_cmdhistory_ts ON db_cmdhistory (ts);
+CREATE INDEX idx_cmdhistory_blockid ON db_cmdhistory (blockid, ts);
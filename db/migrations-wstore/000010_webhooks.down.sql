@@ -0,0 +1,2 @@
+DROP TABLE db_webhook;
+DROP TABLE db_webhookdelivery;
@@ -0,0 +1,11 @@
+CREATE TABLE db_webhook (
+    oid varchar(36) PRIMARY KEY,
+    version int NOT NULL,
+    data json NOT NULL
+);
+
+CREATE TABLE db_webhookdelivery (
+    oid varchar(36) PRIMARY KEY,
+    version int NOT NULL,
+    data json NOT NULL
+);
@@ -0,0 +1 @@
+DROP TABLE db_connection;
@@ -0,0 +1,5 @@
+CREATE TABLE db_theme (
+    oid varchar(36) PRIMARY KEY,
+    version int NOT NULL,
+    data json NOT NULL
+);
@@ -0,0 +1,4 @@
+DROP INDEX idx_webhookdelivery_webhookid;
+UPDATE db_webhook SET data = json_set(data, '$.secret', (SELECT secret FROM db_webhooksecret WHERE webhookid = db_webhook.oid))
+    WHERE oid IN (SELECT webhookid FROM db_webhooksecret);
+DROP TABLE db_webhooksecret;
@@ -0,0 +1,8 @@
+CREATE TABLE db_webhooksecret (
+    webhookid varchar(36) PRIMARY KEY,
+    secret varchar(200) NOT NULL
+);
+INSERT INTO db_webhooksecret (webhookid, secret)
+    SELECT oid, json_extract(data, '$.secret') FROM db_webhook WHERE json_extract(data, '$.secret') IS NOT NULL;
+UPDATE db_webhook SET data = json_remove(data, '$.secret');
+CREATE INDEX idx_webhookdelivery_webhookid ON db_webhookdelivery (json_extract(data, '$.webhookid'), json_extract(data, '$.ts'));
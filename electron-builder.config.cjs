@@ -37,6 +37,7 @@ const config = {
     asarUnpack: [
         "dist/bin/**/*", // wavesrv and wsh binaries
         "dist/docsite/**/*", // the static docsite
+        "dist/frontend/**/*", // the frontend (served to browsers by the remote-access server)
     ],
     mac: {
         target: [
@@ -1,23 +1,68 @@
 // Copyright 2025, Command Line Inc.
 // SPDX-License-Identifier: Apache-2.0
 
-import { ipcMain } from "electron";
+import { RpcApi } from "@/app/store/wshclientapi";
 import { getWebServerEndpoint, getWSServerEndpoint } from "../frontend/util/endpoints";
+import { ElectronWshClient } from "./emain-wsh";
 
 const AuthKeyHeader = "X-AuthKey";
 export const WaveAuthKeyEnv = "WAVETERM_AUTH_KEY";
 export const AuthKey = crypto.randomUUID();
 
-ipcMain.on("get-auth-key", (event) => {
-    event.returnValue = AuthKey;
-});
+const TokenRotateInterval = 60 * 60 * 1000;
+
+// the auth key is only used by the main process.  each tab view gets its own client token (issued by wavesrv),
+// which is injected into the requests of that view.  other web contents (webviews, the hot spare tab) get no
+// header, so they cannot connect to wavesrv.
+type ClientTokenInfo = {
+    tabId: string;
+    token: string;
+};
+
+const clientTokens = new Map<number, ClientTokenInfo>(); // webcontents id => token
+
+export async function issueClientToken(webContentsId: number, tabId: string) {
+    const rtn = await RpcApi.AuthTokenIssueCommand(ElectronWshClient, { tabid: tabId });
+    clientTokens.set(webContentsId, { tabId, token: rtn.token });
+}
+
+export function revokeClientToken(webContentsId: number) {
+    const info = clientTokens.get(webContentsId);
+    if (info == null) {
+        return;
+    }
+    clientTokens.delete(webContentsId);
+    RpcApi.AuthTokenRevokeCommand(ElectronWshClient, { tabid: info.tabId }).catch((e) => {
+        console.log("error revoking client token", info.tabId, e);
+    });
+}
+
+async function rotateClientTokens() {
+    for (const [webContentsId, info] of Array.from(clientTokens.entries())) {
+        try {
+            await issueClientToken(webContentsId, info.tabId);
+        } catch (e) {
+            console.log("error rotating client token", info.tabId, e);
+        }
+    }
+}
+
+setInterval(rotateClientTokens, TokenRotateInterval);
 
 export function configureAuthKeyRequestInjection(session: Electron.Session) {
     const filter: Electron.WebRequestFilter = {
         urls: [`${getWebServerEndpoint()}/*`, `${getWSServerEndpoint()}/*`],
     };
     session.webRequest.onBeforeSendHeaders(filter, (details, callback) => {
-        details.requestHeaders[AuthKeyHeader] = AuthKey;
+        if (details.webContentsId == null) {
+            // requests from the main process
+            details.requestHeaders[AuthKeyHeader] = AuthKey;
+        } else {
+            const info = clientTokens.get(details.webContentsId);
+            if (info != null) {
+                details.requestHeaders[AuthKeyHeader] = info.token;
+            }
+        }
         callback({ requestHeaders: details.requestHeaders });
     });
 }
@@ -7,7 +7,7 @@ import { CHORD_TIMEOUT } from "@/util/sharedconst";
 import { Rectangle, shell, WebContentsView } from "electron";
 import { getWaveWindowById } from "emain/emain-window";
 import path from "path";
-import { configureAuthKeyRequestInjection } from "./authkey";
+import { configureAuthKeyRequestInjection, issueClientToken, revokeClientToken } from "./authkey";
 import { setWasActive } from "./emain-activity";
 import { handleCtrlShiftFocus, handleCtrlShiftState, shFrameNavHandler, shNavHandler } from "./emain-util";
 import { ElectronWshClient } from "./emain-wsh";
@@ -80,6 +80,7 @@ export class WaveTabView extends WebContentsView {
         }
         this.webContents.on("destroyed", () => {
             wcIdToWaveTabMap.delete(this.webContents.id);
+            revokeClientToken(this.webContents.id);
             removeWaveTabView(this.waveTabId);
             this.isDestroyed = true;
         });
@@ -142,6 +143,7 @@ export class WaveTabView extends WebContentsView {
         console.log("destroy tab", this.waveTabId);
         removeWaveTabView(this.waveTabId);
         if (!this.isDestroyed) {
+            revokeClientToken(this.webContents.id);
             this.webContents?.close();
         }
         this.isDestroyed = true;
@@ -227,6 +229,8 @@ export async function getOrCreateWebViewForTab(waveWindowId: string, tabId: stri
     tabView.lastUsedTs = Date.now();
     setWaveTabView(tabId, tabView);
     tabView.waveTabId = tabId;
+    // the frontend connects to wavesrv once it gets wave-init, the token has to be issued before that
+    await issueClientToken(tabView.webContents.id, tabId);
     tabView.webContents.on("will-navigate", shNavHandler);
     tabView.webContents.on("will-frame-navigate", shFrameNavHandler);
     tabView.webContents.on("did-attach-webview", (event, wc) => {
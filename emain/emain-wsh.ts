@@ -3,7 +3,7 @@
 
 import { WindowService } from "@/app/store/services";
 import { RpcApi } from "@/app/store/wshclientapi";
-import { Notification } from "electron";
+import { clipboard, Notification } from "electron";
 import { getResolvedUpdateChannel } from "emain/updater";
 import { RpcResponseHelper, WshClient } from "../frontend/app/store/wshclient";
 import { getWebContentsByBlockId, webGetSelector } from "./emain-web";
@@ -39,6 +39,15 @@ export class ElectronWshClientType extends WshClient {
         }).show();
     }
 
+    // used for OSC 52 (the controller checks the block's term:osc52 policy)
+    async handle_clipboardread(rh: RpcResponseHelper): Promise<string> {
+        return clipboard.readText();
+    }
+
+    async handle_clipboardwrite(rh: RpcResponseHelper, data: CommandClipboardWriteData) {
+        clipboard.writeText(data.text);
+    }
+
     async handle_getupdatechannel(rh: RpcResponseHelper): Promise<string> {
         return getResolvedUpdateChannel();
     }
@@ -4,7 +4,6 @@
 import { contextBridge, ipcRenderer, Rectangle, WebviewTag } from "electron";
 
 contextBridge.exposeInMainWorld("api", {
-    getAuthKey: () => ipcRenderer.sendSync("get-auth-key"),
     getIsDev: () => ipcRenderer.sendSync("get-is-dev"),
     getPlatform: () => ipcRenderer.sendSync("get-platform"),
     getCursorPoint: () => ipcRenderer.sendSync("get-cursor-point"),
@@ -9,7 +9,10 @@ import {
     FullSubBlockProps,
     SubBlockProps,
 } from "@/app/block/blocktypes";
+import { ClusterExecViewModel } from "@/app/view/clusterexec/clusterexec";
+import { ConnectionsViewModel } from "@/app/view/connections/connections";
 import { LauncherViewModel } from "@/app/view/launcher/launcher";
+import { PlayerViewModel } from "@/app/view/player/player";
 import { PreviewModel } from "@/app/view/preview/preview";
 import { SysinfoViewModel } from "@/app/view/sysinfo/sysinfo";
 import { VDomModel } from "@/app/view/vdom/vdom-model";
@@ -48,6 +51,9 @@ BlockRegistry.set("vdom", VDomModel);
 BlockRegistry.set("tips", QuickTipsViewModel);
 BlockRegistry.set("help", HelpViewModel);
 BlockRegistry.set("launcher", LauncherViewModel);
+BlockRegistry.set("player", PlayerViewModel);
+BlockRegistry.set("connections", ConnectionsViewModel);
+BlockRegistry.set("clusterexec", ClusterExecViewModel);
 
 function makeViewModel(blockId: string, blockView: string, nodeModel: BlockNodeModel): ViewModel {
     const ctor = BlockRegistry.get(blockView);
@@ -10,6 +10,7 @@ import {
     atoms,
     getBlockComponentModel,
     getConnStatusAtom,
+    getOverrideConfigAtom,
     getSettingsKeyAtom,
     globalStore,
     recordTEvent,
@@ -18,6 +19,7 @@ import {
 } from "@/app/store/global";
 import { RpcApi } from "@/app/store/wshclientapi";
 import { TabRpcClient } from "@/app/store/wshrpcutil";
+import { DefaultTermTheme } from "@/app/view/term/termutil";
 import { ErrorBoundary } from "@/element/errorboundary";
 import { IconButton, ToggleIconButton } from "@/element/iconbutton";
 import { MagnifyIcon } from "@/element/magnify";
@@ -34,7 +36,28 @@ import { BlockFrameProps } from "./blocktypes";
 
 const NumActiveConnColors = 8;
 
-function handleHeaderContextMenu(
+async function getBackendActionMenuItems(blockData: Block): Promise<ContextMenuItem[]> {
+    const oref = WOS.makeORef("block", blockData.oid);
+    let actions: ActionDef[] = [];
+    try {
+        actions = await RpcApi.ListActionsCommand(TabRpcClient, { oref });
+    } catch (e) {
+        console.error("error listing block actions", e);
+        return [];
+    }
+    return (actions ?? []).map((action) => ({
+        label: action.title,
+        click: () => {
+            RpcApi.ExecuteActionCommand(TabRpcClient, {
+                actionid: action.actionid,
+                oref,
+                tabid: globalStore.get(atoms.staticTabId),
+            }).catch((e) => console.error("error executing action", action.actionid, e));
+        },
+    }));
+}
+
+async function handleHeaderContextMenu(
     e: React.MouseEvent<HTMLDivElement>,
     blockData: Block,
     viewModel: ViewModel,
@@ -72,6 +95,8 @@ function handleHeaderContextMenu(
     ];
     const extraItems = viewModel?.getSettingsMenuItems?.();
     if (extraItems && extraItems.length > 0) menu.push({ type: "separator" }, ...extraItems);
+    const actionItems = await getBackendActionMenuItems(blockData);
+    if (actionItems.length > 0) menu.push({ type: "separator" }, ...actionItems);
     menu.push(
         { type: "separator" },
         {
@@ -482,6 +507,9 @@ const BlockMask = React.memo(({ nodeModel }: { nodeModel: NodeModel }) => {
     const blockNum = jotai.useAtomValue(nodeModel.blockNum);
     const isLayoutMode = jotai.useAtomValue(atoms.controlShiftDelayAtom);
     const [blockData] = WOS.useWaveObjectValue<Block>(WOS.makeORef("block", nodeModel.blockId));
+    const fullConfig = jotai.useAtomValue(atoms.fullConfigAtom);
+    const termThemeName =
+        jotai.useAtomValue(getOverrideConfigAtom(nodeModel.blockId, "term:theme")) ?? DefaultTermTheme;
     const style: React.CSSProperties = {};
     let showBlockMask = false;
     if (isFocused) {
@@ -490,6 +518,11 @@ const BlockMask = React.memo(({ nodeModel }: { nodeModel: NodeModel }) => {
         if (tabActiveBorderColor) {
             style.borderColor = tabActiveBorderColor;
         }
+        // the accent color of the terminal theme (from an imported theme)
+        const themeAccent = fullConfig?.termthemes?.[termThemeName]?.accent;
+        if (blockData?.meta?.view == "term" && themeAccent) {
+            style.borderColor = themeAccent;
+        }
         if (blockData?.meta?.["frame:activebordercolor"]) {
             style.borderColor = blockData.meta["frame:activebordercolor"];
         }
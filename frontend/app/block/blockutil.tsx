@@ -30,6 +30,15 @@ export function blockViewToIcon(view: string): string {
     if (view == "tips") {
         return "lightbulb";
     }
+    if (view == "player") {
+        return "circle-play";
+    }
+    if (view == "connections") {
+        return "network-wired";
+    }
+    if (view == "clusterexec") {
+        return "server";
+    }
     return "square";
 }
 
@@ -55,6 +64,15 @@ export function blockViewToName(view: string): string {
     if (view == "tips") {
         return "Tips";
     }
+    if (view == "player") {
+        return "Player";
+    }
+    if (view == "connections") {
+        return "Connections";
+    }
+    if (view == "clusterexec") {
+        return "Cluster Exec";
+    }
     return view;
 }
 
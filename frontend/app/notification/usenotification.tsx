@@ -1,7 +1,7 @@
 // Copyright 2025, Command Line Inc.
 // SPDX-License-Identifier: Apache-2.0
 
-import { atoms, getApi } from "@/store/global";
+import { atoms, dismissBackendNotifications, getApi } from "@/store/global";
 import { useAtom, useAtomValue } from "jotai";
 import { useCallback, useEffect, useState } from "react";
 
@@ -19,9 +19,10 @@ export function useNotification() {
 
     const removeNotification = useCallback(
         (id: string) => {
+            dismissBackendNotifications(notifications.filter((n) => n.id === id));
             setNotifications((prevNotifications) => prevNotifications.filter((n) => n.id !== id));
         },
-        [setNotifications]
+        [notifications, setNotifications]
     );
 
     const hideNotification = useCallback(
@@ -38,8 +39,9 @@ export function useNotification() {
     }, [setNotifications]);
 
     const removeAllNotifications = useCallback(() => {
+        dismissBackendNotifications(notifications.filter((n) => !n.persistent));
         setNotifications((prevNotifications) => prevNotifications.filter((n) => n.persistent));
-    }, [setNotifications]);
+    }, [notifications, setNotifications]);
 
     const copyNotification = useCallback(
         (id: string) => {
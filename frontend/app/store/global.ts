@@ -18,13 +18,14 @@ import {
 import { getWebServerEndpoint } from "@/util/endpoints";
 import { fetch } from "@/util/fetchutil";
 import { setPlatform } from "@/util/platformutil";
-import { deepCompareReturnPrev, getPrefixedSettings, isBlank } from "@/util/util";
+import { deepCompareReturnPrev, fireAndForget, getPrefixedSettings, isBlank } from "@/util/util";
 import { atom, Atom, PrimitiveAtom, useAtomValue } from "jotai";
 import { globalStore } from "./jotaiStore";
 import { modalsModel } from "./modalmodel";
 import { ClientService, ObjectService } from "./services";
 import * as WOS from "./wos";
-import { getFileSubject, waveEventSubscribe } from "./wps";
+import { getFileSubject, invalidateFileSubjects, replayWaveEvents, waveEventSubscribe } from "./wps";
+import { addWSReconnectHandler, FeClientId } from "./ws";
 
 let atoms: GlobalAtomsType;
 let globalEnvironment: "electron" | "renderer";
@@ -53,6 +54,7 @@ function initGlobalAtoms(initOpts: GlobalInitOptions) {
         const uiContext: UIContext = {
             windowid: initOpts.windowId,
             activetabid: initOpts.tabId,
+            feclientid: FeClientId,
         };
         return uiContext;
     }) as Atom<UIContext>;
@@ -97,8 +99,18 @@ function initGlobalAtoms(initOpts: GlobalInitOptions) {
         return WOS.getObjectValue(WOS.makeORef("workspace", windowData.workspaceid), get);
     });
     const fullConfigAtom = atom(null) as PrimitiveAtom<FullConfigType>;
+    // the settings of wave and of the workspace (see pkg/wsettings), they override settings.json
+    const settingsOverridesAtom = atom({}) as PrimitiveAtom<MetaType>;
     const settingsAtom = atom((get) => {
-        return get(fullConfigAtom)?.settings ?? {};
+        const settings = get(fullConfigAtom)?.settings ?? {};
+        const overrides = get(settingsOverridesAtom);
+        const rtn: SettingsType = { ...settings, ...overrides };
+        // the locked settings of every layer apply (see getOverrideConfigAtom)
+        const locked = new Set([...(settings["settings:locked"] ?? []), ...(overrides["settings:locked"] ?? [])]);
+        if (locked.size > 0) {
+            rtn["settings:locked"] = Array.from(locked).sort();
+        }
+        return rtn;
     }) as Atom<SettingsType>;
     const tabAtom: Atom<Tab> = atom((get) => {
         return WOS.getObjectValue(WOS.makeORef("tab", initOpts.tabId), get);
@@ -154,6 +166,7 @@ function initGlobalAtoms(initOpts: GlobalInitOptions) {
         waveWindow: windowDataAtom,
         workspace: workspaceAtom,
         fullConfigAtom,
+        settingsOverridesAtom,
         settingsAtom,
         tabAtom,
         staticTabId: staticTabIdAtom,
@@ -180,6 +193,10 @@ function initGlobalWaveEventSubs(initOpts: WaveInitOpts) {
                 // console.log("waveobj:update wave event handler", event);
                 const update: WaveObjUpdate = event.data;
                 WOS.updateWaveObject(update);
+                if (update.otype == "notification" && update.updatetype == "delete") {
+                    // dismissed in another window (or with wsh)
+                    removeNotificationById(update.oid);
+                }
             },
         },
         {
@@ -190,6 +207,16 @@ function initGlobalWaveEventSubs(initOpts: WaveInitOpts) {
                 globalStore.set(atoms.fullConfigAtom, fullConfig);
             },
         },
+        {
+            eventType: "settings:change",
+            handler: (event) => {
+                const change = event.data as SettingsChangeEventData;
+                const workspaceId = globalStore.get(atoms.waveWindow)?.workspaceid;
+                if (isBlank(change?.workspaceid) || change.workspaceid == workspaceId) {
+                    fireAndForget(loadSettingsOverrides);
+                }
+            },
+        },
         {
             eventType: "userinput",
             handler: (event) => {
@@ -209,8 +236,41 @@ function initGlobalWaveEventSubs(initOpts: WaveInitOpts) {
                     fileSubject.next(fileData);
                 }
             },
+        },
+        {
+            eventType: "notification",
+            handler: (event) => {
+                const data: NotificationEventData = event.data;
+                pushNotification(makeEventNotification(data, new Date()));
+            },
+            scope: WOS.makeORef("window", initOpts.windowId),
+        },
+        {
+            eventType: "events:dropped",
+            handler: (event) => {
+                // this client fell behind and wavesrv dropped its events, reload what they would have updated
+                console.log("wave events dropped", event.data?.count);
+                WOS.reloadAllWaveObjects();
+                invalidateFileSubjects();
+            },
         }
     );
+    addWSReconnectHandler(() => fireAndForget(resyncAfterReconnect));
+}
+
+// replays the events missed while the websocket was disconnected, reloads the wave objects if they are no longer
+// buffered.  the file subscribers always catch up from the filestore (blockfile events are not replayed).
+async function resyncAfterReconnect() {
+    let replayed = false;
+    try {
+        replayed = await replayWaveEvents((data) => RpcApi.EventReplayCommand(TabRpcClient, data));
+    } catch (e) {
+        console.log("error replaying wave events", e);
+    }
+    if (!replayed) {
+        WOS.reloadAllWaveObjects();
+    }
+    invalidateFileSubjects();
 }
 
 const blockCache = new Map<string, Map<string, any>>();
@@ -274,6 +334,11 @@ function getOverrideConfigAtom<T extends keyof SettingsType>(blockId: string, ke
         return overrideAtom;
     }
     overrideAtom = atom((get) => {
+        // a locked setting (settings:locked) can't be overridden by the block or its connection
+        const lockedKeys = get(getSettingsKeyAtom("settings:locked"));
+        if (lockedKeys?.includes(key) && get(getSettingsKeyAtom(key)) != null) {
+            return get(getSettingsKeyAtom(key));
+        }
         const blockMetaKeyAtom = getBlockMetaKeyAtom(blockId, key as any);
         const metaKeyVal = get(blockMetaKeyAtom);
         if (metaKeyVal != null) {
@@ -622,6 +687,67 @@ function countersPrint() {
     console.log(outStr);
 }
 
+function makeEventNotification(data: NotificationEventData, ts: Date): NotificationType {
+    return {
+        id: data.id,
+        icon: data.type == "error" ? "circle-exclamation" : "bell",
+        title: data.title,
+        message: data.message,
+        timestamp: ts.toLocaleString(),
+        type: (data.type as NotificationType["type"]) ?? "info",
+        backend: data.id != null,
+    };
+}
+
+// the notifications that were sent before this window was opened (and not dismissed)
+async function loadNotifications() {
+    const notifs = await RpcApi.NotificationListCommand(TabRpcClient);
+    for (const notif of (notifs ?? []).reverse()) {
+        if (!notif.delivered) {
+            // held by do-not-disturb
+            continue;
+        }
+        const data: NotificationEventData = {
+            id: notif.oid,
+            title: notif.title,
+            message: notif.message,
+            type: notif.type,
+        };
+        // only shown in the notification list, not as a toast
+        pushNotification({ ...makeEventNotification(data, new Date(notif.updatedts)), hidden: true });
+    }
+}
+
+function dismissBackendNotifications(notifs: NotificationType[]) {
+    for (const notif of notifs) {
+        if (!notif.backend) {
+            continue;
+        }
+        fireAndForget(() => RpcApi.NotificationDismissCommand(TabRpcClient, { id: notif.id }));
+    }
+}
+
+async function loadSettingsOverrides() {
+    const workspaceId = globalStore.get(atoms.waveWindow)?.workspaceid;
+    const globalSettings = await RpcApi.SettingsGetCommand(TabRpcClient, {});
+    let workspaceSettings: MetaType = {};
+    if (!isBlank(workspaceId)) {
+        workspaceSettings = await RpcApi.SettingsGetCommand(TabRpcClient, { workspaceid: workspaceId });
+    }
+    // the active profile is applied over the global settings and under the workspace
+    const profileSettings: MetaType = (await RpcApi.ProfileListCommand(TabRpcClient))?.activesettings ?? {};
+    const overrides: MetaType = { ...globalSettings, ...profileSettings, ...workspaceSettings };
+    const locked = [
+        ...(globalSettings["settings:locked"] ?? []),
+        ...(profileSettings["settings:locked"] ?? []),
+        ...(workspaceSettings["settings:locked"] ?? []),
+    ];
+    if (locked.length > 0) {
+        overrides["settings:locked"] = locked;
+    }
+    globalStore.set(atoms.settingsOverridesAtom, overrides);
+}
+
 async function loadConnStatus() {
     const connStatusArr = await ClientService.GetAllConnStatus();
     if (connStatusArr == null) {
@@ -767,6 +893,7 @@ export {
     createBlockSplitHorizontally,
     createBlockSplitVertically,
     createTab,
+    dismissBackendNotifications,
     fetchWaveFile,
     getAllBlockComponentModels,
     getApi,
@@ -785,6 +912,8 @@ export {
     initGlobalWaveEventSubs,
     isDev,
     loadConnStatus,
+    loadNotifications,
+    loadSettingsOverrides,
     openLink,
     pushFlashError,
     pushNotification,
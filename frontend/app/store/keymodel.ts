@@ -17,6 +17,8 @@ import {
     replaceBlock,
     WOS,
 } from "@/app/store/global";
+import { RpcApi } from "@/app/store/wshclientapi";
+import { TabRpcClient } from "@/app/store/wshrpcutil";
 import {
     deleteLayoutModelForTab,
     getLayoutModelForTab,
@@ -26,15 +28,17 @@ import {
 import { getLayoutModelForStaticTab } from "@/layout/lib/layoutModelHooks";
 import * as keyutil from "@/util/keyutil";
 import { CHORD_TIMEOUT } from "@/util/sharedconst";
-import { fireAndForget } from "@/util/util";
+import { fireAndForget, isBlank } from "@/util/util";
 import * as jotai from "jotai";
 import { modalsModel } from "./modalmodel";
+import { waveEventSubscribe } from "./wps";
 
 type KeyHandler = (event: WaveKeyboardEvent) => boolean;
 
 const simpleControlShiftAtom = jotai.atom(false);
 const globalKeyMap = new Map<string, (waveEvent: WaveKeyboardEvent) => boolean>();
 const globalChordMap = new Map<string, Map<string, KeyHandler>>();
+const globalActions = new Map<string, KeyHandler>(); // the frontend actions (see pkg/keybind), by name
 
 // track current chord state and timeout (for resetting)
 let activeChord: string | null = null;
@@ -360,48 +364,40 @@ function countTermBlocks(): number {
 }
 
 function registerGlobalKeys() {
-    globalKeyMap.set("Cmd:]", () => {
+    globalActions.set("tab:next", () => {
         switchTab(1);
         return true;
     });
-    globalKeyMap.set("Shift:Cmd:]", () => {
-        switchTab(1);
-        return true;
-    });
-    globalKeyMap.set("Cmd:[", () => {
-        switchTab(-1);
-        return true;
-    });
-    globalKeyMap.set("Shift:Cmd:[", () => {
+    globalActions.set("tab:prev", () => {
         switchTab(-1);
         return true;
     });
-    globalKeyMap.set("Cmd:n", () => {
+    globalActions.set("block:new", () => {
         handleCmdN();
         return true;
     });
-    globalKeyMap.set("Cmd:d", () => {
+    globalActions.set("block:splitright", () => {
         handleSplitHorizontal("after");
         return true;
     });
-    globalKeyMap.set("Shift:Cmd:d", () => {
+    globalActions.set("block:splitdown", () => {
         handleSplitVertical("after");
         return true;
     });
-    globalKeyMap.set("Cmd:i", () => {
+    globalActions.set("block:refocus", () => {
         handleCmdI();
         return true;
     });
-    globalKeyMap.set("Cmd:t", () => {
+    globalActions.set("tab:new", () => {
         createTab();
         return true;
     });
-    globalKeyMap.set("Cmd:w", () => {
+    globalActions.set("block:close", () => {
         const tabId = globalStore.get(atoms.staticTabId);
         genericClose(tabId);
         return true;
     });
-    globalKeyMap.set("Cmd:Shift:w", () => {
+    globalActions.set("tab:close", () => {
         const tabId = globalStore.get(atoms.staticTabId);
         const ws = globalStore.get(atoms.workspace);
         if (ws.pinnedtabids?.includes(tabId)) {
@@ -414,7 +410,7 @@ function registerGlobalKeys() {
         getApi().closeTab(ws.oid, tabId);
         return true;
     });
-    globalKeyMap.set("Cmd:m", () => {
+    globalActions.set("block:magnify", () => {
         const layoutModel = getLayoutModelForStaticTab();
         const focusedNode = globalStore.get(layoutModel.focusedNode);
         if (focusedNode != null) {
@@ -422,27 +418,27 @@ function registerGlobalKeys() {
         }
         return true;
     });
-    globalKeyMap.set("Ctrl:Shift:ArrowUp", () => {
+    globalActions.set("block:focusup", () => {
         const tabId = globalStore.get(atoms.staticTabId);
         switchBlockInDirection(tabId, NavigateDirection.Up);
         return true;
     });
-    globalKeyMap.set("Ctrl:Shift:ArrowDown", () => {
+    globalActions.set("block:focusdown", () => {
         const tabId = globalStore.get(atoms.staticTabId);
         switchBlockInDirection(tabId, NavigateDirection.Down);
         return true;
     });
-    globalKeyMap.set("Ctrl:Shift:ArrowLeft", () => {
+    globalActions.set("block:focusleft", () => {
         const tabId = globalStore.get(atoms.staticTabId);
         switchBlockInDirection(tabId, NavigateDirection.Left);
         return true;
     });
-    globalKeyMap.set("Ctrl:Shift:ArrowRight", () => {
+    globalActions.set("block:focusright", () => {
         const tabId = globalStore.get(atoms.staticTabId);
         switchBlockInDirection(tabId, NavigateDirection.Right);
         return true;
     });
-    globalKeyMap.set("Ctrl:Shift:k", () => {
+    globalActions.set("block:launcher", () => {
         const blockId = getFocusedBlockId();
         if (blockId == null) {
             return true;
@@ -454,14 +450,14 @@ function registerGlobalKeys() {
         });
         return true;
     });
-    globalKeyMap.set("Cmd:g", () => {
+    globalActions.set("conn:switch", () => {
         const bcm = getBlockComponentModel(getFocusedBlockInStaticTab());
         if (bcm.openSwitchConnection != null) {
             bcm.openSwitchConnection();
             return true;
         }
     });
-    globalKeyMap.set("Ctrl:Shift:i", () => {
+    globalActions.set("term:multiinput", () => {
         const curMI = globalStore.get(atoms.isTermMultiInput);
         if (!curMI && countTermBlocks() <= 1) {
             // don't turn on multi-input unless there are 2 or more basic term blocks
@@ -471,15 +467,11 @@ function registerGlobalKeys() {
         return true;
     });
     for (let idx = 1; idx <= 9; idx++) {
-        globalKeyMap.set(`Cmd:${idx}`, () => {
+        globalActions.set(`tab:switch${idx}`, () => {
             switchTabAbs(idx);
             return true;
         });
-        globalKeyMap.set(`Ctrl:Shift:c{Digit${idx}}`, () => {
-            switchBlockByBlockNum(idx);
-            return true;
-        });
-        globalKeyMap.set(`Ctrl:Shift:c{Numpad${idx}}`, () => {
+        globalActions.set(`block:focus${idx}`, () => {
             switchBlockByBlockNum(idx);
             return true;
         });
@@ -504,8 +496,8 @@ function registerGlobalKeys() {
         }
         return false;
     }
-    globalKeyMap.set("Cmd:f", activateSearch);
-    globalKeyMap.set("Escape", () => {
+    globalActions.set("search:open", activateSearch);
+    globalActions.set("app:dismiss", () => {
         if (modalsModel.hasOpenModals()) {
             modalsModel.popModal();
             return true;
@@ -515,29 +507,82 @@ function registerGlobalKeys() {
         }
         return false;
     });
-    const allKeys = Array.from(globalKeyMap.keys());
-    // special case keys, handled by web view
-    allKeys.push("Cmd:l", "Cmd:r", "Cmd:ArrowRight", "Cmd:ArrowLeft", "Cmd:o");
-    getApi().registerGlobalWebviewKeys(allKeys);
-
-    const splitBlockKeys = new Map<string, KeyHandler>();
-    splitBlockKeys.set("ArrowUp", () => {
+    globalActions.set("block:splitup", () => {
         handleSplitVertical("before");
         return true;
     });
-    splitBlockKeys.set("ArrowDown", () => {
-        handleSplitVertical("after");
-        return true;
-    });
-    splitBlockKeys.set("ArrowLeft", () => {
+    globalActions.set("block:splitleft", () => {
         handleSplitHorizontal("before");
         return true;
     });
-    splitBlockKeys.set("ArrowRight", () => {
-        handleSplitHorizontal("after");
-        return true;
+    waveEventSubscribe({
+        eventType: "settings:change",
+        handler: (event) => {
+            const change = event.data as SettingsChangeEventData;
+            if (!change?.keys?.includes("app:keybindings")) {
+                return;
+            }
+            if (isBlank(change.workspaceid) || change.workspaceid == globalStore.get(atoms.workspace)?.oid) {
+                fireAndForget(loadKeyBindings);
+            }
+        },
     });
-    globalChordMap.set("Ctrl:Shift:s", splitBlockKeys);
+    fireAndForget(loadKeyBindings);
+}
+
+// runs a backend (registered) action on the focused block
+function runBackendAction(actionId: string): boolean {
+    const blockId = getFocusedBlockInStaticTab();
+    if (blockId == null) {
+        return false;
+    }
+    fireAndForget(() =>
+        RpcApi.ExecuteActionCommand(TabRpcClient, {
+            actionid: actionId,
+            oref: WOS.makeORef("block", blockId),
+            tabid: globalStore.get(atoms.staticTabId),
+        })
+    );
+    return true;
+}
+
+// the keys are bound on the server (the default keymap with the app:keybindings setting), a chord is two keys
+// separated by a space
+async function loadKeyBindings() {
+    const workspaceId = globalStore.get(atoms.workspace)?.oid;
+    const data = await RpcApi.KeyBindingsListCommand(TabRpcClient, { workspaceid: workspaceId });
+    for (const conflict of data.conflicts ?? []) {
+        console.log("keybinding conflict", conflict.keys, conflict.action, conflict.shadowed, conflict.reason);
+    }
+    for (const errStr of data.errors ?? []) {
+        console.log("keybinding error", errStr);
+    }
+    globalKeyMap.clear();
+    globalChordMap.clear();
+    for (const binding of data.bindings ?? []) {
+        const handler: KeyHandler = binding.backend
+            ? () => runBackendAction(binding.action)
+            : globalActions.get(binding.action);
+        if (handler == null) {
+            console.log("keybinding for an unknown action", binding.action);
+            continue;
+        }
+        const [firstKey, secondKey] = binding.keys.split(" ");
+        if (secondKey == null) {
+            globalKeyMap.set(firstKey, handler);
+            continue;
+        }
+        let chordKeys = globalChordMap.get(firstKey);
+        if (chordKeys == null) {
+            chordKeys = new Map<string, KeyHandler>();
+            globalChordMap.set(firstKey, chordKeys);
+        }
+        chordKeys.set(secondKey, handler);
+    }
+    const allKeys = Array.from(globalKeyMap.keys());
+    // special case keys, handled by web view
+    allKeys.push("Cmd:l", "Cmd:r", "Cmd:ArrowRight", "Cmd:ArrowLeft", "Cmd:o");
+    getApi().registerGlobalWebviewKeys(allKeys);
 }
 
 function getAllGlobalKeyBindings(): string[] {
@@ -565,6 +610,7 @@ export {
     getSimpleControlShiftAtom,
     globalRefocus,
     globalRefocusWithTimeout,
+    loadKeyBindings,
     registerControlShiftStateUpdateHandler,
     registerElectronReinjectKeyHandler,
     registerGlobalKeys,
@@ -0,0 +1,534 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// generated by cmd/generate/main-generatets.go from the automation api schema (schema/api.json)
+
+export type ApiBlockInputData = {
+    text: string;
+    signal: string;
+};
+
+export type ApiBlockMetaData = {
+    meta: MetaMapType;
+};
+
+export type ApiBlockRunData = {
+    cmd: string;
+};
+
+export type ApiCreateBlockData = {
+    meta: MetaMapType;
+    magnified: boolean;
+    targetblockid: string;
+    targetaction: string;
+};
+
+export type ApiError = {
+    error: string;
+};
+
+export type ApiNotificationData = {
+    title: string;
+    message: string;
+    type: string;
+    blockid: string;
+    dedupkey: string;
+    desktop: boolean;
+};
+
+export type ApiOutputData = {
+    output: string;
+    offset: number;
+};
+
+export type ApiTabData = {
+    name: string;
+    activate: boolean;
+    pinned: boolean;
+};
+
+export type ApiWorkspace = {
+    workspace: Workspace;
+    windowid?: string;
+};
+
+export type ApiWorkspaceData = {
+    name: string;
+    icon: string;
+    color: string;
+};
+
+export type Block = {
+    oid: string;
+    parentoref?: string;
+    version: number;
+    runtimeopts?: RuntimeOpts;
+    stickers?: StickerType[];
+    meta: MetaMapType;
+    subblockids?: string[];
+    defhash?: string;
+};
+
+export type BlockControllerRuntimeStatus = {
+    blockid: string;
+    version: number;
+    shellprocstatus?: string;
+    shellprocconnname?: string;
+    shellprocexitcode: number;
+    outputbufferdepth?: number;
+    outputpaused?: boolean;
+    recording?: boolean;
+    altscreen?: boolean;
+    secureinput?: boolean;
+    activepane?: string;
+    panes?: TermPaneInfo[];
+    connfrozen?: boolean;
+};
+
+export type BlockDef = {
+    files?: {[key: string]: FileDef};
+    meta?: MetaMapType;
+};
+
+export type Client = {
+    oid: string;
+    version: number;
+    windowids: string[];
+    meta: MetaMapType;
+    tosagreed?: number;
+    hasoldhistory?: boolean;
+    tempoid?: string;
+    activeprofile?: string;
+};
+
+export type CloseTabRtnType = {
+    closewindow?: boolean;
+    newactivetabid?: string;
+};
+
+export type ConnStatus = {
+    status: string;
+    wshenabled: boolean;
+    connection: string;
+    connected: boolean;
+    hasconnected: boolean;
+    activeconnnum: number;
+    error?: string;
+    wsherror?: string;
+    nowshreason?: string;
+    wshversion?: string;
+    attempt?: number;
+    nextretryts?: number;
+    latencyms?: number;
+};
+
+export type Connection = {
+    oid: string;
+    version: number;
+    name: string;
+    host: string;
+    user?: string;
+    port?: string;
+    authmethod?: string;
+    identityfile?: string;
+    certificatefile?: string;
+    secret?: string;
+    proxyjump?: string[];
+    forwardagent?: boolean;
+    forwards?: PortForward[];
+    tags?: string[];
+    createdts: number;
+    meta: MetaMapType;
+};
+
+export type FileDef = {
+    content?: string;
+    meta?: {[key: string]: any};
+};
+
+export type LayoutActionData = {
+    actiontype: string;
+    blockid: string;
+    nodesize?: number;
+    indexarr?: number[];
+    focused: boolean;
+    magnified: boolean;
+    ephemeral: boolean;
+    targetblockid?: string;
+    position?: string;
+};
+
+export type LayoutState = {
+    oid: string;
+    version: number;
+    rootnode?: any;
+    magnifiednodeid?: string;
+    focusednodeid?: string;
+    leaforder?: LeafOrderEntry[];
+    pendingbackendactions?: LayoutActionData[];
+    meta?: MetaMapType;
+};
+
+export type LeafOrderEntry = {
+    nodeid: string;
+    blockid: string;
+};
+
+export type MetaMapType = {[key: string]: any};
+
+export type Notification = {
+    oid: string;
+    version: number;
+    title: string;
+    message?: string;
+    type?: string;
+    source?: string;
+    blockid?: string;
+    dedupkey: string;
+    count: number;
+    createdts: number;
+    updatedts: number;
+    delivered?: boolean;
+    meta: MetaMapType;
+};
+
+export type Point = {
+    x: number;
+    y: number;
+};
+
+export type PortForward = {
+    type: string;
+    bindaddr?: string;
+    bindport: number;
+    desthost?: string;
+    destport?: number;
+};
+
+export type Profile = {
+    oid: string;
+    version: number;
+    name: string;
+    settings: MetaMapType;
+    theme?: string;
+    keybindings?: {[key: string]: string[]};
+    templates: ProfileTemplates;
+    createdts: number;
+    updatedts: number;
+    meta: MetaMapType;
+};
+
+export type ProfileTemplates = {
+    newblock?: string;
+    tabpreset?: string;
+    aipreset?: string;
+};
+
+export type QueuedCommand = {
+    id: number;
+    cmd: string;
+    status: string;
+    queuedts: number;
+    startts?: number;
+    endts?: number;
+    exitcode?: number;
+};
+
+export type RuntimeOpts = {
+    termsize?: TermSize;
+    winsize?: WinSize;
+    termfont?: TermFontOpts;
+};
+
+export type Settings = {
+    oid: string;
+    version: number;
+    workspaceid?: string;
+    settings: MetaMapType;
+    updatedts: number;
+    meta: MetaMapType;
+};
+
+export type StickerClickOptsType = {
+    sendinput?: string;
+    createblock?: BlockDef;
+};
+
+export type StickerDisplayOptsType = {
+    icon: string;
+    imgsrc: string;
+    svgblob?: string;
+};
+
+export type StickerType = {
+    stickertype: string;
+    style: {[key: string]: any};
+    clickopts?: StickerClickOptsType;
+    display: StickerDisplayOptsType;
+};
+
+export type Tab = {
+    oid: string;
+    version: number;
+    name: string;
+    layoutstate: string;
+    blockids: string[];
+    meta: MetaMapType;
+};
+
+export type TermFontOpts = {
+    fontfamily?: string;
+    fontsize?: number;
+    lineheight?: number;
+    ligatures?: boolean;
+};
+
+export type TermPaneInfo = {
+    paneid: string;
+    cmd?: string;
+    filename: string;
+    startts: number;
+    active?: boolean;
+};
+
+export type TermSize = {
+    rows: number;
+    cols: number;
+};
+
+export type Theme = {
+    oid: string;
+    version: number;
+    name: string;
+    source?: string;
+    palette: MetaMapType;
+    createdts: number;
+    updatedts: number;
+    meta: MetaMapType;
+};
+
+export type UserInputResponse = {
+    type: string;
+    requestid: string;
+    text?: string;
+    confirm?: boolean;
+    errormsg?: string;
+    checkboxstat?: boolean;
+};
+
+export type WaveAIPromptMessageType = {
+    role: string;
+    content: string;
+    name?: string;
+};
+
+export type Webhook = {
+    oid: string;
+    version: number;
+    name?: string;
+    url: string;
+    filters: WebhookFilter[];
+    disabled?: boolean;
+    meta: MetaMapType;
+};
+
+export type WebhookDelivery = {
+    oid: string;
+    version: number;
+    webhookid: string;
+    event: string;
+    ts: number;
+    payload: string;
+    status: string;
+    attempts: number;
+    statuscode?: number;
+    error?: string;
+    meta: MetaMapType;
+};
+
+export type WebhookFilter = {
+    event: string;
+    nonzeroexit?: boolean;
+};
+
+export type WinSize = {
+    width: number;
+    height: number;
+};
+
+export type Window = {
+    oid: string;
+    version: number;
+    workspaceid: string;
+    isnew?: boolean;
+    pos: Point;
+    winsize: WinSize;
+    lastfocusts: number;
+    meta: MetaMapType;
+};
+
+export type Workspace = {
+    oid: string;
+    version: number;
+    name?: string;
+    icon?: string;
+    color?: string;
+    tabids: string[];
+    pinnedtabids: string[];
+    activetabid: string;
+    meta: MetaMapType;
+};
+
+export type WorkspaceList = WorkspaceListEntry[];
+
+export type WorkspaceListEntry = {
+    workspaceid: string;
+    windowid: string;
+};
+
+// Wave Terminal Automation API (v1)
+export class WaveApiClient {
+    baseUrl: string;
+    token: string;
+
+    // baseUrl is e.g. "http://127.0.0.1:61269/api/v1", token is the contents of the api-token file
+    constructor(baseUrl: string, token: string) {
+        this.baseUrl = baseUrl;
+        this.token = token;
+    }
+
+    async call(method: string, path: string, body: any, query: { [key: string]: string }): Promise<any> {
+        let url = this.baseUrl + path;
+        if (query != null) {
+            const usp = new URLSearchParams();
+            for (const [key, val] of Object.entries(query)) {
+                if (val != null) {
+                    usp.set(key, val);
+                }
+            }
+            if (usp.size > 0) {
+                url += "?" + usp.toString();
+            }
+        }
+        const resp = await fetch(url, {
+            method: method,
+            headers: { Authorization: "Bearer " + this.token },
+            body: body == null ? undefined : JSON.stringify(body),
+        });
+        const rtn = await resp.json();
+        if (!resp.ok || rtn?.error != null) {
+            throw new Error(rtn?.error ?? resp.statusText);
+        }
+        return rtn.data;
+    }
+
+    // POST /tabs/{id}/blocks (blocks.create)
+    blocksCreate(id: string, data: Partial<ApiCreateBlockData>): Promise<Block> {
+        return this.call("POST", `/tabs/${encodeURIComponent(id)}/blocks`, data, null);
+    }
+
+    // DELETE /blocks/{id} (blocks.delete)
+    blocksDelete(id: string): Promise<null> {
+        return this.call("DELETE", `/blocks/${encodeURIComponent(id)}`, null, null);
+    }
+
+    // GET /blocks/{id} (blocks.get)
+    blocksGet(id: string): Promise<Block> {
+        return this.call("GET", `/blocks/${encodeURIComponent(id)}`, null, null);
+    }
+
+    // POST /blocks/{id}/input (blocks.input)
+    blocksInput(id: string, data: Partial<ApiBlockInputData>): Promise<null> {
+        return this.call("POST", `/blocks/${encodeURIComponent(id)}/input`, data, null);
+    }
+
+    // GET /tabs/{id}/blocks (blocks.list)
+    blocksList(id: string): Promise<Block[]> {
+        return this.call("GET", `/tabs/${encodeURIComponent(id)}/blocks`, null, null);
+    }
+
+    // GET /blocks/{id}/output (blocks.output)
+    blocksOutput(id: string, query?: { offset?: string; maxbytes?: string; raw?: string }): Promise<ApiOutputData> {
+        return this.call("GET", `/blocks/${encodeURIComponent(id)}/output`, null, query);
+    }
+
+    // GET /blocks/{id}/queue (blocks.queue)
+    blocksQueue(id: string): Promise<QueuedCommand[]> {
+        return this.call("GET", `/blocks/${encodeURIComponent(id)}/queue`, null, null);
+    }
+
+    // POST /blocks/{id}/run (blocks.run)
+    blocksRun(id: string, data: Partial<ApiBlockRunData>): Promise<QueuedCommand> {
+        return this.call("POST", `/blocks/${encodeURIComponent(id)}/run`, data, null);
+    }
+
+    // PATCH /blocks/{id} (blocks.update)
+    blocksUpdate(id: string, data: Partial<ApiBlockMetaData>): Promise<Block> {
+        return this.call("PATCH", `/blocks/${encodeURIComponent(id)}`, data, null);
+    }
+
+    // POST /notifications (notifications.create)
+    notificationsCreate(data: Partial<ApiNotificationData>): Promise<Notification> {
+        return this.call("POST", `/notifications`, data, null);
+    }
+
+    // DELETE /notifications/{id} (notifications.dismiss)
+    notificationsDismiss(id: string): Promise<null> {
+        return this.call("DELETE", `/notifications/${encodeURIComponent(id)}`, null, null);
+    }
+
+    // GET /notifications (notifications.list)
+    notificationsList(): Promise<Notification[]> {
+        return this.call("GET", `/notifications`, null, null);
+    }
+
+    // POST /workspaces/{id}/tabs (tabs.create)
+    tabsCreate(id: string, data: Partial<ApiTabData>): Promise<Tab> {
+        return this.call("POST", `/workspaces/${encodeURIComponent(id)}/tabs`, data, null);
+    }
+
+    // DELETE /tabs/{id} (tabs.delete)
+    tabsDelete(id: string): Promise<null> {
+        return this.call("DELETE", `/tabs/${encodeURIComponent(id)}`, null, null);
+    }
+
+    // GET /tabs/{id} (tabs.get)
+    tabsGet(id: string): Promise<Tab> {
+        return this.call("GET", `/tabs/${encodeURIComponent(id)}`, null, null);
+    }
+
+    // GET /workspaces/{id}/tabs (tabs.list)
+    tabsList(id: string): Promise<Tab[]> {
+        return this.call("GET", `/workspaces/${encodeURIComponent(id)}/tabs`, null, null);
+    }
+
+    // PATCH /tabs/{id} (tabs.update)
+    tabsUpdate(id: string, data: Partial<ApiTabData>): Promise<Tab> {
+        return this.call("PATCH", `/tabs/${encodeURIComponent(id)}`, data, null);
+    }
+
+    // POST /workspaces (workspaces.create)
+    workspacesCreate(data: Partial<ApiWorkspaceData>): Promise<Workspace> {
+        return this.call("POST", `/workspaces`, data, null);
+    }
+
+    // DELETE /workspaces/{id} (workspaces.delete)
+    workspacesDelete(id: string): Promise<null> {
+        return this.call("DELETE", `/workspaces/${encodeURIComponent(id)}`, null, null);
+    }
+
+    // GET /workspaces/{id} (workspaces.get)
+    workspacesGet(id: string): Promise<Workspace> {
+        return this.call("GET", `/workspaces/${encodeURIComponent(id)}`, null, null);
+    }
+
+    // GET /workspaces (workspaces.list)
+    workspacesList(): Promise<ApiWorkspace[]> {
+        return this.call("GET", `/workspaces`, null, null);
+    }
+
+    // PATCH /workspaces/{id} (workspaces.update)
+    workspacesUpdate(id: string, data: Partial<ApiWorkspaceData>): Promise<Workspace> {
+        return this.call("PATCH", `/workspaces/${encodeURIComponent(id)}`, data, null);
+    }
+}
@@ -58,7 +58,7 @@ function makeORef(otype: string, oid: string): string {
 }
 
 function GetObject<T>(oref: string): Promise<T> {
-    return callBackendService("object", "GetObject", [oref], true);
+    return callBackendService("object", "GetObject", [oref]);
 }
 
 function debugLogBackendCall(methodName: string, durationStr: string, args: any[]) {
@@ -155,6 +155,15 @@ function reloadWaveObject<T extends WaveObj>(oref: string): Promise<T> {
     return prtn;
 }
 
+// reloads the cached objects that are in use (used when their updates were dropped)
+function reloadAllWaveObjects() {
+    for (const [oref, wov] of waveObjectValueCache) {
+        if (wov.refCount > 0) {
+            fireAndForget(() => reloadWaveObject(oref));
+        }
+    }
+}
+
 function createWaveValueObject<T extends WaveObj>(oref: string, shouldFetch: boolean): WaveObjectValue<T> {
     const wov = { pendingPromise: null, dataAtom: null, refCount: 0, holdTime: Date.now() + 5000 };
     wov.dataAtom = atom({ value: null, loading: true });
@@ -315,6 +324,7 @@ export {
     getWaveObjectLoadingAtom,
     loadAndPinWaveObject,
     makeORef,
+    reloadAllWaveObjects,
     reloadWaveObject,
     setObjectValue,
     splitORef,
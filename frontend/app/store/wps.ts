@@ -27,18 +27,23 @@ type WaveEventUnsubscribe = {
 const fileSubjects = new Map<string, SubjectWithRef<WSFileEventData>>();
 const waveEventSubjects = new Map<string, WaveEventSubjectContainer[]>();
 
+// the seq of the last event received, sent on reconnect to replay the missed events (see pkg/wps/wpsreplay.go)
+let lastEventSeq = 0;
+// seqs received while a replay is running (an event can be both replayed and sent)
+let replaySeenSeqs: Set<number> = null;
+
 function wpsReconnectHandler() {
     for (const eventType of waveEventSubjects.keys()) {
         updateWaveEventSub(eventType);
     }
 }
 
-function makeWaveReSubCommand(eventType: string): RpcMessage {
-    let subjects = waveEventSubjects.get(eventType);
+function makeSubscriptionRequest(eventType: string): SubscriptionRequest {
+    const subjects = waveEventSubjects.get(eventType);
     if (subjects == null) {
-        return { command: "eventunsub", data: eventType };
+        return null;
     }
-    let subreq: SubscriptionRequest = { event: eventType, scopes: [], allscopes: false };
+    const subreq: SubscriptionRequest = { event: eventType, scopes: [], allscopes: false };
     for (const scont of subjects) {
         if (isBlank(scont.scope)) {
             subreq.allscopes = true;
@@ -47,9 +52,49 @@ function makeWaveReSubCommand(eventType: string): RpcMessage {
         }
         subreq.scopes.push(scont.scope);
     }
+    return subreq;
+}
+
+function makeWaveReSubCommand(eventType: string): RpcMessage {
+    const subreq = makeSubscriptionRequest(eventType);
+    if (subreq == null) {
+        return { command: "eventunsub", data: eventType };
+    }
     return { command: "eventsub", data: subreq };
 }
 
+// resubscribes and handles the events missed while the websocket was disconnected.  returns false if they are
+// no longer buffered and the caller must reload its state.  blockfile events are never replayed.
+async function replayWaveEvents(
+    replayFn: (data: CommandEventReplayData) => Promise<EventReplayRtnData>
+): Promise<boolean> {
+    const sinceSeq = lastEventSeq;
+    const subscriptions: SubscriptionRequest[] = [];
+    for (const eventType of waveEventSubjects.keys()) {
+        subscriptions.push(makeSubscriptionRequest(eventType));
+    }
+    replaySeenSeqs = new Set();
+    try {
+        const rtn = await replayFn({ sinceseq: sinceSeq, subscriptions });
+        if (sinceSeq == 0) {
+            // first connection, nothing was missed
+            lastEventSeq = Math.max(lastEventSeq, rtn.lastseq);
+            return true;
+        }
+        if (!rtn.complete) {
+            lastEventSeq = Math.max(lastEventSeq, rtn.lastseq);
+            return false;
+        }
+        for (const event of rtn.events ?? []) {
+            handleWaveEvent(event);
+        }
+        lastEventSeq = Math.max(lastEventSeq, rtn.lastseq);
+        return true;
+    } finally {
+        replaySeenSeqs = null;
+    }
+}
+
 function updateWaveEventSub(eventType: string) {
     const command = makeWaveReSubCommand(eventType);
     // console.log("updateWaveEventSub", eventType, command);
@@ -123,8 +168,25 @@ function getFileSubject(zoneId: string, fileName: string): SubjectWithRef<WSFile
     return subject;
 }
 
+// tells the file subscribers to reload their files (used when events were dropped)
+function invalidateFileSubjects() {
+    for (const [subjectKey, subject] of fileSubjects) {
+        const [zoneId, fileName] = subjectKey.split("|");
+        subject.next({ zoneid: zoneId, filename: fileName, fileop: "invalidate", data64: "" });
+    }
+}
+
 function handleWaveEvent(event: WaveEvent) {
     // console.log("handleWaveEvent", event);
+    if (event.seq != null) {
+        if (replaySeenSeqs != null) {
+            if (replaySeenSeqs.has(event.seq)) {
+                return;
+            }
+            replaySeenSeqs.add(event.seq);
+        }
+        lastEventSeq = Math.max(lastEventSeq, event.seq);
+    }
     const subjects = waveEventSubjects.get(event.event);
     if (subjects == null) {
         return;
@@ -143,4 +205,12 @@ function handleWaveEvent(event: WaveEvent) {
     }
 }
 
-export { getFileSubject, handleWaveEvent, waveEventSubscribe, waveEventUnsubscribe, wpsReconnectHandler };
+export {
+    getFileSubject,
+    handleWaveEvent,
+    invalidateFileSubjects,
+    replayWaveEvents,
+    waveEventSubscribe,
+    waveEventUnsubscribe,
+    wpsReconnectHandler,
+};
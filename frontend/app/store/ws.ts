@@ -12,6 +12,8 @@ const dlog = debug("wave:ws");
 const WarnWebSocketSendSize = 1024 * 1024; // 1MB
 const MaxWebSocketSendSize = 5 * 1024 * 1024; // 5MB
 const reconnectHandlers: (() => void)[] = [];
+// identifies this frontend to wavesrv (several frontends can show the same tab), it is kept across reconnects
+const FeClientId = crypto.randomUUID();
 const StableConnTime = 2000;
 
 function addWSReconnectHandler(handler: () => void) {
@@ -75,7 +77,7 @@ class WSControl {
         dlog("try reconnect:", desc);
         this.opening = true;
         this.wsConn = newWebSocket(
-            this.baseHostPort + "/ws?tabid=" + this.tabId,
+            this.baseHostPort + "/ws?tabid=" + this.tabId + "&feclientid=" + FeClientId,
             this.eoOpts
                 ? {
                       [AuthKeyHeader]: this.eoOpts.authKey,
@@ -248,6 +250,7 @@ function sendWSCommand(cmd: WSCommandType) {
 }
 
 export {
+    FeClientId,
     WSControl,
     addWSReconnectHandler,
     globalWS,
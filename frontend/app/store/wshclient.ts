@@ -10,6 +10,7 @@ class RpcResponseHelper {
     client: WshClient;
     cmdMsg: RpcMessage;
     done: boolean;
+    canceled: boolean; // the requestor canceled the request, handlers that stream responses should stop
 
     constructor(client: WshClient, cmdMsg: RpcMessage) {
         this.client = client;
@@ -35,13 +36,21 @@ class RpcResponseHelper {
         if (!msg.cont) {
             this.done = true;
             this.client.openRpcs.delete(this.cmdMsg.reqid);
+            this.client.respHelpers.delete(this.cmdMsg.reqid);
         }
     }
+
+    // the final (empty) response lets the routers clean up the request
+    cancel() {
+        this.canceled = true;
+        this.sendResponse({});
+    }
 }
 
 class WshClient {
     routeId: string;
     openRpcs: Map<string, ClientRpcEntry> = new Map();
+    respHelpers: Map<string, RpcResponseHelper> = new Map(); // reqid => helper, for the requests being handled
 
     constructor(routeId: string) {
         this.routeId = routeId;
@@ -95,6 +104,9 @@ class WshClient {
     async handleIncomingCommand(msg: RpcMessage) {
         // TODO implement a timeout (setTimeout + sendResponse)
         const helper = new RpcResponseHelper(this, msg);
+        if (!helper.done) {
+            this.respHelpers.set(msg.reqid, helper);
+        }
         const handlerName = `handle_${msg.command}`;
         try {
             let result: any = null;
@@ -127,6 +139,10 @@ class WshClient {
     }
 
     recvRpcMessage(msg: RpcMessage) {
+        if (msg.cancel) {
+            this.respHelpers.get(msg.reqid)?.cancel();
+            return;
+        }
         const isRequest = msg.command != null || msg.reqid != null;
         if (isRequest) {
             this.handleIncomingCommand(msg);
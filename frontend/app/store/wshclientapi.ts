@@ -17,6 +17,11 @@ class RpcApiType {
         return client.wshRpcCall("aisendmessage", data, opts);
     }
 
+    // command "attachblock" [call]
+    AttachBlockCommand(client: WshClient, data: CommandAttachBlockData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("attachblock", data, opts);
+    }
+
     // command "authenticate" [call]
     AuthenticateCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<CommandAuthenticateRtnData> {
         return client.wshRpcCall("authenticate", data, opts);
@@ -27,21 +32,111 @@ class RpcApiType {
         return client.wshRpcCall("authenticatetoken", data, opts);
     }
 
+    // command "authtokenissue" [call]
+    AuthTokenIssueCommand(client: WshClient, data: CommandAuthTokenData, opts?: RpcOpts): Promise<AuthTokenRtnData> {
+        return client.wshRpcCall("authtokenissue", data, opts);
+    }
+
+    // command "authtokenrevoke" [call]
+    AuthTokenRevokeCommand(client: WshClient, data: CommandAuthTokenData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("authtokenrevoke", data, opts);
+    }
+
     // command "blockinfo" [call]
     BlockInfoCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<BlockInfoData> {
         return client.wshRpcCall("blockinfo", data, opts);
     }
 
+    // command "clipboardread" [call]
+    ClipboardReadCommand(client: WshClient, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("clipboardread", null, opts);
+    }
+
+    // command "clipboardwrite" [call]
+    ClipboardWriteCommand(client: WshClient, data: CommandClipboardWriteData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("clipboardwrite", data, opts);
+    }
+
+    // command "clusterexeccancel" [call]
+    ClusterExecCancelCommand(client: WshClient, data: CommandClusterExecRunData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("clusterexeccancel", data, opts);
+    }
+
+    // command "clusterexecget" [call]
+    ClusterExecGetCommand(client: WshClient, data: CommandClusterExecRunData, opts?: RpcOpts): Promise<ClusterExecRun> {
+        return client.wshRpcCall("clusterexecget", data, opts);
+    }
+
+    // command "clusterexecstart" [call]
+    ClusterExecStartCommand(client: WshClient, data: CommandClusterExecData, opts?: RpcOpts): Promise<ClusterExecRun> {
+        return client.wshRpcCall("clusterexecstart", data, opts);
+    }
+
+    // command "cmdhistorydelete" [call]
+    CmdHistoryDeleteCommand(client: WshClient, data: string[], opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("cmdhistorydelete", data, opts);
+    }
+
+    // command "cmdhistorysearch" [call]
+    CmdHistorySearchCommand(client: WshClient, data: CommandCmdHistorySearchData, opts?: RpcOpts): Promise<CmdHistoryEntry[]> {
+        return client.wshRpcCall("cmdhistorysearch", data, opts);
+    }
+
     // command "connconnect" [call]
     ConnConnectCommand(client: WshClient, data: ConnRequest, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("connconnect", data, opts);
     }
 
+    // command "conndashboard" [call]
+    ConnDashboardCommand(client: WshClient, opts?: RpcOpts): Promise<ConnDashboardEntry[]> {
+        return client.wshRpcCall("conndashboard", null, opts);
+    }
+
     // command "conndisconnect" [call]
     ConnDisconnectCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("conndisconnect", data, opts);
     }
 
+    // command "connectioncreate" [call]
+    ConnectionCreateCommand(client: WshClient, data: Connection, opts?: RpcOpts): Promise<Connection> {
+        return client.wshRpcCall("connectioncreate", data, opts);
+    }
+
+    // command "connectiondelete" [call]
+    ConnectionDeleteCommand(client: WshClient, data: CommandConnectionData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("connectiondelete", data, opts);
+    }
+
+    // command "connectionlist" [call]
+    ConnectionListCommand(client: WshClient, data: CommandConnectionListData, opts?: RpcOpts): Promise<ConnectionInfo[]> {
+        return client.wshRpcCall("connectionlist", data, opts);
+    }
+
+    // command "connectionopenterm" [call]
+    ConnectionOpenTermCommand(client: WshClient, data: CommandConnectionData, opts?: RpcOpts): Promise<ORef> {
+        return client.wshRpcCall("connectionopenterm", data, opts);
+    }
+
+    // command "connectionrun" [call]
+    ConnectionRunCommand(client: WshClient, data: CommandConnectionRunData, opts?: RpcOpts): Promise<ConnectionRunResult> {
+        return client.wshRpcCall("connectionrun", data, opts);
+    }
+
+    // command "connectionrunstream" [responsestream]
+	ConnectionRunStreamCommand(client: WshClient, data: CommandConnectionRunData, opts?: RpcOpts): AsyncGenerator<ConnectionRunOutput, void, boolean> {
+        return client.wshRpcStream("connectionrunstream", data, opts);
+    }
+
+    // command "connectionsetkey" [call]
+    ConnectionSetKeyCommand(client: WshClient, data: CommandConnectionSetKeyData, opts?: RpcOpts): Promise<Connection> {
+        return client.wshRpcCall("connectionsetkey", data, opts);
+    }
+
+    // command "connectionupdate" [call]
+    ConnectionUpdateCommand(client: WshClient, data: Connection, opts?: RpcOpts): Promise<Connection> {
+        return client.wshRpcCall("connectionupdate", data, opts);
+    }
+
     // command "connensure" [call]
     ConnEnsureCommand(client: WshClient, data: ConnExtData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("connensure", data, opts);
@@ -57,6 +152,11 @@ class RpcApiType {
         return client.wshRpcCall("connlistaws", null, opts);
     }
 
+    // command "connmetrics" [call]
+    ConnMetricsCommand(client: WshClient, data: CommandConnMetricsData, opts?: RpcOpts): Promise<ConnMetrics[]> {
+        return client.wshRpcCall("connmetrics", data, opts);
+    }
+
     // command "connreinstallwsh" [call]
     ConnReinstallWshCommand(client: WshClient, data: ConnExtData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("connreinstallwsh", data, opts);
@@ -82,11 +182,36 @@ class RpcApiType {
         return client.wshRpcCall("controllerinput", data, opts);
     }
 
+    // command "controlleroutputack" [call]
+    ControllerOutputAckCommand(client: WshClient, data: CommandControllerOutputAckData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("controlleroutputack", data, opts);
+    }
+
+    // command "controllerpaste" [call]
+    ControllerPasteCommand(client: WshClient, data: CommandControllerPasteData, opts?: RpcOpts): Promise<CommandControllerPasteRtnData> {
+        return client.wshRpcCall("controllerpaste", data, opts);
+    }
+
+    // command "controllerprocesstree" [call]
+    ControllerProcessTreeCommand(client: WshClient, data: CommandControllerProcessTreeData, opts?: RpcOpts): Promise<ProcessInfo[]> {
+        return client.wshRpcCall("controllerprocesstree", data, opts);
+    }
+
+    // command "controllerresize" [call]
+    ControllerResizeCommand(client: WshClient, data: CommandControllerResizeData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("controllerresize", data, opts);
+    }
+
     // command "controllerresync" [call]
     ControllerResyncCommand(client: WshClient, data: CommandControllerResyncData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("controllerresync", data, opts);
     }
 
+    // command "controllersignal" [call]
+    ControllerSignalCommand(client: WshClient, data: CommandControllerSignalData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("controllersignal", data, opts);
+    }
+
     // command "controllerstop" [call]
     ControllerStopCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("controllerstop", data, opts);
@@ -112,6 +237,11 @@ class RpcApiType {
         return client.wshRpcCall("deletesubblock", data, opts);
     }
 
+    // command "detachblock" [call]
+    DetachBlockCommand(client: WshClient, data: CommandDetachBlockData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("detachblock", data, opts);
+    }
+
     // command "dismisswshfail" [call]
     DismissWshFailCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("dismisswshfail", data, opts);
@@ -142,6 +272,11 @@ class RpcApiType {
         return client.wshRpcCall("eventrecv", data, opts);
     }
 
+    // command "eventreplay" [call]
+    EventReplayCommand(client: WshClient, data: CommandEventReplayData, opts?: RpcOpts): Promise<EventReplayRtnData> {
+        return client.wshRpcCall("eventreplay", data, opts);
+    }
+
     // command "eventsub" [call]
     EventSubCommand(client: WshClient, data: SubscriptionRequest, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("eventsub", data, opts);
@@ -157,6 +292,11 @@ class RpcApiType {
         return client.wshRpcCall("eventunsuball", null, opts);
     }
 
+    // command "executeaction" [call]
+    ExecuteActionCommand(client: WshClient, data: CommandExecuteActionData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("executeaction", data, opts);
+    }
+
     // command "fetchsuggestions" [call]
     FetchSuggestionsCommand(client: WshClient, data: FetchSuggestionsData, opts?: RpcOpts): Promise<FetchSuggestionsResponse> {
         return client.wshRpcCall("fetchsuggestions", data, opts);
@@ -187,6 +327,21 @@ class RpcApiType {
         return client.wshRpcCall("filedelete", data, opts);
     }
 
+    // command "filedownload" [responsestream]
+	FileDownloadCommand(client: WshClient, data: CommandFileTransferData, opts?: RpcOpts): AsyncGenerator<FileTransferChunk, void, boolean> {
+        return client.wshRpcStream("filedownload", data, opts);
+    }
+
+    // command "fileexport" [responsestream]
+	FileExportCommand(client: WshClient, data: CommandFileExportData, opts?: RpcOpts): AsyncGenerator<FileExportProgress, void, boolean> {
+        return client.wshRpcStream("fileexport", data, opts);
+    }
+
+    // command "fileimport" [call]
+    FileImportCommand(client: WshClient, data: CommandFileImportData, opts?: RpcOpts): Promise<FileInfo> {
+        return client.wshRpcCall("fileimport", data, opts);
+    }
+
     // command "fileinfo" [call]
     FileInfoCommand(client: WshClient, data: FileData, opts?: RpcOpts): Promise<FileInfo> {
         return client.wshRpcCall("fileinfo", data, opts);
@@ -222,11 +377,21 @@ class RpcApiType {
         return client.wshRpcCall("fileread", data, opts);
     }
 
+    // command "filereadranges" [call]
+    FileReadRangesCommand(client: WshClient, data: CommandFileReadRangesData, opts?: RpcOpts): Promise<FileReadRangesData> {
+        return client.wshRpcCall("filereadranges", data, opts);
+    }
+
     // command "filereadstream" [responsestream]
 	FileReadStreamCommand(client: WshClient, data: FileData, opts?: RpcOpts): AsyncGenerator<FileData, void, boolean> {
         return client.wshRpcStream("filereadstream", data, opts);
     }
 
+    // command "filesearch" [call]
+    FileSearchCommand(client: WshClient, data: CommandFileSearchData, opts?: RpcOpts): Promise<FileSearchHit[]> {
+        return client.wshRpcCall("filesearch", data, opts);
+    }
+
     // command "filesharecapability" [call]
     FileShareCapabilityCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<FileShareCapability> {
         return client.wshRpcCall("filesharecapability", data, opts);
@@ -237,6 +402,26 @@ class RpcApiType {
         return client.wshRpcStream("filestreamtar", data, opts);
     }
 
+    // command "filetransfercancel" [call]
+    FileTransferCancelCommand(client: WshClient, data: CommandFileTransferData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("filetransfercancel", data, opts);
+    }
+
+    // command "filetransferstatus" [call]
+    FileTransferStatusCommand(client: WshClient, data: CommandFileTransferData, opts?: RpcOpts): Promise<FileTransferStatus> {
+        return client.wshRpcCall("filetransferstatus", data, opts);
+    }
+
+    // command "fileuploadchunk" [call]
+    FileUploadChunkCommand(client: WshClient, data: CommandFileUploadChunkData, opts?: RpcOpts): Promise<FileTransferStatus> {
+        return client.wshRpcCall("fileuploadchunk", data, opts);
+    }
+
+    // command "filewatch" [responsestream]
+	FileWatchCommand(client: WshClient, data: CommandFileWatchData, opts?: RpcOpts): AsyncGenerator<FileWatchEvent, void, boolean> {
+        return client.wshRpcStream("filewatch", data, opts);
+    }
+
     // command "filewrite" [call]
     FileWriteCommand(client: WshClient, data: FileData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("filewrite", data, opts);
@@ -247,6 +432,16 @@ class RpcApiType {
         return client.wshRpcCall("focuswindow", data, opts);
     }
 
+    // command "fontlist" [call]
+    FontListCommand(client: WshClient, opts?: RpcOpts): Promise<FontInfo[]> {
+        return client.wshRpcCall("fontlist", null, opts);
+    }
+
+    // command "getenvsnapshot" [call]
+    GetEnvSnapshotCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<EnvSnapshot> {
+        return client.wshRpcCall("getenvsnapshot", data, opts);
+    }
+
     // command "getfullconfig" [call]
     GetFullConfigCommand(client: WshClient, opts?: RpcOpts): Promise<FullConfigType> {
         return client.wshRpcCall("getfullconfig", null, opts);
@@ -272,21 +467,196 @@ class RpcApiType {
         return client.wshRpcCall("getvar", data, opts);
     }
 
+    // command "hostkeyforget" [call]
+    HostKeyForgetCommand(client: WshClient, data: CommandHostKeyForgetData, opts?: RpcOpts): Promise<number> {
+        return client.wshRpcCall("hostkeyforget", data, opts);
+    }
+
+    // command "hostkeylist" [call]
+    HostKeyListCommand(client: WshClient, opts?: RpcOpts): Promise<KnownHostKey[]> {
+        return client.wshRpcCall("hostkeylist", null, opts);
+    }
+
+    // command "i18ncatalog" [call]
+    I18nCatalogCommand(client: WshClient, data: CommandI18nCatalogData, opts?: RpcOpts): Promise<I18nCatalogData> {
+        return client.wshRpcCall("i18ncatalog", data, opts);
+    }
+
+    // command "i18nlist" [call]
+    I18nListCommand(client: WshClient, opts?: RpcOpts): Promise<I18nCatalogInfo[]> {
+        return client.wshRpcCall("i18nlist", null, opts);
+    }
+
+    // command "keybindingresolve" [call]
+    KeyBindingResolveCommand(client: WshClient, data: CommandKeyBindingResolveData, opts?: RpcOpts): Promise<KeyBindingResolveRtnData> {
+        return client.wshRpcCall("keybindingresolve", data, opts);
+    }
+
+    // command "keybindingslist" [call]
+    KeyBindingsListCommand(client: WshClient, data: CommandSettingsData, opts?: RpcOpts): Promise<KeyBindingsData> {
+        return client.wshRpcCall("keybindingslist", data, opts);
+    }
+
+    // command "listactions" [call]
+    ListActionsCommand(client: WshClient, data: CommandListActionsData, opts?: RpcOpts): Promise<ActionDef[]> {
+        return client.wshRpcCall("listactions", data, opts);
+    }
+
+    // command "listdetachedblocks" [call]
+    ListDetachedBlocksCommand(client: WshClient, opts?: RpcOpts): Promise<DetachedBlockInfo[]> {
+        return client.wshRpcCall("listdetachedblocks", null, opts);
+    }
+
     // command "message" [call]
     MessageCommand(client: WshClient, data: CommandMessageData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("message", data, opts);
     }
 
+    // command "notificationdismiss" [call]
+    NotificationDismissCommand(client: WshClient, data: CommandNotificationDismissData, opts?: RpcOpts): Promise<number> {
+        return client.wshRpcCall("notificationdismiss", data, opts);
+    }
+
+    // command "notificationlist" [call]
+    NotificationListCommand(client: WshClient, opts?: RpcOpts): Promise<Notification[]> {
+        return client.wshRpcCall("notificationlist", null, opts);
+    }
+
+    // command "notificationsend" [call]
+    NotificationSendCommand(client: WshClient, data: CommandNotificationSendData, opts?: RpcOpts): Promise<Notification> {
+        return client.wshRpcCall("notificationsend", data, opts);
+    }
+
     // command "notify" [call]
     NotifyCommand(client: WshClient, data: WaveNotificationOptions, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("notify", data, opts);
     }
 
+    // command "palettesearch" [call]
+    PaletteSearchCommand(client: WshClient, data: CommandPaletteSearchData, opts?: RpcOpts): Promise<PaletteItem[]> {
+        return client.wshRpcCall("palettesearch", data, opts);
+    }
+
     // command "path" [call]
     PathCommand(client: WshClient, data: PathCommandData, opts?: RpcOpts): Promise<string> {
         return client.wshRpcCall("path", data, opts);
     }
 
+    // command "playerload" [call]
+    PlayerLoadCommand(client: WshClient, data: CommandPlayerLoadData, opts?: RpcOpts): Promise<PlayerStatus> {
+        return client.wshRpcCall("playerload", data, opts);
+    }
+
+    // command "playerpause" [call]
+    PlayerPauseCommand(client: WshClient, data: CommandPlayerData, opts?: RpcOpts): Promise<PlayerStatus> {
+        return client.wshRpcCall("playerpause", data, opts);
+    }
+
+    // command "playerplay" [call]
+    PlayerPlayCommand(client: WshClient, data: CommandPlayerData, opts?: RpcOpts): Promise<PlayerStatus> {
+        return client.wshRpcCall("playerplay", data, opts);
+    }
+
+    // command "playerseek" [call]
+    PlayerSeekCommand(client: WshClient, data: CommandPlayerSeekData, opts?: RpcOpts): Promise<PlayerStatus> {
+        return client.wshRpcCall("playerseek", data, opts);
+    }
+
+    // command "playersetspeed" [call]
+    PlayerSetSpeedCommand(client: WshClient, data: CommandPlayerSetSpeedData, opts?: RpcOpts): Promise<PlayerStatus> {
+        return client.wshRpcCall("playersetspeed", data, opts);
+    }
+
+    // command "pluginblockevent" [call]
+    PluginBlockEventCommand(client: WshClient, data: PluginBlockEvent, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("pluginblockevent", data, opts);
+    }
+
+    // command "plugincall" [call]
+    PluginCallCommand(client: WshClient, data: CommandPluginCallData, opts?: RpcOpts): Promise<any> {
+        return client.wshRpcCall("plugincall", data, opts);
+    }
+
+    // command "plugingetmeta" [call]
+    PluginGetMetaCommand(client: WshClient, data: CommandPluginBlockData, opts?: RpcOpts): Promise<MetaType> {
+        return client.wshRpcCall("plugingetmeta", data, opts);
+    }
+
+    // command "pluginhandler" [call]
+    PluginHandlerCommand(client: WshClient, data: CommandPluginCallData, opts?: RpcOpts): Promise<any> {
+        return client.wshRpcCall("pluginhandler", data, opts);
+    }
+
+    // command "pluginlist" [call]
+    PluginListCommand(client: WshClient, opts?: RpcOpts): Promise<PluginInfo[]> {
+        return client.wshRpcCall("pluginlist", null, opts);
+    }
+
+    // command "pluginreadfile" [call]
+    PluginReadFileCommand(client: WshClient, data: CommandPluginFileData, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("pluginreadfile", data, opts);
+    }
+
+    // command "pluginregister" [call]
+    PluginRegisterCommand(client: WshClient, data: CommandPluginRegisterData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("pluginregister", data, opts);
+    }
+
+    // command "pluginrevoke" [call]
+    PluginRevokeCommand(client: WshClient, data: CommandPluginRevokeData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("pluginrevoke", data, opts);
+    }
+
+    // command "pluginsetmeta" [call]
+    PluginSetMetaCommand(client: WshClient, data: CommandPluginSetMetaData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("pluginsetmeta", data, opts);
+    }
+
+    // command "plugintokenissue" [call]
+    PluginTokenIssueCommand(client: WshClient, data: CommandPluginTokenIssueData, opts?: RpcOpts): Promise<PluginTokenRtnData> {
+        return client.wshRpcCall("plugintokenissue", data, opts);
+    }
+
+    // command "pluginwritefile" [call]
+    PluginWriteFileCommand(client: WshClient, data: CommandPluginFileData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("pluginwritefile", data, opts);
+    }
+
+    // command "portforwardcreate" [call]
+    PortForwardCreateCommand(client: WshClient, data: CommandPortForwardCreateData, opts?: RpcOpts): Promise<PortForwardInfo> {
+        return client.wshRpcCall("portforwardcreate", data, opts);
+    }
+
+    // command "portforwardlist" [call]
+    PortForwardListCommand(client: WshClient, data: CommandPortForwardListData, opts?: RpcOpts): Promise<PortForwardInfo[]> {
+        return client.wshRpcCall("portforwardlist", data, opts);
+    }
+
+    // command "portforwardstop" [call]
+    PortForwardStopCommand(client: WshClient, data: CommandPortForwardStopData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("portforwardstop", data, opts);
+    }
+
+    // command "profiledelete" [call]
+    ProfileDeleteCommand(client: WshClient, data: CommandProfileData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("profiledelete", data, opts);
+    }
+
+    // command "profilelist" [call]
+    ProfileListCommand(client: WshClient, opts?: RpcOpts): Promise<ProfileListData> {
+        return client.wshRpcCall("profilelist", null, opts);
+    }
+
+    // command "profilesave" [call]
+    ProfileSaveCommand(client: WshClient, data: Profile, opts?: RpcOpts): Promise<Profile> {
+        return client.wshRpcCall("profilesave", data, opts);
+    }
+
+    // command "profileswitch" [call]
+    ProfileSwitchCommand(client: WshClient, data: CommandProfileData, opts?: RpcOpts): Promise<Profile> {
+        return client.wshRpcCall("profileswitch", data, opts);
+    }
+
     // command "recordtevent" [call]
     RecordTEventCommand(client: WshClient, data: TEvent, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("recordtevent", data, opts);
@@ -342,6 +712,36 @@ class RpcApiType {
         return client.wshRpcCall("remotemkdir", data, opts);
     }
 
+    // command "remoteptystart" [call]
+    RemotePtyStartCommand(client: WshClient, data: CommandRemotePtyStartData, opts?: RpcOpts): Promise<RemotePtyInfo> {
+        return client.wshRpcCall("remoteptystart", data, opts);
+    }
+
+    // command "remoterevoke" [call]
+    RemoteRevokeCommand(client: WshClient, data: CommandRemoteRevokeData, opts?: RpcOpts): Promise<number> {
+        return client.wshRpcCall("remoterevoke", data, opts);
+    }
+
+    // command "remotesessions" [call]
+    RemoteSessionsCommand(client: WshClient, opts?: RpcOpts): Promise<RemoteSessionInfo[]> {
+        return client.wshRpcCall("remotesessions", null, opts);
+    }
+
+    // command "remotesetpassword" [call]
+    RemoteSetPasswordCommand(client: WshClient, data: CommandRemoteSetPasswordData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remotesetpassword", data, opts);
+    }
+
+    // command "remotesharecreate" [call]
+    RemoteShareCreateCommand(client: WshClient, data: CommandRemoteShareCreateData, opts?: RpcOpts): Promise<RemoteShareInfo> {
+        return client.wshRpcCall("remotesharecreate", data, opts);
+    }
+
+    // command "remotesharelist" [call]
+    RemoteShareListCommand(client: WshClient, opts?: RpcOpts): Promise<RemoteShareInfo[]> {
+        return client.wshRpcCall("remotesharelist", null, opts);
+    }
+
     // command "remotestreamcpudata" [responsestream]
 	RemoteStreamCpuDataCommand(client: WshClient, opts?: RpcOpts): AsyncGenerator<TimeSeriesData, void, boolean> {
         return client.wshRpcStream("remotestreamcpudata", null, opts);
@@ -362,6 +762,11 @@ class RpcApiType {
         return client.wshRpcCall("remotewritefile", data, opts);
     }
 
+    // command "rerunblock" [call]
+    RerunBlockCommand(client: WshClient, data: CommandRerunBlockData, opts?: RpcOpts): Promise<ORef> {
+        return client.wshRpcCall("rerunblock", data, opts);
+    }
+
     // command "resolveids" [call]
     ResolveIdsCommand(client: WshClient, data: CommandResolveIdsData, opts?: RpcOpts): Promise<CommandResolveIdsRtnData> {
         return client.wshRpcCall("resolveids", data, opts);
@@ -377,6 +782,26 @@ class RpcApiType {
         return client.wshRpcCall("routeunannounce", null, opts);
     }
 
+    // command "saveenvsnapshot" [call]
+    SaveEnvSnapshotCommand(client: WshClient, data: CommandSaveEnvSnapshotData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("saveenvsnapshot", data, opts);
+    }
+
+    // command "secretdelete" [call]
+    SecretDeleteCommand(client: WshClient, data: CommandSecretData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("secretdelete", data, opts);
+    }
+
+    // command "secretlist" [call]
+    SecretListCommand(client: WshClient, data: CommandSecretListData, opts?: RpcOpts): Promise<SecretInfo[]> {
+        return client.wshRpcCall("secretlist", data, opts);
+    }
+
+    // command "secretset" [call]
+    SecretSetCommand(client: WshClient, data: CommandSecretSetData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("secretset", data, opts);
+    }
+
     // command "sendtelemetry" [call]
     SendTelemetryCommand(client: WshClient, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("sendtelemetry", null, opts);
@@ -397,6 +822,36 @@ class RpcApiType {
         return client.wshRpcCall("setmeta", data, opts);
     }
 
+    // command "settingsexplain" [call]
+    SettingsExplainCommand(client: WshClient, data: CommandSettingsExplainData, opts?: RpcOpts): Promise<SettingValueInfo[]> {
+        return client.wshRpcCall("settingsexplain", data, opts);
+    }
+
+    // command "settingsget" [call]
+    SettingsGetCommand(client: WshClient, data: CommandSettingsData, opts?: RpcOpts): Promise<MetaType> {
+        return client.wshRpcCall("settingsget", data, opts);
+    }
+
+    // command "settingsimport" [call]
+    SettingsImportCommand(client: WshClient, data: CommandSettingsImportData, opts?: RpcOpts): Promise<SettingsImportResult> {
+        return client.wshRpcCall("settingsimport", data, opts);
+    }
+
+    // command "settingsmigrate" [call]
+    SettingsMigrateCommand(client: WshClient, data: CommandSettingsMigrateData, opts?: RpcOpts): Promise<SettingsMigration[]> {
+        return client.wshRpcCall("settingsmigrate", data, opts);
+    }
+
+    // command "settingsresolve" [call]
+    SettingsResolveCommand(client: WshClient, data: CommandSettingsData, opts?: RpcOpts): Promise<MetaType> {
+        return client.wshRpcCall("settingsresolve", data, opts);
+    }
+
+    // command "settingsset" [call]
+    SettingsSetCommand(client: WshClient, data: CommandSettingsSetData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("settingsset", data, opts);
+    }
+
     // command "setvar" [call]
     SetVarCommand(client: WshClient, data: CommandVarData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("setvar", data, opts);
@@ -407,6 +862,81 @@ class RpcApiType {
         return client.wshRpcCall("setview", data, opts);
     }
 
+    // command "sftpcopy" [responsestream]
+	SftpCopyCommand(client: WshClient, data: CommandSftpCopyData, opts?: RpcOpts): AsyncGenerator<SftpTransferProgress, void, boolean> {
+        return client.wshRpcStream("sftpcopy", data, opts);
+    }
+
+    // command "sftpdownload" [responsestream]
+	SftpDownloadCommand(client: WshClient, data: CommandSftpTransferData, opts?: RpcOpts): AsyncGenerator<SftpTransferProgress, void, boolean> {
+        return client.wshRpcStream("sftpdownload", data, opts);
+    }
+
+    // command "sftpupload" [responsestream]
+	SftpUploadCommand(client: WshClient, data: CommandSftpTransferData, opts?: RpcOpts): AsyncGenerator<SftpTransferProgress, void, boolean> {
+        return client.wshRpcStream("sftpupload", data, opts);
+    }
+
+    // command "shellintegrationcheck" [call]
+    ShellIntegrationCheckCommand(client: WshClient, data: CommandShellIntegrationCheckData, opts?: RpcOpts): Promise<ShellIntegrationStatus> {
+        return client.wshRpcCall("shellintegrationcheck", data, opts);
+    }
+
+    // command "sshkeyaddtoagent" [call]
+    SshKeyAddToAgentCommand(client: WshClient, data: CommandSshKeyAddToAgentData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("sshkeyaddtoagent", data, opts);
+    }
+
+    // command "sshkeygenerate" [call]
+    SshKeyGenerateCommand(client: WshClient, data: CommandSshKeyGenerateData, opts?: RpcOpts): Promise<SshKeyInfo> {
+        return client.wshRpcCall("sshkeygenerate", data, opts);
+    }
+
+    // command "sshkeylist" [call]
+    SshKeyListCommand(client: WshClient, opts?: RpcOpts): Promise<SshKeyInfo[]> {
+        return client.wshRpcCall("sshkeylist", null, opts);
+    }
+
+    // command "storagecache" [call]
+    StorageCacheCommand(client: WshClient, opts?: RpcOpts): Promise<StorageCacheStats> {
+        return client.wshRpcCall("storagecache", null, opts);
+    }
+
+    // command "storagecheck" [call]
+    StorageCheckCommand(client: WshClient, data: CommandStorageCheckData, opts?: RpcOpts): Promise<StorageCheckResult> {
+        return client.wshRpcCall("storagecheck", data, opts);
+    }
+
+    // command "storageprune" [call]
+    StoragePruneCommand(client: WshClient, data: CommandStoragePruneData, opts?: RpcOpts): Promise<RetentionAction[]> {
+        return client.wshRpcCall("storageprune", data, opts);
+    }
+
+    // command "storagereplicakey" [call]
+    StorageReplicaKeyCommand(client: WshClient, data: CommandStorageReplicaKeyData, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("storagereplicakey", data, opts);
+    }
+
+    // command "storagereplicalist" [call]
+    StorageReplicaListCommand(client: WshClient, data: CommandStorageReplicaListData, opts?: RpcOpts): Promise<ReplicaFileInfo[]> {
+        return client.wshRpcCall("storagereplicalist", data, opts);
+    }
+
+    // command "storagereplicarestore" [call]
+    StorageReplicaRestoreCommand(client: WshClient, data: CommandStorageReplicaRestoreData, opts?: RpcOpts): Promise<ReplicaFileInfo[]> {
+        return client.wshRpcCall("storagereplicarestore", data, opts);
+    }
+
+    // command "storagereplicasync" [call]
+    StorageReplicaSyncCommand(client: WshClient, opts?: RpcOpts): Promise<ReplicaSyncResult> {
+        return client.wshRpcCall("storagereplicasync", null, opts);
+    }
+
+    // command "storageusage" [call]
+    StorageUsageCommand(client: WshClient, data: CommandStorageUsageData, opts?: RpcOpts): Promise<StorageUsage[]> {
+        return client.wshRpcCall("storageusage", data, opts);
+    }
+
     // command "streamcpudata" [responsestream]
 	StreamCpuDataCommand(client: WshClient, data: CpuDataRequest, opts?: RpcOpts): AsyncGenerator<TimeSeriesData, void, boolean> {
         return client.wshRpcStream("streamcpudata", data, opts);
@@ -422,11 +952,116 @@ class RpcApiType {
         return client.wshRpcStream("streamwaveai", data, opts);
     }
 
+    // command "termfontget" [call]
+    TermFontGetCommand(client: WshClient, data: CommandTermFontData, opts?: RpcOpts): Promise<TermFontInfo> {
+        return client.wshRpcCall("termfontget", data, opts);
+    }
+
+    // command "termfontset" [call]
+    TermFontSetCommand(client: WshClient, data: CommandTermFontSetData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("termfontset", data, opts);
+    }
+
+    // command "termgetlinks" [call]
+    TermGetLinksCommand(client: WshClient, data: CommandTermGetLinksData, opts?: RpcOpts): Promise<TermLink[]> {
+        return client.wshRpcCall("termgetlinks", data, opts);
+    }
+
+    // command "termgetsegmentoutput" [call]
+    TermGetSegmentOutputCommand(client: WshClient, data: CommandTermGetSegmentOutputData, opts?: RpcOpts): Promise<TermSegmentOutput> {
+        return client.wshRpcCall("termgetsegmentoutput", data, opts);
+    }
+
+    // command "termgetsegments" [call]
+    TermGetSegmentsCommand(client: WshClient, data: CommandTermGetSegmentsData, opts?: RpcOpts): Promise<TermSegment[]> {
+        return client.wshRpcCall("termgetsegments", data, opts);
+    }
+
+    // command "termlistrecordings" [call]
+    TermListRecordingsCommand(client: WshClient, data: CommandTermRecordData, opts?: RpcOpts): Promise<TermRecording[]> {
+        return client.wshRpcCall("termlistrecordings", data, opts);
+    }
+
+    // command "termpanecreate" [call]
+    TermPaneCreateCommand(client: WshClient, data: CommandTermPaneCreateData, opts?: RpcOpts): Promise<TermPaneInfo> {
+        return client.wshRpcCall("termpanecreate", data, opts);
+    }
+
+    // command "termpanekill" [call]
+    TermPaneKillCommand(client: WshClient, data: CommandTermPaneData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("termpanekill", data, opts);
+    }
+
+    // command "termpanelist" [call]
+    TermPaneListCommand(client: WshClient, data: CommandTermPaneData, opts?: RpcOpts): Promise<TermPaneInfo[]> {
+        return client.wshRpcCall("termpanelist", data, opts);
+    }
+
+    // command "termpaneswitch" [call]
+    TermPaneSwitchCommand(client: WshClient, data: CommandTermPaneData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("termpaneswitch", data, opts);
+    }
+
+    // command "termqueueadd" [call]
+    TermQueueAddCommand(client: WshClient, data: CommandTermQueueData, opts?: RpcOpts): Promise<QueuedCommand> {
+        return client.wshRpcCall("termqueueadd", data, opts);
+    }
+
+    // command "termqueuecancel" [call]
+    TermQueueCancelCommand(client: WshClient, data: CommandTermQueueData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("termqueuecancel", data, opts);
+    }
+
+    // command "termqueuelist" [call]
+    TermQueueListCommand(client: WshClient, data: CommandTermQueueData, opts?: RpcOpts): Promise<QueuedCommand[]> {
+        return client.wshRpcCall("termqueuelist", data, opts);
+    }
+
+    // command "termrecordstart" [call]
+    TermRecordStartCommand(client: WshClient, data: CommandTermRecordData, opts?: RpcOpts): Promise<TermRecording> {
+        return client.wshRpcCall("termrecordstart", data, opts);
+    }
+
+    // command "termrecordstop" [call]
+    TermRecordStopCommand(client: WshClient, data: CommandTermRecordData, opts?: RpcOpts): Promise<TermRecording> {
+        return client.wshRpcCall("termrecordstop", data, opts);
+    }
+
     // command "test" [call]
     TestCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("test", data, opts);
     }
 
+    // command "themeassign" [call]
+    ThemeAssignCommand(client: WshClient, data: CommandThemeAssignData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("themeassign", data, opts);
+    }
+
+    // command "themedelete" [call]
+    ThemeDeleteCommand(client: WshClient, data: CommandThemeData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("themedelete", data, opts);
+    }
+
+    // command "themeget" [call]
+    ThemeGetCommand(client: WshClient, data: CommandThemeData, opts?: RpcOpts): Promise<TermThemeType> {
+        return client.wshRpcCall("themeget", data, opts);
+    }
+
+    // command "themeimport" [call]
+    ThemeImportCommand(client: WshClient, data: CommandThemeImportData, opts?: RpcOpts): Promise<Theme> {
+        return client.wshRpcCall("themeimport", data, opts);
+    }
+
+    // command "themelist" [call]
+    ThemeListCommand(client: WshClient, opts?: RpcOpts): Promise<ThemeInfo[]> {
+        return client.wshRpcCall("themelist", null, opts);
+    }
+
+    // command "themesave" [call]
+    ThemeSaveCommand(client: WshClient, data: CommandThemeSaveData, opts?: RpcOpts): Promise<Theme> {
+        return client.wshRpcCall("themesave", data, opts);
+    }
+
     // command "vdomasyncinitiation" [call]
     VDomAsyncInitiationCommand(client: WshClient, data: VDomAsyncInitiationRequest, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("vdomasyncinitiation", data, opts);
@@ -457,6 +1092,36 @@ class RpcApiType {
         return client.wshRpcCall("waveinfo", null, opts);
     }
 
+    // command "webhookcreate" [call]
+    WebhookCreateCommand(client: WshClient, data: CommandWebhookCreateData, opts?: RpcOpts): Promise<WebhookCreateRtnData> {
+        return client.wshRpcCall("webhookcreate", data, opts);
+    }
+
+    // command "webhookdelete" [call]
+    WebhookDeleteCommand(client: WshClient, data: CommandWebhookData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("webhookdelete", data, opts);
+    }
+
+    // command "webhookdeliveries" [call]
+    WebhookDeliveriesCommand(client: WshClient, data: CommandWebhookData, opts?: RpcOpts): Promise<WebhookDelivery[]> {
+        return client.wshRpcCall("webhookdeliveries", data, opts);
+    }
+
+    // command "webhooklist" [call]
+    WebhookListCommand(client: WshClient, opts?: RpcOpts): Promise<Webhook[]> {
+        return client.wshRpcCall("webhooklist", null, opts);
+    }
+
+    // command "webhooksetdisabled" [call]
+    WebhookSetDisabledCommand(client: WshClient, data: CommandWebhookData, opts?: RpcOpts): Promise<Webhook> {
+        return client.wshRpcCall("webhooksetdisabled", data, opts);
+    }
+
+    // command "webhooktest" [call]
+    WebhookTestCommand(client: WshClient, data: CommandWebhookData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("webhooktest", data, opts);
+    }
+
     // command "webselector" [call]
     WebSelectorCommand(client: WshClient, data: CommandWebSelectorData, opts?: RpcOpts): Promise<string[]> {
         return client.wshRpcCall("webselector", data, opts);
@@ -477,11 +1142,26 @@ class RpcApiType {
         return client.wshRpcCall("wsldefaultdistro", null, opts);
     }
 
+    // command "wsldistrolist" [call]
+    WslDistroListCommand(client: WshClient, opts?: RpcOpts): Promise<WslDistroInfo[]> {
+        return client.wshRpcCall("wsldistrolist", null, opts);
+    }
+
     // command "wsllist" [call]
     WslListCommand(client: WshClient, opts?: RpcOpts): Promise<string[]> {
         return client.wshRpcCall("wsllist", null, opts);
     }
 
+    // command "wslpath" [call]
+    WslPathCommand(client: WshClient, data: CommandWslPathData, opts?: RpcOpts): Promise<WslPathResult> {
+        return client.wshRpcCall("wslpath", data, opts);
+    }
+
+    // command "wslsetshell" [call]
+    WslSetShellCommand(client: WshClient, data: CommandWslSetShellData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("wslsetshell", data, opts);
+    }
+
     // command "wslstatus" [call]
     WslStatusCommand(client: WshClient, opts?: RpcOpts): Promise<ConnStatus[]> {
         return client.wshRpcCall("wslstatus", null, opts);
@@ -23,6 +23,10 @@ function makeTabRouteId(tabId: string): string {
     return `tab:${tabId}`;
 }
 
+function makeFeClientRouteId(feClientId: string): string {
+    return `feclient:${feClientId}`;
+}
+
 class WshRouter {
     routeMap: Map<string, AbstractWshClient>; // routeid -> client
     upstreamClient: AbstractWshClient;
@@ -149,4 +153,4 @@ class WshRouter {
     }
 }
 
-export { makeFeBlockRouteId, makeTabRouteId, WshRouter };
+export { makeFeBlockRouteId, makeFeClientRouteId, makeTabRouteId, WshRouter };
@@ -3,9 +3,16 @@
 
 import { wpsReconnectHandler } from "@/app/store/wps";
 import { WshClient } from "@/app/store/wshclient";
-import { makeTabRouteId, WshRouter } from "@/app/store/wshrouter";
+import { makeFeClientRouteId, makeTabRouteId, WshRouter } from "@/app/store/wshrouter";
 import { getWSServerEndpoint } from "@/util/endpoints";
-import { addWSReconnectHandler, ElectronOverrideOpts, globalWS, initGlobalWS, WSControl } from "./ws";
+import {
+    addWSReconnectHandler,
+    ElectronOverrideOpts,
+    FeClientId,
+    globalWS,
+    initGlobalWS,
+    WSControl,
+} from "./ws";
 
 let DefaultRouter: WshRouter;
 let TabRpcClient: WshClient;
@@ -20,9 +27,10 @@ async function* rpcResponseGenerator(
     let signalFn: () => void;
     let signalPromise = new Promise<void>((resolve) => (signalFn = resolve));
     let timeoutId: NodeJS.Timeout = null;
+    const timeoutMsg: RpcMessage = { resid: reqid, error: "EC-TIME: timeout waiting for response" };
     if (timeout > 0) {
         timeoutId = setTimeout(() => {
-            msgQueue.push({ resid: reqid, error: "EC-TIME: timeout waiting for response" });
+            msgQueue.push(timeoutMsg);
             signalFn();
         }, timeout);
     }
@@ -39,28 +47,35 @@ async function* rpcResponseGenerator(
         msgFn: msgFn,
     });
     yield null;
+    // set once the server is done with the request, if the generator exits before that (the caller stopped
+    // reading the stream, or it timed out), the request is canceled so the server stops working on it
+    let responseDone = false;
     try {
         while (true) {
             while (msgQueue.length > 0) {
                 const msg = msgQueue.shift()!;
                 if (msg.error != null) {
+                    responseDone = msg !== timeoutMsg;
                     throw new Error(msg.error);
                 }
                 if (!msg.cont && msg.data == null) {
-                    return;
-                }
-                const shouldTerminate = yield msg.data;
-                if (shouldTerminate) {
-                    sendRpcCancel(reqid);
+                    responseDone = true;
                     return;
                 }
                 if (!msg.cont) {
+                    responseDone = true;
+                }
+                const shouldTerminate = yield msg.data;
+                if (shouldTerminate || !msg.cont) {
                     return;
                 }
             }
             await signalPromise;
         }
     } finally {
+        if (!responseDone) {
+            sendRpcCancel(reqid);
+        }
         openRpcs.delete(reqid);
         if (timeoutId != null) {
             clearTimeout(timeoutId);
@@ -133,12 +148,16 @@ function initWshrpc(tabId: string): WSControl {
     };
     initGlobalWS(getWSServerEndpoint(), tabId, handleFn);
     globalWS.connectNow("connectWshrpc");
-    TabRpcClient = new WshClient(makeTabRouteId(tabId));
+    // this frontend's own route (rpc responses and events), the tab's route is shared with the other frontends
+    // showing the tab.  the last one to announce it gets its requests, so the routes are re-announced on focus.
+    TabRpcClient = new WshClient(makeFeClientRouteId(FeClientId));
     DefaultRouter.registerRoute(TabRpcClient.routeId, TabRpcClient);
+    DefaultRouter.registerRoute(makeTabRouteId(tabId), TabRpcClient);
     addWSReconnectHandler(() => {
         DefaultRouter.reannounceRoutes();
     });
     addWSReconnectHandler(wpsReconnectHandler);
+    window.addEventListener("focus", () => DefaultRouter.reannounceRoutes());
     return globalWS;
 }
 
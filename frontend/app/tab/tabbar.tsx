@@ -8,6 +8,7 @@ import { deleteLayoutModelForTab } from "@/layout/index";
 import { atoms, createTab, getApi, globalStore, isDev, setActiveTab } from "@/store/global";
 import { PLATFORM, PlatformMacOS } from "@/util/platformutil";
 import { fireAndForget } from "@/util/util";
+import clsx from "clsx";
 import { useAtomValue } from "jotai";
 import { OverlayScrollbars } from "overlayscrollbars";
 import { createRef, memo, useCallback, useEffect, useRef, useState } from "react";
@@ -42,10 +43,48 @@ interface TabBarProps {
     workspace: Workspace;
 }
 
+function formatConfigErrorLocation(error: ConfigError): string {
+    if (error.line == null || error.line == 0) {
+        return error.file;
+    }
+    return `${error.file}:${error.line}:${error.col}`;
+}
+
+// the settings renamed or changed by the migrations when settings.json was read (see wconfig.MigrateSettings)
+const ConfigMigrations = ({ migrations }: { migrations: SettingsMigration[] }) => {
+    if (migrations == null || migrations.length == 0) {
+        return null;
+    }
+    return (
+        <>
+            <h3>Settings Migrated</h3>
+            <p>
+                These settings are from an older version of Wave and were migrated, run{" "}
+                <code>wsh settings migrate</code> to update the files.
+            </p>
+            <ul>
+                {migrations.map((migration, index) => (
+                    <li key={index}>
+                        {migration.file}: {migration.key} {migration.desc}
+                    </li>
+                ))}
+            </ul>
+        </>
+    );
+};
+
 const ConfigErrorMessage = () => {
     const fullConfig = useAtomValue(atoms.fullConfigAtom);
+    const migrations = fullConfig?.migrations;
 
     if (fullConfig?.configerrors == null || fullConfig?.configerrors.length == 0) {
+        if (migrations?.length > 0) {
+            return (
+                <div className="config-error-message">
+                    <ConfigMigrations migrations={migrations} />
+                </div>
+            );
+        }
         return (
             <div className="config-error-message">
                 <h3>Configuration Clean</h3>
@@ -59,8 +98,9 @@ const ConfigErrorMessage = () => {
             <div className="config-error-message">
                 <h3>Configuration Error</h3>
                 <div>
-                    {singleError.file}: {singleError.err}
+                    {formatConfigErrorLocation(singleError)}: {singleError.err}
                 </div>
+                <ConfigMigrations migrations={migrations} />
             </div>
         );
     }
@@ -70,10 +110,11 @@ const ConfigErrorMessage = () => {
             <ul>
                 {fullConfig.configerrors.map((error, index) => (
                     <li key={index}>
-                        {error.file}: {error.err}
+                        {formatConfigErrorLocation(error)}: {error.err}
                     </li>
                 ))}
             </ul>
+            <ConfigMigrations migrations={migrations} />
         </div>
     );
 };
@@ -85,17 +126,18 @@ const ConfigErrorIcon = ({ buttonRef }: { buttonRef: React.RefObject<HTMLElement
         modalsModel.pushModal("MessageModal", { children: <ConfigErrorMessage /> });
     }
 
-    if (fullConfig?.configerrors == null || fullConfig?.configerrors.length == 0) {
+    const hasErrors = fullConfig?.configerrors?.length > 0;
+    if (!hasErrors && !(fullConfig?.migrations?.length > 0)) {
         return null;
     }
     return (
         <Button
             ref={buttonRef as React.RefObject<HTMLButtonElement>}
-            className="config-error-button red"
+            className={clsx("config-error-button", hasErrors ? "red" : "grey")}
             onClick={handleClick}
         >
-            <i className="fa fa-solid fa-exclamation-triangle" />
-            Config Error
+            <i className={clsx("fa fa-solid", hasErrors ? "fa-exclamation-triangle" : "fa-arrow-up-right-dots")} />
+            {hasErrors ? "Config Error" : "Settings Migrated"}
         </Button>
     );
 };
@@ -0,0 +1,229 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+import { BlockNodeModel } from "@/app/block/blocktypes";
+import { waveEventSubscribe } from "@/app/store/wps";
+import { RpcApi } from "@/app/store/wshclientapi";
+import { TabRpcClient } from "@/app/store/wshrpcutil";
+import { globalStore, WOS } from "@/store/global";
+import { fireAndForget } from "@/util/util";
+import clsx from "clsx";
+import * as jotai from "jotai";
+import { useState } from "react";
+import "./clusterexec.scss";
+
+// a run with many hosts sends an event for every host that starts and finishes, they are fetched at most this often
+const RefreshThrottleMs = 250;
+
+function formatDuration(ms: number): string {
+    if (ms < 1000) {
+        return `${ms} ms`;
+    }
+    return `${(ms / 1000).toFixed(1)} s`;
+}
+
+function hostStatusClass(host: ClusterExecHost): string {
+    if (host.status == "done" && (host.result?.exitcode != 0 || host.result?.timedout)) {
+        return "status-failed";
+    }
+    return `status-${host.status}`;
+}
+
+function hostSummary(host: ClusterExecHost): string {
+    if (host.status == "error") {
+        return host.error;
+    }
+    if (host.status != "done") {
+        return host.status;
+    }
+    if (host.result.timedout) {
+        return `timed out after ${formatDuration(host.result.durationms)}`;
+    }
+    const exit = host.result.signal ? host.result.signal : `exit ${host.result.exitcode}`;
+    return `${exit}, ${formatDuration(host.result.durationms)}`;
+}
+
+class ClusterExecViewModel implements ViewModel {
+    viewType: string;
+    blockId: string;
+    nodeModel: BlockNodeModel;
+    blockAtom: jotai.Atom<Block>;
+    viewIcon: jotai.Atom<string>;
+    viewName: jotai.Atom<string>;
+    viewText: jotai.Atom<HeaderElem[]>;
+    runAtom: jotai.PrimitiveAtom<ClusterExecRun>;
+    errorAtom: jotai.PrimitiveAtom<string>;
+    eventUnsubFn: () => void;
+    refreshTimeout: ReturnType<typeof setTimeout>;
+
+    constructor(blockId: string, nodeModel: BlockNodeModel) {
+        this.viewType = "clusterexec";
+        this.blockId = blockId;
+        this.nodeModel = nodeModel;
+        this.blockAtom = WOS.getWaveObjectAtom<Block>(`block:${blockId}`);
+        this.viewIcon = jotai.atom("server");
+        this.viewName = jotai.atom("Cluster Exec");
+        this.runAtom = jotai.atom(null) as jotai.PrimitiveAtom<ClusterExecRun>;
+        this.errorAtom = jotai.atom(null) as jotai.PrimitiveAtom<string>;
+        this.viewText = jotai.atom((get) => {
+            const run = get(this.runAtom);
+            if (run == null) {
+                return [];
+            }
+            const numDone = run.hosts.filter((host) => host.status == "done" || host.status == "error").length;
+            const numFailed = run.hosts.filter((host) => hostStatusClass(host) != "status-done").length;
+            const elems: HeaderElem[] = [{ elemtype: "text", text: run.cmd, className: "clusterexec-cmd" }];
+            if (run.endts) {
+                const status = run.canceled ? "canceled" : numFailed > 0 ? `${numFailed} failed` : "all ok";
+                elems.push({ elemtype: "text", text: `${run.hosts.length} hosts, ${status}` });
+            } else {
+                elems.push({ elemtype: "text", text: `${numDone}/${run.hosts.length} done` });
+                elems.push({
+                    elemtype: "iconbutton",
+                    icon: "stop",
+                    title: "Cancel the run",
+                    click: () => fireAndForget(() => this.cancel()),
+                });
+            }
+            return elems;
+        });
+        this.eventUnsubFn = waveEventSubscribe({
+            eventType: "clusterexec:update",
+            handler: (event) => {
+                const update = event.data as ClusterExecEventData;
+                if (update?.runid == this.getRunId()) {
+                    this.scheduleRefresh();
+                }
+            },
+        });
+        fireAndForget(() => this.refresh());
+    }
+
+    get viewComponent(): ViewComponent {
+        return ClusterExecView;
+    }
+
+    dispose() {
+        this.eventUnsubFn?.();
+        clearTimeout(this.refreshTimeout);
+    }
+
+    getRunId(): string {
+        return globalStore.get(this.blockAtom)?.meta?.["clusterexec:runid"];
+    }
+
+    scheduleRefresh() {
+        if (this.refreshTimeout != null) {
+            return;
+        }
+        this.refreshTimeout = setTimeout(() => {
+            this.refreshTimeout = null;
+            fireAndForget(() => this.refresh());
+        }, RefreshThrottleMs);
+    }
+
+    async refresh() {
+        const runId = this.getRunId();
+        if (!runId) {
+            globalStore.set(this.errorAtom, 'No cluster exec run (start one with "wsh conn cluster")');
+            return;
+        }
+        try {
+            const run = await RpcApi.ClusterExecGetCommand(TabRpcClient, { runid: runId });
+            globalStore.set(this.runAtom, run);
+            globalStore.set(this.errorAtom, null);
+        } catch (e) {
+            globalStore.set(this.errorAtom, `${e}`);
+        }
+    }
+
+    async cancel() {
+        try {
+            await RpcApi.ClusterExecCancelCommand(TabRpcClient, { runid: this.getRunId() });
+        } catch (e) {
+            globalStore.set(this.errorAtom, `${e}`);
+        }
+    }
+}
+
+function OutputBlock({ stdout, stderr }: { stdout: string; stderr: string }) {
+    if (!stdout && !stderr) {
+        return <div className="clusterexec-nooutput">(no output)</div>;
+    }
+    return (
+        <div className="clusterexec-output">
+            {stdout ? <pre>{stdout}</pre> : null}
+            {stderr ? <pre className="stderr">{stderr}</pre> : null}
+        </div>
+    );
+}
+
+function HostRow({ host }: { host: ClusterExecHost }) {
+    const [expanded, setExpanded] = useState(false);
+    const hasOutput = host.result != null;
+    return (
+        <div className="clusterexec-host">
+            <div
+                className={clsx("clusterexec-host-main", { clickable: hasOutput })}
+                onClick={() => hasOutput && setExpanded(!expanded)}
+            >
+                <span className={clsx("clusterexec-status", hostStatusClass(host))} />
+                <div className="clusterexec-host-name" title={host.connname}>
+                    {host.connection}
+                </div>
+                <div className="clusterexec-host-summary">{hostSummary(host)}</div>
+                {hasOutput ? (
+                    <i className={clsx("fa-sharp fa-solid", expanded ? "fa-chevron-up" : "fa-chevron-down")} />
+                ) : null}
+            </div>
+            {expanded ? <OutputBlock stdout={host.result.stdout} stderr={host.result.stderr} /> : null}
+        </div>
+    );
+}
+
+function GroupRow({ group }: { group: ClusterExecGroup }) {
+    const status = group.error ? group.error : `exit ${group.exitcode}`;
+    const statusClass = group.exitcode == 0 && !group.error ? "status-done" : "status-failed";
+    return (
+        <div className="clusterexec-group">
+            <div className="clusterexec-group-header">
+                <span className={clsx("clusterexec-status", statusClass)} />
+                <span className="clusterexec-group-count">{group.hosts.length}</span>
+                <span className="clusterexec-group-hosts">{group.hosts.join(", ")}</span>
+                <span className="clusterexec-group-status">{status}</span>
+            </div>
+            {group.error && group.exitcode == -1 && !group.stdout && !group.stderr ? null : (
+                <OutputBlock stdout={group.stdout} stderr={group.stderr} />
+            )}
+        </div>
+    );
+}
+
+function ClusterExecView({ model }: ViewComponentProps<ClusterExecViewModel>) {
+    const run = jotai.useAtomValue(model.runAtom);
+    const error = jotai.useAtomValue(model.errorAtom);
+    if (run == null) {
+        return <div className="clusterexec-view clusterexec-message">{error ?? "Loading..."}</div>;
+    }
+    return (
+        <div className="clusterexec-view">
+            {error ? <div className="clusterexec-error">{error}</div> : null}
+            {run.groups?.length > 0 ? (
+                <div className="clusterexec-section">
+                    <div className="clusterexec-section-title">Grouped output</div>
+                    {run.groups.map((group, idx) => (
+                        <GroupRow key={idx} group={group} />
+                    ))}
+                </div>
+            ) : null}
+            <div className="clusterexec-section">
+                <div className="clusterexec-section-title">Hosts</div>
+                {run.hosts.map((host) => (
+                    <HostRow key={host.connection} host={host} />
+                ))}
+            </div>
+        </div>
+    );
+}
+
+export { ClusterExecViewModel };
@@ -0,0 +1,213 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+import { BlockNodeModel } from "@/app/block/blocktypes";
+import { waveEventSubscribe } from "@/app/store/wps";
+import { RpcApi } from "@/app/store/wshclientapi";
+import { TabRpcClient } from "@/app/store/wshrpcutil";
+import { atoms, globalStore, WOS } from "@/store/global";
+import { fireAndForget, makeIconClass } from "@/util/util";
+import clsx from "clsx";
+import * as jotai from "jotai";
+import "./connections.scss";
+
+// the metrics and the bytes of the forwards are not sent as events
+const DashboardRefreshMs = 5000;
+
+// connect only applies to connections that are down, disconnect to the ones that are up
+const ConnectActionId = "conn:connect";
+const DisconnectActionId = "conn:disconnect";
+
+function formatBytes(bytes: number): string {
+    if (bytes < 1024) {
+        return `${bytes} B`;
+    }
+    if (bytes < 1024 * 1024) {
+        return `${(bytes / 1024).toFixed(1)} KB`;
+    }
+    return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+}
+
+function latencyTitle(metrics: ConnMetrics): string {
+    if (!metrics?.smoothedlatencyms) {
+        return "latency of the keepalive probes";
+    }
+    return (
+        `smoothed latency of the keepalive probes (last ${metrics.latencyms} ms, ` +
+        `min ${metrics.minlatencyms} ms, max ${metrics.maxlatencyms} ms in the last 10 minutes)`
+    );
+}
+
+function formatForward(fwd: PortForwardInfo): string {
+    const spec = fwd.forward;
+    if (spec.type == "dynamic") {
+        return `socks ${spec.bindport}`;
+    }
+    const arrow = spec.type == "remote" ? "<-" : "->";
+    return `${spec.bindport} ${arrow} ${spec.desthost}:${spec.destport}`;
+}
+
+function isConnUp(status: ConnStatus): boolean {
+    return status?.status == "connected" || status?.status == "degraded" || status?.status == "connecting";
+}
+
+class ConnectionsViewModel implements ViewModel {
+    viewType: string;
+    blockId: string;
+    nodeModel: BlockNodeModel;
+    viewIcon: jotai.Atom<string>;
+    viewName: jotai.Atom<string>;
+    viewText: jotai.Atom<HeaderElem[]>;
+    entriesAtom: jotai.PrimitiveAtom<ConnDashboardEntry[]>;
+    actionsAtom: jotai.PrimitiveAtom<ActionDef[]>;
+    errorAtom: jotai.PrimitiveAtom<string>;
+    connChangeUnsubFn: () => void;
+    refreshTimer: ReturnType<typeof setInterval>;
+
+    constructor(blockId: string, nodeModel: BlockNodeModel) {
+        this.viewType = "connections";
+        this.blockId = blockId;
+        this.nodeModel = nodeModel;
+        this.viewIcon = jotai.atom("network-wired");
+        this.viewName = jotai.atom("Connections");
+        this.entriesAtom = jotai.atom(null) as jotai.PrimitiveAtom<ConnDashboardEntry[]>;
+        this.actionsAtom = jotai.atom(null) as jotai.PrimitiveAtom<ActionDef[]>;
+        this.errorAtom = jotai.atom(null) as jotai.PrimitiveAtom<string>;
+        this.viewText = jotai.atom((get) => {
+            const entries = get(this.entriesAtom) ?? [];
+            const numUp = entries.filter((entry) => isConnUp(entry.info.status)).length;
+            return [{ elemtype: "text", text: `${numUp}/${entries.length} connected` }];
+        });
+        this.connChangeUnsubFn = waveEventSubscribe({
+            eventType: "connchange",
+            handler: () => fireAndForget(() => this.refresh()),
+        });
+        this.refreshTimer = setInterval(() => fireAndForget(() => this.refresh()), DashboardRefreshMs);
+        fireAndForget(() => this.refresh());
+    }
+
+    get viewComponent(): ViewComponent {
+        return ConnectionsView;
+    }
+
+    dispose() {
+        this.connChangeUnsubFn?.();
+        clearInterval(this.refreshTimer);
+    }
+
+    async refresh() {
+        try {
+            const entries = await RpcApi.ConnDashboardCommand(TabRpcClient);
+            globalStore.set(this.entriesAtom, entries ?? []);
+            globalStore.set(this.errorAtom, null);
+            // the actions of a connection are the same for all of them
+            if (globalStore.get(this.actionsAtom) == null && entries?.length > 0) {
+                const oref = WOS.makeORef("connection", entries[0].info.connection.oid);
+                const actions = await RpcApi.ListActionsCommand(TabRpcClient, { oref });
+                globalStore.set(this.actionsAtom, actions ?? []);
+            }
+        } catch (e) {
+            globalStore.set(this.errorAtom, `${e}`);
+        }
+    }
+
+    async runAction(action: ActionDef, connId: string) {
+        try {
+            await RpcApi.ExecuteActionCommand(TabRpcClient, {
+                actionid: action.actionid,
+                oref: WOS.makeORef("connection", connId),
+                tabid: globalStore.get(atoms.staticTabId),
+            });
+            globalStore.set(this.errorAtom, null);
+        } catch (e) {
+            globalStore.set(this.errorAtom, `${e}`);
+        }
+        await this.refresh();
+    }
+}
+
+function ConnectionRow({ model, entry }: { model: ConnectionsViewModel; entry: ConnDashboardEntry }) {
+    const actions = jotai.useAtomValue(model.actionsAtom) ?? [];
+    const { connection, connname, status, blockids } = entry.info;
+    const connUp = isConnUp(status);
+    const metrics = entry.metrics;
+    const latencyMs = metrics?.smoothedlatencyms || status.latencyms;
+    const rowActions = actions.filter(
+        (action) =>
+            !(action.actionid == ConnectActionId && connUp) && !(action.actionid == DisconnectActionId && !connUp)
+    );
+    return (
+        <div className="conn-row">
+            <div className="conn-main">
+                <span
+                    className={clsx("conn-status", `status-${status.status}`)}
+                    title={status.error || status.status}
+                />
+                <div className="conn-name">
+                    <div className="conn-title">{connection.name}</div>
+                    <div className="conn-subtitle">{connname}</div>
+                </div>
+                <div className="conn-stat" title={latencyTitle(metrics)}>
+                    {latencyMs > 0 && connUp ? `${latencyMs} ms` : "-"}
+                </div>
+                <div
+                    className="conn-stat"
+                    title={`received / sent per second (${formatBytes(metrics?.bytesin ?? 0)} / ${formatBytes(
+                        metrics?.bytesout ?? 0
+                    )} in total)`}
+                >
+                    {metrics && connUp
+                        ? `${formatBytes(metrics.bytesinrate)}/s / ${formatBytes(metrics.bytesoutrate)}/s`
+                        : "-"}
+                </div>
+                <div className="conn-stat" title="blocks using this connection">
+                    <i className={makeIconClass("square-terminal", false)} /> {blockids?.length ?? 0}
+                </div>
+                <div className="conn-actions">
+                    {rowActions.map((action) => (
+                        <button
+                            key={action.actionid}
+                            className="ghost grey"
+                            title={action.title}
+                            onClick={() => fireAndForget(() => model.runAction(action, connection.oid))}
+                        >
+                            <i className={makeIconClass(action.icon, false)} />
+                        </button>
+                    ))}
+                </div>
+            </div>
+            {status.error ? <div className="conn-error">{status.error}</div> : null}
+            {entry.forwards?.map((fwd) => (
+                <div key={fwd.id} className="conn-forward" title={fwd.error}>
+                    <span className={clsx("conn-forward-status", `status-${fwd.status}`)}>{fwd.status}</span>
+                    <span className="conn-forward-spec">{formatForward(fwd)}</span>
+                    <span className="conn-forward-bytes">
+                        {formatBytes(fwd.bytessent)} / {formatBytes(fwd.bytesrecv)}, {fwd.activeconns} open
+                    </span>
+                </div>
+            ))}
+        </div>
+    );
+}
+
+function ConnectionsView({ model }: ViewComponentProps<ConnectionsViewModel>) {
+    const entries = jotai.useAtomValue(model.entriesAtom);
+    const error = jotai.useAtomValue(model.errorAtom);
+    if (entries == null) {
+        return <div className="connections-view connections-message">Loading...</div>;
+    }
+    return (
+        <div className="connections-view">
+            {error ? <div className="connections-error">{error}</div> : null}
+            {entries.length == 0 ? (
+                <div className="connections-message">No saved connections (add one with "wsh conn add")</div>
+            ) : (
+                entries.map((entry) => (
+                    <ConnectionRow key={entry.info.connection.oid} model={model} entry={entry} />
+                ))
+            )}
+        </div>
+    );
+}
+
+export { ConnectionsViewModel };
@@ -0,0 +1,321 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+import { BlockNodeModel } from "@/app/block/blocktypes";
+import { getFileSubject, waveEventSubscribe } from "@/app/store/wps";
+import { RpcApi } from "@/app/store/wshclientapi";
+import { TabRpcClient } from "@/app/store/wshrpcutil";
+import { computeTheme, DefaultTermTheme } from "@/app/view/term/termutil";
+import { atoms, fetchWaveFile, getSettingsKeyAtom, globalStore, WOS } from "@/store/global";
+import { checkKeyPressed } from "@/util/keyutil";
+import { base64ToArray, fireAndForget, makeIconClass } from "@/util/util";
+import { Terminal } from "@xterm/xterm";
+import * as jotai from "jotai";
+import * as React from "react";
+import "../term/xterm.css";
+import "./player.scss";
+
+// the backend (pkg/castplayer) writes the recording's output to the block's term file as it plays
+const PlayerTermFileName = "term";
+const PlayerSpeeds = [0.5, 1, 2, 4, 8];
+
+function formatPlayerTime(secs: number): string {
+    const totalSecs = Math.max(0, Math.floor(secs));
+    const mins = Math.floor(totalSecs / 60);
+    return `${mins}:${(totalSecs % 60).toString().padStart(2, "0")}`;
+}
+
+// the status has the position at status.ts
+function getPlayerPos(status: PlayerStatus): number {
+    if (status == null) {
+        return 0;
+    }
+    if (status.state != "playing") {
+        return status.pos;
+    }
+    return Math.min(status.duration, status.pos + ((Date.now() - status.ts) / 1000) * status.speed);
+}
+
+class PlayerViewModel implements ViewModel {
+    viewType: string;
+    blockId: string;
+    nodeModel: BlockNodeModel;
+    blockAtom: jotai.Atom<Block>;
+    viewIcon: jotai.Atom<string>;
+    viewName: jotai.Atom<string>;
+    viewText: jotai.Atom<HeaderElem[]>;
+    srcAtom: jotai.Atom<string>;
+    statusAtom: jotai.PrimitiveAtom<PlayerStatus>;
+    errorAtom: jotai.PrimitiveAtom<string>;
+    statusUnsubFn: () => void;
+
+    constructor(blockId: string, nodeModel: BlockNodeModel) {
+        this.viewType = "player";
+        this.blockId = blockId;
+        this.nodeModel = nodeModel;
+        this.blockAtom = WOS.getWaveObjectAtom<Block>(`block:${blockId}`);
+        this.viewIcon = jotai.atom("circle-play");
+        this.viewName = jotai.atom("Player");
+        this.srcAtom = jotai.atom((get) => get(this.blockAtom)?.meta?.["player:src"]);
+        this.statusAtom = jotai.atom(null) as jotai.PrimitiveAtom<PlayerStatus>;
+        this.errorAtom = jotai.atom(null) as jotai.PrimitiveAtom<string>;
+        this.viewText = jotai.atom((get) => {
+            const status = get(this.statusAtom);
+            const src = get(this.srcAtom) ?? "";
+            const text = status?.title || src.split("/").pop();
+            return [{ elemtype: "text", text: text }];
+        });
+        this.statusUnsubFn = waveEventSubscribe({
+            eventType: "player:status",
+            scope: WOS.makeORef("block", blockId),
+            handler: (event) => {
+                globalStore.set(this.statusAtom, event.data as PlayerStatus);
+            },
+        });
+    }
+
+    get viewComponent(): ViewComponent {
+        return PlayerView;
+    }
+
+    dispose() {
+        this.statusUnsubFn?.();
+    }
+
+    async runPlayerCommand(fn: () => Promise<PlayerStatus>) {
+        try {
+            const status = await fn();
+            globalStore.set(this.statusAtom, status);
+            globalStore.set(this.errorAtom, null);
+        } catch (e) {
+            globalStore.set(this.errorAtom, `${e}`);
+        }
+    }
+
+    // a newly loaded recording starts playing
+    async load(src: string) {
+        await this.runPlayerCommand(() =>
+            RpcApi.PlayerLoadCommand(TabRpcClient, { blockid: this.blockId, src: src })
+        );
+        const status = globalStore.get(this.statusAtom);
+        if (status?.state == "paused" && status.pos == 0) {
+            await this.play();
+        }
+    }
+
+    play(): Promise<void> {
+        return this.runPlayerCommand(() => RpcApi.PlayerPlayCommand(TabRpcClient, { blockid: this.blockId }));
+    }
+
+    pause(): Promise<void> {
+        return this.runPlayerCommand(() => RpcApi.PlayerPauseCommand(TabRpcClient, { blockid: this.blockId }));
+    }
+
+    seek(pos: number): Promise<void> {
+        return this.runPlayerCommand(() => RpcApi.PlayerSeekCommand(TabRpcClient, { blockid: this.blockId, pos: pos }));
+    }
+
+    setSpeed(speed: number): Promise<void> {
+        return this.runPlayerCommand(() =>
+            RpcApi.PlayerSetSpeedCommand(TabRpcClient, { blockid: this.blockId, speed: speed })
+        );
+    }
+
+    togglePlay(): Promise<void> {
+        const status = globalStore.get(this.statusAtom);
+        return status?.state == "playing" ? this.pause() : this.play();
+    }
+
+    keyDownHandler(waveEvent: WaveKeyboardEvent): boolean {
+        if (checkKeyPressed(waveEvent, "Space")) {
+            fireAndForget(() => this.togglePlay());
+            return true;
+        }
+        const status = globalStore.get(this.statusAtom);
+        if (status != null && checkKeyPressed(waveEvent, "ArrowLeft")) {
+            fireAndForget(() => this.seek(getPlayerPos(status) - 5));
+            return true;
+        }
+        if (status != null && checkKeyPressed(waveEvent, "ArrowRight")) {
+            fireAndForget(() => this.seek(getPlayerPos(status) + 5));
+            return true;
+        }
+        return false;
+    }
+}
+
+// shows the player's term file in a (read only) terminal the size of the recording
+function PlayerTerminal({ model }: { model: PlayerViewModel }) {
+    const connectElemRef = React.useRef<HTMLDivElement>(null);
+    const termRef = React.useRef<Terminal>(null);
+    const status = jotai.useAtomValue(model.statusAtom);
+    const fullConfig = jotai.useAtomValue(atoms.fullConfigAtom);
+    const themeName = jotai.useAtomValue(getSettingsKeyAtom("term:theme")) ?? DefaultTermTheme;
+    const fontSize = jotai.useAtomValue(getSettingsKeyAtom("term:fontsize")) ?? 12;
+    const [termTheme, _] = computeTheme(fullConfig, themeName, 0);
+
+    React.useEffect(() => {
+        const terminal = new Terminal({
+            theme: termTheme,
+            fontSize: fontSize,
+            fontFamily: "Hack",
+            disableStdin: true,
+            cursorBlink: false,
+            scrollback: 1000,
+        });
+        terminal.open(connectElemRef.current);
+        termRef.current = terminal;
+        let loaded = false;
+        let fileOffset = 0;
+        let heldData: WSFileEventData[] = [];
+        const handleFileData = (msg: WSFileEventData) => {
+            if (msg.fileop == "invalidate") {
+                // updates were dropped, reload the file (unless it is being loaded)
+                if (loaded) {
+                    loaded = false;
+                    fireAndForget(loadFile);
+                }
+                return;
+            }
+            if (!loaded) {
+                heldData.push(msg);
+                return;
+            }
+            if (msg.fileop == "truncate") {
+                terminal.reset();
+                fileOffset = 0;
+            } else if (msg.fileop == "append") {
+                const data = base64ToArray(msg.data64);
+                const endOffset = msg.offset + data.byteLength;
+                if (endOffset <= fileOffset) {
+                    return;
+                }
+                terminal.write(msg.offset < fileOffset ? data.slice(fileOffset - msg.offset) : data);
+                fileOffset = endOffset;
+            }
+        };
+        const fileSubject = getFileSubject(model.blockId, PlayerTermFileName);
+        fileSubject.subscribe(handleFileData);
+        const loadFile = async () => {
+            // reload if the file was truncated (a seek) while it was being read
+            do {
+                heldData = heldData.filter((msg) => msg.fileop != "truncate");
+                const { data, fileInfo } = await fetchWaveFile(model.blockId, PlayerTermFileName);
+                terminal.reset();
+                if (data != null && data.byteLength > 0) {
+                    terminal.write(data);
+                }
+                fileOffset = fileInfo?.size ?? 0;
+            } while (heldData.some((msg) => msg.fileop == "truncate"));
+            loaded = true;
+            for (const msg of heldData) {
+                handleFileData(msg);
+            }
+            heldData = [];
+        };
+        fireAndForget(loadFile);
+        return () => {
+            fileSubject.release();
+            terminal.dispose();
+            termRef.current = null;
+        };
+    }, []);
+
+    React.useEffect(() => {
+        if (termRef.current != null && status?.width > 0 && status?.height > 0) {
+            termRef.current.resize(status.width, status.height);
+        }
+    }, [status?.width, status?.height]);
+
+    React.useEffect(() => {
+        if (termRef.current != null) {
+            termRef.current.options.theme = termTheme;
+            termRef.current.options.fontSize = fontSize;
+        }
+    }, [themeName, fontSize, fullConfig]);
+
+    return (
+        <div className="player-term" style={{ backgroundColor: termTheme.background }}>
+            <div className="player-term-connect" ref={connectElemRef} />
+        </div>
+    );
+}
+
+function PlayerControls({ model }: { model: PlayerViewModel }) {
+    const status = jotai.useAtomValue(model.statusAtom);
+    const [, setTick] = React.useState(0);
+    const isPlaying = status?.state == "playing";
+
+    React.useEffect(() => {
+        if (!isPlaying) {
+            return;
+        }
+        const interval = setInterval(() => setTick((tick) => tick + 1), 250);
+        return () => clearInterval(interval);
+    }, [isPlaying]);
+
+    const pos = getPlayerPos(status);
+    const duration = status?.duration ?? 0;
+    return (
+        <div className="player-controls">
+            <button
+                className="player-button"
+                title={isPlaying ? "Pause (Space)" : "Play (Space)"}
+                disabled={status == null}
+                onClick={() => fireAndForget(() => model.togglePlay())}
+            >
+                <i className={makeIconClass(isPlaying ? "pause" : "play", false)} />
+            </button>
+            <input
+                className="player-seek"
+                type="range"
+                min={0}
+                max={duration}
+                step={0.1}
+                value={pos}
+                disabled={status == null}
+                onChange={(e) => fireAndForget(() => model.seek(Number(e.target.value)))}
+            />
+            <span className="player-time">
+                {formatPlayerTime(pos)} / {formatPlayerTime(duration)}
+            </span>
+            <select
+                className="player-speed"
+                title="Playback Speed"
+                value={status?.speed ?? 1}
+                disabled={status == null}
+                onChange={(e) => fireAndForget(() => model.setSpeed(Number(e.target.value)))}
+            >
+                {PlayerSpeeds.map((speed) => (
+                    <option key={speed} value={speed}>
+                        {speed}x
+                    </option>
+                ))}
+            </select>
+        </div>
+    );
+}
+
+function PlayerView({ model }: ViewComponentProps<PlayerViewModel>) {
+    const src = jotai.useAtomValue(model.srcAtom);
+    const error = jotai.useAtomValue(model.errorAtom);
+
+    React.useEffect(() => {
+        if (src) {
+            fireAndForget(() => model.load(src));
+        }
+    }, [src]);
+
+    if (!src) {
+        return <div className="player-view player-message">No recording to play (set player:src)</div>;
+    }
+    return (
+        <div className="player-view">
+            {error && <div className="player-error">{error}</div>}
+            <PlayerTerminal model={model} />
+            <PlayerControls model={model} />
+        </div>
+    );
+}
+
+export { PlayerViewModel };
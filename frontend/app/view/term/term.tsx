@@ -21,12 +21,13 @@ import {
     getSettingsKeyAtom,
     getSettingsPrefixAtom,
     globalStore,
+    pushFlashError,
     useBlockAtom,
     WOS,
 } from "@/store/global";
 import * as services from "@/store/services";
 import * as keyutil from "@/util/keyutil";
-import { boundNumber, fireAndForget, stringToBase64, useAtomValueSafe } from "@/util/util";
+import { base64ToString, boundNumber, fireAndForget, stringToBase64, useAtomValueSafe } from "@/util/util";
 import { computeBgStyleFromMeta } from "@/util/waveutil";
 import { ISearchOptions } from "@xterm/addon-search";
 import clsx from "clsx";
@@ -35,7 +36,7 @@ import * as jotai from "jotai";
 import * as React from "react";
 import { TermStickers } from "./termsticker";
 import { TermThemeUpdater } from "./termtheme";
-import { computeTheme, DefaultTermTheme } from "./termutil";
+import { computeTheme, DefaultTermTheme, stripTermEscapes } from "./termutil";
 import { TermWrap } from "./termwrap";
 import "./xterm.css";
 
@@ -46,6 +47,14 @@ type InitialLoadDataType = {
     heldData: Uint8Array[];
 };
 
+// a font number in its range, undefined if it isn't one (so the next layer is used)
+function checkFontNumber(val: number, min: number, max: number): number {
+    if (typeof val != "number" || isNaN(val) || val < min || val > max) {
+        return undefined;
+    }
+    return val;
+}
+
 class TermViewModel implements ViewModel {
     viewType: string;
     nodeModel: BlockNodeModel;
@@ -65,16 +74,19 @@ class TermViewModel implements ViewModel {
     vdomBlockId: jotai.Atom<string>;
     vdomToolbarBlockId: jotai.Atom<string>;
     vdomToolbarTarget: jotai.PrimitiveAtom<VDomTargetToolbar>;
+    termFontAtom: jotai.Atom<TermFontOpts>;
     fontSizeAtom: jotai.Atom<number>;
     termThemeNameAtom: jotai.Atom<string>;
     termTransparencyAtom: jotai.Atom<number>;
     noPadding: jotai.PrimitiveAtom<boolean>;
     endIconButtons: jotai.Atom<IconButtonDecl[]>;
     shellProcFullStatus: jotai.PrimitiveAtom<BlockControllerRuntimeStatus>;
+    activePaneFileAtom: jotai.Atom<string>;
     shellProcStatus: jotai.Atom<string>;
     shellProcStatusUnsubFn: () => void;
     isCmdController: jotai.Atom<boolean>;
     isRestarting: jotai.PrimitiveAtom<boolean>;
+    lastInputErrorTs: number = 0;
     searchAtoms?: SearchAtoms;
 
     constructor(blockId: string, nodeModel: BlockNodeModel) {
@@ -150,7 +162,10 @@ class TermViewModel implements ViewModel {
                 const blockMeta = get(this.blockAtom)?.meta;
                 let cmdText = blockMeta?.["cmd"];
                 let cmdArgs = blockMeta?.["cmd:args"];
-                if (cmdArgs != null && Array.isArray(cmdArgs) && cmdArgs.length > 0) {
+                const cmdArgv = blockMeta?.["cmd:argv"];
+                if (cmdArgv != null && Array.isArray(cmdArgv) && cmdArgv.length > 0) {
+                    cmdText = cmdArgv.join(" ");
+                } else if (cmdArgs != null && Array.isArray(cmdArgs) && cmdArgs.length > 0) {
                     cmdText += " " + cmdArgs.join(" ");
                 }
                 rtn.push({
@@ -191,6 +206,34 @@ class TermViewModel implements ViewModel {
                     }
                 }
             }
+            const activePane = get(this.shellProcFullStatus)?.activepane;
+            if (activePane) {
+                rtn.push({
+                    elemtype: "textbutton",
+                    text: "Pane " + activePane,
+                    className: "grey",
+                    title: "Showing a pane (click to go back to the block's shell)",
+                    onClick: () => this.switchPane(""),
+                });
+            }
+            if (get(this.shellProcFullStatus)?.secureinput) {
+                rtn.push({
+                    elemtype: "iconbutton",
+                    icon: "lock",
+                    iconColor: "var(--warning-color)",
+                    title: "Password prompt (input is hidden and is not sent to other terminals)",
+                    noAction: true,
+                });
+            }
+            if (get(this.shellProcFullStatus)?.recording) {
+                rtn.push({
+                    elemtype: "iconbutton",
+                    icon: "circle",
+                    iconColor: "var(--error-color)",
+                    title: "Recording (click to stop)",
+                    click: () => this.setRecording(false),
+                });
+            }
             const isMI = get(atoms.isTermMultiInput);
             if (isMI && this.isBasicTerm(get)) {
                 rtn.push({
@@ -244,21 +287,46 @@ class TermViewModel implements ViewModel {
             const connAtom = getConnStatusAtom(connName);
             return get(connAtom);
         });
-        this.fontSizeAtom = useBlockAtom(blockId, "fontsizeatom", () => {
-            return jotai.atom<number>((get) => {
+        // the font set for the block (runtimeopts), then its meta, its connection and the settings (the same
+        // layers as wsettings.ResolveTermFont, the values set with wave are checked by the backend)
+        this.termFontAtom = useBlockAtom(blockId, "termfontatom", () => {
+            return jotai.atom<TermFontOpts>((get) => {
                 const blockData = get(this.blockAtom);
-                const fsSettingsAtom = getSettingsKeyAtom("term:fontsize");
-                const settingsFontSize = get(fsSettingsAtom);
+                const blockFont = blockData?.runtimeopts?.termfont;
                 const connName = blockData?.meta?.connection;
-                const fullConfig = get(atoms.fullConfigAtom);
-                const connFontSize = fullConfig?.connections?.[connName]?.["term:fontsize"];
-                const rtnFontSize = blockData?.meta?.["term:fontsize"] ?? connFontSize ?? settingsFontSize ?? 12;
-                if (typeof rtnFontSize != "number" || isNaN(rtnFontSize) || rtnFontSize < 4 || rtnFontSize > 64) {
-                    return 12;
-                }
-                return rtnFontSize;
+                const connConfig = get(atoms.fullConfigAtom)?.connections?.[connName];
+                const settingsFont: TermFontOpts = {
+                    fontfamily: get(getSettingsKeyAtom("term:fontfamily")),
+                    fontsize: get(getSettingsKeyAtom("term:fontsize")),
+                    lineheight: get(getSettingsKeyAtom("term:lineheight")),
+                    ligatures: get(getSettingsKeyAtom("term:ligatures")),
+                };
+                const fontSize =
+                    blockFont?.fontsize ?? blockData?.meta?.["term:fontsize"] ?? connConfig?.["term:fontsize"];
+                const lineHeight =
+                    blockFont?.lineheight ?? blockData?.meta?.["term:lineheight"] ?? connConfig?.["term:lineheight"];
+                return {
+                    fontfamily:
+                        blockFont?.fontfamily ??
+                        blockData?.meta?.["term:fontfamily"] ??
+                        connConfig?.["term:fontfamily"] ??
+                        settingsFont.fontfamily ??
+                        "Hack",
+                    fontsize: checkFontNumber(fontSize, 4, 64) ?? checkFontNumber(settingsFont.fontsize, 4, 64) ?? 12,
+                    lineheight:
+                        checkFontNumber(lineHeight, 0.5, 3) ?? checkFontNumber(settingsFont.lineheight, 0.5, 3) ?? 1,
+                    ligatures:
+                        blockFont?.ligatures ??
+                        blockData?.meta?.["term:ligatures"] ??
+                        connConfig?.["term:ligatures"] ??
+                        settingsFont.ligatures ??
+                        false,
+                };
             });
         });
+        this.fontSizeAtom = useBlockAtom(blockId, "fontsizeatom", () => {
+            return jotai.atom<number>((get) => get(this.termFontAtom).fontsize);
+        });
         this.noPadding = jotai.atom(true);
         this.endIconButtons = jotai.atom((get) => {
             const blockData = get(this.blockAtom);
@@ -301,6 +369,10 @@ class TermViewModel implements ViewModel {
             return get(controllerMetaAtom) == "cmd";
         });
         this.shellProcFullStatus = jotai.atom(null) as jotai.PrimitiveAtom<BlockControllerRuntimeStatus>;
+        this.activePaneFileAtom = jotai.atom((get) => {
+            const fullStatus = get(this.shellProcFullStatus);
+            return fullStatus?.panes?.find((pane) => pane.active)?.filename;
+        });
         const initialShellProcStatus = services.BlockService.GetControllerStatus(blockId);
         initialShellProcStatus.then((rts) => {
             this.updateShellProcStatus(rts);
@@ -337,6 +409,10 @@ class TermViewModel implements ViewModel {
     }
 
     multiInputHandler(data: string) {
+        // a password typed into this block should not end up in the other terminals
+        if (globalStore.get(this.shellProcFullStatus)?.secureinput) {
+            return;
+        }
         let tvms = getAllBasicTermModels();
         // filter out "this" from the list
         tvms = tvms.filter((tvm) => tvm != this);
@@ -350,7 +426,20 @@ class TermViewModel implements ViewModel {
 
     sendDataToController(data: string) {
         const b64data = stringToBase64(data);
-        RpcApi.ControllerInputCommand(TabRpcClient, { blockid: this.blockId, inputdata64: b64data });
+        RpcApi.ControllerInputCommand(TabRpcClient, { blockid: this.blockId, inputdata64: b64data }).catch((e) => {
+            // e.g. the block is being typed in from another window, only shown once per keystroke burst
+            const now = Date.now();
+            if (now - this.lastInputErrorTs > 5000) {
+                pushFlashError({
+                    id: null,
+                    icon: "keyboard",
+                    title: "Input Not Sent",
+                    message: String(e?.message ?? e),
+                    expiration: null,
+                });
+            }
+            this.lastInputErrorTs = now;
+        });
     }
 
     setTermMode(mode: "term" | "vdom") {
@@ -460,7 +549,7 @@ class TermViewModel implements ViewModel {
         if (keyutil.checkKeyPressed(waveEvent, "Ctrl:Shift:v")) {
             const p = navigator.clipboard.readText();
             p.then((text) => {
-                this.termRef.current?.terminal.paste(text);
+                this.pasteText(text);
             });
             event.preventDefault();
             event.stopPropagation();
@@ -476,6 +565,11 @@ class TermViewModel implements ViewModel {
             event.stopPropagation();
             this.termRef.current?.terminal?.clear();
             return false;
+        } else if (keyutil.checkKeyPressed(waveEvent, "Ctrl:Shift:m")) {
+            event.preventDefault();
+            event.stopPropagation();
+            this.toggleMouseReporting();
+            return false;
         }
         const shellProcStatus = globalStore.get(this.shellProcStatus);
         if ((shellProcStatus == "done" || shellProcStatus == "init") && keyutil.checkKeyPressed(waveEvent, "Enter")) {
@@ -516,6 +610,93 @@ class TermViewModel implements ViewModel {
         prtn.catch((e) => console.log("error controller resync (force restart)", e));
     }
 
+    // the controller checks the paste, it is only written after confirmation if it looks unsafe
+    async pasteText(text: string, confirm?: boolean) {
+        if (!text) {
+            return;
+        }
+        try {
+            const rtn = await RpcApi.ControllerPasteCommand(TabRpcClient, {
+                blockid: this.blockId,
+                text: text,
+                confirm: confirm,
+            });
+            if (rtn?.needsconfirm && !confirm) {
+                const warnings = (rtn.warnings ?? []).map((w) => "- " + w).join("\n");
+                if (window.confirm("This paste may not be safe:\n\n" + warnings + "\n\nPaste anyway?")) {
+                    await this.pasteText(text, true);
+                }
+            }
+        } catch (e) {
+            console.log("error pasting", e);
+        }
+    }
+
+    sendSignal(signal: string) {
+        const prtn = RpcApi.ControllerSignalCommand(TabRpcClient, { blockid: this.blockId, signal: signal });
+        prtn.catch((e) => console.log("error sending signal", signal, e));
+    }
+
+    setRecording(recording: boolean) {
+        const data: CommandTermRecordData = { blockid: this.blockId };
+        const prtn = recording
+            ? RpcApi.TermRecordStartCommand(TabRpcClient, data)
+            : RpcApi.TermRecordStopCommand(TabRpcClient, data);
+        prtn.catch((e) => console.log("error setting recording", recording, e));
+    }
+
+    createPane() {
+        const prtn = RpcApi.TermPaneCreateCommand(TabRpcClient, { blockid: this.blockId, switch: true });
+        prtn.catch((e) => console.log("error creating pane", e));
+    }
+
+    // an empty paneId switches back to the block's shell
+    switchPane(paneId: string) {
+        const prtn = RpcApi.TermPaneSwitchCommand(TabRpcClient, { blockid: this.blockId, paneid: paneId });
+        prtn.catch((e) => console.log("error switching pane", paneId, e));
+    }
+
+    killPane(paneId: string) {
+        const prtn = RpcApi.TermPaneKillCommand(TabRpcClient, { blockid: this.blockId, paneid: paneId });
+        prtn.catch((e) => console.log("error killing pane", paneId, e));
+    }
+
+    // when mouse reporting is off the mouse selects text even if the application asked for mouse events
+    toggleMouseReporting() {
+        const enabled = globalStore.get(getOverrideConfigAtom(this.blockId, "term:mousereporting")) ?? true;
+        RpcApi.SetMetaCommand(TabRpcClient, {
+            oref: WOS.makeORef("block", this.blockId),
+            meta: { "term:mousereporting": !enabled },
+        });
+    }
+
+    // the output of the last command (from shell integration) as plain text
+    async copyLastCommandOutput() {
+        try {
+            const output = await RpcApi.TermGetSegmentOutputCommand(TabRpcClient, {
+                blockid: this.blockId,
+                segidx: -1,
+            });
+            await navigator.clipboard.writeText(stripTermEscapes(base64ToString(output.data64)));
+        } catch (e) {
+            console.log("error copying last command output", e);
+        }
+    }
+
+    // sets the font size of the block (its runtimeopts, null for the default), a term:fontsize in its meta (set
+    // before the font was kept in the runtimeopts) is removed so it doesn't come back
+    async setBlockFontSize(fontSize: number) {
+        const blockData = globalStore.get(this.blockAtom);
+        const font: TermFontOpts = { ...(blockData?.runtimeopts?.termfont ?? {}), fontsize: fontSize ?? undefined };
+        await RpcApi.TermFontSetCommand(TabRpcClient, { blockid: this.blockId, font: font });
+        if (blockData?.meta?.["term:fontsize"] != null) {
+            await RpcApi.SetMetaCommand(TabRpcClient, {
+                oref: WOS.makeORef("block", this.blockId),
+                meta: { "term:fontsize": null },
+            });
+        }
+    }
+
     getSettingsMenuItems(): ContextMenuItem[] {
         const fullConfig = globalStore.get(atoms.fullConfigAtom);
         const termThemes = fullConfig?.termthemes ?? {};
@@ -524,7 +705,8 @@ class TermViewModel implements ViewModel {
         const defaultFontSize = globalStore.get(getSettingsKeyAtom("term:fontsize")) ?? 12;
         const transparencyMeta = globalStore.get(getBlockMetaKeyAtom(this.blockId, "term:transparency"));
         const blockData = globalStore.get(this.blockAtom);
-        const overrideFontSize = blockData?.meta?.["term:fontsize"];
+        const blockFont = blockData?.runtimeopts?.termfont;
+        const overrideFontSize = blockFont?.fontsize ?? blockData?.meta?.["term:fontsize"];
 
         termThemeKeys.sort((a, b) => {
             return (termThemes[a]["display:order"] ?? 0) - (termThemes[b]["display:order"] ?? 0);
@@ -585,12 +767,7 @@ class TermViewModel implements ViewModel {
                     label: fontSize.toString() + "px",
                     type: "checkbox",
                     checked: overrideFontSize == fontSize,
-                    click: () => {
-                        RpcApi.SetMetaCommand(TabRpcClient, {
-                            oref: WOS.makeORef("block", this.blockId),
-                            meta: { "term:fontsize": fontSize },
-                        });
-                    },
+                    click: () => fireAndForget(() => this.setBlockFontSize(fontSize)),
                 };
             }
         );
@@ -598,12 +775,7 @@ class TermViewModel implements ViewModel {
             label: "Default (" + defaultFontSize + "px)",
             type: "checkbox",
             checked: overrideFontSize == null,
-            click: () => {
-                RpcApi.SetMetaCommand(TabRpcClient, {
-                    oref: WOS.makeORef("block", this.blockId),
-                    meta: { "term:fontsize": null },
-                });
-            },
+            click: () => fireAndForget(() => this.setBlockFontSize(null)),
         });
         fullMenu.push({
             label: "Themes",
@@ -618,10 +790,63 @@ class TermViewModel implements ViewModel {
             submenu: transparencySubMenu,
         });
         fullMenu.push({ type: "separator" });
+        fullMenu.push({
+            label: "Copy Last Command Output",
+            click: () => fireAndForget(() => this.copyLastCommandOutput()),
+        });
+        const fullStatus = globalStore.get(this.shellProcFullStatus);
+        const paneSubMenu: ContextMenuItem[] = [
+            { label: "New Shell Pane", click: () => this.createPane() },
+            { type: "separator" },
+            {
+                label: "Block Shell",
+                type: "checkbox",
+                checked: !fullStatus?.activepane,
+                click: () => this.switchPane(""),
+            },
+        ];
+        for (const pane of fullStatus?.panes ?? []) {
+            paneSubMenu.push({
+                label: `Pane ${pane.paneid}: ${pane.cmd || "shell"}`,
+                type: "checkbox",
+                checked: pane.active,
+                click: () => this.switchPane(pane.paneid),
+            });
+        }
+        if (fullStatus?.activepane) {
+            paneSubMenu.push({ type: "separator" });
+            paneSubMenu.push({
+                label: "Kill Pane " + fullStatus.activepane,
+                click: () => this.killPane(fullStatus.activepane),
+            });
+        }
+        fullMenu.push({
+            label: "Panes",
+            submenu: paneSubMenu,
+        });
+        fullMenu.push({
+            label: "Mouse Reporting",
+            type: "checkbox",
+            checked: globalStore.get(getOverrideConfigAtom(this.blockId, "term:mousereporting")) ?? true,
+            click: () => this.toggleMouseReporting(),
+        });
         fullMenu.push({
             label: "Force Restart Controller",
             click: this.forceRestartController.bind(this),
         });
+        fullMenu.push({
+            label: "Send Signal",
+            submenu: [
+                { label: "Interrupt (SIGINT)", click: () => this.sendSignal("SIGINT") },
+                { label: "Terminate (SIGTERM)", click: () => this.sendSignal("SIGTERM") },
+                { label: "Force Kill (SIGKILL)", click: () => this.sendSignal("SIGKILL") },
+            ],
+        });
+        const isRecording = globalStore.get(this.shellProcFullStatus)?.recording;
+        fullMenu.push({
+            label: isRecording ? "Stop Recording" : "Start Recording",
+            click: () => this.setRecording(!isRecording),
+        });
         const isClearOnStart = blockData?.meta?.["cmd:clearonstart"];
         fullMenu.push({
             label: "Clear Output On Restart",
@@ -890,11 +1115,10 @@ const TerminalView = ({ blockId, model }: ViewComponentProps<TermViewModel>) =>
     }
     const termModeRef = React.useRef(termMode);
 
-    const termFontSize = jotai.useAtomValue(model.fontSizeAtom);
-    const fullConfig = globalStore.get(atoms.fullConfigAtom);
-    const connFontFamily = fullConfig.connections?.[blockData?.meta?.connection]?.["term:fontfamily"];
+    const termFont = jotai.useAtomValue(model.termFontAtom);
     const isFocused = jotai.useAtomValue(model.nodeModel.isFocused);
     const isMI = jotai.useAtomValue(atoms.isTermMultiInput);
+    const paneFileName = jotai.useAtomValue(model.activePaneFileAtom);
     const isBasicTerm = termMode != "vdom" && blockData?.meta?.controller != "cmd"; // needs to match isBasicTerm
 
     // search
@@ -993,8 +1217,9 @@ const TerminalView = ({ blockId, model }: ViewComponentProps<TermViewModel>) =>
             connectElemRef.current,
             {
                 theme: termTheme,
-                fontSize: termFontSize,
-                fontFamily: termSettings?.["term:fontfamily"] ?? connFontFamily ?? "Hack",
+                fontSize: termFont.fontsize,
+                fontFamily: termFont.fontfamily,
+                lineHeight: termFont.lineheight,
                 drawBoldTextInBrightColors: false,
                 fontWeight: "normal",
                 fontWeightBold: "bold",
@@ -1005,14 +1230,17 @@ const TerminalView = ({ blockId, model }: ViewComponentProps<TermViewModel>) =>
             },
             {
                 keydownHandler: model.handleTerminalKeydown.bind(model),
-                useWebGl: !termSettings?.["term:disablewebgl"],
+                // the webgl renderer draws each cell on its own, the dom renderer can join ligatures
+                useWebGl: !termSettings?.["term:disablewebgl"] && !termFont.ligatures,
                 sendDataHandler: model.sendDataToController.bind(model),
+                pasteHandler: (text: string) => fireAndForget(() => model.pasteText(text)),
+                paneFileName: paneFileName,
             }
         );
         (window as any).term = termWrap;
         model.termRef.current = termWrap;
         const rszObs = new ResizeObserver(() => {
-            termWrap.handleResize_debounced();
+            termWrap.handleResize_throttled();
         });
         rszObs.observe(connectElemRef.current);
         termWrap.onSearchResultsDidChange = (results) => {
@@ -1029,7 +1257,15 @@ const TerminalView = ({ blockId, model }: ViewComponentProps<TermViewModel>) =>
             termWrap.dispose();
             rszObs.disconnect();
         };
-    }, [blockId, termSettings, termFontSize, connFontFamily]);
+    }, [
+        blockId,
+        termSettings,
+        termFont.fontfamily,
+        termFont.fontsize,
+        termFont.lineheight,
+        termFont.ligatures,
+        paneFileName,
+    ]);
 
     React.useEffect(() => {
         if (termModeRef.current == "vdom" && termMode == "term") {
@@ -1074,7 +1310,10 @@ const TerminalView = ({ blockId, model }: ViewComponentProps<TermViewModel>) =>
     const termBg = computeBgStyleFromMeta(blockData?.meta);
 
     return (
-        <div className={clsx("view-term", "term-mode-" + termMode)} ref={viewRef}>
+        <div
+            className={clsx("view-term", "term-mode-" + termMode, { "term-ligatures": termFont.ligatures })}
+            ref={viewRef}
+        >
             {termBg && <div className="absolute inset-0 z-0 pointer-events-none" style={termBg} />}
             <TermResyncHandler blockId={blockId} model={model} />
             <TermThemeUpdater blockId={blockId} model={model} termRef={model.termRef} />
@@ -33,4 +33,16 @@ function computeTheme(
     return [themeCopy, bgcolor];
 }
 
-export { computeTheme };
+// removes the escape sequences (colors, OSC sequences, cursor movement, etc.) from terminal output so it can be
+// copied as plain text
+function stripTermEscapes(output: string): string {
+    const text = output
+        .replace(/\x1b\][^\x07\x1b]*(\x07|\x1b\\)/g, "")
+        .replace(/\x1b\[[0-?]*[ -/]*[@-~]/g, "")
+        .replace(/\x1b[PX^_][^\x1b]*\x1b\\/g, "")
+        .replace(/\x1b[ -/]*[0-~]/g, "")
+        .replace(/\r+\n/g, "\n");
+    return text.replace(/[\x00-\x08\x0b-\x1f\x7f]/g, "");
+}
+
+export { computeTheme, stripTermEscapes };
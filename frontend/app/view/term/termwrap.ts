@@ -1,11 +1,21 @@
 // Copyright 2025, Command Line Inc.
 // SPDX-License-Identifier: Apache-2.0
 
-import { getFileSubject } from "@/app/store/wps";
+import { getFileSubject, waveEventSubscribe } from "@/app/store/wps";
 import { sendWSCommand } from "@/app/store/ws";
 import { RpcApi } from "@/app/store/wshclientapi";
 import { TabRpcClient } from "@/app/store/wshrpcutil";
-import { WOS, atoms, fetchWaveFile, getSettingsKeyAtom, globalStore, openLink } from "@/store/global";
+import {
+    WOS,
+    atoms,
+    createBlock,
+    fetchWaveFile,
+    getApi,
+    getOverrideConfigAtom,
+    getSettingsKeyAtom,
+    globalStore,
+    openLink,
+} from "@/store/global";
 import * as services from "@/store/services";
 import { PLATFORM, PlatformMacOS } from "@/util/platformutil";
 import { base64ToArray, fireAndForget } from "@/util/util";
@@ -16,7 +26,7 @@ import { WebglAddon } from "@xterm/addon-webgl";
 import * as TermTypes from "@xterm/xterm";
 import { Terminal } from "@xterm/xterm";
 import debug from "debug";
-import { debounce } from "throttle-debounce";
+import { debounce, throttle } from "throttle-debounce";
 import { FitAddon } from "./fitaddon";
 
 const dlog = debug("wave:termwrap");
@@ -24,6 +34,8 @@ const dlog = debug("wave:termwrap");
 const TermFileName = "term";
 const TermCacheFileName = "cache:term:full";
 const MinDataProcessedForCache = 100 * 1024;
+// the DECSET modes that turn on mouse reporting (X10, normal, button event, any event)
+const MouseTrackingModes = [9, 1000, 1002, 1003];
 
 // detect webgl support
 function detectWebGLSupport(): boolean {
@@ -39,12 +51,60 @@ function detectWebGLSupport(): boolean {
 const WebGLSupported = detectWebGLSupport();
 let loggedWebGL = false;
 
+// a command run with shell integration (found from the OSC 133 markers as the output is written).  the offsets are
+// the term file range of the write that had the D marker, used to match the command with the controller's segment
+type TermCommandMarker = {
+    startOffset: number;
+    endOffset: number;
+    promptMarker: TermTypes.IMarker;
+    endMarker: TermTypes.IMarker;
+    segment?: TermSegment;
+    decoration?: TermTypes.IDecoration;
+};
+
 type TermWrapOptions = {
     keydownHandler?: (e: KeyboardEvent) => boolean;
     useWebGl?: boolean;
     sendDataHandler?: (data: string) => void;
+    pasteHandler?: (text: string) => void;
+    paneFileName?: string; // shows one of the block's panes (term:pane:[paneid]) instead of its term file
 };
 
+// links are opened with cmd (macos) or ctrl + click
+function isLinkClick(e: MouseEvent): boolean {
+    return PLATFORM === PlatformMacOS ? e.metaKey : e.ctrlKey;
+}
+
+// matches getUriTarget in pkg/blockcontroller/termlinks.go
+function getUriLinkTarget(uri: string): string {
+    const scheme = uri.split(":")[0].toLowerCase();
+    if (scheme == "http" || scheme == "https" || scheme == "ftp") {
+        return "web";
+    }
+    if (scheme == "file") {
+        return "editor";
+    }
+    return "external";
+}
+
+// opens a link found by the controller (see the term:links event) in a web block, an editor block, or an external app
+export function openTermLink(link: TermLink) {
+    if (link.target == "editor") {
+        const blockDef: BlockDef = {
+            meta: {
+                view: "preview",
+                file: link.path,
+                connection: link.conn,
+            },
+        };
+        fireAndForget(() => createBlock(blockDef));
+    } else if (link.target == "web") {
+        fireAndForget(() => openLink(link.uri));
+    } else {
+        getApi().openExternal(link.uri);
+    }
+}
+
 function handleOscWaveCommand(data: string, blockId: string, loaded: boolean): boolean {
     if (!loaded) {
         return false;
@@ -107,31 +167,33 @@ function handleOscWaveCommand(data: string, blockId: string, loaded: boolean): b
     return true;
 }
 
-function handleOsc7Command(data: string, blockId: string, loaded: boolean): boolean {
-    if (!loaded) {
-        return false;
+function formatCommandDuration(durationMs: number): string {
+    if (durationMs < 1000) {
+        return `${durationMs}ms`;
     }
-    if (data == null || data.length == 0) {
-        console.log("Invalid OSC 7 command received (empty)");
-        return false;
+    if (durationMs < 60000) {
+        return `${(durationMs / 1000).toFixed(1)}s`;
     }
-    if (data.startsWith("file://")) {
-        data = data.substring(7);
-        const nextSlashIdx = data.indexOf("/");
-        if (nextSlashIdx == -1) {
-            console.log("Invalid OSC 7 command received (bad path)", data);
-            return false;
-        }
-        data = data.substring(nextSlashIdx);
+    const totalSecs = Math.floor(durationMs / 1000);
+    return `${Math.floor(totalSecs / 60)}m ${totalSecs % 60}s`;
+}
+
+function getCommandTitle(seg: TermSegment): string {
+    const lines: string[] = [];
+    if (seg.command) {
+        lines.push(seg.command);
     }
-    setTimeout(() => {
-        fireAndForget(() =>
-            services.ObjectService.UpdateObjectMeta(WOS.makeORef("block", blockId), {
-                "cmd:cwd": data,
-            })
-        );
-    }, 0);
-    return true;
+    lines.push(seg.exitcode != null ? `exit code ${seg.exitcode}` : "no exit code");
+    if (seg.durationms != null) {
+        lines.push(`ran for ${formatCommandDuration(seg.durationms)}`);
+    }
+    if (seg.startts) {
+        lines.push(`started ${new Date(seg.startts).toLocaleString()}`);
+    }
+    if (seg.cwd) {
+        lines.push(seg.cwd);
+    }
+    return lines.join("\n");
 }
 
 export class TermWrap {
@@ -145,14 +207,26 @@ export class TermWrap {
     serializeAddon: SerializeAddon;
     mainFileSubject: SubjectWithRef<WSFileEventData>;
     loaded: boolean;
-    heldData: Uint8Array[];
-    handleResize_debounced: () => void;
+    catchingUp: boolean = false;
+    heldData: { offset: number; data: Uint8Array }[];
+    handleResize_throttled: () => void;
+    sendOutputAck_throttled: () => void;
     hasResized: boolean;
     multiInputCallback: (data: string) => void;
     sendDataHandler: (data: string) => void;
+    pasteHandler: (text: string) => void;
     onSearchResultsDidChange?: (result: { resultIndex: number; resultCount: number }) => void;
     private toDispose: TermTypes.IDisposable[] = [];
     pasteActive: boolean = false;
+    pendingWrites: { startOffset: number; endOffset: number }[] = [];
+    curCommand: { promptMarker: TermTypes.IMarker; hasOutput: boolean } = null;
+    commandMarkers: TermCommandMarker[] = [];
+    commandSegments: TermSegment[] = [];
+    termFileName: string;
+    isPane: boolean;
+    appMouseModes: Set<number> = new Set();
+    mouseReportingOff: boolean = false;
+    mouseModeSyncing: boolean = false;
 
     constructor(
         blockId: string,
@@ -163,9 +237,36 @@ export class TermWrap {
         this.loaded = false;
         this.blockId = blockId;
         this.sendDataHandler = waveOptions.sendDataHandler;
+        this.pasteHandler = waveOptions.pasteHandler;
+        this.isPane = waveOptions.paneFileName != null;
+        this.termFileName = waveOptions.paneFileName ?? TermFileName;
         this.ptyOffset = 0;
         this.dataBytesProcessed = 0;
         this.hasResized = false;
+        // OSC 8 hyperlinks
+        options.linkHandler = {
+            allowNonHttpProtocols: true,
+            activate: (e: MouseEvent, uri: string) => {
+                if (!isLinkClick(e)) {
+                    return;
+                }
+                const blockData = globalStore.get(WOS.getWaveObjectAtom<Block>(WOS.makeORef("block", this.blockId)));
+                let path: string = null;
+                if (getUriLinkTarget(uri) == "editor") {
+                    path = decodeURIComponent(new URL(uri).pathname);
+                }
+                openTermLink({
+                    offset: 0,
+                    endoffset: 0,
+                    kind: "hyperlink",
+                    target: getUriLinkTarget(uri),
+                    text: uri,
+                    uri: uri,
+                    path: path,
+                    conn: blockData?.meta?.connection,
+                });
+            },
+        };
         this.terminal = new Terminal(options);
         this.fitAddon = new FitAddon();
         this.fitAddon.noScrollbar = PLATFORM === PlatformMacOS;
@@ -177,17 +278,8 @@ export class TermWrap {
         this.terminal.loadAddon(
             new WebLinksAddon((e, uri) => {
                 e.preventDefault();
-                switch (PLATFORM) {
-                    case PlatformMacOS:
-                        if (e.metaKey) {
-                            fireAndForget(() => openLink(uri));
-                        }
-                        break;
-                    default:
-                        if (e.ctrlKey) {
-                            fireAndForget(() => openLink(uri));
-                        }
-                        break;
+                if (isLinkClick(e)) {
+                    fireAndForget(() => openLink(uri));
                 }
             })
         );
@@ -208,17 +300,56 @@ export class TermWrap {
         this.terminal.parser.registerOscHandler(9283, (data: string) => {
             return handleOscWaveCommand(data, this.blockId, this.loaded);
         });
-        this.terminal.parser.registerOscHandler(7, (data: string) => {
-            return handleOsc7Command(data, this.blockId, this.loaded);
+        // OSC 133 (shell integration) markers, for the command block decorations
+        this.terminal.parser.registerOscHandler(133, (data: string) => {
+            this.handleOscFinalTerm(data);
+            return false;
+        });
+        // mouse reporting can be turned off for the block (see setMouseReporting)
+        const mouseReportingAtom = getOverrideConfigAtom(this.blockId, "term:mousereporting");
+        this.mouseReportingOff = globalStore.get(mouseReportingAtom) == false;
+        this.toDispose.push({
+            dispose: globalStore.sub(mouseReportingAtom, () => {
+                this.setMouseReporting(globalStore.get(mouseReportingAtom) ?? true);
+            }),
         });
+        this.toDispose.push(
+            this.terminal.parser.registerCsiHandler({ prefix: "?", final: "h" }, (params) =>
+                this.handleMouseModeSet(params, true)
+            )
+        );
+        this.toDispose.push(
+            this.terminal.parser.registerCsiHandler({ prefix: "?", final: "l" }, (params) =>
+                this.handleMouseModeSet(params, false)
+            )
+        );
+        this.toDispose.push(
+            this.terminal.parser.registerEscHandler({ final: "c" }, () => {
+                // a full reset turns mouse reporting off
+                this.appMouseModes.clear();
+                return false;
+            })
+        );
         this.terminal.attachCustomKeyEventHandler(waveOptions.keydownHandler);
         this.connectElem = connectElem;
         this.mainFileSubject = null;
         this.heldData = [];
-        this.handleResize_debounced = debounce(50, this.handleResize.bind(this));
+        this.handleResize_throttled = throttle(50, this.handleResize.bind(this));
+        this.sendOutputAck_throttled = throttle(100, this.sendOutputAck.bind(this));
         this.terminal.open(this.connectElem);
         this.handleResize();
-        let pasteEventHandler = () => {
+        let pasteEventHandler = (e: ClipboardEvent) => {
+            if (this.pasteHandler != null) {
+                // pastes are checked by the controller (which also does the bracketed paste)
+                e.preventDefault();
+                e.stopPropagation();
+                const text = e.clipboardData?.getData("text/plain") ?? "";
+                if (this.loaded && text != "") {
+                    this.pasteHandler(text);
+                    this.multiInputCallback?.(text);
+                }
+                return;
+            }
             this.pasteActive = true;
             setTimeout(() => {
                 this.pasteActive = false;
@@ -253,14 +384,134 @@ export class TermWrap {
         if (this.onSearchResultsDidChange != null) {
             this.toDispose.push(this.searchAddon.onDidChangeResults(this.onSearchResultsDidChange.bind(this)));
         }
-        this.mainFileSubject = getFileSubject(this.blockId, TermFileName);
+        this.mainFileSubject = getFileSubject(this.blockId, this.termFileName);
         this.mainFileSubject.subscribe(this.handleNewFileSubjectData.bind(this));
         try {
             await this.loadInitialTerminalData();
         } finally {
             this.loaded = true;
         }
+        await this.writeHeldData();
+        if (this.isPane) {
+            // panes are not cached and do not have command segments
+            return;
+        }
         this.runProcessIdleTimeout();
+        this.toDispose.push({
+            // save the terminal state while the server can still write it
+            dispose: waveEventSubscribe({
+                eventType: "server:shutdown",
+                handler: () => {
+                    this.processAndCacheData();
+                },
+            }),
+        });
+        await this.loadCommandSegments();
+    }
+
+    async loadCommandSegments() {
+        this.toDispose.push({
+            dispose: waveEventSubscribe({
+                eventType: "term:segment",
+                scope: WOS.makeORef("block", this.blockId),
+                handler: (event) => {
+                    this.commandSegments.push(event.data as TermSegment);
+                    this.updateCommandDecorations();
+                },
+            }),
+        });
+        try {
+            const segments = await RpcApi.TermGetSegmentsCommand(TabRpcClient, { blockid: this.blockId });
+            const lastSegIdx = segments?.[segments.length - 1]?.segidx ?? -1;
+            // keep the segments that finished while we were loading
+            this.commandSegments = [...(segments ?? []), ...this.commandSegments.filter((s) => s.segidx > lastSegIdx)];
+        } catch (e) {
+            console.log("error loading term segments", this.blockId, e);
+        }
+        this.updateCommandDecorations();
+    }
+
+    // data is the OSC 133 payload ("A", "B", "C;...", or "D;exitcode")
+    handleOscFinalTerm(data: string) {
+        const marker = data.split(";")[0];
+        if (marker == "A") {
+            if (this.curCommand?.hasOutput) {
+                // the command never sent D
+                this.finishCommand();
+            }
+            this.curCommand?.promptMarker?.dispose();
+            this.curCommand = { promptMarker: this.terminal.registerMarker(0), hasOutput: false };
+        } else if (marker == "C") {
+            if (this.curCommand == null) {
+                this.curCommand = { promptMarker: this.terminal.registerMarker(0), hasOutput: false };
+            }
+            this.curCommand.hasOutput = true;
+        } else if (marker == "D" && this.curCommand?.hasOutput) {
+            this.finishCommand();
+        }
+    }
+
+    finishCommand() {
+        const write = this.pendingWrites[0];
+        const cmd: TermCommandMarker = {
+            startOffset: write?.startOffset ?? this.ptyOffset,
+            endOffset: write?.endOffset ?? this.ptyOffset,
+            promptMarker: this.curCommand.promptMarker,
+            endMarker: this.terminal.registerMarker(0),
+        };
+        this.curCommand = null;
+        if (cmd.promptMarker == null || cmd.endMarker == null) {
+            return;
+        }
+        this.commandMarkers.push(cmd);
+        this.updateCommandDecorations();
+    }
+
+    // matches the commands with their segments (by where the D marker was in the term file) and decorates them
+    updateCommandDecorations() {
+        const enabled = globalStore.get(getOverrideConfigAtom(this.blockId, "term:commandblocks")) ?? true;
+        this.commandMarkers = this.commandMarkers.filter((cmd) => {
+            const isDisposed = cmd.promptMarker.isDisposed || cmd.endMarker.isDisposed;
+            if (isDisposed) {
+                cmd.decoration?.dispose();
+            }
+            return !isDisposed;
+        });
+        let lastSegIdx = -1;
+        for (const cmd of this.commandMarkers) {
+            if (cmd.segment == null) {
+                cmd.segment = this.commandSegments.find(
+                    (seg) =>
+                        seg.segidx > lastSegIdx && seg.endoffset >= cmd.startOffset && seg.endoffset <= cmd.endOffset
+                );
+            }
+            if (cmd.segment == null) {
+                continue;
+            }
+            lastSegIdx = cmd.segment.segidx;
+            if (enabled && cmd.decoration == null) {
+                cmd.decoration = this.makeCommandDecoration(cmd);
+            }
+        }
+    }
+
+    // a bar in the left margin next to the command (and its output), red if the command failed
+    makeCommandDecoration(cmd: TermCommandMarker): TermTypes.IDecoration {
+        const seg = cmd.segment;
+        const failed = seg.exitcode != null && seg.exitcode != 0;
+        const decoration = this.terminal.registerDecoration({
+            marker: cmd.promptMarker,
+            x: 0,
+            width: 1,
+            height: Math.max(1, cmd.endMarker.line - cmd.promptMarker.line),
+            overviewRulerOptions: failed ? { color: "#e54d2e" } : undefined,
+        });
+        decoration?.onRender((elem) => {
+            elem.classList.add("term-cmd-decoration");
+            elem.classList.toggle("term-cmd-failed", failed);
+            elem.title = getCommandTitle(seg);
+        });
+        return decoration;
     }
 
     dispose() {
@@ -300,12 +551,19 @@ export class TermWrap {
         if (msg.fileop == "truncate") {
             this.terminal.clear();
             this.heldData = [];
+            this.ptyOffset = 0;
+            this.curCommand = null;
         } else if (msg.fileop == "append") {
             const decodedData = base64ToArray(msg.data64);
-            if (this.loaded) {
-                this.doTerminalWrite(decodedData, null);
+            if (this.loaded && !this.catchingUp) {
+                this.writeAppendData(msg.offset, decodedData);
             } else {
-                this.heldData.push(decodedData);
+                this.heldData.push({ offset: msg.offset, data: decodedData });
+            }
+        } else if (msg.fileop == "invalidate") {
+            // output was not pushed (rate limited), read what we missed from the term file
+            if (this.loaded) {
+                fireAndForget(() => this.catchUpFromFile());
             }
         } else {
             console.log("bad fileop for terminal", msg);
@@ -313,18 +571,74 @@ export class TermWrap {
         }
     }
 
+    // offset is the term file offset of the data (if known), used to skip data that was already written
+    writeAppendData(offset: number, data: Uint8Array): Promise<void> {
+        if (offset != null && offset < this.ptyOffset) {
+            const skipBytes = this.ptyOffset - offset;
+            if (skipBytes >= data.byteLength) {
+                return Promise.resolve();
+            }
+            data = data.slice(skipBytes);
+        }
+        return this.doTerminalWrite(data, null);
+    }
+
+    async writeHeldData() {
+        const heldData = this.heldData;
+        this.heldData = [];
+        for (const held of heldData) {
+            await this.writeAppendData(held.offset, held.data);
+        }
+    }
+
+    async catchUpFromFile() {
+        if (this.catchingUp) {
+            return;
+        }
+        this.catchingUp = true;
+        try {
+            const { data, fileInfo } = await fetchWaveFile(this.blockId, this.termFileName, this.ptyOffset);
+            if (fileInfo != null && data != null && data.byteLength > 0) {
+                this.dataBytesProcessed += data.byteLength;
+                await this.doTerminalWrite(data, fileInfo.size);
+            }
+        } finally {
+            this.catchingUp = false;
+        }
+        await this.writeHeldData();
+    }
+
+    // lets the controller know how far behind the terminal is (it pauses reading output if we fall too far behind)
+    sendOutputAck() {
+        if (!this.loaded) {
+            return;
+        }
+        RpcApi.ControllerOutputAckCommand(
+            TabRpcClient,
+            { blockid: this.blockId, offset: this.ptyOffset },
+            { noresponse: true }
+        );
+    }
+
     doTerminalWrite(data: string | Uint8Array, setPtyOffset?: number): Promise<void> {
         let resolve: () => void = null;
         let prtn = new Promise<void>((presolve, _) => {
             resolve = presolve;
         });
+        // the term file range of each write (in order), so the OSC handlers know where they are in the file
+        const lastWrite = this.pendingWrites[this.pendingWrites.length - 1];
+        const startOffset =
+            setPtyOffset != null ? setPtyOffset - data.length : (lastWrite?.endOffset ?? this.ptyOffset);
+        this.pendingWrites.push({ startOffset: startOffset, endOffset: startOffset + data.length });
         this.terminal.write(data, () => {
+            this.pendingWrites.shift();
             if (setPtyOffset != null) {
                 this.ptyOffset = setPtyOffset;
             } else {
                 this.ptyOffset += data.length;
                 this.dataBytesProcessed += data.length;
             }
+            this.sendOutputAck_throttled();
             resolve();
         });
         return prtn;
@@ -332,8 +646,10 @@ export class TermWrap {
 
     async loadInitialTerminalData(): Promise<void> {
         let startTs = Date.now();
-        const { data: cacheData, fileInfo: cacheFile } = await fetchWaveFile(this.blockId, TermCacheFileName);
         let ptyOffset = 0;
+        const { data: cacheData, fileInfo: cacheFile } = this.isPane
+            ? { data: null, fileInfo: null }
+            : await fetchWaveFile(this.blockId, TermCacheFileName);
         if (cacheFile != null) {
             ptyOffset = cacheFile.meta["ptyoffset"] ?? 0;
             if (cacheData.byteLength > 0) {
@@ -354,13 +670,79 @@ export class TermWrap {
                 }
             }
         }
-        const { data: mainData, fileInfo: mainFile } = await fetchWaveFile(this.blockId, TermFileName, ptyOffset);
+        const { data: mainData, fileInfo: mainFile } = await fetchWaveFile(this.blockId, this.termFileName, ptyOffset);
         console.log(
             `terminal loaded cachefile:${cacheData?.byteLength ?? 0} main:${mainData?.byteLength ?? 0} bytes, ${Date.now() - startTs}ms`
         );
         if (mainFile != null) {
-            await this.doTerminalWrite(mainData, null);
+            // only replay the last term:scrollback lines (the controller records where they start)
+            const scrollbackStart: number = mainFile.meta?.["scrollbackstart"] ?? 0;
+            const dataStart = mainFile.size - mainData.byteLength;
+            const skipBytes = scrollbackStart - dataStart;
+            const replayData = skipBytes > 0 && skipBytes < mainData.byteLength ? mainData.slice(skipBytes) : mainData;
+            await this.restoreAltScreen(mainFile, mainData, ptyOffset, mainFile.size - replayData.byteLength);
+            this.dataBytesProcessed += replayData.byteLength;
+            await this.doTerminalWrite(replayData, mainFile.size);
+        }
+    }
+
+    // a full screen application is running (the controller records where it switched to the alternate screen).
+    // if the replay starts after the switch, the normal screen up to the switch is written first, so the
+    // application's output goes to the alternate screen and not over the scrollback.
+    async restoreAltScreen(mainFile: WaveFile, mainData: Uint8Array, ptyOffset: number, replayStart: number) {
+        const altScreenStart: number = mainFile.meta?.["altscreen"];
+        if (altScreenStart == null || altScreenStart > replayStart) {
+            // the replay has the switch (or there is no alternate screen)
+            return;
+        }
+        if (ptyOffset > 0 && altScreenStart <= ptyOffset) {
+            // the cache has the switch
+            return;
         }
+        const dataStart = mainFile.size - mainData.byteLength;
+        if (altScreenStart < dataStart) {
+            // the switch is no longer in the term file
+            await this.doTerminalWrite("\x1b[?1049h", replayStart);
+            return;
+        }
+        const normalStart = Math.max(dataStart, mainFile.meta?.["altscreenscrollback"] ?? 0);
+        await this.doTerminalWrite(mainData.slice(normalStart - dataStart, altScreenStart - dataStart), altScreenStart);
+    }
+
+    // keeps track of the mouse modes the application has asked for.  when mouse reporting is off for the block
+    // the modes are not turned on (returning true stops xterm from handling the sequence), so the mouse selects.
+    handleMouseModeSet(params: (number | number[])[], on: boolean): boolean {
+        const modes = params.filter((p): p is number => typeof p == "number" && MouseTrackingModes.includes(p));
+        if (modes.length == 0) {
+            return false;
+        }
+        if (!this.mouseModeSyncing) {
+            for (const mode of modes) {
+                if (on) {
+                    this.appMouseModes.add(mode);
+                } else {
+                    this.appMouseModes.delete(mode);
+                }
+            }
+        }
+        // sequences that set other modes too are let through
+        return on && this.mouseReportingOff && !this.mouseModeSyncing && modes.length == params.length;
+    }
+
+    // applies (or removes) the mouse modes the application has asked for
+    setMouseReporting(enabled: boolean) {
+        if (this.mouseReportingOff == !enabled) {
+            return;
+        }
+        this.mouseReportingOff = !enabled;
+        if (this.appMouseModes.size == 0) {
+            return;
+        }
+        const modes = Array.from(this.appMouseModes).join(";");
+        this.mouseModeSyncing = true;
+        this.terminal.write(`\x1b[?${modes}${enabled ? "h" : "l"}`, () => {
+            this.mouseModeSyncing = false;
+        });
     }
 
     async resyncController(reason: string) {
@@ -13,7 +13,8 @@ declare global {
         waveWindow: jotai.Atom<WaveWindow>; // driven from WOS
         workspace: jotai.Atom<Workspace>; // driven from WOS
         fullConfigAtom: jotai.PrimitiveAtom<FullConfigType>; // driven from WOS, settings -- updated via WebSocket
-        settingsAtom: jotai.Atom<SettingsType>; // derrived from fullConfig
+        settingsOverridesAtom: jotai.PrimitiveAtom<MetaType>; // the global and workspace settings set in wave
+        settingsAtom: jotai.Atom<SettingsType>; // derrived from fullConfig and the settings overrides
         tabAtom: jotai.Atom<Tab>; // driven from WOS
         staticTabId: jotai.Atom<string>;
         isFullScreen: jotai.PrimitiveAtom<boolean>;
@@ -61,7 +62,6 @@ declare global {
     };
 
     type ElectronApi = {
-        getAuthKey(): string;
         getIsDev(): boolean;
         getCursorPoint: () => Electron.Point;
         getPlatform: () => NodeJS.Platform;
@@ -398,6 +398,7 @@ declare global {
         actions?: NotificationActionType[];
         persistent?: boolean;
         type?: "error" | "update" | "info" | "warning";
+        backend?: boolean; // stored in wavesrv until it is dismissed (the id is the notification's oid)
     };
 
     interface AbstractWshClient {
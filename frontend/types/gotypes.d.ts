@@ -5,6 +5,18 @@
 
 declare global {
 
+    // wshrpc.ActionDef
+    type ActionDef = {
+        actionid: string;
+        title: string;
+        icon?: string;
+        otypes?: string[];
+        views?: string[];
+        controllers?: string[];
+        order?: number;
+        pluginid?: string;
+    };
+
     // wshrpc.ActivityDisplayType
     type ActivityDisplayType = {
         width: number;
@@ -45,12 +57,18 @@ declare global {
         message?: string;
     };
 
+    // wshrpc.AuthTokenRtnData
+    type AuthTokenRtnData = {
+        token: string;
+    };
+
     // waveobj.Block
     type Block = WaveObj & {
         parentoref?: string;
         runtimeopts?: RuntimeOpts;
         stickers?: StickerType[];
         subblockids?: string[];
+        defhash?: string;
     };
 
     // blockcontroller.BlockControllerRuntimeStatus
@@ -60,6 +78,14 @@ declare global {
         shellprocstatus?: string;
         shellprocconnname?: string;
         shellprocexitcode: number;
+        outputbufferdepth?: number;
+        outputpaused?: boolean;
+        recording?: boolean;
+        altscreen?: boolean;
+        secureinput?: boolean;
+        activepane?: string;
+        panes?: TermPaneInfo[];
+        connfrozen?: boolean;
     };
 
     // waveobj.BlockDef
@@ -90,6 +116,7 @@ declare global {
         tosagreed?: number;
         hasoldhistory?: boolean;
         tempoid?: string;
+        activeprofile?: string;
     };
 
     // workspaceservice.CloseTabRtnType
@@ -98,6 +125,57 @@ declare global {
         newactivetabid?: string;
     };
 
+    // wps.ClusterExecEventData
+    type ClusterExecEventData = {
+        runid: string;
+        connection?: string;
+        status?: string;
+        done?: boolean;
+    };
+
+    // wshrpc.ClusterExecGroup
+    type ClusterExecGroup = {
+        exitcode: number;
+        error?: string;
+        stdout?: string;
+        stderr?: string;
+        hosts: string[];
+    };
+
+    // wshrpc.ClusterExecHost
+    type ClusterExecHost = {
+        connection: string;
+        connname?: string;
+        status: string;
+        error?: string;
+        startts?: number;
+        result?: ConnectionRunResult;
+    };
+
+    // wshrpc.ClusterExecRun
+    type ClusterExecRun = {
+        runid: string;
+        cmd: string;
+        startts: number;
+        endts?: number;
+        canceled?: boolean;
+        hosts: ClusterExecHost[];
+        groups?: ClusterExecGroup[];
+    };
+
+    // wshrpc.CmdHistoryEntry
+    type CmdHistoryEntry = {
+        historyid: string;
+        ts: number;
+        blockid: string;
+        workspaceid?: string;
+        connname: string;
+        cwd?: string;
+        cmdstr: string;
+        exitcode?: number;
+        durationms?: number;
+    };
+
     // wshrpc.CommandAppendIJsonData
     type CommandAppendIJsonData = {
         zoneid: string;
@@ -105,6 +183,17 @@ declare global {
         data: {[key: string]: any};
     };
 
+    // wshrpc.CommandAttachBlockData
+    type CommandAttachBlockData = {
+        blockid: string;
+        tabid: string;
+    };
+
+    // wshrpc.CommandAuthTokenData
+    type CommandAuthTokenData = {
+        tabid: string;
+    };
+
     // wshrpc.CommandAuthenticateRtnData
     type CommandAuthenticateRtnData = {
         routeid: string;
@@ -132,12 +221,117 @@ declare global {
         view: string;
     };
 
+    // wshrpc.CommandClipboardWriteData
+    type CommandClipboardWriteData = {
+        text: string;
+    };
+
+    // wshrpc.CommandClusterExecData
+    type CommandClusterExecData = {
+        cmd: string;
+        tags?: string[];
+        connections?: string[];
+        concurrency?: number;
+        timeoutms?: number;
+        cwd?: string;
+        env?: {[key: string]: string};
+        maxoutput?: number;
+        sudo?: boolean;
+        sudouser?: string;
+    };
+
+    // wshrpc.CommandClusterExecRunData
+    type CommandClusterExecRunData = {
+        runid: string;
+    };
+
+    // wshrpc.CommandCmdHistorySearchData
+    type CommandCmdHistorySearchData = {
+        query?: string;
+        blockid?: string;
+        workspaceid?: string;
+        connname?: string;
+        limit?: number;
+        dedup?: boolean;
+    };
+
+    // wshrpc.CommandConnMetricsData
+    type CommandConnMetricsData = {
+        connection?: string;
+        nohistory?: boolean;
+    };
+
+    // wshrpc.CommandConnectionData
+    type CommandConnectionData = {
+        connection: string;
+        tabid?: string;
+        force?: boolean;
+    };
+
+    // wshrpc.CommandConnectionListData
+    type CommandConnectionListData = {
+        tag?: string;
+    };
+
+    // wshrpc.CommandConnectionRunData
+    type CommandConnectionRunData = {
+        connection: string;
+        cmd: string;
+        cwd?: string;
+        env?: {[key: string]: string};
+        stdin?: string;
+        timeoutms?: number;
+        maxoutput?: number;
+        sudo?: boolean;
+        sudouser?: string;
+        noprompt?: boolean;
+    };
+
+    // wshrpc.CommandConnectionSetKeyData
+    type CommandConnectionSetKeyData = {
+        connection: string;
+        path: string;
+    };
+
     // wshrpc.CommandControllerAppendOutputData
     type CommandControllerAppendOutputData = {
         blockid: string;
         data64: string;
     };
 
+    // wshrpc.CommandControllerOutputAckData
+    type CommandControllerOutputAckData = {
+        blockid: string;
+        offset: number;
+    };
+
+    // wshrpc.CommandControllerPasteData
+    type CommandControllerPasteData = {
+        blockid: string;
+        text: string;
+        confirm?: boolean;
+    };
+
+    // wshrpc.CommandControllerPasteRtnData
+    type CommandControllerPasteRtnData = {
+        pasted: boolean;
+        needsconfirm?: boolean;
+        warnings?: string[];
+        bracketedpaste?: boolean;
+    };
+
+    // wshrpc.CommandControllerProcessTreeData
+    type CommandControllerProcessTreeData = {
+        blockid: string;
+    };
+
+    // wshrpc.CommandControllerResizeData
+    type CommandControllerResizeData = {
+        blockid: string;
+        termsize: TermSize;
+        final?: boolean;
+    };
+
     // wshrpc.CommandControllerResyncData
     type CommandControllerResyncData = {
         forcerestart?: boolean;
@@ -146,6 +340,13 @@ declare global {
         rtopts?: RuntimeOpts;
     };
 
+    // wshrpc.CommandControllerSignalData
+    type CommandControllerSignalData = {
+        blockid: string;
+        signal: string;
+        pid?: number;
+    };
+
     // wshrpc.CommandCreateBlockData
     type CommandCreateBlockData = {
         tabid: string;
@@ -174,6 +375,11 @@ declare global {
         recursive: boolean;
     };
 
+    // wshrpc.CommandDetachBlockData
+    type CommandDetachBlockData = {
+        blockid: string;
+    };
+
     // wshrpc.CommandDisposeData
     type CommandDisposeData = {
         routeid: string;
@@ -186,6 +392,20 @@ declare global {
         maxitems: number;
     };
 
+    // wshrpc.CommandEventReplayData
+    type CommandEventReplayData = {
+        sinceseq: number;
+        subscriptions: SubscriptionRequest[];
+    };
+
+    // wshrpc.CommandExecuteActionData
+    type CommandExecuteActionData = {
+        actionid: string;
+        oref: ORef;
+        tabid?: string;
+        args?: {[key: string]: any};
+    };
+
     // wshrpc.CommandFileCopyData
     type CommandFileCopyData = {
         srcuri: string;
@@ -193,17 +413,213 @@ declare global {
         opts?: FileCopyOpts;
     };
 
+    // wshrpc.CommandFileExportData
+    type CommandFileExportData = {
+        path: string;
+        dir: string;
+        collision?: string;
+    };
+
+    // wshrpc.CommandFileImportData
+    type CommandFileImportData = {
+        blockid: string;
+        path: string;
+        name?: string;
+        reference?: boolean;
+        overwrite?: boolean;
+    };
+
+    // wshrpc.CommandFileReadRangesData
+    type CommandFileReadRangesData = {
+        path: string;
+        ranges: FileDataAt[];
+        readahead?: number;
+    };
+
+    // wshrpc.CommandFileSearchData
+    type CommandFileSearchData = {
+        text?: string;
+        name?: string;
+        view?: string;
+        limit?: number;
+    };
+
+    // wshrpc.CommandFileTransferData
+    type CommandFileTransferData = {
+        path: string;
+        transferid: string;
+        offset?: number;
+    };
+
+    // wshrpc.CommandFileUploadChunkData
+    type CommandFileUploadChunkData = {
+        path: string;
+        transferid: string;
+        size: number;
+        offset: number;
+        data64?: string;
+    };
+
+    // wshrpc.CommandFileWatchData
+    type CommandFileWatchData = {
+        path: string;
+        offset: number;
+    };
+
     // wshrpc.CommandGetMetaData
     type CommandGetMetaData = {
         oref: ORef;
     };
 
+    // wshrpc.CommandHostKeyForgetData
+    type CommandHostKeyForgetData = {
+        host: string;
+    };
+
+    // wshrpc.CommandI18nCatalogData
+    type CommandI18nCatalogData = {
+        locale?: string;
+    };
+
+    // wshrpc.CommandKeyBindingResolveData
+    type CommandKeyBindingResolveData = {
+        workspaceid?: string;
+        keys: string;
+    };
+
+    // wshrpc.CommandListActionsData
+    type CommandListActionsData = {
+        oref: ORef;
+    };
+
     // wshrpc.CommandMessageData
     type CommandMessageData = {
         oref: ORef;
         message: string;
     };
 
+    // wshrpc.CommandNotificationDismissData
+    type CommandNotificationDismissData = {
+        id?: string;
+        all?: boolean;
+    };
+
+    // wshrpc.CommandNotificationSendData
+    type CommandNotificationSendData = {
+        title?: string;
+        message?: string;
+        type?: string;
+        source?: string;
+        blockid?: string;
+        dedupkey?: string;
+        desktop?: boolean;
+        silent?: boolean;
+    };
+
+    // wshrpc.CommandPaletteSearchData
+    type CommandPaletteSearchData = {
+        query: string;
+        oref: ORef;
+        types?: string[];
+        limit?: number;
+    };
+
+    // wshrpc.CommandPlayerData
+    type CommandPlayerData = {
+        blockid: string;
+    };
+
+    // wshrpc.CommandPlayerLoadData
+    type CommandPlayerLoadData = {
+        blockid: string;
+        src: string;
+    };
+
+    // wshrpc.CommandPlayerSeekData
+    type CommandPlayerSeekData = {
+        blockid: string;
+        pos: number;
+    };
+
+    // wshrpc.CommandPlayerSetSpeedData
+    type CommandPlayerSetSpeedData = {
+        blockid: string;
+        speed: number;
+    };
+
+    // wshrpc.CommandPluginBlockData
+    type CommandPluginBlockData = {
+        blockid: string;
+    };
+
+    // wshrpc.CommandPluginCallData
+    type CommandPluginCallData = {
+        pluginid: string;
+        handler: string;
+        blockid?: string;
+        data?: any;
+    };
+
+    // wshrpc.CommandPluginFileData
+    type CommandPluginFileData = {
+        blockid: string;
+        filename: string;
+        data64?: string;
+        append?: boolean;
+    };
+
+    // wshrpc.CommandPluginRegisterData
+    type CommandPluginRegisterData = {
+        views?: PluginViewDef[];
+        controllers?: string[];
+        actions?: ActionDef[];
+        handlers?: string[];
+        catalogs?: {[key: string]: {[key: string]: string}};
+    };
+
+    // wshrpc.CommandPluginRevokeData
+    type CommandPluginRevokeData = {
+        pluginid: string;
+    };
+
+    // wshrpc.CommandPluginSetMetaData
+    type CommandPluginSetMetaData = {
+        blockid: string;
+        meta: MetaType;
+    };
+
+    // wshrpc.CommandPluginTokenIssueData
+    type CommandPluginTokenIssueData = {
+        pluginid: string;
+        name?: string;
+        caps?: string[];
+        scope?: string[];
+        hours?: number;
+    };
+
+    // wshrpc.CommandPortForwardCreateData
+    type CommandPortForwardCreateData = {
+        connection: string;
+        forward: PortForward;
+        autostart?: boolean;
+    };
+
+    // wshrpc.CommandPortForwardListData
+    type CommandPortForwardListData = {
+        connection?: string;
+    };
+
+    // wshrpc.CommandPortForwardStopData
+    type CommandPortForwardStopData = {
+        id: string;
+        forget?: boolean;
+    };
+
+    // wshrpc.CommandProfileData
+    type CommandProfileData = {
+        name: string;
+    };
+
     // wshrpc.CommandRemoteListEntriesData
     type CommandRemoteListEntriesData = {
         path: string;
@@ -215,6 +631,31 @@ declare global {
         fileinfo?: FileInfo[];
     };
 
+    // wshrpc.CommandRemotePtyStartData
+    type CommandRemotePtyStartData = {
+        cmd: string;
+        env?: string[];
+        termsize: TermSize;
+    };
+
+    // wshrpc.CommandRemoteRevokeData
+    type CommandRemoteRevokeData = {
+        sessionid?: string;
+        all?: boolean;
+    };
+
+    // wshrpc.CommandRemoteSetPasswordData
+    type CommandRemoteSetPasswordData = {
+        password: string;
+    };
+
+    // wshrpc.CommandRemoteShareCreateData
+    type CommandRemoteShareCreateData = {
+        workspace: string;
+        perms: string[];
+        hours?: number;
+    };
+
     // wshrpc.CommandRemoteStreamFileData
     type CommandRemoteStreamFileData = {
         path: string;
@@ -227,6 +668,14 @@ declare global {
         opts?: FileCopyOpts;
     };
 
+    // wshrpc.CommandRerunBlockData
+    type CommandRerunBlockData = {
+        blockid: string;
+        tabid: string;
+        targetaction?: string;
+        magnified?: boolean;
+    };
+
     // wshrpc.CommandResolveIdsData
     type CommandResolveIdsData = {
         blockid: string;
@@ -238,12 +687,232 @@ declare global {
         resolvedids: {[key: string]: ORef};
     };
 
+    // wshrpc.CommandSaveEnvSnapshotData
+    type CommandSaveEnvSnapshotData = {
+        blockid: string;
+        cwd?: string;
+        env: {[key: string]: string};
+    };
+
+    // wshrpc.CommandSecretData
+    type CommandSecretData = {
+        name: string;
+        connection?: string;
+    };
+
+    // wshrpc.CommandSecretListData
+    type CommandSecretListData = {
+        connection?: string;
+    };
+
+    // wshrpc.CommandSecretSetData
+    type CommandSecretSetData = {
+        name: string;
+        connection?: string;
+        value: string;
+    };
+
     // wshrpc.CommandSetMetaData
     type CommandSetMetaData = {
         oref: ORef;
         meta: MetaType;
     };
 
+    // wshrpc.CommandSettingsData
+    type CommandSettingsData = {
+        workspaceid?: string;
+    };
+
+    // wshrpc.CommandSettingsExplainData
+    type CommandSettingsExplainData = {
+        workspaceid?: string;
+        connection?: string;
+        blockid?: string;
+        keys?: string[];
+    };
+
+    // wshrpc.CommandSettingsImportData
+    type CommandSettingsImportData = {
+        format?: string;
+        filename?: string;
+        data: string;
+        only?: string[];
+        dryrun?: boolean;
+    };
+
+    // wshrpc.CommandSettingsMigrateData
+    type CommandSettingsMigrateData = {
+        dryrun?: boolean;
+    };
+
+    // wshrpc.CommandSettingsSetData
+    type CommandSettingsSetData = {
+        workspaceid?: string;
+        settings: MetaType;
+    };
+
+    // wshrpc.CommandSftpCopyData
+    type CommandSftpCopyData = {
+        srcconnection?: string;
+        srcpath: string;
+        destconnection?: string;
+        destpath: string;
+        recursive?: boolean;
+        resume?: boolean;
+        preserve?: boolean;
+        verify?: boolean;
+        sudo?: boolean;
+    };
+
+    // wshrpc.CommandSftpTransferData
+    type CommandSftpTransferData = {
+        connection: string;
+        localpath: string;
+        remotepath: string;
+        recursive?: boolean;
+        resume?: boolean;
+        preserve?: boolean;
+        verify?: boolean;
+        sudo?: boolean;
+    };
+
+    // wshrpc.CommandShellIntegrationCheckData
+    type CommandShellIntegrationCheckData = {
+        repair?: boolean;
+    };
+
+    // wshrpc.CommandSshKeyAddToAgentData
+    type CommandSshKeyAddToAgentData = {
+        path: string;
+        passphrase?: string;
+        lifetimesecs?: number;
+    };
+
+    // wshrpc.CommandSshKeyGenerateData
+    type CommandSshKeyGenerateData = {
+        name: string;
+        keytype?: string;
+        bits?: number;
+        comment?: string;
+        passphrase?: string;
+        addtoagent?: boolean;
+        connection?: string;
+    };
+
+    // wshrpc.CommandStorageCheckData
+    type CommandStorageCheckData = {
+        repair?: boolean;
+    };
+
+    // wshrpc.CommandStoragePruneData
+    type CommandStoragePruneData = {
+        dryrun?: boolean;
+    };
+
+    // wshrpc.CommandStorageReplicaKeyData
+    type CommandStorageReplicaKeyData = {
+        key?: string;
+    };
+
+    // wshrpc.CommandStorageReplicaListData
+    type CommandStorageReplicaListData = {
+        zoneid?: string;
+    };
+
+    // wshrpc.CommandStorageReplicaRestoreData
+    type CommandStorageReplicaRestoreData = {
+        zoneid: string;
+        targetzoneid?: string;
+        names?: string[];
+        overwrite?: boolean;
+    };
+
+    // wshrpc.CommandStorageUsageData
+    type CommandStorageUsageData = {
+        oref?: string;
+    };
+
+    // wshrpc.CommandTermFontData
+    type CommandTermFontData = {
+        blockid: string;
+    };
+
+    // wshrpc.CommandTermFontSetData
+    type CommandTermFontSetData = {
+        blockid: string;
+        font?: TermFontOpts;
+    };
+
+    // wshrpc.CommandTermGetLinksData
+    type CommandTermGetLinksData = {
+        blockid: string;
+        startoffset?: number;
+    };
+
+    // wshrpc.CommandTermGetSegmentOutputData
+    type CommandTermGetSegmentOutputData = {
+        blockid: string;
+        segidx: number;
+        includeprompt?: boolean;
+    };
+
+    // wshrpc.CommandTermGetSegmentsData
+    type CommandTermGetSegmentsData = {
+        blockid: string;
+    };
+
+    // wshrpc.CommandTermPaneCreateData
+    type CommandTermPaneCreateData = {
+        blockid: string;
+        cmd?: string;
+        switch?: boolean;
+    };
+
+    // wshrpc.CommandTermPaneData
+    type CommandTermPaneData = {
+        blockid: string;
+        paneid?: string;
+    };
+
+    // wshrpc.CommandTermQueueData
+    type CommandTermQueueData = {
+        blockid: string;
+        cmd?: string;
+        id?: number;
+    };
+
+    // wshrpc.CommandTermRecordData
+    type CommandTermRecordData = {
+        blockid: string;
+        title?: string;
+    };
+
+    // wshrpc.CommandThemeAssignData
+    type CommandThemeAssignData = {
+        theme: string;
+        blockid?: string;
+        connection?: string;
+    };
+
+    // wshrpc.CommandThemeData
+    type CommandThemeData = {
+        name: string;
+    };
+
+    // wshrpc.CommandThemeImportData
+    type CommandThemeImportData = {
+        name?: string;
+        format?: string;
+        filename?: string;
+        data: string;
+    };
+
+    // wshrpc.CommandThemeSaveData
+    type CommandThemeSaveData = {
+        name: string;
+        theme: TermThemeType;
+    };
+
     // wshrpc.CommandVarData
     type CommandVarData = {
         key: string;
@@ -275,10 +944,41 @@ declare global {
         opts?: WebSelectorOpts;
     };
 
+    // wshrpc.CommandWebhookCreateData
+    type CommandWebhookCreateData = {
+        name?: string;
+        url: string;
+        filters: WebhookFilter[];
+        secret?: string;
+    };
+
+    // wshrpc.CommandWebhookData
+    type CommandWebhookData = {
+        webhookid?: string;
+        disabled?: boolean;
+        limit?: number;
+    };
+
+    // wshrpc.CommandWslPathData
+    type CommandWslPathData = {
+        path: string;
+        distro?: string;
+        towindows?: boolean;
+    };
+
+    // wshrpc.CommandWslSetShellData
+    type CommandWslSetShellData = {
+        distro: string;
+        shellpath?: string;
+    };
+
     // wconfig.ConfigError
     type ConfigError = {
         file: string;
         err: string;
+        line?: number;
+        col?: number;
+        key?: string;
     };
 
     // wshrpc.ConnConfigRequest
@@ -287,6 +987,13 @@ declare global {
         metamaptype: MetaType;
     };
 
+    // wshrpc.ConnDashboardEntry
+    type ConnDashboardEntry = {
+        info: ConnectionInfo;
+        forwards?: PortForwardInfo[];
+        metrics?: ConnMetrics;
+    };
+
     // wshrpc.ConnExtData
     type ConnExtData = {
         connname: string;
@@ -305,7 +1012,11 @@ declare global {
         "term:*"?: boolean;
         "term:fontsize"?: number;
         "term:fontfamily"?: string;
+        "term:lineheight"?: number;
+        "term:ligatures"?: boolean;
         "term:theme"?: string;
+        "term:osc52"?: string;
+        "term:termtype"?: string;
         "cmd:env"?: {[key: string]: string};
         "cmd:initscript"?: string;
         "cmd:initscript.sh"?: string;
@@ -328,6 +1039,44 @@ declare global {
         "ssh:proxyjump"?: string[];
         "ssh:userknownhostsfile"?: string[];
         "ssh:globalknownhostsfile"?: string[];
+        "ssh:forwardagent"?: boolean;
+        "conn:keepaliveinterval"?: number;
+        "conn:keepalivecountmax"?: number;
+        "conn:autoreconnect"?: boolean;
+        "conn:transport"?: string;
+        "conn:roamports"?: string;
+        "conn:tags"?: string[];
+        "cmd:cwd"?: string;
+        "ssh:certificatefile"?: string[];
+        "ssh:gssapiauthentication"?: boolean;
+        "ssh:passwordsecret"?: string;
+        "ssh:passphrasesecret"?: string;
+        "conn:secretenv"?: {[key: string]: string};
+        "conn:sudosecret"?: string;
+    };
+
+    // wshrpc.ConnMetrics
+    type ConnMetrics = {
+        connection: string;
+        status: string;
+        latencyms?: number;
+        smoothedlatencyms?: number;
+        minlatencyms?: number;
+        maxlatencyms?: number;
+        bytesin: number;
+        bytesout: number;
+        bytesinrate: number;
+        bytesoutrate: number;
+        samples?: ConnMetricsSample[];
+    };
+
+    // wshrpc.ConnMetricsSample
+    type ConnMetricsSample = {
+        ts: number;
+        durationms: number;
+        bytesin: number;
+        bytesout: number;
+        latencyms?: number;
     };
 
     // wshrpc.ConnRequest
@@ -349,6 +1098,55 @@ declare global {
         wsherror?: string;
         nowshreason?: string;
         wshversion?: string;
+        attempt?: number;
+        nextretryts?: number;
+        latencyms?: number;
+    };
+
+    // waveobj.Connection
+    type Connection = WaveObj & {
+        name: string;
+        host: string;
+        user?: string;
+        port?: string;
+        authmethod?: string;
+        identityfile?: string;
+        certificatefile?: string;
+        secret?: string;
+        proxyjump?: string[];
+        forwardagent?: boolean;
+        forwards?: PortForward[];
+        tags?: string[];
+        createdts: number;
+    };
+
+    // wshrpc.ConnectionInfo
+    type ConnectionInfo = {
+        connection: Connection;
+        connname: string;
+        status: ConnStatus;
+        blockids?: string[];
+    };
+
+    // wshrpc.ConnectionRunOutput
+    type ConnectionRunOutput = {
+        stdout?: string;
+        stderr?: string;
+        result?: ConnectionRunResult;
+    };
+
+    // wshrpc.ConnectionRunResult
+    type ConnectionRunResult = {
+        connection: string;
+        connname: string;
+        exitcode: number;
+        signal?: string;
+        stdout: string;
+        stderr: string;
+        stdouttruncated?: boolean;
+        stderrtruncated?: boolean;
+        timedout?: boolean;
+        durationms: number;
     };
 
     // wshrpc.CpuDataRequest
@@ -357,6 +1155,17 @@ declare global {
         count: number;
     };
 
+    // wshrpc.DetachedBlockInfo
+    type DetachedBlockInfo = {
+        blockid: string;
+        view?: string;
+        controller: string;
+        connname?: string;
+        cwd?: string;
+        shellprocstatus?: string;
+        shellprocexitcode: number;
+    };
+
     // vdom.DomRect
     type DomRect = {
         top: number;
@@ -367,6 +1176,21 @@ declare global {
         height: number;
     };
 
+    // wshrpc.EnvSnapshot
+    type EnvSnapshot = {
+        connname?: string;
+        cwd?: string;
+        ts: number;
+        env: {[key: string]: string};
+    };
+
+    // wshrpc.EventReplayRtnData
+    type EventReplayRtnData = {
+        events?: WaveEvent[];
+        lastseq: number;
+        complete: boolean;
+    };
+
     // wshrpc.FetchSuggestionsData
     type FetchSuggestionsData = {
         suggestiontype: string;
@@ -404,6 +1228,7 @@ declare global {
     type FileDataAt = {
         offset: number;
         size?: number;
+        readahead?: number;
     };
 
     // waveobj.FileDef
@@ -412,6 +1237,17 @@ declare global {
         meta?: {[key: string]: any};
     };
 
+    // wshrpc.FileExportProgress
+    type FileExportProgress = {
+        name: string;
+        localpath?: string;
+        offset: number;
+        size: number;
+        done?: boolean;
+        skipped?: boolean;
+        error?: string;
+    };
+
     // wshrpc.FileInfo
     type FileInfo = {
         path: string;
@@ -451,6 +1287,29 @@ declare global {
         ijsonbudget?: number;
         truncate?: boolean;
         append?: boolean;
+        lineindex?: boolean;
+        textindex?: boolean;
+    };
+
+    // wshrpc.FileRangeData
+    type FileRangeData = {
+        offset: number;
+        data64?: string;
+    };
+
+    // wshrpc.FileReadRangesData
+    type FileReadRangesData = {
+        info: FileInfo;
+        ranges: FileRangeData[];
+    };
+
+    // wshrpc.FileSearchHit
+    type FileSearchHit = {
+        blockid: string;
+        view?: string;
+        name: string;
+        offset: number;
+        snippet?: string;
     };
 
     // wshrpc.FileShareCapability
@@ -459,6 +1318,34 @@ declare global {
         canmkdir: boolean;
     };
 
+    // wshrpc.FileTransferChunk
+    type FileTransferChunk = {
+        offset: number;
+        size: number;
+        data64?: string;
+    };
+
+    // wshrpc.FileTransferStatus
+    type FileTransferStatus = {
+        transferid: string;
+        offset: number;
+        size: number;
+        done?: boolean;
+    };
+
+    // wshrpc.FileWatchEvent
+    type FileWatchEvent = {
+        type: string;
+        offset: number;
+        data64?: string;
+    };
+
+    // wshrpc.FontInfo
+    type FontInfo = {
+        name: string;
+        source: string;
+    };
+
     // wconfig.FullConfigType
     type FullConfigType = {
         settings: SettingsType;
@@ -468,8 +1355,85 @@ declare global {
         presets: {[key: string]: MetaType};
         termthemes: {[key: string]: TermThemeType};
         connections: {[key: string]: ConnKeywords};
+        connprofiles: {[key: string]: ConnKeywords};
         bookmarks: {[key: string]: WebBookmark};
         configerrors: ConfigError[];
+        migrations?: SettingsMigration[];
+    };
+
+    // userinput.HostKeyPrompt
+    type HostKeyPrompt = {
+        host: string;
+        remote: string;
+        keytype: string;
+        fingerprint: string;
+        art: string;
+        changed?: boolean;
+        knownkeys?: string[];
+        knownhostsfile: string;
+    };
+
+    // wshrpc.I18nCatalogData
+    type I18nCatalogData = {
+        locale: string;
+        chain: string[];
+        strings: {[key: string]: string};
+    };
+
+    // wshrpc.I18nCatalogInfo
+    type I18nCatalogInfo = {
+        locale: string;
+        source: string;
+        pluginid?: string;
+        numstrings: number;
+    };
+
+    // wshrpc.KeyBinding
+    type KeyBinding = {
+        keys: string;
+        action: string;
+        backend?: boolean;
+        source: string;
+    };
+
+    // wshrpc.KeyBindingAction
+    type KeyBindingAction = {
+        action: string;
+        title: string;
+        backend?: boolean;
+    };
+
+    // wshrpc.KeyBindingConflict
+    type KeyBindingConflict = {
+        keys: string;
+        action: string;
+        shadowed: string[];
+        reason: string;
+    };
+
+    // wshrpc.KeyBindingResolveRtnData
+    type KeyBindingResolveRtnData = {
+        keys: string;
+        action?: string;
+        backend?: boolean;
+        partial?: boolean;
+    };
+
+    // wshrpc.KeyBindingsData
+    type KeyBindingsData = {
+        bindings: KeyBinding[];
+        actions: KeyBindingAction[];
+        conflicts?: KeyBindingConflict[];
+        errors?: string[];
+    };
+
+    // wshrpc.KnownHostKey
+    type KnownHostKey = {
+        hosts: string[];
+        keytype: string;
+        fingerprint: string;
+        revoked?: boolean;
+        line: number;
     };
 
     // waveobj.LayoutActionData
@@ -511,6 +1475,7 @@ declare global {
         edit?: boolean;
         history?: string[];
         "history:forward"?: string[];
+        tags?: string[];
         "display:name"?: string;
         "display:order"?: number;
         icon?: string;
@@ -532,10 +1497,14 @@ declare global {
         "cmd:closeonexit"?: boolean;
         "cmd:closeonexitforce"?: boolean;
         "cmd:closeonexitdelay"?: number;
+        "cmd:restart"?: string;
+        "cmd:restartdelay"?: number;
         "cmd:nowsh"?: boolean;
         "cmd:args"?: string[];
+        "cmd:argv"?: string[];
         "cmd:shell"?: boolean;
         "cmd:allowconnchange"?: boolean;
+        "cmd:envfrom"?: string;
         "cmd:env"?: {[key: string]: string};
         "cmd:cwd"?: string;
         "cmd:initscript"?: string;
@@ -563,6 +1532,9 @@ declare global {
         "graph:numpoints"?: number;
         "graph:metrics"?: string[];
         "sysinfo:type"?: string;
+        "clusterexec:runid"?: string;
+        "player:*"?: boolean;
+        "player:src"?: string;
         "bg:*"?: boolean;
         bg?: string;
         "bg:opacity"?: number;
@@ -572,15 +1544,30 @@ declare global {
         "term:*"?: boolean;
         "term:fontsize"?: number;
         "term:fontfamily"?: string;
+        "term:lineheight"?: number;
+        "term:ligatures"?: boolean;
         "term:mode"?: string;
         "term:theme"?: string;
         "term:localshellpath"?: string;
         "term:localshellopts"?: string[];
+        "term:shellpath"?: string;
+        "term:loginshell"?: boolean;
+        "term:interactiveshell"?: boolean;
+        "term:rcfile"?: string;
+        "term:encoding"?: string;
+        "term:locale"?: string;
         "term:scrollback"?: number;
+        "term:scrollbackbytes"?: number;
         "term:vdomblockid"?: string;
         "term:vdomtoolbarblockid"?: string;
         "term:transparency"?: number;
         "term:allowbracketedpaste"?: boolean;
+        "term:safepaste"?: boolean;
+        "term:record"?: boolean;
+        "term:commandblocks"?: boolean;
+        "term:osc52"?: string;
+        "term:termtype"?: string;
+        "term:mousereporting"?: boolean;
         "term:conndebug"?: string;
         "web:zoom"?: number;
         "web:hidenav"?: boolean;
@@ -608,6 +1595,28 @@ declare global {
         color: string;
     };
 
+    // waveobj.Notification
+    type Notification = WaveObj & {
+        title: string;
+        message?: string;
+        type?: string;
+        source?: string;
+        blockid?: string;
+        dedupkey: string;
+        count: number;
+        createdts: number;
+        updatedts: number;
+        delivered?: boolean;
+    };
+
+    // wps.NotificationEventData
+    type NotificationEventData = {
+        id?: string;
+        title: string;
+        message: string;
+        type?: string;
+    };
+
     // waveobj.ORef
     type ORef = string;
 
@@ -617,6 +1626,21 @@ declare global {
         Checksum: string;
     };
 
+    // wshrpc.PaletteItem
+    type PaletteItem = {
+        itemid: string;
+        itemtype: string;
+        title: string;
+        subtext?: string;
+        icon?: string;
+        oref?: string;
+        actionid?: string;
+        value?: string;
+        score?: number;
+        matchpos?: number[];
+        submatchpos?: number[];
+    };
+
     // wshrpc.PathCommandData
     type PathCommandData = {
         pathtype: string;
@@ -625,12 +1649,139 @@ declare global {
         tabid: string;
     };
 
+    // wshrpc.PlayerStatus
+    type PlayerStatus = {
+        blockid: string;
+        src: string;
+        title?: string;
+        state: string;
+        pos: number;
+        ts: number;
+        duration: number;
+        speed: number;
+        width: number;
+        height: number;
+    };
+
+    // wshrpc.PluginBlockEvent
+    type PluginBlockEvent = {
+        event: string;
+        blockid: string;
+        tabid?: string;
+        view?: string;
+        controller?: string;
+        meta?: MetaType;
+    };
+
+    // wshrpc.PluginInfo
+    type PluginInfo = {
+        pluginid: string;
+        name?: string;
+        version?: string;
+        caps?: string[];
+        views?: PluginViewDef[];
+        controllers?: string[];
+        handlers?: string[];
+        external?: boolean;
+        scope?: string[];
+        expirests?: number;
+        connected: boolean;
+    };
+
+    // wshrpc.PluginTokenRtnData
+    type PluginTokenRtnData = {
+        token: string;
+        sockname: string;
+        expirests: number;
+    };
+
+    // wshrpc.PluginViewDef
+    type PluginViewDef = {
+        view: string;
+        displayname?: string;
+        icon?: string;
+        controller?: string;
+    };
+
     // waveobj.Point
     type Point = {
         x: number;
         y: number;
     };
 
+    // waveobj.PortForward
+    type PortForward = {
+        type: string;
+        bindaddr?: string;
+        bindport: number;
+        desthost?: string;
+        destport?: number;
+    };
+
+    // wshrpc.PortForwardInfo
+    type PortForwardInfo = {
+        id: string;
+        connection: string;
+        connname: string;
+        forward: PortForward;
+        autostart?: boolean;
+        status: string;
+        error?: string;
+        startts?: number;
+        bytessent: number;
+        bytesrecv: number;
+        activeconns: number;
+        totalconns: number;
+    };
+
+    // wshrpc.ProcessInfo
+    type ProcessInfo = {
+        pid: number;
+        parentpid: number;
+        depth: number;
+        name: string;
+        cmdline?: string;
+        cpupercent: number;
+        memrss: number;
+        createts?: number;
+    };
+
+    // waveobj.Profile
+    type Profile = WaveObj & {
+        name: string;
+        settings: MetaType;
+        theme?: string;
+        keybindings?: {[key: string]: string[]};
+        templates: ProfileTemplates;
+        createdts: number;
+        updatedts: number;
+    };
+
+    // wshrpc.ProfileListData
+    type ProfileListData = {
+        profiles: Profile[];
+        active?: string;
+        activesettings?: MetaType;
+    };
+
+    // waveobj.ProfileTemplates
+    type ProfileTemplates = {
+        newblock?: string;
+        tabpreset?: string;
+        aipreset?: string;
+    };
+
+    // wshrpc.QueuedCommand
+    type QueuedCommand = {
+        id: number;
+        cmd: string;
+        status: string;
+        queuedts: number;
+        startts?: number;
+        endts?: number;
+        exitcode?: number;
+    };
+
     // wshrpc.RemoteInfo
     type RemoteInfo = {
         clientarch: string;
@@ -639,6 +1790,72 @@ declare global {
         shell: string;
     };
 
+    // wshrpc.RemotePtyInfo
+    type RemotePtyInfo = {
+        sockpath: string;
+        hostpid: number;
+    };
+
+    // wshrpc.RemoteSessionInfo
+    type RemoteSessionInfo = {
+        sessionid: string;
+        shareid?: string;
+        perms: string[];
+        remoteaddr: string;
+        useragent?: string;
+        createdts: number;
+        lastseents: number;
+    };
+
+    // wshrpc.RemoteShareInfo
+    type RemoteShareInfo = {
+        shareid: string;
+        workspaceid: string;
+        workspacename?: string;
+        perms: string[];
+        createdts: number;
+        expirests: number;
+        token?: string;
+        url?: string;
+    };
+
+    // wshrpc.ReplicaFileInfo
+    type ReplicaFileInfo = {
+        zoneid: string;
+        name: string;
+        size: number;
+        modts: number;
+        error?: string;
+    };
+
+    // wshrpc.ReplicaSyncResult
+    type ReplicaSyncResult = {
+        blobs: number;
+        bytes: number;
+        files: number;
+        deleted: number;
+        offloaded: number;
+        offloadedbytes: number;
+        rehydrated: number;
+    };
+
+    // wshrpc.RetentionAction
+    type RetentionAction = {
+        blockid: string;
+        name: string;
+        action: string;
+        reason: string;
+        size: number;
+    };
+
+    // wconfig.RetentionPolicy
+    type RetentionPolicy = {
+        tag?: string;
+        files: string;
+        maxagedays?: number;
+        keeplast?: number;
+    };
+
     // wshutil.RpcMessage
     type RpcMessage = {
         command?: string;
@@ -666,6 +1883,21 @@ declare global {
     type RuntimeOpts = {
         termsize?: TermSize;
         winsize?: WinSize;
+        termfont?: TermFontOpts;
+    };
+
+    // wshrpc.SecretInfo
+    type SecretInfo = {
+        name: string;
+        connname?: string;
+        backend: string;
+        createdts: number;
+        updatedts: number;
+    };
+
+    // wps.ServerShutdownEventData
+    type ServerShutdownEventData = {
+        reason: string;
     };
 
     // webcmd.SetBlockTermSizeWSCommand
@@ -675,12 +1907,72 @@ declare global {
         termsize: TermSize;
     };
 
+    // wshrpc.SettingLayerValue
+    type SettingLayerValue = {
+        source: string;
+        name?: string;
+        value: any;
+        ignored?: boolean;
+    };
+
+    // wshrpc.SettingValueInfo
+    type SettingValueInfo = {
+        key: string;
+        value: any;
+        source: string;
+        locked?: boolean;
+        layers?: SettingLayerValue[];
+    };
+
+    // waveobj.Settings
+    type Settings = WaveObj & {
+        workspaceid?: string;
+        settings: MetaType;
+        updatedts: number;
+    };
+
+    // wps.SettingsChangeEventData
+    type SettingsChangeEventData = {
+        workspaceid?: string;
+        keys: string[];
+        ts: number;
+    };
+
+    // wshrpc.SettingsImportItem
+    type SettingsImportItem = {
+        kind: string;
+        name: string;
+        value?: string;
+        from?: string;
+        skipped?: boolean;
+        reason?: string;
+    };
+
+    // wshrpc.SettingsImportResult
+    type SettingsImportResult = {
+        format: string;
+        items: SettingsImportItem[];
+    };
+
+    // wconfig.SettingsMigration
+    type SettingsMigration = {
+        file?: string;
+        version: number;
+        key: string;
+        newkey?: string;
+        desc: string;
+        oldvalue?: any;
+        newvalue?: any;
+    };
+
     // wconfig.SettingsType
     type SettingsType = {
         "app:*"?: boolean;
         "app:globalhotkey"?: string;
         "app:dismissarchitecturewarning"?: boolean;
         "app:defaultnewblock"?: string;
+        "app:keybindings"?: {[key: string]: string[]};
+        "app:locale"?: string;
         "ai:*"?: boolean;
         "ai:preset"?: string;
         "ai:apitype"?: string;
@@ -697,14 +1989,29 @@ declare global {
         "term:*"?: boolean;
         "term:fontsize"?: number;
         "term:fontfamily"?: string;
+        "term:lineheight"?: number;
+        "term:ligatures"?: boolean;
         "term:theme"?: string;
         "term:disablewebgl"?: boolean;
         "term:localshellpath"?: string;
         "term:localshellopts"?: string[];
+        "term:loginshell"?: boolean;
+        "term:interactiveshell"?: boolean;
+        "term:rcfile"?: string;
         "term:scrollback"?: number;
+        "term:scrollbackbytes"?: number;
+        "term:outputflushms"?: number;
+        "term:outputmaxrate"?: number;
         "term:copyonselect"?: boolean;
         "term:transparency"?: number;
         "term:allowbracketedpaste"?: boolean;
+        "term:safepaste"?: boolean;
+        "term:record"?: boolean;
+        "term:commandblocks"?: boolean;
+        "term:osc52"?: string;
+        "term:termtype"?: string;
+        "term:mousereporting"?: boolean;
+        "term:persistentsessions"?: boolean;
         "editor:minimapenabled"?: boolean;
         "editor:stickyscrollenabled"?: boolean;
         "editor:wordwrap"?: boolean;
@@ -750,6 +2057,89 @@ declare global {
         "conn:*"?: boolean;
         "conn:askbeforewshinstall"?: boolean;
         "conn:wshenabled"?: boolean;
+        "storage:*"?: boolean;
+        "storage:maxblockbytes"?: number;
+        "storage:maxworkspacebytes"?: number;
+        "storage:searchindex"?: boolean;
+        "storage:encrypt"?: boolean;
+        "storage:retention"?: RetentionPolicy[];
+        "storage:replicaurl"?: string;
+        "storage:replicaprofile"?: string;
+        "storage:replicaregion"?: string;
+        "storage:replicaendpoint"?: string;
+        "storage:replicaoffloaddays"?: number;
+        "storage:cachebytes"?: number;
+        "storage:cachepolicy"?: string;
+        "notify:*"?: boolean;
+        "notify:dnd"?: boolean;
+        "notify:dndstart"?: string;
+        "notify:dndend"?: string;
+        "api:*"?: boolean;
+        "api:enabled"?: boolean;
+        "api:listenaddr"?: string;
+        "api:socket"?: boolean;
+        "api:grpc"?: boolean;
+        "api:grpclistenaddr"?: string;
+        "remote:*"?: boolean;
+        "remote:enabled"?: boolean;
+        "remote:listenaddr"?: string;
+        "remote:workspaces"?: string[];
+        "remote:sessionhours"?: number;
+        "remote:tlscert"?: string;
+        "remote:tlskey"?: string;
+        "remote:tlsclientca"?: string;
+        "settings:*"?: boolean;
+        "settings:locked"?: string[];
+        "settings:version"?: number;
+    };
+
+    // wshrpc.SftpTransferProgress
+    type SftpTransferProgress = {
+        path: string;
+        destpath: string;
+        offset: number;
+        size: number;
+        resumedfrom?: number;
+        filedone?: boolean;
+        skipped?: boolean;
+        bytesdone: number;
+        bytestotal: number;
+        filesdone: number;
+        filestotal: number;
+        checksum?: string;
+        direct?: boolean;
+    };
+
+    // wshrpc.ShellIntegrationShellStatus
+    type ShellIntegrationShellStatus = {
+        shelltype: string;
+        installed: boolean;
+        uptodate: boolean;
+        missingfiles?: string[];
+        stalefiles?: string[];
+    };
+
+    // wshrpc.ShellIntegrationStatus
+    type ShellIntegrationStatus = {
+        wavehome: string;
+        wshbindir: string;
+        wshinstalled: boolean;
+        healthy: boolean;
+        repaired?: boolean;
+        shells: ShellIntegrationShellStatus[];
+    };
+
+    // wshrpc.SshKeyInfo
+    type SshKeyInfo = {
+        path: string;
+        pubpath?: string;
+        keytype?: string;
+        bits?: number;
+        fingerprint?: string;
+        comment?: string;
+        encrypted?: boolean;
+        inagent?: boolean;
+        connections?: string[];
     };
 
     // waveobj.StickerClickOptsType
@@ -773,6 +2163,47 @@ declare global {
         display: StickerDisplayOptsType;
     };
 
+    // wshrpc.StorageCacheStats
+    type StorageCacheStats = {
+        hits: number;
+        misses: number;
+        evictions: number;
+        readaheadparts: number;
+        cachedbytes: number;
+        maxbytes: number;
+        policy: string;
+        openfiles: number;
+        dirtyfiles: number;
+    };
+
+    // wshrpc.StorageCheckProblem
+    type StorageCheckProblem = {
+        type: string;
+        zoneid?: string;
+        name?: string;
+        partidx?: number;
+        detail?: string;
+        repaired?: boolean;
+    };
+
+    // wshrpc.StorageCheckResult
+    type StorageCheckResult = {
+        numblobs: number;
+        numparts: number;
+        numquarantined: number;
+        problems?: StorageCheckProblem[];
+    };
+
+    // wshrpc.StorageUsage
+    type StorageUsage = {
+        oref: string;
+        numfiles: number;
+        size: number;
+        storedsize: number;
+        evicted?: number;
+        maxsize?: number;
+    };
+
     // wps.SubscriptionRequest
     type SubscriptionRequest = {
         event: string;
@@ -866,6 +2297,78 @@ declare global {
         blockids: string[];
     };
 
+    // wshrpc.TermFontInfo
+    type TermFontInfo = {
+        font: TermFontOpts;
+        blockset?: TermFontOpts;
+    };
+
+    // waveobj.TermFontOpts
+    type TermFontOpts = {
+        fontfamily?: string;
+        fontsize?: number;
+        lineheight?: number;
+        ligatures?: boolean;
+    };
+
+    // wshrpc.TermLink
+    type TermLink = {
+        offset: number;
+        endoffset: number;
+        kind: string;
+        target: string;
+        text: string;
+        uri?: string;
+        path?: string;
+        line?: number;
+        col?: number;
+        conn?: string;
+    };
+
+    // wshrpc.TermPaneInfo
+    type TermPaneInfo = {
+        paneid: string;
+        cmd?: string;
+        filename: string;
+        startts: number;
+        active?: boolean;
+    };
+
+    // wshrpc.TermRecording
+    type TermRecording = {
+        blockid: string;
+        filename: string;
+        title?: string;
+        startts: number;
+        duration: number;
+        width: number;
+        height: number;
+        size: number;
+        active?: boolean;
+    };
+
+    // wshrpc.TermSegment
+    type TermSegment = {
+        segidx: number;
+        promptoffset: number;
+        cmdoffset: number;
+        outputoffset: number;
+        endoffset: number;
+        exitcode?: number;
+        startts?: number;
+        endts?: number;
+        command?: string;
+        cwd?: string;
+        durationms?: number;
+    };
+
+    // wshrpc.TermSegmentOutput
+    type TermSegmentOutput = {
+        segment: TermSegment;
+        data64: string;
+        truncated?: boolean;
+    };
+
     // waveobj.TermSize
     type TermSize = {
         rows: number;
@@ -898,6 +2401,27 @@ declare global {
         selectionBackground: string;
         background: string;
         cursor: string;
+        cursorAccent?: string;
+        extendedAnsi?: string[];
+        accent?: string;
+    };
+
+    // waveobj.Theme
+    type Theme = WaveObj & {
+        name: string;
+        source?: string;
+        palette: MetaType;
+        createdts: number;
+        updatedts: number;
+    };
+
+    // wshrpc.ThemeInfo
+    type ThemeInfo = {
+        name: string;
+        displayname?: string;
+        source: string;
+        importedfrom?: string;
+        themeid?: string;
     };
 
     // wshrpc.TimeSeriesData
@@ -910,6 +2434,7 @@ declare global {
     type UIContext = {
         windowid: string;
         activetabid: string;
+        feclientid?: string;
     };
 
     // userinput.UserInputRequest
@@ -924,6 +2449,7 @@ declare global {
         publictext: boolean;
         oklabel?: string;
         cancellabel?: string;
+        hostkey?: HostKeyPrompt;
     };
 
     // userinput.UserInputResponse
@@ -1146,6 +2672,7 @@ declare global {
         filename: string;
         fileop: string;
         data64: string;
+        offset?: number;
     };
 
     // webcmd.WSRpcCommand
@@ -1211,6 +2738,7 @@ declare global {
         scopes?: string[];
         sender?: string;
         persist?: number;
+        seq?: number;
         data?: any;
     };
 
@@ -1223,6 +2751,8 @@ declare global {
         size: number;
         modts: number;
         meta: {[key: string]: any};
+        numlines?: number;
+        trimoffset?: number;
     };
 
     // wshrpc.WaveInfoData
@@ -1333,6 +2863,38 @@ declare global {
         inner?: boolean;
     };
 
+    // waveobj.Webhook
+    type Webhook = WaveObj & {
+        name?: string;
+        url: string;
+        filters: WebhookFilter[];
+        disabled?: boolean;
+    };
+
+    // wshrpc.WebhookCreateRtnData
+    type WebhookCreateRtnData = {
+        webhook: Webhook;
+        secret: string;
+    };
+
+    // waveobj.WebhookDelivery
+    type WebhookDelivery = WaveObj & {
+        webhookid: string;
+        event: string;
+        ts: number;
+        payload: string;
+        status: string;
+        attempts: number;
+        statuscode?: number;
+        error?: string;
+    };
+
+    // waveobj.WebhookFilter
+    type WebhookFilter = {
+        event: string;
+        nonzeroexit?: boolean;
+    };
+
     // wconfig.WidgetConfigType
     type WidgetConfigType = {
         "display:order"?: number;
@@ -1378,6 +2940,23 @@ declare global {
         commandtype: string;
     };
 
+    // wshrpc.WslDistroInfo
+    type WslDistroInfo = {
+        name: string;
+        connname: string;
+        default?: boolean;
+        state: string;
+        version?: number;
+        shellpath?: string;
+        status: ConnStatus;
+    };
+
+    // wshrpc.WslPathResult
+    type WslPathResult = {
+        path: string;
+        distro?: string;
+    };
+
 }
 
 export {}
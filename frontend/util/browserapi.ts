@@ -0,0 +1,141 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// When the frontend is served to a browser by wavesrv's remote-access server there is no electron preload, so
+// this installs a stand-in for window.api.  The init opts come from /remote/init (the server picks the window
+// and tab to show), switching tabs or workspaces reloads the page with the new tab/workspace in the url, and
+// the window-management calls that only make sense in electron are no-ops.  It must be imported before
+// anything that calls getApi().
+
+type RemoteInitData = {
+    initopts: WaveInitOpts;
+    workspaceid: string;
+    workspaces: { workspaceid: string; name: string; open: boolean }[];
+    username: string;
+    hostname: string;
+    configdir: string;
+    datadir: string;
+    version: string;
+    perms: string[]; // what this session may do (share links can be view only)
+};
+
+let remoteInitData: RemoteInitData = null;
+let waveInitCallback: (initOpts: WaveInitOpts) => void = null;
+
+export function isRemoteBrowser(): boolean {
+    return !!(globalThis as any).waveRemoteBrowser;
+}
+
+// the keybindings follow the machine the browser runs on, not the host
+function getBrowserPlatform(): NodeJS.Platform {
+    const platform = navigator.platform.toLowerCase();
+    if (platform.startsWith("mac")) {
+        return "darwin";
+    }
+    if (platform.startsWith("win")) {
+        return "win32";
+    }
+    return "linux";
+}
+
+function navigateTo(params: Record<string, string>) {
+    window.location.search = "?" + new URLSearchParams(params).toString();
+}
+
+async function fetchRemoteInit() {
+    const resp = await fetch("/remote/init" + window.location.search);
+    if (resp.status == 401) {
+        window.location.href = "/login";
+        return;
+    }
+    const rtn = await resp.json();
+    if (!rtn.success) {
+        document.body.textContent = "Cannot open Wave: " + rtn.error;
+        document.body.style.visibility = null;
+        document.body.style.opacity = null;
+        return;
+    }
+    remoteInitData = rtn.data;
+    document.title = `Wave Terminal (${remoteInitData.hostname})`;
+    waveInitCallback?.(remoteInitData.initopts);
+}
+
+async function getWorkspaceService() {
+    const { WorkspaceService } = await import("@/app/store/services");
+    return WorkspaceService;
+}
+
+const noop = () => {};
+
+function makeBrowserApi(): ElectronApi {
+    return {
+        getIsDev: () => false,
+        getCursorPoint: () => ({ x: 0, y: 0 }),
+        getPlatform: getBrowserPlatform,
+        getEnv: () => null,
+        getUserName: () => remoteInitData?.username,
+        getHostName: () => remoteInitData?.hostname,
+        getDataDir: () => remoteInitData?.datadir,
+        getConfigDir: () => remoteInitData?.configdir,
+        getWebviewPreload: () => null,
+        getAboutModalDetails: () => ({ version: remoteInitData?.version, buildTime: 0 }),
+        getDocsiteUrl: () => "https://docs.waveterm.dev/",
+        showContextMenu: noop,
+        onContextMenuClick: noop,
+        onNavigate: noop,
+        onIframeNavigate: noop,
+        downloadFile: noop,
+        openExternal: (url: string) => {
+            window.open(url, "_blank", "noopener,noreferrer");
+        },
+        onFullScreenChange: noop,
+        onUpdaterStatusChange: noop,
+        getUpdaterStatus: () => "up-to-date",
+        getUpdaterChannel: () => "latest",
+        installAppUpdate: noop,
+        onMenuItemAbout: noop,
+        updateWindowControlsOverlay: noop,
+        onReinjectKey: noop,
+        setWebviewFocus: noop,
+        registerGlobalWebviewKeys: noop,
+        onControlShiftStateUpdate: noop,
+        createWorkspace: noop,
+        switchWorkspace: (workspaceId: string) => navigateTo({ workspaceid: workspaceId }),
+        deleteWorkspace: noop,
+        setActiveTab: (tabId: string) => navigateTo({ tabid: tabId }),
+        createTab: () => {
+            getWorkspaceService()
+                .then((svc) => svc.CreateTab(remoteInitData?.workspaceid, null, false, false))
+                .then((tabId) => navigateTo({ tabid: tabId }))
+                .catch((e) => console.error("error creating tab", e));
+        },
+        closeTab: (workspaceId: string, tabId: string) => {
+            getWorkspaceService()
+                .then((svc) => svc.CloseTab(workspaceId, tabId, false))
+                .then((rtn) => {
+                    if (tabId == remoteInitData?.initopts?.tabId) {
+                        navigateTo(rtn?.newactivetabid ? { tabid: rtn.newactivetabid } : {});
+                    }
+                })
+                .catch((e) => console.error("error closing tab", e));
+        },
+        setWindowInitStatus: (status: "ready" | "wave-ready") => {
+            if (status == "ready") {
+                fetchRemoteInit().catch((e) => console.error("error initializing remote session", e));
+            }
+        },
+        onWaveInit: (callback: (initOpts: WaveInitOpts) => void) => {
+            waveInitCallback = callback;
+        },
+        sendLog: (log: string) => console.log(log),
+        onQuicklook: noop,
+        openNativePath: noop,
+        captureScreenshot: () => Promise.resolve(null),
+        setKeyboardChordMode: noop,
+    };
+}
+
+if (globalThis.window != null && (window as any).api == null) {
+    (globalThis as any).waveRemoteBrowser = true;
+    (window as any).api = makeBrowserApi();
+}
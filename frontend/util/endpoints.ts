@@ -1,12 +1,18 @@
 // Copyright 2025, Command Line Inc.
 // SPDX-License-Identifier: Apache-2.0
 
+import { isRemoteBrowser } from "./browserapi";
 import { getEnv } from "./getenv";
 import { lazy } from "./util";
 
 export const WebServerEndpointVarName = "WAVE_SERVER_WEB_ENDPOINT";
 export const WSServerEndpointVarName = "WAVE_SERVER_WS_ENDPOINT";
 
-export const getWebServerEndpoint = lazy(() => `http://${getEnv(WebServerEndpointVarName)}`);
+// in a browser (remote access) everything is served from the page's origin
+export const getWebServerEndpoint = lazy(() =>
+    isRemoteBrowser() ? window.location.origin : `http://${getEnv(WebServerEndpointVarName)}`
+);
 
-export const getWSServerEndpoint = lazy(() => `ws://${getEnv(WSServerEndpointVarName)}`);
+export const getWSServerEndpoint = lazy(() =>
+    isRemoteBrowser() ? `wss://${window.location.host}` : `ws://${getEnv(WSServerEndpointVarName)}`
+);
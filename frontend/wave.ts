@@ -1,9 +1,11 @@
 // Copyright 2025, Command Line Inc.
 // SPDX-License-Identifier: Apache-2.0
 
+import "@/util/browserapi"; // must be first, installs window.api in a browser
 import { App } from "@/app/app";
 import {
     globalRefocus,
+    loadKeyBindings,
     registerControlShiftStateUpdateHandler,
     registerElectronReinjectKeyHandler,
     registerGlobalKeys,
@@ -22,6 +24,8 @@ import {
     initGlobal,
     initGlobalWaveEventSubs,
     loadConnStatus,
+    loadNotifications,
+    loadSettingsOverrides,
     pushFlashError,
     pushNotification,
     removeNotificationById,
@@ -30,6 +34,7 @@ import {
 import * as WOS from "@/store/wos";
 import { loadFonts } from "@/util/fontutil";
 import { setKeyUtilPlatform } from "@/util/keyutil";
+import { fireAndForget } from "@/util/util";
 import { createElement } from "react";
 import { createRoot } from "react-dom/client";
 
@@ -102,6 +107,8 @@ async function reinitWave() {
     const initialTab = await WOS.reloadWaveObject<Tab>(WOS.makeORef("tab", savedInitOpts.tabId));
     await WOS.reloadWaveObject<LayoutState>(WOS.makeORef("layout", initialTab.layoutstate));
     reloadAllWorkspaceTabs(ws);
+    await loadSettingsOverrides();
+    fireAndForget(loadKeyBindings);
     document.title = `Wave Terminal - ${initialTab.name}`; // TODO update with tab name change
     getApi().setWindowInitStatus("wave-ready");
     globalStore.set(atoms.reinitVersion, globalStore.get(atoms.reinitVersion) + 1);
@@ -163,6 +170,7 @@ async function initWave(initOpts: WaveInitOpts) {
     (window as any).TabRpcClient = TabRpcClient;
     await loadConnStatus();
     initGlobalWaveEventSubs(initOpts);
+    fireAndForget(loadNotifications);
     subscribeToConnEvents();
 
     // ensures client/window/workspace are loaded into the cache before rendering
@@ -187,6 +195,7 @@ async function initWave(initOpts: WaveInitOpts) {
     const fullConfig = await RpcApi.GetFullConfigCommand(TabRpcClient);
     console.log("fullconfig", fullConfig);
     globalStore.set(atoms.fullConfigAtom, fullConfig);
+    await loadSettingsOverrides();
     console.log("Wave First Render");
     let firstRenderResolveFn: () => void = null;
     let firstRenderPromise = new Promise<void>((resolve) => {
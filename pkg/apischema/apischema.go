@@ -0,0 +1,318 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package apischema describes wave's apis as an OpenAPI 3.1 document generated from the go types: the automation
+// api operations (see pkg/web/restapi.go), the service methods (pkg/service, as "x-wave-services"), and the wave
+// object types.  the document is served by the automation api (GET /api/v1/openapi.json), written to
+// schema/api.json by cmd/generateschema, and the typescript client for the automation api is generated from it
+// (cmd/generatets), so neither has to be kept in sync with the go code by hand.
+package apischema
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/invopop/jsonschema"
+	"github.com/wavetermdev/waveterm/pkg/tsgen/tsgenmeta"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+const OpenApiVersion = "3.1.0"
+const SchemaRefPrefix = "#/components/schemas/"
+const ErrorSchemaName = "ApiError"
+const BearerAuthName = "bearerAuth"
+
+var contextRType = reflect.TypeOf((*context.Context)(nil)).Elem()
+var errorRType = reflect.TypeOf((*error)(nil)).Elem()
+var uiContextRType = reflect.TypeOf(waveobj.UIContext{})
+var updatesRtnRType = reflect.TypeOf(waveobj.UpdatesRtnType{})
+var methodMetaRType = reflect.TypeOf(tsgenmeta.MethodMeta{})
+var orefRType = reflect.TypeOf(waveobj.ORef{})
+
+type Document struct {
+	OpenApi    string                               `json:"openapi"`
+	Info       Info                                 `json:"info"`
+	Servers    []Server                             `json:"servers,omitempty"`
+	Security   []map[string][]string                `json:"security,omitempty"`
+	Paths      map[string]map[string]*PathOperation `json:"paths"`
+	Components Components                           `json:"components"`
+	Services   map[string]*ServiceMethod            `json:"x-wave-services,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	Url string `json:"url"`
+}
+
+type Components struct {
+	Schemas         map[string]*jsonschema.Schema `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme    `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type PathOperation struct {
+	OperationId string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string             `json:"name"`
+	In       string             `json:"in"` // "path" or "query"
+	Required bool               `json:"required,omitempty"`
+	Schema   *jsonschema.Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *jsonschema.Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// a service method, called with POST /wave/service ({"service", "method", "args"}, see pkg/service)
+type ServiceMethod struct {
+	Description string               `json:"description,omitempty"`
+	ArgNames    []string             `json:"argnames"`
+	Args        []*jsonschema.Schema `json:"args"`
+	Result      *jsonschema.Schema   `json:"result,omitempty"`
+	Updates     bool                 `json:"updates,omitempty"` // also returns wave object updates
+}
+
+// an http operation, the response is {"data": [result]} or {"error": "..."}
+type Operation struct {
+	Name       string // the operationId
+	HttpMethod string
+	Path       string // with {id} style path params
+	Summary    string
+	Query      []string     // optional query params (strings)
+	Body       reflect.Type // nil if there is no body
+	Result     reflect.Type // nil if the result is null
+}
+
+type Generator struct {
+	reflector *jsonschema.Reflector
+	doc       *Document
+	typeNames map[string]reflect.Type // to qualify the names of different types with the same name
+}
+
+func MakeGenerator(info Info) *Generator {
+	gen := &Generator{typeNames: make(map[string]reflect.Type)}
+	gen.doc = &Document{
+		OpenApi: OpenApiVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*PathOperation),
+		Components: Components{
+			Schemas: map[string]*jsonschema.Schema{
+				ErrorSchemaName: makeObjectSchema("error", &jsonschema.Schema{Type: "string"}),
+			},
+		},
+	}
+	gen.reflector = &jsonschema.Reflector{
+		Anonymous:                 true,
+		AllowAdditionalProperties: true,
+		Namer:                     gen.typeName,
+		Mapper:                    mapType,
+	}
+	return gen
+}
+
+// an object with one (required) property
+func makeObjectSchema(propName string, propSchema *jsonschema.Schema) *jsonschema.Schema {
+	props := jsonschema.NewProperties()
+	props.Set(propName, propSchema)
+	return &jsonschema.Schema{Type: "object", Properties: props, Required: []string{propName}}
+}
+
+// types with a custom json encoding
+func mapType(rtype reflect.Type) *jsonschema.Schema {
+	if rtype == orefRType {
+		return &jsonschema.Schema{Type: "string", Description: "[otype]:[oid]"}
+	}
+	return nil
+}
+
+func (gen *Generator) typeName(rtype reflect.Type) string {
+	name := rtype.Name()
+	if name == "" || strings.Contains(name, "[") {
+		return ""
+	}
+	// unexported types (e.g. the automation api's request bodies) are still exported in the schema
+	name = string(unicode.ToUpper(rune(name[0]))) + name[1:]
+	if prevType, found := gen.typeNames[name]; found && prevType != rtype {
+		return path.Base(rtype.PkgPath()) + "." + name
+	}
+	gen.typeNames[name] = rtype
+	return name
+}
+
+// returns the schema for the type (a $ref for named types), the named types are added to the components
+func (gen *Generator) TypeSchema(rtype reflect.Type) *jsonschema.Schema {
+	root := gen.reflector.ReflectFromType(rtype)
+	for name, schema := range root.Definitions {
+		rewriteRefs(schema)
+		gen.doc.Components.Schemas[name] = schema
+	}
+	root.Definitions = nil
+	root.Version = ""
+	rewriteRefs(root)
+	return root
+}
+
+func rewriteRefs(schema *jsonschema.Schema) {
+	if schema == nil {
+		return
+	}
+	if name, ok := strings.CutPrefix(schema.Ref, "#/$defs/"); ok {
+		schema.Ref = SchemaRefPrefix + name
+	}
+	if schema.Properties != nil {
+		for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			rewriteRefs(pair.Value)
+		}
+	}
+	for _, sub := range schema.PatternProperties {
+		rewriteRefs(sub)
+	}
+	rewriteRefs(schema.Items)
+	rewriteRefs(schema.AdditionalProperties)
+	for _, subList := range [][]*jsonschema.Schema{schema.PrefixItems, schema.AllOf, schema.AnyOf, schema.OneOf} {
+		for _, sub := range subList {
+			rewriteRefs(sub)
+		}
+	}
+}
+
+func (gen *Generator) AddTypes(rtypes ...reflect.Type) {
+	for _, rtype := range rtypes {
+		gen.TypeSchema(rtype)
+	}
+}
+
+func jsonContent(schema *jsonschema.Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// the operations are authenticated with a bearer token
+func (gen *Generator) AddOperations(ops []Operation) error {
+	gen.doc.Components.SecuritySchemes = map[string]*SecurityScheme{BearerAuthName: {Type: "http", Scheme: "bearer"}}
+	gen.doc.Security = []map[string][]string{{BearerAuthName: {}}}
+	errorResponse := &Response{Description: "error", Content: jsonContent(&jsonschema.Schema{Ref: SchemaRefPrefix + ErrorSchemaName})}
+	for _, op := range ops {
+		httpMethod := strings.ToLower(op.HttpMethod)
+		if gen.doc.Paths[op.Path] == nil {
+			gen.doc.Paths[op.Path] = make(map[string]*PathOperation)
+		}
+		if gen.doc.Paths[op.Path][httpMethod] != nil {
+			return fmt.Errorf("duplicate operation %s %s", op.HttpMethod, op.Path)
+		}
+		pathOp := &PathOperation{OperationId: op.Name, Summary: op.Summary}
+		for _, part := range strings.Split(op.Path, "/") {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				pathOp.Parameters = append(pathOp.Parameters, &Parameter{Name: part[1 : len(part)-1], In: "path", Required: true, Schema: &jsonschema.Schema{Type: "string"}})
+			}
+		}
+		for _, name := range op.Query {
+			pathOp.Parameters = append(pathOp.Parameters, &Parameter{Name: name, In: "query", Schema: &jsonschema.Schema{Type: "string"}})
+		}
+		if op.Body != nil {
+			pathOp.RequestBody = &RequestBody{Content: jsonContent(gen.TypeSchema(op.Body))}
+		}
+		resultSchema := &jsonschema.Schema{Type: "null"}
+		if op.Result != nil {
+			resultSchema = gen.TypeSchema(op.Result)
+		}
+		pathOp.Responses = map[string]*Response{
+			"200": {
+				Description: "ok",
+				Content:     jsonContent(makeObjectSchema("data", resultSchema)),
+			},
+			"default": errorResponse,
+		}
+		gen.doc.Paths[op.Path][httpMethod] = pathOp
+	}
+	return nil
+}
+
+// adds the exported methods of the services (keyed by "[service].[method]"), the context and ui context args
+// are not part of the call
+func (gen *Generator) AddServices(serviceMap map[string]any) {
+	if gen.doc.Services == nil {
+		gen.doc.Services = make(map[string]*ServiceMethod)
+	}
+	serviceNames := make([]string, 0, len(serviceMap))
+	for name := range serviceMap {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, serviceName := range serviceNames {
+		serviceObj := serviceMap[serviceName]
+		serviceType := reflect.TypeOf(serviceObj)
+		for midx := 0; midx < serviceType.NumMethod(); midx++ {
+			method := serviceType.Method(midx)
+			if strings.HasSuffix(method.Name, "_Meta") {
+				continue
+			}
+			var meta tsgenmeta.MethodMeta
+			if metaMethod, found := serviceType.MethodByName(method.Name + "_Meta"); found && metaMethod.Type.NumOut() == 1 && metaMethod.Type.Out(0) == methodMetaRType {
+				meta = metaMethod.Func.Call([]reflect.Value{reflect.ValueOf(serviceObj)})[0].Interface().(tsgenmeta.MethodMeta)
+			}
+			svcMethod := &ServiceMethod{Description: meta.Desc, ArgNames: []string{}, Args: []*jsonschema.Schema{}}
+			// the first arg is the receiver
+			for idx := 1; idx < method.Type.NumIn(); idx++ {
+				inType := method.Type.In(idx)
+				if inType == contextRType || inType == uiContextRType {
+					continue
+				}
+				argName := fmt.Sprintf("arg%d", idx)
+				if idx-1 < len(meta.ArgNames) {
+					argName = meta.ArgNames[idx-1]
+				}
+				svcMethod.ArgNames = append(svcMethod.ArgNames, argName)
+				svcMethod.Args = append(svcMethod.Args, gen.TypeSchema(inType))
+			}
+			for idx := 0; idx < method.Type.NumOut(); idx++ {
+				outType := method.Type.Out(idx)
+				if outType == errorRType {
+					continue
+				}
+				if outType == updatesRtnRType {
+					svcMethod.Updates = true
+					continue
+				}
+				svcMethod.Result = gen.TypeSchema(outType)
+			}
+			if svcMethod.Description == "" && meta.ReturnDesc != "" {
+				svcMethod.Description = "returns " + meta.ReturnDesc
+			}
+			gen.doc.Services[serviceName+"."+method.Name] = svcMethod
+		}
+	}
+}
+
+func (gen *Generator) Document() *Document {
+	return gen.doc
+}
@@ -0,0 +1,78 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package apischema
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/tsgen/tsgenmeta"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+type testItem struct {
+	Name  string              `json:"name"`
+	Child *testItem           `json:"child,omitempty"`
+	ORef  waveobj.ORef        `json:"oref"`
+	Meta  waveobj.MetaMapType `json:"meta,omitempty"`
+}
+
+type testService struct{}
+
+func (*testService) GetItem_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{ArgNames: []string{"ctx", "uiContext", "name"}}
+}
+
+func (*testService) GetItem(ctx context.Context, uiContext waveobj.UIContext, name string) (*testItem, waveobj.UpdatesRtnType, error) {
+	return nil, nil, nil
+}
+
+func TestGenerate(t *testing.T) {
+	gen := MakeGenerator(Info{Title: "test", Version: "v1"})
+	err := gen.AddOperations([]Operation{
+		{Name: "items.list", HttpMethod: "GET", Path: "/items", Result: reflect.TypeOf([]*testItem{})},
+		{Name: "items.update", HttpMethod: "PATCH", Path: "/items/{id}", Body: reflect.TypeOf(testItem{}), Query: []string{"force"}},
+	})
+	if err != nil {
+		t.Fatalf("error adding operations: %v", err)
+	}
+	gen.AddServices(map[string]any{"test": &testService{}})
+	doc := gen.Document()
+
+	if doc.Components.Schemas["TestItem"] == nil {
+		t.Fatalf("expected a TestItem schema, got %v", doc.Components.Schemas)
+	}
+	barr, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("error marshaling the document: %v", err)
+	}
+	if strings.Contains(string(barr), "$defs") {
+		t.Errorf("the refs should point to the components: %s", barr)
+	}
+	updateOp := doc.Paths["/items/{id}"]["patch"]
+	if updateOp == nil || len(updateOp.Parameters) != 2 || updateOp.Parameters[0].In != "path" || updateOp.Parameters[1].Name != "force" {
+		t.Fatalf("expected the id and force params, got %+v", updateOp)
+	}
+	if updateOp.RequestBody.Content["application/json"].Schema.Ref != SchemaRefPrefix+"TestItem" {
+		t.Errorf("expected the body to reference TestItem")
+	}
+	orefSchema, _ := doc.Components.Schemas["TestItem"].Properties.Get("oref")
+	if orefSchema == nil || orefSchema.Type != "string" {
+		t.Errorf("expected orefs to be strings, got %+v", orefSchema)
+	}
+	svcMethod := doc.Services["test.GetItem"]
+	if svcMethod == nil {
+		t.Fatalf("expected the test.GetItem service method")
+	}
+	if !reflect.DeepEqual(svcMethod.ArgNames, []string{"name"}) || !svcMethod.Updates || svcMethod.Result.Ref != SchemaRefPrefix+"TestItem" {
+		t.Errorf("unexpected service method %+v", svcMethod)
+	}
+	err = gen.AddOperations([]Operation{{Name: "items.list2", HttpMethod: "GET", Path: "/items"}})
+	if err == nil {
+		t.Errorf("expected an error for a duplicate operation")
+	}
+}
@@ -19,7 +19,8 @@ func ValidateIncomingRequest(r *http.Request) error {
 	if reqAuthKey == "" {
 		return fmt.Errorf("no x-authkey header")
 	}
-	if reqAuthKey != GetAuthKey() {
+	// the auth key (electron), or one of the tabs' client tokens
+	if !tokenEquals(reqAuthKey, GetAuthKey()) && getClientTokenTab(reqAuthKey) == "" {
 		return fmt.Errorf("x-authkey header is invalid")
 	}
 	return nil
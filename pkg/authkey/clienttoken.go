@@ -0,0 +1,105 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package authkey
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// each tab's frontend gets its own client token (issued by electron when the tab's view is created, through
+// the authtokenissue rpc).  the token is sent in the x-authkey header instead of the auth key, so the auth key
+// itself never reaches a renderer.  a tab token only opens the websocket for its own tab.  electron rotates
+// the tokens periodically, the previous token stays valid for a short grace period so requests that are in
+// flight during a rotation do not fail.  tokens are revoked when the tab's view is destroyed.
+
+const ClientTokenGracePeriod = time.Minute
+
+type clientToken struct {
+	token     string
+	prevToken string
+	prevExp   time.Time
+}
+
+var clientTokenLock = &sync.Mutex{}
+var clientTokens = make(map[string]*clientToken) // tabid => token
+
+func makeToken() string {
+	barr := make([]byte, 32)
+	_, err := rand.Read(barr)
+	if err != nil {
+		// crypto/rand does not fail on the supported platforms
+		panic(fmt.Sprintf("cannot generate client token: %v", err))
+	}
+	return hex.EncodeToString(barr)
+}
+
+func tokenEquals(a string, b string) bool {
+	return a != "" && subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// issues a token for the tab, or rotates its token if it already has one
+func IssueClientToken(tabId string) string {
+	clientTokenLock.Lock()
+	defer clientTokenLock.Unlock()
+	ct := clientTokens[tabId]
+	if ct == nil {
+		ct = &clientToken{}
+		clientTokens[tabId] = ct
+	} else {
+		ct.prevToken = ct.token
+		ct.prevExp = time.Now().Add(ClientTokenGracePeriod)
+	}
+	ct.token = makeToken()
+	return ct.token
+}
+
+func RevokeClientToken(tabId string) {
+	clientTokenLock.Lock()
+	defer clientTokenLock.Unlock()
+	delete(clientTokens, tabId)
+}
+
+func (ct *clientToken) matches(token string) bool {
+	if tokenEquals(token, ct.token) {
+		return true
+	}
+	return time.Now().Before(ct.prevExp) && tokenEquals(token, ct.prevToken)
+}
+
+// returns the tab the token was issued to, or "" if it is not a valid token
+func getClientTokenTab(token string) string {
+	clientTokenLock.Lock()
+	defer clientTokenLock.Unlock()
+	for tabId, ct := range clientTokens {
+		if ct.matches(token) {
+			return tabId
+		}
+	}
+	return ""
+}
+
+// validates the websocket request for the tab (tabid "electron" is the electron main process, which must use
+// the auth key)
+func ValidateTabRequest(r *http.Request, tabId string) error {
+	reqAuthKey := r.Header.Get(AuthKeyHeader)
+	if reqAuthKey == "" {
+		return fmt.Errorf("no x-authkey header")
+	}
+	if tabId == "electron" {
+		if !tokenEquals(reqAuthKey, GetAuthKey()) {
+			return fmt.Errorf("x-authkey header is invalid")
+		}
+		return nil
+	}
+	if getClientTokenTab(reqAuthKey) != tabId {
+		return fmt.Errorf("x-authkey header is invalid for tab %s", tabId)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// full screen applications (vim, htop, less, etc.) switch to the alternate screen.  whether a block is on the
+// alternate screen is tracked from the pty output and written to the term file's meta, along with where the
+// switch happened.  when the terminal reloads it replays the normal screen up to the switch and then the
+// output since, rather than drawing the application's output over the scrollback (the replay normally starts
+// at the scrollback start, which moves past the switch while the application runs).  the application is also
+// made to redraw (see forceRedraw) when the terminal resyncs with a running process, since the output that
+// is replayed only updates parts of the screen.
+//
+// a process that exits without leaving the alternate screen (killed, or the connection dropped) is switched
+// back to the normal screen when the block is restarted (see resetTerminalState).
+
+const (
+	FileMeta_AltScreen           = "altscreen"           // term file offset just after the switch to the alternate screen
+	FileMeta_AltScreenScrollback = "altscreenscrollback" // scrollback start (for the normal screen) at the switch
+
+	altScreenOff     = "\x1b[?1049l"
+	altScreenReset   = "\x1bc" // RIS (full reset) also leaves the alternate screen
+	altScreenMaxSeq  = len("\x1b[?1049h")
+	RedrawNudgeDelay = 50 * time.Millisecond
+)
+
+// 1049 is what applications use now, 47 and 1047 are the older versions (which do not save the cursor)
+var altScreenOnSeqs = [][]byte{[]byte("\x1b[?1049h"), []byte("\x1b[?1047h"), []byte("\x1b[?47h")}
+var altScreenOffSeqs = [][]byte{[]byte(altScreenOff), []byte("\x1b[?1047l"), []byte("\x1b[?47l"), []byte(altScreenReset)}
+
+// watches the pty output for the application switching to and from the alternate screen.  chunks must be
+// written after they are appended to the term file.
+type altScreenTracker struct {
+	bc   *BlockController
+	tail []byte // the end of the previous chunk, for a sequence split across reads
+}
+
+// the state is kept from the file meta, so it carries over when reattaching to a persistent session
+func (bc *BlockController) makeAltScreenTracker() *altScreenTracker {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	wfile, err := filestore.WFS.Stat(ctx, bc.BlockId, wavebase.BlockFile_Term)
+	bc.altScreen.Store(err == nil && wfile.Meta[FileMeta_AltScreen] != nil)
+	return &altScreenTracker{bc: bc}
+}
+
+func (at *altScreenTracker) Write(chunk []byte) {
+	maxTail := altScreenMaxSeq - 1
+	seam := append(at.tail, chunk[:min(len(chunk), maxTail)]...)
+	at.update(seam, int64(len(at.tail)+len(chunk)))
+	at.update(chunk, int64(len(chunk)))
+	if len(chunk) >= maxTail {
+		at.tail = append(at.tail[:0], chunk[len(chunk)-maxTail:]...)
+	} else {
+		at.tail = append(at.tail[:0], seam[max(0, len(seam)-maxTail):]...)
+	}
+}
+
+// returns where the last of seqs found in data ends, -1 if there are none
+func lastSeqEnd(data []byte, seqs [][]byte) int {
+	rtn := -1
+	for _, seq := range seqs {
+		if idx := bytes.LastIndex(data, seq); idx >= 0 {
+			rtn = max(rtn, idx+len(seq))
+		}
+	}
+	return rtn
+}
+
+// dataEnd is the number of bytes from the start of data to the end of the chunk
+func (at *altScreenTracker) update(data []byte, dataEnd int64) {
+	onEnd := lastSeqEnd(data, altScreenOnSeqs)
+	offEnd := lastSeqEnd(data, altScreenOffSeqs)
+	if onEnd == offEnd {
+		return
+	}
+	on := onEnd > offEnd
+	if on == at.bc.altScreen.Load() {
+		return
+	}
+	at.bc.setAltScreen(on, dataEnd-int64(max(onEnd, offEnd)))
+}
+
+// bytesAfter is the amount of output (already in the term file) after the switch
+func (bc *BlockController) setAltScreen(on bool, bytesAfter int64) {
+	bc.altScreen.Store(on)
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	meta := wshrpc.FileMeta{FileMeta_AltScreen: nil, FileMeta_AltScreenScrollback: nil}
+	if on {
+		wfile, err := filestore.WFS.Stat(ctx, bc.BlockId, wavebase.BlockFile_Term)
+		if err != nil {
+			log.Printf("error getting term file for block %s: %v\n", bc.BlockId, err)
+			return
+		}
+		altStart := wfile.Size - bytesAfter
+		scrollbackStart := int64(getFileMetaNum(wfile.Meta, FileMeta_ScrollbackStart))
+		if st := scrollbackTrackers.Get(bc.BlockId); st != nil {
+			scrollbackStart = st.startOffset()
+		}
+		meta = wshrpc.FileMeta{FileMeta_AltScreen: altStart, FileMeta_AltScreenScrollback: min(scrollbackStart, altStart)}
+	}
+	err := filestore.WFS.WriteMeta(ctx, bc.BlockId, wavebase.BlockFile_Term, meta, true)
+	if err != nil {
+		log.Printf("error writing alt screen state for block %s: %v\n", bc.BlockId, err)
+	}
+	bc.UpdateControllerAndSendUpdate(func() bool { return true })
+}
+
+// returns the output that switches the terminal back to the normal screen if the last process left it on the
+// alternate screen (and clears the state)
+func clearAltScreen(ctx context.Context, blockId string, meta wshrpc.FileMeta) string {
+	if meta[FileMeta_AltScreen] == nil {
+		return ""
+	}
+	err := filestore.WFS.WriteMeta(ctx, blockId, wavebase.BlockFile_Term, wshrpc.FileMeta{FileMeta_AltScreen: nil, FileMeta_AltScreenScrollback: nil}, true)
+	if err != nil {
+		log.Printf("error clearing alt screen state for block %s: %v\n", blockId, err)
+	}
+	return altScreenOff
+}
+
+func (bc *BlockController) redrawIfAltScreen(termSize waveobj.TermSize) {
+	if bc.altScreen.Load() {
+		go bc.forceRedraw(termSize)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/waction"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	ActionId_RestartController = "block:restartcontroller"
+	ActionId_ClearTerm         = "term:clear"
+)
+
+func init() {
+	waction.MustRegisterAction(wshrpc.ActionDef{
+		ActionId:    ActionId_RestartController,
+		Title:       "Restart Session",
+		Icon:        "refresh",
+		OTypes:      []string{waveobj.OType_Block},
+		Controllers: []string{BlockController_Shell, BlockController_Cmd},
+		Order:       10,
+	}, restartControllerAction)
+	waction.MustRegisterAction(wshrpc.ActionDef{
+		ActionId: ActionId_ClearTerm,
+		Title:    "Clear Terminal",
+		Icon:     "eraser",
+		OTypes:   []string{waveobj.OType_Block},
+		Views:    []string{"term"},
+		Order:    20,
+	}, clearTermAction)
+}
+
+func restartControllerAction(ctx context.Context, data wshrpc.CommandExecuteActionData) error {
+	tabId := data.TabId
+	if tabId == "" {
+		var err error
+		tabId, err = wstore.DBFindTabForBlockId(ctx, data.ORef.OID)
+		if err != nil {
+			return fmt.Errorf("error finding tab for block: %w", err)
+		}
+	}
+	return ResyncController(ctx, tabId, data.ORef.OID, nil, true)
+}
+
+func clearTermAction(ctx context.Context, data wshrpc.CommandExecuteActionData) error {
+	return HandleTruncateBlockFile(data.ORef.OID)
+}
@@ -6,7 +6,6 @@ package blockcontroller
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"io/fs"
@@ -19,19 +18,24 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/remote"
 	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/secretstore"
 	"github.com/wavetermdev/waveterm/pkg/shellexec"
 	"github.com/wavetermdev/waveterm/pkg/util/envutil"
 	"github.com/wavetermdev/waveterm/pkg/util/fileutil"
 	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/util/sigutil"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wplugin"
 	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wsettings"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
@@ -63,6 +67,7 @@ const (
 )
 
 const DefaultTimeout = 2 * time.Second
+const ProcessCpuSampleTime = 250 * time.Millisecond
 
 var globalLock = &sync.Mutex{}
 var blockControllerMap = make(map[string]*BlockController)
@@ -71,6 +76,7 @@ type BlockInputUnion struct {
 	InputData []byte            `json:"inputdata,omitempty"`
 	SigName   string            `json:"signame,omitempty"`
 	TermSize  *waveobj.TermSize `json:"termsize,omitempty"`
+	noEncode  bool              // wave OSC messages are not transcoded (see term:encoding)
 }
 
 type BlockController struct {
@@ -86,14 +92,36 @@ type BlockController struct {
 	ShellProcExitCode int
 	RunLock           *atomic.Bool
 	StatusVersion     int
+	Resizer           *termResizer
+	stopRequested     bool          // the running process was stopped (not restarted by cmd:restart)
+	restartBackoff    time.Duration // see checkRestartOnExit
+	procStartCount    int
+	bracketedPaste    atomic.Bool // the application has turned on bracketed paste mode, see pasteModeTracker
+	altScreen         atomic.Bool // the application is on the alternate screen, see altScreenTracker
+	mouseReporting    atomic.Bool // the application has turned on mouse reporting, see mouseModeTracker
+	secureInput       atomic.Bool // a password is being read, see secureInputTracker
+	panes             []*termPane // see panes.go
+	activePane        string      // the pane shown in the block, empty for the block's own process
+	nextPaneNum       int
+	cmdQueue          *commandQueue // see cmdqueue.go
+	inputArbiter      inputArbiter  // see inputarbiter.go
+	connFrozen        bool          // the connection is down, see connstate.go
 }
 
 type BlockControllerRuntimeStatus struct {
-	BlockId           string `json:"blockid"`
-	Version           int    `json:"version"`
-	ShellProcStatus   string `json:"shellprocstatus,omitempty"`
-	ShellProcConnName string `json:"shellprocconnname,omitempty"`
-	ShellProcExitCode int    `json:"shellprocexitcode"`
+	BlockId           string                `json:"blockid"`
+	Version           int                   `json:"version"`
+	ShellProcStatus   string                `json:"shellprocstatus,omitempty"`
+	ShellProcConnName string                `json:"shellprocconnname,omitempty"`
+	ShellProcExitCode int                   `json:"shellprocexitcode"`
+	OutputBufferDepth int64                 `json:"outputbufferdepth,omitempty"` // bytes of output not yet written to the terminal
+	OutputPaused      bool                  `json:"outputpaused,omitempty"`      // pty reads are paused waiting for the terminal
+	Recording         bool                  `json:"recording,omitempty"`         // the term output is being recorded (see recording.go)
+	AltScreen         bool                  `json:"altscreen,omitempty"`         // a full screen application is running (see altscreen.go)
+	SecureInput       bool                  `json:"secureinput,omitempty"`       // a password is being read (see secureinput.go)
+	ActivePane        string                `json:"activepane,omitempty"`        // the pane shown in the block (see panes.go)
+	Panes             []wshrpc.TermPaneInfo `json:"panes,omitempty"`
+	ConnFrozen        bool                  `json:"connfrozen,omitempty"` // the connection is degraded or reconnecting (see connstate.go)
 }
 
 func (bc *BlockController) WithLock(f func()) {
@@ -113,7 +141,16 @@ func (bc *BlockController) GetRuntimeStatus() *BlockControllerRuntimeStatus {
 			rtn.ShellProcConnName = bc.ShellProc.ConnName
 		}
 		rtn.ShellProcExitCode = bc.ShellProcExitCode
+		rtn.ActivePane = bc.activePane
+		rtn.Panes = bc.getPaneInfos_nolock()
+		rtn.ConnFrozen = bc.connFrozen
 	})
+	if op := outputPushers.Get(bc.BlockId); op != nil {
+		rtn.OutputBufferDepth, rtn.OutputPaused = op.getStatus()
+	}
+	rtn.Recording = termRecorders.Get(bc.BlockId) != nil
+	rtn.AltScreen = bc.altScreen.Load()
+	rtn.SecureInput = bc.secureInput.Load()
 	return &rtn
 }
 
@@ -129,20 +166,15 @@ type RunShellOpts struct {
 
 func (bc *BlockController) UpdateControllerAndSendUpdate(updateFn func() bool) {
 	var sendUpdate bool
+	var tabId string
 	bc.WithLock(func() {
 		sendUpdate = updateFn()
+		tabId = bc.TabId
 	})
 	if sendUpdate {
 		rtStatus := bc.GetRuntimeStatus()
 		log.Printf("sending blockcontroller update %#v\n", rtStatus)
-		wps.Broker.Publish(wps.WaveEvent{
-			Event: wps.Event_ControllerStatus,
-			Scopes: []string{
-				waveobj.MakeORef(waveobj.OType_Tab, bc.TabId).String(),
-				waveobj.MakeORef(waveobj.OType_Block, bc.BlockId).String(),
-			},
-			Data: rtStatus,
-		})
+		eventbus.Publish(eventbus.ControllerStatusEvent{TabId: tabId, BlockId: bc.BlockId, Status: rtStatus})
 	}
 }
 
@@ -163,15 +195,19 @@ func HandleTruncateBlockFile(blockId string) error {
 	if err != nil {
 		log.Printf("error deleting cache file (continuing): %v\n", err)
 	}
-	wps.Broker.Publish(wps.WaveEvent{
-		Event:  wps.Event_BlockFile,
-		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, blockId).String()},
-		Data: &wps.WSFileEventData{
-			ZoneId:   blockId,
-			FileName: wavebase.BlockFile_Term,
-			FileOp:   wps.FileOp_Truncate,
-		},
-	})
+	deleteTermSegments(ctx, blockId)
+	deleteTermLinks(ctx, blockId)
+	if st := scrollbackTrackers.Get(blockId); st != nil {
+		st.reset(ctx)
+	}
+	if op := outputPushers.Get(blockId); op != nil {
+		op.reset()
+	}
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   blockId,
+		FileName: wavebase.BlockFile_Term,
+		FileOp:   wps.FileOp_Truncate,
+	}})
 	return nil
 
 }
@@ -179,22 +215,23 @@ func HandleTruncateBlockFile(blockId string) error {
 func HandleAppendBlockFile(blockId string, blockFile string, data []byte) error {
 	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
 	defer cancelFn()
-	err := filestore.WFS.AppendData(ctx, blockId, blockFile, data)
+	offset, err := filestore.WFS.Append(ctx, blockId, blockFile, data)
 	if err != nil {
 		return fmt.Errorf("error appending to blockfile: %w", err)
 	}
-	wps.Broker.Publish(wps.WaveEvent{
-		Event: wps.Event_BlockFile,
-		Scopes: []string{
-			waveobj.MakeORef(waveobj.OType_Block, blockId).String(),
-		},
-		Data: &wps.WSFileEventData{
-			ZoneId:   blockId,
-			FileName: blockFile,
-			FileOp:   wps.FileOp_Append,
-			Data64:   base64.StdEncoding.EncodeToString(data),
-		},
-	})
+	if blockFile == wavebase.BlockFile_Term {
+		if st := scrollbackTrackers.Get(blockId); st != nil {
+			st.write()
+		}
+		if tr := termRecorders.Get(blockId); tr != nil {
+			tr.writeOutput(data)
+		}
+		if op := outputPushers.Get(blockId); op != nil {
+			op.add(offset, data)
+			return nil
+		}
+	}
+	publishAppendEvent(blockId, blockFile, offset, data)
 	return nil
 }
 
@@ -208,7 +245,8 @@ func (bc *BlockController) resetTerminalState(logCtx context.Context) {
 	blocklogger.Debugf(logCtx, "[conndebug] resetTerminalState: resetting terminal state\n")
 	// controller type = "shell"
 	var buf bytes.Buffer
-	// buf.WriteString("\x1b[?1049l") // disable alternative buffer
+	// disable alternative buffer (if the last process left it on)
+	buf.WriteString(clearAltScreen(ctx, bc.BlockId, wfile.Meta))
 	buf.WriteString("\x1b[0m")     // reset attributes
 	buf.WriteString("\x1b[?25h")   // show cursor
 	buf.WriteString("\x1b[?1000l") // disable mouse tracking
@@ -300,6 +338,44 @@ func getCustomInitScriptValue(meta waveobj.MetaMapType, connName string, shellTy
 	return "", ""
 }
 
+// for settings that can also be set per connection: block meta (connection override first) > connections.json >
+// settings.  returns "" if the key is not set anywhere.
+func getConnConfigString(blockMeta waveobj.MetaMapType, connName string, key string) string {
+	if connMeta := blockMeta.GetConnectionOverride(connName); connMeta.HasKey(key) {
+		return connMeta.GetString(key, "")
+	}
+	if blockMeta.HasKey(key) {
+		return blockMeta.GetString(key, "")
+	}
+	fullConfig := wconfig.GetWatcher().GetFullConfig()
+	configMaps := []any{fullConfig.Settings}
+	if connName != "" {
+		configMaps = []any{fullConfig.Connections[connName], fullConfig.Settings}
+	}
+	for _, config := range configMaps {
+		configMap := make(map[string]any)
+		err := utilfn.ReUnmarshal(&configMap, config)
+		if err != nil {
+			log.Printf("error re-unmarshalling config: %v\n", err)
+			continue
+		}
+		if val := waveobj.MetaMapType(configMap).GetString(key, ""); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// the cwd of a new shell: cmd:cwd of the block, or else of its connection (connections.json, which can inherit it
+// from a tag profile).  "~" is expanded for local shells, remote shells expand it on the host.
+func getStartCwd(blockMeta waveobj.MetaMapType, connName string) (string, error) {
+	cwd := getConnConfigString(blockMeta, connName, waveobj.MetaKey_CmdCwd)
+	if cwd == "" || connName != "" {
+		return cwd, nil
+	}
+	return wavebase.ExpandHomeDir(cwd)
+}
+
 func resolveEnvMap(blockId string, blockMeta waveobj.MetaMapType, connName string) (map[string]string, error) {
 	rtn := make(map[string]string)
 	config := wconfig.GetWatcher().GetFullConfig()
@@ -308,6 +384,15 @@ func resolveEnvMap(blockId string, blockMeta waveobj.MetaMapType, connName strin
 	for k, v := range ckEnv {
 		rtn[k] = v
 	}
+	// the values are resolved here and only go into the env of the shell
+	for k, secretName := range connKeywords.ConnSecretEnv {
+		secretVal, err := secretstore.Resolve(connName, secretName)
+		if err != nil {
+			log.Printf("cannot set %s from secret %q for connection %q: %v\n", k, secretName, connName, err)
+			continue
+		}
+		rtn[k] = secretVal
+	}
 	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancelFn()
 	_, envFileData, err := filestore.WFS.ReadFile(ctx, blockId, wavebase.BlockFile_Env)
@@ -347,16 +432,17 @@ func createCmdStrAndOpts(blockId string, blockMeta waveobj.MetaMapType, connName
 	var cmdStr string
 	var cmdOpts shellexec.CommandOptsType
 	cmdStr = blockMeta.GetString(waveobj.MetaKey_Cmd, "")
-	if cmdStr == "" {
+	cmdOpts.Argv = getCmdArgv(BlockController_Cmd, blockMeta)
+	if cmdStr == "" && len(cmdOpts.Argv) == 0 {
 		return "", nil, fmt.Errorf("missing cmd in block meta")
 	}
-	cmdOpts.Cwd = blockMeta.GetString(waveobj.MetaKey_CmdCwd, "")
-	if cmdOpts.Cwd != "" {
-		cwdPath, err := wavebase.ExpandHomeDir(cmdOpts.Cwd)
-		if err != nil {
-			return "", nil, err
-		}
-		cmdOpts.Cwd = cwdPath
+	cwd, err := getStartCwd(blockMeta, connName)
+	if err != nil {
+		return "", nil, err
+	}
+	cmdOpts.Cwd = cwd
+	if len(cmdOpts.Argv) > 0 {
+		return "", &cmdOpts, nil
 	}
 	useShell := blockMeta.GetBool(waveobj.MetaKey_CmdShell, true)
 	if !useShell {
@@ -388,6 +474,8 @@ func (bc *BlockController) makeSwapToken(ctx context.Context, logCtx context.Con
 		Exp:   time.Now().Add(5 * time.Minute),
 	}
 	token.Env["TERM_PROGRAM"] = "waveterm"
+	token.Env["TERM_PROGRAM_VERSION"] = wavebase.WaveVersion
+	token.Env["COLORTERM"] = shellutil.DefaultColorTerm
 	token.Env["WAVETERM_BLOCKID"] = bc.BlockId
 	token.Env["WAVETERM_VERSION"] = wavebase.WaveVersion
 	token.Env["WAVETERM"] = "1"
@@ -419,6 +507,14 @@ func (bc *BlockController) makeSwapToken(ctx context.Context, logCtx context.Con
 	for k, v := range envMap {
 		token.Env[k] = v
 	}
+	if termType := getConnConfigString(blockMeta, remoteName, waveobj.MetaKey_TermTermType); termType != "" {
+		// wsh token falls back to xterm-256color if the host does not have the terminfo entry
+		token.Env["TERM"] = termType
+	}
+	if locale := blockMeta.GetString(waveobj.MetaKey_TermLocale, ""); locale != "" {
+		token.Env["LANG"] = locale
+		token.Env["LC_ALL"] = locale
+	}
 	token.ScriptText = getCustomInitScript(logCtx, blockMeta, remoteName, shellType)
 	return token
 }
@@ -434,31 +530,98 @@ type ConnUnion struct {
 	ShellType  string
 }
 
-func getLocalShellPath(blockMeta waveobj.MetaMapType) string {
+// shells that wave has integration scripts for
+func isIntegratedShell(shellPath string) bool {
+	switch shellutil.GetShellTypeFromShellPath(shellPath) {
+	case shellutil.ShellType_bash, shellutil.ShellType_zsh, shellutil.ShellType_fish, shellutil.ShellType_pwsh, shellutil.ShellType_nu:
+		return true
+	}
+	return false
+}
+
+// the shell to start for this block (local or remote), "" for the default.
+// a cmd:argv that is just a shell (e.g. ["nu"]) is started like one, with shell integration.
+func getShellPathOverride(blockMeta waveobj.MetaMapType) string {
+	if shellPath := blockMeta.GetString(waveobj.MetaKey_TermShellPath, ""); shellPath != "" {
+		return shellPath
+	}
+	argv := blockMeta.GetStringList(waveobj.MetaKey_CmdArgv)
+	if len(argv) == 1 && isIntegratedShell(argv[0]) {
+		return argv[0]
+	}
+	return ""
+}
+
+// the command to run directly instead of a shell (or instead of cmd for "cmd" blocks)
+func getCmdArgv(controllerType string, blockMeta waveobj.MetaMapType) []string {
+	argv := blockMeta.GetStringList(waveobj.MetaKey_CmdArgv)
+	if controllerType == BlockController_Shell && len(argv) == 1 && isIntegratedShell(argv[0]) {
+		return nil
+	}
+	return argv
+}
+
+// the settings of the block's workspace (see pkg/wsettings)
+func (bc *BlockController) getSettings() *wconfig.SettingsType {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	settings := wsettings.ResolveForBlock(ctx, bc.BlockId)
+	return &settings
+}
+
+func getLocalShellPath(settings *wconfig.SettingsType, blockMeta waveobj.MetaMapType) string {
+	if shellPath := getShellPathOverride(blockMeta); shellPath != "" {
+		return shellPath
+	}
 	shellPath := blockMeta.GetString(waveobj.MetaKey_TermLocalShellPath, "")
 	if shellPath != "" {
 		return shellPath
 	}
-	settings := wconfig.GetWatcher().GetFullConfig().Settings
 	if settings.TermLocalShellPath != "" {
 		return settings.TermLocalShellPath
 	}
 	return shellutil.DetectLocalShellPath()
 }
 
-func getLocalShellOpts(blockMeta waveobj.MetaMapType) []string {
+func getLocalShellOpts(settings *wconfig.SettingsType, blockMeta waveobj.MetaMapType) []string {
 	if blockMeta.HasKey(waveobj.MetaKey_TermLocalShellOpts) {
 		opts := blockMeta.GetStringList(waveobj.MetaKey_TermLocalShellOpts)
 		return append([]string{}, opts...)
 	}
-	settings := wconfig.GetWatcher().GetFullConfig().Settings
 	if len(settings.TermLocalShellOpts) > 0 {
 		return append([]string{}, settings.TermLocalShellOpts...)
 	}
 	return nil
 }
 
-func (union *ConnUnion) getRemoteInfoAndShellType(blockMeta waveobj.MetaMapType) error {
+// block meta overrides the global setting, shells are started as interactive login shells by default
+func getShellStartupOpts(settings *wconfig.SettingsType, blockMeta waveobj.MetaMapType) (login bool, interactive bool, rcFile string) {
+	login, interactive, rcFile = true, true, settings.TermRcFile
+	if settings.TermLoginShell != nil {
+		login = *settings.TermLoginShell
+	}
+	if settings.TermInteractiveShell != nil {
+		interactive = *settings.TermInteractiveShell
+	}
+	login = blockMeta.GetBool(waveobj.MetaKey_TermLoginShell, login)
+	interactive = blockMeta.GetBool(waveobj.MetaKey_TermInteractiveShell, interactive)
+	if blockMeta.HasKey(waveobj.MetaKey_TermRcFile) {
+		rcFile = blockMeta.GetString(waveobj.MetaKey_TermRcFile, "")
+	}
+	return login, interactive, rcFile
+}
+
+func (union *ConnUnion) getConfigShellPath() string {
+	if union.ConnType == ConnType_Wsl && union.WslConn != nil {
+		return union.WslConn.GetConfigShellPath()
+	}
+	if union.ConnType == ConnType_Ssh && union.SshConn != nil {
+		return union.SshConn.GetConfigShellPath()
+	}
+	return ""
+}
+
+func (union *ConnUnion) getRemoteInfoAndShellType(settings *wconfig.SettingsType, blockMeta waveobj.MetaMapType) error {
 	if !union.WshEnabled {
 		return nil
 	}
@@ -469,10 +632,16 @@ func (union *ConnUnion) getRemoteInfoAndShellType(blockMeta waveobj.MetaMapType)
 			// weird error, could flip the wshEnabled flag and allow it to go forward, but the connection should have already been vetted
 			return fmt.Errorf("unable to obtain remote info from connserver: %w", err)
 		}
-		// TODO allow overriding remote shell path
 		union.ShellPath = remoteInfo.Shell
+		if configShellPath := union.getConfigShellPath(); configShellPath != "" {
+			// conn:shellpath (the per-connection or per-distro shell) comes before the login shell
+			union.ShellPath = configShellPath
+		}
+		if shellPath := getShellPathOverride(blockMeta); shellPath != "" {
+			union.ShellPath = shellPath
+		}
 	} else {
-		union.ShellPath = getLocalShellPath(blockMeta)
+		union.ShellPath = getLocalShellPath(settings, blockMeta)
 	}
 	union.ShellType = shellutil.GetShellTypeFromShellPath(union.ShellPath)
 	return nil
@@ -514,7 +683,7 @@ func (bc *BlockController) getConnUnion(logCtx context.Context, remoteName strin
 		rtn.ConnType = ConnType_Local
 		rtn.WshEnabled = wshEnabled
 	}
-	err := rtn.getRemoteInfoAndShellType(blockMeta)
+	err := rtn.getRemoteInfoAndShellType(bc.getSettings(), blockMeta)
 	if err != nil {
 		return ConnUnion{}, err
 	}
@@ -525,11 +694,21 @@ func (bc *BlockController) setupAndStartShellProcess(logCtx context.Context, rc
 	// create a circular blockfile for the output
 	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancelFn()
-	fsErr := filestore.WFS.MakeFile(ctx, bc.BlockId, wavebase.BlockFile_Term, nil, wshrpc.FileOpts{MaxSize: DefaultTermMaxFileSize, Circular: true})
-	if fsErr != nil && fsErr != fs.ErrExist {
+	settings := bc.getSettings()
+	hasOutput, fsErr := ensureTermFile(ctx, bc.BlockId, getTermMaxFileSize(settings, blockMeta))
+	if fsErr != nil {
 		return nil, fmt.Errorf("error creating blockfile: %w", fsErr)
 	}
-	if fsErr == fs.ErrExist {
+	startScrollbackTracker(ctx, bc.BlockId, getTermScrollbackLines(settings, blockMeta))
+	startOutputPusher(ctx, bc.BlockId)
+	if bc.ControllerType == BlockController_Shell {
+		// the terminal state is still valid for a persistent session, so no reset
+		shellProc, err := bc.reattachPersistentSession(logCtx, blockMeta)
+		if err != nil || shellProc != nil {
+			return shellProc, err
+		}
+	}
+	if hasOutput {
 		// reset the terminal state
 		bc.resetTerminalState(logCtx)
 	}
@@ -547,15 +726,12 @@ func (bc *BlockController) setupAndStartShellProcess(logCtx context.Context, rc
 	var cmdStr string
 	var cmdOpts shellexec.CommandOptsType
 	if bc.ControllerType == BlockController_Shell {
-		cmdOpts.Interactive = true
-		cmdOpts.Login = true
-		cmdOpts.Cwd = blockMeta.GetString(waveobj.MetaKey_CmdCwd, "")
-		if cmdOpts.Cwd != "" {
-			cwdPath, err := wavebase.ExpandHomeDir(cmdOpts.Cwd)
-			if err != nil {
-				return nil, err
-			}
-			cmdOpts.Cwd = cwdPath
+		cmdOpts.Login, cmdOpts.Interactive, cmdOpts.RcFile = getShellStartupOpts(settings, blockMeta)
+		cmdOpts.ShellPath = getShellPathOverride(blockMeta)
+		cmdOpts.Argv = getCmdArgv(bc.ControllerType, blockMeta)
+		cmdOpts.Cwd, err = getStartCwd(blockMeta, remoteName)
+		if err != nil {
+			return nil, err
 		}
 	} else if bc.ControllerType == BlockController_Cmd {
 		var cmdOptsPtr *shellexec.CommandOptsType
@@ -567,6 +743,25 @@ func (bc *BlockController) setupAndStartShellProcess(logCtx context.Context, rc
 	} else {
 		return nil, fmt.Errorf("unknown controller type %q", bc.ControllerType)
 	}
+	shellProc, err := bc.startConnShellProc(ctx, logCtx, rc.TermSize, cmdStr, cmdOpts, blockMeta, remoteName, connUnion, usePersistentSession(bc.ControllerType, connUnion.ConnType))
+	if err != nil {
+		return nil, err
+	}
+	bc.UpdateControllerAndSendUpdate(func() bool {
+		bc.ShellProc = shellProc
+		bc.ShellProcStatus = Status_Running
+		bc.stopRequested = false
+		bc.procStartCount++
+		return true
+	})
+	trackShellProc(bc.BlockId, shellProc)
+	return shellProc, nil
+}
+
+// starts cmdStr (or the shell, if cmdStr is empty) on the block's connection.  used for the block's own process
+// and for its panes (see panes.go).
+func (bc *BlockController) startConnShellProc(ctx context.Context, logCtx context.Context, termSize waveobj.TermSize, cmdStr string, cmdOpts shellexec.CommandOptsType, blockMeta waveobj.MetaMapType, remoteName string, connUnion ConnUnion, persistent bool) (*shellexec.ShellProc, error) {
+	var err error
 	var shellProc *shellexec.ShellProc
 	swapToken := bc.makeSwapToken(ctx, logCtx, blockMeta, remoteName, connUnion.ShellType)
 	cmdOpts.SwapToken = swapToken
@@ -574,7 +769,7 @@ func (bc *BlockController) setupAndStartShellProcess(logCtx context.Context, rc
 	if connUnion.ConnType == ConnType_Wsl {
 		wslConn := connUnion.WslConn
 		if !connUnion.WshEnabled {
-			shellProc, err = shellexec.StartWslShellProcNoWsh(ctx, rc.TermSize, cmdStr, cmdOpts, wslConn)
+			shellProc, err = shellexec.StartWslShellProcNoWsh(ctx, termSize, cmdStr, cmdOpts, wslConn)
 			if err != nil {
 				return nil, err
 			}
@@ -588,13 +783,13 @@ func (bc *BlockController) setupAndStartShellProcess(logCtx context.Context, rc
 			swapToken.SockName = sockName
 			swapToken.RpcContext = &rpcContext
 			swapToken.Env[wshutil.WaveJwtTokenVarName] = jwtStr
-			shellProc, err = shellexec.StartWslShellProc(ctx, rc.TermSize, cmdStr, cmdOpts, wslConn)
+			shellProc, err = shellexec.StartWslShellProc(ctx, termSize, cmdStr, cmdOpts, wslConn)
 			if err != nil {
 				wslConn.SetWshError(err)
 				wslConn.WshEnabled.Store(false)
 				blocklogger.Infof(logCtx, "[conndebug] error starting wsl shell proc with wsh: %v\n", err)
 				blocklogger.Infof(logCtx, "[conndebug] attempting install without wsh\n")
-				shellProc, err = shellexec.StartWslShellProcNoWsh(ctx, rc.TermSize, cmdStr, cmdOpts, wslConn)
+				shellProc, err = shellexec.StartWslShellProcNoWsh(ctx, termSize, cmdStr, cmdOpts, wslConn)
 				if err != nil {
 					return nil, err
 				}
@@ -603,7 +798,7 @@ func (bc *BlockController) setupAndStartShellProcess(logCtx context.Context, rc
 	} else if connUnion.ConnType == ConnType_Ssh {
 		conn := connUnion.SshConn
 		if !connUnion.WshEnabled {
-			shellProc, err = shellexec.StartRemoteShellProcNoWsh(ctx, rc.TermSize, cmdStr, cmdOpts, conn)
+			shellProc, err = shellexec.StartRemoteShellProcNoWsh(ctx, termSize, cmdStr, cmdOpts, conn)
 			if err != nil {
 				return nil, err
 			}
@@ -617,13 +812,14 @@ func (bc *BlockController) setupAndStartShellProcess(logCtx context.Context, rc
 			swapToken.SockName = sockName
 			swapToken.RpcContext = &rpcContext
 			swapToken.Env[wshutil.WaveJwtTokenVarName] = jwtStr
-			shellProc, err = shellexec.StartRemoteShellProc(ctx, logCtx, rc.TermSize, cmdStr, cmdOpts, conn)
+			cmdOpts.RemotePty = persistent
+			shellProc, err = shellexec.StartRemoteShellProc(ctx, logCtx, termSize, cmdStr, cmdOpts, conn)
 			if err != nil {
 				conn.SetWshError(err)
 				conn.WshEnabled.Store(false)
 				blocklogger.Infof(logCtx, "[conndebug] error starting remote shell proc with wsh: %v\n", err)
 				blocklogger.Infof(logCtx, "[conndebug] attempting install without wsh\n")
-				shellProc, err = shellexec.StartRemoteShellProcNoWsh(ctx, rc.TermSize, cmdStr, cmdOpts, conn)
+				shellProc, err = shellexec.StartRemoteShellProcNoWsh(ctx, termSize, cmdStr, cmdOpts, conn)
 				if err != nil {
 					return nil, err
 				}
@@ -641,20 +837,23 @@ func (bc *BlockController) setupAndStartShellProcess(logCtx context.Context, rc
 			swapToken.RpcContext = &rpcContext
 			swapToken.Env[wshutil.WaveJwtTokenVarName] = jwtStr
 		}
-		cmdOpts.ShellPath = connUnion.ShellPath
-		cmdOpts.ShellOpts = getLocalShellOpts(blockMeta)
-		shellProc, err = shellexec.StartLocalShellProc(logCtx, rc.TermSize, cmdStr, cmdOpts)
+		if connUnion.ShellPath != "" {
+			cmdOpts.ShellPath = connUnion.ShellPath
+		}
+		cmdOpts.ShellOpts = getLocalShellOpts(bc.getSettings(), blockMeta)
+		if persistent {
+			cmdOpts.PtyHostSock, err = makePtyHostSockPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+		shellProc, err = shellexec.StartLocalShellProc(logCtx, termSize, cmdStr, cmdOpts)
 		if err != nil {
 			return nil, err
 		}
 	} else {
 		return nil, fmt.Errorf("unknown connection type for conn %q: %s", remoteName, connUnion.ConnType)
 	}
-	bc.UpdateControllerAndSendUpdate(func() bool {
-		bc.ShellProc = shellProc
-		bc.ShellProcStatus = Status_Running
-		return true
-	})
 	return shellProc, nil
 }
 
@@ -670,6 +869,7 @@ func (bc *BlockController) getBlockData_noErr() *waveobj.Block {
 }
 
 func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellProc, rc *RunShellOpts, blockMeta waveobj.MetaMapType) error {
+	startTime := time.Now()
 	shellInputCh := make(chan *BlockInputUnion, 32)
 	bc.ShellInputCh = shellInputCh
 
@@ -679,6 +879,28 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 	wshProxy.SetRpcContext(&wshrpc.RpcContext{TabId: bc.TabId, BlockId: bc.BlockId})
 	wshutil.DefaultRouter.RegisterRoute(wshutil.MakeControllerRouteId(bc.BlockId), wshProxy, true)
 	ptyBuffer := wshutil.MakePtyBuffer(wshutil.WaveOSCPrefix, shellProc.Cmd, wshProxy.FromRemoteCh)
+	termScanner := bc.makeTermOutputScanner(blockMeta)
+	pasteTracker := bc.makePasteModeTracker()
+	altTracker := bc.makeAltScreenTracker()
+	mouseTracker := bc.makeMouseModeTracker()
+	secureTracker := bc.makeSecureInputTracker(shellProc)
+	// a reattached session can still be on the alternate screen
+	bc.redrawIfAltScreen(rc.TermSize)
+	termEnc, err := getTermEncoding(blockMeta)
+	if err != nil {
+		log.Printf("block %s: %v (using utf-8)\n", bc.BlockId, err)
+	}
+	transcoder := makeTermTranscoder(termEnc)
+	ptyReader := transcoder.wrapOutput(ptyBuffer)
+	ptyInput := transcoder.wrapInput(shellProc.Cmd)
+	if getRecordOpt(blockMeta) && termRecorders.Get(bc.BlockId) == nil {
+		ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+		_, err := startTermRecorder(ctx, bc.BlockId, rc.TermSize, "")
+		cancelFn()
+		if err != nil {
+			log.Printf("error starting recording for block %s: %v\n", bc.BlockId, err)
+		}
+	}
 	go func() {
 		// handles regular output from the pty (goes to the blockfile and xterm)
 		defer func() {
@@ -694,7 +916,7 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 			shellProc.Cmd.Wait()
 			exitCode := shellProc.Cmd.ExitCode()
 			blockData := bc.getBlockData_noErr()
-			if blockData != nil && blockData.Meta.GetString(waveobj.MetaKey_Controller, "") == BlockController_Cmd {
+			if blockData != nil && !shellProc.IsDetached() && blockData.Meta.GetString(waveobj.MetaKey_Controller, "") == BlockController_Cmd {
 				termMsg := fmt.Sprintf("\r\nprocess finished with exit code = %d\r\n\r\n", exitCode)
 				HandleAppendBlockFile(bc.BlockId, wavebase.BlockFile_Term, []byte(termMsg))
 			}
@@ -704,12 +926,20 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 		}()
 		buf := make([]byte, 4096)
 		for {
-			nr, err := ptyBuffer.Read(buf)
+			if op := outputPushers.Get(bc.BlockId); op != nil {
+				op.waitForConsumer()
+			}
+			nr, err := ptyReader.Read(buf)
 			if nr > 0 {
 				err := HandleAppendBlockFile(bc.BlockId, wavebase.BlockFile_Term, buf[:nr])
 				if err != nil {
 					log.Printf("error appending to blockfile: %v\n", err)
 				}
+				termScanner.Write(buf[:nr])
+				pasteTracker.Write(buf[:nr])
+				altTracker.Write(buf[:nr])
+				mouseTracker.Write(buf[:nr])
+				secureTracker.Write(buf[:nr])
 			}
 			if err == io.EOF {
 				break
@@ -727,11 +957,22 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 			panichandler.PanicHandler("blockcontroller:shellproc-input-loop", recover())
 		}()
 		for ic := range shellInputCh {
-			if len(ic.InputData) > 0 {
+			if len(ic.InputData) > 0 && ic.noEncode {
 				shellProc.Cmd.Write(ic.InputData)
+			} else if pane := bc.getActivePane(); pane != nil && len(ic.InputData) > 0 {
+				pane.writeInput(ic.InputData)
+			} else if inputData := bc.filterMouseInput(ic.InputData); len(inputData) > 0 {
+				ptyInput.Write(inputData)
+				secureTracker.inputSent(inputData)
+			}
+			if ic.SigName != "" {
+				err := bc.SendSignal(ic.SigName, 0)
+				if err != nil {
+					log.Printf("error sending signal to block %s: %v\n", bc.BlockId, err)
+				}
 			}
 			if ic.TermSize != nil {
-				updateTermSize(shellProc, bc.BlockId, *ic.TermSize)
+				bc.RequestResize(*ic.TermSize, true)
 			}
 		}
 	}()
@@ -745,7 +986,7 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 			if err != nil {
 				log.Printf("error encoding OSC message: %v\n", err)
 			}
-			shellInputCh <- &BlockInputUnion{InputData: encodedMsg}
+			shellInputCh <- &BlockInputUnion{InputData: encodedMsg, noEncode: true}
 		}
 	}()
 	go func() {
@@ -755,7 +996,17 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 		// wait for the shell to finish
 		var exitCode int
 		defer func() {
+			if !shellProc.IsDetached() {
+				untrackShellProc(bc.BlockId, shellProc)
+			}
 			wshutil.DefaultRouter.UnregisterRoute(wshutil.MakeControllerRouteId(bc.BlockId))
+			stopScrollbackTracker(bc.BlockId)
+			stopOutputPusher(bc.BlockId)
+			stopTermRecorder(bc.BlockId)
+			bc.killAllPanes()
+			if q := bc.getQueueIfExists(); q != nil {
+				q.shellDone()
+			}
 			bc.UpdateControllerAndSendUpdate(func() bool {
 				if bc.ShellProcStatus == Status_Running {
 					bc.ShellProcStatus = Status_Done
@@ -768,34 +1019,52 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 		waitErr := shellProc.Cmd.Wait()
 		exitCode = shellProc.Cmd.ExitCode()
 		shellProc.SetWaitErrorAndSignalDone(waitErr)
-		go checkCloseOnExit(bc.BlockId, exitCode)
+		if shellProc.IsDetached() {
+			return
+		}
+		var tabId string
+		bc.WithLock(func() {
+			tabId = bc.TabId
+		})
+		eventbus.Publish(eventbus.BlockExitEvent{Exit: wps.BlockExitEventData{
+			BlockId:  bc.BlockId,
+			TabId:    tabId,
+			ConnName: shellProc.ConnName,
+			ExitCode: exitCode,
+		}})
+		runTime := time.Since(startTime)
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("blockcontroller:proc-exit", recover())
+			}()
+			if shuttingDown.Load() {
+				return
+			}
+			if bc.checkConnDropOnExit(shellProc.ConnName, waitErr) {
+				return
+			}
+			if checkCloseOnExit(bc.BlockId, exitCode) {
+				return
+			}
+			bc.checkRestartOnExit(exitCode, runTime)
+		}()
 	}()
 	return nil
 }
 
-func updateTermSize(shellProc *shellexec.ShellProc, blockId string, termSize waveobj.TermSize) {
-	err := setTermSizeInDB(blockId, termSize)
-	if err != nil {
-		log.Printf("error setting pty size: %v\n", err)
-	}
-	err = shellProc.Cmd.SetSize(termSize.Rows, termSize.Cols)
-	if err != nil {
-		log.Printf("error setting pty size: %v\n", err)
-	}
-}
-
-func checkCloseOnExit(blockId string, exitCode int) {
+// returns true if the block is being closed
+func checkCloseOnExit(blockId string, exitCode int) bool {
 	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
 	defer cancelFn()
 	blockData, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
 	if err != nil {
 		log.Printf("error getting block data: %v\n", err)
-		return
+		return true
 	}
 	closeOnExit := blockData.Meta.GetBool(waveobj.MetaKey_CmdCloseOnExit, false)
 	closeOnExitForce := blockData.Meta.GetBool(waveobj.MetaKey_CmdCloseOnExitForce, false)
 	if !closeOnExitForce && !(closeOnExit && exitCode == 0) {
-		return
+		return false
 	}
 	delayMs := blockData.Meta.GetFloat(waveobj.MetaKey_CmdCloseOnExitDelay, 2000)
 	if delayMs < 0 {
@@ -807,6 +1076,7 @@ func checkCloseOnExit(blockId string, exitCode int) {
 	if err != nil {
 		log.Printf("error deleting block data (close on exit): %v\n", err)
 	}
+	return true
 }
 
 func getBoolFromMeta(meta map[string]any, key string, def bool) bool {
@@ -834,7 +1104,6 @@ func getTermSize(bdata *waveobj.Block) waveobj.TermSize {
 func setTermSizeInDB(blockId string, termSize waveobj.TermSize) error {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancelFn()
-	ctx = waveobj.ContextWithUpdates(ctx)
 	bdata, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
 	if err != nil {
 		return fmt.Errorf("error getting block data: %v", err)
@@ -847,8 +1116,6 @@ func setTermSizeInDB(blockId string, termSize waveobj.TermSize) error {
 	if err != nil {
 		return fmt.Errorf("error updating block data: %v", err)
 	}
-	updates := waveobj.ContextGetUpdatesRtn(ctx)
-	wps.Broker.SendUpdateEvents(updates)
 	return nil
 }
 
@@ -928,9 +1195,14 @@ func (bc *BlockController) run(logCtx context.Context, bdata *waveobj.Block, blo
 
 func (bc *BlockController) SendInput(inputUnion *BlockInputUnion) error {
 	var shellInputCh chan *BlockInputUnion
+	var frozen bool
 	bc.WithLock(func() {
 		shellInputCh = bc.ShellInputCh
+		frozen = bc.connFrozen
 	})
+	if frozen && len(inputUnion.InputData) > 0 {
+		return fmt.Errorf("the connection is down, input is not sent")
+	}
 	if shellInputCh == nil {
 		return fmt.Errorf("no shell input chan")
 	}
@@ -938,6 +1210,68 @@ func (bc *BlockController) SendInput(inputUnion *BlockInputUnion) error {
 	return nil
 }
 
+func (bc *BlockController) getRunningShellProc() *shellexec.ShellProc {
+	var shellProc *shellexec.ShellProc
+	bc.WithLock(func() {
+		if bc.ShellProcStatus == Status_Running {
+			shellProc = bc.ShellProc
+		}
+	})
+	return shellProc
+}
+
+// signals the block's running process group, unlike input this does not go through the terminal.
+// if pid is set only that process is signaled, it must be in the (local) shell's process tree.
+func (bc *BlockController) SendSignal(sigName string, pid int) error {
+	sigName, err := sigutil.NormalizeSignalName(sigName)
+	if err != nil {
+		return err
+	}
+	shellProc := bc.getRunningShellProc()
+	if shellProc == nil {
+		return fmt.Errorf("block %q has no running process", bc.BlockId)
+	}
+	if pane := bc.getActivePane(); pane != nil && pid == 0 {
+		return pane.shellProc.Cmd.Signal(sigName)
+	}
+	if pid == 0 {
+		return shellProc.Cmd.Signal(sigName)
+	}
+	shellPid := shellProc.GetLocalShellPid()
+	if shellPid == 0 {
+		return fmt.Errorf("processes can only be signaled individually for local shells")
+	}
+	if !shellexec.IsInProcessTree(shellPid, pid) {
+		return fmt.Errorf("process %d is not running in block %q", pid, bc.BlockId)
+	}
+	return sigutil.SignalPid(pid, sigName)
+}
+
+func SendSignal(blockId string, sigName string, pid int) error {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.SendSignal(sigName, pid)
+}
+
+// returns the processes running under the block's (local) shell, see shellexec.GetProcessTree
+func GetProcessTree(blockId string) ([]wshrpc.ProcessInfo, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return nil, fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	shellProc := bc.getRunningShellProc()
+	if shellProc == nil {
+		return nil, fmt.Errorf("block %q has no running process", blockId)
+	}
+	shellPid := shellProc.GetLocalShellPid()
+	if shellPid == 0 {
+		return nil, fmt.Errorf("process information is only available for local shells")
+	}
+	return shellexec.GetProcessTree(shellPid, ProcessCpuSampleTime)
+}
+
 func CheckConnStatus(blockId string) error {
 	bdata, err := wstore.DBMustGet[*waveobj.Block](context.Background(), blockId)
 	if err != nil {
@@ -974,6 +1308,7 @@ func (bc *BlockController) StopShellProc(shouldWait bool) {
 	if bc.ShellProc == nil || bc.ShellProcStatus == Status_Done || bc.ShellProcStatus == Status_Init {
 		return
 	}
+	bc.stopRequested = true
 	bc.ShellProc.Close()
 	if shouldWait {
 		doneCh := bc.ShellProc.DoneCh
@@ -1003,6 +1338,7 @@ func getOrCreateBlockController(tabId string, blockId string, controllerName str
 			BlockId:         blockId,
 			ShellProcStatus: Status_Init,
 			RunLock:         &atomic.Bool{},
+			Resizer:         &termResizer{},
 		}
 		blockControllerMap[blockId] = bc
 		createdController = true
@@ -1038,6 +1374,11 @@ func ResyncController(ctx context.Context, tabId string, blockId string, rtOpts
 		}
 		return nil
 	}
+	if wplugin.FindPluginForController(controllerName) != nil {
+		// plugin controllers run inside the plugin process
+		wplugin.SendBlockEvent(blockData, tabId, wshrpc.PluginBlockEvent_Resync)
+		return nil
+	}
 	log.Printf("resync controller %s %q (%q) (force %v)\n", blockId, controllerName, connName, force)
 	// check if conn is different, if so, stop the current controller, and set status back to init
 	if curBc != nil {
@@ -1063,6 +1404,10 @@ func ResyncController(ctx context.Context, tabId string, blockId string, rtOpts
 	if bcStatus.ShellProcStatus == Status_Init || bcStatus.ShellProcStatus == Status_Done {
 		return startBlockController(ctx, tabId, blockId, rtOpts, force)
 	}
+	if rtOpts != nil {
+		// the terminal has reloaded, a full screen application needs to redraw
+		curBc.redrawIfAltScreen(rtOpts.TermSize)
+	}
 	return nil
 }
 
@@ -1104,6 +1449,9 @@ func StopBlockControllerAndSetStatus(blockId string, newStatus string) {
 		return
 	}
 	if bc.getShellProc() != nil {
+		bc.WithLock(func() {
+			bc.stopRequested = true
+		})
 		bc.ShellProc.Close()
 		<-bc.ShellProc.DoneCh
 		bc.UpdateControllerAndSendUpdate(func() bool {
@@ -1142,3 +1490,14 @@ func GetBlockController(blockId string) *BlockController {
 	defer globalLock.Unlock()
 	return blockControllerMap[blockId]
 }
+
+// called when a detached block is attached to a (possibly different) tab, new shell procs get the new tab in their rpc context
+func SetControllerTabId(blockId string, tabId string) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return
+	}
+	bc.WithLock(func() {
+		bc.TabId = tabId
+	})
+}
@@ -0,0 +1,309 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// commands queued for a block (wsh queue, or the termqueue rpcs) are typed into the block's shell one at a
+// time.  the queue uses the shell integration (OSC 133) markers: a command is sent when the shell shows its
+// prompt, and is finished when the shell reports its exit code (D) or shows the next prompt.  nothing is sent
+// until the shell has shown a prompt, so shells without shell integration never run the queue.  the queue is
+// kept in memory with the controller, along with the last few finished commands, and is published as
+// term:queue events (scoped to the block).  queued commands are canceled when the shell exits.
+
+const (
+	QueuedCmdStatus_Queued   = "queued"
+	QueuedCmdStatus_Running  = "running"
+	QueuedCmdStatus_Done     = "done"
+	QueuedCmdStatus_Canceled = "canceled"
+
+	MaxQueuedCommands   = 100
+	MaxFinishedCommands = 20
+)
+
+type commandQueue struct {
+	lock        sync.Mutex
+	blockId     string
+	items       []*wshrpc.QueuedCommand
+	nextId      int
+	shellReady  bool // the shell is at its prompt
+	sendInputFn func(data []byte) error
+}
+
+func (bc *BlockController) getCommandQueue() *commandQueue {
+	bc.Lock.Lock()
+	defer bc.Lock.Unlock()
+	if bc.cmdQueue == nil {
+		bc.cmdQueue = &commandQueue{
+			blockId: bc.BlockId,
+			sendInputFn: func(data []byte) error {
+				return bc.SendInput(&BlockInputUnion{InputData: data})
+			},
+		}
+	}
+	return bc.cmdQueue
+}
+
+func (bc *BlockController) getQueueIfExists() *commandQueue {
+	bc.Lock.Lock()
+	defer bc.Lock.Unlock()
+	return bc.cmdQueue
+}
+
+func (q *commandQueue) list_nolock() []wshrpc.QueuedCommand {
+	rtn := make([]wshrpc.QueuedCommand, 0, len(q.items))
+	for _, item := range q.items {
+		rtn = append(rtn, *item)
+	}
+	return rtn
+}
+
+func (q *commandQueue) list() []wshrpc.QueuedCommand {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.list_nolock()
+}
+
+func (q *commandQueue) countQueued_nolock() int {
+	var count int
+	for _, item := range q.items {
+		if item.Status == QueuedCmdStatus_Queued || item.Status == QueuedCmdStatus_Running {
+			count++
+		}
+	}
+	return count
+}
+
+func (q *commandQueue) publish_nolock() {
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_TermQueue,
+		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, q.blockId).String()},
+		Data:   q.list_nolock(),
+	})
+}
+
+// drops the oldest finished commands
+func (q *commandQueue) trim_nolock() {
+	var finished int
+	for _, item := range q.items {
+		if item.Status == QueuedCmdStatus_Done || item.Status == QueuedCmdStatus_Canceled {
+			finished++
+		}
+	}
+	if finished <= MaxFinishedCommands {
+		return
+	}
+	newItems := make([]*wshrpc.QueuedCommand, 0, len(q.items))
+	for _, item := range q.items {
+		if finished > MaxFinishedCommands && (item.Status == QueuedCmdStatus_Done || item.Status == QueuedCmdStatus_Canceled) {
+			finished--
+			continue
+		}
+		newItems = append(newItems, item)
+	}
+	q.items = newItems
+}
+
+func (q *commandQueue) add(cmdStr string) (*wshrpc.QueuedCommand, error) {
+	cmdStr = strings.TrimSpace(cmdStr)
+	if cmdStr == "" {
+		return nil, fmt.Errorf("no command given")
+	}
+	if strings.ContainsAny(cmdStr, "\r\n") {
+		return nil, fmt.Errorf("queued commands must be a single line")
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.countQueued_nolock() >= MaxQueuedCommands {
+		return nil, fmt.Errorf("too many queued commands (max %d)", MaxQueuedCommands)
+	}
+	q.nextId++
+	item := &wshrpc.QueuedCommand{
+		Id:       q.nextId,
+		Cmd:      cmdStr,
+		Status:   QueuedCmdStatus_Queued,
+		QueuedTs: time.Now().UnixMilli(),
+	}
+	q.items = append(q.items, item)
+	q.runNext_nolock()
+	q.publish_nolock()
+	rtn := *item
+	return &rtn, nil
+}
+
+func (q *commandQueue) getRunning_nolock() *wshrpc.QueuedCommand {
+	for _, item := range q.items {
+		if item.Status == QueuedCmdStatus_Running {
+			return item
+		}
+	}
+	return nil
+}
+
+// sends the next command if the shell is at its prompt
+func (q *commandQueue) runNext_nolock() {
+	if !q.shellReady || q.getRunning_nolock() != nil {
+		return
+	}
+	for _, item := range q.items {
+		if item.Status != QueuedCmdStatus_Queued {
+			continue
+		}
+		item.Status = QueuedCmdStatus_Running
+		item.StartTs = time.Now().UnixMilli()
+		q.shellReady = false
+		input := []byte(item.Cmd + "\r")
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("blockcontroller:cmdqueue-send", recover())
+			}()
+			err := q.sendInputFn(input)
+			if err != nil {
+				log.Printf("error sending queued command to block %s: %v\n", q.blockId, err)
+			}
+		}()
+		return
+	}
+}
+
+// called with each OSC 133 marker, seg is the segment the marker finished (if any)
+func (q *commandQueue) handleMarker(marker string, seg *wshrpc.TermSegment) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	running := q.getRunning_nolock()
+	changed := false
+	switch marker {
+	case "A":
+		if running != nil {
+			// the command finished without sending D
+			q.finish_nolock(running, nil)
+			changed = true
+		}
+		q.shellReady = true
+	case "B":
+		// the end of the prompt (a command sent at A can still be waiting to be read)
+		if running == nil {
+			q.shellReady = true
+		}
+	case "C":
+		q.shellReady = false
+	case "D":
+		if running != nil && seg != nil {
+			q.finish_nolock(running, seg.ExitCode)
+			changed = true
+		}
+	default:
+		return
+	}
+	if q.shellReady && q.getRunning_nolock() == nil && q.countQueued_nolock() > 0 {
+		q.runNext_nolock()
+		changed = true
+	}
+	if changed {
+		q.publish_nolock()
+	}
+}
+
+func (q *commandQueue) finish_nolock(item *wshrpc.QueuedCommand, exitCode *int) {
+	item.Status = QueuedCmdStatus_Done
+	item.EndTs = time.Now().UnixMilli()
+	item.ExitCode = exitCode
+	q.trim_nolock()
+}
+
+// the shell has exited, the queued commands are canceled (they are not run in the next shell)
+func (q *commandQueue) shellDone() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.shellReady = false
+	now := time.Now().UnixMilli()
+	for _, item := range q.items {
+		if item.Status == QueuedCmdStatus_Running {
+			q.finish_nolock(item, nil)
+		} else if item.Status == QueuedCmdStatus_Queued {
+			item.Status = QueuedCmdStatus_Canceled
+			item.EndTs = now
+		}
+	}
+	q.trim_nolock()
+	q.publish_nolock()
+}
+
+// cancels a queued command, or interrupts it (ctrl-c) if it is running.  id 0 cancels everything that is queued
+// (the running command is left alone).
+func (q *commandQueue) cancel(id int) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.publish_nolock()
+	now := time.Now().UnixMilli()
+	for _, item := range q.items {
+		if id != 0 && item.Id != id {
+			continue
+		}
+		switch item.Status {
+		case QueuedCmdStatus_Queued:
+			item.Status = QueuedCmdStatus_Canceled
+			item.EndTs = now
+		case QueuedCmdStatus_Running:
+			if id == 0 {
+				continue
+			}
+			return q.sendInputFn([]byte{0x03})
+		default:
+			if id != 0 {
+				return fmt.Errorf("command %d has already finished", id)
+			}
+		}
+		if id != 0 {
+			q.trim_nolock()
+			return nil
+		}
+	}
+	if id != 0 {
+		return fmt.Errorf("command %d not found in the queue for block %q", id, q.blockId)
+	}
+	q.trim_nolock()
+	return nil
+}
+
+func QueueCommand(blockId string, cmdStr string) (*wshrpc.QueuedCommand, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return nil, fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	if bc.ControllerType != BlockController_Shell {
+		return nil, fmt.Errorf("commands can only be queued for shell blocks")
+	}
+	if bc.getRunningShellProc() == nil {
+		return nil, fmt.Errorf("block %q has no running shell", blockId)
+	}
+	return bc.getCommandQueue().add(cmdStr)
+}
+
+func ListQueuedCommands(blockId string) ([]wshrpc.QueuedCommand, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return nil, fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.getCommandQueue().list(), nil
+}
+
+func CancelQueuedCommand(blockId string, id int) error {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.getCommandQueue().cancel(id)
+}
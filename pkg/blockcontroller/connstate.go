@@ -0,0 +1,190 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"golang.org/x/crypto/ssh"
+)
+
+// a block on an ssh connection is frozen while the connection is degraded or reconnecting (see the conn:state
+// events in conncontroller/keepalive.go): its input is refused instead of being queued for a connection that does
+// not answer, and the state is written to the terminal and set in the runtime status.  when the connection is back
+// the block is resumed, a shell that ended with the connection is started again (a persistent session is reattached
+// instead, see ptysessions.go).  a shell that ends while its connection is down is not closed (cmd:closeonexit) or
+// restarted (cmd:restart), the reconnect decides.  if the connection can't be reconnected the block is unfrozen and
+// left as it is.  blocks with a shell on the roam transport (see conncontroller/roam.go) are not frozen, their shell
+// does not use the ssh connection.
+
+const connStateQueueSize = 64
+
+// how long to wait for the connection to notice that it dropped after a shell ended without an exit status
+const connDropGracePeriod = 2 * time.Second
+
+var connStateCh = make(chan wps.ConnStateEventData, connStateQueueSize)
+var connStateBlocks = make(map[string][]string) // the blocks of the queued events (by conn name)
+var connStateLock = &sync.Mutex{}
+var connStateOnce = &sync.Once{}
+
+func StartConnStateHandler() {
+	connStateOnce.Do(func() {
+		eventbus.Subscribe(eventbus.Topic_ConnState, eventbus.Scope{}, handleConnStateEvent)
+		go runConnStateLoop()
+	})
+}
+
+// called from the publishing goroutine, must not block
+func handleConnStateEvent(event eventbus.Event) {
+	stateEvent, ok := event.(eventbus.ConnStateEvent)
+	if !ok {
+		return
+	}
+	connStateLock.Lock()
+	connStateBlocks[stateEvent.State.ConnName] = stateEvent.BlockIds
+	connStateLock.Unlock()
+	select {
+	case connStateCh <- stateEvent.State:
+	default:
+		log.Printf("conn:state queue is full, dropping %s event for %s\n", stateEvent.State.State, stateEvent.State.ConnName)
+	}
+}
+
+func runConnStateLoop() {
+	defer func() {
+		panichandler.PanicHandler("blockcontroller:runConnStateLoop", recover())
+	}()
+	for state := range connStateCh {
+		connStateLock.Lock()
+		blockIds := connStateBlocks[state.ConnName]
+		connStateLock.Unlock()
+		for _, blockId := range blockIds {
+			bc := GetBlockController(blockId)
+			if bc == nil {
+				continue
+			}
+			bc.handleConnState(state)
+		}
+	}
+}
+
+func (bc *BlockController) handleConnState(state wps.ConnStateEventData) {
+	if shellProc := bc.getShellProc(); shellProc != nil && shellProc.IsRoaming() {
+		// the shell does not go over the ssh connection
+		return
+	}
+	switch state.State {
+	case conncontroller.Status_Degraded:
+		bc.freezeForConn(fmt.Sprintf("[connection to %s is not responding]", state.ConnName))
+	case conncontroller.Status_Reconnecting:
+		retryIn := time.Until(time.UnixMilli(state.NextRetryTs)).Round(time.Second)
+		bc.freezeForConn(fmt.Sprintf("[connection to %s lost, reconnecting in %v (attempt %d)]", state.ConnName, max(0, retryIn), state.Attempt))
+	case conncontroller.Status_Connected:
+		bc.resumeForConn(state.ConnName)
+	case conncontroller.Status_Disconnected, conncontroller.Status_Error:
+		if state.Attempt > 0 {
+			// a failed reconnect attempt, the next one follows
+			return
+		}
+		if bc.setConnFrozen(false) && state.Error != "" {
+			bc.writeConnMessage(fmt.Sprintf("[connection to %s: %s]", state.ConnName, state.Error))
+		}
+	}
+}
+
+func (bc *BlockController) writeConnMessage(msg string) {
+	HandleAppendBlockFile(bc.BlockId, wavebase.BlockFile_Term, []byte("\r\n"+msg+"\r\n"))
+}
+
+// returns true if the frozen state changed
+func (bc *BlockController) setConnFrozen(frozen bool) bool {
+	var changed bool
+	bc.UpdateControllerAndSendUpdate(func() bool {
+		changed = bc.connFrozen != frozen
+		bc.connFrozen = frozen
+		return changed
+	})
+	return changed
+}
+
+func (bc *BlockController) isConnFrozen() bool {
+	bc.Lock.Lock()
+	defer bc.Lock.Unlock()
+	return bc.connFrozen
+}
+
+func (bc *BlockController) freezeForConn(msg string) {
+	bc.setConnFrozen(true)
+	bc.writeConnMessage(msg)
+}
+
+func (bc *BlockController) resumeForConn(connName string) {
+	if !bc.setConnFrozen(false) {
+		return
+	}
+	var tabId, controllerType, status string
+	bc.WithLock(func() {
+		tabId = bc.TabId
+		controllerType = bc.ControllerType
+		status = bc.ShellProcStatus
+	})
+	if status != Status_Done || controllerType != BlockController_Shell || tabId == "" {
+		bc.writeConnMessage(fmt.Sprintf("[connection to %s restored]", connName))
+		return
+	}
+	if tp, ok := getTrackedProc(bc.BlockId); ok && tp.ConnName == connName && tp.SockPath != "" {
+		bc.writeConnMessage(fmt.Sprintf("[connection to %s restored, reattaching to the shell]", connName))
+	} else {
+		bc.writeConnMessage(fmt.Sprintf("[connection to %s restored, starting a new shell]", connName))
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	err := ResyncController(ctx, tabId, bc.BlockId, nil, true)
+	if err != nil {
+		log.Printf("error resuming block %s: %v\n", bc.BlockId, err)
+	}
+}
+
+// called when the shell of the block ended, returns true if it ended because its ssh connection dropped (and the
+// connection is being reconnected).  the block is frozen until the connection is back.
+func (bc *BlockController) checkConnDropOnExit(connName string, waitErr error) bool {
+	if connName == "" || strings.HasPrefix(connName, "wsl://") {
+		return false
+	}
+	opts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return false
+	}
+	conn := conncontroller.GetConn(opts)
+	if conn == nil {
+		return false
+	}
+	status := conn.GetStatus()
+	var exitMissing *ssh.ExitMissingError
+	if errors.As(waitErr, &exitMissing) {
+		// the session ended without an exit status, the connection may not have noticed it dropped yet
+		deadline := time.Now().Add(connDropGracePeriod)
+		for status == conncontroller.Status_Connected && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			status = conn.GetStatus()
+		}
+	}
+	if status != conncontroller.Status_Degraded && status != conncontroller.Status_Reconnecting {
+		return false
+	}
+	bc.setConnFrozen(true)
+	return true
+}
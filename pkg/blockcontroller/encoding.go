@@ -0,0 +1,65 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// term:encoding is the charset a block's programs read and write (e.g. "shift_jis", "euc-jp", "latin1").
+// pty output is decoded to utf-8 after the wave OSC messages have been removed from it, and terminal input is
+// encoded (runes the charset cannot represent are replaced).  names are looked up in the WHATWG encoding index.
+// term:locale sets LANG and LC_ALL in the shell's environment so programs pick the matching charset.
+
+// returns nil for utf-8 (no transcoding)
+func getTermEncoding(blockMeta waveobj.MetaMapType) (encoding.Encoding, error) {
+	encName := strings.TrimSpace(blockMeta.GetString(waveobj.MetaKey_TermEncoding, ""))
+	if encName == "" {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(encName)
+	if err != nil {
+		return nil, fmt.Errorf("unknown term:encoding %q", encName)
+	}
+	if enc == unicode.UTF8 {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+type termTranscoder struct {
+	enc encoding.Encoding
+}
+
+func makeTermTranscoder(enc encoding.Encoding) *termTranscoder {
+	if enc == nil {
+		return nil
+	}
+	return &termTranscoder{enc: enc}
+}
+
+// the decoder keeps multi-byte sequences that are split across reads until they are complete
+func (tt *termTranscoder) wrapOutput(r io.Reader) io.Reader {
+	if tt == nil {
+		return r
+	}
+	return transform.NewReader(r, tt.enc.NewDecoder())
+}
+
+// all of the input of a shell goes through one writer, which keeps a rune that is split across two input
+// messages until it is complete (and the state of stateful encodings, e.g. iso-2022-jp, between messages)
+func (tt *termTranscoder) wrapInput(w io.Writer) io.Writer {
+	if tt == nil {
+		return w
+	}
+	return transform.NewWriter(w, encoding.ReplaceUnsupported(tt.enc.NewEncoder()))
+}
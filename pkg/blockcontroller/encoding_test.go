@@ -0,0 +1,37 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestEncodeInputSplitRune(t *testing.T) {
+	enc, err := getTermEncoding(waveobj.MetaMapType{waveobj.MetaKey_TermEncoding: "shift_jis"})
+	if err != nil || enc == nil {
+		t.Fatalf("expected the shift_jis encoding, got %v, err:%v", enc, err)
+	}
+	var out bytes.Buffer
+	input := makeTermTranscoder(enc).wrapInput(&out)
+	data := []byte("ls 日本語\n")
+	// the first write ends in the middle of the 3 bytes of "日"
+	_, err = input.Write(data[:4])
+	if err != nil {
+		t.Fatalf("error writing input: %v", err)
+	}
+	if out.String() != "ls " {
+		t.Errorf("expected the partial rune to be kept, got %q", out.Bytes())
+	}
+	_, err = input.Write(data[4:])
+	if err != nil {
+		t.Fatalf("error writing input: %v", err)
+	}
+	expected, _ := enc.NewEncoder().Bytes(data)
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Errorf("expected %q, got %q", expected, out.Bytes())
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// several frontends can show the same block.  the frontend that last sent input to a block is its active client,
+// input from the other frontends is rejected while the active client is typing (until InputArbitrationTime after
+// its last input) so keystrokes from two frontends are never interleaved.  resizes are only taken from the active
+// client, so a frontend that is just watching does not resize the terminal under the one that is typing.  input
+// that does not come from a frontend (wsh, the automation api) is not arbitrated.
+
+const InputArbitrationTime = 2 * time.Second
+
+type inputArbiter struct {
+	lock         sync.Mutex
+	activeClient string // the route of the active client
+	lastInputTs  time.Time
+}
+
+func isFeClientRoute(routeId string) bool {
+	return strings.HasPrefix(routeId, wshutil.RoutePrefix_FeClient)
+}
+
+// returns an error if another frontend is typing in the block, otherwise makes routeId the active client
+func (bc *BlockController) ArbitrateInput(routeId string) error {
+	if !isFeClientRoute(routeId) {
+		return nil
+	}
+	ia := &bc.inputArbiter
+	ia.lock.Lock()
+	defer ia.lock.Unlock()
+	if ia.activeClient != "" && ia.activeClient != routeId && time.Since(ia.lastInputTs) < InputArbitrationTime {
+		return fmt.Errorf("block %s is being typed in from another window", bc.BlockId)
+	}
+	ia.activeClient = routeId
+	ia.lastInputTs = time.Now()
+	return nil
+}
+
+// true if routeId may resize the block (it is the active client, or there is none)
+func (bc *BlockController) CanResize(routeId string) bool {
+	if !isFeClientRoute(routeId) {
+		return true
+	}
+	ia := &bc.inputArbiter
+	ia.lock.Lock()
+	defer ia.lock.Unlock()
+	return ia.activeClient == "" || ia.activeClient == routeId
+}
+
+// called when a frontend disconnects, its blocks no longer have an active client
+func ReleaseClientInput(routeId string) {
+	for _, bc := range getControllerList() {
+		ia := &bc.inputArbiter
+		ia.lock.Lock()
+		if ia.activeClient == routeId {
+			ia.activeClient = ""
+		}
+		ia.lock.Unlock()
+	}
+}
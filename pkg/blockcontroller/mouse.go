@@ -0,0 +1,93 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+)
+
+// applications turn on mouse reporting with DECSET 9, 1000, 1002 or 1003, and the terminal then sends the
+// mouse events as input (SGR encoded, ESC [ < button ; col ; row M/m, once DECSET 1006 is on).  the modes are
+// tracked from the pty output, and mouse reports in the input are dropped when the application has not asked
+// for them (a report sent after it exited, or input broadcast from another block) or when term:mousereporting
+// is false for the block.  with term:mousereporting false the terminal does not turn on mouse reporting at all,
+// so the mouse always selects text (the mode the application asked for is kept and applied if it is turned
+// back on).
+
+var mouseTrackingModes = []int{9, 1000, 1002, 1003}
+
+var mouseModeMaxSeq = len("\x1b[?1000h")
+
+// SGR reports, and the default (X10) encoding which is three bytes after ESC [ M
+var mouseReportRe = regexp.MustCompile(`\x1b\[<\d+;\d+;\d+[Mm]|\x1b\[M[\x20-\xff]{3}`)
+
+// watches the pty output for the application turning mouse reporting on and off
+type mouseModeTracker struct {
+	bc    *BlockController
+	tail  []byte // the end of the previous chunk, for a sequence split across reads
+	modes map[int]bool
+}
+
+func (bc *BlockController) makeMouseModeTracker() *mouseModeTracker {
+	bc.mouseReporting.Store(false)
+	return &mouseModeTracker{bc: bc, modes: make(map[int]bool)}
+}
+
+func (mt *mouseModeTracker) Write(chunk []byte) {
+	maxTail := mouseModeMaxSeq - 1
+	seam := append(mt.tail, chunk[:min(len(chunk), maxTail)]...)
+	mt.update(seam)
+	mt.update(chunk)
+	if len(chunk) >= maxTail {
+		mt.tail = append(mt.tail[:0], chunk[len(chunk)-maxTail:]...)
+	} else {
+		mt.tail = append(mt.tail[:0], seam[max(0, len(seam)-maxTail):]...)
+	}
+}
+
+func (mt *mouseModeTracker) update(data []byte) {
+	if bytes.IndexByte(data, '\x1b') == -1 {
+		return
+	}
+	resetIdx := bytes.LastIndex(data, []byte(altScreenReset))
+	for _, mode := range mouseTrackingModes {
+		onIdx := bytes.LastIndex(data, []byte(fmt.Sprintf("\x1b[?%dh", mode)))
+		offIdx := max(bytes.LastIndex(data, []byte(fmt.Sprintf("\x1b[?%dl", mode))), resetIdx)
+		if onIdx > offIdx {
+			mt.modes[mode] = true
+		} else if offIdx > onIdx {
+			delete(mt.modes, mode)
+		}
+	}
+	mt.bc.mouseReporting.Store(len(mt.modes) > 0)
+}
+
+// block meta overrides the global setting
+func getMouseReportingOpt(blockMeta waveobj.MetaMapType) bool {
+	enabled := true
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	if settings.TermMouseReporting != nil {
+		enabled = *settings.TermMouseReporting
+	}
+	return blockMeta.GetBool(waveobj.MetaKey_TermMouseReporting, enabled)
+}
+
+// removes the mouse reports from input the application should not get
+func (bc *BlockController) filterMouseInput(data []byte) []byte {
+	if !bytes.Contains(data, []byte("\x1b[<")) && !bytes.Contains(data, []byte("\x1b[M")) {
+		return data
+	}
+	if bc.mouseReporting.Load() {
+		blockData := bc.getBlockData_noErr()
+		if blockData == nil || getMouseReportingOpt(blockData.Meta) {
+			return data
+		}
+	}
+	return mouseReportRe.ReplaceAll(data, nil)
+}
@@ -0,0 +1,119 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"encoding/base64"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// OSC 52 lets programs (vim, tmux, etc.) use the system clipboard, which also works over ssh since the
+// sequence is part of the output.  term:osc52 controls what they are allowed to do: "write" (the default) only
+// lets them set the clipboard, "readwrite" also lets them read it, and "none" ignores the requests.  reading is
+// off by default since any program that can write to the terminal could read the clipboard.  the policy can be
+// set for a block, for a connection (connections.json, or a [connection] override in the block meta), or in
+// the settings.  the clipboard is read and written by the electron process.
+
+const (
+	OscNum_Clipboard = 52
+
+	// base64 data, so ~750k of text
+	MaxOsc52DataSize = 1024 * 1024
+
+	Osc52Policy_None      = "none"
+	Osc52Policy_Write     = "write"
+	Osc52Policy_ReadWrite = "readwrite"
+)
+
+func getOsc52Policy(blockMeta waveobj.MetaMapType, connName string) string {
+	policy := getConnConfigString(blockMeta, connName, waveobj.MetaKey_TermOsc52)
+	switch policy {
+	case Osc52Policy_None, Osc52Policy_Write, Osc52Policy_ReadWrite:
+		return policy
+	case "":
+		return Osc52Policy_Write
+	default:
+		log.Printf("invalid term:osc52 value %q, using %q\n", policy, Osc52Policy_Write)
+		return Osc52Policy_Write
+	}
+}
+
+// OSC 52 ; selection ; base64-data (or "?" to read the clipboard).  returns the selection to use in the reply
+// (only the system clipboard is supported, primary and the cut buffers are treated the same way)
+func parseOsc52(data []byte) (selection string, payload string) {
+	selection, payload, _ = strings.Cut(string(data), ";")
+	if selection == "" {
+		selection = "c"
+	}
+	return selection[:1], payload
+}
+
+func decodeOsc52Text(payload string) (string, bool) {
+	barr, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		barr, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+		if err != nil {
+			return "", false
+		}
+	}
+	return strings.ToValidUTF8(string(barr), string(utf8.RuneError)), true
+}
+
+func (ts *termOutputScanner) handleOsc52(data []byte) {
+	blockData := ts.bc.getBlockData_noErr()
+	if blockData == nil {
+		return
+	}
+	policy := getOsc52Policy(blockData.Meta, ts.connName)
+	selection, payload := parseOsc52(data)
+	if payload == "?" {
+		if policy != Osc52Policy_ReadWrite {
+			log.Printf("block %s: OSC 52 clipboard read denied (term:osc52 is %q)\n", ts.blockId, policy)
+			return
+		}
+		go ts.replyOsc52Read(selection)
+		return
+	}
+	if policy == Osc52Policy_None {
+		return
+	}
+	text, ok := decodeOsc52Text(payload)
+	if !ok || text == "" {
+		// programs send invalid data to clear the selection, the system clipboard is left alone
+		return
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("blockcontroller:osc52-write", recover())
+		}()
+		err := wshclient.ClipboardWriteCommand(wshclient.GetBareRpcClient(), wshrpc.CommandClipboardWriteData{Text: text}, &wshrpc.RpcOpts{Route: wshutil.ElectronRoute})
+		if err != nil {
+			log.Printf("block %s: error writing OSC 52 clipboard: %v\n", ts.blockId, err)
+		}
+	}()
+}
+
+func (ts *termOutputScanner) replyOsc52Read(selection string) {
+	defer func() {
+		panichandler.PanicHandler("blockcontroller:osc52-read", recover())
+	}()
+	text, err := wshclient.ClipboardReadCommand(wshclient.GetBareRpcClient(), &wshrpc.RpcOpts{Route: wshutil.ElectronRoute})
+	if err != nil {
+		log.Printf("block %s: error reading clipboard for OSC 52: %v\n", ts.blockId, err)
+		return
+	}
+	reply := "\x1b]52;" + selection + ";" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	err = ts.bc.SendInput(&BlockInputUnion{InputData: []byte(reply), noEncode: true})
+	if err != nil {
+		log.Printf("block %s: error sending OSC 52 reply: %v\n", ts.blockId, err)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/ds"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+)
+
+// term output always goes to the blockstore in full, but what is pushed to the frontend is coalesced
+// (flushed every term:outputflushms) and rate limited (term:outputmaxrate bytes/s).  when the rate is
+// exceeded, pushes stop for the rest of the window and an invalidate event is sent at the end of it,
+// the frontend then catches up by reading the term file from its current offset.
+//
+// the frontend acks the offset it has written to the terminal.  when the pushed but unacked output goes
+// over BackpressureThreshold the pty read loop waits (up to BackpressureMaxWait each time) for the
+// consumer to catch up, which also pushes back on remote shells.  a consumer that has not acked within
+// ConsumerIdleTimeout (e.g. the block is detached or its window is closed) is ignored.
+
+const (
+	DefaultOutputFlushMs  = 20
+	MaxOutputFlushMs      = 1000
+	DefaultOutputMaxRate  = 4 * 1024 * 1024
+	OutputMaxBatchSize    = 256 * 1024
+	OutputRateWindow      = time.Second
+	BackpressureThreshold = 1024 * 1024
+	BackpressureMaxWait   = 5 * time.Second
+	ConsumerIdleTimeout   = 10 * time.Second
+)
+
+var outputPushers = ds.MakeSyncMap[*outputPusher]()
+
+type outputPusher struct {
+	lock          sync.Mutex
+	blockId       string
+	flushInterval time.Duration
+	maxRate       int64 // bytes per OutputRateWindow, 0 for no limit
+	pending       bytes.Buffer
+	pendingOffset int64
+	flushTimer    *time.Timer
+	windowStart   time.Time
+	windowBytes   int64
+	skipping      bool
+	pushedOffset  int64 // end offset of the output pushed to the frontend
+	ackedOffset   int64
+	lastAck       time.Time
+	ackCh         chan struct{}
+	paused        bool
+}
+
+func getOutputPusherSettings() (time.Duration, int64) {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	flushMs := int64(DefaultOutputFlushMs)
+	if settings.TermOutputFlushMs != nil {
+		flushMs = max(0, min(MaxOutputFlushMs, *settings.TermOutputFlushMs))
+	}
+	maxRate := int64(DefaultOutputMaxRate)
+	if settings.TermOutputMaxRate != nil {
+		// negative disables rate limiting
+		maxRate = max(0, *settings.TermOutputMaxRate)
+	}
+	return time.Duration(flushMs) * time.Millisecond, maxRate
+}
+
+func startOutputPusher(ctx context.Context, blockId string) {
+	flushInterval, maxRate := getOutputPusherSettings()
+	var fileSize int64
+	if wfile, err := filestore.WFS.Stat(ctx, blockId, wavebase.BlockFile_Term); err == nil {
+		fileSize = wfile.Size
+	}
+	outputPushers.Set(blockId, &outputPusher{
+		blockId:       blockId,
+		flushInterval: flushInterval,
+		maxRate:       maxRate,
+		windowStart:   time.Now(),
+		pushedOffset:  fileSize,
+		ackedOffset:   fileSize,
+		ackCh:         make(chan struct{}, 1),
+	})
+}
+
+func stopOutputPusher(blockId string) {
+	op := outputPushers.Get(blockId)
+	if op == nil {
+		return
+	}
+	outputPushers.Delete(blockId)
+	op.flush()
+}
+
+func publishAppendEvent(blockId string, blockFile string, offset int64, data []byte) {
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   blockId,
+		FileName: blockFile,
+		FileOp:   wps.FileOp_Append,
+		Data64:   base64.StdEncoding.EncodeToString(data),
+		Offset:   offset,
+	}})
+}
+
+func (op *outputPusher) add(offset int64, data []byte) {
+	op.lock.Lock()
+	defer op.lock.Unlock()
+	if op.skipping {
+		return
+	}
+	now := time.Now()
+	if now.Sub(op.windowStart) >= OutputRateWindow {
+		op.windowStart = now
+		op.windowBytes = 0
+	}
+	if op.maxRate > 0 && op.windowBytes+int64(op.pending.Len()+len(data)) > op.maxRate {
+		op.skipping = true
+		op.pending.Reset()
+		if op.flushTimer != nil {
+			op.flushTimer.Stop()
+			op.flushTimer = nil
+		}
+		time.AfterFunc(OutputRateWindow-now.Sub(op.windowStart), op.endSkip)
+		return
+	}
+	if op.pending.Len() == 0 {
+		op.pendingOffset = offset
+	}
+	op.pending.Write(data)
+	if op.flushInterval == 0 || op.pending.Len() >= OutputMaxBatchSize {
+		op.flush_withlock()
+		return
+	}
+	if op.flushTimer == nil {
+		op.flushTimer = time.AfterFunc(op.flushInterval, op.flush)
+	}
+}
+
+func (op *outputPusher) flush() {
+	defer func() {
+		panichandler.PanicHandler("outputPusher:flush", recover())
+	}()
+	op.lock.Lock()
+	defer op.lock.Unlock()
+	op.flush_withlock()
+}
+
+func (op *outputPusher) flush_withlock() {
+	if op.flushTimer != nil {
+		op.flushTimer.Stop()
+		op.flushTimer = nil
+	}
+	if op.pending.Len() == 0 {
+		return
+	}
+	op.windowBytes += int64(op.pending.Len())
+	op.pushedOffset = op.pendingOffset + int64(op.pending.Len())
+	publishAppendEvent(op.blockId, wavebase.BlockFile_Term, op.pendingOffset, op.pending.Bytes())
+	op.pending.Reset()
+}
+
+func (op *outputPusher) endSkip() {
+	defer func() {
+		panichandler.PanicHandler("outputPusher:endSkip", recover())
+	}()
+	op.lock.Lock()
+	defer op.lock.Unlock()
+	op.skipping = false
+	op.windowStart = time.Now()
+	op.windowBytes = 0
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   op.blockId,
+		FileName: wavebase.BlockFile_Term,
+		FileOp:   wps.FileOp_Invalidate,
+	}})
+}
+
+// drops pending output (the term file was truncated)
+func (op *outputPusher) reset() {
+	op.lock.Lock()
+	defer op.lock.Unlock()
+	op.pending.Reset()
+	op.pushedOffset = 0
+	op.ackedOffset = 0
+	if op.flushTimer != nil {
+		op.flushTimer.Stop()
+		op.flushTimer = nil
+	}
+}
+
+func (op *outputPusher) ack(offset int64) {
+	op.lock.Lock()
+	op.ackedOffset = offset
+	op.lastAck = time.Now()
+	op.lock.Unlock()
+	select {
+	case op.ackCh <- struct{}{}:
+	default:
+	}
+}
+
+// output that has been produced but not yet written to the terminal by the frontend
+func (op *outputPusher) bufferDepth_withlock() int64 {
+	return max(0, op.pushedOffset-op.ackedOffset) + int64(op.pending.Len())
+}
+
+func (op *outputPusher) getStatus() (int64, bool) {
+	op.lock.Lock()
+	defer op.lock.Unlock()
+	return op.bufferDepth_withlock(), op.paused
+}
+
+// called by the pty read loop before each read, blocks while the consumer is too far behind
+func (op *outputPusher) waitForConsumer() {
+	deadline := time.Now().Add(BackpressureMaxWait)
+	defer func() {
+		op.lock.Lock()
+		op.paused = false
+		op.lock.Unlock()
+	}()
+	for {
+		op.lock.Lock()
+		consumerActive := time.Since(op.lastAck) < ConsumerIdleTimeout
+		overThreshold := op.bufferDepth_withlock() > BackpressureThreshold
+		op.paused = consumerActive && overThreshold
+		op.lock.Unlock()
+		remaining := time.Until(deadline)
+		if !consumerActive || !overThreshold || remaining <= 0 {
+			return
+		}
+		select {
+		case <-op.ackCh:
+		case <-time.After(remaining):
+		}
+	}
+}
+
+func HandleOutputAck(blockId string, offset int64) {
+	if op := outputPushers.Get(blockId); op != nil {
+		op.ack(offset)
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/shellexec"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// panes are extra commands (or shells) running in a block next to the block's own process.  they are started on
+// the block's connection (for ssh this is a new session on the block's client, not a new connection), each
+// pane writes to its own term file (term:pane:[paneid]), and the block shows the active pane and sends its
+// input, signals, and resizes there.  the active pane is in the runtime status (panes are not persistent, they
+// are killed when the block's process exits).  a pane is removed when its process exits.
+
+type termPane struct {
+	info       wshrpc.TermPaneInfo
+	shellProc  *shellexec.ShellProc
+	transcoder *termTranscoder
+	input      io.Writer // written from the block's input loop only
+}
+
+func (bc *BlockController) getActivePane() *termPane {
+	bc.Lock.Lock()
+	defer bc.Lock.Unlock()
+	if bc.activePane == "" {
+		return nil
+	}
+	for _, pane := range bc.panes {
+		if pane.info.PaneId == bc.activePane {
+			return pane
+		}
+	}
+	return nil
+}
+
+// must hold bc.Lock
+func (bc *BlockController) getPaneInfos_nolock() []wshrpc.TermPaneInfo {
+	var rtn []wshrpc.TermPaneInfo
+	for _, pane := range bc.panes {
+		info := pane.info
+		info.Active = info.PaneId == bc.activePane
+		rtn = append(rtn, info)
+	}
+	return rtn
+}
+
+func (bc *BlockController) CreatePane(cmdStr string, switchTo bool) (*wshrpc.TermPaneInfo, error) {
+	if bc.getRunningShellProc() == nil {
+		return nil, fmt.Errorf("block %q has no running process", bc.BlockId)
+	}
+	blockData := bc.getBlockData_noErr()
+	if blockData == nil {
+		return nil, fmt.Errorf("block %q not found", bc.BlockId)
+	}
+	blockMeta := blockData.Meta
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	var paneId string
+	bc.WithLock(func() {
+		bc.nextPaneNum++
+		paneId = strconv.Itoa(bc.nextPaneNum)
+	})
+	fileName := wavebase.BlockFile_PanePrefix + paneId
+	err := filestore.WFS.DeleteFile(ctx, bc.BlockId, fileName)
+	if err != nil && err != fs.ErrNotExist {
+		return nil, fmt.Errorf("error deleting old pane file: %w", err)
+	}
+	err = filestore.WFS.MakeFile(ctx, bc.BlockId, fileName, nil, wshrpc.FileOpts{MaxSize: getTermMaxFileSize(bc.getSettings(), blockMeta), Circular: true})
+	if err != nil {
+		return nil, fmt.Errorf("error creating pane file: %w", err)
+	}
+	remoteName := blockMeta.GetString(waveobj.MetaKey_Connection, "")
+	connUnion, err := bc.getConnUnion(ctx, remoteName, blockMeta)
+	if err != nil {
+		return nil, err
+	}
+	var cmdOpts shellexec.CommandOptsType
+	if cmdStr == "" {
+		cmdOpts.Login, cmdOpts.Interactive, cmdOpts.RcFile = getShellStartupOpts(bc.getSettings(), blockMeta)
+		cmdOpts.ShellPath = getShellPathOverride(blockMeta)
+	}
+	cmdOpts.Cwd, err = getStartCwd(blockMeta, remoteName)
+	if err != nil {
+		return nil, err
+	}
+	shellProc, err := bc.startConnShellProc(ctx, ctx, getTermSize(blockData), cmdStr, cmdOpts, blockMeta, remoteName, connUnion, false)
+	if err != nil {
+		return nil, fmt.Errorf("error starting pane: %w", err)
+	}
+	termEnc, err := getTermEncoding(blockMeta)
+	if err != nil {
+		log.Printf("block %s: %v (using utf-8)\n", bc.BlockId, err)
+	}
+	pane := &termPane{
+		info: wshrpc.TermPaneInfo{
+			PaneId:   paneId,
+			Cmd:      cmdStr,
+			FileName: fileName,
+			StartTs:  time.Now().UnixMilli(),
+		},
+		shellProc:  shellProc,
+		transcoder: makeTermTranscoder(termEnc),
+	}
+	pane.input = pane.transcoder.wrapInput(shellProc.Cmd)
+	bc.UpdateControllerAndSendUpdate(func() bool {
+		bc.panes = append(bc.panes, pane)
+		if switchTo {
+			bc.activePane = paneId
+		}
+		return true
+	})
+	go bc.runPane(pane)
+	info := pane.info
+	info.Active = switchTo
+	return &info, nil
+}
+
+func (bc *BlockController) runPane(pane *termPane) {
+	defer func() {
+		panichandler.PanicHandler("blockcontroller:runPane", recover())
+	}()
+	reader := pane.transcoder.wrapOutput(pane.shellProc.Cmd)
+	buf := make([]byte, 4096)
+	for {
+		nr, err := reader.Read(buf)
+		if nr > 0 {
+			appendErr := HandleAppendBlockFile(bc.BlockId, pane.info.FileName, buf[:nr])
+			if appendErr != nil {
+				log.Printf("error appending to pane file: %v\n", appendErr)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("error reading from pane %s in block %s: %v\n", pane.info.PaneId, bc.BlockId, err)
+			break
+		}
+	}
+	pane.shellProc.Close()
+	<-pane.shellProc.DoneCh
+	bc.UpdateControllerAndSendUpdate(func() bool {
+		for idx, p := range bc.panes {
+			if p == pane {
+				bc.panes = append(bc.panes[:idx], bc.panes[idx+1:]...)
+				break
+			}
+		}
+		if bc.activePane == pane.info.PaneId {
+			bc.activePane = ""
+		}
+		return true
+	})
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	err := filestore.WFS.DeleteFile(ctx, bc.BlockId, pane.info.FileName)
+	if err != nil && err != fs.ErrNotExist {
+		log.Printf("error deleting pane file: %v\n", err)
+	}
+}
+
+func (bc *BlockController) getPane(paneId string) *termPane {
+	bc.Lock.Lock()
+	defer bc.Lock.Unlock()
+	for _, pane := range bc.panes {
+		if pane.info.PaneId == paneId {
+			return pane
+		}
+	}
+	return nil
+}
+
+// the pane is removed once its process has exited
+func (bc *BlockController) KillPane(paneId string) error {
+	pane := bc.getPane(paneId)
+	if pane == nil {
+		return fmt.Errorf("pane %q not found in block %q", paneId, bc.BlockId)
+	}
+	pane.shellProc.Close()
+	return nil
+}
+
+func (bc *BlockController) killAllPanes() {
+	var panes []*termPane
+	bc.WithLock(func() {
+		panes = append(panes, bc.panes...)
+	})
+	for _, pane := range panes {
+		pane.shellProc.Close()
+	}
+}
+
+// an empty paneId switches back to the block's own process
+func (bc *BlockController) SwitchPane(paneId string) error {
+	if paneId != "" && bc.getPane(paneId) == nil {
+		return fmt.Errorf("pane %q not found in block %q", paneId, bc.BlockId)
+	}
+	bc.UpdateControllerAndSendUpdate(func() bool {
+		if bc.activePane == paneId {
+			return false
+		}
+		bc.activePane = paneId
+		return true
+	})
+	return nil
+}
+
+func (bc *BlockController) resizePanes(termSize waveobj.TermSize) {
+	var panes []*termPane
+	bc.WithLock(func() {
+		panes = append(panes, bc.panes...)
+	})
+	for _, pane := range panes {
+		err := pane.shellProc.Cmd.SetSize(termSize.Rows, termSize.Cols)
+		if err != nil {
+			log.Printf("error setting pane pty size: %v\n", err)
+		}
+	}
+}
+
+func (pane *termPane) writeInput(data []byte) {
+	_, err := pane.input.Write(data)
+	if err != nil {
+		log.Printf("error writing to pane %s: %v\n", pane.info.PaneId, err)
+	}
+}
+
+func CreatePane(blockId string, cmdStr string, switchTo bool) (*wshrpc.TermPaneInfo, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return nil, fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.CreatePane(cmdStr, switchTo)
+}
+
+func KillPane(blockId string, paneId string) error {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.KillPane(paneId)
+}
+
+func SwitchPane(blockId string, paneId string) error {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.SwitchPane(paneId)
+}
+
+func ListPanes(blockId string) ([]wshrpc.TermPaneInfo, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return nil, fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	var rtn []wshrpc.TermPaneInfo
+	bc.WithLock(func() {
+		rtn = bc.getPaneInfos_nolock()
+	})
+	return rtn, nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/blocklogger"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/shellexec"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// persistent sessions run local shells in a ptyhost helper process (see pkg/ptyhost) so they
+// survive wavesrv restarts.  the helper's socket is recorded in shellprocs.json alongside its pid.
+// on ssh connections with wsh the helper runs on the remote host (see wshremote/remotepty.go), the
+// shell also survives the connection dropping and is reattached when the connection is back.
+
+const PtyHostDirName = "ptyhost"
+const DetachTimeout = DefaultTimeout
+
+func usePersistentSession(controllerType string, connType string) bool {
+	if controllerType != BlockController_Shell {
+		return false
+	}
+	if connType != ConnType_Ssh && (connType != ConnType_Local || runtime.GOOS == "windows") {
+		return false
+	}
+	return wconfig.GetWatcher().GetFullConfig().Settings.TermPersistentSessions
+}
+
+// socket paths are kept short (unix socket paths are limited to ~104 bytes on macos)
+func makePtyHostSockPath() (string, error) {
+	dirName := filepath.Join(wavebase.GetWaveDataDir(), PtyHostDirName)
+	err := wavebase.CacheEnsureDir(dirName, PtyHostDirName, 0700, "ptyhost directory")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirName, uuid.New().String()[:8]+".sock"), nil
+}
+
+func getTrackedProc(blockId string) (trackedProc, bool) {
+	procTrackerLock.Lock()
+	defer procTrackerLock.Unlock()
+	tp, ok := trackedProcs[blockId]
+	return tp, ok
+}
+
+func blockExists(blockId string) bool {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	block, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+	return err == nil && block != nil
+}
+
+func getSshConn(connName string) *conncontroller.SSHConn {
+	opts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return nil
+	}
+	return conncontroller.GetConn(opts)
+}
+
+// returns nil if there is no persistent session to reattach to (or it could not be reattached).  a session on an
+// ssh connection that is not connected is kept (with an error), it is reattached once the connection is back.
+func (bc *BlockController) reattachPersistentSession(logCtx context.Context, blockMeta waveobj.MetaMapType) (*shellexec.ShellProc, error) {
+	tp, ok := getTrackedProc(bc.BlockId)
+	if !ok || tp.SockPath == "" {
+		return nil, nil
+	}
+	var shellProc *shellexec.ShellProc
+	var err error
+	if tp.ConnName != "" {
+		if blockMeta.GetString(waveobj.MetaKey_Connection, "") != tp.ConnName {
+			killTrackedProc(bc.BlockId, tp)
+			return nil, nil
+		}
+		conn := getSshConn(tp.ConnName)
+		if conn == nil || conn.GetStatus() != conncontroller.Status_Connected {
+			return nil, fmt.Errorf("ssh connection %s not connected, cannot reattach to the shell", tp.ConnName)
+		}
+		ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancelFn()
+		shellProc, err = shellexec.AttachRemotePtyHostShellProc(ctx, conn, tp.SockPath)
+	} else {
+		shellProc, err = shellexec.AttachPtyHostShellProc(tp.SockPath)
+	}
+	if err != nil {
+		blocklogger.Infof(logCtx, "[conndebug] could not reattach to persistent shell session: %v\n", err)
+		killTrackedProc(bc.BlockId, tp)
+		return nil, nil
+	}
+	if tp.ConnName != "" {
+		blocklogger.Infof(logCtx, "[conndebug] reattached to persistent shell session on %s (ptyhost pid %d)\n", tp.ConnName, tp.Pid)
+	} else {
+		blocklogger.Infof(logCtx, "[conndebug] reattached to persistent shell session (ptyhost pid %d)\n", tp.Pid)
+	}
+	bc.UpdateControllerAndSendUpdate(func() bool {
+		bc.ShellProc = shellProc
+		bc.ShellProcStatus = Status_Running
+		return true
+	})
+	trackShellProc(bc.BlockId, shellProc)
+	return shellProc, nil
+}
+
+func killTrackedProc(blockId string, tp trackedProc) {
+	procTrackerLock.Lock()
+	if cur, ok := trackedProcs[blockId]; ok && cur.Pid == tp.Pid && cur.ConnName == tp.ConnName {
+		delete(trackedProcs, blockId)
+		writeTrackedProcs()
+	}
+	procTrackerLock.Unlock()
+	if tp.ConnName != "" {
+		killRemotePtyHost(tp)
+		return
+	}
+	if shellexec.GetProcCreateTime(tp.Pid) == tp.CreateTime {
+		shellexec.KillProcessTree(tp.Pid)
+	}
+	if tp.SockPath != "" {
+		os.Remove(tp.SockPath)
+	}
+}
+
+// asks the helper of a remote session to kill its shell (the helper then exits), if the connection is up
+func killRemotePtyHost(tp trackedProc) {
+	conn := getSshConn(tp.ConnName)
+	if conn == nil || conn.GetStatus() != conncontroller.Status_Connected {
+		return
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	shellProc, err := shellexec.AttachRemotePtyHostShellProc(ctx, conn, tp.SockPath)
+	if err != nil {
+		return
+	}
+	defer shellProc.Cmd.Close()
+	shellProc.Cmd.Kill()
+	waitCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("killRemotePtyHost", recover())
+		}()
+		waitCh <- shellProc.Cmd.Wait()
+	}()
+	select {
+	case <-waitCh:
+	case <-ctx.Done():
+		log.Printf("timeout killing persistent shell session on %s (ptyhost pid %d)\n", tp.ConnName, tp.Pid)
+	}
+}
+
+func (bc *BlockController) detachShellProc() error {
+	shellProc := bc.getShellProc()
+	if shellProc == nil {
+		return fmt.Errorf("no shell process")
+	}
+	return shellProc.Detach(DetachTimeout)
+}
+
+// detaches from all persistent sessions (leaving the shells running), returns once they are all detached
+func DetachAllPersistentSessions() {
+	wg := &sync.WaitGroup{}
+	for _, bc := range getControllerList() {
+		shellProc := bc.getShellProc()
+		if shellProc == nil || shellProc.GetPtyHostSock() == "" || bc.GetRuntimeStatus().ShellProcStatus != Status_Running {
+			continue
+		}
+		wg.Add(1)
+		go func(bc *BlockController) {
+			defer wg.Done()
+			defer func() {
+				panichandler.PanicHandler("DetachAllPersistentSessions", recover())
+			}()
+			err := bc.detachShellProc()
+			if err != nil {
+				log.Printf("error detaching persistent session (block %s): %v\n", bc.BlockId, err)
+			}
+		}(bc)
+	}
+	wg.Wait()
+}
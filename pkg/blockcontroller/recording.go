@@ -0,0 +1,392 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/util/asciicast"
+	"github.com/wavetermdev/waveterm/pkg/util/ds"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wi18n"
+	"github.com/wavetermdev/waveterm/pkg/wnotify"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// a block's term output can be recorded as an asciicast (v2): a json header line followed by one json line
+// per event, [time, "o", data] for output and [time, "r", "COLSxROWS"] for resizes.  recordings are blockfiles
+// named cast:[timestamp] (not circular, so a recording is never cut off at the start), which can be exported
+// with "wsh file cp" and replayed by the player view.  a recording is started and stopped with the termrecord
+// rpcs, or started with every shell process when term:record is set.  it always stops when the shell process
+// exits or when it reaches MaxRecordingSize.  the output while a password is being read is left out (with a
+// [time, "m", "secure input"] marker in its place).
+
+const (
+	MaxRecordingSize      = 64 * 1024 * 1024
+	FileMeta_CastTitle    = "casttitle"
+	FileMeta_CastStartTs  = "caststartts"
+	FileMeta_CastDuration = "castduration"
+	FileMeta_CastWidth    = "castwidth"
+	FileMeta_CastHeight   = "castheight"
+	castFileTimeFormat    = "20060102-150405"
+)
+
+var termRecorders = ds.MakeSyncMap[*termRecorder]()
+
+// serializes starting and stopping recordings
+var recorderLock sync.Mutex
+
+type termRecorder struct {
+	lock      sync.Mutex
+	blockId   string
+	fileName  string
+	title     string
+	startTime time.Time
+	width     int // size in the header
+	height    int
+	termSize  waveobj.TermSize // current size
+	size      int64
+	lastTime  float64
+	partial   []byte // an incomplete utf-8 sequence at the end of the last chunk
+	secure    bool   // a password is being read, output is not recorded (see secureinput.go)
+	done      bool
+}
+
+// block meta overrides the global setting
+func getRecordOpt(blockMeta waveobj.MetaMapType) bool {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	return blockMeta.GetBool(waveobj.MetaKey_TermRecord, settings.TermRecord)
+}
+
+func getFileMetaNum(meta wshrpc.FileMeta, key string) float64 {
+	// int64 if the file is still in the cache, float64 once it has been read back from the db
+	switch val := meta[key].(type) {
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case float64:
+		return val
+	}
+	return 0
+}
+
+func makeCastFileName(ctx context.Context, blockId string, startTime time.Time) string {
+	baseName := wavebase.BlockFile_CastPrefix + startTime.Format(castFileTimeFormat)
+	fileName := baseName
+	for idx := 2; ; idx++ {
+		if _, err := filestore.WFS.Stat(ctx, blockId, fileName); err == fs.ErrNotExist {
+			return fileName
+		}
+		fileName = fmt.Sprintf("%s-%d", baseName, idx)
+	}
+}
+
+func startTermRecorder(ctx context.Context, blockId string, termSize waveobj.TermSize, title string) (*wshrpc.TermRecording, error) {
+	recorderLock.Lock()
+	defer recorderLock.Unlock()
+	if termRecorders.Get(blockId) != nil {
+		return nil, fmt.Errorf("block %q is already being recorded", blockId)
+	}
+	if termSize.Rows <= 0 || termSize.Cols <= 0 {
+		termSize = shellutil.DefaultTermSize()
+	}
+	startTime := time.Now()
+	tr := &termRecorder{
+		blockId:   blockId,
+		fileName:  makeCastFileName(ctx, blockId, startTime),
+		title:     title,
+		startTime: startTime,
+		width:     termSize.Cols,
+		height:    termSize.Rows,
+		termSize:  termSize,
+	}
+	header := asciicast.Header{
+		Version:   2,
+		Width:     tr.width,
+		Height:    tr.height,
+		Timestamp: startTime.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	}
+	barr, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling cast header: %w", err)
+	}
+	barr = append(barr, '\n')
+	fileMeta := wshrpc.FileMeta{
+		FileMeta_CastTitle:   title,
+		FileMeta_CastStartTs: startTime.UnixMilli(),
+		FileMeta_CastWidth:   tr.width,
+		FileMeta_CastHeight:  tr.height,
+	}
+	err = filestore.WFS.MakeFile(ctx, blockId, tr.fileName, fileMeta, wshrpc.FileOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("error making recording file: %w", err)
+	}
+	err = filestore.WFS.AppendData(ctx, blockId, tr.fileName, barr)
+	if err != nil {
+		return nil, fmt.Errorf("error writing cast header: %w", err)
+	}
+	tr.size = int64(len(barr))
+	termRecorders.Set(blockId, tr)
+	return tr.getRecording(), nil
+}
+
+// returns nil if the block was not being recorded
+func stopTermRecorder(blockId string) *wshrpc.TermRecording {
+	recorderLock.Lock()
+	defer recorderLock.Unlock()
+	tr := termRecorders.Get(blockId)
+	if tr == nil {
+		return nil
+	}
+	termRecorders.Delete(blockId)
+	tr.finish()
+	return tr.getRecording()
+}
+
+func (tr *termRecorder) getRecording() *wshrpc.TermRecording {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	return &wshrpc.TermRecording{
+		BlockId:  tr.blockId,
+		FileName: tr.fileName,
+		Title:    tr.title,
+		StartTs:  tr.startTime.UnixMilli(),
+		Duration: tr.lastTime,
+		Width:    tr.width,
+		Height:   tr.height,
+		Size:     tr.size,
+		Active:   !tr.done,
+	}
+}
+
+func (tr *termRecorder) finish() {
+	tr.lock.Lock()
+	tr.done = true
+	tr.partial = nil
+	tr.lock.Unlock()
+	tr.writeDuration()
+}
+
+// the duration is written to the file meta when the recording stops (and on shutdown, see
+// CheckpointAllBlockControllers)
+func (tr *termRecorder) writeDuration() {
+	tr.lock.Lock()
+	duration := tr.lastTime
+	tr.lock.Unlock()
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	err := filestore.WFS.WriteMeta(ctx, tr.blockId, tr.fileName, wshrpc.FileMeta{FileMeta_CastDuration: duration}, true)
+	if err != nil {
+		log.Printf("error writing recording duration for block %s: %v\n", tr.blockId, err)
+	}
+}
+
+// splits off an incomplete utf-8 sequence at the end of data (so a character split across reads is not
+// written as two invalid ones)
+func splitUtf8Tail(data []byte) ([]byte, []byte) {
+	for idx := len(data) - 1; idx >= 0 && idx >= len(data)-utf8.UTFMax; idx-- {
+		if !utf8.RuneStart(data[idx]) {
+			continue
+		}
+		if utf8.FullRune(data[idx:]) {
+			return data, nil
+		}
+		return data[:idx], data[idx:]
+	}
+	return data, nil
+}
+
+func (tr *termRecorder) writeOutput(data []byte) {
+	tr.lock.Lock()
+	if tr.done || tr.secure {
+		tr.lock.Unlock()
+		return
+	}
+	data = append(tr.partial, data...)
+	data, partial := splitUtf8Tail(data)
+	tr.partial = append([]byte(nil), partial...)
+	full := false
+	if len(data) > 0 {
+		full = tr.writeEvent_withlock(asciicast.EventOutput, string(data))
+	}
+	tr.lock.Unlock()
+	if full {
+		tr.stopFull()
+	}
+}
+
+// the recording gets a marker event where the output was left out
+func (tr *termRecorder) setSecureInput(secure bool) {
+	tr.lock.Lock()
+	if tr.done || secure == tr.secure {
+		tr.lock.Unlock()
+		return
+	}
+	tr.secure = secure
+	tr.partial = nil
+	full := false
+	if secure {
+		full = tr.writeEvent_withlock(asciicast.EventMarker, "secure input")
+	}
+	tr.lock.Unlock()
+	if full {
+		tr.stopFull()
+	}
+}
+
+func (tr *termRecorder) writeResize(termSize waveobj.TermSize) {
+	tr.lock.Lock()
+	if tr.done || termSize == tr.termSize {
+		tr.lock.Unlock()
+		return
+	}
+	tr.termSize = termSize
+	full := tr.writeEvent_withlock(asciicast.EventResize, fmt.Sprintf("%dx%d", termSize.Cols, termSize.Rows))
+	tr.lock.Unlock()
+	if full {
+		tr.stopFull()
+	}
+}
+
+// returns true if the recording has reached MaxRecordingSize (the event is not written)
+func (tr *termRecorder) writeEvent_withlock(code string, data string) bool {
+	elapsed := math.Round(time.Since(tr.startTime).Seconds()*1e6) / 1e6
+	elapsed = max(elapsed, tr.lastTime)
+	barr, err := json.Marshal(asciicast.Event{Time: elapsed, Code: code, Data: data})
+	if err != nil {
+		log.Printf("error marshaling cast event for block %s: %v\n", tr.blockId, err)
+		return false
+	}
+	barr = append(barr, '\n')
+	if tr.size+int64(len(barr)) > MaxRecordingSize {
+		return true
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	err = filestore.WFS.AppendData(ctx, tr.blockId, tr.fileName, barr)
+	if err != nil {
+		log.Printf("error appending to recording for block %s: %v\n", tr.blockId, err)
+		return false
+	}
+	tr.size += int64(len(barr))
+	tr.lastTime = elapsed
+	return false
+}
+
+func (tr *termRecorder) stopFull() {
+	log.Printf("recording %s for block %s reached its size limit, stopping\n", tr.fileName, tr.blockId)
+	stopTermRecorder(tr.blockId)
+	if bc := GetBlockController(tr.blockId); bc != nil {
+		bc.sendStatusUpdate()
+	}
+	wnotify.Notify(wshrpc.CommandNotificationSendData{
+		Title:   wi18n.T("notify.recording.stopped.title"),
+		Message: wi18n.T("notify.recording.stopped.message", tr.fileName, MaxRecordingSize/(1024*1024)),
+		Type:    wnotify.Type_Warning,
+		Source:  wnotify.Source_Controller,
+		BlockId: tr.blockId,
+	})
+}
+
+func (bc *BlockController) sendStatusUpdate() {
+	bc.UpdateControllerAndSendUpdate(func() bool { return true })
+}
+
+func (bc *BlockController) StartRecording(title string) (*wshrpc.TermRecording, error) {
+	if bc.GetRuntimeStatus().ShellProcStatus != Status_Running {
+		return nil, fmt.Errorf("block %q does not have a running shell process", bc.BlockId)
+	}
+	blockData := bc.getBlockData_noErr()
+	if blockData == nil {
+		return nil, fmt.Errorf("block %q not found", bc.BlockId)
+	}
+	var termSize waveobj.TermSize
+	if blockData.RuntimeOpts != nil {
+		termSize = blockData.RuntimeOpts.TermSize
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	rtn, err := startTermRecorder(ctx, bc.BlockId, termSize, title)
+	if err != nil {
+		return nil, err
+	}
+	bc.sendStatusUpdate()
+	return rtn, nil
+}
+
+func (bc *BlockController) StopRecording() (*wshrpc.TermRecording, error) {
+	rtn := stopTermRecorder(bc.BlockId)
+	if rtn == nil {
+		return nil, fmt.Errorf("block %q is not being recorded", bc.BlockId)
+	}
+	bc.sendStatusUpdate()
+	return rtn, nil
+}
+
+func StartRecording(blockId string, title string) (*wshrpc.TermRecording, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return nil, fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.StartRecording(title)
+}
+
+func StopRecording(blockId string) (*wshrpc.TermRecording, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return nil, fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.StopRecording()
+}
+
+// returns the block's recordings, oldest first
+func ListRecordings(ctx context.Context, blockId string) ([]wshrpc.TermRecording, error) {
+	files, err := filestore.WFS.ListFiles(ctx, blockId)
+	if err != nil {
+		return nil, fmt.Errorf("error listing blockfiles: %w", err)
+	}
+	activeRecorder := termRecorders.Get(blockId)
+	var rtn []wshrpc.TermRecording
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name, wavebase.BlockFile_CastPrefix) {
+			continue
+		}
+		if activeRecorder != nil && file.Name == activeRecorder.fileName {
+			rtn = append(rtn, *activeRecorder.getRecording())
+			continue
+		}
+		title, _ := file.Meta[FileMeta_CastTitle].(string)
+		rtn = append(rtn, wshrpc.TermRecording{
+			BlockId:  blockId,
+			FileName: file.Name,
+			Title:    title,
+			StartTs:  int64(getFileMetaNum(file.Meta, FileMeta_CastStartTs)),
+			Duration: getFileMetaNum(file.Meta, FileMeta_CastDuration),
+			Width:    int(getFileMetaNum(file.Meta, FileMeta_CastWidth)),
+			Height:   int(getFileMetaNum(file.Meta, FileMeta_CastHeight)),
+			Size:     file.Size,
+		})
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].StartTs < rtn[j].StartTs
+	})
+	return rtn, nil
+}
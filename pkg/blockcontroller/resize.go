@@ -0,0 +1,119 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/shellexec"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// resizes are streamed from the frontend while a block is being dragged.  they are debounced here and
+// only the last size is applied, to the pty and to the block's RuntimeOpts under one lock so the two
+// never disagree.  the RuntimeOpts update is not broadcast (the frontend already knows its own size).
+
+const ResizeDebounceTime = 50 * time.Millisecond
+
+type termResizer struct {
+	lock      sync.Mutex // protects pending and timer
+	applyLock sync.Mutex // held while a size is applied
+	pending   *waveobj.TermSize
+	timer     *time.Timer
+}
+
+// if final is set the size is applied right away (e.g. the initial size), otherwise once resizes stop
+func (bc *BlockController) RequestResize(termSize waveobj.TermSize, final bool) {
+	tr := bc.Resizer
+	tr.lock.Lock()
+	tr.pending = &termSize
+	if final {
+		if tr.timer != nil {
+			tr.timer.Stop()
+			tr.timer = nil
+		}
+		tr.lock.Unlock()
+		bc.applyPendingResize()
+		return
+	}
+	if tr.timer == nil {
+		tr.timer = time.AfterFunc(ResizeDebounceTime, bc.applyPendingResize)
+	} else {
+		tr.timer.Reset(ResizeDebounceTime)
+	}
+	tr.lock.Unlock()
+}
+
+func (bc *BlockController) applyPendingResize() {
+	defer func() {
+		panichandler.PanicHandler("blockcontroller:applyPendingResize", recover())
+	}()
+	tr := bc.Resizer
+	tr.applyLock.Lock()
+	defer tr.applyLock.Unlock()
+	tr.lock.Lock()
+	termSize := tr.pending
+	tr.pending = nil
+	tr.timer = nil
+	tr.lock.Unlock()
+	if termSize == nil {
+		return
+	}
+	var shellProc *shellexec.ShellProc
+	bc.WithLock(func() {
+		if bc.ShellProcStatus == Status_Running {
+			shellProc = bc.ShellProc
+		}
+	})
+	if shellProc != nil {
+		err := shellProc.Cmd.SetSize(termSize.Rows, termSize.Cols)
+		if err != nil {
+			log.Printf("error setting pty size: %v\n", err)
+		}
+	}
+	bc.resizePanes(*termSize)
+	if rec := termRecorders.Get(bc.BlockId); rec != nil {
+		rec.writeResize(*termSize)
+	}
+	err := setTermSizeInDB(bc.BlockId, *termSize)
+	if err != nil {
+		log.Printf("error setting term size in db: %v\n", err)
+	}
+}
+
+// full screen applications redraw on SIGWINCH, which is only sent when the size changes.  the pty is made one
+// column narrower and then set back (see altscreen.go).
+func (bc *BlockController) forceRedraw(termSize waveobj.TermSize) {
+	defer func() {
+		panichandler.PanicHandler("blockcontroller:forceRedraw", recover())
+	}()
+	if termSize.Rows <= 0 || termSize.Cols <= 1 {
+		return
+	}
+	tr := bc.Resizer
+	tr.applyLock.Lock()
+	defer tr.applyLock.Unlock()
+	var shellProc *shellexec.ShellProc
+	bc.WithLock(func() {
+		if bc.ShellProcStatus == Status_Running {
+			shellProc = bc.ShellProc
+		}
+	})
+	if shellProc == nil {
+		return
+	}
+	err := shellProc.Cmd.SetSize(termSize.Rows, termSize.Cols-1)
+	if err != nil {
+		log.Printf("error setting pty size: %v\n", err)
+		return
+	}
+	time.Sleep(RedrawNudgeDelay)
+	err = shellProc.Cmd.SetSize(termSize.Rows, termSize.Cols)
+	if err != nil {
+		log.Printf("error setting pty size: %v\n", err)
+	}
+}
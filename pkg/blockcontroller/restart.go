@@ -0,0 +1,88 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// cmd:restart restarts a block's process when it exits ("onfailure" only for non-zero exit codes), unless the
+// block is closing on exit or the process was stopped.  a process that exits within RestartMinRunTime of
+// starting is restarted with a doubling delay (up to RestartMaxDelay) so a command that fails right away
+// doesn't spin.
+
+const (
+	CmdRestart_Never     = "never"
+	CmdRestart_OnFailure = "onfailure"
+	CmdRestart_Always    = "always"
+)
+
+const (
+	DefaultRestartDelayMs = 1000
+	RestartMinRunTime     = 5 * time.Second
+	RestartMaxDelay       = 30 * time.Second
+)
+
+func shouldRestart(restartMode string, exitCode int) bool {
+	switch restartMode {
+	case CmdRestart_Always:
+		return true
+	case CmdRestart_OnFailure:
+		return exitCode != 0
+	}
+	return false
+}
+
+func (bc *BlockController) checkRestartOnExit(exitCode int, runTime time.Duration) {
+	blockData := bc.getBlockData_noErr()
+	if blockData == nil {
+		return
+	}
+	restartMode := blockData.Meta.GetString(waveobj.MetaKey_CmdRestart, CmdRestart_Never)
+	if !shouldRestart(restartMode, exitCode) {
+		return
+	}
+	delay := time.Duration(max(0, blockData.Meta.GetFloat(waveobj.MetaKey_CmdRestartDelay, DefaultRestartDelayMs))) * time.Millisecond
+	var tabId string
+	var stopped bool
+	var startCount int
+	bc.WithLock(func() {
+		if runTime < RestartMinRunTime {
+			bc.restartBackoff = min(RestartMaxDelay, max(delay, time.Second, bc.restartBackoff*2))
+		} else {
+			bc.restartBackoff = delay
+		}
+		delay = bc.restartBackoff
+		tabId = bc.TabId
+		stopped = bc.stopRequested
+		startCount = bc.procStartCount
+	})
+	if stopped || tabId == "" {
+		return
+	}
+	termMsg := fmt.Sprintf("\r\n[process exited with code %d, restarting in %v]\r\n", exitCode, delay.Round(100*time.Millisecond))
+	HandleAppendBlockFile(bc.BlockId, wavebase.BlockFile_Term, []byte(termMsg))
+	time.Sleep(delay)
+	// don't restart if the block was restarted, stopped, or closed in the meantime
+	var curStatus string
+	bc.WithLock(func() {
+		stopped = bc.stopRequested || bc.procStartCount != startCount
+		curStatus = bc.ShellProcStatus
+	})
+	if stopped || curStatus != Status_Done || GetBlockController(bc.BlockId) != bc {
+		return
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	err := ResyncController(ctx, tabId, bc.BlockId, nil, true)
+	if err != nil {
+		log.Printf("error restarting block %s: %v\n", bc.BlockId, err)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// pastes from the terminal are checked before they are written to the pty.  a paste that would run by itself
+// (newlines, when the application has not turned on bracketed paste), that has control characters (which could
+// end a bracketed paste early), or that looks like it downloads and runs a script or uses sudo is not written
+// until it is sent again with Confirm set.  the checks are turned off with term:safepaste.
+//
+// bracketed paste mode (DECSET 2004) is tracked from the pty output.  when the application has turned it on the
+// paste is wrapped in the bracketed paste markers, unless term:allowbracketedpaste is set to false.
+
+const (
+	BracketedPasteStart = "\x1b[200~"
+	BracketedPasteEnd   = "\x1b[201~"
+	bracketedPasteOn    = "\x1b[?2004h"
+	bracketedPasteOff   = "\x1b[?2004l"
+)
+
+type pasteCheck struct {
+	re      *regexp.Regexp
+	warning string
+}
+
+var suspiciousPasteChecks = []pasteCheck{
+	{regexp.MustCompile(`\b(curl|wget|fetch)\b[^|;&\n]*\|\s*(sudo\s+)?(\S*/)?(ba|z|fi|da|k)?sh\b`), "downloads and runs a script"},
+	{regexp.MustCompile(`\b(curl|wget|fetch)\b[^|;&\n]*\|\s*(sudo\s+)?(\S*/)?(python3?|perl|ruby|node)\b`), "downloads and runs a script"},
+	{regexp.MustCompile(`(?i)\b(iwr|irm|invoke-webrequest|invoke-restmethod)\b[^|;\n]*\|\s*(iex|invoke-expression)\b`), "downloads and runs a script"},
+	{regexp.MustCompile(`\$\(\s*(curl|wget)\b`), "downloads and runs a script"},
+	{regexp.MustCompile(`(^|[\s;&|(])sudo\s`), "runs a command with sudo"},
+}
+
+// returns why the paste should be confirmed, nothing if it is safe to write
+func checkPaste(text string, bracketed bool) []string {
+	var warnings []string
+	if !bracketed && strings.ContainsAny(text, "\r\n") {
+		warnings = append(warnings, "contains newlines (the command runs when it is pasted)")
+	}
+	for _, ch := range text {
+		if (ch < 0x20 && ch != '\t' && ch != '\n' && ch != '\r') || (ch >= 0x7f && ch <= 0x9f) {
+			warnings = append(warnings, "contains control characters")
+			break
+		}
+	}
+	seen := make(map[string]bool)
+	for _, check := range suspiciousPasteChecks {
+		if seen[check.warning] || !check.re.MatchString(text) {
+			continue
+		}
+		seen[check.warning] = true
+		warnings = append(warnings, check.warning)
+	}
+	return warnings
+}
+
+// newlines are sent as carriage returns (like a terminal does), the end marker is removed from a bracketed paste
+// so the paste cannot end early
+func formatPaste(text string, bracketed bool) string {
+	text = strings.ReplaceAll(text, "\r\n", "\r")
+	text = strings.ReplaceAll(text, "\n", "\r")
+	if !bracketed {
+		return text
+	}
+	text = strings.ReplaceAll(text, BracketedPasteEnd, "")
+	return BracketedPasteStart + text + BracketedPasteEnd
+}
+
+// the settings of the block (with its meta, see wsettings.ResolveForBlock), so a workspace can lock term:safepaste
+func getPasteOpts(settings *wconfig.SettingsType) (safePaste bool, allowBracketed bool) {
+	return wconfig.DefaultBoolPtr(settings.TermSafePaste, true), wconfig.DefaultBoolPtr(settings.TermAllowBracketedPaste, true)
+}
+
+func (bc *BlockController) Paste(text string, confirm bool) (*wshrpc.CommandControllerPasteRtnData, error) {
+	blockData := bc.getBlockData_noErr()
+	if blockData == nil {
+		return nil, fmt.Errorf("block %q not found", bc.BlockId)
+	}
+	safePaste, allowBracketed := getPasteOpts(bc.getSettings())
+	rtn := &wshrpc.CommandControllerPasteRtnData{BracketedPaste: allowBracketed && bc.bracketedPaste.Load()}
+	if safePaste {
+		rtn.Warnings = checkPaste(text, rtn.BracketedPaste)
+	}
+	if len(rtn.Warnings) > 0 && !confirm {
+		rtn.NeedsConfirm = true
+		return rtn, nil
+	}
+	err := bc.SendInput(&BlockInputUnion{InputData: []byte(formatPaste(text, rtn.BracketedPaste))})
+	if err != nil {
+		return nil, err
+	}
+	rtn.Pasted = true
+	return rtn, nil
+}
+
+func Paste(blockId string, text string, confirm bool) (*wshrpc.CommandControllerPasteRtnData, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return nil, fmt.Errorf("block controller not found for block %q", blockId)
+	}
+	return bc.Paste(text, confirm)
+}
+
+// watches the pty output for the application turning bracketed paste mode on and off
+type pasteModeTracker struct {
+	bc   *BlockController
+	tail []byte // the end of the previous chunk, for a sequence split across reads
+}
+
+func (bc *BlockController) makePasteModeTracker() *pasteModeTracker {
+	bc.bracketedPaste.Store(false)
+	return &pasteModeTracker{bc: bc}
+}
+
+func (pt *pasteModeTracker) Write(chunk []byte) {
+	maxTail := len(bracketedPasteOn) - 1
+	seam := append(pt.tail, chunk[:min(len(chunk), maxTail)]...)
+	pt.update(seam)
+	pt.update(chunk)
+	if len(chunk) >= maxTail {
+		pt.tail = append(pt.tail[:0], chunk[len(chunk)-maxTail:]...)
+	} else {
+		pt.tail = append(pt.tail[:0], seam[max(0, len(seam)-maxTail):]...)
+	}
+}
+
+func (pt *pasteModeTracker) update(data []byte) {
+	onIdx := bytes.LastIndex(data, []byte(bracketedPasteOn))
+	offIdx := bytes.LastIndex(data, []byte(bracketedPasteOff))
+	if onIdx > offIdx {
+		pt.bc.bracketedPaste.Store(true)
+	} else if offIdx > onIdx {
+		pt.bc.bracketedPaste.Store(false)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/ds"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// scrollback is limited in bytes by the size of the (circular) term file, and in lines by the term file's line
+// index (see filestore.ReadLines).  the offset of the first of the last N lines is written to the term file's
+// meta so the terminal only replays the last N lines when it loads, however much output is in the file.
+
+const (
+	MinTermMaxFileSize         = filestore.DefaultPartDataSize
+	MaxTermMaxFileSize         = 64 * 1024 * 1024
+	DefaultTermScrollbackLines = 2000 // matches the frontend default
+	MaxTermScrollbackLines     = 50000
+	FileMeta_ScrollbackStart   = "scrollbackstart"
+	ScrollbackMetaInterval     = time.Second
+)
+
+var scrollbackTrackers = ds.MakeSyncMap[*scrollbackTracker]()
+var settingsHandlerOnce = &sync.Once{}
+
+// a change of term:scrollback (in the settings of wave or of a workspace) applies to the running terminals right
+// away, a change of term:scrollbackbytes when their shell is started again (the term file has to be resized)
+func StartSettingsHandler() {
+	settingsHandlerOnce.Do(func() {
+		eventbus.Subscribe(eventbus.Topic_SettingsChange, eventbus.Scope{}, handleSettingsChangeEvent)
+	})
+}
+
+// called from the publishing goroutine, must not block
+func handleSettingsChangeEvent(event eventbus.Event) {
+	changeEvent, ok := event.(eventbus.SettingsChangeEvent)
+	if !ok || !utilfn.ContainsStr(changeEvent.Change.Keys, waveobj.MetaKey_TermScrollback) {
+		return
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("blockcontroller:updateScrollbackLines", recover())
+		}()
+		updateScrollbackLines()
+	}()
+}
+
+func updateScrollbackLines() {
+	for _, blockId := range scrollbackTrackers.Keys() {
+		st := scrollbackTrackers.Get(blockId)
+		bc := GetBlockController(blockId)
+		if st == nil || bc == nil {
+			continue
+		}
+		ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+		block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+		cancelFn()
+		if err != nil {
+			log.Printf("error getting block %s to update its scrollback: %v\n", blockId, err)
+			continue
+		}
+		maxLines := getTermScrollbackLines(bc.getSettings(), block.Meta)
+		st.lock.Lock()
+		changed := st.maxLines != maxLines
+		st.maxLines = maxLines
+		st.lock.Unlock()
+		if changed {
+			st.writeMeta(true)
+		}
+	}
+}
+
+// block meta overrides the global setting, rounded up to the filestore part size
+func getTermMaxFileSize(settings *wconfig.SettingsType, blockMeta waveobj.MetaMapType) int64 {
+	maxSize := int64(DefaultTermMaxFileSize)
+	if settings.TermScrollbackBytes != nil {
+		maxSize = *settings.TermScrollbackBytes
+	}
+	if metaSize := blockMeta.GetInt(waveobj.MetaKey_TermScrollbackBytes, 0); metaSize > 0 {
+		maxSize = int64(metaSize)
+	}
+	maxSize = max(MinTermMaxFileSize, min(MaxTermMaxFileSize, maxSize))
+	if maxSize%MinTermMaxFileSize != 0 {
+		maxSize = (maxSize/MinTermMaxFileSize + 1) * MinTermMaxFileSize
+	}
+	return maxSize
+}
+
+func getTermScrollbackLines(settings *wconfig.SettingsType, blockMeta waveobj.MetaMapType) int {
+	lines := DefaultTermScrollbackLines
+	if settings.TermScrollback != nil && *settings.TermScrollback > 0 {
+		lines = int(*settings.TermScrollback)
+	}
+	if metaLines := blockMeta.GetInt(waveobj.MetaKey_TermScrollback, 0); metaLines > 0 {
+		lines = metaLines
+	}
+	return max(0, min(MaxTermScrollbackLines, lines))
+}
+
+func termFileOpts(maxSize int64) wshrpc.FileOpts {
+	searchIndex := wconfig.GetWatcher().GetFullConfig().Settings.StorageSearchIndex
+	return wshrpc.FileOpts{MaxSize: maxSize, Circular: true, LineIndex: true, TextIndex: searchIndex}
+}
+
+// creates the term file, or recreates it (keeping the tail of the output) if its size limit has changed.
+// returns true if there was already output in the file.
+func ensureTermFile(ctx context.Context, blockId string, maxSize int64) (bool, error) {
+	wfile, err := filestore.WFS.Stat(ctx, blockId, wavebase.BlockFile_Term)
+	if err == fs.ErrNotExist {
+		return false, filestore.WFS.MakeFile(ctx, blockId, wavebase.BlockFile_Term, nil, termFileOpts(maxSize))
+	}
+	if err != nil {
+		return false, err
+	}
+	if wfile.Opts.MaxSize == maxSize {
+		if !wfile.Opts.LineIndex {
+			// made before term files had a line index
+			err = filestore.WFS.EnableLineIndex(ctx, blockId, wavebase.BlockFile_Term)
+			if err != nil {
+				return false, err
+			}
+		}
+		if searchIndex := termFileOpts(maxSize).TextIndex; wfile.Opts.TextIndex != searchIndex {
+			// storage:searchindex has changed
+			err = filestore.WFS.SetTextIndex(ctx, blockId, wavebase.BlockFile_Term, searchIndex)
+			if err != nil {
+				return false, err
+			}
+		}
+		return wfile.Size > 0, nil
+	}
+	log.Printf("resizing term file for block %s (%d => %d)\n", blockId, wfile.Opts.MaxSize, maxSize)
+	_, data, err := filestore.WFS.ReadFile(ctx, blockId, wavebase.BlockFile_Term)
+	if err != nil {
+		return false, err
+	}
+	if int64(len(data)) > maxSize {
+		data = data[int64(len(data))-maxSize:]
+	}
+	err = filestore.WFS.DeleteFile(ctx, blockId, wavebase.BlockFile_Term)
+	if err != nil {
+		return false, err
+	}
+	err = filestore.WFS.MakeFile(ctx, blockId, wavebase.BlockFile_Term, nil, termFileOpts(maxSize))
+	if err != nil {
+		return false, err
+	}
+	// file offsets have changed, so the terminal cache and segments are no longer valid
+	err = filestore.WFS.DeleteFile(ctx, blockId, wavebase.BlockFile_Cache)
+	if err != nil && err != fs.ErrNotExist {
+		log.Printf("error deleting cache file (continuing): %v\n", err)
+	}
+	deleteTermSegments(ctx, blockId)
+	deleteTermLinks(ctx, blockId)
+	if len(data) == 0 {
+		return false, nil
+	}
+	return true, filestore.WFS.AppendData(ctx, blockId, wavebase.BlockFile_Term, data)
+}
+
+type scrollbackTracker struct {
+	lock          sync.Mutex
+	blockId       string
+	maxLines      int
+	metaStart     int64 // last start offset written to the file meta
+	lastMetaWrite time.Time
+}
+
+// starts tracking appends to a block's term file (see HandleAppendBlockFile), replaces any existing tracker
+func startScrollbackTracker(ctx context.Context, blockId string, maxLines int) {
+	wfile, err := filestore.WFS.Stat(ctx, blockId, wavebase.BlockFile_Term)
+	if err != nil {
+		log.Printf("error getting term file for scrollback tracking: %v\n", err)
+		return
+	}
+	st := &scrollbackTracker{
+		blockId:  blockId,
+		maxLines: maxLines,
+	}
+	// int64 if the file is still in the cache, float64 once it has been read back from the db
+	switch start := wfile.Meta[FileMeta_ScrollbackStart].(type) {
+	case int64:
+		st.metaStart = start
+	case float64:
+		st.metaStart = int64(start)
+	}
+	scrollbackTrackers.Set(blockId, st)
+}
+
+func stopScrollbackTracker(blockId string) {
+	st := scrollbackTrackers.Get(blockId)
+	if st == nil {
+		return
+	}
+	st.writeMeta(true)
+	scrollbackTrackers.Delete(blockId)
+}
+
+func (st *scrollbackTracker) reset(ctx context.Context) {
+	st.lock.Lock()
+	st.metaStart = 0
+	st.lock.Unlock()
+	err := filestore.WFS.WriteMeta(ctx, st.blockId, wavebase.BlockFile_Term, wshrpc.FileMeta{FileMeta_ScrollbackStart: nil}, true)
+	if err != nil {
+		log.Printf("error clearing scrollback start for block %s: %v\n", st.blockId, err)
+	}
+}
+
+// called after output is appended to the term file
+func (st *scrollbackTracker) write() {
+	st.writeMeta(false)
+}
+
+// offset of the oldest line to replay (the last start written if the line index can't be read)
+func (st *scrollbackTracker) startOffset() int64 {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	wfile, err := filestore.WFS.Stat(ctx, st.blockId, wavebase.BlockFile_Term)
+	if err == nil {
+		var start int64
+		st.lock.Lock()
+		maxLines := st.maxLines
+		st.lock.Unlock()
+		start, err = filestore.WFS.LineOffset(ctx, st.blockId, wavebase.BlockFile_Term, max(0, wfile.NumLines-int64(maxLines)))
+		if err == nil {
+			return start
+		}
+	}
+	log.Printf("error getting scrollback start for block %s: %v\n", st.blockId, err)
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	return st.metaStart
+}
+
+func (st *scrollbackTracker) writeMeta(force bool) {
+	st.lock.Lock()
+	if !force && time.Since(st.lastMetaWrite) < ScrollbackMetaInterval {
+		st.lock.Unlock()
+		return
+	}
+	st.lastMetaWrite = time.Now()
+	st.lock.Unlock()
+	start := st.startOffset()
+	st.lock.Lock()
+	if start == st.metaStart {
+		st.lock.Unlock()
+		return
+	}
+	st.metaStart = start
+	st.lock.Unlock()
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	err := filestore.WFS.WriteMeta(ctx, st.blockId, wavebase.BlockFile_Term, wshrpc.FileMeta{FileMeta_ScrollbackStart: start}, true)
+	if err != nil {
+		log.Printf("error writing scrollback start for block %s: %v\n", st.blockId, err)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/shellexec"
+)
+
+// a block is in secure input mode while a program is reading a password (sudo, ssh, gpg, read -s, etc.).  it is
+// detected from the pty modes (echo off in canonical mode, only readable for local processes, see
+// shellexec.PasswordModeReader) or from a password prompt at the end of the output (for remote connections, and
+// programs that turn off echo after printing the prompt).  the mode is in the runtime status, so the frontend
+// can mask the input (and not broadcast it to other blocks), and the output is not recorded while the mode is
+// on (see termRecorder.setSecureInput).  the mode is checked with each chunk of output and when a line of input
+// is sent, a prompt stops counting once it has been answered.
+
+const secureLineMax = 256
+
+var securePromptRe = regexp.MustCompile(`(?i)\b(password|passphrase|passcode|pin|verification code|one-time code)\b[^:]{0,80}:\s*$`)
+
+// escape sequences are removed from the line before it is matched
+var secureLineEscRe = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[@-_]`)
+
+type secureInputTracker struct {
+	lock       sync.Mutex
+	bc         *BlockController
+	modeReader shellexec.PasswordModeReader // nil if the pty modes cannot be read
+	line       []byte                       // the output since the last newline (the end of it)
+	answered   bool                         // a line of input was sent since the prompt
+}
+
+func (bc *BlockController) makeSecureInputTracker(shellProc *shellexec.ShellProc) *secureInputTracker {
+	bc.secureInput.Store(false)
+	st := &secureInputTracker{bc: bc}
+	if reader, ok := shellProc.Cmd.(shellexec.PasswordModeReader); ok {
+		st.modeReader = reader
+	}
+	return st
+}
+
+func (st *secureInputTracker) Write(chunk []byte) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	lineData := chunk
+	if idx := bytes.LastIndexByte(chunk, '\n'); idx >= 0 {
+		st.line = st.line[:0]
+		st.answered = false
+		lineData = chunk[idx+1:]
+	}
+	st.line = append(st.line, lineData...)
+	if len(st.line) > secureLineMax {
+		st.line = append(st.line[:0], st.line[len(st.line)-secureLineMax:]...)
+	}
+	wasSecure := st.bc.secureInput.Load()
+	st.update_nolock()
+	if wasSecure && !st.bc.secureInput.Load() {
+		// the chunk that ends the mode was not recorded
+		if tr := termRecorders.Get(st.bc.BlockId); tr != nil {
+			tr.writeOutput(chunk)
+		}
+	}
+}
+
+// called with the input sent to the process
+func (st *secureInputTracker) inputSent(data []byte) {
+	if !bytes.ContainsAny(data, "\r\n") {
+		return
+	}
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.answered = true
+	st.update_nolock()
+}
+
+func (st *secureInputTracker) update_nolock() {
+	secure := !st.answered && securePromptRe.Match(secureLineEscRe.ReplaceAll(st.line, nil))
+	if !secure && st.modeReader != nil {
+		passwordMode, err := st.modeReader.IsPasswordMode()
+		if err != nil {
+			log.Printf("block %s: %v (password prompts are detected from the output)\n", st.bc.BlockId, err)
+			st.modeReader = nil
+		}
+		secure = passwordMode
+	}
+	if st.bc.secureInput.Swap(secure) == secure {
+		return
+	}
+	if tr := termRecorders.Get(st.bc.BlockId); tr != nil {
+		tr.setSecureInput(secure)
+	}
+	st.bc.UpdateControllerAndSendUpdate(func() bool { return true })
+}
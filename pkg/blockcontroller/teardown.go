@@ -0,0 +1,247 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/shellexec"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+)
+
+const ShellProcsFileName = "shellprocs.json"
+const DefaultTeardownTimeout = 3 * time.Second
+
+// running local shell processes are recorded on disk so that if wavesrv exits
+// without cleaning up (crash, force quit), they can be reaped on the next startup.
+// persistent sessions (SockPath set) are kept so they can be reattached.  for sessions
+// on a remote host (ConnName set) the pid and the socket are on that host.
+type trackedProc struct {
+	Pid        int    `json:"pid"`
+	CreateTime int64  `json:"createtime"`
+	SockPath   string `json:"sockpath,omitempty"`
+	ConnName   string `json:"connname,omitempty"`
+}
+
+var procTrackerLock = &sync.Mutex{}
+var shuttingDown = &atomic.Bool{}               // the shells are being stopped for shutdown (no close or restart on exit)
+var trackedProcs = make(map[string]trackedProc) // blockid => proc
+
+func getShellProcsFilePath() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), ShellProcsFileName)
+}
+
+// must hold procTrackerLock
+func writeTrackedProcs() {
+	barr, err := json.Marshal(trackedProcs)
+	if err != nil {
+		log.Printf("error marshaling tracked shell procs: %v\n", err)
+		return
+	}
+	err = os.WriteFile(getShellProcsFilePath(), barr, 0600)
+	if err != nil {
+		log.Printf("error writing tracked shell procs: %v\n", err)
+	}
+}
+
+// returns false for shells that are not tracked (remote shells, other than persistent sessions)
+func makeTrackedProc(shellProc *shellexec.ShellProc) (trackedProc, bool) {
+	if connName, hostPid := shellProc.GetRemotePtyHost(); connName != "" {
+		return trackedProc{Pid: hostPid, SockPath: shellProc.GetPtyHostSock(), ConnName: connName}, true
+	}
+	pid := shellProc.GetLocalPid()
+	if pid == 0 {
+		return trackedProc{}, false
+	}
+	return trackedProc{Pid: pid, CreateTime: shellexec.GetProcCreateTime(pid), SockPath: shellProc.GetPtyHostSock()}, true
+}
+
+func trackShellProc(blockId string, shellProc *shellexec.ShellProc) {
+	tp, ok := makeTrackedProc(shellProc)
+	if !ok {
+		return
+	}
+	procTrackerLock.Lock()
+	defer procTrackerLock.Unlock()
+	trackedProcs[blockId] = tp
+	writeTrackedProcs()
+}
+
+func untrackShellProc(blockId string, shellProc *shellexec.ShellProc) {
+	shellTp, _ := makeTrackedProc(shellProc)
+	procTrackerLock.Lock()
+	defer procTrackerLock.Unlock()
+	if tp, ok := trackedProcs[blockId]; !ok || tp.Pid != shellTp.Pid || tp.ConnName != shellTp.ConnName {
+		return
+	}
+	delete(trackedProcs, blockId)
+	writeTrackedProcs()
+}
+
+// kills shell processes (and their children) left over from a previous run, persistent sessions
+// for blocks that still exist are kept for reattaching.  sessions on remote hosts can't be checked
+// (or killed) before their connection is up, they are kept (or forgotten) without checking.
+// must be called at startup before any block controllers are started.
+func ReapLeftoverShellProcs() {
+	fileName := getShellProcsFilePath()
+	barr, err := os.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+	var leftovers map[string]trackedProc
+	err = json.Unmarshal(barr, &leftovers)
+	if err != nil {
+		log.Printf("error parsing %s: %v\n", ShellProcsFileName, err)
+	}
+	for blockId, tp := range leftovers {
+		if tp.ConnName != "" {
+			if tp.SockPath != "" && blockExists(blockId) {
+				log.Printf("keeping persistent shell session on %s (block %s)\n", tp.ConnName, blockId)
+				procTrackerLock.Lock()
+				trackedProcs[blockId] = tp
+				procTrackerLock.Unlock()
+			}
+			continue
+		}
+		createTime := shellexec.GetProcCreateTime(tp.Pid)
+		if createTime == 0 || createTime != tp.CreateTime {
+			// already gone (or the pid was reused)
+			continue
+		}
+		if tp.SockPath != "" && blockExists(blockId) {
+			log.Printf("keeping persistent shell session %d (block %s)\n", tp.Pid, blockId)
+			procTrackerLock.Lock()
+			trackedProcs[blockId] = tp
+			procTrackerLock.Unlock()
+			continue
+		}
+		log.Printf("reaping leftover shell process %d (block %s)\n", tp.Pid, blockId)
+		shellexec.KillProcessTree(tp.Pid)
+		if tp.SockPath != "" {
+			os.Remove(tp.SockPath)
+		}
+	}
+	procTrackerLock.Lock()
+	defer procTrackerLock.Unlock()
+	if len(trackedProcs) == 0 {
+		os.Remove(fileName)
+		return
+	}
+	writeTrackedProcs()
+}
+
+func removeBlockController(blockId string, bc *BlockController) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	if blockControllerMap[blockId] == bc {
+		delete(blockControllerMap, blockId)
+	}
+}
+
+// stops the block controller, waits (up to timeout) for the shell process to exit, and then
+// removes the controller.  used when the block itself is going away.
+func DestroyBlockController(blockId string, timeout time.Duration) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return
+	}
+	defer removeBlockController(blockId, bc)
+	shellProc := bc.getShellProc()
+	if tp, ok := getTrackedProc(blockId); ok && tp.ConnName != "" && (shellProc == nil || shellProc.IsDetached()) {
+		// a remote session that lost its connection (or was not reattached yet) is still running on its host
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("DestroyBlockController:killTrackedProc", recover())
+			}()
+			killTrackedProc(blockId, tp)
+		}()
+	}
+	if shellProc == nil {
+		return
+	}
+	shellProc.Close()
+	select {
+	case <-shellProc.DoneCh:
+	case <-time.After(timeout):
+		log.Printf("timeout waiting for shell process to exit (block %s), force killing\n", blockId)
+		shellProc.Cmd.Kill()
+		shellexec.KillProcessTree(shellProc.GetLocalPid())
+	}
+	bc.UpdateControllerAndSendUpdate(func() bool {
+		bc.ShellProcStatus = Status_Done
+		return true
+	})
+}
+
+// tears down a set of block controllers concurrently, returns once they are all destroyed
+func DestroyBlockControllers(blockIds []string, timeout time.Duration) {
+	wg := &sync.WaitGroup{}
+	for _, blockId := range blockIds {
+		wg.Add(1)
+		go func(blockId string) {
+			defer wg.Done()
+			defer func() {
+				panichandler.PanicHandler("DestroyBlockControllers", recover())
+			}()
+			DestroyBlockController(blockId, timeout)
+		}(blockId)
+	}
+	wg.Wait()
+}
+
+// writes the state that is otherwise written lazily: pushes the pending output to the frontend, and writes the
+// scrollback start and the duration of active recordings to the file meta.  called on shutdown before the
+// filestore is flushed.
+func CheckpointAllBlockControllers() {
+	for _, bc := range getControllerList() {
+		if op := outputPushers.Get(bc.BlockId); op != nil {
+			op.flush()
+		}
+		if st := scrollbackTrackers.Get(bc.BlockId); st != nil {
+			st.writeMeta(true)
+		}
+		if tr := termRecorders.Get(bc.BlockId); tr != nil {
+			tr.writeDuration()
+		}
+	}
+}
+
+// closes the ptys of the running shells and waits for them to exit (or for ctx to be done), the blocks are not
+// closed or restarted when their shells exit.  called last on shutdown.
+func StopAllBlockControllersAndWait(ctx context.Context) error {
+	shuttingDown.Store(true)
+	wg := &sync.WaitGroup{}
+	for _, bc := range getControllerList() {
+		if bc.GetRuntimeStatus().ShellProcStatus != Status_Running {
+			continue
+		}
+		wg.Add(1)
+		go func(blockId string) {
+			defer wg.Done()
+			defer func() {
+				panichandler.PanicHandler("StopAllBlockControllersAndWait", recover())
+			}()
+			StopBlockController(blockId)
+		}(bc.BlockId)
+	}
+	doneCh := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
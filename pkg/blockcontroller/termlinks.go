@@ -0,0 +1,446 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// links are found in the pty output: OSC 8 hyperlinks, and urls and file paths (with an optional :line:col)
+// in the plain text of each line (escape sequences removed).  each link gets a target that says how a click
+// should open it (web block, editor block, or an external app).  links are stored as json lines in a circular
+// blockfile (like the term segments) and published as term:links events.
+
+const (
+	LinkKind_Hyperlink = "hyperlink"
+	LinkKind_Url       = "url"
+	LinkKind_Path      = "path"
+
+	LinkTarget_Web      = "web"
+	LinkTarget_Editor   = "editor"
+	LinkTarget_External = "external"
+
+	OscNum_Hyperlink = 8
+
+	TermLinksMaxSize = 256 * 1024
+	MaxLinkLineSize  = 4096
+	MaxLinkTextSize  = 1024
+	maxLinkOscSize   = 4096
+)
+
+const (
+	linkStateNormal = iota
+	linkStateEsc
+	linkStateEscInter
+	linkStateCsi
+	linkStateOsc
+	linkStateOscEsc
+)
+
+var linkUrlRe = regexp.MustCompile(`(?i)\b(?:https?|ftp|file)://[^\s<>"'\x60]+`)
+var linkPathTokenRe = regexp.MustCompile(`[\w.@+~/-]+(?::\d+)*`)
+var linkLineColRe = regexp.MustCompile(`^(.*?)(?::(\d+)(?::(\d+))?)?:?$`)
+var linkExtRe = regexp.MustCompile(`[^./]\.[A-Za-z][A-Za-z0-9]{0,9}$`)
+
+type pendingHyperlink struct {
+	uri   string
+	start int64
+	text  []byte
+}
+
+// finds links in a stream of pty output, offsets are stream offsets (see termOutputScanner.toFileOffset)
+type termLinkDetector struct {
+	state     int
+	offset    int64
+	escOffset int64
+	osc       []byte
+	line      []byte
+	lineOffs  []int64 // stream offset of each byte in line
+	lineHyper []bool  // the byte is the text of a hyperlink (not checked for urls and paths)
+	pendingCR bool
+	hyperlink *pendingHyperlink
+	found     []wshrpc.TermLink
+}
+
+// returns the links completed by this chunk
+func (d *termLinkDetector) Write(chunk []byte) []wshrpc.TermLink {
+	d.found = nil
+	for _, ch := range chunk {
+		d.processByte(ch)
+		d.offset++
+	}
+	return d.found
+}
+
+func (d *termLinkDetector) processByte(ch byte) {
+	switch d.state {
+	case linkStateNormal:
+		d.processText(ch)
+	case linkStateEsc:
+		switch {
+		case ch == '[':
+			d.state = linkStateCsi
+		case ch == ']':
+			d.state = linkStateOsc
+			d.osc = d.osc[:0]
+		case ch >= 0x20 && ch <= 0x2f:
+			// charset designation etc, one more byte
+			d.state = linkStateEscInter
+		default:
+			d.state = linkStateNormal
+		}
+	case linkStateEscInter:
+		d.state = linkStateNormal
+	case linkStateCsi:
+		if ch >= 0x40 && ch <= 0x7e {
+			d.state = linkStateNormal
+		}
+	case linkStateOsc:
+		switch ch {
+		case 0x07:
+			d.finishOsc()
+		case 0x1b:
+			d.state = linkStateOscEsc
+		case 0x18, 0x1a:
+			d.state = linkStateNormal
+		default:
+			if len(d.osc) < maxLinkOscSize {
+				d.osc = append(d.osc, ch)
+			}
+		}
+	case linkStateOscEsc:
+		if ch == '\\' {
+			d.finishOsc()
+			return
+		}
+		d.state = linkStateNormal
+		d.processByte(ch)
+	}
+}
+
+func (d *termLinkDetector) processText(ch byte) {
+	switch {
+	case ch == 0x1b:
+		d.state = linkStateEsc
+		d.escOffset = d.offset
+	case ch == '\n':
+		d.pendingCR = false
+		d.flushLine()
+	case ch == '\r':
+		d.pendingCR = true
+	case ch == '\b':
+		if len(d.line) > 0 {
+			d.line = d.line[:len(d.line)-1]
+			d.lineOffs = d.lineOffs[:len(d.lineOffs)-1]
+			d.lineHyper = d.lineHyper[:len(d.lineHyper)-1]
+		}
+	case ch == '\t' || (ch >= 0x20 && ch != 0x7f):
+		if d.pendingCR {
+			// the line is being overwritten
+			d.pendingCR = false
+			d.resetLine()
+		}
+		if len(d.line) >= MaxLinkLineSize {
+			d.flushLine()
+		}
+		d.line = append(d.line, ch)
+		d.lineOffs = append(d.lineOffs, d.offset)
+		d.lineHyper = append(d.lineHyper, d.hyperlink != nil)
+		if d.hyperlink != nil && len(d.hyperlink.text) < MaxLinkTextSize {
+			d.hyperlink.text = append(d.hyperlink.text, ch)
+		}
+	}
+}
+
+// OSC 8 ; params ; uri starts a hyperlink, an empty uri ends it
+func (d *termLinkDetector) finishOsc() {
+	d.state = linkStateNormal
+	oscNum, rest, ok := strings.Cut(string(d.osc), ";")
+	if !ok || oscNum != strconv.Itoa(OscNum_Hyperlink) {
+		return
+	}
+	_, uri, _ := strings.Cut(rest, ";")
+	d.endHyperlink()
+	if uri != "" {
+		d.hyperlink = &pendingHyperlink{uri: uri, start: d.offset + 1}
+	}
+}
+
+func (d *termLinkDetector) endHyperlink() {
+	hl := d.hyperlink
+	d.hyperlink = nil
+	if hl == nil || len(hl.text) == 0 {
+		return
+	}
+	link := makeUriLink(LinkKind_Hyperlink, hl.uri, string(hl.text))
+	link.Offset = hl.start
+	link.EndOffset = d.escOffset
+	d.found = append(d.found, link)
+}
+
+func (d *termLinkDetector) resetLine() {
+	d.line = d.line[:0]
+	d.lineOffs = d.lineOffs[:0]
+	d.lineHyper = d.lineHyper[:0]
+}
+
+func (d *termLinkDetector) flushLine() {
+	defer d.resetLine()
+	if len(d.line) == 0 {
+		return
+	}
+	line := string(d.line)
+	var urlRanges [][]int
+	for _, loc := range linkUrlRe.FindAllStringIndex(line, -1) {
+		start, end := loc[0], trimUrlEnd(line, loc[0], loc[1])
+		if d.lineHyper[start] {
+			continue
+		}
+		urlRanges = append(urlRanges, []int{start, end})
+		link := makeUriLink(LinkKind_Url, line[start:end], line[start:end])
+		d.addLineLink(link, start, end)
+	}
+	for _, loc := range linkPathTokenRe.FindAllStringIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		if d.lineHyper[start] || overlapsRanges(urlRanges, start, end) {
+			continue
+		}
+		link, ok := makePathLink(line[start:end])
+		if !ok {
+			continue
+		}
+		d.addLineLink(link, start, start+len(link.Text))
+	}
+}
+
+func (d *termLinkDetector) addLineLink(link wshrpc.TermLink, start int, end int) {
+	link.Offset = d.lineOffs[start]
+	link.EndOffset = d.lineOffs[end-1] + 1
+	d.found = append(d.found, link)
+}
+
+func overlapsRanges(ranges [][]int, start int, end int) bool {
+	for _, r := range ranges {
+		if start < r[1] && end > r[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// trailing punctuation is not part of a url (unless it closes a paren opened in the url)
+func trimUrlEnd(line string, start int, end int) int {
+	for end > start {
+		ch := line[end-1]
+		if ch == ')' && strings.Count(line[start:end], "(") >= strings.Count(line[start:end], ")") {
+			break
+		}
+		if !strings.ContainsRune(".,;:!?)]}>'\"", rune(ch)) {
+			break
+		}
+		end--
+	}
+	return end
+}
+
+func getUriTarget(uri string) string {
+	scheme, _, _ := strings.Cut(uri, ":")
+	switch strings.ToLower(scheme) {
+	case "http", "https", "ftp":
+		return LinkTarget_Web
+	case "file":
+		return LinkTarget_Editor
+	default:
+		return LinkTarget_External
+	}
+}
+
+func makeUriLink(kind string, uri string, text string) wshrpc.TermLink {
+	link := wshrpc.TermLink{Kind: kind, Target: getUriTarget(uri), Text: text, Uri: uri}
+	if link.Target == LinkTarget_Editor {
+		if parsed, err := url.Parse(uri); err == nil {
+			link.Path = parsed.Path
+		}
+	}
+	return link
+}
+
+// a token is a path if it is absolute (or starts with ~/ ./ ../), if it has a directory and a file extension,
+// or if it is a file name with an extension followed by a line number (e.g. compiler output)
+func makePathLink(token string) (wshrpc.TermLink, bool) {
+	if strings.Contains(token, "//") {
+		return wshrpc.TermLink{}, false
+	}
+	m := linkLineColRe.FindStringSubmatch(token)
+	if m == nil {
+		return wshrpc.TermLink{}, false
+	}
+	pathStr, lineStr, colStr := m[1], m[2], m[3]
+	if lineStr == "" {
+		pathStr = strings.TrimRight(pathStr, ".")
+	}
+	hasExt := linkExtRe.MatchString(pathStr)
+	isRooted := strings.HasPrefix(pathStr, "/") || strings.HasPrefix(pathStr, "~/") || strings.HasPrefix(pathStr, "./") || strings.HasPrefix(pathStr, "../")
+	switch {
+	case isRooted:
+		if strings.Trim(pathStr, "/.~") == "" {
+			return wshrpc.TermLink{}, false
+		}
+	case strings.Contains(pathStr, "/"):
+		if !hasExt {
+			return wshrpc.TermLink{}, false
+		}
+	default:
+		if !hasExt || lineStr == "" {
+			return wshrpc.TermLink{}, false
+		}
+	}
+	link := wshrpc.TermLink{Kind: LinkKind_Path, Target: LinkTarget_Editor, Path: pathStr, Text: pathStr}
+	if lineStr != "" {
+		link.Line, _ = strconv.Atoi(lineStr)
+		link.Text += ":" + lineStr
+	}
+	if colStr != "" {
+		link.Col, _ = strconv.Atoi(colStr)
+		link.Text += ":" + colStr
+	}
+	return link, true
+}
+
+// relative paths are resolved against the block's cwd.  on the local machine paths that do not exist are dropped
+// (on remote connections they are kept).  returns false if the link should be dropped.
+func resolvePathLink(link *wshrpc.TermLink, cwd string, connName string) bool {
+	link.Conn = connName
+	if link.Kind != LinkKind_Path {
+		return true
+	}
+	if !strings.HasPrefix(link.Path, "/") && !strings.HasPrefix(link.Path, "~") && cwd != "" {
+		link.Path = path.Join(cwd, link.Path)
+	}
+	if connName != "" {
+		return true
+	}
+	localPath, err := wavebase.ExpandHomeDir(link.Path)
+	if err != nil {
+		return false
+	}
+	if _, err := os.Stat(localPath); err != nil {
+		return false
+	}
+	return true
+}
+
+func (ts *termOutputScanner) handleLinks(links []wshrpc.TermLink) {
+	if len(links) == 0 {
+		return
+	}
+	// the difference between stream offsets and term file offsets
+	delta, err := ts.toFileOffset(0)
+	if err != nil {
+		log.Printf("block %s: %v\n", ts.blockId, err)
+		return
+	}
+	rtn := make([]wshrpc.TermLink, 0, len(links))
+	for _, link := range links {
+		if !resolvePathLink(&link, ts.lastCwd, ts.connName) {
+			continue
+		}
+		link.Offset += delta
+		link.EndOffset += delta
+		rtn = append(rtn, link)
+	}
+	if len(rtn) == 0 {
+		return
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	err = appendTermLinks(ctx, ts.blockId, rtn)
+	if err != nil {
+		log.Printf("error appending term links for block %s: %v\n", ts.blockId, err)
+	}
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_TermLinks,
+		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, ts.blockId).String()},
+		Data:   rtn,
+	})
+}
+
+func appendTermLinks(ctx context.Context, blockId string, links []wshrpc.TermLink) error {
+	var buf bytes.Buffer
+	for _, link := range links {
+		barr, err := json.Marshal(link)
+		if err != nil {
+			return fmt.Errorf("error marshaling term link: %w", err)
+		}
+		buf.Write(barr)
+		buf.WriteByte('\n')
+	}
+	_, statErr := filestore.WFS.Stat(ctx, blockId, wavebase.BlockFile_TermLinks)
+	if statErr == fs.ErrNotExist {
+		err := filestore.WFS.MakeFile(ctx, blockId, wavebase.BlockFile_TermLinks, nil, wshrpc.FileOpts{MaxSize: TermLinksMaxSize, Circular: true})
+		if err != nil {
+			return fmt.Errorf("error making term links file: %w", err)
+		}
+	}
+	return filestore.WFS.AppendData(ctx, blockId, wavebase.BlockFile_TermLinks, buf.Bytes())
+}
+
+// returns the links that are still in the term file, starting at startOffset
+func GetTermLinks(ctx context.Context, blockId string, startOffset int64) ([]wshrpc.TermLink, error) {
+	_, barr, err := filestore.WFS.ReadFile(ctx, blockId, wavebase.BlockFile_TermLinks)
+	if err == fs.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading term links: %w", err)
+	}
+	wfile, err := filestore.WFS.Stat(ctx, blockId, wavebase.BlockFile_Term)
+	if err == fs.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting term file: %w", err)
+	}
+	startOffset = max(startOffset, wfile.DataStartIdx())
+	var rtn []wshrpc.TermLink
+	for _, line := range bytes.Split(barr, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		var link wshrpc.TermLink
+		if err := json.Unmarshal(line, &link); err != nil {
+			// the first line can be cut off when the circular file wraps
+			continue
+		}
+		if link.Offset < startOffset || link.EndOffset > wfile.Size {
+			continue
+		}
+		rtn = append(rtn, link)
+	}
+	return rtn, nil
+}
+
+func deleteTermLinks(ctx context.Context, blockId string) {
+	err := filestore.WFS.DeleteFile(ctx, blockId, wavebase.BlockFile_TermLinks)
+	if err != nil && err != fs.ErrNotExist {
+		log.Printf("error deleting term links file (continuing): %v\n", err)
+	}
+}
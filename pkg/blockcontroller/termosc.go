@@ -0,0 +1,171 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/cmdhistory"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/util/oscscan"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	OscNum_Cwd = 7
+)
+
+var windowsDrivePathRe = regexp.MustCompile(`^/[A-Za-z]:`)
+
+// OSC 7 ; file://host/path
+// the host is ignored (for remote connections the path is on the remote machine)
+func parseOsc7Cwd(data []byte) (string, error) {
+	dataStr := strings.TrimSpace(string(data))
+	if dataStr == "" {
+		return "", fmt.Errorf("empty cwd")
+	}
+	if !strings.HasPrefix(dataStr, "file://") {
+		// some shells send a bare path
+		return dataStr, nil
+	}
+	rest := dataStr[len("file://"):]
+	slashIdx := strings.Index(rest, "/")
+	if slashIdx == -1 {
+		return "", fmt.Errorf("invalid cwd uri %q (no path)", dataStr)
+	}
+	cwd := rest[slashIdx:]
+	if unescaped, err := url.PathUnescape(cwd); err == nil {
+		cwd = unescaped
+	}
+	if windowsDrivePathRe.MatchString(cwd) {
+		// file://host/C:/Users => C:/Users
+		cwd = cwd[1:]
+	}
+	return cwd, nil
+}
+
+func setBlockCwd(blockId string, cwd string) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	oref := waveobj.MakeORef(waveobj.OType_Block, blockId)
+	err := wstore.UpdateObjectMeta(ctx, oref, waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: cwd}, false)
+	if err != nil {
+		return fmt.Errorf("error updating block cwd: %w", err)
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	eventbus.PublishObjectUpdates(updates)
+	return nil
+}
+
+// scans the pty output for the OSC sequences emitted by the shell integration scripts.
+// chunks must be written after they have been appended to the term file.
+type termOutputScanner struct {
+	bc       *BlockController
+	blockId  string
+	scanner  *oscscan.OscScanner
+	chunkEnd int64 // scanner offset at the end of the chunk currently being scanned
+	connName string
+	lastCwd  string
+	segments *termSegmentTracker
+	links    *termLinkDetector
+}
+
+func (bc *BlockController) makeTermOutputScanner(blockMeta waveobj.MetaMapType) *termOutputScanner {
+	ts := &termOutputScanner{
+		bc:       bc,
+		blockId:  bc.BlockId,
+		connName: blockMeta.GetString(waveobj.MetaKey_Connection, ""),
+		lastCwd:  blockMeta.GetString(waveobj.MetaKey_CmdCwd, ""),
+		segments: makeTermSegmentTracker(bc.BlockId),
+		links:    &termLinkDetector{},
+	}
+	ts.scanner = oscscan.MakeOscScanner(ts.handleOsc, OscNum_Cwd, OscNum_FinalTerm, OscNum_Clipboard)
+	ts.scanner.SetMaxDataSize(OscNum_Clipboard, MaxOsc52DataSize)
+	return ts
+}
+
+func (ts *termOutputScanner) Write(chunk []byte) {
+	ts.chunkEnd = ts.scanner.GetOffset() + int64(len(chunk))
+	ts.scanner.Write(chunk)
+	ts.handleLinks(ts.links.Write(chunk))
+}
+
+// converts a scanner offset to an offset in the term file.  other writers (e.g. status messages) also
+// append to the term file, so this is computed from the file size (which lines up with chunkEnd).
+func (ts *termOutputScanner) toFileOffset(offset int64) (int64, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	wfile, err := filestore.WFS.Stat(ctx, ts.blockId, wavebase.BlockFile_Term)
+	if err != nil {
+		return 0, fmt.Errorf("error getting term file: %w", err)
+	}
+	return wfile.Size - (ts.chunkEnd - offset), nil
+}
+
+func (ts *termOutputScanner) handleOsc(oscNum int, data []byte, offset int64, endOffset int64) {
+	switch oscNum {
+	case OscNum_Cwd:
+		cwd, err := parseOsc7Cwd(data)
+		if err != nil {
+			log.Printf("block %s: %v\n", ts.blockId, err)
+			return
+		}
+		if cwd == ts.lastCwd {
+			return
+		}
+		ts.lastCwd = cwd
+		err = setBlockCwd(ts.blockId, cwd)
+		if err != nil {
+			log.Printf("block %s: %v\n", ts.blockId, err)
+		}
+	case OscNum_FinalTerm:
+		fileOffset, err := ts.toFileOffset(offset)
+		if err != nil {
+			log.Printf("block %s: %v\n", ts.blockId, err)
+			return
+		}
+		seg := ts.segments.handleMarker(data, fileOffset, fileOffset+(endOffset-offset), ts.lastCwd)
+		if seg != nil && seg.Command != "" {
+			ts.addHistoryEntry(seg)
+		}
+		// the queue tracks whether the shell is at its prompt, so it sees every marker
+		marker, _, _ := strings.Cut(string(data), ";")
+		ts.bc.getCommandQueue().handleMarker(marker, seg)
+	case OscNum_Clipboard:
+		ts.handleOsc52(data)
+	}
+}
+
+func (ts *termOutputScanner) addHistoryEntry(seg *wshrpc.TermSegment) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	entry := &wshrpc.CmdHistoryEntry{
+		Ts:         seg.StartTs,
+		BlockId:    ts.blockId,
+		ConnName:   ts.connName,
+		Cwd:        seg.Cwd,
+		CmdStr:     seg.Command,
+		ExitCode:   seg.ExitCode,
+		DurationMs: seg.DurationMs,
+	}
+	tabId, err := wstore.DBFindTabForBlockId(ctx, ts.blockId)
+	if err == nil {
+		entry.WorkspaceId, _ = wstore.DBFindWorkspaceForTabId(ctx, tabId)
+	}
+	err = cmdhistory.AddEntry(ctx, entry)
+	if err != nil {
+		log.Printf("block %s: error adding command history entry: %v\n", ts.blockId, err)
+	}
+}
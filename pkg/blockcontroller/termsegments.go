@@ -0,0 +1,267 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const (
+	OscNum_FinalTerm = 133
+
+	// segments are stored as json lines in a circular file, old segments fall off the front
+	TermSegmentsMaxSize = 256 * 1024
+
+	// max amount of output returned for a single segment
+	TermSegmentMaxOutputSize = 10 * 1024 * 1024
+)
+
+// tracks the OSC 133 markers for a running shell, stored segments are appended to the termsegments blockfile
+type termSegmentTracker struct {
+	blockId string
+	nextIdx int
+	cur     *wshrpc.TermSegment
+}
+
+func makeTermSegmentTracker(blockId string) *termSegmentTracker {
+	tracker := &termSegmentTracker{blockId: blockId}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	segments, err := readTermSegments(ctx, blockId)
+	if err != nil {
+		log.Printf("error reading term segments for block %s: %v\n", blockId, err)
+	}
+	if len(segments) > 0 {
+		tracker.nextIdx = segments[len(segments)-1].SegIdx + 1
+	}
+	return tracker
+}
+
+func (t *termSegmentTracker) startSegment() *wshrpc.TermSegment {
+	seg := &wshrpc.TermSegment{
+		SegIdx:       t.nextIdx,
+		PromptOffset: -1,
+		CmdOffset:    -1,
+		OutputOffset: -1,
+		EndOffset:    -1,
+	}
+	t.nextIdx++
+	t.cur = seg
+	return seg
+}
+
+// parses the command line from the C marker params (cmdline=... or cmdline_url=..., as used by kitty)
+func parseCmdLineParam(params string) string {
+	for _, param := range strings.Split(params, ";") {
+		key, val, _ := strings.Cut(param, "=")
+		switch key {
+		case "cmdline":
+			return val
+		case "cmdline_url":
+			if unescaped, err := url.PathUnescape(val); err == nil {
+				return unescaped
+			}
+			return val
+		}
+	}
+	return ""
+}
+
+// data is the OSC 133 payload ("A", "B", "C;cmdline_url=...", "D;exitcode"), offsets are term file offsets.
+// cwd is the shell's current directory (from OSC 7).  returns the segment if this marker completed one
+func (t *termSegmentTracker) handleMarker(data []byte, offset int64, endOffset int64, cwd string) *wshrpc.TermSegment {
+	marker, params, _ := strings.Cut(string(data), ";")
+	var finished *wshrpc.TermSegment
+	switch marker {
+	case "A":
+		if t.cur != nil && t.cur.OutputOffset >= 0 {
+			// the command never sent D, close it without an exit code
+			t.cur.EndOffset = offset
+			finished = t.finishSegment()
+		}
+		seg := t.startSegment()
+		seg.PromptOffset = offset
+	case "B":
+		seg := t.cur
+		if seg == nil {
+			seg = t.startSegment()
+		}
+		seg.CmdOffset = endOffset
+	case "C":
+		seg := t.cur
+		if seg == nil {
+			seg = t.startSegment()
+		}
+		seg.OutputOffset = endOffset
+		seg.StartTs = time.Now().UnixMilli()
+		seg.Command = parseCmdLineParam(params)
+		seg.Cwd = cwd
+	case "D":
+		if t.cur == nil || t.cur.OutputOffset < 0 {
+			// no command was run (shells send D before every prompt)
+			return nil
+		}
+		t.cur.EndOffset = offset
+		exitCodeStr, _, _ := strings.Cut(params, ";")
+		if exitCode, err := strconv.Atoi(exitCodeStr); err == nil {
+			t.cur.ExitCode = &exitCode
+		}
+		finished = t.finishSegment()
+	}
+	return finished
+}
+
+func (t *termSegmentTracker) finishSegment() *wshrpc.TermSegment {
+	seg := t.cur
+	t.cur = nil
+	seg.EndTs = time.Now().UnixMilli()
+	if seg.StartTs > 0 && seg.EndTs > seg.StartTs {
+		seg.DurationMs = seg.EndTs - seg.StartTs
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	err := appendTermSegment(ctx, t.blockId, seg)
+	if err != nil {
+		log.Printf("error saving term segment for block %s: %v\n", t.blockId, err)
+	}
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_TermSegment,
+		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, t.blockId).String()},
+		Data:   seg,
+	})
+	return seg
+}
+
+func appendTermSegment(ctx context.Context, blockId string, seg *wshrpc.TermSegment) error {
+	barr, err := json.Marshal(seg)
+	if err != nil {
+		return fmt.Errorf("error marshaling term segment: %w", err)
+	}
+	barr = append(barr, '\n')
+	_, statErr := filestore.WFS.Stat(ctx, blockId, wavebase.BlockFile_TermSegments)
+	if statErr == fs.ErrNotExist {
+		err = filestore.WFS.MakeFile(ctx, blockId, wavebase.BlockFile_TermSegments, nil, wshrpc.FileOpts{MaxSize: TermSegmentsMaxSize, Circular: true})
+		if err != nil {
+			return fmt.Errorf("error making term segments file: %w", err)
+		}
+	}
+	return filestore.WFS.AppendData(ctx, blockId, wavebase.BlockFile_TermSegments, barr)
+}
+
+func readTermSegments(ctx context.Context, blockId string) ([]wshrpc.TermSegment, error) {
+	_, barr, err := filestore.WFS.ReadFile(ctx, blockId, wavebase.BlockFile_TermSegments)
+	if err == fs.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rtn []wshrpc.TermSegment
+	for _, line := range bytes.Split(barr, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		var seg wshrpc.TermSegment
+		if err := json.Unmarshal(line, &seg); err != nil {
+			// the first line can be cut off when the circular file wraps
+			continue
+		}
+		rtn = append(rtn, seg)
+	}
+	return rtn, nil
+}
+
+// returns the completed segments whose output is still (at least partially) in the term file
+func GetTermSegments(ctx context.Context, blockId string) ([]wshrpc.TermSegment, error) {
+	segments, err := readTermSegments(ctx, blockId)
+	if err != nil {
+		return nil, fmt.Errorf("error reading term segments: %w", err)
+	}
+	wfile, err := filestore.WFS.Stat(ctx, blockId, wavebase.BlockFile_Term)
+	if err == fs.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting term file: %w", err)
+	}
+	dataStart := wfile.DataStartIdx()
+	rtn := make([]wshrpc.TermSegment, 0, len(segments))
+	for _, seg := range segments {
+		if seg.EndOffset < dataStart || seg.EndOffset > wfile.Size {
+			continue
+		}
+		rtn = append(rtn, seg)
+	}
+	return rtn, nil
+}
+
+// a negative segIdx returns the output of the last completed command
+func GetTermSegmentOutput(ctx context.Context, blockId string, segIdx int, includePrompt bool) (*wshrpc.TermSegmentOutput, error) {
+	segments, err := GetTermSegments(ctx, blockId)
+	if err != nil {
+		return nil, err
+	}
+	var seg *wshrpc.TermSegment
+	for idx := range segments {
+		if segments[idx].SegIdx == segIdx {
+			seg = &segments[idx]
+			break
+		}
+	}
+	if segIdx < 0 {
+		if len(segments) == 0 {
+			return nil, fmt.Errorf("no completed commands in block %q", blockId)
+		}
+		seg = &segments[len(segments)-1]
+	}
+	if seg == nil {
+		return nil, fmt.Errorf("term segment %d not found", segIdx)
+	}
+	startOffset := seg.OutputOffset
+	if includePrompt && seg.PromptOffset >= 0 {
+		startOffset = seg.PromptOffset
+	}
+	size := seg.EndOffset - startOffset
+	truncated := false
+	if size > TermSegmentMaxOutputSize {
+		startOffset = seg.EndOffset - TermSegmentMaxOutputSize
+		size = TermSegmentMaxOutputSize
+		truncated = true
+	}
+	rtnOffset, data, err := filestore.WFS.ReadAt(ctx, blockId, wavebase.BlockFile_Term, startOffset, size)
+	if err != nil {
+		return nil, fmt.Errorf("error reading term file: %w", err)
+	}
+	if rtnOffset > startOffset {
+		truncated = true
+	}
+	return &wshrpc.TermSegmentOutput{
+		Segment:   *seg,
+		Data64:    base64.StdEncoding.EncodeToString(data),
+		Truncated: truncated,
+	}, nil
+}
+
+func deleteTermSegments(ctx context.Context, blockId string) {
+	err := filestore.WFS.DeleteFile(ctx, blockId, wavebase.BlockFile_TermSegments)
+	if err != nil && err != fs.ErrNotExist {
+		log.Printf("error deleting term segments file (continuing): %v\n", err)
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package blockstream streams what a block prints to consumers outside of wave (log shippers, tests), over the
+// automation api's websocket and unix socket (see pkg/web).  a stream starts at a file offset, the output that
+// is already in the filestore is replayed from there and then it follows the appends as they are published.
+// a consumer that falls behind (or misses events) catches up from the filestore, so no output is skipped unless
+// the file dropped it (the term file is circular), which shows up as a jump in the data offsets.
+package blockstream
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+)
+
+const (
+	MsgType_Data     = "data"
+	MsgType_Truncate = "truncate" // the file was cleared, the data starts again at offset 0
+	MsgType_Closed   = "closed"   // the file (or the block) was deleted, nothing more is sent
+	MsgType_Error    = "error"    // the stream failed (sent by the api, not by Stream), nothing more is sent
+)
+
+const ReadChunkSize = 64 * 1024
+const eventQueueSize = 64
+
+type Message struct {
+	Type     string `json:"type"`
+	BlockId  string `json:"blockid"`
+	FileName string `json:"filename"`
+	Offset   int64  `json:"offset"` // the file offset of the data (pass it back, plus the data length, to resume)
+	Data64   string `json:"data64,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type SendFn = func(msg Message) error
+
+type streamer struct {
+	blockId  string
+	fileName string
+	offset   int64 // the end of the output sent so far
+	send     SendFn
+}
+
+func (s *streamer) sendMsg(msgType string, offset int64, data []byte) error {
+	msg := Message{Type: msgType, BlockId: s.blockId, FileName: s.fileName, Offset: offset}
+	if len(data) > 0 {
+		msg.Data64 = base64.StdEncoding.EncodeToString(data)
+	}
+	return s.send(msg)
+}
+
+// skips the part of the data that was already sent
+func (s *streamer) sendData(offset int64, data []byte) error {
+	endOffset := offset + int64(len(data))
+	if endOffset <= s.offset {
+		return nil
+	}
+	if offset < s.offset {
+		data = data[s.offset-offset:]
+		offset = s.offset
+	}
+	s.offset = endOffset
+	return s.sendMsg(MsgType_Data, offset, data)
+}
+
+// returns true if output before the append was missed (it has to be read from the filestore).  not all the
+// appends have an offset (e.g. ijson appends), those are also read from the filestore.
+func (s *streamer) handleAppend(offset int64, data []byte) (bool, error) {
+	if offset > s.offset || (offset == 0 && s.offset > 0) {
+		return true, nil
+	}
+	return false, s.sendData(offset, data)
+}
+
+func (s *streamer) handleTruncate() error {
+	s.offset = 0
+	return s.sendMsg(MsgType_Truncate, 0, nil)
+}
+
+// sends everything in the filestore after s.offset, returns fs.ErrNotExist if the file is gone
+func (s *streamer) catchUp(ctx context.Context) error {
+	wfile, err := filestore.WFS.Stat(ctx, s.blockId, s.fileName)
+	if err != nil {
+		return err
+	}
+	if wfile.Size < s.offset {
+		// truncated (and maybe written again) while we were not looking
+		if err := s.handleTruncate(); err != nil {
+			return err
+		}
+	}
+	for s.offset < wfile.Size {
+		dataOffset, data, err := filestore.WFS.ReadAt(ctx, s.blockId, s.fileName, s.offset, ReadChunkSize)
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			break
+		}
+		// dataOffset is past s.offset if the circular file dropped the output in between
+		err = s.sendData(dataOffset, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streams the block's file from offset (from the current end if offset is negative) until ctx is done, send
+// returns an error, or the file is deleted.  send is only called from this goroutine.
+func Stream(ctx context.Context, blockId string, fileName string, offset int64, send SendFn) error {
+	events := make(chan wps.WSFileEventData, eventQueueSize)
+	var overflow atomic.Bool
+	fileSubId := eventbus.Subscribe(eventbus.Topic_BlockFile, eventbus.Scope{BlockId: blockId}, func(event eventbus.Event) {
+		fileEvent, ok := event.Data().(*wps.WSFileEventData)
+		if !ok || fileEvent.FileName != fileName {
+			return
+		}
+		select {
+		case events <- *fileEvent:
+		default:
+			// caught up from the filestore on the next event
+			overflow.Store(true)
+		}
+	})
+	defer eventbus.Unsubscribe(fileSubId)
+	closedCh := make(chan struct{})
+	closeOnce := &sync.Once{}
+	blockSubId := eventbus.Subscribe(eventbus.Topic_ObjectUpdate, eventbus.Scope{BlockId: blockId}, func(event eventbus.Event) {
+		update, ok := event.Data().(waveobj.WaveObjUpdate)
+		if ok && update.OType == waveobj.OType_Block && update.UpdateType == waveobj.UpdateType_Delete {
+			closeOnce.Do(func() { close(closedCh) })
+		}
+	})
+	defer eventbus.Unsubscribe(blockSubId)
+
+	s := &streamer{blockId: blockId, fileName: fileName, send: send}
+	wfile, err := filestore.WFS.Stat(ctx, blockId, fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("block %s has no %q file", blockId, fileName)
+	}
+	if err != nil {
+		return err
+	}
+	s.offset = offset
+	if offset < 0 || offset > wfile.Size {
+		s.offset = wfile.Size
+	}
+	sendClosed := func() error {
+		return s.sendMsg(MsgType_Closed, s.offset, nil)
+	}
+	err = s.catchUp(ctx)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sendClosed()
+	}
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-closedCh:
+			return sendClosed()
+		case event := <-events:
+			needCatchUp := overflow.Swap(false)
+			switch event.FileOp {
+			case wps.FileOp_Append:
+				if needCatchUp {
+					break
+				}
+				data, err := base64.StdEncoding.DecodeString(event.Data64)
+				if err != nil {
+					needCatchUp = true
+					break
+				}
+				needCatchUp, err = s.handleAppend(event.Offset, data)
+				if err != nil {
+					return err
+				}
+			case wps.FileOp_Truncate:
+				if err := s.handleTruncate(); err != nil {
+					return err
+				}
+			case wps.FileOp_Delete:
+				return sendClosed()
+			default:
+				// create, invalidate (the output pusher dropped appends)
+				needCatchUp = true
+			}
+			if !needCatchUp {
+				continue
+			}
+			err := s.catchUp(ctx)
+			if errors.Is(err, fs.ErrNotExist) {
+				return sendClosed()
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockstream
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func makeTestStreamer(offset int64) (*streamer, *[]Message) {
+	var msgs []Message
+	s := &streamer{blockId: "block", fileName: "term", offset: offset, send: func(msg Message) error {
+		msgs = append(msgs, msg)
+		return nil
+	}}
+	return s, &msgs
+}
+
+func msgData(t *testing.T, msg Message) string {
+	barr, err := base64.StdEncoding.DecodeString(msg.Data64)
+	if err != nil {
+		t.Fatalf("invalid data64: %v", err)
+	}
+	return string(barr)
+}
+
+func TestHandleAppend(t *testing.T) {
+	s, msgs := makeTestStreamer(5)
+	// already sent
+	if catchUp, _ := s.handleAppend(2, []byte("llo")); catchUp || len(*msgs) != 0 {
+		t.Fatalf("output before the offset should be skipped")
+	}
+	// overlaps the offset, only the new part is sent
+	if catchUp, _ := s.handleAppend(3, []byte("lo world")); catchUp {
+		t.Fatalf("an overlapping append should not need a catch up")
+	}
+	if len(*msgs) != 1 || (*msgs)[0].Offset != 5 || msgData(t, (*msgs)[0]) != " world" {
+		t.Fatalf("expected \" world\" at offset 5, got %v", *msgs)
+	}
+	if s.offset != 11 {
+		t.Errorf("expected offset 11, got %d", s.offset)
+	}
+	// a gap means appends were missed
+	if catchUp, _ := s.handleAppend(20, []byte("later")); !catchUp || len(*msgs) != 1 {
+		t.Errorf("an append past the offset should need a catch up")
+	}
+	// appends without an offset are read from the filestore
+	if catchUp, _ := s.handleAppend(0, []byte("{}")); !catchUp {
+		t.Errorf("an append without an offset should need a catch up")
+	}
+	if err := s.handleTruncate(); err != nil || s.offset != 0 || (*msgs)[1].Type != MsgType_Truncate {
+		t.Fatalf("expected a truncate message and the offset to be reset")
+	}
+	if catchUp, _ := s.handleAppend(0, []byte("again")); catchUp || msgData(t, (*msgs)[2]) != "again" {
+		t.Errorf("expected the append after the truncate to be sent")
+	}
+}
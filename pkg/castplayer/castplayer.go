@@ -0,0 +1,373 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package castplayer plays asciicast recordings for the player view.  the output of the recording is written
+// to the player block's term file as it plays (so the frontend displays it the same way it displays a
+// terminal), and the player state is published as player:status events.  seeking rewrites the term file with
+// all of the output up to the new position.  gaps longer than the recording's idle_time_limit are shortened.
+package castplayer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/asciicast"
+	"github.com/wavetermdev/waveterm/pkg/util/ds"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const (
+	State_Playing = "playing"
+	State_Paused  = "paused"
+	State_Ended   = "ended"
+
+	DefaultSpeed      = 1.0
+	MinSpeed          = 0.1
+	MaxSpeed          = 16.0
+	MaxCastFileSize   = 64 * 1024 * 1024
+	PlayerTermMaxSize = 2 * 1024 * 1024
+	WaveFilePrefix    = "wavefile://"
+)
+
+var players = ds.MakeSyncMap[*player]()
+
+type player struct {
+	lock      sync.Mutex
+	blockId   string
+	src       string
+	title     string
+	events    []asciicast.Event // times have the idle time limit applied
+	duration  float64
+	state     string
+	speed     float64
+	pos       float64   // position when playback was last started, paused, or seeked
+	playStart time.Time // wall time playback started at pos
+	nextIdx   int       // next event to write
+	width     int
+	height    int
+	timer     *time.Timer
+}
+
+// src is wavefile://[zoneid]/[name] for a blockfile (e.g. a recording made with wsh record), otherwise it is a
+// path on the local machine
+func readCastFile(ctx context.Context, src string) ([]byte, error) {
+	if blockPath, ok := strings.CutPrefix(src, WaveFilePrefix); ok {
+		zoneId, fileName, found := strings.Cut(blockPath, "/")
+		if !found || zoneId == "" || fileName == "" {
+			return nil, fmt.Errorf("invalid wavefile path %q", src)
+		}
+		_, data, err := filestore.WFS.ReadFile(ctx, zoneId, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", src, err)
+		}
+		return data, nil
+	}
+	filePath, err := wavebase.ExpandHomeDir(src)
+	if err != nil {
+		return nil, err
+	}
+	finfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", src, err)
+	}
+	if finfo.Size() > MaxCastFileSize {
+		return nil, fmt.Errorf("%s is too large to play (%d bytes, max %d)", src, finfo.Size(), MaxCastFileSize)
+	}
+	return os.ReadFile(filePath)
+}
+
+// shortens gaps longer than idleLimit (no limit if idleLimit is 0)
+func limitIdleTime(events []asciicast.Event, idleLimit float64) []asciicast.Event {
+	rtn := make([]asciicast.Event, len(events))
+	var lastTime, adjTime float64
+	for idx, event := range events {
+		gap := max(0, event.Time-lastTime)
+		if idleLimit > 0 {
+			gap = min(gap, idleLimit)
+		}
+		lastTime = max(lastTime, event.Time)
+		adjTime += gap
+		event.Time = adjTime
+		rtn[idx] = event
+	}
+	return rtn
+}
+
+func makePlayer(blockId string, src string, cast *asciicast.Cast) *player {
+	p := &player{
+		blockId: blockId,
+		src:     src,
+		title:   cast.Header.Title,
+		events:  limitIdleTime(cast.Events, cast.Header.IdleTimeLimit),
+		state:   State_Paused,
+		speed:   DefaultSpeed,
+		width:   cast.Header.Width,
+		height:  cast.Header.Height,
+	}
+	if p.width <= 0 || p.height <= 0 {
+		p.width, p.height = shellutil.DefaultTermCols, shellutil.DefaultTermRows
+	}
+	if len(p.events) > 0 {
+		p.duration = p.events[len(p.events)-1].Time
+	}
+	return p
+}
+
+func ensurePlayerTermFile(ctx context.Context, blockId string) error {
+	_, err := filestore.WFS.Stat(ctx, blockId, wavebase.BlockFile_Term)
+	if err == fs.ErrNotExist {
+		return filestore.WFS.MakeFile(ctx, blockId, wavebase.BlockFile_Term, nil, wshrpc.FileOpts{MaxSize: PlayerTermMaxSize, Circular: true})
+	}
+	return err
+}
+
+// loads src into the block's player (paused at the start).  if the block is already playing src, its current
+// status is returned (e.g. when the frontend reloads).
+func Load(ctx context.Context, blockId string, src string) (*wshrpc.PlayerStatus, error) {
+	if src == "" {
+		return nil, fmt.Errorf("no recording to play")
+	}
+	if p := players.Get(blockId); p != nil && p.src == src {
+		return p.getStatus(), nil
+	}
+	data, err := readCastFile(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	cast, err := asciicast.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", src, err)
+	}
+	err = ensurePlayerTermFile(ctx, blockId)
+	if err != nil {
+		return nil, fmt.Errorf("error creating player term file: %w", err)
+	}
+	Close(blockId)
+	p := makePlayer(blockId, src, cast)
+	players.Set(blockId, p)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.seek_withlock(0)
+	return p.publishStatus_withlock(), nil
+}
+
+// stops the block's player (the block is being deleted)
+func Close(blockId string) {
+	p := players.Get(blockId)
+	if p == nil {
+		return
+	}
+	players.Delete(blockId)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.state = State_Paused
+	p.stopTimer_withlock()
+}
+
+func getPlayer(blockId string) (*player, error) {
+	p := players.Get(blockId)
+	if p == nil {
+		return nil, fmt.Errorf("no recording loaded in block %q", blockId)
+	}
+	return p, nil
+}
+
+func Play(blockId string) (*wshrpc.PlayerStatus, error) {
+	p, err := getPlayer(blockId)
+	if err != nil {
+		return nil, err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.state == State_Ended {
+		p.seek_withlock(0)
+	}
+	if p.state != State_Playing {
+		p.state = State_Playing
+		p.playStart = time.Now()
+		p.step_withlock()
+	}
+	return p.publishStatus_withlock(), nil
+}
+
+func Pause(blockId string) (*wshrpc.PlayerStatus, error) {
+	p, err := getPlayer(blockId)
+	if err != nil {
+		return nil, err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.state == State_Playing {
+		p.pos = p.curPos_withlock()
+		p.state = State_Paused
+		p.stopTimer_withlock()
+	}
+	return p.publishStatus_withlock(), nil
+}
+
+func Seek(blockId string, pos float64) (*wshrpc.PlayerStatus, error) {
+	p, err := getPlayer(blockId)
+	if err != nil {
+		return nil, err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.seek_withlock(pos)
+	return p.publishStatus_withlock(), nil
+}
+
+func SetSpeed(blockId string, speed float64) (*wshrpc.PlayerStatus, error) {
+	if speed < MinSpeed || speed > MaxSpeed {
+		return nil, fmt.Errorf("speed must be between %v and %v", MinSpeed, MaxSpeed)
+	}
+	p, err := getPlayer(blockId)
+	if err != nil {
+		return nil, err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.state == State_Playing {
+		p.pos = p.curPos_withlock()
+		p.playStart = time.Now()
+	}
+	p.speed = speed
+	if p.state == State_Playing {
+		p.step_withlock()
+	}
+	return p.publishStatus_withlock(), nil
+}
+
+func (p *player) curPos_withlock() float64 {
+	if p.state != State_Playing {
+		return p.pos
+	}
+	return min(p.duration, p.pos+time.Since(p.playStart).Seconds()*p.speed)
+}
+
+func (p *player) stopTimer_withlock() {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+// applies the events up to (and including) pos, returns the output they write
+func (p *player) advance_withlock(pos float64) []byte {
+	var buf bytes.Buffer
+	for ; p.nextIdx < len(p.events) && p.events[p.nextIdx].Time <= pos; p.nextIdx++ {
+		event := p.events[p.nextIdx]
+		switch event.Code {
+		case asciicast.EventOutput:
+			buf.WriteString(event.Data)
+		case asciicast.EventResize:
+			if cols, rows, ok := asciicast.ParseResize(event.Data); ok {
+				p.width, p.height = cols, rows
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func (p *player) writeOutput(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	err := blockcontroller.HandleAppendBlockFile(p.blockId, wavebase.BlockFile_Term, data)
+	if err != nil {
+		log.Printf("error writing player output for block %s: %v\n", p.blockId, err)
+	}
+}
+
+// the term file is rewritten with all of the output up to pos
+func (p *player) seek_withlock(pos float64) {
+	pos = max(0, min(p.duration, pos))
+	err := blockcontroller.HandleTruncateBlockFile(p.blockId)
+	if err != nil {
+		log.Printf("error truncating player output for block %s: %v\n", p.blockId, err)
+	}
+	p.nextIdx = 0
+	p.pos = pos
+	p.playStart = time.Now()
+	p.writeOutput(p.advance_withlock(pos))
+	if p.state == State_Playing {
+		p.step_withlock()
+	} else if p.state == State_Ended && pos < p.duration {
+		p.state = State_Paused
+	}
+}
+
+// writes the events that are due and schedules the next step
+func (p *player) step_withlock() {
+	p.stopTimer_withlock()
+	if p.state != State_Playing {
+		return
+	}
+	width, height := p.width, p.height
+	curPos := p.curPos_withlock()
+	p.writeOutput(p.advance_withlock(curPos))
+	if p.nextIdx >= len(p.events) {
+		p.state = State_Ended
+		p.pos = p.duration
+		p.publishStatus_withlock()
+		return
+	}
+	if p.width != width || p.height != height {
+		p.publishStatus_withlock()
+	}
+	wait := time.Duration((p.events[p.nextIdx].Time - curPos) / p.speed * float64(time.Second))
+	p.timer = time.AfterFunc(max(0, wait), p.step)
+}
+
+func (p *player) step() {
+	defer func() {
+		panichandler.PanicHandler("castplayer:step", recover())
+	}()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.step_withlock()
+}
+
+func (p *player) getStatus() *wshrpc.PlayerStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.getStatus_withlock()
+}
+
+func (p *player) getStatus_withlock() *wshrpc.PlayerStatus {
+	return &wshrpc.PlayerStatus{
+		BlockId:  p.blockId,
+		Src:      p.src,
+		Title:    p.title,
+		State:    p.state,
+		Pos:      p.curPos_withlock(),
+		Ts:       time.Now().UnixMilli(),
+		Duration: p.duration,
+		Speed:    p.speed,
+		Width:    p.width,
+		Height:   p.height,
+	}
+}
+
+func (p *player) publishStatus_withlock() *wshrpc.PlayerStatus {
+	status := p.getStatus_withlock()
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_PlayerStatus,
+		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, p.blockId).String()},
+		Data:   status,
+	})
+	return status
+}
@@ -0,0 +1,320 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clusterexec runs a command on many connections at once (a small pssh): the saved connections with one
+// of the tags and the connections that are named, at most Concurrency of them at a time, each with its own
+// timeout (which includes connecting).  a run is started in the background and kept in memory, its progress is
+// published as clusterexec:update events and the results view fetches it (ClusterExecGetCommand).  when every
+// host finished, the hosts with the same exit code and output are grouped (like dshbak -c).  the last
+// MaxFinishedRuns finished runs are kept.
+package clusterexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const (
+	DefaultConcurrency = 10
+	MaxConcurrency     = 100
+	MaxHosts           = 1000
+	MaxFinishedRuns    = 20
+)
+
+const (
+	HostStatus_Pending = "pending"
+	HostStatus_Running = "running"
+	HostStatus_Done    = "done" // the command ran (with any exit code)
+	HostStatus_Error   = "error"
+)
+
+type clusterRun struct {
+	lock     sync.Mutex
+	run      wshrpc.ClusterExecRun
+	cancelFn context.CancelFunc
+}
+
+var runsLock = &sync.Mutex{}
+var runs = make(map[string]*clusterRun)
+var finishedRunIds []string // oldest first
+
+// the saved connections (by name) with one of the tags, after the named connections.  a named saved connection
+// (by id or name) is its name, the duplicates are dropped.
+func selectHosts(conns []*waveobj.Connection, tags []string, connections []string) []string {
+	var rtn []string
+	seen := make(map[string]bool)
+	addHost := func(host string) {
+		if host == "" || seen[host] {
+			return
+		}
+		seen[host] = true
+		rtn = append(rtn, host)
+	}
+	for _, connection := range connections {
+		connection = strings.TrimSpace(connection)
+		for _, conn := range conns {
+			if conn.OID == connection {
+				connection = conn.Name
+				break
+			}
+		}
+		addHost(connection)
+	}
+	for _, conn := range conns {
+		for _, tag := range tags {
+			if utilfn.ContainsStr(conn.Tags, tag) {
+				addHost(conn.Name)
+				break
+			}
+		}
+	}
+	return rtn
+}
+
+type groupKey struct {
+	exitCode int
+	errStr   string
+	stdout   string
+	stderr   string
+}
+
+func hostGroupKey(host wshrpc.ClusterExecHost) groupKey {
+	if host.Result == nil {
+		return groupKey{exitCode: -1, errStr: host.Error}
+	}
+	key := groupKey{exitCode: host.Result.ExitCode, stdout: host.Result.Stdout, stderr: host.Result.Stderr}
+	if host.Result.TimedOut {
+		key.errStr = "timed out"
+	} else if host.Result.Signal != "" {
+		key.errStr = "killed by " + host.Result.Signal
+	}
+	return key
+}
+
+// the hosts with the same exit code, output and error, the largest groups first (then in the order of the hosts)
+func groupResults(hosts []wshrpc.ClusterExecHost) []wshrpc.ClusterExecGroup {
+	var rtn []wshrpc.ClusterExecGroup
+	groupIdx := make(map[groupKey]int)
+	for _, host := range hosts {
+		key := hostGroupKey(host)
+		idx, ok := groupIdx[key]
+		if !ok {
+			idx = len(rtn)
+			groupIdx[key] = idx
+			rtn = append(rtn, wshrpc.ClusterExecGroup{ExitCode: key.exitCode, Error: key.errStr, Stdout: key.stdout, Stderr: key.stderr})
+		}
+		rtn[idx].Hosts = append(rtn[idx].Hosts, host.Connection)
+	}
+	sort.SliceStable(rtn, func(i, j int) bool {
+		return len(rtn[i].Hosts) > len(rtn[j].Hosts)
+	})
+	return rtn
+}
+
+// starts the run in the background, returns it with every host pending
+func Start(ctx context.Context, data wshrpc.CommandClusterExecData) (*wshrpc.ClusterExecRun, error) {
+	if strings.TrimSpace(data.Cmd) == "" {
+		return nil, fmt.Errorf("no command to run")
+	}
+	if len(data.Tags) == 0 && len(data.Connections) == 0 {
+		return nil, fmt.Errorf("no connections to run on (select them with tags or by name)")
+	}
+	if data.Concurrency < 0 || data.Concurrency > MaxConcurrency {
+		return nil, fmt.Errorf("invalid concurrency %d (must be between 1 and %d)", data.Concurrency, MaxConcurrency)
+	}
+	if data.Concurrency == 0 {
+		data.Concurrency = DefaultConcurrency
+	}
+	if data.TimeoutMs < 0 {
+		return nil, fmt.Errorf("invalid timeout %dms", data.TimeoutMs)
+	}
+	conns, err := wconn.ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hosts := selectHosts(conns, data.Tags, data.Connections)
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no saved connections with the tags %s", strings.Join(data.Tags, ", "))
+	}
+	if len(hosts) > MaxHosts {
+		return nil, fmt.Errorf("too many connections (%d, the limit is %d)", len(hosts), MaxHosts)
+	}
+	// the run outlives the rpc that started it
+	runCtx, cancelFn := context.WithCancel(context.Background())
+	cr := &clusterRun{
+		run: wshrpc.ClusterExecRun{
+			RunId:   uuid.New().String(),
+			Cmd:     data.Cmd,
+			StartTs: time.Now().UnixMilli(),
+		},
+		cancelFn: cancelFn,
+	}
+	for _, host := range hosts {
+		cr.run.Hosts = append(cr.run.Hosts, wshrpc.ClusterExecHost{Connection: host, Status: HostStatus_Pending})
+	}
+	runsLock.Lock()
+	runs[cr.run.RunId] = cr
+	runsLock.Unlock()
+	rtn := cr.snapshot()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("clusterexec:run", recover())
+		}()
+		defer cancelFn()
+		cr.runHosts(runCtx, data)
+	}()
+	return rtn, nil
+}
+
+func Get(runId string) (*wshrpc.ClusterExecRun, error) {
+	cr, err := getRun(runId)
+	if err != nil {
+		return nil, err
+	}
+	return cr.snapshot(), nil
+}
+
+// the hosts that are running are stopped, the pending ones are not started.  canceling a finished run does nothing.
+func Cancel(runId string) error {
+	cr, err := getRun(runId)
+	if err != nil {
+		return err
+	}
+	cr.lock.Lock()
+	if cr.run.EndTs == 0 {
+		cr.run.Canceled = true
+	}
+	cr.lock.Unlock()
+	cr.cancelFn()
+	return nil
+}
+
+func getRun(runId string) (*clusterRun, error) {
+	runsLock.Lock()
+	defer runsLock.Unlock()
+	cr := runs[runId]
+	if cr == nil {
+		return nil, fmt.Errorf("cluster exec run %q not found", runId)
+	}
+	return cr, nil
+}
+
+func (cr *clusterRun) snapshot() *wshrpc.ClusterExecRun {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	rtn := cr.run
+	// the results and groups are not changed once they are set
+	rtn.Hosts = append([]wshrpc.ClusterExecHost(nil), cr.run.Hosts...)
+	return &rtn
+}
+
+func (cr *clusterRun) runHosts(ctx context.Context, data wshrpc.CommandClusterExecData) {
+	sem := make(chan struct{}, data.Concurrency)
+	wg := &sync.WaitGroup{}
+outer:
+	for idx := range cr.run.Hosts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break outer
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("clusterexec:runHost", recover())
+			}()
+			defer func() { <-sem }()
+			defer wg.Done()
+			cr.runHost(ctx, idx, data)
+		}()
+	}
+	wg.Wait()
+	cr.finish()
+}
+
+func (cr *clusterRun) runHost(ctx context.Context, idx int, data wshrpc.CommandClusterExecData) {
+	cr.lock.Lock()
+	host := &cr.run.Hosts[idx]
+	connection := host.Connection
+	host.Status = HostStatus_Running
+	host.StartTs = time.Now().UnixMilli()
+	cr.lock.Unlock()
+	cr.publish(connection, HostStatus_Running, false)
+	result, err := wconn.RunCommand(ctx, wshrpc.CommandConnectionRunData{
+		Connection: connection,
+		Cmd:        data.Cmd,
+		Cwd:        data.Cwd,
+		Env:        data.Env,
+		TimeoutMs:  data.TimeoutMs,
+		MaxOutput:  data.MaxOutput,
+		Sudo:       data.Sudo,
+		SudoUser:   data.SudoUser,
+		NoPrompt:   true, // a prompt for each host would be too many
+	}, nil)
+	cr.lock.Lock()
+	host = &cr.run.Hosts[idx]
+	if err != nil {
+		host.Status = HostStatus_Error
+		switch {
+		case errors.Is(err, context.Canceled):
+			host.Error = "canceled"
+		case errors.Is(err, context.DeadlineExceeded):
+			host.Error = "timed out connecting"
+		default:
+			host.Error = err.Error()
+		}
+	} else {
+		host.Status = HostStatus_Done
+		host.ConnName = result.ConnName
+		host.Result = result
+	}
+	status := host.Status
+	cr.lock.Unlock()
+	cr.publish(connection, status, false)
+}
+
+func (cr *clusterRun) finish() {
+	cr.lock.Lock()
+	for idx := range cr.run.Hosts {
+		host := &cr.run.Hosts[idx]
+		if host.Status == HostStatus_Pending {
+			host.Status = HostStatus_Error
+			host.Error = "canceled"
+		}
+	}
+	cr.run.EndTs = time.Now().UnixMilli()
+	cr.run.Groups = groupResults(cr.run.Hosts)
+	runId := cr.run.RunId
+	cr.lock.Unlock()
+	runsLock.Lock()
+	finishedRunIds = append(finishedRunIds, runId)
+	for len(finishedRunIds) > MaxFinishedRuns {
+		delete(runs, finishedRunIds[0])
+		finishedRunIds = finishedRunIds[1:]
+	}
+	runsLock.Unlock()
+	cr.publish("", "", true)
+}
+
+func (cr *clusterRun) publish(connection string, status string, done bool) {
+	eventbus.Publish(eventbus.ClusterExecEvent{Update: wps.ClusterExecEventData{
+		RunId:      cr.run.RunId,
+		Connection: connection,
+		Status:     status,
+		Done:       done,
+	}})
+}
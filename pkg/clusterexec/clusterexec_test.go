@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package clusterexec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestSelectHosts(t *testing.T) {
+	conns := []*waveobj.Connection{
+		{OID: "id-db1", Name: "db1", Tags: []string{"db", "prod"}},
+		{OID: "id-db2", Name: "db2", Tags: []string{"db"}},
+		{OID: "id-web", Name: "web", Tags: []string{"prod"}},
+	}
+	hosts := selectHosts(conns, []string{"db"}, nil)
+	if !reflect.DeepEqual(hosts, []string{"db1", "db2"}) {
+		t.Errorf("unexpected hosts for the db tag: %v", hosts)
+	}
+	hosts = selectHosts(conns, []string{"db", "prod"}, []string{"id-web", "admin@other", "db1"})
+	if !reflect.DeepEqual(hosts, []string{"web", "admin@other", "db1", "db2"}) {
+		t.Errorf("unexpected hosts for the named connections and tags: %v", hosts)
+	}
+	if hosts = selectHosts(conns, []string{"none"}, nil); len(hosts) != 0 {
+		t.Errorf("an unused tag should select nothing: %v", hosts)
+	}
+}
+
+func TestGroupResults(t *testing.T) {
+	hosts := []wshrpc.ClusterExecHost{
+		{Connection: "a", Status: HostStatus_Done, Result: &wshrpc.ConnectionRunResult{ExitCode: 0, Stdout: "ok\n"}},
+		{Connection: "b", Status: HostStatus_Error, Error: "connection refused"},
+		{Connection: "c", Status: HostStatus_Done, Result: &wshrpc.ConnectionRunResult{ExitCode: 0, Stdout: "ok\n"}},
+		{Connection: "d", Status: HostStatus_Done, Result: &wshrpc.ConnectionRunResult{ExitCode: -1, TimedOut: true}},
+		{Connection: "e", Status: HostStatus_Done, Result: &wshrpc.ConnectionRunResult{ExitCode: 1, Stdout: "ok\n"}},
+	}
+	groups := groupResults(hosts)
+	if len(groups) != 4 {
+		t.Fatalf("expected 4 groups, got %d: %+v", len(groups), groups)
+	}
+	if !reflect.DeepEqual(groups[0].Hosts, []string{"a", "c"}) || groups[0].Stdout != "ok\n" {
+		t.Errorf("unexpected first group: %+v", groups[0])
+	}
+	if groups[1].Error != "connection refused" || groups[1].ExitCode != -1 {
+		t.Errorf("unexpected error group: %+v", groups[1])
+	}
+	if groups[2].Error != "timed out" || !reflect.DeepEqual(groups[2].Hosts, []string{"d"}) {
+		t.Errorf("unexpected timeout group: %+v", groups[2])
+	}
+	if groups[3].ExitCode != 1 {
+		t.Errorf("another exit code should be its own group: %+v", groups[3])
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cmdhistory stores the command lines run in terminal blocks (reported by the shell integration
+// scripts via OSC 133) so history survives shell restarts and can be searched across blocks.
+package cmdhistory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const MaxHistoryEntries = 20000
+const DefaultSearchLimit = 100
+const MaxSearchLimit = 1000
+
+const historyColumns = `historyid, ts, blockid, workspaceid, connname, cwd, cmdstr, exitcode, durationms`
+
+// adds a command to the history.  if the previous command in the same block is identical it
+// is updated instead of adding a new entry (like HISTCONTROL=ignoredups).
+func AddEntry(ctx context.Context, entry *wshrpc.CmdHistoryEntry) error {
+	if strings.TrimSpace(entry.CmdStr) == "" {
+		return nil
+	}
+	if entry.HistoryId == "" {
+		entry.HistoryId = uuid.New().String()
+	}
+	if entry.Ts == 0 {
+		entry.Ts = time.Now().UnixMilli()
+	}
+	if entry.ConnName == "" {
+		entry.ConnName = wshrpc.LocalConnName
+	}
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		var prev struct {
+			HistoryId string `db:"historyid"`
+			CmdStr    string `db:"cmdstr"`
+		}
+		query := `SELECT historyid, cmdstr FROM db_cmdhistory WHERE blockid = ? ORDER BY ts DESC LIMIT 1`
+		found := tx.Get(&prev, query, entry.BlockId)
+		if found && prev.CmdStr == entry.CmdStr {
+			query = `UPDATE db_cmdhistory SET ts = ?, cwd = ?, exitcode = ?, durationms = ? WHERE historyid = ?`
+			tx.Exec(query, entry.Ts, entry.Cwd, entry.ExitCode, entry.DurationMs, prev.HistoryId)
+			entry.HistoryId = prev.HistoryId
+			return nil
+		}
+		query = `INSERT INTO db_cmdhistory (` + historyColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		tx.Exec(query, entry.HistoryId, entry.Ts, entry.BlockId, entry.WorkspaceId, entry.ConnName, entry.Cwd, entry.CmdStr, entry.ExitCode, entry.DurationMs)
+		query = `DELETE FROM db_cmdhistory WHERE historyid IN (SELECT historyid FROM db_cmdhistory ORDER BY ts DESC LIMIT -1 OFFSET ?)`
+		tx.Exec(query, MaxHistoryEntries)
+		return nil
+	})
+}
+
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `%`, `\%`)
+	return strings.ReplaceAll(s, `_`, `\_`)
+}
+
+// returns matching entries, most recent first
+func Search(ctx context.Context, opts wshrpc.CommandCmdHistorySearchData) ([]*wshrpc.CmdHistoryEntry, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+	var conds []string
+	var args []any
+	if opts.BlockId != "" {
+		conds = append(conds, `blockid = ?`)
+		args = append(args, opts.BlockId)
+	}
+	if opts.WorkspaceId != "" {
+		conds = append(conds, `workspaceid = ?`)
+		args = append(args, opts.WorkspaceId)
+	}
+	if opts.ConnName != "" {
+		conds = append(conds, `connname = ?`)
+		args = append(args, opts.ConnName)
+	}
+	if opts.Query != "" {
+		conds = append(conds, `cmdstr LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(opts.Query)+"%")
+	}
+	whereClause := ""
+	if len(conds) > 0 {
+		whereClause = "WHERE " + strings.Join(conds, " AND ")
+	}
+	var query string
+	if opts.Dedup {
+		query = fmt.Sprintf(`SELECT %s FROM (
+			SELECT %s, ROW_NUMBER() OVER (PARTITION BY cmdstr ORDER BY ts DESC) AS rn FROM db_cmdhistory %s
+		) WHERE rn = 1 ORDER BY ts DESC LIMIT ?`, historyColumns, historyColumns, whereClause)
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM db_cmdhistory %s ORDER BY ts DESC LIMIT ?`, historyColumns, whereClause)
+	}
+	args = append(args, limit)
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*wshrpc.CmdHistoryEntry, error) {
+		var rtn []*wshrpc.CmdHistoryEntry
+		tx.Select(&rtn, query, args...)
+		return rtn, nil
+	})
+}
+
+func DeleteEntries(ctx context.Context, historyIds []string) error {
+	if len(historyIds) == 0 {
+		return nil
+	}
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		query := `DELETE FROM db_cmdhistory WHERE historyid IN (SELECT value FROM json_each(?))`
+		tx.Exec(query, dbutil.QuickJson(historyIds))
+		return nil
+	})
+}
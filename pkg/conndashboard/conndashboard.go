@@ -0,0 +1,136 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package conndashboard is the backend of the connections view: the saved connections with their status (and
+// metrics), the blocks that use them and their port forwards, and the actions on a connection (connect, disconnect
+// and open a terminal) in the action registry.  the view refreshes on the connchange events and every few seconds
+// (for the metrics and the bytes of the forwards).
+package conndashboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/portforward"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/waction"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	ActionId_OpenTerm   = "conn:openterm"
+	ActionId_Connect    = "conn:connect"
+	ActionId_Disconnect = "conn:disconnect"
+)
+
+func init() {
+	waction.MustRegisterAction(wshrpc.ActionDef{
+		ActionId: ActionId_OpenTerm,
+		Title:    "Open Terminal",
+		Icon:     "square-terminal",
+		OTypes:   []string{waveobj.OType_Connection},
+		Order:    10,
+	}, openTermAction)
+	waction.MustRegisterAction(wshrpc.ActionDef{
+		ActionId: ActionId_Connect,
+		Title:    "Connect",
+		Icon:     "link",
+		OTypes:   []string{waveobj.OType_Connection},
+		Order:    20,
+	}, connectAction)
+	waction.MustRegisterAction(wshrpc.ActionDef{
+		ActionId: ActionId_Disconnect,
+		Title:    "Disconnect",
+		Icon:     "link-slash",
+		OTypes:   []string{waveobj.OType_Connection},
+		Order:    30,
+	}, disconnectAction)
+}
+
+// the saved connections (by name) with their forwards and metrics (for the connections used since wave started)
+func GetDashboard(ctx context.Context) ([]wshrpc.ConnDashboardEntry, error) {
+	conns, err := wconn.ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fwds, err := portforward.ListForwards(ctx, wshrpc.CommandPortForwardListData{})
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]wshrpc.ConnDashboardEntry, 0, len(conns))
+	for _, conn := range conns {
+		info, err := wconn.GetConnectionInfo(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		entry := wshrpc.ConnDashboardEntry{Info: *info}
+		if opts, err := remote.ParseOpts(info.ConnName); err == nil {
+			if sshConn := conncontroller.GetConn(opts); sshConn != nil {
+				metrics := sshConn.GetMetrics(false)
+				entry.Metrics = &metrics
+			}
+		}
+		for _, fwd := range fwds {
+			if fwd.ConnName == info.ConnName {
+				entry.Forwards = append(entry.Forwards, fwd)
+			}
+		}
+		rtn = append(rtn, entry)
+	}
+	return rtn, nil
+}
+
+func getSSHConn(ctx context.Context, connId string) (*conncontroller.SSHConn, error) {
+	conn, err := wstore.DBMustGet[*waveobj.Connection](ctx, connId)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := remote.ParseOpts(wconn.ConnName(conn))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing connection name: %w", err)
+	}
+	sshConn := conncontroller.GetConn(opts)
+	if sshConn == nil {
+		return nil, fmt.Errorf("connection not found: %s", wconn.ConnName(conn))
+	}
+	return sshConn, nil
+}
+
+func openTermAction(ctx context.Context, data wshrpc.CommandExecuteActionData) error {
+	if data.TabId == "" {
+		return fmt.Errorf("no tab to open the terminal in")
+	}
+	ctx = waveobj.ContextWithUpdates(ctx)
+	conn, err := wstore.DBMustGet[*waveobj.Connection](ctx, data.ORef.OID)
+	if err != nil {
+		return err
+	}
+	_, err = wconn.OpenTerm(ctx, conn, data.TabId)
+	if err != nil {
+		return fmt.Errorf("error creating block: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func connectAction(ctx context.Context, data wshrpc.CommandExecuteActionData) error {
+	sshConn, err := getSSHConn(ctx, data.ORef.OID)
+	if err != nil {
+		return err
+	}
+	return sshConn.Connect(ctx, &wconfig.ConnKeywords{})
+}
+
+func disconnectAction(ctx context.Context, data wshrpc.CommandExecuteActionData) error {
+	sshConn, err := getSSHConn(ctx, data.ORef.OID)
+	if err != nil {
+		return err
+	}
+	return sshConn.Close()
+}
@@ -0,0 +1,397 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package eventbus
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the event bus carries the backend's typed events (object updates, block file output, controller status,
+// notifications).  each event has a topic and the scopes (orefs) it belongs to.  an event is delivered to the
+// in-process subscribers whose topic pattern and scope match, and is then forwarded to wps, which routes it
+// to the rpc clients (the frontend, wsh) subscribed to the topic and one of its scopes.
+//
+// a subscription's topic can have wildcards ("term:*" matches "term:segment", "**" matches every topic), and
+// it can be scoped to a block, a tab (the tab and the blocks in it), or a window (the window, its tabs, and
+// their blocks).  the tab of a block and the window of a tab are looked up in the db and cached, the cache is
+// cleared when an update for a block, tab, workspace, or window is published.
+
+const (
+	Topic_ObjectUpdate     = wps.Event_WaveObjUpdate
+	Topic_BlockFile        = wps.Event_BlockFile
+	Topic_ControllerStatus = wps.Event_ControllerStatus
+	Topic_Notification     = wps.Event_Notification
+	Topic_BlockExit        = wps.Event_BlockExit
+	Topic_WorkspaceCreate  = wps.Event_WorkspaceCreate
+	Topic_WorkspaceDelete  = wps.Event_WorkspaceDelete
+	Topic_FileTransfer     = wps.Event_FileTransfer
+	Topic_AgentForward     = wps.Event_AgentForward
+	Topic_ConnState        = wps.Event_ConnState
+	Topic_ConnAuthExpiry   = wps.Event_ConnAuthExpiry
+	Topic_ClusterExec      = wps.Event_ClusterExec
+	Topic_SettingsChange   = wps.Event_SettingsChange
+)
+
+const scopeLookupTimeout = 2 * time.Second
+
+type Event interface {
+	Topic() string
+	Scopes() []string // orefs
+	Data() any        // what is sent to the rpc subscribers
+}
+
+type ObjectUpdateEvent struct {
+	Update waveobj.WaveObjUpdate
+}
+
+func (e ObjectUpdateEvent) Topic() string { return Topic_ObjectUpdate }
+func (e ObjectUpdateEvent) Scopes() []string {
+	return []string{waveobj.MakeORef(e.Update.OType, e.Update.OID).String()}
+}
+func (e ObjectUpdateEvent) Data() any { return e.Update }
+
+// output (appends) and other changes to a block's files
+type BlockFileEvent struct {
+	File wps.WSFileEventData
+}
+
+func (e BlockFileEvent) Topic() string { return Topic_BlockFile }
+func (e BlockFileEvent) Scopes() []string {
+	return []string{waveobj.MakeORef(waveobj.OType_Block, e.File.ZoneId).String()}
+}
+func (e BlockFileEvent) Data() any { return &e.File }
+
+type ControllerStatusEvent struct {
+	TabId   string
+	BlockId string
+	Status  any // blockcontroller.BlockControllerRuntimeStatus
+}
+
+func (e ControllerStatusEvent) Topic() string { return Topic_ControllerStatus }
+func (e ControllerStatusEvent) Scopes() []string {
+	return []string{
+		waveobj.MakeORef(waveobj.OType_Tab, e.TabId).String(),
+		waveobj.MakeORef(waveobj.OType_Block, e.BlockId).String(),
+	}
+}
+func (e ControllerStatusEvent) Data() any { return e.Status }
+
+// a notification shown in the frontend.  it is scoped to the block's tab and window as well, so a window can
+// subscribe to the notifications for its blocks.  notifications without a block are scoped to WindowIds.
+type NotificationEvent struct {
+	BlockId      string
+	WindowIds    []string
+	Notification wps.NotificationEventData
+}
+
+func (e NotificationEvent) Topic() string { return Topic_Notification }
+func (e NotificationEvent) Scopes() []string {
+	if e.BlockId == "" {
+		var rtn []string
+		for _, windowId := range e.WindowIds {
+			rtn = append(rtn, waveobj.MakeORef(waveobj.OType_Window, windowId).String())
+		}
+		return rtn
+	}
+	rtn := []string{waveobj.MakeORef(waveobj.OType_Block, e.BlockId).String()}
+	tabId := parents.getBlockTab(e.BlockId)
+	if tabId == "" {
+		return rtn
+	}
+	rtn = append(rtn, waveobj.MakeORef(waveobj.OType_Tab, tabId).String())
+	if windowId := parents.getTabWindow(tabId); windowId != "" {
+		rtn = append(rtn, waveobj.MakeORef(waveobj.OType_Window, windowId).String())
+	}
+	return rtn
+}
+func (e NotificationEvent) Data() any { return &e.Notification }
+
+type BlockExitEvent struct {
+	Exit wps.BlockExitEventData
+}
+
+func (e BlockExitEvent) Topic() string { return Topic_BlockExit }
+func (e BlockExitEvent) Scopes() []string {
+	return []string{
+		waveobj.MakeORef(waveobj.OType_Tab, e.Exit.TabId).String(),
+		waveobj.MakeORef(waveobj.OType_Block, e.Exit.BlockId).String(),
+	}
+}
+func (e BlockExitEvent) Data() any { return &e.Exit }
+
+// a workspace was created or deleted (Deleted is set)
+type WorkspaceEvent struct {
+	Deleted   bool
+	Workspace wps.WorkspaceEventData
+}
+
+func (e WorkspaceEvent) Topic() string {
+	if e.Deleted {
+		return Topic_WorkspaceDelete
+	}
+	return Topic_WorkspaceCreate
+}
+func (e WorkspaceEvent) Scopes() []string {
+	return []string{waveobj.MakeORef(waveobj.OType_Workspace, e.Workspace.WorkspaceId).String()}
+}
+func (e WorkspaceEvent) Data() any { return &e.Workspace }
+
+// the progress of a chunked transfer into or out of a block's files (ZoneId is the block)
+type FileTransferEvent struct {
+	ZoneId   string
+	Transfer wps.FileTransferEventData
+}
+
+func (e FileTransferEvent) Topic() string { return Topic_FileTransfer }
+func (e FileTransferEvent) Scopes() []string {
+	return []string{waveobj.MakeORef(waveobj.OType_Block, e.ZoneId).String()}
+}
+func (e FileTransferEvent) Data() any { return &e.Transfer }
+
+// the forwarded identity agent was used on a connection (an audit event, connections are not scoped)
+type AgentForwardEvent struct {
+	Forward wps.AgentForwardEventData
+}
+
+func (e AgentForwardEvent) Topic() string    { return Topic_AgentForward }
+func (e AgentForwardEvent) Scopes() []string { return nil }
+func (e AgentForwardEvent) Data() any        { return &e.Forward }
+
+// the health of a connection changed, scoped to the blocks that use the connection
+type ConnStateEvent struct {
+	BlockIds []string
+	State    wps.ConnStateEventData
+}
+
+func (e ConnStateEvent) Topic() string { return Topic_ConnState }
+func (e ConnStateEvent) Scopes() []string {
+	var rtn []string
+	for _, blockId := range e.BlockIds {
+		rtn = append(rtn, waveobj.MakeORef(waveobj.OType_Block, blockId).String())
+	}
+	return rtn
+}
+func (e ConnStateEvent) Data() any { return &e.State }
+
+// the kerberos ticket of a connection is expiring, expired or was renewed (like conn:agentforward, not scoped)
+type ConnAuthExpiryEvent struct {
+	Expiry wps.ConnAuthExpiryEventData
+}
+
+func (e ConnAuthExpiryEvent) Topic() string    { return Topic_ConnAuthExpiry }
+func (e ConnAuthExpiryEvent) Scopes() []string { return nil }
+func (e ConnAuthExpiryEvent) Data() any        { return &e.Expiry }
+
+// the progress of a cluster exec run (not scoped, the runs do not belong to a block)
+type ClusterExecEvent struct {
+	Update wps.ClusterExecEventData
+}
+
+func (e ClusterExecEvent) Topic() string    { return Topic_ClusterExec }
+func (e ClusterExecEvent) Scopes() []string { return nil }
+func (e ClusterExecEvent) Data() any        { return &e.Update }
+
+// the global or workspace settings were changed (not scoped, the workspace is in the data)
+type SettingsChangeEvent struct {
+	Change wps.SettingsChangeEventData
+}
+
+func (e SettingsChangeEvent) Topic() string    { return Topic_SettingsChange }
+func (e SettingsChangeEvent) Scopes() []string { return nil }
+func (e SettingsChangeEvent) Data() any        { return &e.Change }
+
+// set one of the ids (the most specific one is used), or none for events with any scope
+type Scope struct {
+	WindowId string
+	TabId    string
+	BlockId  string
+}
+
+type subscription struct {
+	id      string
+	topic   string
+	scope   Scope
+	handler func(Event)
+}
+
+var subLock = &sync.Mutex{}
+var subs []*subscription
+
+// returns the id to unsubscribe with.  handlers are called from the publishing goroutine, they must not block
+// (and must not publish events themselves).
+func Subscribe(topic string, scope Scope, handler func(Event)) string {
+	sub := &subscription{id: uuid.New().String(), topic: topic, scope: scope, handler: handler}
+	subLock.Lock()
+	defer subLock.Unlock()
+	subs = append(subs, sub)
+	return sub.id
+}
+
+func Unsubscribe(id string) {
+	subLock.Lock()
+	defer subLock.Unlock()
+	for idx, sub := range subs {
+		if sub.id == id {
+			subs = append(subs[:idx], subs[idx+1:]...)
+			return
+		}
+	}
+}
+
+func getTopicSubs(topic string) []*subscription {
+	subLock.Lock()
+	defer subLock.Unlock()
+	var rtn []*subscription
+	for _, sub := range subs {
+		if sub.topic == topic || utilfn.StarMatchString(sub.topic, topic, ":") {
+			rtn = append(rtn, sub)
+		}
+	}
+	return rtn
+}
+
+func Publish(event Event) {
+	if update, ok := event.(ObjectUpdateEvent); ok {
+		switch update.Update.OType {
+		case waveobj.OType_Block, waveobj.OType_Tab, waveobj.OType_Workspace, waveobj.OType_Window:
+			parents.clear()
+		}
+	}
+	scopes := event.Scopes()
+	for _, sub := range getTopicSubs(event.Topic()) {
+		if sub.scope.matches(scopes) {
+			sub.handler(event)
+		}
+	}
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  event.Topic(),
+		Scopes: scopes,
+		Data:   event.Data(),
+	})
+}
+
+func PublishObjectUpdates(updates waveobj.UpdatesRtnType) {
+	for _, update := range updates {
+		Publish(ObjectUpdateEvent{Update: update})
+	}
+}
+
+func (s Scope) matches(scopes []string) bool {
+	if s.BlockId == "" && s.TabId == "" && s.WindowId == "" {
+		return true
+	}
+	for _, scope := range scopes {
+		oref, err := waveobj.ParseORef(scope)
+		if err != nil {
+			continue
+		}
+		if s.matchesORef(oref) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Scope) matchesORef(oref waveobj.ORef) bool {
+	if s.BlockId != "" {
+		return oref.OType == waveobj.OType_Block && oref.OID == s.BlockId
+	}
+	tabId := ""
+	switch oref.OType {
+	case waveobj.OType_Block:
+		tabId = parents.getBlockTab(oref.OID)
+	case waveobj.OType_Tab:
+		tabId = oref.OID
+	case waveobj.OType_Window:
+		return s.TabId == "" && oref.OID == s.WindowId
+	}
+	if tabId == "" {
+		return false
+	}
+	if s.TabId != "" {
+		return tabId == s.TabId
+	}
+	return parents.getTabWindow(tabId) == s.WindowId
+}
+
+type parentCache struct {
+	lock      *sync.Mutex
+	blockTab  map[string]string
+	tabWindow map[string]string
+}
+
+var parents = &parentCache{
+	lock:      &sync.Mutex{},
+	blockTab:  make(map[string]string),
+	tabWindow: make(map[string]string),
+}
+
+// replaced in tests
+var findTabForBlock = wstore.DBFindTabForBlockId
+
+var findWindowForTab = func(ctx context.Context, tabId string) (string, error) {
+	workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, tabId)
+	if err != nil || workspaceId == "" {
+		return "", err
+	}
+	return wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+}
+
+func (pc *parentCache) clear() {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+	clear(pc.blockTab)
+	clear(pc.tabWindow)
+}
+
+func (pc *parentCache) getBlockTab(blockId string) string {
+	return pc.get(pc.blockTab, blockId, findTabForBlock)
+}
+
+func (pc *parentCache) getTabWindow(tabId string) string {
+	return pc.get(pc.tabWindow, tabId, findWindowForTab)
+}
+
+// the tab of a block ("" for a detached block), from the cache of the scope lookups
+func GetBlockTabId(blockId string) string {
+	return parents.getBlockTab(blockId)
+}
+
+// the window showing a tab ("" if its workspace is not open in a window), from the cache of the scope lookups
+func GetTabWindowId(tabId string) string {
+	return parents.getTabWindow(tabId)
+}
+
+// lookup errors are not cached
+func (pc *parentCache) get(cache map[string]string, id string, lookupFn func(context.Context, string) (string, error)) string {
+	if id == "" {
+		return ""
+	}
+	pc.lock.Lock()
+	parentId, ok := cache[id]
+	pc.lock.Unlock()
+	if ok {
+		return parentId
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), scopeLookupTimeout)
+	defer cancelFn()
+	parentId, err := lookupFn(ctx, id)
+	if err != nil {
+		log.Printf("eventbus: error looking up the parent of %s: %v\n", id, err)
+		return ""
+	}
+	pc.lock.Lock()
+	cache[id] = parentId
+	pc.lock.Unlock()
+	return parentId
+}
@@ -0,0 +1,104 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+)
+
+const (
+	block1Id  = "11111111-0000-0000-0000-000000000001"
+	block2Id  = "11111111-0000-0000-0000-000000000002"
+	block3Id  = "11111111-0000-0000-0000-000000000003"
+	tab1Id    = "22222222-0000-0000-0000-000000000001"
+	tab2Id    = "22222222-0000-0000-0000-000000000002"
+	window1Id = "33333333-0000-0000-0000-000000000001"
+	window2Id = "33333333-0000-0000-0000-000000000002"
+)
+
+func setupParents(t *testing.T) {
+	blockTabs := map[string]string{block1Id: tab1Id, block2Id: tab2Id}
+	tabWindows := map[string]string{tab1Id: window1Id, tab2Id: window2Id}
+	oldFindTab, oldFindWindow := findTabForBlock, findWindowForTab
+	findTabForBlock = func(ctx context.Context, blockId string) (string, error) {
+		tabId, ok := blockTabs[blockId]
+		if !ok {
+			return "", fmt.Errorf("block %s not found", blockId)
+		}
+		return tabId, nil
+	}
+	findWindowForTab = func(ctx context.Context, tabId string) (string, error) {
+		return tabWindows[tabId], nil
+	}
+	parents.clear()
+	t.Cleanup(func() {
+		findTabForBlock, findWindowForTab = oldFindTab, oldFindWindow
+		parents.clear()
+	})
+}
+
+func collect(t *testing.T, topic string, scope Scope) *[]Event {
+	var events []Event
+	id := Subscribe(topic, scope, func(event Event) {
+		events = append(events, event)
+	})
+	t.Cleanup(func() { Unsubscribe(id) })
+	return &events
+}
+
+func fileEvent(blockId string) BlockFileEvent {
+	return BlockFileEvent{File: wps.WSFileEventData{ZoneId: blockId, FileName: "term", FileOp: wps.FileOp_Append}}
+}
+
+func TestTopicMatch(t *testing.T) {
+	setupParents(t)
+	exact := collect(t, Topic_BlockFile, Scope{})
+	all := collect(t, "**", Scope{})
+	termEvents := collect(t, "term:*", Scope{})
+	Publish(fileEvent(block1Id))
+	Publish(ControllerStatusEvent{TabId: tab1Id, BlockId: block1Id})
+	if len(*exact) != 1 {
+		t.Errorf("expected 1 blockfile event, got %d", len(*exact))
+	}
+	if len(*all) != 2 {
+		t.Errorf("expected 2 events for **, got %d", len(*all))
+	}
+	if len(*termEvents) != 0 {
+		t.Errorf("expected no events for term:*, got %d", len(*termEvents))
+	}
+}
+
+func TestScopeMatch(t *testing.T) {
+	setupParents(t)
+	block1 := collect(t, "**", Scope{BlockId: block1Id})
+	tab1 := collect(t, "**", Scope{TabId: tab1Id})
+	window2 := collect(t, "**", Scope{WindowId: window2Id})
+	Publish(fileEvent(block1Id))
+	Publish(fileEvent(block2Id))
+	Publish(fileEvent(block3Id)) // not found, only matches subscribers without a scope
+	Publish(ObjectUpdateEvent{Update: waveobj.WaveObjUpdate{UpdateType: waveobj.UpdateType_Update, OType: waveobj.OType_Tab, OID: tab2Id}})
+	if len(*block1) != 1 {
+		t.Errorf("expected 1 event for block 1, got %d", len(*block1))
+	}
+	if len(*tab1) != 1 {
+		t.Errorf("expected 1 event for tab 1, got %d", len(*tab1))
+	}
+	if len(*window2) != 2 {
+		t.Errorf("expected 2 events for window 2, got %d", len(*window2))
+	}
+}
+
+func TestNotificationScopes(t *testing.T) {
+	setupParents(t)
+	scopes := NotificationEvent{BlockId: block2Id}.Scopes()
+	expected := []string{"block:" + block2Id, "tab:" + tab2Id, "window:" + window2Id}
+	if fmt.Sprint(scopes) != fmt.Sprint(expected) {
+		t.Errorf("expected scopes %v, got %v", expected, scopes)
+	}
+}
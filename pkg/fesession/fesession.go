@@ -0,0 +1,220 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// frontend sessions: what a (remote) frontend connection is allowed to do
+package fesession
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// a session binds a frontend to a window and a set of permissions.  sessions are created by the remote-access
+// server (a password login gets every permission, a share link can be read-only), they are attached to the
+// context of the frontend's http requests and bound to the route of its websocket connection.  the service
+// layer checks the permission of each service call, and the websocket checks the permission of each rpc the
+// frontend sends (pkg/remoteaccess checks the objects and files they use).  frontends without a session (the
+// electron windows) are not restricted.  a revoked session loses all of its permissions (so its open websocket
+// cannot do anything either).
+
+const (
+	Perm_View  = "view"  // read objects and files, subscribe to events
+	Perm_Input = "input" // type into blocks (and resize them)
+	Perm_Edit  = "edit"  // create, change, and delete blocks, tabs, and files
+	Perm_Admin = "admin" // windows, workspaces, settings, remote access, webhooks
+)
+
+var AllPerms = []string{Perm_View, Perm_Input, Perm_Edit, Perm_Admin}
+
+type Session struct {
+	SessionId string
+	WindowId  string // if set, the session can only use this window
+	Perms     []string
+	revoked   atomic.Bool
+}
+
+func (s *Session) Revoke() {
+	s.revoked.Store(true)
+}
+
+func (s *Session) IsRevoked() bool {
+	return s.revoked.Load()
+}
+
+func (s *Session) HasPerm(perm string) bool {
+	return !s.IsRevoked() && slices.Contains(s.Perms, perm)
+}
+
+func (s *Session) CheckPerm(perm string, opName string) error {
+	if s.IsRevoked() {
+		return fmt.Errorf("%s is not allowed (this session was revoked)", opName)
+	}
+	if s.HasPerm(perm) {
+		return nil
+	}
+	return fmt.Errorf("%s is not allowed (this session does not have the %q permission)", opName, perm)
+}
+
+func (s *Session) CheckWindow(windowId string) error {
+	if s.WindowId == "" || windowId == s.WindowId {
+		return nil
+	}
+	return fmt.Errorf("this session cannot use window %s", windowId)
+}
+
+// the rpcs that only read, everything not listed here (or in the input and admin lists) needs Perm_Edit
+var viewRpcs = map[string]bool{
+	wshrpc.Command_RouteAnnounce:         true,
+	wshrpc.Command_RouteUnannounce:       true,
+	wshrpc.Command_Message:               true,
+	wshrpc.Command_GetMeta:               true,
+	wshrpc.Command_ResolveIds:            true,
+	wshrpc.Command_BlockInfo:             true,
+	wshrpc.Command_FileRead:              true,
+	wshrpc.Command_FileReadStream:        true,
+	wshrpc.Command_EventSub:              true,
+	wshrpc.Command_EventUnsub:            true,
+	wshrpc.Command_EventUnsubAll:         true,
+	wshrpc.Command_EventReadHistory:      true,
+	wshrpc.Command_EventReplay:           true,
+	wshrpc.Command_GetFullConfig:         true,
+	wshrpc.Command_RemoteFileInfo:        true,
+	wshrpc.Command_WaveInfo:              true,
+	wshrpc.Command_WshActivity:           true,
+	wshrpc.Command_Activity:              true,
+	wshrpc.Command_GetVar:                true,
+	wshrpc.Command_ConnStatus:            true,
+	wshrpc.Command_ConnMetrics:           true,
+	wshrpc.Command_WslStatus:             true,
+	wshrpc.Command_ConnList:              true,
+	wshrpc.Command_GetUpdateChannel:      true,
+	wshrpc.Command_StreamCpuData:         true,
+	wshrpc.Command_ListActions:           true,
+	wshrpc.Command_PaletteSearch:         true,
+	wshrpc.Command_TermGetSegments:       true,
+	wshrpc.Command_TermGetSegmentOutput:  true,
+	wshrpc.Command_TermGetLinks:          true,
+	wshrpc.Command_TermListRecordings:    true,
+	wshrpc.Command_TermPaneList:          true,
+	wshrpc.Command_TermQueueList:         true,
+	wshrpc.Command_CmdHistorySearch:      true,
+	wshrpc.Command_NotificationList:      true,
+	wshrpc.Command_ControllerOutputAck:   true,
+	wshrpc.Command_ControllerProcessTree: true,
+	wshrpc.Command_ConnDashboard:         true,
+	wshrpc.Command_ClusterExecGet:        true,
+	wshrpc.Command_SettingsGet:           true,
+	wshrpc.Command_SettingsResolve:       true,
+	wshrpc.Command_SettingsExplain:       true,
+	wshrpc.Command_KeyBindingsList:       true,
+	wshrpc.Command_KeyBindingResolve:     true,
+	wshrpc.Command_ThemeList:             true,
+	wshrpc.Command_ThemeGet:              true,
+	wshrpc.Command_ProfileList:           true,
+	wshrpc.Command_FontList:              true,
+	wshrpc.Command_TermFontGet:           true,
+	wshrpc.Command_I18nList:              true,
+	wshrpc.Command_I18nCatalog:           true,
+}
+
+var inputRpcs = map[string]bool{
+	wshrpc.Command_ControllerInput:  true,
+	wshrpc.Command_ControllerResize: true,
+	wshrpc.Command_ControllerPaste:  true,
+	wshrpc.Command_ControllerSignal: true,
+}
+
+var adminRpcs = map[string]bool{
+	wshrpc.Command_SetConfig:          true,
+	wshrpc.Command_FocusWindow:        true,
+	wshrpc.Command_ConnReinstallWsh:   true,
+	wshrpc.Command_ConnUpdateWsh:      true,
+	wshrpc.Command_PluginRegister:     true,
+	wshrpc.Command_PluginTokenIssue:   true,
+	wshrpc.Command_PluginRevoke:       true,
+	wshrpc.Command_WebhookCreate:      true,
+	wshrpc.Command_WebhookList:        true,
+	wshrpc.Command_WebhookDelete:      true,
+	wshrpc.Command_WebhookSetDisabled: true,
+	wshrpc.Command_WebhookTest:        true,
+	wshrpc.Command_WebhookDeliveries:  true,
+	wshrpc.Command_RemoteSetPassword:  true,
+	wshrpc.Command_RemoteSessions:     true,
+	wshrpc.Command_RemoteRevoke:       true,
+	wshrpc.Command_RemoteShareCreate:  true,
+	wshrpc.Command_RemoteShareList:    true,
+	wshrpc.Command_AuthTokenIssue:     true,
+	wshrpc.Command_AuthTokenRevoke:    true,
+	wshrpc.Command_SecretSet:          true,
+	wshrpc.Command_SecretList:         true,
+	wshrpc.Command_SecretDelete:       true,
+	wshrpc.Command_SettingsSet:        true,
+	wshrpc.Command_SettingsMigrate:    true,
+	wshrpc.Command_SettingsImport:     true,
+	wshrpc.Command_ThemeSave:          true,
+	wshrpc.Command_ThemeImport:        true,
+	wshrpc.Command_ThemeDelete:        true,
+	wshrpc.Command_ThemeAssign:        true,
+	wshrpc.Command_ProfileSave:        true,
+	wshrpc.Command_ProfileDelete:      true,
+	wshrpc.Command_ProfileSwitch:      true,
+	wshrpc.Command_WorkspaceList:      true, // every workspace, not just the exposed ones
+	wshrpc.Command_ListDetachedBlocks: true, // detached blocks are not in a workspace
+}
+
+// the permission an rpc needs
+func GetRpcPerm(command string) string {
+	if viewRpcs[command] {
+		return Perm_View
+	}
+	if inputRpcs[command] {
+		return Perm_Input
+	}
+	if adminRpcs[command] {
+		return Perm_Admin
+	}
+	return Perm_Edit
+}
+
+func (s *Session) CheckRpc(command string) error {
+	return s.CheckPerm(GetRpcPerm(command), fmt.Sprintf("rpc %q", command))
+}
+
+type sessionCtxKey struct{}
+
+func ContextWithSession(ctx context.Context, sess *Session) context.Context {
+	return context.WithValue(ctx, sessionCtxKey{}, sess)
+}
+
+// returns nil if the request is not from a frontend with a session
+func GetSession(ctx context.Context) *Session {
+	sess, _ := ctx.Value(sessionCtxKey{}).(*Session)
+	return sess
+}
+
+var routeLock = &sync.Mutex{}
+var routeSessions = make(map[string]*Session) // routeid => session
+
+func BindRoute(routeId string, sess *Session) {
+	routeLock.Lock()
+	defer routeLock.Unlock()
+	routeSessions[routeId] = sess
+}
+
+func UnbindRoute(routeId string) {
+	routeLock.Lock()
+	defer routeLock.Unlock()
+	delete(routeSessions, routeId)
+}
+
+// returns nil if the route is not bound to a session
+func GetRouteSession(routeId string) *Session {
+	routeLock.Lock()
+	defer routeLock.Unlock()
+	return routeSessions[routeId]
+}
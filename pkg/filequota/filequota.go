@@ -0,0 +1,242 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filequota keeps the files stored for each block (storage:maxblockbytes) and for all the blocks of a
+// workspace (storage:maxworkspacebytes) under their quotas, so a runaway command can not fill the disk.  the usage
+// is checked every CheckInterval, over a quota the parts at the front of the largest files are evicted first (the
+// oldest data of the output that is growing).  the quotas are on the bytes stored in the db (the parts are
+// compressed), circular files (the term output) are limited by their own max size and are not evicted.
+package filequota
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const CheckInterval = 30 * time.Second
+const evictBatchParts = 16
+const dbTimeout = 10 * time.Second
+
+var startOnce = &sync.Once{}
+
+type evictFnType = func(ctx context.Context, zoneId string, name string, numParts int) (int64, error)
+
+func Start() {
+	startOnce.Do(func() {
+		go runCheckLoop()
+	})
+}
+
+func runCheckLoop() {
+	defer func() {
+		panichandler.PanicHandler("filequota:runCheckLoop", recover())
+	}()
+	ticker := time.NewTicker(CheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancelFn := context.WithTimeout(context.Background(), dbTimeout)
+		err := CheckQuotas(ctx)
+		cancelFn()
+		if err != nil {
+			log.Printf("filequota: error checking quotas: %v\n", err)
+		}
+	}
+}
+
+func getQuotas() (int64, int64) {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	return max(0, settings.StorageMaxBlockBytes), max(0, settings.StorageMaxWorkspaceBytes)
+}
+
+func groupByZone(usage []*filestore.FileUsage) map[string][]*filestore.FileUsage {
+	rtn := make(map[string][]*filestore.FileUsage)
+	for _, fileUsage := range usage {
+		rtn[fileUsage.ZoneId] = append(rtn[fileUsage.ZoneId], fileUsage)
+	}
+	return rtn
+}
+
+func storedSize(files []*filestore.FileUsage) int64 {
+	var rtn int64
+	for _, file := range files {
+		rtn += file.StoredSize
+	}
+	return rtn
+}
+
+// the blocks of each workspace
+func getWorkspaceBlockIds(ctx context.Context) (map[string][]string, error) {
+	workspaces, err := wstore.DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting workspaces: %w", err)
+	}
+	rtn := make(map[string][]string)
+	for _, ws := range workspaces {
+		tabIds := append(append([]string{}, ws.PinnedTabIds...), ws.TabIds...)
+		tabs, err := wstore.DBSelectMap[*waveobj.Tab](ctx, tabIds)
+		if err != nil {
+			return nil, fmt.Errorf("error getting tabs for workspace %s: %w", ws.OID, err)
+		}
+		var blockIds []string
+		for _, tabId := range tabIds {
+			if tab := tabs[tabId]; tab != nil {
+				blockIds = append(blockIds, tab.BlockIds...)
+			}
+		}
+		rtn[ws.OID] = blockIds
+	}
+	return rtn, nil
+}
+
+// evicts parts from the largest files until excess bytes are freed (or nothing can be evicted), the StoredSize of
+// the files is updated.  returns the bytes freed.
+func evictFiles(ctx context.Context, files []*filestore.FileUsage, excess int64, evictFn evictFnType) int64 {
+	var candidates []*filestore.FileUsage
+	for _, file := range files {
+		// an upload that is not finished would lose the front of the file
+		if file.Evictable && file.StoredSize > 0 && !strings.HasPrefix(file.Name, wavebase.BlockFile_TransferPrefix) {
+			candidates = append(candidates, file)
+		}
+	}
+	var freed int64
+	for excess > 0 && len(candidates) > 0 {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].StoredSize > candidates[j].StoredSize
+		})
+		file := candidates[0]
+		fileFreed, err := evictFn(ctx, file.ZoneId, file.Name, evictBatchParts)
+		if err != nil {
+			log.Printf("filequota: error evicting parts of %s:%s: %v\n", file.ZoneId, file.Name, err)
+		}
+		if err != nil || fileFreed <= 0 {
+			candidates = candidates[1:]
+			continue
+		}
+		file.StoredSize -= fileFreed
+		excess -= fileFreed
+		freed += fileFreed
+	}
+	return freed
+}
+
+// evicts the parts over the block and workspace quotas
+func CheckQuotas(ctx context.Context) error {
+	maxBlockBytes, maxWorkspaceBytes := getQuotas()
+	if maxBlockBytes == 0 && maxWorkspaceBytes == 0 {
+		return nil
+	}
+	usage, err := filestore.WFS.GetUsage(ctx)
+	if err != nil {
+		return err
+	}
+	byZone := groupByZone(usage)
+	if maxBlockBytes > 0 {
+		for zoneId, files := range byZone {
+			zoneSize := storedSize(files)
+			if zoneSize <= maxBlockBytes {
+				continue
+			}
+			freed := evictFiles(ctx, files, zoneSize-maxBlockBytes, filestore.WFS.EvictParts)
+			log.Printf("filequota: %s is over storage:maxblockbytes (%d > %d), evicted %d bytes\n", zoneId, zoneSize, maxBlockBytes, freed)
+		}
+	}
+	if maxWorkspaceBytes > 0 {
+		wsBlockIds, err := getWorkspaceBlockIds(ctx)
+		if err != nil {
+			return err
+		}
+		for wsId, blockIds := range wsBlockIds {
+			var files []*filestore.FileUsage
+			for _, blockId := range blockIds {
+				files = append(files, byZone[blockId]...)
+			}
+			wsSize := storedSize(files)
+			if wsSize <= maxWorkspaceBytes {
+				continue
+			}
+			freed := evictFiles(ctx, files, wsSize-maxWorkspaceBytes, filestore.WFS.EvictParts)
+			log.Printf("filequota: workspace %s is over storage:maxworkspacebytes (%d > %d), evicted %d bytes\n", wsId, wsSize, maxWorkspaceBytes, freed)
+		}
+	}
+	return nil
+}
+
+func makeUsage(oref waveobj.ORef, files []*filestore.FileUsage, maxSize int64) wshrpc.StorageUsage {
+	rtn := wshrpc.StorageUsage{ORef: oref.String(), NumFiles: len(files), MaxSize: maxSize}
+	for _, file := range files {
+		rtn.Size += file.Size
+		rtn.StoredSize += file.StoredSize
+		rtn.Evicted += file.Evicted
+	}
+	return rtn
+}
+
+// the usage of a block, or of a workspace and its blocks, or of all the workspaces (if orefStr is empty)
+func GetUsage(ctx context.Context, orefStr string) ([]wshrpc.StorageUsage, error) {
+	maxBlockBytes, maxWorkspaceBytes := getQuotas()
+	usage, err := filestore.WFS.GetUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byZone := groupByZone(usage)
+	if orefStr == "" {
+		wsBlockIds, err := getWorkspaceBlockIds(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var rtn []wshrpc.StorageUsage
+		for wsId, blockIds := range wsBlockIds {
+			var files []*filestore.FileUsage
+			for _, blockId := range blockIds {
+				files = append(files, byZone[blockId]...)
+			}
+			rtn = append(rtn, makeUsage(waveobj.MakeORef(waveobj.OType_Workspace, wsId), files, maxWorkspaceBytes))
+		}
+		sort.Slice(rtn, func(i, j int) bool {
+			return rtn[i].StoredSize > rtn[j].StoredSize
+		})
+		return rtn, nil
+	}
+	oref, err := waveobj.ParseORef(orefStr)
+	if err != nil {
+		return nil, err
+	}
+	switch oref.OType {
+	case waveobj.OType_Block:
+		return []wshrpc.StorageUsage{makeUsage(oref, byZone[oref.OID], maxBlockBytes)}, nil
+	case waveobj.OType_Workspace:
+		wsBlockIds, err := getWorkspaceBlockIds(ctx)
+		if err != nil {
+			return nil, err
+		}
+		blockIds, ok := wsBlockIds[oref.OID]
+		if !ok {
+			return nil, fmt.Errorf("workspace not found: %s", oref.OID)
+		}
+		var wsFiles []*filestore.FileUsage
+		var blockUsage []wshrpc.StorageUsage
+		for _, blockId := range blockIds {
+			wsFiles = append(wsFiles, byZone[blockId]...)
+			blockUsage = append(blockUsage, makeUsage(waveobj.MakeORef(waveobj.OType_Block, blockId), byZone[blockId], maxBlockBytes))
+		}
+		sort.Slice(blockUsage, func(i, j int) bool {
+			return blockUsage[i].StoredSize > blockUsage[j].StoredSize
+		})
+		return append([]wshrpc.StorageUsage{makeUsage(oref, wsFiles, maxWorkspaceBytes)}, blockUsage...), nil
+	default:
+		return nil, fmt.Errorf("storage usage is for blocks and workspaces, not %s", oref.OType)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filequota
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+)
+
+func TestEvictFiles(t *testing.T) {
+	files := []*filestore.FileUsage{
+		{ZoneId: "z1", Name: "small", StoredSize: 100, Evictable: true},
+		{ZoneId: "z1", Name: "term", StoredSize: 5000, Evictable: false},
+		{ZoneId: "z1", Name: "big", StoredSize: 1000, Evictable: true},
+		{ZoneId: "z1", Name: "stuck", StoredSize: 2000, Evictable: true},
+	}
+	var evicted []string
+	evictFn := func(ctx context.Context, zoneId string, name string, numParts int) (int64, error) {
+		evicted = append(evicted, name)
+		switch name {
+		case "stuck":
+			return 0, nil
+		case "big":
+			return 300, nil
+		case "small":
+			return 0, fmt.Errorf("test error")
+		}
+		return 0, nil
+	}
+	freed := evictFiles(context.Background(), files, 700, evictFn)
+	if freed != 900 {
+		t.Errorf("expected 900 bytes to be freed, got %d", freed)
+	}
+	// the largest evictable file first, a file that frees nothing is skipped
+	expected := []string{"stuck", "big", "big", "big"}
+	if fmt.Sprint(evicted) != fmt.Sprint(expected) {
+		t.Errorf("expected evictions %v, got %v", expected, evicted)
+	}
+	if files[2].StoredSize != 100 {
+		t.Errorf("expected the stored size to be updated, got %d", files[2].StoredSize)
+	}
+
+	evicted = nil
+	freed = evictFiles(context.Background(), files, 0, evictFn)
+	if freed != 0 || len(evicted) != 0 {
+		t.Errorf("expected nothing to be evicted under the quota, got %v", evicted)
+	}
+}
@@ -0,0 +1,491 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filereplica replicates the block files to an s3 bucket (storage:replicaurl, any s3-compatible store with
+// storage:replicaendpoint).  the replica is write-behind, a sync every SyncInterval puts the new blobs and the
+// manifests of the changed files in the bucket (see pkg/filestore/blockstore_replica.go for what is stored).  the
+// objects are encrypted with a key kept in the OS keychain, the same key (wsh storage replica key) is needed to
+// restore the files on another machine.  with storage:replicaoffloaddays, the data of files that have not been
+// modified for that long is only kept in the bucket (it is fetched when it is read).
+package filereplica
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/keychain"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote/awsconn"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const SyncInterval = 5 * time.Minute
+const KeyName = "filestore-replica"
+const startupDelay = time.Minute
+const syncTimeout = 30 * time.Minute
+const syncBatchSize = 100
+
+var startOnce = &sync.Once{}
+
+// one sync (or restore) at a time
+var syncLock = &sync.Mutex{}
+
+var targetLock = &sync.Mutex{}
+var curTarget *replicaTarget
+
+type replicaTarget struct {
+	settings string // the settings it was made from
+	id       string // the bucket, prefix and key (the blobs are replicated again if it changes)
+	client   *s3.Client
+	bucket   string
+	prefix   string
+}
+
+type replicaSettings struct {
+	url         string
+	profile     string
+	region      string
+	endpoint    string
+	offloadDays float64
+}
+
+func getSettings() replicaSettings {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	return replicaSettings{
+		url:         settings.StorageReplicaUrl,
+		profile:     settings.StorageReplicaProfile,
+		region:      settings.StorageReplicaRegion,
+		endpoint:    settings.StorageReplicaEndpoint,
+		offloadDays: settings.StorageReplicaOffloadDays,
+	}
+}
+
+// "s3://bucket/prefix" to the bucket and the prefix (with a trailing slash)
+func parseUrl(url string) (string, string, error) {
+	path, ok := strings.CutPrefix(url, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("invalid storage:replicaurl %q (expected s3://bucket/prefix)", url)
+	}
+	bucket, prefix, _ := strings.Cut(path, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid storage:replicaurl %q (no bucket)", url)
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return bucket, prefix, nil
+}
+
+func makeClient(ctx context.Context, settings replicaSettings) (*s3.Client, error) {
+	var optFns []func(*config.LoadOptions) error
+	if settings.profile != "" {
+		optFns = append(optFns, config.WithSharedConfigProfile(strings.TrimPrefix(settings.profile, awsconn.ProfilePrefix)))
+	}
+	if settings.region != "" {
+		optFns = append(optFns, config.WithRegion(settings.region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("error loading aws config: %w", err)
+	}
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if settings.endpoint != "" {
+			o.BaseEndpoint = aws.String(settings.endpoint)
+			o.UsePathStyle = true
+		}
+	}), nil
+}
+
+// makes the target for the settings (if they changed) and sets the replica in the filestore, returns nil if
+// replication is not configured
+func configure(ctx context.Context) (*replicaTarget, error) {
+	settings := getSettings()
+	settingsStr := fmt.Sprintf("%s %s %s %s", settings.url, settings.profile, settings.region, settings.endpoint)
+	targetLock.Lock()
+	defer targetLock.Unlock()
+	if curTarget != nil && curTarget.settings == settingsStr {
+		return curTarget, nil
+	}
+	if settings.url == "" {
+		curTarget = nil
+		filestore.SetReplica(nil, nil)
+		return nil, nil
+	}
+	bucket, prefix, err := parseUrl(settings.url)
+	if err != nil {
+		return nil, err
+	}
+	client, err := makeClient(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keychain.GetKey(KeyName, true)
+	if err != nil {
+		return nil, err
+	}
+	keySum := sha256.Sum256(key)
+	target := &replicaTarget{
+		settings: settingsStr,
+		id:       fmt.Sprintf("%s %s/%s %s", settings.endpoint, bucket, prefix, hex.EncodeToString(keySum[:8])),
+		client:   client,
+		bucket:   bucket,
+		prefix:   prefix,
+	}
+	err = filestore.SetReplica(key, target.get)
+	if err != nil {
+		return nil, err
+	}
+	curTarget = target
+	return target, nil
+}
+
+func getTarget(ctx context.Context) (*replicaTarget, error) {
+	target, err := configure(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w (set storage:replicaurl)", filestore.ErrNoReplica)
+	}
+	return target, nil
+}
+
+func (t *replicaTarget) get(ctx context.Context, key string) ([]byte, error) {
+	output, err := t.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(t.prefix + key),
+	})
+	if err != nil {
+		awsconn.CheckAccessDeniedErr(&err)
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+func (t *replicaTarget) put(ctx context.Context, key string, data []byte) error {
+	_, err := t.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(t.bucket),
+		Key:           aws.String(t.prefix + key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	if err != nil {
+		awsconn.CheckAccessDeniedErr(&err)
+		return fmt.Errorf("error putting %s: %w", key, err)
+	}
+	return nil
+}
+
+func (t *replicaTarget) delete(ctx context.Context, key string) error {
+	_, err := t.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(t.prefix + key),
+	})
+	if err != nil {
+		awsconn.CheckAccessDeniedErr(&err)
+		return fmt.Errorf("error deleting %s: %w", key, err)
+	}
+	return nil
+}
+
+// the manifests in the replica (of one zone, or all of them)
+func (t *replicaTarget) listFiles(ctx context.Context, zoneId string) ([]*filestore.ReplicaFile, error) {
+	listPrefix := filestore.ReplicaFilePrefix
+	if zoneId != "" {
+		listPrefix += zoneId + "/"
+	}
+	var rtn []*filestore.ReplicaFile
+	paginator := s3.NewListObjectsV2Paginator(t.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(t.bucket),
+		Prefix: aws.String(t.prefix + listPrefix),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			awsconn.CheckAccessDeniedErr(&err)
+			return nil, fmt.Errorf("error listing the replica: %w", err)
+		}
+		for _, obj := range output.Contents {
+			key := strings.TrimPrefix(aws.ToString(obj.Key), t.prefix)
+			data, err := t.get(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("error getting %s: %w", key, err)
+			}
+			manifest, err := filestore.DecodeReplicaFile(key, data)
+			if err != nil {
+				return nil, err
+			}
+			rtn = append(rtn, manifest)
+		}
+	}
+	return rtn, nil
+}
+
+func Start() {
+	startOnce.Do(func() {
+		// so the offloaded blobs can be read right away
+		_, err := configure(context.Background())
+		if err != nil {
+			log.Printf("filereplica: error configuring replica: %v\n", err)
+		}
+		go runLoop()
+	})
+}
+
+func runLoop() {
+	defer func() {
+		panichandler.PanicHandler("filereplica:runLoop", recover())
+	}()
+	time.Sleep(startupDelay)
+	for {
+		if getSettings().url != "" {
+			ctx, cancelFn := context.WithTimeout(context.Background(), syncTimeout)
+			_, err := Sync(ctx)
+			cancelFn()
+			if err != nil {
+				log.Printf("filereplica: error syncing replica: %v\n", err)
+			}
+		}
+		time.Sleep(SyncInterval)
+	}
+}
+
+// puts the new blobs and the changed files in the replica, and offloads (or brings back) the blobs of the files
+// that were not modified for storage:replicaoffloaddays
+func Sync(ctx context.Context) (*wshrpc.ReplicaSyncResult, error) {
+	syncLock.Lock()
+	defer syncLock.Unlock()
+	target, err := getTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = filestore.WFS.SetReplicaTarget(ctx, target.id)
+	if err != nil {
+		return nil, err
+	}
+	result := &wshrpc.ReplicaSyncResult{}
+	err = syncBlobs(ctx, target, result)
+	if err != nil {
+		return nil, err
+	}
+	err = syncFiles(ctx, target, result)
+	if err != nil {
+		return nil, err
+	}
+	offloadDays := getSettings().offloadDays
+	if offloadDays > 0 {
+		beforeTs := time.Now().Add(-time.Duration(offloadDays * float64(24*time.Hour))).UnixMilli()
+		result.Offloaded, result.OffloadedBytes, err = filestore.WFS.OffloadBlobs(ctx, beforeTs)
+		if err != nil {
+			return nil, fmt.Errorf("error offloading blobs: %w", err)
+		}
+	} else {
+		for {
+			numBlobs, err := filestore.WFS.RehydrateBlobs(ctx, syncBatchSize)
+			result.Rehydrated += numBlobs
+			if err != nil {
+				return nil, fmt.Errorf("error bringing back offloaded blobs: %w", err)
+			}
+			if numBlobs < syncBatchSize {
+				break
+			}
+		}
+	}
+	if result.Blobs > 0 || result.Files > 0 || result.Deleted > 0 || result.Offloaded > 0 || result.Rehydrated > 0 {
+		log.Printf("filereplica: synced %d blobs (%d bytes), %d files, %d deleted, %d offloaded, %d brought back\n",
+			result.Blobs, result.Bytes, result.Files, result.Deleted, result.Offloaded, result.Rehydrated)
+	}
+	return result, nil
+}
+
+func syncBlobs(ctx context.Context, target *replicaTarget, result *wshrpc.ReplicaSyncResult) error {
+	afterHash := ""
+	for {
+		objs, lastHash, err := filestore.WFS.GetUnreplicatedBlobs(ctx, afterHash, syncBatchSize)
+		if err != nil {
+			return fmt.Errorf("error getting blobs: %w", err)
+		}
+		if lastHash == "" {
+			return nil
+		}
+		afterHash = lastHash
+		var done []*filestore.ReplicaObject
+		var putErr error
+		for _, obj := range objs {
+			putErr = target.put(ctx, obj.Key, obj.Data)
+			if putErr != nil {
+				break
+			}
+			done = append(done, obj)
+			result.Blobs++
+			result.Bytes += int64(len(obj.Data))
+		}
+		err = filestore.WFS.SetBlobsReplicated(ctx, done)
+		if err != nil {
+			return fmt.Errorf("error setting blobs replicated: %w", err)
+		}
+		if putErr != nil {
+			return putErr
+		}
+	}
+}
+
+func syncFiles(ctx context.Context, target *replicaTarget, result *wshrpc.ReplicaSyncResult) error {
+	changed, deleted, err := filestore.WFS.GetReplicaFileChanges(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting changed files: %w", err)
+	}
+	var done []*filestore.ReplicaObject
+	for _, obj := range changed {
+		err = target.put(ctx, obj.Key, obj.Data)
+		if err != nil {
+			break
+		}
+		done = append(done, obj)
+	}
+	result.Files = len(done)
+	setErr := filestore.WFS.SetFilesReplicated(ctx, done)
+	if err != nil || setErr != nil {
+		return errors.Join(err, setErr)
+	}
+	done = nil
+	for _, obj := range deleted {
+		err = target.delete(ctx, obj.Key)
+		if err != nil {
+			break
+		}
+		done = append(done, obj)
+	}
+	result.Deleted = len(done)
+	setErr = filestore.WFS.RemoveReplicaFiles(ctx, done)
+	return errors.Join(err, setErr)
+}
+
+func makeFileInfo(manifest *filestore.ReplicaFile) wshrpc.ReplicaFileInfo {
+	return wshrpc.ReplicaFileInfo{
+		ZoneId: manifest.File.ZoneId,
+		Name:   manifest.File.Name,
+		Size:   manifest.File.Size,
+		ModTs:  manifest.File.ModTs,
+	}
+}
+
+// the files in the replica (of the zone, or all of them)
+func List(ctx context.Context, zoneId string) ([]wshrpc.ReplicaFileInfo, error) {
+	target, err := getTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := target.listFiles(ctx, zoneId)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []wshrpc.ReplicaFileInfo
+	for _, manifest := range manifests {
+		rtn = append(rtn, makeFileInfo(manifest))
+	}
+	return rtn, nil
+}
+
+// restores the files of a zone from the replica (into the zone, or another zone), the files that are not restored
+// (they exist, or there was an error) have the error set
+func Restore(ctx context.Context, data wshrpc.CommandStorageReplicaRestoreData) ([]wshrpc.ReplicaFileInfo, error) {
+	if data.ZoneId == "" {
+		return nil, fmt.Errorf("no zone to restore from")
+	}
+	targetZoneId := data.TargetZoneId
+	if targetZoneId == "" {
+		targetZoneId = data.ZoneId
+	}
+	target, err := getTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := target.listFiles(ctx, data.ZoneId)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, name := range data.Names {
+		names[name] = true
+	}
+	var rtn []wshrpc.ReplicaFileInfo
+	for _, manifest := range manifests {
+		if len(names) > 0 && !names[manifest.File.Name] {
+			continue
+		}
+		delete(names, manifest.File.Name)
+		info := makeFileInfo(manifest)
+		info.ZoneId = targetZoneId
+		err = filestore.WFS.RestoreFile(ctx, manifest, targetZoneId, data.Overwrite)
+		if err != nil {
+			info.Error = err.Error()
+		} else {
+			eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+				ZoneId:   targetZoneId,
+				FileName: manifest.File.Name,
+				FileOp:   wps.FileOp_Invalidate,
+			}})
+		}
+		rtn = append(rtn, info)
+	}
+	for name := range names {
+		rtn = append(rtn, wshrpc.ReplicaFileInfo{ZoneId: targetZoneId, Name: name, Error: "not in the replica"})
+	}
+	return rtn, nil
+}
+
+// returns the replica key (base64), or sets it (to restore a replica made on another machine)
+func Key(ctx context.Context, keyStr string) (string, error) {
+	if keyStr == "" {
+		key, err := keychain.GetKey(KeyName, true)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(key), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(keyStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid key: %w", err)
+	}
+	syncLock.Lock()
+	defer syncLock.Unlock()
+	hasOffloaded, err := filestore.WFS.HasOffloadedBlobs(ctx)
+	if err != nil {
+		return "", err
+	}
+	if hasOffloaded {
+		return "", fmt.Errorf("cannot change the key, blobs are offloaded (set storage:replicaoffloaddays to 0 and sync first)")
+	}
+	err = keychain.SetKey(KeyName, key)
+	if err != nil {
+		return "", err
+	}
+	targetLock.Lock()
+	curTarget = nil
+	targetLock.Unlock()
+	_, err = configure(ctx)
+	if err != nil {
+		return "", err
+	}
+	return keyStr, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fileretention deletes the files of blocks that are past their retention (storage:retention), e.g. the
+// term output of blocks that have not had output in 30 days, or all but the last 5 recordings of the blocks tagged
+// "task".  a policy is for the files with a name (or a prefix), and for all the blocks or the blocks with a tag
+// (the "tags" meta), a policy with a tag replaces the policy without a tag for the same files.  the policies are
+// run every CheckInterval (and once after startup), Run with dryRun reports what would be deleted.  the term
+// output is cleared (the block is still using the file), the other files are deleted.
+package fileretention
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const CheckInterval = time.Hour
+const startupDelay = 2 * time.Minute
+const runTimeout = 5 * time.Minute
+
+const (
+	Action_Delete = "delete"
+	Action_Clear  = "clear"
+)
+
+var startOnce = &sync.Once{}
+
+// a file to delete (or clear), and the policy it is past
+type fileAction struct {
+	File   *filestore.WaveFile
+	Reason string
+}
+
+func Start() {
+	startOnce.Do(func() {
+		go runLoop()
+	})
+}
+
+func runLoop() {
+	defer func() {
+		panichandler.PanicHandler("fileretention:runLoop", recover())
+	}()
+	time.Sleep(startupDelay)
+	for {
+		ctx, cancelFn := context.WithTimeout(context.Background(), runTimeout)
+		actions, err := Run(ctx, false)
+		cancelFn()
+		if err != nil {
+			log.Printf("fileretention: error running policies: %v\n", err)
+		} else if len(actions) > 0 {
+			log.Printf("fileretention: deleted or cleared %d block files\n", len(actions))
+		}
+		time.Sleep(CheckInterval)
+	}
+}
+
+func matchFiles(pattern string, name string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return pattern == name
+}
+
+// the policies for a block with the tags
+func getBlockPolicies(policies []wconfig.RetentionPolicy, tags []string) []wconfig.RetentionPolicy {
+	var rtn []wconfig.RetentionPolicy
+	tagFiles := make(map[string]bool)
+	for _, policy := range policies {
+		if policy.Tag != "" && slices.Contains(tags, policy.Tag) {
+			rtn = append(rtn, policy)
+			tagFiles[policy.Files] = true
+		}
+	}
+	for _, policy := range policies {
+		if policy.Tag == "" && !tagFiles[policy.Files] {
+			rtn = append(rtn, policy)
+		}
+	}
+	return rtn
+}
+
+// the files that are past the policies (each file once, with the first policy it is past)
+func selectFiles(files []*filestore.WaveFile, policies []wconfig.RetentionPolicy, now time.Time) []fileAction {
+	var rtn []fileAction
+	selected := make(map[string]bool)
+	add := func(file *filestore.WaveFile, reason string) {
+		if !selected[file.Name] {
+			selected[file.Name] = true
+			rtn = append(rtn, fileAction{File: file, Reason: reason})
+		}
+	}
+	for _, policy := range policies {
+		if policy.Files == "" {
+			continue
+		}
+		var matched []*filestore.WaveFile
+		for _, file := range files {
+			if matchFiles(policy.Files, file.Name) {
+				matched = append(matched, file)
+			}
+		}
+		// the newest first
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].ModTs > matched[j].ModTs
+		})
+		for idx, file := range matched {
+			if policy.KeepLast > 0 && idx >= policy.KeepLast {
+				add(file, fmt.Sprintf("%s: keep the last %d", policy.Files, policy.KeepLast))
+				continue
+			}
+			if policy.MaxAgeDays > 0 {
+				cutoff := now.Add(-time.Duration(policy.MaxAgeDays * float64(24*time.Hour)))
+				if file.ModTs < cutoff.UnixMilli() {
+					add(file, fmt.Sprintf("%s: older than %g days", policy.Files, policy.MaxAgeDays))
+				}
+			}
+		}
+	}
+	return rtn
+}
+
+func runAction(ctx context.Context, blockId string, action fileAction) error {
+	if action.File.Name == wavebase.BlockFile_Term {
+		return blockcontroller.HandleTruncateBlockFile(blockId)
+	}
+	err := filestore.WFS.DeleteFile(ctx, blockId, action.File.Name)
+	if err != nil && err != fs.ErrNotExist {
+		return err
+	}
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   blockId,
+		FileName: action.File.Name,
+		FileOp:   wps.FileOp_Delete,
+	}})
+	return nil
+}
+
+// runs the policies on the files of all the blocks, returns the files that were deleted or cleared (or would be,
+// with dryRun)
+func Run(ctx context.Context, dryRun bool) ([]wshrpc.RetentionAction, error) {
+	policies := wconfig.GetWatcher().GetFullConfig().Settings.StorageRetention
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+	if err != nil {
+		return nil, fmt.Errorf("error getting blocks: %w", err)
+	}
+	now := time.Now()
+	var rtn []wshrpc.RetentionAction
+	for _, block := range blocks {
+		blockPolicies := getBlockPolicies(policies, block.Meta.GetStringList(waveobj.MetaKey_Tags))
+		if len(blockPolicies) == 0 {
+			continue
+		}
+		files, err := filestore.WFS.ListFiles(ctx, block.OID)
+		if err != nil {
+			return nil, fmt.Errorf("error listing files of block %s: %w", block.OID, err)
+		}
+		for _, action := range selectFiles(files, blockPolicies, now) {
+			rtnAction := wshrpc.RetentionAction{
+				BlockId: block.OID,
+				Name:    action.File.Name,
+				Action:  Action_Delete,
+				Reason:  action.Reason,
+				Size:    action.File.DataLength(),
+			}
+			if action.File.Name == wavebase.BlockFile_Term {
+				if action.File.DataLength() == 0 {
+					continue
+				}
+				rtnAction.Action = Action_Clear
+			}
+			if !dryRun {
+				err = runAction(ctx, block.OID, action)
+				if err != nil {
+					log.Printf("fileretention: error deleting %s:%s: %v\n", block.OID, action.File.Name, err)
+					continue
+				}
+			}
+			rtn = append(rtn, rtnAction)
+		}
+	}
+	return rtn, nil
+}
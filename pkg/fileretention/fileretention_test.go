@@ -0,0 +1,79 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package fileretention
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+)
+
+func actionNames(actions []fileAction) []string {
+	var rtn []string
+	for _, action := range actions {
+		rtn = append(rtn, action.File.Name)
+	}
+	return rtn
+}
+
+func TestSelectFiles(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) int64 {
+		return now.Add(-time.Duration(days) * 24 * time.Hour).UnixMilli()
+	}
+	files := []*filestore.WaveFile{
+		{Name: "term", ModTs: daysAgo(40)},
+		{Name: "cast:1", ModTs: daysAgo(4)},
+		{Name: "cast:2", ModTs: daysAgo(3)},
+		{Name: "cast:3", ModTs: daysAgo(2)},
+		{Name: "cast:4", ModTs: daysAgo(1)},
+		{Name: "notes", ModTs: daysAgo(100)},
+	}
+	policies := []wconfig.RetentionPolicy{
+		{Files: "term", MaxAgeDays: 30},
+		{Files: "cast:*", KeepLast: 2},
+		{Files: "cast:*", MaxAgeDays: 1.5},
+	}
+	actions := selectFiles(files, policies, now)
+	// each file once, the keeplast policy is first for cast:1 and cast:2
+	expected := []string{"term", "cast:2", "cast:1", "cast:3"}
+	if fmt.Sprint(actionNames(actions)) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, actionNames(actions))
+	}
+	if actions[1].Reason != "cast:*: keep the last 2" {
+		t.Errorf("unexpected reason %q", actions[1].Reason)
+	}
+	if actions[3].Reason != "cast:*: older than 1.5 days" {
+		t.Errorf("unexpected reason %q", actions[3].Reason)
+	}
+	actions = selectFiles(files, []wconfig.RetentionPolicy{{Files: "term", MaxAgeDays: 60}, {Files: "", MaxAgeDays: 1}}, now)
+	if len(actions) != 0 {
+		t.Errorf("expected no files, got %v", actionNames(actions))
+	}
+}
+
+func TestGetBlockPolicies(t *testing.T) {
+	policies := []wconfig.RetentionPolicy{
+		{Files: "term", MaxAgeDays: 30},
+		{Files: "cast:*", MaxAgeDays: 30},
+		{Files: "cast:*", Tag: "task", KeepLast: 5},
+		{Files: "term", Tag: "scratch", MaxAgeDays: 1},
+	}
+	blockPolicies := getBlockPolicies(policies, nil)
+	if len(blockPolicies) != 2 || blockPolicies[0].Files != "term" || blockPolicies[1].Files != "cast:*" || blockPolicies[1].KeepLast != 0 {
+		t.Errorf("unexpected policies for an untagged block: %v", blockPolicies)
+	}
+	// the tag policy replaces the untagged policy for the same files
+	blockPolicies = getBlockPolicies(policies, []string{"task"})
+	if len(blockPolicies) != 2 || blockPolicies[0].KeepLast != 5 || blockPolicies[1].Files != "term" || blockPolicies[1].MaxAgeDays != 30 {
+		t.Errorf("unexpected policies for a task block: %v", blockPolicies)
+	}
+	blockPolicies = getBlockPolicies(policies, []string{"task", "scratch"})
+	if len(blockPolicies) != 2 || blockPolicies[0].Tag != "task" || blockPolicies[1].Tag != "scratch" {
+		t.Errorf("unexpected policies for a task and scratch block: %v", blockPolicies)
+	}
+}
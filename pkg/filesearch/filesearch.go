@@ -0,0 +1,58 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filesearch finds the blocks whose files match a search: by file name, by the block's view, and by the
+// text of the output (the term files are indexed when storage:searchindex is set, see filestore.Search).  a hit is
+// a block file and the offset of the match in it.
+package filesearch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func Search(ctx context.Context, data wshrpc.CommandFileSearchData) ([]wshrpc.FileSearchHit, error) {
+	if data.Text == "" && data.Name == "" && data.View == "" {
+		return nil, fmt.Errorf("search needs text, a file name or a view")
+	}
+	blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+	if err != nil {
+		return nil, fmt.Errorf("error getting blocks: %w", err)
+	}
+	// only the files of blocks (not empty, that would be all the zones)
+	blockIds := []string{}
+	views := make(map[string]string)
+	for _, block := range blocks {
+		view := block.Meta.GetString(waveobj.MetaKey_View, "")
+		if data.View != "" && view != data.View {
+			continue
+		}
+		blockIds = append(blockIds, block.OID)
+		views[block.OID] = view
+	}
+	if len(blockIds) == 0 {
+		return nil, nil
+	}
+	// with just a view, all the files of the blocks with the view
+	opts := filestore.SearchOpts{Text: data.Text, Name: data.Name, ZoneIds: blockIds, Limit: data.Limit}
+	hits, err := filestore.WFS.Search(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]wshrpc.FileSearchHit, 0, len(hits))
+	for _, hit := range hits {
+		rtn = append(rtn, wshrpc.FileSearchHit{
+			BlockId: hit.ZoneId,
+			View:    views[hit.ZoneId],
+			Name:    hit.Name,
+			Offset:  hit.Offset,
+			Snippet: hit.Snippet,
+		})
+	}
+	return rtn, nil
+}
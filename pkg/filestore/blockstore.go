@@ -9,6 +9,7 @@ package filestore
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -46,6 +47,8 @@ var flushErrorCount = &atomic.Int32{}
 var partDataSize int64 = DefaultPartDataSize // overridden in tests
 var stopFlush = &atomic.Bool{}
 
+var ErrFlushInProgress = errors.New("flush already in progress")
+
 var WFS *FileStore = &FileStore{
 	Lock:  &sync.Mutex{},
 	Cache: make(map[cacheKey]*CacheEntry),
@@ -59,27 +62,26 @@ type WaveFile struct {
 	CreatedTs int64           `json:"createdts"`
 
 	//  these fields are mutable
-	Size  int64           `json:"size"`
-	ModTs int64           `json:"modts"`
-	Meta  wshrpc.FileMeta `json:"meta"` // only top-level keys can be updated (lower levels are immutable)
+	Size       int64           `json:"size"`
+	ModTs      int64           `json:"modts"`
+	Meta       wshrpc.FileMeta `json:"meta"`                 // only top-level keys can be updated (lower levels are immutable)
+	NumLines   int64           `json:"numlines,omitempty"`   // newlines written (including the ones wrapped out of circular files), for files with a line index
+	TrimOffset int64           `json:"trimoffset,omitempty"` // the data before it was evicted (see EvictParts)
 }
 
-// for regular files this is just Size
+// for regular files this is just Size (less the evicted data)
 // for circular files this is min(Size, MaxSize)
 func (f WaveFile) DataLength() int64 {
-	if f.Opts.Circular {
-		return minInt64(f.Size, f.Opts.MaxSize)
-	}
-	return f.Size
+	return f.Size - f.DataStartIdx()
 }
 
-// for regular files this is just 0
+// for regular files this is just 0 (or TrimOffset if parts were evicted)
 // for circular files this is the index of the first byte of data we have
 func (f WaveFile) DataStartIdx() int64 {
 	if f.Opts.Circular && f.Size > f.Opts.MaxSize {
 		return f.Size - f.Opts.MaxSize
 	}
-	return 0
+	return f.TrimOffset
 }
 
 // this works because lower levels are immutable
@@ -147,7 +149,12 @@ func (s *FileStore) MakeFile(ctx context.Context, zoneId string, name string, me
 			Opts:      opts,
 			Meta:      meta,
 		}
-		return dbInsertFile(ctx, file)
+		err := dbInsertFile(ctx, file)
+		if err != nil {
+			return err
+		}
+		notifyWatchers(zoneId, name, false)
+		return nil
 	})
 }
 
@@ -158,6 +165,36 @@ func (s *FileStore) DeleteFile(ctx context.Context, zoneId string, name string)
 			return fmt.Errorf("error deleting file: %v", err)
 		}
 		entry.clear()
+		notifyWatchers(zoneId, name, false)
+		return nil
+	})
+}
+
+// moves the file to newName in the same zone, the file at newName is replaced
+func (s *FileStore) RenameFile(ctx context.Context, zoneId string, name string, newName string) error {
+	if name == newName {
+		return nil
+	}
+	_, err := s.Stat(ctx, zoneId, name)
+	if err != nil {
+		return err
+	}
+	err = s.DeleteFile(ctx, zoneId, newName)
+	if err != nil {
+		return err
+	}
+	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+		err := entry.flushToDB(ctx, false)
+		if err != nil {
+			return err
+		}
+		err = dbRenameFile(ctx, zoneId, name, newName)
+		if err != nil {
+			return err
+		}
+		entry.clear()
+		notifyWatchers(zoneId, name, false)
+		notifyWatchers(zoneId, newName, false)
 		return nil
 	})
 }
@@ -231,6 +268,8 @@ func (s *FileStore) WriteFile(ctx context.Context, zoneId string, name string, d
 		if err != nil {
 			return err
 		}
+		// a reference becomes a regular file
+		delete(entry.File.Meta, ImportMeta_Ref)
 		entry.writeAt(0, data, true)
 		// since WriteFile can *truncate* the file, we need to flush the file to the DB immediately
 		return entry.flushToDB(ctx, true)
@@ -247,6 +286,9 @@ func (s *FileStore) WriteAt(ctx context.Context, zoneId string, name string, off
 			return err
 		}
 		file := entry.File
+		if file.IsReference() {
+			return errWriteReference
+		}
 		if offset > file.Size {
 			return fmt.Errorf("offset is past the end of the file")
 		}
@@ -256,30 +298,24 @@ func (s *FileStore) WriteAt(ctx context.Context, zoneId string, name string, off
 		if err != nil {
 			return err
 		}
-		entry.writeAt(offset, data, false)
-		return nil
-	})
-}
-
-func (s *FileStore) AppendData(ctx context.Context, zoneId string, name string, data []byte) error {
-	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
-		err := entry.loadFileIntoCache(ctx)
-		if err != nil {
-			return err
-		}
-		partMap := entry.File.computePartMap(entry.File.Size, int64(len(data)))
-		incompleteParts := incompletePartsFromMap(partMap)
-		if len(incompleteParts) > 0 {
-			err = entry.loadDataPartsIntoCache(ctx, incompleteParts)
+		if file.Opts.LineIndex && offset < file.Size && offset >= file.DataStartIdx() {
+			// writeAt only counts the lines of appends
+			_, oldData, err := entry.readAt(ctx, offset, int64(len(data)), false)
 			if err != nil {
 				return err
 			}
+			file.NumLines += countLines(data) - countLines(oldData)
 		}
-		entry.writeAt(entry.File.Size, data, false)
+		entry.writeAt(offset, data, false)
 		return nil
 	})
 }
 
+func (s *FileStore) AppendData(ctx context.Context, zoneId string, name string, data []byte) error {
+	_, err := s.Append(ctx, zoneId, name, data)
+	return err
+}
+
 func metaIncrement(file *WaveFile, key string, amount int) int {
 	if file.Meta == nil {
 		file.Meta = make(wshrpc.FileMeta)
@@ -396,7 +432,7 @@ type FlushStats struct {
 func (s *FileStore) FlushCache(ctx context.Context) (stats FlushStats, rtnErr error) {
 	wasFlushing := s.setUnlessFlushing()
 	if wasFlushing {
-		return stats, fmt.Errorf("flush already in progress")
+		return stats, ErrFlushInProgress
 	}
 	defer s.setIsFlushing(false)
 	startTime := time.Now()
@@ -526,6 +562,23 @@ func (s *FileStore) runFlusher() {
 	}
 }
 
+// stops the background flusher and flushes the cache, waits for a flush in progress (instead of failing).  it can
+// be called again to flush the writes made after it.
+func (s *FileStore) FlushAndStop(ctx context.Context) (FlushStats, error) {
+	stopFlush.Store(true)
+	for {
+		stats, err := s.FlushCache(ctx)
+		if err != ErrFlushInProgress {
+			return stats, err
+		}
+		select {
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
 func minInt64(a, b int64) int64 {
 	if a < b {
 		return a
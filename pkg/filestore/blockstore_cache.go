@@ -159,19 +159,25 @@ func (dce *DataCacheEntry) writeToPart(offset int64, data []byte) (int64, *DataC
 func (entry *CacheEntry) writeAt(offset int64, data []byte, replace bool) {
 	if replace {
 		entry.File.Size = 0
+		entry.File.NumLines = 0
+		entry.File.TrimOffset = 0
+	}
+	if entry.File.Opts.LineIndex && offset >= entry.File.Size {
+		// counted before a circular file drops the front of the data
+		entry.File.NumLines += countLines(data)
+	}
+	startOffset := entry.File.DataStartIdx()
+	if offset+int64(len(data)) <= startOffset {
+		// write is before the start of the circular file (or the evicted parts)
+		return
+	}
+	if offset < startOffset {
+		// truncate data (from the front), update offset
+		truncateAmt := startOffset - offset
+		data = data[truncateAmt:]
+		offset += truncateAmt
 	}
 	if entry.File.Opts.Circular {
-		startCirFileOffset := entry.File.Size - entry.File.Opts.MaxSize
-		if offset+int64(len(data)) <= startCirFileOffset {
-			// write is before the start of the circular file
-			return
-		}
-		if offset < startCirFileOffset {
-			// truncate data (from the front), update offset
-			truncateAmt := startCirFileOffset - offset
-			data = data[truncateAmt:]
-			offset += truncateAmt
-		}
 		if int64(len(data)) > entry.File.Opts.MaxSize {
 			// truncate data (from the front), update offset
 			truncateAmt := int64(len(data)) - entry.File.Opts.MaxSize
@@ -200,6 +206,7 @@ func (entry *CacheEntry) writeAt(offset int64, data []byte, replace bool) {
 		entry.File.Size = endWriteOffset
 	}
 	entry.File.ModTs = time.Now().UnixMilli()
+	notifyWatchers(entry.ZoneId, entry.Name, replace)
 }
 
 // returns (realOffset, data, error)
@@ -214,31 +221,47 @@ func (entry *CacheEntry) readAt(ctx context.Context, offset int64, size int64, r
 	if readFull {
 		size = file.Size - offset
 	}
-	if offset+size > file.Size {
-		size = file.Size - offset
+	offset, size, done := file.clampRead(offset, size)
+	if done {
+		return offset, nil, nil
 	}
-	if file.Opts.Circular {
-		realDataOffset := int64(0)
-		if file.Size > file.Opts.MaxSize {
-			realDataOffset = file.Size - file.Opts.MaxSize
-		}
-		if offset < realDataOffset {
-			truncateAmt := realDataOffset - offset
-			offset += truncateAmt
-			size -= truncateAmt
-		}
+	if file.IsReference() {
 		if size <= 0 {
-			return realDataOffset, nil, nil
+			return offset, nil, nil
 		}
+		data, err := readReference(file, offset, size)
+		return offset, data, err
 	}
 	partMap := file.computePartMap(offset, size)
-	dataEntryMap, err := entry.loadDataPartsForRead(ctx, getPartIdxsFromMap(partMap))
+	dataEntryMap, err := entry.loadDataPartsForRead(ctx, file, getPartIdxsFromMap(partMap))
 	if err != nil {
 		return 0, nil, err
 	}
+	return offset, file.readFromParts(dataEntryMap, offset, size), nil
+}
+
+// clamps a read to the data in the file, returns the offset and size to read.  done is set if there is nothing to
+// read from a file whose front was dropped (the offset is then where the data starts).
+func (file *WaveFile) clampRead(offset int64, size int64) (int64, int64, bool) {
+	if offset+size > file.Size {
+		size = file.Size - offset
+	}
+	realDataOffset := file.DataStartIdx()
+	if offset < realDataOffset {
+		truncateAmt := realDataOffset - offset
+		offset += truncateAmt
+		size -= truncateAmt
+	}
+	if size <= 0 && (file.Opts.Circular || realDataOffset > 0) {
+		return realDataOffset, 0, true
+	}
+	return offset, size, false
+}
+
+func (file *WaveFile) readFromParts(dataEntryMap map[int]*DataCacheEntry, offset int64, size int64) []byte {
 	// combine the entries into a single byte slice
 	// note that we only want part of the first and last part depending on offset and size
-	rtnData := make([]byte, 0, size)
+	rtnData := make([]byte, 0, max(size, 0))
 	amtLeftToRead := size
 	curReadOffset := offset
 	for amtLeftToRead > 0 {
@@ -256,7 +279,7 @@ func (entry *CacheEntry) readAt(ctx context.Context, offset int64, size int64, r
 		amtLeftToRead -= amtToRead
 		curReadOffset += amtToRead
 	}
-	return offset, rtnData, nil
+	return rtnData
 }
 
 func prunePartsWithCache(dataEntries map[int]*DataCacheEntry, parts []int) []int {
@@ -286,11 +309,14 @@ func (entry *CacheEntry) loadDataPartsIntoCache(ctx context.Context, parts []int
 	return nil
 }
 
-func (entry *CacheEntry) loadDataPartsForRead(ctx context.Context, parts []int) (map[int]*DataCacheEntry, error) {
+// the parts are read from the cache entry, the read cache or the db (in that order), the parts read from the db
+// are put in the read cache
+func (entry *CacheEntry) loadDataPartsForRead(ctx context.Context, file *WaveFile, parts []int) (map[int]*DataCacheEntry, error) {
 	if len(parts) == 0 {
 		return nil, nil
 	}
-	dbParts := prunePartsWithCache(entry.DataEntries, parts)
+	cachedParts := getCachedParts(file, prunePartsWithCache(entry.DataEntries, parts))
+	dbParts := prunePartsWithCache(entry.DataEntries, prunePartsWithCache(cachedParts, parts))
 	var dbDataParts map[int]*DataCacheEntry
 	if len(dbParts) > 0 {
 		var err error
@@ -298,13 +324,20 @@ func (entry *CacheEntry) loadDataPartsForRead(ctx context.Context, parts []int)
 		if err != nil {
 			return nil, fmt.Errorf("error getting data parts: %w", err)
 		}
+		putCachedParts(file, dbDataParts, false)
 	}
+	cacheStats.hits.Add(int64(len(parts) - len(dbParts)))
+	cacheStats.misses.Add(int64(len(dbParts)))
 	rtn := make(map[int]*DataCacheEntry)
 	for _, partIdx := range parts {
 		if entry.DataEntries[partIdx] != nil {
 			rtn[partIdx] = entry.DataEntries[partIdx]
 			continue
 		}
+		if cachedParts[partIdx] != nil {
+			rtn[partIdx] = cachedParts[partIdx]
+			continue
+		}
 		if dbDataParts[partIdx] != nil {
 			rtn[partIdx] = dbDataParts[partIdx]
 			continue
@@ -0,0 +1,131 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+)
+
+// the parts are compressed when they are written to the db and decompressed when they are read back, the cache
+// only has uncompressed data.  every part is its own zstd frame, so reading at an offset only decompresses the
+// parts it needs (the parts are the seek table).  a part is stored uncompressed ("none") if compressing does not
+// make it smaller.
+//
+// the parts are stored as blobs (see blockstore_dedup.go), the compression is per blob.  the parts written before
+// compression ("") or before dedup are moved into compressed blobs in the background after startup
+// (migrateOldParts).  sqlite reuses the freed pages for new data, the db file itself only shrinks if it is vacuumed.
+
+const (
+	PartCompression_Old  = "" // written before compression
+	PartCompression_None = "none"
+	PartCompression_Zstd = "zstd"
+)
+
+const compressBatchSize = 100
+const compressBatchPause = 100 * time.Millisecond
+const compressStartDelay = 30 * time.Second
+
+var zstdEncoder *zstd.Encoder
+var zstdDecoder *zstd.Decoder
+
+func init() {
+	var err error
+	// EncodeAll and DecodeAll can be called concurrently
+	zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		panic(fmt.Sprintf("error creating zstd encoder: %v", err))
+	}
+	zstdDecoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+	if err != nil {
+		panic(fmt.Sprintf("error creating zstd decoder: %v", err))
+	}
+}
+
+// returns the compression and the data to store
+func compressPart(data []byte) (string, []byte) {
+	if len(data) == 0 {
+		return PartCompression_None, data
+	}
+	compressed := zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)))
+	if len(compressed) >= len(data) {
+		return PartCompression_None, data
+	}
+	return PartCompression_Zstd, compressed
+}
+
+// the returned data has a capacity of partDataSize (like the data in the cache)
+func decompressPart(compression string, data []byte) ([]byte, error) {
+	switch compression {
+	case PartCompression_Old, PartCompression_None:
+		if cap(data) == int(partDataSize) {
+			return data, nil
+		}
+		rtn := make([]byte, len(data), partDataSize)
+		copy(rtn, data)
+		return rtn, nil
+	case PartCompression_Zstd:
+		rtn, err := zstdDecoder.DecodeAll(data, make([]byte, 0, partDataSize))
+		if err != nil {
+			return nil, fmt.Errorf("%w: error decompressing part: %v", errCorruptPart, err)
+		}
+		if int64(len(rtn)) > partDataSize {
+			return nil, fmt.Errorf("%w: decompressed part is too large (%d bytes)", errCorruptPart, len(rtn))
+		}
+		return rtn, nil
+	default:
+		return nil, fmt.Errorf("unknown part compression %q", compression)
+	}
+}
+
+// moves the parts that were written before compression or dedup into blobs, and re-encodes the blobs that do not
+// match the encryption setting, a batch at a time, until they are all done (or the flusher is stopped)
+func migrateOldParts() {
+	defer func() {
+		panichandler.PanicHandler("filestore:migrateOldParts", recover())
+	}()
+	time.Sleep(compressStartDelay)
+	var numParts, savedBytes int64
+	for !stopFlush.Load() {
+		ctx, cancelFn := context.WithTimeout(context.Background(), DefaultFlushTime)
+		batchParts, batchSaved, err := dbMigrateOldParts(ctx, compressBatchSize)
+		cancelFn()
+		if err != nil {
+			log.Printf("filestore: error migrating old parts: %v\n", err)
+			return
+		}
+		numParts += batchParts
+		savedBytes += batchSaved
+		if batchParts < compressBatchSize {
+			break
+		}
+		time.Sleep(compressBatchPause)
+	}
+	if numParts > 0 {
+		log.Printf("filestore: moved %d old parts to compressed blobs (saved %d bytes)\n", numParts, savedBytes)
+	}
+	var numBlobs int64
+	for !stopFlush.Load() {
+		ctx, cancelFn := context.WithTimeout(context.Background(), DefaultFlushTime)
+		batchBlobs, err := dbMigrateBlobs(ctx, compressBatchSize)
+		cancelFn()
+		if err != nil {
+			log.Printf("filestore: error migrating blobs: %v\n", err)
+			return
+		}
+		numBlobs += batchBlobs
+		if batchBlobs < compressBatchSize {
+			break
+		}
+		time.Sleep(compressBatchPause)
+	}
+	if numBlobs > 0 {
+		log.Printf("filestore: re-encoded %d blobs (encrypted:%v)\n", numBlobs, isEncrypting())
+	}
+}
@@ -5,11 +5,14 @@ package filestore
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log"
 	"os"
 
 	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 )
 
 // can return fs.ErrExist
@@ -30,12 +33,31 @@ func dbDeleteFile(ctx context.Context, zoneId string, name string) error {
 	return WithTx(ctx, func(tx *TxWrap) error {
 		query := "DELETE FROM db_wave_file WHERE zoneid = ? AND name = ?"
 		tx.Exec(query, zoneId, name)
-		query = "DELETE FROM db_file_data WHERE zoneid = ? AND name = ?"
+		txDeleteParts(tx, zoneId, name, "")
+		query = "DELETE FROM db_file_quarantine WHERE zoneid = ? AND name = ?"
 		tx.Exec(query, zoneId, name)
 		return nil
 	})
 }
 
+// can return fs.ErrNotExist (no file at name) or fs.ErrExist (a file at newName)
+func dbRenameFile(ctx context.Context, zoneId string, name string, newName string) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		query := "SELECT zoneid FROM db_wave_file WHERE zoneid = ? AND name = ?"
+		if !tx.Exists(query, zoneId, name) {
+			return fs.ErrNotExist
+		}
+		if tx.Exists(query, zoneId, newName) {
+			return fs.ErrExist
+		}
+		for _, table := range []string{"db_wave_file", "db_file_data", "db_file_lineidx", "db_file_textidx"} {
+			query = fmt.Sprintf("UPDATE %s SET name = ? WHERE zoneid = ? AND name = ?", table)
+			tx.Exec(query, newName, zoneId, name)
+		}
+		return nil
+	})
+}
+
 func dbGetZoneFileNames(ctx context.Context, zoneId string) ([]string, error) {
 	return WithTxRtn(ctx, func(tx *TxWrap) ([]string, error) {
 		var files []string
@@ -62,25 +84,68 @@ func dbGetAllZoneIds(ctx context.Context) ([]string, error) {
 	})
 }
 
+// a row of db_file_data (joined with its blob)
+type dbFilePart struct {
+	ZoneId      string
+	Name        string
+	PartIdx     int
+	Data        []byte
+	Compression string
+	Encryption  string
+	Hash        string
+	HasBlob     bool
+	Replica     string
+	Offloaded   bool
+}
+
 func dbGetFileParts(ctx context.Context, zoneId string, name string, parts []int) (map[int]*DataCacheEntry, error) {
 	if len(parts) == 0 {
 		return nil, nil
 	}
-	return WithTxRtn(ctx, func(tx *TxWrap) (map[int]*DataCacheEntry, error) {
-		var data []*DataCacheEntry
-		query := "SELECT partidx, data FROM db_file_data WHERE zoneid = ? AND name = ? AND partidx IN (SELECT value FROM json_each(?))"
-		tx.Select(&data, query, zoneId, name, dbutil.QuickJsonArr(parts))
+	var offloaded []*dbFilePart
+	rtn, err := WithTxRtn(ctx, func(tx *TxWrap) (map[int]*DataCacheEntry, error) {
+		var dbParts []*dbFilePart
+		query := `SELECT d.partidx, d.hash, b.hash IS NOT NULL AS hasblob,
+                         CASE WHEN d.hash = '' THEN d.data ELSE COALESCE(b.data, x'') END AS data,
+                         CASE WHEN d.hash = '' THEN d.compression ELSE COALESCE(b.compression, '') END AS compression,
+                         COALESCE(b.encryption, '') AS encryption, COALESCE(b.replica, '') AS replica,
+                         COALESCE(b.offloaded, 0) AS offloaded
+                  FROM db_file_data d LEFT JOIN db_file_blob b ON b.hash = d.hash
+                  WHERE d.zoneid = ? AND d.name = ? AND d.partidx IN (SELECT value FROM json_each(?))`
+		tx.Select(&dbParts, query, zoneId, name, dbutil.QuickJsonArr(parts))
+		offloaded = nil
 		rtn := make(map[int]*DataCacheEntry)
-		for _, d := range data {
-			if cap(d.Data) != int(partDataSize) {
-				newData := make([]byte, len(d.Data), partDataSize)
-				copy(newData, d.Data)
-				d.Data = newData
+		for _, part := range dbParts {
+			if part.Offloaded {
+				// fetched from the replica, outside of the transaction
+				offloaded = append(offloaded, part)
+				continue
+			}
+			data, err := decodePart(part)
+			if errors.Is(err, errCorruptPart) {
+				// the part reads as zeros
+				log.Printf("filestore: quarantining part %d of %s:%s: %v\n", part.PartIdx, zoneId, name, err)
+				txQuarantinePart(tx, zoneId, name, part.PartIdx, err.Error())
+				continue
 			}
-			rtn[d.PartIdx] = d
+			if err != nil {
+				return nil, fmt.Errorf("part %d of %s:%s: %w", part.PartIdx, zoneId, name, err)
+			}
+			rtn[part.PartIdx] = &DataCacheEntry{PartIdx: part.PartIdx, Data: data}
 		}
 		return rtn, nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range offloaded {
+		data, err := fetchOffloadedBlob(ctx, part.Hash, part.Encryption, part.Replica)
+		if err != nil {
+			return nil, fmt.Errorf("part %d of %s:%s: %w", part.PartIdx, zoneId, name, err)
+		}
+		rtn[part.PartIdx] = &DataCacheEntry{PartIdx: part.PartIdx, Data: data}
+	}
+	return rtn, nil
 }
 
 func dbGetZoneFiles(ctx context.Context, zoneId string) ([]*WaveFile, error) {
@@ -99,19 +164,92 @@ func dbWriteCacheEntry(ctx context.Context, file *WaveFile, dataEntries map[int]
 			return os.ErrNotExist
 		}
 		// we don't update CreatedTs or Opts
-		query = `UPDATE db_wave_file SET size = ?, modts = ?, meta = ? WHERE zoneid = ? AND name = ?`
-		tx.Exec(query, file.Size, file.ModTs, dbutil.QuickJson(file.Meta), file.ZoneId, file.Name)
+		query = `UPDATE db_wave_file SET size = ?, modts = ?, meta = ?, numlines = ?, trimoffset = ? WHERE zoneid = ? AND name = ?`
+		tx.Exec(query, file.Size, file.ModTs, dbutil.QuickJson(file.Meta), file.NumLines, file.TrimOffset, file.ZoneId, file.Name)
 		if replace {
-			query = `DELETE FROM db_file_data WHERE zoneid = ? AND name = ?`
-			tx.Exec(query, file.ZoneId, file.Name)
+			txDeleteParts(tx, file.ZoneId, file.Name, "")
 		}
-		dataPartQuery := `REPLACE INTO db_file_data (zoneid, name, partidx, data) VALUES (?, ?, ?, ?)`
+		lineIdxQuery := `REPLACE INTO db_file_lineidx (zoneid, name, partidx, fileoffset, numlines) VALUES (?, ?, ?, ?, ?)`
 		for partIdx, dataEntry := range dataEntries {
 			if partIdx != dataEntry.PartIdx {
 				panic(fmt.Sprintf("partIdx:%d and dataEntry.PartIdx:%d do not match", partIdx, dataEntry.PartIdx))
 			}
-			tx.Exec(dataPartQuery, file.ZoneId, file.Name, dataEntry.PartIdx, dataEntry.Data)
+			err := txWritePart(tx, file.ZoneId, file.Name, dataEntry.PartIdx, dataEntry.Data)
+			if err != nil {
+				return err
+			}
+			if file.Opts.LineIndex {
+				part := makeLineIndexPart(file, dataEntry)
+				tx.Exec(lineIdxQuery, file.ZoneId, file.Name, part.PartIdx, part.FileOffset, part.NumLines)
+			}
+			if file.Opts.TextIndex {
+				txWriteTextIndex(tx, file, dataEntry)
+			}
 		}
 		return nil
 	})
 }
+
+func dbGetLineIndex(ctx context.Context, zoneId string, name string) ([]*lineIndexPart, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) ([]*lineIndexPart, error) {
+		var parts []*lineIndexPart
+		query := "SELECT partidx, fileoffset, numlines FROM db_file_lineidx WHERE zoneid = ? AND name = ?"
+		tx.Select(&parts, query, zoneId, name)
+		return parts, nil
+	})
+}
+
+// opts are otherwise static, the line and text index can be turned on for an existing file (see EnableLineIndex
+// and SetTextIndex)
+func dbUpdateFileOpts(ctx context.Context, zoneId string, name string, opts wshrpc.FileOpts) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		query := "UPDATE db_wave_file SET opts = ? WHERE zoneid = ? AND name = ?"
+		tx.Exec(query, dbutil.QuickJson(opts), zoneId, name)
+		return nil
+	})
+}
+
+// deletes the parts (and their line index), returns the bytes they used in the db
+func dbDeleteFileParts(ctx context.Context, zoneId string, name string, parts []int) (int64, error) {
+	if len(parts) == 0 {
+		return 0, nil
+	}
+	return WithTxRtn(ctx, func(tx *TxWrap) (int64, error) {
+		return txDeleteParts(tx, zoneId, name, dbutil.QuickJsonArr(parts)), nil
+	})
+}
+
+// the bytes each file uses in the db (by zoneid and name), a blob that is shared counts for each part that has it
+func dbGetStoredSizes(ctx context.Context) (map[cacheKey]int64, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) (map[cacheKey]int64, error) {
+		var rows []struct {
+			ZoneId     string
+			Name       string
+			StoredSize int64
+		}
+		query := `SELECT d.zoneid, d.name, SUM(CASE WHEN d.hash = '' THEN length(d.data) ELSE COALESCE(length(b.data), 0) END) AS storedsize
+                  FROM db_file_data d LEFT JOIN db_file_blob b ON b.hash = d.hash
+                  GROUP BY d.zoneid, d.name`
+		tx.Select(&rows, query)
+		rtn := make(map[cacheKey]int64)
+		for _, row := range rows {
+			rtn[cacheKey{ZoneId: row.ZoneId, Name: row.Name}] = row.StoredSize
+		}
+		return rtn, nil
+	})
+}
+
+func dbDeleteTextIndex(ctx context.Context, zoneId string, name string) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		txDeleteTextIndex(tx, "", []any{zoneId, name})
+		return nil
+	})
+}
+
+func dbGetAllFiles(ctx context.Context) ([]*WaveFile, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) ([]*WaveFile, error) {
+		query := "SELECT * FROM db_wave_file"
+		files := dbutil.SelectMappable[*WaveFile](tx, query)
+		return files, nil
+	})
+}
@@ -44,11 +44,20 @@ func InitFilestore() error {
 	}
 	if !stopFlush.Load() {
 		go WFS.runFlusher()
+		go migrateOldParts()
 	}
 	log.Printf("filestore initialized\n")
 	return nil
 }
 
+// waits for the queries in progress, call after the last flush (FlushAndStop) (on shutdown)
+func CloseFilestore() error {
+	if globalDB == nil {
+		return nil
+	}
+	return globalDB.Close()
+}
+
 func GetDBName() string {
 	waveHome := wavebase.GetWaveDataDir()
 	return filepath.Join(waveHome, wavebase.WaveDBDir, FilestoreDBName)
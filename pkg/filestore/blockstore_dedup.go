@@ -0,0 +1,175 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"fmt"
+)
+
+// the data of the parts is stored by its content: db_file_blob has one (compressed) copy of each distinct part,
+// keyed by the sha256 of the uncompressed data, and db_file_data has the hash of each part.  the same file opened
+// in many blocks, or the same output written again, is stored once.  the blobs are reference counted in the same
+// transaction that writes or deletes the parts (deleting a block or tab deletes its zone, that releases the
+// blobs), a blob is deleted with its last part.  ReapBlobs recounts the references in case they are ever off.
+//
+// empty parts, and the parts written before dedup, have their data in db_file_data (hash is "").  the old parts
+// are moved to blobs in the background after startup (migrateOldParts).  the blobs can be encrypted, see
+// blockstore_encrypt.go, and replicated (see blockstore_replica.go).
+
+// a row of db_file_blob
+type dbBlob struct {
+	Hash        string
+	Data        []byte
+	Compression string
+	Encryption  string
+	RefCount    int
+	Replica     string // the name of the blob in the replica ("" if it is not replicated)
+	Offloaded   bool   // the data is only in the replica
+}
+
+type dbBlobRef struct {
+	Hash       string
+	StoredSize int64
+}
+
+// adds a reference to the blob for data (inserting it if it is new)
+func txAddBlobRef(tx *TxWrap, hash string, data []byte) error {
+	query := "SELECT hash FROM db_file_blob WHERE hash = ?"
+	if tx.Exists(query, hash) {
+		query = "UPDATE db_file_blob SET refcount = refcount + 1 WHERE hash = ?"
+		tx.Exec(query, hash)
+		return nil
+	}
+	compression, blobEncryption, stored, err := encodeBlob(hash, data)
+	if err != nil {
+		return err
+	}
+	query = "INSERT INTO db_file_blob (hash, data, compression, encryption, refcount) VALUES (?, ?, ?, ?, 1)"
+	tx.Exec(query, hash, stored, compression, blobEncryption)
+	return nil
+}
+
+// releases one reference for each hash (a hash can be in the list more than once), the blobs that are no longer
+// referenced are deleted
+func txReleaseBlobs(tx *TxWrap, hashes []string) {
+	counts := make(map[string]int)
+	for _, hash := range hashes {
+		if hash != "" {
+			counts[hash]++
+		}
+	}
+	for hash, count := range counts {
+		query := "UPDATE db_file_blob SET refcount = refcount - ? WHERE hash = ?"
+		tx.Exec(query, count, hash)
+		query = "DELETE FROM db_file_blob WHERE hash = ? AND refcount <= 0"
+		tx.Exec(query, hash)
+	}
+}
+
+// writes the data of a part, the blob of the data it replaces is released
+func txWritePart(tx *TxWrap, zoneId string, name string, partIdx int, data []byte) error {
+	query := "SELECT hash FROM db_file_data WHERE zoneid = ? AND name = ? AND partidx = ?"
+	oldHash := tx.GetString(query, zoneId, name, partIdx)
+	query = "REPLACE INTO db_file_data (zoneid, name, partidx, data, compression, hash) VALUES (?, ?, ?, ?, ?, ?)"
+	if len(data) == 0 {
+		tx.Exec(query, zoneId, name, partIdx, []byte{}, PartCompression_None, "")
+	} else {
+		hash := partHash(data)
+		if hash == oldHash {
+			return nil
+		}
+		err := txAddBlobRef(tx, hash, data)
+		if err != nil {
+			return err
+		}
+		tx.Exec(query, zoneId, name, partIdx, []byte{}, PartCompression_None, hash)
+	}
+	txReleaseBlobs(tx, []string{oldHash})
+	return nil
+}
+
+// deletes the parts of the file (all of them if partsJson is empty) and their line and text index, and releases
+// their blobs.  returns the bytes the parts used in the db (a blob counts for each part that has it).
+func txDeleteParts(tx *TxWrap, zoneId string, name string, partsJson string) int64 {
+	partsCond := ""
+	args := []any{zoneId, name}
+	if partsJson != "" {
+		partsCond = " AND partidx IN (SELECT value FROM json_each(?))"
+		args = append(args, partsJson)
+	}
+	var refs []*dbBlobRef
+	query := `SELECT d.hash, CASE WHEN d.hash = '' THEN length(d.data) ELSE COALESCE(length(b.data), 0) END AS storedsize
+              FROM db_file_data d LEFT JOIN db_file_blob b ON b.hash = d.hash
+              WHERE d.zoneid = ? AND d.name = ?` + partsCond
+	tx.Select(&refs, query, args...)
+	query = "DELETE FROM db_file_data WHERE zoneid = ? AND name = ?" + partsCond
+	tx.Exec(query, args...)
+	query = "DELETE FROM db_file_lineidx WHERE zoneid = ? AND name = ?" + partsCond
+	tx.Exec(query, args...)
+	txDeleteTextIndex(tx, partsCond, args)
+	var storedSize int64
+	var hashes []string
+	for _, ref := range refs {
+		storedSize += ref.StoredSize
+		hashes = append(hashes, ref.Hash)
+	}
+	txReleaseBlobs(tx, hashes)
+	return storedSize
+}
+
+// moves up to limit parts that were written before dedup into blobs, returns the number of parts and the bytes
+// saved
+func dbMigrateOldParts(ctx context.Context, limit int) (int64, int64, error) {
+	var numParts, savedBytes int64
+	err := WithTx(ctx, func(tx *TxWrap) error {
+		var dbParts []*dbFilePart
+		query := "SELECT zoneid, name, partidx, data, compression FROM db_file_data WHERE hash = '' AND length(data) > 0 LIMIT ?"
+		tx.Select(&dbParts, query, limit)
+		for _, part := range dbParts {
+			data, err := decompressPart(part.Compression, part.Data)
+			if err != nil {
+				return fmt.Errorf("part %d of %s:%s: %w", part.PartIdx, part.ZoneId, part.Name, err)
+			}
+			hash := partHash(data)
+			isNew := !tx.Exists("SELECT hash FROM db_file_blob WHERE hash = ?", hash)
+			err = txAddBlobRef(tx, hash, data)
+			if err != nil {
+				return err
+			}
+			blobSize := 0
+			if isNew {
+				blobSize = tx.GetInt("SELECT length(data) FROM db_file_blob WHERE hash = ?", hash)
+			}
+			query = "UPDATE db_file_data SET data = ?, compression = ?, hash = ? WHERE zoneid = ? AND name = ? AND partidx = ?"
+			tx.Exec(query, []byte{}, PartCompression_None, hash, part.ZoneId, part.Name, part.PartIdx)
+			numParts++
+			savedBytes += int64(len(part.Data) - blobSize)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return numParts, savedBytes, nil
+}
+
+// recounts the references to the blobs from the parts and deletes the blobs that are not referenced, returns the
+// number of blobs deleted
+func dbReapBlobs(ctx context.Context) (int, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) (int, error) {
+		query := `UPDATE db_file_blob SET refcount = (SELECT COUNT(*) FROM db_file_data d WHERE d.hash = db_file_blob.hash)`
+		tx.Exec(query)
+		query = "DELETE FROM db_file_blob WHERE refcount <= 0"
+		result := tx.Exec(query)
+		numDeleted, _ := result.RowsAffected()
+		return int(numDeleted), nil
+	})
+}
+
+// fixes the blob reference counts (the counts are kept by the writes and deletes, this is a check that is run with
+// the reaper at startup)
+func (s *FileStore) ReapBlobs(ctx context.Context) (int, error) {
+	return dbReapBlobs(ctx)
+}
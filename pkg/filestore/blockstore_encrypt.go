@@ -0,0 +1,247 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// the blobs are encrypted at rest (storage:encrypt) with a key from the OS keychain (see pkg/keychain), the key is
+// set when the filestore starts (SetEncryption).  a blob is compressed and then encrypted with aes-256-gcm, the
+// nonce is stored before the ciphertext and the blob's hash is the additional data (a blob can not be swapped for
+// another).  when encrypting, the hashes are hmacs, so the hashes do not tell what is stored.  the cipher key and
+// the hmac key are derived from the keychain key with hkdf, so the hashes never use the cipher key.  the first
+// encrypted blobs ("aes-gcm") used the keychain key for both, they are still read and are migrated to the derived
+// keys like the other blobs that do not match the setting.
+//
+// the blobs that do not match the setting (written before it was turned on, or off) are re-encoded and re-hashed
+// in the background after startup (migrateOldParts), the key is kept for reading while there are encrypted blobs.
+// the text index is not encrypted, so the text of files is not indexed while encrypting.
+
+const (
+	BlobEncryption_None     = ""
+	BlobEncryption_AesGcmV1 = "aes-gcm" // the keychain key is the cipher key and the hmac key (only read)
+	BlobEncryption_AesGcm   = "aes-gcm-v2"
+)
+
+const EncryptionKeyName = "filestore"
+
+const (
+	hkdfInfo_BlobCipher = "waveterm filestore blob cipher"
+	hkdfInfo_BlobHash   = "waveterm filestore blob hash"
+)
+
+type encryptionState struct {
+	key     []byte      // the keychain key (for the aes-gcm blobs)
+	aeadV1  cipher.AEAD // with the keychain key
+	hashKey []byte      // derived, for the hmacs
+	aead    cipher.AEAD // with the derived cipher key
+	encrypt bool        // writes are encrypted (otherwise the key is only for reading)
+}
+
+var encryption atomic.Pointer[encryptionState]
+
+// sets the key (nil for no key) and whether new blobs are encrypted, call before the filestore is used
+func SetEncryption(key []byte, encrypt bool) error {
+	if key == nil {
+		if encrypt {
+			return fmt.Errorf("cannot encrypt without a key")
+		}
+		encryption.Store(nil)
+		return nil
+	}
+	aeadV1, err := makeAead(key)
+	if err != nil {
+		return err
+	}
+	cipherKey, err := deriveKey(key, hkdfInfo_BlobCipher)
+	if err != nil {
+		return err
+	}
+	aead, err := makeAead(cipherKey)
+	if err != nil {
+		return err
+	}
+	hashKey, err := deriveKey(key, hkdfInfo_BlobHash)
+	if err != nil {
+		return err
+	}
+	encryption.Store(&encryptionState{key: key, aeadV1: aeadV1, hashKey: hashKey, aead: aead, encrypt: encrypt})
+	return nil
+}
+
+// a subkey (as long as the key) for one use of the key
+func deriveKey(key []byte, info string) ([]byte, error) {
+	subKey := make([]byte, len(key))
+	_, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(info)), subKey)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key: %w", err)
+	}
+	return subKey, nil
+}
+
+func makeAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error making cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error making cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// the cipher and the hmac key of an encryption, nil if it is not an encryption
+func (state *encryptionState) getKeys(blobEncryption string) (cipher.AEAD, []byte) {
+	switch blobEncryption {
+	case BlobEncryption_AesGcm:
+		return state.aead, state.hashKey
+	case BlobEncryption_AesGcmV1:
+		return state.aeadV1, state.key
+	}
+	return nil, nil
+}
+
+func isEncrypting() bool {
+	state := encryption.Load()
+	return state != nil && state.encrypt
+}
+
+func targetEncryption() string {
+	if isEncrypting() {
+		return BlobEncryption_AesGcm
+	}
+	return BlobEncryption_None
+}
+
+func partHash(data []byte) string {
+	return blobHash(targetEncryption(), data)
+}
+
+// the hash of a blob with the encryption (an hmac for the encrypted blobs), "" if there is no key for it
+func blobHash(blobEncryption string, data []byte) string {
+	if blobEncryption != BlobEncryption_None {
+		state := encryption.Load()
+		if state == nil {
+			return ""
+		}
+		_, hashKey := state.getKeys(blobEncryption)
+		if hashKey == nil {
+			return ""
+		}
+		mac := hmac.New(sha256.New, hashKey)
+		mac.Write(data)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// compresses (and encrypts) the data of a blob, returns the compression, the encryption and the data to store
+func encodeBlob(hash string, data []byte) (string, string, []byte, error) {
+	blobEncryption := targetEncryption()
+	compression, stored, err := encodeBlobAs(hash, blobEncryption, data)
+	return compression, blobEncryption, stored, err
+}
+
+// encodes the data of a blob with the encryption (the key is needed for an encrypted blob even when the writes are
+// not encrypted), returns the compression and the data to store
+func encodeBlobAs(hash string, blobEncryption string, data []byte) (string, []byte, error) {
+	compression, stored := compressPart(data)
+	if blobEncryption == BlobEncryption_None {
+		return compression, stored, nil
+	}
+	state := encryption.Load()
+	if state == nil {
+		return "", nil, fmt.Errorf("cannot encrypt blob, there is no key")
+	}
+	aead, _ := state.getKeys(blobEncryption)
+	if aead == nil {
+		return "", nil, fmt.Errorf("unknown blob encryption %q", blobEncryption)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(stored)+aead.Overhead())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", nil, fmt.Errorf("error making nonce: %w", err)
+	}
+	return compression, aead.Seal(nonce, nonce, stored, []byte(hash)), nil
+}
+
+// decrypts (and decompresses) the data of a blob
+func decodeBlob(hash string, compression string, blobEncryption string, data []byte) ([]byte, error) {
+	switch blobEncryption {
+	case BlobEncryption_None:
+	case BlobEncryption_AesGcm, BlobEncryption_AesGcmV1:
+		state := encryption.Load()
+		if state == nil {
+			return nil, fmt.Errorf("blob is encrypted and there is no key")
+		}
+		aead, _ := state.getKeys(blobEncryption)
+		nonceSize := aead.NonceSize()
+		if len(data) < nonceSize {
+			return nil, fmt.Errorf("%w: encrypted blob is too short", errCorruptPart)
+		}
+		var err error
+		data, err = aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(hash))
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting blob (wrong key?): %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown blob encryption %q", blobEncryption)
+	}
+	return decompressPart(compression, data)
+}
+
+// re-encodes (and re-hashes) up to limit blobs that do not match the encryption setting, the parts are moved to
+// the new hashes.  returns the number of blobs.
+func dbMigrateBlobs(ctx context.Context, limit int) (int64, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) (int64, error) {
+		var blobs []*dbBlob
+		// the offloaded blobs are migrated when they are brought back (see blockstore_replica.go)
+		query := "SELECT hash, data, compression, encryption, refcount, replica FROM db_file_blob WHERE encryption != ? AND offloaded = 0 LIMIT ?"
+		tx.Select(&blobs, query, targetEncryption(), limit)
+		for _, blob := range blobs {
+			data, err := decodeBlob(blob.Hash, blob.Compression, blob.Encryption, blob.Data)
+			if err != nil {
+				return 0, fmt.Errorf("blob %s: %w", blob.Hash, err)
+			}
+			newHash := partHash(data)
+			tx.Exec("DELETE FROM db_file_blob WHERE hash = ?", blob.Hash)
+			query = "SELECT hash FROM db_file_blob WHERE hash = ?"
+			if tx.Exists(query, newHash) {
+				tx.Exec("UPDATE db_file_blob SET refcount = refcount + ? WHERE hash = ?", blob.RefCount, newHash)
+			} else {
+				compression, blobEncryption, stored, err := encodeBlob(newHash, data)
+				if err != nil {
+					return 0, err
+				}
+				// the replica is named by the data, it is the same for the new hash
+				query = "INSERT INTO db_file_blob (hash, data, compression, encryption, refcount, replica) VALUES (?, ?, ?, ?, ?, ?)"
+				tx.Exec(query, newHash, stored, compression, blobEncryption, blob.RefCount, blob.Replica)
+			}
+			tx.Exec("UPDATE db_file_data SET hash = ? WHERE hash = ?", newHash, blob.Hash)
+		}
+		return int64(len(blobs)), nil
+	})
+}
+
+// there are blobs that need the key to be read
+func (s *FileStore) HasEncryptedBlobs(ctx context.Context) (bool, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) (bool, error) {
+		query := "SELECT hash FROM db_file_blob WHERE encryption != ? LIMIT 1"
+		return tx.Exists(query, BlobEncryption_None), nil
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"fmt"
+)
+
+// parts can be evicted from the front of regular files to keep them under a quota (see pkg/filequota).  the
+// evicted data is gone, the file starts at TrimOffset (like a circular file that has wrapped around) and the
+// offsets of the rest of the data do not change.  circular files are already limited by their MaxSize, and ijson
+// files can not lose their first commands, so their parts are not evicted.
+
+type FileUsage struct {
+	ZoneId     string
+	Name       string
+	Size       int64 // the data in the file (DataLength)
+	StoredSize int64 // the bytes in the db (compressed, a shared blob counts for each file), the writes that are not flushed yet are not counted
+	Evicted    int64 // the data evicted from the front of the file
+	Evictable  bool
+}
+
+func (f WaveFile) CanEvict() bool {
+	return !f.Opts.Circular && !f.Opts.IJson
+}
+
+// the usage of all the files (from the db)
+func (s *FileStore) GetUsage(ctx context.Context) ([]*FileUsage, error) {
+	files, err := dbGetAllFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting files: %w", err)
+	}
+	storedSizes, err := dbGetStoredSizes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting stored sizes: %w", err)
+	}
+	rtn := make([]*FileUsage, 0, len(files))
+	for _, file := range files {
+		rtn = append(rtn, &FileUsage{
+			ZoneId:     file.ZoneId,
+			Name:       file.Name,
+			Size:       file.DataLength(),
+			StoredSize: storedSizes[cacheKey{ZoneId: file.ZoneId, Name: file.Name}],
+			Evicted:    file.TrimOffset,
+			Evictable:  file.CanEvict(),
+		})
+	}
+	return rtn, nil
+}
+
+// evicts up to numParts parts from the front of the file (the part with the end of the file is kept), returns the
+// bytes that were freed in the db (0 if there was nothing to evict)
+func (s *FileStore) EvictParts(ctx context.Context, zoneId string, name string, numParts int) (int64, error) {
+	if numParts <= 0 {
+		return 0, nil
+	}
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
+		err := entry.loadFileIntoCache(ctx)
+		if err != nil {
+			return 0, err
+		}
+		file := entry.File
+		if !file.CanEvict() {
+			return 0, fmt.Errorf("cannot evict parts from circular or ijson file %s:%s", zoneId, name)
+		}
+		if file.Size == 0 {
+			return 0, nil
+		}
+		firstPart := int(file.TrimOffset / partDataSize)
+		lastPart := int((file.Size - 1) / partDataSize)
+		numParts = min(numParts, lastPart-firstPart)
+		if numParts <= 0 {
+			return 0, nil
+		}
+		var parts []int
+		for partIdx := firstPart; partIdx < firstPart+numParts; partIdx++ {
+			parts = append(parts, partIdx)
+			delete(entry.DataEntries, partIdx)
+		}
+		storedSize, err := dbDeleteFileParts(ctx, zoneId, name, parts)
+		if err != nil {
+			return 0, err
+		}
+		file.TrimOffset = int64(firstPart+numParts) * partDataSize
+		dropCachedParts(zoneId, name)
+		err = entry.flushToDB(ctx, false)
+		if err != nil {
+			return 0, err
+		}
+		return storedSize, nil
+	})
+}
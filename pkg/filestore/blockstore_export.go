@@ -0,0 +1,160 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// a file is exported (ExportFile) into a local directory, under its name (made safe for a file name).  it is
+// written to a temp file in the directory that is renamed onto the local file when it is complete, so a local file
+// is never left half written.  if a local file with the name exists it is replaced, skipped, or (the default) the
+// export gets the next free name ("name (1).ext").  the local file has the mtime of the file.
+
+const (
+	ExportCollision_Rename    = "rename"
+	ExportCollision_Overwrite = "overwrite"
+	ExportCollision_Skip      = "skip"
+)
+
+const exportChunkSize = 256 * 1024
+const exportMaxRenames = 1000
+
+type ExportOpts struct {
+	Collision  string                   // ExportCollision_*, rename if empty
+	ProgressFn func(offset, size int64) // called after each chunk is written
+}
+
+// the name of the local file for a file, the characters that can not be in a file name (on any os) are replaced
+func ExportFileName(name string) string {
+	rtn := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	rtn = strings.TrimRight(rtn, ". ")
+	if rtn == "" || strings.Trim(rtn, ".") == "" {
+		rtn = "_" + rtn
+	}
+	return rtn
+}
+
+// reserves the local file for an export (an empty file), returns its path.  the path is "" (and fs.ErrExist is
+// returned) if the file exists and the collision is skip.
+func reserveExportPath(dir string, fileName string, collision string) (string, error) {
+	ext := filepath.Ext(fileName)
+	base := strings.TrimSuffix(fileName, ext)
+	for idx := 0; idx < exportMaxRenames; idx++ {
+		path := filepath.Join(dir, fileName)
+		if idx > 0 {
+			path = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, idx, ext))
+		}
+		if collision == ExportCollision_Overwrite {
+			return path, nil
+		}
+		fd, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fd.Close()
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		if collision == ExportCollision_Skip {
+			return "", fs.ErrExist
+		}
+	}
+	return "", fmt.Errorf("no free name for %s in %s", fileName, dir)
+}
+
+// writes the file into the local directory dir, returns the path of the local file.  fs.ErrExist is returned if
+// the local file exists and opts.Collision is skip.
+func (s *FileStore) ExportFile(ctx context.Context, zoneId string, name string, dir string, opts ExportOpts) (rtnPath string, rtnErr error) {
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("directory %q is not absolute", dir)
+	}
+	switch opts.Collision {
+	case "":
+		opts.Collision = ExportCollision_Rename
+	case ExportCollision_Rename, ExportCollision_Overwrite, ExportCollision_Skip:
+	default:
+		return "", fmt.Errorf("invalid collision %q (rename, overwrite or skip)", opts.Collision)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	file, err := s.Stat(ctx, zoneId, name)
+	if err != nil {
+		return "", err
+	}
+	path, err := reserveExportPath(dir, ExportFileName(name), opts.Collision)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if rtnErr != nil && opts.Collision != ExportCollision_Overwrite {
+			os.Remove(path)
+		}
+	}()
+	tempFd, err := os.CreateTemp(dir, ".wave-export-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		tempFd.Close()
+		if rtnErr != nil {
+			os.Remove(tempFd.Name())
+		}
+	}()
+	offset := file.DataStartIdx()
+	for offset < file.Size {
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
+		readOffset, data, err := s.ReadAt(ctx, zoneId, name, offset, min(exportChunkSize, file.Size-offset))
+		if err != nil {
+			return "", fmt.Errorf("error reading %s: %w", name, err)
+		}
+		if readOffset != offset {
+			return "", fmt.Errorf("the data at %d is no longer in %s (it starts at %d)", offset, name, readOffset)
+		}
+		if len(data) == 0 {
+			// the file was truncated
+			break
+		}
+		_, err = tempFd.Write(data)
+		if err != nil {
+			return "", fmt.Errorf("error writing %s: %w", path, err)
+		}
+		offset += int64(len(data))
+		if opts.ProgressFn != nil {
+			opts.ProgressFn(offset, file.Size)
+		}
+	}
+	err = tempFd.Close()
+	if err != nil {
+		return "", fmt.Errorf("error writing %s: %w", path, err)
+	}
+	// temp files are only readable by the user
+	os.Chmod(tempFd.Name(), 0644)
+	modTime := time.UnixMilli(file.ModTs)
+	os.Chtimes(tempFd.Name(), modTime, modTime)
+	err = os.Rename(tempFd.Name(), path)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/util/fileutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// a local file is imported into a zone (ImportFile) by copying it, or by reference.  a copy is written to a staging
+// file that is renamed onto the name when it is complete (its parts are blobs, so the same content imported into
+// many blocks is stored once).  a reference has the size of the local file and no data, it is read from the local
+// file as long as the local file has the size and mtime it had when it was imported (it can not be written, except
+// by WriteFile, which makes it a regular file).  the path, sha256, size, mime type and mtime of the local file are
+// in the meta of the file.  importing the same content into a zone again returns the file that is there.
+
+const (
+	ImportMeta_Path     = "import:path"
+	ImportMeta_Sha256   = "import:sha256"
+	ImportMeta_Size     = "import:size"
+	ImportMeta_MimeType = "import:mimetype"
+	ImportMeta_MTime    = "import:mtime"
+	ImportMeta_Ref      = "import:ref"
+)
+
+const importChunkSize = 256 * 1024
+
+var ErrReferenceChanged = errors.New("the referenced file has changed since it was imported")
+var errWriteReference = errors.New("cannot write to a referenced file (import it as a copy)")
+
+type ImportOpts struct {
+	Name      string // the name in the zone (the base name of the path if empty)
+	Reference bool   // reference the local file instead of copying it
+	Overwrite bool   // replace the file with the name (if it has other content)
+}
+
+func (f WaveFile) IsReference() bool {
+	ref, _ := f.Meta[ImportMeta_Ref].(bool)
+	return ref
+}
+
+func hashLocalFile(path string) (string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	hasher := sha256.New()
+	_, err = io.Copy(hasher, fd)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// the imported file in the zone with the content (and the name, if it is set), nil if there is none
+func (s *FileStore) findImportedFile(ctx context.Context, zoneId string, sha256Hex string, name string, reference bool) (*WaveFile, error) {
+	files, err := s.ListFiles(ctx, zoneId)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.Meta[ImportMeta_Sha256] != sha256Hex || file.IsReference() != reference || (name != "" && file.Name != name) {
+			continue
+		}
+		if reference && checkReference(file) != nil {
+			// the local file changed
+			continue
+		}
+		return file, nil
+	}
+	return nil, nil
+}
+
+// copies (or references) the local file at path into the zone, returns the file
+func (s *FileStore) ImportFile(ctx context.Context, zoneId string, path string, opts ImportOpts) (*WaveFile, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("path %q is not absolute", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	sha256Hex, err := hashLocalFile(path)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.findImportedFile(ctx, zoneId, sha256Hex, opts.Name, opts.Reference)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	name := opts.Name
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	_, err = s.Stat(ctx, zoneId, name)
+	if err == nil && !opts.Overwrite {
+		return nil, fs.ErrExist
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	meta := wshrpc.FileMeta{
+		ImportMeta_Path:     path,
+		ImportMeta_Sha256:   sha256Hex,
+		ImportMeta_Size:     info.Size(),
+		ImportMeta_MimeType: fileutil.DetectMimeType(path, info, true),
+		ImportMeta_MTime:    info.ModTime().UnixMilli(),
+	}
+	if opts.Reference {
+		err = s.makeReference(ctx, zoneId, name, info.Size(), meta)
+	} else {
+		err = s.copyLocalFile(ctx, zoneId, name, path, meta)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Stat(ctx, zoneId, name)
+}
+
+func (s *FileStore) makeReference(ctx context.Context, zoneId string, name string, size int64, meta wshrpc.FileMeta) error {
+	meta[ImportMeta_Ref] = true
+	err := s.DeleteFile(ctx, zoneId, name)
+	if err != nil {
+		return err
+	}
+	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+		now := time.Now().UnixMilli()
+		file := &WaveFile{
+			ZoneId:    zoneId,
+			Name:      name,
+			Size:      size,
+			CreatedTs: now,
+			ModTs:     now,
+			Meta:      meta,
+		}
+		err := dbInsertFile(ctx, file)
+		if err != nil {
+			return err
+		}
+		notifyWatchers(zoneId, name, false)
+		return nil
+	})
+}
+
+// copies the local file into a staging file, and renames it onto name if it did not change while it was copied
+func (s *FileStore) copyLocalFile(ctx context.Context, zoneId string, name string, path string, meta wshrpc.FileMeta) (rtnErr error) {
+	stagingName := wavebase.BlockFile_TransferPrefix + "import-" + uuid.NewString()
+	err := s.MakeFile(ctx, zoneId, stagingName, meta, wshrpc.FileOpts{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rtnErr != nil {
+			s.DeleteFile(ctx, zoneId, stagingName)
+		}
+	}()
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	hasher := sha256.New()
+	buf := make([]byte, importChunkSize)
+	for {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		n, err := io.ReadFull(fd, buf)
+		if n > 0 {
+			hasher.Write(buf[:n])
+			_, appendErr := s.Append(ctx, zoneId, stagingName, buf[:n])
+			if appendErr == nil {
+				// the chunks are not kept in the cache
+				appendErr = s.Sync(ctx, zoneId, stagingName)
+			}
+			if appendErr != nil {
+				return fmt.Errorf("error writing %s: %w", stagingName, appendErr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != meta[ImportMeta_Sha256] {
+		return fmt.Errorf("%s changed while it was imported", path)
+	}
+	return s.RenameFile(ctx, zoneId, stagingName, name)
+}
+
+// the local file of a reference has the size and mtime it had when it was imported
+func checkReference(file *WaveFile) error {
+	localPath, _ := file.Meta[ImportMeta_Path].(string)
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("error reading referenced file: %w", err)
+	}
+	if info.Size() != file.Size || info.ModTime().UnixMilli() != getMetaInt64(file.Meta, ImportMeta_MTime) {
+		return fmt.Errorf("%w: %s", ErrReferenceChanged, localPath)
+	}
+	return nil
+}
+
+func readReference(file *WaveFile, offset int64, size int64) ([]byte, error) {
+	err := checkReference(file)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := os.Open(file.Meta[ImportMeta_Path].(string))
+	if err != nil {
+		return nil, fmt.Errorf("error reading referenced file: %w", err)
+	}
+	defer fd.Close()
+	data := make([]byte, size)
+	n, err := fd.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error reading referenced file: %w", err)
+	}
+	return data[:n], nil
+}
+
+func getMetaInt64(meta wshrpc.FileMeta, key string) int64 {
+	switch val := meta[key].(type) {
+	case int64:
+		return val
+	case float64:
+		return int64(val)
+	}
+	return 0
+}
@@ -0,0 +1,270 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
+)
+
+// the hash of a part (its blob) is the checksum of the part's data, it is checked when the part is read.  a part
+// that is corrupt (its blob is missing, can not be decompressed, or does not match the checksum) is moved to
+// db_file_quarantine (until the file is deleted) and reads as zeros, so one bad part does not make the whole file
+// unreadable.  the parts that can not be decrypted are not quarantined (it can be the wrong key), the read fails.
+//
+// Check is the fsck of the filestore: it checks every blob, the parts that refer to missing blobs, the blob
+// reference counts, the parts and indexes of files that do not exist, and the sqlite db itself, and can repair
+// what it finds (everything but the db and the blobs that can not be decrypted).
+
+const checkBatchSize = 100
+
+var errCorruptPart = errors.New("corrupt part")
+
+const (
+	CheckProblem_CorruptBlob = "corruptblob" // a blob that does not match its checksum (or can not be decompressed)
+	CheckProblem_Unreadable  = "unreadable"  // a blob that can not be decrypted (no key, or the wrong key)
+	CheckProblem_CorruptPart = "corruptpart" // a part with its data in db_file_data that can not be decompressed
+	CheckProblem_MissingBlob = "missingblob" // a part that refers to a blob that does not exist
+	CheckProblem_RefCount    = "refcount"    // a blob's refcount is not the number of parts that refer to it
+	CheckProblem_Orphan      = "orphan"      // parts, line index or text index of a file that does not exist
+	CheckProblem_Db          = "db"          // from sqlite's quick_check
+)
+
+type CheckProblem struct {
+	Type     string
+	ZoneId   string
+	Name     string
+	PartIdx  int
+	Detail   string
+	Repaired bool
+}
+
+type CheckResult struct {
+	NumBlobs       int
+	NumParts       int
+	NumQuarantined int // the parts in quarantine (from before, and the ones quarantined by this check)
+	Problems       []*CheckProblem
+}
+
+// decodes the data of a part and checks it against its hash, the error wraps errCorruptPart if the part is corrupt
+func decodePart(part *dbFilePart) ([]byte, error) {
+	if part.Hash == "" {
+		return decompressPart(part.Compression, part.Data)
+	}
+	if !part.HasBlob {
+		return nil, fmt.Errorf("%w: missing blob %s", errCorruptPart, part.Hash)
+	}
+	data, err := decodeBlob(part.Hash, part.Compression, part.Encryption, part.Data)
+	if err != nil {
+		return nil, err
+	}
+	if blobHash(part.Encryption, data) != part.Hash {
+		return nil, fmt.Errorf("%w: data does not match the checksum %s", errCorruptPart, part.Hash)
+	}
+	return data, nil
+}
+
+// moves the part (and a copy of its blob) to the quarantine, the part is deleted
+func txQuarantinePart(tx *TxWrap, zoneId string, name string, partIdx int, reason string) {
+	query := `INSERT INTO db_file_quarantine (zoneid, name, partidx, hash, data, compression, encryption, reason, ts)
+              SELECT d.zoneid, d.name, d.partidx, d.hash,
+                     CASE WHEN d.hash = '' THEN d.data ELSE COALESCE(b.data, x'') END,
+                     CASE WHEN d.hash = '' THEN d.compression ELSE COALESCE(b.compression, '') END,
+                     COALESCE(b.encryption, ''), ?, ?
+              FROM db_file_data d LEFT JOIN db_file_blob b ON b.hash = d.hash
+              WHERE d.zoneid = ? AND d.name = ? AND d.partidx = ?`
+	tx.Exec(query, reason, time.Now().UnixMilli(), zoneId, name, partIdx)
+	txDeleteParts(tx, zoneId, name, dbutil.QuickJsonArr([]int{partIdx}))
+}
+
+func checkBlobs(ctx context.Context, repair bool, result *CheckResult) error {
+	lastHash := ""
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var numBlobs int
+		err := WithTx(ctx, func(tx *TxWrap) error {
+			var blobs []*dbBlob
+			// the offloaded blobs are checked when they are fetched from the replica
+			query := "SELECT hash, data, compression, encryption, refcount FROM db_file_blob WHERE hash > ? AND offloaded = 0 ORDER BY hash LIMIT ?"
+			tx.Select(&blobs, query, lastHash, checkBatchSize)
+			numBlobs = len(blobs)
+			for _, blob := range blobs {
+				lastHash = blob.Hash
+				_, err := decodePart(&dbFilePart{Hash: blob.Hash, HasBlob: true, Data: blob.Data, Compression: blob.Compression, Encryption: blob.Encryption})
+				if err == nil {
+					continue
+				}
+				problemType := CheckProblem_CorruptBlob
+				if !errors.Is(err, errCorruptPart) {
+					problemType = CheckProblem_Unreadable
+				}
+				var parts []*dbFilePart
+				query = "SELECT zoneid, name, partidx FROM db_file_data WHERE hash = ?"
+				tx.Select(&parts, query, blob.Hash)
+				if len(parts) == 0 {
+					result.Problems = append(result.Problems, &CheckProblem{Type: problemType, Detail: err.Error()})
+				}
+				for _, part := range parts {
+					problem := &CheckProblem{Type: problemType, ZoneId: part.ZoneId, Name: part.Name, PartIdx: part.PartIdx, Detail: err.Error()}
+					if repair && problemType == CheckProblem_CorruptBlob {
+						txQuarantinePart(tx, part.ZoneId, part.Name, part.PartIdx, err.Error())
+						problem.Repaired = true
+					}
+					result.Problems = append(result.Problems, problem)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		result.NumBlobs += numBlobs
+		if numBlobs < checkBatchSize {
+			return nil
+		}
+	}
+}
+
+// the parts with their data in db_file_data (written before dedup, they are moved to blobs after startup)
+func checkInlineParts(ctx context.Context, repair bool, result *CheckResult) error {
+	var lastRowId int64
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var numParts int
+		err := WithTx(ctx, func(tx *TxWrap) error {
+			var parts []struct {
+				RowId int64
+				dbFilePart
+			}
+			query := `SELECT rowid, zoneid, name, partidx, data, compression FROM db_file_data
+                      WHERE rowid > ? AND hash = '' AND length(data) > 0 ORDER BY rowid LIMIT ?`
+			tx.Select(&parts, query, lastRowId, checkBatchSize)
+			numParts = len(parts)
+			for _, part := range parts {
+				lastRowId = part.RowId
+				_, err := decodePart(&part.dbFilePart)
+				if err == nil {
+					continue
+				}
+				problem := &CheckProblem{Type: CheckProblem_CorruptPart, ZoneId: part.ZoneId, Name: part.Name, PartIdx: part.PartIdx, Detail: err.Error()}
+				if repair && errors.Is(err, errCorruptPart) {
+					txQuarantinePart(tx, part.ZoneId, part.Name, part.PartIdx, err.Error())
+					problem.Repaired = true
+				}
+				result.Problems = append(result.Problems, problem)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if numParts < checkBatchSize {
+			return nil
+		}
+	}
+}
+
+// the checks of the references between the tables (missing blobs, refcounts, orphans) and of the db
+func checkReferences(ctx context.Context, repair bool, result *CheckResult) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		var missing []*dbFilePart
+		query := `SELECT d.zoneid, d.name, d.partidx, d.hash FROM db_file_data d LEFT JOIN db_file_blob b ON b.hash = d.hash
+                  WHERE d.hash != '' AND b.hash IS NULL`
+		tx.Select(&missing, query)
+		for _, part := range missing {
+			problem := &CheckProblem{Type: CheckProblem_MissingBlob, ZoneId: part.ZoneId, Name: part.Name, PartIdx: part.PartIdx, Detail: part.Hash}
+			if repair {
+				txQuarantinePart(tx, part.ZoneId, part.Name, part.PartIdx, "missing blob "+part.Hash)
+				problem.Repaired = true
+			}
+			result.Problems = append(result.Problems, problem)
+		}
+		var orphans []struct {
+			ZoneId string
+			Name   string
+		}
+		query = `SELECT zoneid, name FROM db_file_data
+                 UNION SELECT zoneid, name FROM db_file_lineidx
+                 UNION SELECT zoneid, name FROM db_file_textidx
+                 EXCEPT SELECT zoneid, name FROM db_wave_file`
+		tx.Select(&orphans, query)
+		for _, orphan := range orphans {
+			problem := &CheckProblem{Type: CheckProblem_Orphan, ZoneId: orphan.ZoneId, Name: orphan.Name}
+			if repair {
+				txDeleteParts(tx, orphan.ZoneId, orphan.Name, "")
+				problem.Repaired = true
+			}
+			result.Problems = append(result.Problems, problem)
+		}
+		var badCounts []struct {
+			Hash     string
+			RefCount int
+			NumParts int
+		}
+		query = `SELECT hash, refcount, numparts FROM
+                   (SELECT b.hash, b.refcount, (SELECT COUNT(*) FROM db_file_data d WHERE d.hash = b.hash) AS numparts FROM db_file_blob b)
+                 WHERE refcount != numparts`
+		tx.Select(&badCounts, query)
+		for _, badCount := range badCounts {
+			result.Problems = append(result.Problems, &CheckProblem{
+				Type:     CheckProblem_RefCount,
+				Detail:   fmt.Sprintf("blob %s has refcount %d, %d parts", badCount.Hash, badCount.RefCount, badCount.NumParts),
+				Repaired: repair,
+			})
+		}
+		if repair && len(badCounts) > 0 {
+			tx.Exec(`UPDATE db_file_blob SET refcount = (SELECT COUNT(*) FROM db_file_data d WHERE d.hash = db_file_blob.hash)`)
+			tx.Exec("DELETE FROM db_file_blob WHERE refcount <= 0")
+		}
+		var dbErrors []string
+		tx.Select(&dbErrors, "PRAGMA quick_check")
+		for _, dbError := range dbErrors {
+			if dbError != "ok" {
+				result.Problems = append(result.Problems, &CheckProblem{Type: CheckProblem_Db, Detail: dbError})
+			}
+		}
+		result.NumParts = tx.GetInt("SELECT COUNT(*) FROM db_file_data")
+		result.NumQuarantined = tx.GetInt("SELECT COUNT(*) FROM db_file_quarantine")
+		return nil
+	})
+}
+
+// checks the whole filestore, with repair the problems that can be repaired are (the corrupt parts are
+// quarantined).  the cache is flushed first, so the check is of everything that was written.
+func (s *FileStore) Check(ctx context.Context, repair bool) (*CheckResult, error) {
+	_, err := s.FlushCache(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error flushing cache: %w", err)
+	}
+	result := &CheckResult{}
+	err = checkBlobs(ctx, repair, result)
+	if err != nil {
+		return nil, fmt.Errorf("error checking blobs: %w", err)
+	}
+	err = checkInlineParts(ctx, repair, result)
+	if err != nil {
+		return nil, fmt.Errorf("error checking parts: %w", err)
+	}
+	err = checkReferences(ctx, repair, result)
+	if err != nil {
+		return nil, fmt.Errorf("error checking references: %w", err)
+	}
+	if repair {
+		// the quarantined parts read as zeros now
+		dropAllCachedParts()
+	}
+	if len(result.Problems) > 0 {
+		log.Printf("filestore check: %d problems (repair:%v)\n", len(result.Problems), repair)
+	}
+	return result, nil
+}
@@ -0,0 +1,323 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// the append api is meant for terminal output.  appends go to the cache and are committed to the db by the
+// flusher (one transaction per file every DefaultFlushTime), so a burst of small writes costs one db write, Sync
+// commits a file right away.
+//
+// files made with the LineIndex opt also keep a line index: the number of newlines in each part is written to
+// db_file_lineidx when the part is flushed, and the file counts all the newlines written to it (NumLines).  line
+// n starts after the nth newline (line 0 at offset 0), and the numbers do not change when a circular file wraps
+// around (the first lines are dropped).  the start line of each part is counted back from the end of the file, so
+// the index only needs to be written for the parts that changed.
+
+var newlineBytes = []byte{'\n'}
+
+type lineIndexPart struct {
+	PartIdx    int
+	FileOffset int64 // the file offset of the start of the part
+	NumLines   int64
+	StartLine  int64 // not stored, see makeLineIndex
+}
+
+// lines read with ReadLines
+type FileLines struct {
+	StartLine int64  `json:"startline"`
+	Offset    int64  `json:"offset"`   // the file offset of the data
+	NumLines  int64  `json:"numlines"` // the lines in the file (the next line to be written)
+	Data      []byte `json:"data"`
+}
+
+func countLines(data []byte) int64 {
+	return int64(bytes.Count(data, newlineBytes))
+}
+
+// the file offset of the data in a part, for circular files the parts are reused as the file wraps around
+func (f *WaveFile) partFileOffset(partIdx int) int64 {
+	if !f.Opts.Circular || f.Size == 0 {
+		return int64(partIdx) * partDataSize
+	}
+	maxPart := int(f.Opts.MaxSize / partDataSize)
+	lastPart := int((f.Size - 1) / partDataSize)
+	logicalPart := lastPart - ((lastPart-partIdx)%maxPart+maxPart)%maxPart
+	return int64(logicalPart) * partDataSize
+}
+
+func makeLineIndexPart(file *WaveFile, dce *DataCacheEntry) *lineIndexPart {
+	fileOffset := file.partFileOffset(dce.PartIdx)
+	// a reused part can have data from before the file wrapped past the end of the file
+	validLen := max(0, min(int64(len(dce.Data)), file.Size-fileOffset))
+	return &lineIndexPart{PartIdx: dce.PartIdx, FileOffset: fileOffset, NumLines: countLines(dce.Data[:validLen])}
+}
+
+// the index of the parts of the file that are still in the file (sorted by offset), the parts in dataEntries (the
+// cache) override the parts in the db.  the index stops at a part that is missing.
+func makeLineIndex(file *WaveFile, dbParts []*lineIndexPart, dataEntries map[int]*DataCacheEntry) []*lineIndexPart {
+	if file.Size == 0 {
+		return nil
+	}
+	byOffset := make(map[int64]*lineIndexPart)
+	for _, part := range dbParts {
+		if part.FileOffset != file.partFileOffset(part.PartIdx) {
+			// the part has been reused (the new data is in the cache)
+			continue
+		}
+		partCopy := *part
+		byOffset[part.FileOffset] = &partCopy
+	}
+	for _, dce := range dataEntries {
+		part := makeLineIndexPart(file, dce)
+		byOffset[part.FileOffset] = part
+	}
+	firstOffset := file.DataStartIdx() - file.DataStartIdx()%partDataSize
+	nextStartLine := file.NumLines
+	var rtn []*lineIndexPart
+	for offset := ((file.Size - 1) / partDataSize) * partDataSize; offset >= firstOffset; offset -= partDataSize {
+		part := byOffset[offset]
+		if part == nil {
+			break
+		}
+		part.StartLine = nextStartLine - part.NumLines
+		nextStartLine = part.StartLine
+		rtn = append(rtn, part)
+	}
+	slices.Reverse(rtn)
+	return rtn
+}
+
+func (entry *CacheEntry) loadLineIndex(ctx context.Context, file *WaveFile) ([]*lineIndexPart, error) {
+	if !file.Opts.LineIndex {
+		return nil, fmt.Errorf("file %s:%s does not have a line index", entry.ZoneId, entry.Name)
+	}
+	dbParts, err := dbGetLineIndex(ctx, entry.ZoneId, entry.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting line index: %w", err)
+	}
+	return makeLineIndex(file, dbParts, entry.DataEntries), nil
+}
+
+// the data before the first part of the index, that is the start of a circular file when it shares its part with
+// the end of the file (or all the data if the index is missing a part).  returns the line of the start of the data
+// (the first line that is at least partly in the file).
+func (entry *CacheEntry) readIndexHead(ctx context.Context, file *WaveFile, index []*lineIndexPart) (int64, []byte, error) {
+	headEnd := file.Size
+	headEndLine := file.NumLines
+	if len(index) > 0 {
+		headEnd = index[0].FileOffset
+		headEndLine = index[0].StartLine
+	}
+	if headEnd <= file.DataStartIdx() {
+		return headEndLine, nil, nil
+	}
+	_, head, err := entry.readAt(ctx, file.DataStartIdx(), headEnd-file.DataStartIdx(), false)
+	if err != nil {
+		return 0, nil, err
+	}
+	return headEndLine - countLines(head), head, nil
+}
+
+// the offset after the nth newline in data, -1 if there are fewer newlines
+func newlineEndPos(data []byte, n int64) int {
+	for pos := 0; pos < len(data); {
+		idx := bytes.IndexByte(data[pos:], '\n')
+		if idx < 0 {
+			break
+		}
+		pos += idx + 1
+		n--
+		if n == 0 {
+			return pos
+		}
+	}
+	return -1
+}
+
+// the offset of the start of the line, clamped to the data in the file
+func (entry *CacheEntry) lineOffset(ctx context.Context, file *WaveFile, index []*lineIndexPart, line int64) (int64, error) {
+	if line > file.NumLines {
+		return file.Size, nil
+	}
+	if len(index) == 0 || line <= index[0].StartLine {
+		headStartLine, head, err := entry.readIndexHead(ctx, file, index)
+		if err != nil {
+			return 0, err
+		}
+		if line <= headStartLine {
+			return file.DataStartIdx(), nil
+		}
+		pos := newlineEndPos(head, line-headStartLine)
+		if pos < 0 {
+			return 0, fmt.Errorf("line %d not found (the line index is out of date)", line)
+		}
+		return file.DataStartIdx() + int64(pos), nil
+	}
+	partPos, found := slices.BinarySearchFunc(index, line, func(part *lineIndexPart, line int64) int {
+		// the part with the (line)th newline
+		if part.StartLine+part.NumLines < line {
+			return -1
+		}
+		if part.StartLine >= line {
+			return 1
+		}
+		return 0
+	})
+	if !found {
+		return 0, fmt.Errorf("line %d not found in the line index", line)
+	}
+	part := index[partPos]
+	dataEntries, err := entry.loadDataPartsForRead(ctx, file, []int{part.PartIdx})
+	if err != nil {
+		return 0, err
+	}
+	dce := dataEntries[part.PartIdx]
+	if dce == nil {
+		return 0, fmt.Errorf("missing data for part %d", part.PartIdx)
+	}
+	data := dce.Data[:max(0, min(int64(len(dce.Data)), file.Size-part.FileOffset))]
+	pos := newlineEndPos(data, line-part.StartLine)
+	if pos < 0 {
+		return 0, fmt.Errorf("line %d not found in part %d (the line index is out of date)", line, part.PartIdx)
+	}
+	return max(file.DataStartIdx(), part.FileOffset+int64(pos)), nil
+}
+
+// appends the data and returns its offset (the size of the file before the append)
+func (s *FileStore) Append(ctx context.Context, zoneId string, name string, data []byte) (int64, error) {
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
+		err := entry.loadFileIntoCache(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if entry.File.IsReference() {
+			return 0, errWriteReference
+		}
+		partMap := entry.File.computePartMap(entry.File.Size, int64(len(data)))
+		incompleteParts := incompletePartsFromMap(partMap)
+		if len(incompleteParts) > 0 {
+			err = entry.loadDataPartsIntoCache(ctx, incompleteParts)
+			if err != nil {
+				return 0, err
+			}
+		}
+		offset := entry.File.Size
+		entry.writeAt(offset, data, false)
+		return offset, nil
+	})
+}
+
+// commits the file's writes to the db now (instead of on the next flush)
+func (s *FileStore) Sync(ctx context.Context, zoneId string, name string) error {
+	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+		return entry.flushToDB(ctx, false)
+	})
+}
+
+// the offset of the start of the line, clamped to the data in the file (the start of a circular file if the line
+// has been dropped, the end of the file if it has not been written yet)
+func (s *FileStore) LineOffset(ctx context.Context, zoneId string, name string, line int64) (int64, error) {
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
+		file, err := entry.loadFileForRead(ctx)
+		if err != nil {
+			return 0, err
+		}
+		index, err := entry.loadLineIndex(ctx, file)
+		if err != nil {
+			return 0, err
+		}
+		return entry.lineOffset(ctx, file, index, line)
+	})
+}
+
+// reads numLines lines starting at startLine, or to the end of the file if numLines is 0 (including the last line
+// if it does not end with a newline yet).  a negative startLine counts back from the last newline (-10 is the last
+// 10 lines).
+func (s *FileStore) ReadLines(ctx context.Context, zoneId string, name string, startLine int64, numLines int64) (*FileLines, error) {
+	if numLines < 0 {
+		return nil, fmt.Errorf("numlines must be non-negative")
+	}
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (*FileLines, error) {
+		file, err := entry.loadFileForRead(ctx)
+		if err != nil {
+			return nil, err
+		}
+		index, err := entry.loadLineIndex(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		if startLine < 0 {
+			startLine = max(0, file.NumLines+startLine)
+		}
+		endLine := startLine + numLines
+		// the lines dropped from a circular file are not read
+		firstLine, _, err := entry.readIndexHead(ctx, file, index)
+		if err != nil {
+			return nil, err
+		}
+		startLine = max(startLine, firstLine)
+		startOffset, err := entry.lineOffset(ctx, file, index, startLine)
+		if err != nil {
+			return nil, err
+		}
+		endOffset := file.Size
+		if numLines > 0 {
+			endOffset, err = entry.lineOffset(ctx, file, index, endLine)
+			if err != nil {
+				return nil, err
+			}
+		}
+		rtn := &FileLines{StartLine: startLine, Offset: startOffset, NumLines: file.NumLines}
+		if endOffset > startOffset {
+			rtn.Offset, rtn.Data, err = entry.readAt(ctx, startOffset, endOffset-startOffset, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return rtn, nil
+	})
+}
+
+// turns on the line index for an existing file (the lines in the file are counted, lines that were dropped from a
+// circular file are not)
+func (s *FileStore) EnableLineIndex(ctx context.Context, zoneId string, name string) error {
+	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+		err := entry.loadFileIntoCache(ctx)
+		if err != nil {
+			return err
+		}
+		file := entry.File
+		if file.Opts.LineIndex {
+			return nil
+		}
+		// all the parts are written (with their line counts) on the flush below
+		var partIdxs []int
+		if file.Size > 0 {
+			firstOffset := file.DataStartIdx() - file.DataStartIdx()%partDataSize
+			for offset := firstOffset; offset < file.Size; offset += partDataSize {
+				partIdxs = append(partIdxs, file.partIdxAtOffset(offset))
+			}
+		}
+		err = entry.loadDataPartsIntoCache(ctx, partIdxs)
+		if err != nil {
+			return err
+		}
+		file.NumLines = 0
+		for _, dce := range entry.DataEntries {
+			file.NumLines += makeLineIndexPart(file, dce).NumLines
+		}
+		file.Opts.LineIndex = true
+		err = dbUpdateFileOpts(ctx, zoneId, name, file.Opts)
+		if err != nil {
+			return err
+		}
+		return entry.flushToDB(ctx, false)
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+)
+
+// viewers (hex, pdf, table) read the windows they display with ReadRanges, the parts of all the ranges are read
+// from the db at once.  a read-ahead hint (the bytes after the last range that will be read next) reads those parts
+// in the background into the read cache (see blockstore_readcache.go).
+
+const ReadRangesMax = 256
+const ReadRangesMaxSize = 16 * 1024 * 1024
+const ReadAheadMax = 4 * 1024 * 1024
+const readAheadTimeout = 10 * time.Second
+
+type ReadRange struct {
+	Offset int64
+	Size   int64
+}
+
+type RangeData struct {
+	Offset int64 // where the data starts (after the offset of the range if the front of the file was dropped)
+	Data   []byte
+}
+
+var readAheadLock = &sync.Mutex{}
+var readAheadPending = make(map[cacheKey]bool)
+
+// reads the ranges of the file (a range past the end of the file is cut), and reads readAhead bytes after the end
+// of the last range into the read-ahead cache in the background
+func (s *FileStore) ReadRanges(ctx context.Context, zoneId string, name string, ranges []ReadRange, readAhead int64) (*WaveFile, []RangeData, error) {
+	if len(ranges) > ReadRangesMax {
+		return nil, nil, fmt.Errorf("too many ranges (%d, the max is %d)", len(ranges), ReadRangesMax)
+	}
+	var totalSize int64
+	for _, r := range ranges {
+		if r.Offset < 0 || r.Size < 0 {
+			return nil, nil, fmt.Errorf("invalid range %d:%d", r.Offset, r.Size)
+		}
+		totalSize += r.Size
+	}
+	if totalSize > ReadRangesMaxSize {
+		return nil, nil, fmt.Errorf("ranges are too large (%d bytes, the max is %d)", totalSize, ReadRangesMaxSize)
+	}
+	var rtnFile *WaveFile
+	var rtnRanges []RangeData
+	var readAheadOffset int64
+	err := withLock(s, zoneId, name, func(entry *CacheEntry) error {
+		file, err := entry.loadFileForRead(ctx)
+		if err != nil {
+			return err
+		}
+		rtnFile = file.DeepCopy()
+		clamped := make([]ReadRange, len(ranges))
+		partMap := make(map[int]int)
+		for idx, r := range ranges {
+			offset, size, _ := file.clampRead(r.Offset, r.Size)
+			clamped[idx] = ReadRange{Offset: offset, Size: size}
+			readAheadOffset = max(readAheadOffset, offset+max(size, 0))
+			if size > 0 && !file.IsReference() {
+				for partIdx := range file.computePartMap(offset, size) {
+					partMap[partIdx] = 1
+				}
+			}
+		}
+		dataEntryMap, err := entry.loadDataPartsForRead(ctx, file, getPartIdxsFromMap(partMap))
+		if err != nil {
+			return err
+		}
+		for _, r := range clamped {
+			var data []byte
+			if file.IsReference() && r.Size > 0 {
+				data, err = readReference(file, r.Offset, r.Size)
+				if err != nil {
+					return err
+				}
+			} else {
+				data = file.readFromParts(dataEntryMap, r.Offset, r.Size)
+			}
+			rtnRanges = append(rtnRanges, RangeData{Offset: r.Offset, Data: data})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if readAhead > 0 && readAheadOffset < rtnFile.Size && !rtnFile.IsReference() {
+		s.startReadAhead(zoneId, name, readAheadOffset, min(readAhead, ReadAheadMax))
+	}
+	return rtnFile, rtnRanges, nil
+}
+
+// reads the parts of the range into the read cache in the background (unless a read-ahead of the file is
+// running)
+func (s *FileStore) startReadAhead(zoneId string, name string, offset int64, size int64) {
+	key := cacheKey{ZoneId: zoneId, Name: name}
+	readAheadLock.Lock()
+	if readAheadPending[key] {
+		readAheadLock.Unlock()
+		return
+	}
+	readAheadPending[key] = true
+	readAheadLock.Unlock()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("filestore:readAhead", recover())
+		}()
+		defer func() {
+			readAheadLock.Lock()
+			delete(readAheadPending, key)
+			readAheadLock.Unlock()
+		}()
+		ctx, cancelFn := context.WithTimeout(context.Background(), readAheadTimeout)
+		defer cancelFn()
+		withLock(s, zoneId, name, func(entry *CacheEntry) error {
+			file, err := entry.loadFileForRead(ctx)
+			if err != nil {
+				return err
+			}
+			offset, size, done := file.clampRead(offset, size)
+			if done || size <= 0 {
+				return nil
+			}
+			parts := getPartIdxsFromMap(file.computePartMap(offset, size))
+			parts = prunePartsWithCache(entry.DataEntries, parts)
+			parts = prunePartsWithCache(getCachedParts(file, parts), parts)
+			dbParts, err := dbGetFileParts(ctx, zoneId, name, parts)
+			if err != nil {
+				return err
+			}
+			cacheStats.readAheadParts.Add(int64(len(dbParts)))
+			putCachedParts(file, dbParts, true)
+			return nil
+		})
+	}()
+}
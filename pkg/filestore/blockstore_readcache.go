@@ -0,0 +1,193 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// the parts read from the db are kept in a read cache (up to readCacheMaxBytes, see SetReadCache) that is used by
+// all the reads of a file.  the policy is which parts are kept and evicted: lru (the least recently read part is
+// evicted), lfu (the least often read of the readCacheLfuSample least recently read parts is evicted, so the parts
+// that are read again and again stay when a large file is read through once), or readahead (only the parts read
+// ahead for viewers are kept, lru).  the parts of a file are dropped when it changes (notifyWatchers) or its parts
+// are evicted, and they are stamped with the modts and size of the file they were read from (a part read from
+// another version of the file is not used).  the hits, misses and evictions are counted, see GetCacheStats.
+
+const (
+	ReadCachePolicy_Lru       = "lru"
+	ReadCachePolicy_Lfu       = "lfu"
+	ReadCachePolicy_ReadAhead = "readahead"
+)
+
+const DefaultReadCacheBytes = 16 * 1024 * 1024
+const readCacheLfuSample = 16
+
+type cachedPart struct {
+	Key     cacheKey
+	PartIdx int
+	Dce     *DataCacheEntry
+	ModTs   int64
+	Size    int64
+	Reads   int64
+}
+
+var readCacheLock = &sync.Mutex{}
+var readCacheLru = list.New() // of *cachedPart, the most recently read is in front
+var readCacheFiles = make(map[cacheKey]map[int]*list.Element)
+var readCacheMaxBytes int64 = DefaultReadCacheBytes
+var readCachePolicy = ReadCachePolicy_Lru
+
+var cacheStats struct {
+	hits           atomic.Int64
+	misses         atomic.Int64
+	evictions      atomic.Int64
+	readAheadParts atomic.Int64
+}
+
+type CacheStats struct {
+	Hits           int64 // parts read from memory (the writes that are not flushed, or the read cache)
+	Misses         int64 // parts read from the db
+	Evictions      int64 // parts evicted from the read cache to keep it under its size
+	ReadAheadParts int64 // parts read ahead for viewers
+	CachedParts    int
+	CachedBytes    int64
+	MaxBytes       int64
+	Policy         string
+	OpenFiles      int // files in the write cache (being read or written, or with writes that are not flushed)
+	DirtyFiles     int // files with writes that are not flushed
+}
+
+// sets the size (0 to not keep the parts read from the db) and policy of the read cache, the policy is lru if empty
+func SetReadCache(maxBytes int64, policy string) error {
+	switch policy {
+	case "":
+		policy = ReadCachePolicy_Lru
+	case ReadCachePolicy_Lru, ReadCachePolicy_Lfu, ReadCachePolicy_ReadAhead:
+	default:
+		return fmt.Errorf("invalid cache policy %q (lru, lfu or readahead)", policy)
+	}
+	if maxBytes < 0 {
+		return fmt.Errorf("invalid cache size %d", maxBytes)
+	}
+	readCacheLock.Lock()
+	defer readCacheLock.Unlock()
+	readCacheMaxBytes = maxBytes
+	readCachePolicy = policy
+	evictCachedParts()
+	return nil
+}
+
+func (s *FileStore) GetCacheStats() CacheStats {
+	rtn := CacheStats{
+		Hits:           cacheStats.hits.Load(),
+		Misses:         cacheStats.misses.Load(),
+		Evictions:      cacheStats.evictions.Load(),
+		ReadAheadParts: cacheStats.readAheadParts.Load(),
+		DirtyFiles:     len(s.getDirtyCacheKeys()),
+	}
+	s.Lock.Lock()
+	rtn.OpenFiles = len(s.Cache)
+	s.Lock.Unlock()
+	readCacheLock.Lock()
+	defer readCacheLock.Unlock()
+	rtn.CachedParts = readCacheLru.Len()
+	rtn.CachedBytes = int64(readCacheLru.Len()) * partDataSize
+	rtn.MaxBytes = readCacheMaxBytes
+	rtn.Policy = readCachePolicy
+	return rtn
+}
+
+// the parts of the file in the read cache (parts read from another version of the file are dropped)
+func getCachedParts(file *WaveFile, parts []int) map[int]*DataCacheEntry {
+	readCacheLock.Lock()
+	defer readCacheLock.Unlock()
+	fileParts := readCacheFiles[cacheKey{ZoneId: file.ZoneId, Name: file.Name}]
+	if len(fileParts) == 0 {
+		return nil
+	}
+	rtn := make(map[int]*DataCacheEntry)
+	for _, partIdx := range parts {
+		elem := fileParts[partIdx]
+		if elem == nil {
+			continue
+		}
+		part := elem.Value.(*cachedPart)
+		if part.ModTs != file.ModTs || part.Size != file.Size {
+			removeCachedPart(elem)
+			continue
+		}
+		part.Reads++
+		readCacheLru.MoveToFront(elem)
+		rtn[partIdx] = part.Dce
+	}
+	return rtn
+}
+
+// readAhead is set for the parts read ahead for viewers (the other parts are not kept with the readahead policy)
+func putCachedParts(file *WaveFile, parts map[int]*DataCacheEntry, readAhead bool) {
+	readCacheLock.Lock()
+	defer readCacheLock.Unlock()
+	if len(parts) == 0 || readCacheMaxBytes < partDataSize || (readCachePolicy == ReadCachePolicy_ReadAhead && !readAhead) {
+		return
+	}
+	key := cacheKey{ZoneId: file.ZoneId, Name: file.Name}
+	for partIdx, dce := range parts {
+		if elem := readCacheFiles[key][partIdx]; elem != nil {
+			removeCachedPart(elem)
+		}
+		if readCacheFiles[key] == nil {
+			readCacheFiles[key] = make(map[int]*list.Element)
+		}
+		part := &cachedPart{Key: key, PartIdx: partIdx, Dce: dce, ModTs: file.ModTs, Size: file.Size, Reads: 1}
+		readCacheFiles[key][partIdx] = readCacheLru.PushFront(part)
+	}
+	evictCachedParts()
+}
+
+// must hold readCacheLock
+func evictCachedParts() {
+	maxParts := int(readCacheMaxBytes / partDataSize)
+	for readCacheLru.Len() > maxParts {
+		victim := readCacheLru.Back()
+		if readCachePolicy == ReadCachePolicy_Lfu {
+			elem := victim
+			for idx := 0; idx < readCacheLfuSample && elem != nil; idx++ {
+				if elem.Value.(*cachedPart).Reads < victim.Value.(*cachedPart).Reads {
+					victim = elem
+				}
+				elem = elem.Prev()
+			}
+		}
+		removeCachedPart(victim)
+		cacheStats.evictions.Add(1)
+	}
+}
+
+// must hold readCacheLock
+func removeCachedPart(elem *list.Element) {
+	part := readCacheLru.Remove(elem).(*cachedPart)
+	delete(readCacheFiles[part.Key], part.PartIdx)
+	if len(readCacheFiles[part.Key]) == 0 {
+		delete(readCacheFiles, part.Key)
+	}
+}
+
+func dropCachedParts(zoneId string, name string) {
+	readCacheLock.Lock()
+	defer readCacheLock.Unlock()
+	for _, elem := range readCacheFiles[cacheKey{ZoneId: zoneId, Name: name}] {
+		removeCachedPart(elem)
+	}
+}
+
+func dropAllCachedParts() {
+	readCacheLock.Lock()
+	defer readCacheLock.Unlock()
+	readCacheLru.Init()
+	readCacheFiles = make(map[cacheKey]map[int]*list.Element)
+}
@@ -0,0 +1,488 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync/atomic"
+
+	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
+)
+
+// the blobs and the files can be replicated to an object store (pkg/filereplica has the s3 side and runs the sync).
+// the replica is write-behind: the blobs that are not replicated yet are uploaded (GetUnreplicatedBlobs), then the
+// files whose blobs are all replicated get a manifest (GetReplicaFileChanges), and the manifests of deleted files
+// are deleted.  a file can be restored from its manifest, on this machine or another one with the same key
+// (RestoreFile).
+//
+// everything in the replica is encrypted with the replica key (aes-256-gcm, the object key is the additional
+// data), a blob is named by the hmac of its data (keyed with the replica key), a manifest by its zone and the hmac
+// of the file name.  the blobs are named by their data (not their local hash), so the names do not change when the
+// local encryption changes.
+//
+// the replicated blobs of files that have not been modified for a while can be offloaded (OffloadBlobs): their
+// data is dropped from the db and is fetched from the replica when it is read.  RehydrateBlobs brings them back.
+// the blobs of deleted files are not deleted from the replica.
+
+const (
+	ReplicaBlobPrefix = "blobs/"
+	ReplicaFilePrefix = "files/"
+)
+
+const restoreBatchSize = 16
+
+var ErrNoReplica = errors.New("replication is not configured")
+
+// fetches an object from the replica
+type ReplicaFetchFn func(ctx context.Context, key string) ([]byte, error)
+
+type replicaState struct {
+	key     []byte
+	aead    cipher.AEAD
+	fetchFn ReplicaFetchFn
+}
+
+var replica atomic.Pointer[replicaState]
+
+// an object to put in the replica (a blob or a manifest), or to delete
+type ReplicaObject struct {
+	Key  string
+	Data []byte
+
+	hash   string // of the blob
+	zoneId string // of the manifest
+	name   string
+	modTs  int64
+	size   int64
+}
+
+// the manifest of a replicated file, the parts are the names of their blobs ("" for an empty part)
+type ReplicaFile struct {
+	File     *WaveFile      `json:"file"`
+	PartSize int64          `json:"partsize"`
+	Parts    map[int]string `json:"parts"`
+}
+
+// sets the replica key (nil to turn the replica off) and the function that fetches objects from the replica
+func SetReplica(key []byte, fetchFn ReplicaFetchFn) error {
+	if key == nil {
+		replica.Store(nil)
+		return nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("error making cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("error making cipher: %w", err)
+	}
+	replica.Store(&replicaState{key: key, aead: aead, fetchFn: fetchFn})
+	return nil
+}
+
+func getReplica() (*replicaState, error) {
+	state := replica.Load()
+	if state == nil {
+		return nil, ErrNoReplica
+	}
+	return state, nil
+}
+
+func (state *replicaState) hmacHex(data []byte) string {
+	mac := hmac.New(sha256.New, state.key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (state *replicaState) fileKey(zoneId string, name string) string {
+	return ReplicaFilePrefix + zoneId + "/" + state.hmacHex([]byte(name))[:32]
+}
+
+// an object is the length of the compression, the compression, the nonce and the sealed (compressed) data
+func (state *replicaState) encodeObject(key string, data []byte) ([]byte, error) {
+	compression, stored := compressPart(data)
+	nonceSize := state.aead.NonceSize()
+	obj := make([]byte, 1+len(compression)+nonceSize, 1+len(compression)+nonceSize+len(stored)+state.aead.Overhead())
+	obj[0] = byte(len(compression))
+	copy(obj[1:], compression)
+	nonce := obj[1+len(compression):]
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("error making nonce: %w", err)
+	}
+	return state.aead.Seal(obj, nonce, stored, []byte(key)), nil
+}
+
+// decrypts an object, returns its compression and the compressed data
+func (state *replicaState) openObject(key string, obj []byte) (string, []byte, error) {
+	nonceSize := state.aead.NonceSize()
+	if len(obj) < 1 || len(obj) < 1+int(obj[0])+nonceSize {
+		return "", nil, fmt.Errorf("replica object %s is too short", key)
+	}
+	compression := string(obj[1 : 1+obj[0]])
+	nonce := obj[1+len(compression) : 1+len(compression)+nonceSize]
+	stored, err := state.aead.Open(nil, nonce, obj[1+len(compression)+nonceSize:], []byte(key))
+	if err != nil {
+		return "", nil, fmt.Errorf("error decrypting replica object %s (wrong key?): %w", key, err)
+	}
+	return compression, stored, nil
+}
+
+// fetches the data of a blob from the replica and checks it against the blob's name
+func (state *replicaState) fetchBlob(ctx context.Context, name string) ([]byte, error) {
+	if state.fetchFn == nil {
+		return nil, ErrNoReplica
+	}
+	key := ReplicaBlobPrefix + name
+	obj, err := state.fetchFn(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s from the replica: %w", key, err)
+	}
+	compression, stored, err := state.openObject(key, obj)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decompressPart(compression, stored)
+	if err != nil {
+		return nil, fmt.Errorf("replica object %s: %w", key, err)
+	}
+	if state.hmacHex(data) != name {
+		return nil, fmt.Errorf("replica object %s does not match its checksum", key)
+	}
+	return data, nil
+}
+
+// the data of an offloaded blob (checked against the blob's hash)
+func fetchOffloadedBlob(ctx context.Context, hash string, blobEncryption string, name string) ([]byte, error) {
+	state, err := getReplica()
+	if err != nil {
+		return nil, fmt.Errorf("blob %s is offloaded: %w", hash, err)
+	}
+	data, err := state.fetchBlob(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if blobHash(blobEncryption, data) != hash {
+		return nil, fmt.Errorf("replica of blob %s does not match the blob", hash)
+	}
+	return data, nil
+}
+
+// sets the replica the blobs are replicated to (the bucket and the key), if it changed the blobs and files are
+// replicated again.  returns an error if there are offloaded blobs in another replica.
+func (s *FileStore) SetReplicaTarget(ctx context.Context, target string) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		curTarget := tx.GetString("SELECT target FROM db_file_replica_target WHERE id = 1")
+		if curTarget == target {
+			return nil
+		}
+		if tx.Exists("SELECT hash FROM db_file_blob WHERE offloaded LIMIT 1") {
+			return fmt.Errorf("blobs are offloaded to the previous replica, they have to be brought back first")
+		}
+		tx.Exec("UPDATE db_file_blob SET replica = '' WHERE replica != ''")
+		tx.Exec("DELETE FROM db_file_replica")
+		tx.Exec("REPLACE INTO db_file_replica_target (id, target) VALUES (1, ?)", target)
+		return nil
+	})
+}
+
+// there are blobs that are only in the replica
+func (s *FileStore) HasOffloadedBlobs(ctx context.Context) (bool, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) (bool, error) {
+		return tx.Exists("SELECT hash FROM db_file_blob WHERE offloaded LIMIT 1"), nil
+	})
+}
+
+// returns up to limit blobs (after the blob afterHash) that are not replicated, encoded for the replica.  the
+// blobs that can not be decoded are skipped (see Check).  returns the hash of the last blob.
+func (s *FileStore) GetUnreplicatedBlobs(ctx context.Context, afterHash string, limit int) ([]*ReplicaObject, string, error) {
+	state, err := getReplica()
+	if err != nil {
+		return nil, "", err
+	}
+	var blobs []*dbBlob
+	err = WithTx(ctx, func(tx *TxWrap) error {
+		query := `SELECT hash, data, compression, encryption FROM db_file_blob
+                  WHERE hash > ? AND replica = '' AND NOT offloaded ORDER BY hash LIMIT ?`
+		tx.Select(&blobs, query, afterHash, limit)
+		return nil
+	})
+	if err != nil || len(blobs) == 0 {
+		return nil, "", err
+	}
+	var rtn []*ReplicaObject
+	for _, blob := range blobs {
+		data, err := decodePart(&dbFilePart{Hash: blob.Hash, HasBlob: true, Data: blob.Data, Compression: blob.Compression, Encryption: blob.Encryption})
+		if err != nil {
+			continue
+		}
+		key := ReplicaBlobPrefix + state.hmacHex(data)
+		obj, err := state.encodeObject(key, data)
+		if err != nil {
+			return nil, "", err
+		}
+		rtn = append(rtn, &ReplicaObject{Key: key, Data: obj, hash: blob.Hash})
+	}
+	return rtn, blobs[len(blobs)-1].Hash, nil
+}
+
+// marks the blobs as replicated (after they were put in the replica)
+func (s *FileStore) SetBlobsReplicated(ctx context.Context, objs []*ReplicaObject) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		for _, obj := range objs {
+			tx.Exec("UPDATE db_file_blob SET replica = ? WHERE hash = ?", obj.Key[len(ReplicaBlobPrefix):], obj.hash)
+		}
+		return nil
+	})
+}
+
+// returns the manifests of the files that changed since they were replicated (the files that have blobs that are
+// not replicated yet are not included), and the manifests of the deleted files (to delete from the replica)
+func (s *FileStore) GetReplicaFileChanges(ctx context.Context) ([]*ReplicaObject, []*ReplicaObject, error) {
+	state, err := getReplica()
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifests []*ReplicaFile
+	var deleted []*ReplicaObject
+	err = WithTx(ctx, func(tx *TxWrap) error {
+		manifests = nil
+		deleted = nil
+		query := `SELECT f.* FROM db_wave_file f LEFT JOIN db_file_replica r ON r.zoneid = f.zoneid AND r.name = f.name
+                  WHERE r.zoneid IS NULL OR r.modts != f.modts OR r.size != f.size`
+		files := dbutil.SelectMappable[*WaveFile](tx, query)
+		for _, file := range files {
+			var parts []struct {
+				PartIdx int
+				Hash    string
+				Inline  bool
+				Replica string
+			}
+			query = `SELECT d.partidx, d.hash, length(d.data) > 0 AS inline, COALESCE(b.replica, '') AS replica
+                     FROM db_file_data d LEFT JOIN db_file_blob b ON b.hash = d.hash
+                     WHERE d.zoneid = ? AND d.name = ?`
+			tx.Select(&parts, query, file.ZoneId, file.Name)
+			manifest := &ReplicaFile{File: file, PartSize: partDataSize, Parts: make(map[int]string)}
+			ready := true
+			for _, part := range parts {
+				// the parts with their data in db_file_data are moved to blobs soon
+				if (part.Hash != "" && part.Replica == "") || part.Inline {
+					ready = false
+					break
+				}
+				manifest.Parts[part.PartIdx] = part.Replica
+			}
+			if ready {
+				manifests = append(manifests, manifest)
+			}
+		}
+		var removed []struct {
+			ZoneId string
+			Name   string
+		}
+		query = `SELECT r.zoneid, r.name FROM db_file_replica r LEFT JOIN db_wave_file f ON f.zoneid = r.zoneid AND f.name = r.name
+                 WHERE f.zoneid IS NULL`
+		tx.Select(&removed, query)
+		for _, file := range removed {
+			deleted = append(deleted, &ReplicaObject{Key: state.fileKey(file.ZoneId, file.Name), zoneId: file.ZoneId, name: file.Name})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var changed []*ReplicaObject
+	for _, manifest := range manifests {
+		file := manifest.File
+		key := state.fileKey(file.ZoneId, file.Name)
+		obj, err := state.encodeObject(key, dbutil.QuickJsonBytes(manifest))
+		if err != nil {
+			return nil, nil, err
+		}
+		changed = append(changed, &ReplicaObject{Key: key, Data: obj, zoneId: file.ZoneId, name: file.Name, modTs: file.ModTs, size: file.Size})
+	}
+	return changed, deleted, nil
+}
+
+// records the manifests that were put in the replica
+func (s *FileStore) SetFilesReplicated(ctx context.Context, objs []*ReplicaObject) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		query := "REPLACE INTO db_file_replica (zoneid, name, modts, size) VALUES (?, ?, ?, ?)"
+		for _, obj := range objs {
+			tx.Exec(query, obj.zoneId, obj.name, obj.modTs, obj.size)
+		}
+		return nil
+	})
+}
+
+// records the manifests that were deleted from the replica
+func (s *FileStore) RemoveReplicaFiles(ctx context.Context, objs []*ReplicaObject) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		for _, obj := range objs {
+			tx.Exec("DELETE FROM db_file_replica WHERE zoneid = ? AND name = ?", obj.zoneId, obj.name)
+		}
+		return nil
+	})
+}
+
+// drops the data of the replicated blobs that are only in files not modified since beforeTs (unix millis), the data
+// is fetched from the replica when it is read.  returns the number of blobs and the bytes freed.
+func (s *FileStore) OffloadBlobs(ctx context.Context, beforeTs int64) (int, int64, error) {
+	var numBlobs int
+	var freed int64
+	err := WithTx(ctx, func(tx *TxWrap) error {
+		cond := `replica != '' AND NOT offloaded AND hash NOT IN
+                 (SELECT d.hash FROM db_file_data d JOIN db_wave_file f ON f.zoneid = d.zoneid AND f.name = d.name WHERE f.modts >= ?)`
+		numBlobs = tx.GetInt("SELECT COUNT(*) FROM db_file_blob WHERE "+cond, beforeTs)
+		freed = int64(tx.GetInt("SELECT COALESCE(SUM(length(data)), 0) FROM db_file_blob WHERE "+cond, beforeTs))
+		tx.Exec("UPDATE db_file_blob SET data = x'', offloaded = 1 WHERE "+cond, beforeTs)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return numBlobs, freed, nil
+}
+
+// fetches up to limit offloaded blobs from the replica and stores them in the db again, returns the number of blobs
+func (s *FileStore) RehydrateBlobs(ctx context.Context, limit int) (int, error) {
+	state, err := getReplica()
+	if err != nil {
+		return 0, err
+	}
+	var blobs []*dbBlob
+	err = WithTx(ctx, func(tx *TxWrap) error {
+		query := "SELECT hash, encryption, replica FROM db_file_blob WHERE offloaded LIMIT ?"
+		tx.Select(&blobs, query, limit)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	var numBlobs int
+	for _, blob := range blobs {
+		data, err := state.fetchBlob(ctx, blob.Replica)
+		if err != nil {
+			return numBlobs, err
+		}
+		if blobHash(blob.Encryption, data) != blob.Hash {
+			return numBlobs, fmt.Errorf("replica of blob %s does not match the blob", blob.Hash)
+		}
+		// the blob keeps its encryption (it is migrated to the setting later, see dbMigrateBlobs)
+		compression, stored, err := encodeBlobAs(blob.Hash, blob.Encryption, data)
+		if err != nil {
+			return numBlobs, err
+		}
+		err = WithTx(ctx, func(tx *TxWrap) error {
+			query := "UPDATE db_file_blob SET data = ?, compression = ?, offloaded = 0 WHERE hash = ? AND offloaded"
+			tx.Exec(query, stored, compression, blob.Hash)
+			return nil
+		})
+		if err != nil {
+			return numBlobs, err
+		}
+		numBlobs++
+	}
+	return numBlobs, nil
+}
+
+// decodes a manifest fetched from the replica
+func DecodeReplicaFile(key string, obj []byte) (*ReplicaFile, error) {
+	state, err := getReplica()
+	if err != nil {
+		return nil, err
+	}
+	compression, data, err := state.openObject(key, obj)
+	if err != nil {
+		return nil, err
+	}
+	if compression == PartCompression_Zstd {
+		// not a part, it can be larger than a part
+		data, err = zstdDecoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing manifest %s: %w", key, err)
+		}
+	}
+	var manifest ReplicaFile
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding manifest %s: %w", key, err)
+	}
+	if manifest.File == nil {
+		return nil, fmt.Errorf("manifest %s has no file", key)
+	}
+	return &manifest, nil
+}
+
+// restores a file from its manifest into the zone (the zone it was in, or another one), the blobs are fetched from
+// the replica.  returns fs.ErrExist if there is a file with the name in the zone (it is replaced with overwrite).
+func (s *FileStore) RestoreFile(ctx context.Context, manifest *ReplicaFile, zoneId string, overwrite bool) error {
+	state, err := getReplica()
+	if err != nil {
+		return err
+	}
+	if manifest.PartSize != partDataSize {
+		return fmt.Errorf("the file was replicated with %d byte parts, not %d", manifest.PartSize, partDataSize)
+	}
+	file := manifest.File.DeepCopy()
+	file.ZoneId = zoneId
+	if overwrite {
+		err = s.DeleteFile(ctx, zoneId, file.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return withLock(s, zoneId, file.Name, func(entry *CacheEntry) error {
+		if entry.File != nil {
+			return fs.ErrExist
+		}
+		err := dbInsertFile(ctx, file)
+		if err != nil {
+			return err
+		}
+		err = restoreParts(ctx, state, file, manifest.Parts)
+		if err != nil {
+			// no partial files
+			dbDeleteFile(ctx, zoneId, file.Name)
+			return err
+		}
+		notifyWatchers(zoneId, file.Name, false)
+		return nil
+	})
+}
+
+// fetches the parts of a file from the replica and writes them (with their line and text index)
+func restoreParts(ctx context.Context, state *replicaState, file *WaveFile, parts map[int]string) error {
+	dataEntries := make(map[int]*DataCacheEntry)
+	for partIdx, name := range parts {
+		var data []byte
+		if name != "" {
+			var err error
+			data, err = state.fetchBlob(ctx, name)
+			if err != nil {
+				return fmt.Errorf("part %d: %w", partIdx, err)
+			}
+		}
+		dataEntries[partIdx] = &DataCacheEntry{PartIdx: partIdx, Data: data}
+		if len(dataEntries) >= restoreBatchSize {
+			err := dbWriteCacheEntry(ctx, file, dataEntries, false)
+			if err != nil {
+				return err
+			}
+			dataEntries = make(map[int]*DataCacheEntry)
+		}
+	}
+	return dbWriteCacheEntry(ctx, file, dataEntries, false)
+}
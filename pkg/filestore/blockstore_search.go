@@ -0,0 +1,276 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
+)
+
+// files can be found by name (Search with just a Name), and files made with the TextIndex opt can be searched by
+// their text.  the text of each part is written to db_file_text (an fts4 table) when the part is flushed, and
+// db_file_textidx has the file offset of each part's text.  escape sequences, control characters and invalid utf-8
+// are blanked out (replaced with spaces) so the offset of a match in the text is its offset in the part.  parts
+// with NUL bytes are not text and are not indexed, and nothing is indexed while the blobs are encrypted.  the words
+// of a search have to be in the same part, and the writes that are not flushed yet are not found.
+
+const MaxSearchHits = 1000
+const searchSnippetTokens = 12
+
+type SearchOpts struct {
+	Text    string   // all the words have to be in a part, a word with punctuation (CVE-2024-1234) is a phrase
+	Name    string   // a substring of the file name
+	ZoneIds []string // only search these zones (nil is all the zones)
+	Limit   int      // MaxSearchHits if 0
+}
+
+type SearchHit struct {
+	ZoneId  string
+	Name    string
+	Offset  int64  // the file offset of the first match in the part (0 if there is no Text)
+	Snippet string // the text around the match
+}
+
+// the length of the escape sequence at the start of data (data[0] is ESC)
+func escapeSeqLen(data []byte) int {
+	if len(data) < 2 {
+		return len(data)
+	}
+	switch data[1] {
+	case '[':
+		// CSI, ends with a byte in 0x40-0x7e
+		for i := 2; i < len(data); i++ {
+			if data[i] >= 0x40 && data[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(data)
+	case ']', 'P', '_', '^':
+		// OSC (and DCS, APC, PM), ends with BEL or ST (ESC \)
+		for i := 2; i < len(data); i++ {
+			if data[i] == 0x07 {
+				return i + 1
+			}
+			if data[i] == 0x1b && i+1 < len(data) && data[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(data)
+	default:
+		return 2
+	}
+}
+
+// the text of a part for the index (the same length as the data), "" if the part is not text
+func makeIndexText(data []byte) string {
+	if len(data) == 0 || bytes.IndexByte(data, 0) >= 0 {
+		return ""
+	}
+	text := make([]byte, len(data))
+	copy(text, data)
+	blank := func(start int, end int) {
+		for i := start; i < end; i++ {
+			text[i] = ' '
+		}
+	}
+	for i := 0; i < len(text); {
+		ch := text[i]
+		switch {
+		case ch == 0x1b:
+			seqLen := escapeSeqLen(text[i:])
+			blank(i, i+seqLen)
+			i += seqLen
+		case (ch < 0x20 && ch != '\n' && ch != '\t') || ch == 0x7f:
+			text[i] = ' '
+			i++
+		case ch < utf8.RuneSelf:
+			i++
+		default:
+			r, size := utf8.DecodeRune(text[i:])
+			if r == utf8.RuneError && size <= 1 {
+				text[i] = ' '
+				size = 1
+			}
+			i += size
+		}
+	}
+	if len(bytes.TrimSpace(text)) == 0 {
+		return ""
+	}
+	return string(text)
+}
+
+// every word is quoted (a phrase), so the fts query syntax (operators, prefixes, columns) is not used
+func makeMatchQuery(text string) string {
+	var phrases []string
+	for _, word := range strings.Fields(text) {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		phrases = append(phrases, `"`+strings.ReplaceAll(word, `"`, " ")+`"`)
+	}
+	return strings.Join(phrases, " ")
+}
+
+// the offset of the first match from the fts offsets() string (column, term, byte offset, size for each match)
+func firstMatchOffset(offsets string) int64 {
+	fields := strings.Fields(offsets)
+	if len(fields) < 3 {
+		return 0
+	}
+	offset, _ := strconv.ParseInt(fields[2], 10, 64)
+	return offset
+}
+
+func escapeLike(str string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(str)
+}
+
+// the part's text is indexed again (or removed from the index if it is no longer text)
+func txWriteTextIndex(tx *TxWrap, file *WaveFile, dce *DataCacheEntry) {
+	query := "SELECT docid FROM db_file_textidx WHERE zoneid = ? AND name = ? AND partidx = ?"
+	docId := tx.GetInt64(query, file.ZoneId, file.Name, dce.PartIdx)
+	if docId != 0 {
+		tx.Exec("DELETE FROM db_file_text WHERE docid = ?", docId)
+		tx.Exec("DELETE FROM db_file_textidx WHERE docid = ?", docId)
+	}
+	if isEncrypting() {
+		// the index would have the text in the clear
+		return
+	}
+	fileOffset := file.partFileOffset(dce.PartIdx)
+	// a reused part can have data from before the file wrapped past the end of the file
+	validLen := max(0, min(int64(len(dce.Data)), file.Size-fileOffset))
+	text := makeIndexText(dce.Data[:validLen])
+	if text == "" {
+		return
+	}
+	query = "INSERT INTO db_file_textidx (zoneid, name, partidx, fileoffset) VALUES (?, ?, ?, ?)"
+	result := tx.Exec(query, file.ZoneId, file.Name, dce.PartIdx, fileOffset)
+	docId, _ = result.LastInsertId()
+	tx.Exec("INSERT INTO db_file_text (docid, content) VALUES (?, ?)", docId, text)
+}
+
+// partsCond and args are the same as in txDeleteParts
+func txDeleteTextIndex(tx *TxWrap, partsCond string, args []any) {
+	query := "DELETE FROM db_file_text WHERE docid IN (SELECT docid FROM db_file_textidx WHERE zoneid = ? AND name = ?" + partsCond + ")"
+	tx.Exec(query, args...)
+	query = "DELETE FROM db_file_textidx WHERE zoneid = ? AND name = ?" + partsCond
+	tx.Exec(query, args...)
+}
+
+func dbSearch(ctx context.Context, opts SearchOpts, limit int) ([]*SearchHit, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) ([]*SearchHit, error) {
+		var conds []string
+		var args []any
+		if opts.Name != "" {
+			conds = append(conds, `name LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escapeLike(opts.Name)+"%")
+		}
+		if opts.ZoneIds != nil {
+			conds = append(conds, "zoneid IN (SELECT value FROM json_each(?))")
+			args = append(args, dbutil.QuickJsonArr(opts.ZoneIds))
+		}
+		var rtn []*SearchHit
+		if opts.Text == "" {
+			var rows []struct {
+				ZoneId string
+				Name   string
+			}
+			query := "SELECT zoneid, name FROM db_wave_file WHERE " + strings.Join(conds, " AND ") + " ORDER BY zoneid, name LIMIT ?"
+			tx.Select(&rows, query, append(args, limit)...)
+			for _, row := range rows {
+				rtn = append(rtn, &SearchHit{ZoneId: row.ZoneId, Name: row.Name})
+			}
+			return rtn, nil
+		}
+		var rows []struct {
+			ZoneId     string
+			Name       string
+			FileOffset int64
+			Offsets    string
+			Snippet    string
+		}
+		query := fmt.Sprintf(`SELECT i.zoneid, i.name, i.fileoffset, offsets(db_file_text) AS offsets,
+                                     snippet(db_file_text, '', '', '...', -1, %d) AS snippet
+                              FROM db_file_text JOIN db_file_textidx i ON i.docid = db_file_text.docid
+                              WHERE db_file_text MATCH ?`, searchSnippetTokens)
+		for _, cond := range conds {
+			query += " AND i." + cond
+		}
+		query += " ORDER BY i.zoneid, i.name, i.fileoffset LIMIT ?"
+		tx.Select(&rows, query, append(append([]any{makeMatchQuery(opts.Text)}, args...), limit)...)
+		for _, row := range rows {
+			rtn = append(rtn, &SearchHit{
+				ZoneId:  row.ZoneId,
+				Name:    row.Name,
+				Offset:  row.FileOffset + firstMatchOffset(row.Offsets),
+				Snippet: strings.Join(strings.Fields(row.Snippet), " "),
+			})
+		}
+		return rtn, nil
+	})
+}
+
+// finds the files by name and (if they have the TextIndex opt) by text, a hit for each part with a match.  with
+// just ZoneIds, all the files in the zones are returned.
+func (s *FileStore) Search(ctx context.Context, opts SearchOpts) ([]*SearchHit, error) {
+	if opts.Text != "" && makeMatchQuery(opts.Text) == "" {
+		return nil, fmt.Errorf("no words to search for in %q", opts.Text)
+	}
+	if opts.Text == "" && opts.Name == "" && opts.ZoneIds == nil {
+		return nil, fmt.Errorf("search needs text, a file name or zones")
+	}
+	limit := opts.Limit
+	if limit <= 0 || limit > MaxSearchHits {
+		limit = MaxSearchHits
+	}
+	return dbSearch(ctx, opts, limit)
+}
+
+// turns the text index on (the text in the file is indexed) or off (the file's text is removed from the index)
+func (s *FileStore) SetTextIndex(ctx context.Context, zoneId string, name string, enabled bool) error {
+	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+		err := entry.loadFileIntoCache(ctx)
+		if err != nil {
+			return err
+		}
+		file := entry.File
+		if file.Opts.TextIndex == enabled {
+			return nil
+		}
+		file.Opts.TextIndex = enabled
+		if !enabled {
+			err = dbUpdateFileOpts(ctx, zoneId, name, file.Opts)
+			if err != nil {
+				return err
+			}
+			return dbDeleteTextIndex(ctx, zoneId, name)
+		}
+		// all the parts are indexed on the flush below
+		var partIdxs []int
+		if file.Size > 0 {
+			firstOffset := file.DataStartIdx() - file.DataStartIdx()%partDataSize
+			for offset := firstOffset; offset < file.Size; offset += partDataSize {
+				partIdxs = append(partIdxs, file.partIdxAtOffset(offset))
+			}
+		}
+		err = entry.loadDataPartsIntoCache(ctx, partIdxs)
+		if err != nil {
+			return err
+		}
+		err = dbUpdateFileOpts(ctx, zoneId, name, file.Opts)
+		if err != nil {
+			return err
+		}
+		return entry.flushToDB(ctx, false)
+	})
+}
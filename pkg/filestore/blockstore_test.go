@@ -6,11 +6,18 @@ package filestore
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -60,6 +67,7 @@ func (s *FileStore) clearCache() {
 	s.Lock.Lock()
 	defer s.Lock.Unlock()
 	s.Cache = make(map[cacheKey]*CacheEntry)
+	dropAllCachedParts()
 }
 
 //lint:ignore U1000 used for testing
@@ -202,6 +210,63 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestRename(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err := WFS.MakeFile(ctx, zoneId, "staging", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	data := strings.Repeat("upload data\n", 10000)
+	err = WFS.WriteFile(ctx, zoneId, "staging", []byte(data[:60000]))
+	if err != nil {
+		t.Fatalf("error writing data: %v", err)
+	}
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	// the rest is only in the cache
+	err = WFS.AppendData(ctx, zoneId, "staging", []byte(data[60000:]))
+	if err != nil {
+		t.Fatalf("error appending data: %v", err)
+	}
+	err = WFS.MakeFile(ctx, zoneId, "dest", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	err = WFS.WriteFile(ctx, zoneId, "dest", []byte("old data"))
+	if err != nil {
+		t.Fatalf("error writing data: %v", err)
+	}
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	err = WFS.RenameFile(ctx, zoneId, "staging", "dest")
+	if err != nil {
+		t.Fatalf("error renaming file: %v", err)
+	}
+	_, err = WFS.Stat(ctx, zoneId, "staging")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected file not found error for staging, got %v", err)
+	}
+	checkFileSize(t, ctx, zoneId, "dest", int64(len(data)))
+	checkFileData(t, ctx, zoneId, "dest", data)
+	if refCount := getBlobRefCount(t, ctx, partHash([]byte("old data"))); refCount != 0 {
+		t.Errorf("expected the replaced file's blob to be released, refcount %d", refCount)
+	}
+	err = WFS.RenameFile(ctx, zoneId, "staging", "dest")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected file not found error renaming a missing file, got %v", err)
+	}
+	checkFileData(t, ctx, zoneId, "dest", data)
+}
+
 func checkMapsEqual(t *testing.T, m1 map[string]any, m2 map[string]any, msg string) {
 	if len(m1) != len(m2) {
 		t.Errorf("%s: map length mismatch", msg)
@@ -609,6 +674,39 @@ func TestSimpleDBFlush(t *testing.T) {
 	checkFileByteCount(t, ctx, zoneId, fileName, 'l', 3)
 }
 
+func TestFlushAndStop(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	fileName := "t1"
+	err := WFS.MakeFile(ctx, zoneId, fileName, nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	err = WFS.WriteFile(ctx, zoneId, fileName, []byte("hello world!"))
+	if err != nil {
+		t.Fatalf("error writing data: %v", err)
+	}
+	// a flush in progress (the background flusher) is waited for
+	WFS.setIsFlushing(true)
+	time.AfterFunc(50*time.Millisecond, func() { WFS.setIsFlushing(false) })
+	_, err = WFS.FlushCache(ctx)
+	if err != ErrFlushInProgress {
+		t.Fatalf("expected a flush in progress error, got %v", err)
+	}
+	_, err = WFS.FlushAndStop(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	if WFS.getCacheSize() != 0 {
+		t.Errorf("cache size mismatch")
+	}
+	checkFileData(t, ctx, zoneId, fileName, "hello world!")
+}
+
 func TestConcurrentAppend(t *testing.T) {
 	initDb(t)
 	defer cleanupDb(t)
@@ -774,3 +872,1201 @@ func TestIJson(t *testing.T) {
 		t.Errorf("data mismatch: expected %v, got %v", rootSet["data"], outData)
 	}
 }
+
+func checkLines(t *testing.T, ctx context.Context, zoneId string, name string, startLine int64, numLines int64, expected string) {
+	t.Helper()
+	lines, err := WFS.ReadLines(ctx, zoneId, name, startLine, numLines)
+	if err != nil {
+		t.Fatalf("error reading lines %d+%d: %v", startLine, numLines, err)
+	}
+	if string(lines.Data) != expected {
+		t.Errorf("lines %d+%d mismatch: expected %q, got %q", startLine, numLines, expected, string(lines.Data))
+	}
+}
+
+func TestLineIndex(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err := WFS.MakeFile(ctx, zoneId, "l1", nil, wshrpc.FileOpts{LineIndex: true})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	var expected bytes.Buffer
+	for i := 0; i < 30; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		offset, err := WFS.Append(ctx, zoneId, "l1", []byte(line))
+		if err != nil {
+			t.Fatalf("error appending data: %v", err)
+		}
+		if offset != int64(expected.Len()) {
+			t.Errorf("append offset mismatch: expected %d, got %d", expected.Len(), offset)
+		}
+		expected.WriteString(line)
+		if i == 15 {
+			// half of the index is in the db
+			err = WFS.Sync(ctx, zoneId, "l1")
+			if err != nil {
+				t.Fatalf("error syncing file: %v", err)
+			}
+		}
+	}
+	WFS.Append(ctx, zoneId, "l1", []byte("partial"))
+	checkLines(t, ctx, zoneId, "l1", 3, 2, "line 3\nline 4\n")
+	checkLines(t, ctx, zoneId, "l1", 12, 6, "line 12\nline 13\nline 14\nline 15\nline 16\nline 17\n")
+	checkLines(t, ctx, zoneId, "l1", -2, 0, "line 28\nline 29\npartial")
+	checkLines(t, ctx, zoneId, "l1", 29, 5, "line 29\npartial")
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	file, _ := WFS.Stat(ctx, zoneId, "l1")
+	if file.NumLines != 30 {
+		t.Errorf("numlines mismatch: expected 30, got %d", file.NumLines)
+	}
+	checkLines(t, ctx, zoneId, "l1", 12, 6, "line 12\nline 13\nline 14\nline 15\nline 16\nline 17\n")
+	offset, err := WFS.LineOffset(ctx, zoneId, "l1", 10)
+	if err != nil || offset != int64(strings.Index(expected.String(), "line 10")) {
+		t.Errorf("line offset mismatch: got %d (err %v)", offset, err)
+	}
+	// overwriting a newline changes the line numbers after it
+	err = WFS.WriteAt(ctx, zoneId, "l1", int64(strings.Index(expected.String(), "\nline 2\n")), []byte(" "))
+	if err != nil {
+		t.Fatalf("error writing data: %v", err)
+	}
+	checkLines(t, ctx, zoneId, "l1", 1, 2, "line 1 line 2\nline 3\n")
+
+	// the line numbers do not change when a circular file drops its first lines
+	err = WFS.MakeFile(ctx, zoneId, "c1", nil, wshrpc.FileOpts{Circular: true, MaxSize: 100, LineIndex: true})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	for i := 0; i < 40; i++ {
+		WFS.Append(ctx, zoneId, "c1", []byte(fmt.Sprintf("c%02d\n", i)))
+		if i%7 == 0 {
+			WFS.Sync(ctx, zoneId, "c1")
+		}
+	}
+	// the file starts at c15 (in the part that has the end of the file)
+	checkLines(t, ctx, zoneId, "c1", 37, 0, "c37\nc38\nc39\n")
+	checkLines(t, ctx, zoneId, "c1", 16, 2, "c16\nc17\n")
+	checkLines(t, ctx, zoneId, "c1", 26, 2, "c26\nc27\n")
+	checkLines(t, ctx, zoneId, "c1", 0, 1, "")
+	checkLines(t, ctx, zoneId, "c1", 10, 6, "c15\n")
+	lines, _ := WFS.ReadLines(ctx, zoneId, "c1", 0, 2)
+	if lines == nil || lines.StartLine != 15 || lines.Offset != 60 {
+		t.Errorf("expected the lines to start at line 15, got %+v", lines)
+	}
+
+	// turning on the index for an existing file
+	err = WFS.MakeFile(ctx, zoneId, "e1", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	WFS.AppendData(ctx, zoneId, "e1", []byte(expected.String()))
+	_, err = WFS.ReadLines(ctx, zoneId, "e1", 0, 1)
+	if err == nil {
+		t.Errorf("expected an error reading lines without a line index")
+	}
+	err = WFS.EnableLineIndex(ctx, zoneId, "e1")
+	if err != nil {
+		t.Fatalf("error enabling the line index: %v", err)
+	}
+	checkLines(t, ctx, zoneId, "e1", 25, 2, "line 25\nline 26\n")
+}
+
+func nextWatchEvent(t *testing.T, ch <-chan WatchEvent) WatchEvent {
+	t.Helper()
+	select {
+	case event, ok := <-ch:
+		if !ok {
+			t.Fatalf("watch channel closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for a watch event")
+	}
+	return WatchEvent{}
+}
+
+func TestWatch(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	_, err := WFS.Watch(ctx, zoneId, "w1", 0)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist watching a missing file, got %v", err)
+	}
+	err = WFS.MakeFile(ctx, zoneId, "w1", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	WFS.AppendData(ctx, zoneId, "w1", []byte("hello "))
+	ch, err := WFS.Watch(ctx, zoneId, "w1", -1)
+	if err != nil {
+		t.Fatalf("error watching file: %v", err)
+	}
+	WFS.AppendData(ctx, zoneId, "w1", []byte("world"))
+	event := nextWatchEvent(t, ch)
+	if event.Type != WatchEvent_Data || event.Offset != 6 || string(event.Data) != "world" {
+		t.Errorf("expected the appended data, got %+v", event)
+	}
+	WFS.WriteFile(ctx, zoneId, "w1", []byte("new"))
+	event = nextWatchEvent(t, ch)
+	if event.Type != WatchEvent_Truncate || event.Offset != 0 {
+		t.Errorf("expected a truncate event, got %+v", event)
+	}
+	event = nextWatchEvent(t, ch)
+	if event.Type != WatchEvent_Data || event.Offset != 0 || string(event.Data) != "new" {
+		t.Errorf("expected the new data, got %+v", event)
+	}
+	WFS.DeleteFile(ctx, zoneId, "w1")
+	event = nextWatchEvent(t, ch)
+	if event.Type != WatchEvent_Deleted {
+		t.Errorf("expected a deleted event, got %+v", event)
+	}
+	// made again with the same created ts would look like the same file
+	time.Sleep(5 * time.Millisecond)
+	WFS.MakeFile(ctx, zoneId, "w1", nil, wshrpc.FileOpts{})
+	event = nextWatchEvent(t, ch)
+	if event.Type != WatchEvent_Rotate || event.Offset != 0 {
+		t.Errorf("expected a rotate event, got %+v", event)
+	}
+	WFS.AppendData(ctx, zoneId, "w1", []byte("again"))
+	event = nextWatchEvent(t, ch)
+	if event.Type != WatchEvent_Data || string(event.Data) != "again" {
+		t.Errorf("expected the data of the new file, got %+v", event)
+	}
+
+	// data dropped from a circular file is skipped
+	err = WFS.MakeFile(ctx, zoneId, "c1", nil, wshrpc.FileOpts{Circular: true, MaxSize: 100})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	cch, err := WFS.Watch(ctx, zoneId, "c1", 0)
+	if err != nil {
+		t.Fatalf("error watching file: %v", err)
+	}
+	WFS.AppendData(ctx, zoneId, "c1", []byte(strings.Repeat("x", 150)))
+	event = nextWatchEvent(t, cch)
+	if event.Type != WatchEvent_Data || event.Offset != 50 || len(event.Data) != 100 {
+		t.Errorf("expected the last 100 bytes at offset 50, got type:%s offset:%d len:%d", event.Type, event.Offset, len(event.Data))
+	}
+
+	cancelFn()
+	for range ch {
+	}
+	for range cch {
+	}
+	watchersLock.Lock()
+	numWatched := len(fileWatchers)
+	watchersLock.Unlock()
+	if numWatched != 0 {
+		t.Errorf("expected the watchers to be removed, got %d files", numWatched)
+	}
+}
+
+func getDbParts(t *testing.T, ctx context.Context, zoneId string, name string) []*dbFilePart {
+	parts, err := WithTxRtn(ctx, func(tx *TxWrap) ([]*dbFilePart, error) {
+		var parts []*dbFilePart
+		query := `SELECT d.zoneid, d.name, d.partidx, d.hash, b.hash IS NOT NULL AS hasblob,
+                         CASE WHEN d.hash = '' THEN d.data ELSE COALESCE(b.data, x'') END AS data,
+                         CASE WHEN d.hash = '' THEN d.compression ELSE COALESCE(b.compression, '') END AS compression
+                  FROM db_file_data d LEFT JOIN db_file_blob b ON b.hash = d.hash
+                  WHERE d.zoneid = ? AND d.name = ? ORDER BY d.partidx`
+		tx.Select(&parts, query, zoneId, name)
+		return parts, nil
+	})
+	if err != nil {
+		t.Fatalf("error getting parts: %v", err)
+	}
+	return parts
+}
+
+func TestCompression(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err := WFS.MakeFile(ctx, zoneId, "z1", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	// the second part compresses, the third does not
+	data := []byte(strings.Repeat("a", 20) + strings.Repeat("b", 60) + "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmn")
+	WFS.AppendData(ctx, zoneId, "z1", data)
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	parts := getDbParts(t, ctx, zoneId, "z1")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+	if parts[1].Compression != PartCompression_Zstd || len(parts[1].Data) >= 50 {
+		t.Errorf("expected part 1 to be compressed, got %q (%d bytes)", parts[1].Compression, len(parts[1].Data))
+	}
+	if parts[2].Compression != PartCompression_None || len(parts[2].Data) != 30 {
+		t.Errorf("expected part 2 to be stored uncompressed, got %q (%d bytes)", parts[2].Compression, len(parts[2].Data))
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "z1", string(data))
+	checkFileDataAt(t, ctx, zoneId, "z1", 60, string(data[60:90]))
+
+	// parts written before compression (and dedup), the blob the part had is reaped
+	err = WithTx(ctx, func(tx *TxWrap) error {
+		tx.Exec("UPDATE db_file_data SET data = ?, compression = ?, hash = '' WHERE zoneid = ? AND name = ? AND partidx = 1", data[50:100], PartCompression_Old, zoneId, "z1")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error updating part: %v", err)
+	}
+	numReaped, err := WFS.ReapBlobs(ctx)
+	if err != nil || numReaped != 1 {
+		t.Errorf("expected 1 blob to be reaped, got %d, err:%v", numReaped, err)
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "z1", string(data))
+	numParts, savedBytes, err := dbMigrateOldParts(ctx, compressBatchSize)
+	if err != nil || numParts != 1 || savedBytes <= 0 {
+		t.Errorf("expected 1 part to be migrated, got %d (saved %d), err:%v", numParts, savedBytes, err)
+	}
+	parts = getDbParts(t, ctx, zoneId, "z1")
+	if parts[1].Hash == "" || parts[1].Compression != PartCompression_Zstd {
+		t.Errorf("expected the old part to be in a compressed blob, got %q %q", parts[1].Hash, parts[1].Compression)
+	}
+	checkFileData(t, ctx, zoneId, "z1", string(data))
+}
+
+func getBlobRefCount(t *testing.T, ctx context.Context, hash string) int {
+	refCount, err := WithTxRtn(ctx, func(tx *TxWrap) (int, error) {
+		return tx.GetInt("SELECT refcount FROM db_file_blob WHERE hash = ?", hash), nil
+	})
+	if err != nil {
+		t.Fatalf("error getting blob refcount: %v", err)
+	}
+	return refCount
+}
+
+func TestDedup(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId1 := uuid.NewString()
+	zoneId2 := uuid.NewString()
+	// the first two parts are the same
+	data := []byte(strings.Repeat("x", 100) + "the end")
+	for _, zoneId := range []string{zoneId1, zoneId2} {
+		err := WFS.MakeFile(ctx, zoneId, "d1", nil, wshrpc.FileOpts{})
+		if err != nil {
+			t.Fatalf("error creating file: %v", err)
+		}
+		err = WFS.WriteFile(ctx, zoneId, "d1", data)
+		if err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+	_, err := WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	parts := getDbParts(t, ctx, zoneId1, "d1")
+	if len(parts) != 3 || parts[0].Hash != parts[1].Hash || parts[0].Hash == parts[2].Hash {
+		t.Fatalf("expected parts 0 and 1 to share a blob, got %d parts", len(parts))
+	}
+	xHash, endHash := parts[0].Hash, parts[2].Hash
+	if refCount := getBlobRefCount(t, ctx, xHash); refCount != 4 {
+		t.Errorf("expected the shared blob to have 4 refs, got %d", refCount)
+	}
+	if refCount := getBlobRefCount(t, ctx, endHash); refCount != 2 {
+		t.Errorf("expected the last blob to have 2 refs, got %d", refCount)
+	}
+
+	err = WFS.DeleteZone(ctx, zoneId1)
+	if err != nil {
+		t.Fatalf("error deleting zone: %v", err)
+	}
+	if refCount := getBlobRefCount(t, ctx, xHash); refCount != 2 {
+		t.Errorf("expected the shared blob to have 2 refs after the delete, got %d", refCount)
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId2, "d1", string(data))
+
+	// replacing the data releases the old blobs
+	err = WFS.WriteFile(ctx, zoneId2, "d1", []byte("new data"))
+	if err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	if getBlobRefCount(t, ctx, xHash) != 0 || getBlobRefCount(t, ctx, endHash) != 0 {
+		t.Errorf("expected the old blobs to be deleted")
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId2, "d1", "new data")
+
+	err = WFS.DeleteFile(ctx, zoneId2, "d1")
+	if err != nil {
+		t.Fatalf("error deleting file: %v", err)
+	}
+	numBlobs, err := WithTxRtn(ctx, func(tx *TxWrap) (int, error) {
+		return tx.GetInt("SELECT COUNT(*) FROM db_file_blob"), nil
+	})
+	if err != nil || numBlobs != 0 {
+		t.Errorf("expected no blobs to be left, got %d, err:%v", numBlobs, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err := WFS.MakeFile(ctx, zoneId, "s1", nil, wshrpc.FileOpts{TextIndex: true})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	err = WFS.MakeFile(ctx, zoneId, "s2", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	// parts are 50 bytes, the match is in part 1 (after an escape sequence)
+	data := strings.Repeat("x", 60) + "\x1b[31mfixed CVE-2024-1234\x1b[0m in openssl\n"
+	WFS.AppendData(ctx, zoneId, "s1", []byte(data))
+	WFS.AppendData(ctx, zoneId, "s2", []byte(data))
+	WFS.FlushCache(ctx)
+	hits, err := WFS.Search(ctx, SearchOpts{Text: "cve-2024-1234"})
+	if err != nil {
+		t.Fatalf("error searching: %v", err)
+	}
+	if len(hits) != 1 || hits[0].ZoneId != zoneId || hits[0].Name != "s1" {
+		t.Fatalf("expected 1 hit in s1, got %d", len(hits))
+	}
+	if expected := int64(strings.Index(data, "CVE")); hits[0].Offset != expected {
+		t.Errorf("expected the hit at %d, got %d", expected, hits[0].Offset)
+	}
+	if !strings.Contains(hits[0].Snippet, "CVE-2024-1234") || strings.Contains(hits[0].Snippet, "\x1b") {
+		t.Errorf("unexpected snippet %q", hits[0].Snippet)
+	}
+	hits, _ = WFS.Search(ctx, SearchOpts{Text: "CVE-2024-1234 nomatch"})
+	if len(hits) != 0 {
+		t.Errorf("expected no hits when a word is missing, got %d", len(hits))
+	}
+	hits, _ = WFS.Search(ctx, SearchOpts{Text: "openssl", ZoneIds: []string{uuid.NewString()}})
+	if len(hits) != 0 {
+		t.Errorf("expected no hits in another zone, got %d", len(hits))
+	}
+	hits, _ = WFS.Search(ctx, SearchOpts{Name: "s"})
+	if len(hits) != 2 {
+		t.Errorf("expected 2 files to match the name, got %d", len(hits))
+	}
+
+	// turning the index on indexes the data that is already in the file
+	err = WFS.SetTextIndex(ctx, zoneId, "s2", true)
+	if err != nil {
+		t.Fatalf("error enabling the text index: %v", err)
+	}
+	hits, _ = WFS.Search(ctx, SearchOpts{Text: "openssl"})
+	if len(hits) != 2 {
+		t.Errorf("expected 2 hits after enabling the index, got %d", len(hits))
+	}
+	err = WFS.SetTextIndex(ctx, zoneId, "s2", false)
+	if err != nil {
+		t.Fatalf("error disabling the text index: %v", err)
+	}
+	// replacing the data drops the old text
+	err = WFS.WriteFile(ctx, zoneId, "s1", []byte("nothing to see"))
+	if err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	WFS.FlushCache(ctx)
+	hits, _ = WFS.Search(ctx, SearchOpts{Text: "openssl"})
+	if len(hits) != 0 {
+		t.Errorf("expected no hits after the replace, got %d", len(hits))
+	}
+	err = WFS.DeleteFile(ctx, zoneId, "s1")
+	if err != nil {
+		t.Fatalf("error deleting file: %v", err)
+	}
+	numDocs, err := WithTxRtn(ctx, func(tx *TxWrap) (int, error) {
+		return tx.GetInt("SELECT COUNT(*) FROM db_file_textidx"), nil
+	})
+	if err != nil || numDocs != 0 {
+		t.Errorf("expected the text index to be empty, got %d, err:%v", numDocs, err)
+	}
+}
+
+func getDbBlobs(t *testing.T, ctx context.Context) []*dbBlob {
+	blobs, err := WithTxRtn(ctx, func(tx *TxWrap) ([]*dbBlob, error) {
+		var blobs []*dbBlob
+		tx.Select(&blobs, "SELECT hash, data, compression, encryption, refcount FROM db_file_blob ORDER BY hash")
+		return blobs, nil
+	})
+	if err != nil {
+		t.Fatalf("error getting blobs: %v", err)
+	}
+	return blobs
+}
+
+func TestEncryption(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+	defer SetEncryption(nil, false)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	key := make([]byte, 32)
+	rand.Read(key)
+	err := SetEncryption(key, true)
+	if err != nil {
+		t.Fatalf("error setting the key: %v", err)
+	}
+	zoneId := uuid.NewString()
+	err = WFS.MakeFile(ctx, zoneId, "c1", nil, wshrpc.FileOpts{TextIndex: true})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	secret := "export API_TOKEN=s3cr3t-t0k3n-v4lu3"
+	data := strings.Repeat(secret+"\n", 3)
+	WFS.AppendData(ctx, zoneId, "c1", []byte(data))
+	WFS.FlushCache(ctx)
+	blobs := getDbBlobs(t, ctx)
+	if len(blobs) == 0 {
+		t.Fatalf("expected blobs")
+	}
+	plainHash := sha256.Sum256([]byte(data[:50]))
+	for _, blob := range blobs {
+		if blob.Encryption != BlobEncryption_AesGcm || bytes.Contains(blob.Data, []byte("s3cr3t")) {
+			t.Errorf("expected blob %s to be encrypted, got %q", blob.Hash, blob.Encryption)
+		}
+		if blob.Hash == hex.EncodeToString(plainHash[:]) {
+			t.Errorf("expected the hash to be keyed")
+		}
+	}
+	hits, _ := WFS.Search(ctx, SearchOpts{Text: "API_TOKEN"})
+	if len(hits) != 0 {
+		t.Errorf("expected the text not to be indexed while encrypting, got %d hits", len(hits))
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "c1", data)
+
+	// a blob can not be read with another key
+	otherKey := make([]byte, 32)
+	rand.Read(otherKey)
+	SetEncryption(otherKey, false)
+	WFS.clearCache()
+	_, _, err = WFS.ReadFile(ctx, zoneId, "c1")
+	if err == nil {
+		t.Errorf("expected an error reading with the wrong key")
+	}
+
+	// turning encryption off decrypts the blobs (with the key)
+	SetEncryption(key, false)
+	numBlobs, err := dbMigrateBlobs(ctx, compressBatchSize)
+	if err != nil || numBlobs != int64(len(blobs)) {
+		t.Fatalf("expected %d blobs to be migrated, got %d, err:%v", len(blobs), numBlobs, err)
+	}
+	hasEncrypted, _ := WFS.HasEncryptedBlobs(ctx)
+	if hasEncrypted {
+		t.Errorf("expected no encrypted blobs after the migration")
+	}
+	SetEncryption(nil, false)
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "c1", data)
+	parts := getDbParts(t, ctx, zoneId, "c1")
+	if len(parts) == 0 || parts[0].Hash != hex.EncodeToString(plainHash[:]) {
+		t.Errorf("expected the parts to be moved to the plain hashes")
+	}
+
+	// and back on
+	SetEncryption(key, true)
+	dbMigrateBlobs(ctx, compressBatchSize)
+	hasEncrypted, _ = WFS.HasEncryptedBlobs(ctx)
+	if !hasEncrypted {
+		t.Errorf("expected encrypted blobs after the migration")
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "c1", data)
+}
+
+func TestEncryptionKeys(t *testing.T) {
+	defer SetEncryption(nil, false)
+	key := make([]byte, 32)
+	rand.Read(key)
+	err := SetEncryption(key, true)
+	if err != nil {
+		t.Fatalf("error setting the key: %v", err)
+	}
+	data := []byte("export API_TOKEN=s3cr3t-t0k3n-v4lu3")
+	keyMac := hmac.New(sha256.New, key)
+	keyMac.Write(data)
+	hash := blobHash(BlobEncryption_AesGcm, data)
+	if hash == "" || hash == hex.EncodeToString(keyMac.Sum(nil)) {
+		t.Errorf("expected the hash to be keyed with a derived key")
+	}
+	compression, stored, err := encodeBlobAs(hash, BlobEncryption_AesGcm, data)
+	if err != nil {
+		t.Fatalf("error encoding blob: %v", err)
+	}
+	state := encryption.Load()
+	nonceSize := state.aeadV1.NonceSize()
+	_, err = state.aeadV1.Open(nil, stored[:nonceSize], stored[nonceSize:], []byte(hash))
+	if err == nil {
+		t.Errorf("expected the blob not to be encrypted with the keychain key")
+	}
+	decoded, err := decodeBlob(hash, compression, BlobEncryption_AesGcm, stored)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("expected the blob to be decoded, got %q, err:%v", decoded, err)
+	}
+
+	// the blobs encrypted with the keychain key are still read
+	hashV1 := blobHash(BlobEncryption_AesGcmV1, data)
+	if hashV1 != hex.EncodeToString(keyMac.Sum(nil)) {
+		t.Errorf("expected the aes-gcm hashes to be keyed with the keychain key")
+	}
+	compression, stored, err = encodeBlobAs(hashV1, BlobEncryption_AesGcmV1, data)
+	if err != nil {
+		t.Fatalf("error encoding blob: %v", err)
+	}
+	decoded, err = decodeBlob(hashV1, compression, BlobEncryption_AesGcmV1, stored)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("expected the aes-gcm blob to be decoded, got %q, err:%v", decoded, err)
+	}
+}
+
+func checkProblems(t *testing.T, result *CheckResult, expected []string, repaired bool) {
+	var types []string
+	for _, problem := range result.Problems {
+		types = append(types, problem.Type)
+		if problem.Repaired != repaired {
+			t.Errorf("expected problem %q repaired:%v, got %v", problem.Type, repaired, problem.Repaired)
+		}
+	}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected problems %v, got %v", expected, types)
+	}
+}
+
+func TestIntegrity(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err := WFS.MakeFile(ctx, zoneId, "f1", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	data := []byte(strings.Repeat("a", 50) + strings.Repeat("b", 50) + strings.Repeat("c", 50))
+	err = WFS.WriteFile(ctx, zoneId, "f1", data)
+	if err != nil {
+		t.Fatalf("error writing data: %v", err)
+	}
+	result, err := WFS.Check(ctx, false)
+	if err != nil {
+		t.Fatalf("error checking filestore: %v", err)
+	}
+	checkProblems(t, result, nil, false)
+	if result.NumBlobs != 3 || result.NumParts != 3 {
+		t.Errorf("expected 3 blobs and 3 parts, got %d and %d", result.NumBlobs, result.NumParts)
+	}
+
+	// the data of part 1 does not match its checksum
+	parts := getDbParts(t, ctx, zoneId, "f1")
+	err = WithTx(ctx, func(tx *TxWrap) error {
+		tx.Exec("UPDATE db_file_blob SET data = ?, compression = ? WHERE hash = ?", []byte(strings.Repeat("x", 50)), PartCompression_None, parts[1].Hash)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error updating blob: %v", err)
+	}
+	result, err = WFS.Check(ctx, false)
+	if err != nil {
+		t.Fatalf("error checking filestore: %v", err)
+	}
+	checkProblems(t, result, []string{CheckProblem_CorruptBlob}, false)
+	if len(result.Problems) > 0 && (result.Problems[0].Name != "f1" || result.Problems[0].PartIdx != 1) {
+		t.Errorf("expected the problem to be part 1 of f1, got %s part %d", result.Problems[0].Name, result.Problems[0].PartIdx)
+	}
+	// the corrupt part is quarantined when it is read, and reads as zeros
+	WFS.clearCache()
+	expected := append(append(append([]byte{}, data[:50]...), make([]byte, 50)...), data[100:]...)
+	checkFileData(t, ctx, zoneId, "f1", string(expected))
+	if len(getDbParts(t, ctx, zoneId, "f1")) != 2 {
+		t.Errorf("expected the corrupt part to be deleted")
+	}
+
+	// part 2 refers to a missing blob (its blob's refcount is then off), and a line index of a deleted file
+	err = WithTx(ctx, func(tx *TxWrap) error {
+		tx.Exec("UPDATE db_file_data SET hash = 'missing' WHERE zoneid = ? AND name = ? AND partidx = 2", zoneId, "f1")
+		tx.Exec("INSERT INTO db_file_lineidx (zoneid, name, partidx, fileoffset, numlines) VALUES (?, 'deleted', 0, 0, 1)", zoneId)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error updating parts: %v", err)
+	}
+	result, err = WFS.Check(ctx, true)
+	if err != nil {
+		t.Fatalf("error checking filestore: %v", err)
+	}
+	checkProblems(t, result, []string{CheckProblem_MissingBlob, CheckProblem_Orphan, CheckProblem_RefCount}, true)
+	result, err = WFS.Check(ctx, false)
+	if err != nil {
+		t.Fatalf("error checking filestore: %v", err)
+	}
+	checkProblems(t, result, nil, false)
+	if result.NumParts != 1 || result.NumQuarantined != 2 {
+		t.Errorf("expected 1 part and 2 quarantined parts, got %d and %d", result.NumParts, result.NumQuarantined)
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "f1", string(append(append([]byte{}, data[:50]...), make([]byte, 100)...)))
+}
+
+func TestEvictParts(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err := WFS.MakeFile(ctx, zoneId, "e1", nil, wshrpc.FileOpts{LineIndex: true})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	var expected bytes.Buffer
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&expected, "line %d\n", i)
+	}
+	WFS.AppendData(ctx, zoneId, "e1", expected.Bytes())
+	WFS.FlushCache(ctx)
+	// 220 bytes, parts 0-4
+	freed, err := WFS.EvictParts(ctx, zoneId, "e1", 2)
+	if err != nil || freed <= 0 {
+		t.Fatalf("expected parts to be evicted, freed:%d err:%v", freed, err)
+	}
+	file, _ := WFS.Stat(ctx, zoneId, "e1")
+	if file.TrimOffset != 100 || file.DataStartIdx() != 100 || file.DataLength() != int64(expected.Len()-100) {
+		t.Errorf("expected the file to start at 100, got %+v", file)
+	}
+	WFS.clearCache()
+	offset, data, err := WFS.ReadFile(ctx, zoneId, "e1")
+	if err != nil || offset != 100 || string(data) != expected.String()[100:] {
+		t.Errorf("unexpected data after evicting, offset:%d data:%q err:%v", offset, data, err)
+	}
+	// writes before the evicted parts are dropped
+	WFS.WriteAt(ctx, zoneId, "e1", 90, []byte("0123456789AB"))
+	checkFileDataAt(t, ctx, zoneId, "e1", 100, "AB")
+	checkLines(t, ctx, zoneId, "e1", 28, 2, "line 28\nline 29\n")
+	parts := getDbParts(t, ctx, zoneId, "e1")
+	if len(parts) != 3 || parts[0].PartIdx != 2 {
+		t.Errorf("expected the first 2 parts to be deleted, got %d parts", len(parts))
+	}
+	// the last part is kept
+	WFS.EvictParts(ctx, zoneId, "e1", 10)
+	file, _ = WFS.Stat(ctx, zoneId, "e1")
+	if file.TrimOffset != 200 {
+		t.Errorf("expected the file to start at 200, got %d", file.TrimOffset)
+	}
+	usage, err := WFS.GetUsage(ctx)
+	if err != nil || len(usage) != 1 || usage[0].Evicted != 200 || usage[0].Size != int64(expected.Len()-200) || usage[0].StoredSize <= 0 {
+		t.Errorf("unexpected usage %+v, err:%v", usage, err)
+	}
+	// replacing the file resets it
+	WFS.WriteFile(ctx, zoneId, "e1", []byte("hello"))
+	checkFileData(t, ctx, zoneId, "e1", "hello")
+
+	err = WFS.MakeFile(ctx, zoneId, "c1", nil, wshrpc.FileOpts{Circular: true, MaxSize: 100})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	_, err = WFS.EvictParts(ctx, zoneId, "c1", 1)
+	if err == nil {
+		t.Errorf("expected an error evicting parts from a circular file")
+	}
+}
+
+// replicates the blobs and the changed files to the bucket, returns the objects
+func syncReplica(t *testing.T, ctx context.Context, bucket map[string][]byte) ([]*ReplicaObject, []*ReplicaObject) {
+	afterHash := ""
+	for {
+		objs, lastHash, err := WFS.GetUnreplicatedBlobs(ctx, afterHash, 2)
+		if err != nil {
+			t.Fatalf("error getting blobs: %v", err)
+		}
+		if lastHash == "" {
+			break
+		}
+		afterHash = lastHash
+		for _, obj := range objs {
+			bucket[obj.Key] = obj.Data
+		}
+		err = WFS.SetBlobsReplicated(ctx, objs)
+		if err != nil {
+			t.Fatalf("error setting blobs replicated: %v", err)
+		}
+	}
+	changed, deleted, err := WFS.GetReplicaFileChanges(ctx)
+	if err != nil {
+		t.Fatalf("error getting file changes: %v", err)
+	}
+	for _, obj := range changed {
+		bucket[obj.Key] = obj.Data
+	}
+	for _, obj := range deleted {
+		delete(bucket, obj.Key)
+	}
+	err = WFS.SetFilesReplicated(ctx, changed)
+	if err == nil {
+		err = WFS.RemoveReplicaFiles(ctx, deleted)
+	}
+	if err != nil {
+		t.Fatalf("error recording files: %v", err)
+	}
+	return changed, deleted
+}
+
+func TestReplica(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+	defer SetReplica(nil, nil)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	bucket := make(map[string][]byte)
+	var numFetches atomic.Int32
+	fetchFn := func(ctx context.Context, key string) ([]byte, error) {
+		numFetches.Add(1)
+		obj, ok := bucket[key]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		return obj, nil
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	err := SetReplica(key, fetchFn)
+	if err != nil {
+		t.Fatalf("error setting replica: %v", err)
+	}
+	err = WFS.SetReplicaTarget(ctx, "bucket1")
+	if err != nil {
+		t.Fatalf("error setting replica target: %v", err)
+	}
+	zoneId := uuid.NewString()
+	err = WFS.MakeFile(ctx, zoneId, "f1", wshrpc.FileMeta{"a": "b"}, wshrpc.FileOpts{LineIndex: true})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	data := strings.Repeat("line one\n", 8) + strings.Repeat("z", 50)
+	_, err = WFS.Append(ctx, zoneId, "f1", []byte(data))
+	if err != nil {
+		t.Fatalf("error appending data: %v", err)
+	}
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	changed, _ := syncReplica(t, ctx, bucket)
+	if len(changed) != 1 || len(bucket) != 4 {
+		t.Fatalf("expected 1 file and 4 objects in the bucket, got %d and %d", len(changed), len(bucket))
+	}
+	for objKey, obj := range bucket {
+		if bytes.Contains(obj, []byte("line one")) || bytes.Contains(obj, []byte(zoneId)) {
+			t.Errorf("replica object %s is not encrypted", objKey)
+		}
+	}
+	changed, _ = syncReplica(t, ctx, bucket)
+	if len(changed) != 0 {
+		t.Errorf("expected no changes, got %d", len(changed))
+	}
+
+	// the offloaded blobs are read from the replica
+	numBlobs, freed, err := WFS.OffloadBlobs(ctx, time.Now().UnixMilli()+1000)
+	if err != nil {
+		t.Fatalf("error offloading blobs: %v", err)
+	}
+	if numBlobs != 3 || freed == 0 {
+		t.Errorf("expected 3 blobs to be offloaded, got %d (%d bytes)", numBlobs, freed)
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "f1", data)
+	if numFetches.Load() != 3 {
+		t.Errorf("expected 3 fetches, got %d", numFetches.Load())
+	}
+	result, err := WFS.Check(ctx, false)
+	if err != nil {
+		t.Fatalf("error checking filestore: %v", err)
+	}
+	checkProblems(t, result, nil, false)
+	err = WFS.SetReplicaTarget(ctx, "bucket2")
+	if err == nil {
+		t.Errorf("expected an error changing the replica with offloaded blobs")
+	}
+
+	// restore into another zone
+	manifestKey := getManifestKey(t, bucket)
+	manifest, err := DecodeReplicaFile(manifestKey, bucket[manifestKey])
+	if err != nil {
+		t.Fatalf("error decoding manifest: %v", err)
+	}
+	zoneId2 := uuid.NewString()
+	err = WFS.RestoreFile(ctx, manifest, zoneId2, false)
+	if err != nil {
+		t.Fatalf("error restoring file: %v", err)
+	}
+	checkFileData(t, ctx, zoneId2, "f1", data)
+	file, err := WFS.Stat(ctx, zoneId2, "f1")
+	if err != nil {
+		t.Fatalf("error stating file: %v", err)
+	}
+	if file.Meta["a"] != "b" || file.NumLines != 8 || !file.Opts.LineIndex {
+		t.Errorf("unexpected restored file: %+v", file)
+	}
+	lines, err := WFS.ReadLines(ctx, zoneId2, "f1", 7, 1)
+	if err != nil || string(lines.Data) != "line one\n" {
+		t.Errorf("unexpected lines of the restored file: %v %v", lines, err)
+	}
+	err = WFS.RestoreFile(ctx, manifest, zoneId2, false)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected fs.ErrExist restoring over a file, got %v", err)
+	}
+
+	// the blobs are brought back, the replica is not needed to read them
+	numRehydrated, err := WFS.RehydrateBlobs(ctx, 10)
+	if err != nil || numRehydrated != 3 {
+		t.Errorf("expected 3 blobs to be brought back, got %d: %v", numRehydrated, err)
+	}
+	SetReplica(nil, nil)
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "f1", data)
+	err = SetReplica(key, fetchFn)
+	if err != nil {
+		t.Fatalf("error setting replica: %v", err)
+	}
+
+	// the manifest of a deleted file is deleted, the restored file is replicated
+	err = WFS.DeleteFile(ctx, zoneId, "f1")
+	if err != nil {
+		t.Fatalf("error deleting file: %v", err)
+	}
+	changed, deleted := syncReplica(t, ctx, bucket)
+	if len(changed) != 1 || len(deleted) != 1 {
+		t.Errorf("expected 1 changed and 1 deleted file, got %d and %d", len(changed), len(deleted))
+	}
+	// a new replica gets everything again
+	err = WFS.SetReplicaTarget(ctx, "bucket2")
+	if err != nil {
+		t.Fatalf("error changing the replica: %v", err)
+	}
+	objs, _, err := WFS.GetUnreplicatedBlobs(ctx, "", 10)
+	if err != nil || len(objs) != 3 {
+		t.Errorf("expected 3 blobs to replicate, got %d: %v", len(objs), err)
+	}
+}
+
+func getManifestKey(t *testing.T, bucket map[string][]byte) string {
+	for objKey := range bucket {
+		if strings.HasPrefix(objKey, ReplicaFilePrefix) {
+			return objKey
+		}
+	}
+	t.Fatalf("no manifest in the bucket")
+	return ""
+}
+
+func TestImportFile(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	localPath := filepath.Join(t.TempDir(), "notes.txt")
+	data := strings.Repeat("imported data\n", 10)
+	err := os.WriteFile(localPath, []byte(data), 0644)
+	if err != nil {
+		t.Fatalf("error writing local file: %v", err)
+	}
+	file, err := WFS.ImportFile(ctx, zoneId, localPath, ImportOpts{})
+	if err != nil {
+		t.Fatalf("error importing file: %v", err)
+	}
+	sum := sha256.Sum256([]byte(data))
+	if file.Name != "notes.txt" || file.Size != int64(len(data)) || file.Meta[ImportMeta_Sha256] != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected imported file: %+v", file)
+	}
+	if file.Meta[ImportMeta_MimeType] != "text/plain" || file.Meta[ImportMeta_Path] != localPath {
+		t.Errorf("unexpected import meta: %v", file.Meta)
+	}
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "notes.txt", data)
+
+	// the same content is not imported again
+	file2, err := WFS.ImportFile(ctx, zoneId, localPath, ImportOpts{})
+	if err != nil || file2.Name != "notes.txt" || file2.CreatedTs != file.CreatedTs {
+		t.Errorf("expected the imported file back, got %+v: %v", file2, err)
+	}
+	_, err = WFS.ImportFile(ctx, zoneId, localPath, ImportOpts{Name: "copy.txt"})
+	if err != nil {
+		t.Fatalf("error importing file with a name: %v", err)
+	}
+	checkFileData(t, ctx, zoneId, "copy.txt", data)
+	files, _ := WFS.ListFiles(ctx, zoneId)
+	if len(files) != 2 {
+		t.Errorf("expected 2 files (no staging files), got %d", len(files))
+	}
+
+	// a reference is read from the local file, until the local file changes
+	refFile, err := WFS.ImportFile(ctx, zoneId, localPath, ImportOpts{Name: "ref.txt", Reference: true})
+	if err != nil {
+		t.Fatalf("error importing reference: %v", err)
+	}
+	if !refFile.IsReference() || refFile.Size != int64(len(data)) {
+		t.Errorf("unexpected reference: %+v", refFile)
+	}
+	checkFileDataAt(t, ctx, zoneId, "ref.txt", 14, "imported data\n")
+	err = WFS.WriteAt(ctx, zoneId, "ref.txt", 0, []byte("x"))
+	if err == nil {
+		t.Errorf("expected an error writing to a reference")
+	}
+	err = os.WriteFile(localPath, []byte("changed"), 0644)
+	if err != nil {
+		t.Fatalf("error writing local file: %v", err)
+	}
+	_, _, err = WFS.ReadFile(ctx, zoneId, "ref.txt")
+	if !errors.Is(err, ErrReferenceChanged) {
+		t.Errorf("expected ErrReferenceChanged, got %v", err)
+	}
+	// the copy is not affected, and a file with other content is not replaced without Overwrite
+	checkFileData(t, ctx, zoneId, "notes.txt", data)
+	_, err = WFS.ImportFile(ctx, zoneId, localPath, ImportOpts{})
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected fs.ErrExist, got %v", err)
+	}
+	_, err = WFS.ImportFile(ctx, zoneId, localPath, ImportOpts{Overwrite: true})
+	if err != nil {
+		t.Fatalf("error importing with overwrite: %v", err)
+	}
+	checkFileData(t, ctx, zoneId, "notes.txt", "changed")
+	err = WFS.WriteFile(ctx, zoneId, "ref.txt", []byte("regular"))
+	if err != nil {
+		t.Fatalf("error writing reference: %v", err)
+	}
+	checkFileData(t, ctx, zoneId, "ref.txt", "regular")
+}
+
+func TestExportFile(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	dir := t.TempDir()
+	data := strings.Repeat("exported data\n", 10)
+	err := WFS.MakeFile(ctx, zoneId, "cast:1", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	err = WFS.WriteFile(ctx, zoneId, "cast:1", []byte(data))
+	if err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	var lastOffset int64
+	path, err := WFS.ExportFile(ctx, zoneId, "cast:1", dir, ExportOpts{ProgressFn: func(offset, size int64) { lastOffset = offset }})
+	if err != nil {
+		t.Fatalf("error exporting file: %v", err)
+	}
+	if path != filepath.Join(dir, "cast_1") || lastOffset != int64(len(data)) {
+		t.Errorf("unexpected export path %q (progress %d)", path, lastOffset)
+	}
+	localData, err := os.ReadFile(path)
+	if err != nil || string(localData) != data {
+		t.Errorf("unexpected local data %q: %v", localData, err)
+	}
+	// a local file with the name gets a new name, is replaced or is skipped
+	path, err = WFS.ExportFile(ctx, zoneId, "cast:1", dir, ExportOpts{})
+	if err != nil || path != filepath.Join(dir, "cast_1 (1)") {
+		t.Errorf("expected a new name, got %q: %v", path, err)
+	}
+	_, err = WFS.ExportFile(ctx, zoneId, "cast:1", dir, ExportOpts{Collision: ExportCollision_Skip})
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected fs.ErrExist, got %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "cast_1"), []byte("old"), 0644)
+	path, err = WFS.ExportFile(ctx, zoneId, "cast:1", dir, ExportOpts{Collision: ExportCollision_Overwrite})
+	if err != nil || path != filepath.Join(dir, "cast_1") {
+		t.Errorf("expected the file to be replaced, got %q: %v", path, err)
+	}
+	localData, _ = os.ReadFile(path)
+	if string(localData) != data {
+		t.Errorf("unexpected local data %q", localData)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected 2 local files (no temp files), got %d", len(entries))
+	}
+	if ExportFileName("../x/y") != ".._x_y" || ExportFileName("..") != "_" {
+		t.Errorf("unexpected export names %q %q", ExportFileName("../x/y"), ExportFileName(".."))
+	}
+}
+
+func numCachedParts(zoneId string, name string) int {
+	readCacheLock.Lock()
+	defer readCacheLock.Unlock()
+	return len(readCacheFiles[cacheKey{ZoneId: zoneId, Name: name}])
+}
+
+func waitForReadAhead(t *testing.T, zoneId string, name string, numParts int) {
+	for i := 0; i < 100; i++ {
+		readAheadLock.Lock()
+		pending := readAheadPending[cacheKey{ZoneId: zoneId, Name: name}]
+		readAheadLock.Unlock()
+		if numCachedParts(zoneId, name) >= numParts && !pending {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("read-ahead parts not cached")
+}
+
+func TestReadRanges(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+	// only the read-ahead parts are cached
+	SetReadCache(DefaultReadCacheBytes, ReadCachePolicy_ReadAhead)
+	defer SetReadCache(DefaultReadCacheBytes, ReadCachePolicy_Lru)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err := WFS.MakeFile(ctx, zoneId, "data", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	var data []byte
+	for i := 0; i < 500; i++ {
+		data = append(data, byte('a'+i%26))
+	}
+	err = WFS.WriteFile(ctx, zoneId, "data", data)
+	if err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	WFS.clearCache()
+	ranges := []ReadRange{{Offset: 10, Size: 20}, {Offset: 45, Size: 60}, {Offset: 480, Size: 100}, {Offset: 600, Size: 10}}
+	file, rdata, err := WFS.ReadRanges(ctx, zoneId, "data", ranges, 100)
+	if err != nil {
+		t.Fatalf("error reading ranges: %v", err)
+	}
+	if file.Size != 500 || len(rdata) != 4 {
+		t.Fatalf("unexpected result: size %d, %d ranges", file.Size, len(rdata))
+	}
+	if string(rdata[0].Data) != string(data[10:30]) || string(rdata[1].Data) != string(data[45:105]) || string(rdata[2].Data) != string(data[480:]) || len(rdata[3].Data) != 0 {
+		t.Errorf("unexpected range data: %q %q %q %q", rdata[0].Data, rdata[1].Data, rdata[2].Data, rdata[3].Data)
+	}
+	// the read-ahead starts after the end of the last range (there is nothing after 500)
+	if numCached := numCachedParts(zoneId, "data"); numCached != 0 {
+		t.Errorf("expected no read-ahead parts, got %d", numCached)
+	}
+	_, _, err = WFS.ReadRanges(ctx, zoneId, "data", []ReadRange{{Offset: 0, Size: 50}}, 100)
+	if err != nil {
+		t.Fatalf("error reading ranges: %v", err)
+	}
+	waitForReadAhead(t, zoneId, "data", 2)
+	_, rdata, err = WFS.ReadRanges(ctx, zoneId, "data", []ReadRange{{Offset: 60, Size: 30}}, 0)
+	if err != nil || string(rdata[0].Data) != string(data[60:90]) {
+		t.Errorf("unexpected read-ahead data %q: %v", rdata[0].Data, err)
+	}
+	// the read-ahead parts are dropped when the file changes
+	err = WFS.WriteAt(ctx, zoneId, "data", 60, []byte("XYZ"))
+	if err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if numCached := numCachedParts(zoneId, "data"); numCached != 0 {
+		t.Errorf("expected the read-ahead parts to be dropped, got %d", numCached)
+	}
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	WFS.clearCache()
+	checkFileDataAt(t, ctx, zoneId, "data", 58, string(data[58:60])+"XYZ"+string(data[63:70]))
+	_, _, err = WFS.ReadRanges(ctx, zoneId, "data", []ReadRange{{Offset: -1, Size: 10}}, 0)
+	if err == nil {
+		t.Errorf("expected an error for a negative offset")
+	}
+}
+
+func TestReadCache(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+	// room for 4 parts
+	err := SetReadCache(4*partDataSize, ReadCachePolicy_Lfu)
+	if err != nil {
+		t.Fatalf("error setting the read cache: %v", err)
+	}
+	defer SetReadCache(DefaultReadCacheBytes, ReadCachePolicy_Lru)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err = WFS.MakeFile(ctx, zoneId, "data", nil, wshrpc.FileOpts{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	data := strings.Repeat("0123456789", 100)
+	err = WFS.WriteFile(ctx, zoneId, "data", []byte(data))
+	if err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	WFS.clearCache()
+	stats := WFS.GetCacheStats()
+	// part 0 is read 3 times (2 hits)
+	for i := 0; i < 3; i++ {
+		checkFileDataAt(t, ctx, zoneId, "data", 0, data[0:50])
+	}
+	newStats := WFS.GetCacheStats()
+	if newStats.Misses-stats.Misses != 1 || newStats.Hits-stats.Hits != 2 {
+		t.Errorf("expected 1 miss and 2 hits, got %d and %d", newStats.Misses-stats.Misses, newStats.Hits-stats.Hits)
+	}
+	// reading through the file evicts the other parts, the part read most often stays
+	checkFileDataAt(t, ctx, zoneId, "data", 50, data[50:500])
+	newStats = WFS.GetCacheStats()
+	if newStats.CachedParts != 4 || newStats.Evictions-stats.Evictions != 6 || newStats.Policy != ReadCachePolicy_Lfu {
+		t.Errorf("unexpected stats %+v", newStats)
+	}
+	checkFileDataAt(t, ctx, zoneId, "data", 0, data[0:50])
+	if WFS.GetCacheStats().Misses != newStats.Misses {
+		t.Errorf("expected part 0 to be in the read cache")
+	}
+	// a part read from another version of the file is not used
+	if parts := getCachedParts(&WaveFile{ZoneId: zoneId, Name: "data", Size: 1}, []int{0}); len(parts) != 0 {
+		t.Errorf("expected no parts for another version of the file, got %d", len(parts))
+	}
+	err = SetReadCache(0, ReadCachePolicy_Lru)
+	if err != nil || WFS.GetCacheStats().CachedParts != 0 {
+		t.Errorf("expected the read cache to be emptied: %v", err)
+	}
+	if SetReadCache(0, "mru") == nil {
+		t.Errorf("expected an error for an invalid policy")
+	}
+}
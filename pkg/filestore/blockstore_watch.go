@@ -0,0 +1,199 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filestore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+)
+
+// Watch follows a file: the data that is appended to it, and when it is truncated (or replaced), deleted or
+// recreated.  the files are in the db (there is nothing on disk for fsnotify to watch), so the writes to the
+// filestore wake up the watchers of the file directly.  the watchers also poll the file (WatchPollTime), that
+// picks up changes made to the db outside of the filestore.
+
+const (
+	WatchEvent_Data     = "data"     // Data was written at Offset (for circular files, the data dropped before Offset is skipped)
+	WatchEvent_Truncate = "truncate" // the file was truncated or replaced, the data is read again from Offset
+	WatchEvent_Rotate   = "rotate"   // the file was deleted and made again, the data is read again from Offset
+	WatchEvent_Deleted  = "deleted"  // the file was deleted (it is still watched in case it is made again)
+)
+
+const WatchPollTime = 1 * time.Second
+const watchReadSize = 64 * 1024
+
+type WatchEvent struct {
+	Type   string
+	Offset int64
+	Data   []byte
+}
+
+type fileWatcher struct {
+	notifyCh  chan struct{}
+	lock      *sync.Mutex
+	truncated bool
+}
+
+var watchersLock = &sync.Mutex{}
+var fileWatchers = make(map[cacheKey]map[*fileWatcher]bool)
+
+func (w *fileWatcher) notify(truncated bool) {
+	if truncated {
+		w.lock.Lock()
+		w.truncated = true
+		w.lock.Unlock()
+	}
+	select {
+	case w.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *fileWatcher) takeTruncated() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	truncated := w.truncated
+	w.truncated = false
+	return truncated
+}
+
+// called when a file changes (the parts of the file in the read cache are dropped too), does not block (it is
+// called with the entry lock held)
+func notifyWatchers(zoneId string, name string, truncated bool) {
+	dropCachedParts(zoneId, name)
+	watchersLock.Lock()
+	defer watchersLock.Unlock()
+	for w := range fileWatchers[cacheKey{ZoneId: zoneId, Name: name}] {
+		w.notify(truncated)
+	}
+}
+
+func addWatcher(key cacheKey) *fileWatcher {
+	w := &fileWatcher{notifyCh: make(chan struct{}, 1), lock: &sync.Mutex{}}
+	watchersLock.Lock()
+	defer watchersLock.Unlock()
+	if fileWatchers[key] == nil {
+		fileWatchers[key] = make(map[*fileWatcher]bool)
+	}
+	fileWatchers[key][w] = true
+	return w
+}
+
+func removeWatcher(key cacheKey, w *fileWatcher) {
+	watchersLock.Lock()
+	defer watchersLock.Unlock()
+	delete(fileWatchers[key], w)
+	if len(fileWatchers[key]) == 0 {
+		delete(fileWatchers, key)
+	}
+}
+
+type watchState struct {
+	offset    int64
+	createdTs int64
+	deleted   bool
+}
+
+// returns false if ctx is done
+func sendWatchEvent(ctx context.Context, ch chan WatchEvent, event WatchEvent) bool {
+	select {
+	case ch <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sends the events for the changes since the last check, returns false if ctx is done
+func (s *FileStore) checkWatchedFile(ctx context.Context, zoneId string, name string, state *watchState, truncated bool, ch chan WatchEvent) bool {
+	file, err := s.Stat(ctx, zoneId, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		if state.deleted {
+			return true
+		}
+		state.deleted = true
+		return sendWatchEvent(ctx, ch, WatchEvent{Type: WatchEvent_Deleted, Offset: state.offset})
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("error checking watched file %s:%s: %v\n", zoneId, name, err)
+		}
+		return ctx.Err() == nil
+	}
+	if state.deleted || file.CreatedTs != state.createdTs {
+		state.deleted = false
+		state.createdTs = file.CreatedTs
+		state.offset = file.DataStartIdx()
+		if !sendWatchEvent(ctx, ch, WatchEvent{Type: WatchEvent_Rotate, Offset: state.offset}) {
+			return false
+		}
+	} else if truncated || file.Size < state.offset {
+		state.offset = file.DataStartIdx()
+		if !sendWatchEvent(ctx, ch, WatchEvent{Type: WatchEvent_Truncate, Offset: state.offset}) {
+			return false
+		}
+	}
+	for state.offset < file.Size {
+		readOffset, data, err := s.ReadAt(ctx, zoneId, name, state.offset, min(watchReadSize, file.Size-state.offset))
+		if err != nil {
+			// the file changed after the stat, the next check catches up
+			return ctx.Err() == nil
+		}
+		if len(data) == 0 {
+			break
+		}
+		state.offset = readOffset + int64(len(data))
+		if !sendWatchEvent(ctx, ch, WatchEvent{Type: WatchEvent_Data, Offset: readOffset, Data: data}) {
+			return false
+		}
+	}
+	return true
+}
+
+// streams the data written to the file from fromOffset (a negative fromOffset is the end of the file) until ctx is
+// done, then the channel is closed.  returns fs.ErrNotExist if the file does not exist.
+func (s *FileStore) Watch(ctx context.Context, zoneId string, name string, fromOffset int64) (<-chan WatchEvent, error) {
+	key := cacheKey{ZoneId: zoneId, Name: name}
+	// added before the stat so no writes are missed
+	w := addWatcher(key)
+	file, err := s.Stat(ctx, zoneId, name)
+	if err != nil {
+		removeWatcher(key, w)
+		return nil, err
+	}
+	state := &watchState{offset: fromOffset, createdTs: file.CreatedTs}
+	if fromOffset < 0 || fromOffset > file.Size {
+		state.offset = file.Size
+	}
+	ch := make(chan WatchEvent, 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("FileStore.Watch", recover())
+		}()
+		defer close(ch)
+		defer removeWatcher(key, w)
+		ticker := time.NewTicker(WatchPollTime)
+		defer ticker.Stop()
+		truncated := false
+		for {
+			if !s.checkWatchedFile(ctx, zoneId, name, state, truncated, ch) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.notifyCh:
+			case <-ticker.C:
+			}
+			truncated = w.takeTruncated()
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,258 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package grpcapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// the requests and responses are (dynamic) proto messages, the rpcs take and return json.  the fields are
+// converted by their json names.  unset fields are left out (so they get their zero values, like omitempty).
+
+// converts a message to the json value of its rpc data
+func protoToJson(msg protoreflect.Message) (any, error) {
+	switch "." + string(msg.Descriptor().FullName()) {
+	case wktEmpty:
+		return nil, nil
+	case wktValue:
+		var val structpb.Value
+		if err := copyMessage(msg.Interface(), &val); err != nil {
+			return nil, err
+		}
+		return val.AsInterface(), nil
+	case wktStruct:
+		var val structpb.Struct
+		if err := copyMessage(msg.Interface(), &val); err != nil {
+			return nil, err
+		}
+		return val.AsMap(), nil
+	}
+	rtn := make(map[string]any)
+	var rangeErr error
+	msg.Range(func(fd protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		var jsonVal any
+		jsonVal, rangeErr = fieldToJson(fd, val)
+		if rangeErr != nil {
+			return false
+		}
+		rtn[fd.JSONName()] = jsonVal
+		return true
+	})
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+	return rtn, nil
+}
+
+func fieldToJson(fd protoreflect.FieldDescriptor, val protoreflect.Value) (any, error) {
+	if fd.IsList() {
+		list := val.List()
+		rtn := make([]any, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			elem, err := singularToJson(fd, list.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			rtn = append(rtn, elem)
+		}
+		return rtn, nil
+	}
+	if fd.IsMap() {
+		rtn := make(map[string]any)
+		var rangeErr error
+		val.Map().Range(func(key protoreflect.MapKey, mapVal protoreflect.Value) bool {
+			rtn[key.String()], rangeErr = singularToJson(fd.MapValue(), mapVal)
+			return rangeErr == nil
+		})
+		return rtn, rangeErr
+	}
+	return singularToJson(fd, val)
+}
+
+func singularToJson(fd protoreflect.FieldDescriptor, val protoreflect.Value) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoToJson(val.Message())
+	case protoreflect.BytesKind:
+		return val.Bytes(), nil
+	}
+	return val.Interface(), nil
+}
+
+// sets the fields of a message from the json value of an rpc result
+func jsonToProto(val any, msg protoreflect.Message) error {
+	switch "." + string(msg.Descriptor().FullName()) {
+	case wktEmpty:
+		return nil
+	case wktValue:
+		pbVal, err := structpb.NewValue(normalizeJson(val))
+		if err != nil {
+			return err
+		}
+		return copyMessage(pbVal, msg.Interface())
+	case wktStruct:
+		mapVal, ok := normalizeJson(val).(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected an object, got %T", val)
+		}
+		pbVal, err := structpb.NewStruct(mapVal)
+		if err != nil {
+			return err
+		}
+		return copyMessage(pbVal, msg.Interface())
+	}
+	if val == nil {
+		return nil
+	}
+	obj, ok := val.(map[string]any)
+	if !ok {
+		return fmt.Errorf("expected an object for %s, got %T", msg.Descriptor().FullName(), val)
+	}
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		fieldVal, ok := obj[fd.JSONName()]
+		if !ok || fieldVal == nil {
+			continue
+		}
+		err := setField(msg, fd, fieldVal)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fd.JSONName(), err)
+		}
+	}
+	return nil
+}
+
+func setField(msg protoreflect.Message, fd protoreflect.FieldDescriptor, val any) error {
+	if fd.IsList() {
+		arr, ok := val.([]any)
+		if !ok {
+			return fmt.Errorf("expected an array, got %T", val)
+		}
+		list := msg.Mutable(fd).List()
+		for _, elem := range arr {
+			protoVal, err := jsonToSingular(fd, list.NewElement, elem)
+			if err != nil {
+				return err
+			}
+			list.Append(protoVal)
+		}
+		return nil
+	}
+	if fd.IsMap() {
+		obj, ok := val.(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected an object, got %T", val)
+		}
+		mapVal := msg.Mutable(fd).Map()
+		for key, elem := range obj {
+			protoVal, err := jsonToSingular(fd.MapValue(), mapVal.NewValue, elem)
+			if err != nil {
+				return err
+			}
+			mapVal.Set(protoreflect.ValueOfString(key).MapKey(), protoVal)
+		}
+		return nil
+	}
+	if fd.Kind() == protoreflect.MessageKind {
+		return jsonToProto(val, msg.Mutable(fd).Message())
+	}
+	protoVal, err := jsonToSingular(fd, nil, val)
+	if err != nil {
+		return err
+	}
+	msg.Set(fd, protoVal)
+	return nil
+}
+
+func jsonToSingular(fd protoreflect.FieldDescriptor, newMsgFn func() protoreflect.Value, val any) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		protoVal := newMsgFn()
+		err := jsonToProto(val, protoVal.Message())
+		return protoVal, err
+	case protoreflect.StringKind:
+		if str, ok := val.(string); ok {
+			return protoreflect.ValueOfString(str), nil
+		}
+	case protoreflect.BoolKind:
+		if bval, ok := val.(bool); ok {
+			return protoreflect.ValueOfBool(bval), nil
+		}
+	case protoreflect.BytesKind:
+		if str, ok := val.(string); ok {
+			barr, err := base64.StdEncoding.DecodeString(str)
+			return protoreflect.ValueOfBytes(barr), err
+		}
+	case protoreflect.Int64Kind, protoreflect.Uint64Kind, protoreflect.DoubleKind:
+		if jsonNum, ok := val.(json.Number); ok && fd.Kind() == protoreflect.Int64Kind {
+			if num, err := jsonNum.Int64(); err == nil {
+				return protoreflect.ValueOfInt64(num), nil
+			}
+		}
+		num, err := toFloat(val)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		switch fd.Kind() {
+		case protoreflect.Int64Kind:
+			return protoreflect.ValueOfInt64(int64(num)), nil
+		case protoreflect.Uint64Kind:
+			return protoreflect.ValueOfUint64(uint64(num)), nil
+		}
+		return protoreflect.ValueOfFloat64(num), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("cannot convert %T to %s", val, fd.Kind())
+}
+
+func toFloat(val any) (float64, error) {
+	switch num := val.(type) {
+	case float64:
+		return num, nil
+	case json.Number:
+		return num.Float64()
+	case int64:
+		return float64(num), nil
+	case int:
+		return float64(num), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", val)
+}
+
+// structpb does not take json.Numbers
+func normalizeJson(val any) any {
+	switch tval := val.(type) {
+	case json.Number:
+		num, _ := tval.Float64()
+		return num
+	case map[string]any:
+		rtn := make(map[string]any, len(tval))
+		for key, elem := range tval {
+			rtn[key] = normalizeJson(elem)
+		}
+		return rtn
+	case []any:
+		rtn := make([]any, len(tval))
+		for idx, elem := range tval {
+			rtn[idx] = normalizeJson(elem)
+		}
+		return rtn
+	}
+	return val
+}
+
+// copies between a dynamic message and a generated one (of the same type)
+func copyMessage(src proto.Message, dest proto.Message) error {
+	barr, err := proto.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return proto.UnmarshalOptions{Merge: true}.Unmarshal(barr, dest)
+}
@@ -0,0 +1,243 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package grpcapi serves the core rpcs (objects, blocks, files, and connections) over grpc, so integrations that
+// are not written in javascript (ci jobs, ide plugins) get typed clients in any language.  the services and
+// messages are generated from the wsh rpc declarations and their go types (see protogen.go), cmd/generategrpc
+// writes them to proto/wave/v1/wave.proto for protoc.  the server has no generated code: the requests are
+// decoded as dynamic messages, converted to json, and sent to wavesrv like any other rpc.  it is off by
+// default ("api:grpc"), and every call needs the automation api's token ("authorization: Bearer [token]").
+// the reflection service is also served, so tools like grpcurl work without the .proto file.
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+)
+
+const ProtoPackage = "wave.v1"
+const ProtoFileName = "wave/v1/wave.proto"
+const DefaultCallTimeout = 10 * time.Second
+
+const RequestFieldName = "data"
+const ResponseFieldName = "result"
+
+type serviceDef struct {
+	Name    string
+	Methods []string // the rpcs (by method name, without the Command suffix)
+}
+
+var Services = []serviceDef{
+	{"ObjectService", []string{"GetMeta", "SetMeta", "ResolveIds", "WorkspaceList"}},
+	{"BlockService", []string{"BlockInfo", "CreateBlock", "DeleteBlock", "SetView", "ControllerInput", "ControllerStop", "ControllerSignal"}},
+	{"FileService", []string{"FileInfo", "FileList", "FileRead", "FileWrite", "FileAppend", "FileCreate", "FileMkdir", "FileDelete", "FileMove", "FileCopy"}},
+	{"ConnectionService", []string{"ConnList", "ConnStatus", "ConnConnect", "ConnDisconnect", "ConnEnsure", "ConnMetrics"}},
+}
+
+type protoFile struct {
+	File    protoreflect.FileDescriptor
+	Methods map[string]*methodInfo
+}
+
+var loadOnce = &sync.Once{}
+var loadedFile *protoFile
+var loadErr error
+
+// builds the file descriptor (once) and registers it (for the reflection service)
+func loadProtoFile() (*protoFile, error) {
+	loadOnce.Do(func() {
+		b, err := buildProtoFile()
+		if err != nil {
+			loadErr = err
+			return
+		}
+		fd, err := protodesc.NewFile(b.File, protoregistry.GlobalFiles)
+		if err != nil {
+			loadErr = fmt.Errorf("invalid proto file: %w", err)
+			return
+		}
+		err = protoregistry.GlobalFiles.RegisterFile(fd)
+		if err != nil {
+			loadErr = fmt.Errorf("registering proto file: %w", err)
+			return
+		}
+		loadedFile = &protoFile{File: fd, Methods: b.Methods}
+	})
+	return loadedFile, loadErr
+}
+
+func checkToken(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authVal := range md.Get("authorization") {
+		reqToken, ok := strings.CutPrefix(authVal, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid token")
+}
+
+func makeServer(token string) *grpc.Server {
+	unaryAuth := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := checkToken(ctx, token); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	streamAuth := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkToken(ss.Context(), token); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return grpc.NewServer(grpc.UnaryInterceptor(unaryAuth), grpc.StreamInterceptor(streamAuth))
+}
+
+// serves the api on the listener (blocks until the listener is closed)
+func Serve(listener net.Listener, token string) error {
+	server, err := MakeServer(token)
+	if err != nil {
+		return err
+	}
+	return server.Serve(listener)
+}
+
+// a server with the wave services registered, call Serve on it
+func MakeServer(token string) (*grpc.Server, error) {
+	pf, err := loadProtoFile()
+	if err != nil {
+		return nil, err
+	}
+	server := makeServer(token)
+	services := pf.File.Services()
+	for i := 0; i < services.Len(); i++ {
+		svc := services.Get(i)
+		desc := &grpc.ServiceDesc{
+			ServiceName: string(svc.FullName()),
+			HandlerType: (*any)(nil),
+			Metadata:    ProtoFileName,
+		}
+		methods := svc.Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			fullMethod := fmt.Sprintf("/%s/%s", svc.FullName(), method.Name())
+			desc.Methods = append(desc.Methods, grpc.MethodDesc{
+				MethodName: string(method.Name()),
+				Handler:    makeMethodHandler(fullMethod, method, pf.Methods[fullMethod]),
+			})
+		}
+		server.RegisterService(desc, struct{}{})
+	}
+	reflection.Register(server)
+	return server, nil
+}
+
+// stops accepting connections and waits for the calls in flight, they are canceled when ctx is done
+func Shutdown(ctx context.Context, server *grpc.Server) error {
+	doneCh := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		return ctx.Err()
+	}
+}
+
+func makeMethodHandler(fullMethod string, method protoreflect.MethodDescriptor, info *methodInfo) grpc.MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := dynamicpb.NewMessage(method.Input())
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req any) (any, error) {
+			return callRpc(ctx, method, info, req.(*dynamicpb.Message))
+		}
+		if interceptor == nil {
+			return handler(ctx, req)
+		}
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}, handler)
+	}
+}
+
+func callRpc(ctx context.Context, method protoreflect.MethodDescriptor, info *methodInfo, req *dynamicpb.Message) (rtn any, rtnErr error) {
+	defer func() {
+		panicErr := panichandler.PanicHandler("grpcapi:callRpc", recover())
+		if panicErr != nil {
+			rtnErr = status.Error(codes.Internal, panicErr.Error())
+		}
+	}()
+	var data any
+	if info.Decl.CommandDataType != nil {
+		reqData, err := protoToJson(req)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		if reqMap, ok := reqData.(map[string]any); ok && info.WrapRequest {
+			reqData = reqMap[RequestFieldName]
+		}
+		data = reqData
+	}
+	timeout := DefaultCallTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	rpcOpts := &wshrpc.RpcOpts{Route: wshutil.DefaultRoute, Timeout: timeout.Milliseconds()}
+	rpcRtn, err := wshclient.GetBareRpcClient().SendRpcRequest(info.Decl.Command, data, rpcOpts)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	rtnData, err := recodeJson(rpcRtn)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid response: %v", err)
+	}
+	if info.WrapResponse {
+		rtnData = map[string]any{ResponseFieldName: rtnData}
+	}
+	resp := dynamicpb.NewMessage(method.Output())
+	err = jsonToProto(rtnData, resp)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid response: %v", err)
+	}
+	return resp, nil
+}
+
+// the json value of an rpc result (with json.Numbers, so int64s are not rounded)
+func recodeJson(val any) (any, error) {
+	barr, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(barr))
+	decoder.UseNumber()
+	var rtn any
+	err = decoder.Decode(&rtn)
+	return rtn, err
+}
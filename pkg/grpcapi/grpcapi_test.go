@@ -0,0 +1,108 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func getMessageDesc(t *testing.T, name string) protoreflect.MessageDescriptor {
+	pf, err := loadProtoFile()
+	if err != nil {
+		t.Fatalf("error loading proto file: %v", err)
+	}
+	desc := pf.File.Messages().ByName(protoreflect.Name(name))
+	if desc == nil {
+		t.Fatalf("message %q not found", name)
+	}
+	return desc
+}
+
+func TestProtoFile(t *testing.T) {
+	pf, err := loadProtoFile()
+	if err != nil {
+		t.Fatalf("error loading proto file: %v", err)
+	}
+	for _, svc := range Services {
+		svcDesc := pf.File.Services().ByName(protoreflect.Name(svc.Name))
+		if svcDesc == nil {
+			t.Fatalf("service %q not found", svc.Name)
+		}
+		for _, methodName := range svc.Methods {
+			if pf.Methods["/"+ProtoPackage+"."+svc.Name+"/"+methodName] == nil {
+				t.Errorf("no rpc for %s.%s", svc.Name, methodName)
+			}
+		}
+	}
+	if _, err := GenerateProtoFile(); err != nil {
+		t.Errorf("error generating proto file: %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	data := map[string]any{
+		"oref": "block:7f5f3c1a-0000-4000-8000-000000000000",
+		"meta": map[string]any{"view": "term", "term:fontsize": 12.0, "cmd:args": []any{"-l"}},
+	}
+	msg := dynamicpb.NewMessage(getMessageDesc(t, "CommandSetMetaData"))
+	if err := jsonToProto(data, msg); err != nil {
+		t.Fatalf("error converting to proto: %v", err)
+	}
+	rtn, err := protoToJson(msg)
+	if err != nil {
+		t.Fatalf("error converting from proto: %v", err)
+	}
+	if !reflect.DeepEqual(rtn, data) {
+		t.Errorf("round trip changed the data: %v != %v", rtn, data)
+	}
+
+	// optional fields keep explicit false values, int64s are not rounded
+	wshEnabled := false
+	msg = dynamicpb.NewMessage(getMessageDesc(t, "ConnKeywords"))
+	if err := jsonToProto(map[string]any{"conn:wshenabled": wshEnabled, "ssh:user": "me"}, msg); err != nil {
+		t.Fatalf("error converting to proto: %v", err)
+	}
+	rtn, _ = protoToJson(msg)
+	if rtnMap := rtn.(map[string]any); rtnMap["conn:wshenabled"] != false || rtnMap["ssh:user"] != "me" || len(rtnMap) != 2 {
+		t.Errorf("expected the optional fields to be kept, got %v", rtn)
+	}
+	msg = dynamicpb.NewMessage(getMessageDesc(t, "FileInfo"))
+	resp, _ := recodeJson(map[string]any{"size": int64(1<<62 + 1)})
+	if err := jsonToProto(resp, msg); err != nil {
+		t.Fatalf("error converting to proto: %v", err)
+	}
+	if size := msg.Get(msg.Descriptor().Fields().ByName("size")).Int(); size != 1<<62+1 {
+		t.Errorf("int64 was rounded: %d", size)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer listener.Close()
+	go Serve(listener, "secret-token")
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("error connecting: %v", err)
+	}
+	defer conn.Close()
+	resp := dynamicpb.NewMessage(getMessageDesc(t, "ConnListResponse"))
+	err = conn.Invoke(context.Background(), "/wave.v1.ConnectionService/ConnList", &emptypb.Empty{}, resp)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected a call without the token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package grpcapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// the messages are generated from the go types of the rpcs: a struct is a message (its fields are its json
+// fields, numbered in order, so fields must only be appended to keep the generated clients compatible),
+// map[string]any is a google.protobuf.Struct and any is a google.protobuf.Value.  slices of slices, maps of
+// slices, and types with their own json encoding (other than ORef, which is a string) are also Values.
+
+const (
+	wktStruct = ".google.protobuf.Struct"
+	wktValue  = ".google.protobuf.Value"
+	wktEmpty  = ".google.protobuf.Empty"
+
+	structProtoFile = "google/protobuf/struct.proto"
+	emptyProtoFile  = "google/protobuf/empty.proto"
+)
+
+var oRefRType = reflect.TypeOf(waveobj.ORef{})
+var jsonMarshalerRType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+var protoNameRe = regexp.MustCompile(`[^a-z0-9_]`)
+
+// how a method's request and response are mapped (the data and result of rpcs that do not take or return a
+// struct are wrapped in a [Method]Request or [Method]Response message)
+type methodInfo struct {
+	Decl         *wshrpc.WshRpcMethodDecl
+	WrapRequest  bool
+	WrapResponse bool
+}
+
+type protoBuilder struct {
+	File     *descriptorpb.FileDescriptorProto
+	Messages map[reflect.Type]string
+	Names    map[string]reflect.Type
+	Methods  map[string]*methodInfo // by full method name (/wave.v1.Service/Method)
+}
+
+func makeProtoBuilder() *protoBuilder {
+	return &protoBuilder{
+		File: &descriptorpb.FileDescriptorProto{
+			Name:    proto.String(ProtoFileName),
+			Package: proto.String(ProtoPackage),
+			Syntax:  proto.String("proto3"),
+		},
+		Messages: make(map[reflect.Type]string),
+		Names:    make(map[string]reflect.Type),
+		Methods:  make(map[string]*methodInfo),
+	}
+}
+
+func (b *protoBuilder) addDependency(fileName string) {
+	for _, dep := range b.File.Dependency {
+		if dep == fileName {
+			return
+		}
+	}
+	b.File.Dependency = append(b.File.Dependency, fileName)
+}
+
+func (b *protoBuilder) fullName(msgName string) string {
+	return "." + ProtoPackage + "." + msgName
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// slices (other than []byte) and maps that are not google.protobuf.Structs
+func isContainerType(t reflect.Type) bool {
+	t = derefType(t)
+	if t.Kind() == reflect.Slice {
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return t.Kind() == reflect.Map && !isStructMapType(t)
+}
+
+func isStructMapType(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Interface
+}
+
+func isValueType(t reflect.Type) bool {
+	if t == oRefRType {
+		return false
+	}
+	return t.Kind() == reflect.Interface || t.Implements(jsonMarshalerRType) || reflect.PointerTo(t).Implements(jsonMarshalerRType)
+}
+
+func toProtoName(jsonName string) string {
+	return protoNameRe.ReplaceAllString(strings.ToLower(jsonName), "_")
+}
+
+func toCamelCase(name string) string {
+	var rtn strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			rtn.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return rtn.String()
+}
+
+// sets the type of a non-container field
+func (b *protoBuilder) setSingularType(field *descriptorpb.FieldDescriptorProto, t reflect.Type) error {
+	t = derefType(t)
+	setType := func(ftype descriptorpb.FieldDescriptorProto_Type) {
+		field.Type = ftype.Enum()
+	}
+	switch {
+	case t == oRefRType:
+		setType(descriptorpb.FieldDescriptorProto_TYPE_STRING)
+		return nil
+	case isValueType(t) || isContainerType(t):
+		b.addDependency(structProtoFile)
+		setType(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+		field.TypeName = proto.String(wktValue)
+		return nil
+	case isStructMapType(t):
+		b.addDependency(structProtoFile)
+		setType(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+		field.TypeName = proto.String(wktStruct)
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		setType(descriptorpb.FieldDescriptorProto_TYPE_STRING)
+	case reflect.Bool:
+		setType(descriptorpb.FieldDescriptorProto_TYPE_BOOL)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		setType(descriptorpb.FieldDescriptorProto_TYPE_INT64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		setType(descriptorpb.FieldDescriptorProto_TYPE_UINT64)
+	case reflect.Float32, reflect.Float64:
+		setType(descriptorpb.FieldDescriptorProto_TYPE_DOUBLE)
+	case reflect.Slice:
+		setType(descriptorpb.FieldDescriptorProto_TYPE_BYTES)
+	case reflect.Struct:
+		msgName, err := b.addMessage(t)
+		if err != nil {
+			return err
+		}
+		setType(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+		field.TypeName = proto.String(b.fullName(msgName))
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	return nil
+}
+
+func (b *protoBuilder) makeField(parent *descriptorpb.DescriptorProto, name string, jsonName string, num int32, t reflect.Type) (*descriptorpb.FieldDescriptorProto, error) {
+	field := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(jsonName),
+		Number:   proto.Int32(num),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	isPtr := t.Kind() == reflect.Pointer
+	t = derefType(t)
+	switch {
+	case t.Kind() == reflect.Slice && isContainerType(t) && !isContainerType(t.Elem()) && !isValueType(t):
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		t = t.Elem()
+	case t.Kind() == reflect.Map && isContainerType(t) && t.Key().Kind() == reflect.String && !isContainerType(t.Elem()) && !isValueType(t):
+		entry := &descriptorpb.DescriptorProto{
+			Name:    proto.String(toCamelCase(name) + "Entry"),
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}
+		keyField := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String("key"),
+			JsonName: proto.String("key"),
+			Number:   proto.Int32(1),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		}
+		valField := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String("value"),
+			JsonName: proto.String("value"),
+			Number:   proto.Int32(2),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		err := b.setSingularType(valField, t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		entry.Field = []*descriptorpb.FieldDescriptorProto{keyField, valField}
+		parent.NestedType = append(parent.NestedType, entry)
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		field.TypeName = proto.String(b.fullName(parent.GetName() + "." + entry.GetName()))
+		return field, nil
+	}
+	err := b.setSingularType(field, t)
+	if err != nil {
+		return nil, err
+	}
+	if isPtr && field.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED && field.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		// pointers to scalars are optional fields (so an explicit false or 0 is sent)
+		field.Proto3Optional = proto.Bool(true)
+		field.OneofIndex = proto.Int32(int32(len(parent.OneofDecl)))
+		parent.OneofDecl = append(parent.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + name)})
+	}
+	return field, nil
+}
+
+type structField struct {
+	JsonName string
+	Type     reflect.Type
+}
+
+// the json fields of a struct (embedded structs without a json tag are flattened, like encoding/json does)
+func getStructFields(t reflect.Type) []structField {
+	var rtn []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && jsonName == "" && derefType(field.Type).Kind() == reflect.Struct {
+			rtn = append(rtn, getStructFields(derefType(field.Type))...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		rtn = append(rtn, structField{JsonName: jsonName, Type: field.Type})
+	}
+	return rtn
+}
+
+func (b *protoBuilder) messageName(t reflect.Type) (string, error) {
+	name := t.Name()
+	if name == "" {
+		return "", fmt.Errorf("anonymous struct types are not supported")
+	}
+	if other, ok := b.Names[name]; ok && other != t {
+		pkgParts := strings.Split(t.PkgPath(), "/")
+		name = toCamelCase(pkgParts[len(pkgParts)-1]) + name
+		if other, ok := b.Names[name]; ok && other != t {
+			return "", fmt.Errorf("duplicate message name %q", name)
+		}
+	}
+	return name, nil
+}
+
+func (b *protoBuilder) addMessage(t reflect.Type) (string, error) {
+	t = derefType(t)
+	if msgName, ok := b.Messages[t]; ok {
+		return msgName, nil
+	}
+	msgName, err := b.messageName(t)
+	if err != nil {
+		return "", err
+	}
+	msg := &descriptorpb.DescriptorProto{Name: proto.String(msgName)}
+	// registered before the fields are added (for recursive types)
+	b.Messages[t] = msgName
+	b.Names[msgName] = t
+	b.File.MessageType = append(b.File.MessageType, msg)
+	usedNames := make(map[string]bool)
+	for idx, sfield := range getStructFields(t) {
+		protoName := toProtoName(sfield.JsonName)
+		for usedNames[protoName] {
+			protoName += "_"
+		}
+		usedNames[protoName] = true
+		field, err := b.makeField(msg, protoName, sfield.JsonName, int32(idx+1), sfield.Type)
+		if err != nil {
+			return "", fmt.Errorf("%s.%s: %w", msgName, sfield.JsonName, err)
+		}
+		msg.Field = append(msg.Field, field)
+	}
+	return msgName, nil
+}
+
+// a message with a single field (for the data and results that are not structs)
+func (b *protoBuilder) addWrapperMessage(msgName string, fieldName string, t reflect.Type) error {
+	if _, ok := b.Names[msgName]; ok {
+		return fmt.Errorf("duplicate message name %q", msgName)
+	}
+	msg := &descriptorpb.DescriptorProto{Name: proto.String(msgName)}
+	b.Names[msgName] = nil
+	b.File.MessageType = append(b.File.MessageType, msg)
+	field, err := b.makeField(msg, fieldName, fieldName, 1, t)
+	if err != nil {
+		return fmt.Errorf("%s: %w", msgName, err)
+	}
+	msg.Field = append(msg.Field, field)
+	return nil
+}
+
+// returns the message type of a method's request or response, and whether it is a wrapper
+func (b *protoBuilder) getMethodMessage(t reflect.Type, wrapperName string, fieldName string) (string, bool, error) {
+	if t == nil {
+		b.addDependency(emptyProtoFile)
+		return wktEmpty, false, nil
+	}
+	if derefType(t).Kind() == reflect.Struct && t != oRefRType && !isValueType(derefType(t)) {
+		msgName, err := b.addMessage(t)
+		if err != nil {
+			return "", false, err
+		}
+		return b.fullName(msgName), false, nil
+	}
+	err := b.addWrapperMessage(wrapperName, fieldName, t)
+	if err != nil {
+		return "", false, err
+	}
+	return b.fullName(wrapperName), true, nil
+}
+
+func (b *protoBuilder) addService(svc serviceDef) error {
+	svcProto := &descriptorpb.ServiceDescriptorProto{Name: proto.String(svc.Name)}
+	for _, methodName := range svc.Methods {
+		decl := wshrpc.GenerateWshCommandDeclMap()[strings.ToLower(methodName)]
+		if decl == nil {
+			return fmt.Errorf("%s: no rpc for method %q", svc.Name, methodName)
+		}
+		if decl.CommandType != wshrpc.RpcType_Call {
+			return fmt.Errorf("%s: %q is a streaming rpc", svc.Name, methodName)
+		}
+		inputType, wrapRequest, err := b.getMethodMessage(decl.CommandDataType, methodName+"Request", RequestFieldName)
+		if err != nil {
+			return fmt.Errorf("%s.%s request: %w", svc.Name, methodName, err)
+		}
+		outputType, wrapResponse, err := b.getMethodMessage(decl.DefaultResponseDataType, methodName+"Response", ResponseFieldName)
+		if err != nil {
+			return fmt.Errorf("%s.%s response: %w", svc.Name, methodName, err)
+		}
+		svcProto.Method = append(svcProto.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(methodName),
+			InputType:  proto.String(inputType),
+			OutputType: proto.String(outputType),
+		})
+		fullMethod := fmt.Sprintf("/%s.%s/%s", ProtoPackage, svc.Name, methodName)
+		b.Methods[fullMethod] = &methodInfo{Decl: decl, WrapRequest: wrapRequest, WrapResponse: wrapResponse}
+	}
+	b.File.Service = append(b.File.Service, svcProto)
+	return nil
+}
+
+func buildProtoFile() (*protoBuilder, error) {
+	b := makeProtoBuilder()
+	for _, svc := range Services {
+		err := b.addService(svc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
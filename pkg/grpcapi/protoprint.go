@@ -0,0 +1,97 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package grpcapi
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// returns the .proto file (for protoc and the other code generators)
+func GenerateProtoFile() (string, error) {
+	b, err := buildProtoFile()
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	file := b.File
+	buf.WriteString("// Code generated by cmd/generategrpc. DO NOT EDIT.\n\n")
+	buf.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&buf, "package %s;\n\n", file.GetPackage())
+	for _, dep := range file.Dependency {
+		fmt.Fprintf(&buf, "import %q;\n", dep)
+	}
+	for _, svc := range file.Service {
+		fmt.Fprintf(&buf, "\nservice %s {\n", svc.GetName())
+		for _, method := range svc.Method {
+			fmt.Fprintf(&buf, "  rpc %s(%s) returns (%s);\n", method.GetName(), relTypeName(method.GetInputType()), relTypeName(method.GetOutputType()))
+		}
+		buf.WriteString("}\n")
+	}
+	for _, msg := range file.MessageType {
+		fmt.Fprintf(&buf, "\nmessage %s {\n", msg.GetName())
+		for _, field := range msg.Field {
+			fmt.Fprintf(&buf, "  %s %s = %d", fieldTypeString(msg, field), field.GetName(), field.GetNumber())
+			if field.GetJsonName() != defaultJsonName(field.GetName()) {
+				fmt.Fprintf(&buf, " [json_name = %q]", field.GetJsonName())
+			}
+			buf.WriteString(";\n")
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.String(), nil
+}
+
+func relTypeName(typeName string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(typeName, "."+ProtoPackage+"."), ".")
+}
+
+func findNestedType(msg *descriptorpb.DescriptorProto, typeName string) *descriptorpb.DescriptorProto {
+	for _, nested := range msg.NestedType {
+		if strings.HasSuffix(typeName, "."+msg.GetName()+"."+nested.GetName()) {
+			return nested
+		}
+	}
+	return nil
+}
+
+func scalarTypeString(field *descriptorpb.FieldDescriptorProto) string {
+	if field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return relTypeName(field.GetTypeName())
+	}
+	return strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+}
+
+func fieldTypeString(msg *descriptorpb.DescriptorProto, field *descriptorpb.FieldDescriptorProto) string {
+	if field.GetProto3Optional() {
+		return "optional " + scalarTypeString(field)
+	}
+	if field.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		return scalarTypeString(field)
+	}
+	if entry := findNestedType(msg, field.GetTypeName()); entry != nil && entry.GetOptions().GetMapEntry() {
+		return fmt.Sprintf("map<%s, %s>", scalarTypeString(entry.Field[0]), scalarTypeString(entry.Field[1]))
+	}
+	return "repeated " + scalarTypeString(field)
+}
+
+// the json name protoc gives a field (lowerCamelCase)
+func defaultJsonName(name string) string {
+	var rtn strings.Builder
+	upper := false
+	for _, ch := range name {
+		if ch == '_' {
+			upper = true
+			continue
+		}
+		if upper && ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		upper = false
+		rtn.WriteRune(ch)
+	}
+	return rtn.String()
+}
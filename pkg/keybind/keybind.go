@@ -0,0 +1,239 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package keybind resolves the key bindings: the default keymap, with the keys of the actions set in the
+// "app:keybindings" setting (action => keys) replacing their default keys.  an action is a frontend action (in
+// DefaultKeymap, run by the keymodel of the frontend) or a backend action (any action registered with waction, run
+// on the focused block with ExecuteActionCommand), so every backend action can be bound.  a binding is a key
+// ("Cmd:t") or a chord of two keys ("Ctrl:Shift:s ArrowUp").  when keys are bound to more than one action the
+// binding from the settings wins over a default one, and a chord wins over a key bound alone that starts it, the
+// others are reported as conflicts.
+package keybind
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waction"
+	"github.com/wavetermdev/waveterm/pkg/wsettings"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const (
+	Source_Default  = "default"
+	Source_Settings = "settings"
+)
+
+var modifierOrder = []string{"Cmd", "Option", "Alt", "Meta", "Ctrl", "Shift"}
+
+var modifierNames = map[string]string{
+	"cmd":     "Cmd",
+	"option":  "Option",
+	"alt":     "Alt",
+	"meta":    "Meta",
+	"ctrl":    "Ctrl",
+	"control": "Ctrl",
+	"shift":   "Shift",
+}
+
+// normalizes a key ("shift:cmd:W" => "Cmd:Shift:w"), an upper case letter implies Shift (like in the frontend)
+func NormalizeKey(key string) (string, error) {
+	mods := make(map[string]bool)
+	var keyName string
+	for _, part := range strings.Split(key, ":") {
+		if modName, ok := modifierNames[strings.ToLower(part)]; ok {
+			mods[modName] = true
+			continue
+		}
+		if part == "" {
+			return "", fmt.Errorf("invalid key %q", key)
+		}
+		if keyName != "" {
+			return "", fmt.Errorf("invalid key %q: more than one key (%s and %s)", key, keyName, part)
+		}
+		keyName = part
+	}
+	if keyName == "" {
+		return "", fmt.Errorf("invalid key %q: no key, only modifiers", key)
+	}
+	if len(keyName) == 1 && keyName[0] >= 'A' && keyName[0] <= 'Z' {
+		mods["Shift"] = true
+		keyName = strings.ToLower(keyName)
+	}
+	var parts []string
+	for _, modName := range modifierOrder {
+		if mods[modName] {
+			parts = append(parts, modName)
+		}
+	}
+	return strings.Join(append(parts, keyName), ":"), nil
+}
+
+// normalizes a key or a chord (two keys separated by whitespace)
+func NormalizeKeys(keys string) (string, error) {
+	fields := strings.Fields(keys)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", fmt.Errorf("invalid keys %q: expected a key or a chord of two keys", keys)
+	}
+	for idx, field := range fields {
+		normKey, err := NormalizeKey(field)
+		if err != nil {
+			return "", err
+		}
+		fields[idx] = normKey
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// the first key of a chord ("" for a single key)
+func chordPrefix(keys string) string {
+	prefix, _, found := strings.Cut(keys, " ")
+	if !found {
+		return ""
+	}
+	return prefix
+}
+
+// the bindings of the default keymap and of the backend actions with the overrides (action => keys) applied
+func Resolve(overrides map[string][]string) *wshrpc.KeyBindingsData {
+	rtn := &wshrpc.KeyBindingsData{}
+	known := make(map[string]bool)
+	for _, keyAction := range DefaultKeymap {
+		known[keyAction.Action] = true
+		rtn.Actions = append(rtn.Actions, wshrpc.KeyBindingAction{Action: keyAction.Action, Title: keyAction.Title})
+	}
+	backend := make(map[string]bool)
+	for _, def := range waction.ListAllActions() {
+		if known[def.ActionId] {
+			continue
+		}
+		known[def.ActionId] = true
+		backend[def.ActionId] = true
+		rtn.Actions = append(rtn.Actions, wshrpc.KeyBindingAction{Action: def.ActionId, Title: def.Title, Backend: true})
+	}
+	var candidates []wshrpc.KeyBinding
+	for _, keyAction := range DefaultKeymap {
+		if _, overridden := overrides[keyAction.Action]; overridden {
+			continue
+		}
+		for _, keys := range keyAction.Keys {
+			normKeys, err := NormalizeKeys(keys)
+			if err != nil {
+				// the default keymap is checked by the tests
+				continue
+			}
+			candidates = append(candidates, wshrpc.KeyBinding{Keys: normKeys, Action: keyAction.Action, Source: Source_Default})
+		}
+	}
+	overrideActions := make([]string, 0, len(overrides))
+	for action := range overrides {
+		overrideActions = append(overrideActions, action)
+	}
+	sort.Strings(overrideActions)
+	for _, action := range overrideActions {
+		if !known[action] {
+			rtn.Errors = append(rtn.Errors, fmt.Sprintf("unknown action %q", action))
+			continue
+		}
+		for _, keys := range overrides[action] {
+			normKeys, err := NormalizeKeys(keys)
+			if err != nil {
+				rtn.Errors = append(rtn.Errors, fmt.Sprintf("action %q: %v", action, err))
+				continue
+			}
+			candidates = append(candidates, wshrpc.KeyBinding{Keys: normKeys, Action: action, Backend: backend[action], Source: Source_Settings})
+		}
+	}
+	rtn.Bindings, rtn.Conflicts = resolveConflicts(candidates)
+	return rtn
+}
+
+// keeps one binding for each keys (the first one from the settings, or else the first default one), and drops the
+// keys bound alone that start a chord
+func resolveConflicts(candidates []wshrpc.KeyBinding) ([]wshrpc.KeyBinding, []wshrpc.KeyBindingConflict) {
+	var order []string
+	byKeys := make(map[string][]wshrpc.KeyBinding)
+	for _, binding := range candidates {
+		if byKeys[binding.Keys] == nil {
+			order = append(order, binding.Keys)
+		}
+		byKeys[binding.Keys] = append(byKeys[binding.Keys], binding)
+	}
+	chordPrefixes := make(map[string]string) // first key => an action bound to a chord starting with it
+	var conflicts []wshrpc.KeyBindingConflict
+	winners := make(map[string]wshrpc.KeyBinding)
+	for _, keys := range order {
+		bindings := byKeys[keys]
+		sort.SliceStable(bindings, func(i, j int) bool {
+			return bindings[i].Source == Source_Settings && bindings[j].Source != Source_Settings
+		})
+		winner := bindings[0]
+		var shadowed []string
+		for _, binding := range bindings[1:] {
+			if binding.Action != winner.Action && !utilfn.ContainsStr(shadowed, binding.Action) {
+				shadowed = append(shadowed, binding.Action)
+			}
+		}
+		if len(shadowed) > 0 {
+			conflicts = append(conflicts, wshrpc.KeyBindingConflict{
+				Keys:     keys,
+				Action:   winner.Action,
+				Shadowed: shadowed,
+				Reason:   "bound to more than one action",
+			})
+		}
+		winners[keys] = winner
+		if prefix := chordPrefix(keys); prefix != "" {
+			if _, found := chordPrefixes[prefix]; !found {
+				chordPrefixes[prefix] = winner.Action
+			}
+		}
+	}
+	var rtn []wshrpc.KeyBinding
+	for _, keys := range order {
+		binding := winners[keys]
+		if chordAction, found := chordPrefixes[keys]; found {
+			conflicts = append(conflicts, wshrpc.KeyBindingConflict{
+				Keys:     keys,
+				Action:   chordAction,
+				Shadowed: []string{binding.Action},
+				Reason:   "starts a chord",
+			})
+			continue
+		}
+		rtn = append(rtn, binding)
+	}
+	return rtn, conflicts
+}
+
+// the bindings for a workspace (or the global ones for workspaceId ""), with the settings of the workspace
+func ResolveForWorkspace(ctx context.Context, workspaceId string) (*wshrpc.KeyBindingsData, error) {
+	settings, err := wsettings.Resolve(ctx, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	return Resolve(settings.AppKeybindings), nil
+}
+
+// the action bound to keys (a key or a chord), or Partial if keys is the first key of a chord
+func ResolveKeys(data *wshrpc.KeyBindingsData, keys string) (*wshrpc.KeyBindingResolveRtnData, error) {
+	normKeys, err := NormalizeKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	rtn := &wshrpc.KeyBindingResolveRtnData{Keys: normKeys}
+	for _, binding := range data.Bindings {
+		if binding.Keys == normKeys {
+			rtn.Action = binding.Action
+			rtn.Backend = binding.Backend
+			return rtn, nil
+		}
+		if chordPrefix(binding.Keys) == normKeys {
+			rtn.Partial = true
+		}
+	}
+	return rtn, nil
+}
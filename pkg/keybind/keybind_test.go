@@ -0,0 +1,93 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package keybind
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestNormalizeKeys(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"Shift:Cmd:]", "Cmd:Shift:]"},
+		{"ctrl:shift:S  arrowup", "Ctrl:Shift:s arrowup"},
+		{"Cmd:W", "Cmd:Shift:w"},
+		{"Ctrl:Shift:c{Digit1}", "Ctrl:Shift:c{Digit1}"},
+	}
+	for _, tc := range tests {
+		got, err := NormalizeKeys(tc.keys)
+		if err != nil || got != tc.expected {
+			t.Errorf("%q: expected %q, got %q (%v)", tc.keys, tc.expected, got, err)
+		}
+	}
+	for _, keys := range []string{"", "Cmd:Shift", "Cmd:a:b", "a b c"} {
+		if _, err := NormalizeKeys(keys); err == nil {
+			t.Errorf("%q: expected an error", keys)
+		}
+	}
+}
+
+func TestDefaultKeymap(t *testing.T) {
+	rtn := Resolve(nil)
+	if len(rtn.Conflicts) > 0 || len(rtn.Errors) > 0 {
+		t.Errorf("expected no conflicts or errors in the default keymap, got %v %v", rtn.Conflicts, rtn.Errors)
+	}
+	for _, keyAction := range DefaultKeymap {
+		for _, keys := range keyAction.Keys {
+			if _, err := NormalizeKeys(keys); err != nil {
+				t.Errorf("action %s: %v", keyAction.Action, err)
+			}
+		}
+	}
+}
+
+func TestResolveOverrides(t *testing.T) {
+	rtn := Resolve(map[string][]string{
+		"block:magnify":    {"Cmd:t"},          // takes Cmd:t from tab:new
+		"block:splitright": {},                 // unbinds Cmd:d (and the chord)
+		"tab:close":        {"Cmd:i x"},        // a chord starting with the key of a binding
+		"tab:nope":         {"Cmd:y"},          // not an action
+		"block:launcher":   {"Cmd:Shift:Ctrl"}, // not a key
+	})
+	if !reflect.DeepEqual(rtn.Errors, []string{`action "block:launcher": invalid key "Cmd:Shift:Ctrl": no key, only modifiers`, `unknown action "tab:nope"`}) {
+		t.Errorf("unexpected errors: %v", rtn.Errors)
+	}
+	bindings := make(map[string]wshrpc.KeyBinding)
+	for _, binding := range rtn.Bindings {
+		bindings[binding.Keys] = binding
+	}
+	if b := bindings["Cmd:t"]; b.Action != "block:magnify" || b.Source != Source_Settings {
+		t.Errorf("expected Cmd:t to magnify (from the settings), got %+v", b)
+	}
+	if _, found := bindings["Cmd:d"]; found {
+		t.Errorf("expected Cmd:d to be unbound")
+	}
+	if _, found := bindings["Cmd:i"]; found {
+		t.Errorf("expected Cmd:i to be dropped (it starts a chord)")
+	}
+	expected := []wshrpc.KeyBindingConflict{
+		{Keys: "Cmd:t", Action: "block:magnify", Shadowed: []string{"tab:new"}, Reason: "bound to more than one action"},
+		{Keys: "Cmd:i", Action: "tab:close", Shadowed: []string{"block:refocus"}, Reason: "starts a chord"},
+	}
+	if !reflect.DeepEqual(rtn.Conflicts, expected) {
+		t.Errorf("unexpected conflicts: %+v", rtn.Conflicts)
+	}
+	res, err := ResolveKeys(rtn, "cmd:M")
+	if err != nil || res.Partial || res.Action != "" || res.Keys != "Cmd:Shift:m" {
+		t.Errorf("expected Cmd:Shift:m to be unbound, got %+v (%v)", res, err)
+	}
+	res, _ = ResolveKeys(rtn, "Cmd:i")
+	if !res.Partial || res.Action != "" {
+		t.Errorf("expected Cmd:i to start a chord, got %+v", res)
+	}
+	res, _ = ResolveKeys(rtn, "Cmd:i x")
+	if res.Action != "tab:close" {
+		t.Errorf("expected the chord to close the tab, got %+v", res)
+	}
+}
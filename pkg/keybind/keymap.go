@@ -0,0 +1,59 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package keybind
+
+import "strconv"
+
+// a frontend action (implemented by the keymodel of the frontend) and its default keys
+type KeyAction struct {
+	Action string
+	Title  string
+	Keys   []string
+}
+
+// the default keymap (every frontend action, the backend actions have no default keys)
+var DefaultKeymap = buildDefaultKeymap()
+
+func buildDefaultKeymap() []KeyAction {
+	keymap := []KeyAction{
+		{"tab:next", "Next Tab", []string{"Cmd:]", "Cmd:Shift:]"}},
+		{"tab:prev", "Previous Tab", []string{"Cmd:[", "Cmd:Shift:["}},
+		{"tab:new", "New Tab", []string{"Cmd:t"}},
+		{"tab:close", "Close Tab", []string{"Cmd:Shift:w"}},
+		{"block:new", "New Block", []string{"Cmd:n"}},
+		{"block:close", "Close Block", []string{"Cmd:w"}},
+		{"block:splitright", "Split Right", []string{"Cmd:d", "Ctrl:Shift:s ArrowRight"}},
+		{"block:splitleft", "Split Left", []string{"Ctrl:Shift:s ArrowLeft"}},
+		{"block:splitdown", "Split Down", []string{"Cmd:Shift:d", "Ctrl:Shift:s ArrowDown"}},
+		{"block:splitup", "Split Up", []string{"Ctrl:Shift:s ArrowUp"}},
+		{"block:magnify", "Magnify Block", []string{"Cmd:m"}},
+		{"block:refocus", "Focus the Block Input", []string{"Cmd:i"}},
+		{"block:focusup", "Focus the Block Above", []string{"Ctrl:Shift:ArrowUp"}},
+		{"block:focusdown", "Focus the Block Below", []string{"Ctrl:Shift:ArrowDown"}},
+		{"block:focusleft", "Focus the Block on the Left", []string{"Ctrl:Shift:ArrowLeft"}},
+		{"block:focusright", "Focus the Block on the Right", []string{"Ctrl:Shift:ArrowRight"}},
+		{"block:launcher", "Replace with the Launcher", []string{"Ctrl:Shift:k"}},
+		{"conn:switch", "Switch Connection", []string{"Cmd:g"}},
+		{"term:multiinput", "Toggle Multi-Input", []string{"Ctrl:Shift:i"}},
+		{"search:open", "Search", []string{"Cmd:f"}},
+		{"app:dismiss", "Close the Modal or the Search", []string{"Escape"}},
+	}
+	for idx := 1; idx <= 9; idx++ {
+		digit := strconv.Itoa(idx)
+		keymap = append(keymap, KeyAction{
+			Action: "tab:switch" + digit,
+			Title:  "Switch to Tab " + digit,
+			Keys:   []string{"Cmd:" + digit},
+		})
+	}
+	for idx := 1; idx <= 9; idx++ {
+		digit := strconv.Itoa(idx)
+		keymap = append(keymap, KeyAction{
+			Action: "block:focus" + digit,
+			Title:  "Focus Block " + digit,
+			Keys:   []string{"Ctrl:Shift:c{Digit" + digit + "}", "Ctrl:Shift:c{Numpad" + digit + "}"},
+		})
+	}
+	return keymap
+}
@@ -0,0 +1,73 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package keychain keeps wave's encryption keys in the OS keychain (the macOS keychain, the windows credential
+// manager, or the secret service on linux), so the keys are not stored next to the data they encrypt.  the keys are
+// under the service "waveterm" ("waveterm-dev" in dev mode), by name.
+package keychain
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/zalando/go-keyring"
+)
+
+const KeySize = 32
+
+var ErrNotFound = errors.New("key not found in the keychain")
+
+func getService() string {
+	if wavebase.IsDevMode() {
+		return "waveterm-dev"
+	}
+	return "waveterm"
+}
+
+// returns the key, a new random key is made (and stored) if there is no key and create is set.  returns
+// ErrNotFound if there is no key and create is not set.
+func GetKey(name string, create bool) ([]byte, error) {
+	keyStr, err := keyring.Get(getService(), name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		if !create {
+			return nil, ErrNotFound
+		}
+		return makeKey(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting key %q from the keychain: %w", name, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(keyStr)
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key %q in the keychain", name)
+	}
+	return key, nil
+}
+
+func makeKey(name string) ([]byte, error) {
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, fmt.Errorf("error making key: %w", err)
+	}
+	err = SetKey(name, key)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// stores the key (replacing the key with the name), e.g. a key from another machine
+func SetKey(name string, key []byte) error {
+	if len(key) != KeySize {
+		return fmt.Errorf("invalid key size %d (expected %d)", len(key), KeySize)
+	}
+	err := keyring.Set(getService(), name, base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		return fmt.Errorf("error storing key %q in the keychain: %w", name, err)
+	}
+	return nil
+}
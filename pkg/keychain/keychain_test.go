@@ -0,0 +1,54 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestGetKey(t *testing.T) {
+	keyring.MockInit()
+	_, err := GetKey("test", false)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	key, err := GetKey("test", true)
+	if err != nil || len(key) != KeySize {
+		t.Fatalf("expected a new key, got %d bytes, err:%v", len(key), err)
+	}
+	key2, err := GetKey("test", false)
+	if err != nil || !bytes.Equal(key, key2) {
+		t.Errorf("expected the same key back, err:%v", err)
+	}
+	otherKey, err := GetKey("other", true)
+	if err != nil || bytes.Equal(key, otherKey) {
+		t.Errorf("expected a different key for another name, err:%v", err)
+	}
+	keyring.Set(getService(), "bad", "not a key")
+	_, err = GetKey("bad", false)
+	if err == nil {
+		t.Errorf("expected an error for an invalid key")
+	}
+}
+
+func TestSetKey(t *testing.T) {
+	keyring.MockInit()
+	key := bytes.Repeat([]byte{7}, KeySize)
+	err := SetKey("test", key)
+	if err != nil {
+		t.Fatalf("error setting key: %v", err)
+	}
+	key2, err := GetKey("test", true)
+	if err != nil || !bytes.Equal(key, key2) {
+		t.Errorf("expected the key that was set, err:%v", err)
+	}
+	err = SetKey("test", key[:16])
+	if err == nil {
+		t.Errorf("expected an error for a short key")
+	}
+}
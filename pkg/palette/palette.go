@@ -0,0 +1,519 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package palette is the search index behind the command palette (and "wsh palette").  it aggregates the
+// workspaces, tabs, blocks, actions, bookmarks, connections, and settings into one list of items ranked with
+// fzf's fuzzy matcher.  the workspaces, tabs, and blocks are indexed once (on the first search) and then kept
+// up to date from the object updates on the event bus.  the rest is cheap to compute and is read when searching.
+package palette
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/junegunn/fzf/src/algo"
+	"github.com/junegunn/fzf/src/util"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/waction"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	ItemType_Action     = "action"
+	ItemType_Workspace  = "workspace"
+	ItemType_Tab        = "tab"
+	ItemType_Block      = "block"
+	ItemType_Bookmark   = "bookmark"
+	ItemType_Connection = "connection"
+	ItemType_Setting    = "setting"
+)
+
+// also the order items with the same score are listed in
+var ItemTypes = []string{ItemType_Action, ItemType_Tab, ItemType_Block, ItemType_Workspace, ItemType_Bookmark, ItemType_Connection, ItemType_Setting}
+
+const DefaultLimit = 50
+const MaxLimit = 500
+const connListCacheTime = 10 * time.Second
+const updateQueueSize = 256
+const dbTimeout = 5 * time.Second
+
+// the values of these settings are not shown
+var secretSettingWords = []string{"token", "password", "secret"}
+
+// the indexed workspaces, tabs, and blocks (by oref).  built is false until the first search (and is reset when
+// an update is dropped, so the next search rebuilds the index).
+type objIndex struct {
+	Lock    *sync.Mutex
+	Built   bool
+	Items   map[string]wshrpc.PaletteItem
+	Parents map[string]string // the tab of each block, the workspace of each tab
+}
+
+var index = &objIndex{Lock: &sync.Mutex{}}
+
+type connListCache struct {
+	Lock      *sync.Mutex
+	Conns     []string
+	UpdatedTs time.Time
+}
+
+var connCache = &connListCache{Lock: &sync.Mutex{}}
+
+var updateQueue = make(chan waveobj.WaveObjUpdate, updateQueueSize)
+var startOnce = &sync.Once{}
+
+// subscribes to the object updates that keep the index current
+func Start() {
+	startOnce.Do(func() {
+		eventbus.Subscribe(eventbus.Topic_ObjectUpdate, eventbus.Scope{}, handleEvent)
+		go runUpdateLoop()
+	})
+}
+
+// called from the publishing goroutine, must not block
+func handleEvent(event eventbus.Event) {
+	objEvent, ok := event.(eventbus.ObjectUpdateEvent)
+	if !ok {
+		return
+	}
+	switch objEvent.Update.OType {
+	case waveobj.OType_Workspace, waveobj.OType_Tab, waveobj.OType_Block:
+	default:
+		return
+	}
+	select {
+	case updateQueue <- objEvent.Update:
+	default:
+		index.Lock.Lock()
+		index.Built = false
+		index.Lock.Unlock()
+	}
+}
+
+func runUpdateLoop() {
+	defer func() {
+		panichandler.PanicHandler("palette:runUpdateLoop", recover())
+	}()
+	for update := range updateQueue {
+		ctx, cancelFn := context.WithTimeout(context.Background(), dbTimeout)
+		err := index.applyUpdate(ctx, update)
+		cancelFn()
+		if err != nil {
+			log.Printf("palette: error indexing %s:%s: %v\n", update.OType, update.OID, err)
+		}
+	}
+}
+
+func makeWorkspaceItem(ws *waveobj.Workspace) wshrpc.PaletteItem {
+	oref := waveobj.MakeORef(waveobj.OType_Workspace, ws.OID).String()
+	return wshrpc.PaletteItem{
+		ItemId:   oref,
+		ItemType: ItemType_Workspace,
+		Title:    ws.Name,
+		SubText:  fmt.Sprintf("%d tabs", len(ws.TabIds)+len(ws.PinnedTabIds)),
+		Icon:     ws.Icon,
+		ORef:     oref,
+	}
+}
+
+func makeTabItem(tab *waveobj.Tab, wsName string) wshrpc.PaletteItem {
+	oref := waveobj.MakeORef(waveobj.OType_Tab, tab.OID).String()
+	return wshrpc.PaletteItem{
+		ItemId:   oref,
+		ItemType: ItemType_Tab,
+		Title:    tab.Name,
+		SubText:  wsName,
+		Icon:     "window-maximize",
+		ORef:     oref,
+	}
+}
+
+// a block is titled by its frame:title, or by its view and what it shows (its command, file, or url)
+func makeBlockItem(block *waveobj.Block, tabName string) wshrpc.PaletteItem {
+	oref := waveobj.MakeORef(waveobj.OType_Block, block.OID).String()
+	title := block.Meta.GetString(waveobj.MetaKey_FrameTitle, "")
+	if title == "" {
+		view := block.Meta.GetString(waveobj.MetaKey_View, "block")
+		var parts []string
+		for _, key := range []string{waveobj.MetaKey_Cmd, waveobj.MetaKey_File, waveobj.MetaKey_Url, waveobj.MetaKey_Connection} {
+			if val := block.Meta.GetString(key, ""); val != "" {
+				parts = append(parts, val)
+				break
+			}
+		}
+		title = strings.TrimSpace(view + " " + strings.Join(parts, " "))
+	}
+	return wshrpc.PaletteItem{
+		ItemId:   oref,
+		ItemType: ItemType_Block,
+		Title:    title,
+		SubText:  tabName,
+		Icon:     block.Meta.GetString(waveobj.MetaKey_FrameIcon, "square"),
+		ORef:     oref,
+	}
+}
+
+func (idx *objIndex) setItem(item wshrpc.PaletteItem, parentId string) {
+	idx.Items[item.ItemId] = item
+	if parentId != "" {
+		idx.Parents[item.ItemId] = parentId
+	}
+}
+
+// must hold the lock
+func (idx *objIndex) build(ctx context.Context) error {
+	workspaces, err := wstore.DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
+	if err != nil {
+		return fmt.Errorf("getting workspaces: %w", err)
+	}
+	tabs, err := wstore.DBGetAllObjsByType[*waveobj.Tab](ctx, waveobj.OType_Tab)
+	if err != nil {
+		return fmt.Errorf("getting tabs: %w", err)
+	}
+	blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+	if err != nil {
+		return fmt.Errorf("getting blocks: %w", err)
+	}
+	idx.Items = make(map[string]wshrpc.PaletteItem)
+	idx.Parents = make(map[string]string)
+	tabWorkspace := make(map[string]*waveobj.Workspace)
+	for _, ws := range workspaces {
+		idx.setItem(makeWorkspaceItem(ws), "")
+		for _, tabId := range append(slices.Clone(ws.PinnedTabIds), ws.TabIds...) {
+			tabWorkspace[tabId] = ws
+		}
+	}
+	blockTab := make(map[string]*waveobj.Tab)
+	for _, tab := range tabs {
+		ws := tabWorkspace[tab.OID]
+		if ws == nil {
+			continue
+		}
+		idx.setItem(makeTabItem(tab, ws.Name), ws.OID)
+		for _, blockId := range tab.BlockIds {
+			blockTab[blockId] = tab
+		}
+	}
+	for _, block := range blocks {
+		tab := blockTab[block.OID]
+		if tab == nil {
+			// sub-blocks and blocks that are not in a tab are not indexed
+			continue
+		}
+		idx.setItem(makeBlockItem(block, tab.Name), tab.OID)
+	}
+	idx.Built = true
+	return nil
+}
+
+func (idx *objIndex) removeItem(oref waveobj.ORef) {
+	delete(idx.Items, oref.String())
+	delete(idx.Parents, oref.String())
+}
+
+// re-indexes the updated object.  a renamed workspace re-indexes its tabs and a renamed tab its blocks (they show
+// their parent's name), which also picks up the tabs and blocks that were added.
+func (idx *objIndex) applyUpdate(ctx context.Context, update waveobj.WaveObjUpdate) error {
+	idx.Lock.Lock()
+	defer idx.Lock.Unlock()
+	if !idx.Built {
+		return nil
+	}
+	oref := waveobj.MakeORef(update.OType, update.OID)
+	if update.UpdateType == waveobj.UpdateType_Delete {
+		idx.removeItem(oref)
+		return nil
+	}
+	switch obj := update.Obj.(type) {
+	case *waveobj.Workspace:
+		idx.setItem(makeWorkspaceItem(obj), "")
+		for _, tabId := range append(slices.Clone(obj.PinnedTabIds), obj.TabIds...) {
+			tab, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+			if err != nil {
+				return err
+			}
+			if tab != nil {
+				idx.setItem(makeTabItem(tab, obj.Name), obj.OID)
+			}
+		}
+	case *waveobj.Tab:
+		wsId, err := wstore.DBFindWorkspaceForTabId(ctx, obj.OID)
+		if err != nil || wsId == "" {
+			idx.removeItem(oref)
+			return nil
+		}
+		ws, err := wstore.DBGet[*waveobj.Workspace](ctx, wsId)
+		if err != nil || ws == nil {
+			return err
+		}
+		idx.setItem(makeTabItem(obj, ws.Name), ws.OID)
+		for _, blockId := range obj.BlockIds {
+			block, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+			if err != nil {
+				return err
+			}
+			if block != nil {
+				idx.setItem(makeBlockItem(block, obj.Name), obj.OID)
+			}
+		}
+	case *waveobj.Block:
+		tabId := idx.Parents[oref.String()]
+		if tabId == "" {
+			var err error
+			tabId, err = wstore.DBFindTabForBlockId(ctx, obj.OID)
+			if err != nil || tabId == "" {
+				return nil
+			}
+		}
+		tab, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+		if err != nil || tab == nil {
+			return err
+		}
+		idx.setItem(makeBlockItem(obj, tab.Name), tab.OID)
+	}
+	return nil
+}
+
+func (idx *objIndex) getItems(ctx context.Context) ([]wshrpc.PaletteItem, error) {
+	idx.Lock.Lock()
+	defer idx.Lock.Unlock()
+	if !idx.Built {
+		err := idx.build(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rtn := make([]wshrpc.PaletteItem, 0, len(idx.Items))
+	for _, item := range idx.Items {
+		rtn = append(rtn, item)
+	}
+	return rtn, nil
+}
+
+func getConnections() []string {
+	connCache.Lock.Lock()
+	defer connCache.Lock.Unlock()
+	if connCache.Conns != nil && time.Since(connCache.UpdatedTs) < connListCacheTime {
+		return connCache.Conns
+	}
+	conns, err := conncontroller.GetConnectionsList()
+	if err != nil {
+		log.Printf("palette: error getting connections: %v\n", err)
+	}
+	connCache.Conns = append([]string{}, conns...)
+	connCache.UpdatedTs = time.Now()
+	return connCache.Conns
+}
+
+func getConnectionItems() []wshrpc.PaletteItem {
+	var rtn []wshrpc.PaletteItem
+	for _, conn := range getConnections() {
+		rtn = append(rtn, wshrpc.PaletteItem{
+			ItemId:   ItemType_Connection + ":" + conn,
+			ItemType: ItemType_Connection,
+			Title:    conn,
+			Icon:     "arrow-right-arrow-left",
+			Value:    conn,
+		})
+	}
+	return rtn
+}
+
+func getBookmarkItems(fullConfig wconfig.FullConfigType) []wshrpc.PaletteItem {
+	var rtn []wshrpc.PaletteItem
+	for key, bookmark := range fullConfig.Bookmarks {
+		if bookmark.Url == "" {
+			continue
+		}
+		title := bookmark.Title
+		if title == "" {
+			title = bookmark.Url
+		}
+		rtn = append(rtn, wshrpc.PaletteItem{
+			ItemId:   ItemType_Bookmark + ":" + key,
+			ItemType: ItemType_Bookmark,
+			Title:    title,
+			SubText:  bookmark.Url,
+			Icon:     bookmark.Icon,
+			Value:    bookmark.Url,
+		})
+	}
+	return rtn
+}
+
+// one item per setting (from the json tags of SettingsType), with its current value as the subtext
+func getSettingItems(fullConfig wconfig.FullConfigType) []wshrpc.PaletteItem {
+	var values map[string]any
+	barr, err := json.Marshal(fullConfig.Settings)
+	if err == nil {
+		json.Unmarshal(barr, &values)
+	}
+	var rtn []wshrpc.PaletteItem
+	settingsType := reflect.TypeOf(wconfig.SettingsType{})
+	for i := 0; i < settingsType.NumField(); i++ {
+		key, _, _ := strings.Cut(settingsType.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" || strings.HasSuffix(key, ":*") {
+			continue
+		}
+		item := wshrpc.PaletteItem{
+			ItemId:   ItemType_Setting + ":" + key,
+			ItemType: ItemType_Setting,
+			Title:    key,
+			Icon:     "gear",
+			Value:    key,
+		}
+		if val, ok := values[key]; ok && !isSecretSetting(key) {
+			valBarr, _ := json.Marshal(val)
+			item.SubText = string(valBarr)
+		}
+		rtn = append(rtn, item)
+	}
+	return rtn
+}
+
+func isSecretSetting(key string) bool {
+	for _, word := range secretSettingWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func getActionItems(ctx context.Context, oref waveobj.ORef) ([]wshrpc.PaletteItem, error) {
+	actions, err := waction.ListActions(ctx, oref)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []wshrpc.PaletteItem
+	for _, action := range actions {
+		rtn = append(rtn, wshrpc.PaletteItem{
+			ItemId:   ItemType_Action + ":" + action.ActionId,
+			ItemType: ItemType_Action,
+			Title:    action.Title,
+			SubText:  action.ActionId,
+			Icon:     action.Icon,
+			ORef:     oref.String(),
+			ActionId: action.ActionId,
+		})
+	}
+	return rtn, nil
+}
+
+// searches all of the items (or only the given types).  actions are only included when the search has an
+// object (the actions are the ones that apply to it).
+func Search(ctx context.Context, data wshrpc.CommandPaletteSearchData) ([]wshrpc.PaletteItem, error) {
+	for _, itemType := range data.Types {
+		if !slices.Contains(ItemTypes, itemType) {
+			return nil, fmt.Errorf("invalid item type %q (must be one of %s)", itemType, strings.Join(ItemTypes, ", "))
+		}
+	}
+	wantType := func(itemType string) bool {
+		return len(data.Types) == 0 || slices.Contains(data.Types, itemType)
+	}
+	var items []wshrpc.PaletteItem
+	if wantType(ItemType_Workspace) || wantType(ItemType_Tab) || wantType(ItemType_Block) {
+		objItems, err := index.getItems(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("indexing objects: %w", err)
+		}
+		for _, item := range objItems {
+			if wantType(item.ItemType) {
+				items = append(items, item)
+			}
+		}
+	}
+	if wantType(ItemType_Action) && !data.ORef.IsEmpty() {
+		actionItems, err := getActionItems(ctx, data.ORef)
+		if err != nil {
+			return nil, fmt.Errorf("listing actions: %w", err)
+		}
+		items = append(items, actionItems...)
+	}
+	fullConfig := wconfig.GetWatcher().GetFullConfig()
+	if wantType(ItemType_Bookmark) {
+		items = append(items, getBookmarkItems(fullConfig)...)
+	}
+	if wantType(ItemType_Connection) {
+		items = append(items, getConnectionItems()...)
+	}
+	if wantType(ItemType_Setting) {
+		items = append(items, getSettingItems(fullConfig)...)
+	}
+	return rankItems(items, data.Query, data.Limit), nil
+}
+
+func typeOrder(itemType string) int {
+	return slices.Index(ItemTypes, itemType)
+}
+
+func fuzzyMatch(text string, pattern []rune, slab *util.Slab) (int, []int) {
+	if text == "" {
+		return 0, nil
+	}
+	chars := util.ToChars([]byte(strings.ToLower(text)))
+	result, posPtr := algo.FuzzyMatchV2(false, true, true, &chars, pattern, true, slab)
+	if result.Score <= 0 || posPtr == nil {
+		return 0, nil
+	}
+	positions := slices.Clone(*posPtr)
+	slices.Sort(positions)
+	return result.Score, positions
+}
+
+// matches the query against the titles and subtexts (a subtext match counts half) and returns the best limit
+// items.  an empty query returns the items by type and title.
+func rankItems(items []wshrpc.PaletteItem, query string, limit int) []wshrpc.PaletteItem {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+	query = strings.TrimSpace(query)
+	var rtn []wshrpc.PaletteItem
+	if query == "" {
+		rtn = items
+	} else {
+		pattern := []rune(strings.ToLower(query))
+		var slab util.Slab
+		for _, item := range items {
+			titleScore, titlePos := fuzzyMatch(item.Title, pattern, &slab)
+			subScore, subPos := fuzzyMatch(item.SubText, pattern, &slab)
+			if titleScore <= 0 && subScore <= 0 {
+				continue
+			}
+			item.Score = max(titleScore, subScore/2)
+			item.MatchPos = titlePos
+			item.SubMatchPos = subPos
+			rtn = append(rtn, item)
+		}
+	}
+	sort.SliceStable(rtn, func(i, j int) bool {
+		if rtn[i].Score != rtn[j].Score {
+			return rtn[i].Score > rtn[j].Score
+		}
+		if rtn[i].ItemType != rtn[j].ItemType {
+			return typeOrder(rtn[i].ItemType) < typeOrder(rtn[j].ItemType)
+		}
+		if rtn[i].Title != rtn[j].Title {
+			return rtn[i].Title < rtn[j].Title
+		}
+		return rtn[i].ItemId < rtn[j].ItemId
+	})
+	if len(rtn) > limit {
+		rtn = rtn[:limit]
+	}
+	return rtn
+}
@@ -0,0 +1,49 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package palette
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestRankItems(t *testing.T) {
+	items := []wshrpc.PaletteItem{
+		{ItemId: "setting:term:fontsize", ItemType: ItemType_Setting, Title: "term:fontsize", SubText: "12"},
+		{ItemId: "tab:1", ItemType: ItemType_Tab, Title: "logs", SubText: "work"},
+		{ItemId: "block:1", ItemType: ItemType_Block, Title: "term tail -f server.log", SubText: "logs"},
+		{ItemId: "bookmark:github", ItemType: ItemType_Bookmark, Title: "GitHub", SubText: "https://github.com"},
+	}
+	rtn := rankItems(items, "", 0)
+	if len(rtn) != 4 || rtn[0].ItemType != ItemType_Tab || rtn[3].ItemType != ItemType_Setting {
+		t.Fatalf("an empty query should list the items by type, got %v", rtn)
+	}
+	rtn = rankItems(items, "logs", 0)
+	if len(rtn) != 2 || rtn[0].ItemId != "tab:1" || rtn[1].ItemId != "block:1" {
+		t.Fatalf("expected the tab (title match) before the block (subtext match), got %v", rtn)
+	}
+	if len(rtn[0].MatchPos) != 4 || rtn[0].MatchPos[0] != 0 {
+		t.Errorf("expected the match positions of the title, got %v", rtn[0].MatchPos)
+	}
+	rtn = rankItems(items, "GITH", 0)
+	if len(rtn) != 1 || rtn[0].ItemId != "bookmark:github" {
+		t.Errorf("matching should ignore case, got %v", rtn)
+	}
+	if rtn = rankItems(items, "", 2); len(rtn) != 2 {
+		t.Errorf("expected the limit to be applied, got %d items", len(rtn))
+	}
+}
+
+func TestBlockItemTitle(t *testing.T) {
+	block := &waveobj.Block{OID: "b1", Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "term", waveobj.MetaKey_Cmd: "htop"}}
+	if item := makeBlockItem(block, "tab"); item.Title != "term htop" || item.SubText != "tab" {
+		t.Errorf("unexpected block item %+v", item)
+	}
+	block.Meta[waveobj.MetaKey_FrameTitle] = "monitor"
+	if item := makeBlockItem(block, "tab"); item.Title != "monitor" {
+		t.Errorf("frame:title should be the title, got %q", item.Title)
+	}
+}
@@ -0,0 +1,596 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package portforward runs the port forwards of the saved connections: local forwards (-L) listen on this machine
+// and dial the destination from the remote host, remote forwards (-R) listen on the remote host and dial the
+// destination from this machine, and dynamic forwards (-D) are a socks5 proxy on this machine that dials from the
+// remote host.  a forward can be saved on its connection (auto-start), it is started every time the connection
+// connects.  local and dynamic forwards keep listening while their connection is down (the new connections are
+// refused), a remote forward waits for the connection to come back and listens again.  a forward's bind port
+// can't overlap one of another forward (on the same side), and the bytes it carries are counted as they go.
+package portforward
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	Type_Local   = "local"
+	Type_Remote  = "remote"
+	Type_Dynamic = "dynamic"
+)
+
+const (
+	Status_Running = "running"
+	Status_Waiting = "waiting" // for the connection to connect
+	Status_Error   = "error"
+)
+
+const DefaultBindAddr = "127.0.0.1"
+const dialTimeout = 30 * time.Second
+const dbTimeout = 5 * time.Second
+const connStateQueueSize = 64
+
+type forward struct {
+	id        string
+	connId    string // the saved connection
+	connLabel string // its name
+	connName  string // its ssh conn name
+	spec      waveobj.PortForward
+	autoStart bool
+
+	lock     sync.Mutex
+	status   string
+	err      string
+	startTs  int64
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	stopped  bool
+
+	bytesSent   atomic.Int64
+	bytesRecv   atomic.Int64
+	activeConns atomic.Int32
+	totalConns  atomic.Int64
+}
+
+var globalLock = &sync.Mutex{}
+var forwards []*forward
+var nextId int
+
+var connStateCh = make(chan string, connStateQueueSize)
+var startOnce = &sync.Once{}
+
+// starts the auto-start forwards when their connection connects
+func Start() {
+	startOnce.Do(func() {
+		eventbus.Subscribe(eventbus.Topic_ConnState, eventbus.Scope{}, handleConnStateEvent)
+		go runConnStateLoop()
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("portforward:loadSavedForwards", recover())
+			}()
+			loadSavedForwards()
+		}()
+	})
+}
+
+// called from the publishing goroutine, must not block
+func handleConnStateEvent(event eventbus.Event) {
+	state, ok := event.Data().(*wps.ConnStateEventData)
+	if !ok || state.State != conncontroller.Status_Connected {
+		return
+	}
+	select {
+	case connStateCh <- state.ConnName:
+	default:
+		log.Printf("portforward: conn:state queue is full, dropping event for %s\n", state.ConnName)
+	}
+}
+
+func runConnStateLoop() {
+	defer func() {
+		panichandler.PanicHandler("portforward:runConnStateLoop", recover())
+	}()
+	for connName := range connStateCh {
+		loadSavedForwards()
+		for _, fwd := range getForwards() {
+			if fwd.connName == connName && fwd.getStatus() != Status_Running {
+				fwd.start()
+			}
+		}
+	}
+}
+
+func getForwards() []*forward {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	rtn := make([]*forward, len(forwards))
+	copy(rtn, forwards)
+	return rtn
+}
+
+// adds the saved forwards that are not in the table (waiting), they are started if their connection is connected
+func loadSavedForwards() {
+	ctx, cancelFn := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancelFn()
+	conns, err := wconn.ListConnections(ctx)
+	if err != nil {
+		log.Printf("portforward: error getting connections: %v\n", err)
+		return
+	}
+	for _, conn := range conns {
+		for _, spec := range conn.Forwards {
+			fwd, err := addForward(conn, spec, true)
+			if err != nil {
+				log.Printf("portforward: cannot set up a saved forward of %s: %v\n", conn.Name, err)
+				continue
+			}
+			if isConnected(fwd.connName) {
+				fwd.start()
+			}
+		}
+	}
+}
+
+func isConnected(connName string) bool {
+	_, err := getClient(connName)
+	return err == nil
+}
+
+func getClient(connName string) (*ssh.Client, error) {
+	opts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return nil, err
+	}
+	conn := conncontroller.GetConn(opts)
+	status := conn.GetStatus()
+	if status != conncontroller.Status_Connected && status != conncontroller.Status_Degraded {
+		return nil, fmt.Errorf("%s is not connected (%s)", connName, status)
+	}
+	client := conn.GetClient()
+	if client == nil {
+		return nil, fmt.Errorf("%s is not connected", connName)
+	}
+	return client, nil
+}
+
+func normalizeForward(spec *waveobj.PortForward) error {
+	if spec.BindAddr == "" {
+		spec.BindAddr = DefaultBindAddr
+	}
+	if spec.BindPort <= 0 || spec.BindPort > 65535 {
+		return fmt.Errorf("invalid bind port %d", spec.BindPort)
+	}
+	switch spec.Type {
+	case Type_Local, Type_Remote:
+		if spec.DestHost == "" {
+			return fmt.Errorf("a %s forward needs a destination host", spec.Type)
+		}
+		if spec.DestPort <= 0 || spec.DestPort > 65535 {
+			return fmt.Errorf("invalid destination port %d", spec.DestPort)
+		}
+	case Type_Dynamic:
+		if spec.DestHost != "" || spec.DestPort != 0 {
+			return fmt.Errorf("a dynamic forward has no destination (the socks client chooses it)")
+		}
+	default:
+		return fmt.Errorf("invalid forward type %q (local, remote or dynamic)", spec.Type)
+	}
+	return nil
+}
+
+func isWildcardAddr(addr string) bool {
+	return addr == "" || addr == "*" || addr == "0.0.0.0" || addr == "::"
+}
+
+func bindAddrsOverlap(addr1 string, addr2 string) bool {
+	return addr1 == addr2 || isWildcardAddr(addr1) || isWildcardAddr(addr2)
+}
+
+// local and dynamic forwards listen on this machine, remote forwards on their connection's host
+func checkConflict(fwds []*forward, connName string, spec waveobj.PortForward) error {
+	for _, other := range fwds {
+		if other.spec.BindPort != spec.BindPort || !bindAddrsOverlap(other.spec.BindAddr, spec.BindAddr) {
+			continue
+		}
+		if spec.Type == Type_Remote {
+			if other.spec.Type == Type_Remote && other.connName == connName {
+				return fmt.Errorf("port %d on %s is already forwarded (forward %s)", spec.BindPort, connName, other.id)
+			}
+			continue
+		}
+		if other.spec.Type != Type_Remote {
+			return fmt.Errorf("port %d is already forwarded (forward %s on %s)", spec.BindPort, other.id, other.connLabel)
+		}
+	}
+	return nil
+}
+
+// adds a forward to the table (waiting), a saved forward that is already in the table is returned as it is
+func addForward(conn *waveobj.Connection, spec waveobj.PortForward, autoStart bool) (*forward, error) {
+	err := normalizeForward(&spec)
+	if err != nil {
+		return nil, err
+	}
+	connName := wconn.ConnName(conn)
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	for _, fwd := range forwards {
+		if fwd.connId == conn.OID && fwd.spec == spec {
+			if autoStart {
+				fwd.lock.Lock()
+				fwd.autoStart = true
+				fwd.lock.Unlock()
+				return fwd, nil
+			}
+			return nil, fmt.Errorf("the forward is already set up (forward %s)", fwd.id)
+		}
+	}
+	err = checkConflict(forwards, connName, spec)
+	if err != nil {
+		return nil, err
+	}
+	nextId++
+	fwd := &forward{
+		id:        strconv.Itoa(nextId),
+		connId:    conn.OID,
+		connLabel: conn.Name,
+		connName:  connName,
+		spec:      spec,
+		autoStart: autoStart,
+		status:    Status_Waiting,
+		conns:     make(map[net.Conn]struct{}),
+	}
+	forwards = append(forwards, fwd)
+	return fwd, nil
+}
+
+func removeForward(fwd *forward) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	for idx, other := range forwards {
+		if other == fwd {
+			forwards = append(forwards[:idx], forwards[idx+1:]...)
+			return
+		}
+	}
+}
+
+func findForward(id string) *forward {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	for _, fwd := range forwards {
+		if fwd.id == id {
+			return fwd
+		}
+	}
+	return nil
+}
+
+// sets up the forward on the saved connection (connecting it if needed) and starts it
+func CreateForward(ctx context.Context, data wshrpc.CommandPortForwardCreateData) (*wshrpc.PortForwardInfo, error) {
+	conn, err := wconn.ResolveConnection(ctx, data.Connection)
+	if err != nil {
+		return nil, err
+	}
+	fwd, err := addForward(conn, data.Forward, false)
+	if err != nil {
+		return nil, err
+	}
+	err = conncontroller.EnsureConnection(ctx, fwd.connName)
+	if err == nil {
+		err = fwd.start()
+	}
+	if err != nil {
+		removeForward(fwd)
+		return nil, err
+	}
+	if data.AutoStart {
+		err = saveForward(ctx, conn.OID, fwd.spec, true)
+		if err != nil {
+			fwd.stop()
+			removeForward(fwd)
+			return nil, err
+		}
+		fwd.lock.Lock()
+		fwd.autoStart = true
+		fwd.lock.Unlock()
+	}
+	info := fwd.getInfo()
+	return &info, nil
+}
+
+// adds the forward to the connection's auto-start forwards (or removes it)
+func saveForward(ctx context.Context, connId string, spec waveobj.PortForward, add bool) error {
+	conn, err := wstore.DBMustGet[*waveobj.Connection](ctx, connId)
+	if err != nil {
+		return err
+	}
+	var newForwards []waveobj.PortForward
+	for _, saved := range conn.Forwards {
+		if saved != spec {
+			newForwards = append(newForwards, saved)
+		}
+	}
+	if add {
+		newForwards = append(newForwards, spec)
+	}
+	conn.Forwards = newForwards
+	return wstore.DBUpdate(ctx, conn)
+}
+
+func ListForwards(ctx context.Context, data wshrpc.CommandPortForwardListData) ([]wshrpc.PortForwardInfo, error) {
+	var connId string
+	if data.Connection != "" {
+		conn, err := wconn.ResolveConnection(ctx, data.Connection)
+		if err != nil {
+			return nil, err
+		}
+		connId = conn.OID
+	}
+	var rtn []wshrpc.PortForwardInfo
+	for _, fwd := range getForwards() {
+		if connId != "" && fwd.connId != connId {
+			continue
+		}
+		rtn = append(rtn, fwd.getInfo())
+	}
+	return rtn, nil
+}
+
+// stops the forward, an auto-start forward is started again on the next connect unless forget is set
+func StopForward(ctx context.Context, data wshrpc.CommandPortForwardStopData) error {
+	fwd := findForward(data.Id)
+	if fwd == nil {
+		return fmt.Errorf("forward %s not found", data.Id)
+	}
+	fwd.lock.Lock()
+	autoStart := fwd.autoStart
+	fwd.lock.Unlock()
+	if data.Forget && autoStart {
+		err := saveForward(ctx, fwd.connId, fwd.spec, false)
+		if err != nil {
+			return err
+		}
+	}
+	fwd.stop()
+	removeForward(fwd)
+	return nil
+}
+
+func (fwd *forward) getStatus() string {
+	fwd.lock.Lock()
+	defer fwd.lock.Unlock()
+	return fwd.status
+}
+
+func (fwd *forward) getInfo() wshrpc.PortForwardInfo {
+	fwd.lock.Lock()
+	defer fwd.lock.Unlock()
+	return wshrpc.PortForwardInfo{
+		Id:          fwd.id,
+		Connection:  fwd.connLabel,
+		ConnName:    fwd.connName,
+		Forward:     fwd.spec,
+		AutoStart:   fwd.autoStart,
+		Status:      fwd.status,
+		Error:       fwd.err,
+		StartTs:     fwd.startTs,
+		BytesSent:   fwd.bytesSent.Load(),
+		BytesRecv:   fwd.bytesRecv.Load(),
+		ActiveConns: int(fwd.activeConns.Load()),
+		TotalConns:  fwd.totalConns.Load(),
+	}
+}
+
+func (fwd *forward) setError(err error) error {
+	fwd.lock.Lock()
+	defer fwd.lock.Unlock()
+	fwd.status = Status_Error
+	fwd.err = err.Error()
+	return err
+}
+
+func listenError(err error, where string, port int) error {
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return fmt.Errorf("port %d is in use %s", port, where)
+	}
+	return fmt.Errorf("cannot listen on port %d %s: %w", port, where, err)
+}
+
+// starts listening (does nothing if it is already running)
+func (fwd *forward) start() error {
+	fwd.lock.Lock()
+	if fwd.stopped || fwd.status == Status_Running {
+		fwd.lock.Unlock()
+		return nil
+	}
+	fwd.lock.Unlock()
+	bindAddr := net.JoinHostPort(fwd.spec.BindAddr, strconv.Itoa(fwd.spec.BindPort))
+	var listener net.Listener
+	var err error
+	if fwd.spec.Type == Type_Remote {
+		client, clientErr := getClient(fwd.connName)
+		if clientErr != nil {
+			return fwd.setError(clientErr)
+		}
+		listener, err = client.Listen("tcp", bindAddr)
+		if err != nil {
+			return fwd.setError(fmt.Errorf("the remote host refused to listen on %s: %w", bindAddr, err))
+		}
+	} else {
+		listener, err = net.Listen("tcp", bindAddr)
+		if err != nil {
+			return fwd.setError(listenError(err, "on this machine", fwd.spec.BindPort))
+		}
+	}
+	fwd.lock.Lock()
+	if fwd.stopped {
+		fwd.lock.Unlock()
+		listener.Close()
+		return nil
+	}
+	fwd.listener = listener
+	fwd.status = Status_Running
+	fwd.err = ""
+	fwd.startTs = time.Now().UnixMilli()
+	fwd.lock.Unlock()
+	log.Printf("portforward: started %s forward %s on %s (%s)\n", fwd.spec.Type, fwd.id, bindAddr, fwd.connName)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("portforward:acceptLoop", recover())
+		}()
+		fwd.acceptLoop(listener)
+	}()
+	return nil
+}
+
+func (fwd *forward) stop() {
+	fwd.lock.Lock()
+	defer fwd.lock.Unlock()
+	fwd.stopped = true
+	if fwd.listener != nil {
+		fwd.listener.Close()
+		fwd.listener = nil
+	}
+	for conn := range fwd.conns {
+		conn.Close()
+	}
+}
+
+func (fwd *forward) acceptLoop(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			fwd.lock.Lock()
+			if !fwd.stopped && fwd.listener == listener {
+				// a remote listener ends with its connection, it is started again when the connection is back
+				fwd.listener = nil
+				fwd.status = Status_Waiting
+				fwd.err = err.Error()
+			}
+			fwd.lock.Unlock()
+			return
+		}
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("portforward:handleConn", recover())
+			}()
+			fwd.handleConn(conn)
+		}()
+	}
+}
+
+func (fwd *forward) dialDest(addr string) (net.Conn, error) {
+	if fwd.spec.Type == Type_Remote {
+		return net.DialTimeout("tcp", addr, dialTimeout)
+	}
+	client, err := getClient(fwd.connName)
+	if err != nil {
+		return nil, err
+	}
+	return client.Dial("tcp", addr)
+}
+
+func (fwd *forward) trackConn(conn net.Conn, add bool) bool {
+	fwd.lock.Lock()
+	defer fwd.lock.Unlock()
+	if !add {
+		delete(fwd.conns, conn)
+		return true
+	}
+	if fwd.stopped {
+		return false
+	}
+	fwd.conns[conn] = struct{}{}
+	return true
+}
+
+func (fwd *forward) handleConn(conn net.Conn) {
+	defer conn.Close()
+	if !fwd.trackConn(conn, true) {
+		return
+	}
+	defer fwd.trackConn(conn, false)
+	var destAddr string
+	if fwd.spec.Type == Type_Dynamic {
+		var err error
+		destAddr, err = socksHandshake(conn)
+		if err != nil {
+			log.Printf("portforward: forward %s: socks error: %v\n", fwd.id, err)
+			return
+		}
+	} else {
+		destAddr = net.JoinHostPort(fwd.spec.DestHost, strconv.Itoa(fwd.spec.DestPort))
+	}
+	destConn, err := fwd.dialDest(destAddr)
+	if fwd.spec.Type == Type_Dynamic {
+		if err != nil {
+			socksReply(conn, socksReply_HostUnreachable)
+		} else {
+			socksReply(conn, socksReply_Succeeded)
+		}
+	}
+	if err != nil {
+		log.Printf("portforward: forward %s: cannot reach %s: %v\n", fwd.id, destAddr, err)
+		return
+	}
+	defer destConn.Close()
+	if !fwd.trackConn(destConn, true) {
+		return
+	}
+	defer fwd.trackConn(destConn, false)
+	fwd.activeConns.Add(1)
+	fwd.totalConns.Add(1)
+	defer fwd.activeConns.Add(-1)
+	doneCh := make(chan struct{}, 2)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("portforward:copySent", recover())
+		}()
+		io.Copy(&countingWriter{w: destConn, count: &fwd.bytesSent}, conn)
+		doneCh <- struct{}{}
+	}()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("portforward:copyRecv", recover())
+		}()
+		io.Copy(&countingWriter{w: conn, count: &fwd.bytesRecv}, destConn)
+		doneCh <- struct{}{}
+	}()
+	// when one side is done, the deferred closes end the other copy
+	<-doneCh
+}
+
+// counts the bytes as they are written, so the counters are live
+type countingWriter struct {
+	w     io.Writer
+	count *atomic.Int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.count.Add(int64(n))
+	return n, err
+}
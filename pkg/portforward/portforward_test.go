@@ -0,0 +1,72 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package portforward
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestCheckConflict(t *testing.T) {
+	fwds := []*forward{
+		{id: "1", connName: "admin@db", connLabel: "db", spec: waveobj.PortForward{Type: Type_Local, BindAddr: "127.0.0.1", BindPort: 5432}},
+		{id: "2", connName: "admin@db", connLabel: "db", spec: waveobj.PortForward{Type: Type_Remote, BindAddr: "0.0.0.0", BindPort: 8080}},
+	}
+	if err := checkConflict(fwds, "ops@web", waveobj.PortForward{Type: Type_Dynamic, BindAddr: "0.0.0.0", BindPort: 5432}); err == nil {
+		t.Errorf("a wildcard bind on a forwarded local port should conflict")
+	}
+	if err := checkConflict(fwds, "ops@web", waveobj.PortForward{Type: Type_Local, BindAddr: "127.0.0.2", BindPort: 5432}); err != nil {
+		t.Errorf("another bind address should not conflict: %v", err)
+	}
+	if err := checkConflict(fwds, "admin@db", waveobj.PortForward{Type: Type_Remote, BindAddr: "127.0.0.1", BindPort: 8080}); err == nil {
+		t.Errorf("the same remote port on the same connection should conflict")
+	}
+	if err := checkConflict(fwds, "ops@web", waveobj.PortForward{Type: Type_Remote, BindAddr: "127.0.0.1", BindPort: 8080}); err != nil {
+		t.Errorf("a remote port on another connection should not conflict: %v", err)
+	}
+	if err := checkConflict(fwds, "admin@db", waveobj.PortForward{Type: Type_Local, BindAddr: "127.0.0.1", BindPort: 8080}); err != nil {
+		t.Errorf("a local port should not conflict with a remote one: %v", err)
+	}
+}
+
+func TestNormalizeForward(t *testing.T) {
+	spec := waveobj.PortForward{Type: Type_Local, BindPort: 8080, DestHost: "localhost", DestPort: 80}
+	if err := normalizeForward(&spec); err != nil || spec.BindAddr != DefaultBindAddr {
+		t.Errorf("unexpected local forward %+v (%v)", spec, err)
+	}
+	if err := normalizeForward(&waveobj.PortForward{Type: Type_Remote, BindPort: 8080}); err == nil {
+		t.Errorf("a remote forward without a destination should be refused")
+	}
+	if err := normalizeForward(&waveobj.PortForward{Type: Type_Dynamic, BindPort: 1080, DestHost: "example.com"}); err == nil {
+		t.Errorf("a dynamic forward with a destination should be refused")
+	}
+	if err := normalizeForward(&waveobj.PortForward{Type: Type_Local, BindPort: 70000, DestHost: "localhost", DestPort: 80}); err == nil {
+		t.Errorf("port 70000 should be refused")
+	}
+}
+
+func TestSocksHandshake(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go func() {
+		client.Write([]byte{5, 1, socksAuth_None})
+		reply := make([]byte, 2)
+		io.ReadFull(client, reply)
+		request := []byte{5, socksCmd_Connect, 0, socksAddr_Domain, byte(len("example.com"))}
+		request = append(request, "example.com"...)
+		request = append(request, 0x01, 0xbb)
+		client.Write(request)
+	}()
+	addr, err := socksHandshake(server)
+	if err != nil {
+		t.Fatalf("handshake error: %v", err)
+	}
+	if addr != "example.com:443" {
+		t.Errorf("expected example.com:443, got %q", addr)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package portforward
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// the server side of a socks5 CONNECT (rfc 1928) without authentication, for dynamic forwards
+
+const socksVersion = 5
+
+const (
+	socksCmd_Connect = 1
+
+	socksAddr_IPv4   = 1
+	socksAddr_Domain = 3
+	socksAddr_IPv6   = 4
+
+	socksAuth_None         = 0
+	socksAuth_NoAcceptable = 0xff
+
+	socksReply_Succeeded        = 0
+	socksReply_HostUnreachable  = 4
+	socksReply_CmdNotSupported  = 7
+	socksReply_AddrNotSupported = 8
+)
+
+// reads the greeting and the request, returns the address to connect to ("host:port").  the reply is sent with
+// socksReply once the destination is dialed (errors in the request are replied to here).
+func socksHandshake(conn net.Conn) (string, error) {
+	buf := make([]byte, 256)
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", err
+	}
+	if buf[0] != socksVersion {
+		return "", fmt.Errorf("not a socks5 client (version %d)", buf[0])
+	}
+	numMethods := int(buf[1])
+	if _, err := io.ReadFull(conn, buf[:numMethods]); err != nil {
+		return "", err
+	}
+	hasNoAuth := false
+	for _, method := range buf[:numMethods] {
+		if method == socksAuth_None {
+			hasNoAuth = true
+		}
+	}
+	if !hasNoAuth {
+		conn.Write([]byte{socksVersion, socksAuth_NoAcceptable})
+		return "", fmt.Errorf("the socks client requires authentication")
+	}
+	if _, err := conn.Write([]byte{socksVersion, socksAuth_None}); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return "", err
+	}
+	if buf[1] != socksCmd_Connect {
+		socksReply(conn, socksReply_CmdNotSupported)
+		return "", fmt.Errorf("unsupported socks command %d", buf[1])
+	}
+	var host string
+	switch buf[3] {
+	case socksAddr_IPv4:
+		if _, err := io.ReadFull(conn, buf[:net.IPv4len]); err != nil {
+			return "", err
+		}
+		host = net.IP(buf[:net.IPv4len]).String()
+	case socksAddr_IPv6:
+		if _, err := io.ReadFull(conn, buf[:net.IPv6len]); err != nil {
+			return "", err
+		}
+		host = net.IP(buf[:net.IPv6len]).String()
+	case socksAddr_Domain:
+		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+			return "", err
+		}
+		nameLen := int(buf[0])
+		if _, err := io.ReadFull(conn, buf[:nameLen]); err != nil {
+			return "", err
+		}
+		host = string(buf[:nameLen])
+	default:
+		socksReply(conn, socksReply_AddrNotSupported)
+		return "", fmt.Errorf("unsupported socks address type %d", buf[3])
+	}
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", err
+	}
+	port := int(buf[0])<<8 | int(buf[1])
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// the bound address is not known (the connection is dialed from the remote host), it is sent as 0.0.0.0:0
+func socksReply(conn net.Conn, code byte) error {
+	_, err := conn.Write([]byte{socksVersion, code, 0, socksAddr_IPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
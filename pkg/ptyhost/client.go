@@ -0,0 +1,223 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package ptyhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const AttachTimeout = 2 * time.Second
+
+// a connection to a running helper.  Read returns the pty output (starting with anything buffered while detached)
+// and returns io.EOF once the shell has exited or the client has detached.
+type Client struct {
+	conn      net.Conn
+	writeLock sync.Mutex
+	hello     helloData
+	outReader *io.PipeReader
+	outWriter *io.PipeWriter
+	doneCh    chan struct{} // closed when the read loop is done (exit, detach, or connection error)
+	exited    bool          // written before doneCh is closed
+	exitCode  int
+	readErr   error
+	detached  atomic.Bool
+}
+
+func Attach(sockPath string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", sockPath, AttachTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to ptyhost: %w", err)
+	}
+	return AttachConn(conn, AttachTimeout)
+}
+
+// attaches over a connection to a helper serving another transport (see Serve), conn is closed on errors
+func AttachConn(conn net.Conn, timeout time.Duration) (*Client, error) {
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	frameType, payload, err := readFrame(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error reading ptyhost hello: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	if frameType != FrameType_Hello {
+		conn.Close()
+		return nil, fmt.Errorf("invalid ptyhost hello frame %q", frameType)
+	}
+	client := &Client{conn: conn, doneCh: make(chan struct{})}
+	err = json.Unmarshal(payload, &client.hello)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error parsing ptyhost hello: %w", err)
+	}
+	client.outReader, client.outWriter = io.Pipe()
+	go client.readLoop()
+	return client, nil
+}
+
+func (c *Client) readLoop() {
+	defer close(c.doneCh)
+	defer c.outWriter.Close()
+	for {
+		frameType, payload, err := readFrame(c.conn)
+		if err != nil {
+			c.readErr = err
+			return
+		}
+		switch frameType {
+		case FrameType_Data:
+			// blocks until the output is consumed
+			_, err = c.outWriter.Write(payload)
+			if err != nil {
+				c.readErr = err
+				return
+			}
+		case FrameType_Exit:
+			var data exitData
+			json.Unmarshal(payload, &data)
+			c.exitCode = data.ExitCode
+			c.exited = true
+			return
+		case FrameType_DetachAck:
+			c.detached.Store(true)
+			return
+		}
+	}
+}
+
+// pid of the shell
+func (c *Client) Pid() int {
+	return c.hello.Pid
+}
+
+func (c *Client) HostPid() int {
+	return c.hello.HostPid
+}
+
+// true if output was dropped while no client was attached
+func (c *Client) Truncated() bool {
+	return c.hello.Truncated
+}
+
+func (c *Client) Read(p []byte) (int, error) {
+	return c.outReader.Read(p)
+}
+
+func (c *Client) Write(p []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	for written := 0; written < len(p); {
+		chunk := p[written:]
+		if len(chunk) > MaxFrameSize {
+			chunk = chunk[:MaxFrameSize]
+		}
+		err := writeFrame(c.conn, FrameType_Data, chunk)
+		if err != nil {
+			return written, err
+		}
+		written += len(chunk)
+	}
+	return len(p), nil
+}
+
+func (c *Client) writeJson(frameType byte, data any) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return writeJsonFrame(c.conn, frameType, data)
+}
+
+func (c *Client) SetSize(rows int, cols int) error {
+	return c.writeJson(FrameType_Resize, resizeData{Rows: rows, Cols: cols})
+}
+
+// a graceful kill hangs up the shell and force kills it after timeout
+func (c *Client) Kill(force bool, timeout time.Duration) error {
+	return c.writeJson(FrameType_Kill, killData{Force: force, TimeoutMs: timeout.Milliseconds()})
+}
+
+// signals the foreground process group of the shell's pty (the helper does not report errors back)
+func (c *Client) Signal(sigName string) error {
+	return c.writeJson(FrameType_Signal, signalData{Signal: sigName})
+}
+
+// detaches from the helper, leaving the shell running.  output produced after this is buffered in the helper.
+func (c *Client) Detach(timeout time.Duration) error {
+	c.detached.Store(true)
+	defer c.conn.Close()
+	c.writeLock.Lock()
+	err := writeFrame(c.conn, FrameType_Detach, nil)
+	c.writeLock.Unlock()
+	if err != nil {
+		return fmt.Errorf("error sending detach: %w", err)
+	}
+	select {
+	case <-c.doneCh:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timeout waiting for detach")
+	}
+}
+
+func (c *Client) Detached() bool {
+	return c.detached.Load()
+}
+
+func (c *Client) Done() <-chan struct{} {
+	return c.doneCh
+}
+
+// returns nil if the shell exited cleanly, ErrDetached if the client detached
+func (c *Client) Wait() error {
+	<-c.doneCh
+	if c.exited {
+		if c.exitCode != 0 {
+			return fmt.Errorf("exit status %d", c.exitCode)
+		}
+		return nil
+	}
+	if c.detached.Load() {
+		return ErrDetached
+	}
+	return fmt.Errorf("lost connection to ptyhost: %w", c.readErr)
+}
+
+// true once the exit code of the shell has been received
+func (c *Client) Exited() bool {
+	select {
+	case <-c.doneCh:
+		return c.exited
+	default:
+		return false
+	}
+}
+
+// true once the connection to the helper was lost (the shell did not exit and the client did not detach), the shell
+// may still be running
+func (c *Client) Lost() bool {
+	select {
+	case <-c.doneCh:
+		return !c.exited && !c.detached.Load()
+	default:
+		return false
+	}
+}
+
+// only valid once Wait() has returned, -1 if the exit code is not known
+func (c *Client) ExitCode() int {
+	if !c.exited {
+		return -1
+	}
+	return c.exitCode
+}
+
+// closes the connection without detaching, the helper keeps the shell running and buffers its output
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
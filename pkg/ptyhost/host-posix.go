@@ -0,0 +1,329 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package ptyhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/wavetermdev/waveterm/pkg/util/sigutil"
+)
+
+type host struct {
+	lock      sync.Mutex
+	listener  net.Listener
+	cmd       *exec.Cmd
+	pty       pty.Pty
+	startTs   int64
+	conn      net.Conn // the attached client (nil when detached)
+	buf       []byte   // output produced while detached
+	truncated bool
+	exited    bool
+	exitCode  int
+	doneCh    chan struct{}
+	doneOnce  sync.Once
+	linger    time.Duration
+}
+
+// entry point for the helper process.  reads a StartSpec from stdin, starts the shell, and serves
+// clients on sockPath until the shell exits and its exit code has been delivered.
+func RunHost(sockPath string) error {
+	log.SetPrefix("[ptyhost] ")
+	sigutil.InstallDetachSignalHandlers()
+	var spec StartSpec
+	err := json.NewDecoder(os.Stdin).Decode(&spec)
+	if err != nil {
+		writeStarted(0, fmt.Errorf("error reading start spec: %w", err))
+		return err
+	}
+	os.Remove(sockPath)
+	listener, err := net.Listen("unix", sockPath)
+	if err != nil {
+		err = fmt.Errorf("error listening on %q: %w", sockPath, err)
+		writeStarted(0, err)
+		return err
+	}
+	os.Chmod(sockPath, 0600)
+	defer os.Remove(sockPath)
+	defer listener.Close()
+	h, err := startHost(spec, listener)
+	if err != nil {
+		writeStarted(0, err)
+		return err
+	}
+	writeStarted(h.cmd.Process.Pid, nil)
+	os.Stdin.Close()
+	os.Stdout.Close()
+	go h.acceptLoop()
+	go h.ptyReadLoop()
+	<-h.doneCh
+	return nil
+}
+
+// runs spec like RunHost, but serves the clients of listener (which is not closed) instead of a unix socket.
+// returns once the shell has exited and its exit code was delivered (or opts.ExitLinger has passed).
+func Serve(spec StartSpec, listener net.Listener, opts HostOpts) error {
+	h, err := startHost(spec, listener)
+	if err != nil {
+		return err
+	}
+	if opts.ExitLinger > 0 {
+		h.linger = opts.ExitLinger
+	}
+	go h.acceptLoop()
+	go h.ptyReadLoop()
+	if opts.KillCh != nil {
+		go func() {
+			select {
+			case <-opts.KillCh:
+				h.kill(killData{Force: true})
+				h.done()
+			case <-h.doneCh:
+			}
+		}()
+	}
+	<-h.doneCh
+	return nil
+}
+
+func writeStarted(pid int, err error) {
+	data := startedData{Pid: pid}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	barr, _ := json.Marshal(data)
+	os.Stdout.Write(append(barr, '\n'))
+}
+
+func startHost(spec StartSpec, listener net.Listener) (*host, error) {
+	if len(spec.Args) == 0 {
+		return nil, fmt.Errorf("no command given")
+	}
+	cmd := &exec.Cmd{Path: spec.Path, Args: spec.Args, Env: spec.Env, Dir: spec.Dir}
+	cmdPty, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: uint16(spec.Rows), Cols: uint16(spec.Cols)})
+	if err != nil {
+		return nil, fmt.Errorf("error starting command: %w", err)
+	}
+	return &host{
+		listener: listener,
+		cmd:      cmd,
+		pty:      cmdPty,
+		startTs:  time.Now().UnixMilli(),
+		doneCh:   make(chan struct{}),
+		linger:   ExitedLingerTime,
+	}, nil
+}
+
+func (h *host) done() {
+	h.doneOnce.Do(func() {
+		close(h.doneCh)
+	})
+}
+
+// must hold lock
+func (h *host) bufferOutput(data []byte) {
+	h.buf = append(h.buf, data...)
+	if len(h.buf) > DetachedBufferSize {
+		h.buf = h.buf[len(h.buf)-DetachedBufferSize:]
+		h.truncated = true
+	}
+}
+
+// must hold lock, returns false (and detaches the client) if the write fails
+func (h *host) writeToClient(frameType byte, payload []byte) bool {
+	if h.conn == nil {
+		return false
+	}
+	h.conn.SetWriteDeadline(time.Now().Add(ClientWriteTimeout))
+	err := writeFrame(h.conn, frameType, payload)
+	if err != nil {
+		log.Printf("error writing to client (detaching): %v\n", err)
+		h.conn.Close()
+		h.conn = nil
+		return false
+	}
+	return true
+}
+
+func (h *host) ptyReadLoop() {
+	buf := make([]byte, 32*1024)
+	for {
+		nr, err := h.pty.Read(buf)
+		if nr > 0 {
+			h.lock.Lock()
+			if !h.writeToClient(FrameType_Data, buf[:nr]) {
+				h.bufferOutput(buf[:nr])
+			}
+			h.lock.Unlock()
+		}
+		if err != nil {
+			break
+		}
+	}
+	h.cmd.Wait()
+	exitCode := -1
+	if h.cmd.ProcessState != nil {
+		exitCode = h.cmd.ProcessState.ExitCode()
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.exited = true
+	h.exitCode = exitCode
+	if h.writeToClient(FrameType_Exit, mustMarshal(exitData{ExitCode: exitCode})) {
+		h.done()
+		return
+	}
+	go func() {
+		time.Sleep(h.linger)
+		h.done()
+	}()
+}
+
+func mustMarshal(data any) []byte {
+	barr, _ := json.Marshal(data)
+	return barr
+}
+
+func (h *host) acceptLoop() {
+	for {
+		conn, err := h.listener.Accept()
+		if err != nil {
+			return
+		}
+		h.attach(conn)
+	}
+}
+
+// a new client replaces the current one
+func (h *host) attach(conn net.Conn) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.conn != nil {
+		h.conn.Close()
+	}
+	h.conn = conn
+	hello := helloData{Pid: h.cmd.Process.Pid, HostPid: os.Getpid(), Truncated: h.truncated, StartTs: h.startTs}
+	if !h.writeToClient(FrameType_Hello, mustMarshal(hello)) {
+		return
+	}
+	for len(h.buf) > 0 {
+		chunk := h.buf
+		if len(chunk) > MaxFrameSize {
+			chunk = chunk[:MaxFrameSize]
+		}
+		if !h.writeToClient(FrameType_Data, chunk) {
+			return
+		}
+		h.buf = h.buf[len(chunk):]
+	}
+	h.buf = nil
+	h.truncated = false
+	if h.exited {
+		if h.writeToClient(FrameType_Exit, mustMarshal(exitData{ExitCode: h.exitCode})) {
+			h.done()
+		}
+		return
+	}
+	go h.clientReadLoop(conn)
+}
+
+func (h *host) clientReadLoop(conn net.Conn) {
+	defer func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		if h.conn == conn {
+			h.conn = nil
+		}
+		conn.Close()
+	}()
+	for {
+		frameType, payload, err := readFrame(conn)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("error reading from client: %v\n", err)
+			}
+			return
+		}
+		switch frameType {
+		case FrameType_Data:
+			h.pty.Write(payload)
+		case FrameType_Resize:
+			var data resizeData
+			if json.Unmarshal(payload, &data) == nil && data.Rows > 0 && data.Cols > 0 {
+				pty.Setsize(h.pty, &pty.Winsize{Rows: uint16(data.Rows), Cols: uint16(data.Cols)})
+			}
+		case FrameType_Kill:
+			var data killData
+			json.Unmarshal(payload, &data)
+			h.kill(data)
+		case FrameType_Signal:
+			var data signalData
+			json.Unmarshal(payload, &data)
+			h.signal(data)
+		case FrameType_Detach:
+			h.lock.Lock()
+			if h.conn == conn {
+				h.writeToClient(FrameType_DetachAck, nil)
+				h.conn = nil
+			}
+			h.lock.Unlock()
+			return
+		}
+	}
+}
+
+func (h *host) signal(data signalData) {
+	h.lock.Lock()
+	exited := h.exited
+	h.lock.Unlock()
+	if exited {
+		return
+	}
+	err := sigutil.SignalPtyForeground(h.pty, h.cmd.Process.Pid, data.Signal)
+	if err != nil {
+		log.Printf("error signaling shell: %v\n", err)
+	}
+}
+
+// the shell is a session leader (pgid == pid), hanging up its process group is what a terminal close does
+func (h *host) kill(data killData) {
+	h.lock.Lock()
+	exited := h.exited
+	h.lock.Unlock()
+	if exited {
+		return
+	}
+	pid := h.cmd.Process.Pid
+	if data.Force {
+		syscall.Kill(-pid, syscall.SIGKILL)
+		h.cmd.Process.Kill()
+		return
+	}
+	syscall.Kill(-pid, syscall.SIGHUP)
+	h.cmd.Process.Signal(syscall.SIGTERM)
+	if data.TimeoutMs <= 0 {
+		return
+	}
+	go func() {
+		time.Sleep(time.Duration(data.TimeoutMs) * time.Millisecond)
+		h.lock.Lock()
+		exited := h.exited
+		h.lock.Unlock()
+		if !exited {
+			syscall.Kill(-pid, syscall.SIGKILL)
+			h.cmd.Process.Kill()
+		}
+	}()
+}
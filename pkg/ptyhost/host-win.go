@@ -0,0 +1,25 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package ptyhost
+
+import (
+	"fmt"
+	"net"
+)
+
+// conpty handles cannot be handed between processes, so persistent sessions are not supported on windows
+
+func RunHost(sockPath string) error {
+	return fmt.Errorf("ptyhost is not supported on windows")
+}
+
+func StartHost(sockPath string, spec StartSpec) (int, error) {
+	return 0, fmt.Errorf("ptyhost is not supported on windows")
+}
+
+func Serve(spec StartSpec, listener net.Listener, opts HostOpts) error {
+	return fmt.Errorf("ptyhost is not supported on windows")
+}
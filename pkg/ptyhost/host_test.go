@@ -0,0 +1,182 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package ptyhost
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/util/sigutil"
+)
+
+func startTestHost(t *testing.T) (*host, string) {
+	sockPath := filepath.Join(t.TempDir(), "test.sock")
+	spec := StartSpec{Path: "/bin/sh", Args: []string{"sh"}, Env: []string{"PS1=", "PATH=" + os.Getenv("PATH")}, Rows: 24, Cols: 80}
+	listener, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	h, err := startHost(spec, listener)
+	if err != nil {
+		t.Fatalf("startHost: %v", err)
+	}
+	go h.acceptLoop()
+	go h.ptyReadLoop()
+	t.Cleanup(func() {
+		h.kill(killData{Force: true})
+		h.listener.Close()
+	})
+	return h, sockPath
+}
+
+// reads all output from a client (until EOF) so the client read loop never blocks
+type outputCollector struct {
+	lock sync.Mutex
+	out  bytes.Buffer
+}
+
+func collectOutput(client *Client) *outputCollector {
+	oc := &outputCollector{}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			nr, err := client.Read(buf)
+			oc.lock.Lock()
+			oc.out.Write(buf[:nr])
+			oc.lock.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return oc
+}
+
+func (oc *outputCollector) waitFor(t *testing.T, want string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		oc.lock.Lock()
+		found := strings.Contains(oc.out.String(), want)
+		oc.lock.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %q in output %q", want, oc.out.String())
+}
+
+func TestPtyHostDetachReattach(t *testing.T) {
+	h, sockPath := startTestHost(t)
+	client, err := Attach(sockPath)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if client.Pid() != h.cmd.Process.Pid {
+		t.Errorf("got pid %d, want %d", client.Pid(), h.cmd.Process.Pid)
+	}
+	output := collectOutput(client)
+	client.Write([]byte("echo first-$((1+1))\n"))
+	output.waitFor(t, "first-2")
+
+	// output produced while detached is replayed on the next attach
+	client.Write([]byte("sleep 0.2; echo second-$((2+2))\n"))
+	err = client.Detach(time.Second)
+	if err != nil {
+		t.Fatalf("Detach: %v", err)
+	}
+	if client.Wait() != ErrDetached {
+		t.Errorf("expected ErrDetached from Wait")
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	client, err = Attach(sockPath)
+	if err != nil {
+		t.Fatalf("reattach: %v", err)
+	}
+	collectOutput(client).waitFor(t, "second-4")
+	client.Write([]byte("exit 3\n"))
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for exit")
+	}
+	if client.ExitCode() != 3 {
+		t.Errorf("got exit code %d, want 3", client.ExitCode())
+	}
+}
+
+func TestPtyHostLostConnection(t *testing.T) {
+	_, sockPath := startTestHost(t)
+	client, err := Attach(sockPath)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	output := collectOutput(client)
+	if client.Lost() {
+		t.Errorf("client is lost while attached")
+	}
+	// a new client replaces this one, like a reattach after the connection to the helper dropped
+	client.Write([]byte("sleep 0.5; echo after-$((3+3))\n"))
+	output.waitFor(t, "sleep 0.5")
+	client2, err := Attach(sockPath)
+	if err != nil {
+		t.Fatalf("reattach: %v", err)
+	}
+	client.Wait()
+	if !client.Lost() || client.Exited() || client.Detached() {
+		t.Errorf("expected the replaced client to be lost (exited %v, detached %v)", client.Exited(), client.Detached())
+	}
+	collectOutput(client2).waitFor(t, "after-6")
+	client2.Write([]byte("exit\n"))
+	client2.Wait()
+	if client2.Lost() || !client2.Exited() {
+		t.Errorf("expected the shell to exit")
+	}
+}
+
+// the signals the host drops must not be ignored by the shell (ignored signals stay ignored across exec)
+func TestPtyHostSignalsNotIgnored(t *testing.T) {
+	if _, err := os.Stat("/proc/self/status"); err != nil {
+		t.Skip("no /proc")
+	}
+	sigutil.InstallDetachSignalHandlers()
+	h, sockPath := startTestHost(t)
+	client, err := Attach(sockPath)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	output := collectOutput(client)
+	client.Write([]byte(fmt.Sprintf("grep SigIgn /proc/%d/status\n", h.cmd.Process.Pid)))
+	output.waitFor(t, "SigIgn:\t")
+	output.lock.Lock()
+	outStr := output.out.String()
+	output.lock.Unlock()
+	idx := strings.Index(outStr, "SigIgn:\t")
+	fields := strings.Fields(outStr[idx+len("SigIgn:\t"):])
+	if len(fields) == 0 {
+		t.Fatalf("no SigIgn value in %q", outStr)
+	}
+	sigIgn, err := strconv.ParseUint(fields[0], 16, 64)
+	if err != nil {
+		t.Fatalf("parsing SigIgn %q: %v", fields[0], err)
+	}
+	// not SIGINT, an interactive shell ignores it itself
+	for _, sig := range []syscall.Signal{syscall.SIGHUP, syscall.SIGPIPE} {
+		if sigIgn&(1<<(uint(sig)-1)) != 0 {
+			t.Errorf("the shell ignores %v (SigIgn %s)", sig, fields[0])
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ptyhost runs a local shell inside a small helper process (wavesrv started with --ptyhost) that owns
+// the pty.  wavesrv talks to the helper over a unix domain socket, so when wavesrv restarts (e.g. for an
+// auto-update) the shell keeps running and the new wavesrv reattaches to it.  output produced while no
+// client is attached is buffered in the helper and replayed on attach.
+package ptyhost
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const HostArg = "--ptyhost"
+
+const (
+	MaxFrameSize       = 1024 * 1024
+	DetachedBufferSize = 2 * 1024 * 1024 // output kept while detached, oldest output is dropped first
+	ExitedLingerTime   = time.Hour       // how long a helper waits to report the exit code of a detached shell
+	ClientWriteTimeout = 5 * time.Second // a client that stops reading for this long is treated as detached
+)
+
+// for helpers that serve another transport than the unix socket (see Serve)
+type HostOpts struct {
+	KillCh     <-chan struct{} // closing it force kills the shell (without waiting to deliver the exit code)
+	ExitLinger time.Duration   // how long to wait to report the exit code of a detached shell (ExitedLingerTime if not set)
+}
+
+// frame types, a frame is [type:1][len:4 big-endian][payload]
+const (
+	FrameType_Hello     = 'h' // host => client, helloData (first frame after attach)
+	FrameType_Data      = 'd' // both directions, raw pty output (host => client) or input (client => host)
+	FrameType_Exit      = 'x' // host => client, exitData (last frame)
+	FrameType_Resize    = 'r' // client => host, resizeData
+	FrameType_Kill      = 'k' // client => host, killData
+	FrameType_Signal    = 's' // client => host, signalData
+	FrameType_Detach    = 'D' // client => host, no payload
+	FrameType_DetachAck = 'A' // host => client, no payload (no more frames follow)
+)
+
+var ErrDetached = errors.New("ptyhost client detached")
+
+// sent to the helper on stdin when it is started
+type StartSpec struct {
+	Path string   `json:"path"`
+	Args []string `json:"args"` // includes argv[0]
+	Env  []string `json:"env"`
+	Dir  string   `json:"dir"`
+	Rows int      `json:"rows"`
+	Cols int      `json:"cols"`
+}
+
+// written by the helper to stdout once the socket is listening
+type startedData struct {
+	Pid   int    `json:"pid"`
+	Error string `json:"error,omitempty"`
+}
+
+type helloData struct {
+	Pid       int   `json:"pid"`
+	HostPid   int   `json:"hostpid"`
+	Truncated bool  `json:"truncated,omitempty"` // buffered output was dropped while detached
+	StartTs   int64 `json:"startts"`
+}
+
+type exitData struct {
+	ExitCode int `json:"exitcode"`
+}
+
+type resizeData struct {
+	Rows int `json:"rows"`
+	Cols int `json:"cols"`
+}
+
+type killData struct {
+	Force     bool  `json:"force,omitempty"`
+	TimeoutMs int64 `json:"timeoutms,omitempty"` // for graceful kills, force kill after this
+}
+
+type signalData struct {
+	Signal string `json:"signal"` // see sigutil.NormalizeSignalName
+}
+
+// a spec that runs cmd with the login shell of the user ($SHELL -c cmd) in the home directory, the way sshd runs the
+// command of a session.  env is added to the environment of this process.
+func MakeShellCmdSpec(cmd string, env []string, rows int, cols int) (StartSpec, error) {
+	shellPath := os.Getenv("SHELL")
+	if shellPath == "" {
+		shellPath = "/bin/sh"
+	}
+	shellPath, err := exec.LookPath(shellPath)
+	if err != nil {
+		return StartSpec{}, err
+	}
+	homeDir, _ := os.UserHomeDir()
+	return StartSpec{
+		Path: shellPath,
+		Args: []string{shellPath, "-c", cmd},
+		Env:  append(os.Environ(), env...),
+		Dir:  homeDir,
+		Rows: rows,
+		Cols: cols,
+	}, nil
+}
+
+func writeFrame(w io.Writer, frameType byte, payload []byte) error {
+	if len(payload) > MaxFrameSize {
+		return fmt.Errorf("ptyhost frame too large (%d bytes)", len(payload))
+	}
+	barr := make([]byte, 5+len(payload))
+	barr[0] = frameType
+	binary.BigEndian.PutUint32(barr[1:5], uint32(len(payload)))
+	copy(barr[5:], payload)
+	_, err := w.Write(barr)
+	return err
+}
+
+func writeJsonFrame(w io.Writer, frameType byte, data any) error {
+	barr, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return writeFrame(w, frameType, barr)
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var header [5]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[1:5])
+	if size > MaxFrameSize {
+		return 0, nil, fmt.Errorf("ptyhost frame too large (%d bytes)", size)
+	}
+	payload := make([]byte, size)
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
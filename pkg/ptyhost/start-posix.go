@@ -0,0 +1,83 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package ptyhost
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+)
+
+const StartTimeout = 5 * time.Second
+
+// starts a helper process (this executable with HostArg) running spec, returns the helper's pid.
+// the helper runs in its own session so it is not killed along with wavesrv.
+func StartHost(sockPath string, spec StartSpec) (int, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("error getting executable path: %w", err)
+	}
+	specBarr, err := json.Marshal(spec)
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling start spec: %w", err)
+	}
+	cmd := exec.Command(exePath, HostArg, sockPath)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	cmd.Env = spec.Env
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return 0, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return 0, fmt.Errorf("error starting ptyhost: %w", err)
+	}
+	// reap the helper if it exits while we are still running (only after stdout has been read, Wait closes it)
+	defer func() {
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("ptyhost:wait", recover())
+			}()
+			cmd.Wait()
+		}()
+	}()
+	stdin.Write(append(specBarr, '\n'))
+	stdin.Close()
+	startedCh := make(chan startedData, 1)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("ptyhost:read-started", recover())
+		}()
+		var data startedData
+		line, err := bufio.NewReader(stdout).ReadBytes('\n')
+		if err != nil {
+			data.Error = fmt.Sprintf("error reading from ptyhost: %v", err)
+		} else if err := json.Unmarshal(line, &data); err != nil {
+			data.Error = fmt.Sprintf("error parsing ptyhost output: %v", err)
+		}
+		startedCh <- data
+	}()
+	select {
+	case data := <-startedCh:
+		if data.Error != "" {
+			return 0, fmt.Errorf("ptyhost: %s", data.Error)
+		}
+		return cmd.Process.Pid, nil
+	case <-time.After(StartTimeout):
+		cmd.Process.Kill()
+		return 0, fmt.Errorf("timeout waiting for ptyhost to start")
+	}
+}
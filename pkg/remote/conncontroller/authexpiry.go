@@ -0,0 +1,105 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"log"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/wi18n"
+	"github.com/wavetermdev/waveterm/pkg/wnotify"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+)
+
+// the kerberos ticket (tgt) of a connection that authenticated with gssapi is checked every AuthExpiryCheckInterval
+// while it is connected.  the connection itself stays up when the ticket expires, but it can not reconnect (and
+// the shells on it lose their kerberos access), so when the ticket is about to expire (AuthExpiryWarning) or has
+// expired, a conn:authexpiry event is published and the user is asked to run kinit.  a ticket that is renewed
+// publishes "valid" again.
+
+const (
+	AuthExpiryCheckInterval = time.Minute
+	AuthExpiryWarning       = 15 * time.Minute
+)
+
+const (
+	AuthExpiry_Valid    = "valid"
+	AuthExpiry_Expiring = "expiring"
+	AuthExpiry_Expired  = "expired"
+)
+
+// the state of a ticket that ends at expiry (err is the error reading it)
+func authExpiryState(expiry time.Time, err error, now time.Time) string {
+	if err != nil || !now.Before(expiry) {
+		return AuthExpiry_Expired
+	}
+	if expiry.Sub(now) <= AuthExpiryWarning {
+		return AuthExpiry_Expiring
+	}
+	return AuthExpiry_Valid
+}
+
+// runs until doneCh is closed (the client disconnected), does nothing if the client did not authenticate with gssapi
+func (conn *SSHConn) watchAuthExpiry(client *ssh.Client, doneCh chan struct{}) {
+	ccPath, ok := remote.GetGSSAPICCache(client)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(AuthExpiryCheckInterval)
+	defer ticker.Stop()
+	state := AuthExpiry_Valid
+	for {
+		expiry, err := remote.GetKrb5TicketExpiry(ccPath)
+		newState := authExpiryState(expiry, err, time.Now())
+		if newState != state {
+			conn.fireAuthExpiryEvent(newState, state, expiry, err)
+			state = newState
+		}
+		select {
+		case <-doneCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (conn *SSHConn) fireAuthExpiryEvent(state string, prevState string, expiry time.Time, readErr error) {
+	connName := conn.GetName()
+	data := wps.ConnAuthExpiryEventData{
+		ConnName:  connName,
+		State:     state,
+		PrevState: prevState,
+		Ts:        time.Now().UnixMilli(),
+	}
+	if readErr != nil {
+		data.Error = readErr.Error()
+	} else {
+		data.ExpiresTs = expiry.UnixMilli()
+	}
+	log.Printf("[conn:%s] kerberos ticket %s (was %s)\n", connName, state, prevState)
+	eventbus.Publish(eventbus.ConnAuthExpiryEvent{Expiry: data})
+	notif := wshrpc.CommandNotificationSendData{
+		Source:  wnotify.Source_Controller,
+		Desktop: state != AuthExpiry_Valid,
+	}
+	switch state {
+	case AuthExpiry_Expiring:
+		notif.Type = wnotify.Type_Warning
+		notif.Title = wi18n.T("notify.kerberos.expiring.title")
+		notif.Message = wi18n.T("notify.kerberos.expiring.message", connName, expiry.Local().Format(time.Kitchen))
+	case AuthExpiry_Expired:
+		notif.Type = wnotify.Type_Error
+		notif.Title = wi18n.T("notify.kerberos.expired.title")
+		notif.Message = wi18n.T("notify.kerberos.expired.message", connName)
+	default:
+		notif.Type = wnotify.Type_Info
+		notif.Title = wi18n.T("notify.kerberos.renewed.title")
+		notif.Message = wi18n.T("notify.kerberos.renewed.message", connName)
+	}
+	wnotify.Notify(notif)
+}
@@ -0,0 +1,32 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAuthExpiryState(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		expiry time.Time
+		err    error
+		state  string
+	}{
+		{now.Add(8 * time.Hour), nil, AuthExpiry_Valid},
+		{now.Add(AuthExpiryWarning + time.Minute), nil, AuthExpiry_Valid},
+		{now.Add(AuthExpiryWarning), nil, AuthExpiry_Expiring},
+		{now.Add(time.Minute), nil, AuthExpiry_Expiring},
+		{now, nil, AuthExpiry_Expired},
+		{now.Add(-time.Hour), nil, AuthExpiry_Expired},
+		{time.Time{}, fmt.Errorf("no kerberos ticket"), AuthExpiry_Expired},
+	}
+	for _, test := range tests {
+		if got := authExpiryState(test.expiry, test.err, now); got != test.state {
+			t.Errorf("expiry in %v (err %v): expected %q, got %q", test.expiry.Sub(now), test.err, test.state, got)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/genconn"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/remote/sftpclient"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/telemetry/telemetrydata"
 	"github.com/wavetermdev/waveterm/pkg/userinput"
@@ -45,6 +46,8 @@ const (
 	Status_Connected    = "connected"
 	Status_Disconnected = "disconnected"
 	Status_Error        = "error"
+	Status_Degraded     = "degraded"     // connected, but the keepalive probes are failing (see keepalive.go)
+	Status_Reconnecting = "reconnecting" // the connection dropped, waiting for the next reconnect attempt
 )
 
 const DefaultConnectionTimeout = 60 * time.Second
@@ -69,6 +72,21 @@ type SSHConn struct {
 	HasWaiter          *atomic.Bool
 	LastConnectTime    int64
 	ActiveConnNum      int
+	ReconnectAttempt   int
+	NextRetryTs        int64
+	LatencyMs          int64 // the round trip of the last keepalive probe (0 if none got a reply yet)
+	reconnectCancelFn  context.CancelFunc
+	lastState          string // the last state sent in a conn:state event
+	lastAttempt        int
+	sftpLock           sync.Mutex
+	sftpClient         *sftpclient.Client
+	sftpSshClient      *ssh.Client // the ssh client the sftp client runs on
+	metrics            *connMetrics
+	sudoPassword       string // see sudo.go, never logged
+	sudoPasswordTs     time.Time
+	sudoSftpLock       sync.Mutex
+	sudoSftpClient     *sftpclient.Client // the sftp client run with sudo
+	sudoSftpSshClient  *ssh.Client
 }
 
 var ConnServerCmdTemplate = strings.TrimSpace(
@@ -106,7 +124,7 @@ func (conn *SSHConn) DeriveConnStatus() wshrpc.ConnStatus {
 	defer conn.Lock.Unlock()
 	return wshrpc.ConnStatus{
 		Status:        conn.Status,
-		Connected:     conn.Status == Status_Connected,
+		Connected:     conn.Status == Status_Connected || conn.Status == Status_Degraded,
 		Connection:    conn.Opts.String(),
 		HasConnected:  (conn.LastConnectTime > 0),
 		ActiveConnNum: conn.ActiveConnNum,
@@ -115,6 +133,9 @@ func (conn *SSHConn) DeriveConnStatus() wshrpc.ConnStatus {
 		WshError:      conn.WshError,
 		NoWshReason:   conn.NoWshReason,
 		WshVersion:    conn.WshVersion,
+		Attempt:       conn.ReconnectAttempt,
+		NextRetryTs:   conn.NextRetryTs,
+		LatencyMs:     conn.LatencyMs,
 	}
 }
 
@@ -138,15 +159,18 @@ func (conn *SSHConn) FireConnChangeEvent() {
 	}
 	log.Printf("sending event: %+#v", event)
 	wps.Broker.Publish(event)
+	conn.fireConnStateEvent(status)
 }
 
 func (conn *SSHConn) Close() error {
 	defer conn.FireConnChangeEvent()
 	conn.WithLock(func() {
-		if conn.Status == Status_Connected || conn.Status == Status_Connecting {
+		switch conn.Status {
+		case Status_Connected, Status_Connecting, Status_Degraded, Status_Reconnecting:
 			// if status is init, disconnected, or error don't change it
 			conn.Status = Status_Disconnected
 		}
+		conn.stopReconnect_nolock()
 		conn.close_nolock()
 	})
 	// we must wait for the waiter to complete
@@ -507,10 +531,10 @@ func (conn *SSHConn) GetClient() *ssh.Client {
 func (conn *SSHConn) WaitForConnect(ctx context.Context) error {
 	for {
 		status := conn.DeriveConnStatus()
-		if status.Status == Status_Connected {
+		if status.Status == Status_Connected || status.Status == Status_Degraded {
 			return nil
 		}
-		if status.Status == Status_Connecting {
+		if status.Status == Status_Connecting || status.Status == Status_Reconnecting {
 			select {
 			case <-ctx.Done():
 				return fmt.Errorf("context timeout")
@@ -567,6 +591,7 @@ func (conn *SSHConn) Connect(ctx context.Context, connFlags *wconfig.ConnKeyword
 			conn.Infof(ctx, "successfully connected (wsh:%v)\n\n", conn.WshEnabled.Load())
 			conn.Status = Status_Connected
 			conn.LastConnectTime = time.Now().UnixMilli()
+			conn.stopReconnect_nolock()
 			if conn.ActiveConnNum == 0 {
 				conn.ActiveConnNum = int(activeConnCounter.Add(1))
 			}
@@ -753,11 +778,30 @@ func (conn *SSHConn) connectInternal(ctx context.Context, connFlags *wconfig.Con
 	conn.WithLock(func() {
 		conn.Client = client
 	})
+	doneCh := make(chan struct{})
 	go func() {
 		defer func() {
 			panichandler.PanicHandler("conncontroller:waitForDisconnect", recover())
 		}()
-		conn.waitForDisconnect()
+		conn.waitForDisconnect(doneCh)
+	}()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("conncontroller:keepAlive", recover())
+		}()
+		conn.keepAlive(client, doneCh)
+	}()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("conncontroller:sampleMetrics", recover())
+		}()
+		conn.sampleMetrics(client, doneCh)
+	}()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("conncontroller:watchAuthExpiry", recover())
+		}()
+		conn.watchAuthExpiry(client, doneCh)
 	}()
 	fmtAddr := knownhosts.Normalize(fmt.Sprintf("%s@%s", client.User(), client.RemoteAddr().String()))
 	conn.Infof(ctx, "normalized knownhosts address: %s\n", fmtAddr)
@@ -775,14 +819,17 @@ func (conn *SSHConn) connectInternal(ctx context.Context, connFlags *wconfig.Con
 	return nil
 }
 
-func (conn *SSHConn) waitForDisconnect() {
+func (conn *SSHConn) waitForDisconnect(doneCh chan struct{}) {
 	defer conn.FireConnChangeEvent()
 	defer conn.HasWaiter.Store(false)
+	defer close(doneCh)
 	client := conn.GetClient()
 	if client == nil {
 		return
 	}
 	err := client.Wait()
+	autoReconnect := conn.shouldAutoReconnect()
+	var reconnectCtx context.Context
 	conn.WithLock(func() {
 		// disconnects happen for a variety of reasons (like network, etc. and are typically transient)
 		// so we just set the status to "disconnected" here (not error)
@@ -790,11 +837,24 @@ func (conn *SSHConn) waitForDisconnect() {
 		if err != nil && conn.Error == "" {
 			conn.Error = err.Error()
 		}
-		if conn.Status != Status_Error {
+		conn.sudoPassword = ""
+		// the connection dropped if it was not closed (Close sets the status to disconnected first)
+		dropped := conn.Client == client && (conn.Status == Status_Connected || conn.Status == Status_Degraded)
+		if dropped && autoReconnect {
+			reconnectCtx = conn.startReconnect_nolock()
+		} else if conn.Status != Status_Error {
 			conn.Status = Status_Disconnected
 		}
 		conn.close_nolock()
 	})
+	if reconnectCtx != nil {
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("conncontroller:reconnectLoop", recover())
+			}()
+			conn.reconnectLoop(reconnectCtx)
+		}()
+	}
 }
 
 func (conn *SSHConn) SetWshError(err error) {
@@ -818,7 +878,7 @@ func getConnInternal(opts *remote.SSHOpts) *SSHConn {
 	defer globalLock.Unlock()
 	rtn := clientControllerMap[*opts]
 	if rtn == nil {
-		rtn = &SSHConn{Lock: &sync.Mutex{}, Status: Status_Init, WshEnabled: &atomic.Bool{}, Opts: opts, HasWaiter: &atomic.Bool{}}
+		rtn = &SSHConn{Lock: &sync.Mutex{}, Status: Status_Init, WshEnabled: &atomic.Bool{}, Opts: opts, HasWaiter: &atomic.Bool{}, metrics: &connMetrics{}}
 		clientControllerMap[*opts] = rtn
 	}
 	return rtn
@@ -845,9 +905,9 @@ func EnsureConnection(ctx context.Context, connName string) error {
 	}
 	connStatus := conn.DeriveConnStatus()
 	switch connStatus.Status {
-	case Status_Connected:
+	case Status_Connected, Status_Degraded:
 		return nil
-	case Status_Connecting:
+	case Status_Connecting, Status_Reconnecting:
 		return conn.WaitForConnect(ctx)
 	case Status_Init, Status_Disconnected:
 		return conn.Connect(ctx, &wconfig.ConnKeywords{})
@@ -0,0 +1,159 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+)
+
+// the metrics of a connection: the round trips of the keepalive probes and the bytes read and written on its ssh
+// transport (see remote.TrafficCounter).  while it is connected a sample is taken every MetricsSampleInterval,
+// the last MetricsHistorySize samples are kept (over reconnects), so a sluggish terminal can be told apart from a
+// slow network.  the smoothed latency is also used by the roam transport for its first retransmit timeouts.
+
+const (
+	MetricsSampleInterval = 5 * time.Second
+	MetricsHistorySize    = 120 // 10 minutes
+)
+
+type connMetrics struct {
+	lock          sync.Mutex
+	bytesIn       int64 // the totals of the samples
+	bytesOut      int64
+	lastLatency   time.Duration
+	srtt          time.Duration
+	sampleLatency time.Duration // the last probe since the last sample
+	samples       []wshrpc.ConnMetricsSample
+}
+
+func (m *connMetrics) recordLatency(latency time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.lastLatency = latency
+	m.sampleLatency = latency
+	if m.srtt == 0 {
+		m.srtt = latency
+	} else {
+		m.srtt = (7*m.srtt + latency) / 8
+	}
+}
+
+// bytesIn and bytesOut are the traffic since the previous sample
+func (m *connMetrics) addSample(now time.Time, duration time.Duration, bytesIn int64, bytesOut int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.bytesIn += bytesIn
+	m.bytesOut += bytesOut
+	m.samples = append(m.samples, wshrpc.ConnMetricsSample{
+		Ts:         now.UnixMilli(),
+		DurationMs: duration.Milliseconds(),
+		BytesIn:    bytesIn,
+		BytesOut:   bytesOut,
+		LatencyMs:  m.sampleLatency.Milliseconds(),
+	})
+	m.sampleLatency = 0
+	if len(m.samples) > MetricsHistorySize {
+		m.samples = m.samples[len(m.samples)-MetricsHistorySize:]
+	}
+}
+
+func (m *connMetrics) getSmoothedLatency() time.Duration {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.srtt
+}
+
+func (m *connMetrics) snapshot(withHistory bool) wshrpc.ConnMetrics {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	rtn := wshrpc.ConnMetrics{
+		LatencyMs:         m.lastLatency.Milliseconds(),
+		SmoothedLatencyMs: m.srtt.Milliseconds(),
+		BytesIn:           m.bytesIn,
+		BytesOut:          m.bytesOut,
+	}
+	for _, sample := range m.samples {
+		if sample.LatencyMs == 0 {
+			continue
+		}
+		if rtn.MinLatencyMs == 0 || sample.LatencyMs < rtn.MinLatencyMs {
+			rtn.MinLatencyMs = sample.LatencyMs
+		}
+		rtn.MaxLatencyMs = max(rtn.MaxLatencyMs, sample.LatencyMs)
+	}
+	if len(m.samples) > 0 {
+		last := m.samples[len(m.samples)-1]
+		if last.DurationMs > 0 {
+			rtn.BytesInRate = last.BytesIn * 1000 / last.DurationMs
+			rtn.BytesOutRate = last.BytesOut * 1000 / last.DurationMs
+		}
+	}
+	if withHistory {
+		rtn.Samples = append([]wshrpc.ConnMetricsSample(nil), m.samples...)
+	}
+	return rtn
+}
+
+// runs until doneCh is closed (the client disconnected), the last sample is taken then
+func (conn *SSHConn) sampleMetrics(client *ssh.Client, doneCh chan struct{}) {
+	counter := remote.GetTrafficCounter(client)
+	if counter == nil {
+		return
+	}
+	ticker := time.NewTicker(MetricsSampleInterval)
+	defer ticker.Stop()
+	var lastIn, lastOut int64
+	lastTs := time.Now()
+	for {
+		var done bool
+		select {
+		case <-doneCh:
+			done = true
+		case <-ticker.C:
+		}
+		now := time.Now()
+		bytesIn, bytesOut := counter.BytesIn.Load(), counter.BytesOut.Load()
+		conn.metrics.addSample(now, now.Sub(lastTs), bytesIn-lastIn, bytesOut-lastOut)
+		lastIn, lastOut, lastTs = bytesIn, bytesOut, now
+		if done {
+			return
+		}
+	}
+}
+
+func (conn *SSHConn) GetMetrics(withHistory bool) wshrpc.ConnMetrics {
+	rtn := conn.metrics.snapshot(withHistory)
+	rtn.Connection = conn.GetName()
+	rtn.Status = conn.GetStatus()
+	return rtn
+}
+
+// the smoothed round trip of the keepalive probes, 0 if none got a reply yet
+func (conn *SSHConn) GetSmoothedLatency() time.Duration {
+	return conn.metrics.getSmoothedLatency()
+}
+
+// the metrics of every ssh connection used since wave started, by name
+func GetAllConnMetrics(withHistory bool) []wshrpc.ConnMetrics {
+	globalLock.Lock()
+	conns := make([]*SSHConn, 0, len(clientControllerMap))
+	for _, conn := range clientControllerMap {
+		conns = append(conns, conn)
+	}
+	globalLock.Unlock()
+	rtn := make([]wshrpc.ConnMetrics, 0, len(conns))
+	for _, conn := range conns {
+		rtn = append(rtn, conn.GetMetrics(withHistory))
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].Connection < rtn[j].Connection
+	})
+	return rtn
+}
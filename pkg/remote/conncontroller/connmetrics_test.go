@@ -0,0 +1,48 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnMetrics(t *testing.T) {
+	m := &connMetrics{}
+	start := time.UnixMilli(1_000_000)
+	m.recordLatency(40 * time.Millisecond)
+	m.addSample(start, 5*time.Second, 5000, 1000)
+	m.addSample(start.Add(5*time.Second), 5*time.Second, 0, 0)
+	m.recordLatency(120 * time.Millisecond)
+	m.addSample(start.Add(10*time.Second), 2*time.Second, 4000, 500)
+	rtn := m.snapshot(true)
+	if rtn.LatencyMs != 120 || rtn.SmoothedLatencyMs != 50 {
+		t.Errorf("expected a latency of 120ms (smoothed 50ms), got %dms (%dms)", rtn.LatencyMs, rtn.SmoothedLatencyMs)
+	}
+	if rtn.MinLatencyMs != 40 || rtn.MaxLatencyMs != 120 {
+		t.Errorf("expected min/max latencies of 40/120ms, got %d/%dms", rtn.MinLatencyMs, rtn.MaxLatencyMs)
+	}
+	if rtn.BytesIn != 9000 || rtn.BytesOut != 1500 {
+		t.Errorf("expected 9000/1500 bytes in/out, got %d/%d", rtn.BytesIn, rtn.BytesOut)
+	}
+	if rtn.BytesInRate != 2000 || rtn.BytesOutRate != 250 {
+		t.Errorf("expected rates of 2000/250 bytes/s (the last sample), got %d/%d", rtn.BytesInRate, rtn.BytesOutRate)
+	}
+	if len(rtn.Samples) != 3 || rtn.Samples[1].LatencyMs != 0 {
+		t.Errorf("expected 3 samples, the second without a latency, got %+v", rtn.Samples)
+	}
+	if m.snapshot(false).Samples != nil {
+		t.Errorf("expected no samples without the history")
+	}
+	for i := 0; i < MetricsHistorySize+10; i++ {
+		m.addSample(start.Add(time.Duration(i)*MetricsSampleInterval), MetricsSampleInterval, 1, 1)
+	}
+	rtn = m.snapshot(true)
+	if len(rtn.Samples) != MetricsHistorySize {
+		t.Errorf("expected the history to keep %d samples, got %d", MetricsHistorySize, len(rtn.Samples))
+	}
+	if rtn.MinLatencyMs != 0 || rtn.BytesIn != 9000+MetricsHistorySize+10 {
+		t.Errorf("expected the old samples to be dropped (but counted in the totals), got %+v", rtn)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+	"golang.org/x/crypto/ssh"
+)
+
+// a connected client is probed with a keepalive request every conn:keepaliveinterval seconds (0 turns the probes
+// off).  a probe that fails or gets no reply within the interval makes the connection "degraded", the next one that
+// gets a reply makes it "connected" again, and after conn:keepalivecountmax failed probes in a row the client is
+// closed.  a saved connection (or one with conn:autoreconnect set) that drops without being disconnected is
+// reconnected with a doubling delay (up to ReconnectMaxDelay), it is "reconnecting" between the attempts and is
+// left in "error" after ReconnectMaxAttempts.  the round trip of the last probe that got a reply is the latency of
+// the connection (in its status, and in its metrics, see connmetrics.go).  every change of state is published as a conn:state event scoped to
+// the blocks that use the connection, so they can freeze while it is down and resume when it is back.
+
+const (
+	DefaultKeepAliveInterval = 15 * time.Second
+	DefaultKeepAliveCountMax = 3
+	ReconnectMinDelay        = time.Second
+	ReconnectMaxDelay        = 60 * time.Second
+	ReconnectMaxAttempts     = 8
+)
+
+const keepAliveRequestType = "keepalive@openssh.com"
+const connStateLookupTimeout = 2 * time.Second
+
+// the delay before the reconnect attempt (starting at 1)
+func reconnectDelay(attempt int) time.Duration {
+	delay := ReconnectMinDelay
+	for i := 1; i < attempt && delay < ReconnectMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, ReconnectMaxDelay)
+}
+
+// returns (interval, count-max), the interval is 0 if the probes are off
+func (conn *SSHConn) getKeepAliveSettings() (time.Duration, int) {
+	interval := DefaultKeepAliveInterval
+	countMax := DefaultKeepAliveCountMax
+	connSettings, ok := conn.getConnectionConfig()
+	if !ok {
+		return interval, countMax
+	}
+	if connSettings.ConnKeepAliveInterval != nil {
+		interval = time.Duration(max(0, *connSettings.ConnKeepAliveInterval) * float64(time.Second))
+	}
+	if connSettings.ConnKeepAliveCountMax != nil && *connSettings.ConnKeepAliveCountMax > 0 {
+		countMax = *connSettings.ConnKeepAliveCountMax
+	}
+	return interval, countMax
+}
+
+// saved connections are reconnected unless conn:autoreconnect is false, other connections only when it is true
+func (conn *SSHConn) shouldAutoReconnect() bool {
+	connSettings, ok := conn.getConnectionConfig()
+	if ok && connSettings.ConnAutoReconnect != nil {
+		return *connSettings.ConnAutoReconnect
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), connStateLookupTimeout)
+	defer cancelFn()
+	savedConns, err := wstore.DBGetAllObjsByType[*waveobj.Connection](ctx, waveobj.OType_Connection)
+	if err != nil {
+		log.Printf("error getting saved connections: %v\n", err)
+		return false
+	}
+	for _, saved := range savedConns {
+		if (remote.SSHOpts{SSHHost: saved.Host, SSHUser: saved.User, SSHPort: saved.Port}).String() == conn.GetName() {
+			return true
+		}
+	}
+	return false
+}
+
+func sendKeepAlive(client *ssh.Client, timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("conncontroller:sendKeepAlive", recover())
+		}()
+		// servers that don't know the request reply with a failure, any reply means the connection is alive
+		_, _, err := client.SendRequest(keepAliveRequestType, true, nil)
+		errCh <- err
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("no reply in %v", timeout)
+	}
+}
+
+// runs until doneCh is closed (the client disconnected)
+func (conn *SSHConn) keepAlive(client *ssh.Client, doneCh chan struct{}) {
+	conn.setLatency(0)
+	interval, countMax := conn.getKeepAliveSettings()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var numFailed int
+	for {
+		select {
+		case <-doneCh:
+			return
+		case <-ticker.C:
+		}
+		probeStart := time.Now()
+		err := sendKeepAlive(client, interval)
+		if err == nil {
+			conn.setLatency(time.Since(probeStart))
+			if numFailed > 0 {
+				log.Printf("[conn:%s] keepalive ok after %d failed probe(s)\n", conn.GetName(), numFailed)
+				conn.setHealth(Status_Degraded, Status_Connected)
+			}
+			numFailed = 0
+			continue
+		}
+		numFailed++
+		log.Printf("[conn:%s] keepalive failed (%d/%d): %v\n", conn.GetName(), numFailed, countMax, err)
+		if numFailed == 1 {
+			conn.setHealth(Status_Connected, Status_Degraded)
+		}
+		if numFailed >= countMax {
+			conn.WithLock(func() {
+				if conn.Error == "" {
+					conn.Error = fmt.Sprintf("no reply to %d keepalive probes", numFailed)
+				}
+			})
+			// waitForDisconnect sets the new status (and reconnects)
+			client.Close()
+			return
+		}
+	}
+}
+
+func (conn *SSHConn) setLatency(latency time.Duration) {
+	conn.WithLock(func() {
+		conn.LatencyMs = latency.Milliseconds()
+	})
+	if latency > 0 {
+		conn.metrics.recordLatency(latency)
+	}
+}
+
+// moves the status from fromStatus to toStatus (and does nothing if the status is not fromStatus)
+func (conn *SSHConn) setHealth(fromStatus string, toStatus string) {
+	changed := WithLockRtn(conn, func() bool {
+		if conn.Status != fromStatus {
+			return false
+		}
+		conn.Status = toStatus
+		return true
+	})
+	if changed {
+		conn.FireConnChangeEvent()
+	}
+}
+
+// called after the connection dropped, the status is "reconnecting" until the first attempt is made.  returns the
+// context of the reconnect loop (canceled when the connection is closed, or connected again).
+func (conn *SSHConn) startReconnect_nolock() context.Context {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	conn.stopReconnect_nolock()
+	conn.reconnectCancelFn = cancelFn
+	conn.setReconnectAttempt_nolock(1)
+	return ctx
+}
+
+func (conn *SSHConn) setReconnectAttempt_nolock(attempt int) {
+	conn.Status = Status_Reconnecting
+	conn.ReconnectAttempt = attempt
+	conn.NextRetryTs = time.Now().Add(reconnectDelay(attempt)).UnixMilli()
+}
+
+// cancels the reconnect attempts (when the connection is closed, or connected again)
+func (conn *SSHConn) stopReconnect_nolock() {
+	if conn.reconnectCancelFn != nil {
+		conn.reconnectCancelFn()
+		conn.reconnectCancelFn = nil
+	}
+	conn.ReconnectAttempt = 0
+	conn.NextRetryTs = 0
+}
+
+func (conn *SSHConn) reconnectLoop(ctx context.Context) {
+	for attempt := 1; attempt <= ReconnectMaxAttempts; attempt++ {
+		if attempt > 1 {
+			stillReconnecting := WithLockRtn(conn, func() bool {
+				// anything but a failed attempt means the connection was connected or closed in the meantime
+				if conn.Status != Status_Error || ctx.Err() != nil {
+					return false
+				}
+				conn.setReconnectAttempt_nolock(attempt)
+				return true
+			})
+			if !stillReconnecting {
+				return
+			}
+			conn.FireConnChangeEvent()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay(attempt)):
+		}
+		if conn.GetStatus() != Status_Reconnecting {
+			return
+		}
+		log.Printf("[conn:%s] reconnect attempt %d/%d\n", conn.GetName(), attempt, ReconnectMaxAttempts)
+		connectCtx, cancelFn := context.WithTimeout(ctx, DefaultConnectionTimeout)
+		err := conn.Connect(connectCtx, &wconfig.ConnKeywords{})
+		cancelFn()
+		if err == nil {
+			return
+		}
+	}
+	gaveUp := WithLockRtn(conn, func() bool {
+		if conn.Status != Status_Error || ctx.Err() != nil {
+			return false
+		}
+		conn.Error = fmt.Sprintf("could not reconnect after %d attempts: %s", ReconnectMaxAttempts, conn.Error)
+		conn.stopReconnect_nolock()
+		return true
+	})
+	if gaveUp {
+		conn.FireConnChangeEvent()
+	}
+}
+
+func (conn *SSHConn) findBlockIds() []string {
+	ctx, cancelFn := context.WithTimeout(context.Background(), connStateLookupTimeout)
+	defer cancelFn()
+	blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+	if err != nil {
+		log.Printf("error getting blocks for conn:state event: %v\n", err)
+		return nil
+	}
+	var rtn []string
+	for _, block := range blocks {
+		if block.Meta.GetString(waveobj.MetaKey_Connection, "") == conn.GetName() {
+			rtn = append(rtn, block.OID)
+		}
+	}
+	return rtn
+}
+
+// publishes a conn:state event if the state (or the reconnect attempt) changed since the last one
+func (conn *SSHConn) fireConnStateEvent(status wshrpc.ConnStatus) {
+	var prevState string
+	changed := WithLockRtn(conn, func() bool {
+		if conn.lastState == status.Status && conn.lastAttempt == status.Attempt {
+			return false
+		}
+		prevState = conn.lastState
+		conn.lastState = status.Status
+		conn.lastAttempt = status.Attempt
+		return true
+	})
+	if !changed {
+		return
+	}
+	eventbus.Publish(eventbus.ConnStateEvent{
+		BlockIds: conn.findBlockIds(),
+		State: wps.ConnStateEventData{
+			ConnName:    status.Connection,
+			State:       status.Status,
+			PrevState:   prevState,
+			Error:       status.Error,
+			Attempt:     status.Attempt,
+			NextRetryTs: status.NextRetryTs,
+			Ts:          time.Now().UnixMilli(),
+		},
+	})
+}
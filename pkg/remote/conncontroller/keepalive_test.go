@@ -0,0 +1,21 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, ReconnectMaxDelay, ReconnectMaxDelay}
+	for idx, delay := range expected {
+		if got := reconnectDelay(idx + 1); got != delay {
+			t.Errorf("attempt %d: expected a delay of %v, got %v", idx+1, delay, got)
+		}
+	}
+	if got := reconnectDelay(100); got != ReconnectMaxDelay {
+		t.Errorf("the delay should be capped at %v, got %v", ReconnectMaxDelay, got)
+	}
+}
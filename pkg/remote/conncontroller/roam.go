@@ -0,0 +1,107 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/roam"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// with conn:transport set to "roam" the shells of a connection run in a roam server (wsh roamhost, see pkg/roam)
+// that is started over ssh and then reached over udp, so they survive network changes and suspends that drop the
+// ssh connection.  a connection that can't use it (an old wsh, a jump host, or udp blocked by a firewall) falls
+// back to ssh, and is not tried again for RoamRetryInterval.
+
+const (
+	ConnTransport_Ssh  = "ssh"
+	ConnTransport_Roam = "roam"
+)
+
+const RoamRetryInterval = 10 * time.Minute
+
+var roamFailuresLock = &sync.Mutex{}
+var roamFailures = make(map[string]time.Time) // conn name => the last failure
+
+func (conn *SSHConn) UseRoamTransport() bool {
+	config, ok := conn.getConnectionConfig()
+	if !ok || config.ConnTransport != ConnTransport_Roam {
+		return false
+	}
+	roamFailuresLock.Lock()
+	defer roamFailuresLock.Unlock()
+	return time.Since(roamFailures[conn.GetName()]) > RoamRetryInterval
+}
+
+// starts cmdStr (a shell command line) in a roam server and attaches to it.  the command only runs once the udp
+// handshake worked, a caller that gets an error can run it over ssh instead.
+func (conn *SSHConn) StartRoamShell(ctx context.Context, cmdStr string, termSize waveobj.TermSize) (*roam.Shell, error) {
+	shell, err := conn.startRoamShell(ctx, cmdStr, termSize)
+	roamFailuresLock.Lock()
+	defer roamFailuresLock.Unlock()
+	if err != nil {
+		roamFailures[conn.GetName()] = time.Now()
+		return nil, err
+	}
+	delete(roamFailures, conn.GetName())
+	return shell, nil
+}
+
+func (conn *SSHConn) startRoamShell(ctx context.Context, cmdStr string, termSize waveobj.TermSize) (*roam.Shell, error) {
+	client := conn.GetClient()
+	if client == nil {
+		return nil, fmt.Errorf("%s is not connected", conn.GetName())
+	}
+	// the client of a connection through a jump host has no local port (and its remote address is not reachable)
+	localAddr, localOk := client.LocalAddr().(*net.TCPAddr)
+	remoteAddr, remoteOk := client.RemoteAddr().(*net.TCPAddr)
+	if !localOk || !remoteOk || localAddr.Port == 0 || remoteAddr.IP == nil {
+		return nil, fmt.Errorf("the connection does not go directly to the host")
+	}
+	spec := roam.ServerSpec{Cmd: cmdStr, Env: []string{"TERM=xterm-256color"}, Rows: termSize.Rows, Cols: termSize.Cols}
+	if config, ok := conn.getConnectionConfig(); ok {
+		spec.PortRange = config.ConnRoamPorts
+	}
+	specBarr, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	session.Stdin = bytes.NewReader(append(specBarr, '\n'))
+	output, err := session.Output(fmt.Sprintf("%s roamhost", conn.getWshPath()))
+	if err != nil {
+		return nil, fmt.Errorf("error starting the roam server: %w", err)
+	}
+	var info roam.ServerInfo
+	if err := json.Unmarshal(bytes.TrimSpace(output), &info); err != nil {
+		return nil, fmt.Errorf("error parsing the roam server output: %w", err)
+	}
+	if info.Error != "" {
+		return nil, fmt.Errorf("roam server: %s", info.Error)
+	}
+	roamSession, err := roam.Dial(ctx, remoteAddr.IP.String(), info.Port, info.Key)
+	if err != nil {
+		return nil, err
+	}
+	// the ssh connection goes to the same host, its latency is a better first guess than none
+	roamSession.SetRttEstimate(conn.GetSmoothedLatency())
+	shell, err := roam.AttachShell(roamSession)
+	if err != nil {
+		roamSession.Close()
+		return nil, err
+	}
+	conn.Infof(ctx, "roaming shell started on udp port %d (server pid %d)\n", info.Port, info.Pid)
+	return shell, nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"golang.org/x/crypto/ssh"
+)
+
+// non-interactive commands on a connection (like "ssh host cmd"), for automations and health checks.  the command
+// runs in its own ssh session (no pty), through the login shell of the user.
+
+const DefaultRunMaxOutput = 1024 * 1024
+
+type RunOpts struct {
+	Cwd       string // "~" and "~/..." are relative to the home directory
+	Env       map[string]string
+	Stdin     string
+	MaxOutput int       // the bytes kept of stdout and of stderr, DefaultRunMaxOutput if not set
+	Sudo      *SudoOpts // run with sudo (see sudo.go)
+}
+
+type RunResult struct {
+	ExitCode        int // -1 if the command was killed by a signal, or did not report its status
+	Signal          string
+	Stdout          []byte
+	Stderr          []byte
+	StdoutTruncated bool
+	StderrTruncated bool
+	TimedOut        bool
+}
+
+// keeps the first max bytes of a stream, and passes all of it to outputFn
+type runOutput struct {
+	lock      *sync.Mutex // shared by stdout and stderr, so outputFn is not called concurrently
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+	isStderr  bool
+	outputFn  func(isStderr bool, data []byte)
+	prompts   *sudoPromptFilter // set on the stderr of a command run with sudo
+}
+
+func (o *runOutput) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.prompts != nil {
+		o.write_nolock(o.prompts.filter(p))
+	} else {
+		o.write_nolock(p)
+	}
+	return len(p), nil
+}
+
+// writes what the prompt filter held back (at the end of the command)
+func (o *runOutput) flush() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.prompts == nil {
+		return
+	}
+	o.write_nolock(o.prompts.flush())
+}
+
+func (o *runOutput) write_nolock(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	if room := o.max - o.buf.Len(); room < len(p) {
+		o.buf.Write(p[:max(room, 0)])
+		o.truncated = true
+	} else {
+		o.buf.Write(p)
+	}
+	if o.outputFn != nil {
+		o.outputFn(o.isStderr, p)
+	}
+}
+
+func quoteCwd(cwd string) string {
+	if cwd == "~" {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(cwd, "~/"); ok {
+		return "~/" + shellutil.HardQuote(rest)
+	}
+	return shellutil.HardQuote(cwd)
+}
+
+// the command line sent to the shell, with the cd and the exports first
+func buildRunCommand(cmdStr string, opts RunOpts) (string, error) {
+	var parts []string
+	if opts.Cwd != "" {
+		parts = append(parts, "cd "+quoteCwd(opts.Cwd))
+	}
+	envNames := make([]string, 0, len(opts.Env))
+	for name := range opts.Env {
+		if !shellutil.IsValidEnvVarName(name) {
+			return "", fmt.Errorf("invalid environment variable name %q", name)
+		}
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		parts = append(parts, fmt.Sprintf("export %s=%s", name, shellutil.HardQuote(opts.Env[name])))
+	}
+	parts = append(parts, cmdStr)
+	return strings.Join(parts, " && "), nil
+}
+
+// runs the command on the connection (which must be connected), with sudo if opts.Sudo is set.  a command that exits with a non-zero status is not
+// an error, neither is reaching the deadline of the context (the command is killed and the result has TimedOut set).
+// outputFn (if set) gets the output as it comes.
+func (conn *SSHConn) RunCommand(ctx context.Context, cmdStr string, opts RunOpts, outputFn func(isStderr bool, data []byte)) (*RunResult, error) {
+	fullCmd, err := buildRunCommand(cmdStr, opts)
+	if err != nil {
+		return nil, err
+	}
+	client := conn.GetClient()
+	status := conn.GetStatus()
+	if client == nil || (status != Status_Connected && status != Status_Degraded) {
+		return nil, fmt.Errorf("%s is not connected (%s)", conn.GetName(), status)
+	}
+	stdin := []byte(opts.Stdin)
+	var prompts *sudoPromptFilter
+	if opts.Sudo != nil {
+		sudo, err := conn.prepareSudo(ctx, client, *opts.Sudo)
+		if err != nil {
+			return nil, err
+		}
+		fullCmd, err = sudo.wrapCommand(fullCmd)
+		if err != nil {
+			return nil, err
+		}
+		stdin = append(sudo.stdinPrefix, stdin...)
+		prompts = &sudoPromptFilter{}
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("error starting a session on %s: %w", conn.GetName(), err)
+	}
+	defer session.Close()
+	maxOutput := opts.MaxOutput
+	if maxOutput <= 0 {
+		maxOutput = DefaultRunMaxOutput
+	}
+	outputLock := &sync.Mutex{}
+	stdout := &runOutput{lock: outputLock, max: maxOutput, outputFn: outputFn}
+	stderr := &runOutput{lock: outputLock, max: maxOutput, isStderr: true, outputFn: outputFn, prompts: prompts}
+	session.Stdout = stdout
+	session.Stderr = stderr
+	session.Stdin = bytes.NewReader(stdin)
+	if err := session.Start(fullCmd); err != nil {
+		return nil, fmt.Errorf("error running the command on %s: %w", conn.GetName(), err)
+	}
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- session.Wait()
+	}()
+	rtn := &RunResult{}
+	var waitErr error
+	select {
+	case waitErr = <-waitCh:
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ctx.Err()
+		}
+		rtn.TimedOut = true
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		waitErr = <-waitCh
+	}
+	var exitErr *ssh.ExitError
+	var missingErr *ssh.ExitMissingError
+	switch {
+	case waitErr == nil:
+		rtn.ExitCode = 0
+	case errors.As(waitErr, &exitErr):
+		rtn.ExitCode = exitErr.ExitStatus()
+		if exitErr.Signal() != "" {
+			rtn.ExitCode = -1
+			rtn.Signal = exitErr.Signal()
+		}
+	case errors.As(waitErr, &missingErr) || rtn.TimedOut:
+		rtn.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("error running the command on %s: %w", conn.GetName(), waitErr)
+	}
+	stderr.flush()
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	rtn.Stdout = stdout.buf.Bytes()
+	rtn.Stderr = stderr.buf.Bytes()
+	rtn.StdoutTruncated = stdout.truncated
+	rtn.StderrTruncated = stderr.truncated
+	return rtn, nil
+}
+
+// connects if needed
+func RunCommand(ctx context.Context, connName string, cmdStr string, opts RunOpts, outputFn func(isStderr bool, data []byte)) (*RunResult, error) {
+	if err := EnsureConnection(ctx, connName); err != nil {
+		return nil, err
+	}
+	connOpts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return nil, err
+	}
+	return GetConn(connOpts).RunCommand(ctx, cmdStr, opts, outputFn)
+}
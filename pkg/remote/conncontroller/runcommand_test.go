@@ -0,0 +1,40 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBuildRunCommand(t *testing.T) {
+	opts := RunOpts{Cwd: "~/my project", Env: map[string]string{"B": "two words", "A": "1"}}
+	cmd, err := buildRunCommand("make test", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `cd ~/"my project" && export A=1 && export B="two words" && make test`
+	if cmd != expected {
+		t.Errorf("expected %q, got %q", expected, cmd)
+	}
+	if cmd, _ := buildRunCommand("uptime", RunOpts{}); cmd != "uptime" {
+		t.Errorf("expected the command alone, got %q", cmd)
+	}
+	if _, err := buildRunCommand("true", RunOpts{Env: map[string]string{"A;rm": "x"}}); err == nil {
+		t.Errorf("expected an error for an invalid variable name")
+	}
+}
+
+func TestRunOutput(t *testing.T) {
+	var streamed []byte
+	out := &runOutput{lock: &sync.Mutex{}, max: 5, outputFn: func(isStderr bool, data []byte) { streamed = append(streamed, data...) }}
+	out.Write([]byte("abc"))
+	out.Write([]byte("defg"))
+	if out.buf.String() != "abcde" || !out.truncated {
+		t.Errorf("expected the first 5 bytes and truncated, got %q (%v)", out.buf.String(), out.truncated)
+	}
+	if string(streamed) != "abcdefg" {
+		t.Errorf("expected all of the output to be streamed, got %q", streamed)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/remote/sftpclient"
+	"golang.org/x/crypto/ssh"
+)
+
+// the sftp client of a connection is started on first use and shared by the file operations and transfers.  it runs
+// on the ssh client of the connection, a reconnect (a new ssh client) starts a new one.
+func (conn *SSHConn) GetSftpClient() (*sftpclient.Client, error) {
+	conn.sftpLock.Lock()
+	defer conn.sftpLock.Unlock()
+	var client *ssh.Client
+	var status string
+	conn.WithLock(func() {
+		client = conn.Client
+		status = conn.Status
+	})
+	if client == nil || (status != Status_Connected && status != Status_Degraded) {
+		return nil, fmt.Errorf("%s is not connected (%s)", conn.GetName(), status)
+	}
+	if conn.sftpClient != nil && conn.sftpSshClient == client && !conn.sftpClient.IsClosed() {
+		return conn.sftpClient, nil
+	}
+	if conn.sftpClient != nil {
+		conn.sftpClient.Close()
+		conn.sftpClient = nil
+	}
+	sftpClient, err := sftpclient.NewClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("starting sftp on %s: %w", conn.GetName(), err)
+	}
+	conn.sftpClient = sftpClient
+	conn.sftpSshClient = client
+	return sftpClient, nil
+}
+
+// the paths of sftp-server on the common systems (debian, red hat, arch, macos), it is not a subsystem with sudo
+const sudoSftpServerCmd = `for p in /usr/lib/openssh/sftp-server /usr/libexec/openssh/sftp-server /usr/lib/ssh/sftp-server /usr/libexec/sftp-server /usr/lib/sftp-server; do if [ -x "$p" ]; then exec "$p"; fi; done; echo "sftp-server not found" >&2; exit 127`
+
+// like GetSftpClient, with the sftp server run as root with sudo (see sudo.go).  it is started on first use (which
+// can ask for the sudo password) and shared like the other one.
+func (conn *SSHConn) GetSudoSftpClient(ctx context.Context) (*sftpclient.Client, error) {
+	// not sftpLock, the other client is not held up by the password prompt
+	conn.sudoSftpLock.Lock()
+	defer conn.sudoSftpLock.Unlock()
+	var client *ssh.Client
+	var status string
+	conn.WithLock(func() {
+		client = conn.Client
+		status = conn.Status
+	})
+	if client == nil || (status != Status_Connected && status != Status_Degraded) {
+		return nil, fmt.Errorf("%s is not connected (%s)", conn.GetName(), status)
+	}
+	if conn.sudoSftpClient != nil && conn.sudoSftpSshClient == client && !conn.sudoSftpClient.IsClosed() {
+		return conn.sudoSftpClient, nil
+	}
+	if conn.sudoSftpClient != nil {
+		conn.sudoSftpClient.Close()
+		conn.sudoSftpClient = nil
+	}
+	sudo, err := conn.prepareSudo(ctx, client, SudoOpts{})
+	if err != nil {
+		return nil, err
+	}
+	cmdStr, err := sudo.wrapCommand(sudoSftpServerCmd)
+	if err != nil {
+		return nil, err
+	}
+	sftpClient, err := sftpclient.NewClientWithCommand(client, cmdStr, sudo.stdinPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("starting sftp with sudo on %s: %s", conn.GetName(), stripSudoPrompts(err.Error()))
+	}
+	conn.sudoSftpClient = sftpClient
+	conn.sudoSftpSshClient = client
+	return sftpClient, nil
+}
+
+// connects if needed
+func GetSftpClient(ctx context.Context, connName string) (*sftpclient.Client, error) {
+	if err := EnsureConnection(ctx, connName); err != nil {
+		return nil, err
+	}
+	opts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return nil, err
+	}
+	return GetConn(opts).GetSftpClient()
+}
+
+// connects if needed
+func GetSudoSftpClient(ctx context.Context, connName string) (*sftpclient.Client, error) {
+	if err := EnsureConnection(ctx, connName); err != nil {
+		return nil, err
+	}
+	opts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return nil, err
+	}
+	return GetConn(opts).GetSudoSftpClient(ctx)
+}
+
+// a connection that is up without wsh (disabled, or it could not be installed), its files are reached over sftp
+func UseSftp(connName string) bool {
+	opts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return false
+	}
+	globalLock.Lock()
+	conn := clientControllerMap[*opts]
+	globalLock.Unlock()
+	if conn == nil {
+		return false
+	}
+	status := conn.GetStatus()
+	return (status == Status_Connected || status == Status_Degraded) && !conn.WshEnabled.Load()
+}
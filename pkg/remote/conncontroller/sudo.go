@@ -0,0 +1,224 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/secretstore"
+	"github.com/wavetermdev/waveterm/pkg/userinput"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"golang.org/x/crypto/ssh"
+)
+
+// the commands and file operations run with sudo.  sudo is first run with -n, so a host with passwordless sudo is
+// never asked for a password.  otherwise the password is the secret in conn:sudosecret, or is asked for with a
+// password prompt (not when the caller can't wait for one, e.g. a cluster exec), and it is checked (with sudo -k,
+// so a cached sudo timestamp is not taken for a correct password) before the real command is run.  sudo prompts
+// with SudoPromptMarker on stderr, which is how a prompt is told apart from the output (and removed from it).  the
+// password is only written to the stdin of sudo (-S), it is never logged or put in an error, and it is kept in
+// memory for SudoPasswordCacheTime (until the connection is closed).
+
+const (
+	SudoPromptMarker      = "[wave:sudo-password]"
+	SudoMaxAttempts       = 3
+	SudoPasswordCacheTime = 5 * time.Minute
+	sudoPromptTimeout     = 60 * time.Second
+)
+
+var ErrSudoPasswordRequired = errors.New("sudo requires a password (set conn:sudosecret, or run it interactively)")
+
+type SudoOpts struct {
+	User     string // the user to run as (root if not set)
+	NoPrompt bool   // fail instead of asking for the password
+}
+
+// how a command is run with sudo: the command line prefix, and the stdin it needs first (the password)
+type sudoInfo struct {
+	prefix      string
+	stdinPrefix []byte
+}
+
+func sudoArgs(opts SudoOpts, withPassword bool) string {
+	var args []string
+	if withPassword {
+		args = append(args, "sudo", "-k", "-S", "-p", shellutil.HardQuote(SudoPromptMarker))
+	} else {
+		args = append(args, "sudo", "-n")
+	}
+	if opts.User != "" {
+		args = append(args, "-u", shellutil.HardQuote(opts.User))
+	}
+	return strings.Join(args, " ") + " --"
+}
+
+// the command line that runs cmdStr (a command line for sh) with sudo
+func (si *sudoInfo) wrapCommand(cmdStr string) (string, error) {
+	quoted := shellutil.HardQuote(cmdStr)
+	if quoted == "" {
+		return "", fmt.Errorf("the command is too long to run with sudo")
+	}
+	return si.prefix + " sh -c " + quoted, nil
+}
+
+// runs a short command in its own session, returns its exit code (-1 if it did not report one) and its stderr
+func runSudoCheck(ctx context.Context, client *ssh.Client, cmdStr string, stdin []byte) (int, string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return 0, "", err
+	}
+	defer session.Close()
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+	session.Stdin = bytes.NewReader(stdin)
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- session.Run(cmdStr)
+	}()
+	var waitErr error
+	select {
+	case waitErr = <-waitCh:
+	case <-ctx.Done():
+		session.Close()
+		return 0, "", ctx.Err()
+	}
+	var exitErr *ssh.ExitError
+	switch {
+	case waitErr == nil:
+		return 0, stderr.String(), nil
+	case errors.As(waitErr, &exitErr):
+		return exitErr.ExitStatus(), stderr.String(), nil
+	default:
+		var missingErr *ssh.ExitMissingError
+		if errors.As(waitErr, &missingErr) {
+			return -1, stderr.String(), nil
+		}
+		return 0, "", waitErr
+	}
+}
+
+// the stderr of sudo without its prompts
+func stripSudoPrompts(stderr string) string {
+	return strings.TrimSpace(strings.ReplaceAll(stderr, SudoPromptMarker, ""))
+}
+
+func (conn *SSHConn) getCachedSudoPassword() string {
+	return WithLockRtn(conn, func() string {
+		if conn.sudoPassword == "" || time.Since(conn.sudoPasswordTs) > SudoPasswordCacheTime {
+			conn.sudoPassword = ""
+			return ""
+		}
+		return conn.sudoPassword
+	})
+}
+
+func (conn *SSHConn) setCachedSudoPassword(password string) {
+	conn.WithLock(func() {
+		conn.sudoPassword = password
+		conn.sudoPasswordTs = time.Now()
+	})
+}
+
+// the password to try: the cached one or the secret first, then the user is asked
+func (conn *SSHConn) getSudoPassword(ctx context.Context, attempt int, opts SudoOpts) (string, error) {
+	if attempt == 1 {
+		if password := conn.getCachedSudoPassword(); password != "" {
+			return password, nil
+		}
+		config, _ := conn.getConnectionConfig()
+		if config.ConnSudoSecret != "" {
+			password, err := secretstore.Resolve(conn.GetName(), config.ConnSudoSecret)
+			if err == nil {
+				return password, nil
+			}
+			log.Printf("[conn:%s] sudo secret %q: %v\n", conn.GetName(), config.ConnSudoSecret, err)
+		}
+	}
+	if opts.NoPrompt {
+		return "", ErrSudoPasswordRequired
+	}
+	queryText := fmt.Sprintf("sudo on %s requires the password of the user.\n\nPassword:", conn.GetName())
+	if attempt > 1 {
+		queryText = fmt.Sprintf("The sudo password was not correct, try again (attempt %d of %d).\n\n%s", attempt, SudoMaxAttempts, queryText)
+	}
+	promptCtx, cancelFn := context.WithTimeout(ctx, sudoPromptTimeout)
+	defer cancelFn()
+	response, err := userinput.GetUserInput(promptCtx, &userinput.UserInputRequest{
+		ResponseType: "text",
+		QueryText:    queryText,
+		Title:        "Sudo Password",
+	})
+	if err != nil {
+		return "", fmt.Errorf("no sudo password: %w", err)
+	}
+	return response.Text, nil
+}
+
+// finds out how to run commands with sudo on the client (asking for the password if needed)
+func (conn *SSHConn) prepareSudo(ctx context.Context, client *ssh.Client, opts SudoOpts) (*sudoInfo, error) {
+	exitCode, stderr, err := runSudoCheck(ctx, client, sudoArgs(opts, false)+" true", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error running sudo on %s: %w", conn.GetName(), err)
+	}
+	if exitCode == 0 {
+		return &sudoInfo{prefix: sudoArgs(opts, false)}, nil
+	}
+	if !strings.Contains(stderr, "password is required") {
+		return nil, fmt.Errorf("sudo on %s: %s", conn.GetName(), stripSudoPrompts(stderr))
+	}
+	withPassword := sudoArgs(opts, true)
+	for attempt := 1; attempt <= SudoMaxAttempts; attempt++ {
+		password, err := conn.getSudoPassword(ctx, attempt, opts)
+		if err != nil {
+			return nil, err
+		}
+		stdinPrefix := []byte(password + "\n")
+		exitCode, stderr, err = runSudoCheck(ctx, client, withPassword+" true", stdinPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("error running sudo on %s: %w", conn.GetName(), err)
+		}
+		if exitCode == 0 {
+			conn.setCachedSudoPassword(password)
+			return &sudoInfo{prefix: withPassword, stdinPrefix: stdinPrefix}, nil
+		}
+		// sudo prompts again after a wrong password (and then fails, stdin has no more lines)
+		if strings.Count(stderr, SudoPromptMarker) < 2 {
+			return nil, fmt.Errorf("sudo on %s: %s", conn.GetName(), stripSudoPrompts(stderr))
+		}
+		conn.setCachedSudoPassword("")
+		log.Printf("[conn:%s] sudo password rejected (attempt %d/%d)\n", conn.GetName(), attempt, SudoMaxAttempts)
+	}
+	return nil, fmt.Errorf("sudo on %s: %d incorrect password attempts", conn.GetName(), SudoMaxAttempts)
+}
+
+// removes the sudo prompts from a stream (stderr), a marker split over two writes is held back until the next one
+type sudoPromptFilter struct {
+	pending []byte
+}
+
+func (f *sudoPromptFilter) filter(p []byte) []byte {
+	data := append(f.pending, p...)
+	data = bytes.ReplaceAll(data, []byte(SudoPromptMarker), nil)
+	f.pending = nil
+	for keep := min(len(SudoPromptMarker)-1, len(data)); keep > 0; keep-- {
+		if bytes.HasSuffix(data, []byte(SudoPromptMarker[:keep])) {
+			f.pending = append([]byte(nil), data[len(data)-keep:]...)
+			data = data[:len(data)-keep]
+			break
+		}
+	}
+	return data
+}
+
+func (f *sudoPromptFilter) flush() []byte {
+	rtn := f.pending
+	f.pending = nil
+	return rtn
+}
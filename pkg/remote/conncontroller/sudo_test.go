@@ -0,0 +1,59 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSudoArgs(t *testing.T) {
+	if got := sudoArgs(SudoOpts{}, false); got != "sudo -n --" {
+		t.Errorf("unexpected args without a password: %q", got)
+	}
+	got := sudoArgs(SudoOpts{User: "postgres"}, true)
+	expected := `sudo -k -S -p "` + SudoPromptMarker + `" -u postgres --`
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	si := &sudoInfo{prefix: "sudo -n --"}
+	cmdStr, err := si.wrapCommand(`cd /srv && echo "$HOME"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmdStr != `sudo -n -- sh -c "cd /srv && echo \"\$HOME\""` {
+		t.Errorf("unexpected command: %s", cmdStr)
+	}
+}
+
+func TestSudoPromptFilter(t *testing.T) {
+	half := len(SudoPromptMarker) / 2
+	tests := []struct {
+		name   string
+		writes []string
+	}{
+		{"whole", []string{SudoPromptMarker + "error: no such unit\n"}},
+		{"split", []string{"x" + SudoPromptMarker[:half], SudoPromptMarker[half:] + "error: no such unit\n"}},
+		{"twice", []string{SudoPromptMarker, SudoPromptMarker[:3], SudoPromptMarker[3:] + "error: no such unit\n"}},
+		{"no prompt", []string{"error: no such", " unit\n"}},
+	}
+	for _, tc := range tests {
+		f := &sudoPromptFilter{}
+		var out strings.Builder
+		for _, w := range tc.writes {
+			out.Write(f.filter([]byte(w)))
+		}
+		out.Write(f.flush())
+		got := strings.TrimPrefix(out.String(), "x")
+		if got != "error: no such unit\n" {
+			t.Errorf("%s: expected the prompts to be removed, got %q", tc.name, got)
+		}
+	}
+	// a partial marker at the end of the output is not a prompt
+	f := &sudoPromptFilter{}
+	out := string(f.filter([]byte("done [wave"))) + string(f.flush())
+	if out != "done [wave" {
+		t.Errorf("expected the output to be kept, got %q", out)
+	}
+}
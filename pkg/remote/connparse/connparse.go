@@ -11,12 +11,14 @@ import (
 
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wsl"
 )
 
 const (
 	ConnectionTypeWsh  = "wsh"
 	ConnectionTypeS3   = "s3"
 	ConnectionTypeWave = "wavefile"
+	ConnectionTypeSftp = "sftp"
 
 	ConnHostCurrent = "current"
 	ConnHostWaveSrv = "wavesrv"
@@ -149,8 +151,9 @@ func ParseURI(uri string) (*Connection, error) {
 		parseGenericPath()
 	}
 
-	if scheme == ConnectionTypeWsh {
-		if host == "" {
+	// sftp paths are the paths of the remote host, like wsh paths
+	if scheme == ConnectionTypeWsh || scheme == ConnectionTypeSftp {
+		if host == "" && scheme == ConnectionTypeWsh {
 			host = wshrpc.LocalConnName
 		}
 		if strings.HasPrefix(remotePath, "/~") {
@@ -160,6 +163,16 @@ func ParseURI(uri string) (*Connection, error) {
 		}
 	}
 
+	// the windows paths of the files of a distro (on a drive, or in \\wsl.localhost\<distro>) are translated
+	if strings.HasPrefix(host, "wsl://") {
+		if winPath := strings.TrimPrefix(remotePath, "/"); wsl.IsWindowsPath(winPath) {
+			distro, wslPath, err := wsl.WindowsToWslPath(winPath)
+			if err == nil && (distro == "" || "wsl://"+distro == host) {
+				remotePath = wslPath
+			}
+		}
+	}
+
 	conn := &Connection{
 		Scheme: scheme,
 		Host:   host,
@@ -357,6 +357,25 @@ func TestParseURI_WSHWSL(t *testing.T) {
 	testUri()
 }
 
+func TestParseURI_WSLWindowsPath(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		`wsh://wsl://Ubuntu/C:\Users\me\notes.txt`:          "/mnt/c/Users/me/notes.txt",
+		`wsh://wsl://Ubuntu/\\wsl.localhost\Ubuntu\home\me`: "/home/me",
+		`wsh://wsl://Ubuntu/\\wsl.localhost\Debian\home\me`: `/\\wsl.localhost\Debian\home\me`,
+		"wsh://wsl://Ubuntu/home/me":                        "/home/me",
+	}
+	for uri, expected := range tests {
+		c, err := connparse.ParseURI(uri)
+		if err != nil {
+			t.Fatalf("failed to parse URI %q: %v", uri, err)
+		}
+		if c.Path != expected {
+			t.Errorf("%s: expected path %q, got %q", uri, expected, c.Path)
+		}
+	}
+}
+
 func TestParseUri_LocalWindowsAbsPath(t *testing.T) {
 	t.Parallel()
 	cstr := "wsh://local/C:\\path\\to\\file"
@@ -481,3 +500,27 @@ func TestParseURI_S3BucketOnly(t *testing.T) {
 	t.Log("Testing with trailing slash")
 	testUri("profile:s3://bucket/", "/", "bucket/")
 }
+
+func TestParseURI_Sftp(t *testing.T) {
+	t.Parallel()
+
+	testUri := func(cstr string, hostExpected string, pathExpected string) {
+		c, err := connparse.ParseURI(cstr)
+		if err != nil {
+			t.Fatalf("failed to parse URI: %v", err)
+		}
+		if c.Host != hostExpected {
+			t.Fatalf("expected host to be %q, got %q", hostExpected, c.Host)
+		}
+		if c.Path != pathExpected {
+			t.Fatalf("expected path to be %q, got %q", pathExpected, c.Path)
+		}
+		if c.GetType() != connparse.ConnectionTypeSftp {
+			t.Fatalf("expected conn type to be %q, got %q", connparse.ConnectionTypeSftp, c.GetType())
+		}
+	}
+
+	testUri("sftp://user@example.com:2222/etc/hosts", "user@example.com:2222", "/etc/hosts")
+	testUri("sftp://user@example.com/~/notes.txt", "user@example.com", "~/notes.txt")
+	testUri("sftp://user@example.com", "user@example.com", "")
+}
@@ -6,9 +6,11 @@ import (
 	"log"
 
 	"github.com/wavetermdev/waveterm/pkg/remote/awsconn"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
 	"github.com/wavetermdev/waveterm/pkg/remote/connparse"
 	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/fstype"
 	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/s3fs"
+	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/sftpfs"
 	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/wavefs"
 	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/wshfs"
 	"github.com/wavetermdev/waveterm/pkg/util/iochan/iochantypes"
@@ -38,7 +40,13 @@ func CreateFileShareClient(ctx context.Context, connection string) (fstype.FileS
 		return s3fs.NewS3Client(config), conn
 	} else if conntype == connparse.ConnectionTypeWave {
 		return wavefs.NewWaveClient(), conn
+	} else if conntype == connparse.ConnectionTypeSftp {
+		return sftpfs.NewSftpClient(), conn
 	} else if conntype == connparse.ConnectionTypeWsh {
+		if conncontroller.UseSftp(conn.Host) {
+			// no wsh on the host, the file browser falls back to sftp
+			return sftpfs.NewSftpClient(), conn
+		}
 		return wshfs.NewWshClient(), conn
 	} else {
 		log.Printf("unsupported connection type: %s", conntype)
@@ -64,6 +72,91 @@ func ReadStream(ctx context.Context, data wshrpc.FileData) <-chan wshrpc.RespOrE
 	return client.ReadStream(ctx, conn, data)
 }
 
+func Watch(ctx context.Context, data wshrpc.CommandFileWatchData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileWatchEvent] {
+	log.Printf("Watch: %v", data.Path)
+	client, conn := CreateFileShareClient(ctx, data.Path)
+	if conn == nil || client == nil {
+		return wshutil.SendErrCh[wshrpc.FileWatchEvent](fmt.Errorf(ErrorParsingConnection, data.Path))
+	}
+	waveClient, ok := client.(*wavefs.WaveClient)
+	if !ok {
+		return wshutil.SendErrCh[wshrpc.FileWatchEvent](fmt.Errorf("only wave files can be watched, not %s", data.Path))
+	}
+	return waveClient.Watch(ctx, conn, data.Offset)
+}
+
+// the wave client of a chunked transfer (only wave files have them)
+func getTransferClient(ctx context.Context, path string) (*wavefs.WaveClient, *connparse.Connection, error) {
+	client, conn := CreateFileShareClient(ctx, path)
+	if conn == nil || client == nil {
+		return nil, nil, fmt.Errorf(ErrorParsingConnection, path)
+	}
+	waveClient, ok := client.(*wavefs.WaveClient)
+	if !ok {
+		return nil, nil, fmt.Errorf("chunked transfers are for wave files, not %s", path)
+	}
+	return waveClient, conn, nil
+}
+
+func UploadChunk(ctx context.Context, data wshrpc.CommandFileUploadChunkData) (*wshrpc.FileTransferStatus, error) {
+	waveClient, conn, err := getTransferClient(ctx, data.Path)
+	if err != nil {
+		return nil, err
+	}
+	return waveClient.UploadChunk(ctx, conn, data)
+}
+
+func TransferStatus(ctx context.Context, data wshrpc.CommandFileTransferData) (*wshrpc.FileTransferStatus, error) {
+	waveClient, conn, err := getTransferClient(ctx, data.Path)
+	if err != nil {
+		return nil, err
+	}
+	return waveClient.TransferStatus(ctx, conn, data)
+}
+
+func TransferCancel(ctx context.Context, data wshrpc.CommandFileTransferData) error {
+	log.Printf("TransferCancel: %v %v", data.Path, data.TransferId)
+	waveClient, conn, err := getTransferClient(ctx, data.Path)
+	if err != nil {
+		return err
+	}
+	return waveClient.TransferCancel(ctx, conn, data)
+}
+
+func Download(ctx context.Context, data wshrpc.CommandFileTransferData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileTransferChunk] {
+	log.Printf("Download: %v", data.Path)
+	waveClient, conn, err := getTransferClient(ctx, data.Path)
+	if err != nil {
+		return wshutil.SendErrCh[wshrpc.FileTransferChunk](err)
+	}
+	return waveClient.Download(ctx, conn, data)
+}
+
+func ReadRanges(ctx context.Context, data wshrpc.CommandFileReadRangesData) (*wshrpc.FileReadRangesData, error) {
+	client, conn := CreateFileShareClient(ctx, data.Path)
+	if conn == nil || client == nil {
+		return nil, fmt.Errorf(ErrorParsingConnection, data.Path)
+	}
+	waveClient, ok := client.(*wavefs.WaveClient)
+	if !ok {
+		return nil, fmt.Errorf("range reads are for wave files, not %s", data.Path)
+	}
+	return waveClient.ReadRanges(ctx, conn, data)
+}
+
+func Export(ctx context.Context, data wshrpc.CommandFileExportData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileExportProgress] {
+	log.Printf("Export: %v %v", data.Path, data.Dir)
+	client, conn := CreateFileShareClient(ctx, data.Path)
+	if conn == nil || client == nil {
+		return wshutil.SendErrCh[wshrpc.FileExportProgress](fmt.Errorf(ErrorParsingConnection, data.Path))
+	}
+	waveClient, ok := client.(*wavefs.WaveClient)
+	if !ok {
+		return wshutil.SendErrCh[wshrpc.FileExportProgress](fmt.Errorf("only wave files can be exported, not %s", data.Path))
+	}
+	return waveClient.Export(ctx, conn, data)
+}
+
 func ReadTarStream(ctx context.Context, data wshrpc.CommandRemoteStreamTarData) <-chan wshrpc.RespOrErrorUnion[iochantypes.Packet] {
 	log.Printf("ReadTarStream: %v", data.Path)
 	client, conn := CreateFileShareClient(ctx, data.Path)
@@ -0,0 +1,521 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// the files of ssh connections over sftp.  serves the "sftp" uris, and the wsh uris of the connections that run
+// without wsh (see fileshare.CreateFileShareClient), so the file browser works on hosts where wsh can't be installed.
+package sftpfs
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/remote/connparse"
+	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/fstype"
+	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/fsutil"
+	"github.com/wavetermdev/waveterm/pkg/remote/sftpclient"
+	"github.com/wavetermdev/waveterm/pkg/util/fileutil"
+	"github.com/wavetermdev/waveterm/pkg/util/iochan/iochantypes"
+	"github.com/wavetermdev/waveterm/pkg/util/tarcopy"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+type SftpClient struct{}
+
+var _ fstype.FileShareClient = SftpClient{}
+
+func NewSftpClient() *SftpClient {
+	return &SftpClient{}
+}
+
+// a path on the remote host, resolved to an absolute path (a relative path or "~" is in the home directory)
+type remotePath struct {
+	client *sftpclient.Client
+	abs    string
+	home   string
+}
+
+func resolve(ctx context.Context, conn *connparse.Connection) (*remotePath, error) {
+	client, err := conncontroller.GetSftpClient(ctx, conn.Host)
+	if err != nil {
+		return nil, err
+	}
+	return resolvePath(client, conn.Path)
+}
+
+func resolvePath(client *sftpclient.Client, p string) (*remotePath, error) {
+	home, err := client.HomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &remotePath{client: client, abs: absPath(p, home), home: home}, nil
+}
+
+func absPath(p string, home string) string {
+	if strings.HasPrefix(p, "/") {
+		return path.Clean(p)
+	}
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		p = strings.TrimPrefix(p[1:], "/")
+	}
+	return path.Join(home, p)
+}
+
+// the path shown for abs, with the home directory as "~" (like the wsh file infos)
+func displayPath(abs string, home string) string {
+	if abs == home {
+		return "~"
+	}
+	if home != "/" && strings.HasPrefix(abs, home+"/") {
+		return "~" + strings.TrimPrefix(abs, home)
+	}
+	return abs
+}
+
+func (p *remotePath) join(name string) *remotePath {
+	return &remotePath{client: p.client, abs: path.Join(p.abs, name), home: p.home}
+}
+
+func (p *remotePath) toFileInfo(info fs.FileInfo) *wshrpc.FileInfo {
+	rtn := &wshrpc.FileInfo{
+		Path:          displayPath(p.abs, p.home),
+		Dir:           path.Dir(p.abs),
+		Name:          info.Name(),
+		Size:          info.Size(),
+		Mode:          info.Mode(),
+		ModeStr:       info.Mode().String(),
+		ModTime:       info.ModTime().UnixMilli(),
+		IsDir:         info.IsDir(),
+		MimeType:      fileutil.DetectMimeType(p.abs, info, false),
+		SupportsMkdir: true,
+	}
+	if info.IsDir() {
+		rtn.Size = -1
+	}
+	return rtn
+}
+
+func (p *remotePath) stat() (*wshrpc.FileInfo, error) {
+	info, err := p.client.Stat(p.abs)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &wshrpc.FileInfo{
+			Path:          displayPath(p.abs, p.home),
+			Dir:           path.Dir(p.abs),
+			Name:          path.Base(p.abs),
+			NotFound:      true,
+			SupportsMkdir: true,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat %q: %w", p.abs, err)
+	}
+	return p.toFileInfo(info), nil
+}
+
+// the entries of the directory.  like the wsh listings, All lists the files of the directory and its subdirectories.
+func (p *remotePath) listEntries(opts *wshrpc.FileListOpts) ([]*wshrpc.FileInfo, error) {
+	if opts == nil {
+		opts = &wshrpc.FileListOpts{}
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = wshrpc.MaxDirSize
+	}
+	var rtn []*wshrpc.FileInfo
+	seen := 0
+	var walkFn func(dir *remotePath) error
+	walkFn = func(dir *remotePath) error {
+		entries, err := dir.client.ReadDir(dir.abs)
+		if err != nil {
+			return fmt.Errorf("cannot read directory %q: %w", dir.abs, err)
+		}
+		for _, entry := range entries {
+			if seen >= opts.Offset+limit {
+				return nil
+			}
+			entryPath := dir.join(entry.Name())
+			if opts.All && entry.IsDir() {
+				if err := walkFn(entryPath); err != nil {
+					return err
+				}
+				continue
+			}
+			seen++
+			if seen <= opts.Offset {
+				continue
+			}
+			if entry.Mode()&os.ModeSymlink != 0 {
+				// show what the link points to (a directory link is browsed like a directory)
+				if target, err := dir.client.Stat(entryPath.abs); err == nil {
+					entry = target
+				}
+			}
+			rtn = append(rtn, entryPath.toFileInfo(entry))
+		}
+		return nil
+	}
+	err := walkFn(p)
+	return rtn, err
+}
+
+func (c SftpClient) Stat(ctx context.Context, conn *connparse.Connection) (*wshrpc.FileInfo, error) {
+	p, err := resolve(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return p.stat()
+}
+
+func (c SftpClient) Read(ctx context.Context, conn *connparse.Connection, data wshrpc.FileData) (*wshrpc.FileData, error) {
+	rtnCh := c.ReadStream(ctx, conn, data)
+	return fsutil.ReadStreamToFileData(ctx, rtnCh)
+}
+
+func (c SftpClient) ReadStream(ctx context.Context, conn *connparse.Connection, data wshrpc.FileData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileData] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.FileData], 16)
+	go func() {
+		defer close(rtn)
+		err := readStream(ctx, conn, data, func(resp wshrpc.FileData) {
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.FileData]{Response: resp}
+		})
+		if err != nil {
+			rtn <- wshutil.RespErr[wshrpc.FileData](err)
+		}
+	}()
+	return rtn
+}
+
+func readStream(ctx context.Context, conn *connparse.Connection, data wshrpc.FileData, sendFn func(resp wshrpc.FileData)) error {
+	p, err := resolve(ctx, conn)
+	if err != nil {
+		return err
+	}
+	finfo, err := p.stat()
+	if err != nil {
+		return err
+	}
+	sendFn(wshrpc.FileData{Info: finfo})
+	if finfo.NotFound {
+		return nil
+	}
+	if finfo.IsDir {
+		entries, err := p.listEntries(nil)
+		if err != nil {
+			return err
+		}
+		for len(entries) > 0 {
+			chunk := entries[:min(len(entries), wshrpc.DirChunkSize)]
+			entries = entries[len(chunk):]
+			sendFn(wshrpc.FileData{Entries: chunk})
+		}
+		return nil
+	}
+	file, err := p.client.Open(p.abs)
+	if err != nil {
+		return fmt.Errorf("cannot open file %q: %w", p.abs, err)
+	}
+	defer utilfn.GracefulClose(file, "sftpfs", p.abs)
+	var offset int64
+	end := int64(-1)
+	if data.At != nil {
+		offset = data.At.Offset
+		if data.At.Size > 0 {
+			end = offset + int64(data.At.Size)
+		}
+	}
+	buf := make([]byte, wshrpc.FileChunkSize)
+	for end < 0 || offset < end {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		readBuf := buf
+		if end >= 0 && int64(len(readBuf)) > end-offset {
+			readBuf = readBuf[:end-offset]
+		}
+		n, err := file.ReadAt(readBuf, offset)
+		if n > 0 {
+			sendFn(wshrpc.FileData{Data64: base64.StdEncoding.EncodeToString(readBuf[:n]), At: &wshrpc.FileDataAt{Offset: offset, Size: n}})
+			offset += int64(n)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read file %q: %w", p.abs, err)
+		}
+	}
+	return nil
+}
+
+func (c SftpClient) ReadTarStream(ctx context.Context, conn *connparse.Connection, opts *wshrpc.FileCopyOpts) <-chan wshrpc.RespOrErrorUnion[iochantypes.Packet] {
+	if opts == nil {
+		opts = &wshrpc.FileCopyOpts{}
+	}
+	p, err := resolve(ctx, conn)
+	if err != nil {
+		return wshutil.SendErrCh[iochantypes.Packet](err)
+	}
+	finfo, err := p.client.Stat(p.abs)
+	if err != nil {
+		return wshutil.SendErrCh[iochantypes.Packet](fmt.Errorf("cannot stat file %q: %w", p.abs, err))
+	}
+	singleFile := !finfo.IsDir()
+	if !singleFile && !opts.Recursive {
+		return wshutil.SendErrCh[iochantypes.Packet](fmt.Errorf(fstype.RecursiveRequiredError))
+	}
+	// like the wsh tar streams, a trailing slash copies the contents of the directory instead of the directory
+	pathPrefix := path.Dir(p.abs)
+	if !singleFile && strings.HasSuffix(conn.Path, "/") {
+		pathPrefix = p.abs
+	}
+	timeout := fstype.DefaultTimeout
+	if opts.Timeout > 0 {
+		timeout = time.Duration(opts.Timeout) * time.Millisecond
+	}
+	readerCtx, cancel := context.WithTimeout(ctx, timeout)
+	rtn, writeHeader, fileWriter, tarClose := tarcopy.TarCopySrc(readerCtx, pathPrefix)
+	go func() {
+		defer func() {
+			tarClose()
+			cancel()
+		}()
+		var walkFn func(filePath string, info fs.FileInfo) error
+		walkFn = func(filePath string, info fs.FileInfo) error {
+			if readerCtx.Err() != nil {
+				return readerCtx.Err()
+			}
+			if err := writeHeader(info, filePath, singleFile); err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				file, err := p.client.Open(filePath)
+				if err != nil {
+					return err
+				}
+				defer utilfn.GracefulClose(file, "sftpfs", filePath)
+				_, err = io.Copy(fileWriter, file)
+				return err
+			}
+			if !info.IsDir() {
+				return nil
+			}
+			entries, err := p.client.ReadDir(filePath)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() && !entry.Mode().IsRegular() {
+					// symlinks and special files are not copied
+					continue
+				}
+				if err := walkFn(path.Join(filePath, entry.Name()), entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := walkFn(p.abs, finfo); err != nil {
+			rtn <- wshutil.RespErr[iochantypes.Packet](err)
+		}
+	}()
+	return rtn
+}
+
+func (c SftpClient) ListEntries(ctx context.Context, conn *connparse.Connection, opts *wshrpc.FileListOpts) ([]*wshrpc.FileInfo, error) {
+	p, err := resolve(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return p.listEntries(opts)
+}
+
+func (c SftpClient) ListEntriesStream(ctx context.Context, conn *connparse.Connection, opts *wshrpc.FileListOpts) <-chan wshrpc.RespOrErrorUnion[wshrpc.CommandRemoteListEntriesRtnData] {
+	entries, err := c.ListEntries(ctx, conn, opts)
+	if err != nil {
+		return wshutil.SendErrCh[wshrpc.CommandRemoteListEntriesRtnData](err)
+	}
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.CommandRemoteListEntriesRtnData], len(entries)/wshrpc.DirChunkSize+1)
+	defer close(rtn)
+	for len(entries) > 0 {
+		chunk := entries[:min(len(entries), wshrpc.DirChunkSize)]
+		entries = entries[len(chunk):]
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.CommandRemoteListEntriesRtnData]{Response: wshrpc.CommandRemoteListEntriesRtnData{FileInfo: chunk}}
+	}
+	return rtn
+}
+
+func (c SftpClient) PutFile(ctx context.Context, conn *connparse.Connection, data wshrpc.FileData) error {
+	return c.writeFile(ctx, conn, data, false)
+}
+
+func (c SftpClient) AppendFile(ctx context.Context, conn *connparse.Connection, data wshrpc.FileData) error {
+	return c.writeFile(ctx, conn, data, true)
+}
+
+// like the wsh writes: a write at an offset goes into the existing file, other writes replace it (or append to it)
+func (c SftpClient) writeFile(ctx context.Context, conn *connparse.Connection, data wshrpc.FileData, appendData bool) error {
+	p, err := resolve(ctx, conn)
+	if err != nil {
+		return err
+	}
+	dataBytes, err := base64.StdEncoding.DecodeString(data.Data64)
+	if err != nil {
+		return fmt.Errorf("cannot decode base64 data: %w", err)
+	}
+	createMode := os.FileMode(0644)
+	if data.Info != nil && data.Info.Mode > 0 {
+		createMode = data.Info.Mode.Perm()
+	}
+	var atOffset int64
+	if data.At != nil {
+		atOffset = data.At.Offset
+	}
+	flag := os.O_WRONLY | os.O_CREATE
+	switch {
+	case appendData:
+		flag |= os.O_APPEND
+	case data.At == nil:
+		flag |= os.O_TRUNC
+	}
+	file, err := p.client.OpenFile(p.abs, flag, createMode)
+	if err != nil {
+		return fmt.Errorf("cannot open file %q: %w", p.abs, err)
+	}
+	defer utilfn.GracefulClose(file, "sftpfs", p.abs)
+	if appendData {
+		// the offset of an append is ignored by most servers, but not all of them
+		atOffset, err = file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return fmt.Errorf("cannot append to file %q: %w", p.abs, err)
+		}
+	}
+	if _, err := file.WriteAt(dataBytes, atOffset); err != nil {
+		return fmt.Errorf("cannot write to file %q: %w", p.abs, err)
+	}
+	return nil
+}
+
+func (c SftpClient) Mkdir(ctx context.Context, conn *connparse.Connection) error {
+	p, err := resolve(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return p.client.MkdirAll(p.abs, 0755)
+}
+
+func (c SftpClient) MoveInternal(ctx context.Context, srcConn, destConn *connparse.Connection, opts *wshrpc.FileCopyOpts) error {
+	if srcConn.Host != destConn.Host {
+		return fmt.Errorf("move internal, src and dest hosts do not match")
+	}
+	if opts == nil {
+		opts = &wshrpc.FileCopyOpts{}
+	}
+	src, err := resolve(ctx, srcConn)
+	if err != nil {
+		return err
+	}
+	srcInfo, err := src.client.Stat(src.abs)
+	if err != nil {
+		return fmt.Errorf("cannot stat %q: %w", src.abs, err)
+	}
+	if srcInfo.IsDir() && !opts.Recursive {
+		return fmt.Errorf(fstype.RecursiveRequiredError)
+	}
+	dest := &remotePath{client: src.client, abs: absPath(destConn.Path, src.home), home: src.home}
+	destInfo, err := dest.client.Stat(dest.abs)
+	if err == nil {
+		if destInfo.IsDir() {
+			dest = dest.join(path.Base(src.abs))
+		} else if !opts.Overwrite {
+			return fmt.Errorf(fstype.OverwriteRequiredError, dest.abs)
+		}
+	}
+	if opts.Overwrite {
+		// sftp renames don't replace the destination
+		if err := dest.client.RemoveAll(dest.abs); err != nil {
+			return fmt.Errorf("cannot remove %q: %w", dest.abs, err)
+		}
+	}
+	if err := src.client.Rename(src.abs, dest.abs); err != nil {
+		return fmt.Errorf("cannot move %q to %q: %w", src.abs, dest.abs, err)
+	}
+	return nil
+}
+
+// sftp has no copies on the server, the files go through wave
+func (c SftpClient) CopyInternal(ctx context.Context, srcConn, destConn *connparse.Connection, opts *wshrpc.FileCopyOpts) (bool, error) {
+	return c.CopyRemote(ctx, srcConn, destConn, c, opts)
+}
+
+func (c SftpClient) CopyRemote(ctx context.Context, srcConn, destConn *connparse.Connection, srcClient fstype.FileShareClient, opts *wshrpc.FileCopyOpts) (bool, error) {
+	dest, err := resolve(ctx, destConn)
+	if err != nil {
+		return false, err
+	}
+	return fsutil.PrefixCopyRemote(ctx, srcConn, destConn, srcClient, c, func(host string, filePath string, size int64, reader io.Reader) error {
+		destPath := absPath(filePath, dest.home)
+		if err := dest.client.MkdirAll(path.Dir(destPath), 0755); err != nil {
+			return err
+		}
+		file, err := dest.client.Create(destPath)
+		if err != nil {
+			return err
+		}
+		defer utilfn.GracefulClose(file, "sftpfs", destPath)
+		_, err = io.Copy(file, reader)
+		return err
+	}, opts)
+}
+
+func (c SftpClient) Delete(ctx context.Context, conn *connparse.Connection, recursive bool) error {
+	p, err := resolve(ctx, conn)
+	if err != nil {
+		return err
+	}
+	info, err := p.client.Lstat(p.abs)
+	if err != nil {
+		return fmt.Errorf("cannot delete %q: %w", p.abs, err)
+	}
+	if !info.IsDir() {
+		return p.client.Remove(p.abs)
+	}
+	if !recursive {
+		return fmt.Errorf(fstype.RecursiveRequiredError)
+	}
+	log.Printf("sftpfs: deleting directory %s:%s", conn.Host, p.abs)
+	return p.client.RemoveAll(p.abs)
+}
+
+func (c SftpClient) Join(ctx context.Context, conn *connparse.Connection, parts ...string) (*wshrpc.FileInfo, error) {
+	p, err := resolve(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		if strings.HasPrefix(part, "/") || part == "~" || strings.HasPrefix(part, "~/") {
+			p.abs = absPath(part, p.home)
+		} else {
+			p = p.join(part)
+		}
+	}
+	return p.stat()
+}
+
+func (c SftpClient) GetConnectionType() string {
+	return connparse.ConnectionTypeSftp
+}
+
+func (c SftpClient) GetCapability() wshrpc.FileShareCapability {
+	return wshrpc.FileShareCapability{CanAppend: true, CanMkdir: true}
+}
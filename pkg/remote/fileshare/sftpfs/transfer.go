@@ -0,0 +1,200 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sftpfs
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/remote/sftpclient"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// the uploads and downloads between the machine running wave and an ssh connection (the sftpupload and sftpdownload
+// rpcs), and the copies between two connections (sftpcopy), with their progress streamed back.  a copy between two
+// hosts goes through wavesrv (sftp on both sides), a copy within one host runs cp on it, unless it is resumed or
+// verified (cp can do neither).
+
+func Upload(ctx context.Context, data wshrpc.CommandSftpTransferData) <-chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	return runTransfer(ctx, data, true)
+}
+
+func Download(ctx context.Context, data wshrpc.CommandSftpTransferData) <-chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	return runTransfer(ctx, data, false)
+}
+
+func runTransfer(ctx context.Context, data wshrpc.CommandSftpTransferData, upload bool) <-chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	if !filepath.IsAbs(data.LocalPath) {
+		return wshutil.SendErrCh[wshrpc.SftpTransferProgress](fmt.Errorf("the local path must be absolute, got %q", data.LocalPath))
+	}
+	if data.RemotePath == "" {
+		return wshutil.SendErrCh[wshrpc.SftpTransferProgress](fmt.Errorf("the remote path is required"))
+	}
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("sftpfs:runTransfer", recover())
+		}()
+		defer close(rtn)
+		connName := wconn.ResolveConnName(ctx, data.Connection)
+		client, err := getSftpClient(ctx, connName, data.Sudo)
+		if err != nil {
+			rtn <- wshutil.RespErr[wshrpc.SftpTransferProgress](err)
+			return
+		}
+		remote, err := resolvePath(client, data.RemotePath)
+		if err != nil {
+			rtn <- wshutil.RespErr[wshrpc.SftpTransferProgress](err)
+			return
+		}
+		opts := sftpclient.TransferOpts{Recursive: data.Recursive, Resume: data.Resume, Preserve: data.Preserve, Verify: data.Verify}
+		progressFn := func(p sftpclient.Progress) {
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress]{Response: makeTransferProgress(p)}
+		}
+		if upload {
+			err = client.Upload(ctx, data.LocalPath, remote.abs, opts, progressFn)
+		} else {
+			err = client.Download(ctx, remote.abs, data.LocalPath, opts, progressFn)
+		}
+		if err != nil {
+			rtn <- wshutil.RespErr[wshrpc.SftpTransferProgress](err)
+		}
+	}()
+	return rtn
+}
+
+func makeTransferProgress(p sftpclient.Progress) wshrpc.SftpTransferProgress {
+	return wshrpc.SftpTransferProgress{
+		Path:        p.Path,
+		DestPath:    p.DestPath,
+		Offset:      p.Offset,
+		Size:        p.Size,
+		ResumedFrom: p.ResumedFrom,
+		FileDone:    p.FileDone,
+		Skipped:     p.Skipped,
+		BytesDone:   p.BytesDone,
+		BytesTotal:  p.BytesTotal,
+		FilesDone:   p.FilesDone,
+		FilesTotal:  p.FilesTotal,
+		Checksum:    p.Checksum,
+	}
+}
+
+// a side of a copy, the client is nil for the local machine
+type copySide struct {
+	connName string
+	client   *sftpclient.Client
+	path     string
+}
+
+func getSftpClient(ctx context.Context, connName string, sudo bool) (*sftpclient.Client, error) {
+	if sudo {
+		return conncontroller.GetSudoSftpClient(ctx, connName)
+	}
+	return conncontroller.GetSftpClient(ctx, connName)
+}
+
+func openCopySide(ctx context.Context, connection string, p string, sudo bool) (*copySide, error) {
+	if p == "" {
+		return nil, fmt.Errorf("the path is required")
+	}
+	if connection == "" {
+		if !filepath.IsAbs(p) {
+			return nil, fmt.Errorf("the local path must be absolute, got %q", p)
+		}
+		return &copySide{path: p}, nil
+	}
+	connName := wconn.ResolveConnName(ctx, connection)
+	client, err := getSftpClient(ctx, connName, sudo)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := resolvePath(client, p)
+	if err != nil {
+		return nil, err
+	}
+	return &copySide{connName: connName, client: client, path: remote.abs}, nil
+}
+
+func Copy(ctx context.Context, data wshrpc.CommandSftpCopyData) <-chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	if data.SrcConnection == "" && data.DestConnection == "" {
+		return wshutil.SendErrCh[wshrpc.SftpTransferProgress](fmt.Errorf("at least one side of the copy must be a connection"))
+	}
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("sftpfs:Copy", recover())
+		}()
+		defer close(rtn)
+		src, err := openCopySide(ctx, data.SrcConnection, data.SrcPath, data.Sudo)
+		if err != nil {
+			rtn <- wshutil.RespErr[wshrpc.SftpTransferProgress](fmt.Errorf("source: %w", err))
+			return
+		}
+		dest, err := openCopySide(ctx, data.DestConnection, data.DestPath, data.Sudo)
+		if err != nil {
+			rtn <- wshutil.RespErr[wshrpc.SftpTransferProgress](fmt.Errorf("destination: %w", err))
+			return
+		}
+		if src.client != nil && src.connName == dest.connName && !data.Resume && !data.Verify {
+			progress, err := copyOnHost(ctx, src, dest.path, data)
+			if err != nil {
+				rtn <- wshutil.RespErr[wshrpc.SftpTransferProgress](err)
+				return
+			}
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress]{Response: *progress}
+			return
+		}
+		opts := sftpclient.TransferOpts{Recursive: data.Recursive, Resume: data.Resume, Preserve: data.Preserve, Verify: data.Verify}
+		progressFn := func(p sftpclient.Progress) {
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress]{Response: makeTransferProgress(p)}
+		}
+		err = sftpclient.Copy(ctx, src.client, src.path, dest.client, dest.path, opts, progressFn)
+		if err != nil {
+			rtn <- wshutil.RespErr[wshrpc.SftpTransferProgress](err)
+		}
+	}()
+	return rtn
+}
+
+// runs cp on the host of src, the progress is sent once it is done
+func copyOnHost(ctx context.Context, src *copySide, destPath string, data wshrpc.CommandSftpCopyData) (*wshrpc.SftpTransferProgress, error) {
+	info, err := src.client.Stat(src.path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() && !data.Recursive {
+		return nil, fmt.Errorf("%s is a directory (the recursive flag is not set)", src.path)
+	}
+	cmd := "cp"
+	if data.Recursive {
+		cmd += " -R"
+	}
+	if data.Preserve {
+		cmd += " -p"
+	}
+	cmd += " -- " + shellutil.HardQuote(src.path) + " " + shellutil.HardQuote(destPath)
+	result, err := wconn.RunCommand(ctx, wshrpc.CommandConnectionRunData{Connection: src.connName, Cmd: cmd, Sudo: data.Sudo}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("cp failed on %s (exit code %d): %s", src.connName, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	progress := &wshrpc.SftpTransferProgress{Path: src.path, DestPath: destPath, FileDone: true, Direct: true, FilesDone: 1, FilesTotal: 1}
+	if !info.IsDir() {
+		progress.Size = info.Size()
+		progress.Offset = info.Size()
+		progress.BytesDone = info.Size()
+		progress.BytesTotal = info.Size()
+	}
+	return progress, nil
+}
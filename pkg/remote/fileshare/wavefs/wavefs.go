@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/remote/connparse"
 	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/fspath"
@@ -25,7 +26,6 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/util/iochan/iochantypes"
 	"github.com/wavetermdev/waveterm/pkg/util/tarcopy"
 	"github.com/wavetermdev/waveterm/pkg/util/wavefileutil"
-	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
@@ -75,6 +75,47 @@ func (c WaveClient) ReadStream(ctx context.Context, conn *connparse.Connection,
 	return ch
 }
 
+// the stream ends this long before ctx's deadline (the rpc timeout), so the client can watch again without a
+// timeout error
+const watchDeadlineMargin = 2 * time.Second
+
+// follows the file (not part of FileShareClient, the other file systems can not be watched)
+func (c WaveClient) Watch(ctx context.Context, conn *connparse.Connection, offset int64) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileWatchEvent] {
+	zoneId := conn.Host
+	if zoneId == "" {
+		return wshutil.SendErrCh[wshrpc.FileWatchEvent](fmt.Errorf("zoneid not found in connection"))
+	}
+	fileName, err := cleanPath(conn.Path)
+	if err != nil {
+		return wshutil.SendErrCh[wshrpc.FileWatchEvent](fmt.Errorf("error cleaning path: %w", err))
+	}
+	cancelFn := func() {}
+	if deadline, ok := ctx.Deadline(); ok {
+		ctx, cancelFn = context.WithDeadline(ctx, deadline.Add(-watchDeadlineMargin))
+	}
+	events, err := filestore.WFS.Watch(ctx, zoneId, fileName, offset)
+	if err != nil {
+		cancelFn()
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("NOTFOUND: %w", err)
+		}
+		return wshutil.SendErrCh[wshrpc.FileWatchEvent](err)
+	}
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.FileWatchEvent], 16)
+	go func() {
+		defer close(ch)
+		defer cancelFn()
+		for event := range events {
+			rtnEvent := wshrpc.FileWatchEvent{Type: event.Type, Offset: event.Offset}
+			if len(event.Data) > 0 {
+				rtnEvent.Data64 = base64.StdEncoding.EncodeToString(event.Data)
+			}
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.FileWatchEvent]{Response: rtnEvent}
+		}
+	}()
+	return ch
+}
+
 func (c WaveClient) Read(ctx context.Context, conn *connparse.Connection, data wshrpc.FileData) (*wshrpc.FileData, error) {
 	zoneId := conn.Host
 	if zoneId == "" {
@@ -84,7 +125,17 @@ func (c WaveClient) Read(ctx context.Context, conn *connparse.Connection, data w
 	if err != nil {
 		return nil, fmt.Errorf("error cleaning path: %w", err)
 	}
-	if data.At != nil {
+	if data.At != nil && data.At.ReadAhead > 0 {
+		ranges := []filestore.ReadRange{{Offset: data.At.Offset, Size: int64(data.At.Size)}}
+		_, rangeData, err := filestore.WFS.ReadRanges(ctx, zoneId, fileName, ranges, data.At.ReadAhead)
+		if err == nil {
+			return &wshrpc.FileData{Info: data.Info, Data64: base64.StdEncoding.EncodeToString(rangeData[0].Data)}, nil
+		} else if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("NOTFOUND: %w", err)
+		} else {
+			return nil, fmt.Errorf("error reading blockfile: %w", err)
+		}
+	} else if data.At != nil {
 		_, dataBuf, err := filestore.WFS.ReadAt(ctx, zoneId, fileName, data.At.Offset, int64(data.At.Size))
 		if err == nil {
 			return &wshrpc.FileData{Info: data.Info, Data64: base64.StdEncoding.EncodeToString(dataBuf)}, nil
@@ -118,6 +169,34 @@ func (c WaveClient) Read(ctx context.Context, conn *connparse.Connection, data w
 	return &wshrpc.FileData{Info: data.Info, Entries: list}, nil
 }
 
+// reads several windows of the file at once, see filestore.ReadRanges
+func (c WaveClient) ReadRanges(ctx context.Context, conn *connparse.Connection, data wshrpc.CommandFileReadRangesData) (*wshrpc.FileReadRangesData, error) {
+	zoneId := conn.Host
+	if zoneId == "" {
+		return nil, fmt.Errorf("zoneid not found in connection")
+	}
+	fileName, err := cleanPath(conn.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error cleaning path: %w", err)
+	}
+	var ranges []filestore.ReadRange
+	for _, at := range data.Ranges {
+		ranges = append(ranges, filestore.ReadRange{Offset: at.Offset, Size: int64(at.Size)})
+	}
+	file, rangeData, err := filestore.WFS.ReadRanges(ctx, zoneId, fileName, ranges, data.ReadAhead)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("NOTFOUND: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading blockfile: %w", err)
+	}
+	rtn := &wshrpc.FileReadRangesData{Info: wavefileutil.WaveFileToFileInfo(file)}
+	for _, r := range rangeData {
+		rtn.Ranges = append(rtn.Ranges, wshrpc.FileRangeData{Offset: r.Offset, Data64: base64.StdEncoding.EncodeToString(r.Data)})
+	}
+	return rtn, nil
+}
+
 func (c WaveClient) ReadTarStream(ctx context.Context, conn *connparse.Connection, opts *wshrpc.FileCopyOpts) <-chan wshrpc.RespOrErrorUnion[iochantypes.Packet] {
 	log.Printf("ReadTarStream: conn: %v, opts: %v\n", conn, opts)
 	path := conn.Path
@@ -360,15 +439,11 @@ func (c WaveClient) PutFile(ctx context.Context, conn *connparse.Connection, dat
 			return fmt.Errorf("error writing to blockfile: %w", err)
 		}
 	}
-	wps.Broker.Publish(wps.WaveEvent{
-		Event:  wps.Event_BlockFile,
-		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, zoneId).String()},
-		Data: &wps.WSFileEventData{
-			ZoneId:   zoneId,
-			FileName: fileName,
-			FileOp:   wps.FileOp_Invalidate,
-		},
-	})
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   zoneId,
+		FileName: fileName,
+		FileOp:   wps.FileOp_Invalidate,
+	}})
 	return nil
 }
 
@@ -411,15 +486,11 @@ func (c WaveClient) AppendFile(ctx context.Context, conn *connparse.Connection,
 	if err != nil {
 		return fmt.Errorf("error writing to blockfile: %w", err)
 	}
-	wps.Broker.Publish(wps.WaveEvent{
-		Event:  wps.Event_BlockFile,
-		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, zoneId).String()},
-		Data: &wps.WSFileEventData{
-			ZoneId:   zoneId,
-			FileName: fileName,
-			FileOp:   wps.FileOp_Invalidate,
-		},
-	})
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   zoneId,
+		FileName: fileName,
+		FileOp:   wps.FileOp_Invalidate,
+	}})
 	return nil
 }
 
@@ -465,15 +536,11 @@ func (c WaveClient) CopyInternal(ctx context.Context, srcConn, destConn *connpar
 		if err := filestore.WFS.WriteFile(ctx, destHost, destFileName, dataBuf); err != nil {
 			return fmt.Errorf("error writing to destination blockfile: %w", err)
 		}
-		wps.Broker.Publish(wps.WaveEvent{
-			Event:  wps.Event_BlockFile,
-			Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, destHost).String()},
-			Data: &wps.WSFileEventData{
-				ZoneId:   destHost,
-				FileName: destFileName,
-				FileOp:   wps.FileOp_Invalidate,
-			},
-		})
+		eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+			ZoneId:   destHost,
+			FileName: destFileName,
+			FileOp:   wps.FileOp_Invalidate,
+		}})
 		return nil
 	})
 }
@@ -506,15 +573,11 @@ func (c WaveClient) CopyRemote(ctx context.Context, srcConn, destConn *connparse
 		if err := filestore.WFS.WriteFile(ctx, zoneId, path, dataBuf); err != nil {
 			return fmt.Errorf("error writing to blockfile: %w", err)
 		}
-		wps.Broker.Publish(wps.WaveEvent{
-			Event:  wps.Event_BlockFile,
-			Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, zoneId).String()},
-			Data: &wps.WSFileEventData{
-				ZoneId:   zoneId,
-				FileName: path,
-				FileOp:   wps.FileOp_Invalidate,
-			},
-		})
+		eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+			ZoneId:   zoneId,
+			FileName: path,
+			FileOp:   wps.FileOp_Invalidate,
+		}})
 		return nil
 	}, opts)
 }
@@ -560,15 +623,11 @@ func (c WaveClient) Delete(ctx context.Context, conn *connparse.Connection, recu
 				errs = append(errs, fmt.Errorf("error deleting blockfile %s/%s: %w", zoneId, entry, err))
 				continue
 			}
-			wps.Broker.Publish(wps.WaveEvent{
-				Event:  wps.Event_BlockFile,
-				Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, zoneId).String()},
-				Data: &wps.WSFileEventData{
-					ZoneId:   zoneId,
-					FileName: entry,
-					FileOp:   wps.FileOp_Delete,
-				},
-			})
+			eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+				ZoneId:   zoneId,
+				FileName: entry,
+				FileOp:   wps.FileOp_Delete,
+			}})
 		}
 		if len(errs) > 0 {
 			return fmt.Errorf("error deleting blockfiles: %v", errs)
@@ -0,0 +1,111 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wavefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/remote/connparse"
+	"github.com/wavetermdev/waveterm/pkg/util/wavefileutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// exports a file (or all the files of the zone, if the path has no file name) into a local directory.  the
+// progress is sent on the channel (at most every transferProgressInterval, the end of each file is always sent)
+// and published as file:transfer events.  a file that fails does not stop the export of the others.
+func (c WaveClient) Export(ctx context.Context, conn *connparse.Connection, data wshrpc.CommandFileExportData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileExportProgress] {
+	zoneId := conn.Host
+	if zoneId == "" {
+		return wshutil.SendErrCh[wshrpc.FileExportProgress](fmt.Errorf("zoneid not found in connection"))
+	}
+	fileName, err := cleanPath(conn.Path)
+	if err != nil {
+		return wshutil.SendErrCh[wshrpc.FileExportProgress](fmt.Errorf("error cleaning path: %w", err))
+	}
+	var files []*filestore.WaveFile
+	if fileName != "" {
+		file, err := filestore.WFS.Stat(ctx, zoneId, fileName)
+		if errors.Is(err, fs.ErrNotExist) {
+			return wshutil.SendErrCh[wshrpc.FileExportProgress](fmt.Errorf("NOTFOUND: %w", err))
+		}
+		if err != nil {
+			return wshutil.SendErrCh[wshrpc.FileExportProgress](fmt.Errorf("error getting blockfile info: %w", err))
+		}
+		files = append(files, file)
+	} else {
+		zoneFiles, err := filestore.WFS.ListFiles(ctx, zoneId)
+		if err != nil {
+			return wshutil.SendErrCh[wshrpc.FileExportProgress](fmt.Errorf("error listing blockfiles: %w", err))
+		}
+		for _, file := range zoneFiles {
+			if !strings.HasPrefix(file.Name, wavebase.BlockFile_TransferPrefix) {
+				files = append(files, file)
+			}
+		}
+	}
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.FileExportProgress], 16)
+	go func() {
+		defer close(ch)
+		for _, file := range files {
+			if ctx.Err() != nil {
+				ch <- wshutil.RespErr[wshrpc.FileExportProgress](context.Cause(ctx))
+				return
+			}
+			exportFile(ctx, zoneId, file, data, ch)
+		}
+	}()
+	return ch
+}
+
+func exportFile(ctx context.Context, zoneId string, file *filestore.WaveFile, data wshrpc.CommandFileExportData, ch chan wshrpc.RespOrErrorUnion[wshrpc.FileExportProgress]) {
+	event := wps.FileTransferEventData{
+		TransferId: uuid.NewString(),
+		Path:       fmt.Sprintf(wavefileutil.WaveFilePathPattern, zoneId, file.Name),
+		Direction:  TransferDirection_Export,
+		Size:       file.Size,
+	}
+	progress := wshrpc.FileExportProgress{Name: file.Name, Size: file.Size}
+	var lastSendTs time.Time
+	opts := filestore.ExportOpts{
+		Collision: data.Collision,
+		ProgressFn: func(offset, size int64) {
+			event.Offset = offset
+			publishTransferEvent(zoneId, event)
+			if time.Since(lastSendTs) >= transferProgressInterval {
+				lastSendTs = time.Now()
+				progress.Offset = offset
+				ch <- wshrpc.RespOrErrorUnion[wshrpc.FileExportProgress]{Response: progress}
+			}
+		},
+	}
+	localPath, err := filestore.WFS.ExportFile(ctx, zoneId, file.Name, data.Dir, opts)
+	if errors.Is(err, fs.ErrExist) && opts.Collision == filestore.ExportCollision_Skip {
+		progress.Skipped = true
+		err = nil
+	}
+	if err != nil {
+		event.Error = err.Error()
+		progress.Error = err.Error()
+	} else {
+		if !progress.Skipped {
+			event.Offset = file.Size
+			progress.Offset = file.Size
+		}
+		event.Done = true
+		progress.Done = true
+	}
+	progress.LocalPath = localPath
+	publishTransferEvent(zoneId, event)
+	ch <- wshrpc.RespOrErrorUnion[wshrpc.FileExportProgress]{Response: progress}
+}
@@ -0,0 +1,320 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wavefs
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/remote/connparse"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// large files are uploaded in chunks (UploadChunk) into a staging file in the zone of the destination
+// (wavebase.BlockFile_TransferPrefix + the transfer id).  when all the bytes are there the staging file is renamed
+// onto the destination, so an upload that is not finished never replaces the file.  the client makes the transfer
+// id, the same id resumes an upload that was interrupted (TransferStatus has the offset to resume from), and a
+// chunk at another offset is not written.  staging files that are not finished are deleted by the reaper after
+// TransferMaxAge.  downloads are streamed in chunks from an offset (Download), the client resumes from the bytes
+// it has.  the progress of both is published on the event bus (file:transfer, scoped to the block).
+
+const TransferChunkSize = 256 * 1024
+const TransferMaxAge = 7 * 24 * time.Hour
+const transferProgressInterval = 250 * time.Millisecond
+
+const (
+	TransferDirection_Upload   = "upload"
+	TransferDirection_Download = "download"
+	TransferDirection_Export   = "export"
+)
+
+const (
+	transferMeta_Path = "transfer:path"
+	transferMeta_Size = "transfer:size"
+)
+
+var transferIdRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// the offset check and the append of a chunk have to be atomic, the chunks of all the uploads are written one at
+// a time
+var uploadLock = &sync.Mutex{}
+
+var progressLock = &sync.Mutex{}
+var lastProgressTs = make(map[string]time.Time)
+
+// publishes the progress of a transfer, at most every transferProgressInterval (the last event is always sent)
+func publishTransferEvent(zoneId string, data wps.FileTransferEventData) {
+	final := data.Done || data.Error != ""
+	progressLock.Lock()
+	if final {
+		delete(lastProgressTs, data.TransferId)
+	} else {
+		now := time.Now()
+		if now.Sub(lastProgressTs[data.TransferId]) < transferProgressInterval {
+			progressLock.Unlock()
+			return
+		}
+		lastProgressTs[data.TransferId] = now
+	}
+	progressLock.Unlock()
+	eventbus.Publish(eventbus.FileTransferEvent{ZoneId: zoneId, Transfer: data})
+}
+
+// returns the zone id, the file name and the name of the staging file
+func getTransferNames(conn *connparse.Connection, transferId string) (string, string, string, error) {
+	zoneId := conn.Host
+	if zoneId == "" {
+		return "", "", "", fmt.Errorf("zoneid not found in connection")
+	}
+	fileName, err := cleanPath(conn.Path)
+	if err != nil {
+		return "", "", "", fmt.Errorf("error cleaning path: %w", err)
+	}
+	if fileName == "" {
+		return "", "", "", fmt.Errorf("no file name in %s", conn.GetFullURI())
+	}
+	if !transferIdRe.MatchString(transferId) {
+		return "", "", "", fmt.Errorf("invalid transfer id %q", transferId)
+	}
+	return zoneId, fileName, wavebase.BlockFile_TransferPrefix + transferId, nil
+}
+
+func getMetaInt64(meta wshrpc.FileMeta, key string) int64 {
+	switch val := meta[key].(type) {
+	case int64:
+		return val
+	case float64:
+		return int64(val)
+	}
+	return 0
+}
+
+// returns the staging file of an upload (nil if it has not started)
+func getStagingFile(ctx context.Context, zoneId string, stagingName string) (*filestore.WaveFile, error) {
+	file, err := filestore.WFS.Stat(ctx, zoneId, stagingName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting transfer file: %w", err)
+	}
+	return file, nil
+}
+
+// writes a chunk of an upload, the upload is finished (the file is replaced) when all the bytes are written
+func (c WaveClient) UploadChunk(ctx context.Context, conn *connparse.Connection, data wshrpc.CommandFileUploadChunkData) (*wshrpc.FileTransferStatus, error) {
+	zoneId, fileName, stagingName, err := getTransferNames(conn, data.TransferId)
+	if err != nil {
+		return nil, err
+	}
+	if data.Size < 0 {
+		return nil, fmt.Errorf("invalid upload size %d", data.Size)
+	}
+	dataBuf, err := base64.StdEncoding.DecodeString(data.Data64)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding data64: %w", err)
+	}
+	uploadLock.Lock()
+	defer uploadLock.Unlock()
+	file, err := getStagingFile(ctx, zoneId, stagingName)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		meta := wshrpc.FileMeta{transferMeta_Path: fileName, transferMeta_Size: data.Size}
+		err = filestore.WFS.MakeFile(ctx, zoneId, stagingName, meta, wshrpc.FileOpts{})
+		if err != nil {
+			return nil, fmt.Errorf("error making transfer file: %w", err)
+		}
+		file, err = getStagingFile(ctx, zoneId, stagingName)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil {
+			return nil, fmt.Errorf("transfer file %s was deleted", stagingName)
+		}
+	}
+	if file.Meta[transferMeta_Path] != fileName || getMetaInt64(file.Meta, transferMeta_Size) != data.Size {
+		return nil, fmt.Errorf("transfer %s is for another file (%v, %d bytes)", data.TransferId, file.Meta[transferMeta_Path], getMetaInt64(file.Meta, transferMeta_Size))
+	}
+	status := &wshrpc.FileTransferStatus{TransferId: data.TransferId, Offset: file.Size, Size: data.Size}
+	if data.Offset != file.Size {
+		// a chunk that was already written (sent again), or a client that has to resume from status.Offset
+		return status, nil
+	}
+	if file.Size+int64(len(dataBuf)) > data.Size {
+		return nil, fmt.Errorf("chunk at %d is past the end of the upload (%d bytes)", data.Offset, data.Size)
+	}
+	if len(dataBuf) > 0 {
+		err = filestore.WFS.AppendData(ctx, zoneId, stagingName, dataBuf)
+		if err != nil {
+			return nil, fmt.Errorf("error writing to transfer file: %w", err)
+		}
+		status.Offset += int64(len(dataBuf))
+	}
+	event := wps.FileTransferEventData{
+		TransferId: data.TransferId,
+		Path:       conn.GetFullURI(),
+		Direction:  TransferDirection_Upload,
+		Offset:     status.Offset,
+		Size:       data.Size,
+	}
+	if status.Offset < data.Size {
+		publishTransferEvent(zoneId, event)
+		return status, nil
+	}
+	err = finishUpload(ctx, zoneId, fileName, stagingName)
+	if err != nil {
+		event.Error = err.Error()
+		publishTransferEvent(zoneId, event)
+		return nil, err
+	}
+	status.Done = true
+	event.Done = true
+	publishTransferEvent(zoneId, event)
+	return status, nil
+}
+
+func finishUpload(ctx context.Context, zoneId string, fileName string, stagingName string) error {
+	err := filestore.WFS.RenameFile(ctx, zoneId, stagingName, fileName)
+	if err != nil {
+		return fmt.Errorf("error moving transfer file to %s: %w", fileName, err)
+	}
+	err = filestore.WFS.WriteMeta(ctx, zoneId, fileName, wshrpc.FileMeta{transferMeta_Path: nil, transferMeta_Size: nil}, true)
+	if err != nil {
+		return fmt.Errorf("error writing blockfile meta: %w", err)
+	}
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   zoneId,
+		FileName: fileName,
+		FileOp:   wps.FileOp_Invalidate,
+	}})
+	return nil
+}
+
+// the offset to resume an upload from (0 if it has not started, or is finished)
+func (c WaveClient) TransferStatus(ctx context.Context, conn *connparse.Connection, data wshrpc.CommandFileTransferData) (*wshrpc.FileTransferStatus, error) {
+	zoneId, _, stagingName, err := getTransferNames(conn, data.TransferId)
+	if err != nil {
+		return nil, err
+	}
+	file, err := getStagingFile(ctx, zoneId, stagingName)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return &wshrpc.FileTransferStatus{TransferId: data.TransferId}, nil
+	}
+	return &wshrpc.FileTransferStatus{TransferId: data.TransferId, Offset: file.Size, Size: getMetaInt64(file.Meta, transferMeta_Size)}, nil
+}
+
+// deletes the staging file of an upload
+func (c WaveClient) TransferCancel(ctx context.Context, conn *connparse.Connection, data wshrpc.CommandFileTransferData) error {
+	zoneId, _, stagingName, err := getTransferNames(conn, data.TransferId)
+	if err != nil {
+		return err
+	}
+	uploadLock.Lock()
+	defer uploadLock.Unlock()
+	file, err := getStagingFile(ctx, zoneId, stagingName)
+	if err != nil || file == nil {
+		return err
+	}
+	err = filestore.WFS.DeleteFile(ctx, zoneId, stagingName)
+	if err != nil {
+		return fmt.Errorf("error deleting transfer file: %w", err)
+	}
+	publishTransferEvent(zoneId, wps.FileTransferEventData{
+		TransferId: data.TransferId,
+		Path:       conn.GetFullURI(),
+		Direction:  TransferDirection_Upload,
+		Offset:     file.Size,
+		Size:       getMetaInt64(file.Meta, transferMeta_Size),
+		Error:      "canceled",
+	})
+	return nil
+}
+
+// streams the file from data.Offset in chunks of TransferChunkSize (to the size of the file when it starts).  if
+// the front of the file was dropped (a circular file) the stream starts where the data starts.
+func (c WaveClient) Download(ctx context.Context, conn *connparse.Connection, data wshrpc.CommandFileTransferData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileTransferChunk] {
+	transferId := data.TransferId
+	if transferId == "" {
+		transferId = uuid.NewString()
+	}
+	zoneId, fileName, _, err := getTransferNames(conn, transferId)
+	if err != nil {
+		return wshutil.SendErrCh[wshrpc.FileTransferChunk](err)
+	}
+	file, err := filestore.WFS.Stat(ctx, zoneId, fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return wshutil.SendErrCh[wshrpc.FileTransferChunk](fmt.Errorf("NOTFOUND: %w", err))
+	}
+	if err != nil {
+		return wshutil.SendErrCh[wshrpc.FileTransferChunk](fmt.Errorf("error getting blockfile info: %w", err))
+	}
+	if data.Offset < 0 || data.Offset > file.Size {
+		return wshutil.SendErrCh[wshrpc.FileTransferChunk](fmt.Errorf("offset %d is not in the file (%d bytes)", data.Offset, file.Size))
+	}
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.FileTransferChunk], 16)
+	go func() {
+		defer close(ch)
+		event := wps.FileTransferEventData{
+			TransferId: transferId,
+			Path:       conn.GetFullURI(),
+			Direction:  TransferDirection_Download,
+			Offset:     data.Offset,
+			Size:       file.Size,
+		}
+		sendErr := func(err error) {
+			event.Error = err.Error()
+			publishTransferEvent(zoneId, event)
+			ch <- wshutil.RespErr[wshrpc.FileTransferChunk](err)
+		}
+		offset := max(data.Offset, file.DataStartIdx())
+		for offset < file.Size {
+			if ctx.Err() != nil {
+				sendErr(context.Cause(ctx))
+				return
+			}
+			readOffset, dataBuf, err := filestore.WFS.ReadAt(ctx, zoneId, fileName, offset, min(TransferChunkSize, file.Size-offset))
+			if err != nil {
+				sendErr(fmt.Errorf("error reading blockfile: %w", err))
+				return
+			}
+			if readOffset != offset {
+				// the front of the file was dropped since the download started
+				sendErr(fmt.Errorf("the data at %d is no longer in the file (it starts at %d)", offset, readOffset))
+				return
+			}
+			if len(dataBuf) == 0 {
+				// the file was truncated
+				break
+			}
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.FileTransferChunk]{Response: wshrpc.FileTransferChunk{
+				Offset: offset,
+				Size:   file.Size,
+				Data64: base64.StdEncoding.EncodeToString(dataBuf),
+			}}
+			offset += int64(len(dataBuf))
+			event.Offset = offset
+			publishTransferEvent(zoneId, event)
+		}
+		event.Done = true
+		publishTransferEvent(zoneId, event)
+	}()
+	return ch
+}
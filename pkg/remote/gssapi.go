@@ -0,0 +1,284 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcmturner/gofork/encoding/asn1"
+	"github.com/jcmturner/gokrb5/v8/asn1tools"
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana/chksumtype"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/flags"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/jcmturner/gokrb5/v8/types"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"golang.org/x/crypto/ssh"
+)
+
+// gssapi-with-mic auth (ssh:gssapiauthentication, or GSSAPIAuthentication yes in the ssh config) uses the kerberos
+// tickets of the user: the tgt in the credential cache (KRB5CCNAME, which must be a FILE: cache, or
+// /tmp/krb5cc_<uid>) gets a ticket for host/<hostname> from the kdc of krb5.conf (KRB5_CONFIG or /etc/krb5.conf).
+// the ticket is fetched before connecting, so a connection without a usable ticket skips the method (and tries
+// the next one) instead of failing.  only the rfc 4121 enctypes (aes) are supported, and credentials are not
+// delegated.
+//
+// the tgt of a connection that authenticated with gssapi is watched while it is up (see
+// conncontroller/authexpiry.go), the user is asked to renew it (kinit) before it expires, as the connection can
+// not reconnect without it.
+
+const DefaultKrb5ConfigPath = "/etc/krb5.conf"
+
+const (
+	gssapiTokenIdAPReq = 0x0100
+	gssapiChecksumLen  = 16
+)
+
+// *ssh.Client -> the credential cache path, for the connections that authenticated with gssapi
+var gssapiClients = &sync.Map{}
+
+// the path of the credential cache (only FILE: caches can be read)
+func Krb5ccPath() (string, error) {
+	ccName := os.Getenv("KRB5CCNAME")
+	if ccName == "" {
+		if runtime.GOOS == "windows" {
+			return "", fmt.Errorf("no kerberos credential cache (set KRB5CCNAME to a FILE: cache)")
+		}
+		return filepath.Join("/tmp", "krb5cc_"+strconv.Itoa(os.Getuid())), nil
+	}
+	ccType, ccPath, found := strings.Cut(ccName, ":")
+	if !found || (runtime.GOOS == "windows" && len(ccType) == 1) {
+		return ccName, nil
+	}
+	if ccType != "FILE" {
+		return "", fmt.Errorf("unsupported kerberos credential cache type %q (only FILE: caches are supported)", ccType)
+	}
+	return ccPath, nil
+}
+
+func loadKrb5Config() (*config.Config, error) {
+	configPath := DefaultKrb5ConfigPath
+	if envPath := os.Getenv("KRB5_CONFIG"); envPath != "" {
+		configPath = strings.Split(envPath, string(os.PathListSeparator))[0]
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the kerberos config %s: %w", configPath, err)
+	}
+	return cfg, nil
+}
+
+func getTGT(ccache *credentials.CCache) (*credentials.Credential, error) {
+	tgtName := types.PrincipalName{NameType: nametype.KRB_NT_SRV_INST, NameString: []string{"krbtgt", ccache.GetClientRealm()}}
+	tgt, ok := ccache.GetEntry(tgtName)
+	if !ok {
+		return nil, fmt.Errorf("no kerberos ticket for %s (run kinit)", ccache.GetClientPrincipalName().PrincipalNameString())
+	}
+	return tgt, nil
+}
+
+// returns the end time of the tgt in the credential cache at ccPath
+func GetKrb5TicketExpiry(ccPath string) (time.Time, error) {
+	ccache, err := credentials.LoadCCache(ccPath)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading the kerberos credential cache %s: %w", ccPath, err)
+	}
+	tgt, err := getTGT(ccache)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tgt.EndTime, nil
+}
+
+// the credential cache a connection authenticated with (if it authenticated with gssapi)
+func GetGSSAPICCache(client *ssh.Client) (string, bool) {
+	ccPath, ok := gssapiClients.Load(client)
+	if !ok {
+		return "", false
+	}
+	return ccPath.(string), true
+}
+
+func trackGSSAPIClient(client *ssh.Client, ccPath string) {
+	gssapiClients.Store(client, ccPath)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("gssapi:trackGSSAPIClient", recover())
+		}()
+		defer gssapiClients.Delete(client)
+		client.Wait()
+	}()
+}
+
+// a kerberos (rfc 4121) initiator for ssh.GSSAPIWithMICAuthMethod
+type krb5GSSAPIClient struct {
+	ccPath  string
+	cname   types.PrincipalName
+	crealm  string
+	ticket  messages.Ticket
+	key     types.EncryptionKey // the session key, then the subkey of the server (if it sent one)
+	subkey  bool
+	seqNum  int64
+	ctime   time.Time
+	cusec   int
+	micSent bool // the context was established (the server accepted the ap-req)
+}
+
+// gets the service ticket for host/<hostName> from the tgt in the credential cache
+func makeKrb5GSSAPIClient(hostName string) (*krb5GSSAPIClient, error) {
+	ccPath, err := Krb5ccPath()
+	if err != nil {
+		return nil, err
+	}
+	ccache, err := credentials.LoadCCache(ccPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the kerberos credential cache %s: %w", ccPath, err)
+	}
+	tgt, err := getTGT(ccache)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(tgt.EndTime) {
+		return nil, fmt.Errorf("the kerberos ticket of %s expired at %s (run kinit)", ccache.GetClientPrincipalName().PrincipalNameString(), tgt.EndTime.Format(time.RFC3339))
+	}
+	cfg, err := loadKrb5Config()
+	if err != nil {
+		return nil, err
+	}
+	cl, err := client.NewFromCCache(ccache, cfg, client.DisablePAFXFAST(true))
+	if err != nil {
+		return nil, fmt.Errorf("error creating the kerberos client: %w", err)
+	}
+	spn := "host/" + strings.ToLower(hostName)
+	ticket, key, err := cl.GetServiceTicket(spn)
+	if err != nil {
+		return nil, fmt.Errorf("error getting a kerberos ticket for %s: %w", spn, err)
+	}
+	if !isCFXEnctype(key.KeyType) {
+		return nil, fmt.Errorf("unsupported kerberos enctype %d for %s (only aes enctypes are supported)", key.KeyType, spn)
+	}
+	return &krb5GSSAPIClient{
+		ccPath: ccPath,
+		cname:  ccache.GetClientPrincipalName(),
+		crealm: ccache.GetClientRealm(),
+		ticket: ticket,
+		key:    key,
+	}, nil
+}
+
+func isCFXEnctype(keyType int32) bool {
+	switch keyType {
+	case etypeID.AES128_CTS_HMAC_SHA1_96, etypeID.AES256_CTS_HMAC_SHA1_96, etypeID.AES128_CTS_HMAC_SHA256_128, etypeID.AES256_CTS_HMAC_SHA384_192:
+		return true
+	}
+	return false
+}
+
+// the first call returns the ap-req (mutual auth is required), the second one reads the ap-rep of the server
+func (c *krb5GSSAPIClient) InitSecContext(target string, token []byte, isGSSDelegCreds bool) ([]byte, bool, error) {
+	if token == nil {
+		apReq, err := c.makeAPReq()
+		if err != nil {
+			return nil, false, err
+		}
+		return apReq, true, nil
+	}
+	return nil, false, c.readAPRep(token)
+}
+
+func (c *krb5GSSAPIClient) makeAPReq() ([]byte, error) {
+	auth, err := types.NewAuthenticator(c.crealm, c.cname)
+	if err != nil {
+		return nil, err
+	}
+	// rfc 4121 4.1.1, the checksum carries the context flags (no channel bindings)
+	cksum := make([]byte, 4+gssapiChecksumLen+4)
+	binary.LittleEndian.PutUint32(cksum[0:4], gssapiChecksumLen)
+	binary.LittleEndian.PutUint32(cksum[4+gssapiChecksumLen:], uint32(gssapi.ContextFlagMutual|gssapi.ContextFlagInteg))
+	auth.Cksum = types.Checksum{CksumType: chksumtype.GSSAPI, Checksum: cksum}
+	c.seqNum = auth.SeqNumber
+	c.ctime = auth.CTime.Truncate(time.Second) // the authenticator has whole seconds (and cusec)
+	c.cusec = auth.Cusec
+	apReq, err := messages.NewAPReq(c.ticket, c.key, auth)
+	if err != nil {
+		return nil, err
+	}
+	types.SetFlag(&apReq.APOptions, flags.APOptionMutualRequired)
+	apReqBytes, err := apReq.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling the ap-req: %w", err)
+	}
+	oidBytes, err := asn1.Marshal(gssapi.OIDKRB5.OID())
+	if err != nil {
+		return nil, err
+	}
+	tokenBytes := binary.BigEndian.AppendUint16(oidBytes, gssapiTokenIdAPReq)
+	tokenBytes = append(tokenBytes, apReqBytes...)
+	return asn1tools.AddASNAppTag(tokenBytes, 0), nil
+}
+
+func (c *krb5GSSAPIClient) readAPRep(token []byte) error {
+	var krbToken spnego.KRB5Token
+	err := krbToken.Unmarshal(token)
+	if err != nil {
+		return fmt.Errorf("error reading the gssapi token of the server: %w", err)
+	}
+	if krbToken.IsKRBError() {
+		return fmt.Errorf("kerberos error from the server: %s", krbToken.KRBError.Error())
+	}
+	if !krbToken.IsAPRep() {
+		return fmt.Errorf("expected an ap-rep from the server")
+	}
+	encPartBytes, err := crypto.DecryptEncPart(krbToken.APRep.EncPart, c.key, keyusage.AP_REP_ENCPART)
+	if err != nil {
+		return fmt.Errorf("error decrypting the ap-rep: %w", err)
+	}
+	var encPart messages.EncAPRepPart
+	err = encPart.Unmarshal(encPartBytes)
+	if err != nil {
+		return err
+	}
+	// mutual auth, the server echoes the time of the authenticator
+	if !encPart.CTime.Equal(c.ctime) || encPart.Cusec != c.cusec {
+		return fmt.Errorf("the ap-rep of the server does not match the ap-req")
+	}
+	if encPart.Subkey.KeyType != 0 {
+		c.key = encPart.Subkey
+		c.subkey = true
+	}
+	return nil
+}
+
+func (c *krb5GSSAPIClient) GetMIC(micField []byte) ([]byte, error) {
+	token := gssapi.MICToken{SndSeqNum: uint64(c.seqNum), Payload: micField}
+	if c.subkey {
+		token.Flags = gssapi.MICTokenFlagAcceptorSubkey
+	}
+	err := token.SetChecksum(c.key, keyusage.GSSAPI_INITIATOR_SIGN)
+	if err != nil {
+		return nil, fmt.Errorf("error signing the gssapi mic: %w", err)
+	}
+	c.micSent = true
+	return token.Marshal()
+}
+
+func (c *krb5GSSAPIClient) DeleteSecContext() error {
+	return nil
+}
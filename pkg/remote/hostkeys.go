@@ -0,0 +1,340 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/userinput"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+	xknownhosts "golang.org/x/crypto/ssh/knownhosts"
+)
+
+// the host keys of unknown hosts (and of hosts whose key changed) are shown to the user with their fingerprint and
+// randomart, and the connection waits until the user accepts or rejects the key.  the decisions are saved in a
+// known_hosts file that wave manages (and reads along with the ones from the ssh config): an accepted key is a
+// normal line, a rejected one is a @revoked line, so the host is refused without asking again until it is forgotten.
+
+const HostKeyPromptTimeout = 2 * time.Minute
+
+// the art is the "drunken bishop" walk of openssh's VisualHostKey
+const artWidth = 17
+const artHeight = 9
+const artSymbols = " .o+=*BOX@%&#/^SE"
+
+var managedKnownHostsLock = &sync.Mutex{}
+
+func GetManagedKnownHostsFile() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), "known_hosts")
+}
+
+// creates the managed known_hosts file if it does not exist (so it can always be read)
+func ensureManagedKnownHostsFile() (string, error) {
+	fileName := GetManagedKnownHostsFile()
+	managedKnownHostsLock.Lock()
+	defer managedKnownHostsLock.Unlock()
+	f, err := openKnownHostsForEdit(fileName)
+	if err != nil {
+		return "", err
+	}
+	return fileName, f.Close()
+}
+
+func appendManagedKnownHostsLine(line string) error {
+	managedKnownHostsLock.Lock()
+	defer managedKnownHostsLock.Unlock()
+	f, err := openKnownHostsForEdit(GetManagedKnownHostsFile())
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(line + "\n")
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// the size of the key in bits (0 if it is not known)
+func PublicKeyBits(key ssh.PublicKey) int {
+	if cert, ok := key.(*ssh.Certificate); ok {
+		key = cert.Key
+	}
+	cryptoKey, ok := key.(ssh.CryptoPublicKey)
+	if !ok {
+		return 0
+	}
+	switch pubKey := cryptoKey.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return pubKey.N.BitLen()
+	case *ecdsa.PublicKey:
+		return pubKey.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	}
+	return 0
+}
+
+// the key type the way openssh names it in the art (e.g. "ED25519")
+func artKeyType(key ssh.PublicKey) string {
+	keyType := key.Type()
+	suffix := ""
+	if strings.HasSuffix(keyType, "-cert-v01@openssh.com") {
+		keyType = strings.TrimSuffix(keyType, "-cert-v01@openssh.com")
+		suffix = "-CERT"
+	}
+	switch {
+	case keyType == ssh.KeyAlgoED25519:
+		keyType = "ED25519"
+	case keyType == ssh.KeyAlgoSKED25519:
+		keyType = "ED25519-SK"
+	case keyType == ssh.KeyAlgoRSA:
+		keyType = "RSA"
+	case keyType == ssh.KeyAlgoDSA:
+		keyType = "DSA"
+	case keyType == ssh.KeyAlgoSKECDSA256:
+		keyType = "ECDSA-SK"
+	case strings.HasPrefix(keyType, "ecdsa-"):
+		keyType = "ECDSA"
+	default:
+		keyType = strings.ToUpper(keyType)
+	}
+	return keyType + suffix
+}
+
+func artBorder(title string) string {
+	var buf strings.Builder
+	buf.WriteString("+")
+	left := (artWidth - len(title)) / 2
+	buf.WriteString(strings.Repeat("-", left))
+	buf.WriteString(title)
+	buf.WriteString(strings.Repeat("-", artWidth-left-len(title)))
+	buf.WriteString("+")
+	return buf.String()
+}
+
+// the randomart of the key (the same as "ssh-keygen -lv" for its SHA256 fingerprint)
+func HostKeyArt(key ssh.PublicKey) string {
+	digest := sha256.Sum256(key.Marshal())
+	var field [artWidth][artHeight]int
+	maxSymbol := len(artSymbols) - 1
+	x, y := artWidth/2, artHeight/2
+	for _, b := range digest {
+		for i := 0; i < 4; i++ {
+			if b&0x1 != 0 {
+				x++
+			} else {
+				x--
+			}
+			if b&0x2 != 0 {
+				y++
+			} else {
+				y--
+			}
+			x = max(0, min(x, artWidth-1))
+			y = max(0, min(y, artHeight-1))
+			if field[x][y] < maxSymbol-2 {
+				field[x][y]++
+			}
+			b >>= 2
+		}
+	}
+	field[artWidth/2][artHeight/2] = maxSymbol - 1
+	field[x][y] = maxSymbol
+	title := fmt.Sprintf("[%s %d]", artKeyType(key), PublicKeyBits(key))
+	if len(title) >= artWidth {
+		title = fmt.Sprintf("[%s]", artKeyType(key))
+	}
+	lines := []string{artBorder(title)}
+	for row := 0; row < artHeight; row++ {
+		var buf strings.Builder
+		buf.WriteString("|")
+		for col := 0; col < artWidth; col++ {
+			buf.WriteByte(artSymbols[min(field[col][row], maxSymbol)])
+		}
+		buf.WriteString("|")
+		lines = append(lines, buf.String())
+	}
+	lines = append(lines, artBorder("[SHA256]"))
+	return strings.Join(lines, "\n")
+}
+
+func makeHostKeyPrompt(hostname string, remote string, key ssh.PublicKey, knownKeys []xknownhosts.KnownKey) *userinput.HostKeyPrompt {
+	prompt := &userinput.HostKeyPrompt{
+		Host:           hostname,
+		Remote:         remote,
+		KeyType:        key.Type(),
+		Fingerprint:    ssh.FingerprintSHA256(key),
+		Art:            HostKeyArt(key),
+		Changed:        len(knownKeys) > 0,
+		KnownHostsFile: GetManagedKnownHostsFile(),
+	}
+	for _, knownKey := range knownKeys {
+		prompt.KnownKeys = append(prompt.KnownKeys, fmt.Sprintf("%s %s (%s:%d)", knownKey.Key.Type(), ssh.FingerprintSHA256(knownKey.Key), knownKey.Filename, knownKey.Line))
+	}
+	return prompt
+}
+
+func makeHostKeyRequest(prompt *userinput.HostKeyPrompt) *userinput.UserInputRequest {
+	var queryText string
+	title := "Unknown Host Key"
+	if prompt.Changed {
+		title = "Host Key Changed"
+		queryText = fmt.Sprintf(
+			"**WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!**\n\n"+
+				"If this is not expected, someone could be trying to eavesdrop on you (a man-in-the-middle attack), "+
+				"or the host '%s (%s)' may have changed its key. It now provides this %s key:  \n"+
+				"`%s`\n\n```\n%s\n```\n\n"+
+				"The known_hosts files have these keys for the host:  \n- %s\n\n"+
+				"**Do you trust the new key?** If so, it will be added to %s. "+
+				"If you reject it, the key will be refused until you remove it with `wsh conn hostkey rm`.",
+			prompt.Host, prompt.Remote, prompt.KeyType, prompt.Fingerprint, prompt.Art, strings.Join(prompt.KnownKeys, "  \n- "), prompt.KnownHostsFile)
+	} else {
+		queryText = fmt.Sprintf(
+			"The authenticity of host '%s (%s)' can't be established "+
+				"as it **does not exist in any checked known_hosts files**. "+
+				"The host provides this %s key:  \n"+
+				"`%s`\n\n```\n%s\n```\n\n"+
+				"**Would you like to continue connecting?** If so, the key will be added to %s "+
+				"to protect from future man-in-the-middle attacks. "+
+				"If you reject it, the key will be refused until you remove it with `wsh conn hostkey rm`.",
+			prompt.Host, prompt.Remote, prompt.KeyType, prompt.Fingerprint, prompt.Art, prompt.KnownHostsFile)
+	}
+	return &userinput.UserInputRequest{
+		ResponseType: "confirm",
+		QueryText:    queryText,
+		Markdown:     true,
+		Title:        title,
+		OkLabel:      "Accept",
+		CancelLabel:  "Reject",
+		HostKey:      prompt,
+	}
+}
+
+// asks the user to accept or reject the host key (knownKeys are the keys the known_hosts files have for the host
+// if it changed), and saves the decision.  returns a UserInputCancelError if the key is not accepted.
+func verifyHostKey(ctx context.Context, hostname string, remote string, key ssh.PublicKey, knownKeys []xknownhosts.KnownKey) error {
+	request := makeHostKeyRequest(makeHostKeyPrompt(hostname, remote, key, knownKeys))
+	ctx, cancelFn := context.WithTimeout(ctx, HostKeyPromptTimeout)
+	defer cancelFn()
+	resp, err := userinput.GetUserInput(ctx, request)
+	if err != nil {
+		// canceled or timed out, nothing is saved
+		return UserInputCancelError{Err: err}
+	}
+	hostLine := xknownhosts.Line([]string{xknownhosts.Normalize(hostname)}, key)
+	if !resp.Confirm {
+		err = appendManagedKnownHostsLine("@revoked " + hostLine)
+		if err != nil {
+			return fmt.Errorf("unable to save the rejected host key: %w", err)
+		}
+		return UserInputCancelError{Err: fmt.Errorf("host key rejected by the user")}
+	}
+	err = appendManagedKnownHostsLine(hostLine)
+	if err != nil {
+		return fmt.Errorf("unable to save the host key: %w", err)
+	}
+	return nil
+}
+
+// the keys in the managed known_hosts file (the decisions of the user)
+func ListManagedHostKeys() ([]wshrpc.KnownHostKey, error) {
+	managedKnownHostsLock.Lock()
+	defer managedKnownHostsLock.Unlock()
+	data, err := os.ReadFile(GetManagedKnownHostsFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rtn []wshrpc.KnownHostKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		marker, hosts, key, _, _, err := ssh.ParseKnownHosts(scanner.Bytes())
+		if err != nil {
+			// blank lines and comments
+			continue
+		}
+		rtn = append(rtn, wshrpc.KnownHostKey{
+			Hosts:       hosts,
+			KeyType:     key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Revoked:     marker == "revoked",
+			Line:        lineNum,
+		})
+	}
+	return rtn, scanner.Err()
+}
+
+// removes the keys of the host (accepted or rejected) from the managed known_hosts file, so the user is asked again
+// the next time it is connected to.  returns the number of keys removed.
+func ForgetHostKeys(host string) (int, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return 0, fmt.Errorf("no host")
+	}
+	names := []string{host, xknownhosts.Normalize(host)}
+	managedKnownHostsLock.Lock()
+	defer managedKnownHostsLock.Unlock()
+	fileName := GetManagedKnownHostsFile()
+	data, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var kept bytes.Buffer
+	numRemoved := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		_, hosts, _, _, _, err := ssh.ParseKnownHosts(scanner.Bytes())
+		if err == nil && hostsMatch(hosts, names) {
+			numRemoved++
+			continue
+		}
+		kept.Write(scanner.Bytes())
+		kept.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if numRemoved == 0 {
+		return 0, nil
+	}
+	tmpName := fileName + ".tmp"
+	err = os.WriteFile(tmpName, kept.Bytes(), 0644)
+	if err != nil {
+		return 0, err
+	}
+	return numRemoved, os.Rename(tmpName, fileName)
+}
+
+func hostsMatch(hosts []string, names []string) bool {
+	for _, host := range hosts {
+		for _, name := range names {
+			if host == name {
+				return true
+			}
+		}
+	}
+	return false
+}
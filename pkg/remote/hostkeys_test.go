@@ -0,0 +1,77 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func parseTestKey(t *testing.T, authorizedKey string) ssh.PublicKey {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		t.Fatalf("error parsing key: %v", err)
+	}
+	return key
+}
+
+// the expected art is the output of "ssh-keygen -lv" for the keys
+func TestHostKeyArt(t *testing.T) {
+	tests := []struct {
+		key string
+		art []string
+	}{
+		{
+			key: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIL0oglCgwzQEmqbSWBhicSvnCArAO7m4X97YNWTlZ9lc",
+			art: []string{
+				"+--[ED25519 256]--+",
+				"|                 |",
+				"|                 |",
+				"|      .          |",
+				"|   . . .         |",
+				"|  + o   S        |",
+				"|o=o*.o o         |",
+				"|*+X*o.* o        |",
+				"|oXB***oo .       |",
+				"|*=+B=+Eoo.       |",
+				"+----[SHA256]-----+",
+			},
+		},
+		{
+			key: "ecdsa-sha2-nistp384 AAAAE2VjZHNhLXNoYTItbmlzdHAzODQAAAAIbmlzdHAzODQAAABhBJ9Y1ZKySrZUTx9NsfGvefxXIF1evZl3tNLrCUyQ9UCV2STjWM+I29Y7YQ65jNlnASjR4LHaM77U6Ps4RUGfXzqdNAC0keZWwEorxw9EuQ1ZsOUFJ2HrmufD3noJjn+tEA==",
+			art: []string{
+				"+---[ECDSA 384]---+",
+				"| .o    ...       |",
+				"|.. . . .o .      |",
+				"|o   + oo +       |",
+				"|.. ..+o.o .      |",
+				"|.oo+oE..S        |",
+				"| .***   .        |",
+				"|+.oB+o   o       |",
+				"|+=*.o.+ . .      |",
+				"|o*+.o+ .         |",
+				"+----[SHA256]-----+",
+			},
+		},
+	}
+	for _, test := range tests {
+		art := HostKeyArt(parseTestKey(t, test.key))
+		if art != strings.Join(test.art, "\n") {
+			t.Errorf("unexpected art for %s:\n%s", test.key[:12], art)
+		}
+	}
+}
+
+func TestMakeHostKeyRequest(t *testing.T) {
+	key := parseTestKey(t, "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIL0oglCgwzQEmqbSWBhicSvnCArAO7m4X97YNWTlZ9lc")
+	request := makeHostKeyRequest(makeHostKeyPrompt("example.com", "10.0.0.1:22", key, nil))
+	if request.HostKey == nil || request.HostKey.Changed || request.HostKey.Fingerprint != "SHA256:oI+YKU3W8rfkahInsuDW4Gas9bDHPb9hmIrmI23T3vI" {
+		t.Errorf("unexpected host key prompt: %+v", request.HostKey)
+	}
+	if request.ResponseType != "confirm" || !strings.Contains(request.QueryText, request.HostKey.Art) {
+		t.Errorf("the query should be a confirm with the art, got %q", request.QueryText)
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sftpclient
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+)
+
+// the packets of sftp version 3 (draft-ietf-secsh-filexfer-02), the version openssh implements
+
+const protocolVersion = 3
+
+const (
+	fxp_Init     = 1
+	fxp_Version  = 2
+	fxp_Open     = 3
+	fxp_Close    = 4
+	fxp_Read     = 5
+	fxp_Write    = 6
+	fxp_Lstat    = 7
+	fxp_Fstat    = 8
+	fxp_Setstat  = 9
+	fxp_Fsetstat = 10
+	fxp_Opendir  = 11
+	fxp_Readdir  = 12
+	fxp_Remove   = 13
+	fxp_Mkdir    = 14
+	fxp_Rmdir    = 15
+	fxp_Realpath = 16
+	fxp_Stat     = 17
+	fxp_Rename   = 18
+	fxp_Readlink = 19
+	fxp_Status   = 101
+	fxp_Handle   = 102
+	fxp_Data     = 103
+	fxp_Name     = 104
+	fxp_Attrs    = 105
+)
+
+const (
+	fx_Ok               = 0
+	fx_EOF              = 1
+	fx_NoSuchFile       = 2
+	fx_PermissionDenied = 3
+	fx_Failure          = 4
+	fx_BadMessage       = 5
+	fx_NoConnection     = 6
+	fx_ConnectionLost   = 7
+	fx_OpUnsupported    = 8
+)
+
+const (
+	fxf_Read   = 0x01
+	fxf_Write  = 0x02
+	fxf_Append = 0x04
+	fxf_Creat  = 0x08
+	fxf_Trunc  = 0x10
+	fxf_Excl   = 0x20
+)
+
+const (
+	attr_Size        = 0x01
+	attr_UidGid      = 0x02
+	attr_Permissions = 0x04
+	attr_ACModTime   = 0x08
+	attr_Extended    = 0x80000000
+)
+
+// the unix file type bits of the permissions attribute
+const (
+	s_IFMT   = 0170000
+	s_IFSOCK = 0140000
+	s_IFLNK  = 0120000
+	s_IFREG  = 0100000
+	s_IFBLK  = 0060000
+	s_IFDIR  = 0040000
+	s_IFCHR  = 0020000
+	s_IFIFO  = 0010000
+	s_ISUID  = 04000
+	s_ISGID  = 02000
+	s_ISVTX  = 01000
+)
+
+// servers must accept packets up to 34000 bytes, larger ones are refused
+const maxPacketSize = 256 * 1024
+
+type StatusError struct {
+	Code uint32
+	Msg  string
+}
+
+func (e *StatusError) Error() string {
+	if e.Msg != "" {
+		return fmt.Sprintf("sftp: %s (code %d)", e.Msg, e.Code)
+	}
+	return fmt.Sprintf("sftp: error code %d", e.Code)
+}
+
+// so errors.Is(err, fs.ErrNotExist) works for sftp errors
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return e.Code == fx_NoSuchFile
+	case fs.ErrPermission:
+		return e.Code == fx_PermissionDenied
+	case io.EOF:
+		return e.Code == fx_EOF
+	}
+	return false
+}
+
+// the attributes of a file.  only the fields of the set flags are valid.
+type Attrs struct {
+	Flags uint32
+	Size  uint64
+	Uid   uint32
+	Gid   uint32
+	Perms uint32
+	Atime uint32
+	Mtime uint32
+}
+
+func (a *Attrs) FileMode() os.FileMode {
+	return toFileMode(a.Perms)
+}
+
+func (a *Attrs) ModTime() time.Time {
+	return time.Unix(int64(a.Mtime), 0)
+}
+
+func toFileMode(perms uint32) os.FileMode {
+	mode := os.FileMode(perms & 0777)
+	switch perms & s_IFMT {
+	case s_IFDIR:
+		mode |= os.ModeDir
+	case s_IFLNK:
+		mode |= os.ModeSymlink
+	case s_IFSOCK:
+		mode |= os.ModeSocket
+	case s_IFIFO:
+		mode |= os.ModeNamedPipe
+	case s_IFBLK:
+		mode |= os.ModeDevice
+	case s_IFCHR:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	}
+	if perms&s_ISUID != 0 {
+		mode |= os.ModeSetuid
+	}
+	if perms&s_ISGID != 0 {
+		mode |= os.ModeSetgid
+	}
+	if perms&s_ISVTX != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+func fromFileMode(mode os.FileMode) uint32 {
+	perms := uint32(mode.Perm())
+	switch {
+	case mode&os.ModeDir != 0:
+		perms |= s_IFDIR
+	case mode&os.ModeSymlink != 0:
+		perms |= s_IFLNK
+	case mode&os.ModeSocket != 0:
+		perms |= s_IFSOCK
+	case mode&os.ModeNamedPipe != 0:
+		perms |= s_IFIFO
+	case mode&os.ModeCharDevice != 0:
+		perms |= s_IFCHR
+	case mode&os.ModeDevice != 0:
+		perms |= s_IFBLK
+	}
+	if mode&os.ModeSetuid != 0 {
+		perms |= s_ISUID
+	}
+	if mode&os.ModeSetgid != 0 {
+		perms |= s_ISGID
+	}
+	if mode&os.ModeSticky != 0 {
+		perms |= s_ISVTX
+	}
+	return perms
+}
+
+// implements fs.FileInfo, Sys() returns the *Attrs
+type fileInfo struct {
+	name  string
+	attrs *Attrs
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return int64(fi.attrs.Size) }
+func (fi *fileInfo) Mode() os.FileMode  { return fi.attrs.FileMode() }
+func (fi *fileInfo) ModTime() time.Time { return fi.attrs.ModTime() }
+func (fi *fileInfo) IsDir() bool        { return fi.Mode().IsDir() }
+func (fi *fileInfo) Sys() any           { return fi.attrs }
+
+func makeFileInfo(name string, attrs *Attrs) fs.FileInfo {
+	return &fileInfo{name: path.Base(name), attrs: attrs}
+}
+
+type packetBuilder struct {
+	buf []byte
+}
+
+func (b *packetBuilder) uint32(v uint32) *packetBuilder {
+	b.buf = binary.BigEndian.AppendUint32(b.buf, v)
+	return b
+}
+
+func (b *packetBuilder) uint64(v uint64) *packetBuilder {
+	b.buf = binary.BigEndian.AppendUint64(b.buf, v)
+	return b
+}
+
+func (b *packetBuilder) string(s string) *packetBuilder {
+	b.uint32(uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	return b
+}
+
+func (b *packetBuilder) bytes(data []byte) *packetBuilder {
+	b.uint32(uint32(len(data)))
+	b.buf = append(b.buf, data...)
+	return b
+}
+
+func (b *packetBuilder) attrs(a *Attrs) *packetBuilder {
+	flags := a.Flags &^ attr_Extended
+	b.uint32(flags)
+	if flags&attr_Size != 0 {
+		b.uint64(a.Size)
+	}
+	if flags&attr_UidGid != 0 {
+		b.uint32(a.Uid).uint32(a.Gid)
+	}
+	if flags&attr_Permissions != 0 {
+		b.uint32(a.Perms)
+	}
+	if flags&attr_ACModTime != 0 {
+		b.uint32(a.Atime).uint32(a.Mtime)
+	}
+	return b
+}
+
+// reads the fields of a packet in order, the first error sticks (and the later reads return zero values)
+type packetReader struct {
+	data []byte
+	err  error
+}
+
+func (r *packetReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("sftp: short packet")
+	}
+	r.data = nil
+}
+
+func (r *packetReader) uint32() uint32 {
+	if len(r.data) < 4 {
+		r.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *packetReader) uint64() uint64 {
+	if len(r.data) < 8 {
+		r.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return v
+}
+
+func (r *packetReader) bytes() []byte {
+	n := r.uint32()
+	if r.err != nil {
+		return nil
+	}
+	if uint32(len(r.data)) < n {
+		r.fail()
+		return nil
+	}
+	v := r.data[:n]
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *packetReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *packetReader) attrs() *Attrs {
+	a := &Attrs{Flags: r.uint32()}
+	if a.Flags&attr_Size != 0 {
+		a.Size = r.uint64()
+	}
+	if a.Flags&attr_UidGid != 0 {
+		a.Uid = r.uint32()
+		a.Gid = r.uint32()
+	}
+	if a.Flags&attr_Permissions != 0 {
+		a.Perms = r.uint32()
+	}
+	if a.Flags&attr_ACModTime != 0 {
+		a.Atime = r.uint32()
+		a.Mtime = r.uint32()
+	}
+	if a.Flags&attr_Extended != 0 {
+		count := r.uint32()
+		for i := uint32(0); i < count && r.err == nil; i++ {
+			r.string()
+			r.string()
+		}
+	}
+	return a
+}
+
+func writePacket(w io.Writer, pktType byte, payload []byte) error {
+	buf := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)+1))
+	buf[4] = pktType
+	_, err := w.Write(append(buf, payload...))
+	return err
+}
+
+func readPacket(r io.Reader) (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > maxPacketSize {
+		return 0, nil, fmt.Errorf("sftp: invalid packet length %d", length)
+	}
+	data := make([]byte, length-1)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	return header[4], data, nil
+}
+
+// the error of a status response (nil for fx_Ok)
+func statusError(data []byte) error {
+	r := &packetReader{data: data}
+	code := r.uint32()
+	msg := r.string()
+	if r.err != nil {
+		// the message is missing in some old servers
+		msg = ""
+	}
+	if code == fx_Ok {
+		return nil
+	}
+	return &StatusError{Code: code, Msg: msg}
+}
+
+func unexpectedPacket(pktType byte) error {
+	return fmt.Errorf("sftp: unexpected response packet type %d", pktType)
+}
@@ -0,0 +1,716 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// a minimal sftp (version 3) client over the "sftp" subsystem of an ssh connection.  requests are pipelined, the
+// responses are dispatched to the waiting requests by id.
+package sftpclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"golang.org/x/crypto/ssh"
+)
+
+// the data of a read or write request, and how many of them are sent before waiting for the responses
+const (
+	ChunkSize   = 32 * 1024
+	MaxInFlight = 64
+)
+
+var ErrClosed = errors.New("sftp: client closed")
+
+type response struct {
+	pktType byte
+	data    []byte
+}
+
+type Client struct {
+	closeFn   func() error
+	stdin     io.WriteCloser
+	writeLock *sync.Mutex
+	lock      *sync.Mutex
+	nextId    uint32
+	pending   map[uint32]chan response
+	err       error // set when the session ended, the pending requests fail with it
+	homeDir   string
+}
+
+func NewClient(sshClient *ssh.Client) (*Client, error) {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("opening sftp session: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("starting sftp subsystem: %w", err)
+	}
+	c, err := newClient(stdin, stdout, session.Close)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// runs an sftp server with a command instead of the subsystem (e.g. with sudo), stdinPrefix is written to its stdin
+// before the sftp packets (e.g. the password of sudo -S)
+func NewClientWithCommand(sshClient *ssh.Client, cmdStr string, stdinPrefix []byte) (*Client, error) {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("opening sftp session: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	stderr := &limitedBuffer{max: 4096}
+	session.Stderr = stderr
+	if err := session.Start(cmdStr); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("starting sftp server: %w", err)
+	}
+	if _, err := stdin.Write(stdinPrefix); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("starting sftp server: %w", err)
+	}
+	c, err := newClient(stdin, stdout, session.Close)
+	if err != nil {
+		session.Close()
+		if msg := stderr.String(); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// keeps the first max bytes written
+type limitedBuffer struct {
+	lock sync.Mutex
+	buf  []byte
+	max  int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if room := b.max - len(b.buf); room > 0 {
+		b.buf = append(b.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
+
+// sends the init packet and starts reading the responses, closeFn ends the session
+func newClient(stdin io.WriteCloser, stdout io.Reader, closeFn func() error) (*Client, error) {
+	if err := writePacket(stdin, fxp_Init, (&packetBuilder{}).uint32(protocolVersion).buf); err != nil {
+		return nil, fmt.Errorf("sending sftp init: %w", err)
+	}
+	pktType, data, err := readPacket(stdout)
+	if err != nil {
+		return nil, fmt.Errorf("reading sftp version: %w", err)
+	}
+	if pktType != fxp_Version {
+		return nil, unexpectedPacket(pktType)
+	}
+	r := &packetReader{data: data}
+	version := r.uint32()
+	if r.err != nil || version < protocolVersion {
+		return nil, fmt.Errorf("sftp: unsupported server version %d", version)
+	}
+	c := &Client{
+		closeFn:   closeFn,
+		stdin:     stdin,
+		writeLock: &sync.Mutex{},
+		lock:      &sync.Mutex{},
+		pending:   make(map[uint32]chan response),
+	}
+	go c.recvLoop(stdout)
+	return c, nil
+}
+
+func (c *Client) recvLoop(stdout io.Reader) {
+	defer func() {
+		panichandler.PanicHandler("sftpclient:recvLoop", recover())
+	}()
+	for {
+		pktType, data, err := readPacket(stdout)
+		if err != nil {
+			c.fail(fmt.Errorf("sftp: session ended: %w", err))
+			return
+		}
+		if len(data) < 4 {
+			c.fail(fmt.Errorf("sftp: short packet"))
+			return
+		}
+		id := binary.BigEndian.Uint32(data)
+		c.lock.Lock()
+		ch := c.pending[id]
+		delete(c.pending, id)
+		c.lock.Unlock()
+		if ch != nil {
+			ch <- response{pktType: pktType, data: data[4:]}
+		}
+	}
+}
+
+func (c *Client) fail(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+func (c *Client) Close() error {
+	c.fail(ErrClosed)
+	return c.closeFn()
+}
+
+// true once the session ended (closed, or the ssh connection dropped)
+func (c *Client) IsClosed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.err != nil
+}
+
+func (c *Client) closedErr() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err == nil {
+		return ErrClosed
+	}
+	return c.err
+}
+
+// sends a request, the response is read from the returned channel with wait
+func (c *Client) send(pktType byte, payload []byte) (<-chan response, error) {
+	c.lock.Lock()
+	if c.err != nil {
+		c.lock.Unlock()
+		return nil, c.err
+	}
+	c.nextId++
+	id := c.nextId
+	ch := make(chan response, 1)
+	c.pending[id] = ch
+	c.lock.Unlock()
+	buf := make([]byte, 9, 9+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)+5))
+	buf[4] = pktType
+	binary.BigEndian.PutUint32(buf[5:], id)
+	c.writeLock.Lock()
+	_, err := c.stdin.Write(append(buf, payload...))
+	c.writeLock.Unlock()
+	if err != nil {
+		c.fail(fmt.Errorf("sftp: session ended: %w", err))
+		return nil, c.closedErr()
+	}
+	return ch, nil
+}
+
+func (c *Client) wait(ch <-chan response) (response, error) {
+	resp, ok := <-ch
+	if !ok {
+		return response{}, c.closedErr()
+	}
+	return resp, nil
+}
+
+func (c *Client) request(pktType byte, payload []byte) (response, error) {
+	ch, err := c.send(pktType, payload)
+	if err != nil {
+		return response{}, err
+	}
+	return c.wait(ch)
+}
+
+// for the requests answered with a status
+func (c *Client) requestStatus(pktType byte, payload []byte) error {
+	resp, err := c.request(pktType, payload)
+	if err != nil {
+		return err
+	}
+	if resp.pktType != fxp_Status {
+		return unexpectedPacket(resp.pktType)
+	}
+	return statusError(resp.data)
+}
+
+// a status response is always an error here (an "ok" status is not the expected answer)
+func responseError(resp response) error {
+	if resp.pktType != fxp_Status {
+		return unexpectedPacket(resp.pktType)
+	}
+	if err := statusError(resp.data); err != nil {
+		return err
+	}
+	return unexpectedPacket(resp.pktType)
+}
+
+func (c *Client) requestHandle(pktType byte, payload []byte) (string, error) {
+	resp, err := c.request(pktType, payload)
+	if err != nil {
+		return "", err
+	}
+	if resp.pktType != fxp_Handle {
+		return "", responseError(resp)
+	}
+	r := &packetReader{data: resp.data}
+	handle := r.string()
+	return handle, r.err
+}
+
+func (c *Client) requestAttrs(pktType byte, payload []byte) (*Attrs, error) {
+	resp, err := c.request(pktType, payload)
+	if err != nil {
+		return nil, err
+	}
+	if resp.pktType != fxp_Attrs {
+		return nil, responseError(resp)
+	}
+	r := &packetReader{data: resp.data}
+	attrs := r.attrs()
+	return attrs, r.err
+}
+
+// for the requests answered with a single name (realpath, readlink)
+func (c *Client) requestName(pktType byte, p string) (string, error) {
+	resp, err := c.request(pktType, (&packetBuilder{}).string(p).buf)
+	if err != nil {
+		return "", err
+	}
+	if resp.pktType != fxp_Name {
+		return "", responseError(resp)
+	}
+	r := &packetReader{data: resp.data}
+	if count := r.uint32(); count != 1 && r.err == nil {
+		return "", fmt.Errorf("sftp: expected one name, got %d", count)
+	}
+	name := r.string()
+	return name, r.err
+}
+
+func (c *Client) closeHandle(handle string) error {
+	return c.requestStatus(fxp_Close, (&packetBuilder{}).string(handle).buf)
+}
+
+func (c *Client) Stat(p string) (fs.FileInfo, error) {
+	attrs, err := c.requestAttrs(fxp_Stat, (&packetBuilder{}).string(p).buf)
+	if err != nil {
+		return nil, err
+	}
+	return makeFileInfo(p, attrs), nil
+}
+
+func (c *Client) Lstat(p string) (fs.FileInfo, error) {
+	attrs, err := c.requestAttrs(fxp_Lstat, (&packetBuilder{}).string(p).buf)
+	if err != nil {
+		return nil, err
+	}
+	return makeFileInfo(p, attrs), nil
+}
+
+// the entries of a directory, without "." and ".."
+func (c *Client) ReadDir(p string) ([]fs.FileInfo, error) {
+	handle, err := c.requestHandle(fxp_Opendir, (&packetBuilder{}).string(p).buf)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeHandle(handle)
+	var entries []fs.FileInfo
+	for {
+		resp, err := c.request(fxp_Readdir, (&packetBuilder{}).string(handle).buf)
+		if err != nil {
+			return nil, err
+		}
+		if resp.pktType != fxp_Name {
+			err := responseError(resp)
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, err
+		}
+		r := &packetReader{data: resp.data}
+		count := r.uint32()
+		for i := uint32(0); i < count && r.err == nil; i++ {
+			name := r.string()
+			r.string() // the "ls -l" line
+			attrs := r.attrs()
+			if name == "." || name == ".." {
+				continue
+			}
+			entries = append(entries, makeFileInfo(name, attrs))
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+}
+
+// the absolute path of p ("." is the home directory)
+func (c *Client) RealPath(p string) (string, error) {
+	return c.requestName(fxp_Realpath, p)
+}
+
+// the directory the relative paths are resolved from (the home directory of the user)
+func (c *Client) HomeDir() (string, error) {
+	c.lock.Lock()
+	homeDir := c.homeDir
+	c.lock.Unlock()
+	if homeDir != "" {
+		return homeDir, nil
+	}
+	homeDir, err := c.RealPath(".")
+	if err != nil {
+		return "", err
+	}
+	c.lock.Lock()
+	c.homeDir = homeDir
+	c.lock.Unlock()
+	return homeDir, nil
+}
+
+func (c *Client) ReadLink(p string) (string, error) {
+	return c.requestName(fxp_Readlink, p)
+}
+
+func (c *Client) Mkdir(p string, perm os.FileMode) error {
+	attrs := &Attrs{Flags: attr_Permissions, Perms: uint32(perm.Perm())}
+	return c.requestStatus(fxp_Mkdir, (&packetBuilder{}).string(p).attrs(attrs).buf)
+}
+
+func (c *Client) MkdirAll(p string, perm os.FileMode) error {
+	info, err := c.Stat(p)
+	if err == nil {
+		if info.IsDir() {
+			return nil
+		}
+		return fmt.Errorf("%s exists and is not a directory", p)
+	}
+	if parent := path.Dir(p); parent != p && parent != "." && parent != "/" {
+		if err := c.MkdirAll(parent, perm); err != nil {
+			return err
+		}
+	}
+	err = c.Mkdir(p, perm)
+	if err != nil {
+		// created in the meantime
+		if info, statErr := c.Stat(p); statErr == nil && info.IsDir() {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *Client) Remove(p string) error {
+	return c.requestStatus(fxp_Remove, (&packetBuilder{}).string(p).buf)
+}
+
+func (c *Client) RemoveDirectory(p string) error {
+	return c.requestStatus(fxp_Rmdir, (&packetBuilder{}).string(p).buf)
+}
+
+// removes p and what it contains, a missing p is not an error
+func (c *Client) RemoveAll(p string) error {
+	info, err := c.Lstat(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return c.Remove(p)
+	}
+	entries, err := c.ReadDir(p)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := c.RemoveAll(path.Join(p, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return c.RemoveDirectory(p)
+}
+
+// fails if newPath exists (sftp version 3 has no overwriting rename)
+func (c *Client) Rename(oldPath string, newPath string) error {
+	return c.requestStatus(fxp_Rename, (&packetBuilder{}).string(oldPath).string(newPath).buf)
+}
+
+func (c *Client) Chmod(p string, mode os.FileMode) error {
+	attrs := &Attrs{Flags: attr_Permissions, Perms: fromFileMode(mode) &^ s_IFMT}
+	return c.requestStatus(fxp_Setstat, (&packetBuilder{}).string(p).attrs(attrs).buf)
+}
+
+func (c *Client) Chtimes(p string, atime time.Time, mtime time.Time) error {
+	attrs := &Attrs{Flags: attr_ACModTime, Atime: uint32(atime.Unix()), Mtime: uint32(mtime.Unix())}
+	return c.requestStatus(fxp_Setstat, (&packetBuilder{}).string(p).attrs(attrs).buf)
+}
+
+func toOpenFlags(flag int) uint32 {
+	var pflags uint32
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_WRONLY:
+		pflags = fxf_Write
+	case os.O_RDWR:
+		pflags = fxf_Read | fxf_Write
+	default:
+		pflags = fxf_Read
+	}
+	if flag&os.O_APPEND != 0 {
+		pflags |= fxf_Append
+	}
+	if flag&os.O_CREATE != 0 {
+		pflags |= fxf_Creat
+	}
+	if flag&os.O_TRUNC != 0 {
+		pflags |= fxf_Trunc
+	}
+	if flag&os.O_EXCL != 0 {
+		pflags |= fxf_Excl
+	}
+	return pflags
+}
+
+// like os.OpenFile, perm is used when the file is created
+func (c *Client) OpenFile(p string, flag int, perm os.FileMode) (*File, error) {
+	attrs := &Attrs{}
+	if flag&os.O_CREATE != 0 {
+		attrs = &Attrs{Flags: attr_Permissions, Perms: uint32(perm.Perm())}
+	}
+	payload := (&packetBuilder{}).string(p).uint32(toOpenFlags(flag)).attrs(attrs).buf
+	handle, err := c.requestHandle(fxp_Open, payload)
+	if err != nil {
+		return nil, err
+	}
+	return &File{c: c, name: p, handle: handle}, nil
+}
+
+func (c *Client) Open(p string) (*File, error) {
+	return c.OpenFile(p, os.O_RDONLY, 0)
+}
+
+func (c *Client) Create(p string) (*File, error) {
+	return c.OpenFile(p, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+}
+
+// an open remote file.  Read, Write and Seek share an offset and must not be called concurrently, ReadAt and WriteAt
+// can be.
+type File struct {
+	c      *Client
+	name   string
+	handle string
+	offset int64
+}
+
+func (f *File) Name() string {
+	return f.name
+}
+
+func (f *File) Stat() (fs.FileInfo, error) {
+	attrs, err := f.c.requestAttrs(fxp_Fstat, (&packetBuilder{}).string(f.handle).buf)
+	if err != nil {
+		return nil, err
+	}
+	return makeFileInfo(f.name, attrs), nil
+}
+
+func (f *File) Chmod(mode os.FileMode) error {
+	attrs := &Attrs{Flags: attr_Permissions, Perms: fromFileMode(mode) &^ s_IFMT}
+	return f.c.requestStatus(fxp_Fsetstat, (&packetBuilder{}).string(f.handle).attrs(attrs).buf)
+}
+
+func (f *File) Close() error {
+	return f.c.closeHandle(f.handle)
+}
+
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	var total int
+	for total < len(p) {
+		n, err := f.readChunks(p[total:], off+int64(total))
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			return total, io.ErrNoProgress
+		}
+	}
+	return total, nil
+}
+
+// sends the read requests for p (at most MaxInFlight of them) and returns how many contiguous bytes were read from
+// off.  a short read ends the run, the rest is requested again.
+func (f *File) readChunks(p []byte, off int64) (int, error) {
+	var chans []<-chan response
+	for start := 0; start < len(p) && len(chans) < MaxInFlight; start += ChunkSize {
+		size := min(ChunkSize, len(p)-start)
+		payload := (&packetBuilder{}).string(f.handle).uint64(uint64(off) + uint64(start)).uint32(uint32(size)).buf
+		ch, err := f.c.send(fxp_Read, payload)
+		if err != nil {
+			if len(chans) == 0 {
+				return 0, err
+			}
+			break
+		}
+		chans = append(chans, ch)
+	}
+	var total int
+	var rtnErr error
+	done := false
+	for i, ch := range chans {
+		resp, err := f.c.wait(ch)
+		if done {
+			// the responses after a short read or an error are dropped
+			continue
+		}
+		if err != nil {
+			rtnErr, done = err, true
+			continue
+		}
+		if resp.pktType != fxp_Data {
+			rtnErr, done = responseError(resp), true
+			if errors.Is(rtnErr, io.EOF) {
+				rtnErr = io.EOF
+			}
+			continue
+		}
+		r := &packetReader{data: resp.data}
+		data := r.bytes()
+		if r.err != nil {
+			rtnErr, done = r.err, true
+			continue
+		}
+		size := min(ChunkSize, len(p)-i*ChunkSize)
+		n := copy(p[total:total+size], data)
+		total += n
+		if n < size {
+			done = true
+		}
+	}
+	return total, rtnErr
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	var total int
+	for total < len(p) {
+		n, err := f.writeChunks(p[total:], off+int64(total))
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// sends the write requests for p (at most MaxInFlight of them), returns how many bytes were written from off
+func (f *File) writeChunks(p []byte, off int64) (int, error) {
+	var chans []<-chan response
+	var sizes []int
+	var sendErr error
+	for start := 0; start < len(p) && len(chans) < MaxInFlight; start += ChunkSize {
+		size := min(ChunkSize, len(p)-start)
+		payload := (&packetBuilder{}).string(f.handle).uint64(uint64(off) + uint64(start)).bytes(p[start : start+size]).buf
+		ch, err := f.c.send(fxp_Write, payload)
+		if err != nil {
+			sendErr = err
+			break
+		}
+		chans = append(chans, ch)
+		sizes = append(sizes, size)
+	}
+	var total int
+	var rtnErr error
+	for i, ch := range chans {
+		resp, err := f.c.wait(ch)
+		if rtnErr != nil {
+			continue
+		}
+		if err == nil && resp.pktType != fxp_Status {
+			err = unexpectedPacket(resp.pktType)
+		} else if err == nil {
+			err = statusError(resp.data)
+		}
+		if err != nil {
+			rtnErr = err
+			continue
+		}
+		total += sizes[i]
+	}
+	if rtnErr == nil {
+		rtnErr = sendErr
+	}
+	return total, rtnErr
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	n, err := f.WriteAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		info, err := f.Stat()
+		if err != nil {
+			return f.offset, err
+		}
+		offset += info.Size()
+	default:
+		return f.offset, fmt.Errorf("sftp: invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return f.offset, fmt.Errorf("sftp: negative offset")
+	}
+	f.offset = offset
+	return offset, nil
+}
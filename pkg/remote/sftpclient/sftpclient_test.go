@@ -0,0 +1,283 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sftpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAttrsRoundTrip(t *testing.T) {
+	attrs := &Attrs{Flags: attr_Size | attr_UidGid | attr_Permissions | attr_ACModTime, Size: 1 << 40, Uid: 1000, Gid: 100, Perms: s_IFREG | 0640, Atime: 1700000000, Mtime: 1700000001}
+	r := &packetReader{data: (&packetBuilder{}).attrs(attrs).string("rest").buf}
+	decoded := r.attrs()
+	if r.err != nil || *decoded != *attrs {
+		t.Fatalf("expected %+v, got %+v (%v)", attrs, decoded, r.err)
+	}
+	if rest := r.string(); rest != "rest" {
+		t.Errorf("expected the fields after the attributes to be read, got %q", rest)
+	}
+	short := &packetReader{data: []byte{0, 0, 0, attr_Size, 0, 0}}
+	short.attrs()
+	if short.err == nil {
+		t.Errorf("expected an error for a short packet")
+	}
+}
+
+func TestFileMode(t *testing.T) {
+	modes := []os.FileMode{0644, os.ModeDir | 0755, os.ModeSymlink | 0777, os.ModeSetuid | 0755, os.ModeDevice | os.ModeCharDevice | 0600, os.ModeNamedPipe | 0600}
+	for _, mode := range modes {
+		if rtn := toFileMode(fromFileMode(mode)); rtn != mode {
+			t.Errorf("expected %v, got %v", mode, rtn)
+		}
+	}
+	if mode := toFileMode(s_IFDIR | 0700); !mode.IsDir() || mode.Perm() != 0700 {
+		t.Errorf("unexpected directory mode %v", mode)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	err := statusError((&packetBuilder{}).uint32(fx_NoSuchFile).string("no such file").string("en").buf)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+	if err := statusError((&packetBuilder{}).uint32(fx_Ok).buf); err != nil {
+		t.Errorf("expected no error for an ok status, got %v", err)
+	}
+}
+
+// the transfers are the same between any two sides, they are tested from a local directory to another
+func TestTransferResume(t *testing.T) {
+	srcDir := t.TempDir()
+	destDir := t.TempDir()
+	data := bytes.Repeat([]byte("0123456789"), 100000)
+	srcFile := filepath.Join(srcDir, "data.bin")
+	if err := os.WriteFile(srcFile, data, 0640); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(srcFile, modTime, modTime)
+	// a partial copy to continue
+	destFile := filepath.Join(destDir, "data.bin")
+	if err := os.WriteFile(destFile, data[:300000], 0600); err != nil {
+		t.Fatal(err)
+	}
+	var last Progress
+	opts := TransferOpts{Resume: true, Preserve: true}
+	err := transfer(context.Background(), localFs{}, localFs{}, srcFile, destDir, opts, func(p Progress) { last = p })
+	if err != nil {
+		t.Fatalf("transfer error: %v", err)
+	}
+	copied, _ := os.ReadFile(destFile)
+	if !bytes.Equal(copied, data) {
+		t.Fatalf("the resumed copy does not match the source (%d bytes)", len(copied))
+	}
+	if !last.FileDone || last.ResumedFrom != 300000 || last.BytesDone != int64(len(data)) || last.FilesDone != 1 {
+		t.Errorf("unexpected progress %+v", last)
+	}
+	info, _ := os.Stat(destFile)
+	if info.Mode().Perm() != 0640 || !info.ModTime().Equal(modTime) {
+		t.Errorf("expected mode 0640 and mtime %v, got %v and %v", modTime, info.Mode().Perm(), info.ModTime())
+	}
+	// complete now, it is skipped
+	err = transfer(context.Background(), localFs{}, localFs{}, srcFile, destFile, opts, func(p Progress) { last = p })
+	if err != nil || !last.Skipped {
+		t.Errorf("expected the complete file to be skipped (%+v, %v)", last, err)
+	}
+}
+
+func TestTransferVerify(t *testing.T) {
+	srcDir := t.TempDir()
+	destDir := t.TempDir()
+	data := bytes.Repeat([]byte("abcdefghij"), 50000)
+	srcFile := filepath.Join(srcDir, "data.bin")
+	if err := os.WriteFile(srcFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// a partial copy that is not the start of the source, the resumed file does not match and is copied again
+	destFile := filepath.Join(destDir, "data.bin")
+	if err := os.WriteFile(destFile, bytes.Repeat([]byte("x"), 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var last Progress
+	opts := TransferOpts{Resume: true, Verify: true}
+	err := transfer(context.Background(), localFs{}, localFs{}, srcFile, destFile, opts, func(p Progress) { last = p })
+	if err != nil {
+		t.Fatalf("transfer error: %v", err)
+	}
+	copied, _ := os.ReadFile(destFile)
+	if !bytes.Equal(copied, data) {
+		t.Fatalf("the copy does not match the source (%d bytes)", len(copied))
+	}
+	sum := sha256.Sum256(data)
+	if last.Checksum != hex.EncodeToString(sum[:]) || last.ResumedFrom != 0 || last.BytesDone != int64(len(data)) {
+		t.Errorf("unexpected progress %+v", last)
+	}
+	// the destination is changed after the copy
+	os.WriteFile(destFile, []byte("changed"), 0644)
+	state := &transferState{src: localFs{}, dest: localFs{}, opts: TransferOpts{Verify: true}}
+	_, err = state.verify(transferItem{srcPath: srcFile, destPath: destFile})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestTransferDir(t *testing.T) {
+	srcDir := t.TempDir()
+	destDir := t.TempDir()
+	os.MkdirAll(filepath.Join(srcDir, "proj", "sub"), 0755)
+	os.WriteFile(filepath.Join(srcDir, "proj", "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "proj", "sub", "b.txt"), []byte("bb"), 0755)
+	err := transfer(context.Background(), localFs{}, localFs{}, filepath.Join(srcDir, "proj"), destDir, TransferOpts{}, nil)
+	if err == nil {
+		t.Errorf("expected an error for a directory without the recursive flag")
+	}
+	err = transfer(context.Background(), localFs{}, localFs{}, filepath.Join(srcDir, "proj"), destDir, TransferOpts{Recursive: true}, nil)
+	if err != nil {
+		t.Fatalf("transfer error: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(destDir, "proj", "sub", "b.txt")); err != nil || string(data) != "bb" {
+		t.Errorf("expected proj/sub/b.txt in the destination (%q, %v)", data, err)
+	}
+}
+
+// an in-memory sftp server for the client tests.  reads are answered with at most maxRead bytes, like the servers
+// that cap the read size.
+type testServer struct {
+	files   map[string][]byte
+	handles map[string]string
+	maxRead int
+}
+
+func startTestClient(t *testing.T, files map[string][]byte) (*Client, *testServer) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	srv := &testServer{files: files, handles: make(map[string]string), maxRead: 20000}
+	go srv.serve(serverR, serverW)
+	c, err := newClient(clientW, clientR, clientW.Close)
+	if err != nil {
+		t.Fatalf("error starting the client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, srv
+}
+
+func (s *testServer) serve(r io.Reader, w io.WriteCloser) {
+	defer w.Close()
+	if _, _, err := readPacket(r); err != nil {
+		return
+	}
+	writePacket(w, fxp_Version, (&packetBuilder{}).uint32(protocolVersion).buf)
+	for {
+		pktType, data, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		req := &packetReader{data: data}
+		id := req.uint32()
+		reply := func(rtnType byte, b *packetBuilder) {
+			writePacket(w, rtnType, append(binary.BigEndian.AppendUint32(nil, id), b.buf...))
+		}
+		status := func(code uint32) {
+			reply(fxp_Status, (&packetBuilder{}).uint32(code).string("").string(""))
+		}
+		switch pktType {
+		case fxp_Open:
+			name := req.string()
+			pflags := req.uint32()
+			if _, ok := s.files[name]; !ok && pflags&fxf_Creat == 0 {
+				status(fx_NoSuchFile)
+				continue
+			}
+			if _, ok := s.files[name]; !ok || pflags&fxf_Trunc != 0 {
+				s.files[name] = []byte{}
+			}
+			handle := fmt.Sprintf("h%d", id)
+			s.handles[handle] = name
+			reply(fxp_Handle, (&packetBuilder{}).string(handle))
+		case fxp_Read:
+			fileData := s.files[s.handles[req.string()]]
+			offset := int(req.uint64())
+			size := int(req.uint32())
+			if offset >= len(fileData) {
+				status(fx_EOF)
+				continue
+			}
+			end := min(len(fileData), offset+size, offset+s.maxRead)
+			reply(fxp_Data, (&packetBuilder{}).bytes(fileData[offset:end]))
+		case fxp_Write:
+			name := s.handles[req.string()]
+			offset := int(req.uint64())
+			payload := req.bytes()
+			if len(s.files[name]) < offset+len(payload) {
+				s.files[name] = append(s.files[name], make([]byte, offset+len(payload)-len(s.files[name]))...)
+			}
+			copy(s.files[name][offset:], payload)
+			status(fx_Ok)
+		case fxp_Stat, fxp_Lstat, fxp_Fstat:
+			name := req.string()
+			if pktType == fxp_Fstat {
+				name = s.handles[name]
+			}
+			fileData, ok := s.files[name]
+			if !ok {
+				status(fx_NoSuchFile)
+				continue
+			}
+			reply(fxp_Attrs, (&packetBuilder{}).attrs(&Attrs{Flags: attr_Size | attr_Permissions, Size: uint64(len(fileData)), Perms: s_IFREG | 0644}))
+		case fxp_Close:
+			status(fx_Ok)
+		default:
+			status(fx_OpUnsupported)
+		}
+	}
+}
+
+func TestClientReadWrite(t *testing.T) {
+	data := make([]byte, 1000000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	c, srv := startTestClient(t, map[string][]byte{"/data.bin": data})
+	f, err := c.Open("/data.bin")
+	if err != nil {
+		t.Fatalf("open error: %v", err)
+	}
+	read, err := io.ReadAll(f)
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("the data read does not match (%d bytes, %v)", len(read), err)
+	}
+	f.Close()
+	f, err = c.Create("/copy.bin")
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+	if n, err := f.Write(data); err != nil || n != len(data) {
+		t.Fatalf("write error: %d, %v", n, err)
+	}
+	if info, err := f.Stat(); err != nil || info.Size() != int64(len(data)) {
+		t.Errorf("unexpected stat of the written file (%v)", err)
+	}
+	f.Close()
+	if !bytes.Equal(srv.files["/copy.bin"], data) {
+		t.Errorf("the data written does not match")
+	}
+	if _, err := c.Stat("/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+	if err := c.Rename("/copy.bin", "/moved.bin"); err == nil {
+		t.Errorf("expected the unsupported rename to fail")
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sftpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// the uploads and downloads of files and directories (and the copies between two hosts, through this process).  like
+// scp, a destination that is an existing directory gets the source inside of it.  the files are created with the
+// permissions of the source (less the umask), Preserve sets them exactly and copies the modification times.  Verify
+// compares the sha256 of each file on both sides once it is copied (the files are read again for it), a resumed file
+// that does not match is copied again from the start.
+
+const transferBufSize = MaxInFlight * ChunkSize / 2
+const transferProgressInterval = 250 * time.Millisecond
+
+type TransferOpts struct {
+	Recursive bool // directories are copied with their contents
+	Resume    bool // a destination file smaller than the source is continued instead of copied again
+	Preserve  bool // the destination gets the permissions and modification times of the source
+	Verify    bool // the checksums of the source and the destination are compared after each file
+}
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// sent while a file is copied (at most every transferProgressInterval) and when it is done
+type Progress struct {
+	Path        string // the source file
+	DestPath    string
+	Offset      int64
+	Size        int64
+	ResumedFrom int64 // the offset the copy started from (Resume)
+	FileDone    bool
+	Skipped     bool // the destination was already complete (Resume)
+	BytesDone   int64
+	BytesTotal  int64
+	FilesDone   int
+	FilesTotal  int
+	Checksum    string // the sha256 of the file (Verify, when it is done)
+}
+
+type transferFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+}
+
+// the local or the remote side of a transfer
+type transferFs interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.FileInfo, error)
+	OpenFile(name string, flag int, perm os.FileMode) (transferFile, error)
+	MkdirAll(name string, perm os.FileMode) error
+	Chmod(name string, mode os.FileMode) error
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+	Join(elem ...string) string
+	Base(name string) string
+	Checksum(name string) (string, error)
+}
+
+// the hex sha256 of the file
+func checksumFile(tfs transferFs, name string) (string, error) {
+	file, err := tfs.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.CopyBuffer(hash, file, make([]byte, transferBufSize)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type localFs struct{}
+
+func (localFs) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+func (localFs) ReadDir(name string) ([]fs.FileInfo, error) {
+	dirEntries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	var entries []fs.FileInfo
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if err != nil {
+			// removed since the directory was read
+			continue
+		}
+		entries = append(entries, info)
+	}
+	return entries, nil
+}
+
+func (localFs) OpenFile(name string, flag int, perm os.FileMode) (transferFile, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (localFs) MkdirAll(name string, perm os.FileMode) error { return os.MkdirAll(name, perm) }
+func (localFs) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (localFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+func (localFs) Join(elem ...string) string { return filepath.Join(elem...) }
+func (localFs) Base(name string) string    { return filepath.Base(name) }
+func (l localFs) Checksum(name string) (string, error) {
+	return checksumFile(l, name)
+}
+
+type remoteFs struct {
+	c *Client
+}
+
+func (r remoteFs) Stat(name string) (fs.FileInfo, error)      { return r.c.Stat(name) }
+func (r remoteFs) ReadDir(name string) ([]fs.FileInfo, error) { return r.c.ReadDir(name) }
+func (r remoteFs) OpenFile(name string, flag int, perm os.FileMode) (transferFile, error) {
+	return r.c.OpenFile(name, flag, perm)
+}
+func (r remoteFs) MkdirAll(name string, perm os.FileMode) error { return r.c.MkdirAll(name, perm) }
+func (r remoteFs) Chmod(name string, mode os.FileMode) error    { return r.c.Chmod(name, mode) }
+func (r remoteFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return r.c.Chtimes(name, atime, mtime)
+}
+func (r remoteFs) Join(elem ...string) string { return path.Join(elem...) }
+func (r remoteFs) Base(name string) string    { return path.Base(name) }
+func (r remoteFs) Checksum(name string) (string, error) {
+	return checksumFile(r, name)
+}
+
+func makeTransferFs(c *Client) transferFs {
+	if c == nil {
+		return localFs{}
+	}
+	return remoteFs{c: c}
+}
+
+// copies a local file or directory to the remote host
+func (c *Client) Upload(ctx context.Context, localPath string, remotePath string, opts TransferOpts, progressFn func(Progress)) error {
+	return transfer(ctx, localFs{}, remoteFs{c: c}, localPath, remotePath, opts, progressFn)
+}
+
+// copies a remote file or directory to the local host
+func (c *Client) Download(ctx context.Context, remotePath string, localPath string, opts TransferOpts, progressFn func(Progress)) error {
+	return transfer(ctx, remoteFs{c: c}, localFs{}, remotePath, localPath, opts, progressFn)
+}
+
+// copies a file or directory from one host to another, the data goes through this process.  a nil client is the
+// local host.
+func Copy(ctx context.Context, src *Client, srcPath string, dest *Client, destPath string, opts TransferOpts, progressFn func(Progress)) error {
+	return transfer(ctx, makeTransferFs(src), makeTransferFs(dest), srcPath, destPath, opts, progressFn)
+}
+
+type transferItem struct {
+	srcPath  string
+	destPath string
+	info     fs.FileInfo
+}
+
+type transferState struct {
+	src        transferFs
+	dest       transferFs
+	opts       TransferOpts
+	progressFn func(Progress)
+	bytesDone  int64
+	bytesTotal int64
+	filesDone  int
+	filesTotal int
+}
+
+func transfer(ctx context.Context, src transferFs, dest transferFs, srcPath string, destPath string, opts TransferOpts, progressFn func(Progress)) error {
+	srcInfo, err := src.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	if srcInfo.IsDir() && !opts.Recursive {
+		return fmt.Errorf("%s is a directory (the recursive flag is not set)", srcPath)
+	}
+	if destInfo, err := dest.Stat(destPath); err == nil && destInfo.IsDir() {
+		destPath = dest.Join(destPath, src.Base(srcPath))
+	}
+	var items []transferItem
+	if srcInfo.IsDir() {
+		items, err = planDir(src, dest, srcPath, destPath, srcInfo)
+		if err != nil {
+			return err
+		}
+	} else {
+		items = []transferItem{{srcPath: srcPath, destPath: destPath, info: srcInfo}}
+	}
+	state := &transferState{src: src, dest: dest, opts: opts, progressFn: progressFn}
+	for _, item := range items {
+		if !item.info.IsDir() {
+			state.filesTotal++
+			state.bytesTotal += item.info.Size()
+		}
+	}
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if item.info.IsDir() {
+			if err := dest.MkdirAll(item.destPath, 0755); err != nil {
+				return fmt.Errorf("creating %s: %w", item.destPath, err)
+			}
+			continue
+		}
+		if err := state.copyFile(ctx, item); err != nil {
+			return fmt.Errorf("copying %s: %w", item.srcPath, err)
+		}
+	}
+	if opts.Preserve {
+		// the directories last (and the deepest first), copying the files changed their modification times
+		for i := len(items) - 1; i >= 0; i-- {
+			if items[i].info.IsDir() {
+				if err := state.preserve(items[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// the directory and what it contains, parents before their entries.  symlinks to files are copied as files,
+// symlinks to directories and special files are skipped.
+func planDir(src transferFs, dest transferFs, srcPath string, destPath string, info fs.FileInfo) ([]transferItem, error) {
+	items := []transferItem{{srcPath: srcPath, destPath: destPath, info: info}}
+	entries, err := src.ReadDir(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		entrySrc := src.Join(srcPath, entry.Name())
+		entryDest := dest.Join(destPath, entry.Name())
+		if entry.Mode()&os.ModeSymlink != 0 {
+			target, err := src.Stat(entrySrc)
+			if err != nil || !target.Mode().IsRegular() {
+				continue
+			}
+			entry = target
+		}
+		switch {
+		case entry.IsDir():
+			subItems, err := planDir(src, dest, entrySrc, entryDest, entry)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, subItems...)
+		case entry.Mode().IsRegular():
+			items = append(items, transferItem{srcPath: entrySrc, destPath: entryDest, info: entry})
+		}
+	}
+	return items, nil
+}
+
+func (s *transferState) sendProgress(item transferItem, progress Progress) {
+	if s.progressFn == nil {
+		return
+	}
+	progress.Path = item.srcPath
+	progress.DestPath = item.destPath
+	progress.Size = item.info.Size()
+	progress.BytesDone = s.bytesDone
+	progress.BytesTotal = s.bytesTotal
+	progress.FilesDone = s.filesDone
+	progress.FilesTotal = s.filesTotal
+	s.progressFn(progress)
+}
+
+func (s *transferState) copyFile(ctx context.Context, item transferItem) error {
+	return s.copyFileFrom(ctx, item, s.opts.Resume)
+}
+
+func (s *transferState) copyFileFrom(ctx context.Context, item transferItem, resume bool) error {
+	size := item.info.Size()
+	var offset int64
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resume {
+		if destInfo, err := s.dest.Stat(item.destPath); err == nil && destInfo.Mode().IsRegular() && destInfo.Size() <= size {
+			offset = destInfo.Size()
+			flag = os.O_WRONLY | os.O_CREATE
+		}
+	}
+	resumedFrom := offset
+	s.bytesDone += offset
+	if offset == size && resumedFrom > 0 {
+		checksum, err := s.verify(item)
+		if errors.Is(err, ErrChecksumMismatch) {
+			s.bytesDone -= offset
+			return s.copyFileFrom(ctx, item, false)
+		}
+		if err != nil {
+			return err
+		}
+		s.filesDone++
+		s.sendProgress(item, Progress{Offset: offset, ResumedFrom: resumedFrom, FileDone: true, Skipped: true, Checksum: checksum})
+		if s.opts.Preserve {
+			return s.preserve(item)
+		}
+		return nil
+	}
+	srcFile, err := s.src.OpenFile(item.srcPath, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	destFile, err := s.dest.OpenFile(item.destPath, flag, item.info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	destClosed := false
+	defer func() {
+		if !destClosed {
+			destFile.Close()
+		}
+	}()
+	if offset > 0 {
+		if _, err := srcFile.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := destFile.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	buf := make([]byte, transferBufSize)
+	lastProgress := time.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, readErr := srcFile.Read(buf)
+		if n > 0 {
+			if _, err := destFile.Write(buf[:n]); err != nil {
+				return err
+			}
+			offset += int64(n)
+			s.bytesDone += int64(n)
+			if time.Since(lastProgress) >= transferProgressInterval {
+				lastProgress = time.Now()
+				s.sendProgress(item, Progress{Offset: offset, ResumedFrom: resumedFrom})
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	destClosed = true
+	if err := destFile.Close(); err != nil {
+		return err
+	}
+	checksum, err := s.verify(item)
+	if errors.Is(err, ErrChecksumMismatch) && resumedFrom > 0 {
+		// the part that was there before the resume is not the start of the source
+		s.bytesDone -= offset
+		return s.copyFileFrom(ctx, item, false)
+	}
+	if err != nil {
+		return err
+	}
+	if s.opts.Preserve {
+		if err := s.preserve(item); err != nil {
+			return err
+		}
+	}
+	s.filesDone++
+	s.sendProgress(item, Progress{Offset: offset, ResumedFrom: resumedFrom, FileDone: true, Checksum: checksum})
+	return nil
+}
+
+// the checksum of the copied file ("" if Verify is not set), an error wrapping ErrChecksumMismatch if the source and
+// the destination differ
+func (s *transferState) verify(item transferItem) (string, error) {
+	if !s.opts.Verify {
+		return "", nil
+	}
+	srcSum, err := s.src.Checksum(item.srcPath)
+	if err != nil {
+		return "", fmt.Errorf("computing the checksum of %s: %w", item.srcPath, err)
+	}
+	destSum, err := s.dest.Checksum(item.destPath)
+	if err != nil {
+		return "", fmt.Errorf("computing the checksum of %s: %w", item.destPath, err)
+	}
+	if srcSum != destSum {
+		return "", fmt.Errorf("%w: %s is %s, %s is %s", ErrChecksumMismatch, item.srcPath, srcSum, item.destPath, destSum)
+	}
+	return srcSum, nil
+}
+
+func (s *transferState) preserve(item transferItem) error {
+	mode := item.info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	if err := s.dest.Chmod(item.destPath, mode); err != nil {
+		return fmt.Errorf("setting the permissions of %s: %w", item.destPath, err)
+	}
+	modTime := item.info.ModTime()
+	if err := s.dest.Chtimes(item.destPath, modTime, modTime); err != nil {
+		return fmt.Errorf("setting the modification time of %s: %w", item.destPath, err)
+	}
+	return nil
+}
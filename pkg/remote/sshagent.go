@@ -0,0 +1,125 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// the identity agent is a unix socket (ssh-agent), or a named pipe on windows (the windows openssh agent, which
+// is the default, or pageant with "IdentityAgent \\.\pipe\pageant..." from its --openssh-config file).
+//
+// with ssh:forwardagent (or ForwardAgent yes in the ssh config) the shell sessions of a connection request agent
+// forwarding, and each agent channel the remote host opens is served by a new connection to the local agent.
+// every use of the forwarded agent is logged and published as a conn:agentforward event.
+
+const AgentForwardChannelType = "auth-agent@openssh.com"
+const WindowsAgentPipe = `\\.\pipe\openssh-ssh-agent`
+
+// *ssh.Client -> the agent path, for the connections that forward the agent
+var agentForwardClients = &sync.Map{}
+
+func defaultIdentityAgent() string {
+	if runtime.GOOS == "windows" {
+		return WindowsAgentPipe
+	}
+	return ""
+}
+
+// the identity agent of the connections that do not set their own (IdentityAgent for "Host *" in the ssh config,
+// SSH_AUTH_SOCK, or the windows openssh agent)
+func GetDefaultIdentityAgent() (string, error) {
+	sshKeywords, err := findSshConfigKeywords("*")
+	if err != nil {
+		return "", err
+	}
+	return utilfn.SafeDeref(sshKeywords.SshIdentityAgent), nil
+}
+
+func DialIdentityAgent(agentPath string) (io.ReadWriteCloser, error) {
+	if agentPath == "" {
+		return nil, fmt.Errorf("no identity agent (SSH_AUTH_SOCK is not set)")
+	}
+	if strings.HasPrefix(agentPath, `\\.\pipe\`) {
+		return os.OpenFile(agentPath, os.O_RDWR, 0)
+	}
+	return net.Dial("unix", agentPath)
+}
+
+// serves the agent channels the remote host opens with the local agent (the sessions request forwarding, see
+// RequestAgentForwarding)
+func forwardAgent(client *ssh.Client, connName string, agentPath string) {
+	channels := client.HandleChannelOpen(AgentForwardChannelType)
+	if channels == nil {
+		// already forwarded
+		return
+	}
+	agentForwardClients.Store(client, agentPath)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("sshagent:forwardAgent", recover())
+		}()
+		defer agentForwardClients.Delete(client)
+		for newChannel := range channels {
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				log.Printf("agent forwarding for %s: error accepting channel: %v\n", connName, err)
+				continue
+			}
+			go ssh.DiscardRequests(reqs)
+			go serveForwardedAgent(channel, connName, agentPath)
+		}
+	}()
+}
+
+func serveForwardedAgent(channel ssh.Channel, connName string, agentPath string) {
+	defer func() {
+		panichandler.PanicHandler("sshagent:serveForwardedAgent", recover())
+	}()
+	defer channel.Close()
+	log.Printf("agent forwarding: the forwarded agent was used on %s\n", connName)
+	eventbus.Publish(eventbus.AgentForwardEvent{Forward: wps.AgentForwardEventData{ConnName: connName, AgentPath: agentPath, Ts: time.Now().UnixMilli()}})
+	agentConn, err := DialIdentityAgent(agentPath)
+	if err != nil {
+		log.Printf("agent forwarding for %s: cannot connect to the identity agent: %v\n", connName, err)
+		return
+	}
+	defer agentConn.Close()
+	// served one request at a time (a named pipe opened as a file can not be read and written at once)
+	err = agent.ServeAgent(agent.NewClient(agentConn), channel)
+	if err != nil && err != io.EOF {
+		log.Printf("agent forwarding for %s: %v\n", connName, err)
+	}
+}
+
+func AgentForwardingEnabled(client *ssh.Client) bool {
+	if client == nil {
+		return false
+	}
+	_, ok := agentForwardClients.Load(client)
+	return ok
+}
+
+// requests agent forwarding for a new session if the connection forwards the agent
+func RequestAgentForwarding(client *ssh.Client, session *ssh.Session) error {
+	if !AgentForwardingEnabled(client) {
+		return nil
+	}
+	return agent.RequestAgentForwarding(session)
+}
@@ -8,7 +8,6 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
-	"encoding/base64"
 	"fmt"
 	"log"
 	"math"
@@ -25,6 +24,7 @@ import (
 	"github.com/skeema/knownhosts"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/secretstore"
 	"github.com/wavetermdev/waveterm/pkg/trimquotes"
 	"github.com/wavetermdev/waveterm/pkg/userinput"
 	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
@@ -63,6 +63,7 @@ type ConnectionDebugInfo struct {
 	CurrentClient *ssh.Client
 	NextOpts      *SSHOpts
 	JumpNum       int32
+	Via           []string // the jump hosts CurrentClient went through, in order
 }
 
 type ConnectionError struct {
@@ -71,10 +72,10 @@ type ConnectionError struct {
 }
 
 func (ce ConnectionError) Error() string {
-	if ce.CurrentClient == nil {
+	if ce.CurrentClient == nil || len(ce.Via) == 0 {
 		return fmt.Sprintf("Connecting to %s, Error: %v", ce.NextOpts, ce.Err)
 	}
-	return fmt.Sprintf("Connecting from %v to %s (jump number %d), Error: %v", ce.CurrentClient, ce.NextOpts, ce.JumpNum, ce.Err)
+	return fmt.Sprintf("Connecting to %s via %s (jump number %d), Error: %v", ce.NextOpts, strings.Join(ce.Via, " -> "), ce.JumpNum, ce.Err)
 }
 
 func SimpleMessageFromPossibleConnectionError(err error) string {
@@ -114,7 +115,7 @@ func createDummySigner() ([]ssh.Signer, error) {
 // they were successes. An error in this function prevents any other
 // keys from being attempted. But if there's an error because of a dummy
 // file, the library can still try again with a new key.
-func createPublicKeyCallback(connCtx context.Context, sshKeywords *wconfig.ConnKeywords, authSockSignersExt []ssh.Signer, agentClient agent.ExtendedAgent, debugInfo *ConnectionDebugInfo) func() ([]ssh.Signer, error) {
+func createPublicKeyCallback(connCtx context.Context, connName string, sshKeywords *wconfig.ConnKeywords, authSockSignersExt []ssh.Signer, agentClient agent.ExtendedAgent, debugInfo *ConnectionDebugInfo) func() ([]ssh.Signer, error) {
 	var identityFiles []string
 	existingKeys := make(map[string][]byte)
 
@@ -139,6 +140,7 @@ func createPublicKeyCallback(connCtx context.Context, sshKeywords *wconfig.ConnK
 	var authSockSigners []ssh.Signer
 	authSockSigners = append(authSockSigners, authSockSignersExt...)
 	authSockSignersPtr := &authSockSigners
+	certs := loadCertificates(connCtx, sshKeywords)
 
 	return func() (outSigner []ssh.Signer, outErr error) {
 		defer func() {
@@ -151,7 +153,7 @@ func createPublicKeyCallback(connCtx context.Context, sshKeywords *wconfig.ConnK
 		if len(*authSockSignersPtr) != 0 {
 			authSockSigner := (*authSockSignersPtr)[0]
 			*authSockSignersPtr = (*authSockSignersPtr)[1:]
-			return []ssh.Signer{authSockSigner}, nil
+			return withCertSigners(authSockSigner, certs), nil
 		}
 
 		if len(*identityFilesPtr) == 0 {
@@ -176,7 +178,7 @@ func createPublicKeyCallback(connCtx context.Context, sshKeywords *wconfig.ConnK
 						PrivateKey: unencryptedPrivateKey,
 					})
 				}
-				return []ssh.Signer{signer}, nil
+				return withCertSigners(signer, certs), nil
 			}
 		}
 		if _, ok := err.(*ssh.PassphraseMissingError); !ok {
@@ -184,6 +186,14 @@ func createPublicKeyCallback(connCtx context.Context, sshKeywords *wconfig.ConnK
 			return createDummySigner()
 		}
 
+		if sshKeywords.SshPassphraseSecret != "" {
+			signer, err := signerFromPassphraseSecret(connCtx, connName, sshKeywords, privateKey, agentClient)
+			if err == nil {
+				return withCertSigners(signer, certs), nil
+			}
+			blocklogger.Infof(connCtx, "[conndebug] passphrase secret %q for keyfile %q: %v\n", sshKeywords.SshPassphraseSecret, identityFile, err)
+		}
+
 		// batch mode deactivates user input
 		if utilfn.SafeDeref(sshKeywords.SshBatchMode) {
 			// skip this key and try with the next
@@ -219,7 +229,115 @@ func createPublicKeyCallback(connCtx context.Context, sshKeywords *wconfig.ConnK
 				PrivateKey: unencryptedPrivateKey,
 			})
 		}
-		return []ssh.Signer{signer}, nil
+		return withCertSigners(signer, certs), nil
+	}
+}
+
+// decrypts the key with the passphrase in ssh:passphrasesecret
+func signerFromPassphraseSecret(connCtx context.Context, connName string, sshKeywords *wconfig.ConnKeywords, privateKey []byte, agentClient agent.ExtendedAgent) (ssh.Signer, error) {
+	passphrase, err := secretstore.Resolve(connName, sshKeywords.SshPassphraseSecret)
+	if err != nil {
+		return nil, err
+	}
+	unencryptedPrivateKey, err := ssh.ParseRawPrivateKeyWithPassphrase(privateKey, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt the key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(unencryptedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if utilfn.SafeDeref(sshKeywords.SshAddKeysToAgent) && agentClient != nil {
+		agentClient.Add(agent.AddedKey{
+			PrivateKey: unencryptedPrivateKey,
+		})
+	}
+	blocklogger.Infof(connCtx, "[conndebug] decrypted the key with passphrase secret %q\n", sshKeywords.SshPassphraseSecret)
+	return signer, nil
+}
+
+// the certificates of ssh:certificatefile (CertificateFile in the ssh config), and the <identityfile>-cert.pub
+// next to the identity files (like openssh).  expired certificates are skipped.
+func loadCertificates(connCtx context.Context, sshKeywords *wconfig.ConnKeywords) []*ssh.Certificate {
+	var certFiles []string
+	certFiles = append(certFiles, sshKeywords.SshCertificateFile...)
+	for _, identityFile := range sshKeywords.SshIdentityFile {
+		certFiles = append(certFiles, identityFile+"-cert.pub")
+	}
+	var rtn []*ssh.Certificate
+	now := uint64(time.Now().Unix())
+	for _, certFile := range certFiles {
+		filePath, err := wavebase.ExpandHomeDir(certFile)
+		if err != nil {
+			continue
+		}
+		certBytes, err := os.ReadFile(filePath)
+		if err != nil {
+			// the default -cert.pub files usually don't exist
+			continue
+		}
+		pubKey, _, _, _, err := ssh.ParseAuthorizedKey(certBytes)
+		if err != nil {
+			blocklogger.Infof(connCtx, "[conndebug] cannot parse certificate %q: %v\n", certFile, err)
+			continue
+		}
+		cert, ok := pubKey.(*ssh.Certificate)
+		if !ok || cert.CertType != ssh.UserCert {
+			blocklogger.Infof(connCtx, "[conndebug] %q is not a user certificate\n", certFile)
+			continue
+		}
+		if cert.ValidBefore != ssh.CertTimeInfinity && now >= cert.ValidBefore {
+			blocklogger.Infof(connCtx, "[conndebug] certificate %q expired at %s\n", certFile, time.Unix(int64(cert.ValidBefore), 0).Format(time.RFC3339))
+			continue
+		}
+		rtn = append(rtn, cert)
+	}
+	return rtn
+}
+
+// the signers of a key: one for each of its certificates (tried first), then the key itself
+func withCertSigners(signer ssh.Signer, certs []*ssh.Certificate) []ssh.Signer {
+	var rtn []ssh.Signer
+	pubKeyBytes := signer.PublicKey().Marshal()
+	for _, cert := range certs {
+		if !bytes.Equal(cert.Key.Marshal(), pubKeyBytes) {
+			continue
+		}
+		certSigner, err := ssh.NewCertSigner(cert, signer)
+		if err != nil {
+			continue
+		}
+		rtn = append(rtn, certSigner)
+	}
+	return append(rtn, signer)
+}
+
+// the password in ssh:passwordsecret on the first try (if it is set), then the prompt
+func createPasswordCallback(connCtx context.Context, connName string, remoteDisplayName string, sshKeywords *wconfig.ConnKeywords, debugInfo *ConnectionDebugInfo) func() (secret string, err error) {
+	promptFn := createInteractivePasswordCallbackPrompt(connCtx, remoteDisplayName, debugInfo)
+	secretName := sshKeywords.SshPasswordSecret
+	if secretName == "" {
+		return promptFn
+	}
+	triedSecret := false
+	return func() (string, error) {
+		if triedSecret {
+			if utilfn.SafeDeref(sshKeywords.SshBatchMode) {
+				return "", ConnectionError{ConnectionDebugInfo: debugInfo, Err: fmt.Errorf("the password in secret %q was rejected", secretName)}
+			}
+			return promptFn()
+		}
+		triedSecret = true
+		password, err := secretstore.Resolve(connName, secretName)
+		if err != nil {
+			blocklogger.Infof(connCtx, "[conndebug] password secret %q: %v\n", secretName, err)
+			if utilfn.SafeDeref(sshKeywords.SshBatchMode) {
+				return "", ConnectionError{ConnectionDebugInfo: debugInfo, Err: err}
+			}
+			return promptFn()
+		}
+		blocklogger.Infof(connCtx, "[conndebug] sending the password in secret %q\n", secretName)
+		return password, nil
 	}
 }
 
@@ -309,107 +427,6 @@ func openKnownHostsForEdit(knownHostsFilename string) (*os.File, error) {
 	return os.OpenFile(knownHostsFilename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
 }
 
-func writeToKnownHosts(knownHostsFile string, newLine string, getUserVerification func() (*userinput.UserInputResponse, error)) error {
-	if getUserVerification == nil {
-		getUserVerification = func() (*userinput.UserInputResponse, error) {
-			return &userinput.UserInputResponse{
-				Type:    "confirm",
-				Confirm: true,
-			}, nil
-		}
-	}
-
-	path, _ := filepath.Split(knownHostsFile)
-	err := os.MkdirAll(path, 0700)
-	if err != nil {
-		return err
-	}
-	f, err := os.OpenFile(knownHostsFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	// do not close writeable files with defer
-
-	// this file works, so let's ask the user for permission
-	response, err := getUserVerification()
-	if err != nil {
-		f.Close()
-		return UserInputCancelError{Err: err}
-	}
-	if !response.Confirm {
-		f.Close()
-		return UserInputCancelError{Err: fmt.Errorf("canceled by the user")}
-	}
-
-	_, err = f.WriteString(newLine + "\n")
-	if err != nil {
-		f.Close()
-		return err
-	}
-	return f.Close()
-}
-
-func createUnknownKeyVerifier(ctx context.Context, knownHostsFile string, hostname string, remote string, key ssh.PublicKey) func() (*userinput.UserInputResponse, error) {
-	base64Key := base64.StdEncoding.EncodeToString(key.Marshal())
-	queryText := fmt.Sprintf(
-		"The authenticity of host '%s (%s)' can't be established "+
-			"as it **does not exist in any checked known_hosts files**. "+
-			"The host you are attempting to connect to provides this %s key:  \n"+
-			"%s.\n\n"+
-			"**Would you like to continue connecting?** If so, the key will be permanently "+
-			"added to the file %s "+
-			"to protect from future man-in-the-middle attacks.", hostname, remote, key.Type(), base64Key, knownHostsFile)
-	request := &userinput.UserInputRequest{
-		ResponseType: "confirm",
-		QueryText:    queryText,
-		Markdown:     true,
-		Title:        "Known Hosts Key Missing",
-	}
-	return func() (*userinput.UserInputResponse, error) {
-		ctx, cancelFn := context.WithTimeout(ctx, 60*time.Second)
-		defer cancelFn()
-		resp, err := userinput.GetUserInput(ctx, request)
-		if err != nil {
-			return nil, err
-		}
-		if !resp.Confirm {
-			return nil, fmt.Errorf("user selected no")
-		}
-		return resp, nil
-	}
-}
-
-func createMissingKnownHostsVerifier(knownHostsFile string, hostname string, remote string, key ssh.PublicKey) func() (*userinput.UserInputResponse, error) {
-	base64Key := base64.StdEncoding.EncodeToString(key.Marshal())
-	queryText := fmt.Sprintf(
-		"The authenticity of host '%s (%s)' can't be established "+
-			"as **no known_hosts files could be found**. "+
-			"The host you are attempting to connect to provides this %s key:  \n"+
-			"%s.\n\n"+
-			"**Would you like to continue connecting?** If so:  \n"+
-			"- %s will be created  \n"+
-			"- the key will be added to %s\n\n"+
-			"This will protect from future man-in-the-middle attacks.", hostname, remote, key.Type(), base64Key, knownHostsFile, knownHostsFile)
-	request := &userinput.UserInputRequest{
-		ResponseType: "confirm",
-		QueryText:    queryText,
-		Markdown:     true,
-		Title:        "Known Hosts File Missing",
-	}
-	return func() (*userinput.UserInputResponse, error) {
-		ctx, cancelFn := context.WithTimeout(context.Background(), 60*time.Second)
-		defer cancelFn()
-		resp, err := userinput.GetUserInput(ctx, request)
-		if err != nil {
-			return nil, err
-		}
-		if !resp.Confirm {
-			return nil, fmt.Errorf("user selected no")
-		}
-		return resp, nil
-	}
-}
-
 func lineContainsMatch(line []byte, matches [][]byte) bool {
 	for _, match := range matches {
 		if bytes.Contains(line, match) {
@@ -443,12 +460,12 @@ func createHostKeyCallback(ctx context.Context, sshKeywords *wconfig.ConnKeyword
 		knownHostsFiles = append(knownHostsFiles, filePath)
 	}
 
-	// there are no good known hosts files
-	if len(knownHostsFiles) == 0 {
-		return nil, nil, fmt.Errorf("no known_hosts files provided by ssh. defaults are overridden")
+	// the decisions of the user are read along with the known hosts files of the ssh config
+	managedKnownHostsFile, err := ensureManagedKnownHostsFile()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create %s: %w", GetManagedKnownHostsFile(), err)
 	}
-
-	var unreadableFiles []string
+	knownHostsFiles = append(knownHostsFiles, managedKnownHostsFile)
 
 	// the library we use isn't very forgiving about files that are formatted
 	// incorrectly. if a problem file is found, it is removed from our list
@@ -459,7 +476,6 @@ func createHostKeyCallback(ctx context.Context, sshKeywords *wconfig.ConnKeyword
 		keyDb, err := knownhosts.NewDB(knownHostsFiles...)
 		if serr, ok := err.(*os.PathError); ok {
 			badFile := serr.Path
-			unreadableFiles = append(unreadableFiles, badFile)
 			var okFiles []string
 			for _, filename := range knownHostsFiles {
 				if filename != badFile {
@@ -502,82 +518,17 @@ func createHostKeyCallback(ctx context.Context, sshKeywords *wconfig.ConnKeyword
 			// success
 			return nil
 		} else if _, ok := err.(*xknownhosts.RevokedError); ok {
-			// revoked credentials are refused outright
-			return err
+			// revoked credentials (and the keys the user rejected) are refused outright
+			return fmt.Errorf("the host key of %s was rejected, forget it with \"wsh conn hostkey rm %s\" to be asked again: %w", hostname, hostname, err)
 		} else if _, ok := err.(*xknownhosts.KeyError); !ok {
 			// this is an unknown error (note the !ok is opposite of usual)
 			return err
 		}
+		// the key was not found (or it changed), the user decides
 		serr, _ := err.(*xknownhosts.KeyError)
-		if len(serr.Want) == 0 {
-			// the key was not found
-
-			// try to write to a file that could be read
-			err := fmt.Errorf("placeholder, should not be returned") // a null value here can cause problems with empty slice
-			for _, filename := range knownHostsFiles {
-				newLine := xknownhosts.Line([]string{xknownhosts.Normalize(hostname)}, key)
-				getUserVerification := createUnknownKeyVerifier(ctx, filename, hostname, remote.String(), key)
-				err = writeToKnownHosts(filename, newLine, getUserVerification)
-				if err == nil {
-					break
-				}
-				if serr, ok := err.(UserInputCancelError); ok {
-					return serr
-				}
-			}
-
-			// try to write to a file that could not be read (file likely doesn't exist)
-			// should catch cases where there is no known_hosts file
-			if err != nil {
-				for _, filename := range unreadableFiles {
-					newLine := xknownhosts.Line([]string{xknownhosts.Normalize(hostname)}, key)
-					getUserVerification := createMissingKnownHostsVerifier(filename, hostname, remote.String(), key)
-					err = writeToKnownHosts(filename, newLine, getUserVerification)
-					if err == nil {
-						knownHostsFiles = []string{filename}
-						break
-					}
-					if serr, ok := err.(UserInputCancelError); ok {
-						return serr
-					}
-				}
-			}
-			if err != nil {
-				return fmt.Errorf("unable to create new knownhost key: %e", err)
-			}
-		} else {
-			// the key changed
-			correctKeyFingerprint := base64.StdEncoding.EncodeToString(key.Marshal())
-			var bulletListKnownHosts []string
-			for _, knownHostName := range knownHostsFiles {
-				withBulletPoint := "- " + knownHostName
-				bulletListKnownHosts = append(bulletListKnownHosts, withBulletPoint)
-			}
-			var offendingKeysFmt []string
-			for _, badKey := range serr.Want {
-				formattedKey := "- " + base64.StdEncoding.EncodeToString(badKey.Key.Marshal())
-				offendingKeysFmt = append(offendingKeysFmt, formattedKey)
-			}
-			// todo
-			errorMsg := fmt.Sprintf("**WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!**\n\n"+
-				"If this is not expected, it is possible that someone could be trying to "+
-				"eavesdrop on you via a man-in-the-middle attack. "+
-				"Alternatively, the host you are connecting to may have changed its key. "+
-				"The %s key sent by the remote hist has the fingerprint:  \n"+
-				"%s\n\n"+
-				"If you are sure this is correct, please update your known_hosts files to "+
-				"remove the lines with the offending before trying to connect again.  \n"+
-				"**Known Hosts Files**  \n"+
-				"%s\n\n"+
-				"**Offending Keys**  \n"+
-				"%s", key.Type(), correctKeyFingerprint, strings.Join(bulletListKnownHosts, "  \n"), strings.Join(offendingKeysFmt, "  \n"))
-
-			log.Print(errorMsg)
-			//update := scbus.MakeUpdatePacket()
-			// create update into alert message
-
-			//send update via bus?
-			return fmt.Errorf("remote host identification has changed")
+		err = verifyHostKey(ctx, hostname, remote.String(), key, serr.Want)
+		if err != nil {
+			return err
 		}
 
 		updatedCallback, err := xknownhosts.New(knownHostsFiles...)
@@ -591,7 +542,8 @@ func createHostKeyCallback(ctx context.Context, sshKeywords *wconfig.ConnKeyword
 	return waveHostKeyCallback, hostKeyAlgorithms, nil
 }
 
-func createClientConfig(connCtx context.Context, sshKeywords *wconfig.ConnKeywords, debugInfo *ConnectionDebugInfo) (*ssh.ClientConfig, error) {
+// the gssapi client is returned (nil if gssapi-with-mic is not used) to know if the connection authenticated with it
+func createClientConfig(connCtx context.Context, connName string, sshKeywords *wconfig.ConnKeywords, debugInfo *ConnectionDebugInfo) (*ssh.ClientConfig, *krb5GSSAPIClient, error) {
 	chosenUser := utilfn.SafeDeref(sshKeywords.SshUser)
 	chosenHostName := utilfn.SafeDeref(sshKeywords.SshHostName)
 	chosenPort := utilfn.SafeDeref(sshKeywords.SshPort)
@@ -606,7 +558,7 @@ func createClientConfig(connCtx context.Context, sshKeywords *wconfig.ConnKeywor
 	// IdentitiesOnly indicates that only the keys listed in the identity and certificate files or passed as arguments should be used, even if there are matches in the SSH Agent, PKCS11Provider, or SecurityKeyProvider. See https://man.openbsd.org/ssh_config#IdentitiesOnly
 	// TODO: Update if we decide to support PKCS11Provider and SecurityKeyProvider
 	if !utilfn.SafeDeref(sshKeywords.SshIdentitiesOnly) {
-		conn, err := net.Dial("unix", utilfn.SafeDeref(sshKeywords.SshIdentityAgent))
+		conn, err := DialIdentityAgent(utilfn.SafeDeref(sshKeywords.SshIdentityAgent))
 		if err != nil {
 			log.Printf("Failed to open Identity Agent Socket: %v", err)
 		} else {
@@ -615,22 +567,41 @@ func createClientConfig(connCtx context.Context, sshKeywords *wconfig.ConnKeywor
 		}
 	}
 
-	publicKeyCallback := ssh.PublicKeysCallback(createPublicKeyCallback(connCtx, sshKeywords, authSockSigners, agentClient, debugInfo))
+	publicKeyCallback := ssh.PublicKeysCallback(createPublicKeyCallback(connCtx, connName, sshKeywords, authSockSigners, agentClient, debugInfo))
 	keyboardInteractive := ssh.KeyboardInteractive(createInteractiveKbdInteractiveChallenge(connCtx, remoteName, debugInfo))
-	passwordCallback := ssh.PasswordCallback(createInteractivePasswordCallbackPrompt(connCtx, remoteName, debugInfo))
+	passwordCallback := ssh.PasswordCallback(createPasswordCallback(connCtx, connName, remoteName, sshKeywords, debugInfo))
+	// a stored password is tried first, then the user is asked for one (unless in batch mode)
+	passwordTries := 1
+	if sshKeywords.SshPasswordSecret != "" && !utilfn.SafeDeref(sshKeywords.SshBatchMode) {
+		passwordTries = 2
+	}
+
+	// the kerberos service ticket is fetched here, a connection that can't get one skips gssapi-with-mic
+	var gssapiClient *krb5GSSAPIClient
+	var gssapiErr error
+	if utilfn.SafeDeref(sshKeywords.SshGSSAPIAuthentication) && utilfn.ContainsStr(sshKeywords.SshPreferredAuthentications, "gssapi-with-mic") {
+		gssapiClient, gssapiErr = makeKrb5GSSAPIClient(chosenHostName)
+		if gssapiErr != nil {
+			blocklogger.Infof(connCtx, "[conndebug] skipping gssapi-with-mic: %v\n", gssapiErr)
+		}
+	}
 
-	// exclude gssapi-with-mic and hostbased until implemented
+	// exclude hostbased until implemented
 	authMethodMap := map[string]ssh.AuthMethod{
 		"publickey":            ssh.RetryableAuthMethod(publicKeyCallback, len(sshKeywords.SshIdentityFile)+len(authSockSigners)),
 		"keyboard-interactive": ssh.RetryableAuthMethod(keyboardInteractive, 1),
-		"password":             ssh.RetryableAuthMethod(passwordCallback, 1),
+		"password":             ssh.RetryableAuthMethod(passwordCallback, passwordTries),
+	}
+	if gssapiClient != nil {
+		authMethodMap["gssapi-with-mic"] = ssh.GSSAPIWithMICAuthMethod(gssapiClient, chosenHostName)
 	}
 
 	// note: batch mode turns off interactive input
 	authMethodActiveMap := map[string]bool{
 		"publickey":            utilfn.SafeDeref(sshKeywords.SshPubkeyAuthentication),
 		"keyboard-interactive": utilfn.SafeDeref(sshKeywords.SshKbdInteractiveAuthentication) && !utilfn.SafeDeref(sshKeywords.SshBatchMode),
-		"password":             utilfn.SafeDeref(sshKeywords.SshPasswordAuthentication) && !utilfn.SafeDeref(sshKeywords.SshBatchMode),
+		"password":             utilfn.SafeDeref(sshKeywords.SshPasswordAuthentication) && (!utilfn.SafeDeref(sshKeywords.SshBatchMode) || sshKeywords.SshPasswordSecret != ""),
+		"gssapi-with-mic":      gssapiClient != nil,
 	}
 
 	var authMethods []ssh.AuthMethod
@@ -645,10 +616,14 @@ func createClientConfig(connCtx context.Context, sshKeywords *wconfig.ConnKeywor
 		}
 		authMethods = append(authMethods, authMethod)
 	}
+	// a connection that only uses gssapi fails with the reason it could not be used
+	if len(authMethods) == 0 && gssapiErr != nil {
+		return nil, nil, gssapiErr
+	}
 
 	hostKeyCallback, hostKeyAlgorithms, err := createHostKeyCallback(connCtx, sshKeywords)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	networkAddr := chosenHostName + ":" + chosenPort
@@ -657,7 +632,7 @@ func createClientConfig(connCtx context.Context, sshKeywords *wconfig.ConnKeywor
 		Auth:              authMethods,
 		HostKeyCallback:   hostKeyCallback,
 		HostKeyAlgorithms: hostKeyAlgorithms(networkAddr),
-	}, nil
+	}, gssapiClient, nil
 }
 
 func connectInternal(ctx context.Context, networkAddr string, clientConfig *ssh.ClientConfig, currentClient *ssh.Client) (*ssh.Client, error) {
@@ -679,25 +654,48 @@ func connectInternal(ctx context.Context, networkAddr string, clientConfig *ssh.
 			return nil, err
 		}
 	}
-	c, chans, reqs, err := ssh.NewClientConn(clientConn, networkAddr, clientConfig)
+	counter := &TrafficCounter{}
+	c, chans, reqs, err := ssh.NewClientConn(&countingConn{Conn: clientConn, counter: counter}, networkAddr, clientConfig)
 	if err != nil {
 		blocklogger.Infof(ctx, "[conndebug] ERROR ssh auth/negotiation: %s\n", SimpleMessageFromPossibleConnectionError(err))
 		return nil, err
 	}
 	blocklogger.Infof(ctx, "[conndebug] successful ssh connection to %s\n", networkAddr)
-	return ssh.NewClient(c, chans, reqs), nil
+	client := ssh.NewClient(c, chans, reqs)
+	registerTrafficCounter(client, counter)
+	return client, nil
+}
+
+// parses a ProxyJump host, "[ssh://][user@]host[:port]"
+func ParseProxyJumpOpts(proxyName string) (*SSHOpts, error) {
+	opts, err := ParseOpts(strings.TrimPrefix(proxyName, "ssh://"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ProxyJump host %q: %w", proxyName, err)
+	}
+	return opts, nil
 }
 
 func ConnectToClient(connCtx context.Context, opts *SSHOpts, currentClient *ssh.Client, jumpNum int32, connFlags *wconfig.ConnKeywords) (*ssh.Client, int32, error) {
+	return connectToClient(connCtx, opts, currentClient, nil, nil, jumpNum, connFlags)
+}
+
+// via is the chain of jump hosts currentClient is connected through, and targets are the connections whose jump
+// hosts are being connected (a jump host can not be one of them).  each jump host is connected with its own
+// keywords (ssh config and internal config), so every hop has its own auth.
+func connectToClient(connCtx context.Context, opts *SSHOpts, currentClient *ssh.Client, via []string, targets []string, jumpNum int32, connFlags *wconfig.ConnKeywords) (*ssh.Client, int32, error) {
 	blocklogger.Infof(connCtx, "[conndebug] ConnectToClient %s (jump:%d)...\n", opts.String(), jumpNum)
 	debugInfo := &ConnectionDebugInfo{
 		CurrentClient: currentClient,
 		NextOpts:      opts,
 		JumpNum:       jumpNum,
+		Via:           via,
 	}
 	if jumpNum > SshProxyJumpMaxDepth {
 		return nil, jumpNum, ConnectionError{ConnectionDebugInfo: debugInfo, Err: fmt.Errorf("ProxyJump %d exceeds Wave's max depth of %d", jumpNum, SshProxyJumpMaxDepth)}
 	}
+	if utilfn.ContainsStr(targets, opts.String()) || utilfn.ContainsStr(via, opts.String()) {
+		return nil, jumpNum, ConnectionError{ConnectionDebugInfo: debugInfo, Err: fmt.Errorf("ProxyJump loop, %s is already a jump host of the connection", opts.String())}
+	}
 
 	rawName := opts.String()
 	fullConfig := wconfig.GetWatcher().GetFullConfig()
@@ -746,10 +744,21 @@ func ConnectToClient(connCtx context.Context, opts *SSHOpts, currentClient *ssh.
 	sshKeywords.SshIdentityFile = append(sshKeywords.SshIdentityFile, connFlags.SshIdentityFile...)
 	sshKeywords.SshIdentityFile = append(sshKeywords.SshIdentityFile, internalSshConfigKeywords.SshIdentityFile...)
 	sshKeywords.SshIdentityFile = append(sshKeywords.SshIdentityFile, sshConfigKeywords.SshIdentityFile...)
-
+	sshKeywords.SshCertificateFile = append(sshKeywords.SshCertificateFile, connFlags.SshCertificateFile...)
+	sshKeywords.SshCertificateFile = append(sshKeywords.SshCertificateFile, internalSshConfigKeywords.SshCertificateFile...)
+	sshKeywords.SshCertificateFile = append(sshKeywords.SshCertificateFile, sshConfigKeywords.SshCertificateFile...)
+
+	// the jump hosts connected here are closed if the connection fails
+	var jumpClients []*ssh.Client
+	closeJumpClients := func() {
+		for idx := len(jumpClients) - 1; idx >= 0; idx-- {
+			jumpClients[idx].Close()
+		}
+	}
 	for _, proxyName := range sshKeywords.SshProxyJump {
-		proxyOpts, err := ParseOpts(proxyName)
+		proxyOpts, err := ParseProxyJumpOpts(proxyName)
 		if err != nil {
+			closeJumpClients()
 			return nil, debugInfo.JumpNum, ConnectionError{ConnectionDebugInfo: debugInfo, Err: err}
 		}
 
@@ -759,22 +768,47 @@ func ConnectToClient(connCtx context.Context, opts *SSHOpts, currentClient *ssh.
 		}
 
 		// do not apply supplied keywords to proxies - ssh config must be used for that
-		debugInfo.CurrentClient, jumpNum, err = ConnectToClient(connCtx, proxyOpts, debugInfo.CurrentClient, jumpNum, &wconfig.ConnKeywords{})
+		blocklogger.Infof(connCtx, "[conndebug] jump host %d for %s: %s\n", jumpNum, opts.String(), proxyOpts.String())
+		proxyTargets := append(append([]string{}, targets...), opts.String())
+		debugInfo.CurrentClient, jumpNum, err = connectToClient(connCtx, proxyOpts, debugInfo.CurrentClient, debugInfo.Via, proxyTargets, jumpNum, &wconfig.ConnKeywords{})
 		if err != nil {
+			closeJumpClients()
 			// do not add a context on a recursive call
 			// (this can cause a recursive nested context that's arbitrarily deep)
 			return nil, jumpNum, err
 		}
+		jumpClients = append(jumpClients, debugInfo.CurrentClient)
+		debugInfo.Via = append(append([]string{}, debugInfo.Via...), proxyOpts.String())
 	}
-	clientConfig, err := createClientConfig(connCtx, sshKeywords, debugInfo)
+	clientConfig, gssapiClient, err := createClientConfig(connCtx, rawName, sshKeywords, debugInfo)
 	if err != nil {
+		closeJumpClients()
 		return nil, debugInfo.JumpNum, ConnectionError{ConnectionDebugInfo: debugInfo, Err: err}
 	}
 	networkAddr := utilfn.SafeDeref(sshKeywords.SshHostName) + ":" + utilfn.SafeDeref(sshKeywords.SshPort)
 	client, err := connectInternal(connCtx, networkAddr, clientConfig, debugInfo.CurrentClient)
 	if err != nil {
+		closeJumpClients()
 		return client, debugInfo.JumpNum, ConnectionError{ConnectionDebugInfo: debugInfo, Err: err}
 	}
+	// gssapi is tried before the other methods, a mic that was sent means it was accepted (or the others failed)
+	if gssapiClient != nil && gssapiClient.micSent {
+		blocklogger.Infof(connCtx, "[conndebug] authenticated with gssapi-with-mic\n")
+		trackGSSAPIClient(client, gssapiClient.ccPath)
+	}
+	if len(targets) == 0 && utilfn.SafeDeref(sshKeywords.SshForwardAgent) {
+		blocklogger.Infof(connCtx, "[conndebug] forwarding the identity agent to %s\n", opts.String())
+		forwardAgent(client, opts.String(), utilfn.SafeDeref(sshKeywords.SshIdentityAgent))
+	}
+	if len(jumpClients) > 0 {
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("sshclient:closeJumpClients", recover())
+			}()
+			client.Wait()
+			closeJumpClients()
+		}()
+	}
 	return client, debugInfo.JumpNum, nil
 }
 
@@ -830,6 +864,12 @@ func findSshConfigKeywords(hostPattern string) (connKeywords *wconfig.ConnKeywor
 	}
 	sshKeywords.SshIdentityFile = identityFileRaw
 
+	certificateFileRaw := WaveSshConfigUserSettings().GetAll(hostPattern, "CertificateFile")
+	for i := 0; i < len(certificateFileRaw); i++ {
+		certificateFileRaw[i] = trimquotes.TryTrimQuotes(certificateFileRaw[i])
+	}
+	sshKeywords.SshCertificateFile = certificateFileRaw
+
 	batchModeRaw, err := WaveSshConfigUserSettings().GetStrict(hostPattern, "BatchMode")
 	if err != nil {
 		return nil, err
@@ -855,6 +895,12 @@ func findSshConfigKeywords(hostPattern string) (connKeywords *wconfig.ConnKeywor
 	}
 	sshKeywords.SshKbdInteractiveAuthentication = utilfn.Ptr(strings.ToLower(trimquotes.TryTrimQuotes(kbdInteractiveAuthenticationRaw)) != "no")
 
+	gssapiAuthenticationRaw, err := WaveSshConfigUserSettings().GetStrict(hostPattern, "GSSAPIAuthentication")
+	if err != nil {
+		return nil, err
+	}
+	sshKeywords.SshGSSAPIAuthentication = utilfn.Ptr(strings.ToLower(trimquotes.TryTrimQuotes(gssapiAuthenticationRaw)) == "yes")
+
 	// these are parsed as a single string and must be separated
 	// these are case sensitive in openssh so they are here too
 	preferredAuthenticationsRaw, err := WaveSshConfigUserSettings().GetStrict(hostPattern, "PreferredAuthentications")
@@ -880,16 +926,20 @@ func findSshConfigKeywords(hostPattern string) (connKeywords *wconfig.ConnKeywor
 	}
 	if identityAgentRaw == "" {
 		shellPath := shellutil.DetectLocalShellPath()
-		authSockCommand := exec.Command(shellPath, "-c", "echo ${SSH_AUTH_SOCK}")
+		authSockCommand := exec.Command(shellPath, shellutil.GetPrintEnvVarArgs(shellPath, "SSH_AUTH_SOCK")...)
 		sshAuthSock, err := authSockCommand.Output()
 		if err == nil {
 			agentPath, err := wavebase.ExpandHomeDir(trimquotes.TryTrimQuotes(strings.TrimSpace(string(sshAuthSock))))
 			if err != nil {
 				return nil, err
 			}
+			if agentPath == "" {
+				agentPath = defaultIdentityAgent()
+			}
 			sshKeywords.SshIdentityAgent = utilfn.Ptr(agentPath)
 		} else {
 			log.Printf("unable to find SSH_AUTH_SOCK: %v\n", err)
+			sshKeywords.SshIdentityAgent = utilfn.Ptr(defaultIdentityAgent())
 		}
 	} else {
 		agentPath, err := wavebase.ExpandHomeDir(trimquotes.TryTrimQuotes(identityAgentRaw))
@@ -899,6 +949,12 @@ func findSshConfigKeywords(hostPattern string) (connKeywords *wconfig.ConnKeywor
 		sshKeywords.SshIdentityAgent = utilfn.Ptr(agentPath)
 	}
 
+	forwardAgentRaw, err := WaveSshConfigUserSettings().GetStrict(hostPattern, "ForwardAgent")
+	if err != nil {
+		return nil, err
+	}
+	sshKeywords.SshForwardAgent = utilfn.Ptr(strings.ToLower(trimquotes.TryTrimQuotes(forwardAgentRaw)) == "yes")
+
 	proxyJumpRaw, err := WaveSshConfigUserSettings().GetStrict(hostPattern, "ProxyJump")
 	if err != nil {
 		return nil, err
@@ -934,10 +990,15 @@ func findSshDefaults(hostPattern string) (connKeywords *wconfig.ConnKeywords, ou
 	sshKeywords.SshPubkeyAuthentication = utilfn.Ptr(true)
 	sshKeywords.SshPasswordAuthentication = utilfn.Ptr(true)
 	sshKeywords.SshKbdInteractiveAuthentication = utilfn.Ptr(true)
+	sshKeywords.SshGSSAPIAuthentication = utilfn.Ptr(false)
 	sshKeywords.SshPreferredAuthentications = strings.Split(ssh_config.Default("PreferredAuthentications"), ",")
 	sshKeywords.SshAddKeysToAgent = utilfn.Ptr(false)
 	sshKeywords.SshIdentitiesOnly = utilfn.Ptr(false)
 	sshKeywords.SshIdentityAgent = utilfn.Ptr(ssh_config.Default("IdentityAgent"))
+	if *sshKeywords.SshIdentityAgent == "" {
+		sshKeywords.SshIdentityAgent = utilfn.Ptr(defaultIdentityAgent())
+	}
+	sshKeywords.SshForwardAgent = utilfn.Ptr(false)
 	sshKeywords.SshProxyJump = []string{}
 	sshKeywords.SshUserKnownHostsFile = strings.Fields(ssh_config.Default("UserKnownHostsFile"))
 	sshKeywords.SshGlobalKnownHostsFile = strings.Fields(ssh_config.Default("GlobalKnownHostsFile"))
@@ -980,7 +1041,7 @@ func mergeKeywords(oldKeywords *wconfig.ConnKeywords, newKeywords *wconfig.ConnK
 	if newKeywords.SshPort != nil {
 		outKeywords.SshPort = newKeywords.SshPort
 	}
-	// skip identityfile (handled separately due to different behavior)
+	// skip identityfile and certificatefile (handled separately due to different behavior)
 	if newKeywords.SshBatchMode != nil {
 		outKeywords.SshBatchMode = newKeywords.SshBatchMode
 	}
@@ -993,6 +1054,15 @@ func mergeKeywords(oldKeywords *wconfig.ConnKeywords, newKeywords *wconfig.ConnK
 	if newKeywords.SshKbdInteractiveAuthentication != nil {
 		outKeywords.SshKbdInteractiveAuthentication = newKeywords.SshKbdInteractiveAuthentication
 	}
+	if newKeywords.SshGSSAPIAuthentication != nil {
+		outKeywords.SshGSSAPIAuthentication = newKeywords.SshGSSAPIAuthentication
+	}
+	if newKeywords.SshPasswordSecret != "" {
+		outKeywords.SshPasswordSecret = newKeywords.SshPasswordSecret
+	}
+	if newKeywords.SshPassphraseSecret != "" {
+		outKeywords.SshPassphraseSecret = newKeywords.SshPassphraseSecret
+	}
 	if newKeywords.SshPreferredAuthentications != nil {
 		outKeywords.SshPreferredAuthentications = newKeywords.SshPreferredAuthentications
 	}
@@ -1005,6 +1075,9 @@ func mergeKeywords(oldKeywords *wconfig.ConnKeywords, newKeywords *wconfig.ConnK
 	if newKeywords.SshIdentitiesOnly != nil {
 		outKeywords.SshIdentitiesOnly = newKeywords.SshIdentitiesOnly
 	}
+	if newKeywords.SshForwardAgent != nil {
+		outKeywords.SshForwardAgent = newKeywords.SshForwardAgent
+	}
 	if newKeywords.SshProxyJump != nil {
 		outKeywords.SshProxyJump = newKeywords.SshProxyJump
 	}
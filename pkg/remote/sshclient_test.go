@@ -0,0 +1,81 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"golang.org/x/crypto/ssh"
+)
+
+func makeTestSigner(t *testing.T) ssh.Signer {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(privKey)
+	if err != nil {
+		t.Fatalf("error creating signer: %v", err)
+	}
+	return signer
+}
+
+func writeTestCert(t *testing.T, path string, key ssh.PublicKey, caSigner ssh.Signer, validBefore time.Time) {
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"admin"},
+		ValidBefore:     uint64(validBefore.Unix()),
+	}
+	err := cert.SignCert(rand.Reader, caSigner)
+	if err != nil {
+		t.Fatalf("error signing cert: %v", err)
+	}
+	err = os.WriteFile(path, ssh.MarshalAuthorizedKey(cert), 0600)
+	if err != nil {
+		t.Fatalf("error writing cert: %v", err)
+	}
+}
+
+func TestCertSigners(t *testing.T) {
+	dir := t.TempDir()
+	caSigner := makeTestSigner(t)
+	signer := makeTestSigner(t)
+	otherSigner := makeTestSigner(t)
+	identityFile := filepath.Join(dir, "id_ed25519")
+	expiredFile := filepath.Join(dir, "expired-cert.pub")
+	otherFile := filepath.Join(dir, "other-cert.pub")
+	// the default cert of the identity file, an expired one, and one for another key
+	writeTestCert(t, identityFile+"-cert.pub", signer.PublicKey(), caSigner, time.Now().Add(time.Hour))
+	writeTestCert(t, expiredFile, signer.PublicKey(), caSigner, time.Now().Add(-time.Hour))
+	writeTestCert(t, otherFile, otherSigner.PublicKey(), caSigner, time.Now().Add(time.Hour))
+	sshKeywords := &wconfig.ConnKeywords{
+		SshIdentityFile:    []string{identityFile},
+		SshCertificateFile: []string{expiredFile, otherFile},
+	}
+	certs := loadCertificates(context.Background(), sshKeywords)
+	if len(certs) != 2 {
+		t.Fatalf("expected 2 certificates (the expired one is skipped), got %d", len(certs))
+	}
+	signers := withCertSigners(signer, certs)
+	if len(signers) != 2 {
+		t.Fatalf("expected a cert signer and the key, got %d signers", len(signers))
+	}
+	if _, ok := signers[0].PublicKey().(*ssh.Certificate); !ok {
+		t.Errorf("the cert signer should be tried first")
+	}
+	if string(signers[1].PublicKey().Marshal()) != string(signer.PublicKey().Marshal()) {
+		t.Errorf("the key should be tried after its certificates")
+	}
+	if signers := withCertSigners(makeTestSigner(t), certs); len(signers) != 1 {
+		t.Errorf("a key without certificates should only have its own signer, got %d", len(signers))
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"golang.org/x/crypto/ssh"
+)
+
+// the bytes read and written on the transport of an ssh client (its tcp connection, or its channel through the
+// jump host), so the traffic of a connection is what goes over the network (encrypted, with the ssh overhead)
+type TrafficCounter struct {
+	BytesIn  atomic.Int64
+	BytesOut atomic.Int64
+}
+
+type countingConn struct {
+	net.Conn
+	counter *TrafficCounter
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.counter.BytesIn.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.counter.BytesOut.Add(int64(n))
+	return n, err
+}
+
+var trafficLock = &sync.Mutex{}
+var trafficCounters = make(map[*ssh.Client]*TrafficCounter)
+
+// the counter is dropped when the client is closed
+func registerTrafficCounter(client *ssh.Client, counter *TrafficCounter) {
+	trafficLock.Lock()
+	trafficCounters[client] = counter
+	trafficLock.Unlock()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("remote:registerTrafficCounter", recover())
+		}()
+		client.Wait()
+		trafficLock.Lock()
+		delete(trafficCounters, client)
+		trafficLock.Unlock()
+	}()
+}
+
+// the traffic of a client made by ConnectToClient (nil once the client is closed)
+func GetTrafficCounter(client *ssh.Client) *TrafficCounter {
+	trafficLock.Lock()
+	defer trafficLock.Unlock()
+	return trafficCounters[client]
+}
@@ -0,0 +1,298 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remoteaccess
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/fesession"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// what a session can use: the windows that show an exposed workspace (only its own window, for a session bound
+// to a window), and the tabs and blocks in them.  every service call, rpc, and file request of a session names
+// the objects it uses, they are checked here (and the events sent to a session are filtered the same way).
+// files that are not in a block (local files, files on connections) need Perm_Admin.
+
+const waveFilePrefix = "wavefile://"
+
+// the objects that are not in a workspace, but any session can read (like the full config, see
+// fesession.viewRpcs).  other objects that are not in a workspace (webhooks, temp objects) need Perm_Admin.
+var globalOTypes = map[string]bool{
+	waveobj.OType_Client:     true,
+	waveobj.OType_Connection: true,
+	waveobj.OType_Settings:   true,
+	waveobj.OType_Theme:      true,
+	waveobj.OType_Profile:    true,
+}
+
+// the keys of rpc data (at any depth) that name objects, files, or event scopes
+const (
+	refKind_Block      = "block"
+	refKind_Tab        = "tab"
+	refKind_Workspace  = "workspace"
+	refKind_Window     = "window"
+	refKind_ORef       = "oref"
+	refKind_Zone       = "zone"
+	refKind_File       = "file"
+	refKind_EventScope = "eventscope"
+)
+
+var rpcRefKeys = map[string]string{
+	"blockid":       refKind_Block,
+	"blockids":      refKind_Block,
+	"targetblockid": refKind_Block,
+	"parentblockid": refKind_Block,
+	"logblockid":    refKind_Block,
+	"tabid":         refKind_Tab,
+	"workspaceid":   refKind_Workspace,
+	"windowid":      refKind_Window,
+	"oref":          refKind_ORef,
+	"zoneid":        refKind_Zone,
+	"targetzoneid":  refKind_Zone,
+	"path":          refKind_File,
+	"srcpath":       refKind_File,
+	"destpath":      refKind_File,
+	"localpath":     refKind_File,
+	"remotepath":    refKind_File,
+	"uri":           refKind_File,
+	"srcuri":        refKind_File,
+	"desturi":       refKind_File,
+	"dir":           refKind_File,
+	"file:cwd":      refKind_File,
+	"scope":         refKind_EventScope,
+	"scopes":        refKind_EventScope,
+}
+
+func CheckWindowAccess(ctx context.Context, scope *fesession.Session, windowId string) error {
+	err := scope.CheckWindow(windowId)
+	if err != nil {
+		return err
+	}
+	window, err := wstore.DBGet[*waveobj.Window](ctx, windowId)
+	if err != nil || window == nil {
+		return fmt.Errorf("window %s not found", windowId)
+	}
+	ws, err := wstore.DBGet[*waveobj.Workspace](ctx, window.WorkspaceId)
+	if err != nil || !IsWorkspaceExposed(ws) {
+		return fmt.Errorf("window %s does not show an exposed workspace", windowId)
+	}
+	return nil
+}
+
+func CheckWorkspaceAccess(ctx context.Context, scope *fesession.Session, workspaceId string) error {
+	windowId, err := wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+	if err != nil || windowId == "" {
+		return fmt.Errorf("workspace %s is not open in a wave window", workspaceId)
+	}
+	return CheckWindowAccess(ctx, scope, windowId)
+}
+
+// returns an error if the tab is not in an exposed workspace (or not in the session's window)
+func CheckTabAccess(ctx context.Context, scope *fesession.Session, tabId string) error {
+	windowId := eventbus.GetTabWindowId(tabId)
+	if windowId == "" {
+		return fmt.Errorf("tab %s not found (or not open in a wave window)", tabId)
+	}
+	return CheckWindowAccess(ctx, scope, windowId)
+}
+
+// detached blocks are not in any session's scope
+func CheckBlockAccess(ctx context.Context, scope *fesession.Session, blockId string) error {
+	tabId := eventbus.GetBlockTabId(blockId)
+	if tabId == "" {
+		return fmt.Errorf("block %s not found (or not in a tab)", blockId)
+	}
+	return CheckTabAccess(ctx, scope, tabId)
+}
+
+func CheckORefAccess(ctx context.Context, scope *fesession.Session, oref waveobj.ORef) error {
+	switch oref.OType {
+	case waveobj.OType_Block:
+		return CheckBlockAccess(ctx, scope, oref.OID)
+	case waveobj.OType_Tab:
+		return CheckTabAccess(ctx, scope, oref.OID)
+	case waveobj.OType_Workspace:
+		return CheckWorkspaceAccess(ctx, scope, oref.OID)
+	case waveobj.OType_Window:
+		return CheckWindowAccess(ctx, scope, oref.OID)
+	case waveobj.OType_LayoutState:
+		tabId, err := wstore.DBFindTabForLayoutId(ctx, oref.OID)
+		if err != nil || tabId == "" {
+			return fmt.Errorf("layout %s not found", oref.OID)
+		}
+		return CheckTabAccess(ctx, scope, tabId)
+	case waveobj.OType_Notification:
+		// notifications from a block are checked like the block, the others are not about a workspace
+		notif, err := wstore.DBGet[*waveobj.Notification](ctx, oref.OID)
+		if err == nil && notif != nil && notif.BlockId != "" {
+			return CheckBlockAccess(ctx, scope, notif.BlockId)
+		}
+		return scope.CheckPerm(fesession.Perm_Admin, fmt.Sprintf("using %s", oref))
+	}
+	if globalOTypes[oref.OType] {
+		return nil
+	}
+	return scope.CheckPerm(fesession.Perm_Admin, fmt.Sprintf("using %s", oref))
+}
+
+// the files of a block are checked like the block, other zones (e.g. the client's) need Perm_Admin
+func CheckZoneAccess(ctx context.Context, scope *fesession.Session, zoneId string) error {
+	exists, err := wstore.DBExistsORef(ctx, waveobj.MakeORef(waveobj.OType_Block, zoneId))
+	if err == nil && exists {
+		return CheckBlockAccess(ctx, scope, zoneId)
+	}
+	return scope.CheckPerm(fesession.Perm_Admin, fmt.Sprintf("using the files of %q", zoneId))
+}
+
+// a wave file (wavefile://<zoneid>/<name>) is checked like its zone, any other file (a local file, or a file on a
+// connection) needs Perm_Admin
+func CheckFileAccess(ctx context.Context, scope *fesession.Session, path string) error {
+	if rest, ok := strings.CutPrefix(path, waveFilePrefix); ok {
+		zoneId, _, _ := strings.Cut(rest, "/")
+		return CheckZoneAccess(ctx, scope, zoneId)
+	}
+	return scope.CheckPerm(fesession.Perm_Admin, fmt.Sprintf("using file %q", path))
+}
+
+// event scopes are orefs, window ids (e.g. user input requests), or names that are not about a workspace
+// (connections, routes)
+func CheckEventScope(ctx context.Context, scope *fesession.Session, eventScope string) error {
+	if oref, err := waveobj.ParseORef(eventScope); err == nil {
+		return CheckORefAccess(ctx, scope, oref)
+	}
+	if _, err := uuid.Parse(eventScope); err == nil {
+		return CheckWindowAccess(ctx, scope, eventScope)
+	}
+	return nil
+}
+
+// true if the session can get an event with the scopes (every scope must be allowed).  events without scopes
+// are not about a workspace (e.g. config changes).
+func CanSeeEvent(ctx context.Context, scope *fesession.Session, eventScopes []string) bool {
+	if scope.IsRevoked() {
+		return false
+	}
+	for _, eventScope := range eventScopes {
+		if CheckEventScope(ctx, scope, eventScope) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// the routes a session can send to (or send as).  connRouteId is the route of the session's own connection.
+func checkRpcRoute(ctx context.Context, scope *fesession.Session, routeId string, connRouteId string) error {
+	if routeId == "" || routeId == wshutil.DefaultRoute || routeId == connRouteId {
+		return nil
+	}
+	if strings.HasPrefix(routeId, wshutil.RoutePrefix_Conn) || strings.HasPrefix(routeId, wshutil.RoutePrefix_Proc) {
+		// the rpcs sent to connections and processes are checked by their data
+		return nil
+	}
+	if blockId, ok := strings.CutPrefix(routeId, wshutil.RoutePrefix_Controller); ok {
+		return CheckBlockAccess(ctx, scope, blockId)
+	}
+	if blockId, ok := strings.CutPrefix(routeId, wshutil.RoutePrefix_FeBlock); ok {
+		return CheckBlockAccess(ctx, scope, blockId)
+	}
+	if tabId, ok := strings.CutPrefix(routeId, wshutil.RoutePrefix_Tab); ok {
+		return CheckTabAccess(ctx, scope, tabId)
+	}
+	return scope.CheckPerm(fesession.Perm_Admin, fmt.Sprintf("using route %q", routeId))
+}
+
+func checkRpcRef(ctx context.Context, scope *fesession.Session, kind string, value string) error {
+	if value == "" {
+		return nil
+	}
+	switch kind {
+	case refKind_Block:
+		return CheckBlockAccess(ctx, scope, value)
+	case refKind_Tab:
+		return CheckTabAccess(ctx, scope, value)
+	case refKind_Workspace:
+		return CheckWorkspaceAccess(ctx, scope, value)
+	case refKind_Window:
+		return CheckWindowAccess(ctx, scope, value)
+	case refKind_ORef:
+		oref, err := waveobj.ParseORef(value)
+		if err != nil {
+			return err
+		}
+		return CheckORefAccess(ctx, scope, oref)
+	case refKind_Zone:
+		return CheckZoneAccess(ctx, scope, value)
+	case refKind_File:
+		return CheckFileAccess(ctx, scope, value)
+	case refKind_EventScope:
+		return CheckEventScope(ctx, scope, value)
+	}
+	return nil
+}
+
+// checks the objects, files, and event scopes named in rpc data (in nested objects too)
+func checkRpcData(ctx context.Context, scope *fesession.Session, data any) error {
+	switch data := data.(type) {
+	case map[string]any:
+		for key, val := range data {
+			kind := rpcRefKeys[key]
+			if strVal, ok := val.(string); ok && kind != "" {
+				err := checkRpcRef(ctx, scope, kind, strVal)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if listVal, ok := val.([]any); ok && kind != "" {
+				for _, elem := range listVal {
+					if strElem, ok := elem.(string); ok {
+						err := checkRpcRef(ctx, scope, kind, strElem)
+						if err != nil {
+							return err
+						}
+					}
+				}
+				continue
+			}
+			err := checkRpcData(ctx, scope, val)
+			if err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, elem := range data {
+			err := checkRpcData(ctx, scope, elem)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// returns an error unless the session can use everything an rpc names: its source and route, and the objects,
+// files, and event scopes in its data (all scopes subscriptions are allowed, the events are filtered when they
+// are sent).  connRouteId is the route of the session's connection.
+func CheckRpcAccess(ctx context.Context, scope *fesession.Session, connRouteId string, msg *wshutil.RpcMessage) error {
+	err := checkRpcRoute(ctx, scope, msg.Source, connRouteId)
+	if err != nil {
+		return err
+	}
+	err = checkRpcRoute(ctx, scope, msg.Route, connRouteId)
+	if err != nil {
+		return err
+	}
+	if blockId, ok := msg.Data.(string); ok && msg.Command == wshrpc.Command_BlockInfo {
+		return CheckBlockAccess(ctx, scope, blockId)
+	}
+	return checkRpcData(ctx, scope, msg.Data)
+}
@@ -0,0 +1,121 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remoteaccess
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/fesession"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+type testWorkspace struct {
+	WorkspaceId string
+	WindowId    string
+	TabId       string
+	BlockId     string
+}
+
+// a workspace open in a window, with a tab and a block
+func makeTestWorkspace(t *testing.T, ctx context.Context, name string) testWorkspace {
+	tw := testWorkspace{WorkspaceId: uuid.NewString(), WindowId: uuid.NewString(), TabId: uuid.NewString(), BlockId: uuid.NewString()}
+	objs := []waveobj.WaveObj{
+		&waveobj.Workspace{OID: tw.WorkspaceId, Name: name, TabIds: []string{tw.TabId}, ActiveTabId: tw.TabId},
+		&waveobj.Window{OID: tw.WindowId, WorkspaceId: tw.WorkspaceId},
+		&waveobj.Tab{OID: tw.TabId, BlockIds: []string{tw.BlockId}},
+		&waveobj.Block{OID: tw.BlockId, ParentORef: waveobj.MakeORef(waveobj.OType_Tab, tw.TabId).String()},
+	}
+	for _, obj := range objs {
+		if err := wstore.DBInsert(ctx, obj); err != nil {
+			t.Fatalf("error inserting %T: %v", obj, err)
+		}
+	}
+	return tw
+}
+
+func TestAccessScope(t *testing.T) {
+	wavebase.DataHome_VarCache = t.TempDir()
+	wavebase.ConfigHome_VarCache = t.TempDir()
+	err := os.WriteFile(filepath.Join(wavebase.ConfigHome_VarCache, "settings.json"), []byte(`{"remote:workspaces": ["exposed", "shared"]}`), 0600)
+	if err != nil {
+		t.Fatalf("error writing settings: %v", err)
+	}
+	wconfig.GetWatcher().Start()
+	os.MkdirAll(filepath.Join(wavebase.DataHome_VarCache, wavebase.WaveDBDir), 0700)
+	if err := wstore.InitWStore(); err != nil {
+		t.Fatalf("error initializing wstore: %v", err)
+	}
+	ctx := context.Background()
+	exposed := makeTestWorkspace(t, ctx, "exposed")
+	shared := makeTestWorkspace(t, ctx, "shared")
+	private := makeTestWorkspace(t, ctx, "private")
+	full := &fesession.Session{SessionId: "full", Perms: fesession.AllPerms}
+	share := &fesession.Session{SessionId: "share", WindowId: shared.WindowId, Perms: []string{fesession.Perm_View}}
+
+	if CheckBlockAccess(ctx, full, exposed.BlockId) != nil || CheckBlockAccess(ctx, full, shared.BlockId) != nil {
+		t.Errorf("a password session should use the blocks of the exposed workspaces")
+	}
+	if CheckBlockAccess(ctx, full, private.BlockId) == nil || CheckWorkspaceAccess(ctx, full, private.WorkspaceId) == nil {
+		t.Errorf("a workspace that is not exposed should not be usable")
+	}
+	if CheckBlockAccess(ctx, share, shared.BlockId) != nil || CheckBlockAccess(ctx, share, exposed.BlockId) == nil {
+		t.Errorf("a share session should only use the blocks of its window")
+	}
+	if CheckFileAccess(ctx, full, "/etc/passwd") != nil || CheckFileAccess(ctx, share, "/etc/passwd") == nil {
+		t.Errorf("local files should need the admin permission")
+	}
+	if CheckFileAccess(ctx, share, "wavefile://"+shared.BlockId+"/term") != nil || CheckFileAccess(ctx, share, "wavefile://"+exposed.BlockId+"/term") == nil {
+		t.Errorf("wave files should be checked like their block")
+	}
+
+	readMsg := &wshutil.RpcMessage{
+		Command: wshrpc.Command_FileRead,
+		Data:    map[string]any{"info": map[string]any{"path": "wavefile://" + private.BlockId + "/term"}},
+	}
+	if CheckRpcAccess(ctx, full, "feclient:test", readMsg) == nil {
+		t.Errorf("an rpc should not read a file in a workspace that is not exposed")
+	}
+	subMsg := &wshutil.RpcMessage{
+		Command: wshrpc.Command_EventSub,
+		Data:    map[string]any{"event": "blockfile", "scopes": []any{waveobj.MakeORef(waveobj.OType_Block, exposed.BlockId).String()}},
+	}
+	if CheckRpcAccess(ctx, full, "feclient:test", subMsg) != nil || CheckRpcAccess(ctx, share, "feclient:test", subMsg) == nil {
+		t.Errorf("event subscriptions should be checked against the session's scope")
+	}
+	infoMsg := &wshutil.RpcMessage{Command: wshrpc.Command_BlockInfo, Data: exposed.BlockId}
+	if CheckRpcAccess(ctx, share, "feclient:test", infoMsg) == nil {
+		t.Errorf("blockinfo should be checked against the session's scope")
+	}
+	routeMsg := &wshutil.RpcMessage{Command: wshrpc.Command_Message, Route: wshutil.MakeControllerRouteId(exposed.BlockId)}
+	if CheckRpcAccess(ctx, share, "feclient:test", routeMsg) == nil {
+		t.Errorf("an rpc should not be routed to a block outside of the session's scope")
+	}
+
+	privateEvent := []string{waveobj.MakeORef(waveobj.OType_Block, private.BlockId).String()}
+	if CanSeeEvent(ctx, full, privateEvent) || !CanSeeEvent(ctx, full, nil) {
+		t.Errorf("events should only be sent for the objects in the session's scope")
+	}
+
+	// removing a workspace from remote:workspaces takes effect right away
+	err = os.WriteFile(filepath.Join(wavebase.ConfigHome_VarCache, "settings.json"), []byte(`{"remote:workspaces": ["exposed"]}`), 0600)
+	if err != nil {
+		t.Fatalf("error writing settings: %v", err)
+	}
+	wconfig.GetWatcher().Reload()
+	if CheckTabAccess(ctx, share, shared.TabId) == nil || CheckBlockAccess(ctx, full, shared.BlockId) == nil {
+		t.Errorf("a workspace removed from remote:workspaces should not be usable")
+	}
+	if CheckTabAccess(ctx, full, exposed.TabId) != nil {
+		t.Errorf("the workspaces still in remote:workspaces should stay usable")
+	}
+}
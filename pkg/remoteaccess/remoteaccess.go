@@ -0,0 +1,450 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// the password, login sessions, and exposed workspaces of the browser remote-access mode (the https server
+// that serves the frontend to browsers is in pkg/web/remoteaccess.go)
+package remoteaccess
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/fesession"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+	"golang.org/x/crypto/scrypt"
+)
+
+// the password is stored as a salted scrypt hash in the data dir.  a login creates a session, the browser
+// gets its token in a cookie.  sessions expire when they are not used for "remote:sessionhours" (12 by
+// default), and are only kept in memory (restarting wave logs every browser out).  setting a new password
+// revokes all of the sessions.
+//
+// a password login can do everything (in the exposed workspaces).  a share link opens one workspace without
+// the password, its sessions are bound to the window showing that workspace and only get the link's
+// permissions (e.g. view only).  share links expire, revoking a link also revokes its sessions.  a revoked
+// session loses its permissions right away, also on websockets that are still open.
+
+const DefaultListenAddr = ":61270"
+const PasswordFile = "remote-password"
+const MinPasswordLength = 8
+const DefaultSessionHours = 12
+const DefaultShareHours = 24
+const ShareUrlPrefix = "/share/"
+
+const (
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+type session struct {
+	Id         string
+	Token      string
+	ShareId    string // set if the session was created from a share link
+	RemoteAddr string
+	UserAgent  string
+	CreatedTs  int64
+	LastSeenTs int64
+	Scope      *fesession.Session
+}
+
+type shareLink struct {
+	Id          string
+	Token       string
+	WorkspaceId string
+	Perms       []string
+	CreatedTs   int64
+	ExpiresTs   int64
+}
+
+var sessionLock = &sync.Mutex{}
+var sessions = make(map[string]*session)     // token => session
+var shareLinks = make(map[string]*shareLink) // token => share link
+
+func getPasswordPath() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), PasswordFile)
+}
+
+func hashPassword(password string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(password), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+}
+
+// sets the password (and logs out all of the browsers)
+func SetPassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("the password must be at least %d characters", MinPasswordLength)
+	}
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return fmt.Errorf("error generating salt: %w", err)
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+	line := fmt.Sprintf("scrypt$%s$%s\n", hex.EncodeToString(salt), hex.EncodeToString(hash))
+	err = os.WriteFile(getPasswordPath(), []byte(line), 0600)
+	if err != nil {
+		return fmt.Errorf("error writing password file: %w", err)
+	}
+	RevokeAllSessions()
+	return nil
+}
+
+// returns the salt and hash, both nil if no password is set
+func readPasswordHash() ([]byte, []byte, error) {
+	barr, err := os.ReadFile(getPasswordPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading password file: %w", err)
+	}
+	parts := strings.Split(strings.TrimSpace(string(barr)), "$")
+	if len(parts) != 3 || parts[0] != "scrypt" {
+		return nil, nil, fmt.Errorf("invalid password file %s", getPasswordPath())
+	}
+	salt, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid password file %s", getPasswordPath())
+	}
+	hash, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid password file %s", getPasswordPath())
+	}
+	return salt, hash, nil
+}
+
+func HasPassword() bool {
+	_, hash, err := readPasswordHash()
+	return err == nil && hash != nil
+}
+
+func CheckPassword(password string) bool {
+	salt, hash, err := readPasswordHash()
+	if err != nil || hash == nil {
+		return false
+	}
+	checkHash, err := hashPassword(password, salt)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(hash, checkHash) == 1
+}
+
+func getSessionTTL() time.Duration {
+	hours := wconfig.GetWatcher().GetFullConfig().Settings.RemoteSessionHours
+	if hours <= 0 {
+		hours = DefaultSessionHours
+	}
+	return time.Duration(hours * float64(time.Hour))
+}
+
+func makeToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+func addSession(shareId string, remoteAddr string, userAgent string, windowId string, perms []string) (string, error) {
+	token, err := makeToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UnixMilli()
+	sess := &session{
+		Id:         uuid.NewString(),
+		Token:      token,
+		ShareId:    shareId,
+		RemoteAddr: remoteAddr,
+		UserAgent:  userAgent,
+		CreatedTs:  now,
+		LastSeenTs: now,
+	}
+	sess.Scope = &fesession.Session{SessionId: sess.Id, WindowId: windowId, Perms: perms}
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	sessions[sess.Token] = sess
+	return sess.Token, nil
+}
+
+// creates a session for a browser that logged in with the password, returns its token
+func CreateSession(remoteAddr string, userAgent string) (string, error) {
+	return addSession("", remoteAddr, userAgent, "", fesession.AllPerms)
+}
+
+// returns the scope of the token's session (nil if it is not valid or expired), and marks the session as used
+func CheckSession(token string) *fesession.Session {
+	if token == "" {
+		return nil
+	}
+	ttl := getSessionTTL()
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	sess := sessions[token]
+	if sess == nil {
+		return nil
+	}
+	now := time.Now()
+	if now.Sub(time.UnixMilli(sess.LastSeenTs)) > ttl {
+		delete(sessions, token)
+		return nil
+	}
+	sess.LastSeenTs = now.UnixMilli()
+	return sess.Scope
+}
+
+func ListSessions() []wshrpc.RemoteSessionInfo {
+	ttl := getSessionTTL()
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	var rtn []wshrpc.RemoteSessionInfo
+	for token, sess := range sessions {
+		if time.Since(time.UnixMilli(sess.LastSeenTs)) > ttl {
+			delete(sessions, token)
+			continue
+		}
+		rtn = append(rtn, wshrpc.RemoteSessionInfo{
+			SessionId:  sess.Id,
+			ShareId:    sess.ShareId,
+			Perms:      sess.Scope.Perms,
+			RemoteAddr: sess.RemoteAddr,
+			UserAgent:  sess.UserAgent,
+			CreatedTs:  sess.CreatedTs,
+			LastSeenTs: sess.LastSeenTs,
+		})
+	}
+	sort.Slice(rtn, func(i, j int) bool { return rtn[i].CreatedTs < rtn[j].CreatedTs })
+	return rtn
+}
+
+// revokes the session or share link with the id (or an id prefix), revoking a share link also revokes its
+// sessions.  returns the number of sessions and links revoked.
+func RevokeSession(id string) (int, error) {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	var foundIds []string
+	for _, sess := range sessions {
+		if strings.HasPrefix(sess.Id, id) {
+			foundIds = append(foundIds, sess.Id)
+		}
+	}
+	for _, link := range shareLinks {
+		if strings.HasPrefix(link.Id, id) {
+			foundIds = append(foundIds, link.Id)
+		}
+	}
+	if len(foundIds) == 0 {
+		return 0, fmt.Errorf("session or share link %q not found", id)
+	}
+	if len(foundIds) > 1 && !slices.Contains(foundIds, id) {
+		return 0, fmt.Errorf("id %q is ambiguous", id)
+	}
+	if len(foundIds) > 1 {
+		foundIds = []string{id}
+	}
+	numRevoked := 0
+	for token, link := range shareLinks {
+		if link.Id == foundIds[0] {
+			delete(shareLinks, token)
+			numRevoked++
+		}
+	}
+	for token, sess := range sessions {
+		if sess.Id == foundIds[0] || sess.ShareId == foundIds[0] {
+			sess.Scope.Revoke()
+			delete(sessions, token)
+			numRevoked++
+		}
+	}
+	return numRevoked, nil
+}
+
+func RevokeSessionByToken(token string) {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	if sess := sessions[token]; sess != nil {
+		sess.Scope.Revoke()
+		delete(sessions, token)
+	}
+}
+
+// revokes all of the sessions and share links, returns the number revoked
+func RevokeAllSessions() int {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	numRevoked := len(sessions) + len(shareLinks)
+	for _, sess := range sessions {
+		sess.Scope.Revoke()
+	}
+	clear(sessions)
+	clear(shareLinks)
+	return numRevoked
+}
+
+// creates a share link for the (exposed) workspace, returns its info (with the token)
+func CreateShareLink(ctx context.Context, workspace string, perms []string, validFor time.Duration) (*wshrpc.RemoteShareInfo, error) {
+	ws, err := FindExposedWorkspace(ctx, workspace)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(perms, fesession.Perm_View) {
+		perms = append([]string{fesession.Perm_View}, perms...)
+	}
+	for _, perm := range perms {
+		if !slices.Contains(fesession.AllPerms, perm) {
+			return nil, fmt.Errorf("invalid permission %q", perm)
+		}
+	}
+	if validFor <= 0 {
+		validFor = DefaultShareHours * time.Hour
+	}
+	token, err := makeToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	link := &shareLink{
+		Id:          uuid.NewString(),
+		Token:       token,
+		WorkspaceId: ws.OID,
+		Perms:       perms,
+		CreatedTs:   now.UnixMilli(),
+		ExpiresTs:   now.Add(validFor).UnixMilli(),
+	}
+	sessionLock.Lock()
+	shareLinks[token] = link
+	sessionLock.Unlock()
+	rtn := makeShareInfo(link, ws.Name)
+	rtn.Token = token
+	return &rtn, nil
+}
+
+func makeShareInfo(link *shareLink, wsName string) wshrpc.RemoteShareInfo {
+	return wshrpc.RemoteShareInfo{
+		ShareId:       link.Id,
+		WorkspaceId:   link.WorkspaceId,
+		WorkspaceName: wsName,
+		Perms:         link.Perms,
+		CreatedTs:     link.CreatedTs,
+		ExpiresTs:     link.ExpiresTs,
+	}
+}
+
+func ListShareLinks(ctx context.Context) []wshrpc.RemoteShareInfo {
+	sessionLock.Lock()
+	var links []*shareLink
+	now := time.Now().UnixMilli()
+	for token, link := range shareLinks {
+		if now > link.ExpiresTs {
+			delete(shareLinks, token)
+			continue
+		}
+		links = append(links, link)
+	}
+	sessionLock.Unlock()
+	var rtn []wshrpc.RemoteShareInfo
+	for _, link := range links {
+		var wsName string
+		if ws, err := wstore.DBGet[*waveobj.Workspace](ctx, link.WorkspaceId); err == nil && ws != nil {
+			wsName = ws.Name
+		}
+		rtn = append(rtn, makeShareInfo(link, wsName))
+	}
+	sort.Slice(rtn, func(i, j int) bool { return rtn[i].CreatedTs < rtn[j].CreatedTs })
+	return rtn
+}
+
+// creates a session for a browser that opened a share link, bound to the window that shows the link's
+// workspace.  returns the session token and the workspace id.
+func CreateShareSession(ctx context.Context, shareToken string, remoteAddr string, userAgent string) (string, string, error) {
+	sessionLock.Lock()
+	link := shareLinks[shareToken]
+	if link != nil && time.Now().UnixMilli() > link.ExpiresTs {
+		delete(shareLinks, shareToken)
+		link = nil
+	}
+	sessionLock.Unlock()
+	if link == nil {
+		return "", "", fmt.Errorf("this share link is not valid (it may have expired)")
+	}
+	ws, err := wstore.DBGet[*waveobj.Workspace](ctx, link.WorkspaceId)
+	if err != nil || !IsWorkspaceExposed(ws) {
+		return "", "", fmt.Errorf("the shared workspace is no longer exposed")
+	}
+	windowId, err := wstore.DBFindWindowForWorkspaceId(ctx, link.WorkspaceId)
+	if err != nil || windowId == "" {
+		return "", "", fmt.Errorf("the shared workspace is not open in a wave window")
+	}
+	token, err := addSession(link.Id, remoteAddr, userAgent, windowId, link.Perms)
+	if err != nil {
+		return "", "", err
+	}
+	return token, link.WorkspaceId, nil
+}
+
+// the url of a share link (this machine's hostname and the remote-access port)
+func GetShareUrl(token string, listenAddr string) string {
+	hostName, err := os.Hostname()
+	if err != nil || hostName == "" {
+		hostName = "localhost"
+	}
+	_, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		port = ""
+	}
+	if port != "" {
+		hostName = net.JoinHostPort(hostName, port)
+	}
+	return "https://" + hostName + ShareUrlPrefix + token
+}
+
+// finds an exposed workspace by id or name
+func FindExposedWorkspace(ctx context.Context, workspace string) (*waveobj.Workspace, error) {
+	workspaces, err := wstore.DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
+	if err != nil {
+		return nil, err
+	}
+	for _, ws := range workspaces {
+		if (ws.OID == workspace || ws.Name == workspace) && IsWorkspaceExposed(ws) {
+			return ws, nil
+		}
+	}
+	return nil, fmt.Errorf("workspace %q is not exposed (see remote:workspaces)", workspace)
+}
+
+// true if the workspace is in "remote:workspaces" (by id or name)
+func IsWorkspaceExposed(ws *waveobj.Workspace) bool {
+	if ws == nil {
+		return false
+	}
+	for _, entry := range wconfig.GetWatcher().GetFullConfig().Settings.RemoteWorkspaces {
+		if entry == ws.OID || (ws.Name != "" && entry == ws.Name) {
+			return true
+		}
+	}
+	return false
+}
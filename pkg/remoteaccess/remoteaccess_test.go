@@ -0,0 +1,85 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remoteaccess
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/fesession"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestPasswordAndSessions(t *testing.T) {
+	wavebase.DataHome_VarCache = t.TempDir()
+	if HasPassword() || CheckPassword("") {
+		t.Fatalf("no password should be set")
+	}
+	if err := SetPassword("short"); err == nil {
+		t.Errorf("a short password should be rejected")
+	}
+	if err := SetPassword("correct horse"); err != nil {
+		t.Fatalf("error setting password: %v", err)
+	}
+	if !CheckPassword("correct horse") || CheckPassword("wrong horse") {
+		t.Errorf("password check failed")
+	}
+	token, err := CreateSession("10.0.0.1:5000", "test")
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+	scope := CheckSession(token)
+	if scope == nil || CheckSession("bad-token") != nil {
+		t.Fatalf("session check failed")
+	}
+	if scope.WindowId != "" || !scope.HasPerm(fesession.Perm_Admin) {
+		t.Errorf("a password session should not be restricted, got %+v", scope)
+	}
+	if sessions := ListSessions(); len(sessions) != 1 || sessions[0].SessionId != scope.SessionId {
+		t.Fatalf("expected the session to be listed, got %v", sessions)
+	}
+	if _, err := RevokeSession(scope.SessionId[:8]); err != nil {
+		t.Fatalf("error revoking session: %v", err)
+	}
+	if CheckSession(token) != nil {
+		t.Errorf("a revoked session should not be valid")
+	}
+	if scope.HasPerm(fesession.Perm_View) {
+		t.Errorf("a revoked session should not have any permissions")
+	}
+	token, _ = CreateSession("10.0.0.1:5000", "test")
+	if err := SetPassword("another password"); err != nil {
+		t.Fatalf("error setting password: %v", err)
+	}
+	if CheckSession(token) != nil {
+		t.Errorf("setting the password should revoke the sessions")
+	}
+}
+
+func TestRevokeShareLink(t *testing.T) {
+	now := time.Now().UnixMilli()
+	link := &shareLink{Id: "share-1", Token: "share-token", Perms: []string{fesession.Perm_View}, CreatedTs: now, ExpiresTs: now + 60000}
+	sessionLock.Lock()
+	shareLinks[link.Token] = link
+	sessionLock.Unlock()
+	token, err := addSession(link.Id, "10.0.0.2:5000", "test", "window-1", link.Perms)
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+	scope := CheckSession(token)
+	if scope == nil || scope.WindowId != "window-1" {
+		t.Fatalf("expected a session bound to the window, got %+v", scope)
+	}
+	if scope.CheckRpc(wshrpc.Command_FileRead) != nil || scope.CheckRpc(wshrpc.Command_ControllerInput) == nil {
+		t.Errorf("a view-only session should only be able to read")
+	}
+	numRevoked, err := RevokeSession(link.Id)
+	if err != nil || numRevoked != 2 {
+		t.Fatalf("expected the link and its session to be revoked, got %d %v", numRevoked, err)
+	}
+	if CheckSession(token) != nil || len(shareLinks) != 0 {
+		t.Errorf("revoking a share link should revoke its sessions")
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package roam is a transport for remote shells that survives network changes and long suspends (like mosh).  a
+// server started over ssh (wsh roamhost) runs the shell in a ptyhost helper (see pkg/ptyhost) and serves it over
+// udp.  the packets are encrypted with a key that only goes over ssh, and the server sends to the address of the
+// newest packet it got from the client, so the session follows the client when its address changes.  the ptyhost
+// frames go over streams that are retransmitted until they are acked, a client that was away (or suspended)
+// continues where it stopped, and the output the shell wrote in the meantime is replayed when it is back.
+package roam
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPortRange   = "60001-61000"
+	DefaultIdleTimeout = 72 * time.Hour // a session that has not heard from the other side for this long is closed
+
+	HandshakeTimeout   = 3 * time.Second  // for the server to answer the first packets of a client
+	FirstAttachTimeout = 15 * time.Second // for the client to open its first stream, or the server exits
+	FlushTimeout       = 5 * time.Second  // for the last frames to be acked when a session is closed
+)
+
+const (
+	keySize       = 32
+	headerSize    = 9 // [counter:8][dir:1]
+	msgHeaderSize = 21
+	maxPayload    = 1200 // stays below the path mtu of most networks (with the headers and the gcm tag)
+	maxPacketSize = 2048
+
+	sendWindow      = 256 * 1024 // bytes of a stream in flight
+	maxSendBuffer   = 1024 * 1024
+	maxRecvBuffer   = 1024 * 1024
+	maxBurst        = 64 // packets sent per stream per tick
+	dupAckThreshold = 3
+	tickInterval    = 20 * time.Millisecond
+	pingInterval    = time.Second
+	minRto          = 200 * time.Millisecond
+	maxRto          = 3 * time.Second
+	rebindInterval  = 10 * time.Second // a client that has not heard from the server for this long changes its port
+)
+
+const (
+	dir_ClientToServer = 0
+	dir_ServerToClient = 1
+)
+
+const (
+	flag_Syn  = 1 // the first packets of a stream, opened by the client
+	flag_Fin  = 2 // no data follows Offset (the fin takes one offset)
+	flag_Rst  = 4 // the stream does not exist
+	flag_Ping = 8 // stream 0, asks for a pong echoing Offset
+	flag_Pong = 16
+)
+
+var ErrClosed = errors.New("roam session closed")
+var ErrReset = errors.New("roam stream reset by the other side")
+var ErrIdleTimeout = errors.New("roam session timed out (nothing heard from the other side)")
+
+// sent to the server on stdin when it is started
+type ServerSpec struct {
+	Cmd       string   `json:"cmd"` // run with the login shell of the user, like an ssh command
+	Env       []string `json:"env,omitempty"`
+	Rows      int      `json:"rows"`
+	Cols      int      `json:"cols"`
+	PortRange string   `json:"portrange,omitempty"` // "first-last", DefaultPortRange if not set
+}
+
+// written by the server to stdout once it is listening
+type ServerInfo struct {
+	Port  int    `json:"port,omitempty"`
+	Key   string `json:"key,omitempty"` // base64
+	Pid   int    `json:"pid,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// the plaintext of a packet
+type message struct {
+	StreamId uint32
+	Flags    byte
+	Ack      uint64 // the offset of the peer's stream received in order (data and fin)
+	Offset   uint64 // the offset of Data in the stream (the echoed value for pings and pongs)
+	Data     []byte
+}
+
+func (m *message) marshal() []byte {
+	barr := make([]byte, msgHeaderSize+len(m.Data))
+	binary.BigEndian.PutUint32(barr[0:4], m.StreamId)
+	barr[4] = m.Flags
+	binary.BigEndian.PutUint64(barr[5:13], m.Ack)
+	binary.BigEndian.PutUint64(barr[13:21], m.Offset)
+	copy(barr[msgHeaderSize:], m.Data)
+	return barr
+}
+
+func unmarshalMessage(barr []byte) (*message, error) {
+	if len(barr) < msgHeaderSize {
+		return nil, fmt.Errorf("roam message too short (%d bytes)", len(barr))
+	}
+	return &message{
+		StreamId: binary.BigEndian.Uint32(barr[0:4]),
+		Flags:    barr[4],
+		Ack:      binary.BigEndian.Uint64(barr[5:13]),
+		Offset:   binary.BigEndian.Uint64(barr[13:21]),
+		Data:     barr[msgHeaderSize:],
+	}, nil
+}
+
+// a packet is [counter:8][dir:1][aes-gcm sealed message], the nonce is [dir:1][0:3][counter:8].  each side counts
+// its own packets, so a nonce is never used twice with the key of a session.
+type packetCipher struct {
+	aead cipher.AEAD
+}
+
+func makePacketCipher(key []byte) (*packetCipher, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid roam key size %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &packetCipher{aead: aead}, nil
+}
+
+func makeNonce(dir byte, counter uint64) []byte {
+	nonce := make([]byte, 12)
+	nonce[0] = dir
+	binary.BigEndian.PutUint64(nonce[4:], counter)
+	return nonce
+}
+
+func (pc *packetCipher) seal(dir byte, counter uint64, m *message) []byte {
+	header := make([]byte, headerSize, headerSize+msgHeaderSize+len(m.Data)+pc.aead.Overhead())
+	binary.BigEndian.PutUint64(header[0:8], counter)
+	header[8] = dir
+	return pc.aead.Seal(header, makeNonce(dir, counter), m.marshal(), header)
+}
+
+// returns the counter and the message of a packet sent in direction dir
+func (pc *packetCipher) open(dir byte, packet []byte) (uint64, *message, error) {
+	if len(packet) < headerSize+pc.aead.Overhead() {
+		return 0, nil, fmt.Errorf("roam packet too short (%d bytes)", len(packet))
+	}
+	counter := binary.BigEndian.Uint64(packet[0:8])
+	if packet[8] != dir {
+		return 0, nil, fmt.Errorf("roam packet has the wrong direction")
+	}
+	plain, err := pc.aead.Open(nil, makeNonce(dir, counter), packet[headerSize:], packet[:headerSize])
+	if err != nil {
+		return 0, nil, fmt.Errorf("roam packet does not authenticate: %w", err)
+	}
+	m, err := unmarshalMessage(plain)
+	if err != nil {
+		return 0, nil, err
+	}
+	return counter, m, nil
+}
+
+func makeKey() ([]byte, string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", fmt.Errorf("error generating the roam key: %w", err)
+	}
+	return key, base64.StdEncoding.EncodeToString(key), nil
+}
+
+func decodeKey(keyStr string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(keyStr)
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("invalid roam key")
+	}
+	return key, nil
+}
+
+// parses "first-last" (or a single port)
+func ParsePortRange(portRange string) (int, int, error) {
+	if portRange == "" {
+		portRange = DefaultPortRange
+	}
+	firstStr, lastStr, found := strings.Cut(portRange, "-")
+	if !found {
+		lastStr = firstStr
+	}
+	first, err1 := strconv.Atoi(strings.TrimSpace(firstStr))
+	last, err2 := strconv.Atoi(strings.TrimSpace(lastStr))
+	if err1 != nil || err2 != nil || first <= 0 || last > 65535 || first > last {
+		return 0, 0, fmt.Errorf("invalid port range %q (expected \"first-last\")", portRange)
+	}
+	return first, last, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package roam
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPacketSealOpen(t *testing.T) {
+	key, _, err := makeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc, _ := makePacketCipher(key)
+	m := &message{StreamId: 3, Flags: flag_Fin, Ack: 10, Offset: 20, Data: []byte("hello")}
+	packet := pc.seal(dir_ClientToServer, 7, m)
+	counter, opened, err := pc.open(dir_ClientToServer, packet)
+	if err != nil || counter != 7 || opened.StreamId != 3 || opened.Flags != flag_Fin || opened.Ack != 10 || opened.Offset != 20 || string(opened.Data) != "hello" {
+		t.Fatalf("unexpected message %+v (counter %d, %v)", opened, counter, err)
+	}
+	if _, _, err := pc.open(dir_ServerToClient, packet); err == nil {
+		t.Errorf("expected a packet of the other direction to be refused")
+	}
+	packet[len(packet)-1] ^= 1
+	if _, _, err := pc.open(dir_ClientToServer, packet); err == nil {
+		t.Errorf("expected a modified packet to be refused")
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	if first, last, err := ParsePortRange(""); err != nil || first != 60001 || last != 61000 {
+		t.Errorf("unexpected default range %d-%d (%v)", first, last, err)
+	}
+	if first, last, err := ParsePortRange("7000"); err != nil || first != 7000 || last != 7000 {
+		t.Errorf("unexpected single port range %d-%d (%v)", first, last, err)
+	}
+	for _, bad := range []string{"b-c", "200-100", "0-10", "1-70000"} {
+		if _, _, err := ParsePortRange(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+// relays the packets between a client and a server, dropping some of them.  moveClient makes the packets of the
+// client come from a new address (like a client that changed networks).
+type lossyRelay struct {
+	t          *testing.T
+	listenConn *net.UDPConn
+	serverAddr *net.UDPAddr
+	lock       sync.Mutex
+	clientAddr *net.UDPAddr
+	upConn     *net.UDPConn
+	count      atomic.Int64
+	dropEvery  int64
+}
+
+func startLossyRelay(t *testing.T, serverAddr *net.UDPAddr, dropEvery int64) *lossyRelay {
+	listenConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &lossyRelay{t: t, listenConn: listenConn, serverAddr: serverAddr, dropEvery: dropEvery}
+	r.moveClient()
+	t.Cleanup(func() {
+		listenConn.Close()
+		r.lock.Lock()
+		r.upConn.Close()
+		r.lock.Unlock()
+	})
+	go func() {
+		buf := make([]byte, maxPacketSize)
+		for {
+			n, addr, err := listenConn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			r.lock.Lock()
+			r.clientAddr = addr
+			upConn := r.upConn
+			r.lock.Unlock()
+			if !r.drop() {
+				upConn.WriteToUDP(buf[:n], serverAddr)
+			}
+		}
+	}()
+	return r
+}
+
+func (r *lossyRelay) drop() bool {
+	return r.count.Add(1)%r.dropEvery == 0
+}
+
+func (r *lossyRelay) moveClient() {
+	upConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.lock.Lock()
+	oldConn := r.upConn
+	r.upConn = upConn
+	r.lock.Unlock()
+	if oldConn != nil {
+		oldConn.Close()
+	}
+	go func() {
+		buf := make([]byte, maxPacketSize)
+		for {
+			n, _, err := upConn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			r.lock.Lock()
+			clientAddr := r.clientAddr
+			r.lock.Unlock()
+			if clientAddr != nil && !r.drop() {
+				r.listenConn.WriteToUDP(buf[:n], clientAddr)
+			}
+		}
+	}()
+}
+
+func (r *lossyRelay) port() int {
+	return r.listenConn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func makeTestData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 253)
+	}
+	return data
+}
+
+// writes data and reads back what the other side echoes
+func echoRoundTrip(t *testing.T, st *Stream, data []byte) {
+	go st.Write(data)
+	st.SetReadDeadline(time.Now().Add(20 * time.Second))
+	echoed := make([]byte, len(data))
+	if _, err := io.ReadFull(st, echoed); err != nil {
+		t.Fatalf("error reading the echo: %v", err)
+	}
+	if !bytes.Equal(echoed, data) {
+		t.Fatalf("the echoed data does not match")
+	}
+}
+
+func TestStreamLossAndRoaming(t *testing.T) {
+	server, keyStr, err := Listen("40000-50000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.closeNow(ErrClosed)
+	go func() {
+		for {
+			conn, err := server.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+	serverAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: server.Addr().(*net.UDPAddr).Port}
+	relay := startLossyRelay(t, serverAddr, 7)
+	client, err := Dial(context.Background(), "127.0.0.1", relay.port(), keyStr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.closeNow(ErrClosed)
+	st, err := client.OpenStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	echoRoundTrip(t, st, makeTestData(300000))
+	firstAddr := server.RemoteAddr().String()
+
+	// the server follows the client to its new address
+	relay.moveClient()
+	echoRoundTrip(t, st, makeTestData(50000))
+	if server.RemoteAddr().String() == firstAddr {
+		t.Errorf("expected the server to send to the new address of the client")
+	}
+
+	// a closed stream delivers everything written before the fin
+	st.Close()
+	st2, _ := client.OpenStream()
+	echoRoundTrip(t, st2, []byte("second stream"))
+
+	// a key that does not authenticate gets no answer
+	_, badKey, _ := makeKey()
+	if _, err := Dial(context.Background(), "127.0.0.1", serverAddr.Port, badKey); err == nil {
+		t.Errorf("expected no answer for a client with the wrong key")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package roam
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/ptyhost"
+	"github.com/wavetermdev/waveterm/pkg/util/sigutil"
+)
+
+const StartTimeout = 5 * time.Second
+
+// starts the server in its own session, so it outlives the ssh session that starts it.  serveArgs are the
+// arguments of this executable that call RunServer.  returns the ServerInfo of the server.
+func StartServer(serveArgs []string, spec ServerSpec) (*ServerInfo, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("error getting executable path: %w", err)
+	}
+	specBarr, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(exePath, serveArgs...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting the roam server: %w", err)
+	}
+	stdin.Write(append(specBarr, '\n'))
+	stdin.Close()
+	infoCh := make(chan ServerInfo, 1)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("roam:read-info", recover())
+		}()
+		var info ServerInfo
+		line, err := bufio.NewReader(stdout).ReadBytes('\n')
+		if err != nil {
+			info.Error = fmt.Sprintf("error reading from the roam server: %v", err)
+		} else if err := json.Unmarshal(line, &info); err != nil {
+			info.Error = fmt.Sprintf("error parsing the roam server output: %v", err)
+		}
+		infoCh <- info
+	}()
+	select {
+	case info := <-infoCh:
+		if info.Error != "" {
+			return nil, fmt.Errorf("roam server: %s", info.Error)
+		}
+		return &info, nil
+	case <-time.After(StartTimeout):
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("timeout waiting for the roam server to start")
+	}
+}
+
+func writeInfo(info ServerInfo) {
+	barr, _ := json.Marshal(info)
+	os.Stdout.Write(append(barr, '\n'))
+}
+
+// entry point for the server.  reads a ServerSpec from stdin, listens, writes its ServerInfo to stdout, and starts
+// the shell when the client opens its first stream (a client that can't reach the server falls back to ssh before
+// anything runs).  returns when the shell has exited and its exit code was delivered, or the client was not heard
+// from for DefaultIdleTimeout.
+func RunServer() error {
+	log.SetPrefix("[roamhost] ")
+	sigutil.InstallDetachSignalHandlers()
+	var spec ServerSpec
+	if err := json.NewDecoder(os.Stdin).Decode(&spec); err != nil {
+		writeInfo(ServerInfo{Error: fmt.Sprintf("error reading the server spec: %v", err)})
+		return err
+	}
+	session, keyStr, err := Listen(spec.PortRange)
+	if err != nil {
+		writeInfo(ServerInfo{Error: err.Error()})
+		return err
+	}
+	writeInfo(ServerInfo{Port: session.Addr().(*net.UDPAddr).Port, Key: keyStr, Pid: os.Getpid()})
+	os.Stdin.Close()
+	os.Stdout.Close()
+	first, err := session.AcceptTimeout(FirstAttachTimeout)
+	if err != nil {
+		session.closeNow(ErrClosed)
+		return err
+	}
+	ptySpec, err := ptyhost.MakeShellCmdSpec(spec.Cmd, spec.Env, spec.Rows, spec.Cols)
+	if err != nil {
+		first.Close()
+		session.Close()
+		return err
+	}
+	return serveShell(session, first, ptySpec)
+}
+
+func serveShell(session *Session, first net.Conn, ptySpec ptyhost.StartSpec) error {
+	// a session that times out kills the shell, nobody is left to use it
+	killCh := make(chan struct{})
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("roam:idle-kill", recover())
+		}()
+		<-session.Done()
+		close(killCh)
+	}()
+	err := ptyhost.Serve(ptySpec, &firstListener{Session: session, first: first}, ptyhost.HostOpts{KillCh: killCh, ExitLinger: DefaultIdleTimeout})
+	session.Close()
+	return err
+}
+
+// returns the stream that was accepted before the shell started, then the next ones (Accept is only called by
+// the accept loop of the host)
+type firstListener struct {
+	*Session
+	first net.Conn
+}
+
+func (l *firstListener) Accept() (net.Conn, error) {
+	if l.first != nil {
+		conn := l.first
+		l.first = nil
+		return conn, nil
+	}
+	return l.Session.Accept()
+}
@@ -0,0 +1,18 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package roam
+
+import "fmt"
+
+// the shell runs in a ptyhost helper, which is not supported on windows (connections to windows hosts use ssh)
+
+func StartServer(serveArgs []string, spec ServerSpec) (*ServerInfo, error) {
+	return nil, fmt.Errorf("the roam server is not supported on windows")
+}
+
+func RunServer() error {
+	return fmt.Errorf("the roam server is not supported on windows")
+}
@@ -0,0 +1,434 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package roam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+)
+
+// one side of a session: the udp socket, the streams, and the loops that receive and send their packets.  the
+// client opens the streams, the server accepts them (a server Session is a net.Listener).  both sides ping every
+// pingInterval, which keeps the nat mappings open and measures the round trip time.
+type Session struct {
+	lock        sync.Mutex
+	isServer    bool
+	cipher      *packetCipher
+	conn        *net.UDPConn
+	peer        *net.UDPAddr // the server follows the address of the newest packet from the client
+	idleTimeout time.Duration
+	sendCounter uint64
+	recvCounter uint64
+	heardFrom   bool
+	contactCh   chan struct{} // closed on the first packet from the other side
+	lastRecv    time.Time
+	lastPing    time.Time
+	lastRebind  time.Time
+	srtt        time.Duration
+	streams     map[uint32]*Stream
+	maxStreamId uint32
+	acceptCh    chan *Stream
+	kickCh      chan struct{}
+	doneCh      chan struct{} // closed when the session is closed (or timed out)
+	err         error
+}
+
+var _ net.Listener = (*Session)(nil)
+
+func makeSession(conn *net.UDPConn, pc *packetCipher, isServer bool, peer *net.UDPAddr) *Session {
+	now := time.Now()
+	s := &Session{
+		isServer:    isServer,
+		cipher:      pc,
+		conn:        conn,
+		peer:        peer,
+		idleTimeout: DefaultIdleTimeout,
+		contactCh:   make(chan struct{}),
+		lastRecv:    now,
+		lastRebind:  now,
+		streams:     make(map[uint32]*Stream),
+		acceptCh:    make(chan *Stream, 16),
+		kickCh:      make(chan struct{}, 1),
+		doneCh:      make(chan struct{}),
+	}
+	go s.readLoop(conn)
+	go s.sendLoop()
+	return s
+}
+
+// listens on a free port of portRange ("first-last"), returns the session and its key (base64)
+func Listen(portRange string) (*Session, string, error) {
+	first, last, err := ParsePortRange(portRange)
+	if err != nil {
+		return nil, "", err
+	}
+	key, keyStr, err := makeKey()
+	if err != nil {
+		return nil, "", err
+	}
+	pc, err := makePacketCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	var conn *net.UDPConn
+	for _, offset := range rand.Perm(last - first + 1) {
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{Port: first + offset})
+		if err == nil {
+			break
+		}
+	}
+	if conn == nil {
+		return nil, "", fmt.Errorf("no free udp port in %d-%d: %w", first, last, err)
+	}
+	return makeSession(conn, pc, true, nil), keyStr, nil
+}
+
+// connects to a server (host is an address of the server, port and key are from its ServerInfo) and waits for
+// it to answer for at most HandshakeTimeout
+func Dial(ctx context.Context, host string, port int, keyStr string) (*Session, error) {
+	key, err := decodeKey(keyStr)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := makePacketCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	peer, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	s := makeSession(conn, pc, false, peer)
+	ctx, cancelFn := context.WithTimeout(ctx, HandshakeTimeout)
+	defer cancelFn()
+	select {
+	case <-s.contactCh:
+		return s, nil
+	case <-ctx.Done():
+		s.closeNow(ErrClosed)
+		return nil, fmt.Errorf("no answer from %s (udp): %w", peer, ctx.Err())
+	}
+}
+
+// must hold lock
+func (s *Session) baseRto() time.Duration {
+	return min(max(2*s.srtt+2*tickInterval, minRto), maxRto)
+}
+
+func (s *Session) kick() {
+	select {
+	case s.kickCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Session) readLoop(conn *net.UDPConn) {
+	defer func() {
+		panichandler.PanicHandler("roam:readLoop", recover())
+	}()
+	recvDir := byte(dir_ServerToClient)
+	if s.isServer {
+		recvDir = dir_ClientToServer
+	}
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// closed, or replaced by a rebind
+			return
+		}
+		counter, m, err := s.cipher.open(recvDir, buf[:n])
+		if err != nil {
+			continue
+		}
+		s.handleMessage(counter, addr, m)
+	}
+}
+
+func (s *Session) handleMessage(counter uint64, addr *net.UDPAddr, m *message) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return
+	}
+	now := time.Now()
+	fresh := !s.heardFrom || counter > s.recvCounter
+	if fresh {
+		s.recvCounter = counter
+		if s.isServer {
+			s.peer = addr
+		}
+	}
+	s.lastRecv = now
+	if !s.heardFrom {
+		s.heardFrom = true
+		close(s.contactCh)
+	}
+	if m.StreamId == 0 {
+		if m.Flags&flag_Ping != 0 {
+			s.sendLocked([]*message{{Flags: flag_Pong, Offset: m.Offset}})
+		}
+		if m.Flags&flag_Pong != 0 && fresh {
+			rtt := now.Sub(time.Unix(0, int64(m.Offset)))
+			if rtt > 0 && rtt < time.Minute {
+				if s.srtt == 0 {
+					s.srtt = rtt
+				} else {
+					s.srtt = (7*s.srtt + rtt) / 8
+				}
+			}
+		}
+		return
+	}
+	st := s.streams[m.StreamId]
+	if st == nil && s.isServer && m.Flags&flag_Syn != 0 && m.StreamId > s.maxStreamId {
+		s.maxStreamId = m.StreamId
+		st = makeStream(s, m.StreamId)
+		select {
+		case s.acceptCh <- st:
+			s.streams[m.StreamId] = st
+		default:
+			st = nil
+		}
+	}
+	if st == nil {
+		if m.Flags&flag_Rst == 0 {
+			s.sendLocked([]*message{{StreamId: m.StreamId, Flags: flag_Rst}})
+		}
+		return
+	}
+	st.handleMessage(m, now)
+	if st.needAck {
+		s.kick()
+	}
+}
+
+// must hold lock
+func (s *Session) sendLocked(msgs []*message) {
+	if s.peer == nil || len(msgs) == 0 {
+		return
+	}
+	sendDir := byte(dir_ClientToServer)
+	if s.isServer {
+		sendDir = dir_ServerToClient
+	}
+	for _, m := range msgs {
+		s.sendCounter++
+		packet := s.cipher.seal(sendDir, s.sendCounter, m)
+		// errors (like a network that is down) are the same as lost packets
+		s.conn.WriteToUDP(packet, s.peer)
+	}
+}
+
+func (s *Session) sendLoop() {
+	defer func() {
+		panichandler.PanicHandler("roam:sendLoop", recover())
+	}()
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.kickCh:
+		case <-s.doneCh:
+			return
+		}
+		s.flush()
+	}
+}
+
+func (s *Session) flush() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(s.lastRecv) > s.idleTimeout {
+		s.closeLocked(ErrIdleTimeout)
+		return
+	}
+	if !s.isServer && now.Sub(s.lastRecv) > rebindInterval && now.Sub(s.lastRebind) > rebindInterval {
+		s.rebindLocked(now)
+	}
+	interval := pingInterval
+	if !s.heardFrom {
+		interval = minRto
+	}
+	if s.peer != nil && now.Sub(s.lastPing) > interval {
+		s.lastPing = now
+		s.sendLocked([]*message{{Flags: flag_Ping, Offset: uint64(now.UnixNano())}})
+	}
+	for id, st := range s.streams {
+		s.sendLocked(st.nextMessages(now))
+		if st.done() {
+			delete(s.streams, id)
+		}
+	}
+}
+
+// must hold lock.  a client that does not hear from the server sends from a new port, a nat that dropped the old
+// mapping (or the new network of the client) then gets a new one.
+func (s *Session) rebindLocked(now time.Time) {
+	s.lastRebind = now
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return
+	}
+	s.conn.Close()
+	s.conn = conn
+	go s.readLoop(conn)
+}
+
+// must hold lock
+func (s *Session) closeLocked(err error) {
+	if s.err != nil {
+		return
+	}
+	if err != ErrClosed {
+		log.Printf("roam session closed: %v\n", err)
+	}
+	s.err = err
+	for _, st := range s.streams {
+		st.fail(err)
+	}
+	s.conn.Close()
+	close(s.doneCh)
+}
+
+func (s *Session) closeNow(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closeLocked(err)
+}
+
+// the next stream opened by the client
+func (s *Session) Accept() (net.Conn, error) {
+	select {
+	case st := <-s.acceptCh:
+		return st, nil
+	case <-s.doneCh:
+		return nil, s.Err()
+	}
+}
+
+// accepts the first stream, or fails after timeout
+func (s *Session) AcceptTimeout(timeout time.Duration) (net.Conn, error) {
+	select {
+	case st := <-s.acceptCh:
+		return st, nil
+	case <-s.doneCh:
+		return nil, s.Err()
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("no roam client within %v", timeout)
+	}
+}
+
+// opens a stream to the server (client sessions)
+func (s *Session) OpenStream() (*Stream, error) {
+	if s.isServer {
+		return nil, errors.New("roam streams are opened by the client")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.maxStreamId++
+	st := makeStream(s, s.maxStreamId)
+	s.streams[st.id] = st
+	s.kick()
+	return st, nil
+}
+
+// closes the session once everything written to its streams has been acked (waits at most FlushTimeout)
+func (s *Session) Close() error {
+	deadline := time.Now().Add(FlushTimeout)
+	for time.Now().Before(deadline) {
+		s.lock.Lock()
+		flushed := s.err != nil
+		if !flushed {
+			flushed = true
+			for _, st := range s.streams {
+				flushed = flushed && st.flushed()
+			}
+		}
+		s.lock.Unlock()
+		if flushed {
+			break
+		}
+		time.Sleep(tickInterval)
+	}
+	s.closeNow(ErrClosed)
+	return nil
+}
+
+func (s *Session) Addr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conn.LocalAddr()
+}
+
+func (s *Session) RemoteAddr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.peer == nil {
+		return nil
+	}
+	return s.peer
+}
+
+func (s *Session) Done() <-chan struct{} {
+	return s.doneCh
+}
+
+// why the session is done (nil while it is open)
+func (s *Session) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.err
+}
+
+// the last time a packet came from the other side
+func (s *Session) LastHeard() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lastRecv
+}
+
+func (s *Session) SetIdleTimeout(timeout time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.idleTimeout = timeout
+}
+
+// the round trip time, smoothed over the pings (0 before the first pong)
+func (s *Session) Rtt() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.srtt
+}
+
+// seeds the round trip time (e.g. with the latency of the ssh connection to the same host) until the first pong,
+// so the first retransmits are not sent too early on a slow link
+func (s *Session) SetRttEstimate(rtt time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.srtt == 0 && rtt > 0 {
+		s.srtt = rtt
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package roam
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/ptyhost"
+)
+
+const reattachDelay = time.Second
+const writeRetryTimeout = 5 * time.Second
+
+const truncatedNotice = "\r\n[output dropped while the connection was away]\r\n"
+
+// the client side of a roaming shell.  the server closes the stream of a client that did not read for a while
+// (see ptyhost.ClientWriteTimeout), the shell is then attached again on a new stream, which replays what the shell
+// wrote in the meantime.  Read returns the output of the shell across the attaches.
+type Shell struct {
+	session   *Session
+	lock      sync.Mutex
+	client    *ptyhost.Client
+	attachCh  chan struct{} // closed (and replaced) when client changes
+	closed    bool
+	outReader *io.PipeReader
+	outWriter *io.PipeWriter
+	doneCh    chan struct{}
+	exitCode  int // written before doneCh is closed
+	waitErr   error
+}
+
+func openClient(session *Session, timeout time.Duration) (*ptyhost.Client, error) {
+	st, err := session.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	return ptyhost.AttachConn(st, timeout)
+}
+
+// attaches to the shell of the server of session (which starts it)
+func AttachShell(session *Session) (*Shell, error) {
+	client, err := openClient(session, FirstAttachTimeout)
+	if err != nil {
+		return nil, err
+	}
+	sh := &Shell{session: session, client: client, attachCh: make(chan struct{}), exitCode: -1, doneCh: make(chan struct{})}
+	sh.outReader, sh.outWriter = io.Pipe()
+	go sh.run()
+	return sh, nil
+}
+
+func (sh *Shell) getClient() (*ptyhost.Client, chan struct{}) {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	return sh.client, sh.attachCh
+}
+
+func (sh *Shell) isClosed() bool {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	return sh.closed
+}
+
+func (sh *Shell) run() {
+	defer func() {
+		panichandler.PanicHandler("roam:Shell.run", recover())
+	}()
+	defer close(sh.doneCh)
+	defer sh.outWriter.Close()
+	client, _ := sh.getClient()
+	for {
+		_, copyErr := io.Copy(sh.outWriter, client)
+		waitErr := client.Wait()
+		if client.Exited() {
+			sh.exitCode = client.ExitCode()
+			sh.waitErr = waitErr
+			return
+		}
+		client.Close()
+		if copyErr != nil || sh.isClosed() {
+			sh.waitErr = fmt.Errorf("roaming shell closed")
+			return
+		}
+		client = sh.reattach()
+		if client == nil {
+			return
+		}
+		if client.Truncated() {
+			sh.outWriter.Write([]byte(truncatedNotice))
+		}
+	}
+}
+
+// returns nil (with waitErr set) if the session is done or the shell was closed
+func (sh *Shell) reattach() *ptyhost.Client {
+	for {
+		if err := sh.session.Err(); err != nil {
+			sh.waitErr = fmt.Errorf("lost the roaming session: %w", err)
+			return nil
+		}
+		if sh.isClosed() {
+			sh.waitErr = fmt.Errorf("roaming shell closed")
+			return nil
+		}
+		client, err := openClient(sh.session, DefaultIdleTimeout)
+		if err == nil {
+			sh.lock.Lock()
+			sh.client = client
+			close(sh.attachCh)
+			sh.attachCh = make(chan struct{})
+			sh.lock.Unlock()
+			return client
+		}
+		time.Sleep(reattachDelay)
+	}
+}
+
+func (sh *Shell) Pid() int {
+	client, _ := sh.getClient()
+	return client.Pid()
+}
+
+func (sh *Shell) Session() *Session {
+	return sh.session
+}
+
+func (sh *Shell) Read(p []byte) (int, error) {
+	return sh.outReader.Read(p)
+}
+
+// the input written while the shell is being attached again goes to the new stream
+func (sh *Shell) Write(p []byte) (int, error) {
+	client, attachCh := sh.getClient()
+	n, err := client.Write(p)
+	if err == nil || sh.isClosed() {
+		return n, err
+	}
+	select {
+	case <-attachCh:
+	case <-sh.doneCh:
+		return n, err
+	case <-time.After(writeRetryTimeout):
+		return n, err
+	}
+	client, _ = sh.getClient()
+	rest, err := client.Write(p[n:])
+	return n + rest, err
+}
+
+func (sh *Shell) SetSize(rows int, cols int) error {
+	client, _ := sh.getClient()
+	return client.SetSize(rows, cols)
+}
+
+func (sh *Shell) Signal(sigName string) error {
+	client, _ := sh.getClient()
+	return client.Signal(sigName)
+}
+
+// a graceful kill hangs up the shell and force kills it after timeout.  a server that does not confirm the kill
+// (by sending the exit code) within FlushTimeout more is given up on, the shell is closed on this side.
+func (sh *Shell) Kill(force bool, timeout time.Duration) error {
+	client, _ := sh.getClient()
+	err := client.Kill(force, timeout)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("roam:Shell.Kill", recover())
+		}()
+		select {
+		case <-sh.doneCh:
+		case <-time.After(timeout + FlushTimeout):
+			sh.Close()
+		}
+	}()
+	return err
+}
+
+func (sh *Shell) Wait() error {
+	<-sh.doneCh
+	return sh.waitErr
+}
+
+// -1 if the shell did not report an exit code
+func (sh *Shell) ExitCode() int {
+	select {
+	case <-sh.doneCh:
+		return sh.exitCode
+	default:
+		return -1
+	}
+}
+
+// closes the session (once what was written is acked, see Session.Close).  the server kills the shell when it does
+// not hear from the client for DefaultIdleTimeout.
+func (sh *Shell) Close() error {
+	sh.lock.Lock()
+	if sh.closed {
+		sh.lock.Unlock()
+		return nil
+	}
+	sh.closed = true
+	client := sh.client
+	sh.lock.Unlock()
+	sh.session.Close()
+	client.Close()
+	sh.outReader.CloseWithError(errors.New("roaming shell closed"))
+	return nil
+}
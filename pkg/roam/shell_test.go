@@ -0,0 +1,103 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package roam
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/ptyhost"
+)
+
+type shellOutput struct {
+	lock sync.Mutex
+	out  bytes.Buffer
+}
+
+func collectShellOutput(sh *Shell) *shellOutput {
+	so := &shellOutput{}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := sh.Read(buf)
+			so.lock.Lock()
+			so.out.Write(buf[:n])
+			so.lock.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return so
+}
+
+func (so *shellOutput) waitFor(t *testing.T, want string) {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		so.lock.Lock()
+		found := strings.Contains(so.out.String(), want)
+		so.lock.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %q in output %q", want, so.out.String())
+}
+
+func TestShellReattach(t *testing.T) {
+	server, keyStr, err := Listen("40000-50000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := ptyhost.StartSpec{Path: "/bin/sh", Args: []string{"sh"}, Env: []string{"PS1=", "PATH=" + os.Getenv("PATH")}, Rows: 24, Cols: 80}
+	go func() {
+		first, err := server.AcceptTimeout(FirstAttachTimeout)
+		if err != nil {
+			return
+		}
+		serveShell(server, first, spec)
+	}()
+	port := server.Addr().(*net.UDPAddr).Port
+	session, err := Dial(context.Background(), "127.0.0.1", port, keyStr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	sh, err := AttachShell(session)
+	if err != nil {
+		t.Fatalf("attach error: %v", err)
+	}
+	defer sh.Close()
+	output := collectShellOutput(sh)
+	sh.Write([]byte("echo first-$((1+1))\n"))
+	output.waitFor(t, "first-2")
+
+	// the output written while no stream is attached is replayed on the next one
+	sh.Write([]byte("sleep 0.3; echo later-$((3+4))\n"))
+	time.Sleep(100 * time.Millisecond)
+	client, _ := sh.getClient()
+	client.Close()
+	output.waitFor(t, "later-7")
+	if newClient, _ := sh.getClient(); newClient == client {
+		t.Errorf("expected the shell to be attached on a new stream")
+	}
+
+	sh.Write([]byte("exit 5\n"))
+	select {
+	case <-sh.doneCh:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timeout waiting for the shell to exit")
+	}
+	if sh.ExitCode() != 5 {
+		t.Errorf("expected exit code 5, got %d (%v)", sh.ExitCode(), sh.Wait())
+	}
+}
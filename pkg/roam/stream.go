@@ -0,0 +1,368 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package roam
+
+import (
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// a reliable byte stream of a session (a net.Conn).  the bytes written are kept until the other side acks them,
+// and are sent again from the first one not acked when no ack comes within the retransmit timeout.  the bytes
+// received out of order are kept and acked right away, the sender retransmits the missing bytes after
+// dupAckThreshold of these acks (and after every partial ack until the bytes in flight then are acked).  Close
+// sends a fin after the bytes written, it does not wait for them.  the fields are guarded by the lock of the
+// session.
+type Stream struct {
+	session *Session
+	id      uint32
+
+	sendBuf      []byte // written and not acked yet, starts at sendBase
+	sendBase     uint64
+	sendNext     uint64 // the next offset to transmit
+	finSent      bool
+	finAcked     bool
+	lastProgress time.Time // the last ack that moved sendBase (or the start of a transmission)
+	rto          time.Duration
+	synAcked     bool      // client streams, the server has answered the syn
+	lastSyn      time.Time // client streams, a syn without data is resent every rto
+	dupAcks      int
+	recovering   bool
+	recoverPoint uint64 // the end of the bytes in flight when the recovery started
+	resendFirst  bool   // retransmit the first byte not acked with the next messages
+
+	recvBuf    []byte
+	recvNext   uint64
+	recvOoo    map[uint64][]byte // received out of order, by offset
+	recvOooLen int
+	recvEOF    bool
+	needAck    bool
+	forceAck   bool // send an ack without data (a duplicate ack for out of order data)
+
+	localClosed   bool
+	err           error // reset, or the session is closed
+	readDeadline  time.Time
+	writeDeadline time.Time
+	notifyCh      chan struct{} // closed (and replaced) when the stream changes
+}
+
+var _ net.Conn = (*Stream)(nil)
+
+func makeStream(session *Session, id uint32) *Stream {
+	return &Stream{session: session, id: id, rto: session.baseRto(), notifyCh: make(chan struct{})}
+}
+
+// must hold lock
+func (st *Stream) notify() {
+	close(st.notifyCh)
+	st.notifyCh = make(chan struct{})
+}
+
+// must hold lock, the lock is released while waiting.  returns when the stream changed or the deadline passed.
+func (st *Stream) waitLocked(deadline time.Time) error {
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		return os.ErrDeadlineExceeded
+	}
+	notifyCh := st.notifyCh
+	st.session.lock.Unlock()
+	defer st.session.lock.Lock()
+	if deadline.IsZero() {
+		<-notifyCh
+		return nil
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-notifyCh:
+	case <-timer.C:
+	}
+	return nil
+}
+
+func (st *Stream) Read(p []byte) (int, error) {
+	st.session.lock.Lock()
+	defer st.session.lock.Unlock()
+	for {
+		if len(st.recvBuf) > 0 {
+			n := copy(p, st.recvBuf)
+			st.recvBuf = st.recvBuf[n:]
+			return n, nil
+		}
+		if st.recvEOF {
+			return 0, io.EOF
+		}
+		if st.err != nil {
+			return 0, st.err
+		}
+		if st.localClosed {
+			return 0, net.ErrClosed
+		}
+		if err := st.waitLocked(st.readDeadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// blocks while more than maxSendBuffer bytes are not acked
+func (st *Stream) Write(p []byte) (int, error) {
+	st.session.lock.Lock()
+	defer st.session.lock.Unlock()
+	written := 0
+	for written < len(p) {
+		if st.err != nil {
+			return written, st.err
+		}
+		if st.localClosed {
+			return written, net.ErrClosed
+		}
+		room := maxSendBuffer - len(st.sendBuf)
+		if room <= 0 {
+			if err := st.waitLocked(st.writeDeadline); err != nil {
+				return written, err
+			}
+			continue
+		}
+		n := min(room, len(p)-written)
+		st.sendBuf = append(st.sendBuf, p[written:written+n]...)
+		written += n
+		st.session.kick()
+	}
+	return written, nil
+}
+
+func (st *Stream) Close() error {
+	st.session.lock.Lock()
+	defer st.session.lock.Unlock()
+	if st.localClosed {
+		return nil
+	}
+	st.localClosed = true
+	st.notify()
+	st.session.kick()
+	return nil
+}
+
+func (st *Stream) LocalAddr() net.Addr {
+	return st.session.Addr()
+}
+
+func (st *Stream) RemoteAddr() net.Addr {
+	return st.session.RemoteAddr()
+}
+
+func (st *Stream) SetDeadline(t time.Time) error {
+	st.session.lock.Lock()
+	defer st.session.lock.Unlock()
+	st.readDeadline = t
+	st.writeDeadline = t
+	st.notify()
+	return nil
+}
+
+func (st *Stream) SetReadDeadline(t time.Time) error {
+	st.session.lock.Lock()
+	defer st.session.lock.Unlock()
+	st.readDeadline = t
+	st.notify()
+	return nil
+}
+
+func (st *Stream) SetWriteDeadline(t time.Time) error {
+	st.session.lock.Lock()
+	defer st.session.lock.Unlock()
+	st.writeDeadline = t
+	st.notify()
+	return nil
+}
+
+// must hold lock
+func (st *Stream) fail(err error) {
+	if st.err == nil {
+		st.err = err
+	}
+	st.notify()
+}
+
+// must hold lock, true once nothing is left to deliver to the other side
+func (st *Stream) flushed() bool {
+	if st.err != nil {
+		return true
+	}
+	if len(st.sendBuf) > 0 {
+		return false
+	}
+	return !st.localClosed || st.finAcked
+}
+
+// must hold lock
+func (st *Stream) finOffset() uint64 {
+	return st.sendBase + uint64(len(st.sendBuf))
+}
+
+// must hold lock
+func (st *Stream) handleMessage(m *message, now time.Time) {
+	if m.Flags&flag_Rst != 0 {
+		st.fail(ErrReset)
+		return
+	}
+	st.synAcked = true
+	st.handleAck(m, now)
+	if len(m.Data) > 0 {
+		st.needAck = true
+		if m.Offset > st.recvNext {
+			st.forceAck = true
+			if !st.recvEOF && st.recvOooLen+len(m.Data) <= maxRecvBuffer {
+				if st.recvOoo == nil {
+					st.recvOoo = make(map[uint64][]byte)
+				}
+				if _, found := st.recvOoo[m.Offset]; !found {
+					st.recvOoo[m.Offset] = append([]byte(nil), m.Data...)
+					st.recvOooLen += len(m.Data)
+				}
+			}
+		} else {
+			st.appendRecv(m.Offset, m.Data)
+		}
+	}
+	if m.Flags&flag_Fin != 0 {
+		st.needAck = true
+		if !st.recvEOF && m.Offset == st.recvNext {
+			st.recvEOF = true
+			st.recvNext++
+			st.recvOoo = nil
+			st.recvOooLen = 0
+			st.notify()
+		}
+	}
+}
+
+// must hold lock
+func (st *Stream) handleAck(m *message, now time.Time) {
+	if m.Ack <= st.sendBase {
+		inFlight := st.sendNext > st.sendBase
+		if m.Ack == st.sendBase && inFlight && len(m.Data) == 0 && m.Flags&flag_Fin == 0 {
+			st.dupAcks++
+			if st.dupAcks == dupAckThreshold && !st.recovering {
+				st.recovering = true
+				st.recoverPoint = st.sendNext
+				st.resendFirst = true
+			}
+		}
+		return
+	}
+	acked := min(m.Ack, st.finOffset()) - st.sendBase
+	st.sendBuf = st.sendBuf[acked:]
+	st.sendBase += acked
+	st.sendNext = max(st.sendNext, st.sendBase)
+	if st.finSent && m.Ack > st.finOffset() {
+		st.finAcked = true
+	}
+	st.dupAcks = 0
+	if st.recovering {
+		if st.sendBase >= st.recoverPoint {
+			st.recovering = false
+		} else {
+			// a partial ack, the next hole is at sendBase
+			st.resendFirst = true
+		}
+	}
+	st.lastProgress = now
+	st.rto = st.session.baseRto()
+	st.notify()
+}
+
+// must hold lock, data starts at or before recvNext
+func (st *Stream) appendRecv(offset uint64, data []byte) {
+	end := offset + uint64(len(data))
+	if st.recvEOF || end <= st.recvNext || len(st.recvBuf) >= maxRecvBuffer {
+		return
+	}
+	st.recvBuf = append(st.recvBuf, data[st.recvNext-offset:]...)
+	st.recvNext = end
+	for len(st.recvOoo) > 0 {
+		progress := false
+		for oooOffset, oooData := range st.recvOoo {
+			if oooOffset > st.recvNext {
+				continue
+			}
+			delete(st.recvOoo, oooOffset)
+			st.recvOooLen -= len(oooData)
+			if oooEnd := oooOffset + uint64(len(oooData)); oooEnd > st.recvNext {
+				st.recvBuf = append(st.recvBuf, oooData[st.recvNext-oooOffset:]...)
+				st.recvNext = oooEnd
+			}
+			progress = true
+		}
+		if !progress {
+			break
+		}
+	}
+	st.notify()
+}
+
+// must hold lock, the messages to send now (data, a fin, a syn or an ack)
+func (st *Stream) nextMessages(now time.Time) []*message {
+	if st.err != nil {
+		return nil
+	}
+	var flags byte
+	if !st.session.isServer && !st.synAcked {
+		flags |= flag_Syn
+	}
+	inFlight := st.sendNext > st.sendBase || (st.finSent && !st.finAcked)
+	if inFlight && now.Sub(st.lastProgress) > st.rto {
+		// go back to the first byte not acked
+		st.sendNext = st.sendBase
+		st.finSent = false
+		st.recovering = false
+		st.dupAcks = 0
+		st.rto = min(st.rto*2, maxRto)
+		st.lastProgress = now
+		inFlight = false
+	}
+	var rtn []*message
+	end := st.finOffset()
+	if st.resendFirst && st.sendNext > st.sendBase {
+		size := min(uint64(maxPayload), st.sendNext-st.sendBase)
+		data := make([]byte, size)
+		copy(data, st.sendBuf[:size])
+		rtn = append(rtn, &message{StreamId: st.id, Flags: flags, Ack: st.recvNext, Offset: st.sendBase, Data: data})
+	}
+	st.resendFirst = false
+	for st.sendNext < end && st.sendNext-st.sendBase < sendWindow && len(rtn) < maxBurst {
+		if !inFlight {
+			st.lastProgress = now
+			inFlight = true
+		}
+		size := min(uint64(maxPayload), end-st.sendNext)
+		start := st.sendNext - st.sendBase
+		data := make([]byte, size)
+		copy(data, st.sendBuf[start:start+size])
+		rtn = append(rtn, &message{StreamId: st.id, Flags: flags, Ack: st.recvNext, Offset: st.sendNext, Data: data})
+		st.sendNext += size
+	}
+	if st.localClosed && !st.finSent && st.sendNext == end {
+		if !inFlight {
+			st.lastProgress = now
+		}
+		st.finSent = true
+		rtn = append(rtn, &message{StreamId: st.id, Flags: flags | flag_Fin, Ack: st.recvNext, Offset: end})
+	}
+	if st.forceAck || (len(rtn) == 0 && (st.needAck || (flags&flag_Syn != 0 && now.Sub(st.lastSyn) > st.rto))) {
+		rtn = append(rtn, &message{StreamId: st.id, Flags: flags, Ack: st.recvNext, Offset: st.sendNext})
+	}
+	if flags&flag_Syn != 0 && len(rtn) > 0 {
+		st.lastSyn = now
+	}
+	st.needAck = false
+	st.forceAck = false
+	return rtn
+}
+
+// must hold lock, the stream can be forgotten
+func (st *Stream) done() bool {
+	return st.err != nil || (st.localClosed && st.finAcked)
+}
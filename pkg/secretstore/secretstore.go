@@ -0,0 +1,354 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package secretstore keeps the secrets of the connections (passwords, key passphrases and api tokens).  the config
+// only has their names (ssh:passwordsecret, ssh:passphrasesecret and conn:secretenv in connections.json), and they
+// are resolved by the connection and controller code when they are used, so a value is never sent to the frontend
+// or stored in a wave object.  a secret belongs to a connection (by its ssh connection name) or is global (usable by
+// every connection), a connection's own secret is used over a global one with the same name.
+//
+// the values are in the OS keychain (the service "waveterm-secrets"), or, when there is no keychain (e.g. linux
+// without a secret service), in the index itself encrypted with aes-256-gcm, with the key in secrets.key next to
+// it.  the index (secrets.json in the data dir) has the names, where each value is, and the times they were set.
+package secretstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	IndexFileName = "secrets.json"
+	KeyFileName   = "secrets.key"
+	KeySize       = 32
+	MaxValueSize  = 16 * 1024
+)
+
+const (
+	Backend_Keychain = "keychain"
+	Backend_File     = "file"
+)
+
+var ErrNotFound = errors.New("secret not found")
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+type secretEntry struct {
+	Name      string `json:"name"`
+	ConnName  string `json:"connname,omitempty"` // "" for a global secret
+	Backend   string `json:"backend"`
+	Value     string `json:"value,omitempty"` // for the file backend, the nonce and the ciphertext (base64)
+	CreatedTs int64  `json:"createdts"`
+	UpdatedTs int64  `json:"updatedts"`
+}
+
+type secretIndex struct {
+	Secrets []*secretEntry `json:"secrets"`
+}
+
+type store struct {
+	lock        sync.Mutex
+	dir         string
+	useKeychain bool
+	index       *secretIndex // loaded on first use
+}
+
+var globalStore *store
+var globalStoreOnce sync.Once
+
+func getStore() *store {
+	globalStoreOnce.Do(func() {
+		globalStore = &store{dir: wavebase.GetWaveDataDir(), useKeychain: keychainAvailable()}
+		if !globalStore.useKeychain {
+			log.Printf("secretstore: no OS keychain, secrets are stored in an encrypted file\n")
+		}
+	})
+	return globalStore
+}
+
+func keychainService() string {
+	if wavebase.IsDevMode() {
+		return "waveterm-dev-secrets"
+	}
+	return "waveterm-secrets"
+}
+
+// a lookup of a name that does not exist tells if the keychain works
+func keychainAvailable() bool {
+	_, err := keyring.Get(keychainService(), "probe")
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+func keychainUser(connName string, name string) string {
+	if connName == "" {
+		return "global/" + name
+	}
+	return "conn/" + connName + "/" + name
+}
+
+func ValidateName(name string) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q (letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+func (s *store) loadIndex() (*secretIndex, error) {
+	if s.index != nil {
+		return s.index, nil
+	}
+	index := &secretIndex{}
+	barr, err := os.ReadFile(filepath.Join(s.dir, IndexFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		s.index = index
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the secrets index: %w", err)
+	}
+	err = json.Unmarshal(barr, index)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the secrets index: %w", err)
+	}
+	s.index = index
+	return index, nil
+}
+
+func (s *store) saveIndex() error {
+	barr, err := json.MarshalIndent(s.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	indexPath := filepath.Join(s.dir, IndexFileName)
+	tmpPath := indexPath + ".tmp"
+	err = os.WriteFile(tmpPath, barr, 0600)
+	if err != nil {
+		return fmt.Errorf("error writing the secrets index: %w", err)
+	}
+	return os.Rename(tmpPath, indexPath)
+}
+
+func (s *store) findEntry(connName string, name string) (int, *secretEntry) {
+	for idx, entry := range s.index.Secrets {
+		if entry.ConnName == connName && entry.Name == name {
+			return idx, entry
+		}
+	}
+	return -1, nil
+}
+
+// the key of the file backend, made on first use
+func (s *store) getFileCipher() (cipher.AEAD, error) {
+	keyPath := filepath.Join(s.dir, KeyFileName)
+	key, err := os.ReadFile(keyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		key = make([]byte, KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("error making the secrets key: %w", err)
+		}
+		err = os.WriteFile(keyPath, key, 0600)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the secrets key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid secrets key %s", keyPath)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// the entry's connection and name are the additional data, a value can not be moved to another secret
+func entryAD(entry *secretEntry) []byte {
+	return []byte(keychainUser(entry.ConnName, entry.Name))
+}
+
+func (s *store) encryptValue(entry *secretEntry, value string) error {
+	aead, err := s.getFileCipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), entryAD(entry))
+	entry.Value = base64.StdEncoding.EncodeToString(sealed)
+	return nil
+}
+
+func (s *store) decryptValue(entry *secretEntry) (string, error) {
+	aead, err := s.getFileCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(entry.Value)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid stored value for secret %q", entry.Name)
+	}
+	nonceSize := aead.NonceSize()
+	plain, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], entryAD(entry))
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt secret %q (was %s replaced?)", entry.Name, KeyFileName)
+	}
+	return string(plain), nil
+}
+
+func (s *store) set(connName string, name string, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("the value of secret %q is empty", name)
+	}
+	if len(value) > MaxValueSize {
+		return fmt.Errorf("the value of secret %q is too large (max %d bytes)", name, MaxValueSize)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := s.loadIndex(); err != nil {
+		return err
+	}
+	now := time.Now().UnixMilli()
+	idx, entry := s.findEntry(connName, name)
+	if entry == nil {
+		entry = &secretEntry{Name: name, ConnName: connName, CreatedTs: now}
+	}
+	oldBackend := entry.Backend
+	if s.useKeychain {
+		err := keyring.Set(keychainService(), keychainUser(connName, name), value)
+		if err != nil {
+			return fmt.Errorf("error storing secret %q in the keychain: %w", name, err)
+		}
+		entry.Backend = Backend_Keychain
+		entry.Value = ""
+	} else {
+		if err := s.encryptValue(entry, value); err != nil {
+			return err
+		}
+		entry.Backend = Backend_File
+	}
+	entry.UpdatedTs = now
+	if idx < 0 {
+		s.index.Secrets = append(s.index.Secrets, entry)
+	}
+	if err := s.saveIndex(); err != nil {
+		return err
+	}
+	if oldBackend == Backend_Keychain && entry.Backend != Backend_Keychain {
+		keyring.Delete(keychainService(), keychainUser(connName, name))
+	}
+	return nil
+}
+
+func (s *store) delete(connName string, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := s.loadIndex(); err != nil {
+		return err
+	}
+	idx, entry := s.findEntry(connName, name)
+	if entry == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if entry.Backend == Backend_Keychain {
+		err := keyring.Delete(keychainService(), keychainUser(connName, name))
+		if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("error removing secret %q from the keychain: %w", name, err)
+		}
+	}
+	s.index.Secrets = append(s.index.Secrets[:idx], s.index.Secrets[idx+1:]...)
+	return s.saveIndex()
+}
+
+// connName "" lists every secret
+func (s *store) list(connName string) ([]wshrpc.SecretInfo, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := s.loadIndex(); err != nil {
+		return nil, err
+	}
+	var rtn []wshrpc.SecretInfo
+	for _, entry := range s.index.Secrets {
+		if connName != "" && entry.ConnName != connName {
+			continue
+		}
+		rtn = append(rtn, wshrpc.SecretInfo{
+			Name:      entry.Name,
+			ConnName:  entry.ConnName,
+			Backend:   entry.Backend,
+			CreatedTs: entry.CreatedTs,
+			UpdatedTs: entry.UpdatedTs,
+		})
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		if rtn[i].ConnName != rtn[j].ConnName {
+			return rtn[i].ConnName < rtn[j].ConnName
+		}
+		return rtn[i].Name < rtn[j].Name
+	})
+	return rtn, nil
+}
+
+func (s *store) resolve(connName string, name string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := s.loadIndex(); err != nil {
+		return "", err
+	}
+	_, entry := s.findEntry(connName, name)
+	if entry == nil {
+		_, entry = s.findEntry("", name)
+	}
+	if entry == nil {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if entry.Backend == Backend_File {
+		return s.decryptValue(entry)
+	}
+	value, err := keyring.Get(keychainService(), keychainUser(entry.ConnName, entry.Name))
+	if err != nil {
+		return "", fmt.Errorf("error getting secret %q from the keychain: %w", name, err)
+	}
+	return value, nil
+}
+
+// stores the secret of the connection (connName "" for a global secret), replacing the secret with the name
+func SetSecret(connName string, name string, value string) error {
+	return getStore().set(connName, name, value)
+}
+
+func DeleteSecret(connName string, name string) error {
+	return getStore().delete(connName, name)
+}
+
+// the secrets (without their values) of the connection, or every secret if connName is ""
+func ListSecrets(connName string) ([]wshrpc.SecretInfo, error) {
+	return getStore().list(connName)
+}
+
+// the value of the connection's secret, or of the global secret with the name.  only for the code that uses the
+// secret (auth, the env of a shell), the value must not be logged or stored.
+func Resolve(connName string, name string) (string, error) {
+	return getStore().resolve(connName, name)
+}
@@ -0,0 +1,75 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package secretstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestKeychainStore(t *testing.T) {
+	keyring.MockInit()
+	s := &store{dir: t.TempDir(), useKeychain: true}
+	if err := s.set("", "api-token", "global-token"); err != nil {
+		t.Fatalf("error setting secret: %v", err)
+	}
+	if err := s.set("admin@db", "api-token", "db-token"); err != nil {
+		t.Fatalf("error setting secret: %v", err)
+	}
+	if val, err := s.resolve("admin@db", "api-token"); err != nil || val != "db-token" {
+		t.Errorf("the connection's secret should be used, got %q (%v)", val, err)
+	}
+	if val, err := s.resolve("ops@web", "api-token"); err != nil || val != "global-token" {
+		t.Errorf("the global secret should be used, got %q (%v)", val, err)
+	}
+	barr, err := os.ReadFile(filepath.Join(s.dir, IndexFileName))
+	if err != nil || strings.Contains(string(barr), "global-token") || strings.Contains(string(barr), "db-token") {
+		t.Errorf("the index should not have the values: %s (%v)", barr, err)
+	}
+	infos, err := s.list("admin@db")
+	if err != nil || len(infos) != 1 || infos[0].Backend != Backend_Keychain {
+		t.Errorf("unexpected secrets for admin@db: %+v (%v)", infos, err)
+	}
+	if err := s.delete("admin@db", "api-token"); err != nil {
+		t.Fatalf("error deleting secret: %v", err)
+	}
+	if val, _ := s.resolve("admin@db", "api-token"); val != "global-token" {
+		t.Errorf("the global secret should be used after the delete, got %q", val)
+	}
+	if err := s.delete("admin@db", "api-token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.set("", "bad name", "x"); err == nil {
+		t.Errorf("expected an error for an invalid name")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	s := &store{dir: t.TempDir(), useKeychain: false}
+	if err := s.set("admin@db", "db-pass", "hunter2"); err != nil {
+		t.Fatalf("error setting secret: %v", err)
+	}
+	barr, err := os.ReadFile(filepath.Join(s.dir, IndexFileName))
+	if err != nil || strings.Contains(string(barr), "hunter2") {
+		t.Errorf("the value should be encrypted in the index: %s (%v)", barr, err)
+	}
+	// a new store reads the index back
+	s2 := &store{dir: s.dir, useKeychain: false}
+	if val, err := s2.resolve("admin@db", "db-pass"); err != nil || val != "hunter2" {
+		t.Errorf("expected the value back, got %q (%v)", val, err)
+	}
+	if _, err := s2.resolve("ops@web", "db-pass"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("another connection should not see the secret, got %v", err)
+	}
+	// a value can not be moved to another secret
+	s2.index.Secrets[0].Name = "other"
+	if _, err := s2.resolve("admin@db", "other"); err == nil {
+		t.Errorf("expected an error decrypting a moved value")
+	}
+}
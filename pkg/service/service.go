@@ -9,6 +9,8 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/wavetermdev/waveterm/pkg/fesession"
+	"github.com/wavetermdev/waveterm/pkg/remoteaccess"
 	"github.com/wavetermdev/waveterm/pkg/service/blockservice"
 	"github.com/wavetermdev/waveterm/pkg/service/clientservice"
 	"github.com/wavetermdev/waveterm/pkg/service/objectservice"
@@ -30,6 +32,71 @@ var ServiceMap = map[string]any{
 	"userinput": &userinputservice.UserInputService{},
 }
 
+// the permission a frontend session needs to call each method (see fesession), methods that are not listed
+// need fesession.Perm_Edit
+var methodPerms = map[string]string{
+	"block.GetControllerStatus":       fesession.Perm_View,
+	"client.GetClientData":            fesession.Perm_View,
+	"client.GetTab":                   fesession.Perm_View,
+	"client.GetAllConnStatus":         fesession.Perm_View,
+	"object.GetObject":                fesession.Perm_View,
+	"object.GetObjects":               fesession.Perm_View,
+	"window.GetWindow":                fesession.Perm_View,
+	"workspace.GetWorkspace":          fesession.Perm_View,
+	"workspace.ListWorkspaces":        fesession.Perm_View,
+	"workspace.GetColors":             fesession.Perm_View,
+	"workspace.GetIcons":              fesession.Perm_View,
+	"userinput.SendUserInputResponse": fesession.Perm_Input,
+	"client.FocusWindow":              fesession.Perm_Admin,
+	"client.AgreeTos":                 fesession.Perm_Admin,
+	"client.TelemetryUpdate":          fesession.Perm_Admin,
+	"window.CreateWindow":             fesession.Perm_Admin,
+	"window.SetWindowPosAndSize":      fesession.Perm_Admin,
+	"window.MoveBlockToNewWindow":     fesession.Perm_Admin,
+	"window.SwitchWorkspace":          fesession.Perm_Admin,
+	"window.CloseWindow":              fesession.Perm_Admin,
+	"workspace.CreateWorkspace":       fesession.Perm_Admin,
+	"workspace.UpdateWorkspace":       fesession.Perm_Admin,
+	"workspace.DeleteWorkspace":       fesession.Perm_Admin,
+}
+
+// the arguments (by their index in WebCallType.Args) that name objects, a frontend with a session can only use the
+// objects in its scope (see remoteaccess).  the kind is the otype of an id argument (a "[]" prefix for a list of
+// ids), or one of the argKind_ constants.
+const (
+	argKind_ORef     = "oref"
+	argKind_ORefList = "[]oref"
+	argKind_WaveObj  = "waveobj"
+)
+
+var methodObjArgs = map[string]map[int]string{
+	"block.GetControllerStatus":   {0: waveobj.OType_Block},
+	"block.SaveTerminalState":     {0: waveobj.OType_Block},
+	"block.SaveWaveAiData":        {0: waveobj.OType_Block},
+	"client.GetTab":               {0: waveobj.OType_Tab},
+	"client.FocusWindow":          {0: waveobj.OType_Window},
+	"object.GetObject":            {0: argKind_ORef},
+	"object.GetObjects":           {0: argKind_ORefList},
+	"object.UpdateTabName":        {0: waveobj.OType_Tab},
+	"object.DeleteBlock":          {0: waveobj.OType_Block},
+	"object.UpdateObjectMeta":     {0: argKind_ORef},
+	"object.UpdateObject":         {0: argKind_WaveObj},
+	"window.GetWindow":            {0: waveobj.OType_Window},
+	"window.CreateWindow":         {1: waveobj.OType_Workspace},
+	"window.SetWindowPosAndSize":  {0: waveobj.OType_Window},
+	"window.MoveBlockToNewWindow": {0: waveobj.OType_Tab, 1: waveobj.OType_Block},
+	"window.SwitchWorkspace":      {0: waveobj.OType_Window, 1: waveobj.OType_Workspace},
+	"window.CloseWindow":          {0: waveobj.OType_Window},
+	"workspace.UpdateWorkspace":   {0: waveobj.OType_Workspace},
+	"workspace.GetWorkspace":      {0: waveobj.OType_Workspace},
+	"workspace.DeleteWorkspace":   {0: waveobj.OType_Workspace},
+	"workspace.CreateTab":         {0: waveobj.OType_Workspace},
+	"workspace.ChangeTabPinning":  {0: waveobj.OType_Workspace, 1: waveobj.OType_Tab},
+	"workspace.UpdateTabIds":      {0: waveobj.OType_Workspace, 1: "[]" + waveobj.OType_Tab, 2: "[]" + waveobj.OType_Tab},
+	"workspace.SetActiveTab":      {0: waveobj.OType_Workspace, 1: waveobj.OType_Tab},
+	"workspace.CloseTab":          {0: waveobj.OType_Workspace, 1: waveobj.OType_Tab},
+}
+
 var contextRType = reflect.TypeOf((*context.Context)(nil)).Elem()
 var errorRType = reflect.TypeOf((*error)(nil)).Elem()
 var updatesRType = reflect.TypeOf(([]waveobj.WaveObjUpdate{}))
@@ -314,6 +381,98 @@ func webErrorRtn(err error) *WebReturnType {
 	}
 }
 
+// calls from a frontend with a session must be allowed by the session
+func checkSessionPerm(ctx context.Context, webCall WebCallType) error {
+	sess := fesession.GetSession(ctx)
+	if sess == nil {
+		return nil
+	}
+	methodName := webCall.Service + "." + webCall.Method
+	perm := methodPerms[methodName]
+	if perm == "" {
+		perm = fesession.Perm_Edit
+	}
+	err := sess.CheckPerm(perm, methodName)
+	if err != nil {
+		return err
+	}
+	if webCall.UIContext == nil {
+		return fmt.Errorf("%s is not allowed (calls from a remote frontend need a ui context)", methodName)
+	}
+	err = remoteaccess.CheckWindowAccess(ctx, sess, webCall.UIContext.WindowId)
+	if err != nil {
+		return err
+	}
+	if webCall.UIContext.ActiveTabId != "" {
+		err = remoteaccess.CheckTabAccess(ctx, sess, webCall.UIContext.ActiveTabId)
+		if err != nil {
+			return err
+		}
+	}
+	for argIdx, argKind := range methodObjArgs[methodName] {
+		if argIdx >= len(webCall.Args) {
+			continue
+		}
+		orefs, err := getArgORefs(argKind, webCall.Args[argIdx])
+		if err != nil {
+			return fmt.Errorf("%s: %w", methodName, err)
+		}
+		for _, oref := range orefs {
+			err = remoteaccess.CheckORefAccess(ctx, sess, oref)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// the objects named by a (json) service argument, see methodObjArgs
+func getArgORefs(argKind string, arg any) ([]waveobj.ORef, error) {
+	var orefStrs []string
+	switch argKind {
+	case argKind_ORef:
+		orefStr, _ := arg.(string)
+		orefStrs = append(orefStrs, orefStr)
+	case argKind_ORefList:
+		argList, _ := arg.([]any)
+		for _, elem := range argList {
+			orefStr, _ := elem.(string)
+			orefStrs = append(orefStrs, orefStr)
+		}
+	case argKind_WaveObj:
+		argMap, _ := arg.(map[string]any)
+		otype, _ := argMap["otype"].(string)
+		oid, _ := argMap["oid"].(string)
+		orefStrs = append(orefStrs, otype+":"+oid)
+	default:
+		otype, isList := strings.CutPrefix(argKind, "[]")
+		var ids []any
+		if isList {
+			ids, _ = arg.([]any)
+		} else {
+			ids = []any{arg}
+		}
+		for _, id := range ids {
+			idStr, _ := id.(string)
+			if idStr == "" && !isList {
+				// optional id (e.g. the workspace of a new window)
+				continue
+			}
+			orefStrs = append(orefStrs, otype+":"+idStr)
+		}
+	}
+	var rtn []waveobj.ORef
+	for _, orefStr := range orefStrs {
+		oref, err := waveobj.ParseORef(orefStr)
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, oref)
+	}
+	return rtn, nil
+}
+
 func CallService(ctx context.Context, webCall WebCallType) *WebReturnType {
 	svcObj := ServiceMap[webCall.Service]
 	if svcObj == nil {
@@ -323,6 +482,10 @@ func CallService(ctx context.Context, webCall WebCallType) *WebReturnType {
 	if !method.IsValid() {
 		return webErrorRtn(fmt.Errorf("invalid method: %s.%s", webCall.Service, webCall.Method))
 	}
+	err := checkSessionPerm(ctx, webCall)
+	if err != nil {
+		return webErrorRtn(err)
+	}
 	var valueArgs []reflect.Value
 	argIdx := 0
 	for idx := 0; idx < method.Type().NumIn(); idx++ {
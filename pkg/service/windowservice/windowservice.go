@@ -14,7 +14,6 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/tsgen/tsgenmeta"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wcore"
-	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
 )
 
@@ -152,9 +151,9 @@ func (svc *WindowService) SwitchWorkspace(ctx context.Context, windowId string,
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	go func() {
 		defer func() {
-			panichandler.PanicHandler("WindowService:SwitchWorkspace:SendUpdateEvents", recover())
+			panichandler.PanicHandler("WindowService:SwitchWorkspace:PublishObjectUpdates", recover())
 		}()
-		wps.Broker.SendUpdateEvents(updates)
+		eventbus.PublishObjectUpdates(updates)
 	}()
 	return ws, err
 }
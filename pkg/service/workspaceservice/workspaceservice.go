@@ -10,7 +10,10 @@ import (
 	"time"
 
 	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/fesession"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remoteaccess"
 	"github.com/wavetermdev/waveterm/pkg/tsgen/tsgenmeta"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wcore"
@@ -60,9 +63,9 @@ func (svc *WorkspaceService) UpdateWorkspace(ctx context.Context, workspaceId st
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	go func() {
 		defer func() {
-			panichandler.PanicHandler("WorkspaceService:UpdateWorkspace:SendUpdateEvents", recover())
+			panichandler.PanicHandler("WorkspaceService:UpdateWorkspace:PublishObjectUpdates", recover())
 		}()
-		wps.Broker.SendUpdateEvents(updates)
+		eventbus.PublishObjectUpdates(updates)
 	}()
 	return updates, nil
 }
@@ -107,17 +110,32 @@ func (svc *WorkspaceService) DeleteWorkspace(workspaceId string) (waveobj.Update
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	go func() {
 		defer func() {
-			panichandler.PanicHandler("WorkspaceService:DeleteWorkspace:SendUpdateEvents", recover())
+			panichandler.PanicHandler("WorkspaceService:DeleteWorkspace:PublishObjectUpdates", recover())
 		}()
-		wps.Broker.SendUpdateEvents(updates)
+		eventbus.PublishObjectUpdates(updates)
 	}()
 	return updates, claimableWorkspace, nil
 }
 
-func (svc *WorkspaceService) ListWorkspaces() (waveobj.WorkspaceList, error) {
-	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+// a remote frontend only gets the workspaces it can use
+func (svc *WorkspaceService) ListWorkspaces(ctx context.Context) (waveobj.WorkspaceList, error) {
+	ctx, cancelFn := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancelFn()
-	return wcore.ListWorkspaces(ctx)
+	wsList, err := wcore.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sess := fesession.GetSession(ctx)
+	if sess == nil {
+		return wsList, nil
+	}
+	var rtn waveobj.WorkspaceList
+	for _, entry := range wsList {
+		if remoteaccess.CheckWorkspaceAccess(ctx, sess, entry.WorkspaceId) == nil {
+			rtn = append(rtn, entry)
+		}
+	}
+	return rtn, nil
 }
 
 func (svc *WorkspaceService) CreateTab_Meta() tsgenmeta.MethodMeta {
@@ -158,9 +176,9 @@ func (svc *WorkspaceService) CreateTab(workspaceId string, tabName string, activ
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	go func() {
 		defer func() {
-			panichandler.PanicHandler("WorkspaceService:CreateTab:SendUpdateEvents", recover())
+			panichandler.PanicHandler("WorkspaceService:CreateTab:PublishObjectUpdates", recover())
 		}()
-		wps.Broker.SendUpdateEvents(updates)
+		eventbus.PublishObjectUpdates(updates)
 	}()
 	return tabId, updates, nil
 }
@@ -181,9 +199,9 @@ func (svc *WorkspaceService) ChangeTabPinning(ctx context.Context, workspaceId s
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	go func() {
 		defer func() {
-			panichandler.PanicHandler("WorkspaceService:ChangeTabPinning:SendUpdateEvents", recover())
+			panichandler.PanicHandler("WorkspaceService:ChangeTabPinning:PublishObjectUpdates", recover())
 		}()
-		wps.Broker.SendUpdateEvents(updates)
+		eventbus.PublishObjectUpdates(updates)
 	}()
 	return updates, nil
 }
@@ -233,9 +251,9 @@ func (svc *WorkspaceService) SetActiveTab(workspaceId string, tabId string) (wav
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	go func() {
 		defer func() {
-			panichandler.PanicHandler("WorkspaceService:SetActiveTab:SendUpdateEvents", recover())
+			panichandler.PanicHandler("WorkspaceService:SetActiveTab:PublishObjectUpdates", recover())
 		}()
-		wps.Broker.SendUpdateEvents(updates)
+		eventbus.PublishObjectUpdates(updates)
 	}()
 	var extraUpdates waveobj.UpdatesRtnType
 	extraUpdates = append(extraUpdates, updates...)
@@ -281,9 +299,9 @@ func (svc *WorkspaceService) CloseTab(ctx context.Context, workspaceId string, t
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	go func() {
 		defer func() {
-			panichandler.PanicHandler("WorkspaceService:CloseTab:SendUpdateEvents", recover())
+			panichandler.PanicHandler("WorkspaceService:CloseTab:PublishObjectUpdates", recover())
 		}()
-		wps.Broker.SendUpdateEvents(updates)
+		eventbus.PublishObjectUpdates(updates)
 	}()
 	return rtn, updates, nil
 }
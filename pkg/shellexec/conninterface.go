@@ -4,16 +4,21 @@
 package shellexec
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/creack/pty"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/ptyhost"
+	"github.com/wavetermdev/waveterm/pkg/roam"
+	"github.com/wavetermdev/waveterm/pkg/util/sigutil"
 	"github.com/wavetermdev/waveterm/pkg/wsl"
 	"golang.org/x/crypto/ssh"
 )
@@ -28,13 +33,21 @@ type ConnInterface interface {
 	StdoutPipe() (io.ReadCloser, error)
 	StderrPipe() (io.ReadCloser, error)
 	SetSize(w int, h int) error
+	Signal(sigName string) error // sigName must be normalized (see sigutil.NormalizeSignalName)
 	pty.Pty
 }
 
+// implemented by the ConnInterfaces that can read their pty's modes (local processes).  for the others a
+// password prompt can only be recognized from the output.
+type PasswordModeReader interface {
+	IsPasswordMode() (bool, error)
+}
+
 type CmdWrap struct {
 	Cmd      *exec.Cmd
 	WaitOnce *sync.Once
 	WaitErr  error
+	procWait *procWaitState // only set on windows
 	pty.Pty
 }
 
@@ -42,6 +55,7 @@ func MakeCmdWrap(cmd *exec.Cmd, cmdPty pty.Pty) CmdWrap {
 	return CmdWrap{
 		Cmd:      cmd,
 		WaitOnce: &sync.Once{},
+		procWait: makeProcWaitState(cmd),
 		Pty:      cmdPty,
 	}
 }
@@ -52,6 +66,10 @@ func (cw CmdWrap) Kill() {
 
 func (cw CmdWrap) Wait() error {
 	cw.WaitOnce.Do(func() {
+		if cw.procWait != nil {
+			cw.WaitErr = cw.procWait.wait()
+			return
+		}
 		cw.WaitErr = cw.Cmd.Wait()
 	})
 	return cw.WaitErr
@@ -59,6 +77,9 @@ func (cw CmdWrap) Wait() error {
 
 // only valid once Wait() has returned (or you know Cmd is done)
 func (cw CmdWrap) ExitCode() int {
+	if cw.procWait != nil {
+		return cw.procWait.exitCode
+	}
 	state := cw.Cmd.ProcessState
 	if state == nil {
 		return -1
@@ -66,13 +87,22 @@ func (cw CmdWrap) ExitCode() int {
 	return state.ExitCode()
 }
 
+func (cw CmdWrap) exited() bool {
+	if cw.procWait != nil {
+		return cw.procWait.exited
+	}
+	return cw.Cmd.ProcessState != nil && cw.Cmd.ProcessState.Exited()
+}
+
 func (cw CmdWrap) KillGraceful(timeout time.Duration) {
 	if cw.Cmd.Process == nil {
 		return
 	}
-	if cw.Cmd.ProcessState != nil && cw.Cmd.ProcessState.Exited() {
+	if cw.exited() {
 		return
 	}
+	// background jobs run in their own process groups, so they need to be signaled directly
+	KillDescendants(cw.Cmd.Process.Pid, timeout)
 	if runtime.GOOS == "windows" {
 		cw.Cmd.Process.Signal(os.Interrupt)
 	} else {
@@ -83,7 +113,7 @@ func (cw CmdWrap) KillGraceful(timeout time.Duration) {
 			panichandler.PanicHandler("KillGraceful:Kill", recover())
 		}()
 		time.Sleep(timeout)
-		if cw.Cmd.ProcessState == nil || !cw.Cmd.ProcessState.Exited() {
+		if !cw.exited() {
 			cw.Cmd.Process.Kill() // force kill if it is already not exited
 		}
 	}()
@@ -148,6 +178,14 @@ func (sw SessionWrap) KillGraceful(timeout time.Duration) {
 	sw.Kill()
 }
 
+// delivered by the remote sshd, which signals the session's process (not all servers support this)
+func (sw SessionWrap) Signal(sigName string) error {
+	if sigName == sigutil.Signal_Break {
+		return fmt.Errorf("signal %s is not supported for ssh sessions", sigName)
+	}
+	return sw.Session.Signal(ssh.Signal(strings.TrimPrefix(sigName, "SIG")))
+}
+
 func (sw SessionWrap) ExitCode() int {
 	waitErr := sw.WaitErr
 	if waitErr == nil {
@@ -225,6 +263,18 @@ func (wcw WslCmdWrap) KillGraceful(timeout time.Duration) {
 	}()
 }
 
+// processes inside the distro cannot be signaled from here, only the wsl process itself can be killed
+func (wcw WslCmdWrap) Signal(sigName string) error {
+	if sigName != sigutil.Signal_Kill {
+		return fmt.Errorf("signal %s is not supported for wsl sessions", sigName)
+	}
+	process := wcw.WslCmd.GetProcess()
+	if process == nil {
+		return fmt.Errorf("process is not running")
+	}
+	return process.Kill()
+}
+
 /**
  * SetSize does nothing for WslCmdWrap as there
  * is no pty to manage.
@@ -232,3 +282,157 @@ func (wcw WslCmdWrap) KillGraceful(timeout time.Duration) {
 func (wcw WslCmdWrap) SetSize(w int, h int) error {
 	return nil
 }
+
+// a shell running inside a ptyhost helper process (persistent session).  ConnName is set for sessions on a remote
+// host, SockPath and HostPid are then on the remote host.
+type PtyHostWrap struct {
+	Client   *ptyhost.Client
+	SockPath string
+	HostPid  int
+	ConnName string
+}
+
+func (pw PtyHostWrap) isDetached() bool {
+	return pw.Client.Detached() || (pw.ConnName != "" && pw.Client.Lost())
+}
+
+func (pw PtyHostWrap) Kill() {
+	pw.Client.Kill(true, 0)
+}
+
+func (pw PtyHostWrap) KillGraceful(timeout time.Duration) {
+	if pw.isDetached() {
+		return
+	}
+	pw.Client.Kill(false, timeout)
+}
+
+func (pw PtyHostWrap) Signal(sigName string) error {
+	return pw.Client.Signal(sigName)
+}
+
+func (pw PtyHostWrap) Wait() error {
+	return pw.Client.Wait()
+}
+
+func (pw PtyHostWrap) Start() error {
+	return nil
+}
+
+func (pw PtyHostWrap) ExitCode() int {
+	return pw.Client.ExitCode()
+}
+
+func (pw PtyHostWrap) StdinPipe() (io.WriteCloser, error) {
+	return nil, fmt.Errorf("StdinPipe not supported for ptyhost sessions")
+}
+
+func (pw PtyHostWrap) StdoutPipe() (io.ReadCloser, error) {
+	return nil, fmt.Errorf("StdoutPipe not supported for ptyhost sessions")
+}
+
+func (pw PtyHostWrap) StderrPipe() (io.ReadCloser, error) {
+	return nil, fmt.Errorf("StderrPipe not supported for ptyhost sessions")
+}
+
+func (pw PtyHostWrap) SetSize(h int, w int) error {
+	return pw.Client.SetSize(h, w)
+}
+
+// the pty lives in the helper process
+func (pw PtyHostWrap) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+func (pw PtyHostWrap) Name() string {
+	if pw.ConnName != "" {
+		return "ptyhost:" + pw.ConnName + ":" + pw.SockPath
+	}
+	return "ptyhost:" + pw.SockPath
+}
+
+func (pw PtyHostWrap) Read(p []byte) (int, error) {
+	return pw.Client.Read(p)
+}
+
+func (pw PtyHostWrap) Write(p []byte) (int, error) {
+	return pw.Client.Write(p)
+}
+
+func (pw PtyHostWrap) WriteString(s string) (int, error) {
+	return pw.Client.Write([]byte(s))
+}
+
+func (pw PtyHostWrap) Close() error {
+	return pw.Client.Close()
+}
+
+// a remote shell on the roam transport (see pkg/roam)
+type RoamWrap struct {
+	Shell *roam.Shell
+}
+
+func (rw RoamWrap) Kill() {
+	rw.Shell.Kill(true, 0)
+}
+
+func (rw RoamWrap) KillGraceful(timeout time.Duration) {
+	rw.Shell.Kill(false, timeout)
+}
+
+func (rw RoamWrap) Signal(sigName string) error {
+	return rw.Shell.Signal(sigName)
+}
+
+func (rw RoamWrap) Wait() error {
+	return rw.Shell.Wait()
+}
+
+func (rw RoamWrap) Start() error {
+	return nil
+}
+
+func (rw RoamWrap) ExitCode() int {
+	return rw.Shell.ExitCode()
+}
+
+func (rw RoamWrap) StdinPipe() (io.WriteCloser, error) {
+	return nil, fmt.Errorf("StdinPipe not supported for roaming shells")
+}
+
+func (rw RoamWrap) StdoutPipe() (io.ReadCloser, error) {
+	return nil, fmt.Errorf("StdoutPipe not supported for roaming shells")
+}
+
+func (rw RoamWrap) StderrPipe() (io.ReadCloser, error) {
+	return nil, fmt.Errorf("StderrPipe not supported for roaming shells")
+}
+
+func (rw RoamWrap) SetSize(h int, w int) error {
+	return rw.Shell.SetSize(h, w)
+}
+
+// the pty is on the remote host
+func (rw RoamWrap) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+func (rw RoamWrap) Name() string {
+	return "roam"
+}
+
+func (rw RoamWrap) Read(p []byte) (int, error) {
+	return rw.Shell.Read(p)
+}
+
+func (rw RoamWrap) Write(p []byte) (int, error) {
+	return rw.Shell.Write(p)
+}
+
+func (rw RoamWrap) WriteString(s string) (int, error) {
+	return rw.Shell.Write([]byte(s))
+}
+
+func (rw RoamWrap) Close() error {
+	return rw.Shell.Close()
+}
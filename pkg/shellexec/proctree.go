@@ -0,0 +1,168 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellexec
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// returns the pids of all descendants of pid (children first, depth-first)
+func getDescendantPids(pid int32) []int32 {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return nil
+	}
+	children, err := proc.Children()
+	if err != nil {
+		return nil
+	}
+	var rtn []int32
+	for _, child := range children {
+		rtn = append(rtn, getDescendantPids(child.Pid)...)
+		rtn = append(rtn, child.Pid)
+	}
+	return rtn
+}
+
+func signalPid(pid int32, graceful bool) {
+	osProc, err := os.FindProcess(int(pid))
+	if err != nil {
+		return
+	}
+	if !graceful {
+		osProc.Kill()
+		return
+	}
+	if runtime.GOOS == "windows" {
+		osProc.Signal(os.Interrupt)
+	} else {
+		osProc.Signal(syscall.SIGTERM)
+	}
+}
+
+// signals the descendants of pid (not pid itself) gracefully, and force kills any that
+// are still running after gracefulWait.  the descendants are captured before signaling so
+// processes that get reparented when their parent exits are still cleaned up.
+func KillDescendants(pid int, gracefulWait time.Duration) {
+	if pid <= 0 {
+		return
+	}
+	descendants := getDescendantPids(int32(pid))
+	if len(descendants) == 0 {
+		return
+	}
+	for _, childPid := range descendants {
+		signalPid(childPid, true)
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("KillDescendants", recover())
+		}()
+		time.Sleep(gracefulWait)
+		for _, childPid := range descendants {
+			if running, _ := process.PidExists(childPid); running {
+				log.Printf("force killing leftover process %d (parent %d)\n", childPid, pid)
+				signalPid(childPid, false)
+			}
+		}
+	}()
+}
+
+// returns the process creation time (unix ms) or 0 if the process does not exist
+func GetProcCreateTime(pid int) int64 {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return 0
+	}
+	createTime, err := proc.CreateTime()
+	if err != nil {
+		return 0
+	}
+	return createTime
+}
+
+// kills pid and all of its descendants, forcefully
+func KillProcessTree(pid int) {
+	if pid <= 0 {
+		return
+	}
+	descendants := getDescendantPids(int32(pid))
+	signalPid(int32(pid), false)
+	for _, childPid := range descendants {
+		signalPid(childPid, false)
+	}
+}
+
+// returns the cpu time (user + system, in seconds) used by proc so far, or -1 if it can't be read
+func getCpuSeconds(proc *process.Process) float64 {
+	times, err := proc.Times()
+	if err != nil {
+		return -1
+	}
+	return times.User + times.System
+}
+
+// returns pid and all of its descendants (pid first, then children depth-first).  cpu usage is
+// sampled over sampleTime, so this blocks for that long.
+func GetProcessTree(pid int, sampleTime time.Duration) ([]wshrpc.ProcessInfo, error) {
+	root, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return nil, fmt.Errorf("process %d not found: %w", pid, err)
+	}
+	var procs []*process.Process
+	var rtn []wshrpc.ProcessInfo
+	var addProc func(proc *process.Process, parentPid int, depth int)
+	addProc = func(proc *process.Process, parentPid int, depth int) {
+		info := wshrpc.ProcessInfo{Pid: int(proc.Pid), ParentPid: parentPid, Depth: depth}
+		info.Name, _ = proc.Name()
+		info.CmdLine, _ = proc.Cmdline()
+		info.CreateTs, _ = proc.CreateTime()
+		if memInfo, err := proc.MemoryInfo(); err == nil {
+			info.MemRss = int64(memInfo.RSS)
+		}
+		procs = append(procs, proc)
+		rtn = append(rtn, info)
+		children, _ := proc.Children()
+		for _, child := range children {
+			addProc(child, int(proc.Pid), depth+1)
+		}
+	}
+	rootPpid, _ := root.Ppid()
+	addProc(root, int(rootPpid), 0)
+	startCpu := make([]float64, len(procs))
+	for idx, proc := range procs {
+		startCpu[idx] = getCpuSeconds(proc)
+	}
+	time.Sleep(sampleTime)
+	for idx, proc := range procs {
+		endCpu := getCpuSeconds(proc)
+		if startCpu[idx] < 0 || endCpu < 0 {
+			continue
+		}
+		rtn[idx].CpuPercent = max(0, endCpu-startCpu[idx]) / sampleTime.Seconds() * 100
+	}
+	return rtn, nil
+}
+
+// reports whether target is pid or one of its descendants
+func IsInProcessTree(pid int, target int) bool {
+	if target == pid {
+		return true
+	}
+	for _, childPid := range getDescendantPids(int32(pid)) {
+		if int(childPid) == target {
+			return true
+		}
+	}
+	return false
+}
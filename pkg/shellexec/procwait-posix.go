@@ -0,0 +1,24 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package shellexec
+
+import (
+	"os/exec"
+)
+
+// on posix systems exec.Cmd tracks the process directly (see procwait-win.go)
+type procWaitState struct {
+	exitCode int
+	exited   bool
+}
+
+func makeProcWaitState(cmd *exec.Cmd) *procWaitState {
+	return nil
+}
+
+func (ws *procWaitState) wait() error {
+	return nil
+}
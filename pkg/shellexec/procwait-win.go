@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package shellexec
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+// on windows the conpty layer starts the process itself (not via exec.Cmd.Start) and
+// reaps it in its own goroutine, so Cmd.Wait() and Cmd.ProcessState are never usable.
+// we hold our own handle to the process so we can wait on it and read its exit code.
+type procWaitState struct {
+	handle   windows.Handle
+	exitCode int
+	exited   bool
+}
+
+func makeProcWaitState(cmd *exec.Cmd) *procWaitState {
+	if cmd.Process == nil {
+		return nil
+	}
+	handle, err := windows.OpenProcess(windows.SYNCHRONIZE|windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		log.Printf("error opening process handle for pid %d: %v\n", cmd.Process.Pid, err)
+		return nil
+	}
+	return &procWaitState{handle: handle, exitCode: -1}
+}
+
+func (ws *procWaitState) wait() error {
+	defer windows.CloseHandle(ws.handle)
+	event, err := windows.WaitForSingleObject(ws.handle, windows.INFINITE)
+	if err != nil {
+		return fmt.Errorf("error waiting for process: %w", err)
+	}
+	if event != windows.WAIT_OBJECT_0 {
+		return fmt.Errorf("unexpected wait result for process: %d", event)
+	}
+	var exitCode uint32
+	err = windows.GetExitCodeProcess(ws.handle, &exitCode)
+	ws.exited = true
+	if err != nil {
+		return fmt.Errorf("error getting process exit code: %w", err)
+	}
+	ws.exitCode = int(exitCode)
+	if exitCode != 0 {
+		return fmt.Errorf("exit status %d", exitCode)
+	}
+	return nil
+}
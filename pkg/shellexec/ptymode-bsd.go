@@ -0,0 +1,10 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build darwin || freebsd || netbsd || openbsd
+
+package shellexec
+
+import "golang.org/x/sys/unix"
+
+const ioctlReadTermios = unix.TIOCGETA
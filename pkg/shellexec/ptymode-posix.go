@@ -0,0 +1,39 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package shellexec
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// returns true when the pty's line discipline has echo turned off but still reads whole lines (canonical mode),
+// which is how programs read passwords (full screen applications and shell line editors turn off canonical mode
+// as well).  the master side of the pty reports the modes set on the terminal side.
+func (cw CmdWrap) IsPasswordMode() (bool, error) {
+	sc, ok := cw.Pty.(syscall.Conn)
+	if !ok {
+		return false, fmt.Errorf("pty modes cannot be read")
+	}
+	// uses SyscallConn because calling Fd() would put the pty back into blocking mode
+	rawConn, err := sc.SyscallConn()
+	if err != nil {
+		return false, err
+	}
+	var termios *unix.Termios
+	ctlErr := rawConn.Control(func(fd uintptr) {
+		termios, err = unix.IoctlGetTermios(int(fd), ioctlReadTermios)
+	})
+	if ctlErr != nil {
+		return false, ctlErr
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading pty modes: %w", err)
+	}
+	return termios.Lflag&unix.ECHO == 0 && termios.Lflag&unix.ICANON != 0, nil
+}
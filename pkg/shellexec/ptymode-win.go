@@ -0,0 +1,13 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package shellexec
+
+import "fmt"
+
+// the pseudo console does not expose the console modes of the program reading its input
+func (cw CmdWrap) IsPasswordMode() (bool, error) {
+	return false, fmt.Errorf("pty modes cannot be read on windows")
+}
@@ -23,9 +23,12 @@ import (
 	"github.com/creack/pty"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/ptyhost"
+	"github.com/wavetermdev/waveterm/pkg/remote"
 	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
 	"github.com/wavetermdev/waveterm/pkg/util/pamparse"
 	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
@@ -35,6 +38,7 @@ import (
 )
 
 const DefaultGracefulKillWait = 400 * time.Millisecond
+const RemotePtyAttachTimeout = 5 * time.Second
 
 type CommandOptsType struct {
 	Interactive bool                      `json:"interactive,omitempty"`
@@ -42,7 +46,11 @@ type CommandOptsType struct {
 	Cwd         string                    `json:"cwd,omitempty"`
 	ShellPath   string                    `json:"shellPath,omitempty"`
 	ShellOpts   []string                  `json:"shellOpts,omitempty"`
+	RcFile      string                    `json:"rcFile,omitempty"` // sourced by the shell integration after the user's startup files
+	Argv        []string                  `json:"argv,omitempty"`   // run this command instead of a shell (no shell integration)
 	SwapToken   *shellutil.TokenSwapEntry `json:"swapToken,omitempty"`
+	PtyHostSock string                    `json:"ptyHostSock,omitempty"` // local shells only, runs the shell in a ptyhost helper listening here
+	RemotePty   bool                      `json:"remotePty,omitempty"`   // ssh shells with wsh, runs the shell in a ptyhost helper on the remote host
 }
 
 type ShellProc struct {
@@ -71,6 +79,69 @@ func (sp *ShellProc) Close() {
 	}()
 }
 
+// returns the pid of the local process backing this shell (0 for remote shells).
+// for persistent sessions this is the ptyhost helper (which owns the shell).
+func (sp *ShellProc) GetLocalPid() int {
+	if pw, ok := sp.Cmd.(PtyHostWrap); ok {
+		if pw.ConnName != "" {
+			return 0
+		}
+		return pw.HostPid
+	}
+	cw, ok := sp.Cmd.(CmdWrap)
+	if !ok || cw.Cmd.Process == nil {
+		return 0
+	}
+	return cw.Cmd.Process.Pid
+}
+
+// returns the pid of the shell itself for local shells (0 for remote shells).
+// unlike GetLocalPid this is the shell, not the ptyhost helper, for persistent sessions.
+func (sp *ShellProc) GetLocalShellPid() int {
+	if pw, ok := sp.Cmd.(PtyHostWrap); ok && pw.ConnName == "" {
+		return pw.Client.Pid()
+	}
+	return sp.GetLocalPid()
+}
+
+// returns the ptyhost socket path for persistent sessions ("" otherwise)
+func (sp *ShellProc) GetPtyHostSock() string {
+	if pw, ok := sp.Cmd.(PtyHostWrap); ok {
+		return pw.SockPath
+	}
+	return ""
+}
+
+// returns the connection and the pid of the ptyhost helper for persistent sessions on a remote host ("" otherwise)
+func (sp *ShellProc) GetRemotePtyHost() (string, int) {
+	if pw, ok := sp.Cmd.(PtyHostWrap); ok && pw.ConnName != "" {
+		return pw.ConnName, pw.HostPid
+	}
+	return "", 0
+}
+
+// detaches from a persistent session, the shell keeps running in its ptyhost helper
+func (sp *ShellProc) Detach(timeout time.Duration) error {
+	pw, ok := sp.Cmd.(PtyHostWrap)
+	if !ok {
+		return fmt.Errorf("shell is not a persistent session")
+	}
+	return pw.Client.Detach(timeout)
+}
+
+// true for persistent sessions that were detached.  a remote session that lost its connection counts as detached,
+// the shell keeps running on the remote host and is reattached when the connection is back.
+func (sp *ShellProc) IsDetached() bool {
+	pw, ok := sp.Cmd.(PtyHostWrap)
+	return ok && pw.isDetached()
+}
+
+// true for remote shells on the roam transport, they do not go over the ssh connection
+func (sp *ShellProc) IsRoaming() bool {
+	_, ok := sp.Cmd.(RoamWrap)
+	return ok
+}
+
 func (sp *ShellProc) SetWaitErrorAndSignalDone(waitErr error) {
 	sp.CloseOnce.Do(func() {
 		sp.WaitErr = waitErr
@@ -121,6 +192,37 @@ type PipePty struct {
 	remoteStdoutRead *os.File
 }
 
+// remote commands are started through the user's login shell, exec replaces it with the command.
+// there is no shell integration to export the block's env, so it is passed as assignments.
+func getArgvCmdStr(argv []string, env map[string]string) string {
+	var parts []string
+	for _, name := range utilfn.GetOrderedMapKeys(env) {
+		if shellutil.IsValidEnvVarName(name) {
+			parts = append(parts, name+"="+shellutil.HardQuote(env[name]))
+		}
+	}
+	parts = append(parts, "exec")
+	for _, arg := range argv {
+		parts = append(parts, shellutil.HardQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func getSwapTokenEnv(swapToken *shellutil.TokenSwapEntry) map[string]string {
+	if swapToken == nil {
+		return nil
+	}
+	return swapToken.Env
+}
+
+// the shell integration sources the rc file named in the swap token env (nu is passed it on the command line instead)
+func setSwapTokenRcFile(swapToken *shellutil.TokenSwapEntry, shellType string, rcFile string) {
+	if swapToken == nil || swapToken.Env == nil || rcFile == "" || shellType == shellutil.ShellType_nu {
+		return
+	}
+	swapToken.Env[shellutil.WaveRcFileVarName] = rcFile
+}
+
 func (pp *PipePty) Fd() uintptr {
 	return pp.remoteStdinWrite.Fd()
 }
@@ -156,6 +258,10 @@ func StartWslShellProcNoWsh(ctx context.Context, termSize waveobj.TermSize, cmdS
 	conn.Infof(ctx, "WSL-NEWSESSION (StartWslShellProcNoWsh)")
 
 	ecmd := exec.Command("wsl.exe", "~", "-d", client.Name())
+	if len(cmdOpts.Argv) > 0 {
+		ecmd.Args = append(ecmd.Args, "--")
+		ecmd.Args = append(ecmd.Args, cmdOpts.Argv...)
+	}
 
 	if termSize.Rows == 0 || termSize.Cols == 0 {
 		termSize.Rows = shellutil.DefaultTermRows
@@ -213,17 +319,26 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 	shellType := shellutil.GetShellTypeFromShellPath(shellPath)
 	conn.Infof(ctx, "detected shell type: %s\n", shellType)
 
-	if cmdStr == "" {
+	if len(cmdOpts.Argv) > 0 {
+		cmdCombined = getArgvCmdStr(cmdOpts.Argv, getSwapTokenEnv(cmdOpts.SwapToken))
+		shellType = shellutil.ShellType_unknown
+	} else if cmdStr == "" {
 		/* transform command in order to inject environment vars */
 		if shellType == shellutil.ShellType_bash {
 			// add --rcfile
 			// cant set -l or -i with --rcfile
 			bashPath := fmt.Sprintf("~/.waveterm/%s/.bashrc", shellutil.BashIntegrationDir)
 			shellOpts = append(shellOpts, "--rcfile", bashPath)
+			if !cmdOpts.Login {
+				shellPath = fmt.Sprintf("%s=0 %s", shellutil.WaveLoginShellVarName, shellPath)
+			}
 		} else if shellType == shellutil.ShellType_fish {
 			if cmdOpts.Login {
 				shellOpts = append(shellOpts, "-l")
 			}
+			if cmdOpts.Interactive {
+				shellOpts = append(shellOpts, "-i")
+			}
 			// source the wave.fish file
 			waveFishPath := fmt.Sprintf("~/.waveterm/%s/wave.fish", shellutil.FishIntegrationDir)
 			carg := fmt.Sprintf(`"source %s"`, waveFishPath)
@@ -232,7 +347,25 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 			pwshPath := fmt.Sprintf("~/.waveterm/%s/wavepwsh.ps1", shellutil.PwshIntegrationDir)
 			// powershell is weird about quoted path executables and requires an ampersand first
 			shellPath = "& " + shellPath
+			// -Login must be the first arg, and does nothing on windows
+			if cmdOpts.Login && !strings.HasSuffix(strings.ToLower(shellPath), ".exe") {
+				shellOpts = append([]string{"-Login"}, shellOpts...)
+			}
 			shellOpts = append(shellOpts, "-ExecutionPolicy", "Bypass", "-NoExit", "-File", pwshPath)
+		} else if shellType == shellutil.ShellType_nu {
+			if cmdOpts.Login {
+				shellOpts = append(shellOpts, "-l")
+			}
+			if cmdOpts.Interactive {
+				shellOpts = append(shellOpts, "-i")
+			}
+			// source the wave.nu file (and the block's rc file), then stay interactive
+			waveNuPath := fmt.Sprintf("~/.waveterm/%s/wave.nu", shellutil.NuIntegrationDir)
+			carg := fmt.Sprintf(`"source %s"`, waveNuPath)
+			if cmdOpts.RcFile != "" {
+				carg = fmt.Sprintf(`"source %s; source %s"`, waveNuPath, cmdOpts.RcFile)
+			}
+			shellOpts = append(shellOpts, "-e", carg)
 		} else {
 			if cmdOpts.Login {
 				shellOpts = append(shellOpts, "-l")
@@ -242,6 +375,7 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 			}
 			// zdotdir setting moved to after session is created
 		}
+		setSwapTokenRcFile(cmdOpts.SwapToken, shellType, cmdOpts.RcFile)
 		cmdCombined = fmt.Sprintf("%s %s", shellPath, strings.Join(shellOpts, " "))
 	} else {
 		// TODO check quoting of cmdStr
@@ -293,6 +427,10 @@ func StartRemoteShellProcNoWsh(ctx context.Context, termSize waveobj.TermSize, c
 	if err != nil {
 		return nil, err
 	}
+	err = remote.RequestAgentForwarding(client, session)
+	if err != nil {
+		conn.Infof(ctx, "unable to forward the identity agent: %v\n", err)
+	}
 
 	remoteStdinRead, remoteStdinWriteOurs, err := os.Pipe()
 	if err != nil {
@@ -321,7 +459,11 @@ func StartRemoteShellProcNoWsh(ctx context.Context, termSize waveobj.TermSize, c
 
 	session.RequestPty("xterm-256color", termSize.Rows, termSize.Cols, nil)
 	sessionWrap := MakeSessionWrap(session, "", pipePty)
-	err = session.Shell()
+	if len(cmdOpts.Argv) > 0 {
+		err = session.Start(getArgvCmdStr(cmdOpts.Argv, getSwapTokenEnv(cmdOpts.SwapToken)))
+	} else {
+		err = session.Shell()
+	}
 	if err != nil {
 		pipePty.Close()
 		return nil, err
@@ -364,17 +506,26 @@ func StartRemoteShellProc(ctx context.Context, logCtx context.Context, termSize
 	conn.Infof(logCtx, "detected shell type: %s\n", shellType)
 	conn.Infof(logCtx, "swaptoken: %s\n", cmdOpts.SwapToken.Token)
 
-	if cmdStr == "" {
+	if len(cmdOpts.Argv) > 0 {
+		cmdCombined = getArgvCmdStr(cmdOpts.Argv, getSwapTokenEnv(cmdOpts.SwapToken))
+		shellType = shellutil.ShellType_unknown
+	} else if cmdStr == "" {
 		/* transform command in order to inject environment vars */
 		if shellType == shellutil.ShellType_bash {
 			// add --rcfile
 			// cant set -l or -i with --rcfile
 			bashPath := fmt.Sprintf("~/.waveterm/%s/.bashrc", shellutil.BashIntegrationDir)
 			shellOpts = append(shellOpts, "--rcfile", bashPath)
+			if !cmdOpts.Login {
+				shellPath = fmt.Sprintf("%s=0 %s", shellutil.WaveLoginShellVarName, shellPath)
+			}
 		} else if shellType == shellutil.ShellType_fish {
 			if cmdOpts.Login {
 				shellOpts = append(shellOpts, "-l")
 			}
+			if cmdOpts.Interactive {
+				shellOpts = append(shellOpts, "-i")
+			}
 			// source the wave.fish file
 			waveFishPath := fmt.Sprintf("~/.waveterm/%s/wave.fish", shellutil.FishIntegrationDir)
 			carg := fmt.Sprintf(`"source %s"`, waveFishPath)
@@ -383,7 +534,25 @@ func StartRemoteShellProc(ctx context.Context, logCtx context.Context, termSize
 			pwshPath := fmt.Sprintf("~/.waveterm/%s/wavepwsh.ps1", shellutil.PwshIntegrationDir)
 			// powershell is weird about quoted path executables and requires an ampersand first
 			shellPath = "& " + shellPath
+			// -Login must be the first arg, and does nothing on windows
+			if cmdOpts.Login && !strings.HasSuffix(strings.ToLower(shellPath), ".exe") {
+				shellOpts = append([]string{"-Login"}, shellOpts...)
+			}
 			shellOpts = append(shellOpts, "-ExecutionPolicy", "Bypass", "-NoExit", "-File", pwshPath)
+		} else if shellType == shellutil.ShellType_nu {
+			if cmdOpts.Login {
+				shellOpts = append(shellOpts, "-l")
+			}
+			if cmdOpts.Interactive {
+				shellOpts = append(shellOpts, "-i")
+			}
+			// source the wave.nu file (and the block's rc file), then stay interactive
+			waveNuPath := fmt.Sprintf("~/.waveterm/%s/wave.nu", shellutil.NuIntegrationDir)
+			carg := fmt.Sprintf(`"source %s"`, waveNuPath)
+			if cmdOpts.RcFile != "" {
+				carg = fmt.Sprintf(`"source %s; source %s"`, waveNuPath, cmdOpts.RcFile)
+			}
+			shellOpts = append(shellOpts, "-e", carg)
 		} else {
 			if cmdOpts.Login {
 				shellOpts = append(shellOpts, "-l")
@@ -393,6 +562,7 @@ func StartRemoteShellProc(ctx context.Context, logCtx context.Context, termSize
 			}
 			// zdotdir setting moved to after session is created
 		}
+		setSwapTokenRcFile(cmdOpts.SwapToken, shellType, cmdOpts.RcFile)
 		cmdCombined = fmt.Sprintf("%s %s", shellPath, strings.Join(shellOpts, " "))
 	} else {
 		// TODO check quoting of cmdStr
@@ -401,11 +571,54 @@ func StartRemoteShellProc(ctx context.Context, logCtx context.Context, termSize
 		cmdCombined = fmt.Sprintf("%s %s", shellPath, strings.Join(shellOpts, " "))
 	}
 	conn.Infof(logCtx, "starting shell, using command: %s\n", cmdCombined)
+	if termSize.Rows == 0 || termSize.Cols == 0 {
+		termSize.Rows = shellutil.DefaultTermRows
+		termSize.Cols = shellutil.DefaultTermCols
+	}
+	if termSize.Rows <= 0 || termSize.Cols <= 0 {
+		return nil, fmt.Errorf("invalid term size: %v", termSize)
+	}
+	if shellType == shellutil.ShellType_zsh {
+		zshDir := fmt.Sprintf("~/.waveterm/%s", shellutil.ZshIntegrationDir)
+		conn.Infof(logCtx, "setting ZDOTDIR to %s\n", zshDir)
+		cmdCombined = fmt.Sprintf(`ZDOTDIR=%s %s`, zshDir, cmdCombined)
+	}
+	packedToken, err := cmdOpts.SwapToken.PackForClient()
+	if err != nil {
+		conn.Infof(logCtx, "error packing swap token: %v", err)
+	} else {
+		conn.Debugf(logCtx, "packed swaptoken %s\n", packedToken)
+		cmdCombined = fmt.Sprintf(`%s=%s %s`, wavebase.WaveSwapTokenVarName, packedToken, cmdCombined)
+	}
+	if cmdOpts.Cwd != "" && remoteInfo.ClientOs != "windows" {
+		conn.Infof(logCtx, "starting in %s\n", cmdOpts.Cwd)
+		cmdCombined = fmt.Sprintf("%s 2>/dev/null; %s", getRemoteCdCmd(cmdOpts.Cwd), cmdCombined)
+	}
+	shellutil.AddTokenSwapEntry(cmdOpts.SwapToken)
+	if cmdOpts.RemotePty && !conn.UseRoamTransport() {
+		shellProc, err := startRemotePtyHostShellProc(ctx, termSize, cmdCombined, conn)
+		if err == nil {
+			conn.Infof(logCtx, "started persistent shell session (remote ptyhost %s)\n", shellProc.GetPtyHostSock())
+			return shellProc, nil
+		}
+		conn.Infof(logCtx, "could not start a persistent shell session, using ssh: %v\n", err)
+	}
+	if conn.UseRoamTransport() {
+		roamShell, err := conn.StartRoamShell(logCtx, cmdCombined, termSize)
+		if err == nil {
+			return &ShellProc{Cmd: RoamWrap{Shell: roamShell}, ConnName: conn.GetName(), CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
+		}
+		conn.Infof(logCtx, "roam transport not available, using ssh: %v\n", err)
+	}
 	conn.Infof(logCtx, "SSH-NEWSESSION (StartRemoteShellProc)\n")
 	session, err := client.NewSession()
 	if err != nil {
 		return nil, err
 	}
+	err = remote.RequestAgentForwarding(client, session)
+	if err != nil {
+		conn.Infof(logCtx, "unable to forward the identity agent: %v\n", err)
+	}
 	remoteStdinRead, remoteStdinWriteOurs, err := os.Pipe()
 	if err != nil {
 		return nil, err
@@ -420,29 +633,9 @@ func StartRemoteShellProc(ctx context.Context, logCtx context.Context, termSize
 		remoteStdinWrite: remoteStdinWriteOurs,
 		remoteStdoutRead: remoteStdoutReadOurs,
 	}
-	if termSize.Rows == 0 || termSize.Cols == 0 {
-		termSize.Rows = shellutil.DefaultTermRows
-		termSize.Cols = shellutil.DefaultTermCols
-	}
-	if termSize.Rows <= 0 || termSize.Cols <= 0 {
-		return nil, fmt.Errorf("invalid term size: %v", termSize)
-	}
 	session.Stdin = remoteStdinRead
 	session.Stdout = remoteStdoutWrite
 	session.Stderr = remoteStdoutWrite
-	if shellType == shellutil.ShellType_zsh {
-		zshDir := fmt.Sprintf("~/.waveterm/%s", shellutil.ZshIntegrationDir)
-		conn.Infof(logCtx, "setting ZDOTDIR to %s\n", zshDir)
-		cmdCombined = fmt.Sprintf(`ZDOTDIR=%s %s`, zshDir, cmdCombined)
-	}
-	packedToken, err := cmdOpts.SwapToken.PackForClient()
-	if err != nil {
-		conn.Infof(logCtx, "error packing swap token: %v", err)
-	} else {
-		conn.Debugf(logCtx, "packed swaptoken %s\n", packedToken)
-		cmdCombined = fmt.Sprintf(`%s=%s %s`, wavebase.WaveSwapTokenVarName, packedToken, cmdCombined)
-	}
-	shellutil.AddTokenSwapEntry(cmdOpts.SwapToken)
 	session.RequestPty("xterm-256color", termSize.Rows, termSize.Cols, nil)
 	sessionWrap := MakeSessionWrap(session, cmdCombined, pipePty)
 	err = sessionWrap.Start()
@@ -453,6 +646,17 @@ func StartRemoteShellProc(ctx context.Context, logCtx context.Context, termSize
 	return &ShellProc{Cmd: sessionWrap, ConnName: conn.GetName(), CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
 }
 
+// the cd for the command line of a remote shell, "~" is left for the remote shell to expand
+func getRemoteCdCmd(cwd string) string {
+	if cwd == "~" {
+		return "cd ~"
+	}
+	if rest, ok := strings.CutPrefix(cwd, "~/"); ok {
+		return "cd ~/" + utilfn.ShellQuote(rest, false, -1)
+	}
+	return "cd " + utilfn.ShellQuote(cwd, false, -1)
+}
+
 func isZshShell(shellPath string) bool {
 	// get the base path, and then check contains
 	shellBase := filepath.Base(shellPath)
@@ -481,36 +685,29 @@ func StartLocalShellProc(logCtx context.Context, termSize waveobj.TermSize, cmdS
 	}
 	shellType := shellutil.GetShellTypeFromShellPath(shellPath)
 	shellOpts = append(shellOpts, cmdOpts.ShellOpts...)
-	if cmdStr == "" {
-		if shellType == shellutil.ShellType_bash {
-			// add --rcfile
-			// cant set -l or -i with --rcfile
-			shellOpts = append(shellOpts, "--rcfile", shellutil.GetLocalBashRcFileOverride())
-		} else if shellType == shellutil.ShellType_fish {
-			if cmdOpts.Login {
-				shellOpts = append(shellOpts, "-l")
-			}
-			waveFishPath := shellutil.GetLocalWaveFishFilePath()
-			carg := fmt.Sprintf("source %s", shellutil.HardQuoteFish(waveFishPath))
-			shellOpts = append(shellOpts, "-C", carg)
-		} else if shellType == shellutil.ShellType_pwsh {
-			shellOpts = append(shellOpts, "-ExecutionPolicy", "Bypass", "-NoExit", "-File", shellutil.GetLocalWavePowershellEnv())
-		} else {
-			if cmdOpts.Login {
-				shellOpts = append(shellOpts, "-l")
-			}
-			if cmdOpts.Interactive {
-				shellOpts = append(shellOpts, "-i")
-			}
+	if len(cmdOpts.Argv) > 0 {
+		blocklogger.Debugf(logCtx, "[conndebug] argv:%v\n", cmdOpts.Argv)
+		ecmd = exec.Command(cmdOpts.Argv[0], cmdOpts.Argv[1:]...)
+		ecmd.Env = os.Environ()
+		shellutil.UpdateCmdEnv(ecmd, getSwapTokenEnv(cmdOpts.SwapToken))
+	} else if cmdStr == "" {
+		rcFile := cmdOpts.RcFile
+		if rcFile != "" {
+			rcFile = wavebase.ExpandHomeDirSafe(rcFile)
 		}
+		integrationOpts, integrationEnv := shellutil.GetLocalShellIntegrationOpts(shellType, cmdOpts.Login, cmdOpts.Interactive, rcFile)
+		setSwapTokenRcFile(cmdOpts.SwapToken, shellType, rcFile)
+		shellOpts = append(shellOpts, integrationOpts...)
 		blocklogger.Debugf(logCtx, "[conndebug] shell:%s shellOpts:%v\n", shellPath, shellOpts)
 		ecmd = exec.Command(shellPath, shellOpts...)
 		ecmd.Env = os.Environ()
-		if shellType == shellutil.ShellType_zsh {
-			shellutil.UpdateCmdEnv(ecmd, map[string]string{"ZDOTDIR": shellutil.GetLocalZshZDotDir()})
-		}
+		shellutil.UpdateCmdEnv(ecmd, integrationEnv)
 	} else {
-		shellOpts = append(shellOpts, "-c", cmdStr)
+		if shellType == shellutil.ShellType_cmd {
+			shellOpts = append(shellOpts, "/C", cmdStr)
+		} else {
+			shellOpts = append(shellOpts, "-c", cmdStr)
+		}
 		ecmd = exec.Command(shellPath, shellOpts...)
 		ecmd.Env = os.Environ()
 	}
@@ -564,6 +761,9 @@ func StartLocalShellProc(logCtx context.Context, termSize waveobj.TermSize, cmdS
 		return nil, fmt.Errorf("invalid term size: %v", termSize)
 	}
 	shellutil.AddTokenSwapEntry(cmdOpts.SwapToken)
+	if cmdOpts.PtyHostSock != "" {
+		return startPtyHostShellProc(ecmd, termSize, cmdOpts.PtyHostSock)
+	}
 	cmdPty, err := pty.StartWithSize(ecmd, &pty.Winsize{Rows: uint16(termSize.Rows), Cols: uint16(termSize.Cols)})
 	if err != nil {
 		return nil, err
@@ -572,6 +772,102 @@ func StartLocalShellProc(logCtx context.Context, termSize waveobj.TermSize, cmdS
 	return &ShellProc{Cmd: cmdWrap, CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
 }
 
+func startPtyHostShellProc(ecmd *exec.Cmd, termSize waveobj.TermSize, sockPath string) (*ShellProc, error) {
+	if ecmd.Err != nil {
+		return nil, ecmd.Err
+	}
+	spec := ptyhost.StartSpec{
+		Path: ecmd.Path,
+		Args: ecmd.Args,
+		Env:  ecmd.Env,
+		Dir:  ecmd.Dir,
+		Rows: termSize.Rows,
+		Cols: termSize.Cols,
+	}
+	hostPid, err := ptyhost.StartHost(sockPath, spec)
+	if err != nil {
+		return nil, err
+	}
+	shellProc, err := AttachPtyHostShellProc(sockPath)
+	if err != nil {
+		KillProcessTree(hostPid)
+		return nil, err
+	}
+	return shellProc, nil
+}
+
+// reattaches to a persistent session started by this (or a previous) wavesrv
+func AttachPtyHostShellProc(sockPath string) (*ShellProc, error) {
+	client, err := ptyhost.Attach(sockPath)
+	if err != nil {
+		return nil, err
+	}
+	pw := PtyHostWrap{Client: client, SockPath: sockPath, HostPid: client.HostPid()}
+	return &ShellProc{Cmd: pw, CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
+}
+
+func startRemotePtyHostShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr string, conn *conncontroller.SSHConn) (*ShellProc, error) {
+	rpcClient := wshclient.GetBareRpcClient()
+	rpcOpts := &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(conn.GetName()), Timeout: 10000}
+	info, err := wshclient.RemotePtyStartCommand(rpcClient, wshrpc.CommandRemotePtyStartData{Cmd: cmdStr, TermSize: termSize}, rpcOpts)
+	if err != nil {
+		return nil, err
+	}
+	shellProc, err := AttachRemotePtyHostShellProc(ctx, conn, info.SockPath)
+	if err != nil {
+		return nil, fmt.Errorf("error attaching to the remote ptyhost (pid %d): %w", info.HostPid, err)
+	}
+	return shellProc, nil
+}
+
+// attaches to a persistent session on the remote host of conn.  the frames go over an ssh channel to the helper's
+// socket, next to the other channels of the connection.
+func AttachRemotePtyHostShellProc(ctx context.Context, conn *conncontroller.SSHConn, sockPath string) (*ShellProc, error) {
+	client := conn.GetClient()
+	if client == nil {
+		return nil, fmt.Errorf("%s is not connected", conn.GetName())
+	}
+	type attachResult struct {
+		client *ptyhost.Client
+		err    error
+	}
+	// ssh channels do not support deadlines, so the attach is given up on (and closed once it returns) after the timeout
+	resultCh := make(chan attachResult, 1)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("AttachRemotePtyHostShellProc", recover())
+		}()
+		chanConn, err := client.Dial("unix", sockPath)
+		if err != nil {
+			resultCh <- attachResult{err: fmt.Errorf("error connecting to remote ptyhost: %w", err)}
+			return
+		}
+		phClient, err := ptyhost.AttachConn(chanConn, ptyhost.AttachTimeout)
+		resultCh <- attachResult{client: phClient, err: err}
+	}()
+	timer := time.NewTimer(RemotePtyAttachTimeout)
+	defer timer.Stop()
+	select {
+	case result := <-resultCh:
+		if result.err != nil {
+			return nil, result.err
+		}
+		pw := PtyHostWrap{Client: result.client, SockPath: sockPath, HostPid: result.client.HostPid(), ConnName: conn.GetName()}
+		return &ShellProc{Cmd: pw, ConnName: conn.GetName(), CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("AttachRemotePtyHostShellProc:close", recover())
+		}()
+		if result := <-resultCh; result.client != nil {
+			result.client.Close()
+		}
+	}()
+	return nil, fmt.Errorf("timeout attaching to remote ptyhost")
+}
+
 func RunSimpleCmdInPty(ecmd *exec.Cmd, termSize waveobj.TermSize) ([]byte, error) {
 	ecmd.Env = os.Environ()
 	shellutil.UpdateCmdEnv(ecmd, shellutil.WaveshellLocalEnvVars(shellutil.DefaultTermType))
@@ -0,0 +1,20 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package shellexec
+
+import (
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/util/sigutil"
+)
+
+// signals the foreground process group of the pty (the shell itself when no job is running)
+func (cw CmdWrap) Signal(sigName string) error {
+	if cw.Cmd.Process == nil || cw.exited() {
+		return fmt.Errorf("process is not running")
+	}
+	return sigutil.SignalPtyForeground(cw.Pty, cw.Cmd.Process.Pid, sigName)
+}
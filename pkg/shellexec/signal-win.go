@@ -0,0 +1,44 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package shellexec
+
+import (
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/util/sigutil"
+	"golang.org/x/sys/windows"
+)
+
+// windows has no process groups in the posix sense.  an interrupt is raised by the pseudo console from a ^C
+// in its input, ctrl-break is sent to the shell's console process group, and the terminating signals kill
+// the shell's descendants (the running command), or the shell itself when nothing else is running.
+func (cw CmdWrap) Signal(sigName string) error {
+	if cw.Cmd.Process == nil || cw.exited() {
+		return fmt.Errorf("process is not running")
+	}
+	pid := cw.Cmd.Process.Pid
+	switch sigName {
+	case sigutil.Signal_Int:
+		_, err := cw.Pty.Write([]byte{0x03})
+		return err
+	case sigutil.Signal_Break:
+		err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(pid))
+		if err != nil {
+			return fmt.Errorf("error sending ctrl-break to pid %d: %w", pid, err)
+		}
+		return nil
+	case sigutil.Signal_Term, sigutil.Signal_Hup, sigutil.Signal_Kill:
+		descendants := getDescendantPids(int32(pid))
+		if len(descendants) == 0 {
+			return cw.Cmd.Process.Kill()
+		}
+		for _, childPid := range descendants {
+			signalPid(childPid, false)
+		}
+		return nil
+	}
+	return fmt.Errorf("signal %s is not supported on windows", sigName)
+}
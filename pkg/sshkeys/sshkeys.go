@@ -0,0 +1,385 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sshkeys manages the ssh keys of the user (the private keys in ~/.ssh on the machine running wave), so
+// keys can be listed, generated, added to the identity agent, and used by saved connections without running
+// ssh-keygen or ssh-add.  generated keys are written in the openssh format, with the public key next to the private
+// key (as "name.pub").
+package sshkeys
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	KeyType_Ed25519 = "ed25519"
+	KeyType_Ecdsa   = "ecdsa"
+	KeyType_Rsa     = "rsa"
+)
+
+const DefaultRsaBits = 3072
+const MaxKeyFileSize = 64 * 1024
+
+var privateKeyMarker = []byte("PRIVATE KEY-----")
+
+func getSshDir() string {
+	return filepath.Join(wavebase.GetHomeDir(), ".ssh")
+}
+
+// the path of a key, a bare file name is in ~/.ssh
+func ResolveKeyPath(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("no key name")
+	}
+	if !strings.ContainsAny(name, `/\`) && name != "~" {
+		return filepath.Join(getSshDir(), name), nil
+	}
+	keyPath, err := wavebase.ExpandHomeDir(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(keyPath)
+}
+
+// the private keys in ~/.ssh, by path (with whether they are in the identity agent, and the saved connections that
+// use them)
+func ListKeys(ctx context.Context) ([]wshrpc.SshKeyInfo, error) {
+	entries, err := os.ReadDir(getSshDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", getSshDir(), err)
+	}
+	var rtn []wshrpc.SshKeyInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasSuffix(entry.Name(), ".pub") {
+			continue
+		}
+		info, err := readKeyInfo(filepath.Join(getSshDir(), entry.Name()))
+		if err != nil {
+			// not a private key
+			continue
+		}
+		rtn = append(rtn, *info)
+	}
+	agentKeys := getAgentFingerprints()
+	conns, err := wconn.ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range rtn {
+		rtn[idx].InAgent = rtn[idx].Fingerprint != "" && agentKeys[rtn[idx].Fingerprint]
+		rtn[idx].Connections = findConnections(conns, rtn[idx].Path)
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].Path < rtn[j].Path
+	})
+	return rtn, nil
+}
+
+// the info of the private key at keyPath, an error if it is not a private key
+func readKeyInfo(keyPath string) (*wshrpc.SshKeyInfo, error) {
+	finfo, err := os.Stat(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if finfo.Size() > MaxKeyFileSize {
+		return nil, fmt.Errorf("%s is too large to be a private key", keyPath)
+	}
+	keyBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(keyBytes, privateKeyMarker) {
+		return nil, fmt.Errorf("%s is not a private key", keyPath)
+	}
+	rtn := &wshrpc.SshKeyInfo{Path: keyPath}
+	var pubKey ssh.PublicKey
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	var missingErr *ssh.PassphraseMissingError
+	if errors.As(err, &missingErr) {
+		rtn.Encrypted = true
+		pubKey = missingErr.PublicKey
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", keyPath, err)
+	} else {
+		pubKey = signer.PublicKey()
+	}
+	pubBytes, err := os.ReadFile(keyPath + ".pub")
+	if err == nil {
+		filePubKey, comment, _, _, err := ssh.ParseAuthorizedKey(pubBytes)
+		if err == nil {
+			rtn.PubPath = keyPath + ".pub"
+			rtn.Comment = comment
+			if pubKey == nil {
+				pubKey = filePubKey
+			}
+		}
+	}
+	if pubKey != nil {
+		rtn.KeyType = pubKey.Type()
+		rtn.Bits = remote.PublicKeyBits(pubKey)
+		rtn.Fingerprint = ssh.FingerprintSHA256(pubKey)
+	}
+	return rtn, nil
+}
+
+// the fingerprints of the keys in the default identity agent (none if there is no agent)
+func getAgentFingerprints() map[string]bool {
+	rtn := make(map[string]bool)
+	agentClient, closeFn, err := dialAgent()
+	if err != nil {
+		log.Printf("sshkeys: cannot list the keys of the identity agent: %v\n", err)
+		return rtn
+	}
+	defer closeFn()
+	keys, err := agentClient.List()
+	if err != nil {
+		log.Printf("sshkeys: cannot list the keys of the identity agent: %v\n", err)
+		return rtn
+	}
+	for _, key := range keys {
+		rtn[ssh.FingerprintSHA256(key)] = true
+	}
+	return rtn
+}
+
+func dialAgent() (agent.ExtendedAgent, func(), error) {
+	agentPath, err := remote.GetDefaultIdentityAgent()
+	if err != nil {
+		return nil, nil, err
+	}
+	agentConn, err := remote.DialIdentityAgent(agentPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return agent.NewClient(agentConn), func() { agentConn.Close() }, nil
+}
+
+// the names of the saved connections that use the key
+func findConnections(conns []*waveobj.Connection, keyPath string) []string {
+	var rtn []string
+	for _, conn := range conns {
+		if conn.IdentityFile == "" {
+			continue
+		}
+		connKeyPath, err := ResolveKeyPath(conn.IdentityFile)
+		if err == nil && connKeyPath == keyPath {
+			rtn = append(rtn, conn.Name)
+		}
+	}
+	return rtn
+}
+
+func makeKey(keyType string, bits int) (crypto.PrivateKey, error) {
+	switch keyType {
+	case "", KeyType_Ed25519:
+		if bits != 0 && bits != 256 {
+			return nil, fmt.Errorf("ed25519 keys are 256 bits")
+		}
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case KeyType_Ecdsa:
+		var curve elliptic.Curve
+		switch bits {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("invalid ecdsa key size %d (256, 384 or 521)", bits)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case KeyType_Rsa:
+		if bits == 0 {
+			bits = DefaultRsaBits
+		}
+		if bits < 2048 || bits > 8192 {
+			return nil, fmt.Errorf("invalid rsa key size %d (2048 to 8192)", bits)
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	}
+	return nil, fmt.Errorf("invalid key type %q (ed25519, ecdsa or rsa)", keyType)
+}
+
+func defaultComment() string {
+	hostName, _ := os.Hostname()
+	userName := ""
+	if curUser, err := user.Current(); err == nil {
+		userName = curUser.Username
+	}
+	return userName + "@" + hostName
+}
+
+// generates a keypair, writing the private key (0600) and the public key (name.pub), it can be added to the agent
+// and used by a saved connection
+func GenerateKey(ctx context.Context, data wshrpc.CommandSshKeyGenerateData) (*wshrpc.SshKeyInfo, error) {
+	keyPath, err := ResolveKeyPath(data.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileName := range []string{keyPath, keyPath + ".pub"} {
+		if _, err := os.Stat(fileName); err == nil {
+			return nil, fmt.Errorf("%s already exists", fileName)
+		}
+	}
+	var conn *waveobj.Connection
+	if data.Connection != "" {
+		conn, err = wconn.ResolveConnection(ctx, data.Connection)
+		if err != nil {
+			return nil, err
+		}
+	}
+	comment := data.Comment
+	if comment == "" {
+		comment = defaultComment()
+	}
+	key, err := makeKey(data.KeyType, data.Bits)
+	if err != nil {
+		return nil, err
+	}
+	var pemBlock *pem.Block
+	if data.Passphrase != "" {
+		pemBlock, err = ssh.MarshalPrivateKeyWithPassphrase(key, comment, []byte(data.Passphrase))
+	} else {
+		pemBlock, err = ssh.MarshalPrivateKey(key, comment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the private key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(keyPath), 0700)
+	if err != nil {
+		return nil, err
+	}
+	// O_EXCL, so an existing key is never overwritten
+	keyFile, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = keyFile.Write(pem.EncodeToMemory(pemBlock))
+	closeErr := keyFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(keyPath)
+		return nil, fmt.Errorf("error writing %s: %w", keyPath, err)
+	}
+	pubLine := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))) + " " + comment + "\n"
+	err = os.WriteFile(keyPath+".pub", []byte(pubLine), 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error writing %s.pub: %w", keyPath, err)
+	}
+	if data.AddToAgent {
+		err = AddKeyToAgent(wshrpc.CommandSshKeyAddToAgentData{Path: keyPath, Passphrase: data.Passphrase})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conn != nil {
+		_, err = SetConnectionKey(ctx, conn, keyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	info, err := readKeyInfo(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	info.InAgent = data.AddToAgent
+	if conn != nil {
+		info.Connections = []string{conn.Name}
+	}
+	return info, nil
+}
+
+// adds the private key to the default identity agent (the passphrase is needed for an encrypted key)
+func AddKeyToAgent(data wshrpc.CommandSshKeyAddToAgentData) error {
+	keyPath, err := ResolveKeyPath(data.Path)
+	if err != nil {
+		return err
+	}
+	keyBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	var key any
+	if data.Passphrase != "" {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(keyBytes, []byte(data.Passphrase))
+	} else {
+		key, err = ssh.ParseRawPrivateKey(keyBytes)
+	}
+	var missingErr *ssh.PassphraseMissingError
+	if errors.As(err, &missingErr) {
+		return fmt.Errorf("%s is encrypted, a passphrase is needed", keyPath)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot parse %s: %w", keyPath, err)
+	}
+	if data.LifetimeSecs < 0 {
+		return fmt.Errorf("invalid lifetime %d", data.LifetimeSecs)
+	}
+	agentClient, closeFn, err := dialAgent()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the identity agent: %w", err)
+	}
+	defer closeFn()
+	comment := keyPath
+	if info, err := readKeyInfo(keyPath); err == nil && info.Comment != "" {
+		comment = info.Comment
+	}
+	err = agentClient.Add(agent.AddedKey{PrivateKey: key, Comment: comment, LifetimeSecs: uint32(data.LifetimeSecs)})
+	if err != nil {
+		return fmt.Errorf("error adding %s to the identity agent: %w", keyPath, err)
+	}
+	return nil
+}
+
+// makes the saved connection authenticate with the private key
+func SetConnectionKey(ctx context.Context, conn *waveobj.Connection, keyName string) (*waveobj.Connection, error) {
+	keyPath, err := ResolveKeyPath(keyName)
+	if err != nil {
+		return nil, err
+	}
+	_, err = readKeyInfo(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	update := *conn
+	update.AuthMethod = wconn.AuthMethod_Key
+	update.IdentityFile = keyPath
+	return wconn.UpdateConnection(ctx, &update)
+}
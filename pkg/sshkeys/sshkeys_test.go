@@ -0,0 +1,82 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sshkeys
+
+import (
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func writeKey(t *testing.T, keyPath string, keyType string, bits int, passphrase string) {
+	key, err := makeKey(keyType, bits)
+	if err != nil {
+		t.Fatalf("error making %s key: %v", keyType, err)
+	}
+	var pemBlock *pem.Block
+	if passphrase != "" {
+		pemBlock, err = ssh.MarshalPrivateKeyWithPassphrase(key, "test@host", []byte(passphrase))
+	} else {
+		pemBlock, err = ssh.MarshalPrivateKey(key, "test@host")
+	}
+	if err != nil {
+		t.Fatalf("error encoding key: %v", err)
+	}
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(pemBlock), 0600)
+	if err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+}
+
+func TestReadKeyInfo(t *testing.T) {
+	dir := t.TempDir()
+	edPath := filepath.Join(dir, "id_ed25519")
+	writeKey(t, edPath, KeyType_Ed25519, 0, "")
+	info, err := readKeyInfo(edPath)
+	if err != nil {
+		t.Fatalf("error reading key: %v", err)
+	}
+	if info.KeyType != ssh.KeyAlgoED25519 || info.Bits != 256 || info.Encrypted || info.Fingerprint == "" {
+		t.Errorf("unexpected ed25519 key info: %+v", info)
+	}
+	ecPath := filepath.Join(dir, "id_ecdsa")
+	writeKey(t, ecPath, KeyType_Ecdsa, 384, "secret")
+	info, err = readKeyInfo(ecPath)
+	if err != nil {
+		t.Fatalf("error reading encrypted key: %v", err)
+	}
+	if !info.Encrypted || info.Bits != 384 || info.KeyType != ssh.KeyAlgoECDSA384 {
+		t.Errorf("unexpected encrypted ecdsa key info: %+v", info)
+	}
+	notKeyPath := filepath.Join(dir, "config")
+	os.WriteFile(notKeyPath, []byte("Host *\n  ForwardAgent no\n"), 0600)
+	if _, err := readKeyInfo(notKeyPath); err == nil {
+		t.Errorf("an ssh config should not be a private key")
+	}
+}
+
+func TestMakeKey(t *testing.T) {
+	if _, err := makeKey(KeyType_Rsa, 1024); err == nil {
+		t.Errorf("a 1024 bit rsa key should be refused")
+	}
+	if _, err := makeKey(KeyType_Ecdsa, 300); err == nil {
+		t.Errorf("a 300 bit ecdsa key should be refused")
+	}
+	if _, err := makeKey("dsa", 0); err == nil {
+		t.Errorf("dsa keys should be refused")
+	}
+}
+
+func TestResolveKeyPath(t *testing.T) {
+	keyPath, err := ResolveKeyPath("id_prod")
+	if err != nil || keyPath != filepath.Join(getSshDir(), "id_prod") {
+		t.Errorf("a file name should be in ~/.ssh, got %q (%v)", keyPath, err)
+	}
+	if _, err := ResolveKeyPath(" "); err == nil {
+		t.Errorf("an empty name should be an error")
+	}
+}
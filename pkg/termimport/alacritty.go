@@ -0,0 +1,67 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package termimport
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/util/tomlutil"
+	"github.com/wavetermdev/waveterm/pkg/wtheme"
+)
+
+// the actions of alacritty (lower case) => the wave actions
+var alacrittyActions = map[string]string{
+	"createnewtab":      "tab:new",
+	"selectnexttab":     "tab:next",
+	"selectprevioustab": "tab:prev",
+	"searchforward":     "search:open",
+	"searchbackward":    "search:open",
+}
+
+var alacrittyImportRe = regexp.MustCompile(`(?m)^\s*import\s*=`)
+
+func init() {
+	for idx := 1; idx <= 9; idx++ {
+		alacrittyActions["selecttab"+strconv.Itoa(idx)] = "tab:switch" + strconv.Itoa(idx)
+	}
+}
+
+// reads an alacritty.toml: its colors are a theme (the default one), font.normal.family and font.size are the font,
+// and the [[keyboard.bindings]] with an action wave has are key bindings (the ones that send chars or run a command
+// are skipped)
+func parseAlacritty(data []byte) *importConfig {
+	cfg := newImportConfig()
+	values, arrays := tomlutil.Parse(data)
+	if theme, _, err := wtheme.ParseTheme(wtheme.Format_Alacritty, data); err == nil {
+		cfg.defaultTheme = cfg.addTheme("alacritty", theme)
+	} else if alacrittyImportRe.Match(data) {
+		cfg.skip(Kind_Theme, "import", "the config imports its colors from another file, import it with: wsh theme import FILE")
+	} else {
+		cfg.skip(Kind_Theme, "colors", err.Error())
+	}
+	cfg.fontFamily = values["font.normal.family"]
+	cfg.fontSize, _ = strconv.ParseFloat(values["font.size"], 64)
+	for _, binding := range arrays["keyboard.bindings"] {
+		from := binding["mods"] + " " + binding["key"]
+		from = strings.TrimSpace(from)
+		if binding["action"] == "" {
+			cfg.skip(Kind_KeyBinding, from, "sends chars or runs a command, not an action")
+			continue
+		}
+		from += " (" + binding["action"] + ")"
+		var mods []string
+		if binding["mods"] != "" {
+			mods = strings.Split(binding["mods"], "|")
+		}
+		keys, err := waveKey(mods, binding["key"])
+		if err != nil {
+			cfg.skip(Kind_KeyBinding, from, err.Error())
+			continue
+		}
+		cfg.addKeyBinding(alacrittyActions[strings.ToLower(binding["action"])], keys, from)
+	}
+	return cfg
+}
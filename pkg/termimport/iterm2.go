@@ -0,0 +1,109 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package termimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/util/plistutil"
+	"github.com/wavetermdev/waveterm/pkg/wtheme"
+)
+
+// the styles at the end of the postscript name of a font ("MesloLGS-NF-Regular")
+var fontStyles = map[string]bool{
+	"regular": true, "bold": true, "italic": true, "bolditalic": true, "medium": true, "light": true, "thin": true,
+	"book": true, "retina": true, "semibold": true, "extralight": true, "extrabold": true, "black": true,
+}
+
+// "MesloLGS-NF-Regular 13" => "MesloLGS NF", 13
+func parseITerm2Font(font string) (string, float64) {
+	psName, sizeStr, found := strings.Cut(strings.TrimSpace(font), " ")
+	var size float64
+	if found {
+		size, _ = strconv.ParseFloat(strings.TrimSpace(sizeStr), 64)
+	}
+	parts := strings.Split(psName, "-")
+	if len(parts) > 1 && fontStyles[strings.ToLower(parts[len(parts)-1])] {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, " "), size
+}
+
+// reads iTerm2's preferences (com.googlecode.iterm2.plist, converted to XML with: plutil -convert xml1) or its
+// profiles exported as json (Settings > Profiles > Other Actions > Save All Profiles as JSON).  every profile's
+// colors are a theme, the font and the theme of the default profile are the default ones, and the profiles with a
+// custom command that runs ssh are connections.  iTerm2's key mappings send keys and escape sequences, they have no
+// wave action and are not imported.
+func parseITerm2(data []byte) (*importConfig, error) {
+	var profiles []any
+	var defaultGuid string
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var exported map[string]any
+		if err := json.Unmarshal(data, &exported); err != nil {
+			return nil, fmt.Errorf("invalid iTerm2 profiles: %w", err)
+		}
+		profiles, _ = exported["Profiles"].([]any)
+	} else {
+		val, err := plistutil.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		prefs, ok := val.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("not iTerm2's preferences (expected a dict)")
+		}
+		profiles, _ = prefs["New Bookmarks"].([]any)
+		defaultGuid, _ = prefs["Default Bookmark Guid"].(string)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no iTerm2 profiles found")
+	}
+	cfg := newImportConfig()
+	hasKeyMap := false
+	for idx, profileVal := range profiles {
+		profile, ok := profileVal.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := profile["Name"].(string)
+		guid, _ := profile["Guid"].(string)
+		isDefault := (defaultGuid == "" && idx == 0) || (defaultGuid != "" && guid == defaultGuid)
+		var themeName string
+		theme, err := wtheme.ParseITerm2Dict(profile)
+		if err != nil {
+			cfg.skip(Kind_Theme, name, err.Error())
+		} else {
+			themeName = cfg.addTheme(name, theme)
+		}
+		if isDefault {
+			cfg.defaultTheme = themeName
+			if font, ok := profile["Normal Font"].(string); ok {
+				cfg.fontFamily, cfg.fontSize = parseITerm2Font(font)
+			}
+		}
+		if keyMap, ok := profile["Keyboard Map"].(map[string]any); ok && len(keyMap) > 0 {
+			hasKeyMap = true
+		}
+		if customCmd, _ := profile["Custom Command"].(string); customCmd == "Yes" {
+			cmdLine, _ := profile["Command"].(string)
+			var tags []string
+			if tagVals, ok := profile["Tags"].([]any); ok {
+				for _, tagVal := range tagVals {
+					if tag, ok := tagVal.(string); ok {
+						tags = append(tags, tag)
+					}
+				}
+			}
+			cfg.addSSHProfile(name, cmdLine, themeName, tags)
+		}
+	}
+	if hasKeyMap {
+		cfg.skip(Kind_KeyBinding, "Keyboard Map", "iTerm2's key mappings send keys, they have no wave action")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package termimport
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var modifierNames = map[string]string{
+	"ctrl":    "Ctrl",
+	"control": "Ctrl",
+	"shift":   "Shift",
+	"alt":     "Alt",
+	"opt":     "Option",
+	"option":  "Option",
+	"cmd":     "Cmd",
+	"command": "Cmd",
+	"super":   "Cmd",
+	"win":     "Meta",
+	"meta":    "Meta",
+}
+
+// the names of the keys in the other terminals (lower case) => the names in wave (the KeyboardEvent key)
+var keyNames = map[string]string{
+	"left":          "ArrowLeft",
+	"right":         "ArrowRight",
+	"up":            "ArrowUp",
+	"down":          "ArrowDown",
+	"pgup":          "PageUp",
+	"pageup":        "PageUp",
+	"page_up":       "PageUp",
+	"pgdn":          "PageDown",
+	"pagedown":      "PageDown",
+	"page_down":     "PageDown",
+	"home":          "Home",
+	"end":           "End",
+	"tab":           "Tab",
+	"enter":         "Enter",
+	"return":        "Enter",
+	"esc":           "Escape",
+	"escape":        "Escape",
+	"space":         "Space",
+	"backspace":     "Backspace",
+	"back":          "Backspace",
+	"delete":        "Delete",
+	"insert":        "Insert",
+	"plus":          "+",
+	"minus":         "-",
+	"equal":         "=",
+	"equals":        "=",
+	"comma":         ",",
+	"period":        ".",
+	"slash":         "/",
+	"backslash":     "\\",
+	"bracketleft":   "[",
+	"left_bracket":  "[",
+	"bracketright":  "]",
+	"right_bracket": "]",
+	"semicolon":     ";",
+	"apostrophe":    "'",
+	"grave":         "`",
+	"grave_accent":  "`",
+}
+
+var functionKeyRe = regexp.MustCompile(`^f([1-9]|1[0-9]|2[0-4])$`)
+var digitKeyRe = regexp.MustCompile(`^key([0-9])$`) // alacritty's old names of the digit keys
+
+// a key as wave keys ("Ctrl:Shift:t") from its modifiers and its key in another terminal
+func waveKey(mods []string, key string) (string, error) {
+	var parts []string
+	for _, mod := range mods {
+		modName, ok := modifierNames[strings.ToLower(strings.TrimSpace(mod))]
+		if !ok {
+			return "", fmt.Errorf("unknown modifier %q", mod)
+		}
+		parts = append(parts, modName)
+	}
+	lowerKey := strings.ToLower(strings.TrimSpace(key))
+	switch {
+	case keyNames[lowerKey] != "":
+		key = keyNames[lowerKey]
+	case len(lowerKey) == 1:
+		key = lowerKey
+	case functionKeyRe.MatchString(lowerKey):
+		key = strings.ToUpper(lowerKey)
+	case digitKeyRe.MatchString(lowerKey):
+		key = lowerKey[3:]
+	default:
+		return "", fmt.Errorf("unknown key %q", key)
+	}
+	return strings.Join(append(parts, key), ":"), nil
+}
+
+// a key combination with a separator ("ctrl+shift+t") as wave keys
+func waveKeyFromCombo(combo string, sep string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(combo), sep)
+	if len(parts) > 1 && parts[len(parts)-1] == "" {
+		// the separator is the key ("ctrl++")
+		parts = append(parts[:len(parts)-2], sep)
+	}
+	return waveKey(parts[:len(parts)-1], parts[len(parts)-1])
+}
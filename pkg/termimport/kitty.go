@@ -0,0 +1,152 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package termimport
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wtheme"
+)
+
+var kittyLineRe = regexp.MustCompile(`(?m)^\s*(font_family|font_size|kitty_mod|map|include|foreground|background|color[0-9]+)\s+[^\s=]`)
+var kittyFamilyRe = regexp.MustCompile(`family=(?:"([^"]*)"|'([^']*)'|(\S+))`)
+
+// the actions of kitty without arguments => the wave actions
+var kittyActions = map[string]string{
+	"new_tab":             "tab:new",
+	"new_tab_with_cwd":    "tab:new",
+	"close_tab":           "tab:close",
+	"next_tab":            "tab:next",
+	"previous_tab":        "tab:prev",
+	"new_window":          "block:new",
+	"new_window_with_cwd": "block:new",
+	"close_window":        "block:close",
+}
+
+// the wave action of a kitty action (with its arguments, "goto_tab 2")
+func kittyWaveAction(action string) string {
+	fields := strings.Fields(action)
+	if len(fields) == 0 {
+		return ""
+	}
+	if len(fields) == 1 {
+		return kittyActions[fields[0]]
+	}
+	args := fields[1:]
+	switch fields[0] {
+	case "goto_tab":
+		if idx, err := strconv.Atoi(args[0]); err == nil && idx >= 1 && idx <= 9 {
+			return "tab:switch" + args[0]
+		}
+	case "toggle_layout":
+		if args[0] == "stack" {
+			return "block:magnify"
+		}
+	case "neighboring_window":
+		switch args[0] {
+		case "left", "right", "up", "down":
+			return "block:focus" + args[0]
+		case "top":
+			return "block:focusup"
+		case "bottom":
+			return "block:focusdown"
+		}
+	case "launch":
+		for _, arg := range args {
+			switch arg {
+			case "--location=vsplit":
+				return "block:splitright"
+			case "--location=hsplit":
+				return "block:splitdown"
+			}
+		}
+	}
+	return ""
+}
+
+// the family of font_family ("JetBrains Mono", or "family='JetBrains Mono' style=Regular" since kitty 0.36), ""
+// for "auto"
+func kittyFontFamily(val string) string {
+	if match := kittyFamilyRe.FindStringSubmatch(val); match != nil {
+		val = match[1] + match[2] + match[3]
+	}
+	if val == "auto" || val == "monospace" {
+		return ""
+	}
+	return val
+}
+
+// reads a kitty.conf: its colors are a theme (the default one), font_family and font_size are the font, and the
+// maps (with kitty_mod, and the sequences of two keys) of the actions wave has are key bindings
+func parseKitty(data []byte) *importConfig {
+	cfg := newImportConfig()
+	if theme, name, err := wtheme.ParseTheme(wtheme.Format_Kitty, data); err == nil {
+		if name == "" {
+			name = "kitty"
+		}
+		cfg.defaultTheme = cfg.addTheme(name, theme)
+	} else {
+		cfg.skip(Kind_Theme, "colors", err.Error())
+	}
+	kittyMod := []string{"ctrl", "shift"}
+	var maps [][2]string // keys, action
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		key, val, _ := strings.Cut(line, " ")
+		val = strings.TrimSpace(val)
+		switch key {
+		case "font_family":
+			cfg.fontFamily = kittyFontFamily(val)
+		case "font_size":
+			cfg.fontSize, _ = strconv.ParseFloat(val, 64)
+		case "kitty_mod":
+			kittyMod = strings.Split(val, "+")
+		case "include", "globinclude":
+			cfg.skip(Kind_Theme, line, "includes another file, import a theme file with: wsh theme import FILE")
+		case "map":
+			fields := strings.Fields(val)
+			// the options ("--when-focus-on title:vim", or --opt=val)
+			for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+				if !strings.Contains(fields[0], "=") && len(fields) > 1 {
+					fields = fields[1:]
+				}
+				fields = fields[1:]
+			}
+			if len(fields) >= 2 {
+				maps = append(maps, [2]string{fields[0], strings.Join(fields[1:], " ")})
+			}
+		}
+	}
+	// kitty_mod applies to all the maps, it can be set after them
+	for _, m := range maps {
+		from := m[0] + " (" + m[1] + ")"
+		var waveKeys []string
+		var keyErr error
+		for _, combo := range strings.Split(m[0], ">") {
+			parts := strings.Split(combo, "+")
+			var mods []string
+			for _, mod := range parts[:len(parts)-1] {
+				if mod == "kitty_mod" {
+					mods = append(mods, kittyMod...)
+				} else {
+					mods = append(mods, mod)
+				}
+			}
+			waveKeyStr, err := waveKey(mods, parts[len(parts)-1])
+			if err != nil {
+				keyErr = err
+				break
+			}
+			waveKeys = append(waveKeys, waveKeyStr)
+		}
+		if keyErr != nil {
+			cfg.skip(Kind_KeyBinding, from, keyErr.Error())
+			continue
+		}
+		cfg.addKeyBinding(kittyWaveAction(m[1]), strings.Join(waveKeys, " "), from)
+	}
+	return cfg
+}
@@ -0,0 +1,146 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package termimport
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
+)
+
+// the options of ssh that take an argument
+const sshArgOpts = "BbcDEeFIiJLlmOoPpQRSWw"
+
+// splits a command line into its arguments (with double and single quotes, a backslash is kept as it is, for windows
+// paths)
+func splitArgs(cmdLine string) []string {
+	var rtn []string
+	var cur strings.Builder
+	var quote rune
+	inArg := false
+	for _, ch := range cmdLine {
+		switch {
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(ch)
+		case ch == '"' || ch == '\'':
+			quote = ch
+			inArg = true
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			if inArg {
+				rtn = append(rtn, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(ch)
+			inArg = true
+		}
+	}
+	if inArg {
+		rtn = append(rtn, cur.String())
+	}
+	return rtn
+}
+
+// the connection of an ssh command line ("ssh -p 2222 -i ~/.ssh/id_work user@host"), isSSH is false if the command
+// is not ssh.  a command that runs a remote command is an error (a connection is a shell).
+func parseSSHCommand(cmdLine string) (conn *waveobj.Connection, isSSH bool, err error) {
+	args := splitArgs(cmdLine)
+	if len(args) == 0 {
+		return nil, false, nil
+	}
+	progName := strings.ToLower(path.Base(strings.ReplaceAll(args[0], `\`, "/")))
+	if strings.TrimSuffix(progName, ".exe") != "ssh" {
+		return nil, false, nil
+	}
+	conn = &waveobj.Connection{}
+	var dest string
+	setOpt := func(opt byte, val string) {
+		switch opt {
+		case 'p':
+			conn.Port = val
+		case 'l':
+			conn.User = val
+		case 'i':
+			conn.IdentityFile = val
+		case 'J':
+			conn.ProxyJump = strings.Split(val, ",")
+		}
+	}
+	for idx := 1; idx < len(args); idx++ {
+		arg := args[idx]
+		if dest != "" {
+			return nil, true, fmt.Errorf("runs a remote command (%s)", strings.Join(args[idx:], " "))
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			dest = arg
+			continue
+		}
+		for pos := 1; pos < len(arg); pos++ {
+			opt := arg[pos]
+			if opt == 'A' {
+				conn.ForwardAgent = true
+				continue
+			}
+			if !strings.ContainsRune(sshArgOpts, rune(opt)) {
+				continue
+			}
+			val := arg[pos+1:]
+			if val == "" && idx+1 < len(args) {
+				idx++
+				val = args[idx]
+			}
+			if opt == 'o' {
+				setConfigOpt(conn, val)
+			} else {
+				setOpt(opt, val)
+			}
+			break
+		}
+	}
+	if dest == "" {
+		return nil, true, fmt.Errorf("no host in %q", cmdLine)
+	}
+	if strings.HasPrefix(dest, "ssh://") {
+		dest = strings.TrimPrefix(dest, "ssh://")
+		if host, port, found := strings.Cut(dest, ":"); found {
+			dest, conn.Port = host, port
+		}
+	}
+	if user, host, found := strings.Cut(dest, "@"); found {
+		conn.User = user
+		dest = host
+	}
+	conn.Host = dest
+	if conn.IdentityFile != "" {
+		conn.AuthMethod = wconn.AuthMethod_Key
+	}
+	return conn, true, nil
+}
+
+// an -o option ("Port=2222" or "Port 2222"), the ones a connection has
+func setConfigOpt(conn *waveobj.Connection, opt string) {
+	key, val, found := strings.Cut(opt, "=")
+	if !found {
+		key, val, _ = strings.Cut(opt, " ")
+	}
+	val = strings.TrimSpace(val)
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "port":
+		conn.Port = val
+	case "user":
+		conn.User = val
+	case "identityfile":
+		conn.IdentityFile = val
+	case "proxyjump":
+		conn.ProxyJump = strings.Split(val, ",")
+	case "forwardagent":
+		conn.ForwardAgent = strings.EqualFold(val, "yes")
+	}
+}
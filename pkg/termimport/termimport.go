@@ -0,0 +1,388 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package termimport imports the config of other terminals into wave: iTerm2's preferences (or its profiles exported
+// as json), Windows Terminal's settings.json, alacritty.toml and kitty.conf.  their color schemes are saved as
+// themes (wtheme), the font and the theme of the default profile and the key bindings of the actions wave has are
+// set in the global settings (wsettings), and the profiles that run ssh are added as connections (with the theme of
+// the profile in connections.json).  what can't be imported is reported as skipped, and the connections and themes
+// that already exist in wave are kept.
+package termimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/keybind"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
+	"github.com/wavetermdev/waveterm/pkg/wsettings"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wtheme"
+)
+
+const (
+	Format_ITerm2          = wtheme.Format_ITerm2
+	Format_WindowsTerminal = wtheme.Format_WindowsTerminal
+	Format_Alacritty       = wtheme.Format_Alacritty
+	Format_Kitty           = wtheme.Format_Kitty
+)
+
+var Formats = []string{Format_ITerm2, Format_WindowsTerminal, Format_Alacritty, Format_Kitty}
+
+const (
+	Kind_Theme      = "theme"
+	Kind_Font       = "font"
+	Kind_KeyBinding = "keybinding"
+	Kind_Connection = "connection"
+	Kind_Setting    = "setting" // the theme of the default profile (term:theme)
+)
+
+var Kinds = []string{Kind_Theme, Kind_Font, Kind_KeyBinding, Kind_Connection}
+
+type importTheme struct {
+	name  string // the name in the other terminal
+	theme *wconfig.TermThemeType
+}
+
+type importConn struct {
+	profile string
+	conn    *waveobj.Connection
+	theme   string // the theme of the profile (its name in the other terminal, or the name of a wave theme)
+}
+
+// what was read from the config of the other terminal
+type importConfig struct {
+	themes       []importTheme
+	defaultTheme string // the theme of the default profile
+	fontFamily   string
+	fontSize     float64
+	keyBindings  map[string][]string // wave action => keys (normalized)
+	conns        []importConn
+	skipped      []wshrpc.SettingsImportItem
+}
+
+func newImportConfig() *importConfig {
+	return &importConfig{keyBindings: make(map[string][]string)}
+}
+
+func (cfg *importConfig) skip(kind string, from string, reason string) {
+	cfg.skipped = append(cfg.skipped, wshrpc.SettingsImportItem{Kind: kind, From: from, Skipped: true, Reason: reason})
+}
+
+// adds a theme, returns the name of the theme (the one of an identical theme that was already added)
+func (cfg *importConfig) addTheme(name string, theme *wconfig.TermThemeType) string {
+	for _, other := range cfg.themes {
+		if other.name == name || reflect.DeepEqual(other.theme, theme) {
+			return other.name
+		}
+	}
+	cfg.themes = append(cfg.themes, importTheme{name: name, theme: theme})
+	return name
+}
+
+// adds the keys (a key or a chord, as wave keys) of a wave action ("" if the action has no wave action)
+func (cfg *importConfig) addKeyBinding(action string, keys string, from string) {
+	if action == "" {
+		cfg.skip(Kind_KeyBinding, from, "no wave action")
+		return
+	}
+	normKeys, err := keybind.NormalizeKeys(keys)
+	if err != nil {
+		cfg.skip(Kind_KeyBinding, from, err.Error())
+		return
+	}
+	if !utilfn.ContainsStr(cfg.keyBindings[action], normKeys) {
+		cfg.keyBindings[action] = append(cfg.keyBindings[action], normKeys)
+	}
+}
+
+// adds the connection of a profile that runs ssh (cmdLine), returns false if it does not run ssh
+func (cfg *importConfig) addSSHProfile(profile string, cmdLine string, theme string, tags []string) bool {
+	conn, isSSH, err := parseSSHCommand(cmdLine)
+	if !isSSH {
+		return false
+	}
+	if err != nil {
+		cfg.skip(Kind_Connection, profile, err.Error())
+		return true
+	}
+	conn.Name = nameFromText(profile)
+	if conn.Name == "" {
+		conn.Name = nameFromText(conn.Host)
+	}
+	conn.Tags = tags
+	cfg.conns = append(cfg.conns, importConn{profile: profile, conn: conn, theme: theme})
+	return true
+}
+
+var nonNameCharsRe = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// "Prod DB (eu)" => "prod-db-eu"
+func nameFromText(text string) string {
+	return strings.Trim(nonNameCharsRe.ReplaceAllString(strings.ToLower(text), "-"), "-._")
+}
+
+// the format of a config file, from its name and then its contents ("" if it is not known)
+func DetectFormat(fileName string, data []byte) string {
+	baseName := strings.ToLower(filepath.Base(fileName))
+	switch {
+	case strings.HasSuffix(baseName, ".plist"):
+		return Format_ITerm2
+	case strings.HasSuffix(baseName, ".toml"):
+		return Format_Alacritty
+	case strings.HasSuffix(baseName, ".conf"):
+		return Format_Kitty
+	}
+	trimmed := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(trimmed, "<?xml") || strings.HasPrefix(trimmed, "<plist") || strings.HasPrefix(trimmed, "bplist"):
+		return Format_ITerm2
+	case strings.HasPrefix(trimmed, "{"):
+		var obj map[string]any
+		if err := json.Unmarshal(wtheme.StripJsonComments(data), &obj); err != nil {
+			return ""
+		}
+		if obj["Profiles"] != nil {
+			return Format_ITerm2
+		}
+		if obj["profiles"] != nil || obj["schemes"] != nil {
+			return Format_WindowsTerminal
+		}
+	case kittyLineRe.MatchString(trimmed):
+		return Format_Kitty
+	case strings.Contains(trimmed, "[font") || strings.Contains(trimmed, "[colors") || strings.Contains(trimmed, "[keyboard"):
+		return Format_Alacritty
+	}
+	return ""
+}
+
+func parseConfig(format string, data []byte) (*importConfig, error) {
+	switch format {
+	case Format_ITerm2:
+		return parseITerm2(data)
+	case Format_WindowsTerminal:
+		return parseWindowsTerminal(data)
+	case Format_Alacritty:
+		return parseAlacritty(data), nil
+	case Format_Kitty:
+		return parseKitty(data), nil
+	}
+	return nil, fmt.Errorf("unknown format %q (expected %s)", format, strings.Join(Formats, ", "))
+}
+
+// imports the config of another terminal (with DryRun, only returns what would be imported)
+func Import(ctx context.Context, data wshrpc.CommandSettingsImportData) (*wshrpc.SettingsImportResult, error) {
+	format := data.Format
+	if format == "" {
+		format = DetectFormat(data.FileName, []byte(data.Data))
+		if format == "" {
+			return nil, fmt.Errorf("cannot detect the format of the file, set it (%s)", strings.Join(Formats, ", "))
+		}
+	}
+	only := make(map[string]bool)
+	for _, kind := range data.Only {
+		if !utilfn.ContainsStr(Kinds, kind) {
+			return nil, fmt.Errorf("unknown kind %q (expected %s)", kind, strings.Join(Kinds, ", "))
+		}
+		only[kind] = true
+	}
+	want := func(kind string) bool {
+		return len(only) == 0 || only[kind]
+	}
+	cfg, err := parseConfig(format, []byte(data.Data))
+	if err != nil {
+		return nil, err
+	}
+	rtn := &wshrpc.SettingsImportResult{Format: format}
+	themeNames := make(map[string]string) // the name in the other terminal => the name in wave
+	if want(Kind_Theme) {
+		rtn.Items = append(rtn.Items, importThemes(ctx, cfg, format, themeNames, data.DryRun)...)
+	}
+	resolveTheme := func(name string) string {
+		if waveName, ok := themeNames[name]; ok {
+			return waveName
+		}
+		if _, err := wtheme.Get(nameFromText(name)); name != "" && err == nil {
+			return nameFromText(name)
+		}
+		return ""
+	}
+	items, err := importSettings(ctx, cfg, want, resolveTheme, data.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	rtn.Items = append(rtn.Items, items...)
+	if want(Kind_Connection) {
+		items, err := importConns(ctx, cfg, resolveTheme, data.DryRun)
+		if err != nil {
+			return nil, err
+		}
+		rtn.Items = append(rtn.Items, items...)
+	}
+	for _, item := range cfg.skipped {
+		if want(item.Kind) {
+			rtn.Items = append(rtn.Items, item)
+		}
+	}
+	return rtn, nil
+}
+
+// the name of a theme in wave: the name from the other terminal, with the format as a prefix if a built-in theme or
+// one of termthemes.json has it (a theme imported into wave before is replaced)
+func waveThemeName(name string, format string) string {
+	waveName := nameFromText(name)
+	if waveName == "" {
+		return format
+	}
+	for _, info := range wtheme.List() {
+		if info.Name == waveName && info.Source != wtheme.Source_Wave {
+			return format + "-" + waveName
+		}
+	}
+	return waveName
+}
+
+func importThemes(ctx context.Context, cfg *importConfig, format string, themeNames map[string]string, dryRun bool) []wshrpc.SettingsImportItem {
+	var rtn []wshrpc.SettingsImportItem
+	for _, theme := range cfg.themes {
+		waveName := waveThemeName(theme.name, format)
+		item := wshrpc.SettingsImportItem{Kind: Kind_Theme, Name: waveName, From: theme.name}
+		termTheme := *theme.theme
+		termTheme.DisplayName = theme.name
+		if !dryRun {
+			if _, err := wtheme.Save(ctx, waveName, termTheme, format); err != nil {
+				item.Skipped, item.Reason = true, err.Error()
+				rtn = append(rtn, item)
+				continue
+			}
+		}
+		themeNames[theme.name] = waveName
+		rtn = append(rtn, item)
+	}
+	return rtn
+}
+
+// the keys of an action set in the settings, or its default keys
+func currentKeys(current waveobj.MetaMapType, action string) []string {
+	if keysMap, ok := current[wconfig.ConfigKey_AppKeybindings].(map[string]any); ok {
+		if keys, ok := keysMap[action]; ok {
+			var rtn []string
+			utilfn.ReUnmarshal(&rtn, keys)
+			return rtn
+		}
+	}
+	for _, keyAction := range keybind.DefaultKeymap {
+		if keyAction.Action == action {
+			return append([]string(nil), keyAction.Keys...)
+		}
+	}
+	return nil
+}
+
+// sets the font, the default theme and the key bindings in the global settings (the imported keys are added to the
+// keys of the action, so its default keys keep working)
+func importSettings(ctx context.Context, cfg *importConfig, want func(string) bool, resolveTheme func(string) string, dryRun bool) ([]wshrpc.SettingsImportItem, error) {
+	var rtn []wshrpc.SettingsImportItem
+	toSet := make(waveobj.MetaMapType)
+	if want(Kind_Font) && cfg.fontFamily != "" {
+		toSet[wconfig.ConfigKey_TermFontFamily] = cfg.fontFamily
+		rtn = append(rtn, wshrpc.SettingsImportItem{Kind: Kind_Font, Name: wconfig.ConfigKey_TermFontFamily, Value: cfg.fontFamily})
+	}
+	if want(Kind_Font) && cfg.fontSize > 0 {
+		toSet[wconfig.ConfigKey_TermFontSize] = cfg.fontSize
+		rtn = append(rtn, wshrpc.SettingsImportItem{Kind: Kind_Font, Name: wconfig.ConfigKey_TermFontSize, Value: strconv.FormatFloat(cfg.fontSize, 'f', -1, 64)})
+	}
+	if want(Kind_Theme) && cfg.defaultTheme != "" {
+		if waveName := resolveTheme(cfg.defaultTheme); waveName != "" {
+			toSet[wconfig.ConfigKey_TermTheme] = waveName
+			rtn = append(rtn, wshrpc.SettingsImportItem{Kind: Kind_Setting, Name: wconfig.ConfigKey_TermTheme, Value: waveName, From: cfg.defaultTheme})
+		}
+	}
+	if want(Kind_KeyBinding) && len(cfg.keyBindings) > 0 {
+		current := make(waveobj.MetaMapType)
+		if obj, err := wsettings.Get(ctx, ""); err != nil {
+			return nil, err
+		} else if obj != nil {
+			current = obj.Settings
+		}
+		keysMap := make(map[string]any)
+		if currentMap, ok := current[wconfig.ConfigKey_AppKeybindings].(map[string]any); ok {
+			for action, keys := range currentMap {
+				keysMap[action] = keys
+			}
+		}
+		actions := make([]string, 0, len(cfg.keyBindings))
+		for action := range cfg.keyBindings {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			keys := currentKeys(current, action)
+			for _, newKeys := range cfg.keyBindings[action] {
+				if !utilfn.ContainsStr(keys, newKeys) {
+					keys = append(keys, newKeys)
+				}
+			}
+			keysMap[action] = keys
+			rtn = append(rtn, wshrpc.SettingsImportItem{Kind: Kind_KeyBinding, Name: action, Value: strings.Join(cfg.keyBindings[action], ", ")})
+		}
+		toSet[wconfig.ConfigKey_AppKeybindings] = keysMap
+	}
+	if !dryRun && len(toSet) > 0 {
+		if _, err := wsettings.Set(ctx, "", toSet); err != nil {
+			return nil, fmt.Errorf("setting the settings: %w", err)
+		}
+	}
+	return rtn, nil
+}
+
+// creates the connections (the ones with the ssh name or the name of a connection in wave are skipped), with the
+// theme of their profile if it is not the default one
+func importConns(ctx context.Context, cfg *importConfig, resolveTheme func(string) string, dryRun bool) ([]wshrpc.SettingsImportItem, error) {
+	existing, err := wconn.ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []wshrpc.SettingsImportItem
+	for _, ic := range cfg.conns {
+		connName := wconn.ConnName(ic.conn)
+		item := wshrpc.SettingsImportItem{Kind: Kind_Connection, Name: ic.conn.Name, Value: connName, From: ic.profile}
+		for _, other := range existing {
+			if other.Name == ic.conn.Name || wconn.ConnName(other) == connName {
+				item.Skipped, item.Reason = true, fmt.Sprintf("connection %q already exists", other.Name)
+				break
+			}
+		}
+		if item.Skipped || dryRun {
+			rtn = append(rtn, item)
+			continue
+		}
+		conn, err := wconn.CreateConnection(ctx, ic.conn)
+		if err != nil {
+			item.Skipped, item.Reason = true, err.Error()
+			rtn = append(rtn, item)
+			continue
+		}
+		existing = append(existing, conn)
+		if ic.theme != "" && ic.theme != cfg.defaultTheme {
+			if waveName := resolveTheme(ic.theme); waveName != "" {
+				err := wconfig.SetConnectionsConfigValue(connName, waveobj.MetaMapType{wconfig.ConfigKey_TermTheme: waveName})
+				if err != nil {
+					item.Reason = fmt.Sprintf("setting its theme: %v", err)
+				}
+			}
+		}
+		rtn = append(rtn, item)
+	}
+	return rtn, nil
+}
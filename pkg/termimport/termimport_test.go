@@ -0,0 +1,278 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package termimport
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testITerm2Prefs = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>Default Bookmark Guid</key>
+	<string>B-GUID</string>
+	<key>New Bookmarks</key>
+	<array>
+		<dict>
+			<key>Name</key>
+			<string>Prod DB</string>
+			<key>Guid</key>
+			<string>A-GUID</string>
+			<key>Custom Command</key>
+			<string>Yes</string>
+			<key>Command</key>
+			<string>ssh -p 2222 -i ~/.ssh/id_prod admin@db.example.com</string>
+			<key>Tags</key>
+			<array><string>prod</string></array>
+			<key>Background Color</key>
+			<dict>
+				<key>Red Component</key><real>1</real>
+				<key>Green Component</key><real>0</real>
+				<key>Blue Component</key><real>0</real>
+			</dict>
+			<key>Foreground Color</key>
+			<dict>
+				<key>Red Component</key><real>1</real>
+				<key>Green Component</key><real>1</real>
+				<key>Blue Component</key><real>1</real>
+			</dict>
+		</dict>
+		<dict>
+			<key>Name</key>
+			<string>Default</string>
+			<key>Guid</key>
+			<string>B-GUID</string>
+			<key>Normal Font</key>
+			<string>MesloLGS-NF-Regular 13</string>
+			<key>Background Color</key>
+			<dict>
+				<key>Red Component</key><real>0</real>
+				<key>Green Component</key><real>0</real>
+				<key>Blue Component</key><real>0</real>
+			</dict>
+			<key>Foreground Color</key>
+			<dict>
+				<key>Red Component</key><real>1</real>
+				<key>Green Component</key><real>1</real>
+				<key>Blue Component</key><real>1</real>
+			</dict>
+			<key>Keyboard Map</key>
+			<dict>
+				<key>0xf702-0x280000</key>
+				<dict><key>Action</key><integer>10</integer><key>Text</key><string>b</string></dict>
+			</dict>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+const testWindowsTerminalSettings = `{
+	// the default profile
+	"defaultProfile": "{61c54bbd-c2c6-5271-96e7-009a87ff44bf}",
+	"profiles": {
+		"defaults": {"font": {"face": "Cascadia Mono", "size": 11}},
+		"list": [
+			{"guid": "{61c54bbd-c2c6-5271-96e7-009a87ff44bf}", "name": "PowerShell", "colorScheme": "One Half Dark", "font": {"size": 12}},
+			{"name": "web-1", "commandline": "ssh.exe deploy@web-1.example.com", "colorScheme": "Campbell"},
+			{"name": "logs", "commandline": "C:\\Windows\\System32\\OpenSSH\\ssh.exe host tail -f /var/log/syslog"},
+		]
+	},
+	"schemes": [
+		{"name": "One Half Dark", "background": "#282C34", "foreground": "#DCDFE4", "purple": "#C678DD", "brightPurple": "#C678DD"}
+	],
+	"actions": [
+		{"command": "newTab", "keys": "ctrl+shift+t"},
+		{"command": {"action": "splitPane", "split": "down"}, "keys": "alt+shift+minus"},
+		{"command": {"action": "switchToTab", "index": 1}, "id": "User.switchToTab.1"},
+		{"command": "toggleFullscreen", "keys": "alt+enter"},
+		{"command": "unbound", "keys": "ctrl+v"}
+	],
+	"keybindings": [
+		{"id": "User.switchToTab.1", "keys": "ctrl+alt+2"}
+	]
+}
+`
+
+const testAlacrittyConfig = `[font]
+size = 12.5
+
+[font.normal]
+family = "JetBrains Mono"
+
+[colors.primary]
+background = "#1e1e2e"
+foreground = "#cdd6f4"
+
+[keyboard]
+bindings = [
+  { key = "T", mods = "Command", action = "CreateNewTab" },
+  { key = "F", mods = "Control|Shift", action = "SearchForward", mode = "~Search" },
+  { key = "N", mods = "Command", action = "CreateNewWindow" },
+  { key = "L", mods = "Control", chars = "\u000c" },
+]
+`
+
+const testKittyConfig = `# a kitty.conf
+font_family      family='Fira Code' style=Retina
+font_size 14.0
+background #000000
+foreground #eeeeee
+color1 #cc0000
+map kitty_mod+enter new_window
+map ctrl+a>v launch --location=vsplit
+map --when-focus-on title:vim ctrl+shift+2 goto_tab 2
+map kitty_mod+f5 load_config_file
+kitty_mod ctrl+alt
+`
+
+func TestParseITerm2(t *testing.T) {
+	cfg, err := parseITerm2([]byte(testITerm2Prefs))
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+	if len(cfg.themes) != 2 || cfg.defaultTheme != "Default" {
+		t.Errorf("expected 2 themes with Default as the default one, got %d (%q)", len(cfg.themes), cfg.defaultTheme)
+	}
+	if cfg.fontFamily != "MesloLGS NF" || cfg.fontSize != 13 {
+		t.Errorf("unexpected font %q %v", cfg.fontFamily, cfg.fontSize)
+	}
+	if len(cfg.conns) != 1 {
+		t.Fatalf("expected 1 connection, got %d", len(cfg.conns))
+	}
+	conn := cfg.conns[0]
+	if conn.conn.Name != "prod-db" || conn.conn.Host != "db.example.com" || conn.conn.User != "admin" || conn.conn.Port != "2222" ||
+		conn.conn.IdentityFile != "~/.ssh/id_prod" || conn.conn.AuthMethod != "key" || conn.theme != "Prod DB" {
+		t.Errorf("unexpected connection %+v (theme %q)", conn.conn, conn.theme)
+	}
+	if !reflect.DeepEqual(conn.conn.Tags, []string{"prod"}) {
+		t.Errorf("unexpected tags %v", conn.conn.Tags)
+	}
+	if len(cfg.skipped) != 1 || cfg.skipped[0].Kind != Kind_KeyBinding {
+		t.Errorf("expected the key map to be skipped, got %+v", cfg.skipped)
+	}
+}
+
+func TestParseWindowsTerminal(t *testing.T) {
+	cfg, err := parseWindowsTerminal([]byte(testWindowsTerminalSettings))
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+	if len(cfg.themes) != 1 || cfg.themes[0].theme.Magenta != "#c678dd" || cfg.defaultTheme != "One Half Dark" {
+		t.Errorf("unexpected themes %+v (default %q)", cfg.themes, cfg.defaultTheme)
+	}
+	if cfg.fontFamily != "Cascadia Mono" || cfg.fontSize != 12 {
+		t.Errorf("unexpected font %q %v", cfg.fontFamily, cfg.fontSize)
+	}
+	if len(cfg.conns) != 1 || cfg.conns[0].conn.Host != "web-1.example.com" || cfg.conns[0].theme != "Campbell" {
+		t.Errorf("unexpected connections %+v", cfg.conns)
+	}
+	expected := map[string][]string{
+		"tab:new":         {"Ctrl:Shift:t"},
+		"block:splitdown": {"Alt:Shift:-"},
+		"tab:switch2":     {"Alt:Ctrl:2"},
+	}
+	if !reflect.DeepEqual(cfg.keyBindings, expected) {
+		t.Errorf("expected key bindings %v, got %v", expected, cfg.keyBindings)
+	}
+	var skippedKinds []string
+	for _, item := range cfg.skipped {
+		skippedKinds = append(skippedKinds, item.Kind)
+	}
+	if !reflect.DeepEqual(skippedKinds, []string{Kind_Connection, Kind_KeyBinding}) {
+		t.Errorf("expected the remote command and toggleFullscreen to be skipped, got %+v", cfg.skipped)
+	}
+}
+
+func TestParseAlacritty(t *testing.T) {
+	cfg := parseAlacritty([]byte(testAlacrittyConfig))
+	if len(cfg.themes) != 1 || cfg.themes[0].theme.Background != "#1e1e2e" || cfg.defaultTheme != "alacritty" {
+		t.Errorf("unexpected themes %+v", cfg.themes)
+	}
+	if cfg.fontFamily != "JetBrains Mono" || cfg.fontSize != 12.5 {
+		t.Errorf("unexpected font %q %v", cfg.fontFamily, cfg.fontSize)
+	}
+	expected := map[string][]string{"tab:new": {"Cmd:t"}, "search:open": {"Ctrl:Shift:f"}}
+	if !reflect.DeepEqual(cfg.keyBindings, expected) {
+		t.Errorf("expected key bindings %v, got %v", expected, cfg.keyBindings)
+	}
+	if len(cfg.skipped) != 2 {
+		t.Errorf("expected CreateNewWindow and the chars binding to be skipped, got %+v", cfg.skipped)
+	}
+}
+
+func TestParseKitty(t *testing.T) {
+	cfg := parseKitty([]byte(testKittyConfig))
+	if len(cfg.themes) != 1 || cfg.themes[0].theme.Red != "#cc0000" {
+		t.Errorf("unexpected themes %+v", cfg.themes)
+	}
+	if cfg.fontFamily != "Fira Code" || cfg.fontSize != 14 {
+		t.Errorf("unexpected font %q %v", cfg.fontFamily, cfg.fontSize)
+	}
+	expected := map[string][]string{
+		"block:new":        {"Alt:Ctrl:Enter"},
+		"block:splitright": {"Ctrl:a v"},
+		"tab:switch2":      {"Ctrl:Shift:2"},
+	}
+	if !reflect.DeepEqual(cfg.keyBindings, expected) {
+		t.Errorf("expected key bindings %v, got %v", expected, cfg.keyBindings)
+	}
+}
+
+func TestParseSSHCommand(t *testing.T) {
+	tests := []struct {
+		cmdLine  string
+		isSSH    bool
+		hasErr   bool
+		expected string // user@host:port identity proxyjump
+	}{
+		{"ssh web", true, false, "@web: "},
+		{"/usr/bin/ssh -A -l root -J bastion,jump2 -o Port=2200 db", true, false, "root@db:2200 bastion,jump2"},
+		{`"C:\Program Files\OpenSSH\ssh.exe" ssh://me@host:2022`, true, false, "me@host:2022 "},
+		{"ssh -i'~/.ssh/my key' -p22 user@host", true, false, "user@host:22 ~/.ssh/my key"},
+		{"ssh host uptime", true, true, ""},
+		{"ssh -v", true, true, ""},
+		{"zsh -l", false, false, ""},
+	}
+	for _, tc := range tests {
+		conn, isSSH, err := parseSSHCommand(tc.cmdLine)
+		if isSSH != tc.isSSH || (err != nil) != tc.hasErr {
+			t.Errorf("%q: expected ssh %v and error %v, got %v and %v", tc.cmdLine, tc.isSSH, tc.hasErr, isSSH, err)
+			continue
+		}
+		if conn == nil || err != nil {
+			continue
+		}
+		got := conn.User + "@" + conn.Host + ":" + conn.Port + " " + conn.IdentityFile
+		if len(conn.ProxyJump) > 0 {
+			got = conn.User + "@" + conn.Host + ":" + conn.Port + " " + conn.ProxyJump[0] + "," + conn.ProxyJump[1]
+		}
+		if got != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.cmdLine, tc.expected, got)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		fileName string
+		data     string
+		expected string
+	}{
+		{"com.googlecode.iterm2.plist", "", Format_ITerm2},
+		{"profiles.json", `{"Profiles": []}`, Format_ITerm2},
+		{"settings.json", testWindowsTerminalSettings, Format_WindowsTerminal},
+		{"alacritty.toml", "", Format_Alacritty},
+		{"kitty.conf", "", Format_Kitty},
+		{"config", testKittyConfig, Format_Kitty},
+		{"config", testAlacrittyConfig, Format_Alacritty},
+		{"config", "{}", ""},
+	}
+	for _, tc := range tests {
+		if got := DetectFormat(tc.fileName, []byte(tc.data)); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.fileName, tc.expected, got)
+		}
+	}
+}
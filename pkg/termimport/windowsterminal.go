@@ -0,0 +1,224 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package termimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wtheme"
+)
+
+// Windows Terminal's default color scheme (the profiles that don't set one)
+const wtDefaultScheme = "Campbell"
+
+type wtFont struct {
+	Face string  `json:"face"`
+	Size float64 `json:"size"`
+}
+
+type wtProfile struct {
+	Guid        string  `json:"guid"`
+	Name        string  `json:"name"`
+	Commandline string  `json:"commandline"`
+	ColorScheme any     `json:"colorScheme"` // a name, or {"dark": NAME, "light": NAME}
+	FontFace    string  `json:"fontFace"`    // the settings before 1.10 (font.face and font.size)
+	FontSize    float64 `json:"fontSize"`
+	Font        *wtFont `json:"font"`
+}
+
+// the color scheme of the profile ("" if it does not set one), the dark one if it has a dark and a light one
+func (p *wtProfile) colorScheme() string {
+	switch scheme := p.ColorScheme.(type) {
+	case string:
+		return scheme
+	case map[string]any:
+		dark, _ := scheme["dark"].(string)
+		return dark
+	}
+	return ""
+}
+
+func (p *wtProfile) font() (string, float64) {
+	if p.Font != nil {
+		return p.Font.Face, p.Font.Size
+	}
+	return p.FontFace, p.FontSize
+}
+
+type wtAction struct {
+	Command any    `json:"command"` // a command name ("newTab"), or {"action": NAME, ...args}
+	Keys    any    `json:"keys"`    // a key or a list of keys
+	Id      string `json:"id"`
+}
+
+// the keys of the action ("ctrl+shift+t")
+func (a *wtAction) keyList() []string {
+	switch keys := a.Keys.(type) {
+	case string:
+		return []string{keys}
+	case []any:
+		var rtn []string
+		for _, key := range keys {
+			if keyStr, ok := key.(string); ok {
+				rtn = append(rtn, keyStr)
+			}
+		}
+		return rtn
+	}
+	return nil
+}
+
+var wtCommands = map[string]string{
+	"newTab":         "tab:new",
+	"closeTab":       "tab:close",
+	"nextTab":        "tab:next",
+	"prevTab":        "tab:prev",
+	"closePane":      "block:close",
+	"find":           "search:open",
+	"togglePaneZoom": "block:magnify",
+}
+
+// the wave action of a command, and the name of the command (for the report)
+func wtWaveAction(command any) (string, string) {
+	var args map[string]any
+	switch cmd := command.(type) {
+	case string:
+		args = map[string]any{"action": cmd}
+	case map[string]any:
+		args = cmd
+	default:
+		return "", ""
+	}
+	name, _ := args["action"].(string)
+	if action, ok := wtCommands[name]; ok {
+		return action, name
+	}
+	switch name {
+	case "splitPane":
+		split, _ := args["split"].(string)
+		switch split {
+		case "down", "horizontal":
+			return "block:splitdown", name
+		case "up":
+			return "block:splitup", name
+		case "left":
+			return "block:splitleft", name
+		}
+		return "block:splitright", name
+	case "moveFocus":
+		direction, _ := args["direction"].(string)
+		switch direction {
+		case "left", "right", "up", "down":
+			return "block:focus" + direction, name
+		}
+	case "switchToTab":
+		index, ok := args["index"].(float64)
+		if ok && index >= 0 && index < 9 {
+			return "tab:switch" + strconv.Itoa(int(index)+1), name
+		}
+	}
+	return "", name
+}
+
+// reads Windows Terminal's settings.json: its color schemes are themes, the font and the color scheme of the default
+// profile (or of the profile defaults) are the default ones, the profiles with an ssh command line are connections,
+// and the actions with keys are key bindings
+func parseWindowsTerminal(data []byte) (*importConfig, error) {
+	var settings struct {
+		DefaultProfile string            `json:"defaultProfile"`
+		Profiles       json.RawMessage   `json:"profiles"` // {"defaults": PROFILE, "list": [PROFILE...]}, or [PROFILE...]
+		Schemes        []json.RawMessage `json:"schemes"`
+		Actions        []wtAction        `json:"actions"`
+		Keybindings    []wtAction        `json:"keybindings"` // the actions before 1.21 (with their keys)
+	}
+	if err := json.Unmarshal(wtheme.StripJsonComments(data), &settings); err != nil {
+		return nil, fmt.Errorf("invalid Windows Terminal settings: %w", err)
+	}
+	var profiles struct {
+		Defaults wtProfile   `json:"defaults"`
+		List     []wtProfile `json:"list"`
+	}
+	if len(settings.Profiles) > 0 && settings.Profiles[0] == '[' {
+		if err := json.Unmarshal(settings.Profiles, &profiles.List); err != nil {
+			return nil, fmt.Errorf("invalid Windows Terminal profiles: %w", err)
+		}
+	} else if len(settings.Profiles) > 0 {
+		if err := json.Unmarshal(settings.Profiles, &profiles); err != nil {
+			return nil, fmt.Errorf("invalid Windows Terminal profiles: %w", err)
+		}
+	}
+	cfg := newImportConfig()
+	for _, schemeData := range settings.Schemes {
+		theme, name, err := wtheme.ParseTheme(wtheme.Format_WindowsTerminal, schemeData)
+		if err != nil {
+			cfg.skip(Kind_Theme, name, err.Error())
+			continue
+		}
+		cfg.addTheme(name, theme)
+	}
+	defaultScheme := profiles.Defaults.colorScheme()
+	if defaultScheme == "" {
+		defaultScheme = wtDefaultScheme
+	}
+	cfg.fontFamily, cfg.fontSize = profiles.Defaults.font()
+	cfg.defaultTheme = defaultScheme
+	for _, profile := range profiles.List {
+		scheme := profile.colorScheme()
+		if scheme == "" {
+			scheme = defaultScheme
+		}
+		if settings.DefaultProfile != "" && (profile.Guid == settings.DefaultProfile || profile.Name == settings.DefaultProfile) {
+			cfg.defaultTheme = scheme
+			if face, size := profile.font(); face != "" || size > 0 {
+				if face != "" {
+					cfg.fontFamily = face
+				}
+				if size > 0 {
+					cfg.fontSize = size
+				}
+			}
+		}
+		cfg.addSSHProfile(profile.Name, profile.Commandline, scheme, nil)
+	}
+	actionsById := make(map[string]any)
+	for _, action := range settings.Actions {
+		if action.Id != "" && action.Command != nil {
+			actionsById[action.Id] = action.Command
+		}
+	}
+	for _, action := range append(settings.Actions, settings.Keybindings...) {
+		command := action.Command
+		if command == nil {
+			command = actionsById[action.Id]
+		}
+		if command == "unbound" {
+			continue
+		}
+		for _, keys := range action.keyList() {
+			waveAction, cmdName := wtWaveAction(command)
+			from := fmt.Sprintf("%s (%s)", keys, cmdName)
+			if cmdName == "" {
+				from = fmt.Sprintf("%s (%s)", keys, action.Id)
+			}
+			if waveAction == "" {
+				cfg.skip(Kind_KeyBinding, from, "no wave action")
+				continue
+			}
+			if strings.Contains(strings.TrimSpace(keys), " ") || strings.Contains(keys, ",") {
+				cfg.skip(Kind_KeyBinding, from, "key sequences are not supported")
+				continue
+			}
+			waveKeys, err := waveKeyFromCombo(keys, "+")
+			if err != nil {
+				cfg.skip(Kind_KeyBinding, from, err.Error())
+				continue
+			}
+			cfg.addKeyBinding(waveAction, waveKeys, from)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package tsgen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"github.com/wavetermdev/waveterm/pkg/apischema"
+)
+
+// the typescript client for the automation api is generated from its OpenAPI document (see pkg/apischema), the
+// types are exported from the client's module (they are not the global types of gotypes.d.ts)
+
+var tsIdentRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func apiSchemaTypeName(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+func apiPropName(name string) string {
+	if tsIdentRe.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+func apiSchemaToTSType(schema *jsonschema.Schema, indent string) string {
+	if schema == nil {
+		return "any"
+	}
+	if name, ok := strings.CutPrefix(schema.Ref, apischema.SchemaRefPrefix); ok {
+		return apiSchemaTypeName(name)
+	}
+	if len(schema.AnyOf) > 0 || len(schema.OneOf) > 0 {
+		var parts []string
+		for _, sub := range append(append([]*jsonschema.Schema{}, schema.AnyOf...), schema.OneOf...) {
+			parts = append(parts, apiSchemaToTSType(sub, indent))
+		}
+		return strings.Join(parts, " | ")
+	}
+	switch schema.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		elemType := apiSchemaToTSType(schema.Items, indent)
+		if strings.Contains(elemType, " | ") {
+			elemType = "(" + elemType + ")"
+		}
+		return elemType + "[]"
+	case "object":
+		if schema.Properties != nil && schema.Properties.Len() > 0 {
+			return apiObjectToTSType(schema, indent)
+		}
+		if schema.AdditionalProperties != nil && schema.AdditionalProperties != jsonschema.TrueSchema && schema.AdditionalProperties != jsonschema.FalseSchema {
+			return fmt.Sprintf("{[key: string]: %s}", apiSchemaToTSType(schema.AdditionalProperties, indent))
+		}
+		return "{[key: string]: any}"
+	}
+	return "any"
+}
+
+func apiObjectToTSType(schema *jsonschema.Schema, indent string) string {
+	var buf strings.Builder
+	buf.WriteString("{\n")
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		optStr := "?"
+		for _, reqName := range schema.Required {
+			if reqName == pair.Key {
+				optStr = ""
+				break
+			}
+		}
+		fmt.Fprintf(&buf, "%s    %s%s: %s;\n", indent, apiPropName(pair.Key), optStr, apiSchemaToTSType(pair.Value, indent+"    "))
+	}
+	buf.WriteString(indent + "}")
+	return buf.String()
+}
+
+// "blocks.output" => "blocksOutput"
+func apiMethodName(operationId string) string {
+	parts := strings.Split(operationId, ".")
+	for idx := 1; idx < len(parts); idx++ {
+		if parts[idx] != "" {
+			parts[idx] = strings.ToUpper(parts[idx][:1]) + parts[idx][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func generateApiClientMethod(path string, httpMethod string, op *apischema.PathOperation) string {
+	var buf strings.Builder
+	var args []string
+	var queryProps []string
+	tsPath := path
+	for _, param := range op.Parameters {
+		if param.In == "path" {
+			args = append(args, fmt.Sprintf("%s: string", param.Name))
+			tsPath = strings.ReplaceAll(tsPath, "{"+param.Name+"}", fmt.Sprintf("${encodeURIComponent(%s)}", param.Name))
+		} else {
+			queryProps = append(queryProps, fmt.Sprintf("%s?: string", apiPropName(param.Name)))
+		}
+	}
+	bodyArg := "null"
+	if op.RequestBody != nil {
+		// the server fills in the fields that are not set
+		args = append(args, fmt.Sprintf("data: Partial<%s>", apiSchemaToTSType(op.RequestBody.Content["application/json"].Schema, "    ")))
+		bodyArg = "data"
+	}
+	queryArg := "null"
+	if len(queryProps) > 0 {
+		args = append(args, fmt.Sprintf("query?: { %s }", strings.Join(queryProps, "; ")))
+		queryArg = "query"
+	}
+	rtnType := "any"
+	if resp := op.Responses["200"]; resp != nil && resp.Content["application/json"] != nil {
+		respSchema := resp.Content["application/json"].Schema
+		if respSchema.Properties != nil {
+			if dataSchema, ok := respSchema.Properties.Get("data"); ok {
+				rtnType = apiSchemaToTSType(dataSchema, "    ")
+			}
+		}
+	}
+	if op.Summary != "" {
+		fmt.Fprintf(&buf, "    // %s\n", op.Summary)
+	}
+	fmt.Fprintf(&buf, "    // %s %s (%s)\n", strings.ToUpper(httpMethod), path, op.OperationId)
+	fmt.Fprintf(&buf, "    %s(%s): Promise<%s> {\n", apiMethodName(op.OperationId), strings.Join(args, ", "), rtnType)
+	fmt.Fprintf(&buf, "        return this.call(%q, `%s`, %s, %s);\n", strings.ToUpper(httpMethod), tsPath, bodyArg, queryArg)
+	buf.WriteString("    }\n")
+	return buf.String()
+}
+
+const apiClientCallMethod = `    baseUrl: string;
+    token: string;
+
+    // baseUrl is e.g. "http://127.0.0.1:61269/api/v1", token is the contents of the api-token file
+    constructor(baseUrl: string, token: string) {
+        this.baseUrl = baseUrl;
+        this.token = token;
+    }
+
+    async call(method: string, path: string, body: any, query: { [key: string]: string }): Promise<any> {
+        let url = this.baseUrl + path;
+        if (query != null) {
+            const usp = new URLSearchParams();
+            for (const [key, val] of Object.entries(query)) {
+                if (val != null) {
+                    usp.set(key, val);
+                }
+            }
+            if (usp.size > 0) {
+                url += "?" + usp.toString();
+            }
+        }
+        const resp = await fetch(url, {
+            method: method,
+            headers: { Authorization: "Bearer " + this.token },
+            body: body == null ? undefined : JSON.stringify(body),
+        });
+        const rtn = await resp.json();
+        if (!resp.ok || rtn?.error != null) {
+            throw new Error(rtn?.error ?? resp.statusText);
+        }
+        return rtn.data;
+    }
+`
+
+// the types (all the component schemas) and a WaveApiClient class with a method per operation
+func GenerateApiClient(doc *apischema.Document) string {
+	var buf strings.Builder
+	typeNames := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		fmt.Fprintf(&buf, "export type %s = %s;\n\n", apiSchemaTypeName(name), apiSchemaToTSType(doc.Components.Schemas[name], ""))
+	}
+	fmt.Fprintf(&buf, "// %s (%s)\n", doc.Info.Title, doc.Info.Version)
+	buf.WriteString("export class WaveApiClient {\n")
+	buf.WriteString(apiClientCallMethod)
+	var ops []*apischema.PathOperation
+	methodCode := make(map[*apischema.PathOperation]string)
+	for path, pathOps := range doc.Paths {
+		for httpMethod, op := range pathOps {
+			ops = append(ops, op)
+			methodCode[op] = generateApiClientMethod(path, httpMethod, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].OperationId < ops[j].OperationId
+	})
+	for _, op := range ops {
+		buf.WriteString("\n")
+		buf.WriteString(methodCode[op])
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -35,6 +35,10 @@ var ExtraTypes = []any{
 	waveobj.UIContext{},
 	eventbus.WSEventType{},
 	wps.WSFileEventData{},
+	wps.NotificationEventData{},
+	wps.ServerShutdownEventData{},
+	wps.ClusterExecEventData{},
+	wps.SettingsChangeEventData{},
 	waveobj.LayoutActionData{},
 	filestore.WaveFile{},
 	wconfig.FullConfigType{},
@@ -22,16 +22,29 @@ import (
 var MainUserInputHandler = UserInputHandler{Channels: make(map[string](chan *UserInputResponse), 1)}
 
 type UserInputRequest struct {
-	RequestId    string `json:"requestid"`
-	QueryText    string `json:"querytext"`
-	ResponseType string `json:"responsetype"`
-	Title        string `json:"title"`
-	Markdown     bool   `json:"markdown"`
-	TimeoutMs    int    `json:"timeoutms"`
-	CheckBoxMsg  string `json:"checkboxmsg"`
-	PublicText   bool   `json:"publictext"`
-	OkLabel      string `json:"oklabel,omitempty"`
-	CancelLabel  string `json:"cancellabel,omitempty"`
+	RequestId    string         `json:"requestid"`
+	QueryText    string         `json:"querytext"`
+	ResponseType string         `json:"responsetype"`
+	Title        string         `json:"title"`
+	Markdown     bool           `json:"markdown"`
+	TimeoutMs    int            `json:"timeoutms"`
+	CheckBoxMsg  string         `json:"checkboxmsg"`
+	PublicText   bool           `json:"publictext"`
+	OkLabel      string         `json:"oklabel,omitempty"`
+	CancelLabel  string         `json:"cancellabel,omitempty"`
+	HostKey      *HostKeyPrompt `json:"hostkey,omitempty"` // set when the user is asked to accept a host key
+}
+
+// the host key of a host that is not in the known_hosts files (or whose key changed)
+type HostKeyPrompt struct {
+	Host           string   `json:"host"`
+	Remote         string   `json:"remote"` // the address of the host
+	KeyType        string   `json:"keytype"`
+	Fingerprint    string   `json:"fingerprint"` // "SHA256:..."
+	Art            string   `json:"art"`         // the randomart of the fingerprint, as "ssh-keygen -lv" shows it
+	Changed        bool     `json:"changed,omitempty"`
+	KnownKeys      []string `json:"knownkeys,omitempty"` // the keys the known_hosts files have for the host (if it changed)
+	KnownHostsFile string   `json:"knownhostsfile"`      // where the decision is saved
 }
 
 type UserInputResponse struct {
@@ -0,0 +1,152 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package asciicast reads and writes asciinema recordings (asciicast files).  v2 is a json header line followed
+// by one [time, code, data] json line per event (time is seconds from the start).  v1 (read only) is a single
+// json object with the output in a "stdout" array of [delay, data] frames.
+package asciicast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	EventOutput = "o"
+	EventInput  = "i"
+	EventResize = "r" // data is COLSxROWS
+	EventMarker = "m"
+)
+
+type Header struct {
+	Version       int               `json:"version"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Timestamp     int64             `json:"timestamp,omitempty"`
+	Duration      float64           `json:"duration,omitempty"`
+	IdleTimeLimit float64           `json:"idle_time_limit,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+}
+
+type Event struct {
+	Time float64
+	Code string
+	Data string
+}
+
+type Cast struct {
+	Header Header
+	Events []Event
+}
+
+type v1Cast struct {
+	Header
+	Stdout [][]json.RawMessage `json:"stdout"`
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Time, e.Code, e.Data})
+}
+
+func (e *Event) UnmarshalJSON(barr []byte) error {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(barr, &parts); err != nil {
+		return err
+	}
+	if len(parts) != 3 {
+		return fmt.Errorf("event has %d fields, expected 3", len(parts))
+	}
+	if err := json.Unmarshal(parts[0], &e.Time); err != nil {
+		return fmt.Errorf("bad event time: %w", err)
+	}
+	if err := json.Unmarshal(parts[1], &e.Code); err != nil {
+		return fmt.Errorf("bad event code: %w", err)
+	}
+	if err := json.Unmarshal(parts[2], &e.Data); err != nil {
+		return fmt.Errorf("bad event data: %w", err)
+	}
+	return nil
+}
+
+// returns the size from a resize event
+func ParseResize(data string) (cols int, rows int, ok bool) {
+	colsStr, rowsStr, found := strings.Cut(data, "x")
+	if !found {
+		return 0, 0, false
+	}
+	cols, colsErr := strconv.Atoi(colsStr)
+	rows, rowsErr := strconv.Atoi(rowsStr)
+	if colsErr != nil || rowsErr != nil || cols <= 0 || rows <= 0 {
+		return 0, 0, false
+	}
+	return cols, rows, true
+}
+
+func Parse(data []byte) (*Cast, error) {
+	data = bytes.TrimLeft(data, "\ufeff \t\r\n")
+	headerLine, rest, _ := bytes.Cut(data, []byte{'\n'})
+	var versionCheck struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(headerLine, &versionCheck); err != nil {
+		// v1 files are usually pretty printed, so the first line is not the whole object
+		if err := json.Unmarshal(data, &versionCheck); err != nil {
+			return nil, fmt.Errorf("not an asciicast file: %w", err)
+		}
+	}
+	switch versionCheck.Version {
+	case 1:
+		return parseV1(data)
+	case 2:
+		return parseV2(headerLine, rest)
+	default:
+		return nil, fmt.Errorf("unsupported asciicast version %d", versionCheck.Version)
+	}
+}
+
+func parseV1(data []byte) (*Cast, error) {
+	var v1 v1Cast
+	if err := json.Unmarshal(data, &v1); err != nil {
+		return nil, fmt.Errorf("error parsing asciicast v1: %w", err)
+	}
+	rtn := &Cast{Header: v1.Header}
+	var curTime float64
+	for idx, frame := range v1.Stdout {
+		var delay float64
+		var frameData string
+		if len(frame) != 2 || json.Unmarshal(frame[0], &delay) != nil || json.Unmarshal(frame[1], &frameData) != nil {
+			return nil, fmt.Errorf("bad asciicast v1 frame %d", idx)
+		}
+		curTime += delay
+		rtn.Events = append(rtn.Events, Event{Time: curTime, Code: EventOutput, Data: frameData})
+	}
+	return rtn, nil
+}
+
+func parseV2(headerLine []byte, rest []byte) (*Cast, error) {
+	rtn := &Cast{}
+	if err := json.Unmarshal(headerLine, &rtn.Header); err != nil {
+		return nil, fmt.Errorf("error parsing asciicast header: %w", err)
+	}
+	lines := bytes.Split(rest, []byte{'\n'})
+	for idx, line := range lines {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			if idx == len(lines)-1 {
+				// the last line is cut off if the recording did not finish cleanly
+				break
+			}
+			return nil, fmt.Errorf("error parsing asciicast event (line %d): %w", idx+2, err)
+		}
+		rtn.Events = append(rtn.Events, event)
+	}
+	return rtn, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package asciicast
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseV2(t *testing.T) {
+	data := `{"version": 2, "width": 80, "height": 24, "timestamp": 1700000000, "idle_time_limit": 2, "title": "demo"}
+[0.5, "o", "hello "]
+[1.25, "r", "100x30"]
+
+[2.0, "o", "wörld\r\n"]
+[3.0, "o", "cut o`
+	cast, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	wantHeader := Header{Version: 2, Width: 80, Height: 24, Timestamp: 1700000000, IdleTimeLimit: 2, Title: "demo"}
+	if !reflect.DeepEqual(cast.Header, wantHeader) {
+		t.Errorf("header = %+v, want %+v", cast.Header, wantHeader)
+	}
+	wantEvents := []Event{
+		{0.5, EventOutput, "hello "},
+		{1.25, EventResize, "100x30"},
+		{2.0, EventOutput, "wörld\r\n"},
+	}
+	if !reflect.DeepEqual(cast.Events, wantEvents) {
+		t.Errorf("events = %+v, want %+v", cast.Events, wantEvents)
+	}
+}
+
+func TestParseV2BadLine(t *testing.T) {
+	data := "{\"version\": 2, \"width\": 80, \"height\": 24}\n[0.5, \"o\"]\n[1.0, \"o\", \"x\"]\n"
+	if _, err := Parse([]byte(data)); err == nil {
+		t.Errorf("expected an error for an event with two fields")
+	}
+}
+
+func TestParseV1(t *testing.T) {
+	data := `{
+  "version": 1,
+  "width": 40,
+  "height": 10,
+  "duration": 1.5,
+  "stdout": [
+    [0.5, "a"],
+    [1.0, "b"]
+  ]
+}`
+	cast, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if cast.Header.Width != 40 || cast.Header.Height != 10 || cast.Header.Duration != 1.5 {
+		t.Errorf("unexpected header %+v", cast.Header)
+	}
+	wantEvents := []Event{{0.5, EventOutput, "a"}, {1.5, EventOutput, "b"}}
+	if !reflect.DeepEqual(cast.Events, wantEvents) {
+		t.Errorf("events = %+v, want %+v", cast.Events, wantEvents)
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	if _, err := Parse([]byte(`{"version": 3, "term": {"cols": 80, "rows": 24}}`)); err == nil {
+		t.Errorf("expected an error for version 3")
+	}
+	if _, err := Parse([]byte("not a cast")); err == nil {
+		t.Errorf("expected an error for a non-json file")
+	}
+}
+
+func TestEventMarshal(t *testing.T) {
+	barr, err := json.Marshal(Event{Time: 1.5, Code: EventOutput, Data: "a\x1b[0m"})
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	if string(barr) != `[1.5,"o","a\u001b[0m"]` {
+		t.Errorf("marshal = %s", barr)
+	}
+	var event Event
+	if err := json.Unmarshal(barr, &event); err != nil || event.Data != "a\x1b[0m" {
+		t.Errorf("unmarshal = %+v, %v", event, err)
+	}
+}
+
+func TestParseResize(t *testing.T) {
+	if cols, rows, ok := ParseResize("120x40"); !ok || cols != 120 || rows != 40 {
+		t.Errorf("ParseResize(120x40) = %d, %d, %v", cols, rows, ok)
+	}
+	for _, bad := range []string{"120", "0x40", "ax40", ""} {
+		if _, _, ok := ParseResize(bad); ok {
+			t.Errorf("ParseResize(%q) should fail", bad)
+		}
+	}
+}
@@ -41,3 +41,13 @@ func (sm *SyncMap[T]) Delete(key string) {
 	defer sm.lock.Unlock()
 	delete(sm.m, key)
 }
+
+func (sm *SyncMap[T]) Keys() []string {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	keys := make([]string, 0, len(sm.m))
+	for key := range sm.m {
+		keys = append(keys, key)
+	}
+	return keys
+}
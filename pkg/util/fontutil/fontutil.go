@@ -0,0 +1,320 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fontutil finds the font families installed on the machine (from the name table of the font files in the
+// font directories of the os), to check a font setting before a terminal silently renders with a fallback font.
+// the families wave bundles and the generic css families are always there.
+package fontutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	Source_Bundled = "bundled"
+	Source_System  = "system"
+	Source_Generic = "generic"
+)
+
+// a family not found is looked up again (a font installed since) if the fonts were scanned longer ago than this
+const RescanInterval = 30 * time.Second
+
+const maxNameTableSize = 1024 * 1024
+
+// the fonts in public/fonts (loaded by frontend/util/fontutil.ts)
+var BundledFamilies = []string{"Hack", "JetBrains Mono", "Inter"}
+
+var GenericFamilies = []string{"monospace", "ui-monospace", "serif", "sans-serif", "system-ui", "cursive", "fantasy"}
+
+type FontFamily struct {
+	Name   string `json:"name"`
+	Source string `json:"source"` // bundled, system, or generic
+}
+
+var scanLock sync.Mutex
+var systemFamilies map[string]string // lowercased => name
+var lastScan time.Time
+
+func homeDir() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// the directories the os installs fonts into
+func FontDirs() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"/System/Library/Fonts", "/Library/Fonts", filepath.Join(homeDir(), "Library/Fonts")}
+	case "windows":
+		winDir := os.Getenv("WINDIR")
+		if winDir == "" {
+			winDir = `C:\Windows`
+		}
+		dirs := []string{filepath.Join(winDir, "Fonts")}
+		if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+			dirs = append(dirs, filepath.Join(localAppData, `Microsoft\Windows\Fonts`))
+		}
+		return dirs
+	default:
+		dirs := []string{"/usr/share/fonts", "/usr/local/share/fonts"}
+		if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+			dirs = append(dirs, filepath.Join(dataHome, "fonts"))
+		} else {
+			dirs = append(dirs, filepath.Join(homeDir(), ".local/share/fonts"))
+		}
+		return append(dirs, filepath.Join(homeDir(), ".fonts"))
+	}
+}
+
+// the family names of a font file (ttf, otf, or a ttc collection), typographic families (name id 16) are preferred
+func ReadFamilies(r io.ReaderAt) ([]string, error) {
+	var header [12]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return nil, err
+	}
+	offsets := []int64{0}
+	if string(header[:4]) == "ttcf" {
+		numFonts := binary.BigEndian.Uint32(header[8:12])
+		if numFonts == 0 || numFonts > 1024 {
+			return nil, fmt.Errorf("invalid font collection (%d fonts)", numFonts)
+		}
+		barr := make([]byte, 4*numFonts)
+		if _, err := r.ReadAt(barr, 12); err != nil {
+			return nil, err
+		}
+		offsets = offsets[:0]
+		for i := uint32(0); i < numFonts; i++ {
+			offsets = append(offsets, int64(binary.BigEndian.Uint32(barr[4*i:])))
+		}
+	}
+	var rtn []string
+	seen := make(map[string]bool)
+	for _, offset := range offsets {
+		families, err := readFontFamilies(r, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, family := range families {
+			if !seen[family] {
+				seen[family] = true
+				rtn = append(rtn, family)
+			}
+		}
+	}
+	return rtn, nil
+}
+
+// the families in the name table of the font at offset
+func readFontFamilies(r io.ReaderAt, offset int64) ([]string, error) {
+	var header [12]byte
+	if _, err := r.ReadAt(header[:], offset); err != nil {
+		return nil, err
+	}
+	switch string(header[:4]) {
+	case "\x00\x01\x00\x00", "OTTO", "true":
+	default:
+		return nil, errors.New("not a truetype or opentype font")
+	}
+	numTables := int(binary.BigEndian.Uint16(header[4:6]))
+	records := make([]byte, 16*numTables)
+	if _, err := r.ReadAt(records, offset+12); err != nil {
+		return nil, err
+	}
+	for i := 0; i < numTables; i++ {
+		record := records[16*i:]
+		if string(record[:4]) != "name" {
+			continue
+		}
+		tableOffset := int64(binary.BigEndian.Uint32(record[8:12]))
+		tableLen := binary.BigEndian.Uint32(record[12:16])
+		if tableLen > maxNameTableSize {
+			return nil, fmt.Errorf("name table too large (%d bytes)", tableLen)
+		}
+		table := make([]byte, tableLen)
+		if _, err := r.ReadAt(table, tableOffset); err != nil {
+			return nil, err
+		}
+		return parseNameTable(table)
+	}
+	return nil, errors.New("no name table")
+}
+
+func parseNameTable(table []byte) ([]string, error) {
+	if len(table) < 6 {
+		return nil, errors.New("invalid name table")
+	}
+	count := int(binary.BigEndian.Uint16(table[2:4]))
+	storageOffset := int(binary.BigEndian.Uint16(table[4:6]))
+	if len(table) < 6+12*count {
+		return nil, errors.New("invalid name table")
+	}
+	byNameId := make(map[uint16][]string)
+	for i := 0; i < count; i++ {
+		record := table[6+12*i:]
+		platformId := binary.BigEndian.Uint16(record[0:2])
+		nameId := binary.BigEndian.Uint16(record[6:8])
+		if nameId != 1 && nameId != 16 {
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(record[8:10]))
+		start := storageOffset + int(binary.BigEndian.Uint16(record[10:12]))
+		if start+length > len(table) {
+			continue
+		}
+		var name string
+		switch platformId {
+		case 0, 3:
+			name = decodeUtf16(table[start : start+length])
+		case 1:
+			name = string(table[start : start+length])
+		default:
+			continue
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			byNameId[nameId] = append(byNameId[nameId], name)
+		}
+	}
+	if len(byNameId[16]) > 0 {
+		return byNameId[16], nil
+	}
+	return byNameId[1], nil
+}
+
+func decodeUtf16(barr []byte) string {
+	units := make([]uint16, len(barr)/2)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(barr[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// reads the families of the font files in the font dirs, must hold scanLock
+func scanSystemFamilies() {
+	families := make(map[string]string)
+	for _, dir := range FontDirs() {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".ttf", ".otf", ".ttc", ".otc":
+			default:
+				return nil
+			}
+			fd, err := os.Open(path)
+			if err != nil {
+				return nil
+			}
+			defer fd.Close()
+			names, _ := ReadFamilies(fd)
+			for _, name := range names {
+				families[strings.ToLower(name)] = name
+			}
+			return nil
+		})
+	}
+	systemFamilies = families
+	lastScan = time.Now()
+}
+
+// the families of the fonts installed on the machine (sorted), scanned the first time
+func SystemFamilies() []string {
+	scanLock.Lock()
+	defer scanLock.Unlock()
+	if systemFamilies == nil {
+		scanSystemFamilies()
+	}
+	rtn := make([]string, 0, len(systemFamilies))
+	for _, name := range systemFamilies {
+		rtn = append(rtn, name)
+	}
+	sort.Strings(rtn)
+	return rtn
+}
+
+// the bundled, system, and generic families
+func ListFamilies() []FontFamily {
+	var rtn []FontFamily
+	for _, name := range BundledFamilies {
+		rtn = append(rtn, FontFamily{Name: name, Source: Source_Bundled})
+	}
+	for _, name := range SystemFamilies() {
+		rtn = append(rtn, FontFamily{Name: name, Source: Source_System})
+	}
+	for _, name := range GenericFamilies {
+		rtn = append(rtn, FontFamily{Name: name, Source: Source_Generic})
+	}
+	return rtn
+}
+
+// the families of a css font-family list ("'Fira Code', monospace")
+func SplitFontFamily(fontFamily string) []string {
+	var rtn []string
+	for _, part := range strings.Split(fontFamily, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) >= 2 && (part[0] == '"' || part[0] == '\'') && part[len(part)-1] == part[0] {
+			part = part[1 : len(part)-1]
+		}
+		if part != "" {
+			rtn = append(rtn, part)
+		}
+	}
+	return rtn
+}
+
+func isKnownFamily(name string) bool {
+	for _, family := range BundledFamilies {
+		if strings.EqualFold(family, name) {
+			return true
+		}
+	}
+	for _, family := range GenericFamilies {
+		if strings.EqualFold(family, name) {
+			return true
+		}
+	}
+	return systemFamilies[strings.ToLower(name)] != ""
+}
+
+// checks that a css font-family list has a family that is bundled, generic, or installed.  if no fonts can be read
+// from the font dirs (a sandbox, an os layout we don't know) every family passes.
+func CheckFontFamily(fontFamily string) error {
+	families := SplitFontFamily(fontFamily)
+	if len(families) == 0 {
+		return errors.New("no font family")
+	}
+	scanLock.Lock()
+	defer scanLock.Unlock()
+	if systemFamilies == nil {
+		scanSystemFamilies()
+	}
+	for _, family := range families {
+		if isKnownFamily(family) {
+			return nil
+		}
+	}
+	if time.Since(lastScan) > RescanInterval {
+		scanSystemFamilies()
+		for _, family := range families {
+			if isKnownFamily(family) {
+				return nil
+			}
+		}
+	}
+	if len(systemFamilies) == 0 {
+		return nil
+	}
+	return fmt.Errorf("font not found: %s (see wsh font ls)", fontFamily)
+}
@@ -0,0 +1,101 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package fontutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+type testName struct {
+	platformId uint16
+	nameId     uint16
+	value      string
+}
+
+// a font with only a name table (at offset in the file)
+func makeTestFont(offset int, names []testName) []byte {
+	var storage bytes.Buffer
+	var table bytes.Buffer
+	binary.Write(&table, binary.BigEndian, []uint16{0, uint16(len(names)), uint16(6 + 12*len(names))})
+	for _, name := range names {
+		var encoded []byte
+		if name.platformId == 1 {
+			encoded = []byte(name.value)
+		} else {
+			for _, unit := range utf16.Encode([]rune(name.value)) {
+				encoded = binary.BigEndian.AppendUint16(encoded, unit)
+			}
+		}
+		binary.Write(&table, binary.BigEndian, []uint16{name.platformId, 0, 0, name.nameId, uint16(len(encoded)), uint16(storage.Len())})
+		storage.Write(encoded)
+	}
+	table.Write(storage.Bytes())
+	var font bytes.Buffer
+	font.Write([]byte{0, 1, 0, 0})
+	binary.Write(&font, binary.BigEndian, []uint16{1, 0, 0, 0})
+	font.WriteString("name")
+	binary.Write(&font, binary.BigEndian, []uint32{0, uint32(offset + 28), uint32(table.Len())})
+	font.Write(table.Bytes())
+	return font.Bytes()
+}
+
+func TestReadFamilies(t *testing.T) {
+	font := makeTestFont(0, []testName{
+		{3, 1, "Fira Code Retina"},
+		{3, 16, "Fira Code"},
+		{1, 16, "Fira Code"},
+		{3, 4, "Fira Code Retina Regular"},
+	})
+	families, err := ReadFamilies(bytes.NewReader(font))
+	if err != nil {
+		t.Fatalf("reading families: %v", err)
+	}
+	if !reflect.DeepEqual(families, []string{"Fira Code"}) {
+		t.Errorf("expected the typographic family, got %v", families)
+	}
+
+	// a collection of two fonts
+	first := makeTestFont(20, []testName{{3, 1, "Menlo"}})
+	second := makeTestFont(20+len(first), []testName{{1, 1, "Monaco"}})
+	var ttc bytes.Buffer
+	ttc.WriteString("ttcf")
+	binary.Write(&ttc, binary.BigEndian, []uint32{0x00010000, 2, 20, uint32(20 + len(first))})
+	ttc.Write(first)
+	ttc.Write(second)
+	families, err = ReadFamilies(bytes.NewReader(ttc.Bytes()))
+	if err != nil {
+		t.Fatalf("reading collection: %v", err)
+	}
+	if !reflect.DeepEqual(families, []string{"Menlo", "Monaco"}) {
+		t.Errorf("expected the families of both fonts, got %v", families)
+	}
+
+	if _, err := ReadFamilies(bytes.NewReader([]byte("wOF2 not a ttf"))); err == nil {
+		t.Errorf("expected an error for a woff2 file")
+	}
+}
+
+func TestCheckFontFamily(t *testing.T) {
+	scanLock.Lock()
+	systemFamilies = map[string]string{"fira code": "Fira Code"}
+	lastScan = lastScan.AddDate(100, 0, 0)
+	scanLock.Unlock()
+	if !reflect.DeepEqual(SplitFontFamily(`"Fira Code", 'Noto Mono',monospace`), []string{"Fira Code", "Noto Mono", "monospace"}) {
+		t.Errorf("unexpected split %v", SplitFontFamily(`"Fira Code", 'Noto Mono',monospace`))
+	}
+	for _, fontFamily := range []string{"fira code", "Hack", "No Such Font, monospace", "'JetBrains Mono'"} {
+		if err := CheckFontFamily(fontFamily); err != nil {
+			t.Errorf("%q: unexpected error %v", fontFamily, err)
+		}
+	}
+	for _, fontFamily := range []string{"No Such Font", " , "} {
+		if err := CheckFontFamily(fontFamily); err == nil {
+			t.Errorf("%q: expected an error", fontFamily)
+		}
+	}
+}
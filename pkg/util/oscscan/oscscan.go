@@ -0,0 +1,154 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package oscscan finds OSC escape sequences (ESC ] num ; data BEL|ST) in a terminal output stream.
+// Sequences may be split across writes.  The stream itself is not modified.
+package oscscan
+
+const MaxOscDataSize = 8 * 1024
+const maxOscNumDigits = 6
+
+const (
+	stateNormal = iota
+	stateEsc
+	stateNum
+	stateData
+	stateDataEsc
+)
+
+// called for every complete OSC sequence the scanner is interested in.
+// offset is the stream offset of the ESC that starts the sequence, endOffset is the offset just past its terminator.
+// data is only valid for the duration of the call.
+type OscHandler func(oscNum int, data []byte, offset int64, endOffset int64)
+
+type OscScanner struct {
+	handler     OscHandler
+	oscNums     map[int]bool
+	maxSizes    map[int]int
+	maxSize     int // max data size of the sequence being captured
+	state       int
+	offset      int64
+	startOffset int64
+	num         int
+	numDigits   int
+	capture     bool
+	data        []byte
+}
+
+// only sequences with a number in oscNums are reported (and buffered)
+func MakeOscScanner(handler OscHandler, oscNums ...int) *OscScanner {
+	scanner := &OscScanner{handler: handler, oscNums: make(map[int]bool), maxSizes: make(map[int]int)}
+	for _, num := range oscNums {
+		scanner.oscNums[num] = true
+	}
+	return scanner
+}
+
+// sequences with more than MaxOscDataSize bytes of data are dropped, this raises the limit for one number
+// (e.g. OSC 52 clipboard data)
+func (s *OscScanner) SetMaxDataSize(oscNum int, maxSize int) {
+	s.maxSizes[oscNum] = maxSize
+}
+
+// sets the stream offset of the next byte written (offsets passed to the handler are relative to this)
+func (s *OscScanner) SetOffset(offset int64) {
+	s.offset = offset
+}
+
+func (s *OscScanner) GetOffset() int64 {
+	return s.offset
+}
+
+func (s *OscScanner) finish() {
+	if s.capture {
+		s.handler(s.num, s.data, s.startOffset, s.offset+1)
+	}
+	s.reset()
+}
+
+func (s *OscScanner) reset() {
+	s.state = stateNormal
+	s.capture = false
+	s.data = s.data[:0]
+}
+
+// never returns an error, implements io.Writer so it can be used with io.MultiWriter / io.TeeReader
+func (s *OscScanner) Write(barr []byte) (int, error) {
+	for _, ch := range barr {
+		s.processByte(ch)
+		s.offset++
+	}
+	return len(barr), nil
+}
+
+func (s *OscScanner) processByte(ch byte) {
+	switch s.state {
+	case stateNormal:
+		if ch == 0x1b {
+			s.state = stateEsc
+		}
+
+	case stateEsc:
+		if ch == ']' {
+			s.state = stateNum
+			s.startOffset = s.offset - 1
+			s.num = 0
+			s.numDigits = 0
+		} else if ch != 0x1b {
+			s.state = stateNormal
+		}
+
+	case stateNum:
+		if ch >= '0' && ch <= '9' && s.numDigits < maxOscNumDigits {
+			s.num = s.num*10 + int(ch-'0')
+			s.numDigits++
+			return
+		}
+		s.capture = s.numDigits > 0 && s.oscNums[s.num]
+		s.maxSize = MaxOscDataSize
+		if maxSize, ok := s.maxSizes[s.num]; ok {
+			s.maxSize = maxSize
+		}
+		switch ch {
+		case ';':
+			s.state = stateData
+		case 0x07:
+			s.finish()
+		case 0x1b:
+			s.state = stateDataEsc
+		default:
+			s.reset()
+		}
+
+	case stateData:
+		switch ch {
+		case 0x07:
+			s.finish()
+		case 0x1b:
+			s.state = stateDataEsc
+		case 0x18, 0x1a:
+			// CAN and SUB abort the sequence
+			s.reset()
+		default:
+			if !s.capture {
+				return
+			}
+			if len(s.data) >= s.maxSize {
+				s.capture = false
+				s.data = s.data[:0]
+				return
+			}
+			s.data = append(s.data, ch)
+		}
+
+	case stateDataEsc:
+		if ch == '\\' {
+			s.finish()
+			return
+		}
+		// not a string terminator, the sequence is abandoned and the ESC starts a new one
+		s.reset()
+		s.state = stateEsc
+		s.processByte(ch)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package oscscan
+
+import (
+	"strings"
+	"testing"
+)
+
+type oscResult struct {
+	num       int
+	data      string
+	offset    int64
+	endOffset int64
+}
+
+func scanAll(chunks []string, oscNums ...int) []oscResult {
+	var rtn []oscResult
+	scanner := MakeOscScanner(func(oscNum int, data []byte, offset int64, endOffset int64) {
+		rtn = append(rtn, oscResult{num: oscNum, data: string(data), offset: offset, endOffset: endOffset})
+	}, oscNums...)
+	for _, chunk := range chunks {
+		scanner.Write([]byte(chunk))
+	}
+	return rtn
+}
+
+func TestOscScanner(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []oscResult
+	}{
+		{
+			name:   "bel terminator",
+			chunks: []string{"ab\x1b]7;file://host/tmp\x07cd"},
+			want:   []oscResult{{7, "file://host/tmp", 2, 22}},
+		},
+		{
+			name:   "st terminator",
+			chunks: []string{"\x1b]7;file:///x\x1b\\"},
+			want:   []oscResult{{7, "file:///x", 0, 15}},
+		},
+		{
+			name:   "split across writes",
+			chunks: []string{"xx\x1b", "]13", "3;A", "\x1b", "\\yy"},
+			want:   []oscResult{{133, "A", 2, 11}},
+		},
+		{
+			name:   "other osc numbers ignored",
+			chunks: []string{"\x1b]0;title\x07\x1b]7;/a\x07"},
+			want:   []oscResult{{7, "/a", 10, 17}},
+		},
+		{
+			name:   "no data",
+			chunks: []string{"\x1b]133\x07"},
+			want:   []oscResult{{133, "", 0, 6}},
+		},
+		{
+			name:   "abandoned sequence restarts on esc",
+			chunks: []string{"\x1b]7;/a\x1b]7;/b\x07"},
+			want:   []oscResult{{7, "/b", 6, 13}},
+		},
+		{
+			name:   "csi is not osc",
+			chunks: []string{"\x1b[7;1m\x1b]7;/c\x07"},
+			want:   []oscResult{{7, "/c", 6, 13}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scanAll(tt.chunks, 7, 133)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results %v, want %d %v", len(got), got, len(tt.want), tt.want)
+			}
+			for idx := range got {
+				if got[idx] != tt.want[idx] {
+					t.Errorf("result %d: got %+v, want %+v", idx, got[idx], tt.want[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestOscScannerMaxDataSize(t *testing.T) {
+	var got []oscResult
+	scanner := MakeOscScanner(func(oscNum int, data []byte, offset int64, endOffset int64) {
+		got = append(got, oscResult{num: oscNum, data: string(data), offset: offset, endOffset: endOffset})
+	}, 7, 52)
+	scanner.SetMaxDataSize(52, MaxOscDataSize*2)
+	bigData := strings.Repeat("x", MaxOscDataSize+1)
+	scanner.Write([]byte("\x1b]7;" + bigData + "\x07"))
+	scanner.Write([]byte("\x1b]52;" + bigData + "\x07"))
+	if len(got) != 1 || got[0].num != 52 || got[0].data != bigData {
+		t.Fatalf("expected only the OSC 52 sequence, got %d results", len(got))
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pipeutil serves and dials windows named pipes as net.Listener and net.Conn (windows has no peer
+// credentials for unix sockets, so local apis that need to know who is connecting use a pipe there).  a pipe is
+// only accessible to the user that made it, and only from the local machine.
+package pipeutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// the pipe name for a service of the wave with the data dir (so two waves, e.g. dev and release, do not collide)
+func PipeName(service string, dataDir string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(dataDir)))
+	return `\\.\pipe\waveterm-` + service + "-" + hex.EncodeToString(hash[:8])
+}
@@ -0,0 +1,333 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package pipeutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const pipeBufSize = 64 * 1024
+
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// a connected pipe, the handle is opened for overlapped i/o so a read and a write can be in progress together
+type PipeConn struct {
+	handle        windows.Handle
+	name          string
+	closed        atomic.Bool
+	closeOnce     sync.Once
+	deadlineLock  sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+// waits for the i/o started by fn, until deadline (if it is set)
+func (c *PipeConn) doIO(deadline time.Time, fn func(o *windows.Overlapped) error) (int, error) {
+	if c.closed.Load() {
+		return 0, net.ErrClosed
+	}
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(event)
+	o := &windows.Overlapped{HEvent: event}
+	err = fn(o)
+	if err != nil && err != windows.ERROR_IO_PENDING {
+		return 0, err
+	}
+	var numBytes uint32
+	if !deadline.IsZero() {
+		timeout := time.Until(deadline)
+		if timeout < 0 {
+			timeout = 0
+		}
+		waitRtn, _ := windows.WaitForSingleObject(event, uint32(timeout.Milliseconds()))
+		if waitRtn == uint32(windows.WAIT_TIMEOUT) {
+			windows.CancelIoEx(c.handle, o)
+			windows.GetOverlappedResult(c.handle, o, &numBytes, true)
+			return int(numBytes), os.ErrDeadlineExceeded
+		}
+	}
+	err = windows.GetOverlappedResult(c.handle, o, &numBytes, true)
+	if err == windows.ERROR_OPERATION_ABORTED && c.closed.Load() {
+		err = net.ErrClosed
+	}
+	return int(numBytes), err
+}
+
+func (c *PipeConn) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	c.deadlineLock.Lock()
+	deadline := c.readDeadline
+	c.deadlineLock.Unlock()
+	n, err := c.doIO(deadline, func(o *windows.Overlapped) error {
+		return windows.ReadFile(c.handle, b, nil, o)
+	})
+	if err == windows.ERROR_BROKEN_PIPE || err == windows.ERROR_PIPE_NOT_CONNECTED {
+		return n, io.EOF
+	}
+	if err == windows.ERROR_MORE_DATA {
+		err = nil
+	}
+	return n, err
+}
+
+func (c *PipeConn) Write(b []byte) (int, error) {
+	c.deadlineLock.Lock()
+	deadline := c.writeDeadline
+	c.deadlineLock.Unlock()
+	total := 0
+	for total < len(b) {
+		n, err := c.doIO(deadline, func(o *windows.Overlapped) error {
+			return windows.WriteFile(c.handle, b[total:], nil, o)
+		})
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (c *PipeConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.closed.Store(true)
+		windows.CancelIoEx(c.handle, nil)
+		err = windows.CloseHandle(c.handle)
+	})
+	return err
+}
+
+func (c *PipeConn) LocalAddr() net.Addr  { return pipeAddr(c.name) }
+func (c *PipeConn) RemoteAddr() net.Addr { return pipeAddr(c.name) }
+
+// a deadline applies to the reads and writes started after it is set
+func (c *PipeConn) SetDeadline(t time.Time) error {
+	c.deadlineLock.Lock()
+	defer c.deadlineLock.Unlock()
+	c.readDeadline = t
+	c.writeDeadline = t
+	return nil
+}
+
+func (c *PipeConn) SetReadDeadline(t time.Time) error {
+	c.deadlineLock.Lock()
+	defer c.deadlineLock.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+func (c *PipeConn) SetWriteDeadline(t time.Time) error {
+	c.deadlineLock.Lock()
+	defer c.deadlineLock.Unlock()
+	c.writeDeadline = t
+	return nil
+}
+
+// returns an error unless the process at the other end of the pipe runs as the user of this process
+func (c *PipeConn) CheckClientUser() error {
+	var pid uint32
+	err := windows.GetNamedPipeClientProcessId(c.handle, &pid)
+	if err != nil {
+		return fmt.Errorf("error getting the client pid: %w", err)
+	}
+	proc, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return fmt.Errorf("error opening client process %d: %w", pid, err)
+	}
+	defer windows.CloseHandle(proc)
+	var token windows.Token
+	err = windows.OpenProcessToken(proc, windows.TOKEN_QUERY, &token)
+	if err != nil {
+		return fmt.Errorf("error getting the token of client process %d: %w", pid, err)
+	}
+	defer token.Close()
+	clientUser, err := token.GetTokenUser()
+	if err != nil {
+		return err
+	}
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return err
+	}
+	if !clientUser.User.Sid.Equals(user.User.Sid) {
+		return fmt.Errorf("client process %d runs as another user (%s)", pid, clientUser.User.Sid.String())
+	}
+	return nil
+}
+
+type PipeListener struct {
+	name   string
+	sa     *windows.SecurityAttributes
+	lock   sync.Mutex
+	next   windows.Handle // the instance waiting for the next client
+	closed bool
+}
+
+// only the current user gets access to the pipe
+func makeSecurityAttributes() (*windows.SecurityAttributes, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the current user: %w", err)
+	}
+	sd, err := windows.SecurityDescriptorFromString(fmt.Sprintf("D:P(A;;GA;;;%s)", user.User.Sid.String()))
+	if err != nil {
+		return nil, fmt.Errorf("error making the pipe security descriptor: %w", err)
+	}
+	return &windows.SecurityAttributes{Length: uint32(unsafe.Sizeof(windows.SecurityAttributes{})), SecurityDescriptor: sd}, nil
+}
+
+func createInstance(name string, sa *windows.SecurityAttributes, first bool) (windows.Handle, error) {
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX | windows.FILE_FLAG_OVERLAPPED)
+	if first {
+		// fails if another process already made the pipe
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+	pipeMode := uint32(windows.PIPE_TYPE_BYTE | windows.PIPE_READMODE_BYTE | windows.PIPE_WAIT | windows.PIPE_REJECT_REMOTE_CLIENTS)
+	return windows.CreateNamedPipe(namePtr, flags, pipeMode, windows.PIPE_UNLIMITED_INSTANCES, pipeBufSize, pipeBufSize, 0, sa)
+}
+
+func Listen(name string) (*PipeListener, error) {
+	sa, err := makeSecurityAttributes()
+	if err != nil {
+		return nil, err
+	}
+	handle, err := createInstance(name, sa, true)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pipe %s: %w", name, err)
+	}
+	return &PipeListener{name: name, sa: sa, next: handle}, nil
+}
+
+func (l *PipeListener) isClosed() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.closed
+}
+
+func (l *PipeListener) Accept() (net.Conn, error) {
+	l.lock.Lock()
+	if l.closed {
+		l.lock.Unlock()
+		return nil, net.ErrClosed
+	}
+	handle := l.next
+	l.lock.Unlock()
+	if handle == windows.InvalidHandle {
+		return nil, fmt.Errorf("no pipe instance to accept on")
+	}
+	for {
+		err := connectInstance(handle)
+		if l.isClosed() {
+			return nil, net.ErrClosed
+		}
+		if err == windows.ERROR_NO_DATA {
+			// the client went away before it was accepted
+			windows.DisconnectNamedPipe(handle)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	next, err := createInstance(l.name, l.sa, false)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		// Close closed handle (it is still l.next)
+		if err == nil {
+			windows.CloseHandle(next)
+		}
+		return nil, net.ErrClosed
+	}
+	if err != nil {
+		// this client is served, the next Accept returns the error
+		next = windows.InvalidHandle
+	}
+	l.next = next
+	return &PipeConn{handle: handle, name: l.name}, nil
+}
+
+// waits for a client to connect to the instance
+func connectInstance(handle windows.Handle) error {
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(event)
+	o := &windows.Overlapped{HEvent: event}
+	err = windows.ConnectNamedPipe(handle, o)
+	if err == windows.ERROR_PIPE_CONNECTED {
+		return nil
+	}
+	if err != windows.ERROR_IO_PENDING {
+		return err
+	}
+	var numBytes uint32
+	return windows.GetOverlappedResult(handle, o, &numBytes, true)
+}
+
+func (l *PipeListener) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	if l.next != windows.InvalidHandle {
+		windows.CancelIoEx(l.next, nil)
+		windows.CloseHandle(l.next)
+		l.next = windows.InvalidHandle
+	}
+	return nil
+}
+
+func (l *PipeListener) Addr() net.Addr {
+	return pipeAddr(l.name)
+}
+
+// connects to the pipe, waiting up to timeout while all its instances are busy
+func Dial(name string, timeout time.Duration) (*PipeConn, error) {
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		// SECURITY_IDENTIFICATION keeps the server from impersonating this process
+		handle, err := windows.CreateFile(namePtr, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING,
+			windows.FILE_FLAG_OVERLAPPED|windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION, 0)
+		if err == nil {
+			return &PipeConn{handle: handle, name: name}, nil
+		}
+		if !errors.Is(err, windows.ERROR_PIPE_BUSY) || time.Now().After(deadline) {
+			return nil, &os.PathError{Op: "dial", Path: name, Err: err}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package plistutil parses XML property lists (e.g. iTerm2's .itermcolors and profile files).  the values are
+// map[string]any (dict), []any (array), string (string and date), float64 (real), int64 (integer), bool, and
+// []byte (data).  binary property lists are not supported.
+package plistutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parses an XML property list, returns its top level value
+func Parse(data []byte) (any, error) {
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		return nil, fmt.Errorf("binary property lists are not supported (convert it with: plutil -convert xml1 FILE)")
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no plist element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "plist" {
+			return parseValue(dec, start)
+		}
+		elem, err := nextStart(dec)
+		if err != nil {
+			return nil, err
+		}
+		if elem == nil {
+			return nil, nil
+		}
+		return parseValue(dec, *elem)
+	}
+}
+
+// the next start element in the current element, nil at its end
+func nextStart(dec *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+func parseValue(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		rtn := make(map[string]any)
+		for {
+			keyElem, err := nextStart(dec)
+			if err != nil {
+				return nil, err
+			}
+			if keyElem == nil {
+				return rtn, nil
+			}
+			if keyElem.Name.Local != "key" {
+				return nil, fmt.Errorf("invalid plist: expected a key in a dict, got <%s>", keyElem.Name.Local)
+			}
+			var key string
+			if err := dec.DecodeElement(&key, keyElem); err != nil {
+				return nil, fmt.Errorf("invalid plist: %w", err)
+			}
+			valElem, err := nextStart(dec)
+			if err != nil {
+				return nil, err
+			}
+			if valElem == nil {
+				return nil, fmt.Errorf("invalid plist: no value for key %q", key)
+			}
+			val, err := parseValue(dec, *valElem)
+			if err != nil {
+				return nil, err
+			}
+			rtn[key] = val
+		}
+	case "array":
+		rtn := []any{}
+		for {
+			elem, err := nextStart(dec)
+			if err != nil {
+				return nil, err
+			}
+			if elem == nil {
+				return rtn, nil
+			}
+			val, err := parseValue(dec, *elem)
+			if err != nil {
+				return nil, err
+			}
+			rtn = append(rtn, val)
+		}
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, fmt.Errorf("invalid plist: %w", err)
+		}
+		return start.Name.Local == "true", nil
+	}
+	var text string
+	if err := dec.DecodeElement(&text, &start); err != nil {
+		return nil, fmt.Errorf("invalid plist: %w", err)
+	}
+	text = strings.TrimSpace(text)
+	switch start.Name.Local {
+	case "string", "date":
+		return text, nil
+	case "real":
+		val, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist real %q", text)
+		}
+		return val, nil
+	case "integer":
+		val, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist integer %q", text)
+		}
+		return val, nil
+	case "data":
+		val, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist data: %w", err)
+		}
+		return val, nil
+	}
+	return nil, fmt.Errorf("invalid plist: unknown element <%s>", start.Name.Local)
+}
+
+// the value of a number (real or integer), ok is false if it is not one
+func GetFloat(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
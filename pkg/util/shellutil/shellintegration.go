@@ -0,0 +1,227 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellutil
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// the shell integration files live in the wave home directory (never in the user's dotfiles).
+// each shell is pointed at them on startup (ZDOTDIR, --rcfile, -C, -File, -e) and they source the user's own rc files.
+type shellIntegrationFile struct {
+	FileName string
+	Template string
+}
+
+type shellIntegration struct {
+	ShellType string
+	Dir       string
+	Files     []shellIntegrationFile
+}
+
+var shellIntegrations = []shellIntegration{
+	{
+		ShellType: ShellType_zsh,
+		Dir:       ZshIntegrationDir,
+		Files: []shellIntegrationFile{
+			{FileName: ".zprofile", Template: ZshStartup_Zprofile},
+			{FileName: ".zshrc", Template: ZshStartup_Zshrc},
+			{FileName: ".zlogin", Template: ZshStartup_Zlogin},
+			{FileName: ".zshenv", Template: ZshStartup_Zshenv},
+		},
+	},
+	{
+		ShellType: ShellType_bash,
+		Dir:       BashIntegrationDir,
+		Files:     []shellIntegrationFile{{FileName: ".bashrc", Template: BashStartup_Bashrc}},
+	},
+	{
+		ShellType: ShellType_fish,
+		Dir:       FishIntegrationDir,
+		Files:     []shellIntegrationFile{{FileName: "wave.fish", Template: FishStartup_Wavefish}},
+	},
+	{
+		ShellType: ShellType_pwsh,
+		Dir:       PwshIntegrationDir,
+		Files:     []shellIntegrationFile{{FileName: "wavepwsh.ps1", Template: PwshStartup_wavepwsh}},
+	},
+	{
+		ShellType: ShellType_nu,
+		Dir:       NuIntegrationDir,
+		Files:     []shellIntegrationFile{{FileName: "wave.nu", Template: NuStartup_Wavenu}},
+	},
+}
+
+func makeShellIntegrationParams(absWshBinDir string) map[string]string {
+	var pathSep string
+	if runtime.GOOS == "windows" {
+		pathSep = ";"
+	} else {
+		pathSep = ":"
+	}
+	return map[string]string{
+		"WSHBINDIR":      HardQuote(absWshBinDir),
+		"WSHBINDIR_PWSH": HardQuotePowerShell(absWshBinDir),
+		"WSHBINDIR_NU":   HardQuoteNu(absWshBinDir),
+		"PATHSEP":        pathSep,
+	}
+}
+
+// writes the shell integration files for every supported shell into waveHome
+func InitRcFiles(waveHome string, absWshBinDir string) error {
+	params := makeShellIntegrationParams(absWshBinDir)
+	for _, si := range shellIntegrations {
+		dir := filepath.Join(waveHome, si.Dir)
+		err := wavebase.CacheEnsureDir(dir, si.Dir, 0755, si.Dir)
+		if err != nil {
+			return err
+		}
+		for _, file := range si.Files {
+			err = utilfn.WriteTemplateToFile(filepath.Join(dir, file.FileName), file.Template, params)
+			if err != nil {
+				return fmt.Errorf("error writing %s-integration %s: %v", si.ShellType, file.FileName, err)
+			}
+		}
+	}
+	err := InstallTerminfo(waveHome)
+	if err != nil {
+		// not fatal, blocks fall back to xterm-256color
+		log.Printf("error installing terminfo entry: %v\n", err)
+	}
+	return nil
+}
+
+// compares the installed shell integration files against what this version of wave would write.
+// files are stale after an upgrade (or if the wave home directory moved) until InitRcFiles runs again.
+func CheckShellIntegration(waveHome string, absWshBinDir string) *wshrpc.ShellIntegrationStatus {
+	rtn := &wshrpc.ShellIntegrationStatus{
+		WaveHome:  waveHome,
+		WshBinDir: absWshBinDir,
+		Healthy:   true,
+	}
+	wshPath := filepath.Join(absWshBinDir, "wsh")
+	if runtime.GOOS == "windows" {
+		wshPath = wshPath + ".exe"
+	}
+	if finfo, err := os.Stat(wshPath); err == nil && !finfo.IsDir() {
+		rtn.WshInstalled = true
+	} else {
+		rtn.Healthy = false
+	}
+	params := makeShellIntegrationParams(absWshBinDir)
+	for _, si := range shellIntegrations {
+		shellStatus := wshrpc.ShellIntegrationShellStatus{ShellType: si.ShellType}
+		for _, file := range si.Files {
+			fileName := filepath.Join(si.Dir, file.FileName)
+			contents, err := os.ReadFile(filepath.Join(waveHome, fileName))
+			if err != nil {
+				shellStatus.MissingFiles = append(shellStatus.MissingFiles, fileName)
+				continue
+			}
+			if !bytes.Equal(contents, utilfn.RenderTemplate(file.Template, params)) {
+				shellStatus.StaleFiles = append(shellStatus.StaleFiles, fileName)
+			}
+		}
+		shellStatus.Installed = len(shellStatus.MissingFiles) == 0
+		shellStatus.UpToDate = shellStatus.Installed && len(shellStatus.StaleFiles) == 0
+		if !shellStatus.UpToDate {
+			rtn.Healthy = false
+		}
+		rtn.Shells = append(rtn.Shells, shellStatus)
+	}
+	return rtn
+}
+
+// checks the local (wave data dir) shell integration, if repair is set the files are rewritten when unhealthy
+func CheckLocalShellIntegration(repair bool) (*wshrpc.ShellIntegrationStatus, error) {
+	waveDataHome := wavebase.GetWaveDataDir()
+	binDir := filepath.Join(waveDataHome, WaveHomeBinDir)
+	status := CheckShellIntegration(waveDataHome, binDir)
+	if !repair || status.Healthy {
+		return status, nil
+	}
+	err := initCustomShellStartupFilesInternal()
+	if err != nil {
+		return nil, fmt.Errorf("error repairing shell integration: %w", err)
+	}
+	status = CheckShellIntegration(waveDataHome, binDir)
+	status.Repaired = true
+	return status, nil
+}
+
+// set in the environment of a non-login bash, the bash integration rc file then sources ~/.bashrc instead of the profile files
+const WaveLoginShellVarName = "WAVETERM_LOGINSHELL"
+
+// set by the swap token, the shell integration sources this file (term:rcfile) after the user's startup files
+const WaveRcFileVarName = "WAVETERM_RCFILE"
+
+// returns the extra args and env vars needed to start an interactive local shell with wave's shell integration.
+// rcFile is only handled here for nu (which cannot source a file named by an env var), see WaveRcFileVarName.
+func GetLocalShellIntegrationOpts(shellType string, login bool, interactive bool, rcFile string) ([]string, map[string]string) {
+	var opts []string
+	var env map[string]string
+	switch shellType {
+	case ShellType_bash:
+		// cant set -l or -i with --rcfile, the rc file sources the profile files itself for a login shell
+		opts = append(opts, "--rcfile", GetLocalBashRcFileOverride())
+		if !login {
+			env = map[string]string{WaveLoginShellVarName: "0"}
+		}
+	case ShellType_zsh:
+		if login {
+			opts = append(opts, "-l")
+		}
+		if interactive {
+			opts = append(opts, "-i")
+		}
+		env = map[string]string{"ZDOTDIR": GetLocalZshZDotDir()}
+	case ShellType_fish:
+		if login {
+			opts = append(opts, "-l")
+		}
+		if interactive {
+			opts = append(opts, "-i")
+		}
+		opts = append(opts, "-C", fmt.Sprintf("source %s", HardQuoteFish(GetLocalWaveFishFilePath())))
+	case ShellType_pwsh:
+		// -Login must be the first arg, and does nothing on windows
+		if login && runtime.GOOS != "windows" {
+			opts = append(opts, "-Login")
+		}
+		opts = append(opts, "-ExecutionPolicy", "Bypass", "-NoExit", "-File", GetLocalWavePowershellEnv())
+	case ShellType_nu:
+		if login {
+			opts = append(opts, "-l")
+		}
+		if interactive {
+			opts = append(opts, "-i")
+		}
+		sourceCmd := fmt.Sprintf("source %s", HardQuoteNu(GetLocalWaveNuFilePath()))
+		if rcFile != "" {
+			sourceCmd += fmt.Sprintf("; source %s", HardQuoteNu(rcFile))
+		}
+		opts = append(opts, "-e", sourceCmd)
+	case ShellType_cmd:
+		// cmd.exe has no rc file, so switch to the utf-8 code page and add wsh to the path on startup.
+		// args are kept free of spaces and quotes so they pass through windows command line escaping untouched
+		opts = append(opts, "/K", "chcp", "65001", ">nul", "&", "set", "PATH=%WAVETERM_WSHBINDIR%;%PATH%")
+	default:
+		if login {
+			opts = append(opts, "-l")
+		}
+		if interactive {
+			opts = append(opts, "-i")
+		}
+	}
+	return opts, env
+}
@@ -0,0 +1,84 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+package shellutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func findShellStatus(t *testing.T, waveHome string, binDir string, shellType string) (bool, []string, []string) {
+	status := CheckShellIntegration(waveHome, binDir)
+	for _, shellStatus := range status.Shells {
+		if shellStatus.ShellType == shellType {
+			return shellStatus.UpToDate, shellStatus.MissingFiles, shellStatus.StaleFiles
+		}
+	}
+	t.Fatalf("no status for shell %q", shellType)
+	return false, nil, nil
+}
+
+func TestCheckShellIntegration(t *testing.T) {
+	waveHome := t.TempDir()
+	binDir := filepath.Join(waveHome, "bin")
+	status := CheckShellIntegration(waveHome, binDir)
+	if status.Healthy || status.WshInstalled {
+		t.Fatalf("empty wave home should not be healthy: %+v", status)
+	}
+	if len(status.Shells) != len(shellIntegrations) {
+		t.Fatalf("got %d shell statuses, want %d", len(status.Shells), len(shellIntegrations))
+	}
+
+	if err := InitRcFiles(waveHome, binDir); err != nil {
+		t.Fatalf("InitRcFiles: %v", err)
+	}
+	for _, si := range shellIntegrations {
+		upToDate, missing, stale := findShellStatus(t, waveHome, binDir, si.ShellType)
+		if !upToDate || len(missing) > 0 || len(stale) > 0 {
+			t.Errorf("%s: uptodate:%v missing:%v stale:%v", si.ShellType, upToDate, missing, stale)
+		}
+	}
+
+	bashrc := filepath.Join(waveHome, BashIntegrationDir, ".bashrc")
+	if err := os.WriteFile(bashrc, []byte("# old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if upToDate, _, stale := findShellStatus(t, waveHome, binDir, ShellType_bash); upToDate || len(stale) != 1 {
+		t.Errorf("bash: expected stale .bashrc, got uptodate:%v stale:%v", upToDate, stale)
+	}
+
+	if err := os.Remove(filepath.Join(waveHome, FishIntegrationDir, "wave.fish")); err != nil {
+		t.Fatal(err)
+	}
+	if upToDate, missing, _ := findShellStatus(t, waveHome, binDir, ShellType_fish); upToDate || len(missing) != 1 {
+		t.Errorf("fish: expected missing wave.fish, got uptodate:%v missing:%v", upToDate, missing)
+	}
+
+	// a different bin dir means the files point at the wrong wsh
+	if upToDate, _, stale := findShellStatus(t, waveHome, filepath.Join(waveHome, "otherbin"), ShellType_zsh); upToDate || len(stale) == 0 {
+		t.Errorf("zsh: expected stale files for a moved bin dir, got uptodate:%v stale:%v", upToDate, stale)
+	}
+}
+
+func TestGetLocalShellIntegrationOpts(t *testing.T) {
+	opts, env := GetLocalShellIntegrationOpts(ShellType_bash, true, true, "")
+	if len(opts) != 2 || opts[0] != "--rcfile" || env[WaveLoginShellVarName] != "" {
+		t.Errorf("bash login: opts:%v env:%v", opts, env)
+	}
+	_, env = GetLocalShellIntegrationOpts(ShellType_bash, false, true, "")
+	if env[WaveLoginShellVarName] != "0" {
+		t.Errorf("bash non-login: expected %s=0, got env:%v", WaveLoginShellVarName, env)
+	}
+
+	opts, _ = GetLocalShellIntegrationOpts(ShellType_zsh, false, true, "")
+	if len(opts) != 1 || opts[0] != "-i" {
+		t.Errorf("zsh non-login: opts:%v", opts)
+	}
+
+	opts, _ = GetLocalShellIntegrationOpts(ShellType_nu, true, false, "/tmp/rc.nu")
+	if len(opts) != 3 || opts[0] != "-l" || opts[1] != "-e" || !strings.HasSuffix(opts[2], "; source /tmp/rc.nu") {
+		t.Errorf("nu rcfile: opts:%v", opts)
+	}
+}
@@ -6,6 +6,7 @@ package shellutil
 import (
 	"log"
 	"regexp"
+	"strings"
 )
 
 const (
@@ -110,6 +111,31 @@ func HardQuotePowerShell(s string) string {
 	return string(buf)
 }
 
+// nushell single-quoted strings have no escapes, so strings containing a single quote
+// use a raw string (r#'...'#) with enough #s that the terminator does not appear in s
+func HardQuoteNu(s string) string {
+	if s == "" {
+		return "''"
+	}
+
+	if safePattern.MatchString(s) {
+		return s
+	}
+
+	if !checkQuoteSize(s) {
+		return ""
+	}
+
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	hashes := "#"
+	for strings.Contains(s, "'"+hashes) {
+		hashes += "#"
+	}
+	return "r" + hashes + "'" + s + "'" + hashes
+}
+
 func SoftQuote(s string) string {
 	if s == "" {
 		return "\"\""
@@ -108,3 +108,22 @@ func TestQuote(t *testing.T) {
 		})
 	}
 }
+
+func TestHardQuoteNu(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "''"},
+		{"path/to/file.txt", "path/to/file.txt"},
+		{"spaces $here", "'spaces $here'"},
+		{"it's", "r#'it's'#"},
+		{"a '# b", "r##'a '# b'##"},
+	}
+
+	for _, tt := range tests {
+		if got := HardQuoteNu(tt.input); got != tt.want {
+			t.Errorf("HardQuoteNu(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/util/pamparse"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
@@ -30,6 +31,9 @@ var cachedMacUserShell string
 var macUserShellOnce = &sync.Once{}
 var userShellRegexp = regexp.MustCompile(`^UserShell: (.*)$`)
 
+var cachedPasswdUserShell string
+var passwdUserShellOnce = &sync.Once{}
+
 const DefaultShellPath = "/bin/bash"
 
 const (
@@ -37,6 +41,8 @@ const (
 	ShellType_zsh     = "zsh"
 	ShellType_fish    = "fish"
 	ShellType_pwsh    = "pwsh"
+	ShellType_cmd     = "cmd"
+	ShellType_nu      = "nu"
 	ShellType_unknown = "unknown"
 )
 
@@ -46,6 +52,7 @@ const (
 	BashIntegrationDir = "shell/bash"
 	PwshIntegrationDir = "shell/pwsh"
 	FishIntegrationDir = "shell/fish"
+	NuIntegrationDir   = "shell/nu"
 	WaveHomeBinDir     = "bin"
 
 	ZshStartup_Zprofile = `
@@ -65,6 +72,13 @@ if [ "$ZDOTDIR" = "$WAVETERM_ZDOTDIR" ]; then
   [ -f ~/.zshrc ] && source ~/.zshrc
 fi
 
+# Source the block's rc file (term:rcfile)
+if [[ -n $WAVETERM_RCFILE ]]; then
+  WAVETERM_RCFILE="${WAVETERM_RCFILE/#\~/$HOME}"
+  [ -f "$WAVETERM_RCFILE" ] && source "$WAVETERM_RCFILE"
+fi
+unset WAVETERM_RCFILE
+
 if [[ ":$PATH:" != *":$WAVETERM_WSHBINDIR:"* ]]; then
   export PATH="$WAVETERM_WSHBINDIR:$PATH"
 fi
@@ -73,6 +87,36 @@ unset WAVETERM_WSHBINDIR
 if [[ -n ${_comps+x} ]]; then
   source <(wsh completion zsh)
 fi
+
+# report the working directory (OSC 7) and mark prompt/command boundaries (OSC 133) for wave
+_waveterm_si_precmd() {
+  local _waveterm_si_status=$?
+  if [[ -n $_waveterm_si_cmdrunning ]]; then
+    printf '\033]133;D;%s\007' "$_waveterm_si_status"
+    unset _waveterm_si_cmdrunning
+  fi
+  printf '\033]7;file://%s%s\007' "$HOST" "$PWD"
+  printf '\033]133;A\007'
+  if [[ $PS1 != *$'\e]133;B\a'* ]]; then
+    PS1="$PS1"$'%{\e]133;B\a%}'
+  fi
+}
+# the command line is sent url encoded (only the chars that would break the sequence are escaped)
+_waveterm_si_urlencode() {
+  local s=${1//\%/%25}
+  s=${s//;/%3B}
+  s=${s//$'\n'/%0A}
+  s=${s//$'\e'/%1B}
+  s=${s//$'\a'/%07}
+  printf '%s' "$s"
+}
+_waveterm_si_preexec() {
+  _waveterm_si_cmdrunning=1
+  printf '\033]133;C;cmdline_url=%s\007' "$(_waveterm_si_urlencode "$1")"
+}
+# precmd must run first to see the exit status of the command
+precmd_functions=(_waveterm_si_precmd $precmd_functions)
+preexec_functions+=(_waveterm_si_preexec)
 `
 
 	ZshStartup_Zlogin = `
@@ -102,8 +146,8 @@ fi
 
 	BashStartup_Bashrc = `
 
-# Source /etc/profile if it exists
-if [ -f /etc/profile ]; then
+# Source /etc/profile if it exists (login shells only, WAVETERM_LOGINSHELL is set to 0 for a non-login shell)
+if [ "$WAVETERM_LOGINSHELL" != "0" ] && [ -f /etc/profile ]; then
     . /etc/profile
 fi
 
@@ -116,14 +160,24 @@ export PATH="$WAVETERM_WSHBINDIR:$PATH"
 eval "$(wsh token "$WAVETERM_SWAPTOKEN" bash 2> /dev/null)"
 unset WAVETERM_SWAPTOKEN
 
-# Source the first of ~/.bash_profile, ~/.bash_login, or ~/.profile that exists
-if [ -f ~/.bash_profile ]; then
+# Source the first of ~/.bash_profile, ~/.bash_login, or ~/.profile that exists (or ~/.bashrc for a non-login shell)
+if [ "$WAVETERM_LOGINSHELL" = "0" ]; then
+    [ -f ~/.bashrc ] && . ~/.bashrc
+elif [ -f ~/.bash_profile ]; then
     . ~/.bash_profile
 elif [ -f ~/.bash_login ]; then
     . ~/.bash_login
 elif [ -f ~/.profile ]; then
     . ~/.profile
 fi
+unset WAVETERM_LOGINSHELL
+
+# Source the block's rc file (term:rcfile)
+if [ -n "$WAVETERM_RCFILE" ]; then
+    WAVETERM_RCFILE="${WAVETERM_RCFILE/#\~/$HOME}"
+    [ -f "$WAVETERM_RCFILE" ] && . "$WAVETERM_RCFILE"
+fi
+unset WAVETERM_RCFILE
 
 if [[ ":$PATH:" != *":$WAVETERM_WSHBINDIR:"* ]]; then
     export PATH="$WAVETERM_WSHBINDIR:$PATH"
@@ -133,6 +187,43 @@ if type _init_completion &>/dev/null; then
   source <(wsh completion bash)
 fi
 
+# report the working directory (OSC 7) and mark prompt/command boundaries (OSC 133) for wave
+# D is sent before every prompt, wave ignores it if no command was run
+_waveterm_si_precmd() {
+    local _waveterm_si_status=$?
+    printf '\033]133;D;%s\007' "$_waveterm_si_status"
+    printf '\033]7;file://%s%s\007' "$HOSTNAME" "$PWD"
+    printf '\033]133;A\007'
+    if [[ "$PS1" != *'\033]133;B\007'* ]]; then
+        PS1="$PS1"'\[\033]133;B\007\]'
+    fi
+}
+# must be first to see the exit status of the command
+if [[ ";$PROMPT_COMMAND;" != *";_waveterm_si_precmd;"* ]]; then
+    PROMPT_COMMAND="_waveterm_si_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+_waveterm_si_urlencode() {
+    local s=${1//\%/%25}
+    s=${s//;/%3B}
+    s=${s//$'\n'/%0A}
+    s=${s//$'\e'/%1B}
+    s=${s//$'\a'/%07}
+    printf '%s' "$s"
+}
+# runs in a subshell from PS0, the command line comes from history (so it is missing if history is disabled)
+_waveterm_si_preexec() {
+    local cmd
+    cmd=$(HISTTIMEFORMAT= builtin history 1 2>/dev/null)
+    if [[ $cmd =~ ^[[:space:]]*[0-9]+[*]?[[:space:]]+(.*)$ ]]; then
+        printf '\033]133;C;cmdline_url=%s\007' "$(_waveterm_si_urlencode "${BASH_REMATCH[1]}")"
+    else
+        printf '\033]133;C\007'
+    fi
+}
+if [[ "$PS0" != *'_waveterm_si_preexec'* ]]; then
+    PS0="$PS0"'$(_waveterm_si_preexec)'
+fi
+
 `
 
 	FishStartup_Wavefish = `
@@ -146,10 +237,33 @@ set -e WAVETERM_SWAPTOKEN
 
 # Load Wave completions
 wsh completion fish | source
+
+# Source the block's rc file (term:rcfile)
+if set -q WAVETERM_RCFILE
+    set -l waveterm_rcfile (string replace -r '^~' $HOME -- $WAVETERM_RCFILE)
+    test -f $waveterm_rcfile; and source $waveterm_rcfile
+    set -e WAVETERM_RCFILE
+end
+
+# report the working directory (OSC 7) and mark prompt/command boundaries (OSC 133) for wave
+function _waveterm_si_prompt --on-event fish_prompt
+    printf '\e]7;file://%s%s\a' $hostname $PWD
+    printf '\e]133;A\a'
+end
+function _waveterm_si_preexec --on-event fish_preexec
+    printf '\e]133;C;cmdline_url=%s\a' (string escape --style=url -- $argv[1])
+end
+function _waveterm_si_postexec --on-event fish_postexec
+    printf '\e]133;D;%s\a' $status
+end
 `
 
 	PwshStartup_wavepwsh = `
 # We source this file with -NoExit -File
+# conpty output is utf-8, make sure native command output is decoded/encoded to match (windows powershell defaults to the oem code page)
+[Console]::OutputEncoding = [System.Text.UTF8Encoding]::new($false)
+$OutputEncoding = [Console]::OutputEncoding
+
 $env:PATH = {{.WSHBINDIR_PWSH}} + "{{.PATHSEP}}" + $env:PATH
 
 # Source dynamic script from wsh token
@@ -162,6 +276,50 @@ Remove-Item Env:WAVETERM_SWAPTOKEN
 
 # Load Wave completions
 wsh completion powershell | Out-String | Invoke-Expression
+
+# Source the block's rc file (term:rcfile)
+if ($env:WAVETERM_RCFILE) {
+    $waveterm_rcfile = $env:WAVETERM_RCFILE -replace '^~', $HOME
+    Remove-Item Env:WAVETERM_RCFILE
+    if (Test-Path $waveterm_rcfile) {
+        . $waveterm_rcfile
+    }
+    Remove-Variable -Name waveterm_rcfile
+}
+
+# report the working directory to wave (OSC 7) before each prompt
+$global:_waveterm_si_origprompt = $function:prompt
+function global:prompt {
+    $waveterm_loc = $executionContext.SessionState.Path.CurrentLocation
+    if ($waveterm_loc.Provider.Name -eq "FileSystem") {
+        $waveterm_path = $waveterm_loc.ProviderPath -replace '\\', '/'
+        if (-not $waveterm_path.StartsWith("/")) {
+            $waveterm_path = "/" + $waveterm_path
+        }
+        [Console]::Write("$([char]27)]7;file://$([System.Net.Dns]::GetHostName())$waveterm_path$([char]7)")
+    }
+    & $global:_waveterm_si_origprompt
+}
+`
+
+	NuStartup_Wavenu = `
+# We source this file with --execute, add Wave binary directory to PATH
+let waveterm_pathkey = if ($nu.os-info.name == "windows") { "Path" } else { "PATH" }
+load-env ({} | insert $waveterm_pathkey ($env | get $waveterm_pathkey | prepend {{.WSHBINDIR_NU}}))
+
+# nushell cannot source a dynamic script, so wsh token returns the env vars as a json record
+if "WAVETERM_SWAPTOKEN" in $env {
+    let waveterm_swaptoken_output = (wsh token $env.WAVETERM_SWAPTOKEN nu | complete)
+    if $waveterm_swaptoken_output.exit_code == 0 {
+        $waveterm_swaptoken_output.stdout | from json | load-env
+    }
+    hide-env WAVETERM_SWAPTOKEN
+}
+
+# report the working directory to wave (OSC 7) before each prompt
+$env.config = ($env.config | upsert hooks.pre_prompt (($env.config.hooks.pre_prompt? | default []) | append {||
+    print -n $"\e]7;file://($env.PWD)\a"
+}))
 `
 )
 
@@ -176,6 +334,9 @@ func DetectLocalShellPath() string {
 		return "powershell.exe"
 	}
 	shellPath := GetMacUserShell()
+	if shellPath == "" {
+		shellPath = GetPasswdUserShell()
+	}
 	if shellPath == "" {
 		shellPath = os.Getenv("SHELL")
 	}
@@ -217,6 +378,48 @@ func internalMacUserShell() string {
 	return m[1]
 }
 
+// returns the login shell from the passwd database (linux and other non-mac unixes)
+// returns "" if it cannot be determined or is not a usable shell (e.g. nologin)
+func GetPasswdUserShell() string {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return ""
+	}
+	passwdUserShellOnce.Do(func() {
+		cachedPasswdUserShell = internalPasswdUserShell()
+	})
+	return cachedPasswdUserShell
+}
+
+// getent also covers users from ldap/sssd, falls back to reading /etc/passwd directly
+func internalPasswdUserShell() string {
+	var shellPath string
+	osUser, err := user.Current()
+	if err == nil {
+		ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancelFn()
+		out, err := exec.CommandContext(ctx, "getent", "passwd", osUser.Username).Output()
+		if err == nil {
+			parts := strings.Split(strings.TrimSpace(string(out)), ":")
+			if len(parts) >= 7 {
+				shellPath = parts[6]
+			}
+		}
+	}
+	if shellPath == "" {
+		if opts := pamparse.ParsePasswdSafe(); opts != nil {
+			shellPath = opts.Shell
+		}
+	}
+	shellPath = strings.TrimSpace(shellPath)
+	if shellPath == "" || strings.HasSuffix(shellPath, "/nologin") || strings.HasSuffix(shellPath, "/false") {
+		return ""
+	}
+	if _, err := os.Stat(shellPath); err != nil {
+		return ""
+	}
+	return shellPath
+}
+
 func DefaultTermSize() waveobj.TermSize {
 	return waveobj.TermSize{Rows: DefaultTermRows, Cols: DefaultTermCols}
 }
@@ -227,6 +430,7 @@ func WaveshellLocalEnvVars(termType string) map[string]string {
 		rtn["TERM"] = termType
 	}
 	// these are not necessary since they should be set with the swap token, but no harm in setting them here
+	rtn["COLORTERM"] = DefaultColorTerm
 	rtn["TERM_PROGRAM"] = "waveterm"
 	rtn["WAVETERM"], _ = os.Executable()
 	rtn["WAVETERM_VERSION"] = wavebase.WaveVersion
@@ -293,6 +497,10 @@ func GetLocalWavePowershellEnv() string {
 	return filepath.Join(wavebase.GetWaveDataDir(), PwshIntegrationDir, "wavepwsh.ps1")
 }
 
+func GetLocalWaveNuFilePath() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), NuIntegrationDir, "wave.nu")
+}
+
 func GetLocalZshZDotDir() string {
 	return filepath.Join(wavebase.GetWaveDataDir(), ZshIntegrationDir)
 }
@@ -317,74 +525,6 @@ func GetLocalWshBinaryPath(version string, goos string, goarch string) (string,
 
 // absWshBinDir must be an absolute, expanded path (no ~ or $HOME, etc.)
 // it will be hard-quoted appropriately for the shell
-func InitRcFiles(waveHome string, absWshBinDir string) error {
-	// ensure directories exist
-	zshDir := filepath.Join(waveHome, ZshIntegrationDir)
-	err := wavebase.CacheEnsureDir(zshDir, ZshIntegrationDir, 0755, ZshIntegrationDir)
-	if err != nil {
-		return err
-	}
-	bashDir := filepath.Join(waveHome, BashIntegrationDir)
-	err = wavebase.CacheEnsureDir(bashDir, BashIntegrationDir, 0755, BashIntegrationDir)
-	if err != nil {
-		return err
-	}
-	fishDir := filepath.Join(waveHome, FishIntegrationDir)
-	err = wavebase.CacheEnsureDir(fishDir, FishIntegrationDir, 0755, FishIntegrationDir)
-	if err != nil {
-		return err
-	}
-	pwshDir := filepath.Join(waveHome, PwshIntegrationDir)
-	err = wavebase.CacheEnsureDir(pwshDir, PwshIntegrationDir, 0755, PwshIntegrationDir)
-	if err != nil {
-		return err
-	}
-
-	var pathSep string
-	if runtime.GOOS == "windows" {
-		pathSep = ";"
-	} else {
-		pathSep = ":"
-	}
-	params := map[string]string{
-		"WSHBINDIR":      HardQuote(absWshBinDir),
-		"WSHBINDIR_PWSH": HardQuotePowerShell(absWshBinDir),
-		"PATHSEP":        pathSep,
-	}
-
-	// write files to directory
-	err = utilfn.WriteTemplateToFile(filepath.Join(zshDir, ".zprofile"), ZshStartup_Zprofile, params)
-	if err != nil {
-		return fmt.Errorf("error writing zsh-integration .zprofile: %v", err)
-	}
-	err = utilfn.WriteTemplateToFile(filepath.Join(zshDir, ".zshrc"), ZshStartup_Zshrc, params)
-	if err != nil {
-		return fmt.Errorf("error writing zsh-integration .zshrc: %v", err)
-	}
-	err = utilfn.WriteTemplateToFile(filepath.Join(zshDir, ".zlogin"), ZshStartup_Zlogin, params)
-	if err != nil {
-		return fmt.Errorf("error writing zsh-integration .zlogin: %v", err)
-	}
-	err = utilfn.WriteTemplateToFile(filepath.Join(zshDir, ".zshenv"), ZshStartup_Zshenv, params)
-	if err != nil {
-		return fmt.Errorf("error writing zsh-integration .zshenv: %v", err)
-	}
-	err = utilfn.WriteTemplateToFile(filepath.Join(bashDir, ".bashrc"), BashStartup_Bashrc, params)
-	if err != nil {
-		return fmt.Errorf("error writing bash-integration .bashrc: %v", err)
-	}
-	err = utilfn.WriteTemplateToFile(filepath.Join(fishDir, "wave.fish"), FishStartup_Wavefish, params)
-	if err != nil {
-		return fmt.Errorf("error writing fish-integration wave.fish: %v", err)
-	}
-	err = utilfn.WriteTemplateToFile(filepath.Join(pwshDir, "wavepwsh.ps1"), PwshStartup_wavepwsh, params)
-	if err != nil {
-		return fmt.Errorf("error writing pwsh-integration wavepwsh.ps1: %v", err)
-	}
-
-	return nil
-}
-
 func initCustomShellStartupFilesInternal() error {
 	log.Printf("initializing wsh and shell startup files\n")
 	waveDataHome := wavebase.GetWaveDataDir()
@@ -421,6 +561,22 @@ func initCustomShellStartupFilesInternal() error {
 	return nil
 }
 
+// returns the arguments needed to have the shell at shellPath print the value of an env var
+func GetPrintEnvVarArgs(shellPath string, varName string) []string {
+	switch GetShellTypeFromShellPath(shellPath) {
+	case ShellType_pwsh:
+		return []string{"-NoProfile", "-Command", "$env:" + varName}
+	case ShellType_cmd:
+		return []string{"/C", "echo %" + varName + "%"}
+	case ShellType_nu:
+		return []string{"-c", fmt.Sprintf("$env.%s? | default ''", varName)}
+	case ShellType_fish:
+		return []string{"-c", "echo $" + varName}
+	default:
+		return []string{"-c", "echo ${" + varName + "}"}
+	}
+}
+
 func GetShellTypeFromShellPath(shellPath string) string {
 	shellBase := filepath.Base(shellPath)
 	if strings.Contains(shellBase, "bash") {
@@ -435,5 +591,12 @@ func GetShellTypeFromShellPath(shellPath string) string {
 	if strings.Contains(shellBase, "pwsh") || strings.Contains(shellBase, "powershell") {
 		return ShellType_pwsh
 	}
+	shellName := strings.TrimSuffix(strings.ToLower(shellBase), ".exe")
+	if shellName == "cmd" {
+		return ShellType_cmd
+	}
+	if shellName == "nu" || shellName == "nushell" {
+		return ShellType_nu
+	}
 	return ShellType_unknown
 }
@@ -0,0 +1,153 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellutil
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// wave's terminfo entry (xterm-256color plus 24-bit color, cursor shapes, styled/colored underlines and bracketed
+// paste) is compiled into [wave home]/terminfo with tic when the shell integration files are written, which
+// happens for the local machine and for every remote connection with wsh.  term:termtype picks the TERM for a
+// block, it is set from the swap token (so on the host the shell runs on), and wsh token falls back to
+// xterm-256color if that host does not have the entry (e.g. tic is not installed).
+
+const (
+	WaveTermType       = "xterm-wave"
+	TerminfoDir        = "terminfo"
+	DefaultColorTerm   = "truecolor"
+	terminfoSrcName    = "xterm-wave.terminfo"
+	terminfoTicTimeout = 5 * time.Second
+)
+
+const WaveTerminfoSrc = `# terminfo entry for Wave Terminal (compiled with tic -x)
+xterm-wave|Wave Terminal,
+	Tc,
+	RGB,
+	setrgbf=\E[38;2;%p1%d;%p2%d;%p3%dm,
+	setrgbb=\E[48;2;%p1%d;%p2%d;%p3%dm,
+	Ss=\E[%p1%d q,
+	Se=\E[2 q,
+	Smulx=\E[4\:%p1%dm,
+	Setulc=\E[58\:2\:\:%p1%{65536}%/%d\:%p1%{256}%/%{255}%&%d\:%p1%{255}%&%dm,
+	BE=\E[?2004h,
+	BD=\E[?2004l,
+	PS=\E[200~,
+	PE=\E[201~,
+	use=xterm-256color,
+`
+
+// the standard terminfo locations, $TERMINFO and $TERMINFO_DIRS are checked first
+var systemTerminfoDirs = []string{
+	"/etc/terminfo",
+	"/lib/terminfo",
+	"/usr/share/terminfo",
+	"/usr/lib/terminfo",
+	"/usr/share/lib/terminfo",
+	"/usr/local/share/terminfo",
+	"/opt/homebrew/share/terminfo",
+}
+
+// writes the terminfo source into waveHome and compiles it.  hosts without tic are skipped (not an error), the
+// blocks on them use xterm-256color (see FixTermEnv).
+func InstallTerminfo(waveHome string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	dir := filepath.Join(waveHome, TerminfoDir)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("error creating terminfo dir: %w", err)
+	}
+	srcPath := filepath.Join(dir, terminfoSrcName)
+	err = os.WriteFile(srcPath, []byte(WaveTerminfoSrc), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing terminfo source: %w", err)
+	}
+	ticPath, err := exec.LookPath("tic")
+	if err != nil {
+		log.Printf("tic not found, not installing the %s terminfo entry\n", WaveTermType)
+		return nil
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), terminfoTicTimeout)
+	defer cancelFn()
+	output, err := exec.CommandContext(ctx, ticPath, "-x", "-o", dir, srcPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error compiling terminfo entry: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// compiled entries are in [dir]/[first char]/[name], or [dir]/[hex of first char]/[name] (macos)
+func terminfoDirHasEntry(dir string, termType string) bool {
+	if dir == "" || termType == "" {
+		return false
+	}
+	for _, subDir := range []string{termType[:1], fmt.Sprintf("%x", termType[0])} {
+		if finfo, err := os.Stat(filepath.Join(dir, subDir, termType)); err == nil && !finfo.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+func splitTerminfoDirs(dirs string) []string {
+	var rtn []string
+	for _, dir := range strings.Split(dirs, ":") {
+		if dir != "" {
+			rtn = append(rtn, dir)
+		}
+	}
+	return rtn
+}
+
+// returns whether termType can be found on this host (waveTerminfoDir is searched too since the shell will have
+// it in TERMINFO_DIRS)
+func HasTerminfoEntry(termType string, waveTerminfoDir string) bool {
+	var dirs []string
+	dirs = append(dirs, os.Getenv("TERMINFO"), waveTerminfoDir)
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, ".terminfo"))
+	}
+	dirs = append(dirs, splitTerminfoDirs(os.Getenv("TERMINFO_DIRS"))...)
+	dirs = append(dirs, systemTerminfoDirs...)
+	for _, dir := range dirs {
+		if terminfoDirHasEntry(dir, termType) {
+			return true
+		}
+	}
+	// some systems use a hashed database, infocmp knows where to look
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	return exec.CommandContext(ctx, "infocmp", termType).Run() == nil
+}
+
+// called on the host the shell runs on (wsh token) with the env from the swap token.  adds wave's terminfo dir
+// to TERMINFO_DIRS and falls back to xterm-256color if TERM is not known on this host.
+func FixTermEnv(env map[string]string, waveHome string) {
+	termType := env["TERM"]
+	if termType == "" || termType == DefaultTermType || runtime.GOOS == "windows" {
+		return
+	}
+	var waveTerminfoDir string
+	if waveHome != "" {
+		waveTerminfoDir = filepath.Join(waveHome, TerminfoDir)
+	}
+	if !HasTerminfoEntry(termType, waveTerminfoDir) {
+		env["TERM"] = DefaultTermType
+		return
+	}
+	if terminfoDirHasEntry(waveTerminfoDir, termType) {
+		// an empty entry is the system default location
+		env["TERMINFO_DIRS"] = waveTerminfoDir + ":" + os.Getenv("TERMINFO_DIRS")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+package shellutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFixTermEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("TERM is not changed on windows")
+	}
+	t.Setenv("TERMINFO_DIRS", "")
+	waveHome := t.TempDir()
+
+	env := map[string]string{"TERM": "wave-no-such-term"}
+	FixTermEnv(env, waveHome)
+	if env["TERM"] != DefaultTermType {
+		t.Errorf("missing entry: TERM = %q, want %q", env["TERM"], DefaultTermType)
+	}
+
+	// macos stores entries under the hex value of the first char
+	entryPath := filepath.Join(waveHome, TerminfoDir, "77", "wave-test-term")
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entryPath, []byte("compiled"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env = map[string]string{"TERM": "wave-test-term"}
+	FixTermEnv(env, waveHome)
+	if env["TERM"] != "wave-test-term" {
+		t.Errorf("installed entry: TERM = %q", env["TERM"])
+	}
+	if !strings.HasPrefix(env["TERMINFO_DIRS"], filepath.Join(waveHome, TerminfoDir)+":") {
+		t.Errorf("TERMINFO_DIRS = %q, expected the wave terminfo dir first", env["TERMINFO_DIRS"])
+	}
+
+	env = map[string]string{"TERM": DefaultTermType}
+	FixTermEnv(env, waveHome)
+	if env["TERM"] != DefaultTermType || env["TERMINFO_DIRS"] != "" {
+		t.Errorf("default TERM should be left alone: %v", env)
+	}
+}
@@ -135,6 +135,21 @@ func encodeEnvVarsForPowerShell(env map[string]string) (string, error) {
 	return encoded, nil
 }
 
+// nushell cannot eval a dynamic script, so env vars are sent as a json record (applied with load-env)
+func encodeEnvVarsForNu(env map[string]string) (string, error) {
+	for k := range env {
+		// validate key
+		if !IsValidEnvVarName(k) {
+			return "", fmt.Errorf("invalid env var name: %q", k)
+		}
+	}
+	barr, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+	return string(barr), nil
+}
+
 func EncodeEnvVarsForShell(shellType string, env map[string]string) (string, error) {
 	switch shellType {
 	case ShellType_bash, ShellType_zsh:
@@ -143,6 +158,8 @@ func EncodeEnvVarsForShell(shellType string, env map[string]string) (string, err
 		return encodeEnvVarsForFish(env)
 	case ShellType_pwsh:
 		return encodeEnvVarsForPowerShell(env)
+	case ShellType_nu:
+		return encodeEnvVarsForNu(env)
 	default:
 		return "", fmt.Errorf("unknown or unsupported shell type for env var encoding: %s", shellType)
 	}
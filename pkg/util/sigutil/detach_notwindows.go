@@ -0,0 +1,24 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sigutil
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// keeps SIGHUP, SIGINT and SIGPIPE from stopping a process that outlives its parent (the pty host, the roam server).
+// the signals are caught and dropped, not ignored: an ignored signal stays ignored across exec, so the shell and
+// everything it runs would ignore them too, a caught one is reset to its default in the child.
+func InstallDetachSignalHandlers() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGPIPE)
+	go func() {
+		for range sigCh {
+		}
+	}()
+}
@@ -0,0 +1,31 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sigutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// signals that can be sent to a block's processes
+const (
+	Signal_Int   = "SIGINT"
+	Signal_Term  = "SIGTERM"
+	Signal_Hup   = "SIGHUP"
+	Signal_Kill  = "SIGKILL"
+	Signal_Break = "SIGBREAK" // ctrl-break, windows only
+)
+
+// accepts "SIGINT", "INT", "int", etc. and returns the canonical name
+func NormalizeSignalName(name string) (string, error) {
+	sigName := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(sigName, "SIG") {
+		sigName = "SIG" + sigName
+	}
+	switch sigName {
+	case Signal_Int, Signal_Term, Signal_Hup, Signal_Kill, Signal_Break:
+		return sigName, nil
+	}
+	return "", fmt.Errorf("unsupported signal %q", name)
+}
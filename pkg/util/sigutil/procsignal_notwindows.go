@@ -0,0 +1,82 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sigutil
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func getSyscallSignal(sigName string) (syscall.Signal, error) {
+	switch sigName {
+	case Signal_Int:
+		return syscall.SIGINT, nil
+	case Signal_Term:
+		return syscall.SIGTERM, nil
+	case Signal_Hup:
+		return syscall.SIGHUP, nil
+	case Signal_Kill:
+		return syscall.SIGKILL, nil
+	}
+	return 0, fmt.Errorf("signal %s is not supported on this platform", sigName)
+}
+
+// returns the foreground process group of the terminal, or 0 if it can't be read.
+// uses SyscallConn because calling Fd() would put the pty back into blocking mode.
+func getForegroundPgid(ptyFile any) int {
+	sc, ok := ptyFile.(syscall.Conn)
+	if !ok {
+		return 0
+	}
+	rawConn, err := sc.SyscallConn()
+	if err != nil {
+		return 0
+	}
+	var pgid int
+	rawConn.Control(func(fd uintptr) {
+		pgid, err = unix.IoctlGetInt(int(fd), unix.TIOCGPGRP)
+	})
+	if err != nil {
+		return 0
+	}
+	return pgid
+}
+
+// signals the foreground process group of ptyFile (the running job, or the shell itself when it is idle).
+// jobs run in their own process groups, so this reaches the running command without going through the
+// terminal's line discipline.  falls back to pid's process group if the foreground group can't be read.
+func SignalPtyForeground(ptyFile any, pid int, sigName string) error {
+	sig, err := getSyscallSignal(sigName)
+	if err != nil {
+		return err
+	}
+	pgid := getForegroundPgid(ptyFile)
+	if pgid <= 0 {
+		pgid, err = syscall.Getpgid(pid)
+		if err != nil {
+			return fmt.Errorf("cannot get process group for pid %d: %w", pid, err)
+		}
+	}
+	err = syscall.Kill(-pgid, sig)
+	if err != nil {
+		return fmt.Errorf("error sending %s to process group %d: %w", sigName, pgid, err)
+	}
+	return nil
+}
+
+func SignalPid(pid int, sigName string) error {
+	sig, err := getSyscallSignal(sigName)
+	if err != nil {
+		return err
+	}
+	err = syscall.Kill(pid, sig)
+	if err != nil {
+		return fmt.Errorf("error sending %s to pid %d: %w", sigName, pid, err)
+	}
+	return nil
+}
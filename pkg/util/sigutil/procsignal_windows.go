@@ -0,0 +1,24 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sigutil
+
+import (
+	"fmt"
+	"os"
+)
+
+// a single windows process can only be terminated
+func SignalPid(pid int, sigName string) error {
+	switch sigName {
+	case Signal_Term, Signal_Hup, Signal_Kill:
+		proc, err := os.FindProcess(pid)
+		if err != nil {
+			return fmt.Errorf("process %d not found: %w", pid, err)
+		}
+		return proc.Kill()
+	}
+	return fmt.Errorf("signal %s cannot be sent to a single process on windows", sigName)
+}
@@ -0,0 +1,180 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// the tls config of a server whose certificate can change while it runs.  the cert/key files (and the client
+// ca file) are checked at most every ReloadCheckInterval when a connection comes in, and are reloaded when
+// their modification times change (so a renewed certificate is picked up without a restart).  if a reload
+// fails the previous certificate is kept.  a self-signed certificate is recreated when it is about to expire.
+// with a client ca file the server requires client certificates signed by it (mtls).
+
+const ReloadCheckInterval = 10 * time.Second
+const SelfSignedRenewBefore = 7 * 24 * time.Hour
+
+type ServerTLSOpts struct {
+	CertFile     string
+	KeyFile      string
+	SelfSigned   bool   // create a self-signed certificate in CertFile/KeyFile if they do not exist
+	ClientCAFile string // optional, requires client certificates signed by these cas
+	LogPrefix    string // for the reload log messages
+}
+
+type CertReloader struct {
+	lock      sync.Mutex
+	opts      ServerTLSOpts
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  [3]time.Time // cert, key, client ca
+	lastCheck time.Time
+}
+
+func getModTime(fileName string) time.Time {
+	if fileName == "" {
+		return time.Time{}
+	}
+	finfo, err := os.Stat(fileName)
+	if err != nil {
+		return time.Time{}
+	}
+	return finfo.ModTime()
+}
+
+func (cr *CertReloader) getModTimes() [3]time.Time {
+	return [3]time.Time{getModTime(cr.opts.CertFile), getModTime(cr.opts.KeyFile), getModTime(cr.opts.ClientCAFile)}
+}
+
+func loadClientCAs(caFile string) (*x509.CertPool, error) {
+	barr, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading client ca file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(barr) {
+		return nil, fmt.Errorf("no certificates found in client ca file %s", caFile)
+	}
+	return pool, nil
+}
+
+func needsRenewal(cert *tls.Certificate) bool {
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return false
+		}
+	}
+	return time.Until(leaf.NotAfter) < SelfSignedRenewBefore
+}
+
+func (cr *CertReloader) load_nolock() error {
+	modTimes := cr.getModTimes()
+	var cert *tls.Certificate
+	var err error
+	if cr.opts.SelfSigned {
+		cert, err = LoadOrCreateSelfSignedCert(cr.opts.CertFile, cr.opts.KeyFile)
+		if err == nil && needsRenewal(cert) {
+			os.Remove(cr.opts.CertFile)
+			os.Remove(cr.opts.KeyFile)
+			cert, err = LoadOrCreateSelfSignedCert(cr.opts.CertFile, cr.opts.KeyFile)
+		}
+		modTimes = cr.getModTimes()
+	} else {
+		var certVal tls.Certificate
+		certVal, err = tls.LoadX509KeyPair(cr.opts.CertFile, cr.opts.KeyFile)
+		if err != nil {
+			err = fmt.Errorf("error loading certificate: %w", err)
+		}
+		cert = &certVal
+	}
+	if err != nil {
+		return err
+	}
+	var clientCAs *x509.CertPool
+	if cr.opts.ClientCAFile != "" {
+		clientCAs, err = loadClientCAs(cr.opts.ClientCAFile)
+		if err != nil {
+			return err
+		}
+	}
+	cr.cert = cert
+	cr.clientCAs = clientCAs
+	cr.modTimes = modTimes
+	return nil
+}
+
+func (cr *CertReloader) checkReload() {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	if time.Since(cr.lastCheck) < ReloadCheckInterval {
+		return
+	}
+	cr.lastCheck = time.Now()
+	if cr.getModTimes() == cr.modTimes && !(cr.opts.SelfSigned && needsRenewal(cr.cert)) {
+		return
+	}
+	err := cr.load_nolock()
+	if err != nil {
+		log.Printf("%s error reloading certificate (keeping the previous one): %v\n", cr.opts.LogPrefix, err)
+		return
+	}
+	log.Printf("%s reloaded certificate (fingerprint %s)\n", cr.opts.LogPrefix, GetFingerprint(cr.cert))
+}
+
+// loads the certificate (and client cas), returns an error if they cannot be loaded
+func MakeCertReloader(opts ServerTLSOpts) (*CertReloader, error) {
+	cr := &CertReloader{opts: opts, lastCheck: time.Now()}
+	err := cr.load_nolock()
+	if err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+func (cr *CertReloader) GetCert() *tls.Certificate {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	return cr.cert
+}
+
+func (cr *CertReloader) RequiresClientCert() bool {
+	return cr.opts.ClientCAFile != ""
+}
+
+func (cr *CertReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	cr.checkReload()
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*cr.cert},
+		NextProtos:   []string{"http/1.1"},
+	}
+	if cr.clientCAs != nil {
+		config.ClientCAs = cr.clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// the server's tls config, the certificate and client cas are looked up (and reloaded) for each connection
+func (cr *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return cr.GetCert(), nil
+		},
+		GetConfigForClient: cr.getConfigForClient,
+	}
+}
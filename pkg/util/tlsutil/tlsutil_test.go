@@ -0,0 +1,95 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	cert, err := LoadOrCreateSelfSignedCert(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("error creating cert: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("error parsing cert: %v", err)
+	}
+	if err := leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("cert is not valid for localhost: %v", err)
+	}
+	if err := leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("cert is not valid for 127.0.0.1: %v", err)
+	}
+	if leaf.NotAfter.Before(time.Now().Add(SelfSignedValidFor - 24*time.Hour)) {
+		t.Errorf("cert expires too soon: %v", leaf.NotAfter)
+	}
+	// the second call loads the same cert
+	cert2, err := LoadOrCreateSelfSignedCert(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("error loading cert: %v", err)
+	}
+	fp := GetFingerprint(cert)
+	if fp != GetFingerprint(cert2) {
+		t.Errorf("fingerprint changed, the cert was recreated")
+	}
+	if len(strings.Split(fp, ":")) != 32 {
+		t.Errorf("bad fingerprint %q", fp)
+	}
+}
+
+func writeCert(t *testing.T, certFile string, keyFile string) {
+	certPem, keyPem, err := MakeSelfSignedCert("test", []string{"localhost"}, time.Hour*24*30)
+	if err != nil {
+		t.Fatalf("error making cert: %v", err)
+	}
+	os.WriteFile(certFile, certPem, 0644)
+	os.WriteFile(keyFile, keyPem, 0600)
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if _, err := MakeCertReloader(ServerTLSOpts{CertFile: certFile, KeyFile: keyFile}); err == nil {
+		t.Fatalf("missing cert files should be an error")
+	}
+	writeCert(t, certFile, keyFile)
+	cr, err := MakeCertReloader(ServerTLSOpts{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile})
+	if err != nil {
+		t.Fatalf("error loading cert: %v", err)
+	}
+	fp := GetFingerprint(cr.GetCert())
+	config, err := cr.getConfigForClient(nil)
+	if err != nil || config.ClientAuth != tls.RequireAndVerifyClientCert || config.ClientCAs == nil {
+		t.Fatalf("client certs should be required with a client ca file")
+	}
+	// a renewed cert is picked up at the next check
+	writeCert(t, certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	cr.lastCheck = time.Time{}
+	cr.checkReload()
+	if GetFingerprint(cr.GetCert()) == fp {
+		t.Errorf("the cert was not reloaded")
+	}
+	// a bad cert keeps the previous one
+	fp = GetFingerprint(cr.GetCert())
+	os.WriteFile(certFile, []byte("bad"), 0644)
+	os.Chtimes(certFile, future.Add(time.Minute), future.Add(time.Minute))
+	cr.lastCheck = time.Time{}
+	cr.checkReload()
+	if GetFingerprint(cr.GetCert()) != fp {
+		t.Errorf("a failed reload should keep the previous cert")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tomlutil reads the toml used by terminal configs (e.g. alacritty.toml): tables, arrays of tables, and
+// string, number, and boolean values, inline tables, and arrays of inline tables (which can span lines).  the
+// values are strings by their dotted key ("colors.primary.background"), the arrays of tables are flat maps (an
+// inline table in them has dotted keys too).  arrays of scalars and the lines it can't read are skipped.
+package tomlutil
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Table = map[string]string
+
+var keyValueRe = regexp.MustCompile(`^([A-Za-z0-9_.-]+|"[^"]*"|'[^']*')\s*=\s*(.*)$`)
+
+// returns the values by their dotted key, and the arrays of tables ([[key]] and key = [{...}, ...]) by their key
+func Parse(data []byte) (map[string]string, map[string][]Table) {
+	values := make(map[string]string)
+	arrays := make(map[string][]Table)
+	var section string
+	var arrayEntry Table // the current [[section]], if any
+	var pending string   // a multi-line array, until its brackets are closed
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(StripComment(line))
+		if line == "" {
+			continue
+		}
+		if pending != "" {
+			pending += " " + line
+			if !isBalanced(pending) {
+				continue
+			}
+			line, pending = pending, ""
+		} else if strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]") {
+			section = strings.TrimSpace(line[2 : len(line)-2])
+			arrayEntry = make(Table)
+			arrays[section] = append(arrays[section], arrayEntry)
+			continue
+		} else if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			arrayEntry = nil
+			continue
+		} else if !isBalanced(line) {
+			pending = line
+			continue
+		}
+		match := keyValueRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		key, rawVal := Scalar(match[1]), strings.TrimSpace(match[2])
+		if arrayEntry != nil {
+			setValue(arrayEntry, nil, key, rawVal)
+			continue
+		}
+		fullKey := key
+		if section != "" {
+			fullKey = section + "." + key
+		}
+		setValue(values, arrays, fullKey, rawVal)
+	}
+	return values, arrays
+}
+
+// sets a value (an inline table is set as its dotted keys, an array of inline tables is added to arrays)
+func setValue(values Table, arrays map[string][]Table, key string, rawVal string) {
+	switch {
+	case strings.HasPrefix(rawVal, "{") && strings.HasSuffix(rawVal, "}"):
+		for _, part := range splitTopLevel(rawVal[1 : len(rawVal)-1]) {
+			match := keyValueRe.FindStringSubmatch(part)
+			if match != nil {
+				setValue(values, arrays, key+"."+Scalar(match[1]), strings.TrimSpace(match[2]))
+			}
+		}
+	case strings.HasPrefix(rawVal, "[") && strings.HasSuffix(rawVal, "]"):
+		if arrays == nil {
+			return
+		}
+		for _, elem := range splitTopLevel(rawVal[1 : len(rawVal)-1]) {
+			if !strings.HasPrefix(elem, "{") {
+				continue
+			}
+			entry := make(Table)
+			setValue(entry, nil, "", elem)
+			flat := make(Table, len(entry))
+			for entryKey, val := range entry {
+				flat[strings.TrimPrefix(entryKey, ".")] = val
+			}
+			arrays[key] = append(arrays[key], flat)
+		}
+	default:
+		values[key] = Scalar(rawVal)
+	}
+}
+
+// splits the elements of an array or an inline table (by the commas that are not in strings, tables or arrays)
+func splitTopLevel(str string) []string {
+	var rtn []string
+	var quote byte
+	depth := 0
+	start := 0
+	for i := 0; i < len(str); i++ {
+		ch := str[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '{' || ch == '[':
+			depth++
+		case ch == '}' || ch == ']':
+			depth--
+		case ch == ',' && depth == 0:
+			if part := strings.TrimSpace(str[start:i]); part != "" {
+				rtn = append(rtn, part)
+			}
+			start = i + 1
+		}
+	}
+	if part := strings.TrimSpace(str[start:]); part != "" {
+		rtn = append(rtn, part)
+	}
+	return rtn
+}
+
+// if the brackets and braces of a line (outside of strings) are closed
+func isBalanced(line string) bool {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '{' || ch == '[':
+			depth++
+		case ch == '}' || ch == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// removes a # comment (that is not in a string) from a line
+func StripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0 && ch == '\\' && quote == '"':
+			i++
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote == 0 && (ch == '"' || ch == '\''):
+			quote = ch
+		case quote == 0 && ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// the value of a string (without its quotes, with the escapes of a "" string), or the raw value of a number or a
+// boolean
+func Scalar(rawVal string) string {
+	if len(rawVal) >= 2 && rawVal[0] == '"' && rawVal[len(rawVal)-1] == '"' {
+		if val, err := strconv.Unquote(rawVal); err == nil {
+			return val
+		}
+	}
+	if len(rawVal) >= 2 && (rawVal[0] == '"' || rawVal[0] == '\'') && rawVal[len(rawVal)-1] == rawVal[0] {
+		return rawVal[1 : len(rawVal)-1]
+	}
+	return rawVal
+}
@@ -0,0 +1,60 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package tomlutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testToml = `# a comment
+title = "a # not a comment" # a comment
+[window]
+padding = { x = 2, y = 3 }
+dimensions.columns = 80
+
+[[hints.enabled]]
+regex = '[a-z]+'
+binding = { key = "U", mods = "Control|Shift" }
+
+[[hints.enabled]]
+regex = "b"
+
+[keyboard]
+bindings = [
+  { key = "T", mods = "Command", action = "CreateNewTab" }, # new tab
+  { key = "\"", chars = "a,b" },
+]
+shells = ["zsh", "bash"]
+`
+
+func TestParse(t *testing.T) {
+	values, arrays := Parse([]byte(testToml))
+	expectedValues := map[string]string{
+		"title":                     "a # not a comment",
+		"window.padding.x":          "2",
+		"window.padding.y":          "3",
+		"window.dimensions.columns": "80",
+	}
+	if !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("expected values %v, got %v", expectedValues, values)
+	}
+	expectedHints := []Table{
+		{"regex": "[a-z]+", "binding.key": "U", "binding.mods": "Control|Shift"},
+		{"regex": "b"},
+	}
+	if !reflect.DeepEqual(arrays["hints.enabled"], expectedHints) {
+		t.Errorf("expected hints %v, got %v", expectedHints, arrays["hints.enabled"])
+	}
+	expectedBindings := []Table{
+		{"key": "T", "mods": "Command", "action": "CreateNewTab"},
+		{"key": `"`, "chars": "a,b"},
+	}
+	if !reflect.DeepEqual(arrays["keyboard.bindings"], expectedBindings) {
+		t.Errorf("expected bindings %v, got %v", expectedBindings, arrays["keyboard.bindings"])
+	}
+	if _, ok := arrays["keyboard.shells"]; ok {
+		t.Errorf("expected the array of strings to be skipped")
+	}
+}
@@ -846,10 +846,14 @@ func AtoiNoErr(str string) int {
 	return val
 }
 
-func WriteTemplateToFile(fileName string, templateText string, vars map[string]string) error {
+func RenderTemplate(templateText string, vars map[string]string) []byte {
 	outBuffer := &bytes.Buffer{}
 	template.Must(template.New("").Parse(templateText)).Execute(outBuffer, vars)
-	return os.WriteFile(fileName, outBuffer.Bytes(), 0644)
+	return outBuffer.Bytes()
+}
+
+func WriteTemplateToFile(fileName string, templateText string, vars map[string]string) error {
+	return os.WriteFile(fileName, RenderTemplate(templateText, vars), 0644)
 }
 
 // every byte is 4-bits of randomness
@@ -0,0 +1,166 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// registry of object actions (used by the frontend to build context menus and the command palette)
+package waction
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wi18n"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+type ActionHandler func(ctx context.Context, data wshrpc.CommandExecuteActionData) error
+
+type actionEntry struct {
+	Def     wshrpc.ActionDef
+	Handler ActionHandler
+}
+
+var globalLock = &sync.Mutex{}
+var actionMap = map[string]*actionEntry{} // actionid => entry
+
+func RegisterAction(def wshrpc.ActionDef, handler ActionHandler) error {
+	if def.ActionId == "" {
+		return fmt.Errorf("actionid cannot be empty")
+	}
+	if handler == nil {
+		return fmt.Errorf("action %q has no handler", def.ActionId)
+	}
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	// a plugin can register its own actions again (to update them), any other duplicate id is an error
+	existing := actionMap[def.ActionId]
+	if existing != nil && (def.PluginId == "" || existing.Def.PluginId != def.PluginId) {
+		return fmt.Errorf("action %q is already registered", def.ActionId)
+	}
+	actionMap[def.ActionId] = &actionEntry{Def: def, Handler: handler}
+	return nil
+}
+
+// for use in init() functions, panics on error
+func MustRegisterAction(def wshrpc.ActionDef, handler ActionHandler) {
+	err := RegisterAction(def, handler)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func UnregisterAction(actionId string) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	delete(actionMap, actionId)
+}
+
+func UnregisterPluginActions(pluginId string) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	for actionId, entry := range actionMap {
+		if entry.Def.PluginId == pluginId {
+			delete(actionMap, actionId)
+		}
+	}
+}
+
+func getEntry(actionId string) *actionEntry {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	return actionMap[actionId]
+}
+
+func (entry *actionEntry) appliesTo(oref waveobj.ORef, meta waveobj.MetaMapType) bool {
+	def := entry.Def
+	if len(def.OTypes) > 0 && !utilfn.ContainsStr(def.OTypes, oref.OType) {
+		return false
+	}
+	if oref.OType != waveobj.OType_Block {
+		return true
+	}
+	if len(def.Views) > 0 && !utilfn.ContainsStr(def.Views, meta.GetString(waveobj.MetaKey_View, "")) {
+		return false
+	}
+	if len(def.Controllers) > 0 && !utilfn.ContainsStr(def.Controllers, meta.GetString(waveobj.MetaKey_Controller, "")) {
+		return false
+	}
+	return true
+}
+
+// the title of an action is translated with the "action.<actionid>" key of the i18n catalogs
+func localizeDef(def wshrpc.ActionDef) wshrpc.ActionDef {
+	def.Title = wi18n.TOr("action."+def.ActionId, def.Title)
+	return def
+}
+
+func getObjMeta(ctx context.Context, oref waveobj.ORef) (waveobj.MetaMapType, error) {
+	obj, err := wstore.DBGetORef(ctx, oref)
+	if err != nil {
+		return nil, wi18n.Errorf("error.object.get", err)
+	}
+	if obj == nil {
+		return nil, wi18n.Errorf("error.object.notfound", oref)
+	}
+	return waveobj.GetMeta(obj), nil
+}
+
+// returns the actions that apply to the given object, sorted by order and then title
+func ListActions(ctx context.Context, oref waveobj.ORef) ([]wshrpc.ActionDef, error) {
+	meta, err := getObjMeta(ctx, oref)
+	if err != nil {
+		return nil, err
+	}
+	globalLock.Lock()
+	entries := make([]*actionEntry, 0, len(actionMap))
+	for _, entry := range actionMap {
+		entries = append(entries, entry)
+	}
+	globalLock.Unlock()
+	var rtn []wshrpc.ActionDef
+	for _, entry := range entries {
+		if entry.appliesTo(oref, meta) {
+			rtn = append(rtn, localizeDef(entry.Def))
+		}
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		if rtn[i].Order != rtn[j].Order {
+			return rtn[i].Order < rtn[j].Order
+		}
+		return rtn[i].Title < rtn[j].Title
+	})
+	return rtn, nil
+}
+
+func ExecuteAction(ctx context.Context, data wshrpc.CommandExecuteActionData) error {
+	entry := getEntry(data.ActionId)
+	if entry == nil {
+		return wi18n.Errorf("error.action.unknown", data.ActionId)
+	}
+	meta, err := getObjMeta(ctx, data.ORef)
+	if err != nil {
+		return err
+	}
+	if !entry.appliesTo(data.ORef, meta) {
+		return wi18n.Errorf("error.action.notapplicable", data.ActionId, data.ORef)
+	}
+	return entry.Handler(ctx, data)
+}
+
+// all the registered actions (for any object), sorted by actionid
+func ListAllActions() []wshrpc.ActionDef {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	rtn := make([]wshrpc.ActionDef, 0, len(actionMap))
+	for _, entry := range actionMap {
+		rtn = append(rtn, localizeDef(entry.Def))
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].ActionId < rtn[j].ActionId
+	})
+	return rtn
+}
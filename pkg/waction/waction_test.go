@@ -0,0 +1,38 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waction
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestRegisterDuplicate(t *testing.T) {
+	handler := func(ctx context.Context, data wshrpc.CommandExecuteActionData) error { return nil }
+	defer UnregisterAction("test:builtin")
+	defer UnregisterAction("test:plugin")
+	if err := RegisterAction(wshrpc.ActionDef{ActionId: "test:builtin"}, handler); err != nil {
+		t.Fatalf("registering action: %v", err)
+	}
+	if err := RegisterAction(wshrpc.ActionDef{ActionId: "test:builtin"}, handler); err == nil {
+		t.Errorf("expected an error for a duplicate built-in action")
+	}
+	if err := RegisterAction(wshrpc.ActionDef{ActionId: "test:builtin", PluginId: "p1"}, handler); err == nil {
+		t.Errorf("expected an error for a plugin action shadowing a built-in")
+	}
+	if err := RegisterAction(wshrpc.ActionDef{ActionId: "test:plugin", PluginId: "p1"}, handler); err != nil {
+		t.Fatalf("registering plugin action: %v", err)
+	}
+	if err := RegisterAction(wshrpc.ActionDef{ActionId: "test:plugin", PluginId: "p1", Title: "Updated"}, handler); err != nil {
+		t.Errorf("expected a plugin to update its own action: %v", err)
+	}
+	if err := RegisterAction(wshrpc.ActionDef{ActionId: "test:plugin", PluginId: "p2"}, handler); err == nil {
+		t.Errorf("expected an error for an action of another plugin")
+	}
+	if err := RegisterAction(wshrpc.ActionDef{ActionId: "test:plugin"}, handler); err == nil {
+		t.Errorf("expected an error for a built-in shadowing a plugin action")
+	}
+}
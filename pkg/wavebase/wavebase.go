@@ -38,10 +38,17 @@ const (
 )
 
 const (
-	BlockFile_Term  = "term"            // used for main pty output
-	BlockFile_Cache = "cache:term:full" // for cached block
-	BlockFile_VDom  = "vdom"            // used for alt html layout
-	BlockFile_Env   = "env"
+	BlockFile_Term           = "term"            // used for main pty output
+	BlockFile_Cache          = "cache:term:full" // for cached block
+	BlockFile_VDom           = "vdom"            // used for alt html layout
+	BlockFile_Env            = "env"
+	BlockFile_Def            = "blockdef"     // resolved BlockDef the block was created from
+	BlockFile_TermSegments   = "termsegments" // OSC 133 command segments for the term file
+	BlockFile_EnvSnapshot    = "envsnapshot"  // environment captured from the block's shell (wsh envsnapshot)
+	BlockFile_TermLinks      = "termlinks"    // hyperlinks, urls, and paths found in the term file
+	BlockFile_CastPrefix     = "cast:"        // asciicast v2 recordings of the term output (cast:[timestamp])
+	BlockFile_PanePrefix     = "term:pane:"   // output of the block's panes (term:pane:[paneid])
+	BlockFile_TransferPrefix = "transfer:"    // uploads that are not finished (transfer:[transferid])
 )
 
 const NeedJwtConst = "NEED-JWT"
@@ -54,6 +61,8 @@ var Dev_VarCache string        // caches WAVETERM_DEV
 const WaveLockFile = "wave.lock"
 const DomainSocketBaseName = "wave.sock"
 const RemoteDomainSocketBaseName = "wave-remote.sock"
+const ApiSocketBaseName = "wave-api.sock"
+const ApiPipeService = "api" // the api socket is a named pipe on windows (see pipeutil.PipeName)
 const WaveDBDir = "db"
 const JwtSecret = "waveterm" // TODO generate and store this
 const ConfigDir = "config"
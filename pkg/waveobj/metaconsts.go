@@ -23,6 +23,8 @@ const (
 	MetaKey_History                          = "history"
 	MetaKey_HistoryForward                   = "history:forward"
 
+	MetaKey_Tags                             = "tags"
+
 	MetaKey_DisplayName                      = "display:name"
 	MetaKey_DisplayOrder                     = "display:order"
 
@@ -47,10 +49,14 @@ const (
 	MetaKey_CmdCloseOnExit                   = "cmd:closeonexit"
 	MetaKey_CmdCloseOnExitForce              = "cmd:closeonexitforce"
 	MetaKey_CmdCloseOnExitDelay              = "cmd:closeonexitdelay"
+	MetaKey_CmdRestart                       = "cmd:restart"
+	MetaKey_CmdRestartDelay                  = "cmd:restartdelay"
 	MetaKey_CmdNoWsh                         = "cmd:nowsh"
 	MetaKey_CmdArgs                          = "cmd:args"
+	MetaKey_CmdArgv                          = "cmd:argv"
 	MetaKey_CmdShell                         = "cmd:shell"
 	MetaKey_CmdAllowConnChange               = "cmd:allowconnchange"
+	MetaKey_CmdEnvFrom                       = "cmd:envfrom"
 	MetaKey_CmdEnv                           = "cmd:env"
 	MetaKey_CmdCwd                           = "cmd:cwd"
 	MetaKey_CmdInitScript                    = "cmd:initscript"
@@ -83,6 +89,11 @@ const (
 
 	MetaKey_SysinfoType                      = "sysinfo:type"
 
+	MetaKey_ClusterExecRunId                 = "clusterexec:runid"
+
+	MetaKey_PlayerClear                      = "player:*"
+	MetaKey_PlayerSrc                        = "player:src"
+
 	MetaKey_BgClear                          = "bg:*"
 	MetaKey_Bg                               = "bg"
 	MetaKey_BgOpacity                        = "bg:opacity"
@@ -93,15 +104,30 @@ const (
 	MetaKey_TermClear                        = "term:*"
 	MetaKey_TermFontSize                     = "term:fontsize"
 	MetaKey_TermFontFamily                   = "term:fontfamily"
+	MetaKey_TermLineHeight                   = "term:lineheight"
+	MetaKey_TermLigatures                    = "term:ligatures"
 	MetaKey_TermMode                         = "term:mode"
 	MetaKey_TermTheme                        = "term:theme"
 	MetaKey_TermLocalShellPath               = "term:localshellpath"
 	MetaKey_TermLocalShellOpts               = "term:localshellopts"
+	MetaKey_TermShellPath                    = "term:shellpath"
+	MetaKey_TermLoginShell                   = "term:loginshell"
+	MetaKey_TermInteractiveShell             = "term:interactiveshell"
+	MetaKey_TermRcFile                       = "term:rcfile"
+	MetaKey_TermEncoding                     = "term:encoding"
+	MetaKey_TermLocale                       = "term:locale"
 	MetaKey_TermScrollback                   = "term:scrollback"
+	MetaKey_TermScrollbackBytes              = "term:scrollbackbytes"
 	MetaKey_TermVDomSubBlockId               = "term:vdomblockid"
 	MetaKey_TermVDomToolbarBlockId           = "term:vdomtoolbarblockid"
 	MetaKey_TermTransparency                 = "term:transparency"
 	MetaKey_TermAllowBracketedPaste          = "term:allowbracketedpaste"
+	MetaKey_TermSafePaste                    = "term:safepaste"
+	MetaKey_TermRecord                       = "term:record"
+	MetaKey_TermCommandBlocks                = "term:commandblocks"
+	MetaKey_TermOsc52                        = "term:osc52"
+	MetaKey_TermTermType                     = "term:termtype"
+	MetaKey_TermMouseReporting               = "term:mousereporting"
 	MetaKey_TermConnDebug                    = "term:conndebug"
 
 	MetaKey_WebZoom                          = "web:zoom"
@@ -14,6 +14,7 @@ type UpdatesRtnType = []WaveObjUpdate
 type UIContext struct {
 	WindowId    string `json:"windowid"`
 	ActiveTabId string `json:"activetabid"`
+	FeClientId  string `json:"feclientid,omitempty"` // the frontend making the call (several can show the same tab)
 }
 
 const (
@@ -22,23 +23,37 @@ const (
 )
 
 const (
-	OType_Client      = "client"
-	OType_Window      = "window"
-	OType_Workspace   = "workspace"
-	OType_Tab         = "tab"
-	OType_LayoutState = "layout"
-	OType_Block       = "block"
-	OType_Temp        = "temp"
+	OType_Client          = "client"
+	OType_Window          = "window"
+	OType_Workspace       = "workspace"
+	OType_Tab             = "tab"
+	OType_LayoutState     = "layout"
+	OType_Block           = "block"
+	OType_Temp            = "temp"
+	OType_Webhook         = "webhook"
+	OType_WebhookDelivery = "webhookdelivery"
+	OType_Notification    = "notification"
+	OType_Connection      = "connection"
+	OType_Settings        = "settings"
+	OType_Theme           = "theme"
+	OType_Profile         = "profile"
 )
 
 var ValidOTypes = map[string]bool{
-	OType_Client:      true,
-	OType_Window:      true,
-	OType_Workspace:   true,
-	OType_Tab:         true,
-	OType_LayoutState: true,
-	OType_Block:       true,
-	OType_Temp:        true,
+	OType_Client:          true,
+	OType_Window:          true,
+	OType_Workspace:       true,
+	OType_Tab:             true,
+	OType_LayoutState:     true,
+	OType_Block:           true,
+	OType_Temp:            true,
+	OType_Webhook:         true,
+	OType_WebhookDelivery: true,
+	OType_Notification:    true,
+	OType_Connection:      true,
+	OType_Settings:        true,
+	OType_Theme:           true,
+	OType_Profile:         true,
 }
 
 type WaveObjUpdate struct {
@@ -130,6 +145,7 @@ type Client struct {
 	TosAgreed     int64       `json:"tosagreed,omitempty"`
 	HasOldHistory bool        `json:"hasoldhistory,omitempty"`
 	TempOID       string      `json:"tempoid,omitempty"`
+	ActiveProfile string      `json:"activeprofile,omitempty"` // the oid of the profile switched to (see Profile)
 }
 
 func (*Client) GetOType() string {
@@ -262,8 +278,18 @@ type StickerType struct {
 }
 
 type RuntimeOpts struct {
-	TermSize TermSize `json:"termsize,omitempty"`
-	WinSize  WinSize  `json:"winsize,omitempty"`
+	TermSize TermSize      `json:"termsize,omitempty"`
+	WinSize  WinSize       `json:"winsize,omitempty"`
+	TermFont *TermFontOpts `json:"termfont,omitempty"`
+}
+
+// the font of a terminal block set with wsh font (or its font size menu), over the settings of the block.  the
+// values are checked when they are set (see pkg/wsettings/termfont.go).
+type TermFontOpts struct {
+	FontFamily string  `json:"fontfamily,omitempty"` // term:fontfamily
+	FontSize   float64 `json:"fontsize,omitempty"`   // term:fontsize
+	LineHeight float64 `json:"lineheight,omitempty"` // term:lineheight
+	Ligatures  *bool   `json:"ligatures,omitempty"`  // term:ligatures
 }
 
 type Point struct {
@@ -284,12 +310,166 @@ type Block struct {
 	Stickers    []*StickerType `json:"stickers,omitempty"`
 	Meta        MetaMapType    `json:"meta"`
 	SubBlockIds []string       `json:"subblockids,omitempty"`
+	DefHash     string         `json:"defhash,omitempty"` // hash of the resolved BlockDef the block was created from
 }
 
 func (*Block) GetOType() string {
 	return OType_Block
 }
 
+// a url that is sent the events matching its filters (see pkg/webhook)
+type Webhook struct {
+	OID      string          `json:"oid"`
+	Version  int             `json:"version"`
+	Name     string          `json:"name,omitempty"`
+	Url      string          `json:"url"`
+	Filters  []WebhookFilter `json:"filters"`
+	Disabled bool            `json:"disabled,omitempty"`
+	Meta     MetaMapType     `json:"meta"`
+}
+
+func (*Webhook) GetOType() string {
+	return OType_Webhook
+}
+
+type WebhookFilter struct {
+	Event       string `json:"event"`                 // e.g. "block:exit", "workspace:create", or "*" for all events
+	NonZeroExit bool   `json:"nonzeroexit,omitempty"` // for block:exit, only when the exit code is not 0
+}
+
+// the log of sending an event to a webhook
+type WebhookDelivery struct {
+	OID        string      `json:"oid"`
+	Version    int         `json:"version"`
+	WebhookId  string      `json:"webhookid"`
+	Event      string      `json:"event"`
+	Ts         int64       `json:"ts"`
+	Payload    string      `json:"payload"`
+	Status     string      `json:"status"` // pending, delivered, or failed
+	Attempts   int         `json:"attempts"`
+	StatusCode int         `json:"statuscode,omitempty"` // the http status of the last attempt
+	Error      string      `json:"error,omitempty"`
+	Meta       MetaMapType `json:"meta"`
+}
+
+func (*WebhookDelivery) GetOType() string {
+	return OType_WebhookDelivery
+}
+
+// a notification sent through the backend (see pkg/wnotify), kept until it is dismissed
+type Notification struct {
+	OID       string      `json:"oid"`
+	Version   int         `json:"version"`
+	Title     string      `json:"title"`
+	Message   string      `json:"message,omitempty"`
+	Type      string      `json:"type,omitempty"`   // "error", "warning", or "info" (the default)
+	Source    string      `json:"source,omitempty"` // who sent it, e.g. "wsh", "webhook", or "controller"
+	BlockId   string      `json:"blockid,omitempty"`
+	DedupKey  string      `json:"dedupkey"`
+	Count     int         `json:"count"` // the number of times it was sent (duplicates are counted, not shown again)
+	CreatedTs int64       `json:"createdts"`
+	UpdatedTs int64       `json:"updatedts"`
+	Delivered bool        `json:"delivered,omitempty"` // false while it is held by do-not-disturb
+	Meta      MetaMapType `json:"meta"`
+}
+
+func (*Notification) GetOType() string {
+	return OType_Notification
+}
+
+// an ssh connection saved by the user (see pkg/wconn), the blocks that use it have its conn name in their
+// "connection" meta
+type Connection struct {
+	OID             string        `json:"oid"`
+	Version         int           `json:"version"`
+	Name            string        `json:"name"` // e.g. "prod-db-3", unique
+	Host            string        `json:"host"`
+	User            string        `json:"user,omitempty"`
+	Port            string        `json:"port,omitempty"`
+	AuthMethod      string        `json:"authmethod,omitempty"` // "key", "agent", "password", "gssapi", or "" for the ssh config
+	IdentityFile    string        `json:"identityfile,omitempty"`
+	CertificateFile string        `json:"certificatefile,omitempty"` // an openssh certificate for the key (key or agent)
+	Secret          string        `json:"secret,omitempty"`          // the name of the secret with the password (password) or the key's passphrase (key)
+	ProxyJump       []string      `json:"proxyjump,omitempty"`       // the jump hosts, saved connections (by name) or "[user@]host[:port]"
+	ForwardAgent    bool          `json:"forwardagent,omitempty"`
+	Forwards        []PortForward `json:"forwards,omitempty"` // the port forwards started every time it connects
+	Tags            []string      `json:"tags,omitempty"`
+	CreatedTs       int64         `json:"createdts"`
+	Meta            MetaMapType   `json:"meta"`
+}
+
+// a local (-L), remote (-R) or dynamic (-D, socks) port forward
+type PortForward struct {
+	Type     string `json:"type"`               // "local", "remote" or "dynamic"
+	BindAddr string `json:"bindaddr,omitempty"` // 127.0.0.1 if not set
+	BindPort int    `json:"bindport"`
+	DestHost string `json:"desthost,omitempty"` // not set for dynamic forwards
+	DestPort int    `json:"destport,omitempty"`
+}
+
+func (*Connection) GetOType() string {
+	return OType_Connection
+}
+
+// the settings of wave (WorkspaceId is not set) or of a workspace, they override the settings in settings.json
+// (see pkg/wsettings).  there is at most one for wave and one for each workspace.
+type Settings struct {
+	OID         string      `json:"oid"`
+	Version     int         `json:"version"`
+	WorkspaceId string      `json:"workspaceid,omitempty"`
+	Settings    MetaMapType `json:"settings"` // the keys of settings.json, e.g. "term:scrollback"
+	UpdatedTs   int64       `json:"updatedts"`
+	Meta        MetaMapType `json:"meta"`
+}
+
+func (*Settings) GetOType() string {
+	return OType_Settings
+}
+
+// a terminal theme defined in wave (imported or saved with wsh theme, see pkg/wtheme).  it is used by its name in
+// term:theme, like the built-in themes and the ones in termthemes.json (which a theme with the same name replaces).
+type Theme struct {
+	OID       string      `json:"oid"`
+	Version   int         `json:"version"`
+	Name      string      `json:"name"`             // unique
+	Source    string      `json:"source,omitempty"` // the format it was imported from ("iterm2", "alacritty", "vscode")
+	Palette   MetaMapType `json:"palette"`          // the keys of a theme in termthemes.json, e.g. "background"
+	CreatedTs int64       `json:"createdts"`
+	UpdatedTs int64       `json:"updatedts"`
+	Meta      MetaMapType `json:"meta"`
+}
+
+func (*Theme) GetOType() string {
+	return OType_Theme
+}
+
+// a named set of settings (e.g. "work", "personal", "presentation") that is switched to as a whole (see
+// pkg/wsettings/profile.go).  the active profile is a layer of the settings, over the global settings and under
+// the settings of the workspace.
+type Profile struct {
+	OID         string              `json:"oid"`
+	Version     int                 `json:"version"`
+	Name        string              `json:"name"`                  // unique
+	Settings    MetaMapType         `json:"settings"`              // the keys of settings.json, e.g. "term:fontsize"
+	Theme       string              `json:"theme,omitempty"`       // the terminal theme (term:theme)
+	Keybindings map[string][]string `json:"keybindings,omitempty"` // action => keys (app:keybindings)
+	Templates   ProfileTemplates    `json:"templates"`
+	CreatedTs   int64               `json:"createdts"`
+	UpdatedTs   int64               `json:"updatedts"`
+	Meta        MetaMapType         `json:"meta"`
+}
+
+// the defaults of the new blocks and tabs
+type ProfileTemplates struct {
+	NewBlock  string `json:"newblock,omitempty"`  // the view of a new block (app:defaultnewblock)
+	TabPreset string `json:"tabpreset,omitempty"` // the background of a new tab (tab:preset)
+	AiPreset  string `json:"aipreset,omitempty"`  // the preset of a new ai block (ai:preset)
+}
+
+func (*Profile) GetOType() string {
+	return OType_Profile
+}
+
 func AllWaveObjTypes() []reflect.Type {
 	return []reflect.Type{
 		reflect.TypeOf(&Client{}),
@@ -298,6 +478,13 @@ func AllWaveObjTypes() []reflect.Type {
 		reflect.TypeOf(&Tab{}),
 		reflect.TypeOf(&Block{}),
 		reflect.TypeOf(&LayoutState{}),
+		reflect.TypeOf(&Webhook{}),
+		reflect.TypeOf(&WebhookDelivery{}),
+		reflect.TypeOf(&Notification{}),
+		reflect.TypeOf(&Connection{}),
+		reflect.TypeOf(&Settings{}),
+		reflect.TypeOf(&Theme{}),
+		reflect.TypeOf(&Profile{}),
 	}
 }
 
@@ -21,6 +21,7 @@ type MetaTSType struct {
 	Edit           bool     `json:"edit,omitempty"`
 	History        []string `json:"history,omitempty"`
 	HistoryForward []string `json:"history:forward,omitempty"`
+	Tags           []string `json:"tags,omitempty"` // for the storage:retention policies
 
 	DisplayName  string  `json:"display:name,omitempty"`
 	DisplayOrder float64 `json:"display:order,omitempty"`
@@ -46,10 +47,14 @@ type MetaTSType struct {
 	CmdCloseOnExit      bool     `json:"cmd:closeonexit,omitempty"`
 	CmdCloseOnExitForce bool     `json:"cmd:closeonexitforce,omitempty"`
 	CmdCloseOnExitDelay float64  `json:"cmd:closeonexitdelay,omitempty"`
+	CmdRestart          string   `json:"cmd:restart,omitempty"` // never (default), onfailure, always
+	CmdRestartDelay     float64  `json:"cmd:restartdelay,omitempty"`
 	CmdNoWsh            bool     `json:"cmd:nowsh,omitempty"`
 	CmdArgs             []string `json:"cmd:args,omitempty"`  // args for cmd (only if cmd:shell is false)
+	CmdArgv             []string `json:"cmd:argv,omitempty"`  // run this command directly (instead of cmd, or the shell for "shell" blocks)
 	CmdShell            bool     `json:"cmd:shell,omitempty"` // shell expansion for cmd+args (defaults to true)
 	CmdAllowConnChange  bool     `json:"cmd:allowconnchange,omitempty"`
+	CmdEnvFrom          string   `json:"cmd:envfrom,omitempty"` // on create, start with the env snapshot of this block
 
 	// these can be nested under "[conn]"
 	CmdEnv            map[string]string `json:"cmd:env,omitempty"`
@@ -85,6 +90,11 @@ type MetaTSType struct {
 
 	SysinfoType string `json:"sysinfo:type,omitempty"`
 
+	ClusterExecRunId string `json:"clusterexec:runid,omitempty"`
+
+	PlayerClear bool   `json:"player:*,omitempty"`
+	PlayerSrc   string `json:"player:src,omitempty"` // asciicast to play, a local path or wavefile://[zoneid]/[name]
+
 	// for tabs
 	BgClear             bool    `json:"bg:*,omitempty"`
 	Bg                  string  `json:"bg,omitempty"`
@@ -96,16 +106,31 @@ type MetaTSType struct {
 	TermClear               bool     `json:"term:*,omitempty"`
 	TermFontSize            int      `json:"term:fontsize,omitempty"`
 	TermFontFamily          string   `json:"term:fontfamily,omitempty"`
+	TermLineHeight          *float64 `json:"term:lineheight,omitempty"`
+	TermLigatures           *bool    `json:"term:ligatures,omitempty"`
 	TermMode                string   `json:"term:mode,omitempty"`
 	TermTheme               string   `json:"term:theme,omitempty"`
-	TermLocalShellPath      string   `json:"term:localshellpath,omitempty"` // matches settings
-	TermLocalShellOpts      []string `json:"term:localshellopts,omitempty"` // matches settings
+	TermLocalShellPath      string   `json:"term:localshellpath,omitempty"`   // matches settings
+	TermLocalShellOpts      []string `json:"term:localshellopts,omitempty"`   // matches settings
+	TermShellPath           string   `json:"term:shellpath,omitempty"`        // shell for this block (local or remote)
+	TermLoginShell          *bool    `json:"term:loginshell,omitempty"`       // matches settings, default true
+	TermInteractiveShell    *bool    `json:"term:interactiveshell,omitempty"` // matches settings, default true
+	TermRcFile              string   `json:"term:rcfile,omitempty"`           // matches settings
+	TermEncoding            string   `json:"term:encoding,omitempty"`         // charset of the pty input/output, default utf-8
+	TermLocale              string   `json:"term:locale,omitempty"`           // sets LANG and LC_ALL
 	TermScrollback          *int     `json:"term:scrollback,omitempty"`
+	TermScrollbackBytes     *int64   `json:"term:scrollbackbytes,omitempty"`
 	TermVDomSubBlockId      string   `json:"term:vdomblockid,omitempty"`
 	TermVDomToolbarBlockId  string   `json:"term:vdomtoolbarblockid,omitempty"`
 	TermTransparency        *float64 `json:"term:transparency,omitempty"` // default 0.5
 	TermAllowBracketedPaste *bool    `json:"term:allowbracketedpaste,omitempty"`
-	TermConnDebug           string   `json:"term:conndebug,omitempty"` // null, info, debug
+	TermSafePaste           *bool    `json:"term:safepaste,omitempty"`      // matches settings, default true
+	TermRecord              *bool    `json:"term:record,omitempty"`         // matches settings, records every session as an asciicast
+	TermCommandBlocks       *bool    `json:"term:commandblocks,omitempty"`  // matches settings, default true
+	TermOsc52               string   `json:"term:osc52,omitempty"`          // matches settings, what OSC 52 clipboard requests are allowed (none, write, or readwrite)
+	TermTermType            string   `json:"term:termtype,omitempty"`       // matches settings, TERM for the shell (default xterm-256color)
+	TermMouseReporting      *bool    `json:"term:mousereporting,omitempty"` // matches settings, default true (false to always select locally)
+	TermConnDebug           string   `json:"term:conndebug,omitempty"`      // null, info, debug
 
 	WebZoom      float64 `json:"web:zoom,omitempty"`
 	WebHideNav   *bool   `json:"web:hidenav,omitempty"`
@@ -6,11 +6,14 @@ package wconfig
 import (
 	"log"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"sync"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 )
@@ -19,10 +22,11 @@ var instance *Watcher
 var once sync.Once
 
 type Watcher struct {
-	initialized bool
-	watcher     *fsnotify.Watcher
-	mutex       sync.Mutex
-	fullConfig  FullConfigType
+	initialized      bool
+	watcher          *fsnotify.Watcher
+	mutex            sync.Mutex
+	fullConfig       FullConfigType
+	settingsHandlers []func(keys []string)
 }
 
 type WatcherUpdate struct {
@@ -119,10 +123,15 @@ func (w *Watcher) GetFullConfig() FullConfigType {
 	return w.fullConfig
 }
 
-func (w *Watcher) handleEvent(event fsnotify.Event) {
+// registers a handler that is called (after the config files are read again) with the settings whose values in
+// settings.json changed.  it is called from the watcher goroutine and must not block.
+func (w *Watcher) OnSettingsChange(handler func(keys []string)) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
+	w.settingsHandlers = append(w.settingsHandlers, handler)
+}
 
+func (w *Watcher) handleEvent(event fsnotify.Event) {
 	fileName := filepath.ToSlash(event.Name)
 	if event.Op == fsnotify.Chmod {
 		return
@@ -130,7 +139,47 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	if !isValidSubSettingsFileName(fileName) {
 		return
 	}
-	w.handleSettingsFileEvent(event, fileName)
+	w.reload()
+}
+
+// reads the config again and sends it (for a change that is not to a config file, e.g. to the themes in wave)
+func (w *Watcher) Reload() {
+	w.reload()
+}
+
+func (w *Watcher) reload() {
+	changedKeys, handlers := func() ([]string, []func([]string)) {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		oldSettings := w.fullConfig.Settings
+		w.handleSettingsFileEvent()
+		return changedSettingsKeys(oldSettings, w.fullConfig.Settings), w.settingsHandlers
+	}()
+	if len(changedKeys) == 0 {
+		return
+	}
+	for _, handler := range handlers {
+		handler(changedKeys)
+	}
+}
+
+// the keys (json tags) of the settings with different values
+func changedSettingsKeys(oldSettings SettingsType, newSettings SettingsType) []string {
+	var keys []string
+	oldVal := reflect.ValueOf(oldSettings)
+	newVal := reflect.ValueOf(newSettings)
+	for i := 0; i < oldVal.NumField(); i++ {
+		field := oldVal.Type().Field(i)
+		jsonTag := utilfn.GetJsonTag(field)
+		if jsonTag == "" || jsonTag == "-" {
+			continue
+		}
+		if !reflect.DeepEqual(oldVal.Field(i).Interface(), newVal.Field(i).Interface()) {
+			keys = append(keys, jsonTag)
+		}
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 var validFileRe = regexp.MustCompile(`^[a-zA-Z0-9_@.-]+\.json$`)
@@ -143,7 +192,7 @@ func isValidSubSettingsFileName(fileName string) bool {
 	return validFileRe.MatchString(baseName)
 }
 
-func (w *Watcher) handleSettingsFileEvent(_ fsnotify.Event, _ string) {
+func (w *Watcher) handleSettingsFileEvent() {
 	fullConfig := ReadFullConfig()
 	w.fullConfig = fullConfig
 	w.broadcast(WatcherUpdate{FullConfig: w.fullConfig})
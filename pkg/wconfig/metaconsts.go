@@ -10,6 +10,8 @@ const (
 	ConfigKey_AppGlobalHotkey                = "app:globalhotkey"
 	ConfigKey_AppDismissArchitectureWarning  = "app:dismissarchitecturewarning"
 	ConfigKey_AppDefaultNewBlock             = "app:defaultnewblock"
+	ConfigKey_AppKeybindings                 = "app:keybindings"
+	ConfigKey_AppLocale                      = "app:locale"
 
 	ConfigKey_AiClear                        = "ai:*"
 	ConfigKey_AiPreset                       = "ai:preset"
@@ -28,14 +30,29 @@ const (
 	ConfigKey_TermClear                      = "term:*"
 	ConfigKey_TermFontSize                   = "term:fontsize"
 	ConfigKey_TermFontFamily                 = "term:fontfamily"
+	ConfigKey_TermLineHeight                 = "term:lineheight"
+	ConfigKey_TermLigatures                  = "term:ligatures"
 	ConfigKey_TermTheme                      = "term:theme"
 	ConfigKey_TermDisableWebGl               = "term:disablewebgl"
 	ConfigKey_TermLocalShellPath             = "term:localshellpath"
 	ConfigKey_TermLocalShellOpts             = "term:localshellopts"
+	ConfigKey_TermLoginShell                 = "term:loginshell"
+	ConfigKey_TermInteractiveShell           = "term:interactiveshell"
+	ConfigKey_TermRcFile                     = "term:rcfile"
 	ConfigKey_TermScrollback                 = "term:scrollback"
+	ConfigKey_TermScrollbackBytes            = "term:scrollbackbytes"
+	ConfigKey_TermOutputFlushMs              = "term:outputflushms"
+	ConfigKey_TermOutputMaxRate              = "term:outputmaxrate"
 	ConfigKey_TermCopyOnSelect               = "term:copyonselect"
 	ConfigKey_TermTransparency               = "term:transparency"
 	ConfigKey_TermAllowBracketedPaste        = "term:allowbracketedpaste"
+	ConfigKey_TermSafePaste                  = "term:safepaste"
+	ConfigKey_TermRecord                     = "term:record"
+	ConfigKey_TermCommandBlocks              = "term:commandblocks"
+	ConfigKey_TermOsc52                      = "term:osc52"
+	ConfigKey_TermTermType                   = "term:termtype"
+	ConfigKey_TermMouseReporting             = "term:mousereporting"
+	ConfigKey_TermPersistentSessions         = "term:persistentsessions"
 
 	ConfigKey_EditorMinimapEnabled           = "editor:minimapenabled"
 	ConfigKey_EditorStickyScrollEnabled      = "editor:stickyscrollenabled"
@@ -92,5 +109,44 @@ const (
 	ConfigKey_ConnClear                      = "conn:*"
 	ConfigKey_ConnAskBeforeWshInstall        = "conn:askbeforewshinstall"
 	ConfigKey_ConnWshEnabled                 = "conn:wshenabled"
+
+	ConfigKey_StorageClear                   = "storage:*"
+	ConfigKey_StorageMaxBlockBytes           = "storage:maxblockbytes"
+	ConfigKey_StorageMaxWorkspaceBytes       = "storage:maxworkspacebytes"
+	ConfigKey_StorageSearchIndex             = "storage:searchindex"
+	ConfigKey_StorageEncrypt                 = "storage:encrypt"
+	ConfigKey_StorageRetention               = "storage:retention"
+	ConfigKey_StorageReplicaUrl              = "storage:replicaurl"
+	ConfigKey_StorageReplicaProfile          = "storage:replicaprofile"
+	ConfigKey_StorageReplicaRegion           = "storage:replicaregion"
+	ConfigKey_StorageReplicaEndpoint         = "storage:replicaendpoint"
+	ConfigKey_StorageReplicaOffloadDays      = "storage:replicaoffloaddays"
+	ConfigKey_StorageCacheBytes              = "storage:cachebytes"
+	ConfigKey_StorageCachePolicy             = "storage:cachepolicy"
+
+	ConfigKey_NotifyClear                    = "notify:*"
+	ConfigKey_NotifyDnd                      = "notify:dnd"
+	ConfigKey_NotifyDndStart                 = "notify:dndstart"
+	ConfigKey_NotifyDndEnd                   = "notify:dndend"
+
+	ConfigKey_ApiClear                       = "api:*"
+	ConfigKey_ApiEnabled                     = "api:enabled"
+	ConfigKey_ApiListenAddr                  = "api:listenaddr"
+	ConfigKey_ApiSocket                      = "api:socket"
+	ConfigKey_ApiGrpc                        = "api:grpc"
+	ConfigKey_ApiGrpcListenAddr              = "api:grpclistenaddr"
+
+	ConfigKey_RemoteClear                    = "remote:*"
+	ConfigKey_RemoteEnabled                  = "remote:enabled"
+	ConfigKey_RemoteListenAddr               = "remote:listenaddr"
+	ConfigKey_RemoteWorkspaces               = "remote:workspaces"
+	ConfigKey_RemoteSessionHours             = "remote:sessionhours"
+	ConfigKey_RemoteTlsCert                  = "remote:tlscert"
+	ConfigKey_RemoteTlsKey                   = "remote:tlskey"
+	ConfigKey_RemoteTlsClientCa              = "remote:tlsclientca"
+
+	ConfigKey_SettingsClear                  = "settings:*"
+	ConfigKey_SettingsLocked                 = "settings:locked"
+	ConfigKey_SettingsVersion                = "settings:version"
 )
 
@@ -0,0 +1,217 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wconfig
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// the version of the settings schema.  a settings file has the version it was written for in settings:version (0 if
+// it is not set), and the migrations after it are applied when the file is read, so the settings that were renamed
+// or restructured keep working (the file itself is only rewritten by MigrateSettingsFiles, wsh settings migrate).
+// a migration must be idempotent: a file without settings:version gets all of them.
+const SettingsVersion = 2
+
+// a change made by a migration to a setting
+type SettingsMigration struct {
+	File     string `json:"file,omitempty"`
+	Version  int    `json:"version"`          // the version of the migration
+	Key      string `json:"key"`              // the setting as it is in the file
+	NewKey   string `json:"newkey,omitempty"` // the setting it is now ("" if it was removed)
+	Desc     string `json:"desc"`
+	OldValue any    `json:"oldvalue,omitempty"`
+	NewValue any    `json:"newvalue,omitempty"`
+}
+
+type settingsMigrationFn func(m waveobj.MetaMapType) []SettingsMigration
+
+var settingsMigrations = []struct {
+	version int
+	fn      settingsMigrationFn
+}{
+	{1, renameSettings(map[string]string{
+		"term:confirmpaste":   "term:safepaste",
+		"term:bracketedpaste": "term:allowbracketedpaste",
+	})},
+	{2, splitStringSetting("term:localshellopts", strings.Fields)},
+	{2, splitStringSetting("remote:workspaces", splitCommaList)},
+}
+
+// renames settings (oldKey => newKey), the old value is dropped if the new setting is already set
+func renameSettings(renames map[string]string) settingsMigrationFn {
+	return func(m waveobj.MetaMapType) []SettingsMigration {
+		oldKeys := make([]string, 0, len(renames))
+		for oldKey := range renames {
+			oldKeys = append(oldKeys, oldKey)
+		}
+		sort.Strings(oldKeys)
+		var rtn []SettingsMigration
+		for _, oldKey := range oldKeys {
+			val, ok := m[oldKey]
+			if !ok {
+				continue
+			}
+			newKey := renames[oldKey]
+			delete(m, oldKey)
+			if _, exists := m[newKey]; exists {
+				rtn = append(rtn, SettingsMigration{Key: oldKey, Desc: fmt.Sprintf("renamed to %s, which is already set (removed)", newKey), OldValue: val})
+				continue
+			}
+			m[newKey] = val
+			rtn = append(rtn, SettingsMigration{Key: oldKey, NewKey: newKey, Desc: fmt.Sprintf("renamed to %s", newKey), OldValue: val, NewValue: val})
+		}
+		return rtn
+	}
+}
+
+// a setting that was a string and is now a list
+func splitStringSetting(key string, splitFn func(string) []string) settingsMigrationFn {
+	return func(m waveobj.MetaMapType) []SettingsMigration {
+		strVal, ok := m[key].(string)
+		if !ok {
+			return nil
+		}
+		parts := splitFn(strVal)
+		newVal := make([]any, 0, len(parts))
+		for _, part := range parts {
+			newVal = append(newVal, part)
+		}
+		m[key] = newVal
+		return []SettingsMigration{{Key: key, NewKey: key, Desc: "changed from a string to a list", OldValue: strVal, NewValue: newVal}}
+	}
+}
+
+func splitCommaList(str string) []string {
+	var rtn []string
+	for _, part := range strings.Split(str, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			rtn = append(rtn, part)
+		}
+	}
+	return rtn
+}
+
+// applies the migrations after the settings:version of m to m, returns the changes.  a version newer than
+// SettingsVersion is returned as an error (the settings are not changed, the unknown ones are reported when the
+// file is validated).
+func MigrateSettings(m waveobj.MetaMapType) ([]SettingsMigration, error) {
+	version := m.GetInt(ConfigKey_SettingsVersion, 0)
+	if version > SettingsVersion {
+		return nil, fmt.Errorf("written by a newer version of wave (settings:version %d, this version reads %d)", version, SettingsVersion)
+	}
+	var rtn []SettingsMigration
+	for _, migration := range settingsMigrations {
+		if migration.version <= version {
+			continue
+		}
+		changes := migration.fn(m)
+		for idx := range changes {
+			changes[idx].Version = migration.version
+		}
+		rtn = append(rtn, changes...)
+	}
+	return rtn, nil
+}
+
+// the keys a migration changed (the old and the new ones), their errors in the file are for the old settings
+func migratedKeys(changes []SettingsMigration) map[string]bool {
+	rtn := make(map[string]bool)
+	for _, change := range changes {
+		rtn[change.Key] = true
+		if change.NewKey != "" {
+			rtn[change.NewKey] = true
+		}
+	}
+	return rtn
+}
+
+// the user's settings files (settings.json and settings/*.json), relative to the config dir
+func getUserSettingsFiles() []string {
+	configDirFsys := os.DirFS(wavebase.GetWaveConfigDir())
+	var rtn []string
+	if _, err := fs.Stat(configDirFsys, SettingsFile); err == nil {
+		rtn = append(rtn, SettingsFile)
+	}
+	dirEnts, _ := fs.ReadDir(configDirFsys, "settings")
+	for _, ent := range selectDirEntsBySuffix(dirEnts, ".json") {
+		rtn = append(rtn, filepath.Join("settings", ent.Name()))
+	}
+	return rtn
+}
+
+// the changes the migrations make to the user's settings files when they are read
+func GetSettingsMigrations() []SettingsMigration {
+	var rtn []SettingsMigration
+	for _, fileName := range getUserSettingsFiles() {
+		barr, err := os.ReadFile(filepath.Join(wavebase.GetWaveConfigDir(), fileName))
+		if err != nil {
+			continue
+		}
+		m, cerrs := readConfigHelper(fileName, barr, nil)
+		if len(cerrs) > 0 || m == nil {
+			continue
+		}
+		changes, _ := MigrateSettings(m)
+		for _, change := range changes {
+			change.File = fileName
+			rtn = append(rtn, change)
+		}
+	}
+	return rtn
+}
+
+// rewrites the user's settings files that have settings to migrate (with settings:version set to SettingsVersion),
+// the original file is kept as FILE.bak.  with dryRun the files are not changed.
+func MigrateSettingsFiles(dryRun bool) ([]SettingsMigration, error) {
+	configDir := wavebase.GetWaveConfigDir()
+	var rtn []SettingsMigration
+	for _, fileName := range getUserSettingsFiles() {
+		fullPath := filepath.Join(configDir, fileName)
+		barr, err := os.ReadFile(fullPath)
+		if err != nil {
+			return rtn, err
+		}
+		m, cerrs := readConfigHelper(fileName, barr, nil)
+		if len(cerrs) > 0 {
+			return rtn, fmt.Errorf("%s (fix it before migrating the settings)", cerrs[0].String())
+		}
+		if m == nil {
+			continue
+		}
+		changes, err := MigrateSettings(m)
+		if err != nil {
+			return rtn, fmt.Errorf("%s: %w", fileName, err)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		for _, change := range changes {
+			change.File = fileName
+			rtn = append(rtn, change)
+		}
+		if dryRun {
+			continue
+		}
+		m[ConfigKey_SettingsVersion] = SettingsVersion
+		newBarr, err := jsonMarshalConfigInOrder(m)
+		if err != nil {
+			return rtn, err
+		}
+		if err := os.WriteFile(fullPath+".bak", barr, 0644); err != nil {
+			return rtn, fmt.Errorf("backing up %s: %w", fileName, err)
+		}
+		if err := os.WriteFile(fullPath, newBarr, 0644); err != nil {
+			return rtn, err
+		}
+	}
+	return rtn, nil
+}
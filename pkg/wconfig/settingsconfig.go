@@ -14,6 +14,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
@@ -23,6 +24,7 @@ import (
 
 const SettingsFile = "settings.json"
 const ConnectionsFile = "connections.json"
+const ConnProfilesFile = "connprofiles.json"
 const ProfilesFile = "profiles.json"
 
 const AnySchema = `
@@ -51,10 +53,12 @@ type AiSettingsType struct {
 }
 
 type SettingsType struct {
-	AppClear                      bool   `json:"app:*,omitempty"`
-	AppGlobalHotkey               string `json:"app:globalhotkey,omitempty"`
-	AppDismissArchitectureWarning bool   `json:"app:dismissarchitecturewarning,omitempty"`
-	AppDefaultNewBlock            string `json:"app:defaultnewblock,omitempty"`
+	AppClear                      bool                `json:"app:*,omitempty"`
+	AppGlobalHotkey               string              `json:"app:globalhotkey,omitempty"`
+	AppDismissArchitectureWarning bool                `json:"app:dismissarchitecturewarning,omitempty"`
+	AppDefaultNewBlock            string              `json:"app:defaultnewblock,omitempty"`
+	AppKeybindings                map[string][]string `json:"app:keybindings,omitempty"` // action => keys (replacing the default keys, [] unbinds it)
+	AppLocale                     string              `json:"app:locale,omitempty"`      // the locale of the text wavesrv generates (defaults to the locale of the os)
 
 	AiClear         bool    `json:"ai:*,omitempty"`
 	AiPreset        string  `json:"ai:preset,omitempty"`
@@ -73,14 +77,29 @@ type SettingsType struct {
 	TermClear               bool     `json:"term:*,omitempty"`
 	TermFontSize            float64  `json:"term:fontsize,omitempty"`
 	TermFontFamily          string   `json:"term:fontfamily,omitempty"`
+	TermLineHeight          *float64 `json:"term:lineheight,omitempty"`
+	TermLigatures           bool     `json:"term:ligatures,omitempty"`
 	TermTheme               string   `json:"term:theme,omitempty"`
 	TermDisableWebGl        bool     `json:"term:disablewebgl,omitempty"`
 	TermLocalShellPath      string   `json:"term:localshellpath,omitempty"`
 	TermLocalShellOpts      []string `json:"term:localshellopts,omitempty"`
+	TermLoginShell          *bool    `json:"term:loginshell,omitempty"`
+	TermInteractiveShell    *bool    `json:"term:interactiveshell,omitempty"`
+	TermRcFile              string   `json:"term:rcfile,omitempty"`
 	TermScrollback          *int64   `json:"term:scrollback,omitempty"`
+	TermScrollbackBytes     *int64   `json:"term:scrollbackbytes,omitempty"`
+	TermOutputFlushMs       *int64   `json:"term:outputflushms,omitempty"`
+	TermOutputMaxRate       *int64   `json:"term:outputmaxrate,omitempty"`
 	TermCopyOnSelect        *bool    `json:"term:copyonselect,omitempty"`
 	TermTransparency        *float64 `json:"term:transparency,omitempty"`
 	TermAllowBracketedPaste *bool    `json:"term:allowbracketedpaste,omitempty"`
+	TermSafePaste           *bool    `json:"term:safepaste,omitempty"`
+	TermRecord              bool     `json:"term:record,omitempty"`
+	TermCommandBlocks       *bool    `json:"term:commandblocks,omitempty"`
+	TermOsc52               string   `json:"term:osc52,omitempty"`
+	TermTermType            string   `json:"term:termtype,omitempty"`
+	TermMouseReporting      *bool    `json:"term:mousereporting,omitempty"`
+	TermPersistentSessions  bool     `json:"term:persistentsessions,omitempty"`
 
 	EditorMinimapEnabled      bool    `json:"editor:minimapenabled,omitempty"`
 	EditorStickyScrollEnabled bool    `json:"editor:stickyscrollenabled,omitempty"`
@@ -137,11 +156,68 @@ type SettingsType struct {
 	ConnClear               bool  `json:"conn:*,omitempty"`
 	ConnAskBeforeWshInstall *bool `json:"conn:askbeforewshinstall,omitempty"`
 	ConnWshEnabled          bool  `json:"conn:wshenabled,omitempty"`
+
+	StorageClear              bool              `json:"storage:*,omitempty"`
+	StorageMaxBlockBytes      int64             `json:"storage:maxblockbytes,omitempty"`
+	StorageMaxWorkspaceBytes  int64             `json:"storage:maxworkspacebytes,omitempty"`
+	StorageSearchIndex        bool              `json:"storage:searchindex,omitempty"`
+	StorageEncrypt            bool              `json:"storage:encrypt,omitempty"`
+	StorageRetention          []RetentionPolicy `json:"storage:retention,omitempty"`
+	StorageReplicaUrl         string            `json:"storage:replicaurl,omitempty"`
+	StorageReplicaProfile     string            `json:"storage:replicaprofile,omitempty"`
+	StorageReplicaRegion      string            `json:"storage:replicaregion,omitempty"`
+	StorageReplicaEndpoint    string            `json:"storage:replicaendpoint,omitempty"`
+	StorageReplicaOffloadDays float64           `json:"storage:replicaoffloaddays,omitempty"`
+	StorageCacheBytes         *int64            `json:"storage:cachebytes,omitempty"`
+	StorageCachePolicy        string            `json:"storage:cachepolicy,omitempty"`
+
+	NotifyClear    bool   `json:"notify:*,omitempty"`
+	NotifyDnd      bool   `json:"notify:dnd,omitempty"`
+	NotifyDndStart string `json:"notify:dndstart,omitempty"`
+	NotifyDndEnd   string `json:"notify:dndend,omitempty"`
+
+	ApiClear          bool   `json:"api:*,omitempty"`
+	ApiEnabled        bool   `json:"api:enabled,omitempty"`
+	ApiListenAddr     string `json:"api:listenaddr,omitempty"`
+	ApiSocket         *bool  `json:"api:socket,omitempty"`
+	ApiGrpc           bool   `json:"api:grpc,omitempty"`
+	ApiGrpcListenAddr string `json:"api:grpclistenaddr,omitempty"`
+
+	RemoteClear        bool     `json:"remote:*,omitempty"`
+	RemoteEnabled      bool     `json:"remote:enabled,omitempty"`
+	RemoteListenAddr   string   `json:"remote:listenaddr,omitempty"`
+	RemoteWorkspaces   []string `json:"remote:workspaces,omitempty"`
+	RemoteSessionHours float64  `json:"remote:sessionhours,omitempty"`
+	RemoteTlsCert      string   `json:"remote:tlscert,omitempty"`
+	RemoteTlsKey       string   `json:"remote:tlskey,omitempty"`
+	RemoteTlsClientCa  string   `json:"remote:tlsclientca,omitempty"`
+
+	SettingsClear   bool     `json:"settings:*,omitempty"`
+	SettingsLocked  []string `json:"settings:locked,omitempty"`  // settings the connections and blocks can't override (see wsettings)
+	SettingsVersion int      `json:"settings:version,omitempty"` // the version of the settings schema the file was written for (see migrate.go)
 }
 
 type ConfigError struct {
 	File string `json:"file"`
 	Err  string `json:"err"`
+	Line int    `json:"line,omitempty"` // the position of the error in the file (if known)
+	Col  int    `json:"col,omitempty"`
+	Key  string `json:"key,omitempty"` // the setting with the error (an unknown setting, or a value of the wrong type)
+}
+
+func (cerr ConfigError) String() string {
+	if cerr.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", cerr.File, cerr.Line, cerr.Col, cerr.Err)
+	}
+	return fmt.Sprintf("%s: %s", cerr.File, cerr.Err)
+}
+
+// the files of blocks that are deleted by the retention job (see pkg/fileretention)
+type RetentionPolicy struct {
+	Tag        string  `json:"tag,omitempty"`        // only the blocks with this tag (in their "tags"), it replaces the policy without a tag for the same files
+	Files      string  `json:"files"`                // a file name, or a prefix ending in * ("cast:*")
+	MaxAgeDays float64 `json:"maxagedays,omitempty"` // the files not written to in this many days (the term output is cleared)
+	KeepLast   int     `json:"keeplast,omitempty"`   // only the newest files are kept
 }
 
 type WebBookmark struct {
@@ -161,8 +237,10 @@ type FullConfigType struct {
 	Presets        map[string]waveobj.MetaMapType `json:"presets"`
 	TermThemes     map[string]TermThemeType       `json:"termthemes"`
 	Connections    map[string]ConnKeywords        `json:"connections"`
+	ConnProfiles   map[string]ConnKeywords        `json:"connprofiles"` // by tag, see applyConnProfiles
 	Bookmarks      map[string]WebBookmark         `json:"bookmarks"`
 	ConfigErrors   []ConfigError                  `json:"configerrors" configfile:"-"`
+	Migrations     []SettingsMigration            `json:"migrations,omitempty" configfile:"-"` // the settings migrated when the files were read
 }
 type ConnKeywords struct {
 	ConnWshEnabled          *bool  `json:"conn:wshenabled,omitempty"`
@@ -174,10 +252,14 @@ type ConnKeywords struct {
 	DisplayHidden *bool   `json:"display:hidden,omitempty"`
 	DisplayOrder  float32 `json:"display:order,omitempty"`
 
-	TermClear      bool    `json:"term:*,omitempty"`
-	TermFontSize   float64 `json:"term:fontsize,omitempty"`
-	TermFontFamily string  `json:"term:fontfamily,omitempty"`
-	TermTheme      string  `json:"term:theme,omitempty"`
+	TermClear      bool     `json:"term:*,omitempty"`
+	TermFontSize   float64  `json:"term:fontsize,omitempty"`
+	TermFontFamily string   `json:"term:fontfamily,omitempty"`
+	TermLineHeight *float64 `json:"term:lineheight,omitempty"`
+	TermLigatures  *bool    `json:"term:ligatures,omitempty"`
+	TermTheme      string   `json:"term:theme,omitempty"`
+	TermOsc52      string   `json:"term:osc52,omitempty"`
+	TermTermType   string   `json:"term:termtype,omitempty"`
 
 	CmdEnv            map[string]string `json:"cmd:env,omitempty"`
 	CmdInitScript     string            `json:"cmd:initscript,omitempty"`
@@ -202,6 +284,24 @@ type ConnKeywords struct {
 	SshProxyJump                    []string `json:"ssh:proxyjump,omitempty"`
 	SshUserKnownHostsFile           []string `json:"ssh:userknownhostsfile,omitempty"`
 	SshGlobalKnownHostsFile         []string `json:"ssh:globalknownhostsfile,omitempty"`
+	SshForwardAgent                 *bool    `json:"ssh:forwardagent,omitempty"`
+
+	ConnKeepAliveInterval *float64 `json:"conn:keepaliveinterval,omitempty"`
+	ConnKeepAliveCountMax *int     `json:"conn:keepalivecountmax,omitempty"`
+	ConnAutoReconnect     *bool    `json:"conn:autoreconnect,omitempty"`
+	ConnTransport         string   `json:"conn:transport,omitempty"`
+	ConnRoamPorts         string   `json:"conn:roamports,omitempty"`
+	ConnTags              []string `json:"conn:tags,omitempty"`
+	CmdCwd                string   `json:"cmd:cwd,omitempty"` // the cwd of new shells on the connection
+
+	SshCertificateFile      []string `json:"ssh:certificatefile,omitempty"`
+	SshGSSAPIAuthentication *bool    `json:"ssh:gssapiauthentication,omitempty"`
+
+	// the names of secrets (see pkg/secretstore), never the values
+	SshPasswordSecret   string            `json:"ssh:passwordsecret,omitempty"`
+	SshPassphraseSecret string            `json:"ssh:passphrasesecret,omitempty"`
+	ConnSecretEnv       map[string]string `json:"conn:secretenv,omitempty"`  // env var -> secret, set in the shells on the connection
+	ConnSudoSecret      string            `json:"conn:sudosecret,omitempty"` // the sudo password, for the commands and file operations run with sudo
 }
 
 func DefaultBoolPtr(arg *bool, def bool) bool {
@@ -257,10 +357,12 @@ func readConfigHelper(fileName string, barr []byte, readErr error) (waveobj.Meta
 			lineNum, colNum := utilfn.GetLineColFromOffset(barr, int(offset))
 			isTrailingComma := isTrailingCommaError(barr, int(offset))
 			if isTrailingComma {
-				err = fmt.Errorf("json syntax error at line %d, col %d: probably an extra trailing comma: %v", lineNum, colNum, syntaxErr)
+				err = fmt.Errorf("json syntax error: probably an extra trailing comma: %v", syntaxErr)
 			} else {
-				err = fmt.Errorf("json syntax error at line %d, col %d: %v", lineNum, colNum, syntaxErr)
+				err = fmt.Errorf("json syntax error: %v", syntaxErr)
 			}
+			cerrs = append(cerrs, ConfigError{File: fileName, Err: err.Error(), Line: lineNum, Col: colNum})
+			return rtn, cerrs
 		}
 		cerrs = append(cerrs, ConfigError{File: fileName, Err: err.Error()})
 	}
@@ -273,7 +375,34 @@ func readConfigFileFS(fsys fs.FS, logPrefix string, fileName string) (waveobj.Me
 		// If we get an error, we may be using the wrong path separator for the given FS interface. Try switching the separator.
 		barr, readErr = fs.ReadFile(fsys, filepath.ToSlash(fileName))
 	}
-	return readConfigHelper(logPrefix+fileName, barr, readErr)
+	rtn, cerrs := readConfigHelper(logPrefix+fileName, barr, readErr)
+	if len(cerrs) == 0 && rtn != nil && isSettingsFileName(fileName) {
+		// the old settings are migrated, the invalid settings are dropped (and reported), so the rest of the file
+		// still applies
+		changes, err := MigrateSettings(rtn)
+		if err != nil {
+			cerrs = append(cerrs, ConfigError{File: logPrefix + fileName, Err: err.Error(), Key: ConfigKey_SettingsVersion})
+		}
+		migrated := migratedKeys(changes)
+		for _, verr := range validateSettingsFile(logPrefix+fileName, barr) {
+			if migrated[verr.Key] {
+				continue
+			}
+			delete(rtn, verr.Key)
+			cerrs = append(cerrs, verr)
+		}
+		for key := range migrated {
+			val, ok := rtn[key]
+			if !ok {
+				continue
+			}
+			if err := CheckSettingValue(key, val); err != nil {
+				delete(rtn, key)
+				cerrs = append(cerrs, ConfigError{File: logPrefix + fileName, Err: err.Error(), Key: key})
+			}
+		}
+	}
+	return rtn, cerrs
 }
 
 func ReadDefaultsConfigFile(fileName string) (waveobj.MetaMapType, []ConfigError) {
@@ -382,6 +511,15 @@ func readConfigPart(partName string, simpleMerge bool) (waveobj.MetaMapType, []C
 	return mergeMetaMap(rtn, homeConfigs, simpleMerge), allErrs
 }
 
+// the settings of the defaults and of the settings files of the user (settings.json and settings/*.json), read
+// again from the files (the watcher has them merged, in GetFullConfig().Settings)
+func ReadSettingsParts() (defaults waveobj.MetaMapType, user waveobj.MetaMapType) {
+	configDirFsys := os.DirFS(wavebase.GetWaveConfigDir())
+	defaults, _ = readConfigPartForFS(defaultconfig.ConfigFS, "defaults:", "settings", true)
+	user, _ = readConfigPartForFS(configDirFsys, "", "settings", true)
+	return defaults, user
+}
+
 // this function should only be called by the wconfig code.
 // in golang code, the best way to get the current config is via the watcher -- wconfig.GetWatcher().GetFullConfig()
 func ReadFullConfig() FullConfigType {
@@ -412,9 +550,86 @@ func ReadFullConfig() FullConfigType {
 			utilfn.ReUnmarshal(fieldPtr, configPart)
 		}
 	}
+	fullConfig.ConfigErrors = append(fullConfig.ConfigErrors, applyConnProfiles(&fullConfig)...)
+	fullConfig.Migrations = GetSettingsMigrations()
+	if themesFn := getTermThemesOverlay(); themesFn != nil {
+		if fullConfig.TermThemes == nil {
+			fullConfig.TermThemes = make(map[string]TermThemeType)
+		}
+		for name, theme := range themesFn() {
+			fullConfig.TermThemes[name] = theme
+		}
+	}
 	return fullConfig
 }
 
+var termThemesOverlayLock = &sync.Mutex{}
+var termThemesOverlay func() map[string]TermThemeType
+
+// the themes defined in wave (see wtheme), added to (or replacing) the ones of termthemes.json when the config is
+// read.  fn must not block, call Watcher.Reload when they change.
+func SetTermThemesOverlay(fn func() map[string]TermThemeType) {
+	termThemesOverlayLock.Lock()
+	defer termThemesOverlayLock.Unlock()
+	termThemesOverlay = fn
+}
+
+func getTermThemesOverlay() func() map[string]TermThemeType {
+	termThemesOverlayLock.Lock()
+	defer termThemesOverlayLock.Unlock()
+	return termThemesOverlay
+}
+
+// a connection inherits the keywords of the profiles of its tags (conn:tags, in order, a later profile overrides an
+// earlier one), its own keywords override them.  cmd:env is merged by variable.
+func applyConnProfiles(fullConfig *FullConfigType) []ConfigError {
+	var errs []ConfigError
+	for connName, connKeywords := range fullConfig.Connections {
+		if len(connKeywords.ConnTags) == 0 {
+			continue
+		}
+		merged := make(map[string]any)
+		mergedEnv := make(map[string]string)
+		layers := make([]ConnKeywords, 0, len(connKeywords.ConnTags)+1)
+		for _, tag := range connKeywords.ConnTags {
+			profile, ok := fullConfig.ConnProfiles[tag]
+			if !ok {
+				errs = append(errs, ConfigError{File: ConnectionsFile, Err: fmt.Sprintf("connection %q: no profile for tag %q in %s", connName, tag, ConnProfilesFile)})
+				continue
+			}
+			layers = append(layers, profile)
+		}
+		layers = append(layers, connKeywords)
+		for _, layer := range layers {
+			layerMap := make(map[string]any)
+			err := utilfn.ReUnmarshal(&layerMap, layer)
+			if err != nil {
+				log.Printf("error re-unmarshalling conn keywords: %v\n", err)
+				continue
+			}
+			delete(layerMap, "conn:tags")
+			for key, val := range layerMap {
+				merged[key] = val
+			}
+			for envKey, envVal := range layer.CmdEnv {
+				mergedEnv[envKey] = envVal
+			}
+		}
+		var rtn ConnKeywords
+		err := utilfn.ReUnmarshal(&rtn, merged)
+		if err != nil {
+			log.Printf("error re-unmarshalling conn keywords: %v\n", err)
+			continue
+		}
+		rtn.ConnTags = connKeywords.ConnTags
+		if len(mergedEnv) > 0 {
+			rtn.CmdEnv = mergedEnv
+		}
+		fullConfig.Connections[connName] = rtn
+	}
+	return errs
+}
+
 func GetConfigSubdirs() []string {
 	var fullConfig FullConfigType
 	configRType := reflect.TypeOf(fullConfig)
@@ -450,6 +665,28 @@ func getConfigKeyType(configKey string) reflect.Type {
 	return nil
 }
 
+// a key of settings.json (not a "ns:*" clear key)
+func IsSettingKey(key string) bool {
+	return getConfigKeyType(key) != nil && !strings.HasSuffix(key, ":*")
+}
+
+// checks that the key is a setting (not a "ns:*" clear key), that the value can be read as its type, and that it
+// is in its range (see checkSettingConstraints)
+func CheckSettingValue(key string, val any) error {
+	ctype := getConfigKeyType(key)
+	if ctype == nil || strings.HasSuffix(key, ":*") {
+		return fmt.Errorf("invalid setting: %s", key)
+	}
+	barr, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %v", key, err)
+	}
+	if err := json.Unmarshal(barr, reflect.New(ctype).Interface()); err != nil {
+		return fmt.Errorf("invalid value for %s: %s", key, string(barr))
+	}
+	return checkSettingConstraints(key, val)
+}
+
 func getConfigKeyNamespace(key string) string {
 	colonIdx := strings.Index(key, ":")
 	if colonIdx == -1 {
@@ -627,28 +864,31 @@ type MimeTypeConfigType struct {
 }
 
 type TermThemeType struct {
-	DisplayName         string  `json:"display:name"`
-	DisplayOrder        float64 `json:"display:order"`
-	Black               string  `json:"black"`
-	Red                 string  `json:"red"`
-	Green               string  `json:"green"`
-	Yellow              string  `json:"yellow"`
-	Blue                string  `json:"blue"`
-	Magenta             string  `json:"magenta"`
-	Cyan                string  `json:"cyan"`
-	White               string  `json:"white"`
-	BrightBlack         string  `json:"brightBlack"`
-	BrightRed           string  `json:"brightRed"`
-	BrightGreen         string  `json:"brightGreen"`
-	BrightYellow        string  `json:"brightYellow"`
-	BrightBlue          string  `json:"brightBlue"`
-	BrightMagenta       string  `json:"brightMagenta"`
-	BrightCyan          string  `json:"brightCyan"`
-	BrightWhite         string  `json:"brightWhite"`
-	Gray                string  `json:"gray"`
-	CmdText             string  `json:"cmdtext"`
-	Foreground          string  `json:"foreground"`
-	SelectionBackground string  `json:"selectionBackground"`
-	Background          string  `json:"background"`
-	Cursor              string  `json:"cursor"`
+	DisplayName         string   `json:"display:name"`
+	DisplayOrder        float64  `json:"display:order"`
+	Black               string   `json:"black"`
+	Red                 string   `json:"red"`
+	Green               string   `json:"green"`
+	Yellow              string   `json:"yellow"`
+	Blue                string   `json:"blue"`
+	Magenta             string   `json:"magenta"`
+	Cyan                string   `json:"cyan"`
+	White               string   `json:"white"`
+	BrightBlack         string   `json:"brightBlack"`
+	BrightRed           string   `json:"brightRed"`
+	BrightGreen         string   `json:"brightGreen"`
+	BrightYellow        string   `json:"brightYellow"`
+	BrightBlue          string   `json:"brightBlue"`
+	BrightMagenta       string   `json:"brightMagenta"`
+	BrightCyan          string   `json:"brightCyan"`
+	BrightWhite         string   `json:"brightWhite"`
+	Gray                string   `json:"gray"`
+	CmdText             string   `json:"cmdtext"`
+	Foreground          string   `json:"foreground"`
+	SelectionBackground string   `json:"selectionBackground"`
+	Background          string   `json:"background"`
+	Cursor              string   `json:"cursor"`
+	CursorAccent        string   `json:"cursorAccent,omitempty"`
+	ExtendedAnsi        []string `json:"extendedAnsi,omitempty"` // the colors 16-255 of the 256 color palette (xterm's are used if not set)
+	Accent              string   `json:"accent,omitempty"`       // the border of the focused block
 }
@@ -0,0 +1,148 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wconfig
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig/defaultconfig"
+)
+
+func TestApplyConnProfiles(t *testing.T) {
+	wshEnabled := false
+	fullConfig := FullConfigType{
+		ConnProfiles: map[string]ConnKeywords{
+			"prod":  {CmdEnv: map[string]string{"PROMPT_COLOR": "red", "STAGE": "prod"}, CmdCwd: "/srv", TermTheme: "redalert"},
+			"linux": {ConnShellPath: "/bin/bash", CmdEnv: map[string]string{"STAGE": "linux"}},
+		},
+		Connections: map[string]ConnKeywords{
+			"web1":  {ConnTags: []string{"prod", "linux"}, CmdEnv: map[string]string{"HOSTROLE": "web"}, CmdCwd: "~/app", ConnWshEnabled: &wshEnabled},
+			"dev":   {ConnTags: []string{"missing"}, TermTheme: "dark"},
+			"plain": {TermTheme: "light"},
+		},
+	}
+	errs := applyConnProfiles(&fullConfig)
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error for the missing tag, got %v", errs)
+	}
+	web1 := fullConfig.Connections["web1"]
+	wantEnv := map[string]string{"PROMPT_COLOR": "red", "STAGE": "linux", "HOSTROLE": "web"}
+	if len(web1.CmdEnv) != len(wantEnv) {
+		t.Errorf("got env %v, want %v", web1.CmdEnv, wantEnv)
+	}
+	for key, val := range wantEnv {
+		if web1.CmdEnv[key] != val {
+			t.Errorf("env %s: got %q, want %q", key, web1.CmdEnv[key], val)
+		}
+	}
+	if web1.CmdCwd != "~/app" || web1.TermTheme != "redalert" || web1.ConnShellPath != "/bin/bash" {
+		t.Errorf("unexpected merged keywords %+v", web1)
+	}
+	if web1.ConnWshEnabled == nil || *web1.ConnWshEnabled {
+		t.Errorf("expected conn:wshenabled false to be kept")
+	}
+	if len(web1.ConnTags) != 2 {
+		t.Errorf("expected the tags to be kept, got %v", web1.ConnTags)
+	}
+	if fullConfig.Connections["dev"].TermTheme != "dark" || fullConfig.Connections["plain"].TermTheme != "light" {
+		t.Errorf("unexpected keywords for connections without profiles")
+	}
+}
+
+func TestCheckSettingValue(t *testing.T) {
+	valid := map[string]any{
+		"term:scrollback":       float64(5000),
+		"term:localshellpath":   "/bin/zsh",
+		"term:localshellopts":   []any{"-l"},
+		"window:transparent":    true,
+		"term:transparency":     0.5,
+		"storage:maxblockbytes": int64(1024),
+		"term:lineheight":       1.2,
+		"term:fontsize":         int64(14),
+		"term:fontfamily":       "'Hack', monospace",
+		"app:locale":            "pt_BR",
+	}
+	for key, val := range valid {
+		if err := CheckSettingValue(key, val); err != nil {
+			t.Errorf("%s: unexpected error %v", key, err)
+		}
+	}
+	invalid := map[string]any{
+		"term:scrollback":     2.5,
+		"term:localshellpath": 5,
+		"window:transparent":  "yes",
+		"term:*":              true,
+		"term:nosuchsetting":  true,
+		"term:fontsize":       float64(200),
+		"term:lineheight":     0.1,
+		"app:locale":          "Portuguese",
+	}
+	for key, val := range invalid {
+		if err := CheckSettingValue(key, val); err == nil {
+			t.Errorf("%s: expected %v to be invalid", key, val)
+		}
+	}
+}
+
+func TestValidateSettingsFile(t *testing.T) {
+	barr := []byte("{\n    \"term:fontsize\": \"big\",\n    \"term:scrolback\": 5000,\n    \"term:localshellopts\": [\"-l\", 5],\n    \"window:blur\": true\n}\n")
+	m, cerrs := readConfigFileFS(fstest.MapFS{"settings.json": {Data: barr}}, "", "settings.json")
+	expected := []ConfigError{
+		{File: "settings.json", Line: 2, Col: 22, Key: "term:fontsize", Err: `invalid value for "term:fontsize": expected a number`},
+		{File: "settings.json", Line: 3, Col: 5, Key: "term:scrolback", Err: `unknown setting "term:scrolback" (did you mean "term:scrollback"?)`},
+		{File: "settings.json", Line: 4, Col: 28, Key: "term:localshellopts", Err: `invalid value for "term:localshellopts": expected an array of strings`},
+	}
+	if !reflect.DeepEqual(cerrs, expected) {
+		t.Errorf("expected %v, got %v", expected, cerrs)
+	}
+	if len(m) != 1 || m["window:blur"] != true {
+		t.Errorf("expected the invalid settings to be dropped, got %v", m)
+	}
+	_, cerrs = readConfigHelper("settings.json", []byte("{\n  \"window:blur\": true,\n}"), nil)
+	if len(cerrs) != 1 || cerrs[0].Line != 3 || cerrs[0].Col != 1 {
+		t.Errorf("expected a syntax error at 3:1, got %v", cerrs)
+	}
+	// the defaults must be valid
+	_, cerrs = readConfigPartForFS(defaultconfig.ConfigFS, "defaults:", "settings", true)
+	if len(cerrs) > 0 {
+		t.Errorf("expected no errors in the default settings, got %v", cerrs)
+	}
+}
+
+func TestMigrateSettings(t *testing.T) {
+	barr := []byte("{\n    \"term:confirmpaste\": false,\n    \"term:bracketedpaste\": true,\n    \"term:allowbracketedpaste\": false,\n    \"term:localshellopts\": \"-l  -i\",\n    \"remote:workspaces\": \"work, prod,\"\n}\n")
+	m, cerrs := readConfigFileFS(fstest.MapFS{"settings.json": {Data: barr}}, "", "settings.json")
+	if len(cerrs) > 0 {
+		t.Errorf("expected the old settings to be migrated without errors, got %v", cerrs)
+	}
+	expected := waveobj.MetaMapType{
+		"term:safepaste":           false,
+		"term:allowbracketedpaste": false,
+		"term:localshellopts":      []any{"-l", "-i"},
+		"remote:workspaces":        []any{"work", "prod"},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %v, got %v", expected, m)
+	}
+	changes, err := MigrateSettings(waveobj.MetaMapType{"settings:version": float64(1), "term:confirmpaste": true, "term:localshellopts": "-l"})
+	if err != nil || len(changes) != 1 || changes[0].Key != "term:localshellopts" || changes[0].Version != 2 {
+		t.Errorf("expected only the migrations after version 1, got %v (%v)", changes, err)
+	}
+	if _, err := MigrateSettings(waveobj.MetaMapType{"settings:version": float64(SettingsVersion + 1)}); err == nil {
+		t.Errorf("expected an error for settings from a newer version")
+	}
+}
+
+func TestChangedSettingsKeys(t *testing.T) {
+	oldSettings := SettingsType{TermFontSize: 12, TermScrollback: nil}
+	scrollback := int64(5000)
+	newSettings := SettingsType{TermFontSize: 13, TermScrollback: &scrollback}
+	keys := changedSettingsKeys(oldSettings, newSettings)
+	if !reflect.DeepEqual(keys, []string{"term:fontsize", "term:scrollback"}) {
+		t.Errorf("unexpected changed keys: %v", keys)
+	}
+}
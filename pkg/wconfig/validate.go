@@ -0,0 +1,185 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/util/fontutil"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+)
+
+// a language tag ("de", "pt-BR"), see wi18n.NormalizeLocale
+var localeRe = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// settings.json or a file in the settings dir (settings/*.json)
+func isSettingsFileName(fileName string) bool {
+	fileName = filepath.ToSlash(fileName)
+	return fileName == SettingsFile || path.Dir(fileName) == "settings"
+}
+
+// checks the settings of a settings file against SettingsType (which is also what schema/settings.json is generated
+// from).  an unknown setting is reported at its key, a value of the wrong type at the value.  barr must be valid
+// json, syntax errors are reported by readConfigHelper.
+func validateSettingsFile(fileName string, barr []byte) []ConfigError {
+	dec := json.NewDecoder(bytes.NewReader(barr))
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('{') {
+		return nil
+	}
+	var cerrs []ConfigError
+	for dec.More() {
+		keyOffset := skipJsonSeparators(barr, int(dec.InputOffset()))
+		tok, err := dec.Token()
+		if err != nil {
+			return cerrs
+		}
+		key, _ := tok.(string)
+		valOffset := skipJsonSeparators(barr, int(dec.InputOffset()))
+		var rawVal json.RawMessage
+		if err := dec.Decode(&rawVal); err != nil {
+			return cerrs
+		}
+		ctype := getConfigKeyType(key)
+		if ctype == nil {
+			errStr := fmt.Sprintf("unknown setting %q", key)
+			if suggestion := suggestSettingKey(key); suggestion != "" {
+				errStr += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			line, col := utilfn.GetLineColFromOffset(barr, keyOffset)
+			cerrs = append(cerrs, ConfigError{File: fileName, Err: errStr, Line: line, Col: col, Key: key})
+			continue
+		}
+		if err := json.Unmarshal(rawVal, reflect.New(ctype).Interface()); err != nil {
+			line, col := utilfn.GetLineColFromOffset(barr, valOffset)
+			errStr := fmt.Sprintf("invalid value for %q: expected %s", key, describeJsonType(ctype))
+			cerrs = append(cerrs, ConfigError{File: fileName, Err: errStr, Line: line, Col: col, Key: key})
+			continue
+		}
+		var val any
+		json.Unmarshal(rawVal, &val)
+		if err := checkSettingConstraints(key, val); err != nil {
+			line, col := utilfn.GetLineColFromOffset(barr, valOffset)
+			cerrs = append(cerrs, ConfigError{File: fileName, Err: err.Error(), Line: line, Col: col, Key: key})
+		}
+	}
+	return cerrs
+}
+
+type settingRange struct {
+	min float64
+	max float64
+}
+
+// the number settings with a sane range (a font size of 0 or a line height of 10 leaves a view unusable)
+var settingRanges = map[string]settingRange{
+	ConfigKey_TermFontSize:          {4, 64},
+	ConfigKey_TermLineHeight:        {0.5, 3},
+	ConfigKey_TermTransparency:      {0, 1},
+	ConfigKey_EditorFontSize:        {4, 64},
+	ConfigKey_AiFontSize:            {4, 64},
+	ConfigKey_AiFixedFontSize:       {4, 64},
+	ConfigKey_MarkdownFontSize:      {4, 64},
+	ConfigKey_MarkdownFixedFontSize: {4, 64},
+}
+
+// checks a value (of the type of the setting) beyond its type: the ranges of settingRanges, and that a font of
+// term:fontfamily is installed
+func checkSettingConstraints(key string, val any) error {
+	if val == nil {
+		return nil
+	}
+	if rng, ok := settingRanges[key]; ok {
+		num, ok := utilfn.ToFloat64(val)
+		if ok && (num < rng.min || num > rng.max) {
+			return fmt.Errorf("invalid value for %q: %v is out of range (%v to %v)", key, val, rng.min, rng.max)
+		}
+	}
+	if key == ConfigKey_AppLocale {
+		if locale, ok := val.(string); ok && locale != "" && !localeRe.MatchString(locale) {
+			return fmt.Errorf("invalid value for %q: %q is not a locale (e.g. \"de\" or \"pt-BR\")", key, locale)
+		}
+	}
+	if key == ConfigKey_TermFontFamily {
+		if fontFamily, ok := val.(string); ok {
+			if err := fontutil.CheckFontFamily(fontFamily); err != nil {
+				return fmt.Errorf("invalid value for %q: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// the offset of the next token (after the whitespace, and the ":" or "," the decoder has not read yet)
+func skipJsonSeparators(barr []byte, offset int) int {
+	for offset < len(barr) && strings.IndexByte(" \t\r\n:,", barr[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func describeJsonType(rtype reflect.Type) string {
+	switch rtype.Kind() {
+	case reflect.Pointer:
+		return describeJsonType(rtype.Elem())
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64:
+		return "a whole number"
+	case reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		elemStr := describeJsonType(rtype.Elem())
+		return "an array of " + strings.TrimPrefix(strings.TrimPrefix(elemStr, "a "), "an ") + "s"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return rtype.String()
+}
+
+// the setting with the closest name (a typo or two away), "" if there is none
+func suggestSettingKey(key string) string {
+	var best string
+	bestDist := 3
+	ctype := reflect.TypeOf(SettingsType{})
+	for i := 0; i < ctype.NumField(); i++ {
+		name := utilfn.GetJsonTag(ctype.Field(i))
+		if name == "" || name == "-" || strings.HasSuffix(name, ":*") {
+			continue
+		}
+		if dist := editDistance(key, name); dist < bestDist {
+			best, bestDist = name, dist
+		}
+	}
+	return best
+}
+
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,87 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wconn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// runs a non-interactive command on a connection (a saved connection or an ssh connection name), connecting it
+// first if needed.  outputFn (if set) gets the output as it comes, the result has it too (up to MaxOutput bytes).
+func RunCommand(ctx context.Context, data wshrpc.CommandConnectionRunData, outputFn func(isStderr bool, data []byte)) (*wshrpc.ConnectionRunResult, error) {
+	if strings.TrimSpace(data.Cmd) == "" {
+		return nil, fmt.Errorf("no command to run")
+	}
+	if data.Connection == "" {
+		return nil, fmt.Errorf("connection is required")
+	}
+	connName := ResolveConnName(ctx, data.Connection)
+	if data.TimeoutMs > 0 {
+		var cancelFn context.CancelFunc
+		ctx, cancelFn = context.WithTimeout(ctx, time.Duration(data.TimeoutMs)*time.Millisecond)
+		defer cancelFn()
+	}
+	opts := conncontroller.RunOpts{Cwd: data.Cwd, Env: data.Env, Stdin: data.Stdin, MaxOutput: data.MaxOutput}
+	if data.Sudo {
+		opts.Sudo = &conncontroller.SudoOpts{User: data.SudoUser, NoPrompt: data.NoPrompt}
+	}
+	startTs := time.Now()
+	result, err := conncontroller.RunCommand(ctx, connName, data.Cmd, opts, outputFn)
+	if err != nil {
+		return nil, err
+	}
+	return &wshrpc.ConnectionRunResult{
+		Connection:      data.Connection,
+		ConnName:        connName,
+		ExitCode:        result.ExitCode,
+		Signal:          result.Signal,
+		Stdout:          string(result.Stdout),
+		Stderr:          string(result.Stderr),
+		StdoutTruncated: result.StdoutTruncated,
+		StderrTruncated: result.StderrTruncated,
+		TimedOut:        result.TimedOut,
+		DurationMs:      time.Since(startTs).Milliseconds(),
+	}, nil
+}
+
+// like RunCommand, the output is sent as it comes and the result is the last message
+func RunCommandStream(ctx context.Context, data wshrpc.CommandConnectionRunData) <-chan wshrpc.RespOrErrorUnion[wshrpc.ConnectionRunOutput] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.ConnectionRunOutput], 32)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("wconn:RunCommandStream", recover())
+		}()
+		defer close(rtn)
+		outputFn := func(isStderr bool, output []byte) {
+			var msg wshrpc.ConnectionRunOutput
+			if isStderr {
+				msg.Stderr = string(output)
+			} else {
+				msg.Stdout = string(output)
+			}
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.ConnectionRunOutput]{Response: msg}
+		}
+		// the output was streamed, it is not sent again with the result
+		data.MaxOutput = 1
+		result, err := RunCommand(ctx, data, outputFn)
+		if err != nil {
+			rtn <- wshutil.RespErr[wshrpc.ConnectionRunOutput](err)
+			return
+		}
+		result.Stdout = ""
+		result.Stderr = ""
+		result.StdoutTruncated = false
+		result.StderrTruncated = false
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.ConnectionRunOutput]{Response: wshrpc.ConnectionRunOutput{Result: result}}
+	}()
+	return rtn
+}
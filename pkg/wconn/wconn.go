@@ -0,0 +1,506 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wconn manages the ssh connections the user saves (host, user, port, auth method and tags) as wave
+// objects, so a terminal on a connection can be opened by its name.  a block uses a connection by having its conn
+// name ("user@host:port") in its "connection" meta, so the blocks that use a connection are tracked by their meta
+// (they are moved to the new conn name when the host, user or port of the connection changes).  the auth method,
+// identity file, jump hosts and agent forwarding are saved in connections.json under the conn name, so every
+// connect to it (from any block) uses them.  a jump host is a saved connection (by name, so it is connected with its
+// own auth) or a "[user@]host[:port]".  the health of a connection is the status of its ssh connection.
+package wconn
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/secretstore"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	AuthMethod_Default  = ""
+	AuthMethod_Key      = "key"      // the identity file (or the keys from the ssh config)
+	AuthMethod_Agent    = "agent"    // the keys in the ssh agent
+	AuthMethod_Password = "password" // a password (or keyboard-interactive), asked for when connecting
+	AuthMethod_GSSAPI   = "gssapi"   // the kerberos ticket of the user (gssapi-with-mic), kinit before connecting
+)
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// the name of the ssh connection (the "connection" meta of the blocks that use it)
+func ConnName(conn *waveobj.Connection) string {
+	return remote.SSHOpts{SSHHost: conn.Host, SSHUser: conn.User, SSHPort: conn.Port}.String()
+}
+
+// normalizes the fields of the connection, and checks them
+func validateConnection(conn *waveobj.Connection) error {
+	conn.Name = strings.TrimSpace(conn.Name)
+	conn.Host = strings.TrimSpace(conn.Host)
+	conn.User = strings.TrimSpace(conn.User)
+	conn.Port = strings.TrimSpace(conn.Port)
+	if conn.Port == "22" {
+		conn.Port = ""
+	}
+	if !nameRe.MatchString(conn.Name) {
+		return fmt.Errorf("invalid name %q (letters, digits, '.', '_' and '-')", conn.Name)
+	}
+	if conn.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if conn.Port != "" {
+		port, err := strconv.Atoi(conn.Port)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %q", conn.Port)
+		}
+	}
+	opts, err := remote.ParseOpts(ConnName(conn))
+	if err != nil || opts.SSHHost != conn.Host || opts.SSHUser != conn.User {
+		return fmt.Errorf("invalid host or user %q", ConnName(conn))
+	}
+	switch conn.AuthMethod {
+	case AuthMethod_Default, AuthMethod_Key, AuthMethod_Agent, AuthMethod_Password, AuthMethod_GSSAPI:
+	default:
+		return fmt.Errorf("invalid auth method %q (key, agent, password or gssapi)", conn.AuthMethod)
+	}
+	if conn.IdentityFile != "" && conn.AuthMethod != AuthMethod_Key {
+		return fmt.Errorf("an identity file is only used with the key auth method")
+	}
+	conn.CertificateFile = strings.TrimSpace(conn.CertificateFile)
+	if conn.CertificateFile != "" && conn.AuthMethod != AuthMethod_Key && conn.AuthMethod != AuthMethod_Agent {
+		return fmt.Errorf("a certificate file is only used with the key or agent auth methods")
+	}
+	conn.Secret = strings.TrimSpace(conn.Secret)
+	if conn.Secret != "" {
+		if conn.AuthMethod != AuthMethod_Key && conn.AuthMethod != AuthMethod_Password {
+			return fmt.Errorf("a secret is only used with the key (passphrase) or password auth methods")
+		}
+		if err := secretstore.ValidateName(conn.Secret); err != nil {
+			return err
+		}
+	}
+	var tags []string
+	for _, tag := range conn.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !utilfn.ContainsStr(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	conn.Tags = tags
+	var hops []string
+	for _, hop := range conn.ProxyJump {
+		hop = strings.TrimSpace(hop)
+		if hop != "" {
+			hops = append(hops, hop)
+		}
+	}
+	conn.ProxyJump = hops
+	return nil
+}
+
+// the conn names of the jump hosts of the connection (the hops that are the names of saved connections are their
+// conn names)
+func resolveProxyJump(conns []*waveobj.Connection, conn *waveobj.Connection) ([]string, error) {
+	var rtn []string
+	for _, hop := range conn.ProxyJump {
+		if hop == conn.Name {
+			return nil, fmt.Errorf("connection %q can not be its own jump host", conn.Name)
+		}
+		hopConnName := ""
+		for _, other := range conns {
+			if other.Name == hop && other.OID != conn.OID {
+				hopConnName = ConnName(other)
+				break
+			}
+		}
+		if hopConnName == "" {
+			opts, err := remote.ParseProxyJumpOpts(hop)
+			if err != nil {
+				return nil, fmt.Errorf("jump host %q is not a saved connection or a [user@]host[:port]", hop)
+			}
+			hopConnName = opts.String()
+		}
+		if hopConnName == ConnName(conn) {
+			return nil, fmt.Errorf("connection %q can not be its own jump host", conn.Name)
+		}
+		rtn = append(rtn, hopConnName)
+	}
+	return rtn, nil
+}
+
+// the connections that have the connection as a jump host
+func findDependents(conns []*waveobj.Connection, conn *waveobj.Connection) []*waveobj.Connection {
+	var rtn []*waveobj.Connection
+	for _, other := range conns {
+		if other.OID != conn.OID && utilfn.ContainsStr(other.ProxyJump, conn.Name) {
+			rtn = append(rtn, other)
+		}
+	}
+	return rtn
+}
+
+// the connections.json keywords for the auth method, nil for the default (the ssh config is used)
+func AuthKeywords(conn *waveobj.Connection) waveobj.MetaMapType {
+	switch conn.AuthMethod {
+	case AuthMethod_Key:
+		rtn := waveobj.MetaMapType{
+			"ssh:preferredauthentications": []string{"publickey"},
+			"ssh:pubkeyauthentication":     true,
+			"ssh:identitiesonly":           conn.IdentityFile != "",
+		}
+		if conn.IdentityFile != "" {
+			rtn["ssh:identityfile"] = []string{conn.IdentityFile}
+		}
+		setCertificateKeyword(rtn, conn)
+		setSecretKeywords(rtn, "", conn.Secret)
+		return rtn
+	case AuthMethod_Agent:
+		rtn := waveobj.MetaMapType{
+			"ssh:preferredauthentications": []string{"publickey"},
+			"ssh:pubkeyauthentication":     true,
+			"ssh:identitiesonly":           false,
+		}
+		setCertificateKeyword(rtn, conn)
+		setSecretKeywords(rtn, "", "")
+		return rtn
+	case AuthMethod_Password:
+		rtn := waveobj.MetaMapType{
+			"ssh:preferredauthentications":     []string{"password", "keyboard-interactive"},
+			"ssh:passwordauthentication":       true,
+			"ssh:kbdinteractiveauthentication": true,
+		}
+		setSecretKeywords(rtn, conn.Secret, "")
+		return rtn
+	case AuthMethod_GSSAPI:
+		rtn := waveobj.MetaMapType{
+			"ssh:preferredauthentications": []string{"gssapi-with-mic"},
+			"ssh:gssapiauthentication":     true,
+		}
+		setSecretKeywords(rtn, "", "")
+		return rtn
+	}
+	return nil
+}
+
+// the password and passphrase secrets (nil removes the one of another auth method)
+func setSecretKeywords(keywords waveobj.MetaMapType, passwordSecret string, passphraseSecret string) {
+	keywords["ssh:passwordsecret"] = nil
+	if passwordSecret != "" {
+		keywords["ssh:passwordsecret"] = passwordSecret
+	}
+	keywords["ssh:passphrasesecret"] = nil
+	if passphraseSecret != "" {
+		keywords["ssh:passphrasesecret"] = passphraseSecret
+	}
+}
+
+// the certificate file of the connection (nil removes one that was saved)
+func setCertificateKeyword(keywords waveobj.MetaMapType, conn *waveobj.Connection) {
+	if conn.CertificateFile != "" {
+		keywords["ssh:certificatefile"] = []string{conn.CertificateFile}
+	} else {
+		keywords["ssh:certificatefile"] = nil
+	}
+}
+
+// saves the auth, jump host and agent forwarding keywords of the connection in connections.json (clearProxyJump
+// and clearForwardAgent remove the ones that were saved, so the ones from the ssh config are used)
+func saveConfigKeywords(ctx context.Context, conn *waveobj.Connection, clearProxyJump bool, clearForwardAgent bool) error {
+	keywords := AuthKeywords(conn)
+	if conn.ForwardAgent || clearForwardAgent {
+		if keywords == nil {
+			keywords = make(waveobj.MetaMapType)
+		}
+		if conn.ForwardAgent {
+			keywords["ssh:forwardagent"] = true
+		} else {
+			keywords["ssh:forwardagent"] = nil
+		}
+	}
+	if len(conn.ProxyJump) > 0 || clearProxyJump {
+		conns, err := ListConnections(ctx)
+		if err != nil {
+			return err
+		}
+		hops, err := resolveProxyJump(conns, conn)
+		if err != nil {
+			return err
+		}
+		if keywords == nil {
+			keywords = make(waveobj.MetaMapType)
+		}
+		keywords["ssh:proxyjump"] = hops
+	}
+	if keywords == nil {
+		return nil
+	}
+	err := wconfig.SetConnectionsConfigValue(ConnName(conn), keywords)
+	if err != nil {
+		return fmt.Errorf("error saving the connection config: %w", err)
+	}
+	return nil
+}
+
+// checks that no other connection has the name or the conn name
+func checkUnique(ctx context.Context, conn *waveobj.Connection) error {
+	conns, err := ListConnections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range conns {
+		if other.OID == conn.OID {
+			continue
+		}
+		if other.Name == conn.Name {
+			return fmt.Errorf("a connection named %q already exists", conn.Name)
+		}
+		if ConnName(other) == ConnName(conn) {
+			return fmt.Errorf("connection %q is already %s", other.Name, ConnName(conn))
+		}
+	}
+	return nil
+}
+
+func CreateConnection(ctx context.Context, conn *waveobj.Connection) (*waveobj.Connection, error) {
+	err := validateConnection(conn)
+	if err != nil {
+		return nil, err
+	}
+	err = checkUnique(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	conn.OID = uuid.NewString()
+	conn.CreatedTs = time.Now().UnixMilli()
+	err = saveConfigKeywords(ctx, conn, false, false)
+	if err != nil {
+		return nil, err
+	}
+	err = wstore.DBInsert(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// replaces the fields of the connection with the fields of update (except the oid), the blocks that use it are
+// moved to its new conn name, and the connections that have it as a jump host are updated
+func UpdateConnection(ctx context.Context, update *waveobj.Connection) (*waveobj.Connection, error) {
+	conn, err := wstore.DBMustGet[*waveobj.Connection](ctx, update.OID)
+	if err != nil {
+		return nil, err
+	}
+	err = validateConnection(update)
+	if err != nil {
+		return nil, err
+	}
+	err = checkUnique(ctx, update)
+	if err != nil {
+		return nil, err
+	}
+	oldName := conn.Name
+	oldConnName := ConnName(conn)
+	hadProxyJump := len(conn.ProxyJump) > 0
+	hadForwardAgent := conn.ForwardAgent
+	conn.Name = update.Name
+	conn.Host = update.Host
+	conn.User = update.User
+	conn.Port = update.Port
+	conn.AuthMethod = update.AuthMethod
+	conn.IdentityFile = update.IdentityFile
+	conn.CertificateFile = update.CertificateFile
+	conn.Secret = update.Secret
+	conn.Tags = update.Tags
+	conn.ProxyJump = update.ProxyJump
+	conn.ForwardAgent = update.ForwardAgent
+	err = saveConfigKeywords(ctx, conn, hadProxyJump, hadForwardAgent)
+	if err != nil {
+		return nil, err
+	}
+	err = wstore.DBUpdate(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if conn.Name != oldName || ConnName(conn) != oldConnName {
+		err = updateDependents(ctx, conn, oldName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ConnName(conn) != oldConnName {
+		blocks, err := findBlocks(ctx, oldConnName)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range blocks {
+			err = wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Block, block.OID), waveobj.MetaMapType{waveobj.MetaKey_Connection: ConnName(conn)}, false)
+			if err != nil {
+				return nil, fmt.Errorf("error updating block %s: %w", block.OID, err)
+			}
+		}
+	}
+	return conn, nil
+}
+
+// renames the jump host of the connections that have the connection as a jump host (it was oldName), and saves
+// their new jump hosts
+func updateDependents(ctx context.Context, conn *waveobj.Connection, oldName string) error {
+	conns, err := ListConnections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range conns {
+		if other.OID == conn.OID || !utilfn.ContainsStr(other.ProxyJump, oldName) {
+			continue
+		}
+		for idx, hop := range other.ProxyJump {
+			if hop == oldName {
+				other.ProxyJump[idx] = conn.Name
+			}
+		}
+		err = wstore.DBUpdate(ctx, other)
+		if err != nil {
+			return err
+		}
+		err = saveConfigKeywords(ctx, other, false, false)
+		if err != nil {
+			return fmt.Errorf("error updating connection %q: %w", other.Name, err)
+		}
+	}
+	return nil
+}
+
+// deletes the connection, unless blocks use it or it is the jump host of other connections (and force is not
+// set).  the blocks and the ssh connection are left as they are.
+func DeleteConnection(ctx context.Context, connId string, force bool) error {
+	conn, err := wstore.DBMustGet[*waveobj.Connection](ctx, connId)
+	if err != nil {
+		return err
+	}
+	if !force {
+		conns, err := ListConnections(ctx)
+		if err != nil {
+			return err
+		}
+		if dependents := findDependents(conns, conn); len(dependents) > 0 {
+			return fmt.Errorf("connection %q is the jump host of %q", conn.Name, dependents[0].Name)
+		}
+		blocks, err := findBlocks(ctx, ConnName(conn))
+		if err != nil {
+			return err
+		}
+		if len(blocks) > 0 {
+			return fmt.Errorf("connection %q is used by %d blocks", conn.Name, len(blocks))
+		}
+	}
+	return wstore.DBDelete(ctx, waveobj.OType_Connection, connId)
+}
+
+// the connections, by name
+func ListConnections(ctx context.Context) ([]*waveobj.Connection, error) {
+	conns, err := wstore.DBGetAllObjsByType[*waveobj.Connection](ctx, waveobj.OType_Connection)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].Name < conns[j].Name
+	})
+	return conns, nil
+}
+
+// the connection with the id or the name
+func ResolveConnection(ctx context.Context, idOrName string) (*waveobj.Connection, error) {
+	conns, err := ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, conn := range conns {
+		if conn.OID == idOrName || conn.Name == idOrName {
+			return conn, nil
+		}
+	}
+	return nil, fmt.Errorf("connection %q not found", idOrName)
+}
+
+// the ssh connection name of a saved connection (by id or name), or the name itself if it is not one
+func ResolveConnName(ctx context.Context, idOrName string) string {
+	if conn, err := ResolveConnection(ctx, idOrName); err == nil {
+		return ConnName(conn)
+	}
+	return idOrName
+}
+
+func findBlocks(ctx context.Context, connName string) ([]*waveobj.Block, error) {
+	blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []*waveobj.Block
+	for _, block := range blocks {
+		if block.Meta.GetString(waveobj.MetaKey_Connection, "") == connName {
+			rtn = append(rtn, block)
+		}
+	}
+	return rtn, nil
+}
+
+// the status of the ssh connection ("init" if it has not been used since wave started)
+func getStatus(connName string) wshrpc.ConnStatus {
+	for _, status := range conncontroller.GetAllConnStatus() {
+		if status.Connection == connName {
+			return status
+		}
+	}
+	return wshrpc.ConnStatus{Status: conncontroller.Status_Init, Connection: connName}
+}
+
+// the connection with its health and the blocks that use it
+func GetConnectionInfo(ctx context.Context, conn *waveobj.Connection) (*wshrpc.ConnectionInfo, error) {
+	connName := ConnName(conn)
+	blocks, err := findBlocks(ctx, connName)
+	if err != nil {
+		return nil, err
+	}
+	rtn := &wshrpc.ConnectionInfo{Connection: conn, ConnName: connName, Status: getStatus(connName)}
+	for _, block := range blocks {
+		rtn.BlockIds = append(rtn.BlockIds, block.OID)
+	}
+	return rtn, nil
+}
+
+// creates a terminal block on the connection in the tab
+func OpenTerm(ctx context.Context, conn *waveobj.Connection, tabId string) (*waveobj.Block, error) {
+	blockDef := &waveobj.BlockDef{
+		Meta: waveobj.MetaMapType{
+			waveobj.MetaKey_View:       "term",
+			waveobj.MetaKey_Controller: "shell",
+			waveobj.MetaKey_Connection: ConnName(conn),
+		},
+	}
+	block, err := wcore.CreateBlock(ctx, tabId, blockDef, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = wcore.QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
+		ActionType: wcore.LayoutActionDataType_Insert,
+		BlockId:    block.OID,
+		Focused:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error queuing layout action: %w", err)
+	}
+	return block, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wconn
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestValidateConnection(t *testing.T) {
+	conn := &waveobj.Connection{Name: " prod-db-3 ", Host: "10.0.3.12", User: "admin", Port: "22", Tags: []string{"prod", " prod", ""}}
+	err := validateConnection(conn)
+	if err != nil {
+		t.Fatalf("error validating connection: %v", err)
+	}
+	if conn.Name != "prod-db-3" || conn.Port != "" {
+		t.Errorf("connection was not normalized: %+v", conn)
+	}
+	if len(conn.Tags) != 1 || conn.Tags[0] != "prod" {
+		t.Errorf("tags should be [prod], got %v", conn.Tags)
+	}
+	if ConnName(conn) != "admin@10.0.3.12" {
+		t.Errorf("conn name should be admin@10.0.3.12, got %q", ConnName(conn))
+	}
+	conn.Port = "2222"
+	if ConnName(conn) != "admin@10.0.3.12:2222" {
+		t.Errorf("conn name should be admin@10.0.3.12:2222, got %q", ConnName(conn))
+	}
+	invalid := []*waveobj.Connection{
+		{Name: "", Host: "host"},
+		{Name: "a b", Host: "host"},
+		{Name: "db", Host: ""},
+		{Name: "db", Host: "host", Port: "70000"},
+		{Name: "db", Host: "bad host"},
+		{Name: "db", Host: "host", AuthMethod: "kerberos"},
+		{Name: "db", Host: "host", AuthMethod: AuthMethod_Agent, IdentityFile: "~/.ssh/id"},
+		{Name: "db", Host: "host", AuthMethod: AuthMethod_GSSAPI, CertificateFile: "~/.ssh/id-cert.pub"},
+		{Name: "db", Host: "host", AuthMethod: AuthMethod_Agent, Secret: "db-pass"},
+		{Name: "db", Host: "host", AuthMethod: AuthMethod_Password, Secret: "bad secret"},
+	}
+	for _, conn := range invalid {
+		if validateConnection(conn) == nil {
+			t.Errorf("connection should not be valid: %+v", conn)
+		}
+	}
+}
+
+func TestAuthKeywords(t *testing.T) {
+	if AuthKeywords(&waveobj.Connection{}) != nil {
+		t.Errorf("the default auth method should not set keywords")
+	}
+	keywords := AuthKeywords(&waveobj.Connection{AuthMethod: AuthMethod_Key, IdentityFile: "~/.ssh/prod"})
+	if keywords["ssh:identitiesonly"] != true {
+		t.Errorf("a key with an identity file should set identitiesonly")
+	}
+	if files, _ := keywords["ssh:identityfile"].([]string); len(files) != 1 || files[0] != "~/.ssh/prod" {
+		t.Errorf("identityfile should be [~/.ssh/prod], got %v", keywords["ssh:identityfile"])
+	}
+	keywords = AuthKeywords(&waveobj.Connection{AuthMethod: AuthMethod_Password, Secret: "db-pass"})
+	if auths, _ := keywords["ssh:preferredauthentications"].([]string); len(auths) == 0 || auths[0] != "password" {
+		t.Errorf("password should be the preferred authentication, got %v", keywords["ssh:preferredauthentications"])
+	}
+	if keywords["ssh:passwordsecret"] != "db-pass" || keywords["ssh:passphrasesecret"] != nil {
+		t.Errorf("the secret should be the password secret, got %v", keywords)
+	}
+	keywords = AuthKeywords(&waveobj.Connection{AuthMethod: AuthMethod_GSSAPI})
+	if auths, _ := keywords["ssh:preferredauthentications"].([]string); len(auths) != 1 || auths[0] != "gssapi-with-mic" || keywords["ssh:gssapiauthentication"] != true {
+		t.Errorf("gssapi should only use gssapi-with-mic, got %v", keywords)
+	}
+	keywords = AuthKeywords(&waveobj.Connection{AuthMethod: AuthMethod_Agent, CertificateFile: "~/.ssh/prod-cert.pub"})
+	if files, _ := keywords["ssh:certificatefile"].([]string); len(files) != 1 || files[0] != "~/.ssh/prod-cert.pub" {
+		t.Errorf("certificatefile should be [~/.ssh/prod-cert.pub], got %v", keywords["ssh:certificatefile"])
+	}
+}
+
+func TestResolveProxyJump(t *testing.T) {
+	bastion := &waveobj.Connection{OID: "1", Name: "bastion", Host: "bastion.example.com", User: "ops"}
+	db := &waveobj.Connection{OID: "2", Name: "db", Host: "10.0.3.12", User: "admin", ProxyJump: []string{"bastion", "ssh://jump@10.0.0.2:2222"}}
+	conns := []*waveobj.Connection{bastion, db}
+	hops, err := resolveProxyJump(conns, db)
+	if err != nil {
+		t.Fatalf("error resolving jump hosts: %v", err)
+	}
+	if len(hops) != 2 || hops[0] != "ops@bastion.example.com" || hops[1] != "jump@10.0.0.2:2222" {
+		t.Errorf("jump hosts should be [ops@bastion.example.com jump@10.0.0.2:2222], got %v", hops)
+	}
+	if dependents := findDependents(conns, bastion); len(dependents) != 1 || dependents[0] != db {
+		t.Errorf("db should be the only dependent of bastion, got %v", dependents)
+	}
+	db.ProxyJump = []string{"db"}
+	if _, err := resolveProxyJump(conns, db); err == nil {
+		t.Errorf("a connection should not be its own jump host")
+	}
+	db.ProxyJump = []string{"admin@10.0.3.12"}
+	if _, err := resolveProxyJump(conns, db); err == nil {
+		t.Errorf("a connection should not be its own jump host (by conn name)")
+	}
+	db.ProxyJump = []string{"not a host"}
+	if _, err := resolveProxyJump(conns, db); err == nil {
+		t.Errorf("an invalid jump host should be an error")
+	}
+}
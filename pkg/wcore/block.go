@@ -11,12 +11,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/castplayer"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/telemetry/telemetrydata"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wplugin"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
@@ -75,12 +77,24 @@ func CreateBlock(ctx context.Context, tabId string, blockDef *waveobj.BlockDef,
 	if blockDef.Meta == nil || blockDef.Meta.GetString(waveobj.MetaKey_View, "") == "" {
 		return nil, fmt.Errorf("no view provided for new block")
 	}
-	blockData, err := createBlockObj(ctx, tabId, blockDef, rtOpts)
+	err := applyEnvFrom(ctx, blockDef)
+	if err != nil {
+		return nil, err
+	}
+	defBytes, defHash, err := serializeBlockDef(blockDef)
+	if err != nil {
+		return nil, err
+	}
+	blockData, err := createBlockObj(ctx, tabId, blockDef, rtOpts, defHash)
 	if err != nil {
 		return nil, fmt.Errorf("error creating block: %w", err)
 	}
 	blockCreated = true
 	newBlockOID = blockData.OID
+	err = saveBlockDef(ctx, newBlockOID, defBytes)
+	if err != nil {
+		return nil, err
+	}
 	// upload the files if present
 	if len(blockDef.Files) > 0 {
 		for fileName, fileDef := range blockDef.Files {
@@ -94,6 +108,7 @@ func CreateBlock(ctx context.Context, tabId string, blockDef *waveobj.BlockDef,
 			}
 		}
 	}
+	wplugin.SendBlockEvent(blockData, tabId, wshrpc.PluginBlockEvent_Create)
 	go func() {
 		defer func() {
 			panichandler.PanicHandler("CreateBlock:telemetry", recover())
@@ -117,7 +132,7 @@ func CreateBlock(ctx context.Context, tabId string, blockDef *waveobj.BlockDef,
 	return blockData, nil
 }
 
-func createBlockObj(ctx context.Context, tabId string, blockDef *waveobj.BlockDef, rtOpts *waveobj.RuntimeOpts) (*waveobj.Block, error) {
+func createBlockObj(ctx context.Context, tabId string, blockDef *waveobj.BlockDef, rtOpts *waveobj.RuntimeOpts, defHash string) (*waveobj.Block, error) {
 	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (*waveobj.Block, error) {
 		tab, _ := wstore.DBGet[*waveobj.Tab](tx.Context(), tabId)
 		if tab == nil {
@@ -129,6 +144,7 @@ func createBlockObj(ctx context.Context, tabId string, blockDef *waveobj.BlockDe
 			ParentORef:  waveobj.MakeORef(waveobj.OType_Tab, tabId).String(),
 			RuntimeOpts: rtOpts,
 			Meta:        blockDef.Meta,
+			DefHash:     defHash,
 		}
 		wstore.DBInsert(tx.Context(), blockData)
 		tab.BlockIds = append(tab.BlockIds, blockId)
@@ -137,6 +153,29 @@ func createBlockObj(ctx context.Context, tabId string, blockDef *waveobj.BlockDe
 	})
 }
 
+// stops the controllers of a set of blocks together and waits for them (replaced in tests)
+var destroyBlockControllers = blockcontroller.DestroyBlockControllers
+
+// the ids of the tab's blocks and of their sub blocks
+func getTabBlockIds(ctx context.Context, tab *waveobj.Tab) []string {
+	var blockIds []string
+	var addBlock func(blockId string)
+	addBlock = func(blockId string) {
+		blockIds = append(blockIds, blockId)
+		block, _ := wstore.DBGet[*waveobj.Block](ctx, blockId)
+		if block == nil {
+			return
+		}
+		for _, subBlockId := range block.SubBlockIds {
+			addBlock(subBlockId)
+		}
+	}
+	for _, blockId := range tab.BlockIds {
+		addBlock(blockId)
+	}
+	return blockIds
+}
+
 // Must delete all blocks individually first.
 // Also deletes LayoutState.
 // recursive: if true, will recursively close parent tab, window, workspace, if they are empty.
@@ -164,7 +203,7 @@ func DeleteBlock(ctx context.Context, blockId string, recursive bool) error {
 	log.Printf("DeleteBlock: parentBlockCount: %d", parentBlockCount)
 	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
 
-	if recursive && parentORef.OType == waveobj.OType_Tab && parentBlockCount == 0 {
+	if recursive && parentORef != nil && parentORef.OType == waveobj.OType_Tab && parentBlockCount == 0 {
 		// if parent tab has no blocks, delete the tab
 		log.Printf("DeleteBlock: parent tab has no blocks, deleting tab %s", parentORef.OID)
 		parentWorkspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, parentORef.OID)
@@ -177,8 +216,14 @@ func DeleteBlock(ctx context.Context, blockId string, recursive bool) error {
 		}
 		SendActiveTabUpdate(ctx, parentWorkspaceId, newActiveTabId)
 	}
-	go blockcontroller.StopBlockController(blockId)
+	go blockcontroller.DestroyBlockController(blockId, blockcontroller.DefaultTeardownTimeout)
+	castplayer.Close(blockId)
 	sendBlockCloseEvent(blockId)
+	if parentORef != nil && parentORef.OType == waveobj.OType_Tab {
+		wplugin.SendBlockEvent(block, parentORef.OID, wshrpc.PluginBlockEvent_Close)
+	} else {
+		wplugin.SendBlockEvent(block, "", wshrpc.PluginBlockEvent_Close)
+	}
 	return nil
 }
 
@@ -229,3 +274,58 @@ func sendBlockCloseEvent(blockId string) {
 	}
 	wps.Broker.Publish(waveEvent)
 }
+
+// removes a block from its tab without stopping its controller.  the controller keeps running headless
+// (output is still appended to the block's term file) until the block is attached to a tab again.
+func DetachBlock(ctx context.Context, blockId string) (string, error) {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return "", fmt.Errorf("error getting block: %w", err)
+	}
+	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+	if parentORef == nil || parentORef.OType != waveobj.OType_Tab {
+		return "", fmt.Errorf("only blocks in a tab can be detached")
+	}
+	if block.Meta.GetString(waveobj.MetaKey_Controller, "") == "" {
+		return "", fmt.Errorf("block has no controller")
+	}
+	tabId := parentORef.OID
+	err = wstore.DetachBlockFromTab(ctx, tabId, blockId)
+	if err != nil {
+		return "", fmt.Errorf("error detaching block: %w", err)
+	}
+	QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
+		ActionType: LayoutActionDataType_Remove,
+		BlockId:    blockId,
+	})
+	return tabId, nil
+}
+
+func AttachBlock(ctx context.Context, blockId string, tabId string) error {
+	err := wstore.AttachBlockToTab(ctx, blockId, tabId)
+	if err != nil {
+		return fmt.Errorf("error attaching block: %w", err)
+	}
+	blockcontroller.SetControllerTabId(blockId, tabId)
+	QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
+		ActionType: LayoutActionDataType_Insert,
+		BlockId:    blockId,
+		Focused:    true,
+	})
+	return nil
+}
+
+func GetDetachedBlocks(ctx context.Context) ([]*waveobj.Block, error) {
+	blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+	if err != nil {
+		return nil, fmt.Errorf("error getting blocks: %w", err)
+	}
+	var rtn []*waveobj.Block
+	for _, block := range blocks {
+		parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+		if parentORef != nil && parentORef.OType == waveobj.OType_Client {
+			rtn = append(rtn, block)
+		}
+	}
+	return rtn, nil
+}
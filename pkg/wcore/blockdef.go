@@ -0,0 +1,66 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// returns the canonical serialization of the BlockDef and its hash.
+// json.Marshal sorts map keys, so equal BlockDefs always produce the same bytes.
+func serializeBlockDef(blockDef *waveobj.BlockDef) ([]byte, string, error) {
+	barr, err := json.Marshal(blockDef)
+	if err != nil {
+		return nil, "", fmt.Errorf("error serializing blockdef: %w", err)
+	}
+	hashBytes := sha256.Sum256(barr)
+	return barr, hex.EncodeToString(hashBytes[:]), nil
+}
+
+func saveBlockDef(ctx context.Context, blockId string, defBytes []byte) error {
+	err := filestore.WFS.MakeFile(ctx, blockId, wavebase.BlockFile_Def, nil, wshrpc.FileOpts{})
+	if err != nil {
+		return fmt.Errorf("error making blockdef file: %w", err)
+	}
+	err = filestore.WFS.WriteFile(ctx, blockId, wavebase.BlockFile_Def, defBytes)
+	if err != nil {
+		return fmt.Errorf("error writing blockdef file: %w", err)
+	}
+	return nil
+}
+
+// returns the resolved BlockDef that the block was originally created from (verified against the stored hash)
+func GetOriginalBlockDef(ctx context.Context, blockId string) (*waveobj.BlockDef, error) {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block: %w", err)
+	}
+	if block.DefHash == "" {
+		return nil, fmt.Errorf("block %s has no stored blockdef", blockId)
+	}
+	_, defBytes, err := filestore.WFS.ReadFile(ctx, blockId, wavebase.BlockFile_Def)
+	if err != nil {
+		return nil, fmt.Errorf("error reading blockdef file: %w", err)
+	}
+	hashBytes := sha256.Sum256(defBytes)
+	if hex.EncodeToString(hashBytes[:]) != block.DefHash {
+		return nil, fmt.Errorf("stored blockdef for block %s does not match its hash", blockId)
+	}
+	var blockDef waveobj.BlockDef
+	err = json.Unmarshal(defBytes, &blockDef)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing stored blockdef: %w", err)
+	}
+	return &blockDef, nil
+}
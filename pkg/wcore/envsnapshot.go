@@ -0,0 +1,131 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the environment of a running shell is captured by "wsh envsnapshot", which inherits the shell's exported
+// variables (so activated virtualenvs are included).  it is stored in the block's envsnapshot file, and a
+// new block created with cmd:envfrom set to that block's id starts with the same environment.
+
+// variables that belong to the shell or terminal that was snapshotted, the new shell sets its own
+var envSnapshotSkipVars = map[string]bool{
+	"_":                    true,
+	"PWD":                  true,
+	"OLDPWD":               true,
+	"SHLVL":                true,
+	"TERM":                 true,
+	"COLORTERM":            true,
+	"TERM_PROGRAM":         true,
+	"TERM_PROGRAM_VERSION": true,
+	"SSH_CLIENT":           true,
+	"SSH_CONNECTION":       true,
+	"SSH_TTY":              true,
+	"SSH_AUTH_SOCK":        true,
+}
+
+func skipEnvSnapshotVar(name string) bool {
+	return envSnapshotSkipVars[name] || strings.HasPrefix(name, "WAVETERM")
+}
+
+func SaveEnvSnapshot(ctx context.Context, blockId string, cwd string, env map[string]string) error {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error getting block: %w", err)
+	}
+	snapshot := wshrpc.EnvSnapshot{
+		ConnName: block.Meta.GetString(waveobj.MetaKey_Connection, ""),
+		Cwd:      cwd,
+		Ts:       time.Now().UnixMilli(),
+		Env:      make(map[string]string),
+	}
+	for name, value := range env {
+		if !skipEnvSnapshotVar(name) {
+			snapshot.Env[name] = value
+		}
+	}
+	barr, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("error serializing env snapshot: %w", err)
+	}
+	err = filestore.WFS.MakeFile(ctx, blockId, wavebase.BlockFile_EnvSnapshot, nil, wshrpc.FileOpts{})
+	if err != nil && err != fs.ErrExist {
+		return fmt.Errorf("error making env snapshot file: %w", err)
+	}
+	err = filestore.WFS.WriteFile(ctx, blockId, wavebase.BlockFile_EnvSnapshot, barr)
+	if err != nil {
+		return fmt.Errorf("error writing env snapshot file: %w", err)
+	}
+	return nil
+}
+
+func GetEnvSnapshot(ctx context.Context, blockId string) (*wshrpc.EnvSnapshot, error) {
+	_, barr, err := filestore.WFS.ReadFile(ctx, blockId, wavebase.BlockFile_EnvSnapshot)
+	if err == fs.ErrNotExist {
+		return nil, fmt.Errorf("block %s has no env snapshot (run \"wsh envsnapshot\" in it first)", blockId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading env snapshot file: %w", err)
+	}
+	var snapshot wshrpc.EnvSnapshot
+	err = json.Unmarshal(barr, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing env snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// applies the env snapshot named by cmd:envfrom to a new block's BlockDef.  the snapshot's variables go
+// into the env file (variables already in the file take precedence), and its cwd and connection are
+// used unless the BlockDef sets its own.  the connection must match the snapshot's.
+func applyEnvFrom(ctx context.Context, blockDef *waveobj.BlockDef) error {
+	srcBlockId := blockDef.Meta.GetString(waveobj.MetaKey_CmdEnvFrom, "")
+	if srcBlockId == "" {
+		return nil
+	}
+	snapshot, err := GetEnvSnapshot(ctx, srcBlockId)
+	if err != nil {
+		return err
+	}
+	if connName, ok := blockDef.Meta[waveobj.MetaKey_Connection].(string); ok && connName != snapshot.ConnName {
+		return fmt.Errorf("env snapshot of block %s is for connection %q, not %q", srcBlockId, snapshot.ConnName, connName)
+	}
+	if snapshot.ConnName != "" {
+		blockDef.Meta[waveobj.MetaKey_Connection] = snapshot.ConnName
+	}
+	if blockDef.Meta.GetString(waveobj.MetaKey_CmdCwd, "") == "" && snapshot.Cwd != "" {
+		blockDef.Meta[waveobj.MetaKey_CmdCwd] = snapshot.Cwd
+	}
+	envMap := make(map[string]string)
+	for name, value := range snapshot.Env {
+		envMap[name] = value
+	}
+	if blockDef.Files == nil {
+		blockDef.Files = make(map[string]*waveobj.FileDef)
+	}
+	envFile := blockDef.Files[wavebase.BlockFile_Env]
+	if envFile == nil {
+		envFile = &waveobj.FileDef{}
+		blockDef.Files[wavebase.BlockFile_Env] = envFile
+	}
+	for name, value := range envutil.EnvToMap(envFile.Content) {
+		envMap[name] = value
+	}
+	envFile.Content = envutil.MapToEnv(envMap)
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/wavefs"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// cleans up resources left behind by tabs/windows that were not torn down cleanly
+// (blocks whose parent no longer exists, blockfiles for objects that no longer exist, uploads that were never
+// finished, and blockfile blobs that no parts refer to).
+// must be called once at startup, synchronously, before any controllers are started or any rpcs are served (an
+// object that is being created could look orphaned).
+func ReapOrphanedObjects(ctx context.Context) error {
+	blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+	if err != nil {
+		return fmt.Errorf("error getting blocks: %w", err)
+	}
+	for _, block := range blocks {
+		parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+		if parentORef != nil {
+			exists, err := wstore.DBExistsORef(ctx, *parentORef)
+			if err != nil || exists {
+				continue
+			}
+		}
+		log.Printf("reaping orphaned block %s (parent %q)\n", block.OID, block.ParentORef)
+		err = wstore.DBDelete(ctx, waveobj.OType_Block, block.OID)
+		if err != nil {
+			log.Printf("error deleting orphaned block %s: %v\n", block.OID, err)
+		}
+	}
+	liveOIDs := make(map[string]bool)
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err == nil && client.TempOID != "" {
+		liveOIDs[client.TempOID] = true
+	}
+	for otype := range waveobj.ValidOTypes {
+		if otype == waveobj.OType_Temp {
+			// temp objects are not stored
+			continue
+		}
+		oids, err := wstore.DBGetAllOIDsByType(ctx, otype)
+		if err != nil {
+			return fmt.Errorf("error getting %s oids: %w", otype, err)
+		}
+		for _, oid := range oids {
+			liveOIDs[oid] = true
+		}
+	}
+	zoneIds, err := filestore.WFS.GetAllZoneIds(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting blockfile zones: %w", err)
+	}
+	for _, zoneId := range zoneIds {
+		if liveOIDs[zoneId] {
+			reapStaleTransfers(ctx, zoneId)
+			continue
+		}
+		if _, err := uuid.Parse(zoneId); err != nil {
+			// not an object zone
+			continue
+		}
+		log.Printf("reaping orphaned blockfile zone %s\n", zoneId)
+		err = filestore.WFS.DeleteZone(ctx, zoneId)
+		if err != nil {
+			log.Printf("error deleting orphaned zone %s: %v\n", zoneId, err)
+		}
+	}
+	numBlobs, err := filestore.WFS.ReapBlobs(ctx)
+	if err != nil {
+		return fmt.Errorf("error reaping blockfile blobs: %w", err)
+	}
+	if numBlobs > 0 {
+		log.Printf("reaped %d unreferenced blockfile blobs\n", numBlobs)
+	}
+	return nil
+}
+
+// deletes the staging files of the uploads in the zone that have not had a chunk in wavefs.TransferMaxAge
+func reapStaleTransfers(ctx context.Context, zoneId string) {
+	files, err := filestore.WFS.ListFiles(ctx, zoneId)
+	if err != nil {
+		log.Printf("error listing blockfiles in zone %s: %v\n", zoneId, err)
+		return
+	}
+	cutoff := time.Now().Add(-wavefs.TransferMaxAge).UnixMilli()
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name, wavebase.BlockFile_TransferPrefix) || file.ModTs >= cutoff {
+			continue
+		}
+		log.Printf("reaping stale upload %s:%s\n", zoneId, file.Name)
+		err = filestore.WFS.DeleteFile(ctx, zoneId, file.Name)
+		if err != nil {
+			log.Printf("error deleting stale upload %s:%s: %v\n", zoneId, file.Name, err)
+		}
+	}
+}
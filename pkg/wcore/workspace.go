@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
 	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/telemetry/telemetrydata"
@@ -71,6 +72,9 @@ func CreateWorkspace(ctx context.Context, name string, icon string, color string
 	})
 
 	ws, _, err = UpdateWorkspace(ctx, ws.OID, name, icon, color, applyDefaults)
+	if err == nil {
+		eventbus.Publish(eventbus.WorkspaceEvent{Workspace: wps.WorkspaceEventData{WorkspaceId: ws.OID, Name: ws.Name}})
+	}
 	return ws, err
 }
 
@@ -136,8 +140,19 @@ func DeleteWorkspace(ctx context.Context, workspaceId string, force bool) (bool,
 		return false, "", nil
 	}
 
+	// the controllers of all of the tabs are stopped together before the tabs are deleted
+	allTabIds := append(append([]string(nil), workspace.TabIds...), workspace.PinnedTabIds...)
+	var blockIds []string
+	for _, tabId := range allTabIds {
+		tab, _ := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+		if tab != nil {
+			blockIds = append(blockIds, getTabBlockIds(ctx, tab)...)
+		}
+	}
+	destroyBlockControllers(blockIds, blockcontroller.DefaultTeardownTimeout)
+
 	// delete all pinned and unpinned tabs
-	for _, tabId := range append(workspace.TabIds, workspace.PinnedTabIds...) {
+	for _, tabId := range allTabIds {
 		log.Printf("deleting tab %s\n", tabId)
 		_, err := DeleteTab(ctx, workspaceId, tabId, false)
 		if err != nil {
@@ -153,6 +168,7 @@ func DeleteWorkspace(ctx context.Context, workspaceId string, force bool) (bool,
 	wps.Broker.Publish(wps.WaveEvent{
 		Event: wps.Event_WorkspaceUpdate,
 	})
+	eventbus.Publish(eventbus.WorkspaceEvent{Deleted: true, Workspace: wps.WorkspaceEventData{WorkspaceId: workspaceId, Name: workspace.Name}})
 
 	if windowId != "" {
 
@@ -295,6 +311,8 @@ func DeleteTab(ctx context.Context, workspaceId string, tabId string, recursive
 	if tab == nil {
 		return "", fmt.Errorf("tab not found: %q", tabId)
 	}
+	// stops the shells of the blocks together, and waits for them before the blocks are deleted
+	destroyBlockControllers(getTabBlockIds(ctx, tab), blockcontroller.DefaultTeardownTimeout)
 	for _, blockId := range tab.BlockIds {
 		err := DeleteBlock(ctx, blockId, false)
 		if err != nil {
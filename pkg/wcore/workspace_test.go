@@ -0,0 +1,88 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// a tab with a block that has a sub block, and a block without sub blocks
+func makeTestTab(t *testing.T, ctx context.Context) (*waveobj.Tab, []string) {
+	tabId := uuid.NewString()
+	tabORef := waveobj.MakeORef(waveobj.OType_Tab, tabId).String()
+	blockId, subBlockId, otherBlockId := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	tab := &waveobj.Tab{OID: tabId, LayoutState: uuid.NewString(), BlockIds: []string{blockId, otherBlockId}}
+	objs := []waveobj.WaveObj{
+		tab,
+		&waveobj.Block{OID: blockId, ParentORef: tabORef, SubBlockIds: []string{subBlockId}},
+		&waveobj.Block{OID: subBlockId, ParentORef: waveobj.MakeORef(waveobj.OType_Block, blockId).String()},
+		&waveobj.Block{OID: otherBlockId, ParentORef: tabORef},
+	}
+	for _, obj := range objs {
+		if err := wstore.DBInsert(ctx, obj); err != nil {
+			t.Fatalf("error inserting %T: %v", obj, err)
+		}
+	}
+	return tab, []string{blockId, subBlockId, otherBlockId}
+}
+
+func TestDeleteDestroysControllers(t *testing.T) {
+	wavebase.DataHome_VarCache = t.TempDir()
+	os.MkdirAll(filepath.Join(wavebase.DataHome_VarCache, wavebase.WaveDBDir), 0700)
+	if err := wstore.InitWStore(); err != nil {
+		t.Fatalf("error initializing wstore: %v", err)
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	var destroyed [][]string
+	destroyBlockControllers = func(blockIds []string, timeout time.Duration) {
+		for _, blockId := range blockIds {
+			block, _ := wstore.DBGet[*waveobj.Block](ctx, blockId)
+			if block == nil {
+				t.Errorf("expected the controllers to be destroyed before block %s is deleted", blockId)
+			}
+		}
+		destroyed = append(destroyed, blockIds)
+	}
+	tab1, tab1Blocks := makeTestTab(t, ctx)
+	tab2, tab2Blocks := makeTestTab(t, ctx)
+	tab3, tab3Blocks := makeTestTab(t, ctx)
+	ws := &waveobj.Workspace{OID: uuid.NewString(), TabIds: []string{tab1.OID, tab2.OID}, PinnedTabIds: []string{tab3.OID}, ActiveTabId: tab1.OID}
+	if err := wstore.DBInsert(ctx, ws); err != nil {
+		t.Fatalf("error inserting workspace: %v", err)
+	}
+
+	_, err := DeleteTab(ctx, ws.OID, tab1.OID, false)
+	if err != nil {
+		t.Fatalf("error deleting tab: %v", err)
+	}
+	if len(destroyed) != 1 || !slices.Equal(destroyed[0], tab1Blocks) {
+		t.Errorf("expected the controllers of the tab's blocks to be destroyed together, got %v", destroyed)
+	}
+
+	destroyed = nil
+	_, _, err = DeleteWorkspace(ctx, ws.OID, true)
+	if err != nil {
+		t.Fatalf("error deleting workspace: %v", err)
+	}
+	if len(destroyed) == 0 || !slices.Equal(destroyed[0], append(tab2Blocks, tab3Blocks...)) {
+		t.Errorf("expected the controllers of all of the workspace's blocks to be destroyed together, got %v", destroyed)
+	}
+	for _, blockId := range append(tab2Blocks, tab3Blocks...) {
+		block, _ := wstore.DBGet[*waveobj.Block](ctx, blockId)
+		if block != nil {
+			t.Errorf("expected block %s to be deleted", blockId)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// the metrics of the automation api (GET /api/v1/metrics) are in the prometheus text format, so they can be scraped
+// (with the api token as the bearer token).  they are the stats of the filestore cache, and the latency and traffic of
+// the ssh connections (labeled with the connection name).
+
+const ContentTypeMetrics = "text/plain; version=0.0.4; charset=utf-8"
+
+func writeMetric(buf *bytes.Buffer, name string, metricType string, help string, value int64) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, metricType)
+	fmt.Fprintf(buf, "%s %d\n", name, value)
+}
+
+func writeConnMetric(buf *bytes.Buffer, name string, metricType string, help string, metrics []wshrpc.ConnMetrics, valueFn func(wshrpc.ConnMetrics) int64) {
+	if len(metrics) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, metricType)
+	for _, m := range metrics {
+		fmt.Fprintf(buf, "%s{conn=%q} %d\n", name, m.Connection, valueFn(m))
+	}
+}
+
+func handleApiMetrics(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		panichandler.PanicHandler("handleApiMetrics", recover())
+	}()
+	err := validateApiRequest(r)
+	if err != nil {
+		writeApiResponse(w, http.StatusUnauthorized, map[string]any{"error": err.Error()})
+		return
+	}
+	stats := filestore.WFS.GetCacheStats()
+	var buf bytes.Buffer
+	writeMetric(&buf, "wave_filestore_cache_hits_total", "counter", "Block file parts read from memory.", stats.Hits)
+	writeMetric(&buf, "wave_filestore_cache_misses_total", "counter", "Block file parts read from the database.", stats.Misses)
+	writeMetric(&buf, "wave_filestore_cache_evictions_total", "counter", "Parts evicted from the read cache to stay under its size.", stats.Evictions)
+	writeMetric(&buf, "wave_filestore_cache_readahead_parts_total", "counter", "Parts read ahead for viewers.", stats.ReadAheadParts)
+	writeMetric(&buf, "wave_filestore_cache_bytes", "gauge", "Bytes in the read cache.", stats.CachedBytes)
+	writeMetric(&buf, "wave_filestore_cache_max_bytes", "gauge", "The size of the read cache (storage:cachebytes).", stats.MaxBytes)
+	writeMetric(&buf, "wave_filestore_open_files", "gauge", "Block files in the write cache.", int64(stats.OpenFiles))
+	writeMetric(&buf, "wave_filestore_dirty_files", "gauge", "Block files with writes that are not flushed.", int64(stats.DirtyFiles))
+	fmt.Fprintf(&buf, "# HELP wave_filestore_cache_info The eviction policy of the read cache (storage:cachepolicy).\n")
+	fmt.Fprintf(&buf, "# TYPE wave_filestore_cache_info gauge\n")
+	fmt.Fprintf(&buf, "wave_filestore_cache_info{policy=%q} 1\n", stats.Policy)
+	connMetrics := conncontroller.GetAllConnMetrics(false)
+	writeConnMetric(&buf, "wave_conn_latency_ms", "gauge", "The round trip of the last keepalive probe of the connection.", connMetrics,
+		func(m wshrpc.ConnMetrics) int64 { return m.LatencyMs })
+	writeConnMetric(&buf, "wave_conn_smoothed_latency_ms", "gauge", "The smoothed round trip of the keepalive probes of the connection.", connMetrics,
+		func(m wshrpc.ConnMetrics) int64 { return m.SmoothedLatencyMs })
+	writeConnMetric(&buf, "wave_conn_received_bytes_total", "counter", "Bytes read from the ssh transport of the connection.", connMetrics,
+		func(m wshrpc.ConnMetrics) int64 { return m.BytesIn })
+	writeConnMetric(&buf, "wave_conn_sent_bytes_total", "counter", "Bytes written to the ssh transport of the connection.", connMetrics,
+		func(m wshrpc.ConnMetrics) int64 { return m.BytesOut })
+	w.Header().Set(ContentTypeHeaderKey, ContentTypeMetrics)
+	w.Header().Set(CacheControlHeaderKey, CacheControlHeaderNoCache)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
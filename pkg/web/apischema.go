@@ -0,0 +1,73 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/apischema"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/service"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+const ApiSchemaVersion = "v1"
+
+var apiSchemaOnce = &sync.Once{}
+var apiSchemaBytes []byte
+var apiSchemaErr error
+
+// the OpenAPI description of the automation api (with the service methods and the wave object types), see
+// pkg/apischema.  cmd/generateschema writes it to schema/api.json.
+func GetApiSchema() (*apischema.Document, error) {
+	gen := apischema.MakeGenerator(apischema.Info{
+		Title:       "Wave Terminal Automation API",
+		Version:     ApiSchemaVersion,
+		Description: "the responses are {\"data\": ...} or {\"error\": \"...\"}, the token is in the api-token file in the wave data directory",
+	})
+	ops := make([]apischema.Operation, 0, len(apiMethods))
+	for _, method := range apiMethods {
+		ops = append(ops, apischema.Operation{
+			Name:       method.Name,
+			HttpMethod: method.HttpMethod,
+			Path:       method.Path,
+			Query:      method.Query,
+			Body:       method.Body,
+			Result:     method.Result,
+		})
+	}
+	err := gen.AddOperations(ops)
+	if err != nil {
+		return nil, err
+	}
+	gen.AddServices(service.ServiceMap)
+	gen.AddTypes(waveobj.AllWaveObjTypes()...)
+	doc := gen.Document()
+	doc.Servers = []apischema.Server{{Url: "http://" + DefaultApiListenAddr + "/api/v1"}}
+	return doc, nil
+}
+
+// GET /api/v1/openapi.json, does not need the token
+func handleApiSchema(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		panichandler.PanicHandler("handleApiSchema", recover())
+	}()
+	apiSchemaOnce.Do(func() {
+		var doc *apischema.Document
+		doc, apiSchemaErr = GetApiSchema()
+		if apiSchemaErr == nil {
+			apiSchemaBytes, apiSchemaErr = json.MarshalIndent(doc, "", "  ")
+		}
+	})
+	if apiSchemaErr != nil {
+		writeApiResponse(w, http.StatusInternalServerError, map[string]any{"error": apiSchemaErr.Error()})
+		return
+	}
+	w.Header().Set(ContentTypeHeaderKey, ContentTypeJson)
+	w.Header().Set(CacheControlHeaderKey, CacheControlHeaderNoCache)
+	w.WriteHeader(http.StatusOK)
+	w.Write(apiSchemaBytes)
+}
@@ -0,0 +1,56 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package web
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+)
+
+func getApiSocketAddr() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), wavebase.ApiSocketBaseName)
+}
+
+// the socket is made in a private directory, given its mode there, and then moved to its path, so it is never
+// reachable with the default permissions.  the socket file is removed when the listener is closed.
+func listenApiSocket() (net.Listener, error) {
+	sockName := getApiSocketAddr()
+	tmpDir, err := os.MkdirTemp(filepath.Dir(sockName), "api-sock-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating socket dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpName := filepath.Join(tmpDir, wavebase.ApiSocketBaseName)
+	listener, err := net.Listen("unix", tmpName)
+	if err != nil {
+		return nil, err
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	err = os.Chmod(tmpName, 0600)
+	if err == nil {
+		os.Remove(sockName) // ignore error
+		err = os.Rename(tmpName, sockName)
+	}
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &apiSocketListener{Listener: listener, sockName: sockName}, nil
+}
+
+type apiSocketListener struct {
+	net.Listener
+	sockName string
+}
+
+func (l *apiSocketListener) Close() error {
+	os.Remove(l.sockName)
+	return l.Listener.Close()
+}
@@ -0,0 +1,22 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package web
+
+import (
+	"net"
+
+	"github.com/wavetermdev/waveterm/pkg/util/pipeutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+)
+
+func getApiSocketAddr() string {
+	return pipeutil.PipeName(wavebase.ApiPipeService, wavebase.GetWaveDataDir())
+}
+
+// a named pipe that only the user running wave can open
+func listenApiSocket() (net.Listener, error) {
+	return pipeutil.Listen(getApiSocketAddr())
+}
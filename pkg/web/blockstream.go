@@ -0,0 +1,162 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/wavetermdev/waveterm/pkg/blockstream"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// a block's output can be streamed (see pkg/blockstream) over a websocket on the automation api
+// (GET /api/v1/blocks/{id}/stream with the "wave.blockstream.v1" sub-protocol), or with the blocks.subscribe
+// method on the json-rpc socket.  the messages are blockstream.Messages, as json.
+
+const BlockStreamSubprotocol = "wave.blockstream.v1"
+const blockStreamWriteTimeout = 10 * time.Second
+
+var blockStreamUpgrader = websocket.Upgrader{
+	ReadBufferSize:   1024,
+	WriteBufferSize:  32 * 1024,
+	HandshakeTimeout: 1 * time.Second,
+	Subprotocols:     []string{BlockStreamSubprotocol},
+	// the token is sent in a header, so this is not for browsers
+	CheckOrigin: func(r *http.Request) bool { return r.Header.Get("Origin") == "" },
+}
+
+type blockStreamParams struct {
+	BlockId  string
+	FileName string
+	Offset   int64
+}
+
+// ?offset= (default 0, negative for only the new output) and ?file= (default "term")
+func getBlockStreamParams(ctx context.Context, req *apiRequest) (*blockStreamParams, error) {
+	block, err := getApiObj[*waveobj.Block](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	rtn := &blockStreamParams{BlockId: block.OID, FileName: req.Params.Get("file")}
+	if rtn.FileName == "" {
+		rtn.FileName = wavebase.BlockFile_Term
+	}
+	if req.Params.Get("offset") != "" {
+		rtn.Offset, err = strconv.ParseInt(req.Params.Get("offset"), 10, 64)
+		if err != nil {
+			return nil, apiErrorf(http.StatusBadRequest, "invalid offset %q", req.Params.Get("offset"))
+		}
+	}
+	return rtn, nil
+}
+
+func handleBlockStreamWs(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		panichandler.PanicHandler("handleBlockStreamWs", recover())
+	}()
+	err := validateApiRequest(r)
+	if err != nil {
+		writeApiResponse(w, http.StatusUnauthorized, map[string]any{"error": err.Error()})
+		return
+	}
+	if !slices.Contains(websocket.Subprotocols(r), BlockStreamSubprotocol) {
+		writeApiResponse(w, http.StatusBadRequest, map[string]any{"error": fmt.Sprintf("the %q websocket sub-protocol is required", BlockStreamSubprotocol)})
+		return
+	}
+	lookupCtx, lookupCancelFn := context.WithTimeout(r.Context(), ApiRequestTimeout)
+	params, err := getBlockStreamParams(lookupCtx, &apiRequest{Id: mux.Vars(r)["id"], Params: r.URL.Query()})
+	lookupCancelFn()
+	if err != nil {
+		writeApiResponse(w, getApiErrorStatus(err), map[string]any{"error": err.Error()})
+		return
+	}
+	conn, err := blockStreamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has written the error response
+		return
+	}
+	defer conn.Close()
+	// clears the http server's timeouts
+	conn.NetConn().SetDeadline(time.Time{})
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("handleBlockStreamWs:read", recover())
+		}()
+		defer cancelFn()
+		// nothing is expected from the client, this reads until it closes the connection (and answers pings)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	writeMsg := func(msg blockstream.Message) error {
+		conn.SetWriteDeadline(time.Now().Add(blockStreamWriteTimeout))
+		return conn.WriteJSON(msg)
+	}
+	err = blockstream.Stream(ctx, params.BlockId, params.FileName, params.Offset, writeMsg)
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err != nil && ctx.Err() == nil {
+		log.Printf("[api] block %s output stream: %v\n", params.BlockId, err)
+		writeMsg(blockstream.Message{Type: blockstream.MsgType_Error, BlockId: params.BlockId, FileName: params.FileName, Error: err.Error()})
+		closeMsg = websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "")
+	}
+	conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+}
+
+// blocks.subscribe on the json-rpc socket, the messages are sent as blocks.output notifications (with the subid)
+// until the stream ends, blocks.unsubscribe is called, or the connection is closed
+func jsonRpcBlockSubscribe(ctx context.Context, rconn *jsonRpcConn, reqId string, req *apiRequest) (any, error) {
+	params, err := getBlockStreamParams(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	subId, sub, streamCtx := rconn.addSub(reqId)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("jsonRpcBlockSubscribe", recover())
+		}()
+		defer rconn.removeSub(subId)
+		// the client gets the subid before the first notification
+		select {
+		case <-sub.ready:
+		case <-streamCtx.Done():
+			return
+		}
+		sendMsg := func(msg blockstream.Message) error {
+			rconn.writeMsg(jsonRpcNotification{JsonRpc: "2.0", Method: "blocks.output", Params: blockOutputParams{SubId: subId, Message: msg}})
+			return nil
+		}
+		err := blockstream.Stream(streamCtx, params.BlockId, params.FileName, params.Offset, sendMsg)
+		if err != nil && streamCtx.Err() == nil {
+			sendMsg(blockstream.Message{Type: blockstream.MsgType_Error, BlockId: params.BlockId, FileName: params.FileName, Error: err.Error()})
+		}
+	}()
+	return map[string]any{"subid": subId}, nil
+}
+
+func jsonRpcBlockUnsubscribe(ctx context.Context, rconn *jsonRpcConn, reqId string, req *apiRequest) (any, error) {
+	subId := req.Params.Get("subid")
+	if !rconn.removeSub(subId) {
+		return nil, apiErrorf(http.StatusNotFound, "subscription %q not found", subId)
+	}
+	return nil, nil
+}
+
+type blockOutputParams struct {
+	SubId string `json:"subid"`
+	blockstream.Message
+}
@@ -0,0 +1,351 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+)
+
+// the automation api (see restapi.go) is also served as json-rpc 2.0 over a unix domain socket in the data dir
+// (wave-api.sock), or a named pipe on windows, so local scripts (and wsh api) do not need a tcp port or the token.
+// instead the peer credentials of the connection are checked, only processes of the user running wave can connect.
+// requests and responses are json values separated by newlines, batches are supported.  the params are an
+// object, "id" is the object id and the other fields are the body and query params of the http api.  it is on
+// by default, set "api:socket" to false to turn it off.
+
+const (
+	JsonRpcParseError     = -32700
+	JsonRpcInvalidRequest = -32600
+	JsonRpcMethodNotFound = -32601
+	JsonRpcInvalidParams  = -32602
+	JsonRpcInternalError  = -32603
+	JsonRpcServerError    = -32000 // error.data.status is the http status of the error
+)
+
+type jsonRpcRequest struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id,omitempty"` // no id for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type jsonRpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type jsonRpcResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *jsonRpcError   `json:"error,omitempty"`
+}
+
+func makeJsonRpcError(id json.RawMessage, code int, msg string, data any) *jsonRpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &jsonRpcResponse{JsonRpc: "2.0", Id: id, Error: &jsonRpcError{Code: code, Message: msg, Data: data}}
+}
+
+// converts the params object to an apiRequest.  scalar fields are also query params.
+func makeJsonRpcApiRequest(params json.RawMessage) (*apiRequest, error) {
+	req := &apiRequest{Body: params, Params: url.Values{}}
+	if len(params) == 0 || string(params) == "null" {
+		req.Body = nil
+		return req, nil
+	}
+	var paramsMap map[string]any
+	err := json.Unmarshal(params, &paramsMap)
+	if err != nil {
+		return nil, fmt.Errorf("params must be an object")
+	}
+	for key, val := range paramsMap {
+		switch tval := val.(type) {
+		case string:
+			req.Params.Set(key, tval)
+		case float64:
+			req.Params.Set(key, strconv.FormatFloat(tval, 'f', -1, 64))
+		case bool:
+			req.Params.Set(key, strconv.FormatBool(tval))
+		}
+	}
+	req.Id = req.Params.Get("id")
+	return req, nil
+}
+
+type jsonRpcNotification struct {
+	JsonRpc string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// a socket connection.  subscriptions (blocks.subscribe) send notifications to it until they are removed or the
+// connection is closed.
+type jsonRpcConn struct {
+	ctx       context.Context
+	conn      net.Conn
+	writeLock sync.Mutex
+	subLock   sync.Mutex
+	subs      map[string]*jsonRpcSub
+}
+
+type jsonRpcSub struct {
+	reqId    string        // the id of the subscribe request
+	ready    chan struct{} // closed once the response to the subscribe request is sent
+	started  bool
+	cancelFn context.CancelFunc
+}
+
+func (rconn *jsonRpcConn) writeMsg(msg any) {
+	barr, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("[api] error marshaling json-rpc message: %v\n", err)
+		return
+	}
+	rconn.writeLock.Lock()
+	defer rconn.writeLock.Unlock()
+	rconn.conn.Write(append(barr, '\n'))
+}
+
+func (rconn *jsonRpcConn) addSub(reqId string) (string, *jsonRpcSub, context.Context) {
+	ctx, cancelFn := context.WithCancel(rconn.ctx)
+	sub := &jsonRpcSub{reqId: reqId, ready: make(chan struct{}), cancelFn: cancelFn}
+	subId := uuid.NewString()
+	rconn.subLock.Lock()
+	defer rconn.subLock.Unlock()
+	rconn.subs[subId] = sub
+	return subId, sub, ctx
+}
+
+// returns false if the subscription does not exist (or has ended)
+func (rconn *jsonRpcConn) removeSub(subId string) bool {
+	rconn.subLock.Lock()
+	defer rconn.subLock.Unlock()
+	sub := rconn.subs[subId]
+	if sub == nil {
+		return false
+	}
+	sub.cancelFn()
+	delete(rconn.subs, subId)
+	return true
+}
+
+// starts the subscriptions created by the requests (after their responses were sent)
+func (rconn *jsonRpcConn) startSubs(reqIds []string) {
+	rconn.subLock.Lock()
+	defer rconn.subLock.Unlock()
+	for _, sub := range rconn.subs {
+		if !sub.started && slices.Contains(reqIds, sub.reqId) {
+			sub.started = true
+			close(sub.ready)
+		}
+	}
+}
+
+type jsonRpcStreamFnType = func(ctx context.Context, rconn *jsonRpcConn, reqId string, req *apiRequest) (any, error)
+
+// the methods that send notifications, only on the socket
+var jsonRpcStreamMethods = []struct {
+	Name string
+	Fn   jsonRpcStreamFnType
+}{
+	{"blocks.subscribe", jsonRpcBlockSubscribe},
+	{"blocks.unsubscribe", jsonRpcBlockUnsubscribe},
+}
+
+func getJsonRpcStreamFn(name string) jsonRpcStreamFnType {
+	for _, method := range jsonRpcStreamMethods {
+		if method.Name == name {
+			return method.Fn
+		}
+	}
+	return nil
+}
+
+func handleJsonRpcRequest(ctx context.Context, rconn *jsonRpcConn, rpcReq *jsonRpcRequest) (rtn *jsonRpcResponse) {
+	isNotification := len(rpcReq.Id) == 0
+	defer func() {
+		recErr := panichandler.PanicHandler("handleJsonRpcRequest", recover())
+		if recErr != nil {
+			rtn = makeJsonRpcError(rpcReq.Id, JsonRpcInternalError, recErr.Error(), nil)
+		}
+		if isNotification {
+			rtn = nil
+		}
+	}()
+	if rpcReq.JsonRpc != "2.0" || rpcReq.Method == "" {
+		return makeJsonRpcError(rpcReq.Id, JsonRpcInvalidRequest, "invalid request", nil)
+	}
+	if rpcReq.Method == "methods" {
+		var names []string
+		for _, method := range apiMethods {
+			names = append(names, method.Name)
+		}
+		for _, method := range jsonRpcStreamMethods {
+			names = append(names, method.Name)
+		}
+		return &jsonRpcResponse{JsonRpc: "2.0", Id: rpcReq.Id, Result: names}
+	}
+	var fn apiFnType
+	if method := getApiMethod(rpcReq.Method); method != nil {
+		fn = method.Fn
+	} else if streamFn := getJsonRpcStreamFn(rpcReq.Method); streamFn != nil {
+		if isNotification {
+			return nil
+		}
+		fn = func(ctx context.Context, req *apiRequest) (any, error) {
+			return streamFn(ctx, rconn, string(rpcReq.Id), req)
+		}
+	} else {
+		return makeJsonRpcError(rpcReq.Id, JsonRpcMethodNotFound, fmt.Sprintf("method %q not found", rpcReq.Method), nil)
+	}
+	apiReq, err := makeJsonRpcApiRequest(rpcReq.Params)
+	if err != nil {
+		return makeJsonRpcError(rpcReq.Id, JsonRpcInvalidParams, err.Error(), nil)
+	}
+	ctx, cancelFn := context.WithTimeout(ctx, ApiRequestTimeout)
+	defer cancelFn()
+	result, err := fn(ctx, apiReq)
+	if err != nil {
+		status := getApiErrorStatus(err)
+		if status == http.StatusBadRequest {
+			return makeJsonRpcError(rpcReq.Id, JsonRpcInvalidParams, err.Error(), nil)
+		}
+		return makeJsonRpcError(rpcReq.Id, JsonRpcServerError, err.Error(), map[string]any{"status": status})
+	}
+	if result == nil {
+		result = struct{}{} // result is required in a success response
+	}
+	return &jsonRpcResponse{JsonRpc: "2.0", Id: rpcReq.Id, Result: result}
+}
+
+// returns nil if nothing should be sent (only notifications)
+func handleJsonRpcMessage(ctx context.Context, rconn *jsonRpcConn, msg json.RawMessage) any {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) > 0 && msg[0] == '[' {
+		var batch []json.RawMessage
+		err := json.Unmarshal(msg, &batch)
+		if err != nil || len(batch) == 0 {
+			return makeJsonRpcError(nil, JsonRpcInvalidRequest, "invalid batch", nil)
+		}
+		var rtn []*jsonRpcResponse
+		for _, item := range batch {
+			var rpcReq jsonRpcRequest
+			if err := json.Unmarshal(item, &rpcReq); err != nil {
+				rtn = append(rtn, makeJsonRpcError(nil, JsonRpcInvalidRequest, "invalid request", nil))
+				continue
+			}
+			if resp := handleJsonRpcRequest(ctx, rconn, &rpcReq); resp != nil {
+				rtn = append(rtn, resp)
+			}
+		}
+		if len(rtn) == 0 {
+			return nil
+		}
+		return rtn
+	}
+	var rpcReq jsonRpcRequest
+	if err := json.Unmarshal(msg, &rpcReq); err != nil {
+		return makeJsonRpcError(nil, JsonRpcInvalidRequest, "invalid request", nil)
+	}
+	if resp := handleJsonRpcRequest(ctx, rconn, &rpcReq); resp != nil {
+		return resp
+	}
+	return nil
+}
+
+func handleJsonRpcConn(conn net.Conn) {
+	defer conn.Close()
+	err := checkPeerCredentials(conn)
+	if err != nil {
+		log.Printf("[api] rejecting socket connection: %v\n", err)
+		return
+	}
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	rconn := &jsonRpcConn{ctx: ctx, conn: conn, subs: make(map[string]*jsonRpcSub)}
+	decoder := json.NewDecoder(bufio.NewReader(conn))
+	for {
+		var msg json.RawMessage
+		err := decoder.Decode(&msg)
+		if err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				rconn.writeMsg(makeJsonRpcError(nil, JsonRpcParseError, err.Error(), nil))
+			}
+			return
+		}
+		// requests are handled concurrently, responses are matched by id
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("handleJsonRpcConn", recover())
+			}()
+			resp := handleJsonRpcMessage(ctx, rconn, msg)
+			if resp == nil {
+				return
+			}
+			rconn.writeMsg(resp)
+			rconn.startSubs(getJsonRpcResponseIds(resp))
+		}()
+	}
+}
+
+func getJsonRpcResponseIds(resp any) []string {
+	switch tresp := resp.(type) {
+	case *jsonRpcResponse:
+		return []string{string(tresp.Id)}
+	case []*jsonRpcResponse:
+		var rtn []string
+		for _, item := range tresp {
+			rtn = append(rtn, string(item.Id))
+		}
+		return rtn
+	}
+	return nil
+}
+
+// does nothing if "api:socket" is false (read at startup)
+func RunApiSocketServer() {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	if settings.ApiSocket != nil && !*settings.ApiSocket {
+		return
+	}
+	sockName := getApiSocketAddr()
+	listener, err := listenApiSocket()
+	if err != nil {
+		log.Printf("[api] error creating socket at %s: %v\n", sockName, err)
+		return
+	}
+	if !registerServer("api-socket", func(ctx context.Context) error { return listener.Close() }) {
+		listener.Close()
+		return
+	}
+	log.Printf("[api] running json-rpc api on %s\n", sockName)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !IsShuttingDown() {
+				log.Printf("[api] error accepting socket connection: %v\n", err)
+			}
+			return
+		}
+		go handleJsonRpcConn(conn)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux && !darwin && !freebsd && !windows
+
+package web
+
+import "net"
+
+// unix sockets have no peer credentials here, the socket is only reachable through the data dir, which is only
+// accessible to the user running wave
+func checkPeerCredentials(conn net.Conn) error {
+	return nil
+}
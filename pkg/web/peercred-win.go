@@ -0,0 +1,22 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package web
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/wavetermdev/waveterm/pkg/util/pipeutil"
+)
+
+// only allows connections from processes of the same user (the pipe's dacl already only lets that user open it)
+func checkPeerCredentials(conn net.Conn) error {
+	pipeConn, ok := conn.(*pipeutil.PipeConn)
+	if !ok {
+		return fmt.Errorf("not a pipe connection")
+	}
+	return pipeConn.CheckClientUser()
+}
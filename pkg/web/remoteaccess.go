@@ -0,0 +1,522 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/wavetermdev/waveterm/pkg/fesession"
+	"github.com/wavetermdev/waveterm/pkg/remoteaccess"
+	"github.com/wavetermdev/waveterm/pkg/schema"
+	"github.com/wavetermdev/waveterm/pkg/util/tlsutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+	"golang.org/x/time/rate"
+)
+
+// the remote-access server serves the frontend over https to browsers (to use this machine's wave from
+// somewhere else).  it is off unless "remote:enabled" is set, and is configured once at startup.  browsers
+// log in with the password set by "wsh remote passwd" and get a session cookie, which stands in for the auth
+// key on the wave routes (/wave/*, /vdom, /ws).  only the workspaces in "remote:workspaces" are exposed (it is
+// checked on every request, see pkg/remoteaccess), and a workspace has to be open in a wave window (the browser
+// shows that window's workspace).  the certificate is "remote:tlscert"/"remote:tlskey" (reloaded when the files
+// change), or a self-signed certificate stored in the data dir, its fingerprint is logged at startup.  with
+// "remote:tlsclientca" browsers also need a client certificate signed by that ca (mtls).  "wsh remote share"
+// creates share links, a share link logs the browser in to one workspace (bound to its window) with fewer
+// permissions (see fesession).
+
+const RemoteSessionCookieName = "wave_remote_session"
+const RemoteCertFile = "remote-cert.pem"
+const RemoteKeyFile = "remote-key.pem"
+
+const remoteLoginRate = 0.2 // one attempt per 5 seconds (after the burst)
+const remoteLoginBurst = 5
+
+//go:embed remotelogin.html
+var remoteLoginHtml string
+
+var remoteLoginTemplate = template.Must(template.New("login").Parse(remoteLoginHtml))
+
+// login attempts are rate limited for the whole server, not per client address (addresses are easy to change)
+var remoteLoginLimiter = rate.NewLimiter(rate.Limit(remoteLoginRate), remoteLoginBurst)
+
+type RemoteInitOpts struct {
+	TabId    string `json:"tabId"`
+	ClientId string `json:"clientId"`
+	WindowId string `json:"windowId"`
+	Activate bool   `json:"activate"`
+}
+
+type RemoteWorkspaceInfo struct {
+	WorkspaceId string `json:"workspaceid"`
+	Name        string `json:"name"`
+	Icon        string `json:"icon,omitempty"`
+	Color       string `json:"color,omitempty"`
+	Open        bool   `json:"open"` // open in a wave window (only open workspaces can be shown)
+}
+
+type RemoteInitData struct {
+	InitOpts    *RemoteInitOpts       `json:"initopts"`
+	WorkspaceId string                `json:"workspaceid"` // the workspace that is shown
+	Workspaces  []RemoteWorkspaceInfo `json:"workspaces"`
+	UserName    string                `json:"username"`
+	HostName    string                `json:"hostname"`
+	ConfigDir   string                `json:"configdir"`
+	DataDir     string                `json:"datadir"`
+	Version     string                `json:"version"`
+	Perms       []string              `json:"perms"` // what this session is allowed to do
+}
+
+func isRemoteSessionRequest(r *http.Request) bool {
+	return fesession.GetSession(r.Context()) != nil
+}
+
+func getRemoteSessionToken(r *http.Request) string {
+	cookie, err := r.Cookie(RemoteSessionCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func setRemoteSecurityHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Frame-Options", "DENY")
+}
+
+// requests without a valid session are sent to the login page (page loads) or get a 401
+func remoteAuthWrap(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setRemoteSecurityHeaders(w)
+		scope := remoteaccess.CheckSession(getRemoteSessionToken(r))
+		if scope == nil {
+			if r.Method == http.MethodGet && (r.URL.Path == "/" || strings.HasSuffix(r.URL.Path, ".html")) {
+				http.Redirect(w, r, "/login", http.StatusSeeOther)
+				return
+			}
+			http.Error(w, "not logged in", http.StatusUnauthorized)
+			return
+		}
+		if scope.WindowId != "" {
+			// a share session can only use its window, nothing is served once it stops showing an exposed
+			// workspace
+			ctx, cancelFn := context.WithTimeout(r.Context(), 2*time.Second)
+			err := remoteaccess.CheckWindowAccess(ctx, scope, scope.WindowId)
+			cancelFn()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		ctx := fesession.ContextWithSession(r.Context(), scope)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func remoteAuthWrapFn(fn WebFnType) http.Handler {
+	return remoteAuthWrap(http.HandlerFunc(fn))
+}
+
+type remoteCheckFn func(ctx context.Context, scope *fesession.Session, r *http.Request) error
+
+// the file routes check that the session can use the file before it is served (the service route and the
+// websocket check each call and rpc themselves)
+func remoteCheckWrap(checkFn remoteCheckFn, fn WebFnType) WebFnType {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancelFn := context.WithTimeout(r.Context(), 2*time.Second)
+		err := checkFn(ctx, fesession.GetSession(r.Context()), r)
+		cancelFn()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		fn(w, r)
+	}
+}
+
+// any file on this machine
+func checkRemoteLocalFile(ctx context.Context, scope *fesession.Session, r *http.Request) error {
+	return scope.CheckPerm(fesession.Perm_Admin, "reading local files")
+}
+
+func checkRemoteStreamFile(ctx context.Context, scope *fesession.Session, r *http.Request) error {
+	err := scope.CheckPerm(fesession.Perm_View, "reading files")
+	if err != nil {
+		return err
+	}
+	return remoteaccess.CheckFileAccess(ctx, scope, r.URL.Query().Get("path"))
+}
+
+func checkRemoteWaveFile(ctx context.Context, scope *fesession.Session, r *http.Request) error {
+	err := scope.CheckPerm(fesession.Perm_View, "reading files")
+	if err != nil {
+		return err
+	}
+	return remoteaccess.CheckZoneAccess(ctx, scope, r.URL.Query().Get("zoneid"))
+}
+
+// state-changing requests and websockets must come from the page this server served
+func checkRemoteOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return originUrl.Host == r.Host
+}
+
+func writeRemoteLoginPage(w http.ResponseWriter, status int, errMsg string) {
+	hostName, _ := os.Hostname()
+	w.Header().Set(ContentTypeHeaderKey, "text/html; charset=utf-8")
+	w.Header().Set(CacheControlHeaderKey, CacheControlHeaderNoCache)
+	w.WriteHeader(status)
+	remoteLoginTemplate.Execute(w, map[string]string{"HostName": hostName, "Error": errMsg})
+}
+
+func handleRemoteLogin(w http.ResponseWriter, r *http.Request) {
+	setRemoteSecurityHeaders(w)
+	if r.Method == http.MethodGet {
+		writeRemoteLoginPage(w, http.StatusOK, "")
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkRemoteOrigin(r) {
+		http.Error(w, "invalid origin", http.StatusForbidden)
+		return
+	}
+	if !remoteLoginLimiter.Allow() {
+		writeRemoteLoginPage(w, http.StatusTooManyRequests, "Too many login attempts, try again later.")
+		return
+	}
+	if !remoteaccess.HasPassword() {
+		writeRemoteLoginPage(w, http.StatusForbidden, "No password is set, run \"wsh remote passwd\" on the host.")
+		return
+	}
+	if !remoteaccess.CheckPassword(r.PostFormValue("password")) {
+		log.Printf("[remote] failed login from %s\n", r.RemoteAddr)
+		writeRemoteLoginPage(w, http.StatusUnauthorized, "Incorrect password.")
+		return
+	}
+	token, err := remoteaccess.CreateSession(r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[remote] login from %s\n", r.RemoteAddr)
+	setRemoteSessionCookie(w, token)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func setRemoteSessionCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     RemoteSessionCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// opening a share link creates a session for the shared workspace (share tokens are as hard to guess as
+// session tokens, but the lookups are rate limited like logins).  the cookie is SameSite=Strict, so the
+// browser would not send it on the redirect that follows the link, the page is loaded with a client-side
+// redirect instead.
+func handleRemoteShare(w http.ResponseWriter, r *http.Request) {
+	setRemoteSecurityHeaders(w)
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !remoteLoginLimiter.Allow() {
+		http.Error(w, "too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+	shareToken := mux.Vars(r)["token"]
+	ctx, cancelFn := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancelFn()
+	token, workspaceId, err := remoteaccess.CreateShareSession(ctx, shareToken, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Printf("[remote] invalid share link from %s: %v\n", r.RemoteAddr, err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	log.Printf("[remote] share link opened from %s\n", r.RemoteAddr)
+	setRemoteSessionCookie(w, token)
+	target := "/?" + url.Values{"workspaceid": {workspaceId}}.Encode()
+	w.Header().Set(ContentTypeHeaderKey, "text/html; charset=utf-8")
+	w.Header().Set(CacheControlHeaderKey, CacheControlHeaderNoCache)
+	fmt.Fprintf(w, "<!DOCTYPE html><meta http-equiv=\"refresh\" content=\"0; url=%s\">\n", template.HTMLEscapeString(target))
+}
+
+func handleRemoteLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkRemoteOrigin(r) {
+		http.Error(w, "invalid origin", http.StatusForbidden)
+		return
+	}
+	remoteaccess.RevokeSessionByToken(getRemoteSessionToken(r))
+	http.SetCookie(w, &http.Cookie{
+		Name:     RemoteSessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// picks the workspace and tab to show: the workspace of the tabid param, the workspaceid param, or the first
+// exposed workspace that is open in a window
+func getRemoteInitOpts(ctx context.Context, scope *fesession.Session, workspaces []RemoteWorkspaceInfo, workspaceId string, tabId string) (*RemoteInitOpts, string, error) {
+	if tabId != "" {
+		tabWsId, err := wstore.DBFindWorkspaceForTabId(ctx, tabId)
+		if err != nil || tabWsId == "" {
+			return nil, "", fmt.Errorf("tab %s not found", tabId)
+		}
+		workspaceId = tabWsId
+	}
+	if workspaceId == "" {
+		for _, wsInfo := range workspaces {
+			if wsInfo.Open {
+				workspaceId = wsInfo.WorkspaceId
+				break
+			}
+		}
+		if workspaceId == "" {
+			return nil, "", fmt.Errorf("none of the exposed workspaces are open in a wave window")
+		}
+	}
+	idx := slices.IndexFunc(workspaces, func(wsInfo RemoteWorkspaceInfo) bool { return wsInfo.WorkspaceId == workspaceId })
+	if idx == -1 {
+		return nil, "", fmt.Errorf("workspace %s is not exposed", workspaceId)
+	}
+	windowId, err := wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+	if err != nil || windowId == "" {
+		return nil, "", fmt.Errorf("workspace %q is not open in a wave window", workspaces[idx].Name)
+	}
+	err = scope.CheckWindow(windowId)
+	if err != nil {
+		return nil, "", fmt.Errorf("this session cannot use workspace %q", workspaces[idx].Name)
+	}
+	ws, err := wstore.DBMustGet[*waveobj.Workspace](ctx, workspaceId)
+	if err != nil {
+		return nil, "", err
+	}
+	if tabId == "" {
+		tabId = ws.ActiveTabId
+	}
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting client: %w", err)
+	}
+	return &RemoteInitOpts{TabId: tabId, ClientId: client.OID, WindowId: windowId, Activate: true}, workspaceId, nil
+}
+
+// a session that is bound to a window only sees that window's workspace
+func getRemoteWorkspaces(ctx context.Context, scope *fesession.Session) ([]RemoteWorkspaceInfo, error) {
+	wsList, err := wcore.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []RemoteWorkspaceInfo
+	for _, entry := range wsList {
+		ws, err := wstore.DBGet[*waveobj.Workspace](ctx, entry.WorkspaceId)
+		if err != nil || !remoteaccess.IsWorkspaceExposed(ws) {
+			continue
+		}
+		if scope.WindowId != "" && entry.WindowId != scope.WindowId {
+			continue
+		}
+		rtn = append(rtn, RemoteWorkspaceInfo{
+			WorkspaceId: ws.OID,
+			Name:        ws.Name,
+			Icon:        ws.Icon,
+			Color:       ws.Color,
+			Open:        entry.WindowId != "",
+		})
+	}
+	return rtn, nil
+}
+
+func handleRemoteInit(w http.ResponseWriter, r *http.Request) {
+	ctx, cancelFn := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancelFn()
+	scope := fesession.GetSession(r.Context())
+	workspaces, err := getRemoteWorkspaces(ctx, scope)
+	if err != nil {
+		WriteJsonError(w, err)
+		return
+	}
+	initOpts, workspaceId, err := getRemoteInitOpts(ctx, scope, workspaces, r.URL.Query().Get("workspaceid"), r.URL.Query().Get("tabid"))
+	if err != nil {
+		WriteJsonError(w, err)
+		return
+	}
+	hostName, _ := os.Hostname()
+	var userName string
+	if curUser, err := user.Current(); err == nil {
+		userName = curUser.Username
+	}
+	WriteJsonSuccess(w, RemoteInitData{
+		InitOpts:    initOpts,
+		WorkspaceId: workspaceId,
+		Workspaces:  workspaces,
+		UserName:    userName,
+		HostName:    hostName,
+		ConfigDir:   wavebase.GetWaveConfigDir(),
+		DataDir:     wavebase.GetWaveDataDir(),
+		Version:     wavebase.WaveVersion,
+		Perms:       scope.Perms,
+	})
+}
+
+func handleRemoteWs(w http.ResponseWriter, r *http.Request) {
+	if !checkRemoteOrigin(r) {
+		http.Error(w, "invalid origin", http.StatusForbidden)
+		return
+	}
+	tabId := r.URL.Query().Get("tabid")
+	if tabId == "" || tabId == wshutil.ElectronRoute {
+		http.Error(w, "invalid tabid", http.StatusBadRequest)
+		return
+	}
+	feClientId := r.URL.Query().Get("feclientid")
+	if feClientId != "" && !feClientIdRe.MatchString(feClientId) {
+		http.Error(w, "invalid feclientid", http.StatusBadRequest)
+		return
+	}
+	scope := fesession.GetSession(r.Context())
+	ctx, cancelFn := context.WithTimeout(r.Context(), 2*time.Second)
+	err := remoteaccess.CheckTabAccess(ctx, scope, tabId)
+	cancelFn()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	err = serveWsConn(w, r, tabId, feClientId, scope)
+	if err != nil {
+		log.Printf("[remote] websocket error: %v\n", err)
+	}
+}
+
+func makeRemoteRouter() http.Handler {
+	timeoutWrap := func(handler http.Handler) http.Handler {
+		return http.TimeoutHandler(handler, HttpTimeoutDuration, "Timeout")
+	}
+	frontendDir := filepath.Join(wavebase.GetWaveAppPath(), "frontend")
+	gr := mux.NewRouter()
+	gr.Handle("/login", timeoutWrap(http.HandlerFunc(handleRemoteLogin)))
+	gr.Handle(remoteaccess.ShareUrlPrefix+"{token}", timeoutWrap(http.HandlerFunc(handleRemoteShare)))
+	gr.Handle("/logout", timeoutWrap(remoteAuthWrapFn(handleRemoteLogout)))
+	gr.Handle("/remote/init", timeoutWrap(remoteAuthWrapFn(handleRemoteInit)))
+	gr.Handle("/ws", remoteAuthWrapFn(handleRemoteWs))
+	gr.Handle("/wave/stream-local-file", timeoutWrap(remoteAuthWrapFn(WebFnWrap(WebFnOpts{AllowCaching: true}, remoteCheckWrap(checkRemoteLocalFile, handleStreamLocalFile)))))
+	gr.Handle("/wave/stream-file", timeoutWrap(remoteAuthWrapFn(WebFnWrap(WebFnOpts{AllowCaching: true}, remoteCheckWrap(checkRemoteStreamFile, handleStreamFile)))))
+	gr.PathPrefix("/wave/stream-file/").Handler(timeoutWrap(remoteAuthWrapFn(WebFnWrap(WebFnOpts{AllowCaching: true}, remoteCheckWrap(checkRemoteStreamFile, handleStreamFile)))))
+	gr.Handle("/wave/file", timeoutWrap(remoteAuthWrapFn(WebFnWrap(WebFnOpts{AllowCaching: false}, remoteCheckWrap(checkRemoteWaveFile, handleWaveFile)))))
+	gr.Handle("/wave/service", timeoutWrap(remoteAuthWrapFn(WebFnWrap(WebFnOpts{JsonErrors: true}, handleService))))
+	gr.Handle("/vdom/{uuid}/{path:.*}", timeoutWrap(remoteAuthWrapFn(WebFnWrap(WebFnOpts{AllowCaching: true}, handleVDom))))
+	gr.PathPrefix(schemaPrefix).Handler(remoteAuthWrap(http.StripPrefix(schemaPrefix, schema.GetSchemaHandler())))
+	gr.PathPrefix("/").Handler(timeoutWrap(remoteAuthWrap(http.FileServer(http.Dir(frontendDir)))))
+	return gr
+}
+
+// the cert/key from "remote:tlscert" and "remote:tlskey", or a self-signed certificate in the data dir
+func makeRemoteCertReloader(settings wconfig.SettingsType) (*tlsutil.CertReloader, error) {
+	opts := tlsutil.ServerTLSOpts{LogPrefix: "[remote]"}
+	if settings.RemoteTlsCert != "" || settings.RemoteTlsKey != "" {
+		if settings.RemoteTlsCert == "" || settings.RemoteTlsKey == "" {
+			return nil, fmt.Errorf("remote:tlscert and remote:tlskey must be set together")
+		}
+		opts.CertFile = wavebase.ExpandHomeDirSafe(settings.RemoteTlsCert)
+		opts.KeyFile = wavebase.ExpandHomeDirSafe(settings.RemoteTlsKey)
+	} else {
+		opts.CertFile = filepath.Join(wavebase.GetWaveDataDir(), RemoteCertFile)
+		opts.KeyFile = filepath.Join(wavebase.GetWaveDataDir(), RemoteKeyFile)
+		opts.SelfSigned = true
+	}
+	if settings.RemoteTlsClientCa != "" {
+		opts.ClientCAFile = wavebase.ExpandHomeDirSafe(settings.RemoteTlsClientCa)
+	}
+	return tlsutil.MakeCertReloader(opts)
+}
+
+// blocking
+func RunRemoteAccessServer() {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	if !settings.RemoteEnabled {
+		return
+	}
+	if len(settings.RemoteWorkspaces) == 0 {
+		log.Printf("[remote] warning: no workspaces are exposed (set \"remote:workspaces\")\n")
+	}
+	if !remoteaccess.HasPassword() {
+		log.Printf("[remote] warning: no password is set (run \"wsh remote passwd\"), logins will fail\n")
+	}
+	certReloader, err := makeRemoteCertReloader(settings)
+	if err != nil {
+		log.Printf("[remote] not starting the remote-access server: %v\n", err)
+		return
+	}
+	listenAddr := settings.RemoteListenAddr
+	if listenAddr == "" {
+		listenAddr = remoteaccess.DefaultListenAddr
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		log.Printf("[remote] error listening on %s: %v\n", listenAddr, err)
+		return
+	}
+	log.Printf("[remote] running remote-access server on https://%s (certificate fingerprint %s)\n", listener.Addr(), tlsutil.GetFingerprint(certReloader.GetCert()))
+	if certReloader.RequiresClientCert() {
+		log.Printf("[remote] requiring client certificates signed by %s\n", settings.RemoteTlsClientCa)
+	}
+	server := &http.Server{
+		ReadTimeout:    HttpReadTimeout,
+		WriteTimeout:   HttpWriteTimeout,
+		MaxHeaderBytes: HttpMaxHeaderBytes,
+		Handler:        makeRemoteRouter(),
+		TLSConfig:      certReloader.TLSConfig(),
+	}
+	if !registerServer("remote", server.Shutdown) {
+		listener.Close()
+		return
+	}
+	err = server.ServeTLS(listener, "", "")
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("[remote] error running remote-access server: %v\n", err)
+	}
+}
@@ -0,0 +1,730 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/grpcapi"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/service/objectservice"
+	"github.com/wavetermdev/waveterm/pkg/service/workspaceservice"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wnotify"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshserver"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the automation api is a small json api for scripts and launchers (raycast, alfred, etc.) that do not want to
+// speak the websocket rpc protocol.  it is off by default ("api:enabled"), listens on localhost
+// ("api:listenaddr"), and every request needs the token from the api-token file in the data dir
+// ("Authorization: Bearer [token]").  the token is created the first time the api is started, delete the file
+// to rotate it.  responses are {"data": ...} or {"error": "..."} with a matching http status.
+
+const ApiTokenFile = "api-token"
+const DefaultApiListenAddr = "127.0.0.1:61269"
+const DefaultGrpcListenAddr = "127.0.0.1:61271"
+const ApiRequestTimeout = 5 * time.Second
+const ApiDefaultOutputBytes = 64 * 1024
+const ApiMaxBodySize = 1024 * 1024
+
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func apiErrorf(status int, format string, args ...any) error {
+	return &apiError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+type apiFnType = func(ctx context.Context, req *apiRequest) (any, error)
+
+var apiToken string
+var apiTokenLock = &sync.Mutex{}
+
+var workspaceSvc = &workspaceservice.WorkspaceService{}
+var objectSvc = &objectservice.ObjectService{}
+
+func getApiTokenPath() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), ApiTokenFile)
+}
+
+// the automation api and the grpc api share the token
+func ensureApiToken() (string, error) {
+	apiTokenLock.Lock()
+	defer apiTokenLock.Unlock()
+	tokenPath := getApiTokenPath()
+	barr, err := os.ReadFile(tokenPath)
+	if err == nil && len(strings.TrimSpace(string(barr))) > 0 {
+		return strings.TrimSpace(string(barr)), nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("error reading api token: %w", err)
+	}
+	tokenBytes := make([]byte, 32)
+	_, err = rand.Read(tokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("error generating api token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+	err = os.WriteFile(tokenPath, []byte(token+"\n"), 0600)
+	if err != nil {
+		return "", fmt.Errorf("error writing api token: %w", err)
+	}
+	return token, nil
+}
+
+func validateApiRequest(r *http.Request) error {
+	authHeader := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok || token == "" {
+		return fmt.Errorf("no bearer token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(apiToken)) != 1 {
+		return fmt.Errorf("invalid token")
+	}
+	return nil
+}
+
+func writeApiResponse(w http.ResponseWriter, status int, rtn map[string]any) {
+	barr, err := json.Marshal(rtn)
+	if err != nil {
+		status = http.StatusInternalServerError
+		barr, _ = json.Marshal(map[string]any{"error": fmt.Sprintf("error marshaling response: %v", err)})
+	}
+	w.Header().Set(ContentTypeHeaderKey, ContentTypeJson)
+	w.Header().Set(CacheControlHeaderKey, CacheControlHeaderNoCache)
+	w.WriteHeader(status)
+	w.Write(barr)
+}
+
+// the same methods are served over http (ApiFnWrap) and json-rpc (see jsonrpc.go)
+type apiRequest struct {
+	Id     string          // the object id ({id} in the path)
+	Body   json.RawMessage // json object
+	Params url.Values      // query params
+}
+
+func (req *apiRequest) readBody(dest any) error {
+	if len(req.Body) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(req.Body, dest)
+	if err != nil {
+		return apiErrorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+func getApiErrorStatus(err error) int {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.status
+	}
+	return http.StatusInternalServerError
+}
+
+func ApiFnWrap(fn apiFnType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recErr := panichandler.PanicHandler("ApiFnWrap", recover())
+			if recErr != nil {
+				writeApiResponse(w, http.StatusInternalServerError, map[string]any{"error": recErr.Error()})
+			}
+		}()
+		err := validateApiRequest(r)
+		if err != nil {
+			writeApiResponse(w, http.StatusUnauthorized, map[string]any{"error": err.Error()})
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ApiMaxBodySize))
+		if err != nil {
+			writeApiResponse(w, http.StatusBadRequest, map[string]any{"error": fmt.Sprintf("error reading body: %v", err)})
+			return
+		}
+		ctx, cancelFn := context.WithTimeout(r.Context(), ApiRequestTimeout)
+		defer cancelFn()
+		data, err := fn(ctx, &apiRequest{Id: mux.Vars(r)["id"], Body: body, Params: r.URL.Query()})
+		if err != nil {
+			writeApiResponse(w, getApiErrorStatus(err), map[string]any{"error": err.Error()})
+			return
+		}
+		writeApiResponse(w, http.StatusOK, map[string]any{"data": data})
+	}
+}
+
+func getApiObj[T waveobj.WaveObj](ctx context.Context, id string) (T, error) {
+	obj, err := wstore.DBMustGet[T](ctx, id)
+	if errors.Is(err, wstore.ErrNotFound) {
+		return obj, apiErrorf(http.StatusNotFound, "%s not found", id)
+	}
+	return obj, err
+}
+
+// workspaces
+
+type apiWorkspace struct {
+	Workspace *waveobj.Workspace `json:"workspace"`
+	WindowId  string             `json:"windowid,omitempty"` // not set if the workspace is not open
+}
+
+type apiWorkspaceData struct {
+	Name  string `json:"name"`
+	Icon  string `json:"icon"`
+	Color string `json:"color"`
+}
+
+func apiListWorkspaces(ctx context.Context, req *apiRequest) (any, error) {
+	wsList, err := wcore.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]apiWorkspace, 0, len(wsList))
+	for _, entry := range wsList {
+		ws, err := getApiObj[*waveobj.Workspace](ctx, entry.WorkspaceId)
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, apiWorkspace{Workspace: ws, WindowId: entry.WindowId})
+	}
+	return rtn, nil
+}
+
+func apiCreateWorkspace(ctx context.Context, req *apiRequest) (any, error) {
+	var data apiWorkspaceData
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	ws, err := wcore.CreateWorkspace(ctx, data.Name, data.Icon, data.Color, true, false)
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func apiGetWorkspace(ctx context.Context, req *apiRequest) (any, error) {
+	return getApiObj[*waveobj.Workspace](ctx, req.Id)
+}
+
+func apiUpdateWorkspace(ctx context.Context, req *apiRequest) (any, error) {
+	ws, err := getApiObj[*waveobj.Workspace](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	data := apiWorkspaceData{Name: ws.Name, Icon: ws.Icon, Color: ws.Color}
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	_, err = workspaceSvc.UpdateWorkspace(ctx, ws.OID, data.Name, data.Icon, data.Color, false)
+	if err != nil {
+		return nil, err
+	}
+	return getApiObj[*waveobj.Workspace](ctx, ws.OID)
+}
+
+func apiDeleteWorkspace(ctx context.Context, req *apiRequest) (any, error) {
+	ws, err := getApiObj[*waveobj.Workspace](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	windowId, err := wstore.DBFindWindowForWorkspaceId(ctx, ws.OID)
+	if err != nil {
+		return nil, err
+	}
+	if windowId != "" {
+		return nil, apiErrorf(http.StatusConflict, "workspace %s is open in a window", ws.OID)
+	}
+	_, _, err = workspaceSvc.DeleteWorkspace(ws.OID)
+	return nil, err
+}
+
+// tabs
+
+type apiTabData struct {
+	Name     string `json:"name"`
+	Activate bool   `json:"activate"`
+	Pinned   bool   `json:"pinned"`
+}
+
+func apiListTabs(ctx context.Context, req *apiRequest) (any, error) {
+	ws, err := getApiObj[*waveobj.Workspace](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]*waveobj.Tab, 0)
+	for _, tabId := range append(append([]string{}, ws.PinnedTabIds...), ws.TabIds...) {
+		tab, err := getApiObj[*waveobj.Tab](ctx, tabId)
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, tab)
+	}
+	return rtn, nil
+}
+
+func apiCreateTab(ctx context.Context, req *apiRequest) (any, error) {
+	ws, err := getApiObj[*waveobj.Workspace](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	var data apiTabData
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	tabId, _, err := workspaceSvc.CreateTab(ws.OID, data.Name, data.Activate, data.Pinned)
+	if err != nil {
+		return nil, err
+	}
+	return getApiObj[*waveobj.Tab](ctx, tabId)
+}
+
+func apiGetTab(ctx context.Context, req *apiRequest) (any, error) {
+	return getApiObj[*waveobj.Tab](ctx, req.Id)
+}
+
+func apiUpdateTab(ctx context.Context, req *apiRequest) (any, error) {
+	tab, err := getApiObj[*waveobj.Tab](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	var data apiTabData
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	if data.Name != "" {
+		updates, err := objectSvc.UpdateTabName(waveobj.UIContext{}, tab.OID, data.Name)
+		if err != nil {
+			return nil, err
+		}
+		eventbus.PublishObjectUpdates(updates)
+	}
+	if data.Activate {
+		workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, tab.OID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = workspaceSvc.SetActiveTab(workspaceId, tab.OID)
+		if err != nil {
+			return nil, err
+		}
+		// switches the tab in the window the workspace is open in
+		wcore.SendActiveTabUpdate(ctx, workspaceId, tab.OID)
+	}
+	return getApiObj[*waveobj.Tab](ctx, tab.OID)
+}
+
+func apiDeleteTab(ctx context.Context, req *apiRequest) (any, error) {
+	tab, err := getApiObj[*waveobj.Tab](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, tab.OID)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := getApiObj[*waveobj.Workspace](ctx, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if len(ws.TabIds)+len(ws.PinnedTabIds) <= 1 {
+		return nil, apiErrorf(http.StatusConflict, "cannot close the last tab of a workspace")
+	}
+	_, _, err = workspaceSvc.CloseTab(ctx, workspaceId, tab.OID, false)
+	return nil, err
+}
+
+// blocks
+
+type apiCreateBlockData struct {
+	Meta          waveobj.MetaMapType `json:"meta"`
+	Magnified     bool                `json:"magnified"`
+	TargetBlockId string              `json:"targetblockid"`
+	TargetAction  string              `json:"targetaction"`
+}
+
+type apiBlockMetaData struct {
+	Meta waveobj.MetaMapType `json:"meta"`
+}
+
+type apiBlockInputData struct {
+	Text   string `json:"text"`
+	Signal string `json:"signal"`
+}
+
+type apiBlockRunData struct {
+	Cmd string `json:"cmd"`
+}
+
+type apiOutputData struct {
+	Output string `json:"output"`
+	Offset int64  `json:"offset"` // pass as ?offset= to only get the output after this
+}
+
+func apiListBlocks(ctx context.Context, req *apiRequest) (any, error) {
+	tab, err := getApiObj[*waveobj.Tab](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]*waveobj.Block, 0, len(tab.BlockIds))
+	for _, blockId := range tab.BlockIds {
+		block, err := getApiObj[*waveobj.Block](ctx, blockId)
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, block)
+	}
+	return rtn, nil
+}
+
+func apiCreateBlock(ctx context.Context, req *apiRequest) (any, error) {
+	tab, err := getApiObj[*waveobj.Tab](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	var data apiCreateBlockData
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	if data.Meta.GetString(waveobj.MetaKey_View, "") == "" {
+		return nil, apiErrorf(http.StatusBadRequest, "meta.view is required")
+	}
+	oref, err := wshserver.WshServerImpl.CreateBlockCommand(ctx, wshrpc.CommandCreateBlockData{
+		TabId:         tab.OID,
+		BlockDef:      &waveobj.BlockDef{Meta: data.Meta},
+		Magnified:     data.Magnified,
+		TargetBlockId: data.TargetBlockId,
+		TargetAction:  data.TargetAction,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return getApiObj[*waveobj.Block](ctx, oref.OID)
+}
+
+func apiGetBlock(ctx context.Context, req *apiRequest) (any, error) {
+	return getApiObj[*waveobj.Block](ctx, req.Id)
+}
+
+func apiUpdateBlock(ctx context.Context, req *apiRequest) (any, error) {
+	block, err := getApiObj[*waveobj.Block](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	var data apiBlockMetaData
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	oref := waveobj.MakeORef(waveobj.OType_Block, block.OID)
+	err = wshserver.WshServerImpl.SetMetaCommand(ctx, wshrpc.CommandSetMetaData{ORef: oref, Meta: data.Meta})
+	if err != nil {
+		return nil, err
+	}
+	return getApiObj[*waveobj.Block](ctx, block.OID)
+}
+
+func apiDeleteBlock(ctx context.Context, req *apiRequest) (any, error) {
+	block, err := getApiObj[*waveobj.Block](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return nil, wshserver.WshServerImpl.DeleteBlockCommand(ctx, wshrpc.CommandDeleteBlockData{BlockId: block.OID})
+}
+
+// sends text (typed as is, include "\r" to press enter) and/or a signal to the block's process
+func apiBlockInput(ctx context.Context, req *apiRequest) (any, error) {
+	block, err := getApiObj[*waveobj.Block](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	var data apiBlockInputData
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	if data.Text == "" && data.Signal == "" {
+		return nil, apiErrorf(http.StatusBadRequest, "text or signal is required")
+	}
+	inputData := wshrpc.CommandBlockInputData{BlockId: block.OID, SigName: data.Signal}
+	if data.Text != "" {
+		inputData.InputData64 = base64.StdEncoding.EncodeToString([]byte(data.Text))
+	}
+	return nil, wshserver.WshServerImpl.ControllerInputCommand(ctx, inputData)
+}
+
+// queues a command in the block's shell (see blockcontroller/cmdqueue.go), poll the queue to see when it is done
+func apiBlockRun(ctx context.Context, req *apiRequest) (any, error) {
+	block, err := getApiObj[*waveobj.Block](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	var data apiBlockRunData
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(data.Cmd) == "" {
+		return nil, apiErrorf(http.StatusBadRequest, "cmd is required")
+	}
+	return blockcontroller.QueueCommand(block.OID, data.Cmd)
+}
+
+func apiBlockQueue(ctx context.Context, req *apiRequest) (any, error) {
+	block, err := getApiObj[*waveobj.Block](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return blockcontroller.ListQueuedCommands(block.OID)
+}
+
+var apiOutputEscRe = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[@-_]`)
+
+// the terminal output as text (escape sequences removed), ?raw=1 returns it as it was written.  ?offset= returns
+// the output after the offset (from a previous call), otherwise the last ?maxbytes= (default 64k) are returned.
+func apiBlockOutput(ctx context.Context, req *apiRequest) (any, error) {
+	block, err := getApiObj[*waveobj.Block](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	query := req.Params
+	maxBytes := int64(ApiDefaultOutputBytes)
+	if query.Get("maxbytes") != "" {
+		maxBytes, err = strconv.ParseInt(query.Get("maxbytes"), 10, 64)
+		if err != nil || maxBytes <= 0 {
+			return nil, apiErrorf(http.StatusBadRequest, "invalid maxbytes %q", query.Get("maxbytes"))
+		}
+	}
+	dataOffset, data, err := filestore.WFS.ReadFile(ctx, block.OID, wavebase.BlockFile_Term)
+	if errors.Is(err, fs.ErrNotExist) {
+		return apiOutputData{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	endOffset := dataOffset + int64(len(data))
+	if query.Get("offset") != "" {
+		offset, err := strconv.ParseInt(query.Get("offset"), 10, 64)
+		if err != nil || offset < 0 {
+			return nil, apiErrorf(http.StatusBadRequest, "invalid offset %q", query.Get("offset"))
+		}
+		if offset > dataOffset {
+			data = data[min(offset-dataOffset, int64(len(data))):]
+		}
+	}
+	if int64(len(data)) > maxBytes {
+		data = data[int64(len(data))-maxBytes:]
+	}
+	output := string(data)
+	if raw, _ := strconv.ParseBool(query.Get("raw")); !raw {
+		output = apiOutputEscRe.ReplaceAllString(output, "")
+		output = strings.ReplaceAll(output, "\r\n", "\n")
+		output = strings.ToValidUTF8(output, "")
+	}
+	return apiOutputData{Output: output, Offset: endOffset}, nil
+}
+
+// notifications (incoming webhooks, e.g. from ci jobs)
+
+type apiNotificationData struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Type     string `json:"type"`
+	BlockId  string `json:"blockid"`
+	DedupKey string `json:"dedupkey"`
+	Desktop  bool   `json:"desktop"`
+}
+
+func apiListNotifications(ctx context.Context, req *apiRequest) (any, error) {
+	return wshserver.WshServerImpl.NotificationListCommand(ctx)
+}
+
+func apiCreateNotification(ctx context.Context, req *apiRequest) (any, error) {
+	var data apiNotificationData
+	if err := req.readBody(&data); err != nil {
+		return nil, err
+	}
+	if data.Title == "" && data.Message == "" {
+		return nil, apiErrorf(http.StatusBadRequest, "title or message is required")
+	}
+	return wshserver.WshServerImpl.NotificationSendCommand(ctx, wshrpc.CommandNotificationSendData{
+		Title:    data.Title,
+		Message:  data.Message,
+		Type:     data.Type,
+		Source:   wnotify.Source_Webhook,
+		BlockId:  data.BlockId,
+		DedupKey: data.DedupKey,
+		Desktop:  data.Desktop,
+	})
+}
+
+func apiDismissNotification(ctx context.Context, req *apiRequest) (any, error) {
+	notif, err := getApiObj[*waveobj.Notification](ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = wshserver.WshServerImpl.NotificationDismissCommand(ctx, wshrpc.CommandNotificationDismissData{Id: notif.OID})
+	return nil, err
+}
+
+type apiMethod struct {
+	Name       string // json-rpc method
+	HttpMethod string
+	Path       string // under /api/v1
+	Fn         apiFnType
+	Body       reflect.Type // for the schema (see apischema.go), nil if there is no body
+	Result     reflect.Type // nil if the result is null
+	Query      []string
+}
+
+// for the list methods of json-rpc, the id is the parent's (e.g. the workspace for tabs.list)
+var apiMethods = []apiMethod{
+	{"workspaces.list", http.MethodGet, "/workspaces", apiListWorkspaces, nil, reflect.TypeFor[[]apiWorkspace](), nil},
+	{"workspaces.create", http.MethodPost, "/workspaces", apiCreateWorkspace, reflect.TypeFor[apiWorkspaceData](), reflect.TypeFor[*waveobj.Workspace](), nil},
+	{"workspaces.get", http.MethodGet, "/workspaces/{id}", apiGetWorkspace, nil, reflect.TypeFor[*waveobj.Workspace](), nil},
+	{"workspaces.update", http.MethodPatch, "/workspaces/{id}", apiUpdateWorkspace, reflect.TypeFor[apiWorkspaceData](), reflect.TypeFor[*waveobj.Workspace](), nil},
+	{"workspaces.delete", http.MethodDelete, "/workspaces/{id}", apiDeleteWorkspace, nil, nil, nil},
+	{"tabs.list", http.MethodGet, "/workspaces/{id}/tabs", apiListTabs, nil, reflect.TypeFor[[]*waveobj.Tab](), nil},
+	{"tabs.create", http.MethodPost, "/workspaces/{id}/tabs", apiCreateTab, reflect.TypeFor[apiTabData](), reflect.TypeFor[*waveobj.Tab](), nil},
+	{"tabs.get", http.MethodGet, "/tabs/{id}", apiGetTab, nil, reflect.TypeFor[*waveobj.Tab](), nil},
+	{"tabs.update", http.MethodPatch, "/tabs/{id}", apiUpdateTab, reflect.TypeFor[apiTabData](), reflect.TypeFor[*waveobj.Tab](), nil},
+	{"tabs.delete", http.MethodDelete, "/tabs/{id}", apiDeleteTab, nil, nil, nil},
+	{"blocks.list", http.MethodGet, "/tabs/{id}/blocks", apiListBlocks, nil, reflect.TypeFor[[]*waveobj.Block](), nil},
+	{"blocks.create", http.MethodPost, "/tabs/{id}/blocks", apiCreateBlock, reflect.TypeFor[apiCreateBlockData](), reflect.TypeFor[*waveobj.Block](), nil},
+	{"blocks.get", http.MethodGet, "/blocks/{id}", apiGetBlock, nil, reflect.TypeFor[*waveobj.Block](), nil},
+	{"blocks.update", http.MethodPatch, "/blocks/{id}", apiUpdateBlock, reflect.TypeFor[apiBlockMetaData](), reflect.TypeFor[*waveobj.Block](), nil},
+	{"blocks.delete", http.MethodDelete, "/blocks/{id}", apiDeleteBlock, nil, nil, nil},
+	{"blocks.input", http.MethodPost, "/blocks/{id}/input", apiBlockInput, reflect.TypeFor[apiBlockInputData](), nil, nil},
+	{"blocks.run", http.MethodPost, "/blocks/{id}/run", apiBlockRun, reflect.TypeFor[apiBlockRunData](), reflect.TypeFor[*wshrpc.QueuedCommand](), nil},
+	{"blocks.queue", http.MethodGet, "/blocks/{id}/queue", apiBlockQueue, nil, reflect.TypeFor[[]wshrpc.QueuedCommand](), nil},
+	{"blocks.output", http.MethodGet, "/blocks/{id}/output", apiBlockOutput, nil, reflect.TypeFor[apiOutputData](), []string{"offset", "maxbytes", "raw"}},
+	{"notifications.list", http.MethodGet, "/notifications", apiListNotifications, nil, reflect.TypeFor[[]*waveobj.Notification](), nil},
+	{"notifications.create", http.MethodPost, "/notifications", apiCreateNotification, reflect.TypeFor[apiNotificationData](), reflect.TypeFor[*waveobj.Notification](), nil},
+	{"notifications.dismiss", http.MethodDelete, "/notifications/{id}", apiDismissNotification, nil, nil, nil},
+}
+
+func getApiMethod(name string) *apiMethod {
+	for idx := range apiMethods {
+		if apiMethods[idx].Name == name {
+			return &apiMethods[idx]
+		}
+	}
+	return nil
+}
+
+func makeApiRouter() *mux.Router {
+	gr := mux.NewRouter()
+	api := gr.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/openapi.json", handleApiSchema).Methods(http.MethodGet)
+	api.HandleFunc("/metrics", handleApiMetrics).Methods(http.MethodGet)
+	for _, method := range apiMethods {
+		api.HandleFunc(method.Path, ApiFnWrap(method.Fn)).Methods(method.HttpMethod)
+	}
+	api.HandleFunc("/blocks/{id}/stream", handleBlockStreamWs).Methods(http.MethodGet)
+	return gr
+}
+
+// does nothing unless "api:enabled" is set (read at startup)
+func RunApiServer() {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	if !settings.ApiEnabled {
+		return
+	}
+	var err error
+	apiToken, err = ensureApiToken()
+	if err != nil {
+		log.Printf("[api] not starting the automation api: %v\n", err)
+		return
+	}
+	listenAddr := settings.ApiListenAddr
+	if listenAddr == "" {
+		listenAddr = DefaultApiListenAddr
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		log.Printf("[api] error listening on %s: %v\n", listenAddr, err)
+		return
+	}
+	log.Printf("[api] running automation api on %s (token in %s)\n", listener.Addr(), getApiTokenPath())
+	server := &http.Server{
+		ReadTimeout:    HttpReadTimeout,
+		WriteTimeout:   HttpWriteTimeout,
+		MaxHeaderBytes: HttpMaxHeaderBytes,
+		Handler:        makeApiRouter(),
+	}
+	if !registerServer("api", server.Shutdown) {
+		listener.Close()
+		return
+	}
+	err = server.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("[api] error running automation api: %v\n", err)
+	}
+}
+
+// does nothing unless "api:grpc" is set (read at startup), see pkg/grpcapi
+func RunGrpcApiServer() {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	if !settings.ApiGrpc {
+		return
+	}
+	token, err := ensureApiToken()
+	if err != nil {
+		log.Printf("[api] not starting the grpc api: %v\n", err)
+		return
+	}
+	listenAddr := settings.ApiGrpcListenAddr
+	if listenAddr == "" {
+		listenAddr = DefaultGrpcListenAddr
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		log.Printf("[api] error listening on %s: %v\n", listenAddr, err)
+		return
+	}
+	server, err := grpcapi.MakeServer(token)
+	if err != nil {
+		listener.Close()
+		log.Printf("[api] not starting the grpc api: %v\n", err)
+		return
+	}
+	if !registerServer("grpc", func(ctx context.Context) error { return grpcapi.Shutdown(ctx, server) }) {
+		listener.Close()
+		return
+	}
+	log.Printf("[api] running grpc api on %s\n", listener.Addr())
+	err = server.Serve(listener)
+	if err != nil {
+		log.Printf("[api] error running grpc api: %v\n", err)
+	}
+}
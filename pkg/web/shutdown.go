@@ -0,0 +1,62 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+)
+
+// the servers register how to shut them down when they start.  ShutdownServers stops them from accepting new
+// connections and waits for the requests in flight.  websocket (and json-rpc socket) connections are not
+// closed, the rpcs on them are drained by wshutil.DrainRpcs.
+
+type shutdownFnType = func(ctx context.Context) error
+
+var serversLock = &sync.Mutex{}
+var serverShutdownFns = make(map[string]shutdownFnType)
+var serversShutDown = &atomic.Bool{}
+
+// returns false if the servers are already shut down (the caller should not serve)
+func registerServer(name string, shutdownFn shutdownFnType) bool {
+	serversLock.Lock()
+	defer serversLock.Unlock()
+	if serversShutDown.Load() {
+		return false
+	}
+	serverShutdownFns[name] = shutdownFn
+	return true
+}
+
+func IsShuttingDown() bool {
+	return serversShutDown.Load()
+}
+
+// shuts down the registered servers in parallel, returns once they are done or ctx is done
+func ShutdownServers(ctx context.Context) {
+	serversLock.Lock()
+	serversShutDown.Store(true)
+	shutdownFns := serverShutdownFns
+	serverShutdownFns = make(map[string]shutdownFnType)
+	serversLock.Unlock()
+	var wg sync.WaitGroup
+	for name, shutdownFn := range shutdownFns {
+		wg.Add(1)
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("ShutdownServers", recover())
+			}()
+			defer wg.Done()
+			err := shutdownFn(ctx)
+			if err != nil {
+				log.Printf("error shutting down the %s server: %v\n", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
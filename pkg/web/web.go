@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -404,6 +405,12 @@ func WebFnWrap(opts WebFnOpts, fn WebFnType) WebFnType {
 			w.Header().Set(CacheControlHeaderKey, CacheControlHeaderNoCache)
 		}
 		w.Header().Set("Access-Control-Expose-Headers", "X-ZoneFileInfo")
+		if isRemoteSessionRequest(r) {
+			// already authorized by the remote-access server (which checks the files the session can use, see
+			// makeRemoteRouter)
+			fn(w, r)
+			return
+		}
 		err := authkey.ValidateIncomingRequest(r)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
@@ -439,7 +446,7 @@ func MakeUnixListener() (net.Listener, error) {
 const docsitePrefix = "/docsite/"
 const schemaPrefix = "/schema/"
 
-// blocking
+// blocking (until the server is shut down)
 func RunWebServer(listener net.Listener) {
 	gr := mux.NewRouter()
 	gr.HandleFunc("/wave/stream-local-file", WebFnWrap(WebFnOpts{AllowCaching: true}, handleStreamLocalFile))
@@ -460,8 +467,11 @@ func RunWebServer(listener net.Listener) {
 		MaxHeaderBytes: HttpMaxHeaderBytes,
 		Handler:        handler,
 	}
+	if !registerServer("web", server.Shutdown) {
+		return
+	}
 	err := server.Serve(listener)
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Printf("ERROR: %v\n", err)
 	}
 }
@@ -4,11 +4,14 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"regexp"
 	"sync"
 	"time"
 
@@ -16,11 +19,18 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/wavetermdev/waveterm/pkg/authkey"
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
 	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/fesession"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remoteaccess"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
 	"github.com/wavetermdev/waveterm/pkg/web/webcmd"
+	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"golang.org/x/time/rate"
 )
 
 const wsReadWaitTimeout = 15 * time.Second
@@ -30,9 +40,21 @@ const wsInitialPingTime = 1 * time.Second
 
 const DefaultCommandTimeout = 2 * time.Second
 
+// each connection can send wsMessageRate messages per second (with bursts of up to wsMessageBurst), messages over
+// the rate or over wsMaxMessageSize are dropped and answered with an error (an rpc error response for rpc
+// requests) instead of being processed, so a misbehaving client cannot flood the rpc router and the block
+// controllers.  pings are not limited.  messages over wsHardReadLimit close the connection.
+const wsMessageRate = 200
+const wsMessageBurst = 400
+const wsMaxMessageSize = 64 * 1024
+const wsHardReadLimit = 1024 * 1024
+const wsThrottleLogInterval = 10 * time.Second
+
 var GlobalLock = &sync.Mutex{}
 var RouteToConnMap = map[string]string{} // routeid => connid
 
+var feClientIdRe = regexp.MustCompile(`^[a-zA-Z0-9-]{1,64}$`)
+
 func RunWebSocketServer(listener net.Listener) {
 	gr := mux.NewRouter()
 	gr.HandleFunc("/ws", HandleWs)
@@ -43,9 +65,12 @@ func RunWebSocketServer(listener net.Listener) {
 		Handler:        gr,
 	}
 	server.SetKeepAlivesEnabled(false)
+	if !registerServer("websocket", server.Shutdown) {
+		return
+	}
 	log.Printf("[websocket] running websocket server on %s\n", listener.Addr())
 	err := server.Serve(listener)
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Printf("[websocket] error trying to run websocket server: %v\n", err)
 	}
 }
@@ -78,7 +103,7 @@ func getStringFromMap(jmsg map[string]any, key string) string {
 	return ""
 }
 
-func processWSCommand(jmsg map[string]any, outputCh chan any, rpcInputCh chan []byte) {
+func processWSCommand(jmsg map[string]any, outputCh chan any, rpcInputCh chan []byte, routeId string) {
 	var rtnErr error
 	defer func() {
 		panicErr := panichandler.PanicHandler("processWSCommand", recover())
@@ -96,14 +121,21 @@ func processWSCommand(jmsg map[string]any, outputCh chan any, rpcInputCh chan []
 		rtnErr = fmt.Errorf("cannot parse wscommand: %v", err)
 		return
 	}
+	scope := fesession.GetRouteSession(routeId) // nil for the electron frontends (not restricted)
 	switch cmd := wsCommand.(type) {
 	case *webcmd.SetBlockTermSizeWSCommand:
-		data := wshrpc.CommandBlockInputData{
+		if scope != nil {
+			rtnErr = checkSessionBlockInput(scope, cmd.BlockId, "resizing a block")
+			if rtnErr != nil {
+				return
+			}
+		}
+		data := wshrpc.CommandControllerResizeData{
 			BlockId:  cmd.BlockId,
-			TermSize: &cmd.TermSize,
+			TermSize: cmd.TermSize,
 		}
 		rpcMsg := wshutil.RpcMessage{
-			Command: wshrpc.Command_ControllerInput,
+			Command: wshrpc.Command_ControllerResize,
 			Data:    data,
 		}
 		msgBytes, err := json.Marshal(rpcMsg)
@@ -115,6 +147,12 @@ func processWSCommand(jmsg map[string]any, outputCh chan any, rpcInputCh chan []
 		rpcInputCh <- msgBytes
 
 	case *webcmd.BlockInputWSCommand:
+		if scope != nil {
+			rtnErr = checkSessionBlockInput(scope, cmd.BlockId, "block input")
+			if rtnErr != nil {
+				return
+			}
+		}
 		data := wshrpc.CommandBlockInputData{
 			BlockId:     cmd.BlockId,
 			InputData64: cmd.InputData64,
@@ -136,6 +174,30 @@ func processWSCommand(jmsg map[string]any, outputCh chan any, rpcInputCh chan []
 		if rpcMsg == nil {
 			return
 		}
+		if rpcMsg.Source == wshutil.ElectronRoute && routeId != wshutil.ElectronRoute {
+			// only the electron connection can send as electron (electron-only rpcs check the source)
+			rtnErr = fmt.Errorf("invalid rpc source %q", rpcMsg.Source)
+			return
+		}
+		if scope != nil && rpcMsg.Command != "" {
+			err = checkSessionRpc(scope, routeId, rpcMsg)
+			if err != nil {
+				if rpcMsg.ReqId == "" {
+					rtnErr = err
+					return
+				}
+				// fails the request right away instead of letting it time out
+				outputCh <- map[string]any{
+					"eventtype": eventbus.WSEvent_Rpc,
+					"data":      wshutil.RpcMessage{ResId: rpcMsg.ReqId, Error: err.Error()},
+				}
+				return
+			}
+			if rpcMsg.Command == wshrpc.Command_EventReplay {
+				replaySessionEvents(scope, routeId, rpcMsg, outputCh)
+				return
+			}
+		}
 		msgBytes, err := json.Marshal(rpcMsg)
 		if err != nil {
 			// this really should never fail since we just unmarshalled this value
@@ -145,25 +207,174 @@ func processWSCommand(jmsg map[string]any, outputCh chan any, rpcInputCh chan []
 	}
 }
 
-func processMessage(jmsg map[string]any, outputCh chan any, rpcInputCh chan []byte) {
+func checkSessionBlockInput(scope *fesession.Session, blockId string, opName string) error {
+	err := scope.CheckPerm(fesession.Perm_Input, opName)
+	if err != nil {
+		return err
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancelFn()
+	return remoteaccess.CheckBlockAccess(ctx, scope, blockId)
+}
+
+func checkSessionRpc(scope *fesession.Session, routeId string, rpcMsg *wshutil.RpcMessage) error {
+	err := scope.CheckRpc(rpcMsg.Command)
+	if err != nil {
+		return err
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancelFn()
+	return remoteaccess.CheckRpcAccess(ctx, scope, routeId, rpcMsg)
+}
+
+// the tab of a session's connection must stay in an exposed workspace (and in the session's window), it is
+// checked again before each message and when "remote:workspaces" changes
+func checkSessionTab(scope *fesession.Session, tabId string) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancelFn()
+	return remoteaccess.CheckTabAccess(ctx, scope, tabId)
+}
+
+// called from the publishing goroutine, must not block.  closing the connection ends its read loop.
+func handleSessionSettingsChange(event eventbus.Event, conn *websocket.Conn, routeId string, checkFn func() error) {
+	changeEvent, ok := event.(eventbus.SettingsChangeEvent)
+	if !ok || !utilfn.ContainsStr(changeEvent.Change.Keys, wconfig.ConfigKey_RemoteWorkspaces) {
+		return
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("handleSessionSettingsChange", recover())
+		}()
+		err := checkFn()
+		if err != nil {
+			log.Printf("[websocket] closing %s: %v\n", routeId, err)
+			conn.Close()
+		}
+	}()
+}
+
+// a session's subscriptions to all scopes get every event of their type, so the events sent to a session are
+// filtered by their scopes (see remoteaccess.CanSeeEvent)
+func sessionCanSeeMessage(scope *fesession.Session, msgBytes []byte) bool {
+	var msg struct {
+		Command string `json:"command"`
+		Data    struct {
+			Scopes []string `json:"scopes"`
+		} `json:"data"`
+	}
+	err := json.Unmarshal(msgBytes, &msg)
+	if err != nil || msg.Command != wshrpc.Command_EventRecv {
+		return true
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancelFn()
+	return remoteaccess.CanSeeEvent(ctx, scope, msg.Data.Scopes)
+}
+
+// the replayed events are returned in the response (they are not sent as events), so a session's replay is
+// answered here, with the events it can see
+func replaySessionEvents(scope *fesession.Session, routeId string, rpcMsg *wshutil.RpcMessage, outputCh chan any) {
+	rtnMsg := wshutil.RpcMessage{ResId: rpcMsg.ReqId}
+	var data wshrpc.CommandEventReplayData
+	err := utilfn.DoMapStructure(&data, rpcMsg.Data)
+	if err != nil {
+		rtnMsg.Error = fmt.Sprintf("invalid eventreplay data: %v", err)
+	} else {
+		source := rpcMsg.Source
+		if source == "" {
+			source = routeId
+		}
+		events, lastSeq, complete := wps.Broker.SubscribeAndReplay(source, data.Subscriptions, data.SinceSeq)
+		ctx, cancelFn := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+		defer cancelFn()
+		rtnData := wshrpc.EventReplayRtnData{LastSeq: lastSeq, Complete: complete}
+		for _, event := range events {
+			if remoteaccess.CanSeeEvent(ctx, scope, event.Scopes) {
+				rtnData.Events = append(rtnData.Events, event)
+			}
+		}
+		rtnMsg.Data = rtnData
+	}
+	if rpcMsg.ReqId == "" {
+		return
+	}
+	outputCh <- map[string]any{
+		"eventtype": eventbus.WSEvent_Rpc,
+		"data":      rtnMsg,
+	}
+}
+
+func processMessage(jmsg map[string]any, outputCh chan any, rpcInputCh chan []byte, routeId string) {
 	wsCommand := getStringFromMap(jmsg, "wscommand")
 	if wsCommand == "" {
 		return
 	}
-	processWSCommand(jmsg, outputCh, rpcInputCh)
+	processWSCommand(jmsg, outputCh, rpcInputCh, routeId)
+}
+
+type wsRejectInfo struct {
+	WSCommand string `json:"wscommand"`
+	Message   *struct {
+		ReqId string `json:"reqid"`
+	} `json:"message"`
+}
+
+// tells the client the message was not processed.  never blocks (the response is dropped if the output is full).
+func rejectMessage(message []byte, outputCh chan any, errMsg string) {
+	var info wsRejectInfo
+	json.Unmarshal(message, &info) // ignore error, the message may be truncated
+	var rtn any
+	if info.WSCommand == "rpc" && info.Message != nil && info.Message.ReqId != "" {
+		// fails the request right away instead of letting it time out
+		rtn = map[string]any{
+			"eventtype": eventbus.WSEvent_Rpc,
+			"data":      wshutil.RpcMessage{ResId: info.Message.ReqId, Error: errMsg},
+		}
+	} else {
+		rtn = map[string]any{"type": "error", "error": errMsg}
+	}
+	select {
+	case outputCh <- rtn:
+	default:
+	}
+}
+
+type wsThrottleLogger struct {
+	routeId  string
+	lastLog  time.Time
+	rejected int
+}
+
+func (tl *wsThrottleLogger) logReject(reason string) {
+	tl.rejected++
+	if time.Since(tl.lastLog) < wsThrottleLogInterval {
+		return
+	}
+	log.Printf("[websocket] throttling %s: %s (%d messages rejected)\n", tl.routeId, reason, tl.rejected)
+	tl.lastLog = time.Now()
+	tl.rejected = 0
 }
 
-func ReadLoop(conn *websocket.Conn, outputCh chan any, closeCh chan any, rpcInputCh chan []byte, routeId string) {
+// checkFn (if set) is called before each message is processed, the connection is closed if it returns an error
+func ReadLoop(conn *websocket.Conn, outputCh chan any, closeCh chan any, rpcInputCh chan []byte, routeId string, checkFn func() error) {
 	readWait := wsReadWaitTimeout
-	conn.SetReadLimit(64 * 1024)
+	conn.SetReadLimit(wsHardReadLimit)
 	conn.SetReadDeadline(time.Now().Add(readWait))
 	defer close(closeCh)
+	limiter := rate.NewLimiter(wsMessageRate, wsMessageBurst)
+	throttleLogger := &wsThrottleLogger{routeId: routeId}
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
 			log.Printf("[websocket] ReadPump error (%s): %v\n", routeId, err)
 			break
 		}
+		if len(message) > wsMaxMessageSize {
+			conn.SetReadDeadline(time.Now().Add(readWait))
+			throttleLogger.logReject(fmt.Sprintf("message too large (%d bytes)", len(message)))
+			rejectMessage(message, outputCh, fmt.Sprintf("message too large (%d bytes, max %d)", len(message), wsMaxMessageSize))
+			continue
+		}
 		jmsg := map[string]any{}
 		err = json.Unmarshal(message, &jmsg)
 		if err != nil {
@@ -182,7 +393,20 @@ func ReadLoop(conn *websocket.Conn, outputCh chan any, closeCh chan any, rpcInpu
 			outputCh <- pongMessage
 			continue
 		}
-		go processMessage(jmsg, outputCh, rpcInputCh)
+		if !limiter.Allow() {
+			throttleLogger.logReject("rate limit exceeded")
+			rejectMessage(message, outputCh, "rate limit exceeded, try again later")
+			continue
+		}
+		if checkFn != nil {
+			err = checkFn()
+			if err != nil {
+				log.Printf("[websocket] closing %s: %v\n", routeId, err)
+				rejectMessage(message, outputCh, err.Error())
+				break
+			}
+		}
+		go processMessage(jmsg, outputCh, rpcInputCh, routeId)
 	}
 }
 
@@ -241,7 +465,7 @@ func WriteLoop(conn *websocket.Conn, outputCh chan any, closeCh chan any, routeI
 	}
 }
 
-func registerConn(wsConnId string, routeId string, wproxy *wshutil.WshRpcProxy) {
+func registerConn(wsConnId string, routeId string, wproxy *wshutil.WshRpcProxy, scope *fesession.Session) {
 	GlobalLock.Lock()
 	defer GlobalLock.Unlock()
 	curConnId := RouteToConnMap[routeId]
@@ -250,6 +474,11 @@ func registerConn(wsConnId string, routeId string, wproxy *wshutil.WshRpcProxy)
 		wshutil.DefaultRouter.UnregisterRoute(routeId)
 	}
 	RouteToConnMap[routeId] = wsConnId
+	if scope != nil {
+		fesession.BindRoute(routeId, scope)
+	} else {
+		fesession.UnbindRoute(routeId)
+	}
 	wshutil.DefaultRouter.RegisterRoute(routeId, wproxy, true)
 }
 
@@ -263,7 +492,9 @@ func unregisterConn(wsConnId string, routeId string) {
 		return
 	}
 	delete(RouteToConnMap, routeId)
+	fesession.UnbindRoute(routeId)
 	wshutil.DefaultRouter.UnregisterRoute(routeId)
+	blockcontroller.ReleaseClientInput(routeId)
 }
 
 func HandleWsInternal(w http.ResponseWriter, r *http.Request) error {
@@ -271,13 +502,24 @@ func HandleWsInternal(w http.ResponseWriter, r *http.Request) error {
 	if tabId == "" {
 		return fmt.Errorf("tabid is required")
 	}
-	err := authkey.ValidateIncomingRequest(r)
+	feClientId := r.URL.Query().Get("feclientid")
+	if feClientId != "" && !feClientIdRe.MatchString(feClientId) {
+		return fmt.Errorf("invalid feclientid")
+	}
+	err := authkey.ValidateTabRequest(r, tabId)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		w.Write([]byte(fmt.Sprintf("error validating authkey: %v", err)))
 		log.Printf("[websocket] error validating authkey: %v\n", err)
 		return err
 	}
+	return serveWsConn(w, r, tabId, feClientId, nil)
+}
+
+// serves the websocket connection of a frontend, the request has already been authorized.  scope is the
+// session of a remote frontend (nil for the electron frontends), it is bound to the frontend's route so the
+// messages it sends are checked against its permissions.
+func serveWsConn(w http.ResponseWriter, r *http.Request, tabId string, feClientId string, scope *fesession.Session) error {
 	conn, err := WebSocketUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return fmt.Errorf("WebSocket Upgrade Failed: %v", err)
@@ -286,19 +528,33 @@ func HandleWsInternal(w http.ResponseWriter, r *http.Request) error {
 	wsConnId := uuid.New().String()
 	outputCh := make(chan any, 100)
 	closeCh := make(chan any)
+	// every frontend (window or browser tab) has its own route, several can show the same tab.  the frontend
+	// announces the tab's route (and its block routes) over it.  a frontend keeps its feclientid when it
+	// reconnects, so the new connection replaces the old one.
 	var routeId string
 	if tabId == wshutil.ElectronRoute {
 		routeId = wshutil.ElectronRoute
 	} else {
-		routeId = wshutil.MakeTabRouteId(tabId)
+		if feClientId == "" {
+			feClientId = wsConnId
+		}
+		routeId = wshutil.MakeFeClientRouteId(feClientId)
 	}
 	log.Printf("[websocket] new connection: tabid:%s connid:%s routeid:%s\n", tabId, wsConnId, routeId)
 	eventbus.RegisterWSChannel(wsConnId, tabId, outputCh)
 	defer eventbus.UnregisterWSChannel(wsConnId)
 	wproxy := wshutil.MakeRpcProxy() // we create a wshproxy to handle rpc messages to/from the window
 	defer close(wproxy.ToRemoteCh)
-	registerConn(wsConnId, routeId, wproxy)
+	registerConn(wsConnId, routeId, wproxy, scope)
 	defer unregisterConn(wsConnId, routeId)
+	var checkFn func() error
+	if scope != nil {
+		checkFn = func() error { return checkSessionTab(scope, tabId) }
+		subId := eventbus.Subscribe(eventbus.Topic_SettingsChange, eventbus.Scope{}, func(event eventbus.Event) {
+			handleSessionSettingsChange(event, conn, routeId, checkFn)
+		})
+		defer eventbus.Unsubscribe(subId)
+	}
 	wg := &sync.WaitGroup{}
 	wg.Add(2)
 	go func() {
@@ -308,6 +564,9 @@ func HandleWsInternal(w http.ResponseWriter, r *http.Request) error {
 		// no waitgroup add here
 		// move values from rpcOutputCh to outputCh
 		for msgBytes := range wproxy.ToRemoteCh {
+			if scope != nil && !sessionCanSeeMessage(scope, msgBytes) {
+				continue
+			}
 			rpcWSMsg := map[string]any{
 				"eventtype": "rpc", // TODO don't hard code this (but def is in eventbus)
 				"data":      json.RawMessage(msgBytes),
@@ -321,7 +580,7 @@ func HandleWsInternal(w http.ResponseWriter, r *http.Request) error {
 		}()
 		// read loop
 		defer wg.Done()
-		ReadLoop(conn, outputCh, closeCh, wproxy.FromRemoteCh, routeId)
+		ReadLoop(conn, outputCh, closeCh, wproxy.FromRemoteCh, routeId, checkFn)
 	}()
 	go func() {
 		defer func() {
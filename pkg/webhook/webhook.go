@@ -0,0 +1,452 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webhook sends events (a block's shell exiting, workspaces being created or deleted) to the urls the
+// user registers.  webhooks and the log of their deliveries are stored as wave objects.  each delivery is a json
+// POST signed with the webhook's secret (an hmac-sha256 of the body in the X-Wave-Signature header), failed
+// deliveries are retried with a backoff.  the secrets are in their own table, not in the webhook objects (which are
+// sent to every subscriber of object updates), and are only returned when the webhook is created.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	Event_All  = "*"
+	Event_Test = "webhook:test" // only sent by SendTestEvent
+)
+
+// the events a webhook can subscribe to
+var Events = []string{eventbus.Topic_BlockExit, eventbus.Topic_WorkspaceCreate, eventbus.Topic_WorkspaceDelete, eventbus.Topic_AgentForward, eventbus.Topic_ConnState, eventbus.Topic_ConnAuthExpiry}
+
+const (
+	DeliveryStatus_Pending   = "pending"
+	DeliveryStatus_Delivered = "delivered"
+	DeliveryStatus_Failed    = "failed"
+)
+
+const (
+	SignatureHeader = "X-Wave-Signature"
+	EventHeader     = "X-Wave-Event"
+	DeliveryHeader  = "X-Wave-Delivery"
+)
+
+const DeliveryTimeout = 10 * time.Second
+const MaxDeliveriesPerWebhook = 100
+const MaxSecretLen = 200
+const eventQueueSize = 256
+const dbTimeout = 5 * time.Second
+
+// the delay before each retry, a delivery is given up after len(RetryDelays)+1 attempts
+var RetryDelays = []time.Duration{5 * time.Second, 30 * time.Second, 2 * time.Minute, 10 * time.Minute}
+
+var httpClient = &http.Client{Timeout: DeliveryTimeout}
+
+type Payload struct {
+	Id        string `json:"id"` // the delivery id
+	Event     string `json:"event"`
+	Ts        int64  `json:"ts"`
+	WebhookId string `json:"webhookid"`
+	Data      any    `json:"data,omitempty"`
+}
+
+type queuedEvent struct {
+	event string
+	data  any
+}
+
+var eventQueue = make(chan queuedEvent, eventQueueSize)
+var startOnce = &sync.Once{}
+
+// subscribes to the events and resumes the deliveries that were pending when wave exited
+func Start() {
+	startOnce.Do(func() {
+		for _, topic := range Events {
+			eventbus.Subscribe(topic, eventbus.Scope{}, handleEvent)
+		}
+		go runEventLoop()
+		go resumePendingDeliveries()
+	})
+}
+
+// called from the publishing goroutine, must not block
+func handleEvent(event eventbus.Event) {
+	select {
+	case eventQueue <- queuedEvent{event: event.Topic(), data: event.Data()}:
+	default:
+		log.Printf("webhook: event queue is full, dropping %s event\n", event.Topic())
+	}
+}
+
+func runEventLoop() {
+	defer func() {
+		panichandler.PanicHandler("webhook:runEventLoop", recover())
+	}()
+	for qe := range eventQueue {
+		dispatchEvent(qe.event, qe.data, "")
+	}
+}
+
+func withDBCtx[T any](fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	rtn, err := fn(ctx)
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return rtn, err
+}
+
+// sends the event to the matching webhooks (or only to onlyWebhookId if it is set)
+func dispatchEvent(event string, data any, onlyWebhookId string) {
+	hooks, err := withDBCtx(func(ctx context.Context) ([]*waveobj.Webhook, error) {
+		return wstore.DBGetAllObjsByType[*waveobj.Webhook](ctx, waveobj.OType_Webhook)
+	})
+	if err != nil {
+		log.Printf("webhook: error getting webhooks: %v\n", err)
+		return
+	}
+	for _, hook := range hooks {
+		if onlyWebhookId != "" {
+			if hook.OID != onlyWebhookId {
+				continue
+			}
+		} else if !Matches(hook, event, data) {
+			continue
+		}
+		delivery, err := createDelivery(hook, event, data)
+		if err != nil {
+			log.Printf("webhook: error creating delivery for %s: %v\n", hook.OID, err)
+			continue
+		}
+		go deliver(delivery)
+	}
+}
+
+func Matches(hook *waveobj.Webhook, event string, data any) bool {
+	if hook.Disabled {
+		return false
+	}
+	for _, filter := range hook.Filters {
+		if filter.Event != event && filter.Event != Event_All {
+			continue
+		}
+		if filter.NonZeroExit {
+			exitData, ok := data.(*wps.BlockExitEventData)
+			if !ok || exitData.ExitCode == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// returns the value of the signature header for the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func createDelivery(hook *waveobj.Webhook, event string, data any) (*waveobj.WebhookDelivery, error) {
+	delivery := &waveobj.WebhookDelivery{
+		OID:       uuid.NewString(),
+		WebhookId: hook.OID,
+		Event:     event,
+		Ts:        time.Now().UnixMilli(),
+		Status:    DeliveryStatus_Pending,
+	}
+	payload, err := json.Marshal(Payload{Id: delivery.OID, Event: event, Ts: delivery.Ts, WebhookId: hook.OID, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(payload)
+	_, err = withDBCtx(func(ctx context.Context) (any, error) {
+		err := wstore.DBInsert(ctx, delivery)
+		if err != nil {
+			return nil, err
+		}
+		return nil, pruneDeliveries(ctx, hook.OID)
+	})
+	return delivery, err
+}
+
+// keeps the last MaxDeliveriesPerWebhook deliveries of the webhook (and the pending ones)
+func pruneDeliveries(ctx context.Context, webhookId string) error {
+	oids, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]string, error) {
+		query := `
+			SELECT oid FROM (
+				SELECT oid, json_extract(data, '$.status') AS status
+				FROM db_webhookdelivery
+				WHERE json_extract(data, '$.webhookid') = ?
+				ORDER BY json_extract(data, '$.ts') DESC
+				LIMIT -1 OFFSET ?
+			) WHERE status <> ?`
+		return tx.SelectStrings(query, webhookId, MaxDeliveriesPerWebhook, DeliveryStatus_Pending), nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, oid := range oids {
+		err = wstore.DBDelete(ctx, waveobj.OType_WebhookDelivery, oid)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resumePendingDeliveries() {
+	defer func() {
+		panichandler.PanicHandler("webhook:resumePendingDeliveries", recover())
+	}()
+	deliveries, err := withDBCtx(func(ctx context.Context) ([]*waveobj.WebhookDelivery, error) {
+		return ListDeliveries(ctx, "")
+	})
+	if err != nil {
+		log.Printf("webhook: error getting deliveries: %v\n", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		if delivery.Status == DeliveryStatus_Pending {
+			go deliver(delivery)
+		}
+	}
+}
+
+// sends the delivery, retrying until it succeeds, fails permanently, or runs out of attempts
+func deliver(delivery *waveobj.WebhookDelivery) {
+	defer func() {
+		panichandler.PanicHandler("webhook:deliver", recover())
+	}()
+	for {
+		hook, err := withDBCtx(func(ctx context.Context) (*waveobj.Webhook, error) {
+			return wstore.DBGet[*waveobj.Webhook](ctx, delivery.WebhookId)
+		})
+		if err != nil || hook == nil {
+			// the webhook was deleted (along with its deliveries)
+			return
+		}
+		secret, err := withDBCtx(func(ctx context.Context) (string, error) {
+			return getSecret(ctx, hook.OID)
+		})
+		if err != nil {
+			log.Printf("webhook: error getting the secret of %s: %v\n", hook.OID, err)
+			return
+		}
+		statusCode, retry, err := sendDelivery(hook, secret, delivery)
+		delivery.Attempts++
+		delivery.StatusCode = statusCode
+		delivery.Error = ""
+		if err == nil {
+			delivery.Status = DeliveryStatus_Delivered
+		} else {
+			delivery.Error = err.Error()
+			if !retry || delivery.Attempts > len(RetryDelays) {
+				delivery.Status = DeliveryStatus_Failed
+			}
+		}
+		_, dbErr := withDBCtx(func(ctx context.Context) (any, error) {
+			return nil, wstore.DBUpdate(ctx, delivery)
+		})
+		if dbErr != nil {
+			log.Printf("webhook: error updating delivery %s: %v\n", delivery.OID, dbErr)
+			return
+		}
+		if delivery.Status != DeliveryStatus_Pending {
+			return
+		}
+		time.Sleep(RetryDelays[delivery.Attempts-1])
+	}
+}
+
+// returns the http status, and if the error is worth retrying
+func sendDelivery(hook *waveobj.Webhook, secret string, delivery *waveobj.WebhookDelivery) (int, bool, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "waveterm-webhook")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.OID)
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return resp.StatusCode, retry, fmt.Errorf("%s", resp.Status)
+}
+
+func validateWebhook(hook *waveobj.Webhook) error {
+	parsedUrl, err := url.Parse(hook.Url)
+	if err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+		return fmt.Errorf("invalid url %q, must be an http or https url", hook.Url)
+	}
+	if len(hook.Filters) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, filter := range hook.Filters {
+		if filter.Event == Event_All {
+			continue
+		}
+		found := false
+		for _, event := range Events {
+			if filter.Event == event {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown event %q (events are %v, or %q for all)", filter.Event, Events, Event_All)
+		}
+		if filter.NonZeroExit && filter.Event != eventbus.Topic_BlockExit {
+			return fmt.Errorf("nonzeroexit is only valid for %s", eventbus.Topic_BlockExit)
+		}
+	}
+	return nil
+}
+
+// returns the webhook and its secret (generated if secret is empty), this is the only time the secret is returned
+func CreateWebhook(ctx context.Context, hook *waveobj.Webhook, secret string) (*waveobj.Webhook, string, error) {
+	err := validateWebhook(hook)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(secret) > MaxSecretLen {
+		return nil, "", fmt.Errorf("secret is too long (max %d characters)", MaxSecretLen)
+	}
+	hook.OID = uuid.NewString()
+	if secret == "" {
+		secretBytes := make([]byte, 32)
+		_, err = rand.Read(secretBytes)
+		if err != nil {
+			return nil, "", err
+		}
+		secret = hex.EncodeToString(secretBytes)
+	}
+	err = wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		err := wstore.DBInsert(tx.Context(), hook)
+		if err != nil {
+			return err
+		}
+		tx.Exec(`INSERT INTO db_webhooksecret (webhookid, secret) VALUES (?, ?)`, hook.OID, secret)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return hook, secret, nil
+}
+
+func getSecret(ctx context.Context, webhookId string) (string, error) {
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (string, error) {
+		return tx.GetString(`SELECT secret FROM db_webhooksecret WHERE webhookid = ?`, webhookId), nil
+	})
+}
+
+func ListWebhooks(ctx context.Context) ([]*waveobj.Webhook, error) {
+	return wstore.DBGetAllObjsByType[*waveobj.Webhook](ctx, waveobj.OType_Webhook)
+}
+
+// deletes the webhook and its deliveries
+func DeleteWebhook(ctx context.Context, webhookId string) error {
+	_, err := wstore.DBMustGet[*waveobj.Webhook](ctx, webhookId)
+	if err != nil {
+		return err
+	}
+	deliveries, err := ListDeliveries(ctx, webhookId)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		err = wstore.DBDelete(ctx, waveobj.OType_WebhookDelivery, delivery.OID)
+		if err != nil {
+			return err
+		}
+	}
+	err = wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		tx.Exec(`DELETE FROM db_webhooksecret WHERE webhookid = ?`, webhookId)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return wstore.DBDelete(ctx, waveobj.OType_Webhook, webhookId)
+}
+
+// enables or disables the webhook
+func SetWebhookDisabled(ctx context.Context, webhookId string, disabled bool) (*waveobj.Webhook, error) {
+	hook, err := wstore.DBMustGet[*waveobj.Webhook](ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	hook.Disabled = disabled
+	err = wstore.DBUpdate(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// returns the deliveries of the webhook (of all webhooks if webhookId is empty), most recent first
+func ListDeliveries(ctx context.Context, webhookId string) ([]*waveobj.WebhookDelivery, error) {
+	deliveries, err := wstore.DBGetAllObjsByType[*waveobj.WebhookDelivery](ctx, waveobj.OType_WebhookDelivery)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []*waveobj.WebhookDelivery
+	for _, delivery := range deliveries {
+		if webhookId == "" || delivery.WebhookId == webhookId {
+			rtn = append(rtn, delivery)
+		}
+	}
+	sort.SliceStable(rtn, func(i, j int) bool {
+		return rtn[i].Ts > rtn[j].Ts
+	})
+	return rtn, nil
+}
+
+// sends a webhook:test event to the webhook (even if it does not subscribe to it, or is disabled)
+func SendTestEvent(ctx context.Context, webhookId string) error {
+	_, err := wstore.DBMustGet[*waveobj.Webhook](ctx, webhookId)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("webhook:SendTestEvent", recover())
+		}()
+		dispatchEvent(Event_Test, map[string]any{"message": "test event from wave"}, webhookId)
+	}()
+	return nil
+}
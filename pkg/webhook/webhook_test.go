@@ -0,0 +1,98 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+)
+
+func TestMatches(t *testing.T) {
+	hook := &waveobj.Webhook{Filters: []waveobj.WebhookFilter{
+		{Event: "block:exit", NonZeroExit: true},
+		{Event: "workspace:create"},
+	}}
+	if Matches(hook, "block:exit", &wps.BlockExitEventData{ExitCode: 0}) {
+		t.Errorf("exit code 0 should not match a nonzeroexit filter")
+	}
+	if !Matches(hook, "block:exit", &wps.BlockExitEventData{ExitCode: 2}) {
+		t.Errorf("exit code 2 should match")
+	}
+	if !Matches(hook, "workspace:create", &wps.WorkspaceEventData{WorkspaceId: "ws"}) {
+		t.Errorf("workspace:create should match")
+	}
+	if Matches(hook, "workspace:delete", &wps.WorkspaceEventData{WorkspaceId: "ws"}) {
+		t.Errorf("workspace:delete should not match")
+	}
+	hook.Disabled = true
+	if Matches(hook, "workspace:create", &wps.WorkspaceEventData{WorkspaceId: "ws"}) {
+		t.Errorf("a disabled webhook should not match")
+	}
+	allHook := &waveobj.Webhook{Filters: []waveobj.WebhookFilter{{Event: Event_All}}}
+	if !Matches(allHook, "workspace:delete", nil) {
+		t.Errorf("* should match every event")
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	tests := []struct {
+		hook  waveobj.Webhook
+		valid bool
+	}{
+		{waveobj.Webhook{Url: "https://example.com/hook", Filters: []waveobj.WebhookFilter{{Event: "block:exit"}}}, true},
+		{waveobj.Webhook{Url: "http://localhost:8080", Filters: []waveobj.WebhookFilter{{Event: Event_All}}}, true},
+		{waveobj.Webhook{Url: "ftp://example.com", Filters: []waveobj.WebhookFilter{{Event: "block:exit"}}}, false},
+		{waveobj.Webhook{Url: "example.com/hook", Filters: []waveobj.WebhookFilter{{Event: "block:exit"}}}, false},
+		{waveobj.Webhook{Url: "https://example.com/hook"}, false},
+		{waveobj.Webhook{Url: "https://example.com/hook", Filters: []waveobj.WebhookFilter{{Event: "block:close"}}}, false},
+		{waveobj.Webhook{Url: "https://example.com/hook", Filters: []waveobj.WebhookFilter{{Event: "workspace:create", NonZeroExit: true}}}, false},
+	}
+	for _, test := range tests {
+		err := validateWebhook(&test.hook)
+		if (err == nil) != test.valid {
+			t.Errorf("validateWebhook(%s %v): got err %v, expected valid=%v", test.hook.Url, test.hook.Filters, err, test.valid)
+		}
+	}
+}
+
+func TestSendDelivery(t *testing.T) {
+	hook := &waveobj.Webhook{OID: "hook1"}
+	delivery := &waveobj.WebhookDelivery{OID: "delivery1", Event: "block:exit", Payload: `{"event":"block:exit"}`}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig := r.Header.Get(SignatureHeader)
+		if !hmac.Equal([]byte(sig), []byte(Sign("s3cret", []byte(delivery.Payload)))) {
+			t.Errorf("bad signature %q", sig)
+		}
+		if r.Header.Get(EventHeader) != "block:exit" || r.Header.Get(DeliveryHeader) != "delivery1" {
+			t.Errorf("bad headers %v", r.Header)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	hook.Url = server.URL
+	tests := []struct {
+		status int
+		ok     bool
+		retry  bool
+	}{
+		{http.StatusOK, true, false},
+		{http.StatusNoContent, true, false},
+		{http.StatusNotFound, false, false},
+		{http.StatusTooManyRequests, false, true},
+		{http.StatusBadGateway, false, true},
+	}
+	for _, test := range tests {
+		status = test.status
+		statusCode, retry, err := sendDelivery(hook, "s3cret", delivery)
+		if statusCode != test.status || (err == nil) != test.ok || retry != test.retry {
+			t.Errorf("status %d: got %d, retry=%v, err=%v", test.status, statusCode, retry, err)
+		}
+	}
+}
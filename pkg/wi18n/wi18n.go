@@ -0,0 +1,434 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wi18n translates the text wavesrv generates (errors, notifications, action titles) into the locale of
+// "app:locale" (the locale of the os when it is not set).  a string is looked up by key in the catalogs of the
+// locale, then of its parent locales ("pt-BR", then "pt"), then of "en".  for each locale the catalog in
+// <configdir>/i18n/<locale>.json is used first, then the one built into wave, then the catalogs of the plugins
+// (from <plugindir>/i18n/<locale>.json, or sent when the plugin registers), so a plugin can add a language.
+// the strings are fmt formats, a translation with other verbs than the english string is skipped.
+package wi18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const DefaultLocale = "en"
+const CatalogsDirName = "i18n"
+
+// the catalogs in the config dir are read again (when they changed) if they were read longer ago than this
+const ReloadInterval = 5 * time.Second
+
+const (
+	Source_Builtin = "builtin"
+	Source_Config  = "config"
+	Source_Plugin  = "plugin"
+)
+
+//go:embed catalogs/*.json
+var builtinFS embed.FS
+
+// key => text (a fmt format)
+type Catalog map[string]string
+
+var localeRe = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+var catalogLock = &sync.Mutex{}
+var builtinCatalogs map[string]Catalog
+var configCatalogs map[string]Catalog
+var configModTimes map[string]time.Time // file name => mod time
+var lastConfigLoad time.Time
+var pluginCatalogs = map[string]map[string]Catalog{} // pluginid => locale => catalog
+
+// "pt_BR.UTF-8" => "pt-BR" (the posix form of the os), "" if it is not a locale
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if idx := strings.IndexAny(locale, ".@"); idx >= 0 {
+		locale = locale[:idx]
+	}
+	parts := strings.Split(strings.ReplaceAll(locale, "_", "-"), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for idx := 1; idx < len(parts); idx++ {
+		if len(parts[idx]) == 2 {
+			parts[idx] = strings.ToUpper(parts[idx])
+		}
+	}
+	locale = strings.Join(parts, "-")
+	if !localeRe.MatchString(locale) {
+		return ""
+	}
+	return locale
+}
+
+// the locale, its parent locales, and the default locale
+func FallbackChain(locale string) []string {
+	var rtn []string
+	locale = NormalizeLocale(locale)
+	for locale != "" {
+		rtn = append(rtn, locale)
+		idx := strings.LastIndex(locale, "-")
+		if idx < 0 {
+			break
+		}
+		locale = locale[:idx]
+	}
+	if len(rtn) == 0 || rtn[len(rtn)-1] != DefaultLocale {
+		rtn = append(rtn, DefaultLocale)
+	}
+	return rtn
+}
+
+// the locale of the os (from LC_ALL, LC_MESSAGES, or LANG)
+func SystemLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := NormalizeLocale(os.Getenv(name)); locale != "" {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// the locale of "app:locale", or the locale of the os
+func CurrentLocale() string {
+	if watcher := wconfig.GetWatcher(); watcher != nil {
+		if locale := NormalizeLocale(watcher.GetFullConfig().Settings.AppLocale); locale != "" {
+			return locale
+		}
+	}
+	return SystemLocale()
+}
+
+// the verbs of a fmt format, in order ("%[2]d" is "[2]d", "%%" is not a verb)
+func formatVerbs(format string) []string {
+	var rtn []string
+	for idx := 0; idx < len(format); idx++ {
+		if format[idx] != '%' {
+			continue
+		}
+		end := idx + 1
+		for end < len(format) && strings.IndexByte("+-# 0123456789.[]*", format[end]) >= 0 {
+			end++
+		}
+		if end >= len(format) {
+			rtn = append(rtn, format[idx+1:])
+			break
+		}
+		if format[end] != '%' {
+			rtn = append(rtn, format[idx+1:end+1])
+		}
+		idx = end
+	}
+	return rtn
+}
+
+func sameVerbs(a string, b string) bool {
+	verbsA, verbsB := formatVerbs(a), formatVerbs(b)
+	if len(verbsA) != len(verbsB) {
+		return false
+	}
+	for idx := range verbsA {
+		if verbsA[idx] != verbsB[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// the english string of a key (must hold catalogLock)
+func defaultText(key string) (string, bool) {
+	loadBuiltinCatalogs()
+	text, ok := builtinCatalogs[DefaultLocale][key]
+	return text, ok
+}
+
+// drops the translations of built-in keys whose verbs are not the ones of the english string (must hold catalogLock)
+func checkCatalog(source string, locale string, catalog Catalog) Catalog {
+	rtn := make(Catalog, len(catalog))
+	for key, text := range catalog {
+		if english, ok := defaultText(key); ok && !sameVerbs(english, text) {
+			log.Printf("wi18n: skipping %q in the %s catalog for %q, its verbs are not the ones of %q\n", key, source, locale, english)
+			continue
+		}
+		rtn[key] = text
+	}
+	return rtn
+}
+
+// parses a catalog file, the locale is the name of the file ("pt-BR.json")
+func ParseCatalog(fileName string, data []byte) (string, Catalog, error) {
+	locale := NormalizeLocale(strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)))
+	if locale == "" {
+		return "", nil, fmt.Errorf("%s: the name of a catalog must be a locale (e.g. pt-BR.json)", fileName)
+	}
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return locale, catalog, nil
+}
+
+// reads the catalogs (<locale>.json) in dir, a catalog with an error is skipped (and logged)
+func ReadCatalogsDir(dir string) (map[string]Catalog, map[string]time.Time) {
+	catalogs := make(map[string]Catalog)
+	modTimes := make(map[string]time.Time)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("wi18n: error reading %s: %v\n", dir, err)
+		}
+		return catalogs, modTimes
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			modTimes[entry.Name()] = info.ModTime()
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			log.Printf("wi18n: error reading catalog: %v\n", err)
+			continue
+		}
+		locale, catalog, err := ParseCatalog(entry.Name(), data)
+		if err != nil {
+			log.Printf("wi18n: invalid catalog: %v\n", err)
+			continue
+		}
+		if catalogs[locale] == nil {
+			catalogs[locale] = make(Catalog)
+		}
+		for key, text := range catalog {
+			catalogs[locale][key] = text
+		}
+	}
+	return catalogs, modTimes
+}
+
+func loadBuiltinCatalogs() {
+	if builtinCatalogs != nil {
+		return
+	}
+	builtinCatalogs = make(map[string]Catalog)
+	entries, _ := builtinFS.ReadDir("catalogs")
+	for _, entry := range entries {
+		data, err := builtinFS.ReadFile("catalogs/" + entry.Name())
+		if err != nil {
+			log.Printf("wi18n: error reading built-in catalog: %v\n", err)
+			continue
+		}
+		locale, catalog, err := ParseCatalog(entry.Name(), data)
+		if err != nil {
+			log.Printf("wi18n: invalid built-in catalog: %v\n", err)
+			continue
+		}
+		builtinCatalogs[locale] = catalog
+	}
+}
+
+func getConfigCatalogsDir() string {
+	return filepath.Join(wavebase.GetWaveConfigDir(), CatalogsDirName)
+}
+
+// reads the catalogs of the config dir again if they changed (must hold catalogLock)
+func loadConfigCatalogs() {
+	if configCatalogs != nil && time.Since(lastConfigLoad) < ReloadInterval {
+		return
+	}
+	lastConfigLoad = time.Now()
+	dir := getConfigCatalogsDir()
+	if configCatalogs != nil {
+		entries, _ := os.ReadDir(dir)
+		changed := len(entries) != len(configModTimes)
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !info.ModTime().Equal(configModTimes[entry.Name()]) {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+	catalogs, modTimes := ReadCatalogsDir(dir)
+	for locale, catalog := range catalogs {
+		catalogs[locale] = checkCatalog(Source_Config, locale, catalog)
+	}
+	configCatalogs = catalogs
+	configModTimes = modTimes
+}
+
+// sets the catalogs of a plugin (adding to the ones it has)
+func AddPluginCatalogs(pluginId string, catalogs map[string]map[string]string) error {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	for locale := range catalogs {
+		if NormalizeLocale(locale) == "" {
+			return fmt.Errorf("invalid locale %q", locale)
+		}
+	}
+	if pluginCatalogs[pluginId] == nil {
+		pluginCatalogs[pluginId] = make(map[string]Catalog)
+	}
+	for locale, catalog := range catalogs {
+		locale = NormalizeLocale(locale)
+		checked := checkCatalog(Source_Plugin+":"+pluginId, locale, catalog)
+		if pluginCatalogs[pluginId][locale] == nil {
+			pluginCatalogs[pluginId][locale] = make(Catalog)
+		}
+		for key, text := range checked {
+			pluginCatalogs[pluginId][locale][key] = text
+		}
+	}
+	return nil
+}
+
+// reads the catalogs in the i18n dir of a plugin (if it has one)
+func LoadPluginCatalogs(pluginId string, pluginDir string) error {
+	catalogs, _ := ReadCatalogsDir(filepath.Join(pluginDir, CatalogsDirName))
+	if len(catalogs) == 0 {
+		return nil
+	}
+	rtn := make(map[string]map[string]string, len(catalogs))
+	for locale, catalog := range catalogs {
+		rtn[locale] = catalog
+	}
+	return AddPluginCatalogs(pluginId, rtn)
+}
+
+func UnregisterPluginCatalogs(pluginId string) {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	delete(pluginCatalogs, pluginId)
+}
+
+type catalogSource struct {
+	source   string
+	pluginId string
+	catalogs map[string]Catalog
+}
+
+// the sources of the catalogs, first the one used first (must hold catalogLock)
+func getSources() []catalogSource {
+	loadBuiltinCatalogs()
+	loadConfigCatalogs()
+	rtn := []catalogSource{{source: Source_Config, catalogs: configCatalogs}, {source: Source_Builtin, catalogs: builtinCatalogs}}
+	pluginIds := make([]string, 0, len(pluginCatalogs))
+	for pluginId := range pluginCatalogs {
+		pluginIds = append(pluginIds, pluginId)
+	}
+	sort.Strings(pluginIds)
+	for _, pluginId := range pluginIds {
+		rtn = append(rtn, catalogSource{source: Source_Plugin, pluginId: pluginId, catalogs: pluginCatalogs[pluginId]})
+	}
+	return rtn
+}
+
+// the text of key in the fallback chain of locale
+func Lookup(locale string, key string) (string, bool) {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	sources := getSources()
+	for _, chainLocale := range FallbackChain(locale) {
+		for _, src := range sources {
+			if text, ok := src.catalogs[chainLocale][key]; ok {
+				return text, true
+			}
+		}
+	}
+	return "", false
+}
+
+func format(text string, args []any) string {
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// the text of key in locale, formatted with args (the key itself if no catalog has it)
+func TL(locale string, key string, args ...any) string {
+	text, ok := Lookup(locale, key)
+	if !ok {
+		log.Printf("wi18n: no catalog has %q\n", key)
+		text = key
+	}
+	return format(text, args)
+}
+
+// the text of key in the current locale, formatted with args
+func T(key string, args ...any) string {
+	return TL(CurrentLocale(), key, args...)
+}
+
+// the text of key in the current locale, or text (formatted with args) if no catalog has it
+func TOr(key string, text string, args ...any) string {
+	if translated, ok := Lookup(CurrentLocale(), key); ok {
+		text = translated
+	}
+	return format(text, args)
+}
+
+// an error with the text of key in the current locale (%w wraps an error like in fmt.Errorf)
+func Errorf(key string, args ...any) error {
+	text, ok := Lookup(CurrentLocale(), key)
+	if !ok {
+		text = key
+	}
+	if len(args) == 0 {
+		return errors.New(text)
+	}
+	return fmt.Errorf(text, args...)
+}
+
+// the catalogs by locale and source, sorted by locale
+func ListCatalogs() []wshrpc.I18nCatalogInfo {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	var rtn []wshrpc.I18nCatalogInfo
+	for _, src := range getSources() {
+		for locale, catalog := range src.catalogs {
+			rtn = append(rtn, wshrpc.I18nCatalogInfo{Locale: locale, Source: src.source, PluginId: src.pluginId, NumStrings: len(catalog)})
+		}
+	}
+	sort.SliceStable(rtn, func(i, j int) bool {
+		return rtn[i].Locale < rtn[j].Locale
+	})
+	return rtn
+}
+
+// all the strings of a locale (the current one if it is empty), with the fallback chain applied
+func GetCatalog(locale string) *wshrpc.I18nCatalogData {
+	if locale == "" {
+		locale = CurrentLocale()
+	}
+	chain := FallbackChain(locale)
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	sources := getSources()
+	strs := make(map[string]string)
+	for idx := len(chain) - 1; idx >= 0; idx-- {
+		for srcIdx := len(sources) - 1; srcIdx >= 0; srcIdx-- {
+			for key, text := range sources[srcIdx].catalogs[chain[idx]] {
+				strs[key] = text
+			}
+		}
+	}
+	return &wshrpc.I18nCatalogData{Locale: chain[0], Chain: chain, Strings: strs}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wi18n
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFallbackChain(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected []string
+	}{
+		{"pt_BR.UTF-8", []string{"pt-BR", "pt", "en"}},
+		{"zh-Hant-tw", []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}},
+		{"DE", []string{"de", "en"}},
+		{"en_US", []string{"en-US", "en"}},
+		{"C", []string{"en"}},
+		{"", []string{"en"}},
+	}
+	for _, test := range tests {
+		if chain := FallbackChain(test.locale); !reflect.DeepEqual(chain, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.locale, test.expected, chain)
+		}
+	}
+}
+
+func TestFormatVerbs(t *testing.T) {
+	if verbs := formatVerbs("%s is 100%% at %[2]d (%-5.2f)%"); !reflect.DeepEqual(verbs, []string{"s", "[2]d", "-5.2f", ""}) {
+		t.Errorf("unexpected verbs %q", verbs)
+	}
+	if !sameVerbs("object not found: %s", "Objekt %s nicht gefunden") || sameVerbs("%s at %s", "%s um %d") {
+		t.Errorf("unexpected verb comparison")
+	}
+}
+
+// the built-in translations must be of english strings (action titles are registered in english), with their verbs
+func TestBuiltinCatalogs(t *testing.T) {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	loadBuiltinCatalogs()
+	english := builtinCatalogs[DefaultLocale]
+	if len(english) == 0 {
+		t.Fatalf("no english catalog")
+	}
+	for locale, catalog := range builtinCatalogs {
+		for key, text := range catalog {
+			if strings.HasPrefix(key, "action.") {
+				continue
+			}
+			if _, ok := english[key]; !ok {
+				t.Errorf("%s: %q is not in the english catalog", locale, key)
+			} else if !sameVerbs(english[key], text) {
+				t.Errorf("%s: %q does not have the verbs of %q", locale, text, english[key])
+			}
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	catalogLock.Lock()
+	configCatalogs = map[string]Catalog{"de": {"error.notify.notitle": "Kein Titel"}}
+	lastConfigLoad = time.Now().Add(time.Hour)
+	catalogLock.Unlock()
+	defer func() {
+		catalogLock.Lock()
+		configCatalogs = nil
+		catalogLock.Unlock()
+	}()
+	err := AddPluginCatalogs("test", map[string]map[string]string{
+		"pt":    {"plugin.hello": "Olá %s", "error.action.unknown": "ação desconhecida"},
+		"de-AT": {"error.notify.notitle": "Servus"},
+	})
+	if err != nil {
+		t.Fatalf("adding catalogs: %v", err)
+	}
+	defer UnregisterPluginCatalogs("test")
+	if err := AddPluginCatalogs("test", map[string]map[string]string{"not a locale": {}}); err == nil {
+		t.Errorf("expected an error for an invalid locale")
+	}
+	tests := []struct {
+		locale   string
+		key      string
+		expected string
+	}{
+		{"de", "error.notify.notitle", "Kein Titel"}, // the config catalog is used first
+		{"de-AT", "error.notify.notitle", "Servus"},  // then the more specific locale of a plugin
+		{"de", "notify.recording.stopped.title", "Aufzeichnung beendet"},
+		{"pt-BR", "plugin.hello", "Olá %s"},                     // a parent locale
+		{"pt-BR", "error.action.unknown", "unknown action %q"},  // skipped, not the verbs of the english string
+		{"fr", "error.object.notfound", "object not found: %s"}, // the default locale
+	}
+	for _, test := range tests {
+		if text, _ := Lookup(test.locale, test.key); text != test.expected {
+			t.Errorf("%s %q: expected %q, got %q", test.locale, test.key, test.expected, text)
+		}
+	}
+	if text := TL("pt", "plugin.hello", "mundo"); text != "Olá mundo" {
+		t.Errorf("unexpected text %q", text)
+	}
+	if text := TL("de", "no.such.key"); text != "no.such.key" {
+		t.Errorf("expected the key, got %q", text)
+	}
+	UnregisterPluginCatalogs("test")
+	if _, ok := Lookup("pt", "plugin.hello"); ok {
+		t.Errorf("expected the catalogs of the plugin to be removed")
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wnotify sends the notifications from the controllers, wsh, and the automation api to every window
+// (and optionally as a desktop notification).  notifications are stored as wave objects until they are
+// dismissed, so they survive a restart.  a notification that is sent again before it is dismissed (the same
+// dedup key) is counted instead of being shown twice.  while do-not-disturb is on ("notify:dnd", optionally
+// between "notify:dndstart" and "notify:dndend") notifications are stored but held, they are shown once it ends.
+package wnotify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wi18n"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	Type_Error   = "error"
+	Type_Warning = "warning"
+	Type_Info    = "info"
+)
+
+const (
+	Source_Wsh        = "wsh"
+	Source_Webhook    = "webhook"
+	Source_Controller = "controller"
+)
+
+const MaxNotifications = 200
+const DndCheckInterval = time.Minute
+const dbTimeout = 5 * time.Second
+
+var sendLock = &sync.Mutex{}
+var startOnce = &sync.Once{}
+
+// starts showing the held notifications when do-not-disturb ends
+func Start() {
+	startOnce.Do(func() {
+		go runDndLoop()
+	})
+}
+
+func runDndLoop() {
+	defer func() {
+		panichandler.PanicHandler("wnotify:runDndLoop", recover())
+	}()
+	ticker := time.NewTicker(DndCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if dndIsOn(time.Now()) {
+			continue
+		}
+		_, err := withDBCtx(func(ctx context.Context) (any, error) {
+			return nil, deliverHeld(ctx)
+		})
+		if err != nil {
+			log.Printf("wnotify: error delivering held notifications: %v\n", err)
+		}
+	}
+}
+
+func withDBCtx[T any](fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	rtn, err := fn(ctx)
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return rtn, err
+}
+
+// parses "HH:MM" into minutes after midnight
+func parseClockTime(val string) (int, bool) {
+	hourStr, minStr, ok := strings.Cut(strings.TrimSpace(val), ":")
+	if !ok {
+		return 0, false
+	}
+	hour, err := strconv.Atoi(hourStr)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, false
+	}
+	minute, err := strconv.Atoi(minStr)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
+
+// do-not-disturb is on all the time unless both ends of the window are set (the window can cross midnight)
+func InDoNotDisturb(settings *wconfig.SettingsType, now time.Time) bool {
+	if !settings.NotifyDnd {
+		return false
+	}
+	start, startOk := parseClockTime(settings.NotifyDndStart)
+	end, endOk := parseClockTime(settings.NotifyDndEnd)
+	if !startOk || !endOk || start == end {
+		return true
+	}
+	cur := now.Hour()*60 + now.Minute()
+	if start < end {
+		return cur >= start && cur < end
+	}
+	return cur >= start || cur < end
+}
+
+func dndIsOn(now time.Time) bool {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	return InDoNotDisturb(&settings, now)
+}
+
+// the explicit key, or a hash of the source, block, and text
+func DedupKey(data wshrpc.CommandNotificationSendData) string {
+	if data.DedupKey != "" {
+		return data.DedupKey
+	}
+	hash := sha256.Sum256([]byte(strings.Join([]string{data.Source, data.BlockId, data.Type, data.Title, data.Message}, "\x00")))
+	return hex.EncodeToString(hash[:16])
+}
+
+// stores the notification and sends it to the windows (unless it is a duplicate or do-not-disturb is on)
+func Send(ctx context.Context, data wshrpc.CommandNotificationSendData) (*waveobj.Notification, error) {
+	if data.Title == "" && data.Message == "" {
+		return nil, wi18n.Errorf("error.notify.notitle")
+	}
+	if data.Type == "" {
+		data.Type = Type_Info
+	}
+	if data.Type != Type_Error && data.Type != Type_Warning && data.Type != Type_Info {
+		return nil, wi18n.Errorf("error.notify.invalidtype", data.Type, Type_Error, Type_Warning, Type_Info)
+	}
+	sendLock.Lock()
+	defer sendLock.Unlock()
+	now := time.Now()
+	dedupKey := DedupKey(data)
+	notifs, err := List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, notif := range notifs {
+		if notif.DedupKey != dedupKey {
+			continue
+		}
+		notif.Count++
+		notif.UpdatedTs = now.UnixMilli()
+		err = wstore.DBUpdate(ctx, notif)
+		if err != nil {
+			return nil, err
+		}
+		return notif, nil
+	}
+	notif := &waveobj.Notification{
+		OID:       uuid.NewString(),
+		Title:     data.Title,
+		Message:   data.Message,
+		Type:      data.Type,
+		Source:    data.Source,
+		BlockId:   data.BlockId,
+		DedupKey:  dedupKey,
+		Count:     1,
+		CreatedTs: now.UnixMilli(),
+		UpdatedTs: now.UnixMilli(),
+		Delivered: !dndIsOn(now),
+	}
+	err = wstore.DBInsert(ctx, notif)
+	if err != nil {
+		return nil, err
+	}
+	err = prune(ctx, append([]*waveobj.Notification{notif}, notifs...))
+	if err != nil {
+		return nil, err
+	}
+	if notif.Delivered {
+		err = deliver(ctx, notif)
+		if err != nil {
+			return nil, err
+		}
+		if data.Desktop {
+			sendDesktopNotification(notif, data.Silent)
+		}
+	}
+	return notif, nil
+}
+
+// sends the notification in the background, for the controllers (errors are logged)
+func Notify(data wshrpc.CommandNotificationSendData) {
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("wnotify:Notify", recover())
+		}()
+		_, err := withDBCtx(func(ctx context.Context) (*waveobj.Notification, error) {
+			return Send(ctx, data)
+		})
+		if err != nil {
+			log.Printf("wnotify: error sending notification %q: %v\n", data.Title, err)
+		}
+	}()
+}
+
+// the notifications that have not been dismissed, most recent first
+func List(ctx context.Context) ([]*waveobj.Notification, error) {
+	notifs, err := wstore.DBGetAllObjsByType[*waveobj.Notification](ctx, waveobj.OType_Notification)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(notifs, func(i, j int) bool {
+		return notifs[i].UpdatedTs > notifs[j].UpdatedTs
+	})
+	return notifs, nil
+}
+
+// deletes the notification (it is removed from every window)
+func Dismiss(ctx context.Context, id string) error {
+	_, err := wstore.DBMustGet[*waveobj.Notification](ctx, id)
+	if err != nil {
+		return err
+	}
+	return wstore.DBDelete(ctx, waveobj.OType_Notification, id)
+}
+
+// returns the number of notifications dismissed
+func DismissAll(ctx context.Context) (int, error) {
+	sendLock.Lock()
+	defer sendLock.Unlock()
+	notifs, err := List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, notif := range notifs {
+		err = wstore.DBDelete(ctx, waveobj.OType_Notification, notif.OID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(notifs), nil
+}
+
+// keeps the most recent MaxNotifications (notifs must be sorted)
+func prune(ctx context.Context, notifs []*waveobj.Notification) error {
+	for idx := MaxNotifications; idx < len(notifs); idx++ {
+		err := wstore.DBDelete(ctx, waveobj.OType_Notification, notifs[idx].OID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deliverHeld(ctx context.Context) error {
+	sendLock.Lock()
+	defer sendLock.Unlock()
+	notifs, err := List(ctx)
+	if err != nil {
+		return err
+	}
+	for idx := len(notifs) - 1; idx >= 0; idx-- {
+		notif := notifs[idx]
+		if notif.Delivered {
+			continue
+		}
+		notif.Delivered = true
+		err = wstore.DBUpdate(ctx, notif)
+		if err != nil {
+			return err
+		}
+		err = deliver(ctx, notif)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// a notification for a block is shown in the block's window, the others (or if the block is gone) in every window
+func deliver(ctx context.Context, notif *waveobj.Notification) error {
+	event := eventbus.NotificationEvent{
+		Notification: wps.NotificationEventData{
+			Id:      notif.OID,
+			Title:   notif.Title,
+			Message: notif.Message,
+			Type:    notif.Type,
+		},
+	}
+	if notif.BlockId != "" {
+		block, err := wstore.DBGet[*waveobj.Block](ctx, notif.BlockId)
+		if err != nil {
+			return err
+		}
+		if block != nil {
+			event.BlockId = block.OID
+		}
+	}
+	if event.BlockId == "" {
+		windows, err := wstore.DBGetAllObjsByType[*waveobj.Window](ctx, waveobj.OType_Window)
+		if err != nil {
+			return err
+		}
+		for _, window := range windows {
+			event.WindowIds = append(event.WindowIds, window.OID)
+		}
+	}
+	eventbus.Publish(event)
+	return nil
+}
+
+// does nothing if the electron app is not connected (e.g. wavesrv is only serving browsers)
+func sendDesktopNotification(notif *waveobj.Notification, silent bool) {
+	if wshutil.DefaultRouter.GetRpc(wshutil.ElectronRoute) == nil {
+		return
+	}
+	opts := wshrpc.WaveNotificationOptions{Title: notif.Title, Body: notif.Message, Silent: silent}
+	err := wshclient.NotifyCommand(wshclient.GetBareRpcClient(), opts, &wshrpc.RpcOpts{Route: wshutil.ElectronRoute, NoResponse: true})
+	if err != nil {
+		log.Printf("wnotify: error sending desktop notification: %v\n", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wnotify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestInDoNotDisturb(t *testing.T) {
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2025, 3, 14, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		settings wconfig.SettingsType
+		now      time.Time
+		dnd      bool
+	}{
+		{wconfig.SettingsType{}, at(12, 0), false},
+		{wconfig.SettingsType{NotifyDndStart: "00:00", NotifyDndEnd: "23:59"}, at(12, 0), false},
+		{wconfig.SettingsType{NotifyDnd: true}, at(12, 0), true},
+		{wconfig.SettingsType{NotifyDnd: true, NotifyDndStart: "09:00"}, at(3, 0), true},
+		{wconfig.SettingsType{NotifyDnd: true, NotifyDndStart: "09:00", NotifyDndEnd: "17:30"}, at(9, 0), true},
+		{wconfig.SettingsType{NotifyDnd: true, NotifyDndStart: "09:00", NotifyDndEnd: "17:30"}, at(17, 30), false},
+		{wconfig.SettingsType{NotifyDnd: true, NotifyDndStart: "09:00", NotifyDndEnd: "17:30"}, at(8, 59), false},
+		{wconfig.SettingsType{NotifyDnd: true, NotifyDndStart: "22:00", NotifyDndEnd: "07:00"}, at(23, 15), true},
+		{wconfig.SettingsType{NotifyDnd: true, NotifyDndStart: "22:00", NotifyDndEnd: "07:00"}, at(6, 59), true},
+		{wconfig.SettingsType{NotifyDnd: true, NotifyDndStart: "22:00", NotifyDndEnd: "07:00"}, at(12, 0), false},
+		{wconfig.SettingsType{NotifyDnd: true, NotifyDndStart: "25:00", NotifyDndEnd: "07:00"}, at(12, 0), true},
+	}
+	for _, test := range tests {
+		dnd := InDoNotDisturb(&test.settings, test.now)
+		if dnd != test.dnd {
+			t.Errorf("InDoNotDisturb(%v %q-%q, %s) = %v, expected %v", test.settings.NotifyDnd, test.settings.NotifyDndStart, test.settings.NotifyDndEnd, test.now.Format("15:04"), dnd, test.dnd)
+		}
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	data := wshrpc.CommandNotificationSendData{Title: "Build", Message: "done", Source: Source_Wsh, BlockId: "block1"}
+	if DedupKey(data) != DedupKey(data) {
+		t.Errorf("the same notification should have the same key")
+	}
+	other := data
+	other.Message = "failed"
+	if DedupKey(other) == DedupKey(data) {
+		t.Errorf("a different message should have a different key")
+	}
+	other = data
+	other.BlockId = "block2"
+	if DedupKey(other) == DedupKey(data) {
+		t.Errorf("a different block should have a different key")
+	}
+	other = data
+	other.DedupKey = "build"
+	if DedupKey(other) != "build" {
+		t.Errorf("an explicit key should be used as is, got %q", DedupKey(other))
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wplugin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// external plugins are processes wave does not launch (an ide extension, a ci job, a script).  "wsh plugin
+// token" issues a capability token for a plugin id: the token names the capabilities the plugin gets and its
+// scope (the blocks, tabs, and workspaces it can use).  the process connects to the domain socket with the
+// token (as WAVETERM_JWT) and is then a plugin like the launched ones: it can call the rpcs its capabilities
+// allow, register views, controllers, actions, and handlers, and answer the plugincall requests for its
+// handlers.  issuing a new token for a plugin id revokes the previous one.
+//
+// every command a plugin sends (launched or external) goes through checkCommand: plugins can only send to
+// wavesrv, can only send the rpcs in pluginRpcCaps, and the block, tab, or object an rpc names has to be one
+// the plugin can use.
+
+const DefaultTokenHours = 24
+const PluginHandlerTimeout = 10 * time.Second
+
+// the rpcs plugins can send and the capability each needs ("" for none).  the plugin* rpcs check the
+// capabilities (and blocks) themselves.
+var pluginRpcCaps = map[string]string{
+	wshrpc.Command_RouteAnnounce:    "",
+	wshrpc.Command_RouteUnannounce:  "",
+	wshrpc.Command_PluginRegister:   wshrpc.PluginCap_Register,
+	wshrpc.Command_PluginList:       "",
+	wshrpc.Command_PluginGetMeta:    "",
+	wshrpc.Command_PluginSetMeta:    "",
+	wshrpc.Command_PluginReadFile:   "",
+	wshrpc.Command_PluginWriteFile:  "",
+	wshrpc.Command_EventUnsub:       "",
+	wshrpc.Command_EventUnsubAll:    "",
+	wshrpc.Command_PluginCall:       wshrpc.PluginCap_PluginCall,
+	wshrpc.Command_GetMeta:          wshrpc.PluginCap_MetaRead,
+	wshrpc.Command_BlockInfo:        wshrpc.PluginCap_MetaRead,
+	wshrpc.Command_SetMeta:          wshrpc.PluginCap_MetaWrite,
+	wshrpc.Command_ControllerInput:  wshrpc.PluginCap_BlockInput,
+	wshrpc.Command_CreateBlock:      wshrpc.PluginCap_BlockCreate,
+	wshrpc.Command_DeleteBlock:      wshrpc.PluginCap_BlockCreate,
+	wshrpc.Command_EventSub:         wshrpc.PluginCap_Events,
+	wshrpc.Command_ControllerResync: wshrpc.PluginCap_BlockCreate,
+	wshrpc.Command_FileWatch:        wshrpc.PluginCap_FileRead,
+}
+
+func init() {
+	wshutil.PluginAuthCheck = checkAuth
+	wshutil.PluginCommandFilter = checkCommand
+}
+
+// a plugin can only connect with its newest token (and not after it expired)
+func checkAuth(rpcCtx *wshrpc.RpcContext) error {
+	plugin := GetPlugin(rpcCtx.PluginId)
+	if plugin == nil {
+		return fmt.Errorf("plugin %q is not registered (its token may have expired or been revoked)", rpcCtx.PluginId)
+	}
+	if plugin.TokenId != rpcCtx.TokenId {
+		return fmt.Errorf("this token for plugin %q was revoked", rpcCtx.PluginId)
+	}
+	return nil
+}
+
+func checkCommand(routeId string, msg *wshutil.RpcMessage) error {
+	plugin := GetPluginFromSource(routeId)
+	if plugin == nil {
+		return fmt.Errorf("plugin is not registered (its token may have expired or been revoked)")
+	}
+	if msg.Route != "" && msg.Route != wshutil.DefaultRoute {
+		return fmt.Errorf("plugins can only send rpcs to %s", wshutil.DefaultRoute)
+	}
+	capName, ok := pluginRpcCaps[msg.Command]
+	if !ok {
+		return fmt.Errorf("plugins cannot call %q", msg.Command)
+	}
+	if capName == "" {
+		return nil
+	}
+	if !plugin.HasCap(capName) {
+		return fmt.Errorf("plugin %q does not have capability %q (needed for %q)", plugin.PluginId, capName, msg.Command)
+	}
+	if msg.Command == wshrpc.Command_PluginCall || msg.Command == wshrpc.Command_PluginRegister {
+		// the handlers decide what they allow, and a plugin can only register names that are not built in (or
+		// registered by another plugin)
+		return nil
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	orefs, err := getCommandORefs(msg)
+	if err != nil {
+		return err
+	}
+	for _, oref := range orefs {
+		if !plugin.CanUseObject(ctx, oref) {
+			return fmt.Errorf("plugin %q does not have access to %s", plugin.PluginId, oref)
+		}
+	}
+	return nil
+}
+
+// the objects an rpc operates on (an rpc that needs a capability has to name at least one)
+func getCommandORefs(msg *wshutil.RpcMessage) ([]waveobj.ORef, error) {
+	var orefStrs []string
+	switch data := msg.Data.(type) {
+	case string:
+		if msg.Command == wshrpc.Command_BlockInfo {
+			orefStrs = append(orefStrs, waveobj.MakeORef(waveobj.OType_Block, data).String())
+		}
+	case map[string]any:
+		if msg.Command == wshrpc.Command_EventSub {
+			if allScopes, _ := data["allscopes"].(bool); allScopes {
+				return nil, fmt.Errorf("plugins cannot subscribe to all scopes")
+			}
+			scopes, _ := data["scopes"].([]any)
+			for _, scope := range scopes {
+				scopeStr, _ := scope.(string)
+				orefStrs = append(orefStrs, scopeStr)
+			}
+			break
+		}
+		if msg.Command == wshrpc.Command_FileWatch {
+			path, _ := data["path"].(string)
+			if zoneId := waveFileZoneId(path); zoneId != "" {
+				orefStrs = append(orefStrs, waveobj.MakeORef(waveobj.OType_Block, zoneId).String())
+			}
+			break
+		}
+		if orefStr, ok := data["oref"].(string); ok {
+			orefStrs = append(orefStrs, orefStr)
+		}
+		if blockId, ok := data["blockid"].(string); ok && blockId != "" {
+			orefStrs = append(orefStrs, waveobj.MakeORef(waveobj.OType_Block, blockId).String())
+		}
+		if tabId, ok := data["tabid"].(string); ok && tabId != "" {
+			orefStrs = append(orefStrs, waveobj.MakeORef(waveobj.OType_Tab, tabId).String())
+		}
+	}
+	if len(orefStrs) == 0 {
+		return nil, fmt.Errorf("%q needs a block, tab, or object (in the plugin's scope)", msg.Command)
+	}
+	var rtn []waveobj.ORef
+	for _, orefStr := range orefStrs {
+		oref, err := waveobj.ParseORef(orefStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object %q: %w", orefStr, err)
+		}
+		rtn = append(rtn, oref)
+	}
+	return rtn, nil
+}
+
+// the block of a wave file path (wavefile://<blockid>/<name>), "" for other paths
+func waveFileZoneId(path string) string {
+	rest, ok := strings.CutPrefix(path, "wavefile://")
+	if !ok {
+		return ""
+	}
+	zoneId, _, _ := strings.Cut(rest, "/")
+	return zoneId
+}
+
+func (p *PluginInstance) inScope(oref waveobj.ORef) bool {
+	return slices.Contains(p.Scope, oref.String())
+}
+
+// a block can be used if it belongs to one of the plugin's views/controllers or is in its scope (or in a tab or
+// workspace that is)
+func (p *PluginInstance) CanUseBlock(ctx context.Context, block *waveobj.Block) bool {
+	if block == nil {
+		return false
+	}
+	if p.OwnsBlock(block) || p.inScope(waveobj.MakeORef(waveobj.OType_Block, block.OID)) {
+		return true
+	}
+	if len(p.Scope) == 0 {
+		return false
+	}
+	tabId, err := wstore.DBFindTabForBlockId(ctx, block.OID)
+	if err != nil || tabId == "" {
+		return false
+	}
+	return p.CanUseObject(ctx, waveobj.MakeORef(waveobj.OType_Tab, tabId))
+}
+
+func (p *PluginInstance) CanUseObject(ctx context.Context, oref waveobj.ORef) bool {
+	switch oref.OType {
+	case waveobj.OType_Block:
+		block, err := wstore.DBGet[*waveobj.Block](ctx, oref.OID)
+		if err != nil {
+			return false
+		}
+		return p.CanUseBlock(ctx, block)
+	case waveobj.OType_Tab:
+		if p.inScope(oref) {
+			return true
+		}
+		workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, oref.OID)
+		if err != nil || workspaceId == "" {
+			return false
+		}
+		return p.inScope(waveobj.MakeORef(waveobj.OType_Workspace, workspaceId))
+	case waveobj.OType_Workspace:
+		return p.inScope(oref)
+	}
+	return false
+}
+
+// issues a token for an external plugin (replacing the plugin's previous token)
+func IssueToken(ctx context.Context, data wshrpc.CommandPluginTokenIssueData) (*wshrpc.PluginTokenRtnData, error) {
+	if !pluginIdRe.MatchString(data.PluginId) {
+		return nil, fmt.Errorf("invalid plugin id %q (lowercase letters, numbers, '-', and '_')", data.PluginId)
+	}
+	for _, capName := range data.Caps {
+		if !slices.Contains(wshrpc.AllPluginCaps, capName) {
+			return nil, fmt.Errorf("invalid capability %q", capName)
+		}
+	}
+	var scope []string
+	for _, orefStr := range data.Scope {
+		oref, err := waveobj.ParseORef(orefStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scope %q: %w", orefStr, err)
+		}
+		if oref.OType != waveobj.OType_Block && oref.OType != waveobj.OType_Tab && oref.OType != waveobj.OType_Workspace {
+			return nil, fmt.Errorf("invalid scope %q (must be a block, tab, or workspace)", orefStr)
+		}
+		exists, err := wstore.DBExistsORef(ctx, oref)
+		if err != nil || !exists {
+			return nil, fmt.Errorf("scope %q not found", orefStr)
+		}
+		scope = append(scope, oref.String())
+	}
+	validFor := time.Duration(data.Hours * float64(time.Hour))
+	if validFor <= 0 {
+		validFor = DefaultTokenHours * time.Hour
+	}
+	inst := &PluginInstance{
+		Lock:      &sync.Mutex{},
+		PluginId:  data.PluginId,
+		Manifest:  PluginManifest{Name: data.Name, Caps: data.Caps},
+		TokenId:   uuid.NewString(),
+		External:  true,
+		Scope:     scope,
+		ExpiresTs: time.Now().Add(validFor).UnixMilli(),
+	}
+	token, err := MakePluginToken(inst.PluginId, inst.TokenId)
+	if err != nil {
+		return nil, err
+	}
+	globalLock.Lock()
+	existing := pluginMap[inst.PluginId]
+	if existing != nil && !existing.External {
+		globalLock.Unlock()
+		return nil, fmt.Errorf("plugin %q is launched by wave (from %s)", inst.PluginId, existing.Dir)
+	}
+	pluginMap[inst.PluginId] = inst
+	globalLock.Unlock()
+	if existing != nil {
+		unregisterPluginDefs(inst.PluginId)
+		disconnect(inst.PluginId)
+	}
+	log.Printf("[plugin] issued token for external plugin %q caps:%v scope:%v\n", inst.PluginId, data.Caps, scope)
+	return &wshrpc.PluginTokenRtnData{
+		Token:     token,
+		SockName:  wavebase.GetDomainSocketName(),
+		ExpiresTs: inst.ExpiresTs,
+	}, nil
+}
+
+// revokes the token of an external plugin (and disconnects it)
+func RevokePlugin(pluginId string) error {
+	inst := GetPlugin(pluginId)
+	if inst == nil {
+		return fmt.Errorf("plugin %q not found", pluginId)
+	}
+	if !inst.External {
+		return fmt.Errorf("plugin %q is launched by wave (disable it in its %s)", pluginId, ManifestFileName)
+	}
+	removePlugin(pluginId, inst)
+	disconnect(pluginId)
+	log.Printf("[plugin] revoked token for external plugin %q\n", pluginId)
+	return nil
+}
+
+// the messages the plugin's connection sends after this are dropped (see WshRouter.RegisterRoute)
+func disconnect(pluginId string) {
+	routeId := wshutil.MakePluginRouteId(pluginId)
+	if wshutil.DefaultRouter.GetRpc(routeId) != nil {
+		wshutil.DefaultRouter.UnregisterRoute(routeId)
+	}
+}
+
+// forwards a plugincall request to the plugin that registered the handler
+func CallHandler(ctx context.Context, data wshrpc.CommandPluginCallData) (any, error) {
+	plugin := GetPlugin(data.PluginId)
+	if plugin == nil {
+		return nil, fmt.Errorf("plugin %q not found", data.PluginId)
+	}
+	if !plugin.HasHandler(data.Handler) {
+		return nil, fmt.Errorf("plugin %q does not have a handler %q", data.PluginId, data.Handler)
+	}
+	routeId := plugin.RouteId()
+	if wshutil.DefaultRouter.GetRpc(routeId) == nil {
+		return nil, fmt.Errorf("plugin %q is not connected", data.PluginId)
+	}
+	timeout := PluginHandlerTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	return wshclient.PluginHandlerCommand(wshclient.GetBareRpcClient(), data, &wshrpc.RpcOpts{Route: routeId, Timeout: timeout.Milliseconds()})
+}
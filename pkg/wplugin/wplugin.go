@@ -0,0 +1,515 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// out-of-process plugins.  plugins are launched from <configdir>/plugins/<pluginid>/plugin.json,
+// connect back to wavesrv over the domain socket (authenticating with the token in WAVETERM_JWT),
+// and can then register view types / controllers and operate on the blocks that use them.  external plugins
+// (processes wave does not launch) connect with a token from "wsh plugin token" instead (see external.go).
+package wplugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waction"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wi18n"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const PluginsDirName = "plugins"
+const ManifestFileName = "plugin.json"
+
+var pluginIdRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// the built-in views (the frontend's block registry) and controllers (see blockcontroller), plugins cannot
+// register them: a plugin can use all of the blocks of its views and controllers
+var reservedViews = map[string]bool{
+	"term":        true,
+	"preview":     true,
+	"web":         true,
+	"waveai":      true,
+	"cpuplot":     true,
+	"sysinfo":     true,
+	"vdom":        true,
+	"tips":        true,
+	"help":        true,
+	"launcher":    true,
+	"player":      true,
+	"connections": true,
+	"clusterexec": true,
+}
+
+var reservedControllers = map[string]bool{
+	"shell": true,
+	"cmd":   true,
+}
+
+type PluginManifest struct {
+	Name        string                 `json:"name,omitempty"`
+	Version     string                 `json:"version,omitempty"`
+	Cmd         []string               `json:"cmd"`
+	Caps        []string               `json:"caps,omitempty"`
+	Views       []wshrpc.PluginViewDef `json:"views,omitempty"`
+	Controllers []string               `json:"controllers,omitempty"`
+	Disabled    bool                   `json:"disabled,omitempty"`
+}
+
+type PluginInstance struct {
+	Lock        *sync.Mutex
+	PluginId    string
+	Dir         string
+	Manifest    PluginManifest
+	Views       []wshrpc.PluginViewDef
+	Controllers []string
+	Handlers    []string
+	Proc        *exec.Cmd
+	TokenId     string   // only the newest token of a plugin is valid
+	External    bool     // connected with a token from "wsh plugin token" (Proc is nil)
+	Scope       []string // orefs of the blocks, tabs, and workspaces an external plugin can use
+	ExpiresTs   int64    // external plugins
+}
+
+var globalLock = &sync.Mutex{}
+var pluginMap = map[string]*PluginInstance{} // pluginid => instance
+
+func GetPluginsDir() string {
+	return filepath.Join(wavebase.GetWaveConfigDir(), PluginsDirName)
+}
+
+func readManifest(pluginDir string) (*PluginManifest, error) {
+	barr, err := os.ReadFile(filepath.Join(pluginDir, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest PluginManifest
+	err = json.Unmarshal(barr, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", ManifestFileName, err)
+	}
+	if len(manifest.Cmd) == 0 {
+		return nil, fmt.Errorf("no cmd specified in %s", ManifestFileName)
+	}
+	err = checkNames(manifest.Views, manifest.Controllers)
+	if err != nil {
+		return nil, fmt.Errorf("error in %s: %w", ManifestFileName, err)
+	}
+	return &manifest, nil
+}
+
+// the views and controllers a plugin can register (not empty, and not built in)
+func checkNames(views []wshrpc.PluginViewDef, controllers []string) error {
+	for _, viewDef := range views {
+		if viewDef.View == "" {
+			return fmt.Errorf("view name cannot be empty")
+		}
+		if reservedViews[viewDef.View] {
+			return fmt.Errorf("view %q is built in, plugins cannot register it", viewDef.View)
+		}
+	}
+	for _, controller := range controllers {
+		if controller == "" {
+			return fmt.Errorf("controller name cannot be empty")
+		}
+		if reservedControllers[controller] {
+			return fmt.Errorf("controller %q is built in, plugins cannot register it", controller)
+		}
+	}
+	return nil
+}
+
+func MakePluginToken(pluginId string, tokenId string) (string, error) {
+	rpcCtx := wshrpc.RpcContext{ClientType: wshrpc.ClientType_Plugin, PluginId: pluginId, TokenId: tokenId}
+	return wshutil.MakeClientJWTToken(rpcCtx, wavebase.GetDomainSocketName())
+}
+
+// loads all of the plugin manifests and starts the enabled plugins
+func StartPlugins() {
+	pluginsDir := GetPluginsDir()
+	entries, err := os.ReadDir(pluginsDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[plugin] error reading plugins dir: %v\n", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		pluginId := entry.Name()
+		if !pluginIdRe.MatchString(pluginId) {
+			log.Printf("[plugin] skipping plugin dir %q, invalid plugin id\n", pluginId)
+			continue
+		}
+		pluginDir := filepath.Join(pluginsDir, pluginId)
+		manifest, err := readManifest(pluginDir)
+		if err != nil {
+			log.Printf("[plugin] error loading plugin %q: %v\n", pluginId, err)
+			continue
+		}
+		if manifest.Disabled {
+			continue
+		}
+		err = startPlugin(pluginId, pluginDir, manifest)
+		if err != nil {
+			log.Printf("[plugin] error starting plugin %q: %v\n", pluginId, err)
+		}
+	}
+}
+
+func startPlugin(pluginId string, pluginDir string, manifest *PluginManifest) error {
+	tokenId := uuid.NewString()
+	token, err := MakePluginToken(pluginId, tokenId)
+	if err != nil {
+		return err
+	}
+	cmdPath := manifest.Cmd[0]
+	if !filepath.IsAbs(cmdPath) && strings.Contains(cmdPath, string(filepath.Separator)) {
+		cmdPath = filepath.Join(pluginDir, cmdPath)
+	}
+	ecmd := exec.Command(cmdPath, manifest.Cmd[1:]...)
+	ecmd.Dir = pluginDir
+	ecmd.Env = append(os.Environ(), wshutil.WaveJwtTokenVarName+"="+token)
+	stdout, err := ecmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := ecmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	inst := &PluginInstance{
+		Lock:        &sync.Mutex{},
+		PluginId:    pluginId,
+		Dir:         pluginDir,
+		Manifest:    *manifest,
+		Views:       manifest.Views,
+		Controllers: manifest.Controllers,
+		Proc:        ecmd,
+		TokenId:     tokenId,
+	}
+	globalLock.Lock()
+	if pluginMap[pluginId] != nil {
+		globalLock.Unlock()
+		return fmt.Errorf("plugin already running")
+	}
+	pluginMap[pluginId] = inst
+	globalLock.Unlock()
+	err = wi18n.LoadPluginCatalogs(pluginId, pluginDir)
+	if err != nil {
+		log.Printf("[plugin] error loading the catalogs of plugin %q: %v\n", pluginId, err)
+	}
+	err = ecmd.Start()
+	if err != nil {
+		removePlugin(pluginId, inst)
+		return err
+	}
+	log.Printf("[plugin] started plugin %q pid:%d\n", pluginId, ecmd.Process.Pid)
+	go logOutput(pluginId, stdout)
+	go logOutput(pluginId, stderr)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("wplugin:wait", recover())
+		}()
+		waitErr := ecmd.Wait()
+		log.Printf("[plugin] plugin %q exited: %v\n", pluginId, waitErr)
+		removePlugin(pluginId, inst)
+	}()
+	return nil
+}
+
+func logOutput(pluginId string, r io.Reader) {
+	defer func() {
+		panichandler.PanicHandler("wplugin:logOutput", recover())
+	}()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		log.Printf("[plugin:%s] %s\n", pluginId, scanner.Text())
+	}
+}
+
+func removePlugin(pluginId string, inst *PluginInstance) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	if pluginMap[pluginId] == inst {
+		delete(pluginMap, pluginId)
+		unregisterPluginDefs(pluginId)
+	}
+}
+
+// removes the actions and the i18n catalogs of a plugin
+func unregisterPluginDefs(pluginId string) {
+	waction.UnregisterPluginActions(pluginId)
+	wi18n.UnregisterPluginCatalogs(pluginId)
+}
+
+func StopAllPlugins() {
+	globalLock.Lock()
+	insts := make([]*PluginInstance, 0, len(pluginMap))
+	for _, inst := range pluginMap {
+		insts = append(insts, inst)
+	}
+	globalLock.Unlock()
+	for _, inst := range insts {
+		if inst.Proc != nil && inst.Proc.Process != nil {
+			inst.Proc.Process.Kill()
+		}
+	}
+}
+
+// returns nil if there is no such plugin (or if its token expired)
+func GetPlugin(pluginId string) *PluginInstance {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	inst := pluginMap[pluginId]
+	if inst != nil && inst.isExpired() {
+		delete(pluginMap, pluginId)
+		unregisterPluginDefs(pluginId)
+		return nil
+	}
+	return inst
+}
+
+func (p *PluginInstance) isExpired() bool {
+	return p.External && time.Now().UnixMilli() > p.ExpiresTs
+}
+
+// returns the plugin for an rpc source route (nil if the source is not a plugin)
+func GetPluginFromSource(source string) *PluginInstance {
+	if !strings.HasPrefix(source, wshutil.RoutePrefix_Plugin) {
+		return nil
+	}
+	return GetPlugin(strings.TrimPrefix(source, wshutil.RoutePrefix_Plugin))
+}
+
+func (p *PluginInstance) RouteId() string {
+	return wshutil.MakePluginRouteId(p.PluginId)
+}
+
+func (p *PluginInstance) HasCap(capName string) bool {
+	return utilfn.ContainsStr(p.Manifest.Caps, capName)
+}
+
+func (p *PluginInstance) OwnsView(view string) bool {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	for _, viewDef := range p.Views {
+		if viewDef.View == view {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PluginInstance) OwnsController(controller string) bool {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return utilfn.ContainsStr(p.Controllers, controller)
+}
+
+func (p *PluginInstance) OwnsBlock(block *waveobj.Block) bool {
+	if block == nil {
+		return false
+	}
+	view := block.Meta.GetString(waveobj.MetaKey_View, "")
+	controller := block.Meta.GetString(waveobj.MetaKey_Controller, "")
+	return (view != "" && p.OwnsView(view)) || (controller != "" && p.OwnsController(controller))
+}
+
+func (p *PluginInstance) Register(data wshrpc.CommandPluginRegisterData) error {
+	err := checkNames(data.Views, data.Controllers)
+	if err != nil {
+		return err
+	}
+	for _, viewDef := range data.Views {
+		owner := findPlugin(func(other *PluginInstance) bool { return other != p && other.OwnsView(viewDef.View) })
+		if owner != nil {
+			return fmt.Errorf("view %q is already registered by plugin %q", viewDef.View, owner.PluginId)
+		}
+	}
+	for _, controller := range data.Controllers {
+		owner := findPlugin(func(other *PluginInstance) bool { return other != p && other.OwnsController(controller) })
+		if owner != nil {
+			return fmt.Errorf("controller %q is already registered by plugin %q", controller, owner.PluginId)
+		}
+	}
+	for locale := range data.Catalogs {
+		if wi18n.NormalizeLocale(locale) == "" {
+			return fmt.Errorf("invalid catalog locale %q", locale)
+		}
+	}
+	for _, actionDef := range data.Actions {
+		actionDef.PluginId = p.PluginId
+		err := waction.RegisterAction(actionDef, p.executeAction)
+		if err != nil {
+			return err
+		}
+	}
+	if len(data.Catalogs) > 0 {
+		err := wi18n.AddPluginCatalogs(p.PluginId, data.Catalogs)
+		if err != nil {
+			return err
+		}
+	}
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	for _, viewDef := range data.Views {
+		found := false
+		for idx, existing := range p.Views {
+			if existing.View == viewDef.View {
+				p.Views[idx] = viewDef
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.Views = append(p.Views, viewDef)
+		}
+	}
+	for _, controller := range data.Controllers {
+		if !utilfn.ContainsStr(p.Controllers, controller) {
+			p.Controllers = append(p.Controllers, controller)
+		}
+	}
+	for _, handler := range data.Handlers {
+		if handler != "" && !utilfn.ContainsStr(p.Handlers, handler) {
+			p.Handlers = append(p.Handlers, handler)
+		}
+	}
+	return nil
+}
+
+func (p *PluginInstance) HasHandler(handler string) bool {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return utilfn.ContainsStr(p.Handlers, handler)
+}
+
+// plugin actions are forwarded to the plugin's route
+func (p *PluginInstance) executeAction(ctx context.Context, data wshrpc.CommandExecuteActionData) error {
+	return wshclient.ExecuteActionCommand(wshclient.GetBareRpcClient(), data, &wshrpc.RpcOpts{Route: p.RouteId()})
+}
+
+func (p *PluginInstance) GetInfo() wshrpc.PluginInfo {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return wshrpc.PluginInfo{
+		PluginId:    p.PluginId,
+		Name:        p.Manifest.Name,
+		Version:     p.Manifest.Version,
+		Caps:        p.Manifest.Caps,
+		Views:       append([]wshrpc.PluginViewDef(nil), p.Views...),
+		Controllers: append([]string(nil), p.Controllers...),
+		Handlers:    append([]string(nil), p.Handlers...),
+		External:    p.External,
+		Scope:       p.Scope,
+		ExpiresTs:   p.ExpiresTs,
+		Connected:   wshutil.DefaultRouter.GetRpc(wshutil.MakePluginRouteId(p.PluginId)) != nil,
+	}
+}
+
+func findPlugin(matchFn func(*PluginInstance) bool) *PluginInstance {
+	globalLock.Lock()
+	insts := make([]*PluginInstance, 0, len(pluginMap))
+	for _, inst := range pluginMap {
+		insts = append(insts, inst)
+	}
+	globalLock.Unlock()
+	for _, inst := range insts {
+		if matchFn(inst) {
+			return inst
+		}
+	}
+	return nil
+}
+
+func ListPlugins() []wshrpc.PluginInfo {
+	globalLock.Lock()
+	insts := make([]*PluginInstance, 0, len(pluginMap))
+	for _, inst := range pluginMap {
+		insts = append(insts, inst)
+	}
+	globalLock.Unlock()
+	rtn := make([]wshrpc.PluginInfo, 0, len(insts))
+	for _, inst := range insts {
+		if inst.isExpired() {
+			continue
+		}
+		rtn = append(rtn, inst.GetInfo())
+	}
+	return rtn
+}
+
+func FindPluginForController(controller string) *PluginInstance {
+	if controller == "" {
+		return nil
+	}
+	return findPlugin(func(p *PluginInstance) bool { return p.OwnsController(controller) })
+}
+
+func FindPluginForBlock(block *waveobj.Block) *PluginInstance {
+	return findPlugin(func(p *PluginInstance) bool { return p.OwnsBlock(block) })
+}
+
+// verifies that the calling plugin has the capability and that it can use the block (the block belongs to one of
+// its views/controllers, or is in its scope)
+func CheckBlockAccess(ctx context.Context, source string, blockId string, capName string) (*PluginInstance, *waveobj.Block, error) {
+	plugin := GetPluginFromSource(source)
+	if plugin == nil {
+		return nil, nil, fmt.Errorf("caller is not a plugin")
+	}
+	if !plugin.HasCap(capName) {
+		return nil, nil, fmt.Errorf("plugin %q does not have capability %q", plugin.PluginId, capName)
+	}
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting block: %w", err)
+	}
+	if !plugin.CanUseBlock(ctx, block) {
+		return nil, nil, fmt.Errorf("plugin %q does not have access to block %s", plugin.PluginId, blockId)
+	}
+	return plugin, block, nil
+}
+
+// sends a lifecycle event to the plugin that owns the block (if any).  does not wait for a response.
+func SendBlockEvent(block *waveobj.Block, tabId string, event string) {
+	plugin := FindPluginForBlock(block)
+	if plugin == nil {
+		return
+	}
+	routeId := plugin.RouteId()
+	if wshutil.DefaultRouter.GetRpc(routeId) == nil {
+		return
+	}
+	data := wshrpc.PluginBlockEvent{
+		Event:      event,
+		BlockId:    block.OID,
+		TabId:      tabId,
+		View:       block.Meta.GetString(waveobj.MetaKey_View, ""),
+		Controller: block.Meta.GetString(waveobj.MetaKey_Controller, ""),
+		Meta:       block.Meta,
+	}
+	err := wshclient.PluginBlockEventCommand(wshclient.GetBareRpcClient(), data, &wshrpc.RpcOpts{Route: routeId, NoResponse: true})
+	if err != nil {
+		log.Printf("[plugin] error sending %s event to plugin %q: %v\n", event, plugin.PluginId, err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wplugin
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func TestRegisterReservedView(t *testing.T) {
+	p := &PluginInstance{Lock: &sync.Mutex{}, PluginId: "test"}
+	for view := range reservedViews {
+		err := p.Register(wshrpc.CommandPluginRegisterData{Views: []wshrpc.PluginViewDef{{View: view}}})
+		if err == nil {
+			t.Errorf("expected registering the built-in view %q to fail", view)
+		}
+	}
+	block := &waveobj.Block{Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "term"}}
+	if p.OwnsBlock(block) {
+		t.Errorf("expected the plugin not to own a term block")
+	}
+	err := p.Register(wshrpc.CommandPluginRegisterData{Views: []wshrpc.PluginViewDef{{View: "test-view"}}})
+	if err != nil || !p.OwnsView("test-view") {
+		t.Errorf("expected a plugin view to be registered, err:%v", err)
+	}
+}
+
+func TestRegisterReservedController(t *testing.T) {
+	p := &PluginInstance{Lock: &sync.Mutex{}, PluginId: "test"}
+	for controller := range reservedControllers {
+		err := p.Register(wshrpc.CommandPluginRegisterData{Controllers: []string{controller}})
+		if err == nil {
+			t.Errorf("expected registering the built-in controller %q to fail", controller)
+		}
+	}
+	block := &waveobj.Block{Meta: waveobj.MetaMapType{waveobj.MetaKey_Controller: "shell"}}
+	if p.OwnsBlock(block) || FindPluginForController("cmd") != nil {
+		t.Errorf("expected the plugin not to own the shell and cmd blocks")
+	}
+	err := p.Register(wshrpc.CommandPluginRegisterData{Controllers: []string{"test-controller"}})
+	if err != nil || !p.OwnsController("test-controller") {
+		t.Errorf("expected a plugin controller to be registered, err:%v", err)
+	}
+}
+
+func TestManifestReservedNames(t *testing.T) {
+	pluginDir := t.TempDir()
+	manifests := []string{
+		`{"cmd": ["./plugin"], "views": [{"view": "preview"}]}`,
+		`{"cmd": ["./plugin"], "controllers": ["cmd"]}`,
+	}
+	for _, manifest := range manifests {
+		err := os.WriteFile(filepath.Join(pluginDir, ManifestFileName), []byte(manifest), 0600)
+		if err != nil {
+			t.Fatalf("error writing manifest: %v", err)
+		}
+		_, err = readManifest(pluginDir)
+		if err == nil {
+			t.Errorf("expected manifest %s to be rejected", manifest)
+		}
+	}
+}
+
+func TestRegisterNeedsCap(t *testing.T) {
+	p := &PluginInstance{Lock: &sync.Mutex{}, PluginId: "test-external", External: true, ExpiresTs: time.Now().Add(time.Hour).UnixMilli()}
+	globalLock.Lock()
+	pluginMap[p.PluginId] = p
+	globalLock.Unlock()
+	defer removePlugin(p.PluginId, p)
+	msg := &wshutil.RpcMessage{
+		Command: wshrpc.Command_PluginRegister,
+		Data:    map[string]any{"views": []any{map[string]any{"view": "test-view"}}},
+	}
+	if checkCommand(p.RouteId(), msg) == nil {
+		t.Errorf("expected pluginregister to need the %q capability", wshrpc.PluginCap_Register)
+	}
+	p.Manifest.Caps = []string{wshrpc.PluginCap_Register}
+	if err := checkCommand(p.RouteId(), msg); err != nil {
+		t.Errorf("expected pluginregister to be allowed with the capability, got %v", err)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"sync"
 
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
-	"github.com/wavetermdev/waveterm/pkg/waveobj"
 )
 
 // this broker interface is mostly generic
@@ -43,12 +42,14 @@ type BrokerType struct {
 	Client     Client
 	SubMap     map[string]*BrokerSubscription
 	PersistMap map[persistKey]*persistEventWrap
+	Replay     *replayBuffer
 }
 
 var Broker = &BrokerType{
 	Lock:       &sync.Mutex{},
 	SubMap:     make(map[string]*BrokerSubscription),
 	PersistMap: make(map[persistKey]*persistEventWrap),
+	Replay:     makeReplayBuffer(),
 }
 
 func scopeHasStarMatch(scope string) bool {
@@ -230,6 +231,7 @@ func (b *BrokerType) persistEvent(event WaveEvent) {
 
 func (b *BrokerType) Publish(event WaveEvent) {
 	// log.Printf("BrokerType.Publish: %v\n", event)
+	b.publishSeq(&event)
 	if event.Persist > 0 {
 		b.persistEvent(event)
 	}
@@ -243,16 +245,6 @@ func (b *BrokerType) Publish(event WaveEvent) {
 	}
 }
 
-func (b *BrokerType) SendUpdateEvents(updates waveobj.UpdatesRtnType) {
-	for _, update := range updates {
-		b.Publish(WaveEvent{
-			Event:  Event_WaveObjUpdate,
-			Scopes: []string{waveobj.MakeORef(update.OType, update.OID).String()},
-			Data:   update,
-		})
-	}
-}
-
 func (b *BrokerType) getMatchingRouteIds(event WaveEvent) []string {
 	b.Lock.Lock()
 	defer b.Lock.Unlock()
@@ -0,0 +1,120 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wps
+
+import (
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+)
+
+// every published event gets a sequence number, and the most recent events are kept in a ring buffer.  a client
+// that reconnects (sleep/wake, network blip) sends the last seq it saw and is sent the events it missed instead
+// of reloading all of its state.  if the events after its seq are no longer buffered the replay is not complete
+// and the client has to do the full resync.  blockfile events are not buffered (they can be large, and the
+// files can be caught up from the filestore), so after a replay clients reload their files from the last offset.
+//
+// the seqs start at the startup time in microseconds, so a seq from a previous run of wavesrv is always older
+// than the buffer (a client that reconnects to a restarted wavesrv does the full resync).
+
+const ReplayBufferSize = 2048
+
+var noReplayEvents = map[string]bool{
+	Event_BlockFile:     true,
+	Event_EventsDropped: true,
+}
+
+type replayBuffer struct {
+	LastSeq    int64 // the seq of the last published event
+	EvictedSeq int64 // events after this seq are in the buffer (or are not buffered)
+	Events     []*WaveEvent
+	Start      int // index of the oldest event (once the buffer is full)
+}
+
+func makeReplayBuffer() *replayBuffer {
+	startSeq := time.Now().UnixMicro()
+	return &replayBuffer{
+		LastSeq:    startSeq,
+		EvictedSeq: startSeq,
+		Events:     make([]*WaveEvent, 0, ReplayBufferSize),
+	}
+}
+
+// assigns the event's seq and buffers it, must hold the broker lock
+func (rb *replayBuffer) add_nolock(event *WaveEvent) {
+	rb.LastSeq++
+	event.Seq = rb.LastSeq
+	if noReplayEvents[event.Event] {
+		return
+	}
+	eventCopy := *event
+	if len(rb.Events) < ReplayBufferSize {
+		rb.Events = append(rb.Events, &eventCopy)
+		return
+	}
+	rb.EvictedSeq = rb.Events[rb.Start].Seq
+	rb.Events[rb.Start] = &eventCopy
+	rb.Start = (rb.Start + 1) % ReplayBufferSize
+}
+
+// returns the buffered events after sinceSeq (oldest first), must hold the broker lock
+func (rb *replayBuffer) eventsSince_nolock(sinceSeq int64) ([]*WaveEvent, bool) {
+	if sinceSeq < rb.EvictedSeq || sinceSeq > rb.LastSeq {
+		return nil, false
+	}
+	var rtn []*WaveEvent
+	for idx := 0; idx < len(rb.Events); idx++ {
+		event := rb.Events[(rb.Start+idx)%len(rb.Events)]
+		if event.Seq > sinceSeq {
+			rtn = append(rtn, event)
+		}
+	}
+	return rtn, true
+}
+
+func (sub SubscriptionRequest) Matches(event *WaveEvent) bool {
+	if sub.Event != event.Event {
+		return false
+	}
+	if sub.AllScopes {
+		return true
+	}
+	for _, subScope := range sub.Scopes {
+		starMatch := scopeHasStarMatch(subScope)
+		for _, scope := range event.Scopes {
+			if scope == subScope || (starMatch && utilfn.StarMatchString(subScope, scope, ":")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *BrokerType) publishSeq(event *WaveEvent) {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	b.Replay.add_nolock(event)
+}
+
+// (re)subscribes the route to subs and returns the buffered events after sinceSeq that match them.  the route
+// is subscribed before the buffer is read, so every event that is not replayed is sent to the route (an event
+// can be both replayed and sent, clients skip seqs they have already seen).  complete is false if the events
+// after sinceSeq are no longer buffered, lastSeq is the seq of the last published event.
+func (b *BrokerType) SubscribeAndReplay(subRouteId string, subs []SubscriptionRequest, sinceSeq int64) (events []*WaveEvent, lastSeq int64, complete bool) {
+	for _, sub := range subs {
+		b.Subscribe(subRouteId, sub)
+	}
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	buffered, complete := b.Replay.eventsSince_nolock(sinceSeq)
+	for _, event := range buffered {
+		for _, sub := range subs {
+			if sub.Matches(event) {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+	return events, b.Replay.LastSeq, complete
+}
@@ -16,6 +16,22 @@ const (
 	Event_UserInput        = "userinput"
 	Event_RouteGone        = "route:gone"
 	Event_WorkspaceUpdate  = "workspace:update"
+	Event_TermSegment      = "term:segment"
+	Event_TermLinks        = "term:links"
+	Event_PlayerStatus     = "player:status"
+	Event_TermQueue        = "term:queue"
+	Event_Notification     = "notification"
+	Event_BlockExit        = "block:exit"
+	Event_WorkspaceCreate  = "workspace:create"
+	Event_WorkspaceDelete  = "workspace:delete"
+	Event_EventsDropped    = "events:dropped" // sent to a route that fell behind instead of its queued events
+	Event_ServerShutdown   = "server:shutdown"
+	Event_FileTransfer     = "file:transfer"
+	Event_AgentForward     = "conn:agentforward"
+	Event_ConnState        = "conn:state"
+	Event_ConnAuthExpiry   = "conn:authexpiry"
+	Event_ClusterExec      = "clusterexec:update"
+	Event_SettingsChange   = "settings:change"
 )
 
 type WaveEvent struct {
@@ -23,6 +39,7 @@ type WaveEvent struct {
 	Scopes  []string `json:"scopes,omitempty"`
 	Sender  string   `json:"sender,omitempty"`
 	Persist int      `json:"persist,omitempty"`
+	Seq     int64    `json:"seq,omitempty"` // set when published, see wpsreplay.go
 	Data    any      `json:"data,omitempty"`
 }
 
@@ -49,4 +66,93 @@ type WSFileEventData struct {
 	FileName string `json:"filename"`
 	FileOp   string `json:"fileop"`
 	Data64   string `json:"data64"`
+	Offset   int64  `json:"offset,omitempty"` // for appends, the file offset of the data
+}
+
+type NotificationEventData struct {
+	Id      string `json:"id,omitempty"` // the oid of the notification object (if it was sent through wnotify)
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"` // "error", "warning", or "info" (the default)
+}
+
+type EventsDroppedData struct {
+	Count int `json:"count"`
+}
+
+// the shell process of a block exited (not sent for detached shells)
+type BlockExitEventData struct {
+	BlockId  string `json:"blockid"`
+	TabId    string `json:"tabid"`
+	ConnName string `json:"connname,omitempty"`
+	ExitCode int    `json:"exitcode"`
+}
+
+type WorkspaceEventData struct {
+	WorkspaceId string `json:"workspaceid"`
+	Name        string `json:"name,omitempty"`
+}
+
+// the progress of an upload into (or a download or export out of) a block file, Done is set at the end (Error is
+// set if it failed or was canceled)
+type FileTransferEventData struct {
+	TransferId string `json:"transferid"`
+	Path       string `json:"path"`
+	Direction  string `json:"direction"` // "upload", "download" or "export"
+	Offset     int64  `json:"offset"`    // the bytes transferred
+	Size       int64  `json:"size"`
+	Done       bool   `json:"done,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// sent before the servers are shut down, the clients have a short grace period to save their state
+type ServerShutdownEventData struct {
+	Reason string `json:"reason"`
+}
+
+// the identity agent forwarded to a connection was used (an agent channel was opened by the remote host)
+type AgentForwardEventData struct {
+	ConnName  string `json:"connname"`
+	AgentPath string `json:"agentpath,omitempty"`
+	Ts        int64  `json:"ts"`
+}
+
+// the health of a connection changed (connected, degraded, reconnecting, disconnected or error).  Attempt and
+// NextRetryTs are set while reconnecting.
+type ConnStateEventData struct {
+	ConnName    string `json:"connname"`
+	State       string `json:"state"`
+	PrevState   string `json:"prevstate,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Attempt     int    `json:"attempt,omitempty"`
+	NextRetryTs int64  `json:"nextretryts,omitempty"`
+	Ts          int64  `json:"ts"`
+}
+
+// the kerberos ticket a connection authenticated with (gssapi) is about to expire, expired, or was renewed.  the
+// user is asked to run kinit while it is "expiring" or "expired".
+type ConnAuthExpiryEventData struct {
+	ConnName  string `json:"connname"`
+	State     string `json:"state"` // "valid", "expiring" or "expired"
+	PrevState string `json:"prevstate,omitempty"`
+	ExpiresTs int64  `json:"expirests,omitempty"` // not set if the ticket is gone (kdestroy)
+	Error     string `json:"error,omitempty"`
+	Ts        int64  `json:"ts"`
+}
+
+// a host of a cluster exec run started or finished (Done is set when the whole run finished), the run is fetched
+// with ClusterExecGetCommand
+type ClusterExecEventData struct {
+	RunId      string `json:"runid"`
+	Connection string `json:"connection,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Done       bool   `json:"done,omitempty"`
+}
+
+// the settings of wave (WorkspaceId is not set) or of a workspace were changed, Keys are the settings that were set
+// or removed
+type SettingsChangeEventData struct {
+	WorkspaceId string   `json:"workspaceid,omitempty"`
+	Keys        []string `json:"keys"`
+	Ts          int64    `json:"ts"`
 }
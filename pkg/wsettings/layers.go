@@ -0,0 +1,235 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsettings
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the layers of the settings, lowest first.  a layer overrides the ones before it, except for the settings locked
+// (settings:locked) in the global settings, settings.json, the active profile or the workspace, which the
+// connection and the block can't override (e.g. a "prod" workspace can force its theme and term:safepaste).
+const (
+	Source_Default    = "default"
+	Source_Config     = "settings.json"
+	Source_Global     = "global"
+	Source_Profile    = "profile"
+	Source_Workspace  = "workspace"
+	Source_Connection = "connection"
+	Source_Block      = "block"
+	Source_BlockFont  = "blockfont" // the font set for the block (its RuntimeOpts, see termfont.go)
+)
+
+// what the settings are resolved for, the workspace and connection of the block are used if they are not set
+type Scope struct {
+	WorkspaceId string
+	Connection  string // the name in connections.json
+	BlockId     string
+}
+
+type settingsLayer struct {
+	source   string
+	name     string
+	settings waveobj.MetaMapType
+	lockable bool // its settings can be locked (the layers before the connection)
+}
+
+// fills in the workspace and the connection of the block, returns the block
+func completeScope(ctx context.Context, scope *Scope) (*waveobj.Block, error) {
+	if scope.BlockId == "" {
+		return nil, nil
+	}
+	block, err := wstore.DBGet[*waveobj.Block](ctx, scope.BlockId)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found: %s", scope.BlockId)
+	}
+	if scope.WorkspaceId == "" {
+		tabId, err := wstore.DBFindTabForBlockId(ctx, scope.BlockId)
+		if err == nil && tabId != "" {
+			scope.WorkspaceId, err = wstore.DBFindWorkspaceForTabId(ctx, tabId)
+		}
+		if err != nil {
+			log.Printf("wsettings: error finding the workspace of block %s: %v\n", scope.BlockId, err)
+		}
+	}
+	if scope.Connection == "" {
+		scope.Connection = block.Meta.GetString(waveobj.MetaKey_Connection, "")
+	}
+	return block, nil
+}
+
+// only the keys of m that are settings
+func filterSettings(m waveobj.MetaMapType) waveobj.MetaMapType {
+	rtn := make(waveobj.MetaMapType)
+	for key, val := range m {
+		if val != nil && wconfig.IsSettingKey(key) {
+			rtn[key] = val
+		}
+	}
+	return rtn
+}
+
+// the layers for the scope.  with splitConfig the defaults and settings.json are read again from the files so they
+// are two layers, otherwise they are one (the merged settings of the config watcher, as Source_Config).
+func getLayers(ctx context.Context, scope Scope, splitConfig bool) ([]settingsLayer, error) {
+	block, err := completeScope(ctx, &scope)
+	if err != nil {
+		return nil, err
+	}
+	fullConfig := wconfig.GetWatcher().GetFullConfig()
+	var layers []settingsLayer
+	if splitConfig {
+		defaults, user := wconfig.ReadSettingsParts()
+		layers = append(layers,
+			settingsLayer{source: Source_Default, settings: filterSettings(defaults), lockable: true},
+			settingsLayer{source: Source_Config, settings: filterSettings(user), lockable: true},
+		)
+	} else {
+		configSettings := make(waveobj.MetaMapType)
+		if err := utilfn.ReUnmarshal(&configSettings, fullConfig.Settings); err != nil {
+			return nil, err
+		}
+		layers = append(layers, settingsLayer{source: Source_Config, settings: configSettings, lockable: true})
+	}
+	cacheLock.Lock()
+	if err := loadCache(ctx); err != nil {
+		cacheLock.Unlock()
+		return nil, err
+	}
+	if obj := cache[""]; obj != nil {
+		layers = append(layers, settingsLayer{source: Source_Global, settings: copySettings(obj).Settings, lockable: true})
+	}
+	if profile := profileCache[activeProfile]; profile != nil {
+		layers = append(layers, settingsLayer{source: Source_Profile, name: profile.Name, settings: ProfileSettings(profile), lockable: true})
+	}
+	if obj := cache[scope.WorkspaceId]; scope.WorkspaceId != "" && obj != nil {
+		layers = append(layers, settingsLayer{source: Source_Workspace, name: scope.WorkspaceId, settings: copySettings(obj).Settings, lockable: true})
+	}
+	cacheLock.Unlock()
+	if connKeywords, ok := fullConfig.Connections[scope.Connection]; scope.Connection != "" && ok {
+		var connMeta waveobj.MetaMapType
+		if err := utilfn.ReUnmarshal(&connMeta, connKeywords); err != nil {
+			return nil, err
+		}
+		layers = append(layers, settingsLayer{source: Source_Connection, name: scope.Connection, settings: filterSettings(connMeta)})
+	}
+	if block != nil {
+		layers = append(layers, settingsLayer{source: Source_Block, name: scope.BlockId, settings: filterSettings(block.Meta)})
+		if block.RuntimeOpts != nil && block.RuntimeOpts.TermFont != nil {
+			layers = append(layers, settingsLayer{source: Source_BlockFont, name: scope.BlockId, settings: termFontSettings(block.RuntimeOpts.TermFont)})
+		}
+	}
+	return layers, nil
+}
+
+// the settings locked by the lockable layers (the union of their settings:locked)
+func getLockedKeys(layers []settingsLayer) map[string]bool {
+	locked := make(map[string]bool)
+	for _, layer := range layers {
+		if !layer.lockable {
+			continue
+		}
+		for _, key := range layer.settings.GetStringList(wconfig.ConfigKey_SettingsLocked) {
+			locked[key] = true
+		}
+	}
+	return locked
+}
+
+// applies the layers in order, returns the settings and the source of each one
+func applyLayers(layers []settingsLayer) (waveobj.MetaMapType, map[string]string) {
+	locked := getLockedKeys(layers)
+	rtn := make(waveobj.MetaMapType)
+	sources := make(map[string]string)
+	for _, layer := range layers {
+		for key, val := range layer.settings {
+			if !layer.lockable && (locked[key] || key == wconfig.ConfigKey_SettingsLocked) {
+				continue
+			}
+			rtn[key] = val
+			sources[key] = layer.source
+		}
+	}
+	lockedList := make([]string, 0, len(locked))
+	for key := range locked {
+		lockedList = append(lockedList, key)
+	}
+	if len(lockedList) > 0 {
+		sort.Strings(lockedList)
+		rtn[wconfig.ConfigKey_SettingsLocked] = lockedList
+	}
+	return rtn, sources
+}
+
+// the effective settings for a scope: settings.json with its defaults, the global settings, the workspace, the
+// connection and the block (see the Source_* layers)
+func ResolveScopeMap(ctx context.Context, scope Scope) (waveobj.MetaMapType, error) {
+	layers, err := getLayers(ctx, scope, false)
+	if err != nil {
+		return nil, err
+	}
+	rtn, _ := applyLayers(layers)
+	return rtn, nil
+}
+
+// the effective settings for a scope (see ResolveScopeMap)
+func ResolveScope(ctx context.Context, scope Scope) (wconfig.SettingsType, error) {
+	var rtn wconfig.SettingsType
+	m, err := ResolveScopeMap(ctx, scope)
+	if err != nil {
+		return rtn, err
+	}
+	err = utilfn.ReUnmarshal(&rtn, m)
+	return rtn, err
+}
+
+// the effective value of settings with the layer it comes from, and the value of every layer that sets them (keys
+// nil for all the settings that are set)
+func Explain(ctx context.Context, scope Scope, keys []string) ([]wshrpc.SettingValueInfo, error) {
+	layers, err := getLayers(ctx, scope, true)
+	if err != nil {
+		return nil, err
+	}
+	settings, sources := applyLayers(layers)
+	locked := getLockedKeys(layers)
+	if len(keys) == 0 {
+		for key := range settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+	rtn := make([]wshrpc.SettingValueInfo, 0, len(keys))
+	for _, key := range keys {
+		if !wconfig.IsSettingKey(key) {
+			return nil, fmt.Errorf("invalid setting: %s", key)
+		}
+		info := wshrpc.SettingValueInfo{Key: key, Value: settings[key], Source: sources[key], Locked: locked[key]}
+		for _, layer := range layers {
+			val, ok := layer.settings[key]
+			if !ok {
+				continue
+			}
+			info.Layers = append(info.Layers, wshrpc.SettingLayerValue{
+				Source:  layer.source,
+				Name:    layer.name,
+				Value:   val,
+				Ignored: !layer.lockable && locked[key],
+			})
+		}
+		rtn = append(rtn, info)
+	}
+	return rtn, nil
+}
@@ -0,0 +1,325 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsettings
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the profiles are cached with the settings (by oid, loaded by loadCache), activeProfile is the oid of the one
+// switched to ("" for none)
+var profileCache = make(map[string]*waveobj.Profile)
+var activeProfile string
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// the settings a profile has a field for (they are moved from its Settings to the field when it is saved)
+var profileFieldKeys = []string{
+	wconfig.ConfigKey_TermTheme,
+	wconfig.ConfigKey_AppKeybindings,
+	wconfig.ConfigKey_AppDefaultNewBlock,
+	wconfig.ConfigKey_TabPreset,
+	wconfig.ConfigKey_AiPreset,
+}
+
+// loads the profiles and the active profile of the client, must hold cacheLock (called by loadCache)
+func loadProfiles(ctx context.Context) error {
+	profiles, err := wstore.DBGetAllObjsByType[*waveobj.Profile](ctx, waveobj.OType_Profile)
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		profileCache[profile.OID] = profile
+	}
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil {
+		return err
+	}
+	if profileCache[client.ActiveProfile] != nil {
+		activeProfile = client.ActiveProfile
+	}
+	return nil
+}
+
+// the settings of a profile (its Settings with its theme, key bindings and templates)
+func ProfileSettings(profile *waveobj.Profile) waveobj.MetaMapType {
+	rtn := filterSettings(profile.Settings)
+	if profile.Theme != "" {
+		rtn[wconfig.ConfigKey_TermTheme] = profile.Theme
+	}
+	if profile.Keybindings != nil {
+		keysMap := make(map[string]any, len(profile.Keybindings))
+		for action, keys := range profile.Keybindings {
+			keysMap[action] = keys
+		}
+		rtn[wconfig.ConfigKey_AppKeybindings] = keysMap
+	}
+	if profile.Templates.NewBlock != "" {
+		rtn[wconfig.ConfigKey_AppDefaultNewBlock] = profile.Templates.NewBlock
+	}
+	if profile.Templates.TabPreset != "" {
+		rtn[wconfig.ConfigKey_TabPreset] = profile.Templates.TabPreset
+	}
+	if profile.Templates.AiPreset != "" {
+		rtn[wconfig.ConfigKey_AiPreset] = profile.Templates.AiPreset
+	}
+	return rtn
+}
+
+// moves the settings a profile has a field for to the field (null clears the field), and checks the settings
+func normalizeProfile(profile *waveobj.Profile) error {
+	if !profileNameRe.MatchString(profile.Name) {
+		return fmt.Errorf("invalid profile name %q (letters, digits, '_', '.', and '-')", profile.Name)
+	}
+	settings := make(waveobj.MetaMapType)
+	for key, val := range profile.Settings {
+		if !wconfig.IsSettingKey(key) {
+			return fmt.Errorf("invalid setting: %s", key)
+		}
+		if val != nil {
+			settings[key] = val
+		}
+	}
+	for _, key := range profileFieldKeys {
+		val, ok := profile.Settings[key]
+		if !ok {
+			continue
+		}
+		delete(settings, key)
+		var field any
+		switch key {
+		case wconfig.ConfigKey_TermTheme:
+			profile.Theme = ""
+			field = &profile.Theme
+		case wconfig.ConfigKey_AppKeybindings:
+			profile.Keybindings = nil
+			field = &profile.Keybindings
+		case wconfig.ConfigKey_AppDefaultNewBlock:
+			profile.Templates.NewBlock = ""
+			field = &profile.Templates.NewBlock
+		case wconfig.ConfigKey_TabPreset:
+			profile.Templates.TabPreset = ""
+			field = &profile.Templates.TabPreset
+		case wconfig.ConfigKey_AiPreset:
+			profile.Templates.AiPreset = ""
+			field = &profile.Templates.AiPreset
+		}
+		if val == nil {
+			continue
+		}
+		if err := utilfn.ReUnmarshal(field, val); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+	profile.Settings = settings
+	for key, val := range ProfileSettings(profile) {
+		if err := wconfig.CheckSettingValue(key, val); err != nil {
+			return err
+		}
+	}
+	if profile.Theme != "" {
+		if _, ok := wconfig.GetWatcher().GetFullConfig().TermThemes[profile.Theme]; !ok {
+			return fmt.Errorf("theme not found: %s", profile.Theme)
+		}
+	}
+	return nil
+}
+
+// the keys whose values differ between two sets of settings (sorted)
+func diffSettings(oldSettings waveobj.MetaMapType, newSettings waveobj.MetaMapType) []string {
+	var rtn []string
+	for key, oldVal := range oldSettings {
+		if newVal, ok := newSettings[key]; !ok || !reflect.DeepEqual(oldVal, newVal) {
+			rtn = append(rtn, key)
+		}
+	}
+	for key := range newSettings {
+		if _, ok := oldSettings[key]; !ok {
+			rtn = append(rtn, key)
+		}
+	}
+	sort.Strings(rtn)
+	return rtn
+}
+
+func publishProfileChange(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	eventbus.Publish(eventbus.SettingsChangeEvent{Change: wps.SettingsChangeEventData{
+		Keys: keys,
+		Ts:   time.Now().UnixMilli(),
+	}})
+}
+
+// the settings of the active profile (nil if no profile is active), must hold cacheLock
+func activeProfileSettings() waveobj.MetaMapType {
+	if profile := profileCache[activeProfile]; profile != nil {
+		return ProfileSettings(profile)
+	}
+	return nil
+}
+
+func copyProfile(profile *waveobj.Profile) *waveobj.Profile {
+	var rtn waveobj.Profile
+	utilfn.ReUnmarshal(&rtn, profile)
+	return &rtn
+}
+
+// finds a profile by name, must hold cacheLock
+func findProfile(name string) *waveobj.Profile {
+	for _, profile := range profileCache {
+		if profile.Name == name {
+			return profile
+		}
+	}
+	return nil
+}
+
+// the profiles (by name) and the active one (nil if no profile is active)
+func ListProfiles(ctx context.Context) ([]*waveobj.Profile, *waveobj.Profile, error) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if err := loadCache(ctx); err != nil {
+		return nil, nil, err
+	}
+	rtn := make([]*waveobj.Profile, 0, len(profileCache))
+	var active *waveobj.Profile
+	for oid, profile := range profileCache {
+		profileCopy := copyProfile(profile)
+		if oid == activeProfile {
+			active = profileCopy
+		}
+		rtn = append(rtn, profileCopy)
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].Name < rtn[j].Name
+	})
+	return rtn, active, nil
+}
+
+// saves a profile (replacing the one with the same name), the settings it changes are applied right away if it is
+// the active one
+func SaveProfile(ctx context.Context, profile *waveobj.Profile) (*waveobj.Profile, error) {
+	profile = copyProfile(profile)
+	if err := normalizeProfile(profile); err != nil {
+		return nil, err
+	}
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if err := loadCache(ctx); err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	existing := findProfile(profile.Name)
+	if existing == nil {
+		profile.OID = uuid.NewString()
+		profile.Version = 0
+		profile.CreatedTs = now
+	} else {
+		profile.OID = existing.OID
+		profile.Version = existing.Version
+		profile.CreatedTs = existing.CreatedTs
+	}
+	if profile.Meta == nil {
+		profile.Meta = make(waveobj.MetaMapType)
+	}
+	profile.UpdatedTs = now
+	var err error
+	if existing == nil {
+		err = wstore.DBInsert(ctx, profile)
+	} else {
+		err = wstore.DBUpdate(ctx, profile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	oldSettings := activeProfileSettings()
+	profileCache[profile.OID] = profile
+	if profile.OID == activeProfile {
+		publishProfileChange(diffSettings(oldSettings, activeProfileSettings()))
+	}
+	return copyProfile(profile), nil
+}
+
+// deletes a profile (if it is the active one, no profile is active anymore)
+func DeleteProfile(ctx context.Context, name string) error {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if err := loadCache(ctx); err != nil {
+		return err
+	}
+	profile := findProfile(name)
+	if profile == nil {
+		return fmt.Errorf("profile not found: %s", name)
+	}
+	if profile.OID == activeProfile {
+		if err := setActiveProfile(ctx, ""); err != nil {
+			return err
+		}
+	}
+	if err := wstore.DBDelete(ctx, waveobj.OType_Profile, profile.OID); err != nil {
+		return err
+	}
+	delete(profileCache, profile.OID)
+	return nil
+}
+
+// switches to a profile ("" for none): its settings replace the ones of the profile that was active in one change,
+// so the settings:change event has every key that changed
+func SwitchProfile(ctx context.Context, name string) (*waveobj.Profile, error) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if err := loadCache(ctx); err != nil {
+		return nil, err
+	}
+	var profile *waveobj.Profile
+	var oid string
+	if name != "" {
+		profile = findProfile(name)
+		if profile == nil {
+			return nil, fmt.Errorf("profile not found: %s", name)
+		}
+		oid = profile.OID
+	}
+	if err := setActiveProfile(ctx, oid); err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, nil
+	}
+	return copyProfile(profile), nil
+}
+
+// saves the active profile in the client and publishes the settings that changed, must hold cacheLock
+func setActiveProfile(ctx context.Context, oid string) error {
+	if oid == activeProfile {
+		return nil
+	}
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil {
+		return err
+	}
+	client.ActiveProfile = oid
+	if err := wstore.DBUpdate(ctx, client); err != nil {
+		return err
+	}
+	oldSettings := activeProfileSettings()
+	activeProfile = oid
+	publishProfileChange(diffSettings(oldSettings, activeProfileSettings()))
+	return nil
+}
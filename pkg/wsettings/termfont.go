@@ -0,0 +1,114 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsettings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the font of a terminal is its term:fontfamily, term:fontsize, term:lineheight and term:ligatures settings, with
+// the font set for the block (RuntimeOpts.TermFont) over them.  the values are checked when they are set, a value
+// that got in unchecked (the meta of a block, set with setmeta) is skipped if it is invalid so the layer under it
+// is used, and the terminal always gets a font it can render with the same metrics every time it is loaded.
+
+var termFontKeys = []string{
+	wconfig.ConfigKey_TermFontFamily,
+	wconfig.ConfigKey_TermFontSize,
+	wconfig.ConfigKey_TermLineHeight,
+	wconfig.ConfigKey_TermLigatures,
+}
+
+// the font when no setting sets it (the frontend renders with the same defaults)
+var DefaultTermFont = waveobj.TermFontOpts{FontFamily: "Hack", FontSize: 12, LineHeight: 1}
+
+// the settings of a font set for a block
+func termFontSettings(font *waveobj.TermFontOpts) waveobj.MetaMapType {
+	rtn := make(waveobj.MetaMapType)
+	if font.FontFamily != "" {
+		rtn[wconfig.ConfigKey_TermFontFamily] = font.FontFamily
+	}
+	if font.FontSize != 0 {
+		rtn[wconfig.ConfigKey_TermFontSize] = font.FontSize
+	}
+	if font.LineHeight != 0 {
+		rtn[wconfig.ConfigKey_TermLineHeight] = font.LineHeight
+	}
+	if font.Ligatures != nil {
+		rtn[wconfig.ConfigKey_TermLigatures] = *font.Ligatures
+	}
+	return rtn
+}
+
+func CheckTermFont(font *waveobj.TermFontOpts) error {
+	for key, val := range termFontSettings(font) {
+		if err := wconfig.CheckSettingValue(key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the font of a block, see above
+func ResolveTermFont(ctx context.Context, blockId string) (*waveobj.TermFontOpts, error) {
+	layers, err := getLayers(ctx, Scope{BlockId: blockId}, false)
+	if err != nil {
+		return nil, err
+	}
+	for idx, layer := range layers {
+		var settings waveobj.MetaMapType
+		for _, key := range termFontKeys {
+			val, ok := layer.settings[key]
+			if !ok || wconfig.CheckSettingValue(key, val) == nil {
+				continue
+			}
+			if settings == nil {
+				settings = make(waveobj.MetaMapType, len(layer.settings))
+				for k, v := range layer.settings {
+					settings[k] = v
+				}
+			}
+			delete(settings, key)
+		}
+		if settings != nil {
+			layers[idx].settings = settings
+		}
+	}
+	resolved, _ := applyLayers(layers)
+	rtn := DefaultTermFont
+	if fontFamily := resolved.GetString(wconfig.ConfigKey_TermFontFamily, ""); fontFamily != "" {
+		rtn.FontFamily = fontFamily
+	}
+	rtn.FontSize = resolved.GetFloat(wconfig.ConfigKey_TermFontSize, rtn.FontSize)
+	rtn.LineHeight = resolved.GetFloat(wconfig.ConfigKey_TermLineHeight, rtn.LineHeight)
+	ligatures := resolved.GetBool(wconfig.ConfigKey_TermLigatures, false)
+	rtn.Ligatures = &ligatures
+	return &rtn, nil
+}
+
+// sets the font of a block (nil or an empty font removes it, so the settings of the block are used), the block
+// update goes in the updates of ctx
+func SetTermFont(ctx context.Context, blockId string, font *waveobj.TermFontOpts) error {
+	if font != nil && len(termFontSettings(font)) == 0 {
+		font = nil
+	}
+	if font != nil {
+		if err := CheckTermFont(font); err != nil {
+			return err
+		}
+	}
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error getting block: %w", err)
+	}
+	if block.RuntimeOpts == nil {
+		block.RuntimeOpts = &waveobj.RuntimeOpts{}
+	}
+	block.RuntimeOpts.TermFont = font
+	return wstore.DBUpdate(ctx, block)
+}
@@ -0,0 +1,250 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wsettings stores the settings set in wave (for all of wave or for one workspace) as wave objects, over
+// the settings in settings.json.  the settings of a workspace override the global settings, which override
+// settings.json (and its defaults), and for a block they are overridden by its connection (connections.json) and
+// its meta, see layers.go.  a profile (profile.go) is a named set of settings, the one switched to is applied over
+// the global settings.  a change (also an edit of settings.json) publishes a settings:change event with
+// the keys that changed, so the controllers and views pick it up while they run (e.g. the scrollback of the
+// terminals, see blockcontroller).  the settings objects are cached, every change goes through this package.
+package wsettings
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const dbTimeout = 5 * time.Second
+
+var cacheLock = &sync.Mutex{}
+var cacheLoaded bool
+var cache = make(map[string]*waveobj.Settings) // by workspace id ("" for the global settings)
+var startOnce = &sync.Once{}
+
+// removes the settings of the workspaces that are deleted, and publishes the changes made to settings.json
+func Start() {
+	startOnce.Do(func() {
+		eventbus.Subscribe(eventbus.Topic_WorkspaceDelete, eventbus.Scope{}, handleWorkspaceDelete)
+		wconfig.GetWatcher().OnSettingsChange(handleSettingsFileChange)
+	})
+}
+
+// called from the config watcher when settings.json (or a file in settings/) is edited, the global settings and
+// the settings of the workspaces still override the changed keys but the controllers resolve them again
+func handleSettingsFileChange(keys []string) {
+	eventbus.Publish(eventbus.SettingsChangeEvent{Change: wps.SettingsChangeEventData{
+		Keys: keys,
+		Ts:   time.Now().UnixMilli(),
+	}})
+}
+
+// called from the publishing goroutine, must not block
+func handleWorkspaceDelete(event eventbus.Event) {
+	wsEvent, ok := event.(eventbus.WorkspaceEvent)
+	if !ok || wsEvent.Workspace.WorkspaceId == "" {
+		return
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("wsettings:handleWorkspaceDelete", recover())
+		}()
+		ctx, cancelFn := context.WithTimeout(context.Background(), dbTimeout)
+		defer cancelFn()
+		err := deleteSettings(ctx, wsEvent.Workspace.WorkspaceId)
+		if err != nil {
+			log.Printf("wsettings: error deleting the settings of workspace %s: %v\n", wsEvent.Workspace.WorkspaceId, err)
+		}
+	}()
+}
+
+// loads the settings objects (and the profiles) into the cache the first time, must hold cacheLock
+func loadCache(ctx context.Context) error {
+	if cacheLoaded {
+		return nil
+	}
+	objs, err := wstore.DBGetAllObjsByType[*waveobj.Settings](ctx, waveobj.OType_Settings)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		migrateStoredSettings(ctx, obj)
+		cache[obj.WorkspaceId] = obj
+	}
+	if err := loadProfiles(ctx); err != nil {
+		return err
+	}
+	cacheLoaded = true
+	return nil
+}
+
+// the stored settings are migrated like settings.json (see wconfig.MigrateSettings), and saved if they changed
+func migrateStoredSettings(ctx context.Context, obj *waveobj.Settings) {
+	changes, err := wconfig.MigrateSettings(obj.Settings)
+	if err != nil {
+		log.Printf("wsettings: settings of workspace %q: %v\n", obj.WorkspaceId, err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+	for _, change := range changes {
+		log.Printf("wsettings: settings of workspace %q: %s %s\n", obj.WorkspaceId, change.Key, change.Desc)
+	}
+	obj.Settings[wconfig.ConfigKey_SettingsVersion] = wconfig.SettingsVersion
+	if err := wstore.DBUpdate(ctx, obj); err != nil {
+		log.Printf("wsettings: error saving the migrated settings of workspace %q: %v\n", obj.WorkspaceId, err)
+	}
+}
+
+// the stored settings of wave (workspaceId "") or of a workspace, nil if none were set
+func Get(ctx context.Context, workspaceId string) (*waveobj.Settings, error) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if err := loadCache(ctx); err != nil {
+		return nil, err
+	}
+	obj := cache[workspaceId]
+	if obj == nil {
+		return nil, nil
+	}
+	return copySettings(obj), nil
+}
+
+func copySettings(obj *waveobj.Settings) *waveobj.Settings {
+	rtn := *obj
+	rtn.Settings = make(waveobj.MetaMapType, len(obj.Settings))
+	for key, val := range obj.Settings {
+		rtn.Settings[key] = val
+	}
+	return &rtn
+}
+
+// sets the settings of wave (workspaceId "") or of a workspace, a nil value removes the setting (so the global
+// setting or settings.json is used again)
+func Set(ctx context.Context, workspaceId string, toMerge waveobj.MetaMapType) (*waveobj.Settings, error) {
+	for key, val := range toMerge {
+		if val == nil {
+			continue
+		}
+		if err := wconfig.CheckSettingValue(key, val); err != nil {
+			return nil, err
+		}
+	}
+	if workspaceId != "" {
+		exists, err := wstore.DBExistsORef(ctx, waveobj.MakeORef(waveobj.OType_Workspace, workspaceId))
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("workspace not found: %s", workspaceId)
+		}
+	}
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if err := loadCache(ctx); err != nil {
+		return nil, err
+	}
+	var obj *waveobj.Settings
+	isNew := cache[workspaceId] == nil
+	if isNew {
+		obj = &waveobj.Settings{OID: uuid.NewString(), WorkspaceId: workspaceId, Settings: make(waveobj.MetaMapType), Meta: make(waveobj.MetaMapType)}
+	} else {
+		obj = copySettings(cache[workspaceId])
+	}
+	changedKeys := mergeSettings(obj.Settings, toMerge)
+	if len(changedKeys) == 0 {
+		return copySettings(obj), nil
+	}
+	obj.UpdatedTs = time.Now().UnixMilli()
+	var err error
+	if isNew {
+		err = wstore.DBInsert(ctx, obj)
+	} else {
+		err = wstore.DBUpdate(ctx, obj)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache[workspaceId] = obj
+	eventbus.Publish(eventbus.SettingsChangeEvent{Change: wps.SettingsChangeEventData{
+		WorkspaceId: workspaceId,
+		Keys:        changedKeys,
+		Ts:          obj.UpdatedTs,
+	}})
+	return copySettings(obj), nil
+}
+
+// merges toMerge into settings (a nil value removes the key), returns the keys that changed (sorted)
+func mergeSettings(settings waveobj.MetaMapType, toMerge waveobj.MetaMapType) []string {
+	var changedKeys []string
+	for key, val := range toMerge {
+		oldVal, exists := settings[key]
+		if val == nil {
+			if exists {
+				delete(settings, key)
+				changedKeys = append(changedKeys, key)
+			}
+			continue
+		}
+		if !exists || !reflect.DeepEqual(oldVal, val) {
+			settings[key] = val
+			changedKeys = append(changedKeys, key)
+		}
+	}
+	sort.Strings(changedKeys)
+	return changedKeys
+}
+
+func deleteSettings(ctx context.Context, workspaceId string) error {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if err := loadCache(ctx); err != nil {
+		return err
+	}
+	obj := cache[workspaceId]
+	if obj == nil {
+		return nil
+	}
+	err := wstore.DBDelete(ctx, waveobj.OType_Settings, obj.OID)
+	if err != nil {
+		return err
+	}
+	delete(cache, workspaceId)
+	return nil
+}
+
+// the effective settings of a workspace (or of wave for workspaceId ""): settings.json with its defaults, then the
+// global settings, then the settings of the workspace
+func ResolveMap(ctx context.Context, workspaceId string) (waveobj.MetaMapType, error) {
+	return ResolveScopeMap(ctx, Scope{WorkspaceId: workspaceId})
+}
+
+// the effective settings of a workspace (see ResolveMap)
+func Resolve(ctx context.Context, workspaceId string) (wconfig.SettingsType, error) {
+	return ResolveScope(ctx, Scope{WorkspaceId: workspaceId})
+}
+
+// the effective settings of a block (with its workspace, connection and meta), settings.json if they can't be
+// resolved
+func ResolveForBlock(ctx context.Context, blockId string) wconfig.SettingsType {
+	settings, err := ResolveScope(ctx, Scope{BlockId: blockId})
+	if err != nil {
+		log.Printf("wsettings: error resolving the settings of block %s: %v\n", blockId, err)
+		return wconfig.GetWatcher().GetFullConfig().Settings
+	}
+	return settings
+}
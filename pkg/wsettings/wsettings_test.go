@@ -0,0 +1,141 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsettings
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestMergeSettings(t *testing.T) {
+	settings := waveobj.MetaMapType{
+		"term:scrollback":     float64(2000),
+		"term:localshellpath": "/bin/bash",
+		"term:localshellopts": []any{"-l"},
+	}
+	changed := mergeSettings(settings, waveobj.MetaMapType{
+		"term:scrollback":     float64(5000),
+		"term:localshellpath": nil,
+		"term:localshellopts": []any{"-l"},
+		"window:transparent":  true,
+		"term:fontfamily":     nil,
+	})
+	expected := []string{"term:localshellpath", "term:scrollback", "window:transparent"}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected the changed keys %v, got %v", expected, changed)
+	}
+	if settings.GetInt("term:scrollback", 0) != 5000 || settings.HasKey("term:localshellpath") || !settings.GetBool("window:transparent", false) {
+		t.Errorf("unexpected merged settings %v", settings)
+	}
+	if changed := mergeSettings(settings, waveobj.MetaMapType{"term:scrollback": float64(5000)}); len(changed) != 0 {
+		t.Errorf("expected no changes when setting the same value, got %v", changed)
+	}
+}
+
+func TestApplyLayers(t *testing.T) {
+	layers := []settingsLayer{
+		{source: Source_Config, lockable: true, settings: waveobj.MetaMapType{
+			"term:theme":      "default-dark",
+			"term:fontsize":   float64(12),
+			"settings:locked": []any{"term:fontsize"},
+		}},
+		{source: Source_Profile, name: "presentation", lockable: true, settings: waveobj.MetaMapType{
+			"term:transparency": float64(0),
+		}},
+		{source: Source_Workspace, name: "ws1", lockable: true, settings: waveobj.MetaMapType{
+			"term:theme":      "red-alert",
+			"term:safepaste":  true,
+			"settings:locked": []any{"term:theme", "term:safepaste"},
+		}},
+		{source: Source_Connection, name: "user@prod", settings: waveobj.MetaMapType{
+			"term:theme":      "dracula",
+			"term:scrollback": float64(5000),
+		}},
+		{source: Source_Block, name: "block1", settings: waveobj.MetaMapType{
+			"term:safepaste":  false,
+			"term:fontsize":   float64(16),
+			"term:scrollback": float64(100),
+			"settings:locked": []any{},
+		}},
+	}
+	settings, sources := applyLayers(layers)
+	expected := map[string]struct {
+		val    any
+		source string
+	}{
+		"term:theme":        {"red-alert", Source_Workspace},
+		"term:safepaste":    {true, Source_Workspace},
+		"term:fontsize":     {float64(12), Source_Config},
+		"term:scrollback":   {float64(100), Source_Block},
+		"term:transparency": {float64(0), Source_Profile},
+	}
+	for key, exp := range expected {
+		if !reflect.DeepEqual(settings[key], exp.val) || sources[key] != exp.source {
+			t.Errorf("%s: expected %v from %s, got %v from %s", key, exp.val, exp.source, settings[key], sources[key])
+		}
+	}
+	lockedExpected := []string{"term:fontsize", "term:safepaste", "term:theme"}
+	if !reflect.DeepEqual(settings["settings:locked"], lockedExpected) {
+		t.Errorf("expected the locked settings %v, got %v", lockedExpected, settings["settings:locked"])
+	}
+}
+
+func TestNormalizeProfile(t *testing.T) {
+	profile := &waveobj.Profile{
+		Name:        "work",
+		Keybindings: map[string][]string{"tab:new": {"Cmd:t"}},
+		Templates:   waveobj.ProfileTemplates{AiPreset: "ai@claude"},
+		Settings: waveobj.MetaMapType{
+			"term:fontsize":       float64(14),
+			"app:defaultnewblock": "term",
+			"app:keybindings":     nil,
+			"term:scrollback":     nil,
+		},
+	}
+	if err := normalizeProfile(profile); err != nil {
+		t.Fatalf("normalizing: %v", err)
+	}
+	if !reflect.DeepEqual(profile.Settings, waveobj.MetaMapType{"term:fontsize": float64(14)}) {
+		t.Errorf("expected the field settings to be moved out, got %v", profile.Settings)
+	}
+	if profile.Templates.NewBlock != "term" || profile.Templates.AiPreset != "ai@claude" || profile.Keybindings != nil {
+		t.Errorf("unexpected fields %+v %v", profile.Templates, profile.Keybindings)
+	}
+	expected := waveobj.MetaMapType{"term:fontsize": float64(14), "app:defaultnewblock": "term", "ai:preset": "ai@claude"}
+	if !reflect.DeepEqual(ProfileSettings(profile), expected) {
+		t.Errorf("expected the profile settings %v, got %v", expected, ProfileSettings(profile))
+	}
+	for _, bad := range []*waveobj.Profile{
+		{Name: "my profile"},
+		{Name: "work", Settings: waveobj.MetaMapType{"term:nosuchsetting": true}},
+		{Name: "work", Settings: waveobj.MetaMapType{"term:fontsize": "big"}},
+	} {
+		if err := normalizeProfile(bad); err == nil {
+			t.Errorf("expected an error for %+v", bad)
+		}
+	}
+	changed := diffSettings(waveobj.MetaMapType{"term:fontsize": float64(14), "term:theme": "dracula"}, expected)
+	if !reflect.DeepEqual(changed, []string{"ai:preset", "app:defaultnewblock", "term:theme"}) {
+		t.Errorf("unexpected changed keys %v", changed)
+	}
+}
+
+func TestTermFontSettings(t *testing.T) {
+	ligatures := true
+	font := &waveobj.TermFontOpts{FontFamily: "monospace", FontSize: 15, Ligatures: &ligatures}
+	expected := waveobj.MetaMapType{"term:fontfamily": "monospace", "term:fontsize": float64(15), "term:ligatures": true}
+	if !reflect.DeepEqual(termFontSettings(font), expected) {
+		t.Errorf("expected %v, got %v", expected, termFontSettings(font))
+	}
+	if err := CheckTermFont(font); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	for _, bad := range []*waveobj.TermFontOpts{{FontSize: 200}, {LineHeight: 0.1}, {FontSize: -1}} {
+		if err := CheckTermFont(bad); err == nil {
+			t.Errorf("expected an error for %+v", bad)
+		}
+	}
+}
@@ -28,6 +28,12 @@ func AiSendMessageCommand(w *wshutil.WshRpc, data wshrpc.AiMessageData, opts *ws
 	return err
 }
 
+// command "attachblock", wshserver.AttachBlockCommand
+func AttachBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandAttachBlockData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "attachblock", data, opts)
+	return err
+}
+
 // command "authenticate", wshserver.AuthenticateCommand
 func AuthenticateCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (wshrpc.CommandAuthenticateRtnData, error) {
 	resp, err := sendRpcRequestCallHelper[wshrpc.CommandAuthenticateRtnData](w, "authenticate", data, opts)
@@ -40,24 +46,131 @@ func AuthenticateTokenCommand(w *wshutil.WshRpc, data wshrpc.CommandAuthenticate
 	return resp, err
 }
 
+// command "authtokenissue", wshserver.AuthTokenIssueCommand
+func AuthTokenIssueCommand(w *wshutil.WshRpc, data wshrpc.CommandAuthTokenData, opts *wshrpc.RpcOpts) (*wshrpc.AuthTokenRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.AuthTokenRtnData](w, "authtokenissue", data, opts)
+	return resp, err
+}
+
+// command "authtokenrevoke", wshserver.AuthTokenRevokeCommand
+func AuthTokenRevokeCommand(w *wshutil.WshRpc, data wshrpc.CommandAuthTokenData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "authtokenrevoke", data, opts)
+	return err
+}
+
 // command "blockinfo", wshserver.BlockInfoCommand
 func BlockInfoCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (*wshrpc.BlockInfoData, error) {
 	resp, err := sendRpcRequestCallHelper[*wshrpc.BlockInfoData](w, "blockinfo", data, opts)
 	return resp, err
 }
 
+// command "clipboardread", wshserver.ClipboardReadCommand
+func ClipboardReadCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "clipboardread", nil, opts)
+	return resp, err
+}
+
+// command "clipboardwrite", wshserver.ClipboardWriteCommand
+func ClipboardWriteCommand(w *wshutil.WshRpc, data wshrpc.CommandClipboardWriteData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "clipboardwrite", data, opts)
+	return err
+}
+
+// command "clusterexeccancel", wshserver.ClusterExecCancelCommand
+func ClusterExecCancelCommand(w *wshutil.WshRpc, data wshrpc.CommandClusterExecRunData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "clusterexeccancel", data, opts)
+	return err
+}
+
+// command "clusterexecget", wshserver.ClusterExecGetCommand
+func ClusterExecGetCommand(w *wshutil.WshRpc, data wshrpc.CommandClusterExecRunData, opts *wshrpc.RpcOpts) (*wshrpc.ClusterExecRun, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ClusterExecRun](w, "clusterexecget", data, opts)
+	return resp, err
+}
+
+// command "clusterexecstart", wshserver.ClusterExecStartCommand
+func ClusterExecStartCommand(w *wshutil.WshRpc, data wshrpc.CommandClusterExecData, opts *wshrpc.RpcOpts) (*wshrpc.ClusterExecRun, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ClusterExecRun](w, "clusterexecstart", data, opts)
+	return resp, err
+}
+
+// command "cmdhistorydelete", wshserver.CmdHistoryDeleteCommand
+func CmdHistoryDeleteCommand(w *wshutil.WshRpc, data []string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "cmdhistorydelete", data, opts)
+	return err
+}
+
+// command "cmdhistorysearch", wshserver.CmdHistorySearchCommand
+func CmdHistorySearchCommand(w *wshutil.WshRpc, data wshrpc.CommandCmdHistorySearchData, opts *wshrpc.RpcOpts) ([]*wshrpc.CmdHistoryEntry, error) {
+	resp, err := sendRpcRequestCallHelper[[]*wshrpc.CmdHistoryEntry](w, "cmdhistorysearch", data, opts)
+	return resp, err
+}
+
 // command "connconnect", wshserver.ConnConnectCommand
 func ConnConnectCommand(w *wshutil.WshRpc, data wshrpc.ConnRequest, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "connconnect", data, opts)
 	return err
 }
 
+// command "conndashboard", wshserver.ConnDashboardCommand
+func ConnDashboardCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.ConnDashboardEntry, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ConnDashboardEntry](w, "conndashboard", nil, opts)
+	return resp, err
+}
+
 // command "conndisconnect", wshserver.ConnDisconnectCommand
 func ConnDisconnectCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "conndisconnect", data, opts)
 	return err
 }
 
+// command "connectioncreate", wshserver.ConnectionCreateCommand
+func ConnectionCreateCommand(w *wshutil.WshRpc, data waveobj.Connection, opts *wshrpc.RpcOpts) (*waveobj.Connection, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Connection](w, "connectioncreate", data, opts)
+	return resp, err
+}
+
+// command "connectiondelete", wshserver.ConnectionDeleteCommand
+func ConnectionDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandConnectionData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "connectiondelete", data, opts)
+	return err
+}
+
+// command "connectionlist", wshserver.ConnectionListCommand
+func ConnectionListCommand(w *wshutil.WshRpc, data wshrpc.CommandConnectionListData, opts *wshrpc.RpcOpts) ([]*wshrpc.ConnectionInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]*wshrpc.ConnectionInfo](w, "connectionlist", data, opts)
+	return resp, err
+}
+
+// command "connectionopenterm", wshserver.ConnectionOpenTermCommand
+func ConnectionOpenTermCommand(w *wshutil.WshRpc, data wshrpc.CommandConnectionData, opts *wshrpc.RpcOpts) (*waveobj.ORef, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.ORef](w, "connectionopenterm", data, opts)
+	return resp, err
+}
+
+// command "connectionrun", wshserver.ConnectionRunCommand
+func ConnectionRunCommand(w *wshutil.WshRpc, data wshrpc.CommandConnectionRunData, opts *wshrpc.RpcOpts) (*wshrpc.ConnectionRunResult, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ConnectionRunResult](w, "connectionrun", data, opts)
+	return resp, err
+}
+
+// command "connectionrunstream", wshserver.ConnectionRunStreamCommand
+func ConnectionRunStreamCommand(w *wshutil.WshRpc, data wshrpc.CommandConnectionRunData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.ConnectionRunOutput] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.ConnectionRunOutput](w, "connectionrunstream", data, opts)
+}
+
+// command "connectionsetkey", wshserver.ConnectionSetKeyCommand
+func ConnectionSetKeyCommand(w *wshutil.WshRpc, data wshrpc.CommandConnectionSetKeyData, opts *wshrpc.RpcOpts) (*waveobj.Connection, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Connection](w, "connectionsetkey", data, opts)
+	return resp, err
+}
+
+// command "connectionupdate", wshserver.ConnectionUpdateCommand
+func ConnectionUpdateCommand(w *wshutil.WshRpc, data waveobj.Connection, opts *wshrpc.RpcOpts) (*waveobj.Connection, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Connection](w, "connectionupdate", data, opts)
+	return resp, err
+}
+
 // command "connensure", wshserver.ConnEnsureCommand
 func ConnEnsureCommand(w *wshutil.WshRpc, data wshrpc.ConnExtData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "connensure", data, opts)
@@ -76,6 +189,12 @@ func ConnListAWSCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]string, erro
 	return resp, err
 }
 
+// command "connmetrics", wshserver.ConnMetricsCommand
+func ConnMetricsCommand(w *wshutil.WshRpc, data wshrpc.CommandConnMetricsData, opts *wshrpc.RpcOpts) ([]wshrpc.ConnMetrics, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ConnMetrics](w, "connmetrics", data, opts)
+	return resp, err
+}
+
 // command "connreinstallwsh", wshserver.ConnReinstallWshCommand
 func ConnReinstallWshCommand(w *wshutil.WshRpc, data wshrpc.ConnExtData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "connreinstallwsh", data, opts)
@@ -106,12 +225,42 @@ func ControllerInputCommand(w *wshutil.WshRpc, data wshrpc.CommandBlockInputData
 	return err
 }
 
+// command "controlleroutputack", wshserver.ControllerOutputAckCommand
+func ControllerOutputAckCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerOutputAckData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "controlleroutputack", data, opts)
+	return err
+}
+
+// command "controllerpaste", wshserver.ControllerPasteCommand
+func ControllerPasteCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerPasteData, opts *wshrpc.RpcOpts) (*wshrpc.CommandControllerPasteRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.CommandControllerPasteRtnData](w, "controllerpaste", data, opts)
+	return resp, err
+}
+
+// command "controllerprocesstree", wshserver.ControllerProcessTreeCommand
+func ControllerProcessTreeCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerProcessTreeData, opts *wshrpc.RpcOpts) ([]wshrpc.ProcessInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ProcessInfo](w, "controllerprocesstree", data, opts)
+	return resp, err
+}
+
+// command "controllerresize", wshserver.ControllerResizeCommand
+func ControllerResizeCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerResizeData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "controllerresize", data, opts)
+	return err
+}
+
 // command "controllerresync", wshserver.ControllerResyncCommand
 func ControllerResyncCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerResyncData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "controllerresync", data, opts)
 	return err
 }
 
+// command "controllersignal", wshserver.ControllerSignalCommand
+func ControllerSignalCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerSignalData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "controllersignal", data, opts)
+	return err
+}
+
 // command "controllerstop", wshserver.ControllerStopCommand
 func ControllerStopCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "controllerstop", data, opts)
@@ -142,6 +291,12 @@ func DeleteSubBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandDeleteBlockData
 	return err
 }
 
+// command "detachblock", wshserver.DetachBlockCommand
+func DetachBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandDetachBlockData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "detachblock", data, opts)
+	return err
+}
+
 // command "dismisswshfail", wshserver.DismissWshFailCommand
 func DismissWshFailCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "dismisswshfail", data, opts)
@@ -178,6 +333,12 @@ func EventRecvCommand(w *wshutil.WshRpc, data wps.WaveEvent, opts *wshrpc.RpcOpt
 	return err
 }
 
+// command "eventreplay", wshserver.EventReplayCommand
+func EventReplayCommand(w *wshutil.WshRpc, data wshrpc.CommandEventReplayData, opts *wshrpc.RpcOpts) (*wshrpc.EventReplayRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.EventReplayRtnData](w, "eventreplay", data, opts)
+	return resp, err
+}
+
 // command "eventsub", wshserver.EventSubCommand
 func EventSubCommand(w *wshutil.WshRpc, data wps.SubscriptionRequest, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "eventsub", data, opts)
@@ -196,6 +357,12 @@ func EventUnsubAllCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
 	return err
 }
 
+// command "executeaction", wshserver.ExecuteActionCommand
+func ExecuteActionCommand(w *wshutil.WshRpc, data wshrpc.CommandExecuteActionData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "executeaction", data, opts)
+	return err
+}
+
 // command "fetchsuggestions", wshserver.FetchSuggestionsCommand
 func FetchSuggestionsCommand(w *wshutil.WshRpc, data wshrpc.FetchSuggestionsData, opts *wshrpc.RpcOpts) (*wshrpc.FetchSuggestionsResponse, error) {
 	resp, err := sendRpcRequestCallHelper[*wshrpc.FetchSuggestionsResponse](w, "fetchsuggestions", data, opts)
@@ -232,6 +399,22 @@ func FileDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandDeleteFileData, opt
 	return err
 }
 
+// command "filedownload", wshserver.FileDownloadCommand
+func FileDownloadCommand(w *wshutil.WshRpc, data wshrpc.CommandFileTransferData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.FileTransferChunk] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.FileTransferChunk](w, "filedownload", data, opts)
+}
+
+// command "fileexport", wshserver.FileExportCommand
+func FileExportCommand(w *wshutil.WshRpc, data wshrpc.CommandFileExportData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.FileExportProgress] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.FileExportProgress](w, "fileexport", data, opts)
+}
+
+// command "fileimport", wshserver.FileImportCommand
+func FileImportCommand(w *wshutil.WshRpc, data wshrpc.CommandFileImportData, opts *wshrpc.RpcOpts) (*wshrpc.FileInfo, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.FileInfo](w, "fileimport", data, opts)
+	return resp, err
+}
+
 // command "fileinfo", wshserver.FileInfoCommand
 func FileInfoCommand(w *wshutil.WshRpc, data wshrpc.FileData, opts *wshrpc.RpcOpts) (*wshrpc.FileInfo, error) {
 	resp, err := sendRpcRequestCallHelper[*wshrpc.FileInfo](w, "fileinfo", data, opts)
@@ -273,11 +456,23 @@ func FileReadCommand(w *wshutil.WshRpc, data wshrpc.FileData, opts *wshrpc.RpcOp
 	return resp, err
 }
 
+// command "filereadranges", wshserver.FileReadRangesCommand
+func FileReadRangesCommand(w *wshutil.WshRpc, data wshrpc.CommandFileReadRangesData, opts *wshrpc.RpcOpts) (*wshrpc.FileReadRangesData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.FileReadRangesData](w, "filereadranges", data, opts)
+	return resp, err
+}
+
 // command "filereadstream", wshserver.FileReadStreamCommand
 func FileReadStreamCommand(w *wshutil.WshRpc, data wshrpc.FileData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.FileData] {
 	return sendRpcRequestResponseStreamHelper[wshrpc.FileData](w, "filereadstream", data, opts)
 }
 
+// command "filesearch", wshserver.FileSearchCommand
+func FileSearchCommand(w *wshutil.WshRpc, data wshrpc.CommandFileSearchData, opts *wshrpc.RpcOpts) ([]wshrpc.FileSearchHit, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.FileSearchHit](w, "filesearch", data, opts)
+	return resp, err
+}
+
 // command "filesharecapability", wshserver.FileShareCapabilityCommand
 func FileShareCapabilityCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (wshrpc.FileShareCapability, error) {
 	resp, err := sendRpcRequestCallHelper[wshrpc.FileShareCapability](w, "filesharecapability", data, opts)
@@ -289,6 +484,29 @@ func FileStreamTarCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteStreamTarD
 	return sendRpcRequestResponseStreamHelper[iochantypes.Packet](w, "filestreamtar", data, opts)
 }
 
+// command "filetransfercancel", wshserver.FileTransferCancelCommand
+func FileTransferCancelCommand(w *wshutil.WshRpc, data wshrpc.CommandFileTransferData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "filetransfercancel", data, opts)
+	return err
+}
+
+// command "filetransferstatus", wshserver.FileTransferStatusCommand
+func FileTransferStatusCommand(w *wshutil.WshRpc, data wshrpc.CommandFileTransferData, opts *wshrpc.RpcOpts) (*wshrpc.FileTransferStatus, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.FileTransferStatus](w, "filetransferstatus", data, opts)
+	return resp, err
+}
+
+// command "fileuploadchunk", wshserver.FileUploadChunkCommand
+func FileUploadChunkCommand(w *wshutil.WshRpc, data wshrpc.CommandFileUploadChunkData, opts *wshrpc.RpcOpts) (*wshrpc.FileTransferStatus, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.FileTransferStatus](w, "fileuploadchunk", data, opts)
+	return resp, err
+}
+
+// command "filewatch", wshserver.FileWatchCommand
+func FileWatchCommand(w *wshutil.WshRpc, data wshrpc.CommandFileWatchData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.FileWatchEvent] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.FileWatchEvent](w, "filewatch", data, opts)
+}
+
 // command "filewrite", wshserver.FileWriteCommand
 func FileWriteCommand(w *wshutil.WshRpc, data wshrpc.FileData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "filewrite", data, opts)
@@ -301,6 +519,18 @@ func FocusWindowCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) er
 	return err
 }
 
+// command "fontlist", wshserver.FontListCommand
+func FontListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.FontInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.FontInfo](w, "fontlist", nil, opts)
+	return resp, err
+}
+
+// command "getenvsnapshot", wshserver.GetEnvSnapshotCommand
+func GetEnvSnapshotCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (*wshrpc.EnvSnapshot, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.EnvSnapshot](w, "getenvsnapshot", data, opts)
+	return resp, err
+}
+
 // command "getfullconfig", wshserver.GetFullConfigCommand
 func GetFullConfigCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (wconfig.FullConfigType, error) {
 	resp, err := sendRpcRequestCallHelper[wconfig.FullConfigType](w, "getfullconfig", nil, opts)
@@ -331,24 +561,234 @@ func GetVarCommand(w *wshutil.WshRpc, data wshrpc.CommandVarData, opts *wshrpc.R
 	return resp, err
 }
 
+// command "hostkeyforget", wshserver.HostKeyForgetCommand
+func HostKeyForgetCommand(w *wshutil.WshRpc, data wshrpc.CommandHostKeyForgetData, opts *wshrpc.RpcOpts) (int, error) {
+	resp, err := sendRpcRequestCallHelper[int](w, "hostkeyforget", data, opts)
+	return resp, err
+}
+
+// command "hostkeylist", wshserver.HostKeyListCommand
+func HostKeyListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.KnownHostKey, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.KnownHostKey](w, "hostkeylist", nil, opts)
+	return resp, err
+}
+
+// command "i18ncatalog", wshserver.I18nCatalogCommand
+func I18nCatalogCommand(w *wshutil.WshRpc, data wshrpc.CommandI18nCatalogData, opts *wshrpc.RpcOpts) (*wshrpc.I18nCatalogData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.I18nCatalogData](w, "i18ncatalog", data, opts)
+	return resp, err
+}
+
+// command "i18nlist", wshserver.I18nListCommand
+func I18nListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.I18nCatalogInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.I18nCatalogInfo](w, "i18nlist", nil, opts)
+	return resp, err
+}
+
+// command "keybindingresolve", wshserver.KeyBindingResolveCommand
+func KeyBindingResolveCommand(w *wshutil.WshRpc, data wshrpc.CommandKeyBindingResolveData, opts *wshrpc.RpcOpts) (*wshrpc.KeyBindingResolveRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.KeyBindingResolveRtnData](w, "keybindingresolve", data, opts)
+	return resp, err
+}
+
+// command "keybindingslist", wshserver.KeyBindingsListCommand
+func KeyBindingsListCommand(w *wshutil.WshRpc, data wshrpc.CommandSettingsData, opts *wshrpc.RpcOpts) (*wshrpc.KeyBindingsData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.KeyBindingsData](w, "keybindingslist", data, opts)
+	return resp, err
+}
+
+// command "listactions", wshserver.ListActionsCommand
+func ListActionsCommand(w *wshutil.WshRpc, data wshrpc.CommandListActionsData, opts *wshrpc.RpcOpts) ([]wshrpc.ActionDef, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ActionDef](w, "listactions", data, opts)
+	return resp, err
+}
+
+// command "listdetachedblocks", wshserver.ListDetachedBlocksCommand
+func ListDetachedBlocksCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.DetachedBlockInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.DetachedBlockInfo](w, "listdetachedblocks", nil, opts)
+	return resp, err
+}
+
 // command "message", wshserver.MessageCommand
 func MessageCommand(w *wshutil.WshRpc, data wshrpc.CommandMessageData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "message", data, opts)
 	return err
 }
 
+// command "notificationdismiss", wshserver.NotificationDismissCommand
+func NotificationDismissCommand(w *wshutil.WshRpc, data wshrpc.CommandNotificationDismissData, opts *wshrpc.RpcOpts) (int, error) {
+	resp, err := sendRpcRequestCallHelper[int](w, "notificationdismiss", data, opts)
+	return resp, err
+}
+
+// command "notificationlist", wshserver.NotificationListCommand
+func NotificationListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]*waveobj.Notification, error) {
+	resp, err := sendRpcRequestCallHelper[[]*waveobj.Notification](w, "notificationlist", nil, opts)
+	return resp, err
+}
+
+// command "notificationsend", wshserver.NotificationSendCommand
+func NotificationSendCommand(w *wshutil.WshRpc, data wshrpc.CommandNotificationSendData, opts *wshrpc.RpcOpts) (*waveobj.Notification, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Notification](w, "notificationsend", data, opts)
+	return resp, err
+}
+
 // command "notify", wshserver.NotifyCommand
 func NotifyCommand(w *wshutil.WshRpc, data wshrpc.WaveNotificationOptions, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "notify", data, opts)
 	return err
 }
 
+// command "palettesearch", wshserver.PaletteSearchCommand
+func PaletteSearchCommand(w *wshutil.WshRpc, data wshrpc.CommandPaletteSearchData, opts *wshrpc.RpcOpts) ([]wshrpc.PaletteItem, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.PaletteItem](w, "palettesearch", data, opts)
+	return resp, err
+}
+
 // command "path", wshserver.PathCommand
 func PathCommand(w *wshutil.WshRpc, data wshrpc.PathCommandData, opts *wshrpc.RpcOpts) (string, error) {
 	resp, err := sendRpcRequestCallHelper[string](w, "path", data, opts)
 	return resp, err
 }
 
+// command "playerload", wshserver.PlayerLoadCommand
+func PlayerLoadCommand(w *wshutil.WshRpc, data wshrpc.CommandPlayerLoadData, opts *wshrpc.RpcOpts) (*wshrpc.PlayerStatus, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.PlayerStatus](w, "playerload", data, opts)
+	return resp, err
+}
+
+// command "playerpause", wshserver.PlayerPauseCommand
+func PlayerPauseCommand(w *wshutil.WshRpc, data wshrpc.CommandPlayerData, opts *wshrpc.RpcOpts) (*wshrpc.PlayerStatus, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.PlayerStatus](w, "playerpause", data, opts)
+	return resp, err
+}
+
+// command "playerplay", wshserver.PlayerPlayCommand
+func PlayerPlayCommand(w *wshutil.WshRpc, data wshrpc.CommandPlayerData, opts *wshrpc.RpcOpts) (*wshrpc.PlayerStatus, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.PlayerStatus](w, "playerplay", data, opts)
+	return resp, err
+}
+
+// command "playerseek", wshserver.PlayerSeekCommand
+func PlayerSeekCommand(w *wshutil.WshRpc, data wshrpc.CommandPlayerSeekData, opts *wshrpc.RpcOpts) (*wshrpc.PlayerStatus, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.PlayerStatus](w, "playerseek", data, opts)
+	return resp, err
+}
+
+// command "playersetspeed", wshserver.PlayerSetSpeedCommand
+func PlayerSetSpeedCommand(w *wshutil.WshRpc, data wshrpc.CommandPlayerSetSpeedData, opts *wshrpc.RpcOpts) (*wshrpc.PlayerStatus, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.PlayerStatus](w, "playersetspeed", data, opts)
+	return resp, err
+}
+
+// command "pluginblockevent", wshserver.PluginBlockEventCommand
+func PluginBlockEventCommand(w *wshutil.WshRpc, data wshrpc.PluginBlockEvent, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "pluginblockevent", data, opts)
+	return err
+}
+
+// command "plugincall", wshserver.PluginCallCommand
+func PluginCallCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginCallData, opts *wshrpc.RpcOpts) (interface {}, error) {
+	resp, err := sendRpcRequestCallHelper[interface {}](w, "plugincall", data, opts)
+	return resp, err
+}
+
+// command "plugingetmeta", wshserver.PluginGetMetaCommand
+func PluginGetMetaCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginBlockData, opts *wshrpc.RpcOpts) (waveobj.MetaMapType, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.MetaMapType](w, "plugingetmeta", data, opts)
+	return resp, err
+}
+
+// command "pluginhandler", wshserver.PluginHandlerCommand
+func PluginHandlerCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginCallData, opts *wshrpc.RpcOpts) (interface {}, error) {
+	resp, err := sendRpcRequestCallHelper[interface {}](w, "pluginhandler", data, opts)
+	return resp, err
+}
+
+// command "pluginlist", wshserver.PluginListCommand
+func PluginListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.PluginInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.PluginInfo](w, "pluginlist", nil, opts)
+	return resp, err
+}
+
+// command "pluginreadfile", wshserver.PluginReadFileCommand
+func PluginReadFileCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginFileData, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "pluginreadfile", data, opts)
+	return resp, err
+}
+
+// command "pluginregister", wshserver.PluginRegisterCommand
+func PluginRegisterCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginRegisterData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "pluginregister", data, opts)
+	return err
+}
+
+// command "pluginrevoke", wshserver.PluginRevokeCommand
+func PluginRevokeCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginRevokeData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "pluginrevoke", data, opts)
+	return err
+}
+
+// command "pluginsetmeta", wshserver.PluginSetMetaCommand
+func PluginSetMetaCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginSetMetaData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "pluginsetmeta", data, opts)
+	return err
+}
+
+// command "plugintokenissue", wshserver.PluginTokenIssueCommand
+func PluginTokenIssueCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginTokenIssueData, opts *wshrpc.RpcOpts) (*wshrpc.PluginTokenRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.PluginTokenRtnData](w, "plugintokenissue", data, opts)
+	return resp, err
+}
+
+// command "pluginwritefile", wshserver.PluginWriteFileCommand
+func PluginWriteFileCommand(w *wshutil.WshRpc, data wshrpc.CommandPluginFileData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "pluginwritefile", data, opts)
+	return err
+}
+
+// command "portforwardcreate", wshserver.PortForwardCreateCommand
+func PortForwardCreateCommand(w *wshutil.WshRpc, data wshrpc.CommandPortForwardCreateData, opts *wshrpc.RpcOpts) (*wshrpc.PortForwardInfo, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.PortForwardInfo](w, "portforwardcreate", data, opts)
+	return resp, err
+}
+
+// command "portforwardlist", wshserver.PortForwardListCommand
+func PortForwardListCommand(w *wshutil.WshRpc, data wshrpc.CommandPortForwardListData, opts *wshrpc.RpcOpts) ([]wshrpc.PortForwardInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.PortForwardInfo](w, "portforwardlist", data, opts)
+	return resp, err
+}
+
+// command "portforwardstop", wshserver.PortForwardStopCommand
+func PortForwardStopCommand(w *wshutil.WshRpc, data wshrpc.CommandPortForwardStopData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "portforwardstop", data, opts)
+	return err
+}
+
+// command "profiledelete", wshserver.ProfileDeleteCommand
+func ProfileDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandProfileData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "profiledelete", data, opts)
+	return err
+}
+
+// command "profilelist", wshserver.ProfileListCommand
+func ProfileListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (*wshrpc.ProfileListData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ProfileListData](w, "profilelist", nil, opts)
+	return resp, err
+}
+
+// command "profilesave", wshserver.ProfileSaveCommand
+func ProfileSaveCommand(w *wshutil.WshRpc, data waveobj.Profile, opts *wshrpc.RpcOpts) (*waveobj.Profile, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Profile](w, "profilesave", data, opts)
+	return resp, err
+}
+
+// command "profileswitch", wshserver.ProfileSwitchCommand
+func ProfileSwitchCommand(w *wshutil.WshRpc, data wshrpc.CommandProfileData, opts *wshrpc.RpcOpts) (*waveobj.Profile, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Profile](w, "profileswitch", data, opts)
+	return resp, err
+}
+
 // command "recordtevent", wshserver.RecordTEventCommand
 func RecordTEventCommand(w *wshutil.WshRpc, data telemetrydata.TEvent, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "recordtevent", data, opts)
@@ -414,6 +854,42 @@ func RemoteMkdirCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) er
 	return err
 }
 
+// command "remoteptystart", wshserver.RemotePtyStartCommand
+func RemotePtyStartCommand(w *wshutil.WshRpc, data wshrpc.CommandRemotePtyStartData, opts *wshrpc.RpcOpts) (*wshrpc.RemotePtyInfo, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.RemotePtyInfo](w, "remoteptystart", data, opts)
+	return resp, err
+}
+
+// command "remoterevoke", wshserver.RemoteRevokeCommand
+func RemoteRevokeCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteRevokeData, opts *wshrpc.RpcOpts) (int, error) {
+	resp, err := sendRpcRequestCallHelper[int](w, "remoterevoke", data, opts)
+	return resp, err
+}
+
+// command "remotesessions", wshserver.RemoteSessionsCommand
+func RemoteSessionsCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.RemoteSessionInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.RemoteSessionInfo](w, "remotesessions", nil, opts)
+	return resp, err
+}
+
+// command "remotesetpassword", wshserver.RemoteSetPasswordCommand
+func RemoteSetPasswordCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteSetPasswordData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remotesetpassword", data, opts)
+	return err
+}
+
+// command "remotesharecreate", wshserver.RemoteShareCreateCommand
+func RemoteShareCreateCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteShareCreateData, opts *wshrpc.RpcOpts) (*wshrpc.RemoteShareInfo, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.RemoteShareInfo](w, "remotesharecreate", data, opts)
+	return resp, err
+}
+
+// command "remotesharelist", wshserver.RemoteShareListCommand
+func RemoteShareListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.RemoteShareInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.RemoteShareInfo](w, "remotesharelist", nil, opts)
+	return resp, err
+}
+
 // command "remotestreamcpudata", wshserver.RemoteStreamCpuDataCommand
 func RemoteStreamCpuDataCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
 	return sendRpcRequestResponseStreamHelper[wshrpc.TimeSeriesData](w, "remotestreamcpudata", nil, opts)
@@ -435,6 +911,12 @@ func RemoteWriteFileCommand(w *wshutil.WshRpc, data wshrpc.FileData, opts *wshrp
 	return err
 }
 
+// command "rerunblock", wshserver.RerunBlockCommand
+func RerunBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandRerunBlockData, opts *wshrpc.RpcOpts) (waveobj.ORef, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.ORef](w, "rerunblock", data, opts)
+	return resp, err
+}
+
 // command "resolveids", wshserver.ResolveIdsCommand
 func ResolveIdsCommand(w *wshutil.WshRpc, data wshrpc.CommandResolveIdsData, opts *wshrpc.RpcOpts) (wshrpc.CommandResolveIdsRtnData, error) {
 	resp, err := sendRpcRequestCallHelper[wshrpc.CommandResolveIdsRtnData](w, "resolveids", data, opts)
@@ -453,6 +935,30 @@ func RouteUnannounceCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
 	return err
 }
 
+// command "saveenvsnapshot", wshserver.SaveEnvSnapshotCommand
+func SaveEnvSnapshotCommand(w *wshutil.WshRpc, data wshrpc.CommandSaveEnvSnapshotData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "saveenvsnapshot", data, opts)
+	return err
+}
+
+// command "secretdelete", wshserver.SecretDeleteCommand
+func SecretDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandSecretData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "secretdelete", data, opts)
+	return err
+}
+
+// command "secretlist", wshserver.SecretListCommand
+func SecretListCommand(w *wshutil.WshRpc, data wshrpc.CommandSecretListData, opts *wshrpc.RpcOpts) ([]wshrpc.SecretInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.SecretInfo](w, "secretlist", data, opts)
+	return resp, err
+}
+
+// command "secretset", wshserver.SecretSetCommand
+func SecretSetCommand(w *wshutil.WshRpc, data wshrpc.CommandSecretSetData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "secretset", data, opts)
+	return err
+}
+
 // command "sendtelemetry", wshserver.SendTelemetryCommand
 func SendTelemetryCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "sendtelemetry", nil, opts)
@@ -477,6 +983,42 @@ func SetMetaCommand(w *wshutil.WshRpc, data wshrpc.CommandSetMetaData, opts *wsh
 	return err
 }
 
+// command "settingsexplain", wshserver.SettingsExplainCommand
+func SettingsExplainCommand(w *wshutil.WshRpc, data wshrpc.CommandSettingsExplainData, opts *wshrpc.RpcOpts) ([]wshrpc.SettingValueInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.SettingValueInfo](w, "settingsexplain", data, opts)
+	return resp, err
+}
+
+// command "settingsget", wshserver.SettingsGetCommand
+func SettingsGetCommand(w *wshutil.WshRpc, data wshrpc.CommandSettingsData, opts *wshrpc.RpcOpts) (waveobj.MetaMapType, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.MetaMapType](w, "settingsget", data, opts)
+	return resp, err
+}
+
+// command "settingsimport", wshserver.SettingsImportCommand
+func SettingsImportCommand(w *wshutil.WshRpc, data wshrpc.CommandSettingsImportData, opts *wshrpc.RpcOpts) (*wshrpc.SettingsImportResult, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.SettingsImportResult](w, "settingsimport", data, opts)
+	return resp, err
+}
+
+// command "settingsmigrate", wshserver.SettingsMigrateCommand
+func SettingsMigrateCommand(w *wshutil.WshRpc, data wshrpc.CommandSettingsMigrateData, opts *wshrpc.RpcOpts) ([]wconfig.SettingsMigration, error) {
+	resp, err := sendRpcRequestCallHelper[[]wconfig.SettingsMigration](w, "settingsmigrate", data, opts)
+	return resp, err
+}
+
+// command "settingsresolve", wshserver.SettingsResolveCommand
+func SettingsResolveCommand(w *wshutil.WshRpc, data wshrpc.CommandSettingsData, opts *wshrpc.RpcOpts) (waveobj.MetaMapType, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.MetaMapType](w, "settingsresolve", data, opts)
+	return resp, err
+}
+
+// command "settingsset", wshserver.SettingsSetCommand
+func SettingsSetCommand(w *wshutil.WshRpc, data wshrpc.CommandSettingsSetData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "settingsset", data, opts)
+	return err
+}
+
 // command "setvar", wshserver.SetVarCommand
 func SetVarCommand(w *wshutil.WshRpc, data wshrpc.CommandVarData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "setvar", data, opts)
@@ -489,6 +1031,93 @@ func SetViewCommand(w *wshutil.WshRpc, data wshrpc.CommandBlockSetViewData, opts
 	return err
 }
 
+// command "sftpcopy", wshserver.SftpCopyCommand
+func SftpCopyCommand(w *wshutil.WshRpc, data wshrpc.CommandSftpCopyData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.SftpTransferProgress](w, "sftpcopy", data, opts)
+}
+
+// command "sftpdownload", wshserver.SftpDownloadCommand
+func SftpDownloadCommand(w *wshutil.WshRpc, data wshrpc.CommandSftpTransferData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.SftpTransferProgress](w, "sftpdownload", data, opts)
+}
+
+// command "sftpupload", wshserver.SftpUploadCommand
+func SftpUploadCommand(w *wshutil.WshRpc, data wshrpc.CommandSftpTransferData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.SftpTransferProgress](w, "sftpupload", data, opts)
+}
+
+// command "shellintegrationcheck", wshserver.ShellIntegrationCheckCommand
+func ShellIntegrationCheckCommand(w *wshutil.WshRpc, data wshrpc.CommandShellIntegrationCheckData, opts *wshrpc.RpcOpts) (*wshrpc.ShellIntegrationStatus, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ShellIntegrationStatus](w, "shellintegrationcheck", data, opts)
+	return resp, err
+}
+
+// command "sshkeyaddtoagent", wshserver.SshKeyAddToAgentCommand
+func SshKeyAddToAgentCommand(w *wshutil.WshRpc, data wshrpc.CommandSshKeyAddToAgentData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "sshkeyaddtoagent", data, opts)
+	return err
+}
+
+// command "sshkeygenerate", wshserver.SshKeyGenerateCommand
+func SshKeyGenerateCommand(w *wshutil.WshRpc, data wshrpc.CommandSshKeyGenerateData, opts *wshrpc.RpcOpts) (*wshrpc.SshKeyInfo, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.SshKeyInfo](w, "sshkeygenerate", data, opts)
+	return resp, err
+}
+
+// command "sshkeylist", wshserver.SshKeyListCommand
+func SshKeyListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.SshKeyInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.SshKeyInfo](w, "sshkeylist", nil, opts)
+	return resp, err
+}
+
+// command "storagecache", wshserver.StorageCacheCommand
+func StorageCacheCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (*wshrpc.StorageCacheStats, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.StorageCacheStats](w, "storagecache", nil, opts)
+	return resp, err
+}
+
+// command "storagecheck", wshserver.StorageCheckCommand
+func StorageCheckCommand(w *wshutil.WshRpc, data wshrpc.CommandStorageCheckData, opts *wshrpc.RpcOpts) (*wshrpc.StorageCheckResult, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.StorageCheckResult](w, "storagecheck", data, opts)
+	return resp, err
+}
+
+// command "storageprune", wshserver.StoragePruneCommand
+func StoragePruneCommand(w *wshutil.WshRpc, data wshrpc.CommandStoragePruneData, opts *wshrpc.RpcOpts) ([]wshrpc.RetentionAction, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.RetentionAction](w, "storageprune", data, opts)
+	return resp, err
+}
+
+// command "storagereplicakey", wshserver.StorageReplicaKeyCommand
+func StorageReplicaKeyCommand(w *wshutil.WshRpc, data wshrpc.CommandStorageReplicaKeyData, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "storagereplicakey", data, opts)
+	return resp, err
+}
+
+// command "storagereplicalist", wshserver.StorageReplicaListCommand
+func StorageReplicaListCommand(w *wshutil.WshRpc, data wshrpc.CommandStorageReplicaListData, opts *wshrpc.RpcOpts) ([]wshrpc.ReplicaFileInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ReplicaFileInfo](w, "storagereplicalist", data, opts)
+	return resp, err
+}
+
+// command "storagereplicarestore", wshserver.StorageReplicaRestoreCommand
+func StorageReplicaRestoreCommand(w *wshutil.WshRpc, data wshrpc.CommandStorageReplicaRestoreData, opts *wshrpc.RpcOpts) ([]wshrpc.ReplicaFileInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ReplicaFileInfo](w, "storagereplicarestore", data, opts)
+	return resp, err
+}
+
+// command "storagereplicasync", wshserver.StorageReplicaSyncCommand
+func StorageReplicaSyncCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (*wshrpc.ReplicaSyncResult, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ReplicaSyncResult](w, "storagereplicasync", nil, opts)
+	return resp, err
+}
+
+// command "storageusage", wshserver.StorageUsageCommand
+func StorageUsageCommand(w *wshutil.WshRpc, data wshrpc.CommandStorageUsageData, opts *wshrpc.RpcOpts) ([]wshrpc.StorageUsage, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.StorageUsage](w, "storageusage", data, opts)
+	return resp, err
+}
+
 // command "streamcpudata", wshserver.StreamCpuDataCommand
 func StreamCpuDataCommand(w *wshutil.WshRpc, data wshrpc.CpuDataRequest, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
 	return sendRpcRequestResponseStreamHelper[wshrpc.TimeSeriesData](w, "streamcpudata", data, opts)
@@ -504,12 +1133,138 @@ func StreamWaveAiCommand(w *wshutil.WshRpc, data wshrpc.WaveAIStreamRequest, opt
 	return sendRpcRequestResponseStreamHelper[wshrpc.WaveAIPacketType](w, "streamwaveai", data, opts)
 }
 
+// command "termfontget", wshserver.TermFontGetCommand
+func TermFontGetCommand(w *wshutil.WshRpc, data wshrpc.CommandTermFontData, opts *wshrpc.RpcOpts) (*wshrpc.TermFontInfo, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.TermFontInfo](w, "termfontget", data, opts)
+	return resp, err
+}
+
+// command "termfontset", wshserver.TermFontSetCommand
+func TermFontSetCommand(w *wshutil.WshRpc, data wshrpc.CommandTermFontSetData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "termfontset", data, opts)
+	return err
+}
+
+// command "termgetlinks", wshserver.TermGetLinksCommand
+func TermGetLinksCommand(w *wshutil.WshRpc, data wshrpc.CommandTermGetLinksData, opts *wshrpc.RpcOpts) ([]wshrpc.TermLink, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.TermLink](w, "termgetlinks", data, opts)
+	return resp, err
+}
+
+// command "termgetsegmentoutput", wshserver.TermGetSegmentOutputCommand
+func TermGetSegmentOutputCommand(w *wshutil.WshRpc, data wshrpc.CommandTermGetSegmentOutputData, opts *wshrpc.RpcOpts) (*wshrpc.TermSegmentOutput, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.TermSegmentOutput](w, "termgetsegmentoutput", data, opts)
+	return resp, err
+}
+
+// command "termgetsegments", wshserver.TermGetSegmentsCommand
+func TermGetSegmentsCommand(w *wshutil.WshRpc, data wshrpc.CommandTermGetSegmentsData, opts *wshrpc.RpcOpts) ([]wshrpc.TermSegment, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.TermSegment](w, "termgetsegments", data, opts)
+	return resp, err
+}
+
+// command "termlistrecordings", wshserver.TermListRecordingsCommand
+func TermListRecordingsCommand(w *wshutil.WshRpc, data wshrpc.CommandTermRecordData, opts *wshrpc.RpcOpts) ([]wshrpc.TermRecording, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.TermRecording](w, "termlistrecordings", data, opts)
+	return resp, err
+}
+
+// command "termpanecreate", wshserver.TermPaneCreateCommand
+func TermPaneCreateCommand(w *wshutil.WshRpc, data wshrpc.CommandTermPaneCreateData, opts *wshrpc.RpcOpts) (*wshrpc.TermPaneInfo, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.TermPaneInfo](w, "termpanecreate", data, opts)
+	return resp, err
+}
+
+// command "termpanekill", wshserver.TermPaneKillCommand
+func TermPaneKillCommand(w *wshutil.WshRpc, data wshrpc.CommandTermPaneData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "termpanekill", data, opts)
+	return err
+}
+
+// command "termpanelist", wshserver.TermPaneListCommand
+func TermPaneListCommand(w *wshutil.WshRpc, data wshrpc.CommandTermPaneData, opts *wshrpc.RpcOpts) ([]wshrpc.TermPaneInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.TermPaneInfo](w, "termpanelist", data, opts)
+	return resp, err
+}
+
+// command "termpaneswitch", wshserver.TermPaneSwitchCommand
+func TermPaneSwitchCommand(w *wshutil.WshRpc, data wshrpc.CommandTermPaneData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "termpaneswitch", data, opts)
+	return err
+}
+
+// command "termqueueadd", wshserver.TermQueueAddCommand
+func TermQueueAddCommand(w *wshutil.WshRpc, data wshrpc.CommandTermQueueData, opts *wshrpc.RpcOpts) (*wshrpc.QueuedCommand, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.QueuedCommand](w, "termqueueadd", data, opts)
+	return resp, err
+}
+
+// command "termqueuecancel", wshserver.TermQueueCancelCommand
+func TermQueueCancelCommand(w *wshutil.WshRpc, data wshrpc.CommandTermQueueData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "termqueuecancel", data, opts)
+	return err
+}
+
+// command "termqueuelist", wshserver.TermQueueListCommand
+func TermQueueListCommand(w *wshutil.WshRpc, data wshrpc.CommandTermQueueData, opts *wshrpc.RpcOpts) ([]wshrpc.QueuedCommand, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.QueuedCommand](w, "termqueuelist", data, opts)
+	return resp, err
+}
+
+// command "termrecordstart", wshserver.TermRecordStartCommand
+func TermRecordStartCommand(w *wshutil.WshRpc, data wshrpc.CommandTermRecordData, opts *wshrpc.RpcOpts) (*wshrpc.TermRecording, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.TermRecording](w, "termrecordstart", data, opts)
+	return resp, err
+}
+
+// command "termrecordstop", wshserver.TermRecordStopCommand
+func TermRecordStopCommand(w *wshutil.WshRpc, data wshrpc.CommandTermRecordData, opts *wshrpc.RpcOpts) (*wshrpc.TermRecording, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.TermRecording](w, "termrecordstop", data, opts)
+	return resp, err
+}
+
 // command "test", wshserver.TestCommand
 func TestCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "test", data, opts)
 	return err
 }
 
+// command "themeassign", wshserver.ThemeAssignCommand
+func ThemeAssignCommand(w *wshutil.WshRpc, data wshrpc.CommandThemeAssignData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "themeassign", data, opts)
+	return err
+}
+
+// command "themedelete", wshserver.ThemeDeleteCommand
+func ThemeDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandThemeData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "themedelete", data, opts)
+	return err
+}
+
+// command "themeget", wshserver.ThemeGetCommand
+func ThemeGetCommand(w *wshutil.WshRpc, data wshrpc.CommandThemeData, opts *wshrpc.RpcOpts) (*wconfig.TermThemeType, error) {
+	resp, err := sendRpcRequestCallHelper[*wconfig.TermThemeType](w, "themeget", data, opts)
+	return resp, err
+}
+
+// command "themeimport", wshserver.ThemeImportCommand
+func ThemeImportCommand(w *wshutil.WshRpc, data wshrpc.CommandThemeImportData, opts *wshrpc.RpcOpts) (*waveobj.Theme, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Theme](w, "themeimport", data, opts)
+	return resp, err
+}
+
+// command "themelist", wshserver.ThemeListCommand
+func ThemeListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.ThemeInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ThemeInfo](w, "themelist", nil, opts)
+	return resp, err
+}
+
+// command "themesave", wshserver.ThemeSaveCommand
+func ThemeSaveCommand(w *wshutil.WshRpc, data wshrpc.CommandThemeSaveData, opts *wshrpc.RpcOpts) (*waveobj.Theme, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Theme](w, "themesave", data, opts)
+	return resp, err
+}
+
 // command "vdomasyncinitiation", wshserver.VDomAsyncInitiationCommand
 func VDomAsyncInitiationCommand(w *wshutil.WshRpc, data vdom.VDomAsyncInitiationRequest, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "vdomasyncinitiation", data, opts)
@@ -544,6 +1299,42 @@ func WaveInfoCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (*wshrpc.WaveInfoD
 	return resp, err
 }
 
+// command "webhookcreate", wshserver.WebhookCreateCommand
+func WebhookCreateCommand(w *wshutil.WshRpc, data wshrpc.CommandWebhookCreateData, opts *wshrpc.RpcOpts) (*wshrpc.WebhookCreateRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.WebhookCreateRtnData](w, "webhookcreate", data, opts)
+	return resp, err
+}
+
+// command "webhookdelete", wshserver.WebhookDeleteCommand
+func WebhookDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandWebhookData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "webhookdelete", data, opts)
+	return err
+}
+
+// command "webhookdeliveries", wshserver.WebhookDeliveriesCommand
+func WebhookDeliveriesCommand(w *wshutil.WshRpc, data wshrpc.CommandWebhookData, opts *wshrpc.RpcOpts) ([]*waveobj.WebhookDelivery, error) {
+	resp, err := sendRpcRequestCallHelper[[]*waveobj.WebhookDelivery](w, "webhookdeliveries", data, opts)
+	return resp, err
+}
+
+// command "webhooklist", wshserver.WebhookListCommand
+func WebhookListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]*waveobj.Webhook, error) {
+	resp, err := sendRpcRequestCallHelper[[]*waveobj.Webhook](w, "webhooklist", nil, opts)
+	return resp, err
+}
+
+// command "webhooksetdisabled", wshserver.WebhookSetDisabledCommand
+func WebhookSetDisabledCommand(w *wshutil.WshRpc, data wshrpc.CommandWebhookData, opts *wshrpc.RpcOpts) (*waveobj.Webhook, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Webhook](w, "webhooksetdisabled", data, opts)
+	return resp, err
+}
+
+// command "webhooktest", wshserver.WebhookTestCommand
+func WebhookTestCommand(w *wshutil.WshRpc, data wshrpc.CommandWebhookData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "webhooktest", data, opts)
+	return err
+}
+
 // command "webselector", wshserver.WebSelectorCommand
 func WebSelectorCommand(w *wshutil.WshRpc, data wshrpc.CommandWebSelectorData, opts *wshrpc.RpcOpts) ([]string, error) {
 	resp, err := sendRpcRequestCallHelper[[]string](w, "webselector", data, opts)
@@ -568,12 +1359,30 @@ func WslDefaultDistroCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (string, e
 	return resp, err
 }
 
+// command "wsldistrolist", wshserver.WslDistroListCommand
+func WslDistroListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.WslDistroInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.WslDistroInfo](w, "wsldistrolist", nil, opts)
+	return resp, err
+}
+
 // command "wsllist", wshserver.WslListCommand
 func WslListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]string, error) {
 	resp, err := sendRpcRequestCallHelper[[]string](w, "wsllist", nil, opts)
 	return resp, err
 }
 
+// command "wslpath", wshserver.WslPathCommand
+func WslPathCommand(w *wshutil.WshRpc, data wshrpc.CommandWslPathData, opts *wshrpc.RpcOpts) (*wshrpc.WslPathResult, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.WslPathResult](w, "wslpath", data, opts)
+	return resp, err
+}
+
+// command "wslsetshell", wshserver.WslSetShellCommand
+func WslSetShellCommand(w *wshutil.WshRpc, data wshrpc.CommandWslSetShellData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "wslsetshell", data, opts)
+	return err
+}
+
 // command "wslstatus", wshserver.WslStatusCommand
 func WslStatusCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.ConnStatus, error) {
 	resp, err := sendRpcRequestCallHelper[[]wshrpc.ConnStatus](w, "wslstatus", nil, opts)
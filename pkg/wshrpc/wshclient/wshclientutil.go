@@ -5,6 +5,7 @@ package wshclient
 
 import (
 	"errors"
+	"sync/atomic"
 
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
@@ -62,8 +63,11 @@ func sendRpcRequestResponseStreamHelper[T any](w *wshutil.WshRpc, command string
 		rtnErr(respChan, err)
 		return respChan
 	}
+	var canceled atomic.Bool
 	opts.StreamCancelFn = func() {
-		// TODO coordinate the cancel with the for loop below
+		if canceled.Swap(true) {
+			return
+		}
 		reqHandler.SendCancel()
 	}
 	go func() {
@@ -76,6 +80,10 @@ func sendRpcRequestResponseStreamHelper[T any](w *wshutil.WshRpc, command string
 				break
 			}
 			resp, err := reqHandler.NextResponse()
+			if canceled.Load() {
+				// the caller canceled the stream, the closed response channel is not an error
+				break
+			}
 			if err != nil {
 				respChan <- wshrpc.RespOrErrorUnion[T]{Error: err}
 				break
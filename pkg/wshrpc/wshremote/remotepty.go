@@ -0,0 +1,47 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/ptyhost"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// persistent sessions on ssh connections run the shell in a ptyhost helper on the remote host (this executable with
+// ptyhost.HostArg), started here in its own session so it outlives connserver and the ssh connection.  wavesrv
+// reaches the helper's socket over the ssh connection (a streamlocal channel) and reattaches to it after a
+// reconnect or a restart.
+
+const RemotePtyHostDirName = "ptyhost"
+
+func (*ServerImpl) RemotePtyStartCommand(ctx context.Context, data wshrpc.CommandRemotePtyStartData) (*wshrpc.RemotePtyInfo, error) {
+	if data.TermSize.Rows <= 0 || data.TermSize.Cols <= 0 {
+		return nil, fmt.Errorf("invalid term size: %v", data.TermSize)
+	}
+	dirName := filepath.Join(wavebase.GetHomeDir(), wavebase.RemoteWaveHomeDirName, RemotePtyHostDirName)
+	err := os.MkdirAll(dirName, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the ptyhost directory: %w", err)
+	}
+	env := append([]string{"TERM=" + shellutil.DefaultTermType}, data.Env...)
+	spec, err := ptyhost.MakeShellCmdSpec(data.Cmd, env, data.TermSize.Rows, data.TermSize.Cols)
+	if err != nil {
+		return nil, err
+	}
+	// socket paths are kept short (unix socket paths are limited to ~104 bytes on macos)
+	sockPath := filepath.Join(dirName, uuid.New().String()[:8]+".sock")
+	hostPid, err := ptyhost.StartHost(sockPath, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &wshrpc.RemotePtyInfo{SockPath: sockPath, HostPid: hostPid}, nil
+}
@@ -853,6 +853,10 @@ func (*ServerImpl) RemoteInstallRcFilesCommand(ctx context.Context) error {
 	return wshutil.InstallRcFiles()
 }
 
+func (*ServerImpl) ShellIntegrationCheckCommand(ctx context.Context, data wshrpc.CommandShellIntegrationCheckData) (*wshrpc.ShellIntegrationStatus, error) {
+	return wshutil.CheckRcFiles(data.Repair)
+}
+
 func (*ServerImpl) FetchSuggestionsCommand(ctx context.Context, data wshrpc.FetchSuggestionsData) (*wshrpc.FetchSuggestionsResponse, error) {
 	return suggestion.FetchSuggestions(ctx, data)
 }
@@ -69,10 +69,12 @@ const (
 	Command_BlockInfo         = "blockinfo"
 	Command_CreateBlock       = "createblock"
 	Command_DeleteBlock       = "deleteblock"
+	Command_RerunBlock        = "rerunblock"
 
 	Command_FileWrite           = "filewrite"
 	Command_FileRead            = "fileread"
 	Command_FileReadStream      = "filereadstream"
+	Command_FileWatch           = "filewatch"
 	Command_FileMove            = "filemove"
 	Command_FileCopy            = "filecopy"
 	Command_FileStreamTar       = "filestreamtar"
@@ -80,6 +82,13 @@ const (
 	Command_FileAppendIJson     = "fileappendijson"
 	Command_FileJoin            = "filejoin"
 	Command_FileShareCapability = "filesharecapability"
+	Command_FileUploadChunk     = "fileuploadchunk"
+	Command_FileTransferStatus  = "filetransferstatus"
+	Command_FileTransferCancel  = "filetransfercancel"
+	Command_FileDownload        = "filedownload"
+	Command_FileImport          = "fileimport"
+	Command_FileExport          = "fileexport"
+	Command_FileReadRanges      = "filereadranges"
 
 	Command_EventPublish         = "eventpublish"
 	Command_EventRecv            = "eventrecv"
@@ -87,6 +96,7 @@ const (
 	Command_EventUnsub           = "eventunsub"
 	Command_EventUnsubAll        = "eventunsuball"
 	Command_EventReadHistory     = "eventreadhistory"
+	Command_EventReplay          = "eventreplay"
 	Command_StreamTest           = "streamtest"
 	Command_StreamWaveAi         = "streamwaveai"
 	Command_StreamCpuData        = "streamcpudata"
@@ -100,18 +110,21 @@ const (
 	Command_RemoteFileTouch      = "remotefiletouch"
 	Command_RemoteWriteFile      = "remotewritefile"
 
-	Command_RemoteFileDelete     = "remotefiledelete"
-	Command_RemoteFileJoin       = "remotefilejoin"
-	Command_WaveInfo             = "waveinfo"
-	Command_WshActivity          = "wshactivity"
-	Command_Activity             = "activity"
-	Command_GetVar               = "getvar"
-	Command_SetVar               = "setvar"
-	Command_RemoteMkdir          = "remotemkdir"
-	Command_RemoteGetInfo        = "remotegetinfo"
-	Command_RemoteInstallRcfiles = "remoteinstallrcfiles"
+	Command_RemoteFileDelete      = "remotefiledelete"
+	Command_RemoteFileJoin        = "remotefilejoin"
+	Command_WaveInfo              = "waveinfo"
+	Command_WshActivity           = "wshactivity"
+	Command_Activity              = "activity"
+	Command_GetVar                = "getvar"
+	Command_SetVar                = "setvar"
+	Command_RemoteMkdir           = "remotemkdir"
+	Command_RemoteGetInfo         = "remotegetinfo"
+	Command_RemoteInstallRcfiles  = "remoteinstallrcfiles"
+	Command_RemotePtyStart        = "remoteptystart"
+	Command_ShellIntegrationCheck = "shellintegrationcheck"
 
 	Command_ConnStatus       = "connstatus"
+	Command_ConnMetrics      = "connmetrics"
 	Command_WslStatus        = "wslstatus"
 	Command_ConnEnsure       = "connensure"
 	Command_ConnReinstallWsh = "connreinstallwsh"
@@ -121,6 +134,9 @@ const (
 	Command_ConnListAWS      = "connlistaws"
 	Command_WslList          = "wsllist"
 	Command_WslDefaultDistro = "wsldefaultdistro"
+	Command_WslDistroList    = "wsldistrolist"
+	Command_WslSetShell      = "wslsetshell"
+	Command_WslPath          = "wslpath"
 	Command_DismissWshFail   = "dismisswshfail"
 	Command_ConnUpdateWsh    = "updatewsh"
 
@@ -130,6 +146,8 @@ const (
 	Command_Notify           = "notify"
 	Command_FocusWindow      = "focuswindow"
 	Command_GetUpdateChannel = "getupdatechannel"
+	Command_ClipboardRead    = "clipboardread"
+	Command_ClipboardWrite   = "clipboardwrite"
 
 	Command_VDomCreateContext   = "vdomcreatecontext"
 	Command_VDomAsyncInitiation = "vdomasyncinitiation"
@@ -137,6 +155,155 @@ const (
 	Command_VDomUrlRequest      = "vdomurlrequest"
 
 	Command_AiSendMessage = "aisendmessage"
+
+	Command_PluginRegister   = "pluginregister"
+	Command_PluginList       = "pluginlist"
+	Command_PluginGetMeta    = "plugingetmeta"
+	Command_PluginSetMeta    = "pluginsetmeta"
+	Command_PluginReadFile   = "pluginreadfile"
+	Command_PluginWriteFile  = "pluginwritefile"
+	Command_PluginBlockEvent = "pluginblockevent"
+	Command_PluginTokenIssue = "plugintokenissue"
+	Command_PluginRevoke     = "pluginrevoke"
+	Command_PluginCall       = "plugincall"
+	Command_PluginHandler    = "pluginhandler"
+
+	Command_ListActions   = "listactions"
+	Command_ExecuteAction = "executeaction"
+	Command_PaletteSearch = "palettesearch"
+
+	Command_TermGetSegments      = "termgetsegments"
+	Command_TermGetSegmentOutput = "termgetsegmentoutput"
+	Command_TermGetLinks         = "termgetlinks"
+
+	Command_TermRecordStart    = "termrecordstart"
+	Command_TermRecordStop     = "termrecordstop"
+	Command_TermListRecordings = "termlistrecordings"
+
+	Command_TermPaneCreate = "termpanecreate"
+	Command_TermPaneKill   = "termpanekill"
+	Command_TermPaneSwitch = "termpaneswitch"
+	Command_TermPaneList   = "termpanelist"
+
+	Command_TermQueueAdd    = "termqueueadd"
+	Command_TermQueueList   = "termqueuelist"
+	Command_TermQueueCancel = "termqueuecancel"
+
+	Command_PlayerLoad     = "playerload"
+	Command_PlayerPlay     = "playerplay"
+	Command_PlayerPause    = "playerpause"
+	Command_PlayerSeek     = "playerseek"
+	Command_PlayerSetSpeed = "playersetspeed"
+
+	Command_CmdHistorySearch = "cmdhistorysearch"
+	Command_CmdHistoryDelete = "cmdhistorydelete"
+
+	Command_WebhookCreate      = "webhookcreate"
+	Command_WebhookList        = "webhooklist"
+	Command_WebhookDelete      = "webhookdelete"
+	Command_WebhookSetDisabled = "webhooksetdisabled"
+	Command_WebhookTest        = "webhooktest"
+	Command_WebhookDeliveries  = "webhookdeliveries"
+
+	Command_NotificationSend    = "notificationsend"
+	Command_NotificationList    = "notificationlist"
+	Command_NotificationDismiss = "notificationdismiss"
+
+	Command_ConnectionCreate    = "connectioncreate"
+	Command_ConnectionUpdate    = "connectionupdate"
+	Command_ConnectionList      = "connectionlist"
+	Command_ConnectionDelete    = "connectiondelete"
+	Command_ConnectionOpenTerm  = "connectionopenterm"
+	Command_ConnectionSetKey    = "connectionsetkey"
+	Command_ConnectionRun       = "connectionrun"
+	Command_ConnectionRunStream = "connectionrunstream"
+
+	Command_SshKeyList       = "sshkeylist"
+	Command_SshKeyGenerate   = "sshkeygenerate"
+	Command_SshKeyAddToAgent = "sshkeyaddtoagent"
+	Command_HostKeyList      = "hostkeylist"
+	Command_HostKeyForget    = "hostkeyforget"
+
+	Command_PortForwardCreate = "portforwardcreate"
+	Command_PortForwardList   = "portforwardlist"
+	Command_PortForwardStop   = "portforwardstop"
+
+	Command_ConnDashboard = "conndashboard"
+
+	Command_ClusterExecStart  = "clusterexecstart"
+	Command_ClusterExecGet    = "clusterexecget"
+	Command_ClusterExecCancel = "clusterexeccancel"
+
+	Command_SecretSet    = "secretset"
+	Command_SecretList   = "secretlist"
+	Command_SecretDelete = "secretdelete"
+
+	Command_SftpUpload   = "sftpupload"
+	Command_SftpDownload = "sftpdownload"
+	Command_SftpCopy     = "sftpcopy"
+
+	Command_StorageUsage = "storageusage"
+	Command_FileSearch   = "filesearch"
+	Command_StorageCheck = "storagecheck"
+	Command_StoragePrune = "storageprune"
+	Command_StorageCache = "storagecache"
+
+	Command_StorageReplicaSync    = "storagereplicasync"
+	Command_StorageReplicaList    = "storagereplicalist"
+	Command_StorageReplicaRestore = "storagereplicarestore"
+	Command_StorageReplicaKey     = "storagereplicakey"
+
+	Command_RemoteSetPassword = "remotesetpassword"
+	Command_RemoteSessions    = "remotesessions"
+	Command_RemoteRevoke      = "remoterevoke"
+	Command_RemoteShareCreate = "remotesharecreate"
+	Command_RemoteShareList   = "remotesharelist"
+
+	Command_AuthTokenIssue  = "authtokenissue"
+	Command_AuthTokenRevoke = "authtokenrevoke"
+
+	Command_DetachBlock        = "detachblock"
+	Command_AttachBlock        = "attachblock"
+	Command_ListDetachedBlocks = "listdetachedblocks"
+
+	Command_ControllerOutputAck = "controlleroutputack"
+	Command_ControllerResize    = "controllerresize"
+	Command_ControllerSignal    = "controllersignal"
+
+	Command_ControllerProcessTree = "controllerprocesstree"
+	Command_ControllerPaste       = "controllerpaste"
+
+	Command_SaveEnvSnapshot = "saveenvsnapshot"
+	Command_GetEnvSnapshot  = "getenvsnapshot"
+
+	Command_SettingsGet     = "settingsget"
+	Command_SettingsSet     = "settingsset"
+	Command_SettingsResolve = "settingsresolve"
+	Command_SettingsExplain = "settingsexplain"
+	Command_SettingsMigrate = "settingsmigrate"
+	Command_SettingsImport  = "settingsimport"
+
+	Command_KeyBindingsList   = "keybindingslist"
+	Command_KeyBindingResolve = "keybindingresolve"
+
+	Command_ThemeList   = "themelist"
+	Command_ThemeGet    = "themeget"
+	Command_ThemeSave   = "themesave"
+	Command_ThemeImport = "themeimport"
+	Command_ThemeDelete = "themedelete"
+	Command_ThemeAssign = "themeassign"
+
+	Command_ProfileList   = "profilelist"
+	Command_ProfileSave   = "profilesave"
+	Command_ProfileDelete = "profiledelete"
+	Command_ProfileSwitch = "profileswitch"
+
+	Command_FontList    = "fontlist"
+	Command_TermFontGet = "termfontget"
+	Command_TermFontSet = "termfontset"
+
+	Command_I18nList    = "i18nlist"
+	Command_I18nCatalog = "i18ncatalog"
 )
 
 type RespOrErrorUnion[T any] struct {
@@ -159,11 +326,22 @@ type WshRpcInterface interface {
 	ControllerStopCommand(ctx context.Context, blockId string) error
 	ControllerResyncCommand(ctx context.Context, data CommandControllerResyncData) error
 	ControllerAppendOutputCommand(ctx context.Context, data CommandControllerAppendOutputData) error
+	ControllerOutputAckCommand(ctx context.Context, data CommandControllerOutputAckData) error
+	ControllerResizeCommand(ctx context.Context, data CommandControllerResizeData) error
+	ControllerSignalCommand(ctx context.Context, data CommandControllerSignalData) error
+	ControllerProcessTreeCommand(ctx context.Context, data CommandControllerProcessTreeData) ([]ProcessInfo, error)
+	ControllerPasteCommand(ctx context.Context, data CommandControllerPasteData) (*CommandControllerPasteRtnData, error)
 	ResolveIdsCommand(ctx context.Context, data CommandResolveIdsData) (CommandResolveIdsRtnData, error)
 	CreateBlockCommand(ctx context.Context, data CommandCreateBlockData) (waveobj.ORef, error)
 	CreateSubBlockCommand(ctx context.Context, data CommandCreateSubBlockData) (waveobj.ORef, error)
 	DeleteBlockCommand(ctx context.Context, data CommandDeleteBlockData) error
 	DeleteSubBlockCommand(ctx context.Context, data CommandDeleteBlockData) error
+	DetachBlockCommand(ctx context.Context, data CommandDetachBlockData) error
+	AttachBlockCommand(ctx context.Context, data CommandAttachBlockData) error
+	ListDetachedBlocksCommand(ctx context.Context) ([]DetachedBlockInfo, error)
+	SaveEnvSnapshotCommand(ctx context.Context, data CommandSaveEnvSnapshotData) error
+	GetEnvSnapshotCommand(ctx context.Context, blockId string) (*EnvSnapshot, error)
+	RerunBlockCommand(ctx context.Context, data CommandRerunBlockData) (waveobj.ORef, error)
 	WaitForRouteCommand(ctx context.Context, data CommandWaitForRouteData) (bool, error)
 
 	FileMkdirCommand(ctx context.Context, data FileData) error
@@ -174,6 +352,14 @@ type WshRpcInterface interface {
 	FileWriteCommand(ctx context.Context, data FileData) error
 	FileReadCommand(ctx context.Context, data FileData) (*FileData, error)
 	FileReadStreamCommand(ctx context.Context, data FileData) <-chan RespOrErrorUnion[FileData]
+	FileReadRangesCommand(ctx context.Context, data CommandFileReadRangesData) (*FileReadRangesData, error)
+	FileWatchCommand(ctx context.Context, data CommandFileWatchData) <-chan RespOrErrorUnion[FileWatchEvent]
+	FileUploadChunkCommand(ctx context.Context, data CommandFileUploadChunkData) (*FileTransferStatus, error)
+	FileTransferStatusCommand(ctx context.Context, data CommandFileTransferData) (*FileTransferStatus, error)
+	FileTransferCancelCommand(ctx context.Context, data CommandFileTransferData) error
+	FileDownloadCommand(ctx context.Context, data CommandFileTransferData) <-chan RespOrErrorUnion[FileTransferChunk]
+	FileImportCommand(ctx context.Context, data CommandFileImportData) (*FileInfo, error)
+	FileExportCommand(ctx context.Context, data CommandFileExportData) <-chan RespOrErrorUnion[FileExportProgress]
 	FileStreamTarCommand(ctx context.Context, data CommandRemoteStreamTarData) <-chan RespOrErrorUnion[iochantypes.Packet]
 	FileMoveCommand(ctx context.Context, data CommandFileCopyData) error
 	FileCopyCommand(ctx context.Context, data CommandFileCopyData) error
@@ -188,6 +374,7 @@ type WshRpcInterface interface {
 	EventUnsubCommand(ctx context.Context, data string) error
 	EventUnsubAllCommand(ctx context.Context) error
 	EventReadHistoryCommand(ctx context.Context, data CommandEventReadHistoryData) ([]*wps.WaveEvent, error)
+	EventReplayCommand(ctx context.Context, data CommandEventReplayData) (*EventReplayRtnData, error)
 	StreamTestCommand(ctx context.Context) chan RespOrErrorUnion[int]
 	StreamWaveAiCommand(ctx context.Context, request WaveAIStreamRequest) chan RespOrErrorUnion[WaveAIPacketType]
 	StreamCpuDataCommand(ctx context.Context, request CpuDataRequest) chan RespOrErrorUnion[TimeSeriesData]
@@ -210,6 +397,7 @@ type WshRpcInterface interface {
 
 	// connection functions
 	ConnStatusCommand(ctx context.Context) ([]ConnStatus, error)
+	ConnMetricsCommand(ctx context.Context, data CommandConnMetricsData) ([]ConnMetrics, error)
 	WslStatusCommand(ctx context.Context) ([]ConnStatus, error)
 	ConnEnsureCommand(ctx context.Context, data ConnExtData) error
 	ConnReinstallWshCommand(ctx context.Context, data ConnExtData) error
@@ -219,6 +407,9 @@ type WshRpcInterface interface {
 	ConnListAWSCommand(ctx context.Context) ([]string, error)
 	WslListCommand(ctx context.Context) ([]string, error)
 	WslDefaultDistroCommand(ctx context.Context) (string, error)
+	WslDistroListCommand(ctx context.Context) ([]WslDistroInfo, error)
+	WslSetShellCommand(ctx context.Context, data CommandWslSetShellData) error
+	WslPathCommand(ctx context.Context, data CommandWslPathData) (*WslPathResult, error)
 	DismissWshFailCommand(ctx context.Context, connName string) error
 	ConnUpdateWshCommand(ctx context.Context, remoteInfo RemoteInfo) (bool, error)
 
@@ -240,11 +431,15 @@ type WshRpcInterface interface {
 	RemoteStreamCpuDataCommand(ctx context.Context) chan RespOrErrorUnion[TimeSeriesData]
 	RemoteGetInfoCommand(ctx context.Context) (RemoteInfo, error)
 	RemoteInstallRcFilesCommand(ctx context.Context) error
+	RemotePtyStartCommand(ctx context.Context, data CommandRemotePtyStartData) (*RemotePtyInfo, error)
+	ShellIntegrationCheckCommand(ctx context.Context, data CommandShellIntegrationCheckData) (*ShellIntegrationStatus, error)
 
 	// emain
 	WebSelectorCommand(ctx context.Context, data CommandWebSelectorData) ([]string, error)
 	NotifyCommand(ctx context.Context, notificationOptions WaveNotificationOptions) error
 	FocusWindowCommand(ctx context.Context, windowId string) error
+	ClipboardReadCommand(ctx context.Context) (string, error)
+	ClipboardWriteCommand(ctx context.Context, data CommandClipboardWriteData) error
 
 	WorkspaceListCommand(ctx context.Context) ([]WorkspaceInfoData, error)
 	GetUpdateChannelCommand(ctx context.Context) (string, error)
@@ -259,6 +454,166 @@ type WshRpcInterface interface {
 	// proc
 	VDomRenderCommand(ctx context.Context, data vdom.VDomFrontendUpdate) chan RespOrErrorUnion[*vdom.VDomBackendUpdate]
 	VDomUrlRequestCommand(ctx context.Context, data VDomUrlRequestData) chan RespOrErrorUnion[VDomUrlRequestResponse]
+
+	// plugins
+	PluginRegisterCommand(ctx context.Context, data CommandPluginRegisterData) error
+	PluginListCommand(ctx context.Context) ([]PluginInfo, error)
+	PluginGetMetaCommand(ctx context.Context, data CommandPluginBlockData) (waveobj.MetaMapType, error)
+	PluginSetMetaCommand(ctx context.Context, data CommandPluginSetMetaData) error
+	PluginReadFileCommand(ctx context.Context, data CommandPluginFileData) (string, error)
+	PluginWriteFileCommand(ctx context.Context, data CommandPluginFileData) error
+	PluginBlockEventCommand(ctx context.Context, data PluginBlockEvent) error // sent from wavesrv to the plugin
+	PluginTokenIssueCommand(ctx context.Context, data CommandPluginTokenIssueData) (*PluginTokenRtnData, error)
+	PluginRevokeCommand(ctx context.Context, data CommandPluginRevokeData) error
+	PluginCallCommand(ctx context.Context, data CommandPluginCallData) (any, error)
+	PluginHandlerCommand(ctx context.Context, data CommandPluginCallData) (any, error) // sent from wavesrv to the plugin
+
+	// actions
+	ListActionsCommand(ctx context.Context, data CommandListActionsData) ([]ActionDef, error)
+	ExecuteActionCommand(ctx context.Context, data CommandExecuteActionData) error
+	PaletteSearchCommand(ctx context.Context, data CommandPaletteSearchData) ([]PaletteItem, error)
+
+	// term segments
+	TermGetSegmentsCommand(ctx context.Context, data CommandTermGetSegmentsData) ([]TermSegment, error)
+	TermGetSegmentOutputCommand(ctx context.Context, data CommandTermGetSegmentOutputData) (*TermSegmentOutput, error)
+	TermGetLinksCommand(ctx context.Context, data CommandTermGetLinksData) ([]TermLink, error)
+
+	// term recordings
+	TermRecordStartCommand(ctx context.Context, data CommandTermRecordData) (*TermRecording, error)
+	TermRecordStopCommand(ctx context.Context, data CommandTermRecordData) (*TermRecording, error)
+	TermListRecordingsCommand(ctx context.Context, data CommandTermRecordData) ([]TermRecording, error)
+
+	// term panes
+	TermPaneCreateCommand(ctx context.Context, data CommandTermPaneCreateData) (*TermPaneInfo, error)
+	TermPaneKillCommand(ctx context.Context, data CommandTermPaneData) error
+	TermPaneSwitchCommand(ctx context.Context, data CommandTermPaneData) error
+	TermPaneListCommand(ctx context.Context, data CommandTermPaneData) ([]TermPaneInfo, error)
+
+	// term command queue
+	TermQueueAddCommand(ctx context.Context, data CommandTermQueueData) (*QueuedCommand, error)
+	TermQueueListCommand(ctx context.Context, data CommandTermQueueData) ([]QueuedCommand, error)
+	TermQueueCancelCommand(ctx context.Context, data CommandTermQueueData) error
+
+	// client auth tokens (electron only)
+	AuthTokenIssueCommand(ctx context.Context, data CommandAuthTokenData) (*AuthTokenRtnData, error)
+	AuthTokenRevokeCommand(ctx context.Context, data CommandAuthTokenData) error
+
+	// player view
+	PlayerLoadCommand(ctx context.Context, data CommandPlayerLoadData) (*PlayerStatus, error)
+	PlayerPlayCommand(ctx context.Context, data CommandPlayerData) (*PlayerStatus, error)
+	PlayerPauseCommand(ctx context.Context, data CommandPlayerData) (*PlayerStatus, error)
+	PlayerSeekCommand(ctx context.Context, data CommandPlayerSeekData) (*PlayerStatus, error)
+	PlayerSetSpeedCommand(ctx context.Context, data CommandPlayerSetSpeedData) (*PlayerStatus, error)
+
+	// command history
+	CmdHistorySearchCommand(ctx context.Context, data CommandCmdHistorySearchData) ([]*CmdHistoryEntry, error)
+	CmdHistoryDeleteCommand(ctx context.Context, historyIds []string) error
+
+	// webhooks
+	WebhookCreateCommand(ctx context.Context, data CommandWebhookCreateData) (*WebhookCreateRtnData, error)
+	WebhookListCommand(ctx context.Context) ([]*waveobj.Webhook, error)
+	WebhookDeleteCommand(ctx context.Context, data CommandWebhookData) error
+	WebhookSetDisabledCommand(ctx context.Context, data CommandWebhookData) (*waveobj.Webhook, error)
+	WebhookTestCommand(ctx context.Context, data CommandWebhookData) error
+	WebhookDeliveriesCommand(ctx context.Context, data CommandWebhookData) ([]*waveobj.WebhookDelivery, error)
+
+	// notifications
+	NotificationSendCommand(ctx context.Context, data CommandNotificationSendData) (*waveobj.Notification, error)
+	NotificationListCommand(ctx context.Context) ([]*waveobj.Notification, error)
+	NotificationDismissCommand(ctx context.Context, data CommandNotificationDismissData) (int, error)
+
+	// saved connections
+	ConnectionCreateCommand(ctx context.Context, data waveobj.Connection) (*waveobj.Connection, error)
+	ConnectionUpdateCommand(ctx context.Context, data waveobj.Connection) (*waveobj.Connection, error)
+	ConnectionListCommand(ctx context.Context, data CommandConnectionListData) ([]*ConnectionInfo, error)
+	ConnectionDeleteCommand(ctx context.Context, data CommandConnectionData) error
+	ConnectionOpenTermCommand(ctx context.Context, data CommandConnectionData) (*waveobj.ORef, error)
+	ConnectionSetKeyCommand(ctx context.Context, data CommandConnectionSetKeyData) (*waveobj.Connection, error)
+	ConnectionRunCommand(ctx context.Context, data CommandConnectionRunData) (*ConnectionRunResult, error)
+	ConnectionRunStreamCommand(ctx context.Context, data CommandConnectionRunData) <-chan RespOrErrorUnion[ConnectionRunOutput]
+
+	// ssh keys (in ~/.ssh on the machine running wave)
+	SshKeyListCommand(ctx context.Context) ([]SshKeyInfo, error)
+	SshKeyGenerateCommand(ctx context.Context, data CommandSshKeyGenerateData) (*SshKeyInfo, error)
+	SshKeyAddToAgentCommand(ctx context.Context, data CommandSshKeyAddToAgentData) error
+	HostKeyListCommand(ctx context.Context) ([]KnownHostKey, error)
+	HostKeyForgetCommand(ctx context.Context, data CommandHostKeyForgetData) (int, error)
+
+	// port forwards on saved connections
+	PortForwardCreateCommand(ctx context.Context, data CommandPortForwardCreateData) (*PortForwardInfo, error)
+	PortForwardListCommand(ctx context.Context, data CommandPortForwardListData) ([]PortForwardInfo, error)
+	PortForwardStopCommand(ctx context.Context, data CommandPortForwardStopData) error
+
+	// the connections view
+	ConnDashboardCommand(ctx context.Context) ([]ConnDashboardEntry, error)
+
+	// a command run on many connections (the results view)
+	ClusterExecStartCommand(ctx context.Context, data CommandClusterExecData) (*ClusterExecRun, error)
+	ClusterExecGetCommand(ctx context.Context, data CommandClusterExecRunData) (*ClusterExecRun, error)
+	ClusterExecCancelCommand(ctx context.Context, data CommandClusterExecRunData) error
+
+	// secrets of the connections (the values are never returned)
+	SecretSetCommand(ctx context.Context, data CommandSecretSetData) error
+	SecretListCommand(ctx context.Context, data CommandSecretListData) ([]SecretInfo, error)
+	SecretDeleteCommand(ctx context.Context, data CommandSecretData) error
+
+	// sftp transfers
+	SftpUploadCommand(ctx context.Context, data CommandSftpTransferData) <-chan RespOrErrorUnion[SftpTransferProgress]
+	SftpDownloadCommand(ctx context.Context, data CommandSftpTransferData) <-chan RespOrErrorUnion[SftpTransferProgress]
+	SftpCopyCommand(ctx context.Context, data CommandSftpCopyData) <-chan RespOrErrorUnion[SftpTransferProgress]
+
+	// storage quotas
+	StorageUsageCommand(ctx context.Context, data CommandStorageUsageData) ([]StorageUsage, error)
+	FileSearchCommand(ctx context.Context, data CommandFileSearchData) ([]FileSearchHit, error)
+	StorageCheckCommand(ctx context.Context, data CommandStorageCheckData) (*StorageCheckResult, error)
+	StoragePruneCommand(ctx context.Context, data CommandStoragePruneData) ([]RetentionAction, error)
+	StorageCacheCommand(ctx context.Context) (*StorageCacheStats, error)
+	StorageReplicaSyncCommand(ctx context.Context) (*ReplicaSyncResult, error)
+	StorageReplicaListCommand(ctx context.Context, data CommandStorageReplicaListData) ([]ReplicaFileInfo, error)
+	StorageReplicaRestoreCommand(ctx context.Context, data CommandStorageReplicaRestoreData) ([]ReplicaFileInfo, error)
+	StorageReplicaKeyCommand(ctx context.Context, data CommandStorageReplicaKeyData) (string, error)
+
+	// browser remote access
+	RemoteSetPasswordCommand(ctx context.Context, data CommandRemoteSetPasswordData) error
+	RemoteSessionsCommand(ctx context.Context) ([]RemoteSessionInfo, error)
+	RemoteRevokeCommand(ctx context.Context, data CommandRemoteRevokeData) (int, error)
+	RemoteShareCreateCommand(ctx context.Context, data CommandRemoteShareCreateData) (*RemoteShareInfo, error)
+	RemoteShareListCommand(ctx context.Context) ([]RemoteShareInfo, error)
+
+	// the settings of wave and of the workspaces (over settings.json)
+	SettingsGetCommand(ctx context.Context, data CommandSettingsData) (waveobj.MetaMapType, error)
+	SettingsSetCommand(ctx context.Context, data CommandSettingsSetData) error
+	SettingsResolveCommand(ctx context.Context, data CommandSettingsData) (waveobj.MetaMapType, error)
+	SettingsExplainCommand(ctx context.Context, data CommandSettingsExplainData) ([]SettingValueInfo, error)
+	SettingsMigrateCommand(ctx context.Context, data CommandSettingsMigrateData) ([]wconfig.SettingsMigration, error)
+	SettingsImportCommand(ctx context.Context, data CommandSettingsImportData) (*SettingsImportResult, error)
+
+	// key bindings (the default keymap with the overrides in app:keybindings)
+	KeyBindingsListCommand(ctx context.Context, data CommandSettingsData) (*KeyBindingsData, error)
+	KeyBindingResolveCommand(ctx context.Context, data CommandKeyBindingResolveData) (*KeyBindingResolveRtnData, error)
+
+	// terminal themes (the built-in ones, termthemes.json, and the ones saved in wave)
+	ThemeListCommand(ctx context.Context) ([]ThemeInfo, error)
+	ThemeGetCommand(ctx context.Context, data CommandThemeData) (*wconfig.TermThemeType, error)
+	ThemeSaveCommand(ctx context.Context, data CommandThemeSaveData) (*waveobj.Theme, error)
+	ThemeImportCommand(ctx context.Context, data CommandThemeImportData) (*waveobj.Theme, error)
+	ThemeDeleteCommand(ctx context.Context, data CommandThemeData) error
+	ThemeAssignCommand(ctx context.Context, data CommandThemeAssignData) error
+
+	// profiles (named sets of settings, theme, key bindings and templates, switched to as a whole)
+	ProfileListCommand(ctx context.Context) (*ProfileListData, error)
+	ProfileSaveCommand(ctx context.Context, data waveobj.Profile) (*waveobj.Profile, error)
+	ProfileDeleteCommand(ctx context.Context, data CommandProfileData) error
+	ProfileSwitchCommand(ctx context.Context, data CommandProfileData) (*waveobj.Profile, error)
+
+	// fonts (the ones installed, and the font of a terminal block with the one set for it)
+	FontListCommand(ctx context.Context) ([]FontInfo, error)
+	TermFontGetCommand(ctx context.Context, data CommandTermFontData) (*TermFontInfo, error)
+	TermFontSetCommand(ctx context.Context, data CommandTermFontSetData) error
+
+	// localization (the catalogs of the strings wavesrv generates, by locale)
+	I18nListCommand(ctx context.Context) ([]I18nCatalogInfo, error)
+	I18nCatalogCommand(ctx context.Context, data CommandI18nCatalogData) (*I18nCatalogData, error)
 }
 
 // for frontend
@@ -277,6 +632,7 @@ type RpcOpts struct {
 const (
 	ClientType_ConnServer      = "connserver"
 	ClientType_BlockController = "blockcontroller"
+	ClientType_Plugin          = "plugin"
 )
 
 type RpcContext struct {
@@ -284,6 +640,8 @@ type RpcContext struct {
 	BlockId    string `json:"blockid,omitempty"`
 	TabId      string `json:"tabid,omitempty"`
 	Conn       string `json:"conn,omitempty"`
+	PluginId   string `json:"pluginid,omitempty"`
+	TokenId    string `json:"tokenid,omitempty"` // plugin tokens, a plugin's token is invalid once a new one is issued
 }
 
 func HackRpcContextIntoData(dataPtr any, rpcContext RpcContext) {
@@ -368,6 +726,13 @@ type CommandCreateBlockData struct {
 	TargetAction  string               `json:"targetaction,omitempty"` // "replace", "splitright", "splitdown", "splitleft", "splitup"
 }
 
+type CommandRerunBlockData struct {
+	BlockId      string `json:"blockid" wshcontext:"BlockId"`
+	TabId        string `json:"tabid" wshcontext:"TabId"`
+	TargetAction string `json:"targetaction,omitempty"` // same as CommandCreateBlockData.TargetAction (defaults to "splitright")
+	Magnified    bool   `json:"magnified,omitempty"`
+}
+
 type CommandCreateSubBlockData struct {
 	ParentBlockId string            `json:"parentblockid"`
 	BlockDef      *waveobj.BlockDef `json:"blockdef"`
@@ -390,6 +755,56 @@ type CommandControllerAppendOutputData struct {
 	Data64  string `json:"data64"`
 }
 
+// sent by the terminal with the term file offset it has written up to (used for backpressure)
+type CommandControllerOutputAckData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Offset  int64  `json:"offset"`
+}
+
+// non-final resizes are debounced by the controller (only the last one is applied)
+type CommandControllerResizeData struct {
+	BlockId  string           `json:"blockid" wshcontext:"BlockId"`
+	TermSize waveobj.TermSize `json:"termsize"`
+	Final    bool             `json:"final,omitempty"`
+}
+
+// Signal is SIGINT, SIGTERM, SIGHUP, SIGKILL or SIGBREAK (windows), the SIG prefix is optional.
+// Pid signals a single process from the block's process tree instead of the foreground process group.
+type CommandControllerSignalData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Signal  string `json:"signal"`
+	Pid     int    `json:"pid,omitempty"`
+}
+
+type CommandControllerProcessTreeData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+}
+
+// a paste that needs confirmation (see Warnings) is not written, it is sent again with Confirm set
+type CommandControllerPasteData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Text    string `json:"text"`
+	Confirm bool   `json:"confirm,omitempty"`
+}
+
+type CommandControllerPasteRtnData struct {
+	Pasted         bool     `json:"pasted"`
+	NeedsConfirm   bool     `json:"needsconfirm,omitempty"`
+	Warnings       []string `json:"warnings,omitempty"`
+	BracketedPaste bool     `json:"bracketedpaste,omitempty"`
+}
+
+type ProcessInfo struct {
+	Pid        int     `json:"pid"`
+	ParentPid  int     `json:"parentpid"`
+	Depth      int     `json:"depth"` // 0 for the shell
+	Name       string  `json:"name"`
+	CmdLine    string  `json:"cmdline,omitempty"`
+	CpuPercent float64 `json:"cpupercent"`
+	MemRss     int64   `json:"memrss"`
+	CreateTs   int64   `json:"createts,omitempty"`
+}
+
 type CommandBlockInputData struct {
 	BlockId     string            `json:"blockid" wshcontext:"BlockId"`
 	InputData64 string            `json:"inputdata64,omitempty"`
@@ -398,8 +813,9 @@ type CommandBlockInputData struct {
 }
 
 type FileDataAt struct {
-	Offset int64 `json:"offset"`
-	Size   int   `json:"size,omitempty"`
+	Offset    int64 `json:"offset"`
+	Size      int   `json:"size,omitempty"`
+	ReadAhead int64 `json:"readahead,omitempty"` // wave files: a hint that the bytes after the read will be read next
 }
 
 type FileData struct {
@@ -433,6 +849,8 @@ type FileOpts struct {
 	IJsonBudget int   `json:"ijsonbudget,omitempty"`
 	Truncate    bool  `json:"truncate,omitempty"`
 	Append      bool  `json:"append,omitempty"`
+	LineIndex   bool  `json:"lineindex,omitempty"` // the filestore keeps a line index (see filestore.ReadLines)
+	TextIndex   bool  `json:"textindex,omitempty"` // the filestore indexes the text for search (see filestore.Search)
 }
 
 type FileMeta = map[string]any
@@ -471,12 +889,56 @@ type CommandDeleteBlockData struct {
 	BlockId string `json:"blockid" wshcontext:"BlockId"`
 }
 
+type CommandDetachBlockData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+}
+
+type CommandAttachBlockData struct {
+	BlockId string `json:"blockid"`
+	TabId   string `json:"tabid" wshcontext:"TabId"`
+}
+
+type DetachedBlockInfo struct {
+	BlockId           string `json:"blockid"`
+	View              string `json:"view,omitempty"`
+	Controller        string `json:"controller"`
+	ConnName          string `json:"connname,omitempty"`
+	Cwd               string `json:"cwd,omitempty"`
+	ShellProcStatus   string `json:"shellprocstatus,omitempty"` // empty if the controller is not running (e.g. after a wavesrv restart)
+	ShellProcExitCode int    `json:"shellprocexitcode"`
+}
+
+type CommandSaveEnvSnapshotData struct {
+	BlockId string            `json:"blockid" wshcontext:"BlockId"`
+	Cwd     string            `json:"cwd,omitempty"`
+	Env     map[string]string `json:"env"`
+}
+
+type EnvSnapshot struct {
+	ConnName string            `json:"connname,omitempty"`
+	Cwd      string            `json:"cwd,omitempty"`
+	Ts       int64             `json:"ts"`
+	Env      map[string]string `json:"env"`
+}
+
 type CommandEventReadHistoryData struct {
 	Event    string `json:"event"`
 	Scope    string `json:"scope"`
 	MaxItems int    `json:"maxitems"`
 }
 
+// subscribes to the events and returns the ones published after sinceseq (used when a client reconnects)
+type CommandEventReplayData struct {
+	SinceSeq      int64                     `json:"sinceseq"`
+	Subscriptions []wps.SubscriptionRequest `json:"subscriptions"`
+}
+
+type EventReplayRtnData struct {
+	Events   []*wps.WaveEvent `json:"events,omitempty"`
+	LastSeq  int64            `json:"lastseq"`
+	Complete bool             `json:"complete"` // false if the events are no longer buffered, the client must resync
+}
+
 type WaveAIStreamRequest struct {
 	ClientId string                    `json:"clientid,omitempty"`
 	Opts     *WaveAIOptsType           `json:"opts"`
@@ -539,6 +1001,95 @@ type CommandFileCopyData struct {
 	Opts    *FileCopyOpts `json:"opts,omitempty"`
 }
 
+// follows a wave file (wavefile:// uris only), the stream ends before the rpc times out (the client watches
+// again from the last offset)
+type CommandFileWatchData struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"` // negative for the end of the file
+}
+
+type FileWatchEvent struct {
+	Type   string `json:"type"` // "data", "truncate", "rotate" or "deleted" (see filestore.Watch)
+	Offset int64  `json:"offset"`
+	Data64 string `json:"data64,omitempty"`
+}
+
+// a chunk of an upload into a wave file, the chunks are sent in order (Offset is where the chunk goes).  the
+// upload is finished when Size bytes have been written.
+type CommandFileUploadChunkData struct {
+	Path       string `json:"path"`
+	TransferId string `json:"transferid"` // made by the client, the same id resumes the upload
+	Size       int64  `json:"size"`       // the size of the whole file
+	Offset     int64  `json:"offset"`
+	Data64     string `json:"data64,omitempty"`
+}
+
+type CommandFileTransferData struct {
+	Path       string `json:"path"`
+	TransferId string `json:"transferid"`
+	Offset     int64  `json:"offset,omitempty"` // for downloads, where to start
+}
+
+// the state of an upload, Offset is where the next chunk goes (a chunk at another offset is not written)
+type FileTransferStatus struct {
+	TransferId string `json:"transferid"`
+	Offset     int64  `json:"offset"`
+	Size       int64  `json:"size"`
+	Done       bool   `json:"done,omitempty"`
+}
+
+type FileTransferChunk struct {
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"` // the size of the file
+	Data64 string `json:"data64,omitempty"`
+}
+
+// reads several windows of a wave file at once (for viewers).  ReadAhead is a hint that the bytes after the last
+// window will be read next, they are read into a cache on the server (see filestore.ReadRanges).
+type CommandFileReadRangesData struct {
+	Path      string       `json:"path"`
+	Ranges    []FileDataAt `json:"ranges"`
+	ReadAhead int64        `json:"readahead,omitempty"`
+}
+
+type FileReadRangesData struct {
+	Info   *FileInfo       `json:"info"`
+	Ranges []FileRangeData `json:"ranges"` // in the order of the requested ranges
+}
+
+type FileRangeData struct {
+	Offset int64  `json:"offset"` // where the data starts (after the requested offset if the front of the file was dropped)
+	Data64 string `json:"data64,omitempty"`
+}
+
+// imports a local file (on the machine running wave) into a block file, see filestore.ImportFile
+type CommandFileImportData struct {
+	BlockId   string `json:"blockid"`
+	Path      string `json:"path"`           // absolute
+	Name      string `json:"name,omitempty"` // the base name of the path if empty
+	Reference bool   `json:"reference,omitempty"`
+	Overwrite bool   `json:"overwrite,omitempty"`
+}
+
+// exports a wave file (or all the files of a block, if the path has no file name) into a local directory (on the
+// machine running wave), see filestore.ExportFile
+type CommandFileExportData struct {
+	Path      string `json:"path"`
+	Dir       string `json:"dir"`                 // absolute
+	Collision string `json:"collision,omitempty"` // "rename" (the default), "overwrite" or "skip"
+}
+
+// the progress of an export, sent as the files are written (and when each file is done)
+type FileExportProgress struct {
+	Name      string `json:"name"`
+	LocalPath string `json:"localpath,omitempty"`
+	Offset    int64  `json:"offset"`
+	Size      int64  `json:"size"`
+	Done      bool   `json:"done,omitempty"`
+	Skipped   bool   `json:"skipped,omitempty"` // the local file exists (collision "skip")
+	Error     string `json:"error,omitempty"`
+}
+
 type CommandRemoteStreamTarData struct {
 	Path string        `json:"path"`
 	Opts *FileCopyOpts `json:"opts,omitempty"`
@@ -578,6 +1129,18 @@ type RemoteInfo struct {
 	Shell         string `json:"shell"`
 }
 
+// a persistent shell session on the remote host, the shell runs in a ptyhost helper started by connserver
+type CommandRemotePtyStartData struct {
+	Cmd      string           `json:"cmd"` // run with the login shell of the user, like the command of an ssh session
+	Env      []string         `json:"env,omitempty"`
+	TermSize waveobj.TermSize `json:"termsize"`
+}
+
+type RemotePtyInfo struct {
+	SockPath string `json:"sockpath"` // the unix socket of the helper on the remote host
+	HostPid  int    `json:"hostpid"`
+}
+
 const (
 	TimeSeries_Cpu = "cpu"
 )
@@ -622,6 +1185,66 @@ type ConnStatus struct {
 	WshError      string `json:"wsherror,omitempty"`
 	NoWshReason   string `json:"nowshreason,omitempty"`
 	WshVersion    string `json:"wshversion,omitempty"`
+	Attempt       int    `json:"attempt,omitempty"`     // the reconnect attempt (while reconnecting)
+	NextRetryTs   int64  `json:"nextretryts,omitempty"` // when the next reconnect attempt is made
+	LatencyMs     int64  `json:"latencyms,omitempty"`   // the round trip of the last keepalive probe
+}
+
+type CommandConnMetricsData struct {
+	Connection string `json:"connection,omitempty"` // a saved connection (id or name) or an ssh connection name, "" for all
+	NoHistory  bool   `json:"nohistory,omitempty"`
+}
+
+// the latency and the traffic of an ssh connection, with a short history (a sample every few seconds while it is
+// connected).  the latencies are the round trips of the keepalive probes.
+type ConnMetrics struct {
+	Connection        string              `json:"connection"`
+	Status            string              `json:"status"`
+	LatencyMs         int64               `json:"latencyms,omitempty"`         // the last probe
+	SmoothedLatencyMs int64               `json:"smoothedlatencyms,omitempty"` // like the srtt of tcp
+	MinLatencyMs      int64               `json:"minlatencyms,omitempty"`      // in the history
+	MaxLatencyMs      int64               `json:"maxlatencyms,omitempty"`
+	BytesIn           int64               `json:"bytesin"` // since wave started, including the ssh overhead
+	BytesOut          int64               `json:"bytesout"`
+	BytesInRate       int64               `json:"bytesinrate"` // bytes per second in the last sample
+	BytesOutRate      int64               `json:"bytesoutrate"`
+	Samples           []ConnMetricsSample `json:"samples,omitempty"` // oldest first
+}
+
+type ConnMetricsSample struct {
+	Ts         int64 `json:"ts"`
+	DurationMs int64 `json:"durationms"` // the time since the previous sample
+	BytesIn    int64 `json:"bytesin"`
+	BytesOut   int64 `json:"bytesout"`
+	LatencyMs  int64 `json:"latencyms,omitempty"` // the probes in the sample (the last one), 0 if there was none
+}
+
+// an installed wsl distro, and its connection ("wsl://<distro>")
+type WslDistroInfo struct {
+	Name      string     `json:"name"`
+	ConnName  string     `json:"connname"`
+	Default   bool       `json:"default,omitempty"`
+	State     string     `json:"state"`             // "running", "stopped", "installing" or "uninstalling"
+	Version   int        `json:"version,omitempty"` // 1 or 2
+	ShellPath string     `json:"shellpath,omitempty"`
+	Status    ConnStatus `json:"status"`
+}
+
+type CommandWslSetShellData struct {
+	Distro    string `json:"distro"`
+	ShellPath string `json:"shellpath,omitempty"` // not set for the login shell of the distro's user
+}
+
+// a windows path (C:\... or \\wsl.localhost\<distro>\...) to a wsl path, or a wsl path (of Distro) to a windows path
+type CommandWslPathData struct {
+	Path      string `json:"path"`
+	Distro    string `json:"distro,omitempty"`
+	ToWindows bool   `json:"towindows,omitempty"`
+}
+
+type WslPathResult struct {
+	Path   string `json:"path"`
+	Distro string `json:"distro,omitempty"` // the distro of a \\wsl.localhost path
 }
 
 type WebSelectorOpts struct {
@@ -637,6 +1260,10 @@ type CommandWebSelectorData struct {
 	Opts        *WebSelectorOpts `json:"opts,omitempty"`
 }
 
+type CommandClipboardWriteData struct {
+	Text string `json:"text"`
+}
+
 type BlockInfoData struct {
 	BlockId     string         `json:"blockid"`
 	TabId       string         `json:"tabid"`
@@ -779,3 +1406,950 @@ type FileShareCapability struct {
 	// CanMkdir indicates whether the file share supports creating directories
 	CanMkdir bool `json:"canmkdir"`
 }
+
+const (
+	PluginCap_MetaRead  = "meta:read"
+	PluginCap_MetaWrite = "meta:write"
+	PluginCap_FileRead  = "file:read"
+	PluginCap_FileWrite = "file:write"
+
+	// for external plugins (the blocks they can use are limited by their scope)
+	PluginCap_BlockInput  = "block:input"  // send input to blocks
+	PluginCap_BlockCreate = "block:create" // create, start, and delete blocks
+	PluginCap_Events      = "events"       // subscribe to the events of the objects in scope
+	PluginCap_PluginCall  = "plugin:call"  // call the handlers of other plugins
+
+	// register views, controllers, actions, handlers, and catalogs (the built-in views and controllers cannot
+	// be registered)
+	PluginCap_Register = "plugin:register"
+)
+
+var AllPluginCaps = []string{
+	PluginCap_MetaRead, PluginCap_MetaWrite, PluginCap_FileRead, PluginCap_FileWrite,
+	PluginCap_BlockInput, PluginCap_BlockCreate, PluginCap_Events, PluginCap_PluginCall, PluginCap_Register,
+}
+
+const (
+	PluginBlockEvent_Create = "create"
+	PluginBlockEvent_Resync = "resync"
+	PluginBlockEvent_Stop   = "stop"
+	PluginBlockEvent_Close  = "close"
+)
+
+type PluginViewDef struct {
+	View        string `json:"view"`
+	DisplayName string `json:"displayname,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	Controller  string `json:"controller,omitempty"` // default controller for new blocks of this view
+}
+
+type PluginInfo struct {
+	PluginId    string          `json:"pluginid"`
+	Name        string          `json:"name,omitempty"`
+	Version     string          `json:"version,omitempty"`
+	Caps        []string        `json:"caps,omitempty"`
+	Views       []PluginViewDef `json:"views,omitempty"`
+	Controllers []string        `json:"controllers,omitempty"`
+	Handlers    []string        `json:"handlers,omitempty"`
+	External    bool            `json:"external,omitempty"` // connected with a token from "wsh plugin token" (not launched by wave)
+	Scope       []string        `json:"scope,omitempty"`    // orefs (external plugins)
+	ExpiresTs   int64           `json:"expirests,omitempty"`
+	Connected   bool            `json:"connected"`
+}
+
+type CommandPluginRegisterData struct {
+	Views       []PluginViewDef              `json:"views,omitempty"`
+	Controllers []string                     `json:"controllers,omitempty"`
+	Actions     []ActionDef                  `json:"actions,omitempty"`
+	Handlers    []string                     `json:"handlers,omitempty"` // names other clients can call with plugincall
+	Catalogs    map[string]map[string]string `json:"catalogs,omitempty"` // locale => key => text (added to the i18n catalogs)
+}
+
+type CommandPluginTokenIssueData struct {
+	PluginId string   `json:"pluginid"`
+	Name     string   `json:"name,omitempty"`
+	Caps     []string `json:"caps,omitempty"`
+	Scope    []string `json:"scope,omitempty"` // orefs of the blocks, tabs, and workspaces the plugin can use
+	Hours    float64  `json:"hours,omitempty"`
+}
+
+type PluginTokenRtnData struct {
+	Token     string `json:"token"`
+	SockName  string `json:"sockname"`
+	ExpiresTs int64  `json:"expirests"`
+}
+
+type CommandPluginRevokeData struct {
+	PluginId string `json:"pluginid"`
+}
+
+type CommandPluginCallData struct {
+	PluginId string `json:"pluginid"`
+	Handler  string `json:"handler"`
+	BlockId  string `json:"blockid,omitempty"`
+	Data     any    `json:"data,omitempty"`
+}
+
+type CommandPluginBlockData struct {
+	BlockId string `json:"blockid"`
+}
+
+type CommandPluginSetMetaData struct {
+	BlockId string              `json:"blockid"`
+	Meta    waveobj.MetaMapType `json:"meta"`
+}
+
+type CommandPluginFileData struct {
+	BlockId  string `json:"blockid"`
+	FileName string `json:"filename"`
+	Data64   string `json:"data64,omitempty"`
+	Append   bool   `json:"append,omitempty"`
+}
+
+type PluginBlockEvent struct {
+	Event      string              `json:"event"`
+	BlockId    string              `json:"blockid"`
+	TabId      string              `json:"tabid,omitempty"`
+	View       string              `json:"view,omitempty"`
+	Controller string              `json:"controller,omitempty"`
+	Meta       waveobj.MetaMapType `json:"meta,omitempty"`
+}
+
+type ActionDef struct {
+	ActionId    string   `json:"actionid"`
+	Title       string   `json:"title"`
+	Icon        string   `json:"icon,omitempty"`
+	OTypes      []string `json:"otypes,omitempty"`      // empty means all otypes
+	Views       []string `json:"views,omitempty"`       // empty means all views (only checked for blocks)
+	Controllers []string `json:"controllers,omitempty"` // empty means all controllers (only checked for blocks)
+	Order       float64  `json:"order,omitempty"`
+	PluginId    string   `json:"pluginid,omitempty"` // set by the server for plugin actions
+}
+
+type CommandListActionsData struct {
+	ORef waveobj.ORef `json:"oref" wshcontext:"BlockORef"`
+}
+
+type CommandExecuteActionData struct {
+	ActionId string         `json:"actionid"`
+	ORef     waveobj.ORef   `json:"oref" wshcontext:"BlockORef"`
+	TabId    string         `json:"tabid,omitempty" wshcontext:"TabId"`
+	Args     map[string]any `json:"args,omitempty"`
+}
+
+type CommandPaletteSearchData struct {
+	Query string       `json:"query"`
+	ORef  waveobj.ORef `json:"oref"`            // the object to list the actions of (no actions if empty)
+	Types []string     `json:"types,omitempty"` // empty means all types
+	Limit int          `json:"limit,omitempty"`
+}
+
+type PaletteItem struct {
+	ItemId      string `json:"itemid"`
+	ItemType    string `json:"itemtype"`
+	Title       string `json:"title"`
+	SubText     string `json:"subtext,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	ORef        string `json:"oref,omitempty"`     // workspaces, tabs, blocks, and the object of an action
+	ActionId    string `json:"actionid,omitempty"` // actions
+	Value       string `json:"value,omitempty"`    // the url of a bookmark, the name of a connection, the key of a setting
+	Score       int    `json:"score,omitempty"`
+	MatchPos    []int  `json:"matchpos,omitempty"`
+	SubMatchPos []int  `json:"submatchpos,omitempty"`
+}
+
+// a single command in a terminal's output, delimited by OSC 133 (FinalTerm) markers.
+// offsets are absolute offsets into the block's term file (-1 if the marker was not seen)
+type TermSegment struct {
+	SegIdx       int    `json:"segidx"`
+	PromptOffset int64  `json:"promptoffset"` // A: prompt start
+	CmdOffset    int64  `json:"cmdoffset"`    // B: end of prompt, start of command input
+	OutputOffset int64  `json:"outputoffset"` // C: start of command output
+	EndOffset    int64  `json:"endoffset"`    // D: command finished
+	ExitCode     *int   `json:"exitcode,omitempty"`
+	StartTs      int64  `json:"startts,omitempty"`
+	EndTs        int64  `json:"endts,omitempty"`
+	Command      string `json:"command,omitempty"` // from the C marker (cmdline / cmdline_url)
+	Cwd          string `json:"cwd,omitempty"`     // shell cwd (OSC 7) when the command started
+	DurationMs   int64  `json:"durationms,omitempty"`
+}
+
+type CommandTermGetSegmentsData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+}
+
+type CommandTermGetSegmentOutputData struct {
+	BlockId       string `json:"blockid" wshcontext:"BlockId"`
+	SegIdx        int    `json:"segidx"` // -1 for the last completed command
+	IncludePrompt bool   `json:"includeprompt,omitempty"`
+}
+
+type TermSegmentOutput struct {
+	Segment   TermSegment `json:"segment"`
+	Data64    string      `json:"data64"`
+	Truncated bool        `json:"truncated,omitempty"` // the start of the segment has scrolled out of the term file
+}
+
+// offsets are term file offsets of the link text
+type TermLink struct {
+	Offset    int64  `json:"offset"`
+	EndOffset int64  `json:"endoffset"`
+	Kind      string `json:"kind"`   // hyperlink (OSC 8), url, or path
+	Target    string `json:"target"` // how a click opens it: web, editor, or external
+	Text      string `json:"text"`
+	Uri       string `json:"uri,omitempty"`
+	Path      string `json:"path,omitempty"` // editor links, relative paths are resolved against the block's cwd
+	Line      int    `json:"line,omitempty"`
+	Col       int    `json:"col,omitempty"`
+	Conn      string `json:"conn,omitempty"` // the connection the path is on
+}
+
+type CommandTermGetLinksData struct {
+	BlockId     string `json:"blockid" wshcontext:"BlockId"`
+	StartOffset int64  `json:"startoffset,omitempty"`
+}
+
+type CommandTermRecordData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Title   string `json:"title,omitempty"` // start only, goes in the asciicast header
+}
+
+// an asciicast v2 recording of a block's term output, stored as a blockfile
+type TermRecording struct {
+	BlockId  string  `json:"blockid"`
+	FileName string  `json:"filename"` // cast:[timestamp]
+	Title    string  `json:"title,omitempty"`
+	StartTs  int64   `json:"startts"`
+	Duration float64 `json:"duration"` // seconds
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Size     int64   `json:"size"`
+	Active   bool    `json:"active,omitempty"` // still recording
+}
+
+// a command running in a block next to the block's own shell (see blockcontroller/panes.go)
+type TermPaneInfo struct {
+	PaneId   string `json:"paneid"`
+	Cmd      string `json:"cmd,omitempty"` // empty for a shell
+	FileName string `json:"filename"`      // term:pane:[paneid]
+	StartTs  int64  `json:"startts"`
+	Active   bool   `json:"active,omitempty"` // shown in the block (and gets its input)
+}
+
+type CommandTermPaneCreateData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Cmd     string `json:"cmd,omitempty"` // empty to start a shell
+	Switch  bool   `json:"switch,omitempty"`
+}
+
+type CommandTermPaneData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	PaneId  string `json:"paneid,omitempty"` // for switch, empty is the block's own shell
+}
+
+// a command queued to run in a block's shell (see blockcontroller/cmdqueue.go)
+type QueuedCommand struct {
+	Id       int    `json:"id"`
+	Cmd      string `json:"cmd"`
+	Status   string `json:"status"` // queued, running, done, or canceled
+	QueuedTs int64  `json:"queuedts"`
+	StartTs  int64  `json:"startts,omitempty"`
+	EndTs    int64  `json:"endts,omitempty"`
+	ExitCode *int   `json:"exitcode,omitempty"` // not set if the shell did not report it
+}
+
+type CommandTermQueueData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Cmd     string `json:"cmd,omitempty"` // add only
+	Id      int    `json:"id,omitempty"`  // cancel only, 0 cancels everything that is queued
+}
+
+type CommandAuthTokenData struct {
+	TabId string `json:"tabid"`
+}
+
+type AuthTokenRtnData struct {
+	Token string `json:"token"`
+}
+
+type CommandPlayerLoadData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Src     string `json:"src"` // local path or wavefile://[zoneid]/[name]
+}
+
+type CommandPlayerData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+}
+
+type CommandPlayerSeekData struct {
+	BlockId string  `json:"blockid" wshcontext:"BlockId"`
+	Pos     float64 `json:"pos"` // seconds
+}
+
+type CommandPlayerSetSpeedData struct {
+	BlockId string  `json:"blockid" wshcontext:"BlockId"`
+	Speed   float64 `json:"speed"`
+}
+
+type PlayerStatus struct {
+	BlockId  string  `json:"blockid"`
+	Src      string  `json:"src"`
+	Title    string  `json:"title,omitempty"`
+	State    string  `json:"state"` // playing, paused, or ended
+	Pos      float64 `json:"pos"`   // seconds, at ts
+	Ts       int64   `json:"ts"`
+	Duration float64 `json:"duration"`
+	Speed    float64 `json:"speed"`
+	Width    int     `json:"width"` // terminal size of the recording at pos
+	Height   int     `json:"height"`
+}
+
+type CmdHistoryEntry struct {
+	HistoryId   string `json:"historyid" db:"historyid"`
+	Ts          int64  `json:"ts" db:"ts"`
+	BlockId     string `json:"blockid" db:"blockid"`
+	WorkspaceId string `json:"workspaceid,omitempty" db:"workspaceid"`
+	ConnName    string `json:"connname" db:"connname"`
+	Cwd         string `json:"cwd,omitempty" db:"cwd"`
+	CmdStr      string `json:"cmdstr" db:"cmdstr"`
+	ExitCode    *int   `json:"exitcode,omitempty" db:"exitcode"`
+	DurationMs  int64  `json:"durationms,omitempty" db:"durationms"`
+}
+
+// all filters are optional.  query is a case-insensitive substring match on the command.
+// dedup returns only the most recent entry for each distinct command.
+type CommandCmdHistorySearchData struct {
+	Query       string `json:"query,omitempty"`
+	BlockId     string `json:"blockid,omitempty"`
+	WorkspaceId string `json:"workspaceid,omitempty"`
+	ConnName    string `json:"connname,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+	Dedup       bool   `json:"dedup,omitempty"`
+}
+
+type CommandWebhookCreateData struct {
+	Name    string                  `json:"name,omitempty"`
+	Url     string                  `json:"url"`
+	Filters []waveobj.WebhookFilter `json:"filters"`
+	Secret  string                  `json:"secret,omitempty"` // generated if not set
+}
+
+// the secret is only returned here, it is not stored in the webhook object
+type WebhookCreateRtnData struct {
+	Webhook *waveobj.Webhook `json:"webhook"`
+	Secret  string           `json:"secret"`
+}
+
+type CommandWebhookData struct {
+	WebhookId string `json:"webhookid,omitempty"` // optional for deliveries (lists the deliveries of all webhooks)
+	Disabled  bool   `json:"disabled,omitempty"`  // setdisabled only
+	Limit     int    `json:"limit,omitempty"`     // deliveries only
+}
+
+type CommandNotificationSendData struct {
+	Title    string `json:"title,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Type     string `json:"type,omitempty"` // "error", "warning", or "info" (the default)
+	Source   string `json:"source,omitempty"`
+	BlockId  string `json:"blockid,omitempty"`
+	DedupKey string `json:"dedupkey,omitempty"` // defaults to a hash of the source, block, type, title, and message
+	Desktop  bool   `json:"desktop,omitempty"`  // also show it as a desktop notification
+	Silent   bool   `json:"silent,omitempty"`   // no sound for the desktop notification
+}
+
+type CommandNotificationDismissData struct {
+	Id  string `json:"id,omitempty"`
+	All bool   `json:"all,omitempty"`
+}
+
+type CommandConnectionListData struct {
+	Tag string `json:"tag,omitempty"` // only the connections with the tag
+}
+
+type CommandConnectionData struct {
+	Connection string `json:"connection"`                         // the id or name of the connection
+	TabId      string `json:"tabid,omitempty" wshcontext:"TabId"` // openterm only
+	Force      bool   `json:"force,omitempty"`                    // delete only, delete it even if blocks use it
+}
+
+type ConnectionInfo struct {
+	Connection *waveobj.Connection `json:"connection"`
+	ConnName   string              `json:"connname"` // the "connection" meta of the blocks that use it
+	Status     ConnStatus          `json:"status"`
+	BlockIds   []string            `json:"blockids,omitempty"`
+}
+
+type CommandConnectionSetKeyData struct {
+	Connection string `json:"connection"` // the id or name of the connection
+	Path       string `json:"path"`       // the private key, a file name in ~/.ssh or a path
+}
+
+type SshKeyInfo struct {
+	Path        string   `json:"path"`
+	PubPath     string   `json:"pubpath,omitempty"`
+	KeyType     string   `json:"keytype,omitempty"` // e.g. "ssh-ed25519", not set for an encrypted key without its public key
+	Bits        int      `json:"bits,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"` // "SHA256:..."
+	Comment     string   `json:"comment,omitempty"`
+	Encrypted   bool     `json:"encrypted,omitempty"`
+	InAgent     bool     `json:"inagent,omitempty"`
+	Connections []string `json:"connections,omitempty"` // the saved connections that use the key
+}
+
+type CommandSshKeyGenerateData struct {
+	Name       string `json:"name"`              // a file name in ~/.ssh or a path, must not exist
+	KeyType    string `json:"keytype,omitempty"` // "ed25519" (the default), "ecdsa" or "rsa"
+	Bits       int    `json:"bits,omitempty"`    // rsa 2048-8192 (3072 if not set), ecdsa 256, 384 or 521 (256 if not set)
+	Comment    string `json:"comment,omitempty"` // user@host if not set
+	Passphrase string `json:"passphrase,omitempty"`
+	AddToAgent bool   `json:"addtoagent,omitempty"`
+	Connection string `json:"connection,omitempty"` // a saved connection (id or name) to use the new key
+}
+
+// a host key the user accepted (or rejected, it is revoked) when connecting
+type KnownHostKey struct {
+	Hosts       []string `json:"hosts"`
+	KeyType     string   `json:"keytype"`
+	Fingerprint string   `json:"fingerprint"`
+	Revoked     bool     `json:"revoked,omitempty"`
+	Line        int      `json:"line"` // in the known_hosts file wave manages
+}
+
+type CommandHostKeyForgetData struct {
+	Host string `json:"host"` // e.g. "example.com" or "[example.com]:2222"
+}
+
+type CommandPortForwardCreateData struct {
+	Connection string              `json:"connection"` // the id or name of the saved connection
+	Forward    waveobj.PortForward `json:"forward"`
+	AutoStart  bool                `json:"autostart,omitempty"` // save it on the connection, it is started every time the connection connects
+}
+
+type CommandPortForwardListData struct {
+	Connection string `json:"connection,omitempty"` // only the forwards of the saved connection (id or name)
+}
+
+type CommandPortForwardStopData struct {
+	Id     string `json:"id"`
+	Forget bool   `json:"forget,omitempty"` // remove it from the connection's auto-start forwards
+}
+
+// the bytes are counted from the listening side: sent goes to the destination, received comes back from it
+type PortForwardInfo struct {
+	Id          string              `json:"id"`
+	Connection  string              `json:"connection"` // the name of the saved connection
+	ConnName    string              `json:"connname"`
+	Forward     waveobj.PortForward `json:"forward"`
+	AutoStart   bool                `json:"autostart,omitempty"`
+	Status      string              `json:"status"` // "running", "waiting" (for the connection) or "error"
+	Error       string              `json:"error,omitempty"`
+	StartTs     int64               `json:"startts,omitempty"`
+	BytesSent   int64               `json:"bytessent"`
+	BytesRecv   int64               `json:"bytesrecv"`
+	ActiveConns int                 `json:"activeconns"`
+	TotalConns  int64               `json:"totalconns"`
+}
+
+// a saved connection in the connections view, with its status (and latency), the blocks that use it and its
+// port forwards
+type ConnDashboardEntry struct {
+	Info     ConnectionInfo    `json:"info"`
+	Forwards []PortForwardInfo `json:"forwards,omitempty"`
+	Metrics  *ConnMetrics      `json:"metrics,omitempty"` // without the history
+}
+
+type CommandConnectionRunData struct {
+	Connection string            `json:"connection"` // a saved connection (id or name) or an ssh connection name
+	Cmd        string            `json:"cmd"`        // run by the login shell of the user, like "ssh host cmd"
+	Cwd        string            `json:"cwd,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	Stdin      string            `json:"stdin,omitempty"`
+	TimeoutMs  int               `json:"timeoutms,omitempty"` // the command is killed after it (TimedOut is set)
+	MaxOutput  int               `json:"maxoutput,omitempty"` // the bytes kept of stdout and of stderr (1MB if not set)
+	Sudo       bool              `json:"sudo,omitempty"`      // run with sudo, the password is conn:sudosecret or asked for
+	SudoUser   string            `json:"sudouser,omitempty"`  // the user to run as with sudo (root if not set)
+	NoPrompt   bool              `json:"noprompt,omitempty"`  // fail instead of asking for the sudo password
+}
+
+type ConnectionRunResult struct {
+	Connection      string `json:"connection"`
+	ConnName        string `json:"connname"`
+	ExitCode        int    `json:"exitcode"` // -1 if the command was killed by a signal (or timed out)
+	Signal          string `json:"signal,omitempty"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	StdoutTruncated bool   `json:"stdouttruncated,omitempty"`
+	StderrTruncated bool   `json:"stderrtruncated,omitempty"`
+	TimedOut        bool   `json:"timedout,omitempty"`
+	DurationMs      int64  `json:"durationms"`
+}
+
+// a chunk of output, or the result (without the output) as the last message
+type ConnectionRunOutput struct {
+	Stdout string               `json:"stdout,omitempty"`
+	Stderr string               `json:"stderr,omitempty"`
+	Result *ConnectionRunResult `json:"result,omitempty"`
+}
+
+type CommandClusterExecData struct {
+	Cmd         string            `json:"cmd"`
+	Tags        []string          `json:"tags,omitempty"`        // the saved connections with any of the tags
+	Connections []string          `json:"connections,omitempty"` // saved connections (id or name) or ssh connection names
+	Concurrency int               `json:"concurrency,omitempty"` // the hosts run at once (10 if not set)
+	TimeoutMs   int               `json:"timeoutms,omitempty"`   // per host, including the time to connect
+	Cwd         string            `json:"cwd,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	MaxOutput   int               `json:"maxoutput,omitempty"` // per host, like CommandConnectionRunData
+	Sudo        bool              `json:"sudo,omitempty"`      // the password is never asked for, it is conn:sudosecret
+	SudoUser    string            `json:"sudouser,omitempty"`
+}
+
+type CommandClusterExecRunData struct {
+	RunId string `json:"runid"`
+}
+
+// a cluster exec run, the hosts are in the order they were selected in
+type ClusterExecRun struct {
+	RunId    string             `json:"runid"`
+	Cmd      string             `json:"cmd"`
+	StartTs  int64              `json:"startts"`
+	EndTs    int64              `json:"endts,omitempty"` // set when every host finished (or the run was canceled)
+	Canceled bool               `json:"canceled,omitempty"`
+	Hosts    []ClusterExecHost  `json:"hosts"`
+	Groups   []ClusterExecGroup `json:"groups,omitempty"` // set with EndTs
+}
+
+type ClusterExecHost struct {
+	Connection string               `json:"connection"`
+	ConnName   string               `json:"connname,omitempty"`
+	Status     string               `json:"status"`          // "pending", "running", "done" or "error"
+	Error      string               `json:"error,omitempty"` // the command could not be run (e.g. the host is down)
+	StartTs    int64                `json:"startts,omitempty"`
+	Result     *ConnectionRunResult `json:"result,omitempty"`
+}
+
+// the hosts that had the same exit code and output (or the same error)
+type ClusterExecGroup struct {
+	ExitCode int      `json:"exitcode"`
+	Error    string   `json:"error,omitempty"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Stderr   string   `json:"stderr,omitempty"`
+	Hosts    []string `json:"hosts"`
+}
+
+type CommandSettingsData struct {
+	WorkspaceId string `json:"workspaceid,omitempty"` // "" for the global settings
+}
+
+type CommandSettingsMigrateData struct {
+	DryRun bool `json:"dryrun,omitempty"` // only return the changes, don't rewrite the files
+}
+
+// the config of another terminal to import into wave
+type CommandSettingsImportData struct {
+	Format   string   `json:"format,omitempty"` // "iterm2", "windowsterminal", "alacritty" or "kitty" (detected if not set)
+	FileName string   `json:"filename,omitempty"`
+	Data     string   `json:"data"`
+	Only     []string `json:"only,omitempty"` // the kinds to import: "theme", "font", "keybinding", "connection" (all if empty)
+	DryRun   bool     `json:"dryrun,omitempty"`
+}
+
+type SettingsImportResult struct {
+	Format string               `json:"format"`
+	Items  []SettingsImportItem `json:"items"`
+}
+
+// a theme, setting, key binding or connection imported (or skipped)
+type SettingsImportItem struct {
+	Kind    string `json:"kind"` // "theme", "font", "keybinding", "connection" or "setting"
+	Name    string `json:"name"` // the name in wave (the theme, setting, action or connection)
+	Value   string `json:"value,omitempty"`
+	From    string `json:"from,omitempty"` // the name in the other terminal (e.g. the profile)
+	Skipped bool   `json:"skipped,omitempty"`
+	Reason  string `json:"reason,omitempty"` // why it was skipped
+}
+
+// the settings of a workspace, connection and block, resolved in layers: the defaults, settings.json, the global
+// settings, the active profile, the workspace, the connection (connections.json) and the block (its meta)
+type CommandSettingsExplainData struct {
+	WorkspaceId string   `json:"workspaceid,omitempty"`
+	Connection  string   `json:"connection,omitempty"`
+	BlockId     string   `json:"blockid,omitempty"` // its workspace and connection are used if they are not set
+	Keys        []string `json:"keys,omitempty"`    // all the settings that are set if empty
+}
+
+type SettingValueInfo struct {
+	Key    string              `json:"key"`
+	Value  any                 `json:"value"`
+	Source string              `json:"source"` // the layer the value is from ("" if no layer sets it)
+	Locked bool                `json:"locked,omitempty"`
+	Layers []SettingLayerValue `json:"layers,omitempty"` // the layers that set it, lowest first
+}
+
+type SettingLayerValue struct {
+	Source  string `json:"source"`         // "default", "settings.json", "global", "profile", "workspace", "connection" or "block"
+	Name    string `json:"name,omitempty"` // the workspace id, connection name or block id
+	Value   any    `json:"value"`
+	Ignored bool   `json:"ignored,omitempty"` // the setting is locked by a lower layer
+}
+
+type CommandSettingsSetData struct {
+	WorkspaceId string              `json:"workspaceid,omitempty"`
+	Settings    waveobj.MetaMapType `json:"settings"` // a null value removes the setting
+}
+
+// a key ("Cmd:t") or a chord of two keys separated by a space ("Ctrl:Shift:s ArrowUp"), normalized (the modifiers
+// in the order Cmd, Option, Alt, Meta, Ctrl, Shift)
+type KeyBinding struct {
+	Keys    string `json:"keys"`
+	Action  string `json:"action"`
+	Backend bool   `json:"backend,omitempty"` // a registered (waction) action, run with ExecuteActionCommand
+	Source  string `json:"source"`            // "default" or "settings"
+}
+
+type KeyBindingAction struct {
+	Action  string `json:"action"`
+	Title   string `json:"title"`
+	Backend bool   `json:"backend,omitempty"`
+}
+
+// keys bound to more than one action (or bound alone and as the first key of a chord), only Action is bound
+type KeyBindingConflict struct {
+	Keys     string   `json:"keys"`
+	Action   string   `json:"action"`
+	Shadowed []string `json:"shadowed"`
+	Reason   string   `json:"reason"`
+}
+
+type KeyBindingsData struct {
+	Bindings  []KeyBinding         `json:"bindings"`
+	Actions   []KeyBindingAction   `json:"actions"`
+	Conflicts []KeyBindingConflict `json:"conflicts,omitempty"`
+	Errors    []string             `json:"errors,omitempty"` // the invalid overrides (unknown actions or keys), they are ignored
+}
+
+type CommandKeyBindingResolveData struct {
+	WorkspaceId string `json:"workspaceid,omitempty"`
+	Keys        string `json:"keys"` // a key, or a chord
+}
+
+type KeyBindingResolveRtnData struct {
+	Keys    string `json:"keys"` // normalized
+	Action  string `json:"action,omitempty"`
+	Backend bool   `json:"backend,omitempty"`
+	Partial bool   `json:"partial,omitempty"` // the first key of one or more chords
+}
+
+type ThemeInfo struct {
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayname,omitempty"`
+	Source       string `json:"source"`                 // "builtin", "config" (termthemes.json) or "wave"
+	ImportedFrom string `json:"importedfrom,omitempty"` // the format a theme in wave was imported from
+	ThemeId      string `json:"themeid,omitempty"`      // the oid of a theme in wave
+}
+
+type CommandThemeData struct {
+	Name string `json:"name"`
+}
+
+type CommandThemeSaveData struct {
+	Name  string                `json:"name"`
+	Theme wconfig.TermThemeType `json:"theme"`
+}
+
+type CommandThemeImportData struct {
+	Name     string `json:"name,omitempty"`     // the name in the file (or the file name) if not set
+	Format   string `json:"format,omitempty"`   // "iterm2", "alacritty", "vscode", "kitty" or "windowsterminal", detected if not set
+	FileName string `json:"filename,omitempty"` // to detect the format and the name
+	Data     string `json:"data"`               // the contents of the file
+}
+
+// sets term:theme of a block (its meta) or a connection (in connections.json), an empty Theme removes it
+type CommandThemeAssignData struct {
+	Theme      string `json:"theme"`
+	BlockId    string `json:"blockid,omitempty"`
+	Connection string `json:"connection,omitempty"`
+}
+
+type CommandProfileData struct {
+	Name string `json:"name"` // "" to switch to no profile
+}
+
+type ProfileListData struct {
+	Profiles       []*waveobj.Profile  `json:"profiles"`
+	Active         string              `json:"active,omitempty"`         // the name of the active profile
+	ActiveSettings waveobj.MetaMapType `json:"activesettings,omitempty"` // its settings, with its theme, key bindings and templates
+}
+
+type FontInfo struct {
+	Name   string `json:"name"`
+	Source string `json:"source"` // bundled, system, or generic
+}
+
+type CommandTermFontData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+}
+
+// sets the font of a block (RuntimeOpts.TermFont), a nil Font removes it
+type CommandTermFontSetData struct {
+	BlockId string                `json:"blockid" wshcontext:"BlockId"`
+	Font    *waveobj.TermFontOpts `json:"font,omitempty"`
+}
+
+type TermFontInfo struct {
+	Font     waveobj.TermFontOpts  `json:"font"`               // the font the terminal renders with
+	BlockSet *waveobj.TermFontOpts `json:"blockset,omitempty"` // the font set for the block
+}
+
+type I18nCatalogInfo struct {
+	Locale     string `json:"locale"`
+	Source     string `json:"source"` // builtin, config, or plugin
+	PluginId   string `json:"pluginid,omitempty"`
+	NumStrings int    `json:"numstrings"`
+}
+
+type CommandI18nCatalogData struct {
+	Locale string `json:"locale,omitempty"` // defaults to the current locale
+}
+
+type I18nCatalogData struct {
+	Locale  string            `json:"locale"`
+	Chain   []string          `json:"chain"` // the locales the strings are looked up in, in order
+	Strings map[string]string `json:"strings"`
+}
+
+type CommandSecretSetData struct {
+	Name       string `json:"name"`
+	Connection string `json:"connection,omitempty"` // a saved connection (id or name) or an ssh connection name, "" for a global secret
+	Value      string `json:"value"`
+}
+
+type CommandSecretData struct {
+	Name       string `json:"name"`
+	Connection string `json:"connection,omitempty"`
+}
+
+type CommandSecretListData struct {
+	Connection string `json:"connection,omitempty"` // "" for every secret
+}
+
+// a stored secret, without its value
+type SecretInfo struct {
+	Name      string `json:"name"`
+	ConnName  string `json:"connname,omitempty"` // "" for a global secret
+	Backend   string `json:"backend"`            // "keychain" or "file"
+	CreatedTs int64  `json:"createdts"`
+	UpdatedTs int64  `json:"updatedts"`
+}
+
+type CommandSftpTransferData struct {
+	Connection string `json:"connection"` // a saved connection (id or name) or an ssh connection name
+	LocalPath  string `json:"localpath"`  // absolute, on the machine running wave
+	RemotePath string `json:"remotepath"` // "~" is the remote home directory
+	Recursive  bool   `json:"recursive,omitempty"`
+	Resume     bool   `json:"resume,omitempty"`
+	Preserve   bool   `json:"preserve,omitempty"` // keep the permissions and modification times
+	Verify     bool   `json:"verify,omitempty"`   // compare the sha256 of each file after it is copied
+	Sudo       bool   `json:"sudo,omitempty"`     // the remote files are read and written as root
+}
+
+// a copy between two connections (or a connection and the machine running wave, with the connection "")
+type CommandSftpCopyData struct {
+	SrcConnection  string `json:"srcconnection,omitempty"`
+	SrcPath        string `json:"srcpath"` // absolute on the local machine, "~" is the remote home directory
+	DestConnection string `json:"destconnection,omitempty"`
+	DestPath       string `json:"destpath"`
+	Recursive      bool   `json:"recursive,omitempty"`
+	Resume         bool   `json:"resume,omitempty"`
+	Preserve       bool   `json:"preserve,omitempty"`
+	Verify         bool   `json:"verify,omitempty"`
+	Sudo           bool   `json:"sudo,omitempty"` // on both connections
+}
+
+type SftpTransferProgress struct {
+	Path        string `json:"path"`
+	DestPath    string `json:"destpath"`
+	Offset      int64  `json:"offset"`
+	Size        int64  `json:"size"`
+	ResumedFrom int64  `json:"resumedfrom,omitempty"`
+	FileDone    bool   `json:"filedone,omitempty"`
+	Skipped     bool   `json:"skipped,omitempty"`
+	BytesDone   int64  `json:"bytesdone"`
+	BytesTotal  int64  `json:"bytestotal"`
+	FilesDone   int    `json:"filesdone"`
+	FilesTotal  int    `json:"filestotal"`
+	Checksum    string `json:"checksum,omitempty"` // the sha256 of the file (verify)
+	Direct      bool   `json:"direct,omitempty"`   // the copy ran on the host (nothing went through wave)
+}
+
+type CommandSshKeyAddToAgentData struct {
+	Path         string `json:"path"`
+	Passphrase   string `json:"passphrase,omitempty"`
+	LifetimeSecs int    `json:"lifetimesecs,omitempty"` // how long the agent keeps the key, forever if not set
+}
+
+type CommandStorageUsageData struct {
+	ORef string `json:"oref,omitempty"` // a block, or a workspace (and its blocks), all the workspaces if not set
+}
+
+type StorageUsage struct {
+	ORef       string `json:"oref"`
+	NumFiles   int    `json:"numfiles"`
+	Size       int64  `json:"size"`              // the data in the files
+	StoredSize int64  `json:"storedsize"`        // the bytes stored (compressed)
+	Evicted    int64  `json:"evicted,omitempty"` // the data evicted to stay under the quota
+	MaxSize    int64  `json:"maxsize,omitempty"` // the quota (storage:maxblockbytes or storage:maxworkspacebytes)
+}
+
+// the block file parts are counted (hits are read from memory, misses from the db), see filestore.GetCacheStats
+type StorageCacheStats struct {
+	Hits           int64  `json:"hits"`
+	Misses         int64  `json:"misses"`
+	Evictions      int64  `json:"evictions"`
+	ReadAheadParts int64  `json:"readaheadparts"`
+	CachedBytes    int64  `json:"cachedbytes"`
+	MaxBytes       int64  `json:"maxbytes"` // storage:cachebytes
+	Policy         string `json:"policy"`   // storage:cachepolicy
+	OpenFiles      int    `json:"openfiles"`
+	DirtyFiles     int    `json:"dirtyfiles"` // files with writes that are not flushed
+}
+
+type CommandFileSearchData struct {
+	Text  string `json:"text,omitempty"`  // the words to find in the output (storage:searchindex)
+	Name  string `json:"name,omitempty"`  // a substring of the file name
+	View  string `json:"view,omitempty"`  // only the blocks with this view
+	Limit int    `json:"limit,omitempty"` // filestore.MaxSearchHits if 0
+}
+
+type FileSearchHit struct {
+	BlockId string `json:"blockid"`
+	View    string `json:"view,omitempty"`
+	Name    string `json:"name"`
+	Offset  int64  `json:"offset"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type CommandStorageCheckData struct {
+	Repair bool `json:"repair,omitempty"` // repair the problems that can be repaired (corrupt parts are quarantined)
+}
+
+type StorageCheckProblem struct {
+	Type     string `json:"type"` // filestore.CheckProblem_*
+	ZoneId   string `json:"zoneid,omitempty"`
+	Name     string `json:"name,omitempty"`
+	PartIdx  int    `json:"partidx,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Repaired bool   `json:"repaired,omitempty"`
+}
+
+type StorageCheckResult struct {
+	NumBlobs       int                   `json:"numblobs"`
+	NumParts       int                   `json:"numparts"`
+	NumQuarantined int                   `json:"numquarantined"`
+	Problems       []StorageCheckProblem `json:"problems,omitempty"`
+}
+
+type CommandStoragePruneData struct {
+	DryRun bool `json:"dryrun,omitempty"` // only report the files that would be deleted
+}
+
+// a block file deleted (or cleared) by the storage:retention policies
+type RetentionAction struct {
+	BlockId string `json:"blockid"`
+	Name    string `json:"name"`
+	Action  string `json:"action"` // "delete", or "clear" for the term output
+	Reason  string `json:"reason"`
+	Size    int64  `json:"size"`
+}
+
+type ReplicaSyncResult struct {
+	Blobs          int   `json:"blobs"` // the blobs put in the replica
+	Bytes          int64 `json:"bytes"`
+	Files          int   `json:"files"`   // the manifests put in the replica
+	Deleted        int   `json:"deleted"` // the manifests of deleted files
+	Offloaded      int   `json:"offloaded"`
+	OffloadedBytes int64 `json:"offloadedbytes"`
+	Rehydrated     int   `json:"rehydrated"` // the offloaded blobs brought back
+}
+
+type CommandStorageReplicaListData struct {
+	ZoneId string `json:"zoneid,omitempty"` // all the zones if empty
+}
+
+type CommandStorageReplicaRestoreData struct {
+	ZoneId       string   `json:"zoneid"`                 // the zone the files were replicated from
+	TargetZoneId string   `json:"targetzoneid,omitempty"` // the zone to restore into (ZoneId if empty)
+	Names        []string `json:"names,omitempty"`        // all the files if empty
+	Overwrite    bool     `json:"overwrite,omitempty"`
+}
+
+// a file in the replica
+type ReplicaFileInfo struct {
+	ZoneId string `json:"zoneid"`
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	ModTs  int64  `json:"modts"`
+	Error  string `json:"error,omitempty"` // why the file was not restored
+}
+
+type CommandStorageReplicaKeyData struct {
+	Key string `json:"key,omitempty"` // sets the key (base64), to restore a replica from another machine
+}
+
+type CommandRemoteSetPasswordData struct {
+	Password string `json:"password"`
+}
+
+type RemoteSessionInfo struct {
+	SessionId  string   `json:"sessionid"`
+	ShareId    string   `json:"shareid,omitempty"` // set if the session was opened from a share link
+	Perms      []string `json:"perms"`
+	RemoteAddr string   `json:"remoteaddr"`
+	UserAgent  string   `json:"useragent,omitempty"`
+	CreatedTs  int64    `json:"createdts"`
+	LastSeenTs int64    `json:"lastseents"`
+}
+
+type CommandRemoteRevokeData struct {
+	SessionId string `json:"sessionid,omitempty"` // the id (or a unique prefix of it) of a session or share link
+	All       bool   `json:"all,omitempty"`
+}
+
+type CommandRemoteShareCreateData struct {
+	Workspace string   `json:"workspace"` // id or name
+	Perms     []string `json:"perms"`     // view is always included
+	Hours     float64  `json:"hours,omitempty"`
+}
+
+type RemoteShareInfo struct {
+	ShareId       string   `json:"shareid"`
+	WorkspaceId   string   `json:"workspaceid"`
+	WorkspaceName string   `json:"workspacename,omitempty"`
+	Perms         []string `json:"perms"`
+	CreatedTs     int64    `json:"createdts"`
+	ExpiresTs     int64    `json:"expirests"`
+	Token         string   `json:"token,omitempty"` // only returned when the link is created
+	Url           string   `json:"url,omitempty"`   // only returned when the link is created
+}
+
+// implemented by wavesrv (local shells) and by wsh on remote connections (route to the connection)
+type CommandShellIntegrationCheckData struct {
+	Repair bool `json:"repair,omitempty"` // rewrites the integration files if they are missing or stale
+}
+
+type ShellIntegrationShellStatus struct {
+	ShellType    string   `json:"shelltype"`
+	Installed    bool     `json:"installed"`
+	UpToDate     bool     `json:"uptodate"`
+	MissingFiles []string `json:"missingfiles,omitempty"`
+	StaleFiles   []string `json:"stalefiles,omitempty"`
+}
+
+type ShellIntegrationStatus struct {
+	WaveHome     string                        `json:"wavehome"`
+	WshBinDir    string                        `json:"wshbindir"`
+	WshInstalled bool                          `json:"wshinstalled"`
+	Healthy      bool                          `json:"healthy"`
+	Repaired     bool                          `json:"repaired,omitempty"`
+	Shells       []ShellIntegrationShellStatus `json:"shells"`
+}
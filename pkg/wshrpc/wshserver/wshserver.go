@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -18,39 +19,66 @@ import (
 	"time"
 
 	"github.com/skratchdot/open-golang/open"
+	"github.com/wavetermdev/waveterm/pkg/authkey"
 	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
+	"github.com/wavetermdev/waveterm/pkg/castplayer"
+	"github.com/wavetermdev/waveterm/pkg/clusterexec"
+	"github.com/wavetermdev/waveterm/pkg/cmdhistory"
+	"github.com/wavetermdev/waveterm/pkg/conndashboard"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filequota"
+	"github.com/wavetermdev/waveterm/pkg/filereplica"
+	"github.com/wavetermdev/waveterm/pkg/fileretention"
+	"github.com/wavetermdev/waveterm/pkg/filesearch"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/genconn"
+	"github.com/wavetermdev/waveterm/pkg/keybind"
+	"github.com/wavetermdev/waveterm/pkg/palette"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/portforward"
 	"github.com/wavetermdev/waveterm/pkg/remote"
 	"github.com/wavetermdev/waveterm/pkg/remote/awsconn"
 	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
 	"github.com/wavetermdev/waveterm/pkg/remote/fileshare"
+	"github.com/wavetermdev/waveterm/pkg/remote/fileshare/sftpfs"
+	"github.com/wavetermdev/waveterm/pkg/remoteaccess"
+	"github.com/wavetermdev/waveterm/pkg/secretstore"
+	"github.com/wavetermdev/waveterm/pkg/sshkeys"
 	"github.com/wavetermdev/waveterm/pkg/suggestion"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/telemetry/telemetrydata"
+	"github.com/wavetermdev/waveterm/pkg/termimport"
 	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/util/fontutil"
 	"github.com/wavetermdev/waveterm/pkg/util/iochan/iochantypes"
 	"github.com/wavetermdev/waveterm/pkg/util/iterfn"
 	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/util/wavefileutil"
+	"github.com/wavetermdev/waveterm/pkg/waction"
 	"github.com/wavetermdev/waveterm/pkg/waveai"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wcloud"
 	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
 	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/webhook"
+	"github.com/wavetermdev/waveterm/pkg/wi18n"
+	"github.com/wavetermdev/waveterm/pkg/wnotify"
+	"github.com/wavetermdev/waveterm/pkg/wplugin"
 	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wsettings"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
 	"github.com/wavetermdev/waveterm/pkg/wsl"
 	"github.com/wavetermdev/waveterm/pkg/wslconn"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
+	"github.com/wavetermdev/waveterm/pkg/wtheme"
 )
 
-var InvalidWslDistroNames = []string{"docker-desktop", "docker-desktop-data"}
+var InvalidWslDistroNames = wsl.InvalidDistroNames
 
 type WshServer struct{}
 
@@ -269,10 +297,35 @@ func (ws *WshServer) CreateBlockCommand(ctx context.Context, data wshrpc.Command
 		return nil, fmt.Errorf("error queuing layout action: %w", err)
 	}
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
-	wps.Broker.SendUpdateEvents(updates)
+	eventbus.PublishObjectUpdates(updates)
 	return &waveobj.ORef{OType: waveobj.OType_Block, OID: blockData.OID}, nil
 }
 
+func (ws *WshServer) RerunBlockCommand(ctx context.Context, data wshrpc.CommandRerunBlockData) (*waveobj.ORef, error) {
+	blockDef, err := wcore.GetOriginalBlockDef(ctx, data.BlockId)
+	if err != nil {
+		return nil, err
+	}
+	tabId := data.TabId
+	if tabId == "" {
+		tabId, err = wstore.DBFindTabForBlockId(ctx, data.BlockId)
+		if err != nil {
+			return nil, fmt.Errorf("error finding tab for block: %w", err)
+		}
+	}
+	targetAction := data.TargetAction
+	if targetAction == "" {
+		targetAction = wshrpc.CreateBlockAction_SplitRight
+	}
+	return ws.CreateBlockCommand(ctx, wshrpc.CommandCreateBlockData{
+		TabId:         tabId,
+		BlockDef:      blockDef,
+		Magnified:     data.Magnified,
+		TargetBlockId: data.BlockId,
+		TargetAction:  targetAction,
+	})
+}
+
 func (ws *WshServer) CreateSubBlockCommand(ctx context.Context, data wshrpc.CommandCreateSubBlockData) (*waveobj.ORef, error) {
 	parentBlockId := data.ParentBlockId
 	blockData, err := wcore.CreateSubBlock(ctx, parentBlockId, data.BlockDef)
@@ -296,13 +349,17 @@ func (ws *WshServer) SetViewCommand(ctx context.Context, data wshrpc.CommandBloc
 		return fmt.Errorf("error updating block: %w", err)
 	}
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
-	wps.Broker.SendUpdateEvents(updates)
+	eventbus.PublishObjectUpdates(updates)
 	return nil
 }
 
 func (ws *WshServer) ControllerStopCommand(ctx context.Context, blockId string) error {
 	bc := blockcontroller.GetBlockController(blockId)
 	if bc == nil {
+		block, _ := wstore.DBGet[*waveobj.Block](ctx, blockId)
+		if block != nil && wplugin.FindPluginForController(block.Meta.GetString(waveobj.MetaKey_Controller, "")) != nil {
+			wplugin.SendBlockEvent(block, "", wshrpc.PluginBlockEvent_Stop)
+		}
 		return nil
 	}
 	bc.StopShellProc(true)
@@ -320,6 +377,12 @@ func (ws *WshServer) ControllerInputCommand(ctx context.Context, data wshrpc.Com
 	if bc == nil {
 		return fmt.Errorf("block controller not found for block %q", data.BlockId)
 	}
+	if len(data.InputData64) > 0 {
+		err := bc.ArbitrateInput(wshutil.GetRpcSourceFromContext(ctx))
+		if err != nil {
+			return err
+		}
+	}
 	inputUnion := &blockcontroller.BlockInputUnion{
 		SigName:  data.SigName,
 		TermSize: data.TermSize,
@@ -335,6 +398,36 @@ func (ws *WshServer) ControllerInputCommand(ctx context.Context, data wshrpc.Com
 	return bc.SendInput(inputUnion)
 }
 
+func (ws *WshServer) ControllerOutputAckCommand(ctx context.Context, data wshrpc.CommandControllerOutputAckData) error {
+	blockcontroller.HandleOutputAck(data.BlockId, data.Offset)
+	return nil
+}
+
+func (ws *WshServer) ControllerResizeCommand(ctx context.Context, data wshrpc.CommandControllerResizeData) error {
+	bc := blockcontroller.GetBlockController(data.BlockId)
+	if bc == nil {
+		return fmt.Errorf("block controller not found for block %q", data.BlockId)
+	}
+	if !bc.CanResize(wshutil.GetRpcSourceFromContext(ctx)) {
+		// another window is typing in the block, it sets the size
+		return nil
+	}
+	bc.RequestResize(data.TermSize, data.Final)
+	return nil
+}
+
+func (ws *WshServer) ControllerSignalCommand(ctx context.Context, data wshrpc.CommandControllerSignalData) error {
+	return blockcontroller.SendSignal(data.BlockId, data.Signal, data.Pid)
+}
+
+func (ws *WshServer) ControllerProcessTreeCommand(ctx context.Context, data wshrpc.CommandControllerProcessTreeData) ([]wshrpc.ProcessInfo, error) {
+	return blockcontroller.GetProcessTree(data.BlockId)
+}
+
+func (ws *WshServer) ControllerPasteCommand(ctx context.Context, data wshrpc.CommandControllerPasteData) (*wshrpc.CommandControllerPasteRtnData, error) {
+	return blockcontroller.Paste(data.BlockId, data.Text, data.Confirm)
+}
+
 func (ws *WshServer) ControllerAppendOutputCommand(ctx context.Context, data wshrpc.CommandControllerAppendOutputData) error {
 	outputBuf := make([]byte, base64.StdEncoding.DecodedLen(len(data.Data64)))
 	nw, err := base64.StdEncoding.Decode(outputBuf, []byte(data.Data64))
@@ -389,6 +482,48 @@ func (ws *WshServer) FileReadStreamCommand(ctx context.Context, data wshrpc.File
 	return fileshare.ReadStream(ctx, data)
 }
 
+func (ws *WshServer) FileWatchCommand(ctx context.Context, data wshrpc.CommandFileWatchData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileWatchEvent] {
+	return fileshare.Watch(ctx, data)
+}
+
+func (ws *WshServer) FileUploadChunkCommand(ctx context.Context, data wshrpc.CommandFileUploadChunkData) (*wshrpc.FileTransferStatus, error) {
+	return fileshare.UploadChunk(ctx, data)
+}
+
+func (ws *WshServer) FileTransferStatusCommand(ctx context.Context, data wshrpc.CommandFileTransferData) (*wshrpc.FileTransferStatus, error) {
+	return fileshare.TransferStatus(ctx, data)
+}
+
+func (ws *WshServer) FileTransferCancelCommand(ctx context.Context, data wshrpc.CommandFileTransferData) error {
+	return fileshare.TransferCancel(ctx, data)
+}
+
+func (ws *WshServer) FileDownloadCommand(ctx context.Context, data wshrpc.CommandFileTransferData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileTransferChunk] {
+	return fileshare.Download(ctx, data)
+}
+
+func (ws *WshServer) FileImportCommand(ctx context.Context, data wshrpc.CommandFileImportData) (*wshrpc.FileInfo, error) {
+	opts := filestore.ImportOpts{Name: data.Name, Reference: data.Reference, Overwrite: data.Overwrite}
+	file, err := filestore.WFS.ImportFile(ctx, data.BlockId, data.Path, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error importing %s: %w", data.Path, err)
+	}
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   data.BlockId,
+		FileName: file.Name,
+		FileOp:   wps.FileOp_Invalidate,
+	}})
+	return wavefileutil.WaveFileToFileInfo(file), nil
+}
+
+func (ws *WshServer) FileReadRangesCommand(ctx context.Context, data wshrpc.CommandFileReadRangesData) (*wshrpc.FileReadRangesData, error) {
+	return fileshare.ReadRanges(ctx, data)
+}
+
+func (ws *WshServer) FileExportCommand(ctx context.Context, data wshrpc.CommandFileExportData) <-chan wshrpc.RespOrErrorUnion[wshrpc.FileExportProgress] {
+	return fileshare.Export(ctx, data)
+}
+
 func (ws *WshServer) FileCopyCommand(ctx context.Context, data wshrpc.CommandFileCopyData) error {
 	return fileshare.Copy(ctx, data)
 }
@@ -417,16 +552,12 @@ func (ws *WshServer) FileAppendIJsonCommand(ctx context.Context, data wshrpc.Com
 	if err != nil {
 		return fmt.Errorf("error appending to blockfile(ijson): %w", err)
 	}
-	wps.Broker.Publish(wps.WaveEvent{
-		Event:  wps.Event_BlockFile,
-		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, data.ZoneId).String()},
-		Data: &wps.WSFileEventData{
-			ZoneId:   data.ZoneId,
-			FileName: data.FileName,
-			FileOp:   wps.FileOp_Append,
-			Data64:   base64.StdEncoding.EncodeToString([]byte("{}")),
-		},
-	})
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   data.ZoneId,
+		FileName: data.FileName,
+		FileOp:   wps.FileOp_Append,
+		Data64:   base64.StdEncoding.EncodeToString([]byte("{}")),
+	}})
 	return nil
 }
 
@@ -459,7 +590,25 @@ func (ws *WshServer) DeleteBlockCommand(ctx context.Context, data wshrpc.Command
 		return fmt.Errorf("error finding tab for block: %w", err)
 	}
 	if tabId == "" {
-		return fmt.Errorf("no tab found for block")
+		block, err := wstore.DBGet[*waveobj.Block](ctx, data.BlockId)
+		if err != nil {
+			return fmt.Errorf("error getting block: %w", err)
+		}
+		if block == nil {
+			return fmt.Errorf("block not found: %q", data.BlockId)
+		}
+		// the block can be detached (or attached) while it is deleted, so it can have no parent here
+		parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+		if parentORef == nil || parentORef.OType != waveobj.OType_Client {
+			return fmt.Errorf("no tab found for block")
+		}
+		// detached block, not in any layout
+		err = wcore.DeleteBlock(ctx, data.BlockId, false)
+		if err != nil {
+			return fmt.Errorf("error deleting block: %w", err)
+		}
+		eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+		return nil
 	}
 	err = wcore.DeleteBlock(ctx, data.BlockId, true)
 	if err != nil {
@@ -470,10 +619,65 @@ func (ws *WshServer) DeleteBlockCommand(ctx context.Context, data wshrpc.Command
 		BlockId:    data.BlockId,
 	})
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
-	wps.Broker.SendUpdateEvents(updates)
+	eventbus.PublishObjectUpdates(updates)
+	return nil
+}
+
+func (ws *WshServer) DetachBlockCommand(ctx context.Context, data wshrpc.CommandDetachBlockData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	_, err := wcore.DetachBlock(ctx, data.BlockId)
+	if err != nil {
+		return err
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) AttachBlockCommand(ctx context.Context, data wshrpc.CommandAttachBlockData) error {
+	if data.TabId == "" {
+		return fmt.Errorf("no tab specified")
+	}
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.AttachBlock(ctx, data.BlockId, data.TabId)
+	if err != nil {
+		return err
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
 	return nil
 }
 
+func (ws *WshServer) ListDetachedBlocksCommand(ctx context.Context) ([]wshrpc.DetachedBlockInfo, error) {
+	blocks, err := wcore.GetDetachedBlocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]wshrpc.DetachedBlockInfo, 0, len(blocks))
+	for _, block := range blocks {
+		info := wshrpc.DetachedBlockInfo{
+			BlockId:    block.OID,
+			View:       block.Meta.GetString(waveobj.MetaKey_View, ""),
+			Controller: block.Meta.GetString(waveobj.MetaKey_Controller, ""),
+			ConnName:   block.Meta.GetString(waveobj.MetaKey_Connection, ""),
+			Cwd:        block.Meta.GetString(waveobj.MetaKey_CmdCwd, ""),
+		}
+		if bc := blockcontroller.GetBlockController(block.OID); bc != nil {
+			rtStatus := bc.GetRuntimeStatus()
+			info.ShellProcStatus = rtStatus.ShellProcStatus
+			info.ShellProcExitCode = rtStatus.ShellProcExitCode
+		}
+		rtn = append(rtn, info)
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) SaveEnvSnapshotCommand(ctx context.Context, data wshrpc.CommandSaveEnvSnapshotData) error {
+	return wcore.SaveEnvSnapshot(ctx, data.BlockId, data.Cwd, data.Env)
+}
+
+func (ws *WshServer) GetEnvSnapshotCommand(ctx context.Context, blockId string) (*wshrpc.EnvSnapshot, error) {
+	return wcore.GetEnvSnapshot(ctx, blockId)
+}
+
 func (ws *WshServer) WaitForRouteCommand(ctx context.Context, data wshrpc.CommandWaitForRouteData) (bool, error) {
 	waitCtx, cancelFn := context.WithTimeout(ctx, time.Duration(data.WaitMs)*time.Millisecond)
 	defer cancelFn()
@@ -529,6 +733,15 @@ func (ws *WshServer) EventReadHistoryCommand(ctx context.Context, data wshrpc.Co
 	return events, nil
 }
 
+func (ws *WshServer) EventReplayCommand(ctx context.Context, data wshrpc.CommandEventReplayData) (*wshrpc.EventReplayRtnData, error) {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if rpcSource == "" {
+		return nil, fmt.Errorf("no rpc source set")
+	}
+	events, lastSeq, complete := wps.Broker.SubscribeAndReplay(rpcSource, data.Subscriptions, data.SinceSeq)
+	return &wshrpc.EventReplayRtnData{Events: events, LastSeq: lastSeq, Complete: complete}, nil
+}
+
 func (ws *WshServer) SetConfigCommand(ctx context.Context, data wshrpc.MetaSettingsType) error {
 	log.Printf("SETCONFIG: %v\n", data)
 	return wconfig.SetBaseConfigValue(data.MetaMapType)
@@ -549,6 +762,22 @@ func (ws *WshServer) ConnStatusCommand(ctx context.Context) ([]wshrpc.ConnStatus
 	return rtn, nil
 }
 
+func (ws *WshServer) ConnMetricsCommand(ctx context.Context, data wshrpc.CommandConnMetricsData) ([]wshrpc.ConnMetrics, error) {
+	if data.Connection == "" {
+		return conncontroller.GetAllConnMetrics(!data.NoHistory), nil
+	}
+	connName := wconn.ResolveConnName(ctx, data.Connection)
+	opts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing connection name: %w", err)
+	}
+	conn := conncontroller.GetConn(opts)
+	if conn == nil {
+		return nil, fmt.Errorf("%s has not been connected", connName)
+	}
+	return []wshrpc.ConnMetrics{conn.GetMetrics(!data.NoHistory)}, nil
+}
+
 func (ws *WshServer) WslStatusCommand(ctx context.Context) ([]wshrpc.ConnStatus, error) {
 	rtn := wslconn.GetAllConnStatus()
 	return rtn, nil
@@ -745,6 +974,33 @@ func (ws *WshServer) WslDefaultDistroCommand(ctx context.Context) (string, error
 	return distro.Name(), nil
 }
 
+func (ws *WshServer) WslDistroListCommand(ctx context.Context) ([]wshrpc.WslDistroInfo, error) {
+	return wslconn.ListDistros(ctx)
+}
+
+func (ws *WshServer) WslSetShellCommand(ctx context.Context, data wshrpc.CommandWslSetShellData) error {
+	distro := strings.TrimPrefix(data.Distro, "wsl://")
+	if distro == "" {
+		return fmt.Errorf("distro is required")
+	}
+	return wslconn.SetDistroShell(ctx, distro, data.ShellPath)
+}
+
+func (ws *WshServer) WslPathCommand(ctx context.Context, data wshrpc.CommandWslPathData) (*wshrpc.WslPathResult, error) {
+	if data.ToWindows {
+		winPath, err := wsl.WslToWindowsPath(strings.TrimPrefix(data.Distro, "wsl://"), data.Path)
+		if err != nil {
+			return nil, err
+		}
+		return &wshrpc.WslPathResult{Path: winPath}, nil
+	}
+	distro, wslPath, err := wsl.WindowsToWslPath(data.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &wshrpc.WslPathResult{Path: wslPath, Distro: distro}, nil
+}
+
 /**
  * Dismisses the WshFail Command in runtime memory on the backend
  */
@@ -973,3 +1229,782 @@ func (ws *WshServer) GetTabCommand(ctx context.Context, tabId string) (*waveobj.
 	}
 	return tab, nil
 }
+
+func (ws *WshServer) PluginRegisterCommand(ctx context.Context, data wshrpc.CommandPluginRegisterData) error {
+	plugin := wplugin.GetPluginFromSource(wshutil.GetRpcSourceFromContext(ctx))
+	if plugin == nil {
+		return fmt.Errorf("caller is not a plugin")
+	}
+	err := plugin.Register(data)
+	if err != nil {
+		return fmt.Errorf("error registering plugin %q: %w", plugin.PluginId, err)
+	}
+	return nil
+}
+
+func (ws *WshServer) PluginListCommand(ctx context.Context) ([]wshrpc.PluginInfo, error) {
+	return wplugin.ListPlugins(), nil
+}
+
+func (ws *WshServer) PluginTokenIssueCommand(ctx context.Context, data wshrpc.CommandPluginTokenIssueData) (*wshrpc.PluginTokenRtnData, error) {
+	return wplugin.IssueToken(ctx, data)
+}
+
+func (ws *WshServer) PluginRevokeCommand(ctx context.Context, data wshrpc.CommandPluginRevokeData) error {
+	return wplugin.RevokePlugin(data.PluginId)
+}
+
+func (ws *WshServer) PluginCallCommand(ctx context.Context, data wshrpc.CommandPluginCallData) (any, error) {
+	return wplugin.CallHandler(ctx, data)
+}
+
+func (ws *WshServer) PluginGetMetaCommand(ctx context.Context, data wshrpc.CommandPluginBlockData) (waveobj.MetaMapType, error) {
+	_, block, err := wplugin.CheckBlockAccess(ctx, wshutil.GetRpcSourceFromContext(ctx), data.BlockId, wshrpc.PluginCap_MetaRead)
+	if err != nil {
+		return nil, err
+	}
+	return block.Meta, nil
+}
+
+func (ws *WshServer) PluginSetMetaCommand(ctx context.Context, data wshrpc.CommandPluginSetMetaData) error {
+	_, _, err := wplugin.CheckBlockAccess(ctx, wshutil.GetRpcSourceFromContext(ctx), data.BlockId, wshrpc.PluginCap_MetaWrite)
+	if err != nil {
+		return err
+	}
+	if _, ok := data.Meta[waveobj.MetaKey_View]; ok {
+		return fmt.Errorf("plugins cannot change the view of a block")
+	}
+	if _, ok := data.Meta[waveobj.MetaKey_Controller]; ok {
+		return fmt.Errorf("plugins cannot change the controller of a block")
+	}
+	oref := waveobj.MakeORef(waveobj.OType_Block, data.BlockId)
+	err = wstore.UpdateObjectMeta(ctx, oref, data.Meta, false)
+	if err != nil {
+		return fmt.Errorf("error updating block meta: %w", err)
+	}
+	sendWaveObjUpdate(oref)
+	return nil
+}
+
+func (ws *WshServer) PluginReadFileCommand(ctx context.Context, data wshrpc.CommandPluginFileData) (string, error) {
+	_, _, err := wplugin.CheckBlockAccess(ctx, wshutil.GetRpcSourceFromContext(ctx), data.BlockId, wshrpc.PluginCap_FileRead)
+	if err != nil {
+		return "", err
+	}
+	_, fileData, err := filestore.WFS.ReadFile(ctx, data.BlockId, data.FileName)
+	if err != nil {
+		return "", fmt.Errorf("error reading blockfile: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(fileData), nil
+}
+
+func (ws *WshServer) PluginWriteFileCommand(ctx context.Context, data wshrpc.CommandPluginFileData) error {
+	_, _, err := wplugin.CheckBlockAccess(ctx, wshutil.GetRpcSourceFromContext(ctx), data.BlockId, wshrpc.PluginCap_FileWrite)
+	if err != nil {
+		return err
+	}
+	if data.FileName == "" {
+		return fmt.Errorf("filename is required")
+	}
+	dataBuf, err := base64.StdEncoding.DecodeString(data.Data64)
+	if err != nil {
+		return fmt.Errorf("error decoding data64: %w", err)
+	}
+	_, err = filestore.WFS.Stat(ctx, data.BlockId, data.FileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = filestore.WFS.MakeFile(ctx, data.BlockId, data.FileName, nil, wshrpc.FileOpts{})
+	}
+	if err != nil {
+		return fmt.Errorf("error making blockfile: %w", err)
+	}
+	if data.Append {
+		err = filestore.WFS.AppendData(ctx, data.BlockId, data.FileName, dataBuf)
+	} else {
+		err = filestore.WFS.WriteFile(ctx, data.BlockId, data.FileName, dataBuf)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing blockfile: %w", err)
+	}
+	eventbus.Publish(eventbus.BlockFileEvent{File: wps.WSFileEventData{
+		ZoneId:   data.BlockId,
+		FileName: data.FileName,
+		FileOp:   wps.FileOp_Invalidate,
+	}})
+	return nil
+}
+
+func (ws *WshServer) ListActionsCommand(ctx context.Context, data wshrpc.CommandListActionsData) ([]wshrpc.ActionDef, error) {
+	return waction.ListActions(ctx, data.ORef)
+}
+
+func (ws *WshServer) ExecuteActionCommand(ctx context.Context, data wshrpc.CommandExecuteActionData) error {
+	return waction.ExecuteAction(ctx, data)
+}
+
+func (ws *WshServer) PaletteSearchCommand(ctx context.Context, data wshrpc.CommandPaletteSearchData) ([]wshrpc.PaletteItem, error) {
+	return palette.Search(ctx, data)
+}
+
+func (ws *WshServer) TermGetSegmentsCommand(ctx context.Context, data wshrpc.CommandTermGetSegmentsData) ([]wshrpc.TermSegment, error) {
+	return blockcontroller.GetTermSegments(ctx, data.BlockId)
+}
+
+func (ws *WshServer) TermGetSegmentOutputCommand(ctx context.Context, data wshrpc.CommandTermGetSegmentOutputData) (*wshrpc.TermSegmentOutput, error) {
+	return blockcontroller.GetTermSegmentOutput(ctx, data.BlockId, data.SegIdx, data.IncludePrompt)
+}
+
+func (ws *WshServer) TermGetLinksCommand(ctx context.Context, data wshrpc.CommandTermGetLinksData) ([]wshrpc.TermLink, error) {
+	return blockcontroller.GetTermLinks(ctx, data.BlockId, data.StartOffset)
+}
+
+func (ws *WshServer) TermRecordStartCommand(ctx context.Context, data wshrpc.CommandTermRecordData) (*wshrpc.TermRecording, error) {
+	return blockcontroller.StartRecording(data.BlockId, data.Title)
+}
+
+func (ws *WshServer) TermRecordStopCommand(ctx context.Context, data wshrpc.CommandTermRecordData) (*wshrpc.TermRecording, error) {
+	return blockcontroller.StopRecording(data.BlockId)
+}
+
+func (ws *WshServer) TermListRecordingsCommand(ctx context.Context, data wshrpc.CommandTermRecordData) ([]wshrpc.TermRecording, error) {
+	return blockcontroller.ListRecordings(ctx, data.BlockId)
+}
+
+func (ws *WshServer) TermPaneCreateCommand(ctx context.Context, data wshrpc.CommandTermPaneCreateData) (*wshrpc.TermPaneInfo, error) {
+	return blockcontroller.CreatePane(data.BlockId, data.Cmd, data.Switch)
+}
+
+func (ws *WshServer) TermPaneKillCommand(ctx context.Context, data wshrpc.CommandTermPaneData) error {
+	return blockcontroller.KillPane(data.BlockId, data.PaneId)
+}
+
+func (ws *WshServer) TermPaneSwitchCommand(ctx context.Context, data wshrpc.CommandTermPaneData) error {
+	return blockcontroller.SwitchPane(data.BlockId, data.PaneId)
+}
+
+func (ws *WshServer) TermQueueAddCommand(ctx context.Context, data wshrpc.CommandTermQueueData) (*wshrpc.QueuedCommand, error) {
+	return blockcontroller.QueueCommand(data.BlockId, data.Cmd)
+}
+
+func (ws *WshServer) TermQueueListCommand(ctx context.Context, data wshrpc.CommandTermQueueData) ([]wshrpc.QueuedCommand, error) {
+	return blockcontroller.ListQueuedCommands(data.BlockId)
+}
+
+func (ws *WshServer) TermQueueCancelCommand(ctx context.Context, data wshrpc.CommandTermQueueData) error {
+	return blockcontroller.CancelQueuedCommand(data.BlockId, data.Id)
+}
+
+func checkElectronSource(ctx context.Context) error {
+	if wshutil.GetRpcSourceFromContext(ctx) != wshutil.ElectronRoute {
+		return fmt.Errorf("only allowed from electron")
+	}
+	return nil
+}
+
+func (ws *WshServer) AuthTokenIssueCommand(ctx context.Context, data wshrpc.CommandAuthTokenData) (*wshrpc.AuthTokenRtnData, error) {
+	if err := checkElectronSource(ctx); err != nil {
+		return nil, err
+	}
+	if data.TabId == "" {
+		return nil, fmt.Errorf("tabid is required")
+	}
+	return &wshrpc.AuthTokenRtnData{Token: authkey.IssueClientToken(data.TabId)}, nil
+}
+
+func (ws *WshServer) AuthTokenRevokeCommand(ctx context.Context, data wshrpc.CommandAuthTokenData) error {
+	if err := checkElectronSource(ctx); err != nil {
+		return err
+	}
+	authkey.RevokeClientToken(data.TabId)
+	return nil
+}
+
+func (ws *WshServer) TermPaneListCommand(ctx context.Context, data wshrpc.CommandTermPaneData) ([]wshrpc.TermPaneInfo, error) {
+	return blockcontroller.ListPanes(data.BlockId)
+}
+
+func (ws *WshServer) PlayerLoadCommand(ctx context.Context, data wshrpc.CommandPlayerLoadData) (*wshrpc.PlayerStatus, error) {
+	return castplayer.Load(ctx, data.BlockId, data.Src)
+}
+
+func (ws *WshServer) PlayerPlayCommand(ctx context.Context, data wshrpc.CommandPlayerData) (*wshrpc.PlayerStatus, error) {
+	return castplayer.Play(data.BlockId)
+}
+
+func (ws *WshServer) PlayerPauseCommand(ctx context.Context, data wshrpc.CommandPlayerData) (*wshrpc.PlayerStatus, error) {
+	return castplayer.Pause(data.BlockId)
+}
+
+func (ws *WshServer) PlayerSeekCommand(ctx context.Context, data wshrpc.CommandPlayerSeekData) (*wshrpc.PlayerStatus, error) {
+	return castplayer.Seek(data.BlockId, data.Pos)
+}
+
+func (ws *WshServer) PlayerSetSpeedCommand(ctx context.Context, data wshrpc.CommandPlayerSetSpeedData) (*wshrpc.PlayerStatus, error) {
+	return castplayer.SetSpeed(data.BlockId, data.Speed)
+}
+
+func (ws *WshServer) CmdHistorySearchCommand(ctx context.Context, data wshrpc.CommandCmdHistorySearchData) ([]*wshrpc.CmdHistoryEntry, error) {
+	return cmdhistory.Search(ctx, data)
+}
+
+func (ws *WshServer) CmdHistoryDeleteCommand(ctx context.Context, historyIds []string) error {
+	return cmdhistory.DeleteEntries(ctx, historyIds)
+}
+
+func (ws *WshServer) WebhookCreateCommand(ctx context.Context, data wshrpc.CommandWebhookCreateData) (*wshrpc.WebhookCreateRtnData, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	hook, secret, err := webhook.CreateWebhook(ctx, &waveobj.Webhook{Name: data.Name, Url: data.Url, Filters: data.Filters}, data.Secret)
+	if err != nil {
+		return nil, err
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return &wshrpc.WebhookCreateRtnData{Webhook: hook, Secret: secret}, nil
+}
+
+func (ws *WshServer) WebhookListCommand(ctx context.Context) ([]*waveobj.Webhook, error) {
+	return webhook.ListWebhooks(ctx)
+}
+
+func (ws *WshServer) WebhookDeleteCommand(ctx context.Context, data wshrpc.CommandWebhookData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := webhook.DeleteWebhook(ctx, data.WebhookId)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) WebhookSetDisabledCommand(ctx context.Context, data wshrpc.CommandWebhookData) (*waveobj.Webhook, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	hook, err := webhook.SetWebhookDisabled(ctx, data.WebhookId, data.Disabled)
+	if err != nil {
+		return nil, fmt.Errorf("error updating webhook: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return hook, nil
+}
+
+func (ws *WshServer) WebhookTestCommand(ctx context.Context, data wshrpc.CommandWebhookData) error {
+	return webhook.SendTestEvent(ctx, data.WebhookId)
+}
+
+func (ws *WshServer) WebhookDeliveriesCommand(ctx context.Context, data wshrpc.CommandWebhookData) ([]*waveobj.WebhookDelivery, error) {
+	deliveries, err := webhook.ListDeliveries(ctx, data.WebhookId)
+	if err != nil {
+		return nil, err
+	}
+	if data.Limit > 0 && len(deliveries) > data.Limit {
+		deliveries = deliveries[:data.Limit]
+	}
+	return deliveries, nil
+}
+
+func (ws *WshServer) ConnectionCreateCommand(ctx context.Context, data waveobj.Connection) (*waveobj.Connection, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	conn, err := wconn.CreateConnection(ctx, &data)
+	if err != nil {
+		return nil, fmt.Errorf("error creating connection: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return conn, nil
+}
+
+func (ws *WshServer) ConnectionUpdateCommand(ctx context.Context, data waveobj.Connection) (*waveobj.Connection, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	conn, err := wconn.UpdateConnection(ctx, &data)
+	if err != nil {
+		return nil, fmt.Errorf("error updating connection: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return conn, nil
+}
+
+func (ws *WshServer) ConnectionListCommand(ctx context.Context, data wshrpc.CommandConnectionListData) ([]*wshrpc.ConnectionInfo, error) {
+	conns, err := wconn.ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []*wshrpc.ConnectionInfo
+	for _, conn := range conns {
+		if data.Tag != "" && !utilfn.ContainsStr(conn.Tags, data.Tag) {
+			continue
+		}
+		info, err := wconn.GetConnectionInfo(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, info)
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) ConnectionDeleteCommand(ctx context.Context, data wshrpc.CommandConnectionData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	conn, err := wconn.ResolveConnection(ctx, data.Connection)
+	if err != nil {
+		return err
+	}
+	err = wconn.DeleteConnection(ctx, conn.OID, data.Force)
+	if err != nil {
+		return fmt.Errorf("error deleting connection: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) ConnectionOpenTermCommand(ctx context.Context, data wshrpc.CommandConnectionData) (*waveobj.ORef, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return nil, fmt.Errorf("no tab to open the terminal in")
+	}
+	conn, err := wconn.ResolveConnection(ctx, data.Connection)
+	if err != nil {
+		return nil, err
+	}
+	block, err := wconn.OpenTerm(ctx, conn, data.TabId)
+	if err != nil {
+		return nil, fmt.Errorf("error creating block: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return &waveobj.ORef{OType: waveobj.OType_Block, OID: block.OID}, nil
+}
+
+func (ws *WshServer) ConnectionSetKeyCommand(ctx context.Context, data wshrpc.CommandConnectionSetKeyData) (*waveobj.Connection, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	conn, err := wconn.ResolveConnection(ctx, data.Connection)
+	if err != nil {
+		return nil, err
+	}
+	conn, err = sshkeys.SetConnectionKey(ctx, conn, data.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error setting the key of connection %q: %w", data.Connection, err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return conn, nil
+}
+
+func (ws *WshServer) SshKeyListCommand(ctx context.Context) ([]wshrpc.SshKeyInfo, error) {
+	return sshkeys.ListKeys(ctx)
+}
+
+func (ws *WshServer) SshKeyGenerateCommand(ctx context.Context, data wshrpc.CommandSshKeyGenerateData) (*wshrpc.SshKeyInfo, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	info, err := sshkeys.GenerateKey(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("error generating key: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return info, nil
+}
+
+func (ws *WshServer) SshKeyAddToAgentCommand(ctx context.Context, data wshrpc.CommandSshKeyAddToAgentData) error {
+	return sshkeys.AddKeyToAgent(data)
+}
+
+func (ws *WshServer) HostKeyListCommand(ctx context.Context) ([]wshrpc.KnownHostKey, error) {
+	return remote.ListManagedHostKeys()
+}
+
+func (ws *WshServer) HostKeyForgetCommand(ctx context.Context, data wshrpc.CommandHostKeyForgetData) (int, error) {
+	return remote.ForgetHostKeys(data.Host)
+}
+
+func (ws *WshServer) PortForwardCreateCommand(ctx context.Context, data wshrpc.CommandPortForwardCreateData) (*wshrpc.PortForwardInfo, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	info, err := portforward.CreateForward(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("error creating port forward: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return info, nil
+}
+
+func (ws *WshServer) PortForwardListCommand(ctx context.Context, data wshrpc.CommandPortForwardListData) ([]wshrpc.PortForwardInfo, error) {
+	return portforward.ListForwards(ctx, data)
+}
+
+func (ws *WshServer) PortForwardStopCommand(ctx context.Context, data wshrpc.CommandPortForwardStopData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := portforward.StopForward(ctx, data)
+	if err != nil {
+		return err
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) ConnDashboardCommand(ctx context.Context) ([]wshrpc.ConnDashboardEntry, error) {
+	return conndashboard.GetDashboard(ctx)
+}
+
+func (ws *WshServer) ClusterExecStartCommand(ctx context.Context, data wshrpc.CommandClusterExecData) (*wshrpc.ClusterExecRun, error) {
+	return clusterexec.Start(ctx, data)
+}
+
+func (ws *WshServer) ClusterExecGetCommand(ctx context.Context, data wshrpc.CommandClusterExecRunData) (*wshrpc.ClusterExecRun, error) {
+	return clusterexec.Get(data.RunId)
+}
+
+func (ws *WshServer) ClusterExecCancelCommand(ctx context.Context, data wshrpc.CommandClusterExecRunData) error {
+	return clusterexec.Cancel(data.RunId)
+}
+
+func (ws *WshServer) SecretSetCommand(ctx context.Context, data wshrpc.CommandSecretSetData) error {
+	return secretstore.SetSecret(resolveSecretConn(ctx, data.Connection), data.Name, data.Value)
+}
+
+func (ws *WshServer) SecretListCommand(ctx context.Context, data wshrpc.CommandSecretListData) ([]wshrpc.SecretInfo, error) {
+	return secretstore.ListSecrets(resolveSecretConn(ctx, data.Connection))
+}
+
+func (ws *WshServer) SecretDeleteCommand(ctx context.Context, data wshrpc.CommandSecretData) error {
+	return secretstore.DeleteSecret(resolveSecretConn(ctx, data.Connection), data.Name)
+}
+
+func (ws *WshServer) SettingsGetCommand(ctx context.Context, data wshrpc.CommandSettingsData) (waveobj.MetaMapType, error) {
+	settings, err := wsettings.Get(ctx, data.WorkspaceId)
+	if err != nil || settings == nil {
+		return waveobj.MetaMapType{}, err
+	}
+	return settings.Settings, nil
+}
+
+func (ws *WshServer) SettingsSetCommand(ctx context.Context, data wshrpc.CommandSettingsSetData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	_, err := wsettings.Set(ctx, data.WorkspaceId, data.Settings)
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return err
+}
+
+func (ws *WshServer) SettingsResolveCommand(ctx context.Context, data wshrpc.CommandSettingsData) (waveobj.MetaMapType, error) {
+	return wsettings.ResolveMap(ctx, data.WorkspaceId)
+}
+
+func (ws *WshServer) SettingsExplainCommand(ctx context.Context, data wshrpc.CommandSettingsExplainData) ([]wshrpc.SettingValueInfo, error) {
+	scope := wsettings.Scope{WorkspaceId: data.WorkspaceId, BlockId: data.BlockId}
+	if data.Connection != "" {
+		scope.Connection = wconn.ResolveConnName(ctx, data.Connection)
+	}
+	return wsettings.Explain(ctx, scope, data.Keys)
+}
+
+func (ws *WshServer) SettingsMigrateCommand(ctx context.Context, data wshrpc.CommandSettingsMigrateData) ([]wconfig.SettingsMigration, error) {
+	return wconfig.MigrateSettingsFiles(data.DryRun)
+}
+
+func (ws *WshServer) SettingsImportCommand(ctx context.Context, data wshrpc.CommandSettingsImportData) (*wshrpc.SettingsImportResult, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	rtn, err := termimport.Import(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("error importing settings: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return rtn, nil
+}
+
+func (ws *WshServer) ProfileListCommand(ctx context.Context) (*wshrpc.ProfileListData, error) {
+	profiles, active, err := wsettings.ListProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rtn := &wshrpc.ProfileListData{Profiles: profiles}
+	if active != nil {
+		rtn.Active = active.Name
+		rtn.ActiveSettings = wsettings.ProfileSettings(active)
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) ProfileSaveCommand(ctx context.Context, data waveobj.Profile) (*waveobj.Profile, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	profile, err := wsettings.SaveProfile(ctx, &data)
+	if err != nil {
+		return nil, fmt.Errorf("error saving profile: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return profile, nil
+}
+
+func (ws *WshServer) ProfileDeleteCommand(ctx context.Context, data wshrpc.CommandProfileData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wsettings.DeleteProfile(ctx, data.Name)
+	if err != nil {
+		return err
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) ProfileSwitchCommand(ctx context.Context, data wshrpc.CommandProfileData) (*waveobj.Profile, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	profile, err := wsettings.SwitchProfile(ctx, data.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error switching profile: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return profile, nil
+}
+
+func (ws *WshServer) FontListCommand(ctx context.Context) ([]wshrpc.FontInfo, error) {
+	var rtn []wshrpc.FontInfo
+	for _, family := range fontutil.ListFamilies() {
+		rtn = append(rtn, wshrpc.FontInfo{Name: family.Name, Source: family.Source})
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) TermFontGetCommand(ctx context.Context, data wshrpc.CommandTermFontData) (*wshrpc.TermFontInfo, error) {
+	font, err := wsettings.ResolveTermFont(ctx, data.BlockId)
+	if err != nil {
+		return nil, err
+	}
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, data.BlockId)
+	if err != nil {
+		return nil, err
+	}
+	rtn := &wshrpc.TermFontInfo{Font: *font}
+	if block.RuntimeOpts != nil {
+		rtn.BlockSet = block.RuntimeOpts.TermFont
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) TermFontSetCommand(ctx context.Context, data wshrpc.CommandTermFontSetData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wsettings.SetTermFont(ctx, data.BlockId, data.Font)
+	if err != nil {
+		return fmt.Errorf("error setting font: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) I18nListCommand(ctx context.Context) ([]wshrpc.I18nCatalogInfo, error) {
+	return wi18n.ListCatalogs(), nil
+}
+
+func (ws *WshServer) I18nCatalogCommand(ctx context.Context, data wshrpc.CommandI18nCatalogData) (*wshrpc.I18nCatalogData, error) {
+	if data.Locale != "" && wi18n.NormalizeLocale(data.Locale) == "" {
+		return nil, fmt.Errorf("invalid locale %q", data.Locale)
+	}
+	return wi18n.GetCatalog(data.Locale), nil
+}
+
+func (ws *WshServer) KeyBindingsListCommand(ctx context.Context, data wshrpc.CommandSettingsData) (*wshrpc.KeyBindingsData, error) {
+	return keybind.ResolveForWorkspace(ctx, data.WorkspaceId)
+}
+
+func (ws *WshServer) KeyBindingResolveCommand(ctx context.Context, data wshrpc.CommandKeyBindingResolveData) (*wshrpc.KeyBindingResolveRtnData, error) {
+	bindings, err := keybind.ResolveForWorkspace(ctx, data.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	return keybind.ResolveKeys(bindings, data.Keys)
+}
+
+func (ws *WshServer) ThemeListCommand(ctx context.Context) ([]wshrpc.ThemeInfo, error) {
+	return wtheme.List(), nil
+}
+
+func (ws *WshServer) ThemeGetCommand(ctx context.Context, data wshrpc.CommandThemeData) (*wconfig.TermThemeType, error) {
+	return wtheme.Get(data.Name)
+}
+
+func (ws *WshServer) ThemeSaveCommand(ctx context.Context, data wshrpc.CommandThemeSaveData) (*waveobj.Theme, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	theme, err := wtheme.Save(ctx, data.Name, data.Theme, "")
+	if err != nil {
+		return nil, fmt.Errorf("error saving theme: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return theme, nil
+}
+
+func (ws *WshServer) ThemeImportCommand(ctx context.Context, data wshrpc.CommandThemeImportData) (*waveobj.Theme, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	theme, err := wtheme.Import(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("error importing theme: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return theme, nil
+}
+
+func (ws *WshServer) ThemeDeleteCommand(ctx context.Context, data wshrpc.CommandThemeData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wtheme.Delete(ctx, data.Name)
+	if err != nil {
+		return err
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) ThemeAssignCommand(ctx context.Context, data wshrpc.CommandThemeAssignData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wtheme.Assign(ctx, data)
+	if err != nil {
+		return err
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+// secrets are scoped to the ssh connection name (the key in connections.json)
+func resolveSecretConn(ctx context.Context, connection string) string {
+	if connection == "" {
+		return ""
+	}
+	return wconn.ResolveConnName(ctx, connection)
+}
+
+func (ws *WshServer) ConnectionRunCommand(ctx context.Context, data wshrpc.CommandConnectionRunData) (*wshrpc.ConnectionRunResult, error) {
+	return wconn.RunCommand(ctx, data, nil)
+}
+
+func (ws *WshServer) ConnectionRunStreamCommand(ctx context.Context, data wshrpc.CommandConnectionRunData) <-chan wshrpc.RespOrErrorUnion[wshrpc.ConnectionRunOutput] {
+	return wconn.RunCommandStream(ctx, data)
+}
+
+func (ws *WshServer) SftpUploadCommand(ctx context.Context, data wshrpc.CommandSftpTransferData) <-chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	return sftpfs.Upload(ctx, data)
+}
+
+func (ws *WshServer) SftpDownloadCommand(ctx context.Context, data wshrpc.CommandSftpTransferData) <-chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	return sftpfs.Download(ctx, data)
+}
+
+func (ws *WshServer) SftpCopyCommand(ctx context.Context, data wshrpc.CommandSftpCopyData) <-chan wshrpc.RespOrErrorUnion[wshrpc.SftpTransferProgress] {
+	return sftpfs.Copy(ctx, data)
+}
+
+func (ws *WshServer) NotificationSendCommand(ctx context.Context, data wshrpc.CommandNotificationSendData) (*waveobj.Notification, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	notif, err := wnotify.Send(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("error sending notification: %w", err)
+	}
+	eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	return notif, nil
+}
+
+func (ws *WshServer) NotificationListCommand(ctx context.Context) ([]*waveobj.Notification, error) {
+	return wnotify.List(ctx)
+}
+
+func (ws *WshServer) NotificationDismissCommand(ctx context.Context, data wshrpc.CommandNotificationDismissData) (int, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	defer func() {
+		eventbus.PublishObjectUpdates(waveobj.ContextGetUpdatesRtn(ctx))
+	}()
+	if data.All {
+		return wnotify.DismissAll(ctx)
+	}
+	err := wnotify.Dismiss(ctx, data.Id)
+	if err != nil {
+		return 0, fmt.Errorf("error dismissing notification: %w", err)
+	}
+	return 1, nil
+}
+
+func (ws *WshServer) StorageUsageCommand(ctx context.Context, data wshrpc.CommandStorageUsageData) ([]wshrpc.StorageUsage, error) {
+	return filequota.GetUsage(ctx, data.ORef)
+}
+
+func (ws *WshServer) FileSearchCommand(ctx context.Context, data wshrpc.CommandFileSearchData) ([]wshrpc.FileSearchHit, error) {
+	return filesearch.Search(ctx, data)
+}
+
+func (ws *WshServer) StorageCheckCommand(ctx context.Context, data wshrpc.CommandStorageCheckData) (*wshrpc.StorageCheckResult, error) {
+	result, err := filestore.WFS.Check(ctx, data.Repair)
+	if err != nil {
+		return nil, err
+	}
+	rtn := &wshrpc.StorageCheckResult{NumBlobs: result.NumBlobs, NumParts: result.NumParts, NumQuarantined: result.NumQuarantined}
+	for _, problem := range result.Problems {
+		rtn.Problems = append(rtn.Problems, wshrpc.StorageCheckProblem{
+			Type:     problem.Type,
+			ZoneId:   problem.ZoneId,
+			Name:     problem.Name,
+			PartIdx:  problem.PartIdx,
+			Detail:   problem.Detail,
+			Repaired: problem.Repaired,
+		})
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) StorageCacheCommand(ctx context.Context) (*wshrpc.StorageCacheStats, error) {
+	stats := filestore.WFS.GetCacheStats()
+	return &wshrpc.StorageCacheStats{
+		Hits:           stats.Hits,
+		Misses:         stats.Misses,
+		Evictions:      stats.Evictions,
+		ReadAheadParts: stats.ReadAheadParts,
+		CachedBytes:    stats.CachedBytes,
+		MaxBytes:       stats.MaxBytes,
+		Policy:         stats.Policy,
+		OpenFiles:      stats.OpenFiles,
+		DirtyFiles:     stats.DirtyFiles,
+	}, nil
+}
+
+func (ws *WshServer) StoragePruneCommand(ctx context.Context, data wshrpc.CommandStoragePruneData) ([]wshrpc.RetentionAction, error) {
+	return fileretention.Run(ctx, data.DryRun)
+}
+
+func (ws *WshServer) StorageReplicaSyncCommand(ctx context.Context) (*wshrpc.ReplicaSyncResult, error) {
+	return filereplica.Sync(ctx)
+}
+
+func (ws *WshServer) StorageReplicaListCommand(ctx context.Context, data wshrpc.CommandStorageReplicaListData) ([]wshrpc.ReplicaFileInfo, error) {
+	return filereplica.List(ctx, data.ZoneId)
+}
+
+func (ws *WshServer) StorageReplicaRestoreCommand(ctx context.Context, data wshrpc.CommandStorageReplicaRestoreData) ([]wshrpc.ReplicaFileInfo, error) {
+	return filereplica.Restore(ctx, data)
+}
+
+func (ws *WshServer) StorageReplicaKeyCommand(ctx context.Context, data wshrpc.CommandStorageReplicaKeyData) (string, error) {
+	return filereplica.Key(ctx, data.Key)
+}
+
+func (ws *WshServer) RemoteSetPasswordCommand(ctx context.Context, data wshrpc.CommandRemoteSetPasswordData) error {
+	return remoteaccess.SetPassword(data.Password)
+}
+
+func (ws *WshServer) RemoteSessionsCommand(ctx context.Context) ([]wshrpc.RemoteSessionInfo, error) {
+	return remoteaccess.ListSessions(), nil
+}
+
+func (ws *WshServer) RemoteRevokeCommand(ctx context.Context, data wshrpc.CommandRemoteRevokeData) (int, error) {
+	if data.All {
+		return remoteaccess.RevokeAllSessions(), nil
+	}
+	if data.SessionId == "" {
+		return 0, fmt.Errorf("sessionid is required")
+	}
+	return remoteaccess.RevokeSession(data.SessionId)
+}
+
+func (ws *WshServer) RemoteShareCreateCommand(ctx context.Context, data wshrpc.CommandRemoteShareCreateData) (*wshrpc.RemoteShareInfo, error) {
+	validFor := time.Duration(data.Hours * float64(time.Hour))
+	share, err := remoteaccess.CreateShareLink(ctx, data.Workspace, data.Perms, validFor)
+	if err != nil {
+		return nil, err
+	}
+	listenAddr := wconfig.GetWatcher().GetFullConfig().Settings.RemoteListenAddr
+	if listenAddr == "" {
+		listenAddr = remoteaccess.DefaultListenAddr
+	}
+	share.Url = remoteaccess.GetShareUrl(share.Token, listenAddr)
+	return share, nil
+}
+
+func (ws *WshServer) RemoteShareListCommand(ctx context.Context) ([]wshrpc.RemoteShareInfo, error) {
+	return remoteaccess.ListShareLinks(ctx), nil
+}
+
+func (ws *WshServer) ShellIntegrationCheckCommand(ctx context.Context, data wshrpc.CommandShellIntegrationCheckData) (*wshrpc.ShellIntegrationStatus, error) {
+	return shellutil.CheckLocalShellIntegration(data.Repair)
+}
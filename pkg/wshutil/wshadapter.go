@@ -147,6 +147,10 @@ func serverImplAdapter(impl any) func(*RpcResponseHandler) bool {
 						handler.SendResponseError(errorVal.Interface().(error))
 						break
 					}
+					if handler.IsCanceled() {
+						// keep draining so the handler is not blocked, but the requestor is gone
+						continue
+					}
 					respData := respVal.FieldByName("Response").Interface()
 					handler.SendResponse(respData, false)
 				}
@@ -0,0 +1,180 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// events are sent to each route through its own bounded queue (and goroutine), so a route that reads slowly
+// (e.g. a stalled websocket) only delays its own events, not the publisher or the other routes.  queued events
+// that carry the full state of an object (waveobj:update, controllerstatus, config) are coalesced, a newer event
+// for the same object replaces the queued one.  if a route falls too far behind, its queued events are dropped
+// and it is sent an events:dropped event instead, so it can reload its state.
+
+const EventQueueMaxEvents = 1000
+const EventQueueMaxBytes = 16 * 1024 * 1024
+
+var coalescedEvents = map[string]bool{
+	wps.Event_WaveObjUpdate:    true,
+	wps.Event_ControllerStatus: true,
+	wps.Event_Config:           true,
+}
+
+type queuedEvent struct {
+	key      string // the coalescing key, empty if the event is not coalesced
+	msgBytes []byte
+}
+
+type routeEventQueue struct {
+	lock     *sync.Mutex
+	routeId  string
+	events   []*queuedEvent
+	keyMap   map[string]*queuedEvent
+	numBytes int
+	dropped  int // the number of events dropped since the last events:dropped event was sent
+	notifyCh chan struct{}
+	doneCh   chan struct{}
+}
+
+func makeRouteEventQueue(routeId string) *routeEventQueue {
+	return &routeEventQueue{
+		lock:     &sync.Mutex{},
+		routeId:  routeId,
+		keyMap:   make(map[string]*queuedEvent),
+		notifyCh: make(chan struct{}, 1),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+func getEventCoalesceKey(event wps.WaveEvent) string {
+	if !coalescedEvents[event.Event] {
+		return ""
+	}
+	return event.Event + "|" + strings.Join(event.Scopes, ",")
+}
+
+func (q *routeEventQueue) enqueue(key string, msgBytes []byte) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if key != "" {
+		if qe := q.keyMap[key]; qe != nil {
+			q.numBytes += len(msgBytes) - len(qe.msgBytes)
+			qe.msgBytes = msgBytes
+			return
+		}
+	}
+	if len(q.events) >= EventQueueMaxEvents || q.numBytes+len(msgBytes) > EventQueueMaxBytes {
+		if q.dropped == 0 {
+			log.Printf("[router] route %q is not reading its events, dropping %d queued events\n", q.routeId, len(q.events))
+		}
+		q.dropped += len(q.events) + 1
+		q.events = nil
+		clear(q.keyMap)
+		q.numBytes = 0
+	} else {
+		qe := &queuedEvent{key: key, msgBytes: msgBytes}
+		q.events = append(q.events, qe)
+		if key != "" {
+			q.keyMap[key] = qe
+		}
+		q.numBytes += len(msgBytes)
+	}
+	select {
+	case q.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// returns the next message to send, the events:dropped event comes first if events were dropped
+func (q *routeEventQueue) pop() []byte {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.dropped > 0 {
+		msg := RpcMessage{
+			Command: wshrpc.Command_EventRecv,
+			Route:   q.routeId,
+			Data:    wps.WaveEvent{Event: wps.Event_EventsDropped, Data: wps.EventsDroppedData{Count: q.dropped}},
+		}
+		q.dropped = 0
+		msgBytes, _ := json.Marshal(msg)
+		return msgBytes
+	}
+	if len(q.events) == 0 {
+		return nil
+	}
+	qe := q.events[0]
+	q.events[0] = nil
+	q.events = q.events[1:]
+	if qe.key != "" {
+		delete(q.keyMap, qe.key)
+	}
+	q.numBytes -= len(qe.msgBytes)
+	return qe.msgBytes
+}
+
+func (q *routeEventQueue) stop() {
+	close(q.doneCh)
+}
+
+func (q *routeEventQueue) run(router *WshRouter) {
+	defer func() {
+		panichandler.PanicHandler("routeEventQueue.run", recover())
+	}()
+	for {
+		select {
+		case <-q.doneCh:
+			return
+		default:
+		}
+		msgBytes := q.pop()
+		if msgBytes == nil {
+			select {
+			case <-q.notifyCh:
+				continue
+			case <-q.doneCh:
+				return
+			}
+		}
+		rpc := router.GetRpc(q.routeId)
+		if rpc == nil {
+			continue
+		}
+		// may block if the route is slow, only this route's events wait
+		rpc.SendRpcMessage(msgBytes)
+	}
+}
+
+// returns nil if the route does not exist
+func (router *WshRouter) getEventQueue(routeId string) *routeEventQueue {
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	if router.RouteMap[routeId] == nil {
+		return nil
+	}
+	q := router.EventQueues[routeId]
+	if q == nil {
+		q = makeRouteEventQueue(routeId)
+		router.EventQueues[routeId] = q
+		go q.run(router)
+	}
+	return q
+}
+
+// must hold router.Lock
+func (router *WshRouter) stopEventQueue_nolock(routeId string) {
+	q := router.EventQueues[routeId]
+	if q == nil {
+		return
+	}
+	q.stop()
+	delete(router.EventQueues, routeId)
+}
@@ -102,12 +102,15 @@ func (p *WshRpcProxy) sendAuthenticateTokenResponse(msg RpcMessage, entry *shell
 	p.SendRpcMessage(respBytes)
 }
 
+// validates the token of a plugin connection (set by wplugin, gets around import cycles)
+var PluginAuthCheck func(rpcCtx *wshrpc.RpcContext) error
+
 func validateRpcContextFromAuth(newCtx *wshrpc.RpcContext) (string, error) {
 	if newCtx == nil {
 		return "", fmt.Errorf("no context found in jwt token")
 	}
-	if newCtx.BlockId == "" && newCtx.Conn == "" {
-		return "", fmt.Errorf("no blockid or conn found in jwt token")
+	if newCtx.BlockId == "" && newCtx.Conn == "" && newCtx.PluginId == "" {
+		return "", fmt.Errorf("no blockid, conn, or pluginid found in jwt token")
 	}
 	if newCtx.BlockId != "" {
 		if _, err := uuid.Parse(newCtx.BlockId); err != nil {
@@ -118,6 +121,12 @@ func validateRpcContextFromAuth(newCtx *wshrpc.RpcContext) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("error making routeId from context: %w", err)
 	}
+	if newCtx.ClientType == wshrpc.ClientType_Plugin && PluginAuthCheck != nil {
+		err = PluginAuthCheck(newCtx)
+		if err != nil {
+			return "", err
+		}
+	}
 	return routeId, nil
 }
 
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,6 +31,8 @@ const (
 	RoutePrefix_Proc       = "proc:"
 	RoutePrefix_Tab        = "tab:"
 	RoutePrefix_FeBlock    = "feblock:"
+	RoutePrefix_FeClient   = "feclient:"
+	RoutePrefix_Plugin     = "plugin:"
 )
 
 // this works like a network switch
@@ -51,9 +54,10 @@ type WshRouter struct {
 	Lock             *sync.Mutex
 	RouteMap         map[string]AbstractRpcClient // routeid => client
 	UpstreamClient   AbstractRpcClient            // upstream client (if we are not the terminal router)
-	AnnouncedRoutes  map[string]string            // routeid => local routeid
+	AnnouncedRoutes  map[string][]string          // routeid => local routeids (the last one announced is used)
 	RpcMap           map[string]*routeInfo        // rpcid => routeinfo
 	SimpleRequestMap map[string]chan *RpcMessage  // simple reqid => response channel
+	EventQueues      map[string]*routeEventQueue  // routeid => outgoing events (see wsheventqueue.go)
 	InputCh          chan msgAndRoute
 }
 
@@ -77,15 +81,33 @@ func MakeFeBlockRouteId(blockId string) string {
 	return "feblock:" + blockId
 }
 
+// the route of a frontend's websocket connection (every window or browser tab has its own)
+func MakeFeClientRouteId(feClientId string) string {
+	return "feclient:" + feClientId
+}
+
+func MakePluginRouteId(pluginId string) string {
+	return "plugin:" + pluginId
+}
+
 var DefaultRouter = NewWshRouter()
 
+// checks the commands that plugins send before they are routed, returns an error to reject the command (set by
+// wplugin, gets around import cycles)
+var PluginCommandFilter func(routeId string, msg *RpcMessage) error
+
+func isRestrictedRoute(routeId string) bool {
+	return strings.HasPrefix(routeId, RoutePrefix_Plugin)
+}
+
 func NewWshRouter() *WshRouter {
 	rtn := &WshRouter{
 		Lock:             &sync.Mutex{},
 		RouteMap:         make(map[string]AbstractRpcClient),
-		AnnouncedRoutes:  make(map[string]string),
+		AnnouncedRoutes:  make(map[string][]string),
 		RpcMap:           make(map[string]*routeInfo),
 		SimpleRequestMap: make(map[string]chan *RpcMessage),
+		EventQueues:      make(map[string]*routeEventQueue),
 		InputCh:          make(chan msgAndRoute, DefaultInputChSize),
 	}
 	go rtn.runServer()
@@ -103,8 +125,8 @@ func (router *WshRouter) SendEvent(routeId string, event wps.WaveEvent) {
 	defer func() {
 		panichandler.PanicHandler("WshRouter.SendEvent", recover())
 	}()
-	rpc := router.GetRpc(routeId)
-	if rpc == nil {
+	queue := router.getEventQueue(routeId)
+	if queue == nil {
 		return
 	}
 	msg := RpcMessage{
@@ -117,7 +139,7 @@ func (router *WshRouter) SendEvent(routeId string, event wps.WaveEvent) {
 		// nothing to do
 		return
 	}
-	rpc.SendRpcMessage(msgBytes)
+	queue.enqueue(getEventCoalesceKey(event), msgBytes)
 }
 
 func (router *WshRouter) handleNoRoute(msg RpcMessage) {
@@ -175,21 +197,38 @@ func (router *WshRouter) handleAnnounceMessage(msg RpcMessage, input msgAndRoute
 		// not necessary to save the id mapping
 		return
 	}
+	// several local routes can announce the same route (e.g. two frontends showing the same tab).  the last
+	// one to announce it gets its messages, frontends re-announce their routes when they get focus.
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
-	router.AnnouncedRoutes[msg.Source] = input.fromRouteId
+	localRouteIds := utilfn.RemoveElemFromSlice(router.AnnouncedRoutes[msg.Source], input.fromRouteId)
+	router.AnnouncedRoutes[msg.Source] = append(localRouteIds, input.fromRouteId)
 }
 
-func (router *WshRouter) handleUnannounceMessage(msg RpcMessage) {
+func (router *WshRouter) handleUnannounceMessage(msg RpcMessage, input msgAndRoute) {
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
-	delete(router.AnnouncedRoutes, msg.Source)
+	router.removeAnnouncedRoute_nolock(msg.Source, input.fromRouteId)
+}
+
+// must hold router.Lock
+func (router *WshRouter) removeAnnouncedRoute_nolock(routeId string, localRouteId string) {
+	localRouteIds := utilfn.RemoveElemFromSlice(router.AnnouncedRoutes[routeId], localRouteId)
+	if len(localRouteIds) == 0 {
+		delete(router.AnnouncedRoutes, routeId)
+	} else {
+		router.AnnouncedRoutes[routeId] = localRouteIds
+	}
 }
 
 func (router *WshRouter) getAnnouncedRoute(routeId string) string {
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
-	return router.AnnouncedRoutes[routeId]
+	localRouteIds := router.AnnouncedRoutes[routeId]
+	if len(localRouteIds) == 0 {
+		return ""
+	}
+	return localRouteIds[len(localRouteIds)-1]
 }
 
 // returns true if message was sent, false if failed
@@ -231,7 +270,7 @@ func (router *WshRouter) runServer() {
 			continue
 		}
 		if msg.Command == wshrpc.Command_RouteUnannounce {
-			router.handleUnannounceMessage(msg)
+			router.handleUnannounceMessage(msg, input)
 			continue
 		}
 		if msg.Command != "" {
@@ -308,6 +347,8 @@ func (router *WshRouter) RegisterRoute(routeId string, rpc AbstractRpcClient, sh
 		log.Printf("[router] warning: route %q already exists (replacing)\n", routeId)
 	}
 	router.RouteMap[routeId] = rpc
+	// events queued for the replaced route are dropped
+	router.stopEventQueue_nolock(routeId)
 	go func() {
 		defer func() {
 			panichandler.PanicHandler("WshRouter:registerRoute:recvloop", recover())
@@ -318,6 +359,7 @@ func (router *WshRouter) RegisterRoute(routeId string, rpc AbstractRpcClient, sh
 			announceBytes, _ := json.Marshal(announceMsg)
 			router.GetUpstreamClient().SendRpcMessage(announceBytes)
 		}
+		restricted := isRestrictedRoute(routeId)
 		for {
 			msgBytes, ok := rpc.RecvRpcMessage()
 			if !ok {
@@ -328,7 +370,23 @@ func (router *WshRouter) RegisterRoute(routeId string, rpc AbstractRpcClient, sh
 			if err != nil {
 				continue
 			}
+			if restricted && router.GetRpc(routeId) != rpc {
+				// the route was unregistered or replaced (e.g. the plugin's token was revoked)
+				continue
+			}
 			if rpcMsg.Command != "" {
+				if restricted {
+					// restricted routes cannot send as another route, and their commands are checked
+					rpcMsg.Source = routeId
+					err = checkPluginCommand(routeId, &rpcMsg)
+					if err != nil {
+						if rpcMsg.ReqId != "" {
+							respBytes, _ := json.Marshal(RpcMessage{ResId: rpcMsg.ReqId, Error: err.Error()})
+							rpc.SendRpcMessage(respBytes)
+						}
+						continue
+					}
+				}
 				if rpcMsg.Source == "" {
 					rpcMsg.Source = routeId
 				}
@@ -345,16 +403,22 @@ func (router *WshRouter) RegisterRoute(routeId string, rpc AbstractRpcClient, sh
 	}()
 }
 
+func checkPluginCommand(routeId string, msg *RpcMessage) error {
+	if PluginCommandFilter == nil {
+		return nil
+	}
+	return PluginCommandFilter(routeId, msg)
+}
+
 func (router *WshRouter) UnregisterRoute(routeId string) {
 	log.Printf("[router] unregistering wsh route %q\n", routeId)
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
 	delete(router.RouteMap, routeId)
+	router.stopEventQueue_nolock(routeId)
 	// clear out announced routes
-	for routeId, localRouteId := range router.AnnouncedRoutes {
-		if localRouteId == routeId {
-			delete(router.AnnouncedRoutes, routeId)
-		}
+	for announcedRouteId := range router.AnnouncedRoutes {
+		router.removeAnnouncedRoute_nolock(announcedRouteId, routeId)
 	}
 	go func() {
 		defer func() {
@@ -29,6 +29,12 @@ const CtxDoneChSize = 10
 
 var blockingExpMap = ds.MakeExpMap[bool]()
 
+// set when wavesrv is shutting down, new requests are rejected with ErrShuttingDown (see DrainRpcs)
+var rpcDraining = &atomic.Bool{}
+var rpcsInFlight = &atomic.Int64{}
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
 type ResponseFnType = func(any) error
 
 // returns true if handler is complete, false for an async handler
@@ -263,8 +269,42 @@ func (w *WshRpc) cancelRequest(reqId string) {
 	handler := w.ResponseHandlerMap[reqId]
 	if handler != nil {
 		handler.canceled.Store(true)
+		// cancels the handler's context, so long-running and streaming handlers stop
+		cancelFn := handler.contextCancelFn.Load()
+		if cancelFn != nil && *cancelFn != nil {
+			(*cancelFn)()
+		}
 	}
+}
+
+func (w *WshRpc) isRpcTimedOut(reqId string) bool {
+	w.Lock.Lock()
+	defer w.Lock.Unlock()
+	rd := w.RpcMap[reqId]
+	return rd != nil && errors.Is(rd.Handler.ctx.Err(), context.DeadlineExceeded)
+}
 
+// tells the server to stop working on a request we are no longer waiting for (timed out, or the response
+// channel is stuck).  the cancel is routed to wherever the request went.
+func (w *WshRpc) sendCancelForRpc(reqId string) {
+	w.Lock.Lock()
+	rd := w.RpcMap[reqId]
+	w.Lock.Unlock()
+	if rd == nil || reqId == "" {
+		return
+	}
+	msg := &RpcMessage{
+		Cancel:    true,
+		ReqId:     reqId,
+		AuthToken: w.GetAuthToken(),
+	}
+	barr, _ := json.Marshal(msg) // will never fail
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("sendCancelForRpc", recover())
+		}()
+		w.OutputCh <- barr
+	}()
 }
 
 func (w *WshRpc) handleRequest(req *RpcMessage) {
@@ -284,6 +324,14 @@ func (w *WshRpc) handleRequest(req *RpcMessage) {
 		return
 	}
 
+	if rpcDraining.Load() {
+		if req.ReqId != "" {
+			barr, _ := json.Marshal(&RpcMessage{ResId: req.ReqId, Error: ErrShuttingDown.Error(), AuthToken: w.GetAuthToken()})
+			w.OutputCh <- barr
+		}
+		return
+	}
+	rpcsInFlight.Add(1)
 	var respHandler *RpcResponseHandler
 	timeoutMs := req.Timeout
 	if timeoutMs <= 0 {
@@ -317,18 +365,34 @@ func (w *WshRpc) handleRequest(req *RpcMessage) {
 				defer func() {
 					panichandler.PanicHandler("handleRequest:finalize", recover())
 				}()
+				defer rpcsInFlight.Add(-1)
 				<-ctx.Done()
 				respHandler.Finalize()
 			}()
 		} else {
 			cancelFn()
 			respHandler.Finalize()
+			rpcsInFlight.Add(-1)
 		}
 	}()
 	handlerFn := serverImplAdapter(w.ServerImpl)
 	isAsync = !handlerFn(respHandler)
 }
 
+// rejects new requests (in every WshRpc of the process) and waits for the requests in flight to finish, until ctx
+// is done.  streaming requests count until their response is done.
+func DrainRpcs(ctx context.Context) error {
+	rpcDraining.Store(true)
+	for rpcsInFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d rpc(s) still in flight: %w", rpcsInFlight.Load(), ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
 func (w *WshRpc) runServer() {
 	defer func() {
 		panichandler.PanicHandler("wshrpc.runServer", recover())
@@ -353,6 +417,9 @@ outer:
 			if w.Debug {
 				log.Printf("[%s] received request timeout: %s\n", w.DebugName, resIdTimeout)
 			}
+			if w.isRpcTimedOut(resIdTimeout) {
+				w.sendCancelForRpc(resIdTimeout)
+			}
 			w.unregisterRpc(resIdTimeout, fmt.Errorf("EC-TIME: timeout waiting for response"))
 			continue
 		}
@@ -782,5 +849,6 @@ func (w *WshRpc) sendRespWithBlockMessage(msg RpcMessage) {
 	case <-ctx.Done():
 	}
 	log.Printf("[rpc:%s] failed to clear response channel (waited 1s), will fail RPC command:%s route:%s resid:%s\n", w.DebugName, rd.Command, rd.Route, msg.ResId)
+	w.sendCancelForRpc(msg.ResId)
 	w.unregisterRpc(msg.ResId, nil) // we don't pass an error because the channel is full, it won't work anyway...
 }
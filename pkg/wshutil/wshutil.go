@@ -315,6 +315,12 @@ func MakeClientJWTToken(rpcCtx wshrpc.RpcContext, sockName string) (string, erro
 	if rpcCtx.ClientType != "" {
 		claims["ctype"] = rpcCtx.ClientType
 	}
+	if rpcCtx.PluginId != "" {
+		claims["pluginid"] = rpcCtx.PluginId
+	}
+	if rpcCtx.TokenId != "" {
+		claims["tokenid"] = rpcCtx.TokenId
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenStr, err := token.SignedString([]byte(wavebase.JwtSecret))
 	if err != nil {
@@ -376,6 +382,16 @@ func mapClaimsToRpcContext(claims jwt.MapClaims) *wshrpc.RpcContext {
 			rpcCtx.ClientType = ctype
 		}
 	}
+	if claims["pluginid"] != nil {
+		if pluginId, ok := claims["pluginid"].(string); ok {
+			rpcCtx.PluginId = pluginId
+		}
+	}
+	if claims["tokenid"] != nil {
+		if tokenId, ok := claims["tokenid"].(string); ok {
+			rpcCtx.TokenId = tokenId
+		}
+	}
 	return rpcCtx
 }
 
@@ -409,6 +425,12 @@ func MakeRouteIdFromCtx(rpcCtx *wshrpc.RpcContext) (string, error) {
 			}
 			return "", fmt.Errorf("invalid block controller connection, no block id")
 		}
+		if rpcCtx.ClientType == wshrpc.ClientType_Plugin {
+			if rpcCtx.PluginId != "" {
+				return MakePluginRouteId(rpcCtx.PluginId), nil
+			}
+			return "", fmt.Errorf("invalid plugin connection, no plugin id")
+		}
 		return "", fmt.Errorf("invalid client type: %q", rpcCtx.ClientType)
 	}
 	procId := uuid.New().String()
@@ -550,14 +572,7 @@ func ExtractUnverifiedSocketName(tokenStr string) (string, error) {
 }
 
 func getShell() string {
-	if runtime.GOOS == "darwin" {
-		return shellutil.GetMacUserShell()
-	}
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		return "/bin/bash"
-	}
-	return strings.TrimSpace(shell)
+	return strings.TrimSpace(shellutil.DetectLocalShellPath())
 }
 
 func GetInfo() wshrpc.RemoteInfo {
@@ -570,13 +585,33 @@ func GetInfo() wshrpc.RemoteInfo {
 
 }
 
-func InstallRcFiles() error {
+func getRemoteWaveDirs() (string, string) {
 	home := wavebase.GetHomeDir()
 	waveDir := filepath.Join(home, wavebase.RemoteWaveHomeDirName)
 	wshBinDir := filepath.Join(waveDir, wavebase.RemoteWshBinDirName)
+	return waveDir, wshBinDir
+}
+
+func InstallRcFiles() error {
+	waveDir, wshBinDir := getRemoteWaveDirs()
 	return shellutil.InitRcFiles(waveDir, wshBinDir)
 }
 
+func CheckRcFiles(repair bool) (*wshrpc.ShellIntegrationStatus, error) {
+	waveDir, wshBinDir := getRemoteWaveDirs()
+	status := shellutil.CheckShellIntegration(waveDir, wshBinDir)
+	if !repair || status.Healthy {
+		return status, nil
+	}
+	err := shellutil.InitRcFiles(waveDir, wshBinDir)
+	if err != nil {
+		return nil, fmt.Errorf("error repairing shell integration: %w", err)
+	}
+	status = shellutil.CheckShellIntegration(waveDir, wshBinDir)
+	status.Repaired = true
+	return status, nil
+}
+
 func SendErrCh[T any](err error) <-chan wshrpc.RespOrErrorUnion[T] {
 	ch := make(chan wshrpc.RespOrErrorUnion[T], 1)
 	ch <- RespErr[T](err)
@@ -0,0 +1,68 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsl
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// the paths of the windows host and of the distros.  the windows drives are mounted in the distros at /mnt/<letter>
+// (the default automount root), and the files of a distro are at \\wsl.localhost\<distro> (or \\wsl$\<distro>) on
+// windows.
+
+const UncPrefix = `\\wsl.localhost\`
+
+// the distros that are not for users (docker desktop runs its engine in them)
+var InvalidDistroNames = []string{"docker-desktop", "docker-desktop-data"}
+
+// the installed distro, State is "running", "stopped", "installing" or "uninstalling" and Version is 1 or 2
+type DistroInfo struct {
+	Name    string
+	Default bool
+	State   string
+	Version int
+}
+
+var drivePathRe = regexp.MustCompile(`^([a-zA-Z]):(?:[\\/](.*))?$`)
+var uncPathRe = regexp.MustCompile(`(?i)^[\\/]{2}(?:wsl\$|wsl\.localhost)[\\/]([^\\/]+)(?:[\\/](.*))?$`)
+var mntPathRe = regexp.MustCompile(`^/mnt/([a-zA-Z])(?:/(.*))?$`)
+
+// a path on a windows drive (C:\...) or in a distro (\\wsl.localhost\<distro>\...)
+func IsWindowsPath(p string) bool {
+	return drivePathRe.MatchString(p) || uncPathRe.MatchString(p)
+}
+
+// the path in a distro of a windows path.  distro is set for the paths in a distro (\\wsl.localhost\<distro>\...),
+// and empty for the paths on a drive (which are the same in every distro).
+func WindowsToWslPath(winPath string) (distro string, wslPath string, err error) {
+	if m := uncPathRe.FindStringSubmatch(winPath); m != nil {
+		return m[1], path.Clean("/" + strings.ReplaceAll(m[2], `\`, "/")), nil
+	}
+	if m := drivePathRe.FindStringSubmatch(winPath); m != nil {
+		rtn := "/mnt/" + strings.ToLower(m[1])
+		if m[2] != "" {
+			rtn = path.Clean(rtn + "/" + strings.ReplaceAll(m[2], `\`, "/"))
+		}
+		return "", rtn, nil
+	}
+	return "", "", fmt.Errorf("%q is not an absolute windows path", winPath)
+}
+
+// the windows path of a path in the distro
+func WslToWindowsPath(distro string, wslPath string) (string, error) {
+	if !strings.HasPrefix(wslPath, "/") {
+		return "", fmt.Errorf("%q is not an absolute path", wslPath)
+	}
+	wslPath = path.Clean(wslPath)
+	if m := mntPathRe.FindStringSubmatch(wslPath); m != nil {
+		return strings.ToUpper(m[1]) + `:\` + strings.ReplaceAll(m[2], "/", `\`), nil
+	}
+	if distro == "" {
+		return "", fmt.Errorf("the distro is required for %q", wslPath)
+	}
+	return UncPrefix + distro + strings.ReplaceAll(wslPath, "/", `\`), nil
+}
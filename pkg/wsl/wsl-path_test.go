@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsl
+
+import "testing"
+
+func TestWindowsToWslPath(t *testing.T) {
+	tests := []struct {
+		winPath string
+		distro  string
+		wslPath string
+	}{
+		{`C:\Users\me\notes.txt`, "", "/mnt/c/Users/me/notes.txt"},
+		{`d:/src/project/`, "", "/mnt/d/src/project"},
+		{`C:\`, "", "/mnt/c"},
+		{`E:`, "", "/mnt/e"},
+		{`\\wsl.localhost\Ubuntu\home\me`, "Ubuntu", "/home/me"},
+		{`\\wsl$\Debian`, "Debian", "/"},
+		{`//WSL.LOCALHOST/Ubuntu-22.04/etc/hosts`, "Ubuntu-22.04", "/etc/hosts"},
+	}
+	for _, test := range tests {
+		distro, wslPath, err := WindowsToWslPath(test.winPath)
+		if err != nil || distro != test.distro || wslPath != test.wslPath {
+			t.Errorf("%s: expected %q %q, got %q %q (%v)", test.winPath, test.distro, test.wslPath, distro, wslPath, err)
+		}
+	}
+	for _, bad := range []string{"/home/me", `notes.txt`, `\\server\share\x`} {
+		if _, _, err := WindowsToWslPath(bad); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+func TestWslToWindowsPath(t *testing.T) {
+	tests := []struct {
+		wslPath string
+		winPath string
+	}{
+		{"/mnt/c/Users/me/notes.txt", `C:\Users\me\notes.txt`},
+		{"/mnt/d", `D:\`},
+		{"/home/me/", `\\wsl.localhost\Ubuntu\home\me`},
+		{"/", `\\wsl.localhost\Ubuntu\`},
+	}
+	for _, test := range tests {
+		winPath, err := WslToWindowsPath("Ubuntu", test.wslPath)
+		if err != nil || winPath != test.winPath {
+			t.Errorf("%s: expected %q, got %q (%v)", test.wslPath, test.winPath, winPath, err)
+		}
+	}
+	if _, err := WslToWindowsPath("Ubuntu", "home/me"); err == nil {
+		t.Errorf("expected an error for a relative path")
+	}
+	if _, err := WslToWindowsPath("", "/home/me"); err == nil {
+		t.Errorf("expected an error without the distro")
+	}
+}
@@ -25,6 +25,10 @@ func DefaultDistro(ctx context.Context) (d Distro, ok bool, err error) {
 	return d, false, fmt.Errorf("DefaultDistro not implemented on this system")
 }
 
+func ListDistros(ctx context.Context) ([]DistroInfo, error) {
+	return nil, fmt.Errorf("ListDistros not implemented on this system")
+}
+
 type Distro struct{}
 
 func (d *Distro) Name() string {
@@ -10,9 +10,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/ubuntu/gowsl"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 )
 
 var RegisteredDistros = gowsl.RegisteredDistros
@@ -134,3 +136,30 @@ func GetDistro(ctx context.Context, wslDistroName WslName) (*Distro, error) {
 	}
 	return nil, fmt.Errorf("wsl distro %s not found", wslDistroName)
 }
+
+// the installed distros (less the InvalidDistroNames), with their state and wsl version
+func ListDistros(ctx context.Context) ([]DistroInfo, error) {
+	distros, err := RegisteredDistros(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defaultDistro, hasDefault, err := DefaultDistro(ctx)
+	if err != nil {
+		hasDefault = false
+	}
+	var rtn []DistroInfo
+	for _, distro := range distros {
+		if utilfn.ContainsStr(InvalidDistroNames, distro.Name()) {
+			continue
+		}
+		info := DistroInfo{Name: distro.Name(), Default: hasDefault && distro.Name() == defaultDistro.Name()}
+		if state, err := distro.State(); err == nil {
+			info.State = strings.ToLower(state.String())
+		}
+		if config, err := distro.GetConfiguration(); err == nil {
+			info.Version = int(config.UndocumentedWSLVersion)
+		}
+		rtn = append(rtn, info)
+	}
+	return rtn, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wslconn
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wsl"
+)
+
+// the installed distros as connections ("wsl://<distro>"), with their state, the status of their connection and
+// their shell (conn:shellpath in connections.json, the login shell of the distro's user if it is not set)
+
+func getConnStatus(distro string) wshrpc.ConnStatus {
+	globalLock.Lock()
+	conn := clientControllerMap[distro]
+	globalLock.Unlock()
+	if conn == nil {
+		return wshrpc.ConnStatus{Status: Status_Init, Connection: "wsl://" + distro}
+	}
+	return conn.DeriveConnStatus()
+}
+
+func ListDistros(ctx context.Context) ([]wshrpc.WslDistroInfo, error) {
+	distros, err := wsl.ListDistros(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fullConfig := wconfig.GetWatcher().GetFullConfig()
+	var rtn []wshrpc.WslDistroInfo
+	for _, distro := range distros {
+		connName := "wsl://" + distro.Name
+		rtn = append(rtn, wshrpc.WslDistroInfo{
+			Name:      distro.Name,
+			ConnName:  connName,
+			Default:   distro.Default,
+			State:     distro.State,
+			Version:   distro.Version,
+			ShellPath: fullConfig.Connections[connName].ConnShellPath,
+			Status:    getConnStatus(distro.Name),
+		})
+	}
+	return rtn, nil
+}
+
+// sets the shell of the new terminals on the distro (an empty shellPath goes back to the login shell)
+func SetDistroShell(ctx context.Context, distro string, shellPath string) error {
+	distros, err := wsl.ListDistros(ctx)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, d := range distros {
+		if d.Name == distro {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("wsl distro %q not found", distro)
+	}
+	var value any
+	if shellPath != "" {
+		value = shellPath
+	}
+	return wconfig.SetConnectionsConfigValue("wsl://"+distro, waveobj.MetaMapType{"conn:shellpath": value})
+}
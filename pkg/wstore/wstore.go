@@ -78,3 +78,51 @@ func MoveBlockToTab(ctx context.Context, currentTabId string, newTabId string, b
 		return nil
 	})
 }
+
+// detached blocks are parented to the client so they are not reaped while they are not in any tab
+func DetachBlockFromTab(ctx context.Context, tabId string, blockId string) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		block, _ := DBGet[*waveobj.Block](tx.Context(), blockId)
+		if block == nil {
+			return fmt.Errorf("block not found: %q", blockId)
+		}
+		tab, _ := DBGet[*waveobj.Tab](tx.Context(), tabId)
+		if tab == nil {
+			return fmt.Errorf("tab not found: %q", tabId)
+		}
+		if utilfn.FindStringInSlice(tab.BlockIds, blockId) == -1 {
+			return fmt.Errorf("block not found in tab: %q", blockId)
+		}
+		client, err := DBGetSingleton[*waveobj.Client](tx.Context())
+		if err != nil {
+			return fmt.Errorf("error getting client: %w", err)
+		}
+		tab.BlockIds = utilfn.RemoveElemFromSlice(tab.BlockIds, blockId)
+		block.ParentORef = waveobj.MakeORef(waveobj.OType_Client, client.OID).String()
+		DBUpdate(tx.Context(), block)
+		DBUpdate(tx.Context(), tab)
+		return nil
+	})
+}
+
+func AttachBlockToTab(ctx context.Context, blockId string, tabId string) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		block, _ := DBGet[*waveobj.Block](tx.Context(), blockId)
+		if block == nil {
+			return fmt.Errorf("block not found: %q", blockId)
+		}
+		parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+		if parentORef == nil || parentORef.OType != waveobj.OType_Client {
+			return fmt.Errorf("block is not detached: %q", blockId)
+		}
+		tab, _ := DBGet[*waveobj.Tab](tx.Context(), tabId)
+		if tab == nil {
+			return fmt.Errorf("tab not found: %q", tabId)
+		}
+		tab.BlockIds = append(tab.BlockIds, blockId)
+		block.ParentORef = waveobj.MakeORef(waveobj.OType_Tab, tabId).String()
+		DBUpdate(tx.Context(), block)
+		DBUpdate(tx.Context(), tab)
+		return nil
+	})
+}
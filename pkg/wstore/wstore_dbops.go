@@ -362,6 +362,10 @@ func DBFindTabForBlockId(ctx context.Context, blockId string) (string, error) {
 				iterNum++
 				continue
 			}
+			if oref.OType == "client" {
+				// detached block
+				return "", nil
+			}
 			return "", fmt.Errorf("bad parent oref type: %v", oref.OType)
 		}
 	})
@@ -401,3 +405,12 @@ func DBFindWindowForWorkspaceId(ctx context.Context, workspaceId string) (string
 		return tx.GetString(query, workspaceId), nil
 	})
 }
+
+func DBFindTabForLayoutId(ctx context.Context, layoutId string) (string, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) (string, error) {
+		query := `
+			SELECT t.oid
+			FROM db_tab t WHERE json_extract(data, '$.layoutstate') = ?`
+		return tx.GetString(query, layoutId), nil
+	})
+}
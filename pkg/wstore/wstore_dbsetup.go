@@ -41,6 +41,14 @@ func InitWStore() error {
 	return nil
 }
 
+// waits for the queries in progress, call after the last write (on shutdown)
+func CloseWStore() error {
+	if globalDB == nil {
+		return nil
+	}
+	return globalDB.Close()
+}
+
 func GetDBName() string {
 	waveHome := wavebase.GetWaveDataDir()
 	return filepath.Join(waveHome, wavebase.WaveDBDir, WStoreDBName)
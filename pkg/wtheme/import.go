@@ -0,0 +1,451 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wtheme
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/util/plistutil"
+	"github.com/wavetermdev/waveterm/pkg/util/tomlutil"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+)
+
+const (
+	Format_ITerm2          = "iterm2"          // .itermcolors (an XML property list)
+	Format_Alacritty       = "alacritty"       // the colors of an alacritty.toml
+	Format_VSCode          = "vscode"          // a VS Code color theme (json with comments), its terminal.* colors
+	Format_Kitty           = "kitty"           // a kitty theme (the colors of a kitty.conf)
+	Format_WindowsTerminal = "windowsterminal" // a Windows Terminal color scheme (json, a "schemes" entry)
+)
+
+var Formats = []string{Format_ITerm2, Format_Alacritty, Format_VSCode, Format_Kitty, Format_WindowsTerminal}
+
+// the 16 colors in the order of the ansi color numbers
+var ansiColorNames = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"brightBlack", "brightRed", "brightGreen", "brightYellow", "brightBlue", "brightMagenta", "brightCyan", "brightWhite",
+}
+
+var kittyColorLineRe = regexp.MustCompile(`(?m)^\s*(foreground|background|color[0-9]+)\s+#`)
+
+// the format of a theme file, from its name and then its contents ("" if it is not known)
+func DetectFormat(fileName string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".itermcolors":
+		return Format_ITerm2
+	case ".toml":
+		return Format_Alacritty
+	case ".conf":
+		return Format_Kitty
+	case ".json", ".jsonc":
+		if detectJsonFormat(data) == Format_WindowsTerminal {
+			return Format_WindowsTerminal
+		}
+		return Format_VSCode
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<plist")):
+		return Format_ITerm2
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return detectJsonFormat(data)
+	case bytes.Contains(trimmed, []byte("[colors")):
+		return Format_Alacritty
+	case kittyColorLineRe.Match(trimmed):
+		return Format_Kitty
+	}
+	return ""
+}
+
+// a VS Code theme has "colors", a Windows Terminal scheme has the colors at the top level
+func detectJsonFormat(data []byte) string {
+	var obj map[string]any
+	if err := json.Unmarshal(StripJsonComments(data), &obj); err == nil && obj["colors"] == nil && obj["background"] != nil {
+		return Format_WindowsTerminal
+	}
+	return Format_VSCode
+}
+
+// parses a theme file, returns the theme and the name in the file (if it has one)
+func ParseTheme(format string, data []byte) (*wconfig.TermThemeType, string, error) {
+	var colors map[string]string
+	var name string
+	var err error
+	switch format {
+	case Format_ITerm2:
+		colors, err = parseITerm2(data)
+	case Format_Alacritty:
+		colors, err = parseAlacritty(data)
+	case Format_VSCode:
+		colors, name, err = parseVSCode(data)
+	case Format_Kitty:
+		colors, name = parseKitty(data)
+	case Format_WindowsTerminal:
+		colors, name, err = parseWindowsTerminal(data)
+	default:
+		return nil, "", fmt.Errorf("unknown theme format %q (expected %s)", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	theme, err := themeFromColors(format, colors)
+	if err != nil {
+		return nil, "", err
+	}
+	return theme, name, nil
+}
+
+// the theme of the colors (by their theme key, or their index for the colors 16-255)
+func themeFromColors(format string, colors map[string]string) (*wconfig.TermThemeType, error) {
+	theme := &wconfig.TermThemeType{}
+	themeMap := make(map[string]any)
+	var extended []string
+	for key, color := range colors {
+		normColor, ok := normalizeColor(color)
+		if !ok {
+			return nil, fmt.Errorf("invalid color for %s: %q", key, color)
+		}
+		if idx, err := strconv.Atoi(key); err == nil {
+			if idx >= 16 && idx <= 255 {
+				extended = ensureExtended(extended)
+				extended[idx-16] = normColor
+			}
+			continue
+		}
+		themeMap[key] = normColor
+	}
+	if extended != nil {
+		themeMap["extendedAnsi"] = extended
+	}
+	barr, _ := json.Marshal(themeMap)
+	if err := json.Unmarshal(barr, theme); err != nil {
+		return nil, err
+	}
+	if theme.Background == "" || theme.Foreground == "" {
+		return nil, fmt.Errorf("no background or foreground color found (is it a %s theme?)", format)
+	}
+	return theme, nil
+}
+
+// the colors 16-255 (the indexed colors that are not set get the xterm color)
+func ensureExtended(extended []string) []string {
+	if extended != nil {
+		return extended
+	}
+	extended = make([]string, 240)
+	for idx := range extended {
+		extended[idx] = xtermColor(idx + 16)
+	}
+	return extended
+}
+
+// the xterm 256 color palette (16-231 is a 6x6x6 color cube, 232-255 a gray ramp)
+func xtermColor(idx int) string {
+	if idx >= 232 {
+		level := 8 + (idx-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", level, level, level)
+	}
+	cube := idx - 16
+	levels := []int{0, 95, 135, 175, 215, 255}
+	return fmt.Sprintf("#%02x%02x%02x", levels[cube/36], levels[(cube/6)%6], levels[cube%6])
+}
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// a color as #rrggbb (or #rrggbbaa), also reads #rgb and 0xrrggbb (alacritty)
+func normalizeColor(color string) (string, bool) {
+	color = strings.TrimSpace(color)
+	if strings.HasPrefix(color, "0x") || strings.HasPrefix(color, "0X") {
+		color = "#" + color[2:]
+	}
+	if !hexColorRe.MatchString(color) {
+		return "", false
+	}
+	color = strings.ToLower(color)
+	if len(color) == 4 {
+		color = string([]byte{'#', color[1], color[1], color[2], color[2], color[3], color[3]})
+	}
+	return color, true
+}
+
+func parseITerm2(data []byte) (map[string]string, error) {
+	val, err := plistutil.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := val.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("not an iTerm2 color file (expected a dict)")
+	}
+	return iterm2DictColors(dict)
+}
+
+// the theme of the colors of an iTerm2 profile (a profile has the same color keys as an .itermcolors file)
+func ParseITerm2Dict(dict map[string]any) (*wconfig.TermThemeType, error) {
+	colors, err := iterm2DictColors(dict)
+	if err != nil {
+		return nil, err
+	}
+	return themeFromColors(Format_ITerm2, colors)
+}
+
+func iterm2DictColors(dict map[string]any) (map[string]string, error) {
+	keys := map[string]string{
+		"Background Color":  "background",
+		"Foreground Color":  "foreground",
+		"Cursor Color":      "cursor",
+		"Cursor Text Color": "cursorAccent",
+		"Selection Color":   "selectionBackground",
+	}
+	for idx, colorName := range ansiColorNames {
+		keys[fmt.Sprintf("Ansi %d Color", idx)] = colorName
+	}
+	rtn := make(map[string]string)
+	for plistKey, colorName := range keys {
+		colorDict, ok := dict[plistKey].(map[string]any)
+		if !ok {
+			continue
+		}
+		color, err := iterm2Color(colorDict)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", plistKey, err)
+		}
+		rtn[colorName] = color
+	}
+	return rtn, nil
+}
+
+// a color dict ("Red Component" etc, from 0 to 1)
+func iterm2Color(colorDict map[string]any) (string, error) {
+	var comps [3]int
+	for idx, compName := range []string{"Red Component", "Green Component", "Blue Component"} {
+		val, ok := plistutil.GetFloat(colorDict[compName])
+		if !ok {
+			return "", fmt.Errorf("no %s", strings.ToLower(compName))
+		}
+		comps[idx] = int(math.Round(math.Max(0, math.Min(1, val)) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", comps[0], comps[1], comps[2]), nil
+}
+
+// reads the colors of an alacritty.toml: the [colors.*] tables and the [[colors.indexed_colors]], the rest of the
+// file is ignored (so only the toml that alacritty configs use is read: tables, strings, numbers and inline tables)
+func parseAlacritty(data []byte) (map[string]string, error) {
+	values, arrays := tomlutil.Parse(data)
+	rtn := make(map[string]string)
+	for idx, colorName := range ansiColorNames {
+		section := "colors.normal."
+		if idx >= 8 {
+			section = "colors.bright."
+		}
+		if val, ok := values[section+ansiColorNames[idx%8]]; ok {
+			rtn[colorName] = val
+		}
+	}
+	keys := map[string]string{
+		"colors.primary.background":   "background",
+		"colors.primary.foreground":   "foreground",
+		"colors.cursor.cursor":        "cursor",
+		"colors.cursor.text":          "cursorAccent",
+		"colors.selection.background": "selectionBackground",
+	}
+	for tomlKey, colorName := range keys {
+		// "CellForeground" and "CellBackground" are not colors
+		if val, ok := values[tomlKey]; ok && !strings.HasPrefix(val, "Cell") {
+			rtn[colorName] = val
+		}
+	}
+	for _, entry := range arrays["colors.indexed_colors"] {
+		if _, err := strconv.Atoi(entry["index"]); err == nil && entry["color"] != "" {
+			rtn[entry["index"]] = entry["color"]
+		}
+	}
+	if len(rtn) == 0 {
+		return nil, fmt.Errorf("no [colors] found (alacritty's yaml configs are not supported, convert it with: alacritty migrate)")
+	}
+	return rtn, nil
+}
+
+// reads the terminal colors of a VS Code color theme (the editor colors are used for the ones it does not set)
+func parseVSCode(data []byte) (map[string]string, string, error) {
+	var vsTheme struct {
+		Name   string            `json:"name"`
+		Colors map[string]string `json:"colors"`
+	}
+	if err := json.Unmarshal(StripJsonComments(data), &vsTheme); err != nil {
+		return nil, "", fmt.Errorf("invalid VS Code theme: %w", err)
+	}
+	if len(vsTheme.Colors) == 0 {
+		return nil, "", fmt.Errorf("no colors in the VS Code theme (a theme that only \"include\"s another is not supported)")
+	}
+	rtn := make(map[string]string)
+	for _, colorName := range ansiColorNames {
+		vsKey := "terminal.ansi" + strings.ToUpper(colorName[:1]) + colorName[1:]
+		if val, ok := vsTheme.Colors[vsKey]; ok {
+			rtn[colorName] = val
+		}
+	}
+	keys := []struct {
+		colorName string
+		vsKeys    []string
+	}{
+		{"background", []string{"terminal.background", "editor.background"}},
+		{"foreground", []string{"terminal.foreground", "editor.foreground"}},
+		{"cursor", []string{"terminalCursor.foreground", "editorCursor.foreground"}},
+		{"cursorAccent", []string{"terminalCursor.background"}},
+		{"selectionBackground", []string{"terminal.selectionBackground", "editor.selectionBackground"}},
+		{"accent", []string{"focusBorder", "activityBarBadge.background"}},
+	}
+	for _, k := range keys {
+		for _, vsKey := range k.vsKeys {
+			if val, ok := vsTheme.Colors[vsKey]; ok {
+				rtn[k.colorName] = val
+				break
+			}
+		}
+	}
+	return rtn, vsTheme.Name, nil
+}
+
+var kittyNameRe = regexp.MustCompile(`^##\s*name:\s*(.+)$`)
+
+// reads the colors of a kitty theme or kitty.conf ("key value" lines), the name is the one in the "## name:" header
+// of the kitty-themes files.  the values that are not colors ("none", "background") are skipped.
+func parseKitty(data []byte) (map[string]string, string) {
+	keys := map[string]string{
+		"background":           "background",
+		"foreground":           "foreground",
+		"cursor":               "cursor",
+		"cursor_text_color":    "cursorAccent",
+		"selection_background": "selectionBackground",
+		"active_border_color":  "accent",
+	}
+	rtn := make(map[string]string)
+	var name string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if match := kittyNameRe.FindStringSubmatch(line); match != nil && name == "" {
+			name = strings.TrimSpace(match[1])
+			continue
+		}
+		key, val, found := strings.Cut(line, " ")
+		val = strings.TrimSpace(val)
+		if !found || strings.HasPrefix(key, "#") || !strings.HasPrefix(val, "#") {
+			continue
+		}
+		if colorName, ok := keys[key]; ok {
+			rtn[colorName] = val
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimPrefix(key, "color"))
+		if !strings.HasPrefix(key, "color") || err != nil || idx < 0 || idx > 255 {
+			continue
+		}
+		if idx < 16 {
+			rtn[ansiColorNames[idx]] = val
+		} else {
+			rtn[strconv.Itoa(idx)] = val
+		}
+	}
+	return rtn, name
+}
+
+// reads a Windows Terminal color scheme (an entry of "schemes" in its settings.json, "purple" is magenta)
+func parseWindowsTerminal(data []byte) (map[string]string, string, error) {
+	var scheme map[string]any
+	if err := json.Unmarshal(StripJsonComments(data), &scheme); err != nil {
+		return nil, "", fmt.Errorf("invalid Windows Terminal scheme: %w", err)
+	}
+	keys := map[string]string{
+		"background":          "background",
+		"foreground":          "foreground",
+		"cursorColor":         "cursor",
+		"selectionBackground": "selectionBackground",
+	}
+	for _, colorName := range ansiColorNames {
+		keys[strings.Replace(strings.Replace(colorName, "magenta", "purple", 1), "Magenta", "Purple", 1)] = colorName
+	}
+	rtn := make(map[string]string)
+	for wtKey, colorName := range keys {
+		if val, ok := scheme[wtKey].(string); ok {
+			rtn[colorName] = val
+		}
+	}
+	name, _ := scheme["name"].(string)
+	return rtn, name, nil
+}
+
+// removes the // and /* */ comments and the trailing commas of json with comments (VS Code's and Windows
+// Terminal's jsonc)
+func StripJsonComments(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+		if inString {
+			out.WriteByte(ch)
+			if ch == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if ch == '"' {
+				inString = false
+			}
+			continue
+		}
+		if ch == '"' {
+			inString = true
+			out.WriteByte(ch)
+			continue
+		}
+		if ch == '/' && i+1 < len(data) && data[i+1] == '/' {
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+			continue
+		}
+		if ch == '/' && i+1 < len(data) && data[i+1] == '*' {
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end == -1 {
+				break
+			}
+			i += end + 3
+			continue
+		}
+		out.WriteByte(ch)
+	}
+	return stripTrailingCommas(out.Bytes())
+}
+
+func stripTrailingCommas(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+		switch {
+		case inString && ch == '\\' && i+1 < len(data):
+			out.WriteByte(ch)
+			i++
+			ch = data[i]
+		case inString && ch == '"':
+			inString = false
+		case !inString && ch == '"':
+			inString = true
+		case !inString && ch == ',':
+			next := bytes.TrimLeft(data[i+1:], " \t\r\n")
+			if len(next) > 0 && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		out.WriteByte(ch)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,222 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wtheme
+
+import (
+	"testing"
+)
+
+const testITerm2Theme = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Ansi 1 Color</key>
+	<dict>
+		<key>Alpha Component</key>
+		<real>1</real>
+		<key>Blue Component</key>
+		<real>0.33333333333333331</real>
+		<key>Color Space</key>
+		<string>sRGB</string>
+		<key>Green Component</key>
+		<real>0.33333333333333331</real>
+		<key>Red Component</key>
+		<real>1</real>
+	</dict>
+	<key>Background Color</key>
+	<dict>
+		<key>Blue Component</key>
+		<real>0.21176470816135406</real>
+		<key>Green Component</key>
+		<real>0.16470588743686676</real>
+		<key>Red Component</key>
+		<real>0.15686275064945221</real>
+	</dict>
+	<key>Foreground Color</key>
+	<dict>
+		<key>Blue Component</key>
+		<real>0.94901961088180542</real>
+		<key>Green Component</key>
+		<real>0.97254902124404907</real>
+		<key>Red Component</key>
+		<integer>1</integer>
+	</dict>
+	<key>Cursor Text Color</key>
+	<dict>
+		<key>Blue Component</key>
+		<real>0</real>
+		<key>Green Component</key>
+		<real>0</real>
+		<key>Red Component</key>
+		<real>0</real>
+	</dict>
+</dict>
+</plist>
+`
+
+const testAlacrittyTheme = `# Colors (Gruvbox)
+[colors.primary]
+background = '#282828'
+foreground = "0xebdbb2"
+
+[colors.cursor]
+text = "CellBackground"
+cursor = "#fff" # a comment
+
+[colors.normal]
+black = '#282828'
+red = '#cc241d'
+
+[colors.bright]
+red = '#fb4934'
+
+[[colors.indexed_colors]]
+index = 16
+color = "#fe8019"
+
+[[colors.indexed_colors]]
+index = 255
+color = "#d65d0e"
+
+[window]
+padding = { x = 2, y = 2 }
+`
+
+const testVSCodeTheme = `{
+	// a VS Code theme
+	"name": "One Dark Pro",
+	"type": "dark",
+	"colors": {
+		"editor.background": "#282c34",
+		"editor.foreground": "#abb2bf", /* the editor foreground */
+		"terminal.foreground": "#ABB2BF",
+		"terminal.ansiBrightBlue": "#61afef",
+		"focusBorder": "#3e4452",
+		"terminal.ansiRed": "#e06c75", // a trailing comma
+	},
+	"tokenColors": [],
+}
+`
+
+const testKittyTheme = `## name: Tokyo Night
+background #1a1b26
+foreground #c0caf5
+cursor_text_color background
+color1 #f7768e
+color9 #ff7a93
+color16 #ff9e64
+`
+
+const testWindowsTerminalScheme = `{
+	"name": "Campbell Powershell",
+	"background": "#012456",
+	"foreground": "#CCCCCC",
+	"purple": "#881798",
+	"brightPurple": "#B4009E",
+	"cursorColor": "#FFFFFF"
+}
+`
+
+func TestParseTheme(t *testing.T) {
+	theme, _, err := ParseTheme(Format_ITerm2, []byte(testITerm2Theme))
+	if err != nil {
+		t.Fatalf("iterm2: %v", err)
+	}
+	if theme.Red != "#ff5555" || theme.Background != "#282a36" || theme.Foreground != "#fff8f2" || theme.CursorAccent != "#000000" {
+		t.Errorf("iterm2: unexpected colors %+v", theme)
+	}
+
+	theme, _, err = ParseTheme(Format_Alacritty, []byte(testAlacrittyTheme))
+	if err != nil {
+		t.Fatalf("alacritty: %v", err)
+	}
+	if theme.Background != "#282828" || theme.Foreground != "#ebdbb2" || theme.Cursor != "#ffffff" || theme.CursorAccent != "" {
+		t.Errorf("alacritty: unexpected primary colors %+v", theme)
+	}
+	if theme.Red != "#cc241d" || theme.BrightRed != "#fb4934" || theme.Green != "" {
+		t.Errorf("alacritty: unexpected ansi colors %+v", theme)
+	}
+	if len(theme.ExtendedAnsi) != 240 || theme.ExtendedAnsi[0] != "#fe8019" || theme.ExtendedAnsi[239] != "#d65d0e" {
+		t.Fatalf("alacritty: unexpected indexed colors %v", theme.ExtendedAnsi)
+	}
+	if theme.ExtendedAnsi[1] != "#00005f" || theme.ExtendedAnsi[232-16] != "#080808" {
+		t.Errorf("alacritty: expected the xterm colors for the other indexed colors, got %v", theme.ExtendedAnsi)
+	}
+
+	theme, name, err := ParseTheme(Format_VSCode, []byte(testVSCodeTheme))
+	if err != nil {
+		t.Fatalf("vscode: %v", err)
+	}
+	if name != "One Dark Pro" {
+		t.Errorf("vscode: expected the name One Dark Pro, got %q", name)
+	}
+	if theme.Background != "#282c34" || theme.Foreground != "#abb2bf" || theme.Red != "#e06c75" || theme.BrightBlue != "#61afef" || theme.Accent != "#3e4452" {
+		t.Errorf("vscode: unexpected colors %+v", theme)
+	}
+
+	theme, name, err = ParseTheme(Format_Kitty, []byte(testKittyTheme))
+	if err != nil {
+		t.Fatalf("kitty: %v", err)
+	}
+	if name != "Tokyo Night" || theme.Red != "#f7768e" || theme.BrightRed != "#ff7a93" || theme.CursorAccent != "" {
+		t.Errorf("kitty: unexpected theme %q %+v", name, theme)
+	}
+	if len(theme.ExtendedAnsi) != 240 || theme.ExtendedAnsi[0] != "#ff9e64" {
+		t.Errorf("kitty: unexpected indexed colors %v", theme.ExtendedAnsi)
+	}
+
+	theme, name, err = ParseTheme(Format_WindowsTerminal, []byte(testWindowsTerminalScheme))
+	if err != nil {
+		t.Fatalf("windowsterminal: %v", err)
+	}
+	if name != "Campbell Powershell" || theme.Background != "#012456" || theme.Magenta != "#881798" || theme.BrightMagenta != "#b4009e" || theme.Cursor != "#ffffff" {
+		t.Errorf("windowsterminal: unexpected theme %q %+v", name, theme)
+	}
+
+	if _, _, err := ParseTheme(Format_VSCode, []byte(`{"colors": {"terminal.ansiRed": "#ff0000"}}`)); err == nil {
+		t.Errorf("expected an error for a theme without a background")
+	}
+	if _, _, err := ParseTheme(Format_VSCode, []byte(`{"colors": {"editor.background": "red", "editor.foreground": "#fff"}}`)); err == nil {
+		t.Errorf("expected an error for an invalid color")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		fileName string
+		data     string
+		expected string
+	}{
+		{"Dracula.itermcolors", "", Format_ITerm2},
+		{"gruvbox.toml", "", Format_Alacritty},
+		{"one-dark.json", "", Format_VSCode},
+		{"theme", testITerm2Theme, Format_ITerm2},
+		{"theme", testAlacrittyTheme, Format_Alacritty},
+		{"theme", testVSCodeTheme, Format_VSCode},
+		{"tokyo-night.conf", "", Format_Kitty},
+		{"campbell.json", testWindowsTerminalScheme, Format_WindowsTerminal},
+		{"theme", testKittyTheme, Format_Kitty},
+		{"theme", testWindowsTerminalScheme, Format_WindowsTerminal},
+		{"theme", "colors:\n  primary:\n", ""},
+	}
+	for _, tc := range tests {
+		if got := DetectFormat(tc.fileName, []byte(tc.data)); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.fileName, tc.expected, got)
+		}
+	}
+}
+
+func TestThemeNameFromText(t *testing.T) {
+	tests := map[string]string{
+		"One Dark Pro":        "one-dark-pro",
+		"Solarized (Dark)":    "solarized-dark",
+		"  gruvbox_material ": "gruvbox_material",
+		"???":                 "",
+	}
+	for text, expected := range tests {
+		if got := themeNameFromText(text); got != expected {
+			t.Errorf("%q: expected %q, got %q", text, expected, got)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wtheme manages the terminal themes: the built-in ones (the default termthemes.json), the ones in the
+// user's termthemes.json, and the ones saved in wave as theme objects (imported from iTerm2, Alacritty, VS Code,
+// kitty, or Windows Terminal themes, or saved with wsh theme).  the themes in wave are added to the config (FullConfigType.TermThemes, where
+// they replace a theme of termthemes.json with the same name), so the views use them like the others.  a theme is
+// assigned with term:theme, in the meta of a block or in the config of a connection.
+package wtheme
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wconn"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	Source_Builtin = "builtin"
+	Source_Config  = "config"
+	Source_Wave    = "wave"
+)
+
+const TermThemesFile = "termthemes.json"
+const dbTimeout = 5 * time.Second
+
+var themeNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+var cacheLock = &sync.Mutex{}
+var cache = make(map[string]*waveobj.Theme) // by name
+var startOnce = &sync.Once{}
+
+// loads the themes saved in wave and adds them to the config
+func Start() {
+	startOnce.Do(func() {
+		ctx, cancelFn := context.WithTimeout(context.Background(), dbTimeout)
+		defer cancelFn()
+		themes, err := wstore.DBGetAllObjsByType[*waveobj.Theme](ctx, waveobj.OType_Theme)
+		if err != nil {
+			log.Printf("wtheme: error loading the themes: %v\n", err)
+		}
+		cacheLock.Lock()
+		for _, theme := range themes {
+			cache[theme.Name] = theme
+		}
+		cacheLock.Unlock()
+		wconfig.SetTermThemesOverlay(getTermThemes)
+		reloadConfig()
+	})
+}
+
+func reloadConfig() {
+	if watcher := wconfig.GetWatcher(); watcher != nil {
+		watcher.Reload()
+	}
+}
+
+// the themes saved in wave (called when the config is read)
+func getTermThemes() map[string]wconfig.TermThemeType {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	rtn := make(map[string]wconfig.TermThemeType, len(cache))
+	for name, theme := range cache {
+		var termTheme wconfig.TermThemeType
+		if err := utilfn.ReUnmarshal(&termTheme, theme.Palette); err != nil {
+			log.Printf("wtheme: invalid theme %q: %v\n", name, err)
+			continue
+		}
+		rtn[name] = termTheme
+	}
+	return rtn
+}
+
+func getBuiltinNames() map[string]bool {
+	m, _ := wconfig.ReadDefaultsConfigFile(TermThemesFile)
+	rtn := make(map[string]bool, len(m))
+	for name := range m {
+		rtn[name] = true
+	}
+	return rtn
+}
+
+// all the themes, sorted by display:order and then name
+func List() []wshrpc.ThemeInfo {
+	builtins := getBuiltinNames()
+	termThemes := wconfig.GetWatcher().GetFullConfig().TermThemes
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	var rtn []wshrpc.ThemeInfo
+	for name, termTheme := range termThemes {
+		info := wshrpc.ThemeInfo{Name: name, DisplayName: termTheme.DisplayName, Source: Source_Config}
+		if theme := cache[name]; theme != nil {
+			info.Source = Source_Wave
+			info.ImportedFrom = theme.Source
+			info.ThemeId = theme.OID
+		} else if builtins[name] {
+			info.Source = Source_Builtin
+		}
+		rtn = append(rtn, info)
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		orderI, orderJ := termThemes[rtn[i].Name].DisplayOrder, termThemes[rtn[j].Name].DisplayOrder
+		if orderI != orderJ {
+			return orderI < orderJ
+		}
+		return rtn[i].Name < rtn[j].Name
+	})
+	return rtn
+}
+
+func Get(name string) (*wconfig.TermThemeType, error) {
+	termTheme, ok := wconfig.GetWatcher().GetFullConfig().TermThemes[name]
+	if !ok {
+		return nil, fmt.Errorf("theme not found: %s", name)
+	}
+	return &termTheme, nil
+}
+
+// checks the colors of a theme (hex colors, or empty) and normalizes them to #rrggbb
+func validateTheme(termTheme *wconfig.TermThemeType) error {
+	var themeMap map[string]any
+	if err := utilfn.ReUnmarshal(&themeMap, termTheme); err != nil {
+		return err
+	}
+	for key, val := range themeMap {
+		if strings.HasPrefix(key, "display:") {
+			continue
+		}
+		if key == "extendedAnsi" {
+			colors, _ := val.([]any)
+			if len(colors) > 240 {
+				return fmt.Errorf("extendedAnsi has %d colors (the colors 16-255 are at most 240)", len(colors))
+			}
+			for idx, color := range colors {
+				colorStr, _ := color.(string)
+				normColor, ok := normalizeColor(colorStr)
+				if !ok {
+					return fmt.Errorf("invalid color for extendedAnsi[%d] (color %d): %q", idx, idx+16, colorStr)
+				}
+				colors[idx] = normColor
+			}
+			continue
+		}
+		colorStr, _ := val.(string)
+		if colorStr == "" {
+			continue
+		}
+		normColor, ok := normalizeColor(colorStr)
+		if !ok {
+			return fmt.Errorf("invalid color for %s: %q (expected #rrggbb)", key, colorStr)
+		}
+		themeMap[key] = normColor
+	}
+	return utilfn.ReUnmarshal(termTheme, themeMap)
+}
+
+// saves a theme in wave (replacing the one with the same name), a built-in theme can't be replaced
+func Save(ctx context.Context, name string, termTheme wconfig.TermThemeType, source string) (*waveobj.Theme, error) {
+	if !themeNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid theme name %q (letters, digits, '_', '.', and '-')", name)
+	}
+	if getBuiltinNames()[name] {
+		return nil, fmt.Errorf("%q is a built-in theme, use another name", name)
+	}
+	if err := validateTheme(&termTheme); err != nil {
+		return nil, err
+	}
+	if termTheme.DisplayName == "" {
+		termTheme.DisplayName = name
+	}
+	if termTheme.DisplayOrder == 0 {
+		termTheme.DisplayOrder = 100
+	}
+	var palette waveobj.MetaMapType
+	if err := utilfn.ReUnmarshal(&palette, termTheme); err != nil {
+		return nil, err
+	}
+	cacheLock.Lock()
+	defer func() {
+		cacheLock.Unlock()
+		reloadConfig()
+	}()
+	now := time.Now().UnixMilli()
+	theme := cache[name]
+	isNew := theme == nil
+	if isNew {
+		theme = &waveobj.Theme{OID: uuid.NewString(), Name: name, CreatedTs: now, Meta: make(waveobj.MetaMapType)}
+	} else {
+		themeCopy := *theme
+		theme = &themeCopy
+	}
+	theme.Source = source
+	theme.Palette = palette
+	theme.UpdatedTs = now
+	var err error
+	if isNew {
+		err = wstore.DBInsert(ctx, theme)
+	} else {
+		err = wstore.DBUpdate(ctx, theme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache[name] = theme
+	return theme, nil
+}
+
+// imports a theme file (the format is detected from the file name and contents if it is not set), the name is the
+// one in the file or the file name if it is not set
+func Import(ctx context.Context, data wshrpc.CommandThemeImportData) (*waveobj.Theme, error) {
+	format := data.Format
+	if format == "" {
+		format = DetectFormat(data.FileName, []byte(data.Data))
+		if format == "" {
+			return nil, fmt.Errorf("cannot detect the format of the theme, set it (%s)", strings.Join(Formats, ", "))
+		}
+	}
+	termTheme, fileThemeName, err := ParseTheme(format, []byte(data.Data))
+	if err != nil {
+		return nil, err
+	}
+	name := data.Name
+	if name == "" {
+		name = themeNameFromText(fileThemeName)
+	}
+	if name == "" {
+		name = themeNameFromText(strings.TrimSuffix(filepath.Base(data.FileName), filepath.Ext(data.FileName)))
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the theme has no name, set one")
+	}
+	if fileThemeName != "" {
+		termTheme.DisplayName = fileThemeName
+	}
+	return Save(ctx, name, *termTheme, format)
+}
+
+var nonNameCharsRe = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// "One Dark Pro" => "one-dark-pro"
+func themeNameFromText(text string) string {
+	return strings.Trim(nonNameCharsRe.ReplaceAllString(strings.ToLower(text), "-"), "-._")
+}
+
+// deletes a theme saved in wave (the blocks and connections using it get the default theme)
+func Delete(ctx context.Context, name string) error {
+	cacheLock.Lock()
+	theme := cache[name]
+	if theme == nil {
+		cacheLock.Unlock()
+		return fmt.Errorf("theme %q is not saved in wave (a theme of termthemes.json is removed by editing it)", name)
+	}
+	err := wstore.DBDelete(ctx, waveobj.OType_Theme, theme.OID)
+	if err == nil {
+		delete(cache, name)
+	}
+	cacheLock.Unlock()
+	if err != nil {
+		return err
+	}
+	reloadConfig()
+	return nil
+}
+
+// sets (or removes, with an empty theme) term:theme for a block or a connection
+func Assign(ctx context.Context, data wshrpc.CommandThemeAssignData) error {
+	if (data.BlockId == "") == (data.Connection == "") {
+		return fmt.Errorf("set either a block or a connection")
+	}
+	var themeVal any
+	if data.Theme != "" {
+		if _, err := Get(data.Theme); err != nil {
+			return err
+		}
+		themeVal = data.Theme
+	}
+	meta := waveobj.MetaMapType{waveobj.MetaKey_TermTheme: themeVal}
+	if data.BlockId != "" {
+		return wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Block, data.BlockId), meta, false)
+	}
+	return wconfig.SetConnectionsConfigValue(wconn.ResolveConnName(ctx, data.Connection), meta)
+}